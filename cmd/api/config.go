@@ -0,0 +1,31 @@
+package main
+
+import (
+	"log"
+
+	"github.com/Tomlord1122/todo-backend/internal/config"
+	"github.com/Tomlord1122/todo-backend/internal/database"
+)
+
+// loadConfig loads the settings from CONFIG_FILE and the environment, and
+// exits listing every problem when they're invalid.
+func loadConfig() config.Config {
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal(err)
+	}
+	return cfg
+}
+
+// openDatabase connects to the database cfg describes, and exits when its
+// settings are missing or it can't be reached.
+func openDatabase(cfg config.Config) database.Service {
+	if err := cfg.RequireDatabase(); err != nil {
+		log.Fatal(err)
+	}
+	db, err := database.New(cfg.DatabaseConfig())
+	if err != nil {
+		log.Fatalf("Failed to set up the database: %v", err)
+	}
+	return db
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/Tomlord1122/todo-backend/internal/listener"
+)
+
+// healthcheck asks a running server whether it's ready, or with -live only
+// whether it's running, and exits with 0 when it answers 200, 1 otherwise.
+// Images without curl or wget use it as their container health check. By
+// default it asks the server this environment configures, over TCP or else
+// its Unix socket.
+func healthcheck(args []string) {
+	fs := flag.NewFlagSet("healthcheck", flag.ExitOnError)
+	url := fs.String("url", "", "health endpoint to check (default /readyz of the configured listener)")
+	live := fs.Bool("live", false, "check /healthz, whether the process is running, instead of /readyz")
+	timeout := fs.Duration("timeout", 5*time.Second, "how long to wait for the answer")
+	fs.Parse(args)
+
+	client := &http.Client{Timeout: *timeout}
+	if *url == "" {
+		path := "/readyz"
+		if *live {
+			path = "/healthz"
+		}
+		cfg, err := listener.ConfigFromEnv(loadConfig().Port)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid listener configuration: %v\n", err)
+			os.Exit(1)
+		}
+		var socket string
+		if *url, socket, err = healthcheckTarget(cfg, path); err != nil {
+			fmt.Fprintf(os.Stderr, "%v, pass -url\n", err)
+			os.Exit(1)
+		}
+		if socket != "" {
+			client.Transport = &http.Transport{DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", socket)
+			}}
+		}
+	}
+
+	resp, err := client.Get(*url)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Health check failed: %v\n", err)
+		os.Exit(1)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		fmt.Fprintf(os.Stderr, "Health check failed: %s answered %s\n", *url, resp.Status)
+		os.Exit(1)
+	}
+}
+
+// healthcheckTarget returns the URL of path on the server cfg configures,
+// and the Unix socket to reach it through when it doesn't listen on TCP.
+func healthcheckTarget(cfg listener.Config, path string) (url, socket string, err error) {
+	switch {
+	case cfg.TCPAddr != "":
+		_, port, err := net.SplitHostPort(cfg.TCPAddr)
+		if err != nil || port == "" {
+			return "", "", fmt.Errorf("no port in the TCP address %q", cfg.TCPAddr)
+		}
+		return "http://" + net.JoinHostPort("127.0.0.1", port) + path, "", nil
+	case cfg.UnixSocket != "":
+		return "http://localhost" + path, cfg.UnixSocket, nil
+	default:
+		return "", "", errors.New("no TCP port or Unix socket configured")
+	}
+}
@@ -1,98 +1,75 @@
+// Command api runs the todo backend. Besides serving, it carries the
+// operational tasks deployments need, so they run from the same image:
+//
+//	api [serve] [--demo]                             serve the API, the default
+//	api migrate up | down [N] | force VERSION | version  manage the schema
+//	api seed [-count N] [-users N] [-truncate] [-set NAME]  generate demo data, or load a fixture set
+//	api healthcheck [-live] [-url URL]               check a running server, for container health checks
 package main
 
 import (
-	"context"
-	"errors"
+	"fmt"
+	"io"
 	"log"
-	"net/http"
-	"os/signal"
-	"syscall"
-	"time"
+	"log/slog"
+	"os"
+	"strings"
 
-	"github.com/Tomlord1122/todo-backend/internal/database"
-	"github.com/Tomlord1122/todo-backend/internal/domain" // Import domain for potential AutoMigrate
-	"github.com/Tomlord1122/todo-backend/internal/repository"
-	"github.com/Tomlord1122/todo-backend/internal/server"
-	"github.com/Tomlord1122/todo-backend/internal/service"
+	"github.com/Tomlord1122/todo-backend/internal/logging"
+	"github.com/Tomlord1122/todo-backend/internal/redact"
 
 	_ "github.com/joho/godotenv/autoload" // Keep if loading .env for PORT or DB
 )
 
-func gracefulShutdown(apiServer *http.Server, dbService database.Service, done chan bool) {
-	// Create context that listens for the interrupt signal from the OS.
-	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
-	defer stop()
-
-	// Listen for the interrupt signal.
-	<-ctx.Done()
-
-	log.Println("Shutting down gracefully, press Ctrl+C again to force")
-	stop() // Allow Ctrl+C to force shutdown
-
-	// The context is used to inform the server it has 5 seconds to finish
-	// the request it is currently handling
-	ctxTimeout, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-	if err := apiServer.Shutdown(ctxTimeout); err != nil {
-		log.Printf("Server forced to shutdown with error: %v", err)
-	}
-
-	// Attempt to close the database connection pool gracefully
-	if dbService != nil {
-		log.Println("Closing database connection pool...")
-		if err := dbService.Close(); err != nil {
-			log.Printf("Error closing database connection pool: %v", err)
-		} else {
-			log.Println("Database connection pool closed.")
-		}
-	}
-
-	log.Println("Server exiting")
-
-	// Notify the main goroutine that the shutdown is complete
-	done <- true
+func usage() {
+	fmt.Fprintln(os.Stderr, `usage: api [serve] [--demo]
+       api migrate up | down [N] | force VERSION | version
+       api seed [-count N] [-users N] [-truncate] [-seed N] [-set NAME]
+       api healthcheck [-live] [-url URL] [-timeout DURATION]`)
+	os.Exit(2)
 }
 
 func main() {
-	// 1. Initialize Database (using the GORM version)
-	dbService := database.New()
-
-	gormDB := dbService.GetDB() // Get the *gorm.DB instance
-
-	// Optional: Auto-migrate schema (use cautiously in production)
-	// Run this only during development or via a separate migration command
-	log.Println("Running database auto-migration (dev only!)...")
-	err := gormDB.AutoMigrate(&domain.Todo{}) // Add other models here
+	// Log structured lines, as text or JSON, and keep personal data and
+	// secrets out of them. The log package logs through the same logger.
+	logCfg, err := logging.ConfigFromEnv()
 	if err != nil {
-		log.Fatalf("Failed to auto-migrate database: %v", err)
+		log.Fatalf("Invalid logging configuration: %v", err)
+	}
+	var logOutput io.Writer = os.Stdout
+	if redact.Enabled() {
+		logOutput = redact.NewWriter(os.Stdout)
+	}
+	slog.SetDefault(logging.New(logOutput, logCfg))
+	if !redact.Enabled() {
+		slog.Warn("LOG_REDACT=false: logs may contain personal data, use for local debugging only")
 	}
-	log.Println("Database auto-migration complete.")
-
-	// 2. Initialize Repositories
-	todoRepo := repository.NewGormTodoRepository(gormDB)
-
-	// 3. Initialize Services
-	todoService := service.NewTodoService(todoRepo)
-
-	// 4. Initialize Server/Router, passing dependencies
-	// NewServer now expects both todoService and dbService
-	chiServer := server.NewServer(todoService, dbService)
 
-	// Create a done channel to signal when the shutdown is complete
-	done := make(chan bool, 1)
+	command, args, err := parseCommand(os.Args[1:])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		usage()
+	}
+	commands[command](args)
+}
 
-	// Run graceful shutdown in a separate goroutine
-	// Pass the *http.Server instance directly and the dbService for closing
-	go gracefulShutdown(chiServer, dbService, done)
+// commands are the commands of the binary, by name.
+var commands = map[string]func(args []string){
+	"serve":       serve,
+	"migrate":     migrate,
+	"seed":        seedData,
+	"healthcheck": healthcheck,
+}
 
-	// Log the actual address the server is listening on
-	log.Printf("Starting server on %s", chiServer.Addr)
-	err = chiServer.ListenAndServe()
-	if err != nil && !errors.Is(err, http.ErrServerClosed) { // Use errors.Is for checking
-		log.Fatalf("HTTP server ListenAndServe error: %v", err) // Use log.Fatalf
+// parseCommand splits the command line into the command and its arguments.
+// Without a command, or with only flags, it is serve, as before there were
+// commands.
+func parseCommand(args []string) (string, []string, error) {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return "serve", args, nil
 	}
-
-	// Wait for the graceful shutdown to complete
-	<-done
-	log.Println("Graceful shutdown complete.")
+	if _, ok := commands[args[0]]; !ok {
+		return "", nil, fmt.Errorf("unknown command %q", args[0])
+	}
+	return args[0], args[1:], nil
 }
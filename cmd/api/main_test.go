@@ -0,0 +1,57 @@
+package main
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/Tomlord1122/todo-backend/internal/listener"
+)
+
+func TestParseCommand(t *testing.T) {
+	tests := []struct {
+		args        []string
+		wantCommand string
+		wantArgs    []string
+	}{
+		{nil, "serve", nil},
+		// Flags alone still serve, as before there were commands
+		{[]string{"--demo"}, "serve", []string{"--demo"}},
+		{[]string{"serve", "--demo"}, "serve", []string{"--demo"}},
+		{[]string{"migrate", "down", "1"}, "migrate", []string{"down", "1"}},
+		{[]string{"healthcheck", "-live"}, "healthcheck", []string{"-live"}},
+	}
+	for _, tt := range tests {
+		command, args, err := parseCommand(tt.args)
+		if err != nil || command != tt.wantCommand || !slices.Equal(args, tt.wantArgs) {
+			t.Errorf("parseCommand(%q) = %q, %q, %v; want %q, %q", tt.args, command, args, err, tt.wantCommand, tt.wantArgs)
+		}
+	}
+
+	// main prints the usage for unknown commands
+	if _, _, err := parseCommand([]string{"frobnicate", "--demo"}); err == nil {
+		t.Error("parseCommand(frobnicate) succeeded, want an unknown command error")
+	}
+}
+
+func TestHealthcheckTarget(t *testing.T) {
+	tests := []struct {
+		cfg        listener.Config
+		wantURL    string
+		wantSocket string
+		wantErr    bool
+	}{
+		{cfg: listener.Config{TCPAddr: ":8080"}, wantURL: "http://127.0.0.1:8080/readyz"},
+		// TCP wins over the socket when both are configured
+		{cfg: listener.Config{TCPAddr: ":8080", UnixSocket: "/run/api.sock"}, wantURL: "http://127.0.0.1:8080/readyz"},
+		{cfg: listener.Config{UnixSocket: "/run/api.sock"}, wantURL: "http://localhost/readyz", wantSocket: "/run/api.sock"},
+		{cfg: listener.Config{TCPAddr: "127.0.0.1"}, wantErr: true},
+		{cfg: listener.Config{TCPAddr: "127.0.0.1:"}, wantErr: true},
+		{cfg: listener.Config{}, wantErr: true},
+	}
+	for _, tt := range tests {
+		url, socket, err := healthcheckTarget(tt.cfg, "/readyz")
+		if (err != nil) != tt.wantErr || url != tt.wantURL || socket != tt.wantSocket {
+			t.Errorf("healthcheckTarget(%+v) = %q, %q, %v; want %q, %q, error %t", tt.cfg, url, socket, err, tt.wantURL, tt.wantSocket, tt.wantErr)
+		}
+	}
+}
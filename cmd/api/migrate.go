@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+
+	"github.com/Tomlord1122/todo-backend/internal/database"
+)
+
+func migrateUsage() {
+	fmt.Fprintln(os.Stderr, "usage: api migrate up | down [N] | force VERSION | version")
+	os.Exit(2)
+}
+
+// migrate applies or reverts the schema migrations in migrations/, or
+// shows the version the database is at.
+func migrate(args []string) {
+	if len(args) < 1 {
+		migrateUsage()
+	}
+	command, args := args[0], args[1:]
+
+	// Check the arguments before connecting
+	var run func(m *database.Migrator) error
+	switch command {
+	case "up":
+		if len(args) != 0 {
+			migrateUsage()
+		}
+		run = (*database.Migrator).Up
+	case "down":
+		steps := 1
+		switch len(args) {
+		case 0:
+		case 1:
+			var err error
+			if steps, err = strconv.Atoi(args[0]); err != nil || steps < 1 {
+				log.Fatalf("Invalid number of migrations %q, expected a positive number", args[0])
+			}
+		default:
+			migrateUsage()
+		}
+		run = func(m *database.Migrator) error { return m.Down(steps) }
+	case "force":
+		if len(args) != 1 {
+			migrateUsage()
+		}
+		version, err := strconv.Atoi(args[0])
+		if err != nil || version < -1 {
+			log.Fatalf("Invalid version %q, expected a version number or -1", args[0])
+		}
+		run = func(m *database.Migrator) error { return m.Force(version) }
+	case "version":
+		if len(args) != 0 {
+			migrateUsage()
+		}
+		run = func(*database.Migrator) error { return nil }
+	default:
+		migrateUsage()
+	}
+
+	cfg := loadConfig()
+	db := openDatabase(cfg)
+	defer db.Close()
+	// Wait this long for instances migrating as they start
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Database.MigrationLockTimeout)
+	defer cancel()
+	m, err := database.NewMigrator(ctx, db.GetDB())
+	if err != nil {
+		log.Fatalf("Failed to prepare migrations: %v", err)
+	}
+	defer m.Close()
+
+	if err := run(m); err != nil {
+		log.Fatalf("Failed to migrate %s: %v", command, err)
+	}
+
+	version, dirty, err := m.Version()
+	if err != nil {
+		log.Fatalf("Failed to read the schema version: %v", err)
+	}
+	if dirty {
+		fmt.Printf("version %d (dirty: the last migration failed, fix it and run force)\n", version)
+		return
+	}
+	fmt.Printf("version %d\n", version)
+}
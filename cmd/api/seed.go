@@ -0,0 +1,46 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/Tomlord1122/todo-backend/internal/fixtures"
+	"github.com/Tomlord1122/todo-backend/internal/repository"
+	"github.com/Tomlord1122/todo-backend/internal/seed"
+)
+
+// seedData adds generated users, todos, tags and subtasks to the database,
+// or replaces its data with a fixture set, for load tests and demo
+// deployments. The schema must be migrated already.
+func seedData(args []string) {
+	fs := flag.NewFlagSet("seed", flag.ExitOnError)
+	count := fs.Int("count", 100, "number of todos to generate")
+	users := fs.Int("users", 5, "number of users to spread the todos over")
+	truncate := fs.Bool("truncate", false, "delete all existing data first")
+	randSeed := fs.Int64("seed", 0, "random seed, to generate the same data again (default random)")
+	set := fs.String("set", "", "load this fixture set instead, replacing all data: "+strings.Join(fixtures.Sets, ", "))
+	fs.Parse(args)
+
+	db := openDatabase(loadConfig())
+	defer db.Close()
+	repos := repository.NewGormRepositories(db.GetDB())
+
+	if *set != "" {
+		counts, err := fixtures.Load(repos, *set, time.Now())
+		if err != nil {
+			log.Fatalf("Failed to seed the database: %v", err)
+		}
+		fmt.Printf("loaded %s: %d users, %d lists, %d todos\n", *set, counts.Users, counts.Lists, counts.Todos)
+		return
+	}
+
+	counts, err := seed.Run(repos, seed.Config{Users: *users, Todos: *count, Truncate: *truncate, Seed: *randSeed}, time.Now())
+	if err != nil {
+		log.Fatalf("Failed to seed the database: %v", err)
+	}
+	fmt.Printf("generated %d users, %d lists, %d tags, %d todos and %d subtasks; users sign in with password %q\n",
+		counts.Users, counts.Lists, counts.Tags, counts.Todos, counts.Subtasks, seed.DefaultPassword)
+}
@@ -0,0 +1,663 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/Tomlord1122/todo-backend/internal/cache"
+	"github.com/Tomlord1122/todo-backend/internal/database"
+	"github.com/Tomlord1122/todo-backend/internal/events"
+	"github.com/Tomlord1122/todo-backend/internal/fixtures"
+	"github.com/Tomlord1122/todo-backend/internal/gcal"
+	"github.com/Tomlord1122/todo-backend/internal/github"
+	"github.com/Tomlord1122/todo-backend/internal/grpcserver"
+	"github.com/Tomlord1122/todo-backend/internal/health"
+	"github.com/Tomlord1122/todo-backend/internal/jobs"
+	"github.com/Tomlord1122/todo-backend/internal/jwt"
+	"github.com/Tomlord1122/todo-backend/internal/lifecycle"
+	"github.com/Tomlord1122/todo-backend/internal/listener"
+	"github.com/Tomlord1122/todo-backend/internal/metrics"
+	"github.com/Tomlord1122/todo-backend/internal/notify"
+	"github.com/Tomlord1122/todo-backend/internal/notion"
+	"github.com/Tomlord1122/todo-backend/internal/oidc"
+	"github.com/Tomlord1122/todo-backend/internal/ratelimit"
+	"github.com/Tomlord1122/todo-backend/internal/readonly"
+	"github.com/Tomlord1122/todo-backend/internal/realtime"
+	"github.com/Tomlord1122/todo-backend/internal/redis"
+	"github.com/Tomlord1122/todo-backend/internal/repository"
+	"github.com/Tomlord1122/todo-backend/internal/scan"
+	"github.com/Tomlord1122/todo-backend/internal/server"
+	"github.com/Tomlord1122/todo-backend/internal/service"
+	"github.com/Tomlord1122/todo-backend/internal/storage"
+	"github.com/Tomlord1122/todo-backend/internal/suggest"
+	"github.com/Tomlord1122/todo-backend/internal/thumbnail"
+	"github.com/Tomlord1122/todo-backend/internal/tracing"
+	"github.com/Tomlord1122/todo-backend/internal/webauthn"
+	"github.com/Tomlord1122/todo-backend/internal/webhook"
+
+	"github.com/quic-go/quic-go/http3"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"gorm.io/gorm"
+)
+
+// serve runs the API servers and background jobs until the process is
+// told to stop.
+func serve(args []string) {
+	// Core settings come from CONFIG_FILE and the environment; stop here
+	// when they're invalid rather than failing on first use
+	cfg := loadConfig()
+
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	// DEMO_MODE=true works like --demo, for platforms where the
+	// environment is easier to set than the command
+	demoMode := fs.Bool("demo", cfg.Dev.DemoMode, "run on seeded in-memory data without a database or other external services (env DEMO_MODE)")
+	fs.Parse(args)
+
+	// Export traces of requests, through the todo service down to its
+	// queries, when an OTLP endpoint is configured
+	var traceShutdown func(context.Context) error
+	if tracing.Enabled() {
+		provider, err := tracing.Setup(context.Background())
+		if err != nil {
+			log.Fatalf("Failed to set up tracing: %v", err)
+		}
+		traceShutdown = provider.Shutdown
+		log.Println("Exporting traces over OTLP")
+	}
+
+	// 1. Initialize storage: Postgres or MySQL, or seeded in-memory repositories in demo mode
+	var dbService database.Service
+	var repos *repository.Repositories
+	if *demoMode {
+		log.Println("Demo mode: using seeded in-memory data, nothing is persisted")
+		repos = repository.NewMemoryRepositories()
+		if _, err := fixtures.Load(repos, "small", time.Now()); err != nil {
+			log.Fatalf("Failed to seed demo data: %v", err)
+		}
+	} else {
+		dbService = openDatabase(cfg)
+
+		gormDB := dbService.GetDB() // Get the *gorm.DB instance
+
+		// Apply pending schema migrations from migrations/. Replicas
+		// starting together take turns instead of racing on the DDL. Set
+		// MIGRATE_ON_START=false to leave migrating to the migrate command.
+		if cfg.Database.MigrateOnStart {
+			log.Println("Running database migrations...")
+			migrateCtx, cancelMigrate := context.WithTimeout(context.Background(), cfg.Database.MigrationLockTimeout)
+			err := database.WithMigrationLock(migrateCtx, gormDB, func(db *gorm.DB) error {
+				return database.Migrate(migrateCtx, db)
+			})
+			cancelMigrate()
+			if err != nil {
+				log.Fatalf("Failed to migrate database: %v", err)
+			}
+			log.Println("Database migrations complete.")
+		}
+
+		// 2. Initialize Repositories
+		repos = repository.NewGormRepositories(gormDB)
+	}
+
+	// Object storage for attachments; attachments are disabled without it
+	var objectStore storage.ObjectStore
+	s3Cfg, ok := storage.S3ConfigFromEnv()
+	switch {
+	case *demoMode:
+		log.Println("Attachments are disabled in demo mode")
+	case ok:
+		objectStore = storage.NewS3Store(s3Cfg, nil)
+	default:
+		log.Println("S3_BUCKET not set, attachments are disabled")
+	}
+
+	// Redis shares state like rate limits between replicas; without it
+	// each instance keeps its own
+	var redisClient *redis.Client
+	redisCfg, ok, err := redis.ConfigFromEnv()
+	switch {
+	case *demoMode:
+	case err != nil:
+		log.Fatalf("Invalid Redis configuration: %v", err)
+	case ok:
+		redisClient = redis.NewClient(redisCfg)
+	}
+
+	// Todo reads are cached in Redis; without it every read hits the database
+	cacheTTL, ok, err := cache.ConfigFromEnv()
+	if err != nil {
+		log.Fatalf("Invalid cache configuration: %v", err)
+	}
+	if ok && redisClient != nil {
+		repos.WithCache(cache.NewRedis(redisClient, "todo-backend:cache:"), cacheTTL)
+	}
+
+	todoRepo := repos.Todos
+	listRepo := repos.Lists
+	feedTokenRepo := repos.FeedTokens
+	reportScheduleRepo := repos.ReportSchedules
+	preferenceRepo := repos.Preferences
+	attachmentRepo := repos.Attachments
+
+	// Notification channels; email is only available when SMTP is configured
+	channels := []notify.Channel{notify.LogChannel{}, notify.NewWebhookChannel(nil)}
+	var emailChannel *notify.EmailChannel
+	if emailCfg, ok := notify.EmailConfigFromEnv(); ok && !*demoMode {
+		emailChannel = notify.NewEmailChannel(emailCfg)
+		channels = append(channels, emailChannel)
+	}
+	notifier := notify.NewRegistry(channels...)
+
+	// 3. Initialize Services
+	pageLimits := cfg.PaginationConfig()
+	suggester := suggest.NewKeywordSuggester(suggest.DefaultKeywordRules())
+	// Todo changes reach the subscribers on every replica through Redis
+	realtimeHub := realtime.NewHub()
+	var realtimeEvents realtime.Publisher = realtimeHub
+	var realtimeBridge *realtime.RedisBridge
+	if redisClient != nil {
+		realtimeBridge = realtime.NewRedisBridge(redisClient, "todo-backend:events", realtimeHub)
+		realtimeEvents = realtimeBridge
+	}
+	// Domain events let other systems follow todo changes through a broker.
+	// Changes add them to an outbox, which the outbox-relay job publishes.
+	eventsCfg, err := events.ConfigFromEnv()
+	if err != nil {
+		log.Fatalf("Invalid event bus configuration: %v", err)
+	}
+	if *demoMode {
+		eventsCfg.Kind = events.KindInProcess
+	}
+	eventBus, err := events.Open(eventsCfg)
+	if err != nil {
+		log.Fatalf("Failed to open the event bus: %v", err)
+	}
+	// Presence is shared through Redis so replicas see each other's users
+	var presenceStore realtime.PresenceStore = realtime.NewMemoryPresence()
+	if redisClient != nil {
+		presenceStore = realtime.NewRedisPresence(redisClient, "todo-backend:presence:")
+	}
+	followService := service.NewFollowService(repos.Watchers, todoRepo, preferenceRepo, repos.Users, notifier)
+	todoService := service.NewTodoService(todoRepo, repos.Tags, preferenceRepo, repos.Activities, suggester, realtimeEvents, followService, service.TodoConfig{Limits: pageLimits, FuzzyThreshold: cfg.Todos.FuzzySearchThreshold})
+	suggestionService := service.NewSuggestionService(suggester)
+	preferenceService := service.NewPreferenceService(preferenceRepo, notifier)
+	listService := service.NewListService(listRepo)
+	feedService := service.NewFeedService(feedTokenRepo, todoRepo)
+	reportService := service.NewReportService(todoRepo, listRepo, pageLimits)
+	reportScheduleService := service.NewReportScheduleService(reportScheduleRepo, reportService, notifier)
+	thumbnails := &thumbnail.Generator{}
+	if renderer := thumbnail.NewPopplerRenderer(); renderer != nil {
+		thumbnails.PDF = renderer
+	} else {
+		log.Println("pdftoppm not found, PDF attachments won't get previews")
+	}
+	var scanner scan.Scanner
+	clamCfg, ok, err := scan.ClamAVConfigFromEnv()
+	switch {
+	case *demoMode:
+		// Attachments are disabled, there's nothing to scan
+	case err != nil:
+		log.Fatalf("Invalid malware scanner configuration: %v", err)
+	case ok:
+		scanner = scan.NewClamAVScanner(clamCfg)
+	default:
+		log.Println("CLAMAV_ADDR not set, attachments are served without a malware scan")
+	}
+	checklistService := service.NewChecklistService(repos.Checklists, todoRepo)
+	reactionService := service.NewReactionService(repos.Reactions, todoRepo)
+	activityService := service.NewActivityService(repos.Activities, todoRepo)
+	escalationCfg, err := service.EscalationConfigFromEnv()
+	if err != nil {
+		log.Fatalf("Invalid escalation configuration: %v", err)
+	}
+	escalationService := service.NewEscalationService(todoRepo, preferenceRepo, repos.Activities, notifier, escalationCfg)
+	overdueService := service.NewOverdueService(todoRepo, preferenceRepo, notifier)
+	recurrenceService := service.NewRecurrenceService(todoRepo, repos.Activities, realtimeEvents)
+	focusService := service.NewFocusService(repos.FocusSessions, todoRepo)
+	statsService := service.NewStatsService(repos.FocusSessions, todoRepo, preferenceRepo)
+	burndownService := service.NewBurndownService(listRepo, repos.Activities, preferenceRepo)
+	timelineService := service.NewTimelineService(listRepo, todoRepo)
+	inboundHookService := service.NewInboundHookService(repos.InboundHooks, todoService)
+	var gitHubClient *github.Client
+	if gitHubCfg, ok := cfg.GitHubConfig(); ok && !*demoMode {
+		gitHubClient = github.NewClient(gitHubCfg, nil)
+	}
+	gitHubService := service.NewGitHubService(repos.GitHub, listRepo, todoRepo, todoService, gitHubClient)
+	var calendarClient *gcal.Client
+	if calendarCfg, ok := cfg.GoogleConfig(); ok && !*demoMode {
+		calendarClient = gcal.NewClient(calendarCfg, nil)
+	}
+	calendarService := service.NewCalendarService(repos.Calendars, todoRepo, todoService, calendarClient)
+	var notionClient *notion.Client
+	if notionCfg, ok := notion.ConfigFromEnv(); ok && !*demoMode {
+		notionClient = notion.NewClient(notionCfg, nil)
+	}
+	notionExportService := service.NewNotionExportService(repos.NotionExports, todoRepo, listRepo, repos.Jobs, notionClient, pageLimits)
+	importService := service.NewImportService(repos.Imports, listRepo)
+	// Passkey login needs to know the site passkeys are bound to
+	var relyingParty *webauthn.RelyingParty
+	if webAuthnCfg, ok := cfg.WebAuthnConfig(); ok {
+		relyingParty = webauthn.NewRelyingParty(webAuthnCfg)
+	} else {
+		log.Println("WEBAUTHN_RP_ID not set, passkey login is disabled")
+	}
+	sessionCfg := service.SessionConfig{TTL: cfg.Auth.SessionTTL}
+	passkeyService := service.NewPasskeyService(repos.Passkeys, repos.Sessions, relyingParty, sessionCfg)
+	// Single sign-on works with any OpenID Connect provider
+	ssoConfigs := cfg.OIDCConfigs()
+	ssoProviders := make([]*oidc.Provider, 0, len(ssoConfigs))
+	for _, ssoCfg := range ssoConfigs {
+		ssoProviders = append(ssoProviders, oidc.NewProvider(ssoCfg, nil))
+	}
+	ssoService := service.NewSSOService(repos.Identities, repos.Sessions, ssoProviders, sessionCfg)
+	// Password logins issue JWTs signed with JWT_SECRET
+	authCfg := service.AuthConfig{Secret: []byte(cfg.Auth.JWTSecret), TTL: cfg.Auth.JWTTTL}
+	if cfg.Auth.JWTSecret == "" {
+		log.Println("JWT_SECRET not set, using a random secret: access tokens won't survive a restart or work across instances")
+		authCfg.Secret = make([]byte, jwt.MinKeyLength)
+		if _, err := rand.Read(authCfg.Secret); err != nil {
+			log.Fatalf("Failed to generate a JWT secret: %v", err)
+		}
+	}
+	authService, err := service.NewAuthService(repos.Users, authCfg)
+	if err != nil {
+		log.Fatalf("Failed to set up authentication: %v", err)
+	}
+	idempotencyService := service.NewIdempotencyService(repos.IdempotencyKeys, service.IdempotencyConfig{TTL: cfg.Todos.IdempotencyKeyTTL})
+	attachmentService := service.NewAttachmentService(attachmentRepo, todoRepo, objectStore, thumbnails, scanner, notifier, service.AttachmentConfigFromEnv())
+
+	// POST /dev/fixtures wipes all data, so it's opt-in outside demo mode
+	var fixtureService service.FixtureService
+	if cfg.Dev.Fixtures || *demoMode {
+		log.Println("Fixtures endpoint enabled: POST /dev/fixtures resets all data")
+		fixtureService = service.NewFixtureService(repos)
+	}
+
+	// Readiness checks every configured dependency; only the database and
+	// its schema are required, the others disable features when they're
+	// down. Liveness checks none, so outages don't get the process restarted.
+	healthTimeout, err := health.TimeoutFromEnv()
+	if err != nil {
+		log.Fatalf("Invalid health check configuration: %v", err)
+	}
+	healthChecker := health.NewChecker(healthTimeout)
+	if dbService != nil {
+		healthChecker.Add(dbService.Driver(), true, dbService.Ping)
+		healthChecker.Add("migrations", true, func(ctx context.Context) error {
+			return database.CheckMigrations(ctx, dbService.GetDB())
+		})
+		for addr, check := range dbService.ReplicaChecks() {
+			healthChecker.Add("replica "+addr, false, check)
+		}
+	}
+	if redisClient != nil {
+		healthChecker.Add("redis", false, redisClient.Ping)
+	}
+	if s3Store, ok := objectStore.(*storage.S3Store); ok {
+		healthChecker.Add("s3", false, s3Store.Ping)
+	}
+	if emailChannel != nil {
+		healthChecker.Add("smtp", false, emailChannel.Ping)
+	}
+	if clamAV, ok := scanner.(*scan.ClamAVScanner); ok {
+		healthChecker.Add("clamav", false, clamAV.Ping)
+	}
+
+	// Prometheus metrics
+	metricsRegistry := metrics.NewRegistry()
+	poolMetrics := metrics.NewDBPool(metricsRegistry)
+	if dbService != nil {
+		metrics.NewSlowQueryCounter(metricsRegistry, dbService.SlowQueries().Total)
+	}
+
+	// Read-only mode rejects API writes and pauses the jobs below
+	readOnly := readonly.New()
+
+	// Rate limiting per client IP for anonymous requests and per user for
+	// signed-in ones, shared between replicas through Redis
+	var rateLimiter, userRateLimiter ratelimit.Limiter
+	rateLimits, err := ratelimit.ConfigFromEnv()
+	if err != nil {
+		log.Fatalf("Invalid rate limit configuration: %v", err)
+	}
+	newLimiter := func(limit ratelimit.Limit, prefix string) ratelimit.Limiter {
+		if redisClient != nil {
+			return ratelimit.NewRedis(redisClient, limit, prefix)
+		}
+		return ratelimit.NewMemory(limit)
+	}
+	if rateLimits.Anonymous.Enabled() {
+		rateLimiter = newLimiter(rateLimits.Anonymous, "ratelimit:")
+	}
+	if rateLimits.Authenticated.Enabled() {
+		userRateLimiter = newLimiter(rateLimits.Authenticated, "ratelimit:user:")
+	}
+	if (rateLimiter != nil || userRateLimiter != nil) && redisClient == nil {
+		log.Println("REDIS_URL not set, rate limits are enforced per instance")
+	}
+
+	// Background jobs. Jobs with side effects run on one instance at a
+	// time; imports claim their work and may run everywhere.
+	var locker jobs.Locker = jobs.NewLocalLocker()
+	if dbService != nil {
+		sqlDB, err := dbService.GetDB().DB()
+		if err != nil {
+			log.Fatalf("Failed to get database handle for job locks: %v", err)
+		}
+		locker = jobs.NewPostgresLocker(sqlDB)
+		if dbService.Driver() == database.DriverMySQL {
+			locker = jobs.NewMySQLLocker(sqlDB)
+		}
+	}
+	// Cron-style jobs run on the elected leader only; the lease fails over
+	// to another instance within its TTL when the leader dies
+	elector := jobs.NewElector(repos.Leases, "scheduler", 30*time.Second)
+	if err := elector.Renew(context.Background()); err != nil {
+		log.Printf("Leader election failed, retrying in the background: %v", err)
+	}
+	scheduler := jobs.NewScheduler()
+	scheduler.Every("leader-election", 10*time.Second, elector.Renew)
+	scheduler.EveryExclusive("report-schedules", time.Minute, locker, elector.Guard(readOnly.Guard(func(ctx context.Context) error {
+		return reportScheduleService.RunDue(ctx, time.Now())
+	})))
+	scheduler.EveryExclusive("overdue-escalation", 5*time.Minute, locker, elector.Guard(readOnly.Guard(func(ctx context.Context) error {
+		return escalationService.RunDue(ctx, time.Now())
+	})))
+	scheduler.EveryExclusive("overdue-reminders", time.Minute, locker, elector.Guard(readOnly.Guard(func(ctx context.Context) error {
+		return overdueService.NotifyDue(ctx, time.Now())
+	})))
+	reminderService := service.NewReminderService(repos.Reminders, todoRepo, preferenceRepo, repos.Jobs, notifier)
+	scheduler.EveryExclusive("todo-reminders", time.Minute, locker, elector.Guard(readOnly.Guard(func(ctx context.Context) error {
+		return reminderService.EnqueueDue(ctx, time.Now())
+	})))
+	// Events leave the outbox for the event bus and for users' webhooks
+	webhookService := service.NewWebhookService(repos.Webhooks, repos.Jobs, webhook.NewSender(nil))
+	outbox := service.NewOutboxService(repos.Outbox)
+	outboxRelay := events.NewRelay(outbox, events.Multi{eventBus, webhookService})
+	scheduler.EveryExclusive("outbox-relay", 5*time.Second, locker, readOnly.Guard(func(ctx context.Context) error {
+		return outboxRelay.RunDue(ctx, time.Now())
+	}))
+	scheduler.EveryExclusive("outbox-cleanup", time.Hour, locker, elector.Guard(readOnly.Guard(outbox.DeletePublished)))
+	scheduler.EveryExclusive("recurring-todos", time.Minute, locker, elector.Guard(readOnly.Guard(recurrenceService.RunPending)))
+	scheduler.EveryExclusive("github-sync", 2*time.Minute, locker, elector.Guard(readOnly.Guard(func(ctx context.Context) error {
+		return gitHubService.SyncAll(ctx, time.Now())
+	})))
+	scheduler.EveryExclusive("calendar-sync", 2*time.Minute, locker, elector.Guard(readOnly.Guard(func(ctx context.Context) error {
+		return calendarService.SyncAll(ctx, time.Now())
+	})))
+	scheduler.Every("imports", 5*time.Second, readOnly.Guard(func(ctx context.Context) error {
+		return importService.RunPending(ctx, time.Now())
+	}))
+	scheduler.EveryExclusive("attachment-cleanup", time.Hour, locker, elector.Guard(readOnly.Guard(func(ctx context.Context) error {
+		return attachmentService.CleanupPending(ctx, time.Now())
+	})))
+	scheduler.EveryExclusive("idempotency-key-cleanup", time.Hour, locker, elector.Guard(readOnly.Guard(idempotencyService.DeleteExpired)))
+	scheduler.EveryExclusive("attachment-scans", 15*time.Second, locker, readOnly.Guard(attachmentService.ScanPending))
+	scheduler.EveryExclusive("attachment-thumbnails", 15*time.Second, locker, readOnly.Guard(attachmentService.GenerateThumbnails))
+	if dbService != nil {
+		scheduler.Every("db-pool-metrics", metrics.ScrapeIntervalFromEnv(), func(ctx context.Context) error {
+			stats, err := dbService.PoolStats()
+			if err != nil {
+				return err
+			}
+			poolMetrics.Update(stats)
+			return nil
+		})
+	}
+	// Queued jobs (reminders, webhook deliveries, Notion exports) run on a
+	// worker pool on every instance
+	jobService := service.NewJobService(repos.Jobs)
+	scheduler.EveryExclusive("job-cleanup", time.Hour, locker, elector.Guard(readOnly.Guard(jobService.DeleteFinished)))
+	scheduler.Start(context.Background())
+	workers, err := jobs.WorkersFromEnv()
+	if err != nil {
+		log.Fatalf("Invalid job worker configuration: %v", err)
+	}
+	workerPool := jobs.NewPool(jobService, workers)
+	service.RegisterJobs(workerPool, reminderService, webhookService, notionExportService)
+	workerPool.PauseWhen(readOnly.Enabled)
+	workerPool.Start(context.Background())
+	if realtimeBridge != nil {
+		realtimeBridge.Start(context.Background())
+	}
+
+	sessionService := service.NewSessionService(repos.Sessions)
+	apiKeyService := service.NewAPIKeyService(repos.APIKeys)
+	userService := service.NewUserService(repos.Users)
+
+	// The gRPC API serves the todo service on a port of its own
+	grpcCfg, err := grpcserver.ConfigFromEnv()
+	if err != nil {
+		log.Fatalf("Invalid gRPC configuration: %v", err)
+	}
+	var grpcServer *grpc.Server
+	var grpcListener net.Listener
+	var gatewayConn *grpc.ClientConn
+	var gateway http.Handler
+	if grpcCfg.Port != 0 {
+		grpcListener, err = net.Listen("tcp", grpcCfg.Addr())
+		if err != nil {
+			log.Fatalf("Failed to open gRPC listener: %v", err)
+		}
+		grpcServer = grpcserver.New(grpcserver.Services{
+			Todo:     todoService,
+			Auth:     authService,
+			Session:  sessionService,
+			APIKeys:  apiKeyService,
+			Users:    userService,
+			ReadOnly: readOnly,
+		})
+		// The HTTP server also serves it as JSON under /rpc, through a
+		// gateway calling the gRPC server over loopback
+		gatewayConn, err = grpc.NewClient(fmt.Sprintf("localhost:%d", grpcListener.Addr().(*net.TCPAddr).Port),
+			grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			log.Fatalf("Failed to connect the gRPC gateway: %v", err)
+		}
+		gateway, err = grpcserver.NewGateway(context.Background(), gatewayConn)
+		if err != nil {
+			log.Fatalf("Failed to register the gRPC gateway: %v", err)
+		}
+	}
+
+	// 4. Initialize Server/Router, passing dependencies
+	drain := &lifecycle.Drain{}
+	httpPort, _ := strconv.Atoi(cfg.Port)
+	chiServer := server.NewServer(server.Config{
+		Port:           httpPort,
+		ReadTimeout:    cfg.HTTP.ReadTimeout,
+		WriteTimeout:   cfg.HTTP.WriteTimeout,
+		IdleTimeout:    cfg.HTTP.IdleTimeout,
+		AllowedOrigins: cfg.CORS.AllowedOrigins,
+		AdminToken:     cfg.Auth.AdminToken,
+		ClientIP:       cfg.ClientIPResolver(),
+		Pages:          pageLimits,
+		Envelope:       cfg.API.Envelope,
+		NoLegacyRoutes: !cfg.API.LegacyRoutes,
+	}, server.Services{
+		Todo:            todoService,
+		Feed:            feedService,
+		List:            listService,
+		Report:          reportService,
+		ReportSchedule:  reportScheduleService,
+		Suggestion:      suggestionService,
+		Preference:      preferenceService,
+		Attachment:      attachmentService,
+		Checklist:       checklistService,
+		Reaction:        reactionService,
+		Activity:        activityService,
+		Overdue:         overdueService,
+		Focus:           focusService,
+		Stats:           statsService,
+		Burndown:        burndownService,
+		Timeline:        timelineService,
+		InboundHook:     inboundHookService,
+		GitHub:          gitHubService,
+		Calendar:        calendarService,
+		NotionExport:    notionExportService,
+		Import:          importService,
+		Passkey:         passkeyService,
+		Session:         sessionService,
+		Auth:            authService,
+		APIKeys:         apiKeyService,
+		Webhooks:        webhookService,
+		Tags:            service.NewTagService(repos.Tags),
+		Subtasks:        service.NewSubtaskService(repos.Subtasks, todoRepo),
+		Reminders:       reminderService,
+		Users:           userService,
+		SSO:             ssoService,
+		Follow:          followService,
+		Presence:        service.NewPresenceService(presenceStore, todoRepo, listRepo, realtimeEvents, service.PresenceConfig{TTL: cfg.Todos.PresenceTTL}),
+		Fixtures:        fixtureService,
+		Idempotency:     idempotencyService,
+		ReadOnly:        readOnly,
+		Drain:           drain,
+		Health:          healthChecker,
+		RateLimiter:     rateLimiter,
+		UserRateLimiter: userRateLimiter,
+		Metrics:         metricsRegistry,
+		Events:          realtimeHub,
+		Gateway:         gateway,
+	}, dbService)
+
+	listenCfg, err := listener.ConfigFromEnv(cfg.Port)
+	if err != nil {
+		log.Fatalf("Invalid listener configuration: %v", err)
+	}
+	listeners, err := listener.Open(listenCfg)
+	if err != nil {
+		log.Fatalf("Failed to open listeners: %v", err)
+	}
+	quicListener, err := listener.OpenQUIC(listenCfg)
+	if err != nil {
+		log.Fatalf("Failed to open the HTTP/3 listener: %v", err)
+	}
+
+	// Listeners with h2c enabled get their own server, since protocols are
+	// configured per http.Server
+	h2cServer := server.WithH2C(chiServer)
+	apiServers := []*http.Server{chiServer, h2cServer}
+	var http3Server *http3.Server
+	if quicListener != nil {
+		http3Server = server.WithHTTP3(chiServer, quicListener.TLSConfig)
+	}
+
+	// Shutdown order: turn new requests away, drain the in-flight ones,
+	// then stop background jobs and the event publisher, then close the
+	// database they all depend on. Each step gets the shutdown timeout.
+	stopTimeout := cfg.Shutdown.Timeout
+	lc := lifecycle.New()
+	lc.OnStop(lifecycle.Hook{Name: "drain", Phase: lifecycle.PhaseDrain, Timeout: cfg.Shutdown.DrainDelay + time.Second, Stop: func(ctx context.Context) error {
+		return drain.Start(ctx, cfg.Shutdown.DrainDelay)
+	}})
+	lc.OnStop(lifecycle.Hook{Name: "http", Phase: lifecycle.PhaseServers, Timeout: stopTimeout, Stop: func(ctx context.Context) error {
+		// Each server drains its own connections, so they drain at once
+		errs := make([]error, len(apiServers)+1)
+		var wg sync.WaitGroup
+		for i, apiServer := range apiServers {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs[i] = apiServer.Shutdown(ctx)
+			}()
+		}
+		if http3Server != nil {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				// Shutdown leaves the socket it was given open
+				errs[len(apiServers)] = errors.Join(http3Server.Shutdown(ctx), quicListener.Close())
+			}()
+		}
+		wg.Wait()
+		return errors.Join(errs...)
+	}})
+	if grpcServer != nil {
+		lc.OnStop(lifecycle.Hook{Name: "grpc", Phase: lifecycle.PhaseServers, Timeout: stopTimeout, Stop: func(ctx context.Context) error {
+			// GracefulStop waits for open streams too, the gateway's
+			// calls included; cut them off when the shutdown deadline
+			// passes
+			stopped := make(chan struct{})
+			go func() {
+				grpcServer.GracefulStop()
+				close(stopped)
+			}()
+			select {
+			case <-stopped:
+				return gatewayConn.Close()
+			case <-ctx.Done():
+				grpcServer.Stop()
+				return errors.Join(ctx.Err(), gatewayConn.Close())
+			}
+		}})
+	}
+	lc.OnStop(lifecycle.Hook{Name: "job workers", Phase: lifecycle.PhaseWorkers, Timeout: stopTimeout, Stop: workerPool.Stop})
+	lc.OnStop(lifecycle.Hook{Name: "background jobs", Phase: lifecycle.PhaseWorkers, Timeout: stopTimeout, Stop: func(ctx context.Context) error {
+		err := scheduler.Stop(ctx)
+		// Hand leadership over now rather than when the lease expires
+		return errors.Join(err, elector.Resign(ctx))
+	}})
+	if realtimeBridge != nil {
+		lc.OnStop(lifecycle.Hook{Name: "realtime bridge", Phase: lifecycle.PhaseWorkers, Timeout: stopTimeout, Stop: realtimeBridge.Stop})
+	}
+	lc.OnStop(lifecycle.Hook{Name: "event bus", Phase: lifecycle.PhaseEvents, Timeout: stopTimeout, Stop: eventBus.Close})
+	if dbService != nil {
+		lc.OnStop(lifecycle.Hook{Name: "database", Phase: lifecycle.PhaseDatabase, Timeout: stopTimeout, Stop: func(context.Context) error {
+			return dbService.Close()
+		}})
+	}
+	if traceShutdown != nil {
+		// Flush the last spans once nothing is left to trace
+		lc.OnStop(lifecycle.Hook{Name: "tracing", Phase: lifecycle.PhaseDatabase, Timeout: stopTimeout, Stop: traceShutdown})
+	}
+	done := lc.ShutdownOnSignal(syscall.SIGINT, syscall.SIGTERM)
+
+	// Serve on every listener; Shutdown closes them all
+	serveErrs := make(chan error, len(listeners))
+	for _, l := range listeners {
+		srv := chiServer
+		if l.H2C {
+			srv = h2cServer
+		}
+		log.Printf("Starting server on %s %s (h2c: %t)", l.Addr().Network(), l.Addr(), l.H2C)
+		go func(srv *http.Server, l net.Listener) {
+			serveErrs <- srv.Serve(l)
+		}(srv, l.Listener)
+	}
+	if http3Server != nil {
+		log.Printf("Starting HTTP/3 server on udp %s", quicListener.LocalAddr())
+		go func() {
+			if err := http3Server.Serve(quicListener.PacketConn); err != nil && !errors.Is(err, http.ErrServerClosed) && !errors.Is(err, net.ErrClosed) {
+				log.Fatalf("HTTP/3 server Serve error: %v", err)
+			}
+		}()
+	}
+	if grpcServer != nil {
+		log.Printf("Starting gRPC server on %s", grpcListener.Addr())
+		go func() {
+			if err := grpcServer.Serve(grpcListener); err != nil {
+				log.Fatalf("gRPC server Serve error: %v", err)
+			}
+		}()
+	}
+	for range listeners {
+		if err := <-serveErrs; err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("HTTP server Serve error: %v", err)
+		}
+	}
+
+	// Wait for the graceful shutdown to complete
+	<-done
+	log.Println("Graceful shutdown complete.")
+}
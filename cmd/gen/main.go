@@ -0,0 +1,48 @@
+// Command gen produces code derived from the API definition.
+//
+//	go run ./cmd/gen ts [-o web/src/api.ts]
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/Tomlord1122/todo-backend/internal/apispec"
+	"github.com/Tomlord1122/todo-backend/internal/tsgen"
+)
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: gen ts [-o file]")
+	os.Exit(2)
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	switch os.Args[1] {
+	case "ts":
+		fs := flag.NewFlagSet("ts", flag.ExitOnError)
+		out := fs.String("o", "", "output file (default stdout)")
+		fs.Parse(os.Args[2:])
+
+		var w io.Writer = os.Stdout
+		if *out != "" {
+			f, err := os.Create(*out)
+			if err != nil {
+				log.Fatalf("Failed to create %s: %v", *out, err)
+			}
+			defer f.Close()
+			w = f
+		}
+		if err := tsgen.Generate(w, apispec.Endpoints); err != nil {
+			log.Fatalf("Failed to generate TypeScript: %v", err)
+		}
+	default:
+		usage()
+	}
+}
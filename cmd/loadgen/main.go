@@ -0,0 +1,369 @@
+// Command loadgen drives a mix of todo operations against a running server
+// and reports throughput and latency per operation.
+//
+//	go run ./cmd/loadgen -url http://localhost:8080 -duration 30s -c 20 \
+//		-mix create=20,get=35,list=20,search=15,update=5,delete=5
+//
+// Run it against demo mode (make demo) or a disposable database: it creates,
+// updates and deletes todos of the given user.
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Tomlord1122/todo-backend/internal/apispec"
+)
+
+// operations in report order.
+var operations = []string{"create", "get", "list", "search", "update", "delete"}
+
+var searchTerms = []string{"report", "review", "plants", "invoice", "call", "deploy"}
+
+func main() {
+	baseURL := flag.String("url", "http://localhost:8080", "server base URL")
+	duration := flag.Duration("duration", 30*time.Second, "how long to run")
+	concurrency := flag.Int("c", 10, "concurrent workers")
+	rps := flag.Float64("rps", 0, "overall request rate limit, 0 for as fast as possible")
+	mixFlag := flag.String("mix", "create=20,get=35,list=20,search=15,update=5,delete=5", "relative weight of each operation")
+	token := flag.String("token", "", "access token of the user the todos belong to; a new account is registered when empty")
+	seed := flag.Int("seed", 50, "todos to create before the run")
+	flag.Parse()
+
+	mix, err := parseMix(*mixFlag)
+	if err != nil {
+		log.Fatalf("Invalid -mix: %v", err)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	g := &generator{
+		base:        strings.TrimSuffix(*baseURL, "/") + apispec.BasePath,
+		client:      &http.Client{Timeout: 10 * time.Second, Transport: &http.Transport{MaxIdleConnsPerHost: *concurrency}},
+		token:       *token,
+		stats:       map[string]*opStats{},
+		firstErrors: map[string]error{},
+	}
+	for _, op := range operations {
+		g.stats[op] = &opStats{}
+	}
+
+	if g.token == "" {
+		if err := g.register(ctx); err != nil {
+			log.Fatalf("Registering a loadgen account failed, is the server running at %s? %v", g.base, err)
+		}
+	}
+	for i := 0; i < *seed; i++ {
+		if err := g.create(ctx); err != nil {
+			log.Fatalf("Seeding failed, is the server running at %s? %v", g.base, err)
+		}
+	}
+
+	runCtx, stop := context.WithTimeout(ctx, *duration)
+	defer stop()
+	var ticks <-chan time.Time
+	if *rps > 0 {
+		ticker := time.NewTicker(time.Duration(float64(time.Second) / *rps))
+		defer ticker.Stop()
+		ticks = ticker.C
+	}
+
+	log.Printf("Running %s against %s with %d workers", *duration, g.base, *concurrency)
+	start := time.Now()
+	var wg sync.WaitGroup
+	for w := 0; w < *concurrency; w++ {
+		wg.Add(1)
+		go func(rng *rand.Rand) {
+			defer wg.Done()
+			for {
+				if ticks != nil {
+					select {
+					case <-ticks:
+					case <-runCtx.Done():
+						return
+					}
+				}
+				if runCtx.Err() != nil {
+					return
+				}
+				g.run(runCtx, mix.pick(rng), rng)
+			}
+		}(rand.New(rand.NewSource(time.Now().UnixNano() + int64(w))))
+	}
+	wg.Wait()
+
+	g.report(os.Stdout, time.Since(start))
+}
+
+// mix is a weighted choice of operations.
+type mix struct {
+	ops     []string
+	weights []int
+	total   int
+}
+
+// parseMix parses "op=weight,..." with ops from operations.
+func parseMix(s string) (mix, error) {
+	var m mix
+	for _, part := range strings.Split(s, ",") {
+		name, weight, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			return m, fmt.Errorf("%q is not op=weight", part)
+		}
+		known := false
+		for _, op := range operations {
+			known = known || op == name
+		}
+		if !known {
+			return m, fmt.Errorf("unknown operation %q, expected one of %s", name, strings.Join(operations, ", "))
+		}
+		w, err := strconv.Atoi(weight)
+		if err != nil || w < 0 {
+			return m, fmt.Errorf("weight of %s must be a non-negative integer", name)
+		}
+		m.ops = append(m.ops, name)
+		m.weights = append(m.weights, w)
+		m.total += w
+	}
+	if m.total == 0 {
+		return m, fmt.Errorf("all weights are zero")
+	}
+	return m, nil
+}
+
+func (m mix) pick(rng *rand.Rand) string {
+	n := rng.Intn(m.total)
+	for i, w := range m.weights {
+		if n < w {
+			return m.ops[i]
+		}
+		n -= w
+	}
+	return m.ops[len(m.ops)-1]
+}
+
+// opStats collects the outcome of one operation.
+type opStats struct {
+	mu        sync.Mutex
+	latencies []time.Duration
+	errors    int
+}
+
+func (s *opStats) record(d time.Duration, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.latencies = append(s.latencies, d)
+	if err != nil {
+		s.errors++
+	}
+}
+
+type generator struct {
+	base   string
+	client *http.Client
+	token  string
+	stats  map[string]*opStats
+
+	mu  sync.Mutex
+	ids []uint
+	// firstErrors keeps one example failure per operation for the report
+	firstErrors map[string]error
+}
+
+// run performs op and records its latency. Operations needing an existing
+// todo fall back to create while there are none.
+func (g *generator) run(ctx context.Context, op string, rng *rand.Rand) {
+	id, ok := g.randomID(rng)
+	if !ok && (op == "get" || op == "update" || op == "delete") {
+		op = "create"
+	}
+
+	start := time.Now()
+	var err error
+	switch op {
+	case "create":
+		err = g.create(ctx)
+	case "get":
+		err = g.do(ctx, "GET", fmt.Sprintf("/todos/%d", id), nil, http.StatusOK, http.StatusNotFound)
+	case "list":
+		err = g.do(ctx, "GET", fmt.Sprintf("/todos?limit=20&offset=%d", rng.Intn(5)*20), nil, http.StatusOK)
+	case "search":
+		q := url.Values{"q": {searchTerms[rng.Intn(len(searchTerms))]}, "limit": {"20"}}
+		err = g.do(ctx, "GET", "/todos/search?"+q.Encode(), nil, http.StatusOK)
+	case "update":
+		body := map[string]any{"completed": rng.Intn(2) == 0, "title": fmt.Sprintf("Review report %d", rng.Intn(1000))}
+		err = g.do(ctx, "PUT", fmt.Sprintf("/todos/%d", id), body, http.StatusOK, http.StatusNotFound)
+	case "delete":
+		g.forget(id)
+		err = g.do(ctx, "DELETE", fmt.Sprintf("/todos/%d", id), nil, http.StatusNoContent, http.StatusNotFound)
+	}
+	if ctx.Err() != nil {
+		// Requests cut off by the end of the run don't count
+		return
+	}
+	g.stats[op].record(time.Since(start), err)
+	if err != nil {
+		g.mu.Lock()
+		if g.firstErrors[op] == nil {
+			g.firstErrors[op] = err
+		}
+		g.mu.Unlock()
+	}
+}
+
+func (g *generator) create(ctx context.Context) error {
+	body := map[string]any{
+		"title":       fmt.Sprintf("%s item %d", searchTerms[rand.Intn(len(searchTerms))], rand.Intn(100000)),
+		"description": "created by loadgen",
+		"priority":    []string{"low", "normal", "high"}[rand.Intn(3)],
+	}
+	var created struct {
+		ID uint `json:"id"`
+	}
+	if err := g.doJSON(ctx, "POST", "/todos", body, &created, http.StatusCreated); err != nil {
+		return err
+	}
+	g.mu.Lock()
+	g.ids = append(g.ids, created.ID)
+	g.mu.Unlock()
+	return nil
+}
+
+// register signs up a throwaway account and uses its access token.
+func (g *generator) register(ctx context.Context) error {
+	body := map[string]any{
+		"email":    fmt.Sprintf("loadgen-%d@example.com", time.Now().UnixNano()),
+		"password": fmt.Sprintf("loadgen-%d", rand.Int63()),
+	}
+	var login struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := g.doJSON(ctx, "POST", "/auth/register", body, &login, http.StatusCreated); err != nil {
+		return err
+	}
+	g.token = login.AccessToken
+	return nil
+}
+
+func (g *generator) randomID(rng *rand.Rand) (uint, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if len(g.ids) == 0 {
+		return 0, false
+	}
+	return g.ids[rng.Intn(len(g.ids))], true
+}
+
+func (g *generator) forget(id uint) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for i, v := range g.ids {
+		if v == id {
+			g.ids[i] = g.ids[len(g.ids)-1]
+			g.ids = g.ids[:len(g.ids)-1]
+			return
+		}
+	}
+}
+
+func (g *generator) do(ctx context.Context, method, path string, body any, okStatus ...int) error {
+	return g.doJSON(ctx, method, path, body, nil, okStatus...)
+}
+
+// doJSON sends body as JSON and decodes the response into out when set. A
+// status outside okStatus is an error.
+func (g *generator) doJSON(ctx context.Context, method, path string, body, out any, okStatus ...int) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, g.base+path, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if g.token != "" {
+		req.Header.Set("Authorization", "Bearer "+g.token)
+	}
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	for _, status := range okStatus {
+		if resp.StatusCode == status {
+			if out != nil && resp.StatusCode < 300 {
+				return json.NewDecoder(resp.Body).Decode(out)
+			}
+			_, err := io.Copy(io.Discard, resp.Body)
+			return err
+		}
+	}
+	snippet, _ := io.ReadAll(io.LimitReader(resp.Body, 200))
+	return fmt.Errorf("%s %s: %s %s", method, path, resp.Status, bytes.TrimSpace(snippet))
+}
+
+// report prints one line per operation and a total.
+func (g *generator) report(w io.Writer, elapsed time.Duration) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "op\trequests\terrors\treq/s\tp50\tp90\tp99\tmax\t")
+	var all []time.Duration
+	totalErrors := 0
+	for _, op := range operations {
+		s := g.stats[op]
+		if len(s.latencies) == 0 {
+			continue
+		}
+		all = append(all, s.latencies...)
+		totalErrors += s.errors
+		writeRow(tw, op, s.latencies, s.errors, elapsed)
+	}
+	if len(all) > 0 {
+		writeRow(tw, "total", all, totalErrors, elapsed)
+	}
+	tw.Flush()
+
+	for _, op := range operations {
+		if err := g.firstErrors[op]; err != nil {
+			fmt.Fprintf(w, "first %s error: %v\n", op, err)
+		}
+	}
+}
+
+func writeRow(w io.Writer, name string, latencies []time.Duration, errors int, elapsed time.Duration) {
+	sorted := append([]time.Duration(nil), latencies...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	fmt.Fprintf(w, "%s\t%d\t%d\t%.1f\t%s\t%s\t%s\t%s\t\n", name, len(sorted), errors,
+		float64(len(sorted))/elapsed.Seconds(),
+		percentile(sorted, 0.50), percentile(sorted, 0.90), percentile(sorted, 0.99), sorted[len(sorted)-1].Round(time.Microsecond))
+}
+
+// percentile returns the p-quantile of sorted latencies.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	i := int(float64(len(sorted)-1) * p)
+	return sorted[i].Round(time.Microsecond)
+}
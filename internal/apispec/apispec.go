@@ -0,0 +1,162 @@
+// Package apispec describes the JSON API in a machine-readable form: each
+// endpoint with its request and response DTOs. Code generators (TypeScript
+// types, API clients) work from this catalog and the server validates
+// incoming requests against it, so it must be updated alongside
+// RegisterRoutes.
+package apispec
+
+import (
+	"reflect"
+
+	"github.com/Tomlord1122/todo-backend/internal/service"
+)
+
+// Version is the current API version, served under BasePath.
+const Version = "v1"
+
+// BasePath prefixes the paths of Endpoints.
+const BasePath = "/api/" + Version
+
+// Endpoint is one JSON API operation.
+type Endpoint struct {
+	// Name is the operation name used by generated clients, e.g. "createTodo".
+	Name   string
+	Method string
+	// Path, relative to BasePath, uses chi-style parameters, e.g.
+	// "/todos/{id}".
+	Path string
+	// Query lists supported query parameters; others are rejected.
+	Query []string
+	// Request is the JSON body type, nil when there is no body.
+	Request reflect.Type
+	// Response is the JSON response type, nil for 204 No Content.
+	Response reflect.Type
+}
+
+func typeOf[T any]() reflect.Type {
+	return reflect.TypeFor[T]()
+}
+
+// Endpoints lists the JSON API. Endpoints returning other content (feeds,
+// report exports, attachment redirects) are not included.
+var Endpoints = []Endpoint{
+	{Name: "createTodo", Method: "POST", Path: "/todos", Request: typeOf[service.CreateTodoRequest](), Response: typeOf[service.TodoResponse]()},
+	{Name: "suggestTodo", Method: "POST", Path: "/todos/suggest", Request: typeOf[service.SuggestTodoRequest](), Response: typeOf[service.SuggestionResponse]()},
+	{Name: "listTodos", Method: "GET", Path: "/todos", Query: []string{"limit", "offset", "cursor", "completed", "due_before", "due_after", "overdue", "tags", "user_id", "sort", "include_deleted", "deleted_since"}, Response: typeOf[[]service.TodoResponse]()},
+	{Name: "searchTodos", Method: "GET", Path: "/todos/search", Query: []string{"q", "fuzzy", "threshold", "limit", "offset"}, Response: typeOf[[]service.TodoSearchResult]()},
+	{Name: "overdueTodos", Method: "GET", Path: "/todos/overdue", Query: []string{"user_id", "tz"}, Response: typeOf[[]service.OverdueTodo]()},
+	{Name: "nearbyTodos", Method: "GET", Path: "/todos/nearby", Query: []string{"lat", "lng", "radius", "limit"}, Response: typeOf[[]service.NearbyTodoResult]()},
+	{Name: "getTodo", Method: "GET", Path: "/todos/{id}", Query: []string{"render"}, Response: typeOf[service.TodoResponse]()},
+	{Name: "updateTodo", Method: "PUT", Path: "/todos/{id}", Request: typeOf[service.UpdateTodoRequest](), Response: typeOf[service.TodoResponse]()},
+	// The body is a merge or JSON patch of the UpdateTodoRequest members,
+	// which the handler checks
+	{Name: "patchTodo", Method: "PATCH", Path: "/todos/{id}", Response: typeOf[service.TodoResponse]()},
+	{Name: "deleteTodo", Method: "DELETE", Path: "/todos/{id}"},
+
+	{Name: "listChecklist", Method: "GET", Path: "/todos/{id}/checklist", Response: typeOf[[]service.ChecklistItemResponse]()},
+	{Name: "addChecklistItem", Method: "POST", Path: "/todos/{id}/checklist", Request: typeOf[service.CreateChecklistItemRequest](), Response: typeOf[service.ChecklistItemResponse]()},
+	{Name: "updateChecklistItem", Method: "PUT", Path: "/todos/{id}/checklist/{itemID}", Request: typeOf[service.UpdateChecklistItemRequest](), Response: typeOf[service.ChecklistItemResponse]()},
+	{Name: "deleteChecklistItem", Method: "DELETE", Path: "/todos/{id}/checklist/{itemID}"},
+	{Name: "listSubtasks", Method: "GET", Path: "/todos/{id}/subtasks", Response: typeOf[[]service.SubtaskResponse]()},
+	{Name: "createSubtask", Method: "POST", Path: "/todos/{id}/subtasks", Request: typeOf[service.CreateSubtaskRequest](), Response: typeOf[service.SubtaskResponse]()},
+	{Name: "updateSubtask", Method: "PATCH", Path: "/todos/{id}/subtasks/{subtaskID}", Request: typeOf[service.UpdateSubtaskRequest](), Response: typeOf[service.SubtaskResponse]()},
+	{Name: "listTrash", Method: "GET", Path: "/todos/trash", Query: []string{"user_id"}, Response: typeOf[[]service.TodoResponse]()},
+	{Name: "restoreTodo", Method: "POST", Path: "/todos/{id}/restore", Response: typeOf[service.TodoResponse]()},
+	{Name: "purgeTodo", Method: "DELETE", Path: "/todos/{id}/purge"},
+	{Name: "bulkCreateTodos", Method: "POST", Path: "/todos/bulk", Request: typeOf[[]service.CreateTodoRequest](), Response: typeOf[[]service.BulkResult]()},
+	{Name: "bulkUpdateTodos", Method: "PATCH", Path: "/todos/bulk", Request: typeOf[[]service.BulkUpdateItem](), Response: typeOf[[]service.BulkResult]()},
+	{Name: "bulkDeleteTodos", Method: "DELETE", Path: "/todos/bulk", Request: typeOf[[]uint](), Response: typeOf[[]service.BulkResult]()},
+	{Name: "deleteSubtask", Method: "DELETE", Path: "/todos/{id}/subtasks/{subtaskID}"},
+	{Name: "listReminders", Method: "GET", Path: "/todos/{id}/reminders", Response: typeOf[[]service.ReminderResponse]()},
+	{Name: "createReminder", Method: "POST", Path: "/todos/{id}/reminders", Request: typeOf[service.CreateReminderRequest](), Response: typeOf[service.ReminderResponse]()},
+	{Name: "deleteReminder", Method: "DELETE", Path: "/todos/{id}/reminders/{reminderID}"},
+	{Name: "listReactions", Method: "GET", Path: "/todos/{id}/reactions", Response: typeOf[[]service.ReactionSummary]()},
+	{Name: "addReaction", Method: "POST", Path: "/todos/{id}/reactions", Request: typeOf[service.AddReactionRequest](), Response: typeOf[[]service.ReactionSummary]()},
+	{Name: "removeReaction", Method: "DELETE", Path: "/todos/{id}/reactions", Query: []string{"emoji"}},
+	{Name: "listActivity", Method: "GET", Path: "/todos/{id}/activity", Response: typeOf[[]service.ActivityResponse]()},
+	{Name: "listAttachments", Method: "GET", Path: "/todos/{id}/attachments", Response: typeOf[[]service.AttachmentResponse]()},
+	{Name: "presignAttachment", Method: "POST", Path: "/todos/{id}/attachments/presign", Request: typeOf[service.PresignAttachmentRequest](), Response: typeOf[service.PresignAttachmentResponse]()},
+	{Name: "confirmAttachment", Method: "POST", Path: "/todos/{id}/attachments/{attachmentID}/confirm", Response: typeOf[service.AttachmentResponse]()},
+	{Name: "deleteAttachment", Method: "DELETE", Path: "/attachments/{id}"},
+
+	{Name: "startFocus", Method: "POST", Path: "/focus/start", Request: typeOf[service.StartFocusRequest](), Response: typeOf[service.FocusSessionResponse]()},
+	{Name: "stopFocus", Method: "POST", Path: "/focus/stop", Request: typeOf[service.StopFocusRequest](), Response: typeOf[service.FocusSessionResponse]()},
+	{Name: "currentFocus", Method: "GET", Path: "/focus/current", Query: []string{"user_id"}, Response: typeOf[service.FocusSessionResponse]()},
+	{Name: "getStats", Method: "GET", Path: "/stats", Query: []string{"user_id", "from", "to", "tz"}, Response: typeOf[service.StatsResponse]()},
+	{Name: "getPreferences", Method: "GET", Path: "/users/{id}/preferences", Response: typeOf[service.PreferencesResponse]()},
+	{Name: "updatePreferences", Method: "PUT", Path: "/users/{id}/preferences", Request: typeOf[service.UpdatePreferencesRequest](), Response: typeOf[service.PreferencesResponse]()},
+	{Name: "connectGoogleCalendar", Method: "POST", Path: "/users/{id}/google-calendar", Request: typeOf[service.ConnectCalendarRequest](), Response: typeOf[service.CalendarConnectionResponse]()},
+	{Name: "getGoogleCalendar", Method: "GET", Path: "/users/{id}/google-calendar", Response: typeOf[service.CalendarConnectionResponse]()},
+	{Name: "disconnectGoogleCalendar", Method: "DELETE", Path: "/users/{id}/google-calendar"},
+
+	{Name: "beginPasskeyLogin", Method: "POST", Path: "/auth/passkeys/login/begin", Response: typeOf[service.PasskeyLoginOptions]()},
+	{Name: "finishPasskeyLogin", Method: "POST", Path: "/auth/passkeys/login/finish", Request: typeOf[service.FinishPasskeyLoginRequest](), Response: typeOf[service.SessionResponse]()},
+	{Name: "logout", Method: "POST", Path: "/auth/logout"},
+	{Name: "register", Method: "POST", Path: "/auth/register", Request: typeOf[service.RegisterRequest](), Response: typeOf[service.AccessTokenResponse]()},
+	{Name: "login", Method: "POST", Path: "/auth/login", Request: typeOf[service.LoginRequest](), Response: typeOf[service.AccessTokenResponse]()},
+	{Name: "listUsers", Method: "GET", Path: "/users", Response: typeOf[[]service.UserResponse]()},
+	{Name: "updateUserRole", Method: "PUT", Path: "/users/{id}/role", Request: typeOf[service.UpdateUserRoleRequest](), Response: typeOf[service.UserResponse]()},
+	{Name: "createAPIKey", Method: "POST", Path: "/apikeys", Request: typeOf[service.CreateAPIKeyRequest](), Response: typeOf[service.CreatedAPIKeyResponse]()},
+	{Name: "listAPIKeys", Method: "GET", Path: "/apikeys", Response: typeOf[[]service.APIKeyResponse]()},
+	{Name: "revokeAPIKey", Method: "DELETE", Path: "/apikeys/{id}"},
+	{Name: "createWebhook", Method: "POST", Path: "/webhooks", Request: typeOf[service.CreateWebhookRequest](), Response: typeOf[service.CreatedWebhookResponse]()},
+	{Name: "listWebhooks", Method: "GET", Path: "/webhooks", Response: typeOf[[]service.WebhookResponse]()},
+	{Name: "getWebhook", Method: "GET", Path: "/webhooks/{id}", Response: typeOf[service.WebhookResponse]()},
+	{Name: "updateWebhook", Method: "PUT", Path: "/webhooks/{id}", Request: typeOf[service.UpdateWebhookRequest](), Response: typeOf[service.WebhookResponse]()},
+	{Name: "deleteWebhook", Method: "DELETE", Path: "/webhooks/{id}"},
+	{Name: "listWebhookDeliveries", Method: "GET", Path: "/webhooks/{id}/deliveries", Response: typeOf[[]service.WebhookDeliveryResponse]()},
+	{Name: "createTag", Method: "POST", Path: "/tags", Request: typeOf[service.CreateTagRequest](), Response: typeOf[service.TagResponse]()},
+	{Name: "listTags", Method: "GET", Path: "/tags", Response: typeOf[[]service.TagResponse]()},
+	{Name: "getTag", Method: "GET", Path: "/tags/{id}", Response: typeOf[service.TagResponse]()},
+	{Name: "updateTag", Method: "PUT", Path: "/tags/{id}", Request: typeOf[service.UpdateTagRequest](), Response: typeOf[service.TagResponse]()},
+	{Name: "deleteTag", Method: "DELETE", Path: "/tags/{id}"},
+	{Name: "beginPasskeyRegistration", Method: "POST", Path: "/me/passkeys/register/begin", Response: typeOf[service.PasskeyRegistrationOptions]()},
+	{Name: "finishPasskeyRegistration", Method: "POST", Path: "/me/passkeys/register/finish", Request: typeOf[service.FinishPasskeyRegistrationRequest](), Response: typeOf[service.PasskeyResponse]()},
+	{Name: "listPasskeys", Method: "GET", Path: "/me/passkeys", Response: typeOf[[]service.PasskeyResponse]()},
+	{Name: "updatePasskey", Method: "PUT", Path: "/me/passkeys/{id}", Request: typeOf[service.UpdatePasskeyRequest](), Response: typeOf[service.PasskeyResponse]()},
+	{Name: "deletePasskey", Method: "DELETE", Path: "/me/passkeys/{id}"},
+	{Name: "listSSOProviders", Method: "GET", Path: "/auth/oidc/providers", Response: typeOf[[]service.SSOProviderResponse]()},
+	{Name: "beginSSOLogin", Method: "POST", Path: "/auth/oidc/{provider}/begin", Response: typeOf[service.SSOLoginResponse]()},
+	{Name: "finishSSOLogin", Method: "POST", Path: "/auth/oidc/{provider}/callback", Request: typeOf[service.FinishSSOLoginRequest](), Response: typeOf[service.SessionResponse]()},
+	{Name: "listIdentities", Method: "GET", Path: "/me/identities", Response: typeOf[[]service.IdentityResponse]()},
+	{Name: "deleteIdentity", Method: "DELETE", Path: "/me/identities/{id}"},
+	{Name: "followTodo", Method: "POST", Path: "/todos/{id}/follow", Response: typeOf[service.FollowingResponse]()},
+	{Name: "unfollowTodo", Method: "DELETE", Path: "/todos/{id}/follow"},
+	{Name: "listFollowing", Method: "GET", Path: "/me/following", Response: typeOf[[]service.FollowingResponse]()},
+	{Name: "listTodoPresence", Method: "GET", Path: "/todos/{id}/presence", Response: typeOf[[]service.ViewerResponse]()},
+	{Name: "updateTodoPresence", Method: "PUT", Path: "/todos/{id}/presence", Request: typeOf[service.PresenceRequest](), Response: typeOf[[]service.ViewerResponse]()},
+	{Name: "leaveTodoPresence", Method: "DELETE", Path: "/todos/{id}/presence"},
+	{Name: "listListPresence", Method: "GET", Path: "/lists/{id}/presence", Response: typeOf[[]service.ViewerResponse]()},
+	{Name: "updateListPresence", Method: "PUT", Path: "/lists/{id}/presence", Request: typeOf[service.PresenceRequest](), Response: typeOf[[]service.ViewerResponse]()},
+	{Name: "leaveListPresence", Method: "DELETE", Path: "/lists/{id}/presence"},
+
+	{Name: "createList", Method: "POST", Path: "/lists", Request: typeOf[service.CreateListRequest](), Response: typeOf[service.ListResponse]()},
+	{Name: "listLists", Method: "GET", Path: "/lists", Query: []string{"user_id", "include_deleted", "deleted_since"}, Response: typeOf[[]service.ListResponse]()},
+	{Name: "getList", Method: "GET", Path: "/lists/{id}", Response: typeOf[service.ListResponse]()},
+	{Name: "updateList", Method: "PUT", Path: "/lists/{id}", Request: typeOf[service.UpdateListRequest](), Response: typeOf[service.ListResponse]()},
+	{Name: "deleteList", Method: "DELETE", Path: "/lists/{id}"},
+	{Name: "getListBurndown", Method: "GET", Path: "/lists/{id}/burndown", Query: []string{"from", "to", "tz"}, Response: typeOf[service.BurndownResponse]()},
+	{Name: "getListTimeline", Method: "GET", Path: "/lists/{id}/timeline", Response: typeOf[service.TimelineResponse]()},
+	{Name: "linkGitHub", Method: "POST", Path: "/lists/{id}/github", Request: typeOf[service.LinkGitHubRequest](), Response: typeOf[service.GitHubLinkResponse]()},
+	{Name: "getGitHubLink", Method: "GET", Path: "/lists/{id}/github", Response: typeOf[service.GitHubLinkResponse]()},
+	{Name: "unlinkGitHub", Method: "DELETE", Path: "/lists/{id}/github"},
+
+	{Name: "createReportSchedule", Method: "POST", Path: "/reports/schedules", Request: typeOf[service.CreateReportScheduleRequest](), Response: typeOf[service.ReportScheduleResponse]()},
+	{Name: "listReportSchedules", Method: "GET", Path: "/reports/schedules", Query: []string{"user_id"}, Response: typeOf[[]service.ReportScheduleResponse]()},
+	{Name: "getReportSchedule", Method: "GET", Path: "/reports/schedules/{id}", Response: typeOf[service.ReportScheduleResponse]()},
+	{Name: "updateReportSchedule", Method: "PUT", Path: "/reports/schedules/{id}", Request: typeOf[service.UpdateReportScheduleRequest](), Response: typeOf[service.ReportScheduleResponse]()},
+	{Name: "deleteReportSchedule", Method: "DELETE", Path: "/reports/schedules/{id}"},
+
+	{Name: "createFeedToken", Method: "POST", Path: "/feeds/tokens", Response: typeOf[service.FeedTokenResponse]()},
+	{Name: "revokeFeedToken", Method: "DELETE", Path: "/feeds/tokens/{token}"},
+
+	{Name: "createNotionExport", Method: "POST", Path: "/export/notion", Request: typeOf[service.CreateNotionExportRequest](), Response: typeOf[service.NotionExportResponse]()},
+	{Name: "getNotionExport", Method: "GET", Path: "/export/notion/{id}", Response: typeOf[service.NotionExportResponse]()},
+
+	{Name: "createImport", Method: "POST", Path: "/imports", Query: []string{"user_id", "format", "list_id"}, Response: typeOf[service.ImportResponse]()},
+	{Name: "getImport", Method: "GET", Path: "/imports/{id}", Response: typeOf[service.ImportResponse]()},
+
+	{Name: "createInboundHook", Method: "POST", Path: "/hooks/inbound", Request: typeOf[service.CreateInboundHookRequest](), Response: typeOf[service.InboundHookResponse]()},
+	{Name: "revokeInboundHook", Method: "DELETE", Path: "/hooks/inbound/{token}"},
+	{Name: "triggerInboundHook", Method: "POST", Path: "/hooks/inbound/{token}", Response: typeOf[service.TodoResponse]()},
+}
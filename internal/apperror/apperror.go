@@ -0,0 +1,120 @@
+// Package apperror defines the errors the application returns for requests
+// that can't succeed as made. Each error has a Code saying what kind of
+// failure it is, which the HTTP layer maps to a status, and a message meant
+// for the client. Any other error is internal, and its message is only
+// logged.
+package apperror
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Code identifies a kind of error. Codes are part of the API: they are sent
+// to clients as the code of problem responses.
+type Code string
+
+const (
+	// CodeInvalid is for invalid input.
+	CodeInvalid Code = "invalid"
+	// CodeUnauthenticated is for requests without valid credentials.
+	CodeUnauthenticated Code = "unauthenticated"
+	// CodeForbidden is for requests the client may not make.
+	CodeForbidden Code = "forbidden"
+	// CodeNotFound is for requests referring to something that doesn't
+	// exist, or that the client may not know about.
+	CodeNotFound Code = "not_found"
+	// CodeConflict is for requests conflicting with the current state,
+	// like duplicates.
+	CodeConflict Code = "conflict"
+	// CodePreconditionFailed is for writes expecting another version of
+	// what they change.
+	CodePreconditionFailed Code = "precondition_failed"
+	// CodeUnprocessable is for well-formed requests that can't be carried
+	// out, like a page size over the limit.
+	CodeUnprocessable Code = "unprocessable"
+	// CodePending is for results that aren't ready yet.
+	CodePending Code = "pending"
+	// CodeUnavailable is for features that aren't configured or whose
+	// dependencies are down.
+	CodeUnavailable Code = "unavailable"
+)
+
+// Error is an error with a code. Errors with an empty Message only serve as
+// kinds to compare with; see Is.
+type Error struct {
+	Code    Code
+	Message string
+	// Err is the wrapped cause, if any
+	Err error
+}
+
+// Kinds of errors, to test errors with errors.Is, whatever their message:
+// errors.Is(err, apperror.ErrNotFound).
+var (
+	ErrInvalid            = &Error{Code: CodeInvalid}
+	ErrUnauthenticated    = &Error{Code: CodeUnauthenticated}
+	ErrForbidden          = &Error{Code: CodeForbidden}
+	ErrNotFound           = &Error{Code: CodeNotFound}
+	ErrConflict           = &Error{Code: CodeConflict}
+	ErrPreconditionFailed = &Error{Code: CodePreconditionFailed}
+	ErrUnprocessable      = &Error{Code: CodeUnprocessable}
+	ErrPending            = &Error{Code: CodePending}
+	ErrUnavailable        = &Error{Code: CodeUnavailable}
+)
+
+func (e *Error) Error() string {
+	if e.Message == "" {
+		return string(e.Code)
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is the kind of e, so that errors.Is matches the
+// kinds above. Errors with a message, like sentinel errors made with New,
+// only match themselves.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Message == "" && t.Code == e.Code
+}
+
+// New returns an error with code and message, e.g. for sentinel errors.
+func New(code Code, message string) *Error {
+	return &Error{Code: code, Message: message}
+}
+
+// Errorf returns an error with code and a formatted message. As with
+// fmt.Errorf, a %w verb wraps its operand.
+func Errorf(code Code, format string, args ...any) error {
+	err := fmt.Errorf(format, args...)
+	return &Error{Code: code, Message: err.Error(), Err: errors.Unwrap(err)}
+}
+
+// Invalidf returns a CodeInvalid error; see Errorf.
+func Invalidf(format string, args ...any) error {
+	return Errorf(CodeInvalid, format, args...)
+}
+
+// NotFoundf returns a CodeNotFound error; see Errorf.
+func NotFoundf(format string, args ...any) error {
+	return Errorf(CodeNotFound, format, args...)
+}
+
+// Conflictf returns a CodeConflict error; see Errorf.
+func Conflictf(format string, args ...any) error {
+	return Errorf(CodeConflict, format, args...)
+}
+
+// CodeOf returns the code of the first Error in err's chain, or "" for
+// internal errors.
+func CodeOf(err error) Code {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Code
+	}
+	return ""
+}
@@ -0,0 +1,47 @@
+package apperror
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestIs(t *testing.T) {
+	errTaken := New(CodeConflict, "name is taken")
+	cause := errors.New("bad offset")
+	notFound := NotFoundf("todo with ID %d not found", 7)
+	invalid := Invalidf("invalid page: %w", cause)
+
+	cases := []struct {
+		name   string
+		err    error
+		target error
+		want   bool
+	}{
+		{"kind", notFound, ErrNotFound, true},
+		{"other kind", notFound, ErrInvalid, false},
+		{"wrapped kind", fmt.Errorf("listing: %w", notFound), ErrNotFound, true},
+		{"cause", invalid, cause, true},
+		{"sentinel", fmt.Errorf("creating: %w", errTaken), errTaken, true},
+		{"sentinel kind", errTaken, ErrConflict, true},
+		{"other sentinel", Conflictf("name is taken"), errTaken, false},
+	}
+	for _, tc := range cases {
+		if got := errors.Is(tc.err, tc.target); got != tc.want {
+			t.Errorf("%s: errors.Is(%v, %v) = %t, want %t", tc.name, tc.err, tc.target, got, tc.want)
+		}
+	}
+}
+
+func TestErrorf(t *testing.T) {
+	err := Invalidf("invalid location: %w", errors.New("latitude out of range"))
+	if got := err.Error(); got != "invalid location: latitude out of range" {
+		t.Errorf("Error() = %q", got)
+	}
+	if got := CodeOf(fmt.Errorf("creating todo: %w", err)); got != CodeInvalid {
+		t.Errorf("CodeOf = %q, want %q", got, CodeInvalid)
+	}
+	if got := CodeOf(errors.New("connection refused")); got != "" {
+		t.Errorf("CodeOf of an internal error = %q, want none", got)
+	}
+}
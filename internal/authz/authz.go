@@ -0,0 +1,84 @@
+// Package authz decides what a signed-in user may do, based on their
+// role. The HTTP layer stores the caller's Principal in the request
+// context; services and middleware then ask Check whether it holds a
+// permission.
+package authz
+
+import (
+	"context"
+
+	"github.com/Tomlord1122/todo-backend/internal/apperror"
+	"github.com/Tomlord1122/todo-backend/internal/domain"
+)
+
+// ErrForbidden is returned when the caller's role lacks a permission.
+var ErrForbidden = apperror.New(apperror.CodeForbidden, "you don't have permission to do this")
+
+// Permission is one thing a role may be allowed to do.
+type Permission string
+
+// The permissions roles are granted.
+const (
+	// ReadTodos lets a user read their own todos
+	ReadTodos Permission = "todos:read"
+	// WriteTodos lets a user create, change and delete their own todos
+	WriteTodos Permission = "todos:write"
+	// AccessAllTodos lets a user read and change anyone's todos
+	AccessAllTodos Permission = "todos:all"
+	// ManageUsers lets a user list accounts and change their roles
+	ManageUsers Permission = "users:manage"
+	// ViewStats lets a user read productivity stats of any user
+	ViewStats Permission = "stats:read"
+)
+
+var grants = map[domain.Role][]Permission{
+	domain.RoleAdmin:  {ReadTodos, WriteTodos, AccessAllTodos, ManageUsers, ViewStats},
+	domain.RoleMember: {ReadTodos, WriteTodos},
+	domain.RoleViewer: {ReadTodos},
+}
+
+// Can reports whether role has permission. Unknown roles have none.
+func Can(role domain.Role, permission Permission) bool {
+	for _, p := range grants[role] {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidRole reports whether role is one of domain.Roles.
+func ValidRole(role domain.Role) bool {
+	_, ok := grants[role]
+	return ok
+}
+
+// Principal is the user a request acts for.
+type Principal struct {
+	UserID uint
+	Role   domain.Role
+}
+
+type principalKey struct{}
+
+// NewContext returns a copy of ctx carrying p.
+func NewContext(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// FromContext returns the principal stored by NewContext, if any.
+func FromContext(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(Principal)
+	return p, ok
+}
+
+// Check returns ErrForbidden unless the principal in ctx has permission.
+// A context without a principal is trusted: it belongs to the server's
+// own work, such as imports and inbound hooks, not to a user's request.
+func Check(ctx context.Context, permission Permission) error {
+	p, ok := FromContext(ctx)
+	if !ok || Can(p.Role, permission) {
+		return nil
+	}
+	return ErrForbidden
+}
@@ -0,0 +1,47 @@
+package authz
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Tomlord1122/todo-backend/internal/domain"
+)
+
+func TestCan(t *testing.T) {
+	cases := []struct {
+		role       domain.Role
+		permission Permission
+		want       bool
+	}{
+		{domain.RoleAdmin, ManageUsers, true},
+		{domain.RoleAdmin, WriteTodos, true},
+		{domain.RoleMember, WriteTodos, true},
+		{domain.RoleMember, AccessAllTodos, false},
+		{domain.RoleMember, ViewStats, false},
+		{domain.RoleViewer, ReadTodos, true},
+		{domain.RoleViewer, WriteTodos, false},
+		{"owner", ReadTodos, false},
+	}
+	for _, c := range cases {
+		if got := Can(c.role, c.permission); got != c.want {
+			t.Errorf("Can(%q, %q) = %v, want %v", c.role, c.permission, got, c.want)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	if err := Check(context.Background(), ManageUsers); err != nil {
+		t.Errorf("without a principal: err = %v, want nil", err)
+	}
+	viewer := NewContext(context.Background(), Principal{UserID: 3, Role: domain.RoleViewer})
+	if err := Check(viewer, ReadTodos); err != nil {
+		t.Errorf("viewer reading: err = %v, want nil", err)
+	}
+	if err := Check(viewer, WriteTodos); !errors.Is(err, ErrForbidden) {
+		t.Errorf("viewer writing: err = %v, want ErrForbidden", err)
+	}
+	if p, ok := FromContext(viewer); !ok || p.UserID != 3 {
+		t.Errorf("FromContext = %+v, %v", p, ok)
+	}
+}
@@ -0,0 +1,159 @@
+// Package cache keeps values for a while so reads can skip the database.
+// A cache is only an optimization: callers treat its errors as misses and
+// carry on without it.
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Tomlord1122/todo-backend/internal/redis"
+)
+
+// ErrUnavailable is returned while a cache is skipped after failing.
+var ErrUnavailable = errors.New("cache unavailable")
+
+// Cache stores values under keys for a limited time.
+type Cache interface {
+	// Get returns the value stored under key; ok is false when there is
+	// none.
+	Get(ctx context.Context, key string) (value []byte, ok bool, err error)
+	// Set stores value under key for ttl.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// Delete removes keys, ignoring the ones that don't exist.
+	Delete(ctx context.Context, keys ...string) error
+}
+
+// ConfigFromEnv reads CACHE_TTL, how long reads stay cached (default 1m).
+// ok is false when it is 0, meaning reads aren't cached.
+func ConfigFromEnv() (ttl time.Duration, ok bool, err error) {
+	ttl = time.Minute
+	if v := os.Getenv("CACHE_TTL"); v != "" {
+		if ttl, err = time.ParseDuration(v); err != nil || ttl < 0 {
+			return 0, false, fmt.Errorf("invalid CACHE_TTL %q, expected a duration", v)
+		}
+	}
+	return ttl, ttl > 0, nil
+}
+
+// Memory is a cache within this process.
+type Memory struct {
+	now func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]memoryEntry
+}
+
+type memoryEntry struct {
+	value   []byte
+	expires time.Time
+}
+
+// NewMemory creates an in-memory cache.
+func NewMemory() *Memory {
+	return &Memory{now: time.Now, entries: make(map[string]memoryEntry)}
+}
+
+// Get implements Cache.
+func (m *Memory) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry, ok := m.entries[key]
+	if !ok || !m.now().Before(entry.expires) {
+		delete(m.entries, key)
+		return nil, false, nil
+	}
+	return entry.value, true, nil
+}
+
+// Set implements Cache.
+func (m *Memory) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries[key] = memoryEntry{value: value, expires: m.now().Add(ttl)}
+	return nil
+}
+
+// Delete implements Cache.
+func (m *Memory) Delete(ctx context.Context, keys ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, key := range keys {
+		delete(m.entries, key)
+	}
+	return nil
+}
+
+// Redis caches in Redis, shared by every instance using the server.
+// Commands taking longer than the timeout count as failed, since a slow
+// cache is worse than none. After a failure the cache is skipped for the
+// cooldown, returning ErrUnavailable, so an unreachable server doesn't
+// slow down every request.
+type Redis struct {
+	client   *redis.Client
+	prefix   string
+	timeout  time.Duration
+	cooldown time.Duration
+	// downUntil is when, in Unix nanoseconds, the cache is tried again
+	downUntil atomic.Int64
+}
+
+// NewRedis creates a Redis cache. Keys are stored under prefix.
+func NewRedis(client *redis.Client, prefix string) *Redis {
+	return &Redis{client: client, prefix: prefix, timeout: 200 * time.Millisecond, cooldown: 10 * time.Second}
+}
+
+// Get implements Cache.
+func (r *Redis) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	reply, err := r.do(ctx, "GET", r.prefix+key)
+	if err != nil || reply == nil {
+		return nil, false, err
+	}
+	value, ok := reply.(string)
+	if !ok {
+		return nil, false, fmt.Errorf("unexpected GET reply %v", reply)
+	}
+	return []byte(value), true, nil
+}
+
+// Set implements Cache.
+func (r *Redis) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	_, err := r.do(ctx, "SET", r.prefix+key, value, "PX", max(ttl.Milliseconds(), 1))
+	return err
+}
+
+// Delete implements Cache.
+func (r *Redis) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	args := []any{"DEL"}
+	for _, key := range keys {
+		args = append(args, r.prefix+key)
+	}
+	_, err := r.do(ctx, args...)
+	return err
+}
+
+// do runs a command unless the cache is cooling down after a failure.
+func (r *Redis) do(ctx context.Context, args ...any) (any, error) {
+	if time.Now().UnixNano() < r.downUntil.Load() {
+		return nil, ErrUnavailable
+	}
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	reply, err := r.client.Do(ctx, args...)
+	if err != nil {
+		var replyErr redis.Error
+		if !errors.As(err, &replyErr) {
+			r.downUntil.Store(time.Now().Add(r.cooldown).UnixNano())
+		}
+		return nil, fmt.Errorf("running cache %s: %w", args[0], err)
+	}
+	return reply, nil
+}
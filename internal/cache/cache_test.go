@@ -0,0 +1,66 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/Tomlord1122/todo-backend/internal/redis"
+)
+
+func TestMemoryExpires(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	m := NewMemory()
+	m.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	_ = m.Set(ctx, "a", []byte("1"), time.Minute)
+	_ = m.Set(ctx, "b", []byte("2"), time.Minute)
+	if value, ok, _ := m.Get(ctx, "a"); !ok || string(value) != "1" {
+		t.Fatalf("Get(a) = %q, %t; want 1", value, ok)
+	}
+	_ = m.Delete(ctx, "a", "missing")
+	if _, ok, _ := m.Get(ctx, "a"); ok {
+		t.Error("deleted key is still cached")
+	}
+	now = now.Add(time.Minute)
+	if _, ok, _ := m.Get(ctx, "b"); ok {
+		t.Error("expired key is still cached")
+	}
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	t.Setenv("CACHE_TTL", "")
+	if ttl, ok, err := ConfigFromEnv(); err != nil || !ok || ttl != time.Minute {
+		t.Errorf("ConfigFromEnv() = %v, %t, %v; want 1m", ttl, ok, err)
+	}
+	t.Setenv("CACHE_TTL", "0")
+	if _, ok, err := ConfigFromEnv(); err != nil || ok {
+		t.Errorf("ConfigFromEnv() = %t, %v; want caching off", ok, err)
+	}
+	t.Setenv("CACHE_TTL", "forever")
+	if _, _, err := ConfigFromEnv(); err == nil {
+		t.Error("expected an error for CACHE_TTL=forever")
+	}
+}
+
+func TestRedisCoolsDownAfterFailing(t *testing.T) {
+	// Nothing listens on a closed listener's address
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+
+	c := NewRedis(redis.NewClient(redis.Config{Network: "tcp", Address: addr}), "cache:")
+	ctx := context.Background()
+	if _, _, err := c.Get(ctx, "a"); err == nil || errors.Is(err, ErrUnavailable) {
+		t.Fatalf("first Get = %v, want the connection error", err)
+	}
+	if err := c.Set(ctx, "a", []byte("1"), time.Minute); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("Set while cooling down = %v, want ErrUnavailable", err)
+	}
+}
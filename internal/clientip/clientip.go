@@ -0,0 +1,173 @@
+// Package clientip determines the real client IP of a request, honouring
+// X-Forwarded-For and Forwarded only when they were set by a trusted proxy.
+// Everything that needs the client address (access logs, rate limiting,
+// audit logs) should read it from here rather than from the headers.
+package clientip
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+type contextKey struct{}
+
+// Resolver extracts client IPs using a list of trusted proxy networks.
+type Resolver struct {
+	trusted []netip.Prefix
+}
+
+// NewResolver creates a resolver trusting the given networks. Entries are
+// CIDRs or bare IPs; the aliases "loopback" and "private" expand to the
+// loopback and RFC 1918/4193 ranges.
+func NewResolver(trusted []string) (*Resolver, error) {
+	r := &Resolver{}
+	for _, entry := range trusted {
+		entry = strings.TrimSpace(entry)
+		switch entry {
+		case "":
+			continue
+		case "loopback":
+			r.trusted = append(r.trusted, netip.MustParsePrefix("127.0.0.0/8"), netip.MustParsePrefix("::1/128"))
+			continue
+		case "private":
+			r.trusted = append(r.trusted,
+				netip.MustParsePrefix("10.0.0.0/8"),
+				netip.MustParsePrefix("172.16.0.0/12"),
+				netip.MustParsePrefix("192.168.0.0/16"),
+				netip.MustParsePrefix("fc00::/7"))
+			continue
+		}
+		if !strings.Contains(entry, "/") {
+			addr, err := netip.ParseAddr(entry)
+			if err != nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q: %w", entry, err)
+			}
+			r.trusted = append(r.trusted, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", entry, err)
+		}
+		r.trusted = append(r.trusted, prefix.Masked())
+	}
+	return r, nil
+}
+
+// isTrusted reports whether addr belongs to a trusted proxy.
+func (r *Resolver) isTrusted(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	for _, prefix := range r.trusted {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// peerTrusted reports whether the direct peer is a trusted proxy. Peers on
+// a Unix socket have no IP; only a local process can connect there, which
+// is exactly the reverse proxy the socket exists for.
+func (r *Resolver) peerTrusted(remoteAddr string) (netip.Addr, bool) {
+	addr, ok := parseHostPort(remoteAddr)
+	if !ok {
+		return netip.Addr{}, true
+	}
+	return addr, r.isTrusted(addr)
+}
+
+// ClientIP returns the client address for a request. Forwarding headers
+// are walked right to left, skipping trusted proxies; the first untrusted
+// hop is the client. Anything further left could have been forged by it.
+func (r *Resolver) ClientIP(req *http.Request) string {
+	peer, trusted := r.peerTrusted(req.RemoteAddr)
+	if !trusted {
+		return peer.String()
+	}
+
+	hops := forwardedFor(req.Header)
+	for i := len(hops) - 1; i >= 0; i-- {
+		addr, ok := parseHostPort(hops[i])
+		if !ok {
+			// Obfuscated or malformed hop; we can't see past it
+			break
+		}
+		if !r.isTrusted(addr) || i == 0 {
+			return addr.String()
+		}
+	}
+	if peer.IsValid() {
+		return peer.String()
+	}
+	return req.RemoteAddr
+}
+
+// Middleware resolves the client IP once per request. It rewrites
+// RemoteAddr so downstream middleware like the access logger sees the real
+// client, stores the IP in the context, and drops X-Forwarded-Proto from
+// untrusted peers so it can't be spoofed.
+func (r *Resolver) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if _, trusted := r.peerTrusted(req.RemoteAddr); !trusted {
+			req.Header.Del("X-Forwarded-Proto")
+		}
+		ip := r.ClientIP(req)
+		req.RemoteAddr = net.JoinHostPort(ip, "0")
+		next.ServeHTTP(w, req.WithContext(context.WithValue(req.Context(), contextKey{}, ip)))
+	})
+}
+
+// FromRequest returns the client IP resolved by Middleware, falling back to
+// the peer address when the middleware didn't run.
+func FromRequest(req *http.Request) string {
+	if ip, ok := req.Context().Value(contextKey{}).(string); ok {
+		return ip
+	}
+	if addr, ok := parseHostPort(req.RemoteAddr); ok {
+		return addr.String()
+	}
+	return req.RemoteAddr
+}
+
+// forwardedFor lists the hops recorded by proxies, oldest first. The
+// standard Forwarded header wins over X-Forwarded-For when both are set.
+func forwardedFor(h http.Header) []string {
+	var hops []string
+	if values := h.Values("Forwarded"); len(values) > 0 {
+		for _, value := range values {
+			for _, element := range strings.Split(value, ",") {
+				for _, pair := range strings.Split(element, ";") {
+					key, val, ok := strings.Cut(strings.TrimSpace(pair), "=")
+					if ok && strings.EqualFold(key, "for") {
+						hops = append(hops, strings.Trim(val, `"`))
+					}
+				}
+			}
+		}
+		return hops
+	}
+	for _, value := range h.Values("X-Forwarded-For") {
+		for _, hop := range strings.Split(value, ",") {
+			if hop = strings.TrimSpace(hop); hop != "" {
+				hops = append(hops, hop)
+			}
+		}
+	}
+	return hops
+}
+
+// parseHostPort parses "ip", "ip:port", "[v6]" or "[v6]:port".
+func parseHostPort(s string) (netip.Addr, bool) {
+	if addrPort, err := netip.ParseAddrPort(s); err == nil {
+		return addrPort.Addr().Unmap(), true
+	}
+	addr, err := netip.ParseAddr(strings.TrimSuffix(strings.TrimPrefix(s, "["), "]"))
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return addr.Unmap(), true
+}
@@ -0,0 +1,83 @@
+package clientip
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+	resolver, err := NewResolver([]string{"10.0.0.0/8", "loopback"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name       string
+		remoteAddr string
+		header     http.Header
+		want       string
+	}{
+		{"direct client", "203.0.113.7:5000", nil, "203.0.113.7"},
+		{"untrusted peer can't spoof", "203.0.113.7:5000", http.Header{"X-Forwarded-For": {"1.2.3.4"}}, "203.0.113.7"},
+		{"trusted proxy", "10.0.0.2:5000", http.Header{"X-Forwarded-For": {"198.51.100.9"}}, "198.51.100.9"},
+		{"skips trusted hops, ignores forged prefix", "10.0.0.2:5000", http.Header{"X-Forwarded-For": {"6.6.6.6, 198.51.100.9, 10.0.0.5"}}, "198.51.100.9"},
+		{"multiple header lines", "127.0.0.1:5000", http.Header{"X-Forwarded-For": {"198.51.100.9", "10.0.0.5"}}, "198.51.100.9"},
+		{"forwarded header wins", "10.0.0.2:5000", http.Header{
+			"Forwarded":       {`for="[2001:db8::1]:4711";proto=https, for=10.0.0.5`},
+			"X-Forwarded-For": {"1.2.3.4"},
+		}, "2001:db8::1"},
+		{"obfuscated hop stops the walk", "10.0.0.2:5000", http.Header{"Forwarded": {"for=_hidden"}}, "10.0.0.2"},
+		{"all hops trusted", "10.0.0.2:5000", http.Header{"X-Forwarded-For": {"10.0.0.9"}}, "10.0.0.9"},
+		{"unix socket peer is trusted", "@", http.Header{"X-Forwarded-For": {"198.51.100.9"}}, "198.51.100.9"},
+		{"ipv4-mapped peer", "[::ffff:10.0.0.2]:5000", http.Header{"X-Forwarded-For": {"198.51.100.9"}}, "198.51.100.9"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tc.remoteAddr
+			req.Header = tc.header
+			if req.Header == nil {
+				req.Header = http.Header{}
+			}
+			if got := resolver.ClientIP(req); got != tc.want {
+				t.Errorf("ClientIP = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	resolver, _ := NewResolver([]string{"10.0.0.1"})
+
+	var gotIP, gotRemote, gotProto string
+	handler := resolver.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotIP, gotRemote, gotProto = FromRequest(r), r.RemoteAddr, r.Header.Get("X-Forwarded-Proto")
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	req.Header.Set("X-Forwarded-For", "198.51.100.9")
+	req.Header.Set("X-Forwarded-Proto", "https")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	if gotIP != "198.51.100.9" || gotRemote != "198.51.100.9:0" || gotProto != "https" {
+		t.Errorf("trusted proxy: got ip=%q remote=%q proto=%q", gotIP, gotRemote, gotProto)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "10.0.0.2:1234"
+	req.Header.Set("X-Forwarded-Proto", "https")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	if gotIP != "10.0.0.2" || gotProto != "" {
+		t.Errorf("untrusted peer: got ip=%q proto=%q", gotIP, gotProto)
+	}
+}
+
+func TestNewResolverRejectsGarbage(t *testing.T) {
+	if _, err := NewResolver([]string{"10.0.0.0/33"}); err == nil {
+		t.Error("expected error for invalid CIDR")
+	}
+	if _, err := NewResolver([]string{"proxy.local"}); err == nil {
+		t.Error("expected error for hostname")
+	}
+}
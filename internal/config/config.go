@@ -0,0 +1,745 @@
+// Package config loads the API's core settings: the database, the HTTP
+// listener and its timeouts, graceful shutdown, CORS, the shape and limits
+// of responses, authentication with its secrets and sign-in providers, the
+// GitHub and Google OAuth apps, how long todo state like presence is kept,
+// and the development modes. They come from the
+// defaults, then an optional YAML or TOML file named by CONFIG_FILE, then
+// the environment, so a deployment can keep a file and still override a
+// value or two. Everything is checked when the process starts, and every
+// problem is reported at once.
+//
+// Other optional integrations (SMTP, S3, Redis, ...) keep reading their own
+// environment variables where they're set up.
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+
+	"github.com/Tomlord1122/todo-backend/internal/clientip"
+	"github.com/Tomlord1122/todo-backend/internal/database"
+	"github.com/Tomlord1122/todo-backend/internal/gcal"
+	"github.com/Tomlord1122/todo-backend/internal/github"
+	"github.com/Tomlord1122/todo-backend/internal/jwt"
+	"github.com/Tomlord1122/todo-backend/internal/oidc"
+	"github.com/Tomlord1122/todo-backend/internal/pagination"
+	"github.com/Tomlord1122/todo-backend/internal/webauthn"
+)
+
+// Config is the API's core settings. The environment variable overriding
+// each is given in its comment.
+type Config struct {
+	// Port is the HTTP API's TCP port, or "off" to listen on a Unix socket
+	// or systemd sockets only (PORT)
+	Port         string       `yaml:"port" toml:"port"`
+	Database     Database     `yaml:"database" toml:"database"`
+	HTTP         HTTP         `yaml:"http" toml:"http"`
+	Shutdown     Shutdown     `yaml:"shutdown" toml:"shutdown"`
+	CORS         CORS         `yaml:"cors" toml:"cors"`
+	API          API          `yaml:"api" toml:"api"`
+	Pagination   Pagination   `yaml:"pagination" toml:"pagination"`
+	Auth         Auth         `yaml:"auth" toml:"auth"`
+	Integrations Integrations `yaml:"integrations" toml:"integrations"`
+	Todos        Todos        `yaml:"todos" toml:"todos"`
+	Dev          Dev          `yaml:"dev" toml:"dev"`
+}
+
+// Database is where the data is stored.
+type Database struct {
+	// Driver is postgres or mysql, which also covers MariaDB
+	// (BLUEPRINT_DB_DRIVER)
+	Driver string `yaml:"driver" toml:"driver"`
+	// Host, Port, Name, Username and Password locate the primary
+	// (BLUEPRINT_DB_HOST, _PORT, _DATABASE, _USERNAME, _PASSWORD). The
+	// port defaults to the driver's.
+	Host     string `yaml:"host" toml:"host"`
+	Port     string `yaml:"port" toml:"port"`
+	Name     string `yaml:"name" toml:"name"`
+	Username string `yaml:"username" toml:"username"`
+	Password string `yaml:"password" toml:"password"`
+	// Schema is the Postgres schema (BLUEPRINT_DB_SCHEMA)
+	Schema string `yaml:"schema" toml:"schema"`
+	// ReplicaHosts are read replicas as host or host:port
+	// (BLUEPRINT_DB_REPLICA_HOSTS, comma-separated)
+	ReplicaHosts []string `yaml:"replica_hosts" toml:"replica_hosts"`
+	// SlowQueryThreshold is when statements are logged as slow
+	// (DB_SLOW_QUERY_THRESHOLD)
+	SlowQueryThreshold time.Duration `yaml:"slow_query_threshold" toml:"slow_query_threshold"`
+	// MigrateOnStart applies pending migrations when the API starts
+	// (MIGRATE_ON_START)
+	MigrateOnStart bool `yaml:"migrate_on_start" toml:"migrate_on_start"`
+	// MigrationLockTimeout is how long to wait for another instance to
+	// finish migrating (MIGRATION_LOCK_TIMEOUT)
+	MigrationLockTimeout time.Duration `yaml:"migration_lock_timeout" toml:"migration_lock_timeout"`
+}
+
+// HTTP is the HTTP server's timeouts and the proxies in front of it.
+type HTTP struct {
+	// ReadTimeout bounds reading a request (HTTP_READ_TIMEOUT)
+	ReadTimeout time.Duration `yaml:"read_timeout" toml:"read_timeout"`
+	// WriteTimeout bounds writing a response (HTTP_WRITE_TIMEOUT)
+	WriteTimeout time.Duration `yaml:"write_timeout" toml:"write_timeout"`
+	// IdleTimeout closes keep-alive connections left idle (HTTP_IDLE_TIMEOUT)
+	IdleTimeout time.Duration `yaml:"idle_timeout" toml:"idle_timeout"`
+	// TrustedProxies are the reverse proxies whose forwarding headers name
+	// the client, as CIDRs, IPs or the aliases loopback and private; none
+	// ignores the headers (TRUSTED_PROXIES, comma-separated)
+	TrustedProxies []string `yaml:"trusted_proxies" toml:"trusted_proxies"`
+}
+
+// Shutdown is how the API stops on SIGTERM: new requests get 503 for
+// DrainDelay while load balancers take the instance out, then the servers
+// wait up to Timeout for in-flight requests before the workers, event
+// publisher and database stop, each also within Timeout.
+type Shutdown struct {
+	// Timeout bounds draining in-flight requests and each later step of
+	// the shutdown (SHUTDOWN_TIMEOUT)
+	Timeout time.Duration `yaml:"timeout" toml:"timeout"`
+	// DrainDelay is how long new requests get 503 before the listeners
+	// close; zero closes them at once (SHUTDOWN_DRAIN_DELAY)
+	DrainDelay time.Duration `yaml:"drain_delay" toml:"drain_delay"`
+}
+
+// CORS is which web pages may call the API.
+type CORS struct {
+	// AllowedOrigins are origins like https://app.example.com, with * as a
+	// wildcard (CORS_ALLOWED_ORIGINS, comma-separated)
+	AllowedOrigins []string `yaml:"allowed_origins" toml:"allowed_origins"`
+}
+
+// API is how responses are shaped and which paths serve them.
+type API struct {
+	// Envelope wraps every JSON response as {"data", "meta", "errors"}
+	// (RESPONSE_ENVELOPE)
+	Envelope bool `yaml:"envelope" toml:"envelope"`
+	// LegacyRoutes serves the unversioned paths as deprecated aliases of
+	// /api/v1 (API_LEGACY_ROUTES)
+	LegacyRoutes bool `yaml:"legacy_routes" toml:"legacy_routes"`
+}
+
+// Pagination is the listing and export limits.
+type Pagination struct {
+	// DefaultPageSize applies when ?limit= is omitted (PAGE_SIZE_DEFAULT)
+	DefaultPageSize int `yaml:"default_page_size" toml:"default_page_size"`
+	// MaxPageSize is the largest ?limit= allowed (PAGE_SIZE_MAX)
+	MaxPageSize int `yaml:"max_page_size" toml:"max_page_size"`
+	// MaxExportSize is the most rows an export may contain
+	// (EXPORT_MAX_ROWS)
+	MaxExportSize int `yaml:"max_export_size" toml:"max_export_size"`
+}
+
+// Auth is the secrets signing tokens and guarding the admin API, and how
+// long sign-ins last.
+type Auth struct {
+	// JWTSecret signs access tokens; empty picks a random one per process
+	// (JWT_SECRET)
+	JWTSecret string `yaml:"jwt_secret" toml:"jwt_secret"`
+	// JWTTTL is how long access tokens are valid (JWT_TTL)
+	JWTTTL time.Duration `yaml:"jwt_ttl" toml:"jwt_ttl"`
+	// SessionTTL is how long a passkey or single sign-on session lasts
+	// (SESSION_TTL)
+	SessionTTL time.Duration `yaml:"session_ttl" toml:"session_ttl"`
+	// AdminToken enables the admin API for requests bearing it
+	// (ADMIN_TOKEN)
+	AdminToken string `yaml:"admin_token" toml:"admin_token"`
+	// WebAuthn is passkey login
+	WebAuthn WebAuthn `yaml:"webauthn" toml:"webauthn"`
+	// OIDC are the single sign-on providers (OIDC_PROVIDERS, a
+	// comma-separated list of names; each NAME's settings are then read
+	// from OIDC_<NAME>_*, upper-cased with dashes turned into underscores)
+	OIDC []OIDCProvider `yaml:"oidc" toml:"oidc"`
+}
+
+// WebAuthn is the site passkeys are bound to.
+type WebAuthn struct {
+	// RPID is the domain passkeys are bound to; empty disables passkey
+	// login (WEBAUTHN_RP_ID)
+	RPID string `yaml:"rp_id" toml:"rp_id"`
+	// RPName is the site name shown by authenticators (WEBAUTHN_RP_NAME)
+	RPName string `yaml:"rp_name" toml:"rp_name"`
+	// Origins are the web origins using the passkeys; none means
+	// https://<RPID> (WEBAUTHN_ORIGINS, comma-separated)
+	Origins []string `yaml:"origins" toml:"origins"`
+}
+
+// OIDCProvider is an OpenID Connect provider users can sign in with. The
+// environment variable of each setting is OIDC_<NAME>_ and the suffix
+// given in its comment.
+type OIDCProvider struct {
+	// Name identifies the provider in URLs, e.g. okta
+	Name string `yaml:"name" toml:"name"`
+	// DisplayName is shown on the login button; default Name
+	// (DISPLAY_NAME)
+	DisplayName string `yaml:"display_name" toml:"display_name"`
+	// Issuer, ClientID, ClientSecret and RedirectURL are required (ISSUER,
+	// CLIENT_ID, CLIENT_SECRET, REDIRECT_URL)
+	Issuer       string `yaml:"issuer" toml:"issuer"`
+	ClientID     string `yaml:"client_id" toml:"client_id"`
+	ClientSecret string `yaml:"client_secret" toml:"client_secret"`
+	RedirectURL  string `yaml:"redirect_url" toml:"redirect_url"`
+	// Scopes default to openid, email and profile (SCOPES, separated by
+	// spaces or commas)
+	Scopes []string `yaml:"scopes" toml:"scopes"`
+	// ClaimSubject, ClaimEmail and ClaimName name the ID token claims
+	// identifying the user, default sub, email and name; ClaimUserID, if
+	// set, holds the user ID to link on first login (CLAIM_SUBJECT,
+	// CLAIM_EMAIL, CLAIM_NAME, CLAIM_USER_ID)
+	ClaimSubject string `yaml:"claim_subject" toml:"claim_subject"`
+	ClaimEmail   string `yaml:"claim_email" toml:"claim_email"`
+	ClaimName    string `yaml:"claim_name" toml:"claim_name"`
+	ClaimUserID  string `yaml:"claim_user_id" toml:"claim_user_id"`
+}
+
+// Integrations are the OAuth apps of the services todos sync with. Each is
+// disabled unless its client ID and secret are set.
+type Integrations struct {
+	GitHub GitHub `yaml:"github" toml:"github"`
+	Google Google `yaml:"google" toml:"google"`
+}
+
+// GitHub is the OAuth app behind GitHub issue sync.
+type GitHub struct {
+	// ClientID and ClientSecret identify the OAuth app
+	// (GITHUB_CLIENT_ID, GITHUB_CLIENT_SECRET)
+	ClientID     string `yaml:"client_id" toml:"client_id"`
+	ClientSecret string `yaml:"client_secret" toml:"client_secret"`
+	// APIURL and TokenURL are the REST API root and OAuth token endpoint,
+	// e.g. for GitHub Enterprise (GITHUB_API_URL, GITHUB_TOKEN_URL)
+	APIURL   string `yaml:"api_url" toml:"api_url"`
+	TokenURL string `yaml:"token_url" toml:"token_url"`
+	// WebhookSecret verifies issue webhooks, which are rejected without
+	// it (GITHUB_WEBHOOK_SECRET)
+	WebhookSecret string `yaml:"webhook_secret" toml:"webhook_secret"`
+}
+
+// Google is the OAuth client behind Google Calendar sync.
+type Google struct {
+	// ClientID and ClientSecret identify the OAuth client
+	// (GOOGLE_CLIENT_ID, GOOGLE_CLIENT_SECRET)
+	ClientID     string `yaml:"client_id" toml:"client_id"`
+	ClientSecret string `yaml:"client_secret" toml:"client_secret"`
+}
+
+// Todos is how long todo state is kept and how search matches.
+type Todos struct {
+	// IdempotencyKeyTTL is how long POST /todos remembers the response to
+	// an Idempotency-Key (IDEMPOTENCY_KEY_TTL)
+	IdempotencyKeyTTL time.Duration `yaml:"idempotency_key_ttl" toml:"idempotency_key_ttl"`
+	// PresenceTTL is how long a heartbeat keeps a user shown as viewing or
+	// editing (PRESENCE_TTL)
+	PresenceTTL time.Duration `yaml:"presence_ttl" toml:"presence_ttl"`
+	// FuzzySearchThreshold is the default minimum similarity, 0 to 1, of
+	// fuzzy search (SEARCH_FUZZY_THRESHOLD)
+	FuzzySearchThreshold float64 `yaml:"fuzzy_search_threshold" toml:"fuzzy_search_threshold"`
+}
+
+// Dev is the modes for development and demos.
+type Dev struct {
+	// DemoMode runs on seeded in-memory data without a database or other
+	// external services, like serve --demo (DEMO_MODE)
+	DemoMode bool `yaml:"demo_mode" toml:"demo_mode"`
+	// Fixtures enables POST /dev/fixtures, which wipes all data; demo mode
+	// always enables it (DEV_FIXTURES)
+	Fixtures bool `yaml:"fixtures" toml:"fixtures"`
+}
+
+// Default returns the settings used for whatever neither the file nor the
+// environment sets.
+func Default() Config {
+	return Config{
+		Database: Database{
+			Driver:               database.DriverPostgres,
+			SlowQueryThreshold:   time.Second,
+			MigrateOnStart:       true,
+			MigrationLockTimeout: 5 * time.Minute,
+		},
+		HTTP: HTTP{
+			ReadTimeout:  10 * time.Second,
+			WriteTimeout: 30 * time.Second,
+			IdleTimeout:  time.Minute,
+		},
+		Shutdown:   Shutdown{Timeout: 5 * time.Second},
+		CORS:       CORS{AllowedOrigins: []string{"https://*", "http://*"}},
+		API:        API{LegacyRoutes: true},
+		Pagination: Pagination{DefaultPageSize: 50, MaxPageSize: 200, MaxExportSize: 5000},
+		Auth: Auth{
+			JWTTTL:     time.Hour,
+			SessionTTL: 30 * 24 * time.Hour,
+			WebAuthn:   WebAuthn{RPName: "Todo"},
+		},
+		Integrations: Integrations{
+			GitHub: GitHub{APIURL: "https://api.github.com", TokenURL: "https://github.com/login/oauth/access_token"},
+		},
+		Todos: Todos{IdempotencyKeyTTL: 24 * time.Hour, PresenceTTL: 30 * time.Second, FuzzySearchThreshold: 0.3},
+	}
+}
+
+// Load reads the file named by CONFIG_FILE, if any, over the defaults, then
+// the environment over both, and checks the result.
+func Load() (Config, error) {
+	return LoadFile(os.Getenv("CONFIG_FILE"))
+}
+
+// LoadFile is Load with the file given; an empty path reads none.
+func LoadFile(path string) (Config, error) {
+	cfg := Default()
+	if path != "" {
+		if err := cfg.readFile(path); err != nil {
+			return cfg, err
+		}
+	}
+	var errs []error
+	errs = append(errs, cfg.readEnv()...)
+	if cfg.Database.Port == "" {
+		cfg.Database.Port = defaultPorts[cfg.Database.Driver]
+	}
+	errs = append(errs, cfg.validate()...)
+	if len(errs) > 0 {
+		// One line, as log lines are structured
+		problems := make([]string, len(errs))
+		for i, err := range errs {
+			problems[i] = err.Error()
+		}
+		return cfg, fmt.Errorf("invalid configuration: %s", strings.Join(problems, "; "))
+	}
+	return cfg, nil
+}
+
+// defaultPorts are the database ports by driver.
+var defaultPorts = map[string]string{
+	database.DriverPostgres: "5432",
+	database.DriverMySQL:    "3306",
+}
+
+// readFile decodes path over c, as YAML or TOML by its extension. Unknown
+// keys are errors, since they're usually typos.
+func (c *Config) readFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading the config file: %w", err)
+	}
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err := dec.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("reading the config file %s: %w", path, err)
+		}
+	case ".toml":
+		meta, err := toml.Decode(string(data), c)
+		if err != nil {
+			return fmt.Errorf("reading the config file %s: %w", path, err)
+		}
+		if undecoded := meta.Undecoded(); len(undecoded) > 0 {
+			return fmt.Errorf("reading the config file %s: unknown keys %v", path, undecoded)
+		}
+	default:
+		return fmt.Errorf("unsupported config file %s, expected .yaml, .yml or .toml", path)
+	}
+	return nil
+}
+
+// readEnv applies the environment variables that are set and not empty.
+func (c *Config) readEnv() []error {
+	var e envReader
+	e.string("PORT", &c.Port)
+	d := &c.Database
+	e.string("BLUEPRINT_DB_DRIVER", &d.Driver)
+	e.string("BLUEPRINT_DB_HOST", &d.Host)
+	e.string("BLUEPRINT_DB_PORT", &d.Port)
+	e.string("BLUEPRINT_DB_DATABASE", &d.Name)
+	e.string("BLUEPRINT_DB_USERNAME", &d.Username)
+	e.string("BLUEPRINT_DB_PASSWORD", &d.Password)
+	e.string("BLUEPRINT_DB_SCHEMA", &d.Schema)
+	e.list("BLUEPRINT_DB_REPLICA_HOSTS", &d.ReplicaHosts)
+	e.duration("DB_SLOW_QUERY_THRESHOLD", &d.SlowQueryThreshold)
+	e.bool("MIGRATE_ON_START", &d.MigrateOnStart)
+	e.duration("MIGRATION_LOCK_TIMEOUT", &d.MigrationLockTimeout)
+	e.duration("HTTP_READ_TIMEOUT", &c.HTTP.ReadTimeout)
+	e.duration("HTTP_WRITE_TIMEOUT", &c.HTTP.WriteTimeout)
+	e.duration("HTTP_IDLE_TIMEOUT", &c.HTTP.IdleTimeout)
+	e.list("TRUSTED_PROXIES", &c.HTTP.TrustedProxies)
+	e.duration("SHUTDOWN_TIMEOUT", &c.Shutdown.Timeout)
+	e.duration("SHUTDOWN_DRAIN_DELAY", &c.Shutdown.DrainDelay)
+	e.list("CORS_ALLOWED_ORIGINS", &c.CORS.AllowedOrigins)
+	e.bool("RESPONSE_ENVELOPE", &c.API.Envelope)
+	e.bool("API_LEGACY_ROUTES", &c.API.LegacyRoutes)
+	e.int("PAGE_SIZE_DEFAULT", &c.Pagination.DefaultPageSize)
+	e.int("PAGE_SIZE_MAX", &c.Pagination.MaxPageSize)
+	e.int("EXPORT_MAX_ROWS", &c.Pagination.MaxExportSize)
+	e.string("JWT_SECRET", &c.Auth.JWTSecret)
+	e.duration("JWT_TTL", &c.Auth.JWTTTL)
+	e.duration("SESSION_TTL", &c.Auth.SessionTTL)
+	e.string("ADMIN_TOKEN", &c.Auth.AdminToken)
+	w := &c.Auth.WebAuthn
+	e.string("WEBAUTHN_RP_ID", &w.RPID)
+	e.string("WEBAUTHN_RP_NAME", &w.RPName)
+	e.list("WEBAUTHN_ORIGINS", &w.Origins)
+	c.readOIDCEnv(&e)
+	g := &c.Integrations.GitHub
+	e.string("GITHUB_CLIENT_ID", &g.ClientID)
+	e.string("GITHUB_CLIENT_SECRET", &g.ClientSecret)
+	e.string("GITHUB_API_URL", &g.APIURL)
+	e.string("GITHUB_TOKEN_URL", &g.TokenURL)
+	e.string("GITHUB_WEBHOOK_SECRET", &g.WebhookSecret)
+	e.string("GOOGLE_CLIENT_ID", &c.Integrations.Google.ClientID)
+	e.string("GOOGLE_CLIENT_SECRET", &c.Integrations.Google.ClientSecret)
+	e.duration("IDEMPOTENCY_KEY_TTL", &c.Todos.IdempotencyKeyTTL)
+	e.duration("PRESENCE_TTL", &c.Todos.PresenceTTL)
+	e.float("SEARCH_FUZZY_THRESHOLD", &c.Todos.FuzzySearchThreshold)
+	e.bool("DEMO_MODE", &c.Dev.DemoMode)
+	e.bool("DEV_FIXTURES", &c.Dev.Fixtures)
+	return e.errs
+}
+
+// readOIDCEnv applies OIDC_PROVIDERS, keeping the file's settings of the
+// providers it lists, then each provider's OIDC_<NAME>_* variables.
+func (c *Config) readOIDCEnv(e *envReader) {
+	var names []string
+	e.list("OIDC_PROVIDERS", &names)
+	if names != nil {
+		providers := make([]OIDCProvider, 0, len(names))
+		for _, name := range names {
+			provider := OIDCProvider{Name: strings.ToLower(name)}
+			for _, fromFile := range c.Auth.OIDC {
+				if fromFile.Name == provider.Name {
+					provider = fromFile
+				}
+			}
+			providers = append(providers, provider)
+		}
+		c.Auth.OIDC = providers
+	}
+	for i := range c.Auth.OIDC {
+		p := &c.Auth.OIDC[i]
+		prefix := oidcEnvPrefix(p.Name)
+		e.string(prefix+"DISPLAY_NAME", &p.DisplayName)
+		e.string(prefix+"ISSUER", &p.Issuer)
+		e.string(prefix+"CLIENT_ID", &p.ClientID)
+		e.string(prefix+"CLIENT_SECRET", &p.ClientSecret)
+		e.string(prefix+"REDIRECT_URL", &p.RedirectURL)
+		if v := os.Getenv(prefix + "SCOPES"); v != "" {
+			p.Scopes = strings.Fields(strings.ReplaceAll(v, ",", " "))
+		}
+		e.string(prefix+"CLAIM_SUBJECT", &p.ClaimSubject)
+		e.string(prefix+"CLAIM_EMAIL", &p.ClaimEmail)
+		e.string(prefix+"CLAIM_NAME", &p.ClaimName)
+		e.string(prefix+"CLAIM_USER_ID", &p.ClaimUserID)
+	}
+}
+
+// oidcEnvPrefix is the prefix of the environment variables of the
+// provider name.
+func oidcEnvPrefix(name string) string {
+	return "OIDC_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+}
+
+// validate returns every problem with c, other than a missing database.
+func (c *Config) validate() []error {
+	var errs []error
+	if c.Port != "" && c.Port != "off" {
+		if p, err := strconv.Atoi(c.Port); err != nil || p < 1 || p > 65535 {
+			errs = append(errs, fmt.Errorf("port %q is not a port number or off", c.Port))
+		}
+	}
+	d := c.Database
+	if _, ok := defaultPorts[d.Driver]; !ok {
+		errs = append(errs, fmt.Errorf("database driver %q is not %s or %s", d.Driver, database.DriverPostgres, database.DriverMySQL))
+	}
+	if _, err := database.ReplicaConfigs(database.ConnConfig{Port: d.Port}, d.ReplicaHosts); err != nil {
+		errs = append(errs, err)
+	}
+	durations := []struct {
+		name string
+		d    time.Duration
+	}{
+		{"database slow query threshold", d.SlowQueryThreshold},
+		{"database migration lock timeout", d.MigrationLockTimeout},
+		{"HTTP read timeout", c.HTTP.ReadTimeout},
+		{"HTTP write timeout", c.HTTP.WriteTimeout},
+		{"HTTP idle timeout", c.HTTP.IdleTimeout},
+		{"shutdown timeout", c.Shutdown.Timeout},
+		{"JWT TTL", c.Auth.JWTTTL},
+		{"session TTL", c.Auth.SessionTTL},
+		{"idempotency key TTL", c.Todos.IdempotencyKeyTTL},
+		{"presence TTL", c.Todos.PresenceTTL},
+	}
+	for _, duration := range durations {
+		if duration.d <= 0 {
+			errs = append(errs, fmt.Errorf("%s must be positive, got %s", duration.name, duration.d))
+		}
+	}
+	if c.Shutdown.DrainDelay < 0 {
+		errs = append(errs, fmt.Errorf("shutdown drain delay must not be negative, got %s", c.Shutdown.DrainDelay))
+	}
+	if _, err := clientip.NewResolver(c.HTTP.TrustedProxies); err != nil {
+		errs = append(errs, err)
+	}
+	pages := c.Pagination
+	sizes := []struct {
+		name string
+		n    int
+	}{
+		{"default page size", pages.DefaultPageSize},
+		{"maximum page size", pages.MaxPageSize},
+		{"maximum export size", pages.MaxExportSize},
+	}
+	for _, size := range sizes {
+		if size.n < 1 {
+			errs = append(errs, fmt.Errorf("%s must be positive, got %d", size.name, size.n))
+		}
+	}
+	if pages.DefaultPageSize > pages.MaxPageSize {
+		errs = append(errs, fmt.Errorf("default page size %d is larger than the maximum page size %d", pages.DefaultPageSize, pages.MaxPageSize))
+	}
+	if len(c.CORS.AllowedOrigins) == 0 {
+		errs = append(errs, errors.New("CORS allowed origins must not be empty"))
+	}
+	for _, origin := range c.CORS.AllowedOrigins {
+		if origin != "*" && !strings.HasPrefix(origin, "https://") && !strings.HasPrefix(origin, "http://") {
+			errs = append(errs, fmt.Errorf("CORS origin %q must start with https:// or http://", origin))
+		}
+	}
+	if c.Auth.JWTSecret != "" && len(c.Auth.JWTSecret) < jwt.MinKeyLength {
+		errs = append(errs, fmt.Errorf("JWT secret must be at least %d bytes", jwt.MinKeyLength))
+	}
+	errs = append(errs, c.validateOIDC()...)
+	if t := c.Todos.FuzzySearchThreshold; t < 0 || t > 1 {
+		errs = append(errs, fmt.Errorf("fuzzy search threshold must be between 0 and 1, got %g", t))
+	}
+	return errs
+}
+
+// validateOIDC checks the single sign-on providers' names and required
+// settings.
+func (c *Config) validateOIDC() []error {
+	var errs []error
+	seen := map[string]bool{}
+	for _, p := range c.Auth.OIDC {
+		if !oidc.ValidProviderName(p.Name) {
+			errs = append(errs, fmt.Errorf("single sign-on provider name %q must be lower-case letters, digits and dashes", p.Name))
+			continue
+		}
+		if seen[p.Name] {
+			errs = append(errs, fmt.Errorf("single sign-on provider %q is listed twice", p.Name))
+		}
+		seen[p.Name] = true
+		prefix := oidcEnvPrefix(p.Name)
+		for _, setting := range []struct{ key, value string }{
+			{"ISSUER", p.Issuer},
+			{"CLIENT_ID", p.ClientID},
+			{"CLIENT_SECRET", p.ClientSecret},
+			{"REDIRECT_URL", p.RedirectURL},
+		} {
+			if setting.value == "" {
+				errs = append(errs, fmt.Errorf("single sign-on provider %q needs %s%s", p.Name, prefix, setting.key))
+			}
+		}
+	}
+	return errs
+}
+
+// RequireDatabase reports the database settings that are missing. Only
+// commands that connect need them, so Load doesn't check.
+func (c Config) RequireDatabase() error {
+	d := c.Database
+	var missing []string
+	for _, setting := range []struct{ env, value string }{
+		{"BLUEPRINT_DB_HOST", d.Host},
+		{"BLUEPRINT_DB_DATABASE", d.Name},
+		{"BLUEPRINT_DB_USERNAME", d.Username},
+	} {
+		if setting.value == "" {
+			missing = append(missing, setting.env)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("missing database settings: set %s, or the database section of the config file", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// DatabaseConfig returns how database.New connects. Call it on a Config
+// from Load, which has checked the replica hosts.
+func (c Config) DatabaseConfig() database.Config {
+	d := c.Database
+	primary := database.ConnConfig{Host: d.Host, Port: d.Port, Username: d.Username, Password: d.Password, Database: d.Name}
+	replicas, _ := database.ReplicaConfigs(primary, d.ReplicaHosts)
+	return database.Config{
+		Driver:             d.Driver,
+		Primary:            primary,
+		Replicas:           replicas,
+		SlowQueryThreshold: d.SlowQueryThreshold,
+	}
+}
+
+// ClientIPResolver returns the resolver trusting the configured proxies.
+// Call it on a Config from Load, which has checked them.
+func (c Config) ClientIPResolver() *clientip.Resolver {
+	resolver, _ := clientip.NewResolver(c.HTTP.TrustedProxies)
+	return resolver
+}
+
+// PaginationConfig returns the listing and export limits.
+func (c Config) PaginationConfig() pagination.Config {
+	p := c.Pagination
+	return pagination.Config{DefaultPageSize: p.DefaultPageSize, MaxPageSize: p.MaxPageSize, MaxExportSize: p.MaxExportSize}
+}
+
+// WebAuthnConfig returns the passkey settings. ok is false when no RPID is
+// set, meaning passkey login is disabled.
+func (c Config) WebAuthnConfig() (cfg webauthn.Config, ok bool) {
+	w := c.Auth.WebAuthn
+	cfg = webauthn.Config{RPID: w.RPID, RPName: w.RPName}
+	for _, origin := range w.Origins {
+		cfg.Origins = append(cfg.Origins, strings.TrimSuffix(origin, "/"))
+	}
+	if len(cfg.Origins) == 0 && cfg.RPID != "" {
+		cfg.Origins = []string{"https://" + cfg.RPID}
+	}
+	return cfg, cfg.RPID != ""
+}
+
+// OIDCConfigs returns the single sign-on providers, with the defaults
+// filled in. Call it on a Config from Load, which has checked them.
+func (c Config) OIDCConfigs() []oidc.Config {
+	configs := make([]oidc.Config, 0, len(c.Auth.OIDC))
+	for _, p := range c.Auth.OIDC {
+		or := func(value, fallback string) string {
+			if value != "" {
+				return value
+			}
+			return fallback
+		}
+		scopes := p.Scopes
+		if len(scopes) == 0 {
+			scopes = []string{"openid", "email", "profile"}
+		}
+		configs = append(configs, oidc.Config{
+			Name:         p.Name,
+			DisplayName:  or(p.DisplayName, p.Name),
+			Issuer:       strings.TrimSuffix(p.Issuer, "/"),
+			ClientID:     p.ClientID,
+			ClientSecret: p.ClientSecret,
+			RedirectURL:  p.RedirectURL,
+			Scopes:       scopes,
+			Claims: oidc.ClaimMapping{
+				Subject: or(p.ClaimSubject, "sub"),
+				Email:   or(p.ClaimEmail, "email"),
+				Name:    or(p.ClaimName, "name"),
+				UserID:  p.ClaimUserID,
+			},
+		})
+	}
+	return configs
+}
+
+// GitHubConfig returns the GitHub OAuth app. ok is false when its client ID
+// or secret is missing, meaning issue sync is disabled.
+func (c Config) GitHubConfig() (cfg github.Config, ok bool) {
+	g := c.Integrations.GitHub
+	cfg = github.Config{
+		ClientID:      g.ClientID,
+		ClientSecret:  g.ClientSecret,
+		APIURL:        strings.TrimRight(g.APIURL, "/"),
+		TokenURL:      g.TokenURL,
+		WebhookSecret: g.WebhookSecret,
+	}
+	return cfg, cfg.ClientID != "" && cfg.ClientSecret != ""
+}
+
+// GoogleConfig returns the Google OAuth client. ok is false when its ID or
+// secret is missing, meaning calendar sync is disabled.
+func (c Config) GoogleConfig() (cfg gcal.Config, ok bool) {
+	g := c.Integrations.Google
+	cfg = gcal.Config{ClientID: g.ClientID, ClientSecret: g.ClientSecret, TokenURL: gcal.TokenURL, APIURL: gcal.APIURL}
+	return cfg, cfg.ClientID != "" && cfg.ClientSecret != ""
+}
+
+// envReader parses environment variables into settings, collecting the
+// errors.
+type envReader struct {
+	errs []error
+}
+
+func (e *envReader) string(name string, dst *string) {
+	if v := os.Getenv(name); v != "" {
+		*dst = v
+	}
+}
+
+func (e *envReader) list(name string, dst *[]string) {
+	v := os.Getenv(name)
+	if v == "" {
+		return
+	}
+	var items []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	*dst = items
+}
+
+func (e *envReader) duration(name string, dst *time.Duration) {
+	v := os.Getenv(name)
+	if v == "" {
+		return
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		e.errs = append(e.errs, fmt.Errorf("%s %q is not a duration like 30s or 5m", name, v))
+		return
+	}
+	*dst = d
+}
+
+func (e *envReader) int(name string, dst *int) {
+	v := os.Getenv(name)
+	if v == "" {
+		return
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		e.errs = append(e.errs, fmt.Errorf("%s %q is not a whole number", name, v))
+		return
+	}
+	*dst = n
+}
+
+func (e *envReader) float(name string, dst *float64) {
+	v := os.Getenv(name)
+	if v == "" {
+		return
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		e.errs = append(e.errs, fmt.Errorf("%s %q is not a number", name, v))
+		return
+	}
+	*dst = f
+}
+
+func (e *envReader) bool(name string, dst *bool) {
+	v := os.Getenv(name)
+	if v == "" {
+		return
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		e.errs = append(e.errs, fmt.Errorf("%s %q is not true or false", name, v))
+		return
+	}
+	*dst = b
+}
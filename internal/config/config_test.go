@@ -0,0 +1,293 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// clearEnv empties every variable Load reads, for the test's duration.
+func clearEnv(t *testing.T) {
+	t.Helper()
+	for _, name := range []string{
+		"CONFIG_FILE", "PORT", "BLUEPRINT_DB_DRIVER", "BLUEPRINT_DB_HOST", "BLUEPRINT_DB_PORT",
+		"BLUEPRINT_DB_DATABASE", "BLUEPRINT_DB_USERNAME", "BLUEPRINT_DB_PASSWORD", "BLUEPRINT_DB_SCHEMA",
+		"BLUEPRINT_DB_REPLICA_HOSTS", "DB_SLOW_QUERY_THRESHOLD", "MIGRATE_ON_START", "MIGRATION_LOCK_TIMEOUT",
+		"HTTP_READ_TIMEOUT", "HTTP_WRITE_TIMEOUT", "HTTP_IDLE_TIMEOUT", "TRUSTED_PROXIES", "SHUTDOWN_TIMEOUT",
+		"SHUTDOWN_DRAIN_DELAY", "CORS_ALLOWED_ORIGINS", "RESPONSE_ENVELOPE", "API_LEGACY_ROUTES",
+		"PAGE_SIZE_DEFAULT", "PAGE_SIZE_MAX", "EXPORT_MAX_ROWS",
+		"JWT_SECRET", "JWT_TTL", "SESSION_TTL", "ADMIN_TOKEN",
+		"WEBAUTHN_RP_ID", "WEBAUTHN_RP_NAME", "WEBAUTHN_ORIGINS", "OIDC_PROVIDERS",
+		"GITHUB_CLIENT_ID", "GITHUB_CLIENT_SECRET", "GITHUB_API_URL", "GITHUB_TOKEN_URL", "GITHUB_WEBHOOK_SECRET",
+		"GOOGLE_CLIENT_ID", "GOOGLE_CLIENT_SECRET", "IDEMPOTENCY_KEY_TTL", "PRESENCE_TTL",
+		"SEARCH_FUZZY_THRESHOLD", "DEMO_MODE", "DEV_FIXTURES",
+	} {
+		t.Setenv(name, "")
+	}
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadDefaults(t *testing.T) {
+	clearEnv(t)
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Default()
+	want.Database.Port = "5432"
+	if !reflect.DeepEqual(cfg, want) {
+		t.Errorf("Load() = %+v, want the defaults %+v", cfg, want)
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	files := map[string]string{
+		"config.yaml": `
+port: "9090"
+database:
+  driver: mysql
+  host: db.internal
+  name: todos
+  username: app
+  replica_hosts: [replica-1, "replica-2:3307"]
+  migrate_on_start: false
+http:
+  write_timeout: 1m
+  trusted_proxies: [loopback]
+shutdown:
+  drain_delay: 5s
+cors:
+  allowed_origins: [https://app.example.com]
+api:
+  legacy_routes: false
+pagination:
+  max_page_size: 100
+auth:
+  jwt_ttl: 15m
+  webauthn:
+    rp_id: todo.example.com
+integrations:
+  github:
+    client_id: gh-id
+    client_secret: gh-secret
+todos:
+  presence_ttl: 1m
+dev:
+  fixtures: true
+`,
+		"config.toml": `
+port = "9090"
+
+[database]
+driver = "mysql"
+host = "db.internal"
+name = "todos"
+username = "app"
+replica_hosts = ["replica-1", "replica-2:3307"]
+migrate_on_start = false
+
+[http]
+write_timeout = "1m"
+trusted_proxies = ["loopback"]
+
+[shutdown]
+drain_delay = "5s"
+
+[cors]
+allowed_origins = ["https://app.example.com"]
+
+[api]
+legacy_routes = false
+
+[pagination]
+max_page_size = 100
+
+[auth]
+jwt_ttl = "15m"
+
+[auth.webauthn]
+rp_id = "todo.example.com"
+
+[integrations.github]
+client_id = "gh-id"
+client_secret = "gh-secret"
+
+[todos]
+presence_ttl = "1m"
+
+[dev]
+fixtures = true
+`,
+	}
+	for name, content := range files {
+		t.Run(name, func(t *testing.T) {
+			clearEnv(t)
+			t.Setenv("CONFIG_FILE", writeFile(t, name, content))
+			// The environment wins over the file
+			t.Setenv("BLUEPRINT_DB_HOST", "db.override")
+			t.Setenv("SESSION_TTL", "24h")
+			t.Setenv("SEARCH_FUZZY_THRESHOLD", "0.5")
+
+			cfg, err := Load()
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := Default()
+			want.Port = "9090"
+			want.Database.Driver = "mysql"
+			want.Database.Host = "db.override"
+			want.Database.Port = "3306"
+			want.Database.Name = "todos"
+			want.Database.Username = "app"
+			want.Database.ReplicaHosts = []string{"replica-1", "replica-2:3307"}
+			want.Database.MigrateOnStart = false
+			want.HTTP.WriteTimeout = time.Minute
+			want.HTTP.TrustedProxies = []string{"loopback"}
+			want.Shutdown.DrainDelay = 5 * time.Second
+			want.CORS.AllowedOrigins = []string{"https://app.example.com"}
+			want.API.LegacyRoutes = false
+			want.Pagination.MaxPageSize = 100
+			want.Auth.JWTTTL = 15 * time.Minute
+			want.Auth.SessionTTL = 24 * time.Hour
+			want.Auth.WebAuthn.RPID = "todo.example.com"
+			want.Integrations.GitHub.ClientID = "gh-id"
+			want.Integrations.GitHub.ClientSecret = "gh-secret"
+			want.Todos.PresenceTTL = time.Minute
+			want.Todos.FuzzySearchThreshold = 0.5
+			want.Dev.Fixtures = true
+			if !reflect.DeepEqual(cfg, want) {
+				t.Errorf("Load() = %+v, want %+v", cfg, want)
+			}
+
+			db := cfg.DatabaseConfig()
+			if db.Primary.Host != "db.override" || len(db.Replicas) != 2 || db.Replicas[1].Port != "3307" {
+				t.Errorf("DatabaseConfig() = %+v", db)
+			}
+			if webAuthn, ok := cfg.WebAuthnConfig(); !ok || webAuthn.RPName != "Todo" || len(webAuthn.Origins) != 1 || webAuthn.Origins[0] != "https://todo.example.com" {
+				t.Errorf("WebAuthnConfig() = %+v, %t", webAuthn, ok)
+			}
+			if gitHub, ok := cfg.GitHubConfig(); !ok || gitHub.APIURL != "https://api.github.com" {
+				t.Errorf("GitHubConfig() = %+v, %t", gitHub, ok)
+			}
+			if _, ok := cfg.GoogleConfig(); ok {
+				t.Error("GoogleConfig() is enabled without a client")
+			}
+		})
+	}
+}
+
+func TestLoadReportsEveryProblem(t *testing.T) {
+	clearEnv(t)
+	t.Setenv("PORT", "http")
+	t.Setenv("BLUEPRINT_DB_DRIVER", "sqlite")
+	t.Setenv("HTTP_READ_TIMEOUT", "soon")
+	t.Setenv("JWT_SECRET", "short")
+	t.Setenv("CORS_ALLOWED_ORIGINS", "app.example.com")
+	t.Setenv("SHUTDOWN_DRAIN_DELAY", "-1s")
+	t.Setenv("TRUSTED_PROXIES", "proxy.internal")
+	t.Setenv("RESPONSE_ENVELOPE", "maybe")
+	t.Setenv("PAGE_SIZE_DEFAULT", "500")
+	t.Setenv("EXPORT_MAX_ROWS", "many")
+	t.Setenv("SESSION_TTL", "0s")
+	t.Setenv("PRESENCE_TTL", "later")
+	t.Setenv("SEARCH_FUZZY_THRESHOLD", "2")
+	t.Setenv("OIDC_PROVIDERS", "okta")
+
+	_, err := Load()
+	if err == nil {
+		t.Fatal("Load() succeeded, want an error")
+	}
+	for _, want := range []string{
+		"port", "sqlite", "HTTP_READ_TIMEOUT", "JWT secret", "app.example.com", "drain delay", "proxy.internal",
+		"RESPONSE_ENVELOPE", "default page size 500", "EXPORT_MAX_ROWS", "session TTL",
+		"PRESENCE_TTL", "fuzzy search threshold", "OIDC_OKTA_ISSUER", "OIDC_OKTA_CLIENT_SECRET",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q doesn't mention %q", err, want)
+		}
+	}
+}
+
+func TestLoadOIDCProviders(t *testing.T) {
+	clearEnv(t)
+	t.Setenv("CONFIG_FILE", writeFile(t, "config.yaml", `
+auth:
+  oidc:
+    - name: okta
+      issuer: https://okta.example.com/
+      client_id: id
+      redirect_url: https://todo.example.com/callback
+`))
+	t.Setenv("OIDC_OKTA_CLIENT_SECRET", "secret")
+	_, err := Load()
+	if err != nil {
+		t.Fatalf("Load() with the file's provider = %v", err)
+	}
+
+	// OIDC_PROVIDERS picks the providers, keeping the file's settings
+	t.Setenv("OIDC_PROVIDERS", "okta, azure-ad")
+	for name, value := range map[string]string{
+		"ISSUER": "https://login.microsoftonline.com/tenant/v2.0", "CLIENT_ID": "id", "CLIENT_SECRET": "secret",
+		"REDIRECT_URL": "https://todo.example.com/callback", "CLAIM_SUBJECT": "oid", "DISPLAY_NAME": "Microsoft",
+	} {
+		t.Setenv("OIDC_AZURE_AD_"+name, value)
+	}
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	configs := cfg.OIDCConfigs()
+	if len(configs) != 2 {
+		t.Fatalf("got %d providers, want 2", len(configs))
+	}
+	okta, azure := configs[0], configs[1]
+	if okta.Name != "okta" || okta.Issuer != "https://okta.example.com" || okta.ClientSecret != "secret" || okta.Claims.Subject != "sub" || len(okta.Scopes) != 3 {
+		t.Errorf("okta = %+v", okta)
+	}
+	if azure.Name != "azure-ad" || azure.Claims.Subject != "oid" || azure.DisplayName != "Microsoft" {
+		t.Errorf("azure-ad = %+v", azure)
+	}
+
+	t.Setenv("OIDC_PROVIDERS", "Not Valid, okta, okta")
+	_, err = Load()
+	if err == nil || !strings.Contains(err.Error(), `"not valid"`) || !strings.Contains(err.Error(), "listed twice") {
+		t.Errorf("invalid providers: err = %v", err)
+	}
+}
+
+func TestLoadFileRejectsUnknownKeys(t *testing.T) {
+	for name, content := range map[string]string{
+		"config.yaml": "database:\n  hostname: db\n",
+		"config.toml": "[database]\nhostname = \"db\"\n",
+		"config.json": "{}",
+	} {
+		clearEnv(t)
+		if _, err := LoadFile(writeFile(t, name, content)); err == nil {
+			t.Errorf("LoadFile(%s) succeeded, want an error", name)
+		}
+	}
+}
+
+func TestRequireDatabase(t *testing.T) {
+	cfg := Default()
+	cfg.Database.Host = "db"
+	err := cfg.RequireDatabase()
+	if err == nil || !strings.Contains(err.Error(), "BLUEPRINT_DB_DATABASE, BLUEPRINT_DB_USERNAME") {
+		t.Errorf("RequireDatabase() = %v, want the missing name and username", err)
+	}
+	cfg.Database.Name, cfg.Database.Username = "todos", "app"
+	if err := cfg.RequireDatabase(); err != nil {
+		t.Errorf("RequireDatabase() = %v, want nil", err)
+	}
+}
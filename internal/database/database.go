@@ -2,17 +2,24 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	_ "github.com/jackc/pgx/v5/stdlib"
 	_ "github.com/joho/godotenv/autoload"
 
+	"github.com/Tomlord1122/todo-backend/internal/redact"
+	"github.com/uptrace/opentelemetry-go-extra/otelgorm"
+
 	// GORM imports
-	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger" // Optional: for GORM logging
 )
@@ -20,73 +27,159 @@ import (
 // Service interface might need adjustment depending on what you expose
 type Service interface {
 	Health() map[string]string
+	// Ping checks that the database answers
+	Ping(ctx context.Context) error
 	Close() error    // May not be needed or different with GORM connection pool
 	GetDB() *gorm.DB // Method to get the GORM DB instance
+	// SlowQueries returns the statements that exceeded the slow query threshold
+	SlowQueries() *SlowQueryLog
+	// PoolStats returns connection pool statistics
+	PoolStats() (sql.DBStats, error)
+	// Settings returns the pool, timeout and log settings in effect
+	Settings() Settings
+	// UpdateSettings changes settings at runtime; invalid values are
+	// rejected with an "invalid settings" error and nothing changes
+	UpdateSettings(u SettingsUpdate) (Settings, error)
+	// Driver returns the kind of database, DriverPostgres or DriverMySQL
+	Driver() string
+	// ReplicaChecks returns a check per read replica, by address
+	ReplicaChecks() map[string]func(ctx context.Context) error
 }
 
 type service struct {
-	db *gorm.DB
-}
+	db          *gorm.DB
+	driver      string
+	name        string
+	slowQueries *SlowQueryLog
+	replicas    []*replica
+	stopMonitor chan struct{}
 
-var (
-	database   = os.Getenv("BLUEPRINT_DB_DATABASE")
-	password   = os.Getenv("BLUEPRINT_DB_PASSWORD")
-	username   = os.Getenv("BLUEPRINT_DB_USERNAME")
-	port       = os.Getenv("BLUEPRINT_DB_PORT")
-	host       = os.Getenv("BLUEPRINT_DB_HOST")
-	schema     = os.Getenv("BLUEPRINT_DB_SCHEMA") // Optional, GORM can handle schema in DSN
-	dbInstance *service
-)
-
-func New() Service {
-	if dbInstance != nil {
-		return dbInstance
-	}
+	settingsMu       sync.Mutex
+	settings         Settings
+	statementTimeout atomic.Int64 // nanoseconds
+	logLevel         *LevelLog
+}
 
+// New connects to the database cfg describes, as loaded by package config.
+// Each call opens its own pools, so tests can use several databases at
+// once; Close releases them.
+func New(cfg Config) (Service, error) {
+	driver := cfg.Driver
 	// Construct DSN for GORM
-	// Example DSN: "host=localhost user=gorm password=gorm dbname=gorm port=9920 sslmode=disable TimeZone=Asia/Shanghai"
-	// Note: search_path might be handled differently or within the DSN if supported by the driver
-	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=disable",
-		host, username, password, database, port)
+	dsn := cfg.Primary.DSN(driver)
 	// Add schema if needed and supported, e.g., append " search_path=" + schema
 
 	// Configure GORM logger (optional, good for development)
+	slowThreshold := cfg.SlowQueryThreshold
+	// With redaction on, statements are logged and captured without their
+	// values, which hold titles, descriptions and tokens
+	redactLogs := redact.Enabled()
+	var logOutput io.Writer = os.Stdout
+	if redactLogs {
+		logOutput = redact.NewWriter(os.Stdout)
+	}
 	newLogger := logger.New(
-		log.New(os.Stdout, "\r\n", log.LstdFlags), // io writer
+		log.New(logOutput, "\r\n", log.LstdFlags), // io writer
 		logger.Config{
-			SlowThreshold:             time.Second, // Slow SQL threshold
-			LogLevel:                  logger.Info, // Log level (Silent, Error, Warn, Info)
-			IgnoreRecordNotFoundError: true,        // Ignore ErrRecordNotFound error for logger
-			Colorful:                  true,        // Disable color
+			SlowThreshold:             slowThreshold, // Slow SQL threshold
+			LogLevel:                  logger.Info,   // Log level (Silent, Error, Warn, Info)
+			IgnoreRecordNotFoundError: true,          // Ignore ErrRecordNotFound error for logger
+			ParameterizedQueries:      redactLogs,    // Log placeholders instead of values
+			Colorful:                  true,          // Disable color
 		},
 	)
+	// Keep slow queries around for the admin endpoint, not just in the
+	// log. Log level, pool limits and statement timeout can be changed at
+	// runtime through the admin API.
+	logLevel := NewLevelLog(newLogger, logger.Info)
+	slowQueries := NewSlowQueryLog(logLevel, slowThreshold)
 
 	// Open GORM connection
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
-		Logger: newLogger, // Use the configured logger
+	db, err := gorm.Open(dialector(driver, dsn), &gorm.Config{
+		Logger: slowQueries, // Use the configured logger
+		// Report unique violations as gorm.ErrDuplicatedKey
+		TranslateError: true,
+		// The primary is pinged below; replicas may be down at startup
+		DisableAutomaticPing: true,
 		// Add schema config if needed, e.g., NamingStrategy: schema.NamingStrategy{TablePrefix: schema + "."} but requires testing
 	})
 	if err != nil {
-		log.Fatalf("Failed to connect to database: %v", err)
+		return nil, fmt.Errorf("connecting to the database: %w", err)
 	}
 
 	// Set connection pool settings (important for production)
 	sqlDB, err := db.DB()
 	if err != nil {
-		log.Fatalf("Failed to get underlying sql.DB: %v", err)
+		return nil, fmt.Errorf("getting the connection pool: %w", err)
+	}
+	if err := sqlDB.Ping(); err != nil {
+		sqlDB.Close()
+		return nil, fmt.Errorf("connecting to the database: %w", err)
 	}
-	sqlDB.SetMaxIdleConns(10)           // Max number of idle connections
-	sqlDB.SetMaxOpenConns(100)          // Max number of open connections
 	sqlDB.SetConnMaxLifetime(time.Hour) // Max lifetime of a connection
 
-	dbInstance = &service{db: db}
-	return dbInstance
+	s := &service{db: db, driver: driver, name: cfg.Primary.Database, slowQueries: slowQueries, logLevel: logLevel}
+	// Read-only queries that ask for ReplicaResolver go to a replica
+	if len(cfg.Replicas) > 0 {
+		if s.replicas, err = openReplicas(db, driver, cfg.Replicas); err != nil {
+			sqlDB.Close()
+			return nil, fmt.Errorf("connecting to the read replicas: %w", err)
+		}
+	}
+	if err := registerStatementTimeout(db, &s.statementTimeout); err != nil {
+		s.closePools()
+		return nil, fmt.Errorf("registering the statement timeout: %w", err)
+	}
+	// Trace queries as children of the span in their context. Values are
+	// left out of the traced statements, like in redacted logs.
+	if err := db.Use(otelgorm.NewPlugin(otelgorm.WithDBName(cfg.Primary.Database), otelgorm.WithoutQueryVariables(), otelgorm.WithoutMetrics())); err != nil {
+		s.closePools()
+		return nil, fmt.Errorf("registering query tracing: %w", err)
+	}
+	s.applySettings(sqlDB, Settings{
+		MaxOpenConns: 100,
+		MaxIdleConns: 10,
+		LogLevel:     "info",
+	})
+	if len(s.replicas) > 0 {
+		s.stopMonitor = make(chan struct{})
+		go monitorReplicas(s.replicas, s.stopMonitor)
+		log.Printf("Reading from %d replica(s) as well as the primary", len(s.replicas))
+	}
+	return s, nil
 }
 
 func (s *service) GetDB() *gorm.DB {
 	return s.db
 }
 
+// Driver implements Service.
+func (s *service) Driver() string {
+	return s.driver
+}
+
+// ReplicaChecks implements Service. A failing check also keeps reads off
+// the replica until it answers again.
+func (s *service) ReplicaChecks() map[string]func(ctx context.Context) error {
+	checks := make(map[string]func(ctx context.Context) error, len(s.replicas))
+	for _, r := range s.replicas {
+		checks[r.addr] = r.ping
+	}
+	return checks
+}
+
+func (s *service) SlowQueries() *SlowQueryLog {
+	return s.slowQueries
+}
+
+func (s *service) PoolStats() (sql.DBStats, error) {
+	sqlDB, err := s.db.DB()
+	if err != nil {
+		return sql.DBStats{}, err
+	}
+	return sqlDB.Stats(), nil
+}
+
 // Health check needs to use the underlying sql.DB from GORM
 func (s *service) Health() map[string]string {
 	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
@@ -142,17 +235,55 @@ func (s *service) Health() map[string]string {
 		stats["message"] = "Many connections are being closed due to max lifetime, consider increasing ConnMaxLifetime or revising the connection usage pattern."
 	}
 
+	// Replicas being down doesn't make the database down, reads go to the
+	// others or to the primary
+	if len(s.replicas) > 0 {
+		var down []string
+		for _, r := range s.replicas {
+			if err := r.ping(ctx); err != nil {
+				stats["replica "+r.addr] = fmt.Sprintf("down: %v", err)
+				down = append(down, r.addr)
+			} else {
+				stats["replica "+r.addr] = "up"
+			}
+		}
+		stats["replicas_up"] = fmt.Sprintf("%d/%d", len(s.replicas)-len(down), len(s.replicas))
+		if len(down) > 0 {
+			stats["message"] = "Read replicas are down, reading from the others: " + strings.Join(down, ", ")
+		}
+	}
+
 	return stats
 }
 
+// Ping implements Service.
+func (s *service) Ping(ctx context.Context) error {
+	sqlDB, err := s.db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
+
 // Close might not be strictly necessary to call manually as GORM manages the pool,
 // but if you need to explicitly close the underlying pool:
 func (s *service) Close() error {
+	log.Printf("Closing connection pool for database: %s", s.name)
+	if s.stopMonitor != nil {
+		close(s.stopMonitor)
+		s.stopMonitor = nil
+	}
+	return s.closePools()
+}
+
+// closePools closes the primary's and the replicas' connection pools.
+func (s *service) closePools() error {
 	sqlDB, err := s.db.DB()
 	if err != nil {
-		log.Printf("Error getting underlying sql.DB for closing: %v", err)
 		return err
 	}
-	log.Printf("Closing connection pool for database: %s", database)
+	if err := closeReplicas(s.replicas); err != nil {
+		log.Printf("Error closing read replica connections: %v", err)
+	}
 	return sqlDB.Close()
 }
@@ -2,15 +2,22 @@ package database
 
 import (
 	"context"
+	"errors"
 	"log"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/testcontainers/testcontainers-go"
 	"github.com/testcontainers/testcontainers-go/modules/postgres"
 	"github.com/testcontainers/testcontainers-go/wait"
+	"gorm.io/gorm"
 )
 
+// testConfig connects to the container TestMain starts.
+var testConfig = Config{Driver: DriverPostgres, SlowQueryThreshold: time.Second}
+
 func mustStartPostgresContainer() (func(context.Context, ...testcontainers.TerminateOption) error, error) {
 	var (
 		dbName = "database"
@@ -33,9 +40,9 @@ func mustStartPostgresContainer() (func(context.Context, ...testcontainers.Termi
 		return nil, err
 	}
 
-	database = dbName
-	password = dbPwd
-	username = dbUser
+	testConfig.Primary.Database = dbName
+	testConfig.Primary.Password = dbPwd
+	testConfig.Primary.Username = dbUser
 
 	dbHost, err := dbContainer.Host(context.Background())
 	if err != nil {
@@ -47,8 +54,8 @@ func mustStartPostgresContainer() (func(context.Context, ...testcontainers.Termi
 		return dbContainer.Terminate, err
 	}
 
-	host = dbHost
-	port = dbPort.Port()
+	testConfig.Primary.Host = dbHost
+	testConfig.Primary.Port = dbPort.Port()
 
 	return dbContainer.Terminate, err
 }
@@ -66,15 +73,48 @@ func TestMain(m *testing.M) {
 	}
 }
 
+// newTestService connects to the test database, until the test ends.
+func newTestService(t *testing.T) Service {
+	t.Helper()
+	srv, err := New(testConfig)
+	if err != nil {
+		t.Fatalf("New returned error: %v", err)
+	}
+	t.Cleanup(func() { srv.Close() })
+	return srv
+}
+
 func TestNew(t *testing.T) {
-	srv := New()
+	srv := newTestService(t)
 	if srv == nil {
 		t.Fatal("New() returned nil")
 	}
 }
 
+func TestNewInstancesAreIndependent(t *testing.T) {
+	first, second := newTestService(t), newTestService(t)
+	if first == second {
+		t.Fatal("New returned the same service twice")
+	}
+	if err := first.Close(); err != nil {
+		t.Fatalf("Close returned error: %v", err)
+	}
+	if err := second.Ping(context.Background()); err != nil {
+		t.Errorf("closing one service broke the other: %v", err)
+	}
+}
+
+func TestNewUnreachable(t *testing.T) {
+	cfg := testConfig
+	cfg.Primary.Host, cfg.Primary.Port = "127.0.0.1", "1"
+	if srv, err := New(cfg); err == nil {
+		srv.Close()
+		t.Error("New succeeded without a database to connect to")
+	}
+}
+
 func TestHealth(t *testing.T) {
-	srv := New()
+	srv := newTestService(t)
 
 	stats := srv.Health()
 
@@ -91,10 +131,132 @@ func TestHealth(t *testing.T) {
 	}
 }
 
+func TestWithMigrationLockSerializes(t *testing.T) {
+	db := newTestService(t).GetDB()
+	ctx := context.Background()
+
+	var running, overlapped atomic.Bool
+	var wg sync.WaitGroup
+	for range 3 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := WithMigrationLock(ctx, db, func(*gorm.DB) error {
+				if running.Swap(true) {
+					overlapped.Store(true)
+				}
+				time.Sleep(50 * time.Millisecond)
+				running.Store(false)
+				return nil
+			})
+			if err != nil {
+				t.Errorf("WithMigrationLock returned error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if overlapped.Load() {
+		t.Error("migrations ran concurrently")
+	}
+}
+
+func TestMigratorUpDown(t *testing.T) {
+	ctx := context.Background()
+	m, err := NewMigrator(ctx, newTestService(t).GetDB())
+	if err != nil {
+		t.Fatalf("NewMigrator returned error: %v", err)
+	}
+	defer m.Close()
+
+	if err := m.Up(); err != nil {
+		t.Fatalf("Up returned error: %v", err)
+	}
+	latest, dirty, err := m.Version()
+	if err != nil || dirty || latest < 1 {
+		t.Fatalf("Version() = %d, %t, %v after Up, want a clean version", latest, dirty, err)
+	}
+	// Nothing left to do is not an error
+	if err := m.Up(); err != nil {
+		t.Fatalf("second Up returned error: %v", err)
+	}
+
+	if err := m.Down(1); err != nil {
+		t.Fatalf("Down(1) returned error: %v", err)
+	}
+	if version, _, _ := m.Version(); version != latest-1 {
+		t.Errorf("Version() = %d after Down(1), want %d", version, latest-1)
+	}
+	if err := Migrate(ctx, newTestService(t).GetDB()); err != nil {
+		t.Fatalf("Migrate returned error: %v", err)
+	}
+	if version, _, _ := m.Version(); version != latest {
+		t.Errorf("Version() = %d after Migrate, want %d", version, latest)
+	}
+}
+
+func TestUpdateSettings(t *testing.T) {
+	srv := newTestService(t)
+	original := srv.Settings()
+	defer srv.UpdateSettings(SettingsUpdate{
+		MaxOpenConns:       &original.MaxOpenConns,
+		MaxIdleConns:       &original.MaxIdleConns,
+		StatementTimeoutMS: &original.StatementTimeoutMS,
+		LogLevel:           &original.LogLevel,
+	})
+
+	tooManyIdle := original.MaxOpenConns + 1
+	if _, err := srv.UpdateSettings(SettingsUpdate{MaxIdleConns: &tooManyIdle}); err == nil {
+		t.Error("expected max_idle_conns above max_open_conns to be rejected")
+	}
+
+	maxOpen, maxIdle, timeout, level := 5, 2, int64(50), "warn"
+	settings, err := srv.UpdateSettings(SettingsUpdate{MaxOpenConns: &maxOpen, MaxIdleConns: &maxIdle, StatementTimeoutMS: &timeout, LogLevel: &level})
+	if err != nil {
+		t.Fatalf("UpdateSettings returned error: %v", err)
+	}
+	if settings != (Settings{MaxOpenConns: 5, MaxIdleConns: 2, StatementTimeoutMS: 50, LogLevel: "warn"}) {
+		t.Errorf("unexpected settings %+v", settings)
+	}
+	if stats, _ := srv.PoolStats(); stats.MaxOpenConnections != 5 {
+		t.Errorf("pool allows %d connections, want 5", stats.MaxOpenConnections)
+	}
+
+	err = srv.GetDB().Exec("SELECT pg_sleep(1)").Error
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the statement timeout to cancel pg_sleep, got %v", err)
+	}
+}
+
 func TestClose(t *testing.T) {
-	srv := New()
+	srv := newTestService(t)
 
 	if srv.Close() != nil {
 		t.Fatalf("expected Close() to return nil")
 	}
 }
+
+func TestCheckMigrations(t *testing.T) {
+	ctx := context.Background()
+	db := newTestService(t).GetDB()
+	if err := Migrate(ctx, db); err != nil {
+		t.Fatalf("Migrate returned error: %v", err)
+	}
+	if err := CheckMigrations(ctx, db); err != nil {
+		t.Errorf("CheckMigrations after Migrate = %v, want nil", err)
+	}
+
+	m, err := NewMigrator(ctx, db)
+	if err != nil {
+		t.Fatalf("NewMigrator returned error: %v", err)
+	}
+	defer m.Close()
+	if err := m.Down(1); err != nil {
+		t.Fatalf("Down(1) returned error: %v", err)
+	}
+	if err := CheckMigrations(ctx, db); err == nil {
+		t.Error("CheckMigrations with a migration pending = nil, want an error")
+	}
+	if err := m.Up(); err != nil {
+		t.Fatalf("Up returned error: %v", err)
+	}
+}
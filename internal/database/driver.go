@@ -0,0 +1,79 @@
+package database
+
+import (
+	"fmt"
+	"net"
+	"time"
+
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// Database drivers: Postgres, or MySQL, which also covers MariaDB.
+const (
+	DriverPostgres = "postgres"
+	DriverMySQL    = "mysql"
+)
+
+// Config is what New connects to and how it logs.
+type Config struct {
+	// Driver is DriverPostgres or DriverMySQL
+	Driver string
+	// Primary takes every write and the reads not sent to replicas
+	Primary ConnConfig
+	// Replicas serve the reads that ask for ReplicaResolver
+	Replicas []ConnConfig
+	// SlowQueryThreshold is when statements are logged as slow
+	SlowQueryThreshold time.Duration
+}
+
+// ConnConfig is where to connect and as whom.
+type ConnConfig struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	Database string
+}
+
+// DSN returns the data source name of c for driver.
+func (c ConnConfig) DSN(driver string) string {
+	if driver == DriverMySQL {
+		return c.mysqlDSN()
+	}
+	// Example DSN: "host=localhost user=gorm password=gorm dbname=gorm port=9920 sslmode=disable TimeZone=Asia/Shanghai"
+	// Note: search_path might be handled differently or within the DSN if supported by the driver
+	return fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=disable",
+		c.Host, c.Username, c.Password, c.Database, c.Port)
+}
+
+// mysqlDSN builds the DSN with the driver's own formatting, so passwords
+// with special characters need no escaping. Times are read into time.Time
+// and kept in UTC, like timestamptz columns on Postgres, and utf8mb4 stores
+// emoji. Migrations run several statements per file.
+func (c ConnConfig) mysqlDSN() string {
+	cfg := mysqldriver.NewConfig()
+	cfg.Net = "tcp"
+	cfg.Addr = net.JoinHostPort(c.Host, c.Port)
+	cfg.User = c.Username
+	cfg.Passwd = c.Password
+	cfg.DBName = c.Database
+	cfg.ParseTime = true
+	cfg.Loc = time.UTC
+	cfg.MultiStatements = true
+	cfg.Params = map[string]string{
+		"charset":   "utf8mb4",
+		"time_zone": "'+00:00'",
+	}
+	return cfg.FormatDSN()
+}
+
+// dialector returns the GORM dialector for driver connecting to dsn.
+func dialector(driver, dsn string) gorm.Dialector {
+	if driver == DriverMySQL {
+		return mysql.Open(dsn)
+	}
+	return postgres.Open(dsn)
+}
@@ -0,0 +1,71 @@
+package database
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// LevelLog is a GORM logger whose level can be changed while in use. GORM
+// keeps the logger it was opened with, so the level is switched here
+// rather than through LogMode.
+type LevelLog struct {
+	byLevel map[logger.LogLevel]logger.Interface
+	level   atomic.Int32
+}
+
+// NewLevelLog wraps inner, starting at level.
+func NewLevelLog(inner logger.Interface, level logger.LogLevel) *LevelLog {
+	l := &LevelLog{byLevel: make(map[logger.LogLevel]logger.Interface)}
+	for _, lvl := range logLevelValues {
+		l.byLevel[lvl] = inner.LogMode(lvl)
+	}
+	l.Set(level)
+	return l
+}
+
+// Set changes the level.
+func (l *LevelLog) Set(level logger.LogLevel) {
+	l.level.Store(int32(level))
+}
+
+func (l *LevelLog) current() logger.Interface {
+	return l.byLevel[logger.LogLevel(l.level.Load())]
+}
+
+// LogMode implements logger.Interface. The returned logger has a fixed
+// level, as for db.Debug().
+func (l *LevelLog) LogMode(level logger.LogLevel) logger.Interface {
+	return l.current().LogMode(level)
+}
+
+// Info implements logger.Interface.
+func (l *LevelLog) Info(ctx context.Context, msg string, args ...interface{}) {
+	l.current().Info(ctx, msg, args...)
+}
+
+// Warn implements logger.Interface.
+func (l *LevelLog) Warn(ctx context.Context, msg string, args ...interface{}) {
+	l.current().Warn(ctx, msg, args...)
+}
+
+// Error implements logger.Interface.
+func (l *LevelLog) Error(ctx context.Context, msg string, args ...interface{}) {
+	l.current().Error(ctx, msg, args...)
+}
+
+// Trace implements logger.Interface.
+func (l *LevelLog) Trace(ctx context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
+	l.current().Trace(ctx, begin, fc, err)
+}
+
+// ParamsFilter implements gorm.ParamsFilter.
+func (l *LevelLog) ParamsFilter(ctx context.Context, sql string, params ...any) (string, []any) {
+	if filter, ok := l.current().(gorm.ParamsFilter); ok {
+		return filter.ParamsFilter(ctx, sql, params...)
+	}
+	return sql, params
+}
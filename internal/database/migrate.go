@@ -0,0 +1,245 @@
+package database
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"time"
+
+	"github.com/Tomlord1122/todo-backend/migrations"
+	"github.com/golang-migrate/migrate/v4"
+	migratedb "github.com/golang-migrate/migrate/v4/database"
+	"github.com/golang-migrate/migrate/v4/database/mysql"
+	"github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"gorm.io/gorm"
+)
+
+// migrationLockKey identifies the lock serializing schema migrations. Any
+// constant works as long as nothing else locks on it.
+const migrationLockKey int64 = 0x746f646f6d6967 // "todomig"
+
+// WithMigrationLock runs migrate while holding a database-wide lock, a
+// Postgres advisory lock or a MySQL named lock, so replicas starting
+// together migrate one after another instead of racing on the same DDL.
+// The first applies the changes; the others wait for it and then run
+// migrate against the finished schema, which checks it and finds nothing
+// left to do, before they start serving. ctx bounds the wait.
+func WithMigrationLock(ctx context.Context, db *gorm.DB, migrate func(db *gorm.DB) error) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	// The locks belong to a session, so keep one connection for them
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("getting a connection for the migration lock: %w", err)
+	}
+	defer conn.Close()
+
+	lock := postgresMigrationLock
+	if db.Dialector.Name() == DriverMySQL {
+		lock = mysqlMigrationLock
+	}
+	var locked bool
+	if err := conn.QueryRowContext(ctx, lock.try, migrationLockKey).Scan(&locked); err != nil {
+		return fmt.Errorf("taking the migration lock: %w", err)
+	}
+	if !locked {
+		log.Println("Another instance is migrating the database, waiting for it to finish...")
+		started := time.Now()
+		if _, err := conn.ExecContext(ctx, lock.wait, migrationLockKey); err != nil {
+			return fmt.Errorf("waiting for the migration lock: %w", err)
+		}
+		log.Printf("Migration lock acquired after %s", time.Since(started).Round(time.Millisecond))
+	}
+	defer func() {
+		unlockCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if _, err := conn.ExecContext(unlockCtx, lock.release, migrationLockKey); err != nil {
+			log.Printf("Releasing the migration lock failed, dropping its connection: %v", err)
+			// Returning the connection to the pool would keep the lock held
+			_ = conn.Raw(func(any) error { return driver.ErrBadConn })
+		}
+	}()
+
+	return migrate(db.WithContext(ctx))
+}
+
+// migrationLock holds the statements taking and releasing the migration
+// lock, given migrationLockKey. try returns whether it got the lock, wait
+// blocks until it does.
+type migrationLock struct {
+	try, wait, release string
+}
+
+var (
+	postgresMigrationLock = migrationLock{
+		try:     "SELECT pg_try_advisory_lock($1)",
+		wait:    "SELECT pg_advisory_lock($1)",
+		release: "SELECT pg_advisory_unlock($1)",
+	}
+	// MySQL locks on names; -1 waits until ctx cancels the statement
+	mysqlMigrationLock = migrationLock{
+		try:     "SELECT GET_LOCK(CONCAT('todo-backend/migrate/', ?), 0) = 1",
+		wait:    "DO GET_LOCK(CONCAT('todo-backend/migrate/', ?), -1)",
+		release: "DO RELEASE_LOCK(CONCAT('todo-backend/migrate/', ?))",
+	}
+)
+
+// Migrator applies and reverts the versioned SQL migrations in migrations/,
+// those in the directory of the database's driver.
+// The version a database is at is kept in its schema_migrations table. A
+// migration that fails halfway leaves the version marked dirty, and
+// nothing runs until it is fixed by hand and Force records the version
+// the schema is really at.
+type Migrator struct {
+	m *migrate.Migrate
+}
+
+// NewMigrator returns a Migrator running migrations on its own connection
+// from db's pool. Close returns the connection. ctx's deadline, if any, also
+// bounds the wait for another instance's migrations.
+func NewMigrator(ctx context.Context, db *gorm.DB) (*Migrator, error) {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("getting a connection for migrations: %w", err)
+	}
+	// The driver closes only the connection it is given, not the pool
+	name := db.Dialector.Name()
+	var target migratedb.Driver
+	switch name {
+	case DriverPostgres:
+		target, err = postgres.WithConnection(ctx, conn, &postgres.Config{})
+	case DriverMySQL:
+		target, err = mysql.WithConnection(ctx, conn, &mysql.Config{})
+	default:
+		err = fmt.Errorf("no migrations for %s databases", name)
+	}
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("preparing the migrations table: %w", err)
+	}
+	source, err := iofs.New(migrations.FS, name)
+	if err != nil {
+		target.Close()
+		return nil, fmt.Errorf("reading migrations: %w", err)
+	}
+	m, err := migrate.NewWithInstance("iofs", source, name, target)
+	if err != nil {
+		source.Close()
+		target.Close()
+		return nil, err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		m.LockTimeout = time.Until(deadline)
+	}
+	return &Migrator{m: m}, nil
+}
+
+// Up applies every pending migration.
+func (m *Migrator) Up() error {
+	return ignoreNoChange(m.m.Up())
+}
+
+// Down reverts the last steps migrations.
+func (m *Migrator) Down(steps int) error {
+	if steps <= 0 {
+		return fmt.Errorf("invalid number of migrations to revert %d, expected at least 1", steps)
+	}
+	return ignoreNoChange(m.m.Steps(-steps))
+}
+
+// Force records version as applied and clears the dirty flag without
+// running anything. -1 records that no migration is applied.
+func (m *Migrator) Force(version int) error {
+	return m.m.Force(version)
+}
+
+// Version returns the version the database is at, 0 when no migration is
+// applied, and whether the last migration failed halfway.
+func (m *Migrator) Version() (version uint, dirty bool, err error) {
+	version, dirty, err = m.m.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		return 0, false, nil
+	}
+	return version, dirty, err
+}
+
+// Close releases the Migrator's connection.
+func (m *Migrator) Close() error {
+	sourceErr, targetErr := m.m.Close()
+	return errors.Join(sourceErr, targetErr)
+}
+
+// Migrate applies every pending migration to db.
+func Migrate(ctx context.Context, db *gorm.DB) error {
+	m, err := NewMigrator(ctx, db)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+	return m.Up()
+}
+
+// CheckMigrations returns an error unless db's schema is at the newest
+// migration or later, and not left dirty by a failed one. A newer schema
+// passes, so instances of the previous release stay ready while a new one
+// rolls out. It reads the version table directly, for readiness checks.
+func CheckMigrations(ctx context.Context, db *gorm.DB) error {
+	latest, err := latestMigration(db.Dialector.Name())
+	if err != nil {
+		return err
+	}
+	var row struct {
+		Version int64
+		Dirty   bool
+	}
+	result := db.WithContext(ctx).Raw("SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&row)
+	switch {
+	case result.Error != nil:
+		return fmt.Errorf("reading the schema version: %w", result.Error)
+	case result.RowsAffected == 0:
+		return fmt.Errorf("no migration applied, expected version %d", latest)
+	case row.Dirty:
+		return fmt.Errorf("migration %d failed halfway, fix the schema and force the version", row.Version)
+	case row.Version < int64(latest):
+		return fmt.Errorf("schema at version %d, expected %d", row.Version, latest)
+	}
+	return nil
+}
+
+// latestMigration returns the newest migration version for driver.
+func latestMigration(driver string) (uint, error) {
+	source, err := iofs.New(migrations.FS, driver)
+	if err != nil {
+		return 0, fmt.Errorf("reading migrations: %w", err)
+	}
+	defer source.Close()
+	version, err := source.First()
+	for err == nil {
+		var next uint
+		if next, err = source.Next(version); err == nil {
+			version = next
+		}
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return 0, fmt.Errorf("reading migrations: %w", err)
+	}
+	return version, nil
+}
+
+// ignoreNoChange treats finding nothing to do as success.
+func ignoreNoChange(err error) error {
+	if errors.Is(err, migrate.ErrNoChange) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,160 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"net"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
+)
+
+// ReplicaResolver names the read replicas. Queries opt into them with
+// Clauses(dbresolver.Use(ReplicaResolver)); everything else, and anything
+// in a transaction, stays on the primary.
+const ReplicaResolver = "replicas"
+
+// replicaCheckInterval is how often replicas are pinged, so reads skip the
+// ones that are down.
+const replicaCheckInterval = 10 * time.Second
+
+// ReplicaConfigs returns the connections to read replicas at hosts, given
+// as host or host:port. Replicas take the primary's port unless given and
+// always its database and credentials.
+func ReplicaConfigs(primary ConnConfig, hosts []string) ([]ConnConfig, error) {
+	var replicas []ConnConfig
+	for _, addr := range hosts {
+		addr = strings.TrimSpace(addr)
+		if addr == "" {
+			continue
+		}
+		replica := primary
+		replica.Host = addr
+		if h, p, err := net.SplitHostPort(addr); err == nil {
+			replica.Host, replica.Port = h, p
+		} else if strings.Contains(addr, ":") {
+			replica.Host = ""
+		}
+		if replica.Host == "" || strings.ContainsAny(replica.Host, "/ ") {
+			return nil, fmt.Errorf("invalid read replica %q, expected host or host:port", addr)
+		}
+		replicas = append(replicas, replica)
+	}
+	return replicas, nil
+}
+
+// replica is a read replica's connection pool and whether its last ping
+// failed.
+type replica struct {
+	addr string
+	db   *sql.DB
+	down atomic.Bool
+}
+
+// ping checks the replica and records whether it's down.
+func (r *replica) ping(ctx context.Context) error {
+	err := r.db.PingContext(ctx)
+	if wasDown := r.down.Swap(err != nil); err != nil && !wasDown {
+		log.Printf("Read replica %s is down, reading from the others: %v", r.addr, err)
+	} else if err == nil && wasDown {
+		log.Printf("Read replica %s is back up", r.addr)
+	}
+	return err
+}
+
+// openReplicas connects to replicas and registers them with db under
+// ReplicaResolver. Connecting doesn't wait for them, so a replica that is
+// down at startup only keeps reads away until it comes up.
+func openReplicas(db *gorm.DB, driver string, replicas []ConnConfig) ([]*replica, error) {
+	primary, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	opened := make([]*replica, 0, len(replicas))
+	byPool := make(map[gorm.ConnPool]*replica, len(replicas))
+	dialectors := make([]gorm.Dialector, 0, len(replicas)+1)
+	for _, cfg := range replicas {
+		driverName := "pgx"
+		if driver == DriverMySQL {
+			driverName = "mysql"
+		}
+		sqlDB, err := sql.Open(driverName, cfg.DSN(driver))
+		if err != nil {
+			closeReplicas(opened)
+			return nil, fmt.Errorf("opening read replica %s: %w", cfg.Host, err)
+		}
+		sqlDB.SetConnMaxLifetime(time.Hour)
+		r := &replica{addr: net.JoinHostPort(cfg.Host, cfg.Port), db: sqlDB}
+		opened = append(opened, r)
+		byPool[sqlDB] = r
+		dialectors = append(dialectors, connDialector(driver, sqlDB))
+	}
+	// The primary comes last, for reads when every replica is down. Listing
+	// it also makes dbresolver consult the policy with a single replica.
+	dialectors = append(dialectors, connDialector(driver, primary))
+
+	policy := dbresolver.PolicyFunc(func(pools []gorm.ConnPool) gorm.ConnPool {
+		up := make([]gorm.ConnPool, 0, len(pools))
+		for _, pool := range pools {
+			if r, ok := byPool[pool]; ok && !r.down.Load() {
+				up = append(up, pool)
+			}
+		}
+		if len(up) == 0 {
+			return pools[len(pools)-1]
+		}
+		return up[rand.IntN(len(up))]
+	})
+	resolver := dbresolver.Register(dbresolver.Config{Replicas: dialectors, Policy: policy}, ReplicaResolver)
+	if err := db.Use(resolver); err != nil {
+		closeReplicas(opened)
+		return nil, fmt.Errorf("registering read replicas: %w", err)
+	}
+	return opened, nil
+}
+
+// connDialector returns the GORM dialector for driver using an open pool.
+func connDialector(driver string, conn *sql.DB) gorm.Dialector {
+	if driver == DriverMySQL {
+		return mysql.New(mysql.Config{Conn: conn, SkipInitializeWithVersion: true})
+	}
+	return postgres.New(postgres.Config{Conn: conn})
+}
+
+// monitorReplicas pings the replicas every replicaCheckInterval until stop
+// is closed.
+func monitorReplicas(replicas []*replica, stop <-chan struct{}) {
+	ticker := time.NewTicker(replicaCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			for _, r := range replicas {
+				ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+				r.ping(ctx)
+				cancel()
+			}
+		}
+	}
+}
+
+// closeReplicas closes the replicas' pools.
+func closeReplicas(replicas []*replica) error {
+	var firstErr error
+	for _, r := range replicas {
+		if err := r.db.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
@@ -0,0 +1,34 @@
+package database
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReplicaConfigs(t *testing.T) {
+	primary := ConnConfig{Host: "primary", Port: "5432", Username: "app", Password: "secret", Database: "todos"}
+	replica := func(host, port string) ConnConfig {
+		c := primary
+		c.Host, c.Port = host, port
+		return c
+	}
+	tests := []struct {
+		hosts   string
+		want    []ConnConfig
+		wantErr bool
+	}{
+		{"", nil, false},
+		{"replica-1", []ConnConfig{replica("replica-1", "5432")}, false},
+		{" replica-1:5433, ,10.0.0.2 ", []ConnConfig{replica("replica-1", "5433"), replica("10.0.0.2", "5432")}, false},
+		{"[::1]:5433", []ConnConfig{replica("::1", "5433")}, false},
+		{"replica-1:5433:1", nil, true},
+		{":5433", nil, true},
+	}
+	for _, tt := range tests {
+		got, err := ReplicaConfigs(primary, strings.Split(tt.hosts, ","))
+		if (err != nil) != tt.wantErr || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ReplicaConfigs(%q) = %+v, %v, want %+v (error %t)", tt.hosts, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"sync/atomic"
+	"time"
+
+	"github.com/Tomlord1122/todo-backend/internal/apperror"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// Settings are the database settings operators can change at runtime.
+type Settings struct {
+	MaxOpenConns int `json:"max_open_conns"`
+	MaxIdleConns int `json:"max_idle_conns"`
+	// StatementTimeoutMS cancels statements running longer; 0 means none
+	StatementTimeoutMS int64 `json:"statement_timeout_ms"`
+	// LogLevel is one of LogLevels
+	LogLevel string `json:"log_level"`
+}
+
+// SettingsUpdate changes the non-nil fields of Settings.
+type SettingsUpdate struct {
+	MaxOpenConns       *int    `json:"max_open_conns"`
+	MaxIdleConns       *int    `json:"max_idle_conns"`
+	StatementTimeoutMS *int64  `json:"statement_timeout_ms"`
+	LogLevel           *string `json:"log_level"`
+}
+
+// LogLevels are the accepted SQL log levels, from quietest to noisiest:
+// errors only, errors and slow statements, or every statement.
+var LogLevels = []string{"silent", "error", "warn", "info"}
+
+var logLevelValues = map[string]logger.LogLevel{
+	"silent": logger.Silent,
+	"error":  logger.Error,
+	"warn":   logger.Warn,
+	"info":   logger.Info,
+}
+
+// apply validates u against current and returns the merged settings.
+func (u SettingsUpdate) apply(current Settings) (Settings, error) {
+	next := current
+	if u.MaxOpenConns != nil {
+		next.MaxOpenConns = *u.MaxOpenConns
+	}
+	if u.MaxIdleConns != nil {
+		next.MaxIdleConns = *u.MaxIdleConns
+	}
+	if u.StatementTimeoutMS != nil {
+		next.StatementTimeoutMS = *u.StatementTimeoutMS
+	}
+	if u.LogLevel != nil {
+		next.LogLevel = *u.LogLevel
+	}
+
+	switch {
+	case next.MaxOpenConns < 0:
+		return current, apperror.Invalidf("invalid settings: max_open_conns must not be negative (0 means unlimited)")
+	case next.MaxIdleConns < 0:
+		return current, apperror.Invalidf("invalid settings: max_idle_conns must not be negative")
+	case next.MaxOpenConns > 0 && next.MaxIdleConns > next.MaxOpenConns:
+		return current, apperror.Invalidf("invalid settings: max_idle_conns (%d) must not exceed max_open_conns (%d)", next.MaxIdleConns, next.MaxOpenConns)
+	case next.StatementTimeoutMS < 0:
+		return current, apperror.Invalidf("invalid settings: statement_timeout_ms must not be negative (0 means none)")
+	}
+	if _, ok := logLevelValues[next.LogLevel]; !ok {
+		return current, apperror.Invalidf("invalid settings: log_level must be one of %v", LogLevels)
+	}
+	return next, nil
+}
+
+// Settings implements Service.
+func (s *service) Settings() Settings {
+	s.settingsMu.Lock()
+	defer s.settingsMu.Unlock()
+	return s.settings
+}
+
+// UpdateSettings implements Service.
+func (s *service) UpdateSettings(u SettingsUpdate) (Settings, error) {
+	s.settingsMu.Lock()
+	defer s.settingsMu.Unlock()
+
+	next, err := u.apply(s.settings)
+	if err != nil {
+		return s.settings, err
+	}
+	sqlDB, err := s.db.DB()
+	if err != nil {
+		return s.settings, err
+	}
+	s.applySettings(sqlDB, next)
+	return next, nil
+}
+
+// applySettings puts settings into effect. Callers hold settingsMu, except
+// New, which runs before the service is shared.
+func (s *service) applySettings(sqlDB *sql.DB, settings Settings) {
+	// database/sql lowers the idle limit to fit the open limit, so set the
+	// open limit first
+	sqlDB.SetMaxOpenConns(settings.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(settings.MaxIdleConns)
+	// Each replica gets a pool of the same size
+	for _, r := range s.replicas {
+		r.db.SetMaxOpenConns(settings.MaxOpenConns)
+		r.db.SetMaxIdleConns(settings.MaxIdleConns)
+	}
+	s.statementTimeout.Store(int64(time.Duration(settings.StatementTimeoutMS) * time.Millisecond))
+	s.logLevel.Set(logLevelValues[settings.LogLevel])
+	s.settings = settings
+}
+
+// statementCancelKey stores the cancel func of a statement's timeout.
+const statementCancelKey = "todo_backend:statement_timeout_cancel"
+
+// registerStatementTimeout bounds every create, query, update, delete and
+// raw statement by the current timeout. The driver cancels the statement on
+// the server when the deadline passes. Row queries are left alone, as their
+// rows are read after the callbacks return.
+func registerStatementTimeout(db *gorm.DB, timeout *atomic.Int64) error {
+	before := func(tx *gorm.DB) {
+		d := time.Duration(timeout.Load())
+		if d <= 0 {
+			return
+		}
+		ctx := tx.Statement.Context
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		ctx, cancel := context.WithTimeout(ctx, d)
+		tx.Statement.Context = ctx
+		tx.InstanceSet(statementCancelKey, cancel)
+	}
+	after := func(tx *gorm.DB) {
+		if cancel, ok := tx.InstanceGet(statementCancelKey); ok {
+			cancel.(context.CancelFunc)()
+		}
+	}
+
+	callbacks := db.Callback()
+	for _, err := range []error{
+		callbacks.Create().Before("*").Register("statement_timeout:before_create", before),
+		callbacks.Create().After("*").Register("statement_timeout:after_create", after),
+		callbacks.Query().Before("*").Register("statement_timeout:before_query", before),
+		callbacks.Query().After("*").Register("statement_timeout:after_query", after),
+		callbacks.Update().Before("*").Register("statement_timeout:before_update", before),
+		callbacks.Update().After("*").Register("statement_timeout:after_update", after),
+		callbacks.Delete().Before("*").Register("statement_timeout:before_delete", before),
+		callbacks.Delete().After("*").Register("statement_timeout:after_delete", after),
+		callbacks.Raw().Before("*").Register("statement_timeout:before_raw", before),
+		callbacks.Raw().After("*").Register("statement_timeout:after_raw", after),
+	} {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
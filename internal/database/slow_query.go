@@ -0,0 +1,216 @@
+package database
+
+import (
+	"context"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/Tomlord1122/todo-backend/internal/requestid"
+)
+
+const (
+	// recentSlowQueries is how many individual slow queries are kept.
+	recentSlowQueries = 100
+	// maxSlowQueryFingerprints caps the per-statement aggregates, so a flood of
+	// distinct statements can't grow memory without bound.
+	maxSlowQueryFingerprints = 500
+)
+
+// SlowQuery is one captured slow statement.
+type SlowQuery struct {
+	SQL      string        `json:"sql"`
+	Duration time.Duration `json:"duration_ns"`
+	Rows     int64         `json:"rows"`
+	// RequestID is only set for statements run with the request's context:
+	// the todo repository's, via WithContext, and RunInTransaction's. The
+	// other repositories don't take a context, so theirs are untagged.
+	RequestID string    `json:"request_id,omitempty"`
+	Error     string    `json:"error,omitempty"`
+	At        time.Time `json:"at"`
+}
+
+// SlowQueryStats aggregates slow executions of statements sharing a fingerprint.
+type SlowQueryStats struct {
+	Fingerprint   string        `json:"fingerprint"`
+	Count         int64         `json:"count"`
+	TotalDuration time.Duration `json:"total_duration_ns"`
+	MaxDuration   time.Duration `json:"max_duration_ns"`
+	LastSeen      time.Time     `json:"last_seen"`
+}
+
+// SlowQuerySnapshot is a point-in-time view of captured slow queries.
+type SlowQuerySnapshot struct {
+	Threshold    time.Duration    `json:"threshold_ns"`
+	Total        int64            `json:"total"`
+	Recent       []SlowQuery      `json:"recent"`
+	MostFrequent []SlowQueryStats `json:"most_frequent"`
+}
+
+// SlowQueryLog is a GORM logger that records statements slower than a
+// threshold, tagged with the request ID from the query's context when it
+// has one, before handing every call on to the wrapped logger.
+type SlowQueryLog struct {
+	logger.Interface
+	*slowQueryStore
+}
+
+// slowQueryStore holds captured queries. It is shared between a
+// SlowQueryLog and the copies GORM makes via LogMode (e.g. db.Debug()).
+type slowQueryStore struct {
+	threshold time.Duration
+
+	mu     sync.Mutex
+	total  int64
+	recent []SlowQuery // ring buffer
+	next   int
+	stats  map[string]*SlowQueryStats
+}
+
+// NewSlowQueryLog wraps inner, capturing statements that take at least threshold.
+func NewSlowQueryLog(inner logger.Interface, threshold time.Duration) *SlowQueryLog {
+	return &SlowQueryLog{
+		Interface: inner,
+		slowQueryStore: &slowQueryStore{
+			threshold: threshold,
+			stats:     make(map[string]*SlowQueryStats),
+		},
+	}
+}
+
+// ParamsFilter implements gorm.ParamsFilter, so the wrapped logger's
+// ParameterizedQueries setting applies to captured queries too.
+func (l *SlowQueryLog) ParamsFilter(ctx context.Context, sql string, params ...any) (string, []any) {
+	if filter, ok := l.Interface.(gorm.ParamsFilter); ok {
+		return filter.ParamsFilter(ctx, sql, params...)
+	}
+	return sql, params
+}
+
+// LogMode implements logger.Interface, keeping the capture in place.
+func (l *SlowQueryLog) LogMode(level logger.LogLevel) logger.Interface {
+	return &SlowQueryLog{Interface: l.Interface.LogMode(level), slowQueryStore: l.slowQueryStore}
+}
+
+// Trace implements logger.Interface.
+func (l *SlowQueryLog) Trace(ctx context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
+	l.capture(ctx, begin, fc, err)
+	l.Interface.Trace(ctx, begin, fc, err)
+}
+
+func (l *slowQueryStore) capture(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	elapsed := time.Since(begin)
+	if elapsed < l.threshold {
+		return
+	}
+	sql, rows := fc()
+	l.Record(SlowQuery{
+		SQL:       sql,
+		Duration:  elapsed,
+		Rows:      rows,
+		RequestID: requestid.FromContext(ctx),
+		Error:     errString(err),
+		At:        begin,
+	})
+}
+
+// Record adds a slow query to the log.
+func (l *slowQueryStore) Record(q SlowQuery) {
+	fingerprint := Fingerprint(q.SQL)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.total++
+	if len(l.recent) < recentSlowQueries {
+		l.recent = append(l.recent, q)
+	} else {
+		l.recent[l.next] = q
+	}
+	l.next = (l.next + 1) % recentSlowQueries
+
+	s, ok := l.stats[fingerprint]
+	if !ok {
+		if len(l.stats) >= maxSlowQueryFingerprints {
+			l.evictOldestLocked()
+		}
+		s = &SlowQueryStats{Fingerprint: fingerprint}
+		l.stats[fingerprint] = s
+	}
+	s.Count++
+	s.TotalDuration += q.Duration
+	s.MaxDuration = max(s.MaxDuration, q.Duration)
+	s.LastSeen = q.At
+}
+
+func (l *slowQueryStore) evictOldestLocked() {
+	var oldest *SlowQueryStats
+	for _, s := range l.stats {
+		if oldest == nil || s.LastSeen.Before(oldest.LastSeen) {
+			oldest = s
+		}
+	}
+	delete(l.stats, oldest.Fingerprint)
+}
+
+// Total returns how many slow queries have been captured since startup.
+func (l *slowQueryStore) Total() int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.total
+}
+
+// Snapshot returns the most recent slow queries (newest first) and the
+// most frequent fingerprints, up to limit each.
+func (l *slowQueryStore) Snapshot(limit int) SlowQuerySnapshot {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	snap := SlowQuerySnapshot{Threshold: l.threshold, Total: l.total}
+	for i := 0; i < len(l.recent) && len(snap.Recent) < limit; i++ {
+		idx := (l.next - 1 - i + len(l.recent)) % len(l.recent)
+		snap.Recent = append(snap.Recent, l.recent[idx])
+	}
+
+	for _, s := range l.stats {
+		snap.MostFrequent = append(snap.MostFrequent, *s)
+	}
+	sort.Slice(snap.MostFrequent, func(i, j int) bool {
+		a, b := snap.MostFrequent[i], snap.MostFrequent[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return a.TotalDuration > b.TotalDuration
+	})
+	if len(snap.MostFrequent) > limit {
+		snap.MostFrequent = snap.MostFrequent[:limit]
+	}
+	return snap
+}
+
+var (
+	stringLiteral  = regexp.MustCompile(`'(?:[^']|'')*'`)
+	numberLiteral  = regexp.MustCompile(`\b\d+(?:\.\d+)?\b`)
+	literalList    = regexp.MustCompile(`\(\s*\?(?:\s*,\s*\?)*\s*\)`)
+	repeatedSpaces = regexp.MustCompile(`\s+`)
+)
+
+// Fingerprint normalizes a statement by replacing literals with ?, so
+// executions differing only in their arguments are grouped together.
+func Fingerprint(sql string) string {
+	sql = stringLiteral.ReplaceAllString(sql, "?")
+	sql = numberLiteral.ReplaceAllString(sql, "?")
+	sql = literalList.ReplaceAllString(sql, "(?)")
+	return repeatedSpaces.ReplaceAllString(sql, " ")
+}
+
+func errString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
@@ -0,0 +1,58 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"gorm.io/gorm/logger"
+
+	"github.com/Tomlord1122/todo-backend/internal/requestid"
+)
+
+func TestFingerprint(t *testing.T) {
+	got := Fingerprint(`SELECT * FROM "todos" WHERE title = 'it''s' AND id IN (1, 2,3) AND score > 1.5   LIMIT 10`)
+	want := `SELECT * FROM "todos" WHERE title = ? AND id IN (?) AND score > ? LIMIT ?`
+	if got != want {
+		t.Errorf("Fingerprint = %q, want %q", got, want)
+	}
+}
+
+func TestSlowQueryLogSnapshot(t *testing.T) {
+	l := NewSlowQueryLog(logger.Discard, 100*time.Millisecond)
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < recentSlowQueries+5; i++ {
+		l.Record(SlowQuery{SQL: "SELECT 1", Duration: time.Second, At: start.Add(time.Duration(i) * time.Second)})
+	}
+	l.Record(SlowQuery{SQL: "SELECT * FROM lists WHERE id = 7", Duration: 2 * time.Second, RequestID: "req-1", At: start.Add(time.Hour)})
+
+	snap := l.Snapshot(3)
+	if snap.Total != recentSlowQueries+6 {
+		t.Errorf("Total = %d, want %d", snap.Total, recentSlowQueries+6)
+	}
+	if len(snap.Recent) != 3 || snap.Recent[0].RequestID != "req-1" {
+		t.Errorf("expected newest query first, got %+v", snap.Recent)
+	}
+	if len(snap.MostFrequent) != 2 || snap.MostFrequent[0].Fingerprint != "SELECT ?" || snap.MostFrequent[0].Count != recentSlowQueries+5 {
+		t.Errorf("unexpected most frequent %+v", snap.MostFrequent)
+	}
+
+	// Copies made by LogMode share the captured queries
+	l.LogMode(logger.Silent).(*SlowQueryLog).Record(SlowQuery{SQL: "SELECT 2"})
+	if l.Total() != snap.Total+1 {
+		t.Error("LogMode copy did not share the slow query store")
+	}
+}
+
+func TestSlowQueryLogTagsRequestID(t *testing.T) {
+	l := NewSlowQueryLog(logger.Discard, 0)
+	query := func() (string, int64) { return "SELECT 1", 1 }
+	l.Trace(requestid.NewContext(context.Background(), "req-1"), time.Now(), query, nil)
+	// Queries run without the request's context are kept untagged
+	l.Trace(context.Background(), time.Now(), query, nil)
+
+	snap := l.Snapshot(2)
+	if len(snap.Recent) != 2 || snap.Recent[0].RequestID != "" || snap.Recent[1].RequestID != "req-1" {
+		t.Errorf("Recent = %+v, want the untagged query after the tagged one", snap.Recent)
+	}
+}
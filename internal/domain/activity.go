@@ -0,0 +1,40 @@
+package domain
+
+import "gorm.io/gorm"
+
+// Activity kinds
+const (
+	// ActivityCreated records a new todo.
+	ActivityCreated = "created"
+	// ActivityCompleted and ActivityReopened record completion changes.
+	ActivityCompleted = "completed"
+	ActivityReopened  = "reopened"
+	// ActivityEstimated means the estimate changed from OldValue to NewValue.
+	ActivityEstimated = "estimate_changed"
+	// ActivityMoved means the todo moved from list OldValue to list NewValue;
+	// empty values mean no list.
+	ActivityMoved = "list_changed"
+	// ActivityDeleted records a todo moved to the trash, ActivityRestored
+	// one taken back out.
+	ActivityDeleted  = "deleted"
+	ActivityRestored = "restored"
+	// ActivityEscalated means an overdue todo's priority was raised or its
+	// owner re-notified; OldValue and NewValue hold the priority.
+	ActivityEscalated = "escalated"
+)
+
+// Activity is an entry in a todo's history.
+type Activity struct {
+	gorm.Model
+	TodoID   uint   `gorm:"not null;index"`
+	UserID   uint   // who acted, when known; 0 for background jobs
+	Kind     string `gorm:"not null;index"`
+	OldValue string
+	NewValue string
+	Detail   string
+	// ListID and OpenEstimate snapshot the todo after the change: its list
+	// and its estimate if still open, 0 once done or deleted. Burndown
+	// charts are replayed from them.
+	ListID       *uint   `gorm:"index"`
+	OpenEstimate float64 `gorm:"not null;default:0"`
+}
@@ -0,0 +1,19 @@
+package domain
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// APIKey lets a script or CI job act as a user without signing in. Like
+// session tokens, the key itself is never stored, only its SHA-256 hash;
+// Prefix is kept so users can tell their keys apart.
+type APIKey struct {
+	gorm.Model
+	UserID     uint   `gorm:"not null;index"`
+	Name       string `gorm:"not null"`
+	Prefix     string `gorm:"not null"`
+	KeyHash    string `gorm:"not null;uniqueIndex"`
+	LastUsedAt *time.Time
+}
@@ -0,0 +1,49 @@
+package domain
+
+import "gorm.io/gorm"
+
+// Attachment statuses
+const (
+	// AttachmentPending means an upload URL was issued but not confirmed yet.
+	AttachmentPending = "pending"
+	// AttachmentUploaded means the object was verified and is available.
+	AttachmentUploaded = "uploaded"
+)
+
+// Scan statuses. Attachments uploaded while scanning is disabled keep an
+// empty status and are served without a verdict.
+const (
+	// ScanScanning means the file is quarantined until the scanner reports back.
+	ScanScanning = "scanning"
+	// ScanClean means no malware was found.
+	ScanClean = "clean"
+	// ScanInfected means malware was found; the file is never served.
+	ScanInfected = "infected"
+)
+
+// Thumbnail statuses. Attachments that can't be previewed keep an empty status.
+const (
+	// ThumbnailPending means thumbnails are waiting to be generated.
+	ThumbnailPending = "pending"
+	// ThumbnailReady means every thumbnail size is stored.
+	ThumbnailReady = "ready"
+	// ThumbnailFailed means generation failed and won't be retried.
+	ThumbnailFailed = "failed"
+)
+
+// Attachment is a file attached to a todo. The bytes live in object storage
+// under ObjectKey; this row only holds metadata.
+type Attachment struct {
+	gorm.Model
+	TodoID      uint   `gorm:"not null;index"`
+	Filename    string `gorm:"not null"`
+	ContentType string `gorm:"not null"`
+	Size        int64  `gorm:"not null"` // declared at presign time, verified on confirm
+	ObjectKey   string `gorm:"not null;uniqueIndex"`
+	Status      string `gorm:"not null;index"`
+	ScanStatus  string `gorm:"index"`
+	// ScanSignature names the malware found in infected files
+	ScanSignature string
+	// ThumbnailStatus tracks preview generation, which runs in the background after confirm.
+	ThumbnailStatus string `gorm:"index"`
+}
@@ -0,0 +1,19 @@
+package domain
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// AuthSession is a signed-in session. The bearer token itself is never
+// stored, only its SHA-256 hash. The session records how the user signed
+// in, so removing that passkey or identity ends it.
+type AuthSession struct {
+	gorm.Model
+	UserID     uint      `gorm:"not null;index"`
+	TokenHash  string    `gorm:"not null;uniqueIndex"`
+	PasskeyID  *uint     `gorm:"index"`
+	IdentityID *uint     `gorm:"index"`
+	ExpiresAt  time.Time `gorm:"not null;index"`
+}
@@ -0,0 +1,33 @@
+package domain
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// CalendarConnection syncs a user's todos with due dates to a dedicated
+// Google Calendar. The OAuth tokens are secrets and never leave the server.
+type CalendarConnection struct {
+	gorm.Model
+	UserID            uint   `gorm:"not null;uniqueIndex:idx_calendar_connections_user,where:deleted_at IS NULL"`
+	RefreshToken      string `gorm:"not null"`
+	AccessToken       string
+	AccessTokenExpiry *time.Time
+	CalendarID        string `gorm:"not null"`
+	// SyncToken lists only the events changed since the last sync
+	SyncToken    string
+	LastSyncedAt *time.Time
+}
+
+// CalendarEvent pairs a todo with its event. SyncedDue and SyncedTitle are
+// what the event was last written or read with, so a change on either side
+// is copied to the other once and the copy isn't mistaken for a new change.
+type CalendarEvent struct {
+	gorm.Model
+	ConnectionID uint      `gorm:"not null;index"`
+	TodoID       uint      `gorm:"not null;index"`
+	EventID      string    `gorm:"not null;index"`
+	SyncedDue    time.Time `gorm:"not null"`
+	SyncedTitle  string    `gorm:"not null"`
+}
@@ -0,0 +1,13 @@
+package domain
+
+import "gorm.io/gorm"
+
+// ChecklistItem is one line of a todo's checklist. Unlike a subtask it has
+// a position, so the checklist reads in the order the user arranged it.
+type ChecklistItem struct {
+	gorm.Model
+	TodoID   uint   `gorm:"not null;index:idx_checklist_items_todo_position"`
+	Position int    `gorm:"not null;index:idx_checklist_items_todo_position"` // 0-based, contiguous per todo
+	Text     string `gorm:"not null"`
+	Done     bool   `gorm:"not null"`
+}
@@ -0,0 +1,20 @@
+package domain
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ExternalIdentity links a user to their account at an OpenID Connect
+// provider. Subject is the provider's stable user identifier, so a user
+// can change their email at the provider without losing the link.
+type ExternalIdentity struct {
+	gorm.Model
+	UserID      uint   `gorm:"not null;index"`
+	Provider    string `gorm:"not null;uniqueIndex:idx_external_identities_subject,where:deleted_at IS NULL"`
+	Subject     string `gorm:"not null;uniqueIndex:idx_external_identities_subject,where:deleted_at IS NULL"`
+	Email       string
+	Name        string
+	LastLoginAt *time.Time
+}
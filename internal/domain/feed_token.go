@@ -0,0 +1,12 @@
+package domain
+
+import "gorm.io/gorm"
+
+// FeedToken grants read-only access to a user's todo feeds.
+// The token is embedded in the feed URL because feed readers
+// can't send Authorization headers.
+type FeedToken struct {
+	gorm.Model
+	UserID uint   `gorm:"not null;index"`
+	Token  string `gorm:"not null;uniqueIndex"`
+}
@@ -0,0 +1,35 @@
+package domain
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// FocusSession is a block of focused work, optionally on a todo. A user has
+// at most one running session, the one without an EndedAt.
+type FocusSession struct {
+	gorm.Model
+	UserID    uint      `gorm:"not null;index;uniqueIndex:idx_focus_sessions_running,where:ended_at IS NULL AND deleted_at IS NULL"`
+	TodoID    *uint     `gorm:"index"`
+	StartedAt time.Time `gorm:"not null;index"`
+	// PlannedMinutes is the Pomodoro length; a session left running ends
+	// when it elapses. Zero means open-ended.
+	PlannedMinutes int `gorm:"not null;default:0"`
+	EndedAt        *time.Time
+}
+
+// EffectiveEnd returns when the session stopped counting at now: when it
+// was stopped, when its planned length ran out, or now if still running.
+func (f *FocusSession) EffectiveEnd(now time.Time) time.Time {
+	end := now
+	if f.EndedAt != nil {
+		end = *f.EndedAt
+	}
+	if f.PlannedMinutes > 0 {
+		if planned := f.StartedAt.Add(time.Duration(f.PlannedMinutes) * time.Minute); planned.Before(end) {
+			end = planned
+		}
+	}
+	return end
+}
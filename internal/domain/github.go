@@ -0,0 +1,42 @@
+package domain
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// GitHubLink syncs a list with the issues of a GitHub repository that are
+// assigned to Login. Login is the account that authorized the link, and
+// GitHub is called with its token.
+type GitHubLink struct {
+	gorm.Model
+	ListID       uint   `gorm:"not null;uniqueIndex:idx_github_links_list,where:deleted_at IS NULL"`
+	UserID       uint   `gorm:"not null;index"`
+	Owner        string `gorm:"not null;index:idx_github_links_repo"`
+	Repo         string `gorm:"not null;index:idx_github_links_repo"`
+	Login        string `gorm:"not null"`
+	AccessToken  string
+	RefreshToken string
+	// AccessTokenExpiry is nil for tokens that don't expire
+	AccessTokenExpiry *time.Time
+	LastSyncedAt      *time.Time
+}
+
+// TableName keeps GORM from naming the table git_hub_links.
+func (GitHubLink) TableName() string { return "github_links" }
+
+// GitHubIssueSync pairs an issue with the todo created for it. Closed is
+// the state both sides last agreed on: a side that differs from it has
+// changed since, so the change is copied to the other side exactly once and
+// the resulting webhook or sync pass finds nothing left to do.
+type GitHubIssueSync struct {
+	gorm.Model
+	LinkID      uint `gorm:"not null;uniqueIndex:idx_github_issue_syncs_issue"`
+	IssueNumber int  `gorm:"not null;uniqueIndex:idx_github_issue_syncs_issue"`
+	TodoID      uint `gorm:"not null;index"`
+	Closed      bool `gorm:"not null"`
+}
+
+// TableName keeps GORM from naming the table git_hub_issue_syncs.
+func (GitHubIssueSync) TableName() string { return "github_issue_syncs" }
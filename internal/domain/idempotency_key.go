@@ -0,0 +1,21 @@
+package domain
+
+import "time"
+
+// IdempotencyKey remembers the response to a request sent with an
+// Idempotency-Key header, so a retry of the request gets that response
+// instead of repeating it. Keys belong to a user and expire.
+type IdempotencyKey struct {
+	UserID uint   `gorm:"primaryKey;autoIncrement:false"`
+	Key    string `gorm:"primaryKey"`
+	// Fingerprint hashes the request, to catch a key reused for another
+	// request
+	Fingerprint string `gorm:"not null"`
+	// StatusCode is 0 while the first request is still being handled
+	StatusCode int
+	// Header holds the response headers worth replaying, like Content-Type
+	Header    map[string]string `gorm:"serializer:json"`
+	Body      []byte
+	CreatedAt time.Time
+	ExpiresAt time.Time `gorm:"index"`
+}
@@ -0,0 +1,47 @@
+package domain
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Import statuses
+const (
+	// ImportPending means the import waits for the background job.
+	ImportPending = "pending"
+	// ImportRunning means the job is creating todos. A running import
+	// whose UpdatedAt stops moving was abandoned and is picked up again.
+	ImportRunning = "running"
+	// ImportDone means every row was processed; rows may still have failed.
+	ImportDone = "done"
+	// ImportFailed means the import couldn't continue, see Error.
+	ImportFailed = "failed"
+)
+
+// Import creates todos from a file exported by another tool. The file is
+// kept in Data until the import finishes so it can resume after a crash;
+// Processed is how many of its rows have been handled so far.
+type Import struct {
+	gorm.Model
+	UserID     uint   `gorm:"not null;index"`
+	ListID     *uint  // Optional list the todos are added to
+	Format     string `gorm:"not null"`
+	Data       []byte
+	Status     string `gorm:"not null;index"`
+	Error      string
+	Total      int `gorm:"not null;default:0"`
+	Processed  int `gorm:"not null;default:0"`
+	Imported   int `gorm:"not null;default:0"`
+	Failed     int `gorm:"not null;default:0"`
+	StartedAt  *time.Time
+	FinishedAt *time.Time
+}
+
+// ImportError is a row of an import that couldn't be imported.
+type ImportError struct {
+	gorm.Model
+	ImportID uint `gorm:"not null;index"`
+	Line     int  `gorm:"not null"`
+	Message  string
+}
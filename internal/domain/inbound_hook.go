@@ -0,0 +1,16 @@
+package domain
+
+import "gorm.io/gorm"
+
+// InboundHook lets automation tools create todos by posting JSON to a
+// secret URL. The templates map the payload onto todo fields, see package
+// mapping.
+type InboundHook struct {
+	gorm.Model
+	UserID              uint   `gorm:"not null;index"`
+	Token               string `gorm:"not null;uniqueIndex"`
+	ListID              *uint  // List new todos go into, if any
+	Priority            string // Priority of new todos; empty uses the default
+	TitleTemplate       string `gorm:"not null"`
+	DescriptionTemplate string
+}
@@ -0,0 +1,39 @@
+package domain
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Job statuses
+const (
+	// JobPending means the job waits for RunAt.
+	JobPending = "pending"
+	// JobRunning means a worker claimed the job until LockedUntil.
+	JobRunning = "running"
+	// JobDone means the job succeeded.
+	JobDone = "done"
+	// JobFailed means every attempt failed, see LastError.
+	JobFailed = "failed"
+)
+
+// Job is background work in the job queue, run by the jobs.Pool of any
+// instance. A running job is claimed until LockedUntil, which its worker
+// keeps extending; if the worker dies, the claim runs out and the job is
+// run again, so delivery is at least once.
+type Job struct {
+	gorm.Model
+	Kind string `gorm:"not null"`
+	// Key deduplicates jobs: a job with the key of one already queued,
+	// running or finished isn't enqueued. Jobs without a key never clash.
+	Key *string `gorm:"uniqueIndex"`
+	// Payload is the job's JSON arguments
+	Payload     string    `gorm:"not null"`
+	Status      string    `gorm:"not null;default:pending;index:idx_jobs_due"`
+	RunAt       time.Time `gorm:"not null;index:idx_jobs_due"`
+	Attempts    int       `gorm:"not null;default:0"`
+	LastError   string
+	LockedUntil *time.Time
+	FinishedAt  *time.Time
+}
@@ -0,0 +1,11 @@
+package domain
+
+import "time"
+
+// Lease is a named, time-limited claim held by one instance, used for
+// leader election. A lease whose ExpiresAt passed is free to take.
+type Lease struct {
+	Name      string `gorm:"primaryKey"`
+	Holder    string `gorm:"not null"`
+	ExpiresAt time.Time
+}
@@ -0,0 +1,10 @@
+package domain
+
+import "gorm.io/gorm"
+
+// List groups todos, e.g. "Work" or "Groceries".
+type List struct {
+	gorm.Model
+	Name   string `gorm:"not null"`
+	UserID uint   `gorm:"not null;index"`
+}
@@ -0,0 +1,50 @@
+package domain
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Notion export statuses
+const (
+	// NotionExportPending means the export waits for the background job.
+	NotionExportPending = "pending"
+	// NotionExportRunning means the job is writing rows.
+	NotionExportRunning = "running"
+	// NotionExportDone means every row was tried; rows may still have failed.
+	NotionExportDone = "done"
+	// NotionExportFailed means nothing could be exported, see Error.
+	NotionExportFailed = "failed"
+)
+
+// Notion export row statuses
+const (
+	NotionRowPending  = "pending"
+	NotionRowExported = "exported"
+	NotionRowFailed   = "failed"
+)
+
+// NotionExport copies todos into a Notion database. Mapping maps todo
+// fields to the names of database properties.
+type NotionExport struct {
+	gorm.Model
+	UserID     uint              `gorm:"not null;index"`
+	DatabaseID string            `gorm:"not null"`
+	Mapping    map[string]string `gorm:"serializer:json"`
+	Status     string            `gorm:"not null;index"`
+	Error      string
+	StartedAt  *time.Time
+	FinishedAt *time.Time
+}
+
+// NotionExportRow is one todo of an export, reported separately so one bad
+// row doesn't fail the rest.
+type NotionExportRow struct {
+	gorm.Model
+	ExportID uint   `gorm:"not null;index"`
+	TodoID   uint   `gorm:"not null"`
+	Status   string `gorm:"not null"`
+	PageID   string
+	Error    string
+}
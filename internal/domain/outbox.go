@@ -0,0 +1,43 @@
+package domain
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Outbox event statuses
+const (
+	// OutboxPending means the event waits to be published.
+	OutboxPending = "pending"
+	// OutboxPublished means the event bus accepted the event.
+	OutboxPublished = "published"
+	// OutboxFailed means every publish attempt failed, see LastError.
+	OutboxFailed = "failed"
+)
+
+// OutboxEvent is a domain event stored in the same transaction as the
+// change it describes, so it can't get lost when the change is saved but
+// the event bus is unreachable. A relay publishes it afterwards: it is
+// claimed until ClaimedUntil while it is published, and published again
+// if the claim runs out first, so delivery is at least once.
+type OutboxEvent struct {
+	gorm.Model
+	// EventID is the event's own ID, for consumers to deduplicate by
+	EventID string `gorm:"not null;uniqueIndex"`
+	// RequestID is the ID of the request that caused the event, if any
+	RequestID  string
+	Type       string    `gorm:"not null"`
+	UserID     uint      `gorm:"not null"`
+	TodoID     uint      `gorm:"not null"`
+	OccurredAt time.Time `gorm:"not null"`
+	// Data is the event's JSON data, if any
+	Data   string
+	Status string `gorm:"not null;default:pending;index:idx_outbox_events_due"`
+	// NextAttemptAt is when the event is due, later after failed attempts
+	NextAttemptAt time.Time `gorm:"not null;index:idx_outbox_events_due"`
+	Attempts      int       `gorm:"not null;default:0"`
+	LastError     string
+	ClaimedUntil  *time.Time
+	PublishedAt   *time.Time
+}
@@ -0,0 +1,24 @@
+package domain
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Passkey is a WebAuthn credential a user signs in with instead of a
+// password.
+type Passkey struct {
+	gorm.Model
+	UserID uint   `gorm:"not null;index"`
+	Name   string `gorm:"not null"`
+	// CredentialID is the authenticator's ID for the passkey, base64url
+	CredentialID string `gorm:"not null;uniqueIndex:idx_passkeys_credential,where:deleted_at IS NULL"`
+	// PublicKey is the COSE_Key the login signatures are checked with
+	PublicKey []byte `gorm:"not null"`
+	// SignCount is the last signature counter, used to detect clones
+	SignCount  uint32 `gorm:"not null;default:0"`
+	Transports string // Comma-separated hints like "internal,hybrid"
+	BackedUp   bool   // Synced by a password manager rather than device-bound
+	LastUsedAt *time.Time
+}
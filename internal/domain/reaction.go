@@ -0,0 +1,12 @@
+package domain
+
+import "gorm.io/gorm"
+
+// Reaction is one user's emoji on a todo. A user can react with several
+// different emoji, but only once with each.
+type Reaction struct {
+	gorm.Model
+	TodoID uint   `gorm:"not null;uniqueIndex:idx_reactions_todo_user_emoji,where:deleted_at IS NULL"`
+	UserID uint   `gorm:"not null;uniqueIndex:idx_reactions_todo_user_emoji,where:deleted_at IS NULL"`
+	Emoji  string `gorm:"not null;uniqueIndex:idx_reactions_todo_user_emoji,where:deleted_at IS NULL"`
+}
@@ -0,0 +1,36 @@
+package domain
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Reminder statuses
+const (
+	// ReminderPending means the reminder waits for RemindAt.
+	ReminderPending = "pending"
+	// ReminderDelivered means the channel accepted the reminder.
+	ReminderDelivered = "delivered"
+	// ReminderFailed means every delivery attempt failed, see LastError.
+	ReminderFailed = "failed"
+	// ReminderSkipped means the todo was completed or deleted first.
+	ReminderSkipped = "skipped"
+)
+
+// Reminder notifies a todo's owner at RemindAt over a notification
+// channel. Once due, it is sent by a job on the job queue; Attempts and
+// LastError mirror that job's.
+type Reminder struct {
+	gorm.Model
+	TodoID   uint      `gorm:"not null;index"`
+	RemindAt time.Time `gorm:"not null;index:idx_reminders_due"`
+	Channel  string    `gorm:"not null"`
+	// Target is the recipient for Channel; empty means the owner's
+	// notification target from their preferences
+	Target      string
+	Status      string `gorm:"not null;default:pending;index:idx_reminders_due"`
+	Attempts    int    `gorm:"not null;default:0"`
+	LastError   string
+	DeliveredAt *time.Time
+}
@@ -0,0 +1,22 @@
+package domain
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ReportSchedule delivers a weekly report to a user on a recurring basis.
+type ReportSchedule struct {
+	gorm.Model
+	UserID    uint      `gorm:"not null;index"`
+	Channel   string    `gorm:"not null"` // notify channel name, e.g. "email" or "webhook"
+	Target    string    `gorm:"not null"` // email address or webhook URL
+	Format    string    `gorm:"not null"` // report format, "md" or "pdf"
+	Weekday   int       `gorm:"not null"` // 0 = Sunday, matching time.Weekday
+	Hour      int       `gorm:"not null"` // 0-23, in Timezone
+	Timezone  string    `gorm:"not null"` // IANA name, e.g. "Europe/Berlin"
+	NextRunAt time.Time `gorm:"not null;index"`
+	LastRunAt *time.Time
+	LastError string
+}
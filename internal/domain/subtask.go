@@ -0,0 +1,12 @@
+package domain
+
+import "gorm.io/gorm"
+
+// Subtask is a smaller piece of work inside a todo. The todo's progress is
+// the share of its subtasks completed.
+type Subtask struct {
+	gorm.Model
+	TodoID    uint   `gorm:"not null;index"`
+	Title     string `gorm:"not null"`
+	Completed bool   `gorm:"not null"`
+}
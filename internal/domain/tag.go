@@ -0,0 +1,12 @@
+package domain
+
+import "gorm.io/gorm"
+
+// Tag labels todos across lists, e.g. "work" or "errand". Todos and tags
+// are linked through the todo_tags join table.
+type Tag struct {
+	gorm.Model
+	UserID uint `gorm:"not null;uniqueIndex:idx_tags_user_name,where:deleted_at IS NULL"`
+	// Name is stored lower-cased and unique per user
+	Name string `gorm:"not null;uniqueIndex:idx_tags_user_name,where:deleted_at IS NULL"`
+}
@@ -1,10 +1,56 @@
 package domain
 
-import "gorm.io/gorm"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
 
 type Todo struct {
 	gorm.Model
-	Title     string `gorm:"not null"`
-	Completed bool   `gorm:"not null"`
-	UserID    uint   // Example: If todos belong to users
+	Title       string     `gorm:"not null"`
+	Description string     `gorm:"type:text"`
+	Completed   bool       `gorm:"not null"`
+	Priority    string     `gorm:"not null;default:normal"` // low, normal or high
+	UserID      uint       // Example: If todos belong to users
+	ListID      *uint      `gorm:"index"` // Optional list the todo belongs to
+	DueDate     *time.Time `gorm:"index"` // Optional deadline
+	StartDate   *time.Time // Optional planned start; never after DueDate
+	CompletedAt *time.Time // Set when the todo transitions to completed
+	// Recurrence is an iCalendar RRULE repeating the todo from its due
+	// date. Completing the todo sets NextOccurrence, the due date of the
+	// next instance, which the recurrence job then creates and hands the
+	// rule on to.
+	Recurrence     string
+	NextOccurrence *time.Time `gorm:"index"`
+	// Optional location; Latitude and Longitude are set together. RadiusMeters
+	// is the geofence for "remind me when near" clients.
+	Latitude     *float64 `gorm:"index:idx_todos_location"`
+	Longitude    *float64 `gorm:"index:idx_todos_location"`
+	RadiusMeters *float64
+	// Tags label the todo; they belong to the same user
+	Tags []Tag `gorm:"many2many:todo_tags"`
+	// Subtasks are only loaded when a single todo is read
+	Subtasks []Subtask
+	// DependsOn lists the todos that must be done before this one starts
+	DependsOn []uint `gorm:"serializer:json"`
+	// Estimate is the expected effort, in minutes or points
+	Estimate *float64
+	// Checklist counts are kept in sync by the checklist service so listings
+	// can show progress without loading the items
+	ChecklistTotal int `gorm:"not null;default:0"`
+	ChecklistDone  int `gorm:"not null;default:0"`
+	// ReactionCounts maps emoji to how many users reacted with it, kept in
+	// sync by the reaction service
+	ReactionCounts map[string]int `gorm:"serializer:json"`
+	// EscalationLevel counts the overdue thresholds already acted on; it's
+	// reset when the due date changes
+	EscalationLevel int `gorm:"not null;default:0"`
+	// OverdueNotifiedAt is set once the overdue reminder was claimed, so it
+	// is sent once even with several instances; reset with the due date
+	OverdueNotifiedAt *time.Time
+	// Version counts the saves of the todo. A save only applies while the
+	// version is the one the todo was read at, so concurrent edits can't
+	// silently overwrite each other; clients see it as the ETag.
+	Version uint `gorm:"not null;default:1"`
 }
@@ -0,0 +1,11 @@
+package domain
+
+import "gorm.io/gorm"
+
+// TodoWatcher is a user following a todo they don't own, to be notified
+// when it changes.
+type TodoWatcher struct {
+	gorm.Model
+	TodoID uint `gorm:"not null;uniqueIndex:idx_todo_watchers_todo_user,where:deleted_at IS NULL"`
+	UserID uint `gorm:"not null;index;uniqueIndex:idx_todo_watchers_todo_user,where:deleted_at IS NULL"`
+}
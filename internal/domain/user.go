@@ -0,0 +1,31 @@
+package domain
+
+import "gorm.io/gorm"
+
+// Role is what a user may do; see package authz for the permissions of
+// each role.
+type Role string
+
+// The roles a user can have.
+const (
+	// RoleAdmin may do anything, including managing other users
+	RoleAdmin Role = "admin"
+	// RoleMember manages their own todos; new accounts are members
+	RoleMember Role = "member"
+	// RoleViewer may only read their own todos
+	RoleViewer Role = "viewer"
+)
+
+// Roles lists every role, most privileged first.
+var Roles = []Role{RoleAdmin, RoleMember, RoleViewer}
+
+// User is an account that signs in with an email address and password.
+type User struct {
+	gorm.Model
+	// Email is stored lower-cased, so lookups are case-insensitive
+	Email string `gorm:"not null;uniqueIndex:idx_users_email,where:deleted_at IS NULL"`
+	Name  string
+	// PasswordHash is the bcrypt hash of the password
+	PasswordHash string `gorm:"not null"`
+	Role         Role   `gorm:"not null;default:member;index"`
+}
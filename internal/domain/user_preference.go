@@ -0,0 +1,24 @@
+package domain
+
+import "time"
+
+// UserPreference stores per-user settings. Users without a row get the defaults.
+type UserPreference struct {
+	UserID uint `gorm:"primaryKey;autoIncrement:false"`
+	// AutoApplySuggestions fills in suggested priority and due date on new
+	// todos when the client didn't provide them.
+	AutoApplySuggestions bool `gorm:"not null;default:false"`
+	// Timezone is the user's IANA zone, e.g. "Europe/Berlin"; empty means UTC.
+	// It decides which calendar day counts as today for overdue todos.
+	Timezone string
+	// EscalationMode opts into overdue escalation: "priority", "notify" or
+	// "both". Empty leaves overdue todos alone.
+	EscalationMode string `gorm:"not null;default:'';index"`
+	// NotifyOverdue sends one reminder when a todo becomes overdue.
+	NotifyOverdue bool `gorm:"not null;default:false;index"`
+	// NotificationChannel and NotificationTarget say where reminders go.
+	NotificationChannel string
+	NotificationTarget  string
+	CreatedAt           time.Time
+	UpdatedAt           time.Time
+}
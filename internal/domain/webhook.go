@@ -0,0 +1,63 @@
+package domain
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Webhook delivery statuses
+const (
+	// WebhookDeliveryPending means the delivery waits for its job.
+	WebhookDeliveryPending = "pending"
+	// WebhookDeliveryDelivered means the endpoint answered with a 2xx.
+	WebhookDeliveryDelivered = "delivered"
+	// WebhookDeliveryFailed means every attempt failed, see LastError.
+	WebhookDeliveryFailed = "failed"
+)
+
+// Webhook sends a user's todo events to a URL of theirs. Deliveries are
+// signed with Secret, so the receiver can tell they came from us.
+type Webhook struct {
+	gorm.Model
+	UserID uint   `gorm:"not null;index"`
+	URL    string `gorm:"not null"`
+	Secret string `gorm:"not null"`
+	// Events is the comma-separated event types sent; empty sends all
+	Events string
+	Active bool `gorm:"not null;default:true"`
+}
+
+// EventTypes returns the event types the webhook is limited to, or nil if
+// it gets all of them.
+func (w *Webhook) EventTypes() []string {
+	if w.Events == "" {
+		return nil
+	}
+	return strings.Split(w.Events, ",")
+}
+
+// Subscribes reports whether the webhook is sent events of eventType.
+func (w *Webhook) Subscribes(eventType string) bool {
+	return w.Active && (w.Events == "" || slices.Contains(w.EventTypes(), eventType))
+}
+
+// WebhookDelivery is an event sent, or to be sent, to a webhook, and the
+// log of its attempts. It is sent, and retried, by a job on the job queue.
+type WebhookDelivery struct {
+	gorm.Model
+	WebhookID uint   `gorm:"not null;uniqueIndex:idx_webhook_deliveries_event;index"`
+	EventID   string `gorm:"not null;uniqueIndex:idx_webhook_deliveries_event"`
+	EventType string `gorm:"not null"`
+	// Payload is the JSON body sent, the same on every attempt
+	Payload  string `gorm:"not null"`
+	Status   string `gorm:"not null;default:pending"`
+	Attempts int    `gorm:"not null;default:0"`
+	// ResponseStatus is the HTTP status of the last attempt, 0 if it got
+	// no response
+	ResponseStatus int
+	LastError      string
+	DeliveredAt    *time.Time
+}
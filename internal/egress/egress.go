@@ -0,0 +1,70 @@
+// Package egress makes HTTP clients for requests to URLs that users chose,
+// such as webhooks. Those requests must not reach the server's own network:
+// the clients refuse to connect to loopback, private and link-local
+// addresses, whatever the host name resolves to, and don't follow
+// redirects, which could lead there.
+package egress
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+)
+
+// ErrBlockedAddress is returned when a request would connect to an address
+// of the server's own network.
+var ErrBlockedAddress = errors.New("address is not allowed")
+
+// NewClient returns a client with timeout that only connects to public
+// addresses and returns redirects as responses instead of following them.
+func NewClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{Timeout: 10 * time.Second, KeepAlive: 30 * time.Second, Control: control}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// A proxy would be dialed instead of the target, bypassing the check
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// Allowed reports whether addr may be connected to: it must not be a
+// loopback, private, link-local, multicast or unspecified address.
+func Allowed(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsValid() && addr.IsGlobalUnicast() && !addr.IsPrivate()
+}
+
+// ValidateHost rejects host names that are blocked addresses themselves,
+// so they can be refused before any request is made. Other names are
+// checked when they are dialed.
+func ValidateHost(host string) error {
+	if host == "localhost" {
+		return fmt.Errorf("%w: %s", ErrBlockedAddress, host)
+	}
+	if addr, err := netip.ParseAddr(host); err == nil && !Allowed(addr) {
+		return fmt.Errorf("%w: %s", ErrBlockedAddress, host)
+	}
+	return nil
+}
+
+// control is the net.Dialer hook that checks the address actually dialed,
+// after name resolution.
+func control(network, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrBlockedAddress, address)
+	}
+	if !Allowed(addrPort.Addr()) {
+		return fmt.Errorf("%w: %s", ErrBlockedAddress, addrPort.Addr())
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package egress
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+)
+
+func TestAllowed(t *testing.T) {
+	for addr, want := range map[string]bool{
+		"93.184.215.14":         true,
+		"2606:2800:21f:cb07::1": true,
+		"127.0.0.1":             false,
+		"::1":                   false,
+		"10.1.2.3":              false,
+		"172.16.0.1":            false,
+		"192.168.1.1":           false,
+		"fd00::1":               false,
+		"169.254.169.254":       false,
+		"fe80::1":               false,
+		"0.0.0.0":               false,
+		"::ffff:127.0.0.1":      false,
+		"224.0.0.1":             false,
+	} {
+		if got := Allowed(netip.MustParseAddr(addr)); got != want {
+			t.Errorf("Allowed(%s) = %t, want %t", addr, got, want)
+		}
+	}
+}
+
+func TestValidateHost(t *testing.T) {
+	for _, host := range []string{"localhost", "127.0.0.1", "::1", "169.254.169.254"} {
+		if err := ValidateHost(host); !errors.Is(err, ErrBlockedAddress) {
+			t.Errorf("ValidateHost(%q) = %v, want blocked", host, err)
+		}
+	}
+	// Names are checked when they are dialed
+	for _, host := range []string{"example.com", "93.184.215.14"} {
+		if err := ValidateHost(host); err != nil {
+			t.Errorf("ValidateHost(%q) = %v", host, err)
+		}
+	}
+}
+
+func TestClientRefusesOwnNetwork(t *testing.T) {
+	called := false
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer ts.Close()
+
+	_, err := NewClient(time.Second).Get(ts.URL)
+	if !errors.Is(err, ErrBlockedAddress) || called {
+		t.Errorf("Get of a loopback server = %v, want it blocked", err)
+	}
+}
+
+func TestClientDoesNotFollowRedirects(t *testing.T) {
+	ts := httptest.NewServer(http.RedirectHandler("http://127.0.0.1/admin", http.StatusFound))
+	defer ts.Close()
+	// The test server is on loopback, so dial it with a client that
+	// skips the address check but keeps the redirect policy
+	client := NewClient(time.Second)
+	client.Transport = ts.Client().Transport
+
+	resp, err := client.Get(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		t.Errorf("status = %d, want the redirect itself", resp.StatusCode)
+	}
+}
@@ -0,0 +1,105 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/segmentio/kafka-go"
+
+	"github.com/Tomlord1122/todo-backend/internal/requestid"
+)
+
+// NATSPublisher publishes events to NATS, on the subject of their prefix
+// and type, e.g. todo-backend.todo.created.
+type NATSPublisher struct {
+	conn   *nats.Conn
+	prefix string
+}
+
+// NewNATSPublisher connects to the NATS server at url. The client
+// reconnects by itself and buffers events meanwhile.
+func NewNATSPublisher(url, subjectPrefix string) (*NATSPublisher, error) {
+	conn, err := nats.Connect(url, nats.Name("todo-backend"), nats.MaxReconnects(-1))
+	if err != nil {
+		return nil, fmt.Errorf("connecting to NATS: %w", err)
+	}
+	return &NATSPublisher{conn: conn, prefix: subjectPrefix}, nil
+}
+
+// Publish implements Publisher.
+func (p *NATSPublisher) Publish(ctx context.Context, event Event) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("encoding %s event: %w", event.Type, err)
+	}
+	msg := nats.NewMsg(p.prefix + "." + event.Type)
+	msg.Data = payload
+	// Lets JetStream streams drop duplicates
+	msg.Header.Set(nats.MsgIdHdr, event.ID)
+	if event.RequestID != "" {
+		msg.Header.Set(requestid.Header, event.RequestID)
+	}
+	if err := p.conn.PublishMsg(msg); err != nil {
+		return fmt.Errorf("publishing %s event to NATS: %w", event.Type, err)
+	}
+	return nil
+}
+
+// Close implements PublishCloser.
+func (p *NATSPublisher) Close(ctx context.Context) error {
+	defer p.conn.Close()
+	if err := p.conn.FlushWithContext(ctx); err != nil {
+		return fmt.Errorf("flushing NATS events: %w", err)
+	}
+	return nil
+}
+
+// KafkaPublisher publishes events to a Kafka topic, keyed by user so each
+// user's events stay in order on one partition.
+type KafkaPublisher struct {
+	writer *kafka.Writer
+}
+
+// NewKafkaPublisher creates a publisher for topic on the given brokers.
+// Connections are made when publishing.
+func NewKafkaPublisher(brokers []string, topic string) *KafkaPublisher {
+	return &KafkaPublisher{writer: &kafka.Writer{
+		Addr:         kafka.TCP(brokers...),
+		Topic:        topic,
+		Balancer:     &kafka.Hash{},
+		RequiredAcks: kafka.RequireAll,
+		// Events are sent one by one, as the service layer emits them
+		BatchTimeout: time.Millisecond,
+	}}
+}
+
+// Publish implements Publisher.
+func (p *KafkaPublisher) Publish(ctx context.Context, event Event) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("encoding %s event: %w", event.Type, err)
+	}
+	headers := []kafka.Header{{Key: "type", Value: []byte(event.Type)}, {Key: "id", Value: []byte(event.ID)}}
+	if event.RequestID != "" {
+		headers = append(headers, kafka.Header{Key: requestid.Header, Value: []byte(event.RequestID)})
+	}
+	err = p.writer.WriteMessages(ctx, kafka.Message{
+		Key:     []byte(strconv.FormatUint(uint64(event.UserID), 10)),
+		Value:   payload,
+		Headers: headers,
+	})
+	if err != nil {
+		return fmt.Errorf("publishing %s event to Kafka: %w", event.Type, err)
+	}
+	return nil
+}
+
+// Close implements PublishCloser. Publish waits for each event to be
+// written, so there is nothing left to flush.
+func (p *KafkaPublisher) Close(context.Context) error {
+	return p.writer.Close()
+}
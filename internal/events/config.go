@@ -0,0 +1,69 @@
+package events
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/nats-io/nats.go"
+)
+
+// Kinds of publishers
+const (
+	KindInProcess = "inprocess"
+	KindNATS      = "nats"
+	KindKafka     = "kafka"
+)
+
+// Config selects and configures the publisher.
+type Config struct {
+	// Kind is KindInProcess, KindNATS or KindKafka
+	Kind string
+	// NATSURL and SubjectPrefix configure KindNATS
+	NATSURL       string
+	SubjectPrefix string
+	// KafkaBrokers and KafkaTopic configure KindKafka
+	KafkaBrokers []string
+	KafkaTopic   string
+}
+
+// ConfigFromEnv reads EVENT_BUS (inprocess, the default, nats or kafka),
+// NATS_URL, NATS_SUBJECT_PREFIX, KAFKA_BROKERS (comma-separated) and
+// KAFKA_TOPIC.
+func ConfigFromEnv() (Config, error) {
+	cfg := Config{
+		Kind:          cmp.Or(os.Getenv("EVENT_BUS"), KindInProcess),
+		NATSURL:       cmp.Or(os.Getenv("NATS_URL"), nats.DefaultURL),
+		SubjectPrefix: cmp.Or(os.Getenv("NATS_SUBJECT_PREFIX"), "todo-backend"),
+		KafkaTopic:    cmp.Or(os.Getenv("KAFKA_TOPIC"), "todo-events"),
+	}
+	for _, broker := range strings.Split(os.Getenv("KAFKA_BROKERS"), ",") {
+		if broker = strings.TrimSpace(broker); broker != "" {
+			cfg.KafkaBrokers = append(cfg.KafkaBrokers, broker)
+		}
+	}
+	switch cfg.Kind {
+	case KindInProcess, KindNATS:
+	case KindKafka:
+		if len(cfg.KafkaBrokers) == 0 {
+			return cfg, fmt.Errorf("EVENT_BUS=kafka needs KAFKA_BROKERS")
+		}
+	default:
+		return cfg, fmt.Errorf("invalid EVENT_BUS %q, want %s, %s or %s", cfg.Kind, KindInProcess, KindNATS, KindKafka)
+	}
+	return cfg, nil
+}
+
+// Open creates the publisher cfg selects. An in-process publisher is a
+// new Bus.
+func Open(cfg Config) (PublishCloser, error) {
+	switch cfg.Kind {
+	case KindNATS:
+		return NewNATSPublisher(cfg.NATSURL, cfg.SubjectPrefix)
+	case KindKafka:
+		return NewKafkaPublisher(cfg.KafkaBrokers, cfg.KafkaTopic), nil
+	default:
+		return NewBus(), nil
+	}
+}
@@ -0,0 +1,133 @@
+// Package events publishes domain events, like a todo being created, to
+// other systems so they can react to changes asynchronously. The service
+// layer saves events to an outbox with the changes they describe, and a
+// Relay publishes them from there to a Publisher: a Bus delivers them in
+// process, and NATSPublisher and KafkaPublisher hand them to a broker.
+package events
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Event types
+const (
+	TodoCreated = "todo.created"
+	TodoUpdated = "todo.updated"
+	TodoDeleted = "todo.deleted"
+)
+
+// Types lists every event type.
+var Types = []string{TodoCreated, TodoUpdated, TodoDeleted}
+
+// Event is a change to a todo. Consumers may get an event more than once
+// and use ID to tell.
+type Event struct {
+	ID         string    `json:"id"`
+	Type       string    `json:"type"`
+	OccurredAt time.Time `json:"occurred_at"`
+	UserID     uint      `json:"user_id"`
+	TodoID     uint      `json:"todo_id"`
+	// RequestID is the X-Request-ID of the request that caused the event,
+	// or omitted for events of background work
+	RequestID string `json:"request_id,omitempty"`
+	// Data is the todo as the API returns it, or omitted for deletions
+	Data json.RawMessage `json:"data,omitempty"`
+}
+
+// New creates an event of eventType about the todo, with a new ID. data,
+// if not nil, is encoded as the event's data.
+func New(eventType string, userID, todoID uint, data any, now time.Time) (Event, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return Event{}, fmt.Errorf("generating event ID: %w", err)
+	}
+	event := Event{ID: hex.EncodeToString(id), Type: eventType, OccurredAt: now.UTC(), UserID: userID, TodoID: todoID}
+	if data != nil {
+		payload, err := json.Marshal(data)
+		if err != nil {
+			return Event{}, fmt.Errorf("encoding %s event: %w", eventType, err)
+		}
+		event.Data = payload
+	}
+	return event, nil
+}
+
+// Publisher sends events to their consumers.
+type Publisher interface {
+	// Publish returns once the event was handed over, or with an error
+	// when it wasn't.
+	Publish(ctx context.Context, event Event) error
+}
+
+// PublishCloser is a Publisher with a connection to close on shutdown.
+type PublishCloser interface {
+	Publisher
+	// Close flushes pending events and closes the connection, giving up
+	// when ctx expires.
+	Close(ctx context.Context) error
+}
+
+// Multi publishes events to each of its publishers in turn. A failing
+// publisher doesn't keep the event from the others; their errors are
+// joined.
+type Multi []Publisher
+
+// Publish implements Publisher.
+func (m Multi) Publish(ctx context.Context, event Event) error {
+	var errs []error
+	for _, p := range m {
+		if err := p.Publish(ctx, event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Handler consumes events published on a Bus.
+type Handler func(ctx context.Context, event Event) error
+
+// Bus is an in-process Publisher: it calls its handlers in the order they
+// subscribed. It is safe for concurrent use.
+type Bus struct {
+	mu       sync.RWMutex
+	handlers []Handler
+}
+
+// NewBus creates a bus without handlers; publishing to it does nothing.
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+// Subscribe adds handler for every event published from now on.
+func (b *Bus) Subscribe(handler Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers = append(b.handlers, handler)
+}
+
+// Publish implements Publisher. Every handler is called, even when an
+// earlier one fails; their errors are joined.
+func (b *Bus) Publish(ctx context.Context, event Event) error {
+	b.mu.RLock()
+	handlers := b.handlers
+	b.mu.RUnlock()
+	var errs []error
+	for _, handler := range handlers {
+		if err := handler(ctx, event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Close implements PublishCloser; a bus holds nothing to release.
+func (b *Bus) Close(context.Context) error {
+	return nil
+}
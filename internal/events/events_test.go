@@ -0,0 +1,141 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNew(t *testing.T) {
+	now := time.Date(2026, 10, 16, 9, 0, 0, 0, time.FixedZone("CEST", 2*60*60))
+	created, err := New(TodoCreated, 1, 7, map[string]string{"title": "Buy milk"}, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(created.ID) != 32 || created.Type != TodoCreated || created.UserID != 1 || created.TodoID != 7 ||
+		!created.OccurredAt.Equal(now) || created.OccurredAt.Location() != time.UTC || string(created.Data) != `{"title":"Buy milk"}` {
+		t.Errorf("New = %+v", created)
+	}
+	deleted, _ := New(TodoDeleted, 1, 7, nil, now)
+	if deleted.ID == created.ID || deleted.Data != nil {
+		t.Errorf("New without data = %+v", deleted)
+	}
+}
+
+func TestBusCallsEveryHandler(t *testing.T) {
+	bus := NewBus()
+	if err := bus.Publish(context.Background(), Event{Type: TodoCreated}); err != nil {
+		t.Errorf("publishing without handlers = %v", err)
+	}
+	var got []string
+	bus.Subscribe(func(_ context.Context, e Event) error {
+		got = append(got, "first "+e.Type)
+		return errors.New("consumer down")
+	})
+	bus.Subscribe(func(_ context.Context, e Event) error {
+		got = append(got, "second "+e.Type)
+		return nil
+	})
+	err := bus.Publish(context.Background(), Event{Type: TodoUpdated})
+	if err == nil || err.Error() != "consumer down" {
+		t.Errorf("Publish = %v, want the failing handler's error", err)
+	}
+	if len(got) != 2 || got[0] != "first todo.updated" || got[1] != "second todo.updated" {
+		t.Errorf("handlers got %q", got)
+	}
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	for _, tc := range []struct {
+		bus, brokers string
+		want         Config
+		wantErr      bool
+	}{
+		{want: Config{Kind: KindInProcess}},
+		{bus: "nats", want: Config{Kind: KindNATS}},
+		{bus: "kafka", brokers: "kafka-1:9092, kafka-2:9092", want: Config{Kind: KindKafka, KafkaBrokers: []string{"kafka-1:9092", "kafka-2:9092"}}},
+		{bus: "kafka", wantErr: true},
+		{bus: "rabbitmq", wantErr: true},
+	} {
+		t.Setenv("EVENT_BUS", tc.bus)
+		t.Setenv("KAFKA_BROKERS", tc.brokers)
+		cfg, err := ConfigFromEnv()
+		if (err != nil) != tc.wantErr {
+			t.Errorf("EVENT_BUS=%q KAFKA_BROKERS=%q: err = %v", tc.bus, tc.brokers, err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if cfg.Kind != tc.want.Kind || len(cfg.KafkaBrokers) != len(tc.want.KafkaBrokers) ||
+			cfg.NATSURL != "nats://127.0.0.1:4222" || cfg.SubjectPrefix != "todo-backend" || cfg.KafkaTopic != "todo-events" {
+			t.Errorf("EVENT_BUS=%q: config = %+v", tc.bus, cfg)
+		}
+	}
+}
+
+// memoryStore is a Store of events in a slice, published in order.
+type memoryStore struct {
+	events    []Event
+	claimed   map[uint]bool
+	published map[uint]bool
+	failures  map[uint]int
+}
+
+func (s *memoryStore) ClaimDue(_ context.Context, _, _ time.Time, limit int) ([]Pending, error) {
+	var due []Pending
+	for i, event := range s.events {
+		id := uint(i + 1)
+		if len(due) < limit && !s.claimed[id] && !s.published[id] {
+			s.claimed[id] = true
+			due = append(due, Pending{ID: id, Event: event})
+		}
+	}
+	return due, nil
+}
+
+func (s *memoryStore) MarkPublished(_ context.Context, id uint, _ time.Time) error {
+	s.published[id] = true
+	return nil
+}
+
+func (s *memoryStore) MarkFailed(_ context.Context, id uint, _ error) error {
+	s.failures[id]++
+	s.claimed[id] = false // due again right away
+	return nil
+}
+
+func TestRelayRetriesFailedEvents(t *testing.T) {
+	store := &memoryStore{claimed: map[uint]bool{}, published: map[uint]bool{}, failures: map[uint]int{}}
+	for _, id := range []string{"a", "b", "c"} {
+		store.events = append(store.events, Event{ID: id, Type: TodoCreated})
+	}
+	bus := NewBus()
+	var got []string
+	down := true
+	bus.Subscribe(func(_ context.Context, e Event) error {
+		if e.ID == "b" && down {
+			return errors.New("broker down")
+		}
+		got = append(got, e.ID)
+		return nil
+	})
+	relay := NewRelay(store, bus)
+
+	if err := relay.RunDue(context.Background(), time.Now()); err == nil {
+		t.Error("RunDue with a failing event = nil, want its error")
+	}
+	if len(got) != 2 || store.failures[2] != 1 || store.published[2] {
+		t.Fatalf("first run published %q with %d failures of b", got, store.failures[2])
+	}
+
+	// The failed event is published on a later run, the others aren't again
+	down = false
+	if err := relay.RunDue(context.Background(), time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 3 || got[2] != "b" || !store.published[2] {
+		t.Errorf("second run: published %q, want b last", got)
+	}
+}
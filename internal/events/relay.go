@@ -0,0 +1,81 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	// defaultLease is how long a claimed event is left to its relay before
+	// another run may publish it again.
+	defaultLease = time.Minute
+	// defaultBatchSize bounds the events published per run.
+	defaultBatchSize = 100
+)
+
+// Pending is an event waiting in an outbox.
+type Pending struct {
+	// ID identifies the event in its Store
+	ID    uint
+	Event Event
+}
+
+// Store is an outbox: events saved with the changes they describe, waiting
+// to be published.
+type Store interface {
+	// ClaimDue claims up to limit events due at now until until, oldest
+	// first, so concurrent relays don't publish them twice. A claimed event
+	// that isn't marked published by then is due again.
+	ClaimDue(ctx context.Context, now, until time.Time, limit int) ([]Pending, error)
+	// MarkPublished records that the event with id was published at at.
+	MarkPublished(ctx context.Context, id uint, at time.Time) error
+	// MarkFailed records a failed attempt. The store decides when, and
+	// whether, the event is retried.
+	MarkFailed(ctx context.Context, id uint, err error) error
+}
+
+// Relay publishes the due events of a Store. Delivery is at least once: an
+// event is only marked published after the publisher accepted it, so a
+// relay that dies in between leaves it to be published again when its claim
+// runs out.
+type Relay struct {
+	store     Store
+	publisher Publisher
+	lease     time.Duration
+	batchSize int
+}
+
+// NewRelay creates a relay of store's events to publisher.
+func NewRelay(store Store, publisher Publisher) *Relay {
+	return &Relay{store: store, publisher: publisher, lease: defaultLease, batchSize: defaultBatchSize}
+}
+
+// RunDue publishes the events due at now. It is meant to be called
+// periodically by the job scheduler and is safe to run on several instances
+// at once.
+func (r *Relay) RunDue(ctx context.Context, now time.Time) error {
+	due, err := r.store.ClaimDue(ctx, now, now.Add(r.lease), r.batchSize)
+	if err != nil {
+		return fmt.Errorf("claiming due events: %w", err)
+	}
+
+	var errs []error
+	for _, pending := range due {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := r.publisher.Publish(ctx, pending.Event); err != nil {
+			if markErr := r.store.MarkFailed(ctx, pending.ID, err); markErr != nil {
+				errs = append(errs, fmt.Errorf("event %d: %w", pending.ID, markErr))
+			}
+			errs = append(errs, fmt.Errorf("event %d: %w", pending.ID, err))
+			continue
+		}
+		if err := r.store.MarkPublished(ctx, pending.ID, time.Now()); err != nil {
+			errs = append(errs, fmt.Errorf("event %d: %w", pending.ID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
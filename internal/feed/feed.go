@@ -0,0 +1,156 @@
+// Package feed renders syndication feeds (RSS 2.0 and Atom 1.0) from a
+// format-neutral Feed model so handlers don't need to know either schema.
+package feed
+
+import (
+	"encoding/xml"
+	"io"
+	"time"
+)
+
+// Feed is the format-neutral representation of a syndication feed.
+type Feed struct {
+	ID          string
+	Title       string
+	Link        string
+	Description string
+	Updated     time.Time
+	Items       []Item
+}
+
+// Item is a single entry in a Feed.
+type Item struct {
+	ID       string
+	Title    string
+	Link     string
+	Summary  string
+	Category string
+	Updated  time.Time
+}
+
+const (
+	RSSContentType  = "application/rss+xml; charset=utf-8"
+	AtomContentType = "application/atom+xml; charset=utf-8"
+)
+
+// --- RSS 2.0 ---
+
+type rss struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string  `xml:"title"`
+	Link        string  `xml:"link,omitempty"`
+	Description string  `xml:"description,omitempty"`
+	Category    string  `xml:"category,omitempty"`
+	GUID        rssGUID `xml:"guid"`
+	PubDate     string  `xml:"pubDate"`
+}
+
+type rssGUID struct {
+	Value       string `xml:",chardata"`
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+}
+
+// WriteRSS encodes the feed as an RSS 2.0 document.
+func WriteRSS(w io.Writer, f Feed) error {
+	doc := rss{
+		Version: "2.0",
+		Channel: rssChannel{
+			Title:         f.Title,
+			Link:          f.Link,
+			Description:   f.Description,
+			LastBuildDate: f.Updated.Format(time.RFC1123Z),
+			Items:         make([]rssItem, 0, len(f.Items)),
+		},
+	}
+	for _, item := range f.Items {
+		doc.Channel.Items = append(doc.Channel.Items, rssItem{
+			Title:       item.Title,
+			Link:        item.Link,
+			Description: item.Summary,
+			Category:    item.Category,
+			GUID:        rssGUID{Value: item.ID},
+			PubDate:     item.Updated.Format(time.RFC1123Z),
+		})
+	}
+	return encode(w, doc)
+}
+
+// --- Atom 1.0 ---
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Link    atomLink    `xml:"link"`
+	Updated string      `xml:"updated"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+}
+
+type atomEntry struct {
+	ID       string        `xml:"id"`
+	Title    string        `xml:"title"`
+	Link     *atomLink     `xml:"link,omitempty"`
+	Summary  string        `xml:"summary,omitempty"`
+	Category *atomCategory `xml:"category,omitempty"`
+	Updated  string        `xml:"updated"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+// WriteAtom encodes the feed as an Atom 1.0 document.
+func WriteAtom(w io.Writer, f Feed) error {
+	doc := atomFeed{
+		ID:      f.ID,
+		Title:   f.Title,
+		Link:    atomLink{Href: f.Link},
+		Updated: f.Updated.Format(time.RFC3339),
+		Entries: make([]atomEntry, 0, len(f.Items)),
+	}
+	for _, item := range f.Items {
+		entry := atomEntry{
+			ID:      item.ID,
+			Title:   item.Title,
+			Summary: item.Summary,
+			Updated: item.Updated.Format(time.RFC3339),
+		}
+		if item.Link != "" {
+			entry.Link = &atomLink{Href: item.Link}
+		}
+		if item.Category != "" {
+			entry.Category = &atomCategory{Term: item.Category}
+		}
+		doc.Entries = append(doc.Entries, entry)
+	}
+	return encode(w, doc)
+}
+
+func encode(w io.Writer, doc any) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	return enc.Close()
+}
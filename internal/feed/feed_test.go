@@ -0,0 +1,72 @@
+package feed
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+)
+
+func sampleFeed() Feed {
+	updated := time.Date(2025, 4, 1, 9, 30, 0, 0, time.UTC)
+	return Feed{
+		ID:          "https://example.com/feeds/abc/today.atom",
+		Title:       "Today",
+		Link:        "https://example.com/feeds/abc/today.atom",
+		Description: "Due today & recently completed",
+		Updated:     updated,
+		Items: []Item{
+			{ID: "https://example.com/todos/1#due", Title: "Buy milk", Link: "https://example.com/todos/1", Category: "due", Updated: updated},
+			{ID: "https://example.com/todos/2#completed", Title: "Ship <release>", Category: "completed", Updated: updated},
+		},
+	}
+}
+
+func TestWriteRSS(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteRSS(&buf, sampleFeed()); err != nil {
+		t.Fatalf("WriteRSS returned error: %v", err)
+	}
+
+	var doc rss
+	if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("output is not valid XML: %v", err)
+	}
+	if doc.Version != "2.0" {
+		t.Errorf("expected version 2.0, got %q", doc.Version)
+	}
+	if len(doc.Channel.Items) != 2 {
+		t.Fatalf("expected 2 items, got %d", len(doc.Channel.Items))
+	}
+	if doc.Channel.Items[1].Title != "Ship <release>" {
+		t.Errorf("expected title to round-trip, got %q", doc.Channel.Items[1].Title)
+	}
+	if doc.Channel.Items[0].PubDate != "Tue, 01 Apr 2025 09:30:00 +0000" {
+		t.Errorf("unexpected pubDate %q", doc.Channel.Items[0].PubDate)
+	}
+}
+
+func TestWriteAtom(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteAtom(&buf, sampleFeed()); err != nil {
+		t.Fatalf("WriteAtom returned error: %v", err)
+	}
+	if !strings.Contains(buf.String(), `xmlns="http://www.w3.org/2005/Atom"`) {
+		t.Errorf("expected Atom namespace in output:\n%s", buf.String())
+	}
+
+	var doc atomFeed
+	if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("output is not valid XML: %v", err)
+	}
+	if len(doc.Entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(doc.Entries))
+	}
+	if doc.Entries[1].Link != nil {
+		t.Errorf("expected entry without link to omit it, got %+v", doc.Entries[1].Link)
+	}
+	if doc.Updated != "2025-04-01T09:30:00Z" {
+		t.Errorf("unexpected updated %q", doc.Updated)
+	}
+}
@@ -0,0 +1,166 @@
+// Package fixtures loads named, reproducible data sets into the
+// repositories, for demos and end-to-end test setups.
+package fixtures
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/Tomlord1122/todo-backend/internal/domain"
+	"github.com/Tomlord1122/todo-backend/internal/repository"
+)
+
+// DemoUserID owns the data in every fixture set; multi-user adds more users after it.
+const DemoUserID uint = 1
+
+// Sets lists the available fixture set names.
+var Sets = []string{"empty", "small", "10k-todos", "multi-user"}
+
+// ErrUnknownSet is returned for a set name not in Sets.
+var ErrUnknownSet = errors.New("unknown fixture set")
+
+// Counts summarizes what a fixture set created.
+type Counts struct {
+	Users int
+	Lists int
+	Todos int
+}
+
+// Load deletes all existing data and loads the named set. Dates are relative
+// to now, so due-today feeds and weekly reports always have content.
+func Load(repos *repository.Repositories, set string, now time.Time) (Counts, error) {
+	var load func(*loader) error
+	switch set {
+	case "empty":
+		load = func(*loader) error { return nil }
+	case "small":
+		load = func(l *loader) error { return l.small(DemoUserID) }
+	case "10k-todos":
+		load = func(l *loader) error { return l.bulk(DemoUserID, 10_000) }
+	case "multi-user":
+		load = func(l *loader) error {
+			for userID := DemoUserID; userID < DemoUserID+3; userID++ {
+				if err := l.small(userID); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	default:
+		return Counts{}, fmt.Errorf("%w %q", ErrUnknownSet, set)
+	}
+
+	if err := repos.Reset(); err != nil {
+		return Counts{}, fmt.Errorf("fixtures: resetting data: %w", err)
+	}
+	l := &loader{repos: repos, now: now, users: make(map[uint]bool)}
+	if err := load(l); err != nil {
+		return Counts{}, fmt.Errorf("fixtures: loading %s: %w", set, err)
+	}
+	l.counts.Users = len(l.users)
+	return l.counts, nil
+}
+
+type loader struct {
+	repos  *repository.Repositories
+	now    time.Time
+	users  map[uint]bool
+	counts Counts
+}
+
+// day returns the current hour offset by the given number of days.
+func (l *loader) day(offset int) *time.Time {
+	t := l.now.Truncate(time.Hour).AddDate(0, 0, offset)
+	return &t
+}
+
+func (l *loader) list(userID uint, name string) (*domain.List, error) {
+	list := &domain.List{Name: name, UserID: userID}
+	if err := l.repos.Lists.Create(list); err != nil {
+		return nil, err
+	}
+	l.users[userID] = true
+	l.counts.Lists++
+	return list, nil
+}
+
+func (l *loader) todo(userID uint, todo domain.Todo) error {
+	todo.UserID = userID
+	if err := l.repos.Todos.Create(&todo); err != nil {
+		return err
+	}
+	l.users[userID] = true
+	l.counts.Todos++
+	return nil
+}
+
+// small is a handful of lists and a week's worth of todos, some done, plus
+// a feed token named "user-<id>" and saved preferences.
+func (l *loader) small(userID uint) error {
+	work, err := l.list(userID, "Work")
+	if err != nil {
+		return err
+	}
+	home, err := l.list(userID, "Home")
+	if err != nil {
+		return err
+	}
+
+	todos := []domain.Todo{
+		{Title: "Prepare sprint demo", Priority: "high", ListID: &work.ID, DueDate: l.day(0)},
+		{Title: "Review open pull requests", Priority: "normal", ListID: &work.ID, DueDate: l.day(1)},
+		{Title: "Write quarterly report", Priority: "high", ListID: &work.ID, DueDate: l.day(4)},
+		{Title: "Book dentist appointment", Priority: "low", ListID: &home.ID},
+		{Title: "Buy groceries", Priority: "normal", ListID: &home.ID, DueDate: l.day(0)},
+		{Title: "Renew passport", Priority: "normal", DueDate: l.day(14)},
+		{Title: "Fix flaky CI job", Priority: "high", ListID: &work.ID, Completed: true, CompletedAt: l.day(-1)},
+		{Title: "Water the plants", Priority: "low", ListID: &home.ID, Completed: true, CompletedAt: l.day(-3)},
+	}
+	for _, todo := range todos {
+		if err := l.todo(userID, todo); err != nil {
+			return err
+		}
+	}
+
+	if err := l.repos.FeedTokens.Create(&domain.FeedToken{UserID: userID, Token: fmt.Sprintf("user-%d", userID)}); err != nil {
+		return err
+	}
+	return l.repos.Preferences.Save(&domain.UserPreference{UserID: userID, AutoApplySuggestions: true})
+}
+
+// bulk creates n todos spread over ten lists and two months, about a third
+// of them completed. The generator is seeded so every load is identical.
+func (l *loader) bulk(userID uint, n int) error {
+	lists := make([]*domain.List, 10)
+	for i := range lists {
+		list, err := l.list(userID, fmt.Sprintf("List %02d", i+1))
+		if err != nil {
+			return err
+		}
+		lists[i] = list
+	}
+
+	rng := rand.New(rand.NewPCG(1, 2))
+	priorities := []string{"low", "normal", "normal", "high"}
+	for i := range n {
+		todo := domain.Todo{
+			Title:    fmt.Sprintf("Todo %05d", i+1),
+			Priority: priorities[rng.IntN(len(priorities))],
+		}
+		if rng.IntN(5) > 0 {
+			todo.ListID = &lists[rng.IntN(len(lists))].ID
+		}
+		if rng.IntN(3) == 0 {
+			todo.Completed = true
+			todo.CompletedAt = l.day(-rng.IntN(30))
+		} else if rng.IntN(4) > 0 {
+			todo.DueDate = l.day(rng.IntN(60) - 30)
+		}
+		if err := l.todo(userID, todo); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package fixtures
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Tomlord1122/todo-backend/internal/repository"
+)
+
+func TestLoad(t *testing.T) {
+	repos := repository.NewMemoryRepositories()
+	now := time.Date(2025, 5, 14, 9, 30, 0, 0, time.UTC)
+
+	cases := []struct {
+		set  string
+		want Counts
+	}{
+		{"small", Counts{Users: 1, Lists: 2, Todos: 8}},
+		{"multi-user", Counts{Users: 3, Lists: 6, Todos: 24}},
+		{"10k-todos", Counts{Users: 1, Lists: 10, Todos: 10_000}},
+		{"empty", Counts{}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.set, func(t *testing.T) {
+			got, err := Load(repos, tc.set, now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tc.want {
+				t.Errorf("Load(%q) = %+v, want %+v", tc.set, got, tc.want)
+			}
+			// Loading resets first, so the repositories hold exactly this set
+			todos, _ := repos.Todos.GetAll()
+			if len(todos) != tc.want.Todos {
+				t.Errorf("repositories hold %d todos, want %d", len(todos), tc.want.Todos)
+			}
+			if tc.want.Todos > 0 && todos[0].ID != 1 {
+				t.Errorf("IDs don't restart after a reset: first ID is %d", todos[0].ID)
+			}
+		})
+	}
+
+	if _, err := Load(repos, "huge", now); !errors.Is(err, ErrUnknownSet) {
+		t.Errorf("Load(huge) = %v, want ErrUnknownSet", err)
+	}
+}
@@ -0,0 +1,260 @@
+// Package gcal is a small Google Calendar API v3 client covering what
+// todo sync needs: the OAuth token endpoints, creating a calendar, writing
+// events and incremental event listing. Like the S3 store it is written on
+// net/http to keep SDKs out of the dependency tree.
+package gcal
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Scope is the OAuth scope clients must request for sync.
+const Scope = "https://www.googleapis.com/auth/calendar"
+
+// Google's token endpoint and Calendar API root, the usual Config.TokenURL
+// and Config.APIURL.
+const (
+	TokenURL = "https://oauth2.googleapis.com/token"
+	APIURL   = "https://www.googleapis.com/calendar/v3"
+)
+
+// ErrSyncTokenExpired means the sync token is no longer valid and the
+// events must be listed in full again.
+var ErrSyncTokenExpired = errors.New("calendar sync token expired")
+
+// Config holds Google OAuth client settings.
+type Config struct {
+	ClientID     string
+	ClientSecret string
+	// TokenURL and APIURL default to Google's; override them for tests
+	TokenURL string
+	APIURL   string
+}
+
+// Token is an OAuth token. RefreshToken is only set by Exchange.
+type Token struct {
+	AccessToken  string
+	RefreshToken string
+	Expiry       time.Time
+}
+
+// Event is a calendar event.
+type Event struct {
+	ID                 string              `json:"id,omitempty"`
+	Status             string              `json:"status,omitempty"` // "cancelled" for deleted events
+	Summary            string              `json:"summary,omitempty"`
+	Description        string              `json:"description,omitempty"`
+	Start              *EventTime          `json:"start,omitempty"`
+	End                *EventTime          `json:"end,omitempty"`
+	ExtendedProperties *ExtendedProperties `json:"extendedProperties,omitempty"`
+}
+
+// EventTime is either a point in time or, for all-day events, a date.
+type EventTime struct {
+	DateTime string `json:"dateTime,omitempty"`
+	Date     string `json:"date,omitempty"`
+}
+
+// ExtendedProperties hold app data on an event.
+type ExtendedProperties struct {
+	Private map[string]string `json:"private,omitempty"`
+}
+
+// NewEventTime returns an EventTime for t.
+func NewEventTime(t time.Time) *EventTime {
+	return &EventTime{DateTime: t.Format(time.RFC3339)}
+}
+
+// Time returns the point in time; all-day events start at midnight UTC.
+func (t *EventTime) Time() (time.Time, error) {
+	switch {
+	case t == nil:
+		return time.Time{}, errors.New("event has no time")
+	case t.DateTime != "":
+		return time.Parse(time.RFC3339, t.DateTime)
+	default:
+		return time.Parse(time.DateOnly, t.Date)
+	}
+}
+
+// Client calls the Google OAuth and Calendar APIs.
+type Client struct {
+	cfg    Config
+	client *http.Client
+}
+
+// NewClient creates a Google Calendar client. A nil client gets a default
+// one with a 30 second timeout.
+func NewClient(cfg Config, client *http.Client) *Client {
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	return &Client{cfg: cfg, client: client}
+}
+
+// Exchange trades an authorization code from the consent screen for
+// tokens. redirectURI must match the one the code was issued for.
+func (c *Client) Exchange(ctx context.Context, code, redirectURI string) (*Token, error) {
+	return c.token(ctx, url.Values{
+		"grant_type":   {"authorization_code"},
+		"code":         {code},
+		"redirect_uri": {redirectURI},
+	})
+}
+
+// Refresh gets a new access token.
+func (c *Client) Refresh(ctx context.Context, refreshToken string) (*Token, error) {
+	return c.token(ctx, url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {refreshToken},
+	})
+}
+
+func (c *Client) token(ctx context.Context, form url.Values) (*Token, error) {
+	form.Set("client_id", c.cfg.ClientID)
+	form.Set("client_secret", c.cfg.ClientSecret)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.cfg.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	var body struct {
+		AccessToken  string `json:"access_token"`
+		RefreshToken string `json:"refresh_token"`
+		ExpiresIn    int    `json:"expires_in"`
+	}
+	if err := c.send(req, &body); err != nil {
+		return nil, err
+	}
+	return &Token{
+		AccessToken:  body.AccessToken,
+		RefreshToken: body.RefreshToken,
+		Expiry:       time.Now().Add(time.Duration(body.ExpiresIn) * time.Second),
+	}, nil
+}
+
+// CreateCalendar creates a secondary calendar and returns its ID.
+func (c *Client) CreateCalendar(ctx context.Context, accessToken, summary string) (string, error) {
+	var calendar struct {
+		ID string `json:"id"`
+	}
+	err := c.call(ctx, accessToken, http.MethodPost, "/calendars", map[string]string{"summary": summary}, &calendar)
+	return calendar.ID, err
+}
+
+// InsertEvent creates an event and returns it with its ID.
+func (c *Client) InsertEvent(ctx context.Context, accessToken, calendarID string, event Event) (*Event, error) {
+	var created Event
+	err := c.call(ctx, accessToken, http.MethodPost, "/calendars/"+url.PathEscape(calendarID)+"/events", event, &created)
+	if err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// PatchEvent changes the fields set in event.
+func (c *Client) PatchEvent(ctx context.Context, accessToken, calendarID, eventID string, event Event) error {
+	return c.call(ctx, accessToken, http.MethodPatch, "/calendars/"+url.PathEscape(calendarID)+"/events/"+url.PathEscape(eventID), event, nil)
+}
+
+// DeleteEvent deletes an event. Events that are already gone count as
+// deleted.
+func (c *Client) DeleteEvent(ctx context.Context, accessToken, calendarID, eventID string) error {
+	err := c.call(ctx, accessToken, http.MethodDelete, "/calendars/"+url.PathEscape(calendarID)+"/events/"+url.PathEscape(eventID), nil, nil)
+	var status *StatusError
+	if errors.As(err, &status) && (status.Code == http.StatusNotFound || status.Code == http.StatusGone) {
+		return nil
+	}
+	return err
+}
+
+// ListEvents returns the events changed since syncToken was issued,
+// including deleted ones, and the token for the next call. An empty
+// syncToken lists every event. It returns ErrSyncTokenExpired when Google
+// no longer accepts syncToken.
+func (c *Client) ListEvents(ctx context.Context, accessToken, calendarID, syncToken string) (events []Event, nextSyncToken string, err error) {
+	query := url.Values{"maxResults": {"250"}}
+	if syncToken != "" {
+		query.Set("syncToken", syncToken)
+	}
+	for {
+		var page struct {
+			Items         []Event `json:"items"`
+			NextPageToken string  `json:"nextPageToken"`
+			NextSyncToken string  `json:"nextSyncToken"`
+		}
+		err := c.call(ctx, accessToken, http.MethodGet, "/calendars/"+url.PathEscape(calendarID)+"/events?"+query.Encode(), nil, &page)
+		var status *StatusError
+		if errors.As(err, &status) && status.Code == http.StatusGone {
+			return nil, "", ErrSyncTokenExpired
+		}
+		if err != nil {
+			return nil, "", err
+		}
+		events = append(events, page.Items...)
+		if page.NextPageToken == "" {
+			return events, page.NextSyncToken, nil
+		}
+		query.Set("pageToken", page.NextPageToken)
+	}
+}
+
+// StatusError is a non-2xx API response.
+type StatusError struct {
+	Code int
+	Msg  string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("google calendar: %d %s: %s", e.Code, http.StatusText(e.Code), e.Msg)
+}
+
+// call sends an authorized Calendar API request.
+func (c *Client) call(ctx context.Context, accessToken, method, path string, body, out any) error {
+	var reader io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(encoded)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.cfg.APIURL+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return c.send(req, out)
+}
+
+// send performs req and decodes the JSON response into out, if set.
+func (c *Client) send(req *http.Request, out any) error {
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("google calendar %s %s: %w", req.Method, req.URL.Path, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return &StatusError{Code: resp.StatusCode, Msg: strings.TrimSpace(string(msg))}
+	}
+	if out != nil && resp.StatusCode != http.StatusNoContent {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return fmt.Errorf("google calendar %s %s: decoding response: %w", req.Method, req.URL.Path, err)
+		}
+	}
+	return nil
+}
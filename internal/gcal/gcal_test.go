@@ -0,0 +1,75 @@
+package gcal
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestListEventsFollowsPages(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer access" {
+			t.Errorf("Authorization = %q", got)
+		}
+		query := r.URL.Query()
+		switch {
+		case query.Get("syncToken") == "stale":
+			w.WriteHeader(http.StatusGone)
+		case query.Get("pageToken") == "":
+			_ = json.NewEncoder(w).Encode(map[string]any{"items": []Event{{ID: "a"}}, "nextPageToken": "p2"})
+		default:
+			_ = json.NewEncoder(w).Encode(map[string]any{"items": []Event{{ID: "b", Status: "cancelled"}}, "nextSyncToken": "next"})
+		}
+	}))
+	defer server.Close()
+	client := NewClient(Config{APIURL: server.URL}, nil)
+
+	events, next, err := client.ListEvents(context.Background(), "access", "cal", "")
+	if err != nil {
+		t.Fatalf("ListEvents returned error: %v", err)
+	}
+	if len(events) != 2 || events[1].Status != "cancelled" || next != "next" {
+		t.Errorf("got %+v and sync token %q, want events a and b and token next", events, next)
+	}
+
+	if _, _, err := client.ListEvents(context.Background(), "access", "cal", "stale"); !errors.Is(err, ErrSyncTokenExpired) {
+		t.Errorf("stale sync token: err = %v, want ErrSyncTokenExpired", err)
+	}
+}
+
+func TestRefresh(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Fatal(err)
+		}
+		if r.Form.Get("grant_type") != "refresh_token" || r.Form.Get("refresh_token") != "refresh" || r.Form.Get("client_secret") != "secret" {
+			t.Errorf("unexpected form %v", r.Form)
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"access_token": "new", "expires_in": 3600})
+	}))
+	defer server.Close()
+	client := NewClient(Config{ClientID: "id", ClientSecret: "secret", TokenURL: server.URL}, nil)
+
+	token, err := client.Refresh(context.Background(), "refresh")
+	if err != nil {
+		t.Fatalf("Refresh returned error: %v", err)
+	}
+	if token.AccessToken != "new" || time.Until(token.Expiry) < 59*time.Minute {
+		t.Errorf("token = %+v, want access token new valid for an hour", token)
+	}
+}
+
+func TestEventTime(t *testing.T) {
+	timed, err := (&EventTime{DateTime: "2026-03-01T09:30:00+01:00"}).Time()
+	if err != nil || !timed.Equal(time.Date(2026, 3, 1, 8, 30, 0, 0, time.UTC)) {
+		t.Errorf("timed event = %v, %v", timed, err)
+	}
+	allDay, err := (&EventTime{Date: "2026-03-01"}).Time()
+	if err != nil || !allDay.Equal(time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("all-day event = %v, %v", allDay, err)
+	}
+}
@@ -0,0 +1,70 @@
+// Package geo has the great-circle math behind location-aware todos.
+package geo
+
+import (
+	"fmt"
+	"math"
+)
+
+const (
+	// EarthRadius is the mean Earth radius in meters.
+	EarthRadius = 6_371_000.0
+	// DefaultRadius is the geofence radius in meters when none is given.
+	DefaultRadius = 100.0
+	// MaxRadius caps geofence and search radii, in meters.
+	MaxRadius = 50_000.0
+	// metersPerDegree is the length of one degree of latitude.
+	metersPerDegree = math.Pi * EarthRadius / 180
+)
+
+// Validate checks that a coordinate is on the globe.
+func Validate(lat, lng float64) error {
+	if math.IsNaN(lat) || lat < -90 || lat > 90 {
+		return fmt.Errorf("latitude must be between -90 and 90, got %g", lat)
+	}
+	if math.IsNaN(lng) || lng < -180 || lng > 180 {
+		return fmt.Errorf("longitude must be between -180 and 180, got %g", lng)
+	}
+	return nil
+}
+
+// ValidateRadius checks that a radius in meters is positive and at most MaxRadius.
+func ValidateRadius(radius float64) error {
+	if math.IsNaN(radius) || radius <= 0 || radius > MaxRadius {
+		return fmt.Errorf("radius must be greater than 0 and at most %g meters, got %g", MaxRadius, radius)
+	}
+	return nil
+}
+
+// Distance returns the haversine distance in meters between two points.
+func Distance(lat1, lng1, lat2, lng2 float64) float64 {
+	rad1, rad2 := lat1*math.Pi/180, lat2*math.Pi/180
+	dLat := (lat2 - lat1) * math.Pi / 180
+	dLng := (lng2 - lng1) * math.Pi / 180
+	a := math.Pow(math.Sin(dLat/2), 2) + math.Cos(rad1)*math.Cos(rad2)*math.Pow(math.Sin(dLng/2), 2)
+	return 2 * EarthRadius * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+// Box is a latitude/longitude bounding box.
+type Box struct {
+	MinLat, MaxLat float64
+	MinLng, MaxLng float64
+	// WrapsLng is set when the box crosses the poles or the antimeridian;
+	// longitude can't be used to narrow a search then.
+	WrapsLng bool
+}
+
+// BoundingBox returns a box containing every point within radius meters of
+// (lat, lng), used to narrow a search before computing exact distances.
+func BoundingBox(lat, lng, radius float64) Box {
+	dLat := radius / metersPerDegree
+	box := Box{MinLat: lat - dLat, MaxLat: lat + dLat}
+	if box.MinLat <= -90 || box.MaxLat >= 90 {
+		box.WrapsLng = true
+		return box
+	}
+	dLng := dLat / math.Cos(lat*math.Pi/180)
+	box.MinLng, box.MaxLng = lng-dLng, lng+dLng
+	box.WrapsLng = box.MinLng < -180 || box.MaxLng > 180
+	return box
+}
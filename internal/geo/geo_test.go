@@ -0,0 +1,53 @@
+package geo
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDistance(t *testing.T) {
+	cases := []struct {
+		name                   string
+		lat1, lng1, lat2, lng2 float64
+		want                   float64 // meters
+	}{
+		{"same point", 52.52, 13.405, 52.52, 13.405, 0},
+		{"Berlin to Paris", 52.5200, 13.4050, 48.8566, 2.3522, 877_500},
+		{"one degree of latitude", 0, 0, 1, 0, 111_195},
+		{"across the antimeridian", 0, 179.99, 0, -179.99, 2_224},
+	}
+	for _, tc := range cases {
+		got := Distance(tc.lat1, tc.lng1, tc.lat2, tc.lng2)
+		if math.Abs(got-tc.want) > tc.want*0.005+1 {
+			t.Errorf("%s: Distance = %.0f m, want about %.0f m", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestBoundingBox(t *testing.T) {
+	box := BoundingBox(52.52, 13.405, 1000)
+	for _, p := range [][2]float64{{52.529, 13.405}, {52.52, 13.419}} {
+		if Distance(52.52, 13.405, p[0], p[1]) <= 1000 &&
+			(p[0] < box.MinLat || p[0] > box.MaxLat || p[1] < box.MinLng || p[1] > box.MaxLng) {
+			t.Errorf("point %v within the radius is outside the box %+v", p, box)
+		}
+	}
+	if box.WrapsLng {
+		t.Error("a small box in Berlin shouldn't wrap")
+	}
+	if !BoundingBox(0, 179.999, 1000).WrapsLng {
+		t.Error("a box across the antimeridian should wrap")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := Validate(52.52, 13.405); err != nil {
+		t.Error(err)
+	}
+	if Validate(91, 0) == nil || Validate(0, -181) == nil || Validate(math.NaN(), 0) == nil {
+		t.Error("Validate accepted an invalid coordinate")
+	}
+	if ValidateRadius(0) == nil || ValidateRadius(MaxRadius+1) == nil {
+		t.Error("ValidateRadius accepted an invalid radius")
+	}
+}
@@ -0,0 +1,296 @@
+// Package github is a small client for the parts of the GitHub REST API
+// behind issue sync: the OAuth token endpoint, the authenticated user,
+// repository access and issues, plus webhook signature checks. Calls are
+// made with the token of the user who linked the list, so it can only
+// reach what that user can. Like the S3 store it is written on net/http to
+// keep SDKs out of the dependency tree.
+package github
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// maxPages bounds how many pages of issues one listing follows.
+const maxPages = 10
+
+// nextLink finds the next page in a Link response header.
+var nextLink = regexp.MustCompile(`<([^>]+)>;\s*rel="next"`)
+
+// Scope is the OAuth scope clients must request for sync: reading issues
+// and closing them, in private repositories too.
+const Scope = "repo"
+
+// Config holds GitHub OAuth app and API settings.
+type Config struct {
+	ClientID     string
+	ClientSecret string
+	// APIURL is the REST API root and TokenURL the OAuth token endpoint,
+	// e.g. for GitHub Enterprise.
+	APIURL   string
+	TokenURL string
+	// WebhookSecret verifies webhook deliveries; webhooks are rejected
+	// when it is empty.
+	WebhookSecret string
+}
+
+// Token is a user's OAuth token. Tokens of OAuth apps don't expire and
+// have no RefreshToken; those of GitHub apps expire and do.
+type Token struct {
+	AccessToken  string
+	RefreshToken string
+	// Expiry is zero for tokens that don't expire
+	Expiry time.Time
+}
+
+// Issue is a GitHub issue.
+type Issue struct {
+	Number    int    `json:"number"`
+	Title     string `json:"title"`
+	Body      string `json:"body"`
+	State     string `json:"state"` // open or closed
+	HTMLURL   string `json:"html_url"`
+	Assignees []User `json:"assignees"`
+	// PullRequest is set when the "issue" is a pull request
+	PullRequest *struct{} `json:"pull_request,omitempty"`
+}
+
+// Closed reports whether the issue is closed.
+func (i Issue) Closed() bool {
+	return i.State == "closed"
+}
+
+// AssignedTo reports whether login is among the assignees.
+func (i Issue) AssignedTo(login string) bool {
+	for _, u := range i.Assignees {
+		if strings.EqualFold(u.Login, login) {
+			return true
+		}
+	}
+	return false
+}
+
+// User is a GitHub account.
+type User struct {
+	Login string `json:"login"`
+}
+
+// Repository identifies a repository in webhook payloads.
+type Repository struct {
+	Name  string `json:"name"`
+	Owner User   `json:"owner"`
+}
+
+// Permissions are what the authenticated user may do in a repository.
+type Permissions struct {
+	Admin  bool `json:"admin"`
+	Push   bool `json:"push"`
+	Triage bool `json:"triage"`
+	Pull   bool `json:"pull"`
+}
+
+// CanCloseIssues reports whether the permissions allow closing and
+// reopening issues.
+func (p Permissions) CanCloseIssues() bool {
+	return p.Admin || p.Push || p.Triage
+}
+
+// IssuesEvent is the payload of an "issues" webhook.
+type IssuesEvent struct {
+	Action     string     `json:"action"`
+	Issue      Issue      `json:"issue"`
+	Repository Repository `json:"repository"`
+}
+
+// Client calls the GitHub REST API.
+type Client struct {
+	cfg    Config
+	client *http.Client
+}
+
+// NewClient creates a GitHub client. A nil client gets a default one with
+// a 30 second timeout.
+func NewClient(cfg Config, client *http.Client) *Client {
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	return &Client{cfg: cfg, client: client}
+}
+
+// Exchange trades an authorization code from the consent screen for the
+// user's token. redirectURI must match the one the code was issued for.
+func (c *Client) Exchange(ctx context.Context, code, redirectURI string) (*Token, error) {
+	return c.token(ctx, url.Values{"code": {code}, "redirect_uri": {redirectURI}})
+}
+
+// Refresh gets a new token for an expiring one.
+func (c *Client) Refresh(ctx context.Context, refreshToken string) (*Token, error) {
+	return c.token(ctx, url.Values{"grant_type": {"refresh_token"}, "refresh_token": {refreshToken}})
+}
+
+func (c *Client) token(ctx context.Context, form url.Values) (*Token, error) {
+	form.Set("client_id", c.cfg.ClientID)
+	form.Set("client_secret", c.cfg.ClientSecret)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.cfg.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("github token: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("github token: %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	var body struct {
+		AccessToken  string `json:"access_token"`
+		RefreshToken string `json:"refresh_token"`
+		ExpiresIn    int    `json:"expires_in"`
+		// GitHub reports a rejected code with 200 OK and an error
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("github token: decoding response: %w", err)
+	}
+	if body.Error != "" || body.AccessToken == "" {
+		return nil, fmt.Errorf("github token: %s: %s", body.Error, body.ErrorDescription)
+	}
+	token := &Token{AccessToken: body.AccessToken, RefreshToken: body.RefreshToken}
+	if body.ExpiresIn > 0 {
+		token.Expiry = time.Now().Add(time.Duration(body.ExpiresIn) * time.Second)
+	}
+	return token, nil
+}
+
+// AuthenticatedUser returns the account accessToken belongs to.
+func (c *Client) AuthenticatedUser(ctx context.Context, accessToken string) (*User, error) {
+	var user User
+	if _, err := c.do(ctx, accessToken, http.MethodGet, c.cfg.APIURL+"/user", nil, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// RepositoryPermissions returns what the owner of accessToken may do in
+// owner/repo. Repositories the user can't see fail like missing ones.
+func (c *Client) RepositoryPermissions(ctx context.Context, accessToken, owner, repo string) (Permissions, error) {
+	var repository struct {
+		Permissions Permissions `json:"permissions"`
+	}
+	endpoint := fmt.Sprintf("%s/repos/%s/%s", c.cfg.APIURL, url.PathEscape(owner), url.PathEscape(repo))
+	_, err := c.do(ctx, accessToken, http.MethodGet, endpoint, nil, &repository)
+	return repository.Permissions, err
+}
+
+// ListAssignedIssues returns the open and closed issues in owner/repo
+// assigned to login, without pull requests.
+func (c *Client) ListAssignedIssues(ctx context.Context, accessToken, owner, repo, login string) ([]Issue, error) {
+	query := url.Values{"assignee": {login}, "state": {"all"}, "per_page": {"100"}}
+	next := fmt.Sprintf("%s/repos/%s/%s/issues?%s", c.cfg.APIURL, url.PathEscape(owner), url.PathEscape(repo), query.Encode())
+
+	var issues []Issue
+	for page := 0; next != "" && page < maxPages; page++ {
+		var batch []Issue
+		resp, err := c.do(ctx, accessToken, http.MethodGet, next, nil, &batch)
+		if err != nil {
+			return nil, err
+		}
+		for _, issue := range batch {
+			if issue.PullRequest == nil {
+				issues = append(issues, issue)
+			}
+		}
+		next = ""
+		if m := nextLink.FindStringSubmatch(resp.Header.Get("Link")); m != nil {
+			next = m[1]
+		}
+	}
+	return issues, nil
+}
+
+// SetIssueState closes or reopens an issue.
+func (c *Client) SetIssueState(ctx context.Context, accessToken, owner, repo string, number int, closed bool) error {
+	state := "open"
+	if closed {
+		state = "closed"
+	}
+	endpoint := fmt.Sprintf("%s/repos/%s/%s/issues/%d", c.cfg.APIURL, url.PathEscape(owner), url.PathEscape(repo), number)
+	_, err := c.do(ctx, accessToken, http.MethodPatch, endpoint, map[string]string{"state": state}, nil)
+	return err
+}
+
+// do sends an API request authorized with accessToken and decodes the JSON
+// response into out, if set.
+func (c *Client) do(ctx context.Context, accessToken, method, endpoint string, body, out any) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(encoded)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, reader)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("github %s %s: %w", method, req.URL.Path, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("github %s %s: %s: %s", method, req.URL.Path, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return nil, fmt.Errorf("github %s %s: decoding response: %w", method, req.URL.Path, err)
+		}
+	}
+	return resp, nil
+}
+
+// VerifyWebhook checks a delivery's signature with the configured secret.
+func (c *Client) VerifyWebhook(body []byte, signature string) bool {
+	return VerifySignature(c.cfg.WebhookSecret, body, signature)
+}
+
+// VerifySignature checks a webhook's X-Hub-Signature-256 header against
+// the HMAC-SHA256 of body. An empty secret never verifies.
+func VerifySignature(secret string, body []byte, signature string) bool {
+	if secret == "" {
+		return false
+	}
+	got, err := hex.DecodeString(strings.TrimPrefix(signature, "sha256="))
+	if err != nil || !strings.HasPrefix(signature, "sha256=") {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(got, mac.Sum(nil))
+}
@@ -0,0 +1,61 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestVerifySignature(t *testing.T) {
+	// Example from GitHub's "Validating webhook deliveries" docs
+	secret := "It's a Secret to Everybody"
+	body := []byte("Hello, World!")
+	valid := "sha256=757107ea0eb2509fc211221cce984b8a37570b6d7586c22c46f4379c8b043e17"
+
+	if !VerifySignature(secret, body, valid) {
+		t.Error("valid signature was rejected")
+	}
+	for _, sig := range []string{"", "sha256=00", "sha1=757107ea0eb2509fc211221cce984b8a37570b6d7586c22c46f4379c8b043e17", "sha256=zz"} {
+		if VerifySignature(secret, body, sig) {
+			t.Errorf("signature %q was accepted", sig)
+		}
+	}
+	if VerifySignature("", body, valid) {
+		t.Error("an empty secret must never verify")
+	}
+}
+
+func TestListAssignedIssuesFollowsPagesAndSkipsPullRequests(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer secret" {
+			t.Errorf("Authorization = %q", got)
+		}
+		if got := r.URL.Query().Get("assignee"); got != "octocat" {
+			t.Errorf("assignee = %q", got)
+		}
+		switch r.URL.Query().Get("page") {
+		case "":
+			w.Header().Set("Link", fmt.Sprintf(`<%s/repos/o/r/issues?assignee=octocat&page=2>; rel="next"`, server.URL))
+			_ = json.NewEncoder(w).Encode([]map[string]any{
+				{"number": 1, "title": "Bug", "state": "open"},
+				{"number": 2, "title": "PR", "state": "open", "pull_request": map[string]any{}},
+			})
+		case "2":
+			_ = json.NewEncoder(w).Encode([]map[string]any{{"number": 3, "title": "Done", "state": "closed"}})
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(Config{APIURL: server.URL}, nil)
+	issues, err := client.ListAssignedIssues(context.Background(), "secret", "o", "r", "octocat")
+	if err != nil {
+		t.Fatalf("ListAssignedIssues returned error: %v", err)
+	}
+	if len(issues) != 2 || issues[0].Number != 1 || issues[1].Number != 3 || !issues[1].Closed() {
+		t.Errorf("issues = %+v, want #1 open and #3 closed", issues)
+	}
+}
@@ -0,0 +1,174 @@
+package graphapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Tomlord1122/todo-backend/internal/authz"
+	"github.com/Tomlord1122/todo-backend/internal/domain"
+	"github.com/Tomlord1122/todo-backend/internal/notify"
+	"github.com/Tomlord1122/todo-backend/internal/pagination"
+	"github.com/Tomlord1122/todo-backend/internal/readonly"
+	"github.com/Tomlord1122/todo-backend/internal/realtime"
+	"github.com/Tomlord1122/todo-backend/internal/repository"
+	"github.com/Tomlord1122/todo-backend/internal/service"
+	"github.com/Tomlord1122/todo-backend/internal/suggest"
+)
+
+// countingUsers counts the batches of user lookups.
+type countingUsers struct {
+	service.UserService
+	batches int
+}
+
+func (u *countingUsers) GetUsers(ctx context.Context, ids []uint) ([]service.UserResponse, error) {
+	u.batches++
+	return u.UserService.GetUsers(ctx, ids)
+}
+
+type testAPI struct {
+	handler  http.Handler
+	users    *countingUsers
+	readOnly *readonly.Mode
+}
+
+func newTestAPI(t *testing.T) *testAPI {
+	t.Helper()
+	repos := repository.NewMemoryRepositories()
+	for _, user := range []domain.User{{Email: "admin@example.com", Name: "Ada", Role: domain.RoleAdmin}, {Email: "member@example.com", Name: "Max", Role: domain.RoleMember}} {
+		if err := repos.Users.Create(&user); err != nil {
+			t.Fatal(err)
+		}
+	}
+	notifier := notify.NewRegistry(notify.LogChannel{})
+	follow := service.NewFollowService(repos.Watchers, repos.Todos, repos.Preferences, repos.Users, notifier)
+	todos := service.NewTodoService(repos.Todos, repos.Tags, repos.Preferences, repos.Activities,
+		suggest.NewKeywordSuggester(suggest.DefaultKeywordRules()), realtime.NewHub(), follow, service.TodoConfig{Limits: pagination.DefaultConfig(), FuzzyThreshold: 0.3})
+	api := &testAPI{users: &countingUsers{UserService: service.NewUserService(repos.Users)}, readOnly: readonly.New()}
+	api.handler = NewHandler(Services{Todo: todos, Tags: service.NewTagService(repos.Tags), Users: api.users, ReadOnly: api.readOnly})
+	return api
+}
+
+type gqlResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []struct {
+		Message    string         `json:"message"`
+		Extensions map[string]any `json:"extensions"`
+	} `json:"errors"`
+}
+
+// do sends query as the user, 1 being the admin and 2 the member.
+func (api *testAPI) do(t *testing.T, userID uint, query string, variables map[string]any) gqlResponse {
+	t.Helper()
+	body, _ := json.Marshal(map[string]any{"query": query, "variables": variables})
+	req := httptest.NewRequest("POST", "/graphql", strings.NewReader(string(body)))
+	req.Header.Set("Content-Type", "application/json")
+	role := domain.RoleMember
+	if userID == 1 {
+		role = domain.RoleAdmin
+	}
+	req = req.WithContext(authz.NewContext(req.Context(), authz.Principal{UserID: userID, Role: role}))
+	rec := httptest.NewRecorder()
+	api.handler.ServeHTTP(rec, req)
+	var resp gqlResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("%s: %d %s", query, rec.Code, rec.Body)
+	}
+	return resp
+}
+
+const createTodo = `mutation($input: CreateTodoInput!) { createTodo(input: $input) { id version } }`
+
+func TestTodos(t *testing.T) {
+	api := newTestAPI(t)
+	var created struct {
+		CreateTodo struct {
+			ID      string `json:"id"`
+			Version int    `json:"version"`
+		} `json:"createTodo"`
+	}
+	for _, title := range []string{"Water the plants", "Buy milk", "Call mum"} {
+		resp := api.do(t, 2, createTodo, map[string]any{"input": map[string]any{"title": title, "tags": []string{"home", "errand"}}})
+		if len(resp.Errors) > 0 || json.Unmarshal(resp.Data, &created) != nil {
+			t.Fatalf("createTodo = %s %v", resp.Data, resp.Errors)
+		}
+	}
+
+	api.users.batches = 0
+	resp := api.do(t, 2, `{ todos(limit: 10) { nodes { title user { id name } tags { name } } pageInfo { total } } }`, nil)
+	want := `{"todos":{"nodes":[` +
+		`{"title":"Water the plants","user":{"id":"2","name":"Max"},"tags":[{"name":"errand"},{"name":"home"}]},` +
+		`{"title":"Buy milk","user":{"id":"2","name":"Max"},"tags":[{"name":"errand"},{"name":"home"}]},` +
+		`{"title":"Call mum","user":{"id":"2","name":"Max"},"tags":[{"name":"errand"},{"name":"home"}]}],` +
+		`"pageInfo":{"total":3}}}`
+	if string(resp.Data) != want || len(resp.Errors) > 0 {
+		t.Errorf("todos = %s %v, want %s", resp.Data, resp.Errors, want)
+	}
+	// The owners of the page are looked up together
+	if api.users.batches != 1 {
+		t.Errorf("user lookups = %d, want 1 batch", api.users.batches)
+	}
+
+	id := created.CreateTodo.ID
+	resp = api.do(t, 2, `mutation($id: ID!, $version: Int!) { updateTodo(id: $id, version: $version, input: {completed: true, tags: []}) { completed tags { name } } }`,
+		map[string]any{"id": id, "version": created.CreateTodo.Version})
+	if want := `{"updateTodo":{"completed":true,"tags":[]}}`; string(resp.Data) != want {
+		t.Errorf("updateTodo = %s %v, want %s", resp.Data, resp.Errors, want)
+	}
+
+	// Other members' todos don't exist for the caller; admins see them
+	query := `query($id: ID!) { todo(id: $id) { title } }`
+	if resp := api.do(t, 3, query, map[string]any{"id": id}); string(resp.Data) != `{"todo":null}` {
+		t.Errorf("todo of another user = %s %v, want null", resp.Data, resp.Errors)
+	}
+	if resp := api.do(t, 1, query, map[string]any{"id": id}); string(resp.Data) != `{"todo":{"title":"Call mum"}}` {
+		t.Errorf("todo as admin = %s %v", resp.Data, resp.Errors)
+	}
+
+	resp = api.do(t, 2, `mutation($id: ID!) { deleteTodo(id: $id, version: 1) }`, map[string]any{"id": id})
+	if len(resp.Errors) != 1 || resp.Errors[0].Extensions["code"] != "precondition_failed" {
+		t.Errorf("deleteTodo with a stale version = %s %v, want precondition_failed", resp.Data, resp.Errors)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	api := newTestAPI(t)
+	cases := []struct {
+		name, query string
+		userID      uint
+		variables   map[string]any
+		wantCode    string
+		wantMessage string
+	}{
+		{"invalid fields", createTodo, 2, map[string]any{"input": map[string]any{"title": " ", "priority": "urgent"}},
+			"invalid", `invalid fields: "title" is required; "priority" must be one of low, normal, high`},
+		{"bad date", createTodo, 2, map[string]any{"input": map[string]any{"title": "Later", "dueDate": "tomorrow"}},
+			"invalid", "invalid dueDate: expected an RFC 3339 timestamp"},
+		{"someone else's todos", `{ todos(userId: 1) { nodes { id } } }`, 2, nil,
+			"forbidden", "you can only access your own todos"},
+		{"missing todo", `mutation { deleteTodo(id: 99, version: 1) }`, 2, nil,
+			"not_found", "todo with ID 99 not found"},
+	}
+	for _, tc := range cases {
+		resp := api.do(t, tc.userID, tc.query, tc.variables)
+		if len(resp.Errors) != 1 || resp.Errors[0].Extensions["code"] != tc.wantCode || resp.Errors[0].Message != tc.wantMessage {
+			t.Errorf("%s = %v, want %s %q", tc.name, resp.Errors, tc.wantCode, tc.wantMessage)
+		}
+	}
+}
+
+func TestReadOnlyRejectsMutations(t *testing.T) {
+	api := newTestAPI(t)
+	api.readOnly.Set(true, "maintenance")
+	resp := api.do(t, 2, createTodo, map[string]any{"input": map[string]any{"title": "Buy milk"}})
+	if len(resp.Errors) != 1 || resp.Errors[0].Extensions["code"] != "unavailable" {
+		t.Errorf("createTodo in read-only mode = %v, want unavailable", resp.Errors)
+	}
+	if resp := api.do(t, 2, `{ todos { nodes { id } } }`, nil); len(resp.Errors) > 0 {
+		t.Errorf("todos in read-only mode = %v, want served", resp.Errors)
+	}
+}
@@ -0,0 +1,71 @@
+package graphapi
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/vikstrous/dataloadgen"
+
+	"github.com/Tomlord1122/todo-backend/internal/service"
+)
+
+// loaderWait is how long loaders collect keys before fetching them in one
+// batch.
+const loaderWait = time.Millisecond
+
+// loaders batch the lookups of todo associations within a request, so a
+// page of todos costs one user lookup and one tag lookup instead of one of
+// each per todo.
+type loaders struct {
+	users *dataloadgen.Loader[uint, service.UserResponse]
+	// tags loads all tags of a user, which a todo picks its own from
+	tags *dataloadgen.Loader[uint, []service.TagResponse]
+}
+
+type loadersKey struct{}
+
+func newLoaders(services Services) *loaders {
+	return &loaders{
+		users: dataloadgen.NewLoader(func(ctx context.Context, ids []uint) ([]service.UserResponse, []error) {
+			users, err := services.Users.GetUsers(ctx, ids)
+			if err != nil {
+				return nil, []error{err}
+			}
+			// Users from before password logins have no account, only an ID
+			resp := make([]service.UserResponse, len(ids))
+			for i, id := range ids {
+				resp[i] = service.UserResponse{ID: id}
+				if j := slices.IndexFunc(users, func(u service.UserResponse) bool { return u.ID == id }); j >= 0 {
+					resp[i] = users[j]
+				}
+			}
+			return resp, nil
+		}, dataloadgen.WithWait(loaderWait)),
+		tags: dataloadgen.NewLoader(func(ctx context.Context, userIDs []uint) ([][]service.TagResponse, []error) {
+			tags, err := services.Tags.ListTagsOfUsers(ctx, userIDs)
+			if err != nil {
+				return nil, []error{err}
+			}
+			resp := make([][]service.TagResponse, len(userIDs))
+			for i, id := range userIDs {
+				resp[i] = tags[id]
+			}
+			return resp, nil
+		}, dataloadgen.WithWait(loaderWait)),
+	}
+}
+
+// withLoaders gives every request loaders of its own, so nothing is cached
+// across requests.
+func withLoaders(services Services, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), loadersKey{}, newLoaders(services))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func loadersFrom(ctx context.Context) *loaders {
+	return ctx.Value(loadersKey{}).(*loaders)
+}
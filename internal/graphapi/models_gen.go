@@ -0,0 +1,54 @@
+// Code generated by github.com/99designs/gqlgen, DO NOT EDIT.
+
+package graphapi
+
+import (
+	"github.com/Tomlord1122/todo-backend/internal/service"
+)
+
+type CreateTodoInput struct {
+	Title       string         `json:"title"`
+	Description *string        `json:"description,omitempty"`
+	Priority    *string        `json:"priority,omitempty"`
+	ListID      *uint          `json:"listId,omitempty"`
+	DueDate     *string        `json:"dueDate,omitempty"`
+	StartDate   *string        `json:"startDate,omitempty"`
+	Location    *LocationInput `json:"location,omitempty"`
+	DependsOn   []uint         `json:"dependsOn,omitempty"`
+	Estimate    *float64       `json:"estimate,omitempty"`
+	Tags        []string       `json:"tags,omitempty"`
+	Recurrence  *string        `json:"recurrence,omitempty"`
+}
+
+type LocationInput struct {
+	Latitude     *float64 `json:"latitude,omitempty"`
+	Longitude    *float64 `json:"longitude,omitempty"`
+	RadiusMeters *float64 `json:"radiusMeters,omitempty"`
+}
+
+type Mutation struct {
+}
+
+type Query struct {
+}
+
+type TodoConnection struct {
+	Nodes    []*service.TodoResponse `json:"nodes"`
+	PageInfo *service.PageInfo       `json:"pageInfo"`
+}
+
+// Fields left out are unchanged; lists replace the current ones.
+type UpdateTodoInput struct {
+	Title       *string        `json:"title,omitempty"`
+	Description *string        `json:"description,omitempty"`
+	Completed   *bool          `json:"completed,omitempty"`
+	Priority    *string        `json:"priority,omitempty"`
+	ListID      *uint          `json:"listId,omitempty"`
+	DueDate     *string        `json:"dueDate,omitempty"`
+	StartDate   *string        `json:"startDate,omitempty"`
+	Location    *LocationInput `json:"location,omitempty"`
+	DependsOn   []uint         `json:"dependsOn,omitempty"`
+	Estimate    *float64       `json:"estimate,omitempty"`
+	Tags        []string       `json:"tags,omitempty"`
+	Recurrence  *string        `json:"recurrence,omitempty"`
+}
@@ -0,0 +1,162 @@
+// Package graphapi serves the todo API over GraphQL, as defined in
+// schema.graphqls, with gqlgen. Resolvers are a thin layer over the same
+// services as the HTTP API; the server authenticates requests before they
+// get here, and the owners and tags of todos are loaded in batches.
+package graphapi
+
+//go:generate go tool gqlgen generate --config gqlgen.yml
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+
+	"github.com/Tomlord1122/todo-backend/internal/apperror"
+	"github.com/Tomlord1122/todo-backend/internal/authz"
+	"github.com/Tomlord1122/todo-backend/internal/logging"
+	"github.com/Tomlord1122/todo-backend/internal/readonly"
+	"github.com/Tomlord1122/todo-backend/internal/service"
+	"github.com/Tomlord1122/todo-backend/internal/validate"
+)
+
+// complexityLimit bounds the work a single query may ask for.
+const complexityLimit = 500
+
+// Services bundles the services the GraphQL API depends on.
+type Services struct {
+	Todo  service.TodoService
+	Tags  service.TagService
+	Users service.UserService
+	// ReadOnly rejects mutations while it is on; optional
+	ReadOnly *readonly.Mode
+}
+
+// Resolver is the root resolver.
+type Resolver struct {
+	todos service.TodoService
+}
+
+// NewHandler returns the GraphQL endpoint. It expects requests to carry an
+// authz.Principal, like the HTTP handlers behind requireSession.
+func NewHandler(services Services) http.Handler {
+	srv := handler.New(NewExecutableSchema(Config{Resolvers: &Resolver{todos: services.Todo}}))
+	srv.AddTransport(transport.POST{})
+	srv.AddTransport(transport.GET{})
+	srv.Use(extension.FixedComplexityLimit(complexityLimit))
+	srv.SetErrorPresenter(presentError)
+	if services.ReadOnly != nil {
+		srv.AroundOperations(rejectMutationsWhen(services.ReadOnly))
+	}
+	return withLoaders(services, srv)
+}
+
+// rejectMutationsWhen rejects mutations while read-only mode is on, like
+// the HTTP API rejects writes; queries are served as usual.
+func rejectMutationsWhen(mode *readonly.Mode) graphql.OperationMiddleware {
+	return func(ctx context.Context, next graphql.OperationHandler) graphql.ResponseHandler {
+		op := graphql.GetOperationContext(ctx).Operation
+		if op == nil || op.Operation != ast.Mutation || !mode.Enabled() {
+			return next(ctx)
+		}
+		return graphql.OneShot(&graphql.Response{Errors: gqlerror.List{{
+			Message:    "the service is in read-only mode, please try again later",
+			Extensions: map[string]any{"code": apperror.CodeUnavailable},
+		}}})
+	}
+}
+
+// presentError adds the error code of service errors to the error's
+// extensions, the same code the HTTP API puts in problem responses.
+func presentError(ctx context.Context, err error) *gqlerror.Error {
+	gqlErr := graphql.DefaultErrorPresenter(ctx, err)
+	if code := apperror.CodeOf(err); code != "" {
+		gqlErr.Extensions = map[string]any{"code": code}
+	}
+	return gqlErr
+}
+
+// serviceError returns errors the client can act on as they are. Other
+// errors are logged, naming the failed service operation, and replaced by
+// fallback.
+func serviceError(ctx context.Context, err error, operation, fallback string) error {
+	if apperror.CodeOf(err) != "" {
+		return err
+	}
+	logging.FromContext(ctx).Error("Error calling service", "operation", operation, "err", err)
+	return errors.New(fallback)
+}
+
+// checkOwner lets the caller change the todo with id only if they own it
+// or may access every todo. Others get the same not found error as for a
+// todo that doesn't exist, so IDs don't reveal whose todos exist.
+func (r *Resolver) checkOwner(ctx context.Context, id uint) error {
+	if authz.Check(ctx, authz.AccessAllTodos) == nil {
+		return nil
+	}
+	todo, err := r.todos.GetTodoByID(ctx, id)
+	if err != nil {
+		return serviceError(ctx, err, "GetTodoByID", "failed to retrieve todo")
+	}
+	if !canAccess(ctx, todo) {
+		return apperror.NotFoundf("todo with ID %d not found", id)
+	}
+	return nil
+}
+
+// canAccess reports whether the caller may see todo.
+func canAccess(ctx context.Context, todo *service.TodoResponse) bool {
+	principal, _ := authz.FromContext(ctx)
+	return todo.UserID == principal.UserID || authz.Check(ctx, authz.AccessAllTodos) == nil
+}
+
+// validateRequest checks the validate tags of a service request, which the
+// HTTP API checks before decoding bodies into the same requests.
+func validateRequest(req any) error {
+	fields := validate.Struct(req)
+	if len(fields) == 0 {
+		return nil
+	}
+	reasons := make([]string, 0, len(fields))
+	for _, field := range fields {
+		reasons = append(reasons, fmt.Sprintf("%q %s", field.Field, field.Reason))
+	}
+	return apperror.Invalidf("invalid fields: %s", strings.Join(reasons, "; "))
+}
+
+// parseTime parses the RFC 3339 timestamp of an optional field.
+func parseTime(field string, value *string) (*time.Time, error) {
+	if value == nil {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, *value)
+	if err != nil {
+		return nil, apperror.Invalidf("invalid %s: expected an RFC 3339 timestamp", field)
+	}
+	return &t, nil
+}
+
+// deref returns the value of an optional argument, or its zero value.
+func deref[T any](v *T) T {
+	if v == nil {
+		var zero T
+		return zero
+	}
+	return *v
+}
+
+func toLocationRequest(loc *LocationInput) *service.LocationRequest {
+	if loc == nil {
+		return nil
+	}
+	return &service.LocationRequest{Latitude: loc.Latitude, Longitude: loc.Longitude, RadiusMeters: loc.RadiusMeters}
+}
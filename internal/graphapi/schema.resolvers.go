@@ -0,0 +1,179 @@
+package graphapi
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+// Code generated by github.com/99designs/gqlgen
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	"github.com/Tomlord1122/todo-backend/internal/apperror"
+	"github.com/Tomlord1122/todo-backend/internal/authz"
+	"github.com/Tomlord1122/todo-backend/internal/service"
+)
+
+// CreateTodo is the resolver for the createTodo field.
+func (r *mutationResolver) CreateTodo(ctx context.Context, input CreateTodoInput) (*service.TodoResponse, error) {
+	dueDate, err := parseTime("dueDate", input.DueDate)
+	if err != nil {
+		return nil, err
+	}
+	startDate, err := parseTime("startDate", input.StartDate)
+	if err != nil {
+		return nil, err
+	}
+	principal, _ := authz.FromContext(ctx)
+	create := service.CreateTodoRequest{
+		Title:       input.Title,
+		Description: deref(input.Description),
+		UserID:      principal.UserID,
+		Priority:    deref(input.Priority),
+		ListID:      input.ListID,
+		DueDate:     dueDate,
+		StartDate:   startDate,
+		Location:    toLocationRequest(input.Location),
+		DependsOn:   input.DependsOn,
+		Estimate:    input.Estimate,
+		Tags:        input.Tags,
+		Recurrence:  deref(input.Recurrence),
+	}
+	if err := validateRequest(create); err != nil {
+		return nil, err
+	}
+	todo, err := r.todos.CreateTodo(ctx, create)
+	if err != nil {
+		return nil, serviceError(ctx, err, "CreateTodo", "failed to create todo")
+	}
+	return todo, nil
+}
+
+// UpdateTodo is the resolver for the updateTodo field.
+func (r *mutationResolver) UpdateTodo(ctx context.Context, id uint, version int, input UpdateTodoInput) (*service.TodoResponse, error) {
+	dueDate, err := parseTime("dueDate", input.DueDate)
+	if err != nil {
+		return nil, err
+	}
+	startDate, err := parseTime("startDate", input.StartDate)
+	if err != nil {
+		return nil, err
+	}
+	update := service.UpdateTodoRequest{
+		Title:       input.Title,
+		Description: input.Description,
+		Completed:   input.Completed,
+		Priority:    input.Priority,
+		ListID:      input.ListID,
+		DueDate:     dueDate,
+		StartDate:   startDate,
+		Location:    toLocationRequest(input.Location),
+		Estimate:    input.Estimate,
+		Recurrence:  input.Recurrence,
+		Version:     uint(version),
+	}
+	// Lists that are given replace the current ones, even when empty
+	if input.DependsOn != nil {
+		update.DependsOn = &input.DependsOn
+	}
+	if input.Tags != nil {
+		update.Tags = &input.Tags
+	}
+	if err := validateRequest(update); err != nil {
+		return nil, err
+	}
+	if err := r.checkOwner(ctx, id); err != nil {
+		return nil, err
+	}
+	todo, err := r.todos.UpdateTodo(ctx, id, update)
+	if err != nil {
+		return nil, serviceError(ctx, err, "UpdateTodo", "failed to update todo")
+	}
+	return todo, nil
+}
+
+// DeleteTodo is the resolver for the deleteTodo field.
+func (r *mutationResolver) DeleteTodo(ctx context.Context, id uint, version int) (bool, error) {
+	if err := r.checkOwner(ctx, id); err != nil {
+		return false, err
+	}
+	if err := r.todos.DeleteTodo(ctx, id, uint(version)); err != nil {
+		return false, serviceError(ctx, err, "DeleteTodo", "failed to delete todo")
+	}
+	return true, nil
+}
+
+// Todos is the resolver for the todos field.
+func (r *queryResolver) Todos(ctx context.Context, limit *int, offset *int, cursor *string, completed *bool, tags []string, sort *string, userID *uint) (*TodoConnection, error) {
+	principal, _ := authz.FromContext(ctx)
+	owner := principal.UserID
+	if userID != nil && *userID != owner {
+		if authz.Check(ctx, authz.AccessAllTodos) != nil {
+			return nil, apperror.New(apperror.CodeForbidden, "you can only access your own todos")
+		}
+		owner = *userID
+	}
+	page := service.PageRequest{Limit: deref(limit), Offset: deref(offset), Cursor: deref(cursor)}
+	filter := service.TodoFilter{Completed: completed, UserID: &owner, Tags: tags, Sort: deref(sort)}
+	todos, info, err := r.todos.GetAllTodos(ctx, page, filter)
+	if err != nil {
+		return nil, serviceError(ctx, err, "GetAllTodos", "failed to retrieve todos")
+	}
+	conn := &TodoConnection{Nodes: make([]*service.TodoResponse, 0, len(todos)), PageInfo: info}
+	for i := range todos {
+		conn.Nodes = append(conn.Nodes, &todos[i])
+	}
+	return conn, nil
+}
+
+// Todo is the resolver for the todo field.
+func (r *queryResolver) Todo(ctx context.Context, id uint) (*service.TodoResponse, error) {
+	todo, err := r.todos.GetTodoByID(ctx, id)
+	if errors.Is(err, apperror.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, serviceError(ctx, err, "GetTodoByID", "failed to retrieve todo")
+	}
+	if !canAccess(ctx, todo) {
+		return nil, nil
+	}
+	return todo, nil
+}
+
+// User is the resolver for the user field.
+func (r *todoResolver) User(ctx context.Context, obj *service.TodoResponse) (*service.UserResponse, error) {
+	user, err := loadersFrom(ctx).users.Load(ctx, obj.UserID)
+	if err != nil {
+		return nil, serviceError(ctx, err, "GetUsers", "failed to retrieve user")
+	}
+	return &user, nil
+}
+
+// Tags is the resolver for the tags field.
+func (r *todoResolver) Tags(ctx context.Context, obj *service.TodoResponse) ([]*service.TagResponse, error) {
+	userTags, err := loadersFrom(ctx).tags.Load(ctx, obj.UserID)
+	if err != nil {
+		return nil, serviceError(ctx, err, "ListTagsOfUsers", "failed to retrieve tags")
+	}
+	tags := make([]*service.TagResponse, 0, len(obj.Tags))
+	for _, name := range obj.Tags {
+		if i := slices.IndexFunc(userTags, func(t service.TagResponse) bool { return t.Name == name }); i >= 0 {
+			tags = append(tags, &userTags[i])
+		}
+	}
+	return tags, nil
+}
+
+// Mutation returns MutationResolver implementation.
+func (r *Resolver) Mutation() MutationResolver { return &mutationResolver{r} }
+
+// Query returns QueryResolver implementation.
+func (r *Resolver) Query() QueryResolver { return &queryResolver{r} }
+
+// Todo returns TodoResolver implementation.
+func (r *Resolver) Todo() TodoResolver { return &todoResolver{r} }
+
+type mutationResolver struct{ *Resolver }
+type queryResolver struct{ *Resolver }
+type todoResolver struct{ *Resolver }
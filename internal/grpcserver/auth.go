@@ -0,0 +1,86 @@
+package grpcserver
+
+import (
+	"context"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/Tomlord1122/todo-backend/internal/authz"
+	"github.com/Tomlord1122/todo-backend/internal/logging"
+	"github.com/Tomlord1122/todo-backend/internal/service"
+)
+
+// publicMethodPrefixes are the methods callable without credentials.
+var publicMethodPrefixes = []string{"/grpc.health.v1.Health/"}
+
+// authenticator checks the credentials of calls, accepting the same ones
+// as the HTTP API.
+type authenticator struct {
+	auth     service.AuthService
+	sessions service.SessionService
+	apiKeys  service.APIKeyService
+	users    service.UserService
+}
+
+// unary rejects calls without valid credentials and makes the user and
+// their role available to the services through authz.FromContext.
+func (a *authenticator) unary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	for _, prefix := range publicMethodPrefixes {
+		if strings.HasPrefix(info.FullMethod, prefix) {
+			return handler(ctx, req)
+		}
+	}
+	userID, err := a.user(ctx)
+	if err == nil && userID == 0 {
+		err = service.ErrUnauthenticated
+	}
+	if err != nil {
+		return nil, statusOf(ctx, err, "Authenticate", "failed to check credentials")
+	}
+	principal, err := a.users.Principal(ctx, userID)
+	if err != nil {
+		return nil, statusOf(ctx, err, "Principal", "failed to check credentials")
+	}
+	return handler(authz.NewContext(ctx, principal), req)
+}
+
+// user returns the user signed in with the call's authorization metadata,
+// or 0 when it has none: "Bearer <token>" with a JWT access token or a
+// session token, or "ApiKey <key>".
+func (a *authenticator) user(ctx context.Context) (uint, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return 0, nil
+	}
+	if key, ok := strings.CutPrefix(values[0], "ApiKey "); ok && a.apiKeys != nil {
+		return a.apiKeys.Authenticate(ctx, key, time.Now())
+	}
+	token, ok := strings.CutPrefix(values[0], "Bearer ")
+	if !ok {
+		return 0, nil
+	}
+	// Session tokens are random and have no dots; JWTs have two
+	if a.auth != nil && strings.Count(token, ".") == 2 {
+		return a.auth.Authenticate(ctx, token, time.Now())
+	}
+	return a.sessions.Authenticate(ctx, token, time.Now())
+}
+
+// recoverPanics turns a panicking call into an Internal error, so one bad
+// request doesn't take the server down.
+func recoverPanics(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			logging.FromContext(ctx).Error("Panic serving call", "method", info.FullMethod, "panic", p, "stack", string(debug.Stack()))
+			err = status.Error(codes.Internal, "internal error")
+		}
+	}()
+	return handler(ctx, req)
+}
@@ -0,0 +1,37 @@
+package grpcserver
+
+import (
+	"context"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/Tomlord1122/todo-backend/internal/apperror"
+	"github.com/Tomlord1122/todo-backend/internal/logging"
+)
+
+// codeStatus maps error codes to gRPC status codes.
+var codeStatus = map[apperror.Code]codes.Code{
+	apperror.CodeInvalid:         codes.InvalidArgument,
+	apperror.CodeUnauthenticated: codes.Unauthenticated,
+	apperror.CodeForbidden:       codes.PermissionDenied,
+	apperror.CodeNotFound:        codes.NotFound,
+	apperror.CodeConflict:        codes.FailedPrecondition,
+	// The todo was changed concurrently; read it again and retry
+	apperror.CodePreconditionFailed: codes.Aborted,
+	apperror.CodeUnprocessable:      codes.InvalidArgument,
+	apperror.CodePending:            codes.Unavailable,
+	apperror.CodeUnavailable:        codes.Unavailable,
+}
+
+// statusOf maps an error returned by a service to a gRPC status. Errors the
+// client can act on keep their message; other errors are logged, naming
+// the failed service operation, and answered with Internal and fallback as
+// the message.
+func statusOf(ctx context.Context, err error, operation, fallback string) error {
+	if code, ok := codeStatus[apperror.CodeOf(err)]; ok {
+		return status.Error(code, err.Error())
+	}
+	logging.FromContext(ctx).Error("Error calling service", "operation", operation, "err", err)
+	return status.Error(codes.Internal, fallback)
+}
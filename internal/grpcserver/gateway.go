@@ -0,0 +1,48 @@
+package grpcserver
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"github.com/Tomlord1122/todo-backend/internal/problem"
+	"github.com/Tomlord1122/todo-backend/proto/todov1"
+)
+
+// NewGateway returns a handler that transcodes JSON/HTTP requests, bound to
+// paths in proto/todo_gateway.yaml, into calls to the gRPC server at the
+// other end of conn. Calls go through the gRPC server itself, so they get
+// its authentication and error mapping; the Authorization header is passed
+// on as metadata.
+//
+// Field names are the proto ones, in snake_case like the JSON API, and
+// errors are problem details like the JSON API's.
+func NewGateway(ctx context.Context, conn *grpc.ClientConn) (http.Handler, error) {
+	mux := runtime.NewServeMux(
+		runtime.WithMarshalerOption(runtime.MIMEWildcard, &runtime.JSONPb{
+			MarshalOptions:   protojson.MarshalOptions{UseProtoNames: true},
+			UnmarshalOptions: protojson.UnmarshalOptions{DiscardUnknown: true},
+		}),
+		runtime.WithErrorHandler(writeProblem),
+	)
+	if err := todov1.RegisterTodoServiceHandler(ctx, mux, conn); err != nil {
+		return nil, err
+	}
+	return mux, nil
+}
+
+// writeProblem answers a failed call with the HTTP status of its gRPC code.
+func writeProblem(_ context.Context, _ *runtime.ServeMux, _ runtime.Marshaler, w http.ResponseWriter, r *http.Request, err error) {
+	st := status.Convert(err)
+	d := problem.New(r, runtime.HTTPStatusFromCode(st.Code()), st.Message())
+	// The path the client sent, before the mount point was stripped
+	if u, err := url.ParseRequestURI(r.RequestURI); err == nil {
+		d.Instance = u.Path
+	}
+	problem.Write(w, d)
+}
@@ -0,0 +1,96 @@
+// Package grpcserver serves the todo API over gRPC, as defined in
+// proto/todo.proto. It is a thin layer over the same services as the HTTP
+// API: requests are authenticated the same way, go through the same
+// authorization and validation, and service errors are mapped to gRPC
+// status codes. NewGateway serves the same calls as JSON/HTTP.
+package grpcserver
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+
+	"github.com/Tomlord1122/todo-backend/internal/readonly"
+	"github.com/Tomlord1122/todo-backend/internal/service"
+	"github.com/Tomlord1122/todo-backend/proto/todov1"
+)
+
+// Config configures the gRPC server.
+type Config struct {
+	// Port is the TCP port to serve on; 0 disables the gRPC server.
+	Port int
+}
+
+// ConfigFromEnv reads GRPC_PORT. It is unset by default, which leaves the
+// gRPC server off.
+func ConfigFromEnv() (Config, error) {
+	var cfg Config
+	if v := os.Getenv("GRPC_PORT"); v != "" {
+		port, err := strconv.Atoi(v)
+		if err != nil || port < 0 || port > 65535 {
+			return cfg, fmt.Errorf("invalid GRPC_PORT %q: expected a port number", v)
+		}
+		cfg.Port = port
+	}
+	return cfg, nil
+}
+
+// Addr is the address to listen on.
+func (c Config) Addr() string {
+	return fmt.Sprintf(":%d", c.Port)
+}
+
+// Services bundles the services the gRPC API depends on.
+type Services struct {
+	Todo service.TodoService
+	// Auth, Session and APIKeys check credentials, like for HTTP requests;
+	// Auth and APIKeys are optional
+	Auth    service.AuthService
+	Session service.SessionService
+	APIKeys service.APIKeyService
+	Users   service.UserService
+	// ReadOnly rejects writes while it is on; optional
+	ReadOnly *readonly.Mode
+}
+
+// writeMethods are the calls that change todos, which read-only mode
+// rejects.
+var writeMethods = map[string]bool{
+	todov1.TodoService_CreateTodo_FullMethodName: true,
+	todov1.TodoService_UpdateTodo_FullMethodName: true,
+	todov1.TodoService_DeleteTodo_FullMethodName: true,
+}
+
+// New returns a gRPC server with the todo service, the standard health
+// service and server reflection, for tools like grpcurl.
+func New(services Services) *grpc.Server {
+	auth := &authenticator{auth: services.Auth, sessions: services.Session, apiKeys: services.APIKeys, users: services.Users}
+	interceptors := []grpc.UnaryServerInterceptor{recoverPanics}
+	if services.ReadOnly != nil {
+		interceptors = append(interceptors, rejectWritesWhen(services.ReadOnly))
+	}
+	srv := grpc.NewServer(grpc.ChainUnaryInterceptor(append(interceptors, auth.unary)...))
+	todov1.RegisterTodoServiceServer(srv, &todoServer{todos: services.Todo})
+	healthpb.RegisterHealthServer(srv, health.NewServer())
+	reflection.Register(srv)
+	return srv
+}
+
+// rejectWritesWhen rejects writes with Unavailable while read-only mode is
+// on, like the HTTP API answers them with 503; reads are served as usual.
+func rejectWritesWhen(mode *readonly.Mode) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if writeMethods[info.FullMethod] && mode.Enabled() {
+			return nil, status.Error(codes.Unavailable, "the service is in read-only mode, please try again later")
+		}
+		return handler(ctx, req)
+	}
+}
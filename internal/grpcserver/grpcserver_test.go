@@ -0,0 +1,304 @@
+package grpcserver
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/Tomlord1122/todo-backend/internal/jwt"
+	"github.com/Tomlord1122/todo-backend/internal/notify"
+	"github.com/Tomlord1122/todo-backend/internal/pagination"
+	"github.com/Tomlord1122/todo-backend/internal/problem"
+	"github.com/Tomlord1122/todo-backend/internal/readonly"
+	"github.com/Tomlord1122/todo-backend/internal/realtime"
+	"github.com/Tomlord1122/todo-backend/internal/repository"
+	"github.com/Tomlord1122/todo-backend/internal/service"
+	"github.com/Tomlord1122/todo-backend/internal/suggest"
+	"github.com/Tomlord1122/todo-backend/proto/todov1"
+)
+
+// newTestClient serves the gRPC API on in-memory repositories, in
+// read-only mode when readOnly is set and enabled, and returns a client for
+// it and the access tokens of an admin and of a member.
+func newTestClient(t *testing.T, readOnly *readonly.Mode) (*grpc.ClientConn, string, string) {
+	t.Helper()
+	repos := repository.NewMemoryRepositories()
+	notifier := notify.NewRegistry(notify.LogChannel{})
+	suggester := suggest.NewKeywordSuggester(suggest.DefaultKeywordRules())
+	auth, err := service.NewAuthService(repos.Users, service.AuthConfig{Secret: []byte(strings.Repeat("k", jwt.MinKeyLength)), TTL: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	follow := service.NewFollowService(repos.Watchers, repos.Todos, repos.Preferences, repos.Users, notifier)
+	srv := New(Services{
+		Todo:     service.NewTodoService(repos.Todos, repos.Tags, repos.Preferences, repos.Activities, suggester, realtime.NewHub(), follow, service.TodoConfig{Limits: pagination.DefaultConfig(), FuzzyThreshold: 0.3}),
+		Auth:     auth,
+		Session:  service.NewSessionService(repos.Sessions),
+		APIKeys:  service.NewAPIKeyService(repos.APIKeys),
+		Users:    service.NewUserService(repos.Users),
+		ReadOnly: readOnly,
+	})
+
+	// The first account is an admin, later ones are members
+	var tokens []string
+	for _, email := range []string{"admin@example.com", "member@example.com"} {
+		resp, err := auth.Register(context.Background(), service.RegisterRequest{Email: email, Password: "correct horse"}, time.Now())
+		if err != nil {
+			t.Fatal(err)
+		}
+		tokens = append(tokens, resp.AccessToken)
+	}
+
+	lis := bufconn.Listen(1 << 20)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn, tokens[0], tokens[1]
+}
+
+func withToken(token string) context.Context {
+	return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+token)
+}
+
+func TestTodoService(t *testing.T) {
+	conn, admin, member := newTestClient(t, nil)
+	client := todov1.NewTodoServiceClient(conn)
+
+	created, err := client.CreateTodo(withToken(member), &todov1.CreateTodoRequest{Title: "Water the plants", Priority: "high", Tags: []string{"home"}})
+	if err != nil {
+		t.Fatalf("CreateTodo: %v", err)
+	}
+	if created.Title != "Water the plants" || created.Priority != "high" || created.UserId == 0 {
+		t.Errorf("CreateTodo = %+v", created)
+	}
+
+	got, err := client.GetTodo(withToken(member), &todov1.GetTodoRequest{Id: created.Id})
+	if err != nil || got.Title != created.Title {
+		t.Errorf("GetTodo = %v, %v", got, err)
+	}
+
+	list, err := client.ListTodos(withToken(member), &todov1.ListTodosRequest{})
+	if err != nil || len(list.Todos) != 1 || list.Page.GetLimit() == 0 {
+		t.Errorf("ListTodos = %v, %v", list, err)
+	}
+
+	title := "Water the garden"
+	updated, err := client.UpdateTodo(withToken(member), &todov1.UpdateTodoRequest{Id: created.Id, Version: created.Version, Title: &title})
+	if err != nil || updated.Title != title {
+		t.Errorf("UpdateTodo = %v, %v", updated, err)
+	}
+	// The version is checked like If-Match
+	if _, err := client.UpdateTodo(withToken(member), &todov1.UpdateTodoRequest{Id: created.Id, Version: created.Version, Title: &title}); status.Code(err) != codes.Aborted {
+		t.Errorf("UpdateTodo with a stale version = %v, want Aborted", err)
+	}
+
+	// Admins may see every todo
+	if _, err := client.GetTodo(withToken(admin), &todov1.GetTodoRequest{Id: created.Id}); err != nil {
+		t.Errorf("GetTodo as admin: %v", err)
+	}
+
+	if _, err := client.DeleteTodo(withToken(member), &todov1.DeleteTodoRequest{Id: created.Id, Version: updated.Version}); err != nil {
+		t.Errorf("DeleteTodo: %v", err)
+	}
+	if _, err := client.GetTodo(withToken(member), &todov1.GetTodoRequest{Id: created.Id}); status.Code(err) != codes.NotFound {
+		t.Errorf("GetTodo after DeleteTodo = %v, want NotFound", err)
+	}
+}
+
+func TestTodoServiceErrors(t *testing.T) {
+	conn, admin, member := newTestClient(t, nil)
+	client := todov1.NewTodoServiceClient(conn)
+	adminTodo, err := client.CreateTodo(withToken(admin), &todov1.CreateTodoRequest{Title: "Admin's todo"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	badDate := "tomorrow"
+	adminID := adminTodo.UserId
+
+	cases := []struct {
+		name string
+		call func() error
+		want codes.Code
+	}{
+		{"no credentials", func() error {
+			_, err := client.GetTodo(context.Background(), &todov1.GetTodoRequest{Id: adminTodo.Id})
+			return err
+		}, codes.Unauthenticated},
+		{"bad token", func() error {
+			_, err := client.GetTodo(withToken("a.b.c"), &todov1.GetTodoRequest{Id: adminTodo.Id})
+			return err
+		}, codes.Unauthenticated},
+		{"missing todo", func() error {
+			_, err := client.GetTodo(withToken(member), &todov1.GetTodoRequest{Id: 999})
+			return err
+		}, codes.NotFound},
+		{"someone else's todo", func() error {
+			_, err := client.DeleteTodo(withToken(member), &todov1.DeleteTodoRequest{Id: adminTodo.Id, Version: adminTodo.Version})
+			return err
+		}, codes.NotFound},
+		{"someone else's todos", func() error {
+			_, err := client.ListTodos(withToken(member), &todov1.ListTodosRequest{UserId: &adminID})
+			return err
+		}, codes.PermissionDenied},
+		{"update without a version", func() error {
+			_, err := client.UpdateTodo(withToken(admin), &todov1.UpdateTodoRequest{Id: adminTodo.Id, Title: &badDate})
+			return err
+		}, codes.InvalidArgument},
+		{"delete without a version", func() error {
+			_, err := client.DeleteTodo(withToken(admin), &todov1.DeleteTodoRequest{Id: adminTodo.Id})
+			return err
+		}, codes.InvalidArgument},
+		{"bad date", func() error {
+			_, err := client.CreateTodo(withToken(member), &todov1.CreateTodoRequest{Title: "Later", DueDate: &badDate})
+			return err
+		}, codes.InvalidArgument},
+	}
+	for _, tc := range cases {
+		if err := tc.call(); status.Code(err) != tc.want {
+			t.Errorf("%s = %v, want %s", tc.name, err, tc.want)
+		}
+	}
+}
+
+func TestReadOnlyModeRejectsWrites(t *testing.T) {
+	mode := readonly.New()
+	conn, _, member := newTestClient(t, mode)
+	client := todov1.NewTodoServiceClient(conn)
+	todo, err := client.CreateTodo(withToken(member), &todov1.CreateTodoRequest{Title: "Before the failover"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mode.Set(true, "failover")
+
+	title := "During the failover"
+	if _, err := client.CreateTodo(withToken(member), &todov1.CreateTodoRequest{Title: title}); status.Code(err) != codes.Unavailable {
+		t.Errorf("CreateTodo in read-only mode = %v, want Unavailable", err)
+	}
+	if _, err := client.UpdateTodo(withToken(member), &todov1.UpdateTodoRequest{Id: todo.Id, Version: todo.Version, Title: &title}); status.Code(err) != codes.Unavailable {
+		t.Errorf("UpdateTodo in read-only mode = %v, want Unavailable", err)
+	}
+	if _, err := client.DeleteTodo(withToken(member), &todov1.DeleteTodoRequest{Id: todo.Id, Version: todo.Version}); status.Code(err) != codes.Unavailable {
+		t.Errorf("DeleteTodo in read-only mode = %v, want Unavailable", err)
+	}
+	// Reads are served as usual
+	if got, err := client.GetTodo(withToken(member), &todov1.GetTodoRequest{Id: todo.Id}); err != nil || got.Title != "Before the failover" {
+		t.Errorf("GetTodo in read-only mode = %v, %v", got, err)
+	}
+}
+
+func TestCreateTodoInvalidFields(t *testing.T) {
+	conn, _, member := newTestClient(t, nil)
+	client := todov1.NewTodoServiceClient(conn)
+	_, err := client.CreateTodo(withToken(member), &todov1.CreateTodoRequest{Title: " ", Priority: "urgent"})
+	st := status.Convert(err)
+	if st.Code() != codes.InvalidArgument {
+		t.Fatalf("CreateTodo = %v, want InvalidArgument", err)
+	}
+	var fields []string
+	for _, detail := range st.Details() {
+		if badRequest, ok := detail.(*errdetails.BadRequest); ok {
+			for _, v := range badRequest.FieldViolations {
+				fields = append(fields, v.Field+" "+v.Description)
+			}
+		}
+	}
+	want := "title is required, priority must be one of low, normal, high"
+	if strings.Join(fields, ", ") != want {
+		t.Errorf("field violations = %q, want %q", fields, want)
+	}
+}
+
+func TestHealthIsPublic(t *testing.T) {
+	conn, _, _ := newTestClient(t, nil)
+	resp, err := healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{})
+	if err != nil || resp.Status != healthpb.HealthCheckResponse_SERVING {
+		t.Errorf("Check = %v, %v", resp, err)
+	}
+}
+
+func TestGateway(t *testing.T) {
+	conn, _, member := newTestClient(t, nil)
+	gateway, err := NewGateway(context.Background(), conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	call := func(method, path, token, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		gateway.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := call(http.MethodPost, "/v1/todos", member, `{"title": "Water the plants", "due_date": "2030-01-02T00:00:00Z"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("POST /v1/todos = %d %s", rec.Code, rec.Body)
+	}
+	var created struct {
+		ID      string `json:"id"`
+		Title   string `json:"title"`
+		DueDate string `json:"due_date"`
+		Version string `json:"version"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &created); err != nil {
+		t.Fatal(err)
+	}
+	if created.Title != "Water the plants" || created.DueDate == "" {
+		t.Errorf("POST /v1/todos = %s", rec.Body)
+	}
+
+	if rec := call(http.MethodGet, "/v1/todos/"+created.ID, member, ""); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "Water the plants") {
+		t.Errorf("GET /v1/todos/%s = %d %s", created.ID, rec.Code, rec.Body)
+	}
+	if rec := call(http.MethodGet, "/v1/todos?limit=1", member, ""); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"limit":1`) {
+		t.Errorf("GET /v1/todos = %d %s", rec.Code, rec.Body)
+	}
+	// Writes carry the version like If-Match, in the body or the query
+	if rec := call(http.MethodPatch, "/v1/todos/"+created.ID, member, `{"completed": true}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("PATCH without a version = %d, want 400", rec.Code)
+	}
+	rec = call(http.MethodPatch, "/v1/todos/"+created.ID, member, `{"completed": true, "version": "`+created.Version+`"}`)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"completed":true`) {
+		t.Errorf("PATCH /v1/todos/%s = %d %s", created.ID, rec.Code, rec.Body)
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &created); err != nil {
+		t.Fatal(err)
+	}
+
+	// Errors keep the gRPC server's authentication and status mapping
+	if rec := call(http.MethodGet, "/v1/todos/"+created.ID, "", ""); rec.Code != http.StatusUnauthorized || rec.Header().Get("Content-Type") != problem.ContentType {
+		t.Errorf("GET without credentials = %d %s", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	if rec := call(http.MethodGet, "/v1/todos/999", member, ""); rec.Code != http.StatusNotFound {
+		t.Errorf("GET a missing todo = %d, want 404", rec.Code)
+	}
+	if rec := call(http.MethodPost, "/v1/todos", member, `{"title": "Later", "due_date": "tomorrow"}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("POST with a bad date = %d, want 400", rec.Code)
+	}
+
+	if rec := call(http.MethodDelete, "/v1/todos/"+created.ID+"?version="+created.Version, member, ""); rec.Code != http.StatusOK {
+		t.Errorf("DELETE /v1/todos/%s = %d %s", created.ID, rec.Code, rec.Body)
+	}
+}
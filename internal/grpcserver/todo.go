@@ -0,0 +1,287 @@
+package grpcserver
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/Tomlord1122/todo-backend/internal/authz"
+	"github.com/Tomlord1122/todo-backend/internal/service"
+	"github.com/Tomlord1122/todo-backend/proto/todov1"
+)
+
+// todoServer implements todov1.TodoServiceServer on the todo service.
+type todoServer struct {
+	todov1.UnimplementedTodoServiceServer
+	todos service.TodoService
+}
+
+func (s *todoServer) CreateTodo(ctx context.Context, req *todov1.CreateTodoRequest) (*todov1.Todo, error) {
+	dueDate, err := parseTime("due_date", req.DueDate)
+	if err != nil {
+		return nil, err
+	}
+	startDate, err := parseTime("start_date", req.StartDate)
+	if err != nil {
+		return nil, err
+	}
+	principal, _ := authz.FromContext(ctx)
+	create := service.CreateTodoRequest{
+		Title:       req.Title,
+		Description: req.Description,
+		UserID:      principal.UserID,
+		Priority:    req.Priority,
+		ListID:      toUintPtr(req.ListId),
+		DueDate:     dueDate,
+		StartDate:   startDate,
+		Location:    toLocationRequest(req.Location),
+		DependsOn:   toUints(req.DependsOn),
+		Estimate:    req.Estimate,
+		Tags:        req.Tags,
+		Recurrence:  req.Recurrence,
+	}
+	if err := validateRequest(create); err != nil {
+		return nil, err
+	}
+	todo, err := s.todos.CreateTodo(ctx, create)
+	if err != nil {
+		return nil, statusOf(ctx, err, "CreateTodo", "failed to create todo")
+	}
+	return toTodo(todo), nil
+}
+
+func (s *todoServer) GetTodo(ctx context.Context, req *todov1.GetTodoRequest) (*todov1.Todo, error) {
+	todo, err := s.todos.GetTodoByID(ctx, uint(req.Id))
+	if err != nil {
+		return nil, statusOf(ctx, err, "GetTodoByID", "failed to retrieve todo")
+	}
+	if !canAccess(ctx, todo) {
+		return nil, todoNotFound(req.Id)
+	}
+	return toTodo(todo), nil
+}
+
+func (s *todoServer) ListTodos(ctx context.Context, req *todov1.ListTodosRequest) (*todov1.ListTodosResponse, error) {
+	principal, _ := authz.FromContext(ctx)
+	userID := principal.UserID
+	if req.UserId != nil && uint(*req.UserId) != userID {
+		if authz.Check(ctx, authz.AccessAllTodos) != nil {
+			return nil, status.Error(codes.PermissionDenied, "you can only access your own todos")
+		}
+		userID = uint(*req.UserId)
+	}
+	page := service.PageRequest{Limit: int(req.Limit), Offset: int(req.Offset), Cursor: req.Cursor}
+	filter := service.TodoFilter{Completed: req.Completed, UserID: &userID, Tags: req.Tags, Sort: req.Sort}
+	todos, info, err := s.todos.GetAllTodos(ctx, page, filter)
+	if err != nil {
+		return nil, statusOf(ctx, err, "GetAllTodos", "failed to retrieve todos")
+	}
+	resp := &todov1.ListTodosResponse{Todos: make([]*todov1.Todo, 0, len(todos)), Page: toPageInfo(info)}
+	for i := range todos {
+		resp.Todos = append(resp.Todos, toTodo(&todos[i]))
+	}
+	return resp, nil
+}
+
+func (s *todoServer) SearchTodos(ctx context.Context, req *todov1.SearchTodosRequest) (*todov1.SearchTodosResponse, error) {
+	principal, _ := authz.FromContext(ctx)
+	results, info, err := s.todos.SearchTodos(ctx, service.SearchTodosRequest{
+		Query:     req.Query,
+		Fuzzy:     req.Fuzzy,
+		Threshold: req.Threshold,
+		Page:      service.PageRequest{Limit: int(req.Limit), Offset: int(req.Offset)},
+		UserID:    principal.UserID,
+	})
+	if err != nil {
+		return nil, statusOf(ctx, err, "SearchTodos", "failed to search todos")
+	}
+	resp := &todov1.SearchTodosResponse{Results: make([]*todov1.SearchResult, 0, len(results)), Page: toPageInfo(info)}
+	for i := range results {
+		resp.Results = append(resp.Results, &todov1.SearchResult{Todo: toTodo(&results[i].TodoResponse), Score: results[i].Score})
+	}
+	return resp, nil
+}
+
+func (s *todoServer) UpdateTodo(ctx context.Context, req *todov1.UpdateTodoRequest) (*todov1.Todo, error) {
+	if err := requireVersion(req.Version); err != nil {
+		return nil, err
+	}
+	if err := s.checkOwner(ctx, req.Id); err != nil {
+		return nil, err
+	}
+	dueDate, err := parseTime("due_date", req.DueDate)
+	if err != nil {
+		return nil, err
+	}
+	startDate, err := parseTime("start_date", req.StartDate)
+	if err != nil {
+		return nil, err
+	}
+	update := service.UpdateTodoRequest{
+		Title:       req.Title,
+		Description: req.Description,
+		Completed:   req.Completed,
+		Priority:    req.Priority,
+		ListID:      toUintPtr(req.ListId),
+		DueDate:     dueDate,
+		StartDate:   startDate,
+		Location:    toLocationRequest(req.Location),
+		Estimate:    req.Estimate,
+		Recurrence:  req.Recurrence,
+		Version:     uint(req.Version),
+	}
+	if req.DependsOn != nil {
+		dependsOn := toUints(req.DependsOn.Ids)
+		if dependsOn == nil {
+			dependsOn = []uint{}
+		}
+		update.DependsOn = &dependsOn
+	}
+	if req.Tags != nil {
+		tags := append([]string{}, req.Tags.Names...)
+		update.Tags = &tags
+	}
+	if err := validateRequest(update); err != nil {
+		return nil, err
+	}
+	todo, err := s.todos.UpdateTodo(ctx, uint(req.Id), update)
+	if err != nil {
+		return nil, statusOf(ctx, err, "UpdateTodo", "failed to update todo")
+	}
+	return toTodo(todo), nil
+}
+
+func (s *todoServer) DeleteTodo(ctx context.Context, req *todov1.DeleteTodoRequest) (*todov1.DeleteTodoResponse, error) {
+	if err := requireVersion(req.Version); err != nil {
+		return nil, err
+	}
+	if err := s.checkOwner(ctx, req.Id); err != nil {
+		return nil, err
+	}
+	if err := s.todos.DeleteTodo(ctx, uint(req.Id), uint(req.Version)); err != nil {
+		return nil, statusOf(ctx, err, "DeleteTodo", "failed to delete todo")
+	}
+	return &todov1.DeleteTodoResponse{}, nil
+}
+
+// requireVersion rejects writes without the version they apply to, like
+// the HTTP API requires If-Match, so they can't overwrite changes the
+// caller hasn't seen.
+func requireVersion(version uint64) error {
+	if version == 0 {
+		return status.Error(codes.InvalidArgument, "version is required, send the version of the todo read")
+	}
+	return nil
+}
+
+// checkOwner lets the caller change the todo with id only if they own it
+// or may access every todo. Others get the same NotFound as for a todo
+// that doesn't exist, so IDs don't reveal whose todos exist.
+func (s *todoServer) checkOwner(ctx context.Context, id uint64) error {
+	if authz.Check(ctx, authz.AccessAllTodos) == nil {
+		return nil
+	}
+	todo, err := s.todos.GetTodoByID(ctx, uint(id))
+	if err != nil {
+		return statusOf(ctx, err, "GetTodoByID", "failed to retrieve todo")
+	}
+	if !canAccess(ctx, todo) {
+		return todoNotFound(id)
+	}
+	return nil
+}
+
+// canAccess reports whether the caller may see todo.
+func canAccess(ctx context.Context, todo *service.TodoResponse) bool {
+	principal, _ := authz.FromContext(ctx)
+	return todo.UserID == principal.UserID || authz.Check(ctx, authz.AccessAllTodos) == nil
+}
+
+func todoNotFound(id uint64) error {
+	return status.Errorf(codes.NotFound, "todo with ID %d not found", id)
+}
+
+// parseTime parses the RFC 3339 timestamp of an optional field.
+func parseTime(field string, value *string) (*time.Time, error) {
+	if value == nil {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, *value)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("invalid %s: expected an RFC 3339 timestamp", field))
+	}
+	return &t, nil
+}
+
+func toTodo(todo *service.TodoResponse) *todov1.Todo {
+	out := &todov1.Todo{
+		Id:          uint64(todo.ID),
+		Title:       todo.Title,
+		Description: todo.Description,
+		Completed:   todo.Completed,
+		Priority:    todo.Priority,
+		UserId:      uint64(todo.UserID),
+		DueDate:     todo.DueDate,
+		StartDate:   todo.StartDate,
+		DependsOn:   make([]uint64, 0, len(todo.DependsOn)),
+		CompletedAt: todo.CompletedAt,
+		CreatedAt:   todo.CreatedAt,
+		UpdatedAt:   todo.UpdatedAt,
+		Estimate:    todo.Estimate,
+		Tags:        todo.Tags,
+		Recurrence:  todo.Recurrence,
+		Version:     uint64(todo.Version),
+	}
+	if todo.ListID != nil {
+		listID := uint64(*todo.ListID)
+		out.ListId = &listID
+	}
+	for _, id := range todo.DependsOn {
+		out.DependsOn = append(out.DependsOn, uint64(id))
+	}
+	if loc := todo.Location; loc != nil {
+		out.Location = &todov1.Location{Latitude: &loc.Latitude, Longitude: &loc.Longitude, RadiusMeters: &loc.RadiusMeters}
+	}
+	return out
+}
+
+func toPageInfo(info *service.PageInfo) *todov1.PageInfo {
+	if info == nil {
+		return nil
+	}
+	out := &todov1.PageInfo{Limit: int32(info.Limit), Offset: int32(info.Offset), Total: info.Total, NextCursor: info.NextCursor}
+	if info.NextOffset != nil {
+		next := int32(*info.NextOffset)
+		out.NextOffset = &next
+	}
+	return out
+}
+
+func toLocationRequest(loc *todov1.Location) *service.LocationRequest {
+	if loc == nil {
+		return nil
+	}
+	return &service.LocationRequest{Latitude: loc.Latitude, Longitude: loc.Longitude, RadiusMeters: loc.RadiusMeters}
+}
+
+func toUintPtr(v *uint64) *uint {
+	if v == nil {
+		return nil
+	}
+	u := uint(*v)
+	return &u
+}
+
+func toUints(ids []uint64) []uint {
+	if len(ids) == 0 {
+		return nil
+	}
+	out := make([]uint, len(ids))
+	for i, id := range ids {
+		out[i] = uint(id)
+	}
+	return out
+}
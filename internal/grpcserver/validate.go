@@ -0,0 +1,35 @@
+package grpcserver
+
+import (
+	"fmt"
+	"strings"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/Tomlord1122/todo-backend/internal/validate"
+)
+
+// validateRequest returns an InvalidArgument status listing the fields of
+// req, a service request, that fail their validate tags as BadRequest field
+// violations, or nil. The HTTP API checks the same tags before decoding
+// bodies into the same requests. Fields are named by their JSON names,
+// which match the proto field names.
+func validateRequest(req any) error {
+	fields := validate.Struct(req)
+	if len(fields) == 0 {
+		return nil
+	}
+	violations := make([]*errdetails.BadRequest_FieldViolation, 0, len(fields))
+	reasons := make([]string, 0, len(fields))
+	for _, field := range fields {
+		violations = append(violations, &errdetails.BadRequest_FieldViolation{Field: field.Field, Description: field.Reason})
+		reasons = append(reasons, fmt.Sprintf("%q %s", field.Field, field.Reason))
+	}
+	st := status.New(codes.InvalidArgument, "invalid fields: "+strings.Join(reasons, "; "))
+	if detailed, err := st.WithDetails(&errdetails.BadRequest{FieldViolations: violations}); err == nil {
+		st = detailed
+	}
+	return st.Err()
+}
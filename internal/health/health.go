@@ -0,0 +1,138 @@
+// Package health checks the dependencies the service talks to, such as the
+// database, Redis and object storage, for the readiness endpoint.
+package health
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// Status is the state of one dependency or of the service as a whole.
+type Status string
+
+const (
+	// StatusUp means the dependency answered in time.
+	StatusUp Status = "up"
+	// StatusDegraded means a dependency is slow, or an optional one is down:
+	// the service works, with some features failing or slower.
+	StatusDegraded Status = "degraded"
+	// StatusDown means a required dependency is unavailable.
+	StatusDown Status = "down"
+)
+
+// CheckFunc checks one dependency; it returns nil when it is usable.
+type CheckFunc func(ctx context.Context) error
+
+// Result is the outcome of one check.
+type Result struct {
+	Status Status `json:"status"`
+	// Critical dependencies take the whole service down when they fail
+	Critical  bool    `json:"critical"`
+	LatencyMS float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// Report is the outcome of all checks. Status rolls them up: down when a
+// critical check fails, degraded when any other check isn't up.
+type Report struct {
+	Status Status            `json:"status"`
+	Checks map[string]Result `json:"checks"`
+}
+
+type check struct {
+	name     string
+	critical bool
+	fn       CheckFunc
+}
+
+// Checker runs the registered checks concurrently, each with a timeout.
+type Checker struct {
+	timeout time.Duration
+	checks  []check
+}
+
+// NewChecker creates a Checker. A check taking longer than timeout fails;
+// one taking more than half of it passes as degraded.
+func NewChecker(timeout time.Duration) *Checker {
+	return &Checker{timeout: timeout}
+}
+
+// TimeoutFromEnv reads HEALTH_CHECK_TIMEOUT (a duration), defaulting to 2s.
+func TimeoutFromEnv() (time.Duration, error) {
+	v := os.Getenv("HEALTH_CHECK_TIMEOUT")
+	if v == "" {
+		return 2 * time.Second, nil
+	}
+	timeout, err := time.ParseDuration(v)
+	if err != nil || timeout <= 0 {
+		return 2 * time.Second, fmt.Errorf("invalid HEALTH_CHECK_TIMEOUT %q, must be a positive duration", v)
+	}
+	return timeout, nil
+}
+
+// Add registers a check. Call it before the checker is used.
+func (c *Checker) Add(name string, critical bool, fn CheckFunc) {
+	c.checks = append(c.checks, check{name: name, critical: critical, fn: fn})
+}
+
+// Run runs every check and rolls up the results.
+func (c *Checker) Run(ctx context.Context) Report {
+	report := Report{Status: StatusUp, Checks: make(map[string]Result, len(c.checks))}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, chk := range c.checks {
+		wg.Add(1)
+		go func(chk check) {
+			defer wg.Done()
+			result := c.run(ctx, chk)
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[chk.name] = result
+			switch {
+			case result.Status == StatusDown && chk.critical:
+				report.Status = StatusDown
+			case result.Status != StatusUp && report.Status == StatusUp:
+				report.Status = StatusDegraded
+			}
+		}(chk)
+	}
+	wg.Wait()
+	return report
+}
+
+func (c *Checker) run(ctx context.Context, chk check) Result {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	// A hanging check must not hold up the report past its timeout
+	done := make(chan error, 1)
+	start := time.Now()
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- fmt.Errorf("check panicked: %v", r)
+			}
+		}()
+		done <- chk.fn(ctx)
+	}()
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = fmt.Errorf("no answer within %s", c.timeout)
+	}
+	latency := time.Since(start)
+
+	result := Result{Status: StatusUp, Critical: chk.critical, LatencyMS: float64(latency.Microseconds()) / 1000}
+	switch {
+	case err != nil:
+		result.Status = StatusDown
+		result.Error = err.Error()
+	case latency > c.timeout/2:
+		result.Status = StatusDegraded
+	}
+	return result
+}
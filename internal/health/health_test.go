@@ -0,0 +1,53 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCheckerRollsUp(t *testing.T) {
+	ok := func(context.Context) error { return nil }
+	failing := func(context.Context) error { return errors.New("connection refused") }
+	slow := func(ctx context.Context) error {
+		time.Sleep(60 * time.Millisecond)
+		return nil
+	}
+	hanging := func(ctx context.Context) error {
+		time.Sleep(time.Second)
+		return nil
+	}
+
+	cases := []struct {
+		name   string
+		setup  func(c *Checker)
+		status Status
+	}{
+		{"no checks", func(c *Checker) {}, StatusUp},
+		{"all up", func(c *Checker) { c.Add("postgres", true, ok); c.Add("redis", false, ok) }, StatusUp},
+		{"optional down", func(c *Checker) { c.Add("postgres", true, ok); c.Add("redis", false, failing) }, StatusDegraded},
+		{"critical slow", func(c *Checker) { c.Add("postgres", true, slow) }, StatusDegraded},
+		{"critical down", func(c *Checker) { c.Add("postgres", true, failing); c.Add("redis", false, ok) }, StatusDown},
+		{"critical hanging", func(c *Checker) { c.Add("postgres", true, hanging) }, StatusDown},
+	}
+	for _, tc := range cases {
+		c := NewChecker(100 * time.Millisecond)
+		tc.setup(c)
+		start := time.Now()
+		report := c.Run(context.Background())
+		if report.Status != tc.status {
+			t.Errorf("%s: status = %s, want %s (%+v)", tc.name, report.Status, tc.status, report.Checks)
+		}
+		if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+			t.Errorf("%s: Run took %s, checks should time out", tc.name, elapsed)
+		}
+	}
+
+	c := NewChecker(time.Second)
+	c.Add("smtp", false, failing)
+	result := c.Run(context.Background()).Checks["smtp"]
+	if result.Status != StatusDown || result.Critical || result.Error != "connection refused" {
+		t.Errorf("smtp result = %+v, want down with the error", result)
+	}
+}
@@ -0,0 +1,224 @@
+// Package importer parses todo exports from other tools into rows that can
+// be created as todos. Parsing is all-or-nothing for the file's structure
+// but per row for its values, so one bad row doesn't reject the file.
+package importer
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Supported formats
+const (
+	// FormatCSV has a header row naming the columns title (required),
+	// description, completed, priority, due_date and estimate.
+	FormatCSV = "csv"
+	// FormatTodoist is Todoist's CSV project export.
+	FormatTodoist = "todoist"
+	// FormatTrello is Trello's JSON board export.
+	FormatTrello = "trello"
+)
+
+// Formats lists the supported formats.
+var Formats = []string{FormatCSV, FormatTodoist, FormatTrello}
+
+// Row is a todo to create.
+type Row struct {
+	Title       string
+	Description string
+	Completed   bool
+	// Priority is low, normal, high or empty for the default
+	Priority string
+	DueDate  *time.Time
+	Estimate *float64
+}
+
+// Record is one parsed row. Line is where it came from, 1-based, for error
+// reports; Err is set when the row can't be imported.
+type Record struct {
+	Line int
+	Row  Row
+	Err  error
+}
+
+// Parse reads data in format.
+func Parse(format string, data []byte) ([]Record, error) {
+	switch format {
+	case FormatCSV:
+		return parseCSV(data)
+	case FormatTodoist:
+		return parseTodoist(data)
+	case FormatTrello:
+		return parseTrello(data)
+	}
+	return nil, fmt.Errorf("unknown format %q, expected one of %s", format, strings.Join(Formats, ", "))
+}
+
+// readCSV returns the rows of a CSV file keyed by lower-cased header.
+func readCSV(data []byte) ([]map[string]string, error) {
+	reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\ufeff"))))
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.New("the file is empty")
+	}
+	if err != nil {
+		return nil, err
+	}
+	for i := range header {
+		header[i] = strings.ToLower(strings.TrimSpace(header[i]))
+	}
+
+	var rows []map[string]string
+	for {
+		fields, err := reader.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		row := make(map[string]string, len(header))
+		for i, name := range header {
+			if i < len(fields) {
+				row[name] = strings.TrimSpace(fields[i])
+			}
+		}
+		rows = append(rows, row)
+	}
+}
+
+func parseCSV(data []byte) ([]Record, error) {
+	rows, err := readCSV(data)
+	if err != nil {
+		return nil, err
+	}
+	records := make([]Record, 0, len(rows))
+	for i, fields := range rows {
+		record := Record{Line: i + 2}
+		record.Row, record.Err = csvRow(fields)
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+func csvRow(fields map[string]string) (Row, error) {
+	row := Row{Title: fields["title"], Description: fields["description"], Priority: strings.ToLower(fields["priority"])}
+	if row.Title == "" {
+		return row, errors.New("title is empty")
+	}
+	switch row.Priority {
+	case "", "low", "normal", "high":
+	default:
+		return row, fmt.Errorf("priority %q must be low, normal or high", fields["priority"])
+	}
+	if v := fields["completed"]; v != "" {
+		completed, err := strconv.ParseBool(v)
+		if err != nil {
+			return row, fmt.Errorf("completed %q must be true or false", v)
+		}
+		row.Completed = completed
+	}
+	if v := fields["due_date"]; v != "" {
+		due, err := parseDate(v)
+		if err != nil {
+			return row, err
+		}
+		row.DueDate = &due
+	}
+	if v := fields["estimate"]; v != "" {
+		estimate, err := strconv.ParseFloat(v, 64)
+		if err != nil || estimate < 0 {
+			return row, fmt.Errorf("estimate %q must be a non-negative number", v)
+		}
+		row.Estimate = &estimate
+	}
+	return row, nil
+}
+
+// parseDate accepts RFC 3339 timestamps and YYYY-MM-DD dates, in UTC.
+func parseDate(v string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.DateOnly, v); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("date %q must be YYYY-MM-DD or RFC 3339", v)
+}
+
+// parseTodoist reads Todoist's CSV export. Only task rows are imported;
+// sections and notes are skipped. Todoist exports natural-language dates
+// ("every monday"), so only dates that parse exactly are kept.
+func parseTodoist(data []byte) ([]Record, error) {
+	rows, err := readCSV(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) > 0 {
+		if _, ok := rows[0]["content"]; !ok {
+			return nil, errors.New("not a Todoist export: there is no CONTENT column")
+		}
+	}
+	var records []Record
+	for i, fields := range rows {
+		if !strings.EqualFold(fields["type"], "task") {
+			continue
+		}
+		record := Record{Line: i + 2, Row: Row{Title: fields["content"], Description: fields["description"]}}
+		// Todoist's p1 is the most urgent
+		switch fields["priority"] {
+		case "1":
+			record.Row.Priority = "high"
+		case "4":
+			record.Row.Priority = "low"
+		}
+		if due, err := parseDate(fields["date"]); err == nil {
+			record.Row.DueDate = &due
+		}
+		if record.Row.Title == "" {
+			record.Err = errors.New("task content is empty")
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// trelloBoard is the part of Trello's board export that is imported.
+type trelloBoard struct {
+	Cards []struct {
+		Name        string     `json:"name"`
+		Desc        string     `json:"desc"`
+		Closed      bool       `json:"closed"`
+		Due         *time.Time `json:"due"`
+		DueComplete bool       `json:"dueComplete"`
+	} `json:"cards"`
+}
+
+// parseTrello reads Trello's JSON board export. Archived cards are
+// skipped; a card counts as done when its due date is marked complete.
+func parseTrello(data []byte) ([]Record, error) {
+	var board trelloBoard
+	if err := json.Unmarshal(data, &board); err != nil {
+		return nil, fmt.Errorf("not a Trello board export: %w", err)
+	}
+	var records []Record
+	for i, card := range board.Cards {
+		if card.Closed {
+			continue
+		}
+		record := Record{Line: i + 1, Row: Row{Title: strings.TrimSpace(card.Name), Description: card.Desc, Completed: card.DueComplete, DueDate: card.Due}}
+		if record.Row.Title == "" {
+			record.Err = errors.New("card name is empty")
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
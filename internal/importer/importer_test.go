@@ -0,0 +1,89 @@
+package importer
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCSV(t *testing.T) {
+	data := []byte("\ufeffTitle,Completed,Priority,Due_Date,Estimate\n" +
+		"Buy milk,true,high,2026-03-01,2\n" +
+		",false,,,\n" +
+		"Call mom,maybe,,,\n" +
+		"Pay rent,,,2026-03-05T09:00:00Z,\n")
+	records, err := Parse(FormatCSV, data)
+	if err != nil {
+		t.Fatalf("Parse returned error: %v", err)
+	}
+	if len(records) != 4 {
+		t.Fatalf("got %d records, want 4", len(records))
+	}
+
+	first := records[0]
+	if first.Err != nil || first.Row.Title != "Buy milk" || !first.Row.Completed || first.Row.Priority != "high" ||
+		!first.Row.DueDate.Equal(time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)) || *first.Row.Estimate != 2 {
+		t.Errorf("first record = %+v", first)
+	}
+	if records[1].Err == nil || records[1].Line != 3 {
+		t.Errorf("a row without title should fail on line 3, got %+v", records[1])
+	}
+	if records[2].Err == nil {
+		t.Error("completed=maybe should fail")
+	}
+	if records[3].Err != nil || records[3].Row.DueDate.Hour() != 9 {
+		t.Errorf("RFC 3339 due date: %+v", records[3])
+	}
+}
+
+func TestParseTodoist(t *testing.T) {
+	data := []byte("TYPE,CONTENT,DESCRIPTION,PRIORITY,INDENT,AUTHOR,RESPONSIBLE,DATE,DATE_LANG,TIMEZONE\n" +
+		"section,Errands,,,,,,,,\n" +
+		"task,Buy milk,2%,1,1,,,2026-03-01,en,UTC\n" +
+		"task,Stretch,,4,1,,,every day,en,UTC\n" +
+		"note,Remember the oat one,,,,,,,,\n")
+	records, err := Parse(FormatTodoist, data)
+	if err != nil {
+		t.Fatalf("Parse returned error: %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("got %d records, want the 2 tasks", len(records))
+	}
+	if r := records[0].Row; r.Title != "Buy milk" || r.Description != "2%" || r.Priority != "high" || r.DueDate == nil {
+		t.Errorf("first task = %+v", r)
+	}
+	if r := records[1].Row; r.Priority != "low" || r.DueDate != nil {
+		t.Errorf("recurring task = %+v, want low priority without a due date", r)
+	}
+
+	if _, err := Parse(FormatTodoist, []byte("title\nx\n")); err == nil {
+		t.Error("a CSV without CONTENT column should be rejected")
+	}
+}
+
+func TestParseTrello(t *testing.T) {
+	data := []byte(`{"name":"Board","cards":[
+		{"name":"Ship it","desc":"v1","due":"2026-03-01T12:00:00.000Z","dueComplete":true},
+		{"name":"Old idea","closed":true},
+		{"name":"  "}
+	]}`)
+	records, err := Parse(FormatTrello, data)
+	if err != nil {
+		t.Fatalf("Parse returned error: %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("got %d records, want 2 without the archived card", len(records))
+	}
+	if r := records[0].Row; r.Title != "Ship it" || !r.Completed || r.DueDate == nil {
+		t.Errorf("first card = %+v", r)
+	}
+	if records[1].Err == nil {
+		t.Error("a card without name should fail")
+	}
+
+	if _, err := Parse(FormatTrello, []byte("not json")); err == nil {
+		t.Error("invalid JSON should be rejected")
+	}
+	if _, err := Parse("asana", nil); err == nil {
+		t.Error("unknown formats should be rejected")
+	}
+}
@@ -0,0 +1,108 @@
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// LeaseStore hands out time-limited leases, see repository.LeaseRepository.
+type LeaseStore interface {
+	Acquire(name, holder string, ttl time.Duration) (bool, error)
+	Release(name, holder string) error
+}
+
+// Elector elects one leader among the instances sharing a LeaseStore.
+// Renew must be called well within the lease TTL, e.g. every TTL/3; when
+// the leader dies its lease runs out and another instance's next Renew
+// takes over.
+type Elector struct {
+	store  LeaseStore
+	name   string
+	holder string
+	ttl    time.Duration
+	now    func() time.Time
+
+	mu sync.Mutex
+	// leaderUntil is when this instance's lease runs out, as far as it
+	// knows; zero when it isn't the leader
+	leaderUntil time.Time
+}
+
+// NewElector creates an elector for the lease name. Each instance gets a
+// unique holder ID.
+func NewElector(store LeaseStore, name string, ttl time.Duration) *Elector {
+	host, _ := os.Hostname()
+	suffix := make([]byte, 4)
+	_, _ = rand.Read(suffix)
+	holder := fmt.Sprintf("%s/%d/%s", host, os.Getpid(), hex.EncodeToString(suffix))
+	return &Elector{store: store, name: name, holder: holder, ttl: ttl, now: time.Now}
+}
+
+// Renew takes or extends the lease. It is a Task, so it can run on the
+// scheduler.
+func (e *Elector) Renew(ctx context.Context) error {
+	// Measured before asking, so the local view never outlives the lease
+	started := e.now()
+	acquired, err := e.store.Acquire(e.name, e.holder, e.ttl)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	wasLeader := e.isLeader(started)
+	if err != nil {
+		// Keep leading until the lease we know of runs out; maybe the next
+		// renewal gets through
+		return fmt.Errorf("renewing %s lease: %w", e.name, err)
+	}
+	if acquired {
+		e.leaderUntil = started.Add(e.ttl)
+		if !wasLeader {
+			log.Printf("This instance (%s) is now the %s leader", e.holder, e.name)
+		}
+		return nil
+	}
+	e.leaderUntil = time.Time{}
+	if wasLeader {
+		log.Printf("This instance (%s) lost the %s lease", e.holder, e.name)
+	}
+	return nil
+}
+
+// IsLeader reports whether this instance holds the lease.
+func (e *Elector) IsLeader() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.isLeader(e.now())
+}
+
+func (e *Elector) isLeader(now time.Time) bool {
+	return now.Before(e.leaderUntil)
+}
+
+// Guard wraps a task so it only runs on the leader.
+func (e *Elector) Guard(task Task) Task {
+	return func(ctx context.Context) error {
+		if !e.IsLeader() {
+			return nil
+		}
+		return task(ctx)
+	}
+}
+
+// Resign releases the lease so another instance can take over without
+// waiting for it to expire. It is meant for graceful shutdown.
+func (e *Elector) Resign(ctx context.Context) error {
+	e.mu.Lock()
+	wasLeader := e.isLeader(e.now())
+	e.leaderUntil = time.Time{}
+	e.mu.Unlock()
+	if !wasLeader {
+		return nil
+	}
+	return e.store.Release(e.name, e.holder)
+}
@@ -0,0 +1,94 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// fakeLeases is a LeaseStore on a fake clock.
+type fakeLeases struct {
+	now     *time.Time
+	holder  string
+	expires time.Time
+	err     error
+}
+
+func (f *fakeLeases) Acquire(name, holder string, ttl time.Duration) (bool, error) {
+	if f.err != nil {
+		return false, f.err
+	}
+	if f.holder != "" && f.holder != holder && f.now.Before(f.expires) {
+		return false, nil
+	}
+	f.holder, f.expires = holder, f.now.Add(ttl)
+	return true, nil
+}
+
+func (f *fakeLeases) Release(name, holder string) error {
+	if f.holder == holder {
+		f.holder = ""
+	}
+	return nil
+}
+
+func TestElectorFailsOver(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	store := &fakeLeases{now: &now}
+	a := NewElector(store, "scheduler", 30*time.Second)
+	b := NewElector(store, "scheduler", 30*time.Second)
+	a.now = func() time.Time { return now }
+	b.now = a.now
+	ctx := context.Background()
+
+	runs := 0
+	guarded := b.Guard(func(ctx context.Context) error { runs++; return nil })
+
+	_ = a.Renew(ctx)
+	_ = b.Renew(ctx)
+	if !a.IsLeader() || b.IsLeader() {
+		t.Fatal("the first instance to renew should lead alone")
+	}
+	_ = guarded(ctx)
+	if runs != 0 {
+		t.Error("guarded tasks must not run on followers")
+	}
+
+	// a dies; b takes over once the lease expired
+	now = now.Add(20 * time.Second)
+	_ = b.Renew(ctx)
+	if b.IsLeader() {
+		t.Fatal("b took over before the lease expired")
+	}
+	now = now.Add(11 * time.Second)
+	if a.IsLeader() {
+		t.Error("a should know its lease ran out")
+	}
+	_ = b.Renew(ctx)
+	if !b.IsLeader() {
+		t.Fatal("b should lead after a's lease expired")
+	}
+	_ = guarded(ctx)
+	if runs != 1 {
+		t.Error("guarded tasks should run on the leader")
+	}
+
+	// Store outages keep the leader until its lease runs out
+	store.err = errors.New("connection refused")
+	if err := b.Renew(ctx); err == nil {
+		t.Error("Renew should report store errors")
+	}
+	if !b.IsLeader() {
+		t.Error("a failed renewal shouldn't end a valid lease early")
+	}
+	store.err = nil
+
+	if err := b.Resign(ctx); err != nil || b.IsLeader() || store.holder != "" {
+		t.Errorf("Resign should release the lease, err %v", err)
+	}
+	_ = a.Renew(ctx)
+	if !a.IsLeader() {
+		t.Error("a released lease should be free right away")
+	}
+}
@@ -0,0 +1,160 @@
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"sync"
+	"time"
+)
+
+// Locker hands out named locks. With several instances sharing a database,
+// a lock is held by at most one of them at a time.
+type Locker interface {
+	// TryLock takes the lock without waiting. ok is false when someone
+	// else holds it; otherwise unlock must be called to release it.
+	TryLock(ctx context.Context, name string) (unlock func(), ok bool, err error)
+}
+
+// EveryExclusive is Every, but a run is skipped when another instance is
+// running the task, so jobs with side effects like notifications don't run
+// twice across replicas.
+func (s *Scheduler) EveryExclusive(name string, interval time.Duration, locker Locker, task Task) {
+	s.Every(name, interval, Exclusive(locker, name, task))
+}
+
+// Exclusive wraps task so it only runs while holding the lock name.
+func Exclusive(locker Locker, name string, task Task) Task {
+	return func(ctx context.Context) error {
+		unlock, ok, err := locker.TryLock(ctx, name)
+		if err != nil {
+			return fmt.Errorf("taking lock: %w", err)
+		}
+		if !ok {
+			return nil
+		}
+		defer unlock()
+		return task(ctx)
+	}
+}
+
+// LocalLocker holds locks within this process, for single-instance setups
+// without a database.
+type LocalLocker struct {
+	mu   sync.Mutex
+	held map[string]bool
+}
+
+// NewLocalLocker creates a LocalLocker.
+func NewLocalLocker() *LocalLocker {
+	return &LocalLocker{held: make(map[string]bool)}
+}
+
+// TryLock implements Locker.
+func (l *LocalLocker) TryLock(ctx context.Context, name string) (func(), bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.held[name] {
+		return nil, false, nil
+	}
+	l.held[name] = true
+	return func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		delete(l.held, name)
+	}, true, nil
+}
+
+// PostgresLocker uses Postgres session-level advisory locks. A lock lives
+// as long as the connection that took it, so when an instance dies its
+// locks are released with its connections.
+type PostgresLocker struct {
+	db *sql.DB
+}
+
+// NewPostgresLocker creates a PostgresLocker. Each held lock pins one
+// connection of db.
+func NewPostgresLocker(db *sql.DB) *PostgresLocker {
+	return &PostgresLocker{db: db}
+}
+
+// TryLock implements Locker.
+func (l *PostgresLocker) TryLock(ctx context.Context, name string) (func(), bool, error) {
+	key := advisoryKey(name)
+	conn, err := l.db.Conn(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	var ok bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", key).Scan(&ok); err != nil {
+		conn.Close()
+		return nil, false, err
+	}
+	if !ok {
+		conn.Close()
+		return nil, false, nil
+	}
+	return func() {
+		// Not ctx: the lock must be released even when the run was canceled
+		unlockCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if _, err := conn.ExecContext(unlockCtx, "SELECT pg_advisory_unlock($1)", key); err != nil {
+			log.Printf("Releasing lock %s failed, dropping its connection: %v", name, err)
+			// Returning the connection to the pool would keep the lock held
+			_ = conn.Raw(func(any) error { return driver.ErrBadConn })
+		}
+		conn.Close()
+	}, true, nil
+}
+
+// MySQLLocker uses MySQL named locks (GET_LOCK). Like Postgres advisory
+// locks they belong to the connection that took them.
+type MySQLLocker struct {
+	db *sql.DB
+}
+
+// NewMySQLLocker creates a MySQLLocker. Each held lock pins one connection
+// of db.
+func NewMySQLLocker(db *sql.DB) *MySQLLocker {
+	return &MySQLLocker{db: db}
+}
+
+// TryLock implements Locker.
+func (l *MySQLLocker) TryLock(ctx context.Context, name string) (func(), bool, error) {
+	// Lock names are limited to 64 characters, so lock on the hash
+	key := fmt.Sprintf("todo-backend/jobs/%x", uint64(advisoryKey(name)))
+	conn, err := l.db.Conn(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	var ok sql.NullBool
+	if err := conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, 0)", key).Scan(&ok); err != nil {
+		conn.Close()
+		return nil, false, err
+	}
+	if !ok.Bool {
+		conn.Close()
+		return nil, false, nil
+	}
+	return func() {
+		// Not ctx: the lock must be released even when the run was canceled
+		unlockCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if _, err := conn.ExecContext(unlockCtx, "DO RELEASE_LOCK(?)", key); err != nil {
+			log.Printf("Releasing lock %s failed, dropping its connection: %v", name, err)
+			// Returning the connection to the pool would keep the lock held
+			_ = conn.Raw(func(any) error { return driver.ErrBadConn })
+		}
+		conn.Close()
+	}, true, nil
+}
+
+// advisoryKey maps a lock name to the bigint Postgres locks on.
+func advisoryKey(name string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte("todo-backend/jobs/" + name))
+	return int64(h.Sum64())
+}
@@ -0,0 +1,40 @@
+package jobs
+
+import (
+	"context"
+	"testing"
+)
+
+func TestExclusiveSkipsWhileLocked(t *testing.T) {
+	locker := NewLocalLocker()
+	runs := 0
+	task := Exclusive(locker, "digest", func(ctx context.Context) error {
+		runs++
+		return nil
+	})
+
+	unlock, ok, _ := locker.TryLock(context.Background(), "digest")
+	if !ok {
+		t.Fatal("the lock should be free")
+	}
+	if _, ok, _ := locker.TryLock(context.Background(), "digest"); ok {
+		t.Fatal("a held lock was handed out twice")
+	}
+	if err := task(context.Background()); err != nil || runs != 0 {
+		t.Fatalf("task ran %d times while locked, err %v", runs, err)
+	}
+
+	unlock()
+	if err := task(context.Background()); err != nil || runs != 1 {
+		t.Fatalf("task ran %d times after unlock, err %v", runs, err)
+	}
+	if _, ok, _ := locker.TryLock(context.Background(), "digest"); !ok {
+		t.Error("Exclusive should release the lock after the run")
+	}
+}
+
+func TestAdvisoryKeyIsStable(t *testing.T) {
+	if advisoryKey("a") != advisoryKey("a") || advisoryKey("a") == advisoryKey("b") {
+		t.Error("keys should be stable per name and differ between names")
+	}
+}
@@ -0,0 +1,360 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultWorkers is how many jobs a pool runs at once.
+	defaultWorkers = 4
+	// defaultPollInterval is how often an idle pool looks for due jobs.
+	defaultPollInterval = time.Second
+	// defaultLease is how long a claimed job is left to its pool before
+	// another one may run it. Running jobs have their claim extended, so it
+	// only runs out when the pool holding it dies.
+	defaultLease = time.Minute
+
+	defaultMaxAttempts = 5
+	defaultBackoff     = 10 * time.Second
+	defaultMaxBackoff  = time.Hour
+	defaultTimeout     = 5 * time.Minute
+)
+
+// Job is a unit of work taken from a queue.
+type Job struct {
+	// ID identifies the job in its Store
+	ID      uint
+	Kind    string
+	Payload json.RawMessage
+	// Attempts counts the attempts so far, this one included
+	Attempts int
+	// LastAttempt is true when a failure of this attempt won't be retried,
+	// so handlers can record the final outcome.
+	LastAttempt bool
+}
+
+// Handler runs a job. An error fails the attempt; the job is retried unless
+// the error is Permanent or it was the last attempt.
+type Handler func(ctx context.Context, job Job) error
+
+// Options tune how jobs of one kind are run. Zero fields take defaults.
+type Options struct {
+	// MaxAttempts bounds the attempts of a job, 5 by default.
+	MaxAttempts int
+	// Backoff is the wait after the first failed attempt, 10s by default;
+	// it doubles with every further one, up to MaxBackoff (1h by default).
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+	// Timeout bounds one attempt, 5m by default.
+	Timeout time.Duration
+}
+
+func (o Options) withDefaults() Options {
+	if o.MaxAttempts <= 0 {
+		o.MaxAttempts = defaultMaxAttempts
+	}
+	if o.Backoff <= 0 {
+		o.Backoff = defaultBackoff
+	}
+	if o.MaxBackoff <= 0 {
+		o.MaxBackoff = defaultMaxBackoff
+	}
+	if o.Timeout <= 0 {
+		o.Timeout = defaultTimeout
+	}
+	return o
+}
+
+// Store is a persistent job queue, e.g. a table shared by all instances.
+type Store interface {
+	// Claim claims up to limit jobs of the given kinds due at now until
+	// until, oldest first, and counts the attempt. Concurrent callers never
+	// claim the same job; a claimed job that isn't completed, retried or
+	// failed by then is due again.
+	Claim(ctx context.Context, kinds []string, now, until time.Time, limit int) ([]Job, error)
+	// Extend extends the claims of running jobs until until.
+	Extend(ctx context.Context, ids []uint, until time.Time) error
+	// Complete records that the job with id succeeded at at.
+	Complete(ctx context.Context, id uint, at time.Time) error
+	// Retry releases the job with id after a failed attempt, due again at
+	// at.
+	Retry(ctx context.Context, id uint, at time.Time, err error) error
+	// Fail gives the job with id up.
+	Fail(ctx context.Context, id uint, err error) error
+}
+
+type permanentError struct{ err error }
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent marks err as one retrying won't fix, e.g. a job referring to a
+// deleted record, so the job fails right away.
+func Permanent(err error) error {
+	return &permanentError{err: err}
+}
+
+// Backoff returns how long to wait after the given number of failed
+// attempts: base after the first, doubling with every further one, up to
+// ceiling.
+func Backoff(attempts int, base, ceiling time.Duration) time.Duration {
+	backoff := base
+	for i := 1; i < attempts && backoff < ceiling; i++ {
+		backoff *= 2
+	}
+	return min(backoff, ceiling)
+}
+
+type registeredHandler struct {
+	opts Options
+	run  Handler
+}
+
+// Pool runs the jobs of a Store on a fixed number of workers. Delivery is
+// at least once: a job whose pool dies while running it is run again once
+// its claim runs out, so handlers must be safe to repeat.
+type Pool struct {
+	store   Store
+	workers int
+	poll    time.Duration
+	lease   time.Duration
+	now     func() time.Time
+
+	handlers map[string]registeredHandler
+	paused   func() bool
+
+	mu      sync.Mutex
+	running map[uint]bool
+	// stop ends claiming; cancel interrupts running jobs
+	stop    context.CancelFunc
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+	started bool
+	// freed wakes the claim loop when a worker becomes free
+	freed chan struct{}
+}
+
+// NewPool creates a pool of workers taking jobs from store.
+func NewPool(store Store, workers int) *Pool {
+	if workers <= 0 {
+		workers = defaultWorkers
+	}
+	return &Pool{
+		store:    store,
+		workers:  workers,
+		poll:     defaultPollInterval,
+		lease:    defaultLease,
+		now:      time.Now,
+		handlers: make(map[string]registeredHandler),
+		running:  make(map[uint]bool),
+		freed:    make(chan struct{}, 1),
+	}
+}
+
+// WorkersFromEnv reads JOB_WORKERS, the number of jobs an instance runs at
+// once, defaulting to 4.
+func WorkersFromEnv() (int, error) {
+	v := os.Getenv("JOB_WORKERS")
+	if v == "" {
+		return defaultWorkers, nil
+	}
+	workers, err := strconv.Atoi(v)
+	if err != nil || workers <= 0 {
+		return defaultWorkers, fmt.Errorf("invalid JOB_WORKERS %q, must be a positive integer", v)
+	}
+	return workers, nil
+}
+
+// Handle registers the handler of a job kind. Handlers must be registered
+// before Start is called; jobs of kinds without one stay queued.
+func (p *Pool) Handle(kind string, opts Options, handler Handler) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.handlers[kind] = registeredHandler{opts: opts.withDefaults(), run: handler}
+}
+
+// PauseWhen stops claiming new jobs while paused returns true, e.g. in
+// read-only mode. Jobs already running finish.
+func (p *Pool) PauseWhen(paused func() bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.paused = paused
+}
+
+// Start launches the workers.
+func (p *Pool) Start(ctx context.Context) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.started {
+		return
+	}
+	p.started = true
+
+	kinds := make([]string, 0, len(p.handlers))
+	for kind := range p.handlers {
+		kinds = append(kinds, kind)
+	}
+	runCtx, cancel := context.WithCancel(ctx)
+	claimCtx, stop := context.WithCancel(runCtx)
+	p.cancel, p.stop = cancel, stop
+
+	p.wg.Add(1)
+	go p.claimLoop(claimCtx, runCtx, kinds)
+	go p.extendLoop(runCtx)
+}
+
+// claimLoop claims due jobs whenever a worker is free.
+func (p *Pool) claimLoop(ctx, runCtx context.Context, kinds []string) {
+	defer p.wg.Done()
+
+	for {
+		free := p.workers - p.runningCount()
+		claimed := 0
+		if free > 0 && (p.paused == nil || !p.paused()) {
+			now := p.now()
+			due, err := p.store.Claim(ctx, kinds, now, now.Add(p.lease), free)
+			if err != nil && ctx.Err() == nil {
+				log.Printf("Claiming jobs failed: %v", err)
+			}
+			for _, job := range due {
+				p.begin(runCtx, job)
+			}
+			claimed = len(due)
+		}
+
+		// With every worker busy, or more jobs likely due, look again as
+		// soon as one is free; otherwise poll
+		var wait <-chan time.Time
+		if free > 0 && claimed < free {
+			wait = time.After(p.poll)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-p.freed:
+		case <-wait:
+		}
+	}
+}
+
+func (p *Pool) runningCount() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.running)
+}
+
+// begin runs a claimed job on a worker of its own.
+func (p *Pool) begin(ctx context.Context, job Job) {
+	p.mu.Lock()
+	p.running[job.ID] = true
+	p.mu.Unlock()
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		p.run(ctx, job)
+
+		p.mu.Lock()
+		delete(p.running, job.ID)
+		p.mu.Unlock()
+		select {
+		case p.freed <- struct{}{}:
+		default:
+		}
+	}()
+}
+
+// run runs a job and records its outcome.
+func (p *Pool) run(ctx context.Context, job Job) {
+	handler, ok := p.handlers[job.Kind]
+	if !ok {
+		return
+	}
+	job.LastAttempt = job.Attempts >= handler.opts.MaxAttempts
+
+	attemptCtx, cancel := context.WithTimeout(ctx, handler.opts.Timeout)
+	err := handler.run(attemptCtx, job)
+	cancel()
+
+	// The outcome is recorded even when ctx was canceled by Stop
+	storeCtx := context.WithoutCancel(ctx)
+	var permanent *permanentError
+	switch {
+	case err == nil:
+		err = p.store.Complete(storeCtx, job.ID, p.now())
+	case ctx.Err() != nil:
+		// Interrupted by shutdown: due again right away, on another
+		// instance or after the restart
+		err = p.store.Retry(storeCtx, job.ID, p.now(), err)
+	case errors.As(err, &permanent) || job.LastAttempt:
+		log.Printf("Job %s %d failed after %d attempts: %v", job.Kind, job.ID, job.Attempts, err)
+		err = p.store.Fail(storeCtx, job.ID, err)
+	default:
+		backoff := Backoff(job.Attempts, handler.opts.Backoff, handler.opts.MaxBackoff)
+		log.Printf("Job %s %d failed, retrying in %s: %v", job.Kind, job.ID, backoff, err)
+		err = p.store.Retry(storeCtx, job.ID, p.now().Add(backoff), err)
+	}
+	if err != nil {
+		log.Printf("Recording the outcome of job %s %d failed: %v", job.Kind, job.ID, err)
+	}
+}
+
+// extendLoop keeps the claims of running jobs from running out.
+func (p *Pool) extendLoop(ctx context.Context) {
+	ticker := time.NewTicker(p.lease / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		p.mu.Lock()
+		ids := make([]uint, 0, len(p.running))
+		for id := range p.running {
+			ids = append(ids, id)
+		}
+		p.mu.Unlock()
+		if len(ids) == 0 {
+			continue
+		}
+		if err := p.store.Extend(ctx, ids, p.now().Add(p.lease)); err != nil && ctx.Err() == nil {
+			log.Printf("Extending job claims failed: %v", err)
+		}
+	}
+}
+
+// Stop drains the pool: it stops claiming jobs and waits for the running
+// ones to finish. If ctx expires first, the running jobs are interrupted
+// and released to run again.
+func (p *Pool) Stop(ctx context.Context) error {
+	p.mu.Lock()
+	stop, cancel := p.stop, p.cancel
+	p.mu.Unlock()
+	if stop == nil {
+		return nil
+	}
+	stop()
+	defer cancel()
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
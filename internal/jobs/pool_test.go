@@ -0,0 +1,253 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeQueue is a Store of jobs kept in memory.
+type fakeQueue struct {
+	mu     sync.Mutex
+	jobs   map[uint]*fakeJob
+	nextID uint
+}
+
+type fakeJob struct {
+	kind     string
+	runAt    time.Time
+	attempts int
+	claimed  bool
+	status   string
+	lastErr  error
+}
+
+func newFakeQueue() *fakeQueue {
+	return &fakeQueue{jobs: make(map[uint]*fakeJob)}
+}
+
+func (q *fakeQueue) add(kind string) uint {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.nextID++
+	q.jobs[q.nextID] = &fakeJob{kind: kind, status: "pending"}
+	return q.nextID
+}
+
+func (q *fakeQueue) get(id uint) fakeJob {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return *q.jobs[id]
+}
+
+func (q *fakeQueue) Claim(ctx context.Context, kinds []string, now, until time.Time, limit int) ([]Job, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var due []Job
+	for id := uint(1); id <= q.nextID && len(due) < limit; id++ {
+		j := q.jobs[id]
+		if j.status != "pending" || j.claimed || j.runAt.After(now) || !slices.Contains(kinds, j.kind) {
+			continue
+		}
+		j.claimed = true
+		j.attempts++
+		due = append(due, Job{ID: id, Kind: j.kind, Attempts: j.attempts})
+	}
+	return due, nil
+}
+
+func (q *fakeQueue) Extend(ctx context.Context, ids []uint, until time.Time) error {
+	return nil
+}
+
+func (q *fakeQueue) Complete(ctx context.Context, id uint, at time.Time) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.jobs[id].claimed, q.jobs[id].status = false, "done"
+	return nil
+}
+
+func (q *fakeQueue) Retry(ctx context.Context, id uint, at time.Time, err error) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	// Retries are due right away, so tests needn't wait for the backoff
+	q.jobs[id].claimed, q.jobs[id].lastErr = false, err
+	return nil
+}
+
+func (q *fakeQueue) Fail(ctx context.Context, id uint, err error) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.jobs[id].claimed, q.jobs[id].status, q.jobs[id].lastErr = false, "failed", err
+	return nil
+}
+
+func newTestPool(q *fakeQueue, workers int) *Pool {
+	p := NewPool(q, workers)
+	p.poll = 5 * time.Millisecond
+	return p
+}
+
+func stopPool(t *testing.T, p *Pool) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := p.Stop(ctx); err != nil {
+		t.Fatalf("Stop returned error: %v", err)
+	}
+}
+
+// waitFor polls cond until it holds or a second passed.
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestPoolRetriesUntilMaxAttempts(t *testing.T) {
+	q := newFakeQueue()
+	flaky := q.add("flaky")
+	broken := q.add("broken")
+	permanent := q.add("permanent")
+
+	var mu sync.Mutex
+	var lastAttempts []bool
+	p := newTestPool(q, 2)
+	p.Handle("flaky", Options{MaxAttempts: 3}, func(ctx context.Context, job Job) error {
+		if job.Attempts < 2 {
+			return errors.New("try again")
+		}
+		return nil
+	})
+	p.Handle("broken", Options{MaxAttempts: 3}, func(ctx context.Context, job Job) error {
+		mu.Lock()
+		lastAttempts = append(lastAttempts, job.LastAttempt)
+		mu.Unlock()
+		return errors.New("still broken")
+	})
+	p.Handle("permanent", Options{}, func(ctx context.Context, job Job) error {
+		return Permanent(errors.New("gone"))
+	})
+	p.Start(context.Background())
+	defer stopPool(t, p)
+
+	waitFor(t, "jobs to finish", func() bool {
+		return q.get(flaky).status == "done" && q.get(broken).status == "failed" && q.get(permanent).status == "failed"
+	})
+	if got := q.get(flaky).attempts; got != 2 {
+		t.Errorf("flaky job ran %d times, want 2", got)
+	}
+	if got := q.get(permanent).attempts; got != 1 {
+		t.Errorf("permanently failing job ran %d times, want 1", got)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if want := []bool{false, false, true}; !slices.Equal(lastAttempts, want) {
+		t.Errorf("LastAttempt of the broken job's attempts = %v, want %v", lastAttempts, want)
+	}
+}
+
+func TestPoolBoundsConcurrency(t *testing.T) {
+	q := newFakeQueue()
+	for i := 0; i < 6; i++ {
+		q.add("slow")
+	}
+
+	var mu sync.Mutex
+	running, peak, done := 0, 0, 0
+	p := newTestPool(q, 2)
+	p.Handle("slow", Options{}, func(ctx context.Context, job Job) error {
+		mu.Lock()
+		running++
+		peak = max(peak, running)
+		mu.Unlock()
+		time.Sleep(5 * time.Millisecond)
+		mu.Lock()
+		running--
+		done++
+		mu.Unlock()
+		return nil
+	})
+	p.Start(context.Background())
+	defer stopPool(t, p)
+
+	waitFor(t, "jobs to finish", func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return done == 6
+	})
+	mu.Lock()
+	defer mu.Unlock()
+	if peak != 2 {
+		t.Errorf("at most %d jobs ran at once, want 2", peak)
+	}
+}
+
+func TestPoolStopDrainsRunningJobs(t *testing.T) {
+	q := newFakeQueue()
+	id := q.add("slow")
+
+	started := make(chan struct{})
+	p := newTestPool(q, 1)
+	p.Handle("slow", Options{}, func(ctx context.Context, job Job) error {
+		close(started)
+		time.Sleep(20 * time.Millisecond)
+		return ctx.Err()
+	})
+	p.Start(context.Background())
+	<-started
+
+	stopPool(t, p)
+	if got := q.get(id).status; got != "done" {
+		t.Errorf("job status after Stop = %q, want it finished as done", got)
+	}
+}
+
+func TestPoolStopInterruptsJobsAfterTimeout(t *testing.T) {
+	q := newFakeQueue()
+	id := q.add("stuck")
+
+	started := make(chan struct{})
+	p := newTestPool(q, 1)
+	p.Handle("stuck", Options{}, func(ctx context.Context, job Job) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	p.Start(context.Background())
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := p.Stop(ctx); err == nil {
+		t.Error("expected Stop to time out while a job runs")
+	}
+	waitFor(t, "the interrupted job to be released", func() bool {
+		job := q.get(id)
+		return !job.claimed && job.lastErr != nil
+	})
+	if got := q.get(id).status; got != "pending" {
+		t.Errorf("interrupted job status = %q, want pending to run again", got)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	for attempts, want := range map[int]time.Duration{
+		1:  10 * time.Second,
+		2:  20 * time.Second,
+		4:  80 * time.Second,
+		20: time.Hour,
+	} {
+		if got := Backoff(attempts, 10*time.Second, time.Hour); got != want {
+			t.Errorf("Backoff(%d) = %s, want %s", attempts, got, want)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+// Package jobs runs background work on behalf of the API.
+package jobs
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// Task is a unit of periodic work. It receives a context that is canceled
+// when the scheduler stops.
+type Task func(ctx context.Context) error
+
+type periodicTask struct {
+	name     string
+	interval time.Duration
+	run      Task
+}
+
+// Scheduler runs registered tasks on fixed intervals until stopped.
+type Scheduler struct {
+	mu      sync.Mutex
+	tasks   []periodicTask
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+	started bool
+}
+
+// NewScheduler creates an empty scheduler.
+func NewScheduler() *Scheduler {
+	return &Scheduler{}
+}
+
+// Every registers a task to run once per interval. Tasks must be registered
+// before Start is called.
+func (s *Scheduler) Every(name string, interval time.Duration, task Task) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tasks = append(s.tasks, periodicTask{name: name, interval: interval, run: task})
+}
+
+// Start launches one goroutine per task. Each task runs immediately and then
+// on every tick; a slow run delays the next one rather than overlapping it.
+func (s *Scheduler) Start(ctx context.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.started {
+		return
+	}
+	s.started = true
+
+	ctx, s.cancel = context.WithCancel(ctx)
+	for _, task := range s.tasks {
+		s.wg.Add(1)
+		go s.loop(ctx, task)
+	}
+}
+
+func (s *Scheduler) loop(ctx context.Context, task periodicTask) {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(task.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := task.run(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("Job %s failed: %v", task.name, err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Stop cancels all running tasks and waits for them to return, or until ctx
+// expires.
+func (s *Scheduler) Stop(ctx context.Context) error {
+	s.mu.Lock()
+	if s.cancel != nil {
+		s.cancel()
+	}
+	s.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,63 @@
+package jobs
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSchedulerRunsTasksUntilStopped(t *testing.T) {
+	s := NewScheduler()
+
+	var runs atomic.Int32
+	ran := make(chan struct{}, 10)
+	s.Every("count", 10*time.Millisecond, func(ctx context.Context) error {
+		runs.Add(1)
+		ran <- struct{}{}
+		return nil
+	})
+	s.Start(context.Background())
+
+	// First run happens immediately, the second on the first tick
+	for i := 0; i < 2; i++ {
+		select {
+		case <-ran:
+		case <-time.After(time.Second):
+			t.Fatalf("task did not run %d times", i+1)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := s.Stop(ctx); err != nil {
+		t.Fatalf("Stop returned error: %v", err)
+	}
+
+	stopped := runs.Load()
+	time.Sleep(30 * time.Millisecond)
+	if runs.Load() != stopped {
+		t.Errorf("task kept running after Stop")
+	}
+}
+
+func TestSchedulerStopTimesOut(t *testing.T) {
+	s := NewScheduler()
+	release := make(chan struct{})
+	defer close(release)
+
+	started := make(chan struct{})
+	s.Every("stuck", time.Hour, func(ctx context.Context) error {
+		close(started)
+		<-release // ignores cancellation
+		return nil
+	})
+	s.Start(context.Background())
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := s.Stop(ctx); err == nil {
+		t.Error("expected Stop to time out while a task ignores cancellation")
+	}
+}
@@ -0,0 +1,329 @@
+// Package jsonpatch applies the two standard patch formats for JSON
+// documents: JSON Merge Patch (RFC 7386), where the patch is a partial
+// document, and JSON Patch (RFC 6902), where it is a list of operations
+// addressed by JSON Pointers (RFC 6901).
+package jsonpatch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Media types of the two formats, as used in Content-Type and Accept-Patch.
+const (
+	MergePatchType = "application/merge-patch+json"
+	PatchType      = "application/json-patch+json"
+)
+
+// ErrTestFailed is returned by Apply when a test operation doesn't match
+// the document.
+var ErrTestFailed = errors.New("test failed")
+
+// Merge applies the JSON Merge Patch patch to doc: objects are merged
+// recursively, null removes a member and any other value replaces it.
+func Merge(doc, patch []byte) ([]byte, error) {
+	var target, changes any
+	if err := json.Unmarshal(doc, &target); err != nil {
+		return nil, fmt.Errorf("malformed document: %w", err)
+	}
+	if err := json.Unmarshal(patch, &changes); err != nil {
+		return nil, fmt.Errorf("malformed patch: %w", err)
+	}
+	return json.Marshal(merge(target, changes))
+}
+
+func merge(target, patch any) any {
+	changes, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	object, ok := target.(map[string]any)
+	if !ok {
+		object = make(map[string]any, len(changes))
+	}
+	for name, value := range changes {
+		if value == nil {
+			delete(object, name)
+		} else {
+			object[name] = merge(object[name], value)
+		}
+	}
+	return object
+}
+
+// operation is one step of a JSON Patch. Value is empty when the member is
+// missing, unlike an explicit null.
+type operation struct {
+	Op    string          `json:"op"`
+	Path  *string         `json:"path"`
+	From  *string         `json:"from"`
+	Value json.RawMessage `json:"value"`
+}
+
+// Apply applies the JSON Patch patch to doc. The operations run in order
+// and the patch applies as a whole or not at all: on the first failing
+// operation the error names it and doc is left as it was.
+func Apply(doc, patch []byte) ([]byte, error) {
+	var target any
+	if err := json.Unmarshal(doc, &target); err != nil {
+		return nil, fmt.Errorf("malformed document: %w", err)
+	}
+	var ops []operation
+	if err := json.Unmarshal(patch, &ops); err != nil {
+		return nil, errors.New("malformed patch: expected an array of operations")
+	}
+	for i, op := range ops {
+		var err error
+		if target, err = op.apply(target); err != nil {
+			return nil, fmt.Errorf("operation %d (%s): %w", i, op.Op, err)
+		}
+	}
+	return json.Marshal(target)
+}
+
+func (op operation) apply(doc any) (any, error) {
+	if op.Path == nil {
+		return nil, errors.New("path is required")
+	}
+	path, err := parsePointer(*op.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch op.Op {
+	case "add", "replace", "test":
+		if len(op.Value) == 0 {
+			return nil, errors.New("value is required")
+		}
+		var value any
+		if err := json.Unmarshal(op.Value, &value); err != nil {
+			return nil, fmt.Errorf("malformed value: %w", err)
+		}
+		switch op.Op {
+		case "add":
+			return add(doc, path, value)
+		case "replace":
+			return replace(doc, path, value)
+		}
+		current, err := get(doc, path)
+		if err != nil {
+			return nil, err
+		}
+		if !reflect.DeepEqual(current, value) {
+			return nil, fmt.Errorf("%w: %s doesn't have the given value", ErrTestFailed, *op.Path)
+		}
+		return doc, nil
+	case "remove":
+		return remove(doc, path)
+	case "move", "copy":
+		if op.From == nil {
+			return nil, errors.New("from is required")
+		}
+		from, err := parsePointer(*op.From)
+		if err != nil {
+			return nil, err
+		}
+		value, err := get(doc, from)
+		if err != nil {
+			return nil, err
+		}
+		if op.Op == "copy" {
+			return add(doc, path, clone(value))
+		}
+		if len(from) < len(path) && reflect.DeepEqual(from, path[:len(from)]) {
+			return nil, errors.New("can't move a value into itself")
+		}
+		if doc, err = remove(doc, from); err != nil {
+			return nil, err
+		}
+		return add(doc, path, value)
+	default:
+		return nil, fmt.Errorf("unknown op %q", op.Op)
+	}
+}
+
+// parsePointer splits a JSON Pointer into its unescaped reference tokens.
+// The empty pointer, the whole document, has none.
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("invalid path %q, must start with /", pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+	}
+	return tokens, nil
+}
+
+// walk finds the parent of the value at path and returns the document with
+// the parent replaced by the result of fn, so arrays can grow and shrink.
+func walk(doc any, path []string, fn func(parent any, token string) (any, error)) (any, error) {
+	if len(path) == 1 {
+		return fn(doc, path[0])
+	}
+	switch parent := doc.(type) {
+	case map[string]any:
+		child, ok := parent[path[0]]
+		if !ok {
+			return nil, fmt.Errorf("member %q not found", path[0])
+		}
+		child, err := walk(child, path[1:], fn)
+		if err != nil {
+			return nil, err
+		}
+		parent[path[0]] = child
+		return parent, nil
+	case []any:
+		i, err := arrayIndex(path[0], len(parent)-1)
+		if err != nil {
+			return nil, err
+		}
+		child, err := walk(parent[i], path[1:], fn)
+		if err != nil {
+			return nil, err
+		}
+		parent[i] = child
+		return parent, nil
+	default:
+		return nil, fmt.Errorf("member %q not found in a scalar", path[0])
+	}
+}
+
+// arrayIndex parses an array index token, which must be a number from 0
+// to max without leading zeros.
+func arrayIndex(token string, max int) (int, error) {
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || (len(token) > 1 && token[0] == '0') || token[0] == '+' {
+		return 0, fmt.Errorf("invalid array index %q", token)
+	}
+	if i > max {
+		return 0, fmt.Errorf("array index %d out of range", i)
+	}
+	return i, nil
+}
+
+func get(doc any, path []string) (any, error) {
+	for _, token := range path {
+		switch node := doc.(type) {
+		case map[string]any:
+			child, ok := node[token]
+			if !ok {
+				return nil, fmt.Errorf("member %q not found", token)
+			}
+			doc = child
+		case []any:
+			i, err := arrayIndex(token, len(node)-1)
+			if err != nil {
+				return nil, err
+			}
+			doc = node[i]
+		default:
+			return nil, fmt.Errorf("member %q not found in a scalar", token)
+		}
+	}
+	return doc, nil
+}
+
+func add(doc any, path []string, value any) (any, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	return walk(doc, path, func(parent any, token string) (any, error) {
+		switch parent := parent.(type) {
+		case map[string]any:
+			parent[token] = value
+			return parent, nil
+		case []any:
+			if token == "-" {
+				return append(parent, value), nil
+			}
+			i, err := arrayIndex(token, len(parent))
+			if err != nil {
+				return nil, err
+			}
+			parent = append(parent, nil)
+			copy(parent[i+1:], parent[i:])
+			parent[i] = value
+			return parent, nil
+		default:
+			return nil, fmt.Errorf("can't add member %q to a scalar", token)
+		}
+	})
+}
+
+func remove(doc any, path []string) (any, error) {
+	if len(path) == 0 {
+		return nil, errors.New("can't remove the whole document")
+	}
+	return walk(doc, path, func(parent any, token string) (any, error) {
+		switch parent := parent.(type) {
+		case map[string]any:
+			if _, ok := parent[token]; !ok {
+				return nil, fmt.Errorf("member %q not found", token)
+			}
+			delete(parent, token)
+			return parent, nil
+		case []any:
+			i, err := arrayIndex(token, len(parent)-1)
+			if err != nil {
+				return nil, err
+			}
+			return append(parent[:i], parent[i+1:]...), nil
+		default:
+			return nil, fmt.Errorf("member %q not found in a scalar", token)
+		}
+	})
+}
+
+func replace(doc any, path []string, value any) (any, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	return walk(doc, path, func(parent any, token string) (any, error) {
+		switch parent := parent.(type) {
+		case map[string]any:
+			if _, ok := parent[token]; !ok {
+				return nil, fmt.Errorf("member %q not found", token)
+			}
+			parent[token] = value
+			return parent, nil
+		case []any:
+			i, err := arrayIndex(token, len(parent)-1)
+			if err != nil {
+				return nil, err
+			}
+			parent[i] = value
+			return parent, nil
+		default:
+			return nil, fmt.Errorf("member %q not found in a scalar", token)
+		}
+	})
+}
+
+// clone deep-copies a decoded JSON value, so copies don't share objects
+// and arrays with their source.
+func clone(value any) any {
+	switch value := value.(type) {
+	case map[string]any:
+		object := make(map[string]any, len(value))
+		for name, member := range value {
+			object[name] = clone(member)
+		}
+		return object
+	case []any:
+		array := make([]any, len(value))
+		for i, element := range value {
+			array[i] = clone(element)
+		}
+		return array
+	default:
+		return value
+	}
+}
@@ -0,0 +1,76 @@
+package jsonpatch
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestMerge(t *testing.T) {
+	tests := []struct {
+		doc, patch, want string
+	}{
+		// Examples from RFC 7386, appendix A
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`{"e":null}`, `{"a":1}`, `{"a":1,"e":null}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+	for _, tt := range tests {
+		got, err := Merge([]byte(tt.doc), []byte(tt.patch))
+		if err != nil {
+			t.Errorf("Merge(%s, %s) = %v", tt.doc, tt.patch, err)
+		} else if string(got) != tt.want {
+			t.Errorf("Merge(%s, %s) = %s, want %s", tt.doc, tt.patch, got, tt.want)
+		}
+	}
+	if _, err := Merge([]byte(`{}`), []byte(`{`)); err == nil {
+		t.Error("Merge with a malformed patch succeeded")
+	}
+}
+
+func TestApply(t *testing.T) {
+	const doc = `{"title":"Buy milk","tags":["home","shop"],"location":{"latitude":1},"a/b":0,"m~n":1}`
+	tests := []struct {
+		patch, want, err string
+	}{
+		{patch: `[{"op":"replace","path":"/title","value":"Buy bread"}]`, want: `{"a/b":0,"location":{"latitude":1},"m~n":1,"tags":["home","shop"],"title":"Buy bread"}`},
+		{patch: `[{"op":"add","path":"/tags/-","value":"work"},{"op":"add","path":"/tags/0","value":"first"}]`, want: `{"a/b":0,"location":{"latitude":1},"m~n":1,"tags":["first","home","shop","work"],"title":"Buy milk"}`},
+		{patch: `[{"op":"remove","path":"/tags/0"},{"op":"remove","path":"/a~1b"},{"op":"remove","path":"/m~0n"}]`, want: `{"location":{"latitude":1},"tags":["shop"],"title":"Buy milk"}`},
+		{patch: `[{"op":"copy","from":"/tags/1","path":"/location/name"},{"op":"move","from":"/title","path":"/name"}]`, want: `{"a/b":0,"location":{"latitude":1,"name":"shop"},"m~n":1,"name":"Buy milk","tags":["home","shop"]}`},
+		{patch: `[{"op":"test","path":"/location","value":{"latitude":1.0}},{"op":"add","path":"","value":[]}]`, want: `[]`},
+		{patch: `[{"op":"test","path":"/title","value":"Buy bread"}]`, err: `operation 0 (test): test failed: /title doesn't have the given value`},
+		{patch: `[{"op":"replace","path":"/due_date","value":null}]`, err: `operation 0 (replace): member "due_date" not found`},
+		{patch: `[{"op":"add","path":"/tags/3","value":"x"}]`, err: `operation 0 (add): array index 3 out of range`},
+		{patch: `[{"op":"remove","path":"/tags/01"}]`, err: `operation 0 (remove): invalid array index "01"`},
+		{patch: `[{"op":"add","path":"/title/x","value":1}]`, err: `operation 0 (add): can't add member "x" to a scalar`},
+		{patch: `[{"op":"move","from":"/location","path":"/location/copy"}]`, err: `operation 0 (move): can't move a value into itself`},
+		{patch: `[{"op":"add","path":"/x"}]`, err: `operation 0 (add): value is required`},
+		{patch: `[{"op":"add","path":"x","value":1}]`, err: `operation 0 (add): invalid path "x", must start with /`},
+		{patch: `[{"op":"increment","path":"/a~1b"}]`, err: `operation 0 (increment): unknown op "increment"`},
+		{patch: `{"op":"remove","path":"/title"}`, err: `malformed patch: expected an array of operations`},
+	}
+	for _, tt := range tests {
+		got, err := Apply([]byte(doc), []byte(tt.patch))
+		switch {
+		case tt.err != "":
+			if err == nil || err.Error() != tt.err {
+				t.Errorf("Apply(%s) error = %v, want %q", tt.patch, err, tt.err)
+			}
+		case err != nil:
+			t.Errorf("Apply(%s) = %v", tt.patch, err)
+		case string(got) != tt.want:
+			t.Errorf("Apply(%s) = %s, want %s", tt.patch, got, tt.want)
+		}
+	}
+
+	_, err := Apply([]byte(doc), []byte(`[{"op":"test","path":"/tags/0","value":"shop"}]`))
+	if !errors.Is(err, ErrTestFailed) {
+		t.Errorf("failed test operation = %v, want ErrTestFailed", err)
+	}
+}
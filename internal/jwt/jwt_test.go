@@ -0,0 +1,64 @@
+package jwt
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testKey = []byte("0123456789abcdef0123456789abcdef")
+
+func TestIssueAndVerify(t *testing.T) {
+	signer, err := NewSigner(testKey, "todo-backend")
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Unix(1_700_000_000, 0)
+	token, issued := signer.Issue("42", now, time.Hour)
+
+	claims, err := signer.Verify(token, now.Add(59*time.Minute))
+	if err != nil {
+		t.Fatalf("Verify returned error: %v", err)
+	}
+	if *claims != issued || claims.Subject != "42" || claims.ExpiresAt != now.Add(time.Hour).Unix() {
+		t.Errorf("claims = %+v, want %+v", *claims, issued)
+	}
+	if _, err := signer.Verify(token, now.Add(time.Hour)); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("expired token: err = %v, want ErrInvalidToken", err)
+	}
+
+	other, _ := NewSigner([]byte(strings.Repeat("x", MinKeyLength)), "todo-backend")
+	if _, err := other.Verify(token, now); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("other key: err = %v, want ErrInvalidToken", err)
+	}
+	otherIssuer, _ := NewSigner(testKey, "someone-else")
+	if _, err := otherIssuer.Verify(token, now); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("other issuer: err = %v, want ErrInvalidToken", err)
+	}
+}
+
+func TestVerifyRejectsTampering(t *testing.T) {
+	signer, _ := NewSigner(testKey, "todo-backend")
+	now := time.Now()
+	token, _ := signer.Issue("42", now, time.Hour)
+	parts := strings.Split(token, ".")
+	encode := base64.RawURLEncoding.EncodeToString
+
+	cases := map[string]string{
+		"other subject": parts[0] + "." + encode([]byte(`{"iss":"todo-backend","sub":"1","exp":9999999999}`)) + "." + parts[2],
+		"alg none":      encode([]byte(`{"alg":"none"}`)) + "." + parts[1] + ".",
+		"no signature":  parts[0] + "." + parts[1] + ".",
+		"two parts":     parts[0] + "." + parts[1],
+		"garbage":       "not-a-token",
+	}
+	for name, tampered := range cases {
+		if _, err := signer.Verify(tampered, now); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("%s: err = %v, want ErrInvalidToken", name, err)
+		}
+	}
+	if _, err := NewSigner([]byte("short"), "todo-backend"); err == nil {
+		t.Error("NewSigner with a short key: want an error")
+	}
+}
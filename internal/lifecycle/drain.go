@@ -0,0 +1,57 @@
+package lifecycle
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/Tomlord1122/todo-backend/internal/pathprefix"
+	"github.com/Tomlord1122/todo-backend/internal/problem"
+)
+
+// Drain marks the process as shutting down, so requests that arrive after
+// a SIGTERM are answered with 503 instead of being started. It is safe for
+// concurrent use; the zero value is not draining.
+type Drain struct {
+	draining atomic.Bool
+}
+
+// Draining reports whether Start has been called.
+func (d *Drain) Draining() bool {
+	return d.draining.Load()
+}
+
+// Start begins draining and waits for delay, or until ctx is done, so load
+// balancers see the instance as unready before its listeners close.
+func (d *Drain) Start(ctx context.Context, delay time.Duration) error {
+	d.draining.Store(true)
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+	return nil
+}
+
+// Middleware answers requests with 503 while draining, closing their
+// connection so the client retries elsewhere. Requests already being
+// served are unaffected, as are paths under exemptPrefixes (e.g. the
+// liveness probe, so the process isn't restarted mid-shutdown).
+func (d *Drain) Middleware(exemptPrefixes ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !d.Draining() || pathprefix.HasAny(r.URL.Path, exemptPrefixes) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			w.Header().Set("Connection", "close")
+			w.Header().Set("Retry-After", "1")
+			problem.Error(w, r, http.StatusServiceUnavailable, "The server is shutting down, please try again")
+		})
+	}
+}
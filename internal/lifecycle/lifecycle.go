@@ -0,0 +1,145 @@
+// Package lifecycle coordinates graceful shutdown. Subsystems register stop
+// hooks with a phase and their own timeout; on shutdown the hooks run one
+// at a time, earliest phase first, so e.g. the HTTP server stops taking
+// requests before the workers and database it depends on go away.
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Phase orders stop hooks. Lower phases stop first; hooks in the same
+// phase stop in registration order.
+type Phase int
+
+// Standard phases, in shutdown order.
+const (
+	// PhaseDrain fails readiness and turns new requests away while load
+	// balancers stop sending traffic.
+	PhaseDrain Phase = iota * 10
+	// PhaseServers stops accepting requests and drains in-flight ones.
+	PhaseServers
+	// PhaseWorkers stops background jobs and consumers.
+	PhaseWorkers
+	// PhaseEvents flushes and closes event publishing.
+	PhaseEvents
+	// PhaseCache closes caches and other shared clients.
+	PhaseCache
+	// PhaseDatabase closes database connections, last since everything above uses them.
+	PhaseDatabase
+)
+
+// DefaultTimeout applies to hooks registered without a timeout.
+const DefaultTimeout = 5 * time.Second
+
+// Hook is a named stop function.
+type Hook struct {
+	Name    string
+	Phase   Phase
+	Timeout time.Duration
+	Stop    func(ctx context.Context) error
+}
+
+// Manager runs registered stop hooks in order.
+type Manager struct {
+	mu       sync.Mutex
+	hooks    []Hook
+	once     sync.Once
+	shutdown error
+}
+
+// New creates an empty Manager.
+func New() *Manager {
+	return &Manager{}
+}
+
+// OnStop registers a hook. A zero Timeout means DefaultTimeout.
+func (m *Manager) OnStop(hook Hook) {
+	if hook.Timeout <= 0 {
+		hook.Timeout = DefaultTimeout
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.hooks = append(m.hooks, hook)
+}
+
+// Shutdown runs every hook once, in phase order, each bounded by its own
+// timeout and by ctx. A failing or slow hook doesn't prevent later hooks
+// from running; all errors are returned joined. Later calls return the
+// result of the first.
+func (m *Manager) Shutdown(ctx context.Context) error {
+	m.once.Do(func() {
+		m.mu.Lock()
+		hooks := append([]Hook(nil), m.hooks...)
+		m.mu.Unlock()
+		sort.SliceStable(hooks, func(i, j int) bool { return hooks[i].Phase < hooks[j].Phase })
+
+		var errs []error
+		for _, hook := range hooks {
+			if err := runHook(ctx, hook); err != nil {
+				log.Printf("Shutdown: %s failed: %v", hook.Name, err)
+				errs = append(errs, fmt.Errorf("%s: %w", hook.Name, err))
+			}
+		}
+		m.shutdown = errors.Join(errs...)
+	})
+	return m.shutdown
+}
+
+func runHook(ctx context.Context, hook Hook) (err error) {
+	ctx, cancel := context.WithTimeout(ctx, hook.Timeout)
+	defer cancel()
+
+	log.Printf("Shutdown: stopping %s...", hook.Name)
+	start := time.Now()
+	defer func() {
+		if err == nil {
+			log.Printf("Shutdown: %s stopped in %s", hook.Name, time.Since(start).Round(time.Millisecond))
+		}
+	}()
+
+	// Run the hook in the background so a hook that ignores its context
+	// can't hold up the rest of the shutdown past its timeout
+	result := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				result <- fmt.Errorf("panic: %v", r)
+			}
+		}()
+		result <- hook.Stop(ctx)
+	}()
+	select {
+	case err := <-result:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("did not stop within %s: %w", hook.Timeout, ctx.Err())
+	}
+}
+
+// ShutdownOnSignal runs Shutdown when one of signals arrives and closes the
+// returned channel once it completes. A second signal is left to the
+// default handler, so pressing Ctrl+C again forces an exit.
+func (m *Manager) ShutdownOnSignal(signals ...os.Signal) <-chan struct{} {
+	done := make(chan struct{})
+	ctx, stop := signal.NotifyContext(context.Background(), signals...)
+	go func() {
+		defer close(done)
+		<-ctx.Done()
+		stop()
+
+		log.Println("Shutting down gracefully, press Ctrl+C again to force")
+		if err := m.Shutdown(context.Background()); err != nil {
+			log.Printf("Shutdown completed with errors: %v", err)
+		}
+	}()
+	return done
+}
@@ -0,0 +1,101 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestShutdownRunsHooksInPhaseOrder(t *testing.T) {
+	m := New()
+	var mu sync.Mutex
+	var order []string
+	record := func(name string) func(context.Context) error {
+		return func(context.Context) error {
+			mu.Lock()
+			defer mu.Unlock()
+			order = append(order, name)
+			return nil
+		}
+	}
+
+	m.OnStop(Hook{Name: "db", Phase: PhaseDatabase, Stop: record("db")})
+	m.OnStop(Hook{Name: "http", Phase: PhaseServers, Stop: record("http")})
+	m.OnStop(Hook{Name: "jobs", Phase: PhaseWorkers, Stop: record("jobs")})
+	m.OnStop(Hook{Name: "h2c", Phase: PhaseServers, Stop: record("h2c")})
+
+	if err := m.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown returned error: %v", err)
+	}
+	want := []string{"http", "h2c", "jobs", "db"}
+	if !reflect.DeepEqual(order, want) {
+		t.Errorf("hooks ran in order %v, want %v", order, want)
+	}
+
+	// Shutdown is idempotent
+	_ = m.Shutdown(context.Background())
+	if len(order) != len(want) {
+		t.Errorf("hooks ran again on second Shutdown: %v", order)
+	}
+}
+
+func TestShutdownContinuesPastFailures(t *testing.T) {
+	m := New()
+	ranDB := false
+	m.OnStop(Hook{Name: "stuck", Timeout: 20 * time.Millisecond, Stop: func(context.Context) error {
+		select {} // ignores its context entirely
+	}})
+	m.OnStop(Hook{Name: "broken", Stop: func(context.Context) error { return errors.New("boom") }})
+	m.OnStop(Hook{Name: "panicky", Stop: func(context.Context) error { panic("oops") }})
+	m.OnStop(Hook{Name: "db", Phase: PhaseDatabase, Stop: func(context.Context) error {
+		ranDB = true
+		return nil
+	}})
+
+	err := m.Shutdown(context.Background())
+	if !ranDB {
+		t.Error("later hooks should run even when earlier ones fail")
+	}
+	for _, want := range []string{"stuck: did not stop within 20ms", "broken: boom", "panicky: panic: oops"} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("expected error to mention %q, got %v", want, err)
+		}
+	}
+}
+
+func TestDrainRejectsNewRequests(t *testing.T) {
+	var d Drain
+	handler := d.Middleware("/healthz")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	serve := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+
+	if rec := serve("/todos"); rec.Code != http.StatusNoContent {
+		t.Fatalf("before draining: status %d, want %d", rec.Code, http.StatusNoContent)
+	}
+
+	start := time.Now()
+	if err := d.Start(context.Background(), 20*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 20*time.Millisecond {
+		t.Errorf("Start returned after %s, want it to wait out the delay", elapsed)
+	}
+	rec := serve("/todos")
+	if rec.Code != http.StatusServiceUnavailable || rec.Header().Get("Connection") != "close" {
+		t.Errorf("while draining: status %d, Connection %q, want 503 and close", rec.Code, rec.Header().Get("Connection"))
+	}
+	if rec := serve("/healthz"); rec.Code != http.StatusNoContent {
+		t.Errorf("exempt path while draining: status %d, want %d", rec.Code, http.StatusNoContent)
+	}
+}
@@ -0,0 +1,258 @@
+// Package listener opens the sockets the HTTP server accepts connections
+// on: a TCP port, a Unix domain socket, and/or listeners inherited from
+// systemd socket activation, plus an optional UDP port for HTTP/3.
+package listener
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Listener kinds, used to configure protocols per listener.
+const (
+	KindTCP     = "tcp"
+	KindUnix    = "unix"
+	KindSystemd = "systemd"
+	KindQUIC    = "quic"
+)
+
+// Listener is an open listener and the options that apply to it.
+type Listener struct {
+	net.Listener
+	Kind string
+	// H2C accepts HTTP/2 without TLS (prior knowledge or Upgrade) alongside
+	// HTTP/1.1, for load balancers and gRPC-style clients that multiplex
+	// over plaintext connections.
+	H2C bool
+}
+
+// Config selects which listeners to open.
+type Config struct {
+	// TCPAddr is the TCP address to listen on, e.g. ":8080". Empty disables TCP.
+	TCPAddr string
+	// UnixSocket is the path of a Unix domain socket to listen on. Empty disables it.
+	UnixSocket string
+	// UnixSocketMode is applied to the socket file so a local reverse proxy
+	// running as another user can connect.
+	UnixSocketMode os.FileMode
+	// Systemd inherits listeners passed via LISTEN_FDS.
+	Systemd bool
+	// H2C lists the listener kinds that accept cleartext HTTP/2.
+	H2C map[string]bool
+	// ReusePort lets a new process bind the same TCP port and Unix socket
+	// path while the old one is still draining, for zero-downtime deploys.
+	ReusePort bool
+	// HTTP3Addr is the UDP address to serve HTTP/3 over QUIC on, e.g.
+	// ":8443". Empty disables it. QUIC always runs over TLS, so it needs
+	// TLSCertFile and TLSKeyFile.
+	HTTP3Addr   string
+	TLSCertFile string
+	TLSKeyFile  string
+}
+
+// QUICListener is an open UDP socket for HTTP/3 and the TLS configuration
+// to serve it with.
+type QUICListener struct {
+	net.PacketConn
+	TLSConfig *tls.Config
+}
+
+// ConfigFromEnv reads UNIX_SOCKET, UNIX_SOCKET_MODE, H2C, REUSE_PORT,
+// HTTP3_ADDR, TLS_CERT_FILE and TLS_KEY_FILE, and listens on port, the
+// configured API port: "off" disables TCP. When port is empty TCP uses
+// 8080, unless systemd passes listeners or a Unix socket is set, so a
+// socket-activated service doesn't also bind :8080.
+func ConfigFromEnv(port string) (Config, error) {
+	cfg := Config{
+		UnixSocket:     os.Getenv("UNIX_SOCKET"),
+		UnixSocketMode: 0o660,
+		Systemd:        os.Getenv("LISTEN_FDS") != "",
+	}
+
+	switch {
+	case port == "off":
+		// TCP explicitly disabled
+	case port != "":
+		if _, err := strconv.Atoi(port); err != nil {
+			return cfg, fmt.Errorf("invalid port %q", port)
+		}
+		cfg.TCPAddr = ":" + port
+	case !cfg.Systemd && cfg.UnixSocket == "":
+		cfg.TCPAddr = ":8080"
+	}
+
+	if v := os.Getenv("UNIX_SOCKET_MODE"); v != "" {
+		mode, err := strconv.ParseUint(v, 8, 32)
+		if err != nil || mode > 0o777 {
+			return cfg, fmt.Errorf("invalid UNIX_SOCKET_MODE %q, expected octal permissions like 660", v)
+		}
+		cfg.UnixSocketMode = os.FileMode(mode)
+	}
+
+	if v := os.Getenv("REUSE_PORT"); v != "" {
+		reuse, err := strconv.ParseBool(v)
+		if err != nil {
+			return cfg, fmt.Errorf("invalid REUSE_PORT %q", v)
+		}
+		cfg.ReusePort = reuse
+	}
+
+	// H2C is a comma-separated list of listener kinds, or "all"
+	cfg.H2C = make(map[string]bool)
+	for _, kind := range strings.Split(os.Getenv("H2C"), ",") {
+		switch kind = strings.TrimSpace(strings.ToLower(kind)); kind {
+		case "":
+		case "all":
+			cfg.H2C[KindTCP], cfg.H2C[KindUnix], cfg.H2C[KindSystemd] = true, true, true
+		case KindTCP, KindUnix, KindSystemd:
+			cfg.H2C[kind] = true
+		default:
+			return cfg, fmt.Errorf("invalid H2C listener kind %q, expected tcp, unix, systemd or all", kind)
+		}
+	}
+
+	cfg.HTTP3Addr = os.Getenv("HTTP3_ADDR")
+	cfg.TLSCertFile = os.Getenv("TLS_CERT_FILE")
+	cfg.TLSKeyFile = os.Getenv("TLS_KEY_FILE")
+	if cfg.HTTP3Addr != "" && (cfg.TLSCertFile == "" || cfg.TLSKeyFile == "") {
+		return cfg, errors.New("HTTP3_ADDR needs TLS_CERT_FILE and TLS_KEY_FILE")
+	}
+	return cfg, nil
+}
+
+// Open opens every configured listener. On error, listeners opened so far
+// are closed.
+func Open(cfg Config) (listeners []Listener, err error) {
+	defer func() {
+		if err != nil {
+			for _, l := range listeners {
+				l.Close()
+			}
+			listeners = nil
+		}
+	}()
+
+	if cfg.Systemd {
+		inherited, err := systemdListeners()
+		if err != nil {
+			return listeners, fmt.Errorf("systemd socket activation: %w", err)
+		}
+		for _, l := range inherited {
+			listeners = append(listeners, Listener{Listener: l, Kind: KindSystemd, H2C: cfg.H2C[KindSystemd]})
+		}
+	}
+
+	if cfg.TCPAddr != "" {
+		var lc net.ListenConfig
+		if cfg.ReusePort {
+			lc.Control = reusePortControl
+		}
+		l, err := lc.Listen(context.Background(), "tcp", cfg.TCPAddr)
+		if err != nil {
+			return listeners, err
+		}
+		listeners = append(listeners, Listener{Listener: l, Kind: KindTCP, H2C: cfg.H2C[KindTCP]})
+	}
+
+	if cfg.UnixSocket != "" {
+		listen := listenUnix
+		if cfg.ReusePort {
+			listen = listenUnixReplace
+		}
+		l, err := listen(cfg.UnixSocket, cfg.UnixSocketMode)
+		if err != nil {
+			return listeners, err
+		}
+		listeners = append(listeners, Listener{Listener: l, Kind: KindUnix, H2C: cfg.H2C[KindUnix]})
+	}
+
+	if len(listeners) == 0 {
+		return nil, errors.New("no listeners configured")
+	}
+	return listeners, nil
+}
+
+// OpenQUIC opens the UDP socket for HTTP/3 and loads its certificate. It
+// returns nil when HTTP3Addr is empty.
+func OpenQUIC(cfg Config) (*QUICListener, error) {
+	if cfg.HTTP3Addr == "" {
+		return nil, nil
+	}
+	cert, err := tls.LoadX509KeyPair(cfg.TLSCertFile, cfg.TLSKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("loading the HTTP/3 certificate: %w", err)
+	}
+	var lc net.ListenConfig
+	if cfg.ReusePort {
+		lc.Control = reusePortControl
+	}
+	conn, err := lc.ListenPacket(context.Background(), "udp", cfg.HTTP3Addr)
+	if err != nil {
+		return nil, err
+	}
+	return &QUICListener{
+		PacketConn: conn,
+		TLSConfig:  &tls.Config{Certificates: []tls.Certificate{cert}},
+	}, nil
+}
+
+// listenUnix listens on a Unix socket, replacing a stale socket file left
+// behind by a previous process that didn't shut down cleanly.
+func listenUnix(path string, mode os.FileMode) (net.Listener, error) {
+	if info, err := os.Lstat(path); err == nil {
+		if info.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("%s exists and is not a socket", path)
+		}
+		// Only remove it if nobody is accepting on it
+		if conn, err := net.Dial("unix", path); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("%s is already in use", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, err
+		}
+	}
+
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, mode); err != nil {
+		l.Close()
+		return nil, err
+	}
+	return l, nil
+}
+
+// listenUnixReplace binds a fresh socket next to path and atomically renames
+// it into place. A process still draining on the old socket keeps its
+// accepted connections, while new connections reach the new process. The
+// socket isn't unlinked on close, since by then path may belong to the
+// replacement; a leftover file is cleaned up by listenUnix on next start.
+func listenUnixReplace(path string, mode os.FileMode) (net.Listener, error) {
+	tmp := fmt.Sprintf("%s.%d.tmp", path, os.Getpid())
+	_ = os.Remove(tmp)
+
+	l, err := net.Listen("unix", tmp)
+	if err != nil {
+		return nil, err
+	}
+	l.(*net.UnixListener).SetUnlinkOnClose(false)
+	if err := os.Chmod(tmp, mode); err != nil {
+		l.Close()
+		os.Remove(tmp)
+		return nil, err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		l.Close()
+		os.Remove(tmp)
+		return nil, err
+	}
+	return l, nil
+}
@@ -0,0 +1,217 @@
+package listener
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestConfigFromEnv(t *testing.T) {
+	cases := []struct {
+		name    string
+		port    string
+		env     map[string]string
+		wantTCP string
+	}{
+		{"defaults to 8080", "", nil, ":8080"},
+		{"explicit port", "9000", nil, ":9000"},
+		{"unix socket only", "", map[string]string{"UNIX_SOCKET": "/tmp/api.sock"}, ""},
+		{"socket activation skips default port", "", map[string]string{"LISTEN_FDS": "1"}, ""},
+		{"explicit port with socket activation", "9000", map[string]string{"LISTEN_FDS": "1"}, ":9000"},
+		{"tcp disabled", "off", nil, ""},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			for _, k := range []string{"UNIX_SOCKET", "LISTEN_FDS"} {
+				t.Setenv(k, "")
+				os.Unsetenv(k)
+			}
+			for k, v := range tc.env {
+				t.Setenv(k, v)
+			}
+			cfg, err := ConfigFromEnv(tc.port)
+			if err != nil {
+				t.Fatalf("ConfigFromEnv returned error: %v", err)
+			}
+			if cfg.TCPAddr != tc.wantTCP {
+				t.Errorf("TCPAddr = %q, want %q", cfg.TCPAddr, tc.wantTCP)
+			}
+		})
+	}
+}
+
+func TestOpenUnixSocketReplacesStaleFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "api.sock")
+
+	// Leave a stale socket behind, as a crashed process would
+	stale, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+
+	listeners, err := Open(Config{UnixSocket: path, UnixSocketMode: 0o600, H2C: map[string]bool{KindUnix: true}})
+	if err != nil {
+		t.Fatalf("Open returned error: %v", err)
+	}
+	defer listeners[0].Close()
+	if listeners[0].Kind != KindUnix || !listeners[0].H2C {
+		t.Errorf("unexpected listener options %+v", listeners[0])
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("socket mode = %o, want 600", info.Mode().Perm())
+	}
+
+	// A live socket must not be replaced
+	if _, err := Open(Config{UnixSocket: path}); err == nil {
+		t.Error("expected error opening a socket that is in use")
+	}
+}
+
+func TestConfigFromEnvH2C(t *testing.T) {
+	t.Setenv("H2C", "tcp, Unix")
+	cfg, err := ConfigFromEnv("")
+	if err != nil {
+		t.Fatalf("ConfigFromEnv returned error: %v", err)
+	}
+	if !cfg.H2C[KindTCP] || !cfg.H2C[KindUnix] || cfg.H2C[KindSystemd] {
+		t.Errorf("unexpected H2C config %v", cfg.H2C)
+	}
+
+	t.Setenv("H2C", "quic")
+	if _, err := ConfigFromEnv(""); err == nil {
+		t.Error("expected error for unknown listener kind")
+	}
+}
+
+func TestReusePortAllowsSecondProcess(t *testing.T) {
+	first, err := Open(Config{TCPAddr: "127.0.0.1:0", ReusePort: true})
+	if err != nil {
+		t.Fatalf("Open returned error: %v", err)
+	}
+	defer first[0].Close()
+
+	// A replacement process binds the same port while the first still listens
+	second, err := Open(Config{TCPAddr: first[0].Addr().String(), ReusePort: true})
+	if err != nil {
+		t.Fatalf("second Open on %s returned error: %v", first[0].Addr(), err)
+	}
+	second[0].Close()
+}
+
+func TestReusePortReplacesUnixSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "api.sock")
+	cfg := Config{UnixSocket: path, UnixSocketMode: 0o600, ReusePort: true}
+
+	old, err := Open(cfg)
+	if err != nil {
+		t.Fatalf("Open returned error: %v", err)
+	}
+	replacement, err := Open(cfg)
+	if err != nil {
+		t.Fatalf("replacement Open returned error: %v", err)
+	}
+	defer replacement[0].Close()
+
+	// Closing the draining listener must leave the replacement reachable
+	old[0].Close()
+	go func() {
+		if conn, err := replacement[0].Accept(); err == nil {
+			conn.Close()
+		}
+	}()
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		t.Fatalf("dialing replacement socket: %v", err)
+	}
+	conn.Close()
+}
+
+func TestConfigFromEnvHTTP3(t *testing.T) {
+	t.Setenv("HTTP3_ADDR", ":8443")
+	t.Setenv("TLS_CERT_FILE", "")
+	t.Setenv("TLS_KEY_FILE", "")
+	if _, err := ConfigFromEnv(""); err == nil {
+		t.Error("expected error for HTTP3_ADDR without a certificate")
+	}
+
+	t.Setenv("TLS_CERT_FILE", "cert.pem")
+	t.Setenv("TLS_KEY_FILE", "key.pem")
+	cfg, err := ConfigFromEnv("")
+	if err != nil {
+		t.Fatalf("ConfigFromEnv returned error: %v", err)
+	}
+	if cfg.HTTP3Addr != ":8443" || cfg.TLSCertFile != "cert.pem" || cfg.TLSKeyFile != "key.pem" {
+		t.Errorf("unexpected HTTP/3 config %+v", cfg)
+	}
+}
+
+func TestOpenQUIC(t *testing.T) {
+	if l, err := OpenQUIC(Config{}); l != nil || err != nil {
+		t.Fatalf("OpenQUIC without HTTP3Addr = %v, %v, want nothing opened", l, err)
+	}
+
+	certFile, keyFile := writeSelfSignedCert(t)
+	l, err := OpenQUIC(Config{HTTP3Addr: "127.0.0.1:0", TLSCertFile: certFile, TLSKeyFile: keyFile})
+	if err != nil {
+		t.Fatalf("OpenQUIC returned error: %v", err)
+	}
+	defer l.Close()
+	if l.LocalAddr().Network() != "udp" || len(l.TLSConfig.Certificates) != 1 {
+		t.Errorf("unexpected QUIC listener on %s with %d certificates", l.LocalAddr(), len(l.TLSConfig.Certificates))
+	}
+
+	// A missing certificate fails before the port is bound
+	if _, err := OpenQUIC(Config{HTTP3Addr: "127.0.0.1:0", TLSCertFile: certFile, TLSKeyFile: filepath.Join(t.TempDir(), "missing.pem")}); err == nil {
+		t.Error("expected error for a missing key file")
+	}
+}
+
+// writeSelfSignedCert writes a certificate for localhost and its key, and
+// returns their paths.
+func writeSelfSignedCert(t *testing.T) (certFile, keyFile string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	certFile, keyFile = filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return certFile, keyFile
+}
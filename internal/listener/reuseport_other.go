@@ -0,0 +1,12 @@
+//go:build !(linux || darwin || freebsd)
+
+package listener
+
+import (
+	"errors"
+	"syscall"
+)
+
+func reusePortControl(network, address string, c syscall.RawConn) error {
+	return errors.New("SO_REUSEPORT is not supported on this platform")
+}
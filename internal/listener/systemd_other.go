@@ -0,0 +1,12 @@
+//go:build !unix
+
+package listener
+
+import (
+	"errors"
+	"net"
+)
+
+func systemdListeners() ([]net.Listener, error) {
+	return nil, errors.New("not supported on this platform")
+}
@@ -0,0 +1,113 @@
+// Package logging sets up structured logging with log/slog. Requests carry
+// a logger in their context, annotated with the request's ID, route and
+// user, so everything logged while serving a request can be told apart.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/Tomlord1122/todo-backend/internal/tracing"
+)
+
+// Config holds the logging settings.
+type Config struct {
+	// Level is the least severe level logged
+	Level slog.Level
+	// JSON logs a JSON object per line instead of key=value text
+	JSON bool
+}
+
+// ConfigFromEnv reads LOG_LEVEL (debug, info, warn or error, default info)
+// and LOG_FORMAT (text or json, default text).
+func ConfigFromEnv() (Config, error) {
+	cfg := Config{Level: slog.LevelInfo}
+	if v := os.Getenv("LOG_LEVEL"); v != "" {
+		if err := cfg.Level.UnmarshalText([]byte(v)); err != nil {
+			return cfg, fmt.Errorf("invalid LOG_LEVEL %q, must be debug, info, warn or error", v)
+		}
+	}
+	switch v := os.Getenv("LOG_FORMAT"); strings.ToLower(v) {
+	case "", "text":
+	case "json":
+		cfg.JSON = true
+	default:
+		return cfg, fmt.Errorf("invalid LOG_FORMAT %q, must be text or json", v)
+	}
+	return cfg, nil
+}
+
+// New returns a logger writing to w as cfg says. Lines logged with the
+// context of a traced request carry its trace_id.
+func New(w io.Writer, cfg Config) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: cfg.Level}
+	var handler slog.Handler = slog.NewTextHandler(w, opts)
+	if cfg.JSON {
+		handler = slog.NewJSONHandler(w, opts)
+	}
+	return slog.New(traceHandler{handler})
+}
+
+// traceHandler adds the trace ID of the context a record is logged with.
+type traceHandler struct {
+	slog.Handler
+}
+
+func (h traceHandler) Handle(ctx context.Context, r slog.Record) error {
+	if traceID := tracing.TraceID(ctx); traceID != "" {
+		r.AddAttrs(slog.String("trace_id", traceID))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h traceHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return traceHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h traceHandler) WithGroup(name string) slog.Handler {
+	return traceHandler{h.Handler.WithGroup(name)}
+}
+
+// scope holds the logger of a request. It is shared by the contexts
+// derived from the request's, so attributes added deep down, like the user
+// once they are authenticated, also show in the request's access log line.
+type scope struct {
+	mu     sync.Mutex
+	logger *slog.Logger
+}
+
+type scopeKey struct{}
+
+// NewContext returns a copy of ctx carrying logger.
+func NewContext(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, scopeKey{}, &scope{logger: logger})
+}
+
+// FromContext returns the logger of ctx, or the default logger when ctx
+// has none.
+func FromContext(ctx context.Context) *slog.Logger {
+	s, ok := ctx.Value(scopeKey{}).(*scope)
+	if !ok {
+		return slog.Default()
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.logger
+}
+
+// With adds args, as in slog.Logger.With, to the logger of ctx. It does
+// nothing when ctx has none.
+func With(ctx context.Context, args ...any) {
+	s, ok := ctx.Value(scopeKey{}).(*scope)
+	if !ok {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.logger = s.logger.With(args...)
+}
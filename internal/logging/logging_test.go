@@ -0,0 +1,78 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestConfigFromEnv(t *testing.T) {
+	t.Setenv("LOG_LEVEL", "")
+	t.Setenv("LOG_FORMAT", "")
+	cfg, err := ConfigFromEnv()
+	if err != nil || cfg.Level != slog.LevelInfo || cfg.JSON {
+		t.Errorf("ConfigFromEnv() = %+v, %v, want info level text", cfg, err)
+	}
+
+	t.Setenv("LOG_LEVEL", "debug")
+	t.Setenv("LOG_FORMAT", "JSON")
+	cfg, err = ConfigFromEnv()
+	if err != nil || cfg.Level != slog.LevelDebug || !cfg.JSON {
+		t.Errorf("ConfigFromEnv() = %+v, %v, want debug level JSON", cfg, err)
+	}
+
+	t.Setenv("LOG_FORMAT", "xml")
+	if _, err := ConfigFromEnv(); err == nil {
+		t.Error("expected an error for LOG_FORMAT=xml")
+	}
+	t.Setenv("LOG_FORMAT", "")
+	t.Setenv("LOG_LEVEL", "loud")
+	if _, err := ConfigFromEnv(); err == nil {
+		t.Error("expected an error for LOG_LEVEL=loud")
+	}
+}
+
+func TestNewAddsTraceID(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf, Config{Level: slog.LevelInfo})
+
+	logger.Debug("hidden")
+	logger.InfoContext(context.Background(), "untraced")
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: trace.TraceID{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36},
+		SpanID:  trace.SpanID{0x00, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7},
+	})
+	logger.With("user_id", 7).InfoContext(trace.ContextWithSpanContext(context.Background(), sc), "traced")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("logged %q, want the untraced and traced lines only", lines)
+	}
+	if strings.Contains(lines[0], "trace_id") {
+		t.Errorf("untraced line %q has a trace ID", lines[0])
+	}
+	if want := "user_id=7 trace_id=4bf92f3577b34da6a3ce929d0e0e4736"; !strings.HasSuffix(lines[1], want) {
+		t.Errorf("traced line %q, want it to end with %q", lines[1], want)
+	}
+}
+
+func TestWithSharesTheRequestLogger(t *testing.T) {
+	var buf bytes.Buffer
+	ctx := NewContext(context.Background(), New(&buf, Config{}).With("request_id", "r1"))
+	// Attributes added through a derived context reach the original one
+	With(context.WithValue(ctx, struct{}{}, true), "user_id", 7)
+	FromContext(ctx).Info("done")
+
+	if got, want := strings.TrimSpace(buf.String()), "request_id=r1 user_id=7"; !strings.HasSuffix(got, want) {
+		t.Errorf("logged %q, want it to end with %q", got, want)
+	}
+	if FromContext(context.Background()) != slog.Default() {
+		t.Error("FromContext without a logger should return the default logger")
+	}
+	// With is a no-op without a logger
+	With(context.Background(), "user_id", 7)
+}
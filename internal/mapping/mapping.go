@@ -0,0 +1,83 @@
+// Package mapping renders the templates that turn arbitrary JSON payloads,
+// such as those sent by automation tools, into todo fields.
+//
+// A template is plain text with {{path}} placeholders. A path is a dotted
+// list of object keys and array indexes into the payload, e.g.
+// {{issue.title}} or {{items.0.name}}; {{.}} is the whole payload. Missing
+// values render as nothing, strings as-is, and anything else as JSON.
+package mapping
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Validate checks that every placeholder in tmpl is closed and not empty.
+func Validate(tmpl string) error {
+	_, err := Render(tmpl, nil)
+	return err
+}
+
+// Render fills the placeholders in tmpl from payload, as decoded by
+// encoding/json. Use a json.Decoder with UseNumber to keep numbers exact.
+func Render(tmpl string, payload any) (string, error) {
+	var b strings.Builder
+	rest := tmpl
+	for {
+		start := strings.Index(rest, "{{")
+		if start < 0 {
+			b.WriteString(rest)
+			return b.String(), nil
+		}
+		b.WriteString(rest[:start])
+		rest = rest[start+2:]
+		end := strings.Index(rest, "}}")
+		if end < 0 {
+			return "", fmt.Errorf("unclosed placeholder at offset %d", len(tmpl)-len(rest)-2)
+		}
+		path := strings.TrimSpace(rest[:end])
+		if path == "" {
+			return "", fmt.Errorf("empty placeholder at offset %d", len(tmpl)-len(rest)-2)
+		}
+		rest = rest[end+2:]
+
+		value, ok := lookup(payload, path)
+		if !ok {
+			continue
+		}
+		if s, isString := value.(string); isString {
+			b.WriteString(s)
+			continue
+		}
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return "", fmt.Errorf("rendering %q: %w", path, err)
+		}
+		b.Write(encoded)
+	}
+}
+
+// lookup walks path through objects and arrays. It reports false when a
+// segment doesn't exist or the value is null.
+func lookup(value any, path string) (any, bool) {
+	if path == "." {
+		return value, value != nil
+	}
+	for _, key := range strings.Split(path, ".") {
+		switch v := value.(type) {
+		case map[string]any:
+			value = v[key]
+		case []any:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil, false
+			}
+			value = v[i]
+		default:
+			return nil, false
+		}
+	}
+	return value, value != nil
+}
@@ -0,0 +1,56 @@
+package mapping
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	decoder := json.NewDecoder(strings.NewReader(`{
+		"issue": {"title": "Broken build", "number": 42, "labels": ["ci", "urgent"]},
+		"items": [{"name": "first"}],
+		"draft": false,
+		"body": null
+	}`))
+	decoder.UseNumber()
+	var payload any
+	if err := decoder.Decode(&payload); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		tmpl string
+		want string
+	}{
+		{"plain text", "plain text"},
+		{"#{{issue.number}}: {{ issue.title }}", "#42: Broken build"},
+		{"{{items.0.name}}", "first"},
+		{"{{issue.labels}}", `["ci","urgent"]`},
+		{"draft={{draft}}", "draft=false"},
+		{"[{{missing.key}}][{{items.5.name}}][{{body}}][{{issue.title.x}}]", "[][][][]"},
+	}
+	for _, tc := range cases {
+		got, err := Render(tc.tmpl, payload)
+		if err != nil {
+			t.Errorf("Render(%q): %v", tc.tmpl, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("Render(%q) = %q, want %q", tc.tmpl, got, tc.want)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	for _, tmpl := range []string{"", "no placeholders", "{{a}} and {{b.0}}", "{{.}}"} {
+		if err := Validate(tmpl); err != nil {
+			t.Errorf("Validate(%q) = %v, want nil", tmpl, err)
+		}
+	}
+	for _, tmpl := range []string{"{{unclosed", "{{ }}", "ok {{a}} then {{"} {
+		if err := Validate(tmpl); err == nil {
+			t.Errorf("Validate(%q) = nil, want an error", tmpl)
+		}
+	}
+}
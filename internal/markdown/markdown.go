@@ -0,0 +1,366 @@
+// Package markdown renders the Markdown of todo descriptions as HTML, so
+// clients don't need a renderer of their own. It covers the common subset:
+// headings, paragraphs, lists, block quotes, code, emphasis and links.
+//
+// The output is safe to embed as is. All text is escaped, raw HTML shows
+// up as text, and links are kept only for http, https and mailto URLs.
+package markdown
+
+import (
+	"html"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// HTML renders src as HTML.
+func HTML(src string) string {
+	src = strings.ReplaceAll(src, "\r\n", "\n")
+	var b strings.Builder
+	renderBlocks(&b, strings.Split(src, "\n"))
+	return b.String()
+}
+
+// renderBlocks renders lines as a sequence of blocks.
+func renderBlocks(b *strings.Builder, lines []string) {
+	for i := 0; i < len(lines); {
+		line := strings.TrimSpace(lines[i])
+		if line == "" {
+			i++
+			continue
+		}
+		if fence, ok := codeFence(line); ok {
+			i = renderCode(b, lines, i, fence)
+			continue
+		}
+		if level, text, ok := heading(line); ok {
+			tag := "h" + strconv.Itoa(level)
+			b.WriteString("<" + tag + ">")
+			renderInline(b, text)
+			b.WriteString("</" + tag + ">\n")
+			i++
+			continue
+		}
+		if isRule(line) {
+			b.WriteString("<hr>\n")
+			i++
+			continue
+		}
+		if strings.HasPrefix(line, ">") {
+			i = renderQuote(b, lines, i)
+			continue
+		}
+		if _, _, ok := listItem(line); ok {
+			i = renderList(b, lines, i)
+			continue
+		}
+		i = renderParagraph(b, lines, i)
+	}
+}
+
+// codeFence reports whether line opens a fenced code block, returning the
+// fence that closes it.
+func codeFence(line string) (string, bool) {
+	for _, c := range []string{"`", "~"} {
+		fence := line[:len(line)-len(strings.TrimLeft(line, c))]
+		if len(fence) >= 3 {
+			return fence, true
+		}
+	}
+	return "", false
+}
+
+// renderCode renders the fenced code block opened at lines[i] and returns
+// the index after it. An unclosed block runs to the end.
+func renderCode(b *strings.Builder, lines []string, i int, fence string) int {
+	lang := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(lines[i]), fence))
+	if fields := strings.Fields(lang); len(fields) > 0 && isLanguage(fields[0]) {
+		b.WriteString(`<pre><code class="language-` + fields[0] + `">`)
+	} else {
+		b.WriteString("<pre><code>")
+	}
+	for i++; i < len(lines); i++ {
+		if strings.HasPrefix(strings.TrimSpace(lines[i]), fence) {
+			i++
+			break
+		}
+		b.WriteString(html.EscapeString(lines[i]))
+		b.WriteByte('\n')
+	}
+	b.WriteString("</code></pre>\n")
+	return i
+}
+
+// isLanguage reports whether s is a plausible code block language such as
+// "go" or "c++", safe to use in a class name.
+func isLanguage(s string) bool {
+	for _, r := range s {
+		if !isAlnum(r) && !strings.ContainsRune("+-_#.", r) {
+			return false
+		}
+	}
+	return true
+}
+
+// heading parses an ATX heading such as "## Notes".
+func heading(line string) (int, string, bool) {
+	level := len(line) - len(strings.TrimLeft(line, "#"))
+	if level == 0 || level > 6 || (len(line) > level && line[level] != ' ') {
+		return 0, "", false
+	}
+	text := strings.TrimSpace(line[level:])
+	text = strings.TrimSpace(strings.TrimRight(text, "#"))
+	return level, text, true
+}
+
+// isRule reports whether line is a thematic break such as "---" or "* * *".
+func isRule(line string) bool {
+	line = strings.ReplaceAll(line, " ", "")
+	if len(line) < 3 || !strings.ContainsRune("-*_", rune(line[0])) {
+		return false
+	}
+	return strings.Trim(line, line[:1]) == ""
+}
+
+// renderQuote renders the block quote starting at lines[i] and returns the
+// index after it.
+func renderQuote(b *strings.Builder, lines []string, i int) int {
+	var inner []string
+	for ; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		if !strings.HasPrefix(line, ">") {
+			break
+		}
+		line = strings.TrimPrefix(line, ">")
+		inner = append(inner, strings.TrimPrefix(line, " "))
+	}
+	b.WriteString("<blockquote>\n")
+	renderBlocks(b, inner)
+	b.WriteString("</blockquote>\n")
+	return i
+}
+
+// listItem parses a list item such as "- milk" or "2. eggs". The number of
+// ordered items is returned, and -1 for bullets.
+func listItem(line string) (int, string, bool) {
+	if len(line) >= 2 && strings.ContainsRune("-*+", rune(line[0])) && line[1] == ' ' {
+		return -1, strings.TrimSpace(line[2:]), true
+	}
+	digits := len(line) - len(strings.TrimLeft(line, "0123456789"))
+	if digits == 0 || digits > 9 || len(line) < digits+2 ||
+		(line[digits] != '.' && line[digits] != ')') || line[digits+1] != ' ' {
+		return 0, "", false
+	}
+	n, _ := strconv.Atoi(line[:digits])
+	return n, strings.TrimSpace(line[digits+2:]), true
+}
+
+// renderList renders the list starting at lines[i] and returns the index
+// after it. Indented lines continue the previous item; nested lists are
+// not supported.
+func renderList(b *strings.Builder, lines []string, i int) int {
+	start, _, _ := listItem(strings.TrimSpace(lines[i]))
+	ordered := start >= 0
+	switch {
+	case !ordered:
+		b.WriteString("<ul>\n")
+	case start == 1:
+		b.WriteString("<ol>\n")
+	default:
+		b.WriteString(`<ol start="` + strconv.Itoa(start) + `">` + "\n")
+	}
+
+	var items []string
+	for ; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		if line == "" {
+			break
+		}
+		if n, text, ok := listItem(line); ok {
+			if (n >= 0) != ordered {
+				break
+			}
+			items = append(items, text)
+			continue
+		}
+		if lines[i][0] != ' ' && lines[i][0] != '\t' {
+			break
+		}
+		items[len(items)-1] += "\n" + line
+	}
+	for _, item := range items {
+		b.WriteString("<li>")
+		renderInline(b, item)
+		b.WriteString("</li>\n")
+	}
+
+	if ordered {
+		b.WriteString("</ol>\n")
+	} else {
+		b.WriteString("</ul>\n")
+	}
+	return i
+}
+
+// renderParagraph renders the paragraph starting at lines[i] and returns
+// the index after it. Paragraphs end at a blank line or another block.
+func renderParagraph(b *strings.Builder, lines []string, i int) int {
+	var text []string
+	for ; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		if line == "" || (len(text) > 0 && startsBlock(line)) {
+			break
+		}
+		text = append(text, line)
+	}
+	b.WriteString("<p>")
+	renderInline(b, strings.Join(text, "\n"))
+	b.WriteString("</p>\n")
+	return i
+}
+
+// startsBlock reports whether line starts a block other than a paragraph.
+func startsBlock(line string) bool {
+	_, fenced := codeFence(line)
+	_, _, isHeading := heading(line)
+	_, _, isItem := listItem(line)
+	return fenced || isHeading || isItem || isRule(line) || strings.HasPrefix(line, ">")
+}
+
+// renderInline renders the spans in s: code, emphasis, links and escapes.
+func renderInline(b *strings.Builder, s string) {
+	for i := 0; i < len(s); {
+		var n int
+		switch s[i] {
+		case '\\':
+			if i+1 < len(s) && strings.IndexByte(punctuation, s[i+1]) >= 0 {
+				b.WriteString(html.EscapeString(s[i+1 : i+2]))
+				n = 2
+			}
+		case '`':
+			n = renderCodeSpan(b, s[i:])
+		case '*', '_', '~':
+			n = renderEmphasis(b, s, i)
+		case '[':
+			n = renderLink(b, s[i:])
+		case '<':
+			n = renderAutolink(b, s[i:])
+		}
+		if n == 0 {
+			b.WriteString(html.EscapeString(s[i : i+1]))
+			n = 1
+		}
+		i += n
+	}
+}
+
+// punctuation lists the characters a backslash escapes.
+const punctuation = "\\`*_~[]()<>#+-.!{}|"
+
+// renderCodeSpan renders the code span s starts with and returns its
+// length, or 0 when there is none.
+func renderCodeSpan(b *strings.Builder, s string) int {
+	ticks := s[:len(s)-len(strings.TrimLeft(s, "`"))]
+	end := strings.Index(s[len(ticks):], ticks)
+	if end < 0 {
+		return 0
+	}
+	code := s[len(ticks) : len(ticks)+end]
+	if len(code) > 2 && code[0] == ' ' && code[len(code)-1] == ' ' {
+		code = code[1 : len(code)-1]
+	}
+	b.WriteString("<code>" + html.EscapeString(code) + "</code>")
+	return 2*len(ticks) + end
+}
+
+// renderEmphasis renders the emphasis opened at s[i]: *em*, **strong** or
+// ~~strikethrough~~. It returns the length rendered, or 0 when the
+// delimiter isn't closed.
+func renderEmphasis(b *strings.Builder, s string, i int) int {
+	c := s[i]
+	run := len(s[i:]) - len(strings.TrimLeft(s[i:], string(c)))
+	n := min(run, 2)
+	if c == '~' && n != 2 {
+		return 0
+	}
+	// Underscores inside words, as in snake_case, are literal
+	if c == '_' && i > 0 && isAlnum(rune(s[i-1])) {
+		return 0
+	}
+	delim := s[i : i+n]
+	body := s[i+n:]
+	end := strings.Index(body, delim)
+	if end <= 0 || body[0] == ' ' || body[end-1] == ' ' {
+		return 0
+	}
+	if c == '_' && end+n < len(body) && isAlnum(rune(body[end+n])) {
+		return 0
+	}
+	tag := map[string]string{"*": "em", "_": "em", "**": "strong", "__": "strong", "~~": "del"}[delim]
+	b.WriteString("<" + tag + ">")
+	renderInline(b, body[:end])
+	b.WriteString("</" + tag + ">")
+	return 2*n + end
+}
+
+// renderLink renders the [text](url) link s starts with and returns its
+// length, or 0 when there is none. Links to unsafe URLs keep their text.
+func renderLink(b *strings.Builder, s string) int {
+	closing := strings.Index(s, "](")
+	if closing < 0 || strings.Contains(s[1:closing], "[") {
+		return 0
+	}
+	end := strings.IndexByte(s[closing:], ')')
+	if end < 0 {
+		return 0
+	}
+	text, target := s[1:closing], strings.TrimSpace(s[closing+2:closing+end])
+	// Drop an optional title: [text](url "title")
+	if fields := strings.Fields(target); len(fields) > 0 {
+		target = fields[0]
+	}
+	if safeURL(target) {
+		b.WriteString(`<a href="` + html.EscapeString(target) + `" rel="nofollow noopener">`)
+		renderInline(b, text)
+		b.WriteString("</a>")
+	} else {
+		renderInline(b, text)
+	}
+	return closing + end + 1
+}
+
+// renderAutolink renders the <https://...> link s starts with and returns
+// its length, or 0 when there is none.
+func renderAutolink(b *strings.Builder, s string) int {
+	end := strings.IndexByte(s, '>')
+	if end < 0 {
+		return 0
+	}
+	target := s[1:end]
+	if strings.ContainsAny(target, " <") || !safeURL(target) {
+		return 0
+	}
+	escaped := html.EscapeString(target)
+	b.WriteString(`<a href="` + escaped + `" rel="nofollow noopener">` + escaped + "</a>")
+	return end + 1
+}
+
+// safeURL reports whether target is an absolute http, https or mailto URL,
+// the only links kept.
+func safeURL(target string) bool {
+	u, err := url.Parse(target)
+	if err != nil {
+		return false
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https":
+		return u.Host != ""
+	case "mailto":
+		return u.Opaque != ""
+	}
+	return false
+}
+
+func isAlnum(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9'
+}
@@ -0,0 +1,54 @@
+package markdown
+
+import "testing"
+
+func TestHTML(t *testing.T) {
+	tests := []struct {
+		name, in, want string
+	}{
+		{"empty", "", ""},
+		{"paragraphs", "Buy milk\nand eggs\n\nThen cook", "<p>Buy milk\nand eggs</p>\n<p>Then cook</p>\n"},
+		{"heading", "## Notes ##", "<h2>Notes</h2>\n"},
+		{"not a heading", "#hashtag", "<p>#hashtag</p>\n"},
+		{"emphasis", "*one* **two** __three__ ~~four~~", "<p><em>one</em> <strong>two</strong> <strong>three</strong> <del>four</del></p>\n"},
+		{"nested emphasis", "**very *much* so**", "<p><strong>very <em>much</em> so</strong></p>\n"},
+		{"snake case", "see file_name_here", "<p>see file_name_here</p>\n"},
+		{"unclosed", "2 * 3 = 6", "<p>2 * 3 = 6</p>\n"},
+		{"code span", "run `rm -rf <dir>` *carefully*", "<p>run <code>rm -rf &lt;dir&gt;</code> <em>carefully</em></p>\n"},
+		{"escapes", `\*not em\*`, "<p>*not em*</p>\n"},
+		{"bullets", "- milk\n- eggs\n  (free range)\n* other", "<ul>\n<li>milk</li>\n<li>eggs\n(free range)</li>\n<li>other</li>\n</ul>\n"},
+		{"ordered", "3. three\n4) four\n- bullet", "<ol start=\"3\">\n<li>three</li>\n<li>four</li>\n</ol>\n<ul>\n<li>bullet</li>\n</ul>\n"},
+		{"quote", "> **Note**\n> twice", "<blockquote>\n<p><strong>Note</strong>\ntwice</p>\n</blockquote>\n"},
+		{"rule", "above\n\n* * *\nbelow", "<p>above</p>\n<hr>\n<p>below</p>\n"},
+		{"fenced code", "```go\nif a < b {\n```\nafter", "<pre><code class=\"language-go\">if a &lt; b {\n</code></pre>\n<p>after</p>\n"},
+		{"bad language", "~~~\"><script>\nx\n~~~", "<pre><code>x\n</code></pre>\n"},
+		{"link", `[docs](https://example.com/a?b=1&c="2" "Title")`, "<p><a href=\"https://example.com/a?b=1&amp;c=&#34;2&#34;\" rel=\"nofollow noopener\">docs</a></p>\n"},
+		{"mailto", "[mail](mailto:ada@example.com)", "<p><a href=\"mailto:ada@example.com\" rel=\"nofollow noopener\">mail</a></p>\n"},
+		{"autolink", "<https://example.com>", "<p><a href=\"https://example.com\" rel=\"nofollow noopener\">https://example.com</a></p>\n"},
+	}
+	for _, tt := range tests {
+		if got := HTML(tt.in); got != tt.want {
+			t.Errorf("%s: HTML(%q)\n got %q\nwant %q", tt.name, tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestHTMLIsSafe(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"<script>alert(1)</script>", "<p>&lt;script&gt;alert(1)&lt;/script&gt;</p>\n"},
+		{`<img src=x onerror="alert(1)">`, "<p>&lt;img src=x onerror=&#34;alert(1)&#34;&gt;</p>\n"},
+		{"[click](javascript:alert(1))", "<p>click)</p>\n"},
+		{"[click](JAVASCRIPT:alert%281%29)", "<p>click</p>\n"},
+		{"[click](data:text/html;base64,PHNjcmlwdD4=)", "<p>click</p>\n"},
+		{"[click](//evil.example.com)", "<p>click</p>\n"},
+		{"<javascript:alert(1)>", "<p>&lt;javascript:alert(1)&gt;</p>\n"},
+		{`[x](https://a.example/" onmouseover="alert(1))`, "<p><a href=\"https://a.example/&#34;\" rel=\"nofollow noopener\">x</a>)</p>\n"},
+	}
+	for _, tt := range tests {
+		if got := HTML(tt.in); got != tt.want {
+			t.Errorf("HTML(%q)\n got %q\nwant %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+// Package metrics defines the Prometheus metrics the service exports.
+package metrics
+
+import (
+	"database/sql"
+	"os"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// DBPool publishes database/sql connection pool statistics. The values are
+// sampled by a background job rather than at scrape time, so scrapes never
+// touch the database layer.
+type DBPool struct {
+	maxOpen           prometheus.Gauge
+	open              prometheus.Gauge
+	inUse             prometheus.Gauge
+	idle              prometheus.Gauge
+	waitCount         prometheus.Gauge
+	waitDuration      prometheus.Gauge
+	maxIdleClosed     prometheus.Gauge
+	maxIdleTimeClosed prometheus.Gauge
+	maxLifetimeClosed prometheus.Gauge
+}
+
+// NewDBPool registers the pool gauges with reg.
+func NewDBPool(reg prometheus.Registerer) *DBPool {
+	gauge := func(name, help string) prometheus.Gauge {
+		g := prometheus.NewGauge(prometheus.GaugeOpts{Namespace: "db", Subsystem: "pool", Name: name, Help: help})
+		reg.MustRegister(g)
+		return g
+	}
+	// The sql.DBStats counters are cumulative but read from a snapshot, so
+	// they're exported as gauges set to the latest sample
+	return &DBPool{
+		maxOpen:           gauge("max_open_connections", "Maximum number of open connections to the database."),
+		open:              gauge("open_connections", "Number of established connections, in use and idle."),
+		inUse:             gauge("in_use_connections", "Number of connections currently in use."),
+		idle:              gauge("idle_connections", "Number of idle connections."),
+		waitCount:         gauge("wait_count", "Total number of connections waited for."),
+		waitDuration:      gauge("wait_duration_seconds", "Total time blocked waiting for a new connection."),
+		maxIdleClosed:     gauge("max_idle_closed", "Total number of connections closed due to SetMaxIdleConns."),
+		maxIdleTimeClosed: gauge("max_idle_time_closed", "Total number of connections closed due to SetConnMaxIdleTime."),
+		maxLifetimeClosed: gauge("max_lifetime_closed", "Total number of connections closed due to SetConnMaxLifetime."),
+	}
+}
+
+// Update sets every gauge from a stats sample.
+func (p *DBPool) Update(stats sql.DBStats) {
+	p.maxOpen.Set(float64(stats.MaxOpenConnections))
+	p.open.Set(float64(stats.OpenConnections))
+	p.inUse.Set(float64(stats.InUse))
+	p.idle.Set(float64(stats.Idle))
+	p.waitCount.Set(float64(stats.WaitCount))
+	p.waitDuration.Set(stats.WaitDuration.Seconds())
+	p.maxIdleClosed.Set(float64(stats.MaxIdleClosed))
+	p.maxIdleTimeClosed.Set(float64(stats.MaxIdleTimeClosed))
+	p.maxLifetimeClosed.Set(float64(stats.MaxLifetimeClosed))
+}
+
+// NewSlowQueryCounter registers a counter reporting total(), the number of
+// slow queries captured since startup.
+func NewSlowQueryCounter(reg prometheus.Registerer, total func() int64) {
+	reg.MustRegister(prometheus.NewCounterFunc(prometheus.CounterOpts{
+		Namespace: "db",
+		Name:      "slow_queries_total",
+		Help:      "Number of statements slower than the slow query threshold.",
+	}, func() float64 { return float64(total()) }))
+}
+
+// ScrapeIntervalFromEnv reads METRICS_SCRAPE_INTERVAL, how often sampled
+// metrics are refreshed, defaulting to 15 seconds.
+func ScrapeIntervalFromEnv() time.Duration {
+	if d, err := time.ParseDuration(os.Getenv("METRICS_SCRAPE_INTERVAL")); err == nil && d > 0 {
+		return d
+	}
+	return 15 * time.Second
+}
@@ -0,0 +1,45 @@
+package metrics
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestDBPool(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	pool := NewDBPool(reg)
+	pool.Update(sql.DBStats{MaxOpenConnections: 100, OpenConnections: 7, InUse: 3, Idle: 4, WaitCount: 12, WaitDuration: 1500 * time.Millisecond})
+
+	want := `
+# HELP db_pool_in_use_connections Number of connections currently in use.
+# TYPE db_pool_in_use_connections gauge
+db_pool_in_use_connections 3
+# HELP db_pool_wait_duration_seconds Total time blocked waiting for a new connection.
+# TYPE db_pool_wait_duration_seconds gauge
+db_pool_wait_duration_seconds 1.5
+`
+	if err := testutil.GatherAndCompare(reg, strings.NewReader(want), "db_pool_in_use_connections", "db_pool_wait_duration_seconds"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSlowQueryCounter(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	total := int64(0)
+	NewSlowQueryCounter(reg, func() int64 { return total })
+	total = 5
+
+	want := `
+# HELP db_slow_queries_total Number of statements slower than the slow query threshold.
+# TYPE db_slow_queries_total counter
+db_slow_queries_total 5
+`
+	if err := testutil.GatherAndCompare(reg, strings.NewReader(want), "db_slow_queries_total"); err != nil {
+		t.Error(err)
+	}
+}
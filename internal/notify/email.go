@@ -0,0 +1,158 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"time"
+)
+
+// EmailConfig holds SMTP settings.
+type EmailConfig struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+// EmailConfigFromEnv reads SMTP settings from SMTP_* environment variables.
+// ok is false when SMTP_HOST is not set, meaning email is disabled.
+func EmailConfigFromEnv() (cfg EmailConfig, ok bool) {
+	cfg = EmailConfig{
+		Host:     os.Getenv("SMTP_HOST"),
+		Port:     os.Getenv("SMTP_PORT"),
+		Username: os.Getenv("SMTP_USERNAME"),
+		Password: os.Getenv("SMTP_PASSWORD"),
+		From:     os.Getenv("SMTP_FROM"),
+	}
+	if cfg.Port == "" {
+		cfg.Port = "587"
+	}
+	return cfg, cfg.Host != ""
+}
+
+// EmailChannel sends messages over SMTP.
+type EmailChannel struct {
+	cfg EmailConfig
+}
+
+// NewEmailChannel creates an SMTP-backed channel.
+func NewEmailChannel(cfg EmailConfig) *EmailChannel {
+	return &EmailChannel{cfg: cfg}
+}
+
+// Name implements Channel.
+func (c *EmailChannel) Name() string { return "email" }
+
+// Send implements Channel.
+func (c *EmailChannel) Send(ctx context.Context, msg Message) error {
+	to, err := mail.ParseAddress(msg.Recipient)
+	if err != nil {
+		return fmt.Errorf("invalid recipient address: %w", err)
+	}
+
+	body, err := buildMIMEMessage(c.cfg.From, to.Address, msg)
+	if err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if c.cfg.Username != "" {
+		auth = smtp.PlainAuth("", c.cfg.Username, c.cfg.Password, c.cfg.Host)
+	}
+
+	// net/smtp has no context support; run it in the background and give up
+	// waiting if the context ends first.
+	addr := net.JoinHostPort(c.cfg.Host, c.cfg.Port)
+	done := make(chan error, 1)
+	go func() {
+		done <- smtp.SendMail(addr, auth, c.cfg.From, []string{to.Address}, body)
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Ping checks that the SMTP server accepts connections and greets.
+func (c *EmailChannel) Ping(ctx context.Context) error {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(c.cfg.Host, c.cfg.Port))
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	client, err := smtp.NewClient(conn, c.cfg.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+	return client.Quit()
+}
+
+// buildMIMEMessage renders a multipart/mixed message with a plain-text body
+// and base64-encoded attachments.
+func buildMIMEMessage(from, to string, msg Message) ([]byte, error) {
+	if from == "" {
+		return nil, errors.New("SMTP_FROM is not configured")
+	}
+
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+
+	header := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\nDate: %s\r\nMIME-Version: 1.0\r\nContent-Type: multipart/mixed; boundary=%s\r\n\r\n",
+		from, to, mime.QEncoding.Encode("utf-8", msg.Subject), time.Now().Format(time.RFC1123Z), mw.Boundary())
+
+	part, err := mw.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {"text/plain; charset=utf-8"},
+		"Content-Transfer-Encoding": {"8bit"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if _, err := part.Write([]byte(msg.Body)); err != nil {
+		return nil, err
+	}
+
+	for _, att := range msg.Attachments {
+		part, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {att.ContentType},
+			"Content-Transfer-Encoding": {"base64"},
+			"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": att.Filename})},
+		})
+		if err != nil {
+			return nil, err
+		}
+		encoded := base64.StdEncoding.EncodeToString(att.Data)
+		// RFC 2045 limits encoded lines to 76 characters
+		for len(encoded) > 76 {
+			if _, err := part.Write([]byte(encoded[:76] + "\r\n")); err != nil {
+				return nil, err
+			}
+			encoded = encoded[76:]
+		}
+		if _, err := part.Write([]byte(encoded)); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+	return append([]byte(header), buf.Bytes()...), nil
+}
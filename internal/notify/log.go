@@ -0,0 +1,18 @@
+package notify
+
+import (
+	"context"
+	"log"
+)
+
+// LogChannel "delivers" messages by logging them. Useful in development.
+type LogChannel struct{}
+
+// Name implements Channel.
+func (LogChannel) Name() string { return "log" }
+
+// Send implements Channel.
+func (LogChannel) Send(ctx context.Context, msg Message) error {
+	log.Printf("notify[log] to=%s subject=%q attachments=%d", msg.Recipient, msg.Subject, len(msg.Attachments))
+	return nil
+}
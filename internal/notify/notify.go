@@ -0,0 +1,72 @@
+// Package notify delivers messages to users over pluggable channels
+// (log, email, webhook).
+package notify
+
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
+// Message is a channel-neutral notification.
+type Message struct {
+	// Recipient is interpreted by the channel: an email address, a webhook URL, ...
+	Recipient   string
+	Subject     string
+	Body        string
+	Attachments []Attachment
+}
+
+// Attachment is a file sent along with a message.
+type Attachment struct {
+	Filename    string
+	ContentType string
+	Data        []byte
+}
+
+// Channel delivers messages over one transport.
+type Channel interface {
+	// Name identifies the channel, e.g. "email".
+	Name() string
+	// Send delivers the message, returning an error if delivery failed.
+	Send(ctx context.Context, msg Message) error
+}
+
+// Registry looks up channels by name.
+type Registry struct {
+	channels map[string]Channel
+}
+
+// NewRegistry creates a registry holding the given channels.
+func NewRegistry(channels ...Channel) *Registry {
+	r := &Registry{channels: make(map[string]Channel, len(channels))}
+	for _, ch := range channels {
+		r.channels[ch.Name()] = ch
+	}
+	return r
+}
+
+// Get returns the channel registered under name.
+func (r *Registry) Get(name string) (Channel, bool) {
+	ch, ok := r.channels[name]
+	return ch, ok
+}
+
+// Names lists the registered channel names in sorted order.
+func (r *Registry) Names() []string {
+	names := make([]string, 0, len(r.channels))
+	for name := range r.channels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Send delivers msg via the named channel.
+func (r *Registry) Send(ctx context.Context, channel string, msg Message) error {
+	ch, ok := r.Get(channel)
+	if !ok {
+		return fmt.Errorf("notification channel %q is not configured", channel)
+	}
+	return ch.Send(ctx, msg)
+}
@@ -0,0 +1,94 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/Tomlord1122/todo-backend/internal/egress"
+)
+
+// WebhookChannel POSTs messages as JSON to the recipient URL.
+type WebhookChannel struct {
+	client *http.Client
+}
+
+// NewWebhookChannel creates a webhook channel. A nil client gets an
+// egress client with a 10 second timeout, which can't reach the server's
+// own network.
+func NewWebhookChannel(client *http.Client) *WebhookChannel {
+	if client == nil {
+		client = egress.NewClient(10 * time.Second)
+	}
+	return &WebhookChannel{client: client}
+}
+
+// webhookPayload is the JSON body sent to webhook recipients.
+// Attachment data is base64-encoded by encoding/json.
+type webhookPayload struct {
+	Subject     string              `json:"subject"`
+	Body        string              `json:"body"`
+	Attachments []webhookAttachment `json:"attachments,omitempty"`
+}
+
+type webhookAttachment struct {
+	Filename    string `json:"filename"`
+	ContentType string `json:"content_type"`
+	Data        []byte `json:"data"`
+}
+
+// Name implements Channel.
+func (c *WebhookChannel) Name() string { return "webhook" }
+
+// Send implements Channel.
+func (c *WebhookChannel) Send(ctx context.Context, msg Message) error {
+	if err := ValidateWebhookURL(msg.Recipient); err != nil {
+		return err
+	}
+
+	payload := webhookPayload{Subject: msg.Subject, Body: msg.Body}
+	for _, att := range msg.Attachments {
+		payload.Attachments = append(payload.Attachments, webhookAttachment(att))
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, msg.Recipient, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// ValidateWebhookURL checks that target is an absolute http(s) URL whose
+// host isn't a loopback, private or link-local address. Host names are
+// checked again when a delivery connects.
+func ValidateWebhookURL(target string) error {
+	u, err := url.Parse(target)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid webhook URL %q", target)
+	}
+	if err := egress.ValidateHost(u.Hostname()); err != nil {
+		return fmt.Errorf("invalid webhook URL %q: %w", target, err)
+	}
+	return nil
+}
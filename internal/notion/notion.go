@@ -0,0 +1,264 @@
+// Package notion is a small client for the Notion API: reading a
+// database's schema and adding pages (rows) to it. Like the S3 store it is
+// written on net/http to keep SDKs out of the dependency tree.
+package notion
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// apiVersion is the Notion-Version the requests are written against.
+	apiVersion = "2022-06-28"
+	// maxTextLength is the longest text Notion accepts in one text object.
+	maxTextLength = 2000
+	// minInterval keeps requests under Notion's average of three per second.
+	minInterval = 350 * time.Millisecond
+	// maxRetryAfter caps how long a rate-limited request waits to retry.
+	maxRetryAfter = 30 * time.Second
+)
+
+// Config holds Notion API settings.
+type Config struct {
+	// Token is an internal integration token; the target databases must be
+	// shared with the integration.
+	Token  string
+	APIURL string
+	// DatabaseIDs are the databases users may export to. The token may
+	// reach others shared with the integration, which stay off limits.
+	DatabaseIDs []string
+}
+
+// ConfigFromEnv reads NOTION_TOKEN, NOTION_API_URL and the comma-separated
+// NOTION_DATABASE_IDS. ok is false when no token or no database is set,
+// meaning Notion export is disabled.
+func ConfigFromEnv() (cfg Config, ok bool) {
+	cfg = Config{
+		Token:  os.Getenv("NOTION_TOKEN"),
+		APIURL: strings.TrimRight(os.Getenv("NOTION_API_URL"), "/"),
+	}
+	if cfg.APIURL == "" {
+		cfg.APIURL = "https://api.notion.com/v1"
+	}
+	for _, id := range strings.Split(os.Getenv("NOTION_DATABASE_IDS"), ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			cfg.DatabaseIDs = append(cfg.DatabaseIDs, id)
+		}
+	}
+	return cfg, cfg.Token != "" && len(cfg.DatabaseIDs) > 0
+}
+
+// Client calls the Notion API. It spaces requests out to stay within
+// Notion's rate limit and retries once when limited anyway.
+type Client struct {
+	cfg    Config
+	client *http.Client
+
+	mu   sync.Mutex
+	last time.Time
+}
+
+// NewClient creates a Notion client. A nil client gets a default one with
+// a 30 second timeout.
+func NewClient(cfg Config, client *http.Client) *Client {
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	return &Client{cfg: cfg, client: client}
+}
+
+// AllowsDatabase reports whether databaseID is one of the configured
+// databases. Notion IDs are compared with or without their dashes.
+func (c *Client) AllowsDatabase(databaseID string) bool {
+	normalize := func(id string) string { return strings.ToLower(strings.ReplaceAll(id, "-", "")) }
+	for _, allowed := range c.cfg.DatabaseIDs {
+		if normalize(allowed) == normalize(databaseID) {
+			return true
+		}
+	}
+	return false
+}
+
+// DatabaseProperties returns the type of each property of a database, by
+// property name.
+func (c *Client) DatabaseProperties(ctx context.Context, databaseID string) (map[string]string, error) {
+	var database struct {
+		Properties map[string]struct {
+			Type string `json:"type"`
+		} `json:"properties"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/databases/"+url.PathEscape(databaseID), nil, &database); err != nil {
+		return nil, err
+	}
+	types := make(map[string]string, len(database.Properties))
+	for name, property := range database.Properties {
+		types[name] = property.Type
+	}
+	return types, nil
+}
+
+// CreatePage adds a page to a database and returns its ID. properties are
+// property values by name, see Value.
+func (c *Client) CreatePage(ctx context.Context, databaseID string, properties map[string]any) (string, error) {
+	body := map[string]any{
+		"parent":     map[string]string{"database_id": databaseID},
+		"properties": properties,
+	}
+	var page struct {
+		ID string `json:"id"`
+	}
+	if err := c.do(ctx, http.MethodPost, "/pages", body, &page); err != nil {
+		return "", err
+	}
+	return page.ID, nil
+}
+
+// Value encodes v as a value for a property of the given type. v may be a
+// string, bool, float64, *time.Time or nil; nil clears the property.
+func Value(propertyType string, v any) (any, error) {
+	switch propertyType {
+	case "title", "rich_text":
+		text, _ := v.(string)
+		if v != nil && !isString(v) {
+			text = fmt.Sprint(v)
+		}
+		if runes := []rune(text); len(runes) > maxTextLength {
+			text = string(runes[:maxTextLength-1]) + "…"
+		}
+		return map[string]any{propertyType: []any{map[string]any{"text": map[string]string{"content": text}}}}, nil
+	case "checkbox":
+		b, ok := v.(bool)
+		if !ok {
+			return nil, fmt.Errorf("a checkbox needs true or false, got %v", v)
+		}
+		return map[string]any{"checkbox": b}, nil
+	case "number":
+		switch n := v.(type) {
+		case nil:
+			return map[string]any{"number": nil}, nil
+		case float64:
+			return map[string]any{"number": n}, nil
+		}
+		return nil, fmt.Errorf("a number property needs a number, got %v", v)
+	case "date":
+		switch t := v.(type) {
+		case nil:
+			return map[string]any{"date": nil}, nil
+		case *time.Time:
+			if t == nil {
+				return map[string]any{"date": nil}, nil
+			}
+			return map[string]any{"date": map[string]string{"start": t.Format(time.RFC3339)}}, nil
+		}
+		return nil, fmt.Errorf("a date property needs a date, got %v", v)
+	case "select":
+		name, _ := v.(string)
+		if v != nil && !isString(v) {
+			name = fmt.Sprint(v)
+		}
+		if name == "" {
+			return map[string]any{"select": nil}, nil
+		}
+		// Notion rejects commas in select option names
+		return map[string]any{"select": map[string]string{"name": strings.ReplaceAll(name, ",", " ")}}, nil
+	}
+	return nil, fmt.Errorf("property type %q is not supported", propertyType)
+}
+
+func isString(v any) bool {
+	_, ok := v.(string)
+	return ok
+}
+
+// do sends an API request, decoding the JSON response into out if set.
+func (c *Client) do(ctx context.Context, method, path string, body, out any) error {
+	var encoded []byte
+	if body != nil {
+		var err error
+		if encoded, err = json.Marshal(body); err != nil {
+			return err
+		}
+	}
+	for attempt := 0; ; attempt++ {
+		if err := c.wait(ctx); err != nil {
+			return err
+		}
+		req, err := http.NewRequestWithContext(ctx, method, c.cfg.APIURL+path, bytes.NewReader(encoded))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", "Bearer "+c.cfg.Token)
+		req.Header.Set("Notion-Version", apiVersion)
+		if body != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+
+		resp, err := c.client.Do(req)
+		if err != nil {
+			return fmt.Errorf("notion %s %s: %w", method, path, err)
+		}
+		if resp.StatusCode == http.StatusTooManyRequests && attempt == 0 {
+			resp.Body.Close()
+			delay, _ := strconv.Atoi(resp.Header.Get("Retry-After"))
+			if err := sleep(ctx, min(time.Duration(max(delay, 1))*time.Second, maxRetryAfter)); err != nil {
+				return err
+			}
+			continue
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode/100 != 2 {
+			var apiErr struct {
+				Message string `json:"message"`
+			}
+			raw, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+			if json.Unmarshal(raw, &apiErr) != nil || apiErr.Message == "" {
+				apiErr.Message = strings.TrimSpace(string(raw))
+			}
+			return fmt.Errorf("notion %s %s: %s: %s", method, path, resp.Status, apiErr.Message)
+		}
+		if out != nil {
+			if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+				return fmt.Errorf("notion %s %s: decoding response: %w", method, path, err)
+			}
+		}
+		return nil
+	}
+}
+
+// wait blocks until minInterval has passed since the previous request.
+func (c *Client) wait(ctx context.Context) error {
+	c.mu.Lock()
+	next := c.last.Add(minInterval)
+	now := time.Now()
+	if next.Before(now) {
+		next = now
+	}
+	c.last = next
+	c.mu.Unlock()
+	return sleep(ctx, time.Until(next))
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,78 @@
+package notion
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValue(t *testing.T) {
+	due := time.Date(2026, 3, 1, 9, 30, 0, 0, time.UTC)
+	cases := []struct {
+		propertyType string
+		value        any
+		want         string
+	}{
+		{"title", "Buy milk", `{"title":[{"text":{"content":"Buy milk"}}]}`},
+		{"rich_text", nil, `{"rich_text":[{"text":{"content":""}}]}`},
+		{"rich_text", 2.5, `{"rich_text":[{"text":{"content":"2.5"}}]}`},
+		{"checkbox", true, `{"checkbox":true}`},
+		{"number", 3.0, `{"number":3}`},
+		{"number", nil, `{"number":null}`},
+		{"date", &due, `{"date":{"start":"2026-03-01T09:30:00Z"}}`},
+		{"date", (*time.Time)(nil), `{"date":null}`},
+		{"select", "high", `{"select":{"name":"high"}}`},
+		{"select", "a,b", `{"select":{"name":"a b"}}`},
+		{"select", "", `{"select":null}`},
+	}
+	for _, tc := range cases {
+		got, err := Value(tc.propertyType, tc.value)
+		if err != nil {
+			t.Errorf("Value(%q, %v): %v", tc.propertyType, tc.value, err)
+			continue
+		}
+		encoded, _ := json.Marshal(got)
+		if string(encoded) != tc.want {
+			t.Errorf("Value(%q, %v) = %s, want %s", tc.propertyType, tc.value, encoded, tc.want)
+		}
+	}
+}
+
+func TestValueRejectsMismatches(t *testing.T) {
+	for _, tc := range []struct {
+		propertyType string
+		value        any
+	}{
+		{"checkbox", "yes"},
+		{"number", "3"},
+		{"date", "tomorrow"},
+		{"people", "someone"},
+	} {
+		if _, err := Value(tc.propertyType, tc.value); err == nil {
+			t.Errorf("Value(%q, %v) succeeded, want an error", tc.propertyType, tc.value)
+		}
+	}
+}
+
+func TestValueTruncatesLongText(t *testing.T) {
+	got, _ := Value("rich_text", strings.Repeat("x", 3000))
+	content := got.(map[string]any)["rich_text"].([]any)[0].(map[string]any)["text"].(map[string]string)["content"]
+	if n := len([]rune(content)); n != maxTextLength {
+		t.Errorf("truncated text has %d characters, want %d", n, maxTextLength)
+	}
+}
+
+func TestAllowsDatabase(t *testing.T) {
+	client := NewClient(Config{DatabaseIDs: []string{"a8aec43c-6f00-4a7a-a8b1-4b4e1c0a1b2c"}}, nil)
+	for id, want := range map[string]bool{
+		"a8aec43c-6f00-4a7a-a8b1-4b4e1c0a1b2c": true,
+		"A8AEC43C6F004A7AA8B14B4E1C0A1B2C":     true,
+		"d9b1e2f3-0000-4a7a-a8b1-4b4e1c0a1b2c": false,
+		"":                                     false,
+	} {
+		if got := client.AllowsDatabase(id); got != want {
+			t.Errorf("AllowsDatabase(%q) = %t, want %t", id, got, want)
+		}
+	}
+}
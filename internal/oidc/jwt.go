@@ -0,0 +1,200 @@
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+)
+
+// jwtHeader is the part of a JWS header verification needs.
+type jwtHeader struct {
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid"`
+}
+
+// splitJWT decodes a compact JWS without verifying it. signed is the
+// "header.payload" the signature covers.
+func splitJWT(raw string) (header jwtHeader, claims map[string]any, signed, signature []byte, err error) {
+	parts := strings.Split(raw, ".")
+	if len(parts) != 3 {
+		return header, nil, nil, nil, fmt.Errorf("%w: not a compact JWS", ErrInvalidToken)
+	}
+	headerJSON, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil || json.Unmarshal(headerJSON, &header) != nil {
+		return header, nil, nil, nil, fmt.Errorf("%w: malformed header", ErrInvalidToken)
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil || json.Unmarshal(payload, &claims) != nil || claims == nil {
+		return header, nil, nil, nil, fmt.Errorf("%w: malformed claims", ErrInvalidToken)
+	}
+	signature, err = base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return header, nil, nil, nil, fmt.Errorf("%w: malformed signature", ErrInvalidToken)
+	}
+	return header, claims, []byte(parts[0] + "." + parts[1]), signature, nil
+}
+
+// jwk is a JSON Web Key as published in a provider's JWKS.
+type jwk struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	N         string `json:"n"`
+	E         string `json:"e"`
+	Curve     string `json:"crv"`
+	X         string `json:"x"`
+	Y         string `json:"y"`
+}
+
+// verificationKey is a signing key of the provider.
+type verificationKey struct {
+	// algorithm restricts the key to one algorithm when the JWK names one
+	algorithm string
+	public    crypto.PublicKey
+}
+
+// parseJWK converts a JWK into a verification key. ok is false for keys
+// this package doesn't verify with, which are skipped.
+func parseJWK(k jwk) (key verificationKey, ok bool) {
+	if k.Use != "" && k.Use != "sig" {
+		return key, false
+	}
+	key.algorithm = k.Algorithm
+	switch k.KeyType {
+	case "RSA":
+		n, errN := base64.RawURLEncoding.DecodeString(k.N)
+		e, errE := base64.RawURLEncoding.DecodeString(k.E)
+		if errN != nil || errE != nil || len(e) == 0 || len(e) > 4 {
+			return key, false
+		}
+		pub := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+		if pub.N.BitLen() < 2048 {
+			return key, false
+		}
+		key.public = pub
+	case "EC":
+		if k.Curve != "P-256" {
+			return key, false
+		}
+		x, errX := base64.RawURLEncoding.DecodeString(k.X)
+		y, errY := base64.RawURLEncoding.DecodeString(k.Y)
+		if errX != nil || errY != nil || len(x) != 32 || len(y) != 32 {
+			return key, false
+		}
+		pub := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !pub.Curve.IsOnCurve(pub.X, pub.Y) {
+			return key, false
+		}
+		key.public = pub
+	default:
+		return key, false
+	}
+	return key, true
+}
+
+// verify checks a JWS signature made with alg. Only RS256 and ES256, the
+// algorithms providers sign ID tokens with, are accepted.
+func (k verificationKey) verify(alg string, signed, signature []byte) error {
+	if k.algorithm != "" && k.algorithm != alg {
+		return fmt.Errorf("%w: key is for %s, token uses %s", ErrInvalidToken, k.algorithm, alg)
+	}
+	digest := sha256.Sum256(signed)
+	switch pub := k.public.(type) {
+	case *rsa.PublicKey:
+		if alg != "RS256" {
+			break
+		}
+		if rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest[:], signature) != nil {
+			return fmt.Errorf("%w: bad signature", ErrInvalidToken)
+		}
+		return nil
+	case *ecdsa.PublicKey:
+		if alg != "ES256" {
+			break
+		}
+		// JWS carries the raw r||s rather than ASN.1
+		if len(signature) != 64 {
+			return fmt.Errorf("%w: bad signature", ErrInvalidToken)
+		}
+		r := new(big.Int).SetBytes(signature[:32])
+		s := new(big.Int).SetBytes(signature[32:])
+		if !ecdsa.Verify(pub, digest[:], r, s) {
+			return fmt.Errorf("%w: bad signature", ErrInvalidToken)
+		}
+		return nil
+	}
+	return fmt.Errorf("%w: unsupported algorithm %q", ErrInvalidToken, alg)
+}
+
+// keyRefreshInterval limits refetching the JWKS for unknown key IDs, so
+// forged tokens can't make us hammer the provider.
+const keyRefreshInterval = time.Minute
+
+// keySet caches a provider's JWKS and refetches it when a token names a
+// key it hasn't seen, which is how providers roll keys.
+type keySet struct {
+	uri   string
+	fetch func(ctx context.Context, url string, out any) error
+
+	mu        sync.Mutex
+	keys      map[string]verificationKey
+	fetchedAt time.Time
+}
+
+func newKeySet(uri string, fetch func(ctx context.Context, url string, out any) error) *keySet {
+	return &keySet{uri: uri, fetch: fetch}
+}
+
+// key returns the key with the given ID. A token without a key ID is
+// accepted only when the provider publishes a single key.
+func (s *keySet) key(ctx context.Context, kid string, now time.Time) (verificationKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if key, ok := s.lookup(kid); ok {
+		return key, nil
+	}
+	if s.keys != nil && now.Sub(s.fetchedAt) < keyRefreshInterval {
+		return verificationKey{}, fmt.Errorf("%w: unknown key %q", ErrInvalidToken, kid)
+	}
+
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := s.fetch(ctx, s.uri, &set); err != nil {
+		return verificationKey{}, fmt.Errorf("oidc keys: %w", err)
+	}
+	s.keys = map[string]verificationKey{}
+	s.fetchedAt = now
+	for _, k := range set.Keys {
+		if key, ok := parseJWK(k); ok {
+			s.keys[k.KeyID] = key
+		}
+	}
+
+	if key, ok := s.lookup(kid); ok {
+		return key, nil
+	}
+	return verificationKey{}, fmt.Errorf("%w: unknown key %q", ErrInvalidToken, kid)
+}
+
+func (s *keySet) lookup(kid string) (verificationKey, bool) {
+	if kid == "" && len(s.keys) == 1 {
+		for _, key := range s.keys {
+			return key, true
+		}
+	}
+	key, ok := s.keys[kid]
+	return key, ok
+}
@@ -0,0 +1,216 @@
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testIdP is a minimal OpenID provider that issues one ID token per code.
+type testIdP struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+	kid    string
+	// claims is merged into the ID token claims of the next exchange
+	claims map[string]any
+	// nonce and challenge are taken from the last authorization request
+	nonce, challenge string
+	jwksFetches      int
+}
+
+func newTestIdP(t *testing.T) *testIdP {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idp := &testIdP{key: key, kid: "key-1"}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 idp.server.URL,
+			"authorization_endpoint": idp.server.URL + "/authorize",
+			"token_endpoint":         idp.server.URL + "/token",
+			"jwks_uri":               idp.server.URL + "/keys",
+		})
+	})
+	mux.HandleFunc("GET /keys", func(w http.ResponseWriter, r *http.Request) {
+		idp.jwksFetches++
+		json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{{
+			"kty": "RSA", "kid": idp.kid, "use": "sig", "alg": "RS256",
+			"n": base64.RawURLEncoding.EncodeToString(idp.key.N.Bytes()),
+			"e": base64.RawURLEncoding.EncodeToString(big.NewInt(int64(idp.key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("POST /token", func(w http.ResponseWriter, r *http.Request) {
+		id, secret, _ := r.BasicAuth()
+		verifier := sha256.Sum256([]byte(r.FormValue("code_verifier")))
+		if id != "client" || secret != "s%3Acret" || r.FormValue("code") != "good-code" ||
+			base64.RawURLEncoding.EncodeToString(verifier[:]) != idp.challenge {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+		claims := map[string]any{
+			"iss": idp.server.URL, "aud": "client", "sub": "user-123",
+			"exp": time.Now().Add(time.Hour).Unix(), "iat": time.Now().Unix(), "nonce": idp.nonce,
+			"email": "ada@example.com", "email_verified": true, "name": "Ada",
+		}
+		for k, v := range idp.claims {
+			claims[k] = v
+		}
+		json.NewEncoder(w).Encode(map[string]string{"id_token": idp.sign(t, claims), "token_type": "Bearer"})
+	})
+	idp.server = httptest.NewServer(mux)
+	t.Cleanup(idp.server.Close)
+	return idp
+}
+
+func (idp *testIdP) sign(t *testing.T, claims map[string]any) string {
+	t.Helper()
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "kid": idp.kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signed))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, idp.key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func (idp *testIdP) provider(claims ClaimMapping) *Provider {
+	if claims.Subject == "" {
+		claims.Subject = "sub"
+	}
+	return NewProvider(Config{
+		Name: "test", Issuer: idp.server.URL, ClientID: "client", ClientSecret: "s:cret",
+		RedirectURL: "https://todo.example.com/callback", Scopes: []string{"openid", "email"},
+		Claims: claims,
+	}, idp.server.Client())
+}
+
+// authorize follows AuthURL the way a browser would, recording what the
+// provider would bind the code to.
+func (idp *testIdP) authorize(t *testing.T, p *Provider, nonce, verifier string) {
+	t.Helper()
+	authURL, err := p.AuthURL(context.Background(), "state", nonce, verifier)
+	if err != nil {
+		t.Fatalf("AuthURL returned error: %v", err)
+	}
+	u, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := u.Query()
+	if q.Get("client_id") != "client" || q.Get("state") != "state" || q.Get("code_challenge_method") != "S256" ||
+		q.Get("redirect_uri") != "https://todo.example.com/callback" || q.Get("scope") != "openid email" {
+		t.Errorf("AuthURL query = %v", q)
+	}
+	idp.nonce, idp.challenge = q.Get("nonce"), q.Get("code_challenge")
+}
+
+func TestExchange(t *testing.T) {
+	idp := newTestIdP(t)
+	p := idp.provider(ClaimMapping{Email: "email", Name: "name"})
+	idp.authorize(t, p, "nonce-1", "verifier-1")
+
+	identity, err := p.Exchange(context.Background(), "good-code", "verifier-1", "nonce-1", time.Now())
+	if err != nil {
+		t.Fatalf("Exchange returned error: %v", err)
+	}
+	want := Identity{Subject: "user-123", Email: "ada@example.com", EmailVerified: true, Name: "Ada"}
+	if *identity != want {
+		t.Errorf("Exchange = %+v, want %+v", *identity, want)
+	}
+
+	if _, err := p.Exchange(context.Background(), "good-code", "other-verifier", "nonce-1", time.Now()); err == nil || !strings.Contains(err.Error(), "invalid_grant") {
+		t.Errorf("wrong verifier: err = %v, want invalid_grant", err)
+	}
+	if _, err := p.Exchange(context.Background(), "good-code", "verifier-1", "other-nonce", time.Now()); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("wrong nonce: err = %v, want ErrInvalidToken", err)
+	}
+	if idp.jwksFetches != 1 {
+		t.Errorf("keys fetched %d times, want 1", idp.jwksFetches)
+	}
+}
+
+func TestExchangeRejectsBadTokens(t *testing.T) {
+	cases := map[string]map[string]any{
+		"other issuer":   {"iss": "https://evil.example.com"},
+		"other audience": {"aud": []any{"someone-else"}},
+		"other azp":      {"azp": "someone-else"},
+		"expired":        {"exp": time.Now().Add(-time.Hour).Unix()},
+		"no subject":     {"sub": ""},
+	}
+	for name, claims := range cases {
+		t.Run(name, func(t *testing.T) {
+			idp := newTestIdP(t)
+			idp.claims = claims
+			p := idp.provider(ClaimMapping{})
+			idp.authorize(t, p, "n", "v")
+			if _, err := p.Exchange(context.Background(), "good-code", "v", "n", time.Now()); !errors.Is(err, ErrInvalidToken) {
+				t.Errorf("err = %v, want ErrInvalidToken", err)
+			}
+		})
+	}
+}
+
+func TestKeyRotation(t *testing.T) {
+	idp := newTestIdP(t)
+	p := idp.provider(ClaimMapping{})
+	idp.authorize(t, p, "n", "v")
+	now := time.Now()
+	if _, err := p.Exchange(context.Background(), "good-code", "v", "n", now); err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idp.key, idp.kid = key, "key-2"
+	// Unknown keys are refetched at most once a minute
+	if _, err := p.Exchange(context.Background(), "good-code", "v", "n", now.Add(time.Second)); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("right after a fetch: err = %v, want ErrInvalidToken", err)
+	}
+	if _, err := p.Exchange(context.Background(), "good-code", "v", "n", now.Add(2*time.Minute)); err != nil {
+		t.Errorf("after rotation: err = %v", err)
+	}
+	if idp.jwksFetches != 2 {
+		t.Errorf("keys fetched %d times, want 2", idp.jwksFetches)
+	}
+}
+
+func TestClaimMapping(t *testing.T) {
+	idp := newTestIdP(t)
+	idp.claims = map[string]any{"oid": "object-7", "ext": map[string]any{"todo_user": 42}}
+	p := idp.provider(ClaimMapping{Subject: "oid", UserID: "ext.todo_user"})
+	idp.authorize(t, p, "n", "v")
+	identity, err := p.Exchange(context.Background(), "good-code", "v", "n", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if identity.Subject != "object-7" || identity.UserID != 42 || identity.Email != "" {
+		t.Errorf("identity = %+v", identity)
+	}
+
+	idp.claims = map[string]any{"ext": map[string]any{"todo_user": "admin"}}
+	p = idp.provider(ClaimMapping{UserID: "ext.todo_user"})
+	idp.authorize(t, p, "n", "v")
+	if _, err := p.Exchange(context.Background(), "good-code", "v", "n", time.Now()); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("non-numeric user ID: err = %v, want ErrInvalidToken", err)
+	}
+}
@@ -0,0 +1,72 @@
+// Package pagination holds the page size and export size limits shared by
+// every listing and export, so they are configured in one place.
+package pagination
+
+import (
+	"fmt"
+
+	"github.com/Tomlord1122/todo-backend/internal/apperror"
+)
+
+// ErrLimitExceeded matches every *LimitError.
+var ErrLimitExceeded = apperror.New(apperror.CodeUnprocessable, "limit exceeded")
+
+// LimitError reports a request over a configured limit, with guidance on
+// how to stay within it.
+type LimitError struct {
+	What      string // e.g. "page size" or "export"
+	Requested int
+	Max       int
+	Hint      string
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("requested %s of %d exceeds the maximum of %d; %s", e.What, e.Requested, e.Max, e.Hint)
+}
+
+// Unwrap makes errors.Is(err, ErrLimitExceeded) match, and gives the error
+// its code.
+func (e *LimitError) Unwrap() error {
+	return ErrLimitExceeded
+}
+
+// Config holds the limits.
+type Config struct {
+	// DefaultPageSize applies when the client doesn't ask for a page size.
+	DefaultPageSize int
+	// MaxPageSize is the largest page a client may request.
+	MaxPageSize int
+	// MaxExportSize caps how many rows a single export may contain.
+	MaxExportSize int
+}
+
+// DefaultConfig returns the limits used when nothing is configured.
+func DefaultConfig() Config {
+	return Config{DefaultPageSize: 50, MaxPageSize: 200, MaxExportSize: 5000}
+}
+
+// PageSize resolves a requested page size: zero means the default, and
+// anything over the maximum is a *LimitError.
+func (c Config) PageSize(requested int) (int, error) {
+	switch {
+	case requested <= 0:
+		return c.DefaultPageSize, nil
+	case requested > c.MaxPageSize:
+		return 0, &LimitError{
+			What:      "page size",
+			Requested: requested,
+			Max:       c.MaxPageSize,
+			Hint:      fmt.Sprintf("request at most %d items and follow the next page link for the rest", c.MaxPageSize),
+		}
+	}
+	return requested, nil
+}
+
+// CheckExport returns a *LimitError when an export would contain more than
+// MaxExportSize rows.
+func (c Config) CheckExport(rows int, hint string) error {
+	if rows > c.MaxExportSize {
+		return &LimitError{What: "export", Requested: rows, Max: c.MaxExportSize, Hint: hint}
+	}
+	return nil
+}
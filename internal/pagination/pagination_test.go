@@ -0,0 +1,30 @@
+package pagination
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPageSize(t *testing.T) {
+	cfg := Config{DefaultPageSize: 20, MaxPageSize: 100, MaxExportSize: 1000}
+
+	for requested, want := range map[int]int{0: 20, -1: 20, 1: 1, 100: 100} {
+		got, err := cfg.PageSize(requested)
+		if err != nil || got != want {
+			t.Errorf("PageSize(%d) = %d, %v; want %d", requested, got, err, want)
+		}
+	}
+
+	_, err := cfg.PageSize(101)
+	var limitErr *LimitError
+	if !errors.Is(err, ErrLimitExceeded) || !errors.As(err, &limitErr) || limitErr.Max != 100 {
+		t.Errorf("PageSize(101) = %v, want a LimitError with Max 100", err)
+	}
+
+	if err := cfg.CheckExport(1000, ""); err != nil {
+		t.Errorf("CheckExport(1000) = %v, want nil", err)
+	}
+	if err := cfg.CheckExport(1001, "narrow it down"); !errors.Is(err, ErrLimitExceeded) {
+		t.Errorf("CheckExport(1001) = %v, want ErrLimitExceeded", err)
+	}
+}
@@ -0,0 +1,15 @@
+// Package pathprefix matches request paths against the path prefixes that
+// middleware like read-only mode and shutdown draining exempt.
+package pathprefix
+
+import "strings"
+
+// HasAny reports whether path starts with any of prefixes.
+func HasAny(path string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
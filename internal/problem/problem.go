@@ -0,0 +1,71 @@
+// Package problem writes error responses as RFC 7807 problem details, the
+// application/problem+json format.
+package problem
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/Tomlord1122/todo-backend/internal/requestid"
+)
+
+// ContentType is the media type of problem details.
+const ContentType = "application/problem+json"
+
+// Details is a problem details object. Type is "about:blank" for problems
+// described by their status alone; Title is then the status text.
+type Details struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	// RequestID is an extension member with the request's X-Request-ID,
+	// to find it in the logs when the problem is reported.
+	RequestID string `json:"request_id,omitempty"`
+	// Code is an extension member with the application's error code, for
+	// clients to tell problems with the same status apart.
+	Code string `json:"code,omitempty"`
+	// InvalidParams lists the request fields that failed validation, as
+	// in the RFC's own example.
+	InvalidParams []InvalidParam `json:"invalid-params,omitempty"`
+}
+
+// InvalidParam is a request field that failed validation. Name is the
+// field's path in the request body, like "title" or "location.latitude";
+// Rule is the rule it broke, like "max".
+type InvalidParam struct {
+	Name   string `json:"name"`
+	Rule   string `json:"rule"`
+	Reason string `json:"reason"`
+}
+
+// New describes a problem with status that occurred handling r. detail
+// explains this occurrence to the client.
+func New(r *http.Request, status int, detail string) Details {
+	return Details{
+		Type:      "about:blank",
+		Title:     http.StatusText(status),
+		Status:    status,
+		Detail:    detail,
+		Instance:  r.URL.Path,
+		RequestID: requestid.FromContext(r.Context()),
+	}
+}
+
+// Write writes d as the response, with d.Status as the status code.
+func Write(w http.ResponseWriter, d Details) {
+	body, err := json.Marshal(d)
+	if err != nil {
+		// Details only holds strings and an int
+		panic(err)
+	}
+	w.Header().Set("Content-Type", ContentType)
+	w.WriteHeader(d.Status)
+	_, _ = w.Write(body)
+}
+
+// Error writes a problem with status and detail for r, like http.Error.
+func Error(w http.ResponseWriter, r *http.Request, status int, detail string) {
+	Write(w, New(r, status, detail))
+}
@@ -0,0 +1,37 @@
+package problem
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Tomlord1122/todo-backend/internal/requestid"
+)
+
+func TestError(t *testing.T) {
+	rec := httptest.NewRecorder()
+	Error(rec, httptest.NewRequest(http.MethodGet, "/todos/7?render=html", nil), http.StatusNotFound, "todo with ID 7 not found")
+
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want 404", rec.Code)
+	}
+	if got := rec.Header().Get("Content-Type"); got != ContentType {
+		t.Errorf("Content-Type = %q, want %q", got, ContentType)
+	}
+	want := `{"type":"about:blank","title":"Not Found","status":404,"detail":"todo with ID 7 not found","instance":"/todos/7"}`
+	if got := rec.Body.String(); got != want {
+		t.Errorf("body = %s, want %s", got, want)
+	}
+}
+
+func TestErrorCarriesRequestID(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/todos/7", nil)
+	req = req.WithContext(requestid.NewContext(req.Context(), "req-1"))
+	rec := httptest.NewRecorder()
+	Error(rec, req, http.StatusInternalServerError, "failed to fetch todo")
+
+	want := `{"type":"about:blank","title":"Internal Server Error","status":500,"detail":"failed to fetch todo","instance":"/todos/7","request_id":"req-1"}`
+	if got := rec.Body.String(); got != want {
+		t.Errorf("body = %s, want %s", got, want)
+	}
+}
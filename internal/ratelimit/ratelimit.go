@@ -0,0 +1,256 @@
+// Package ratelimit limits request rates per key with the generic cell
+// rate algorithm (GCRA). The in-memory limiter enforces limits per
+// instance; the Redis limiter shares them between all replicas.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Tomlord1122/todo-backend/internal/problem"
+	"github.com/Tomlord1122/todo-backend/internal/redis"
+)
+
+// Limit allows Requests per Period on average, with bursts of up to Burst
+// requests.
+type Limit struct {
+	Requests int
+	Period   time.Duration
+	Burst    int
+}
+
+// interval is the time one request "costs".
+func (l Limit) interval() time.Duration {
+	return l.Period / time.Duration(l.Requests)
+}
+
+// Enabled reports whether l limits requests at all.
+func (l Limit) Enabled() bool {
+	return l.Requests > 0
+}
+
+// Config holds the limit of anonymous requests, counted per client IP, and
+// the limit of signed-in users' requests, counted per user. Requests whose
+// Limit isn't enabled aren't limited.
+type Config struct {
+	Anonymous     Limit
+	Authenticated Limit
+}
+
+// ConfigFromEnv reads the anonymous limit from RATE_LIMIT_REQUESTS,
+// RATE_LIMIT_PERIOD (default 1m) and RATE_LIMIT_BURST (default
+// RATE_LIMIT_REQUESTS), and the authenticated limit from the same
+// variables prefixed RATE_LIMIT_USER_. Unset or 0 RATE_LIMIT_REQUESTS
+// doesn't limit anonymous requests. Signed-in users get the anonymous
+// limit while RATE_LIMIT_USER_REQUESTS is unset, and no limit when it is 0.
+func ConfigFromEnv() (Config, error) {
+	var cfg Config
+	var err error
+	if cfg.Anonymous, err = limitFromEnv("RATE_LIMIT_"); err != nil {
+		return cfg, err
+	}
+	cfg.Authenticated = cfg.Anonymous
+	if os.Getenv("RATE_LIMIT_USER_REQUESTS") != "" {
+		if cfg.Authenticated, err = limitFromEnv("RATE_LIMIT_USER_"); err != nil {
+			return cfg, err
+		}
+	}
+	return cfg, nil
+}
+
+// limitFromEnv reads the REQUESTS, PERIOD and BURST variables starting
+// with prefix.
+func limitFromEnv(prefix string) (Limit, error) {
+	var limit Limit
+	requests := os.Getenv(prefix + "REQUESTS")
+	if requests == "" || requests == "0" {
+		return limit, nil
+	}
+	var err error
+	limit.Period = time.Minute
+	if limit.Requests, err = strconv.Atoi(requests); err != nil || limit.Requests < 0 {
+		return Limit{}, fmt.Errorf("invalid %sREQUESTS %q, expected a positive integer", prefix, requests)
+	}
+	if v := os.Getenv(prefix + "PERIOD"); v != "" {
+		if limit.Period, err = time.ParseDuration(v); err != nil || limit.Period <= 0 {
+			return Limit{}, fmt.Errorf("invalid %sPERIOD %q, expected a positive duration", prefix, v)
+		}
+	}
+	limit.Burst = limit.Requests
+	if v := os.Getenv(prefix + "BURST"); v != "" {
+		if limit.Burst, err = strconv.Atoi(v); err != nil || limit.Burst <= 0 {
+			return Limit{}, fmt.Errorf("invalid %sBURST %q, expected a positive integer", prefix, v)
+		}
+	}
+	return limit, nil
+}
+
+// Result is the outcome of one request.
+type Result struct {
+	Allowed bool
+	// Limit is the burst size: how many requests a full bucket allows.
+	Limit int
+	// Remaining is how many more requests would be allowed right now.
+	Remaining int
+	// RetryAfter is how long until the request would be allowed; zero
+	// when it was allowed.
+	RetryAfter time.Duration
+	// Reset is how long until the bucket is full again.
+	Reset time.Duration
+}
+
+// Limiter decides whether a request for key is allowed and counts it.
+type Limiter interface {
+	Allow(ctx context.Context, key string) (Result, error)
+}
+
+// Memory limits requests within this process.
+type Memory struct {
+	limit Limit
+	now   func() time.Time
+
+	mu sync.Mutex
+	// tats holds each key's theoretical arrival time: when its bucket will
+	// be empty again
+	tats      map[string]time.Time
+	lastPrune time.Time
+}
+
+// NewMemory creates an in-memory limiter.
+func NewMemory(limit Limit) *Memory {
+	return &Memory{limit: limit, now: time.Now, tats: make(map[string]time.Time)}
+}
+
+// Allow implements Limiter.
+func (m *Memory) Allow(ctx context.Context, key string) (Result, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.now()
+	m.prune(now)
+
+	interval := m.limit.interval()
+	tat := m.tats[key]
+	if tat.Before(now) {
+		tat = now
+	}
+	newTAT := tat.Add(interval)
+	allowAt := newTAT.Add(-time.Duration(m.limit.Burst) * interval)
+	if allowAt.After(now) {
+		return Result{Limit: m.limit.Burst, RetryAfter: allowAt.Sub(now), Reset: tat.Sub(now)}, nil
+	}
+	m.tats[key] = newTAT
+	return Result{Allowed: true, Limit: m.limit.Burst, Remaining: int(now.Sub(allowAt) / interval), Reset: newTAT.Sub(now)}, nil
+}
+
+// prune drops keys whose bucket has emptied, at most once a period.
+func (m *Memory) prune(now time.Time) {
+	if now.Sub(m.lastPrune) < m.limit.Period {
+		return
+	}
+	m.lastPrune = now
+	for key, tat := range m.tats {
+		if tat.Before(now) {
+			delete(m.tats, key)
+		}
+	}
+}
+
+// gcraScript is Memory.Allow on Redis, in microseconds. It uses the
+// server's clock so replicas with skewed clocks agree.
+var gcraScript = redis.NewScript(`
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000000 + tonumber(t[2])
+local interval = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local tat = tonumber(redis.call('GET', KEYS[1]) or now)
+if tat < now then tat = now end
+local new_tat = tat + interval
+local allow_at = new_tat - burst * interval
+if allow_at > now then
+  return {0, 0, allow_at - now, tat - now}
+end
+redis.call('SET', KEYS[1], new_tat, 'PX', math.ceil((new_tat - now) / 1000))
+return {1, math.floor((now - allow_at) / interval), 0, new_tat - now}
+`)
+
+// Redis limits requests across every instance sharing the Redis server.
+type Redis struct {
+	client *redis.Client
+	limit  Limit
+	prefix string
+}
+
+// NewRedis creates a Redis-backed limiter. Keys are stored under prefix.
+func NewRedis(client *redis.Client, limit Limit, prefix string) *Redis {
+	return &Redis{client: client, limit: limit, prefix: prefix}
+}
+
+// Allow implements Limiter.
+func (r *Redis) Allow(ctx context.Context, key string) (Result, error) {
+	interval := max(r.limit.interval().Microseconds(), 1)
+	reply, err := gcraScript.Run(ctx, r.client, []string{r.prefix + key}, interval, r.limit.Burst)
+	if err != nil {
+		return Result{}, fmt.Errorf("running rate limit script: %w", err)
+	}
+	values, ok := reply.([]any)
+	if !ok || len(values) != 4 {
+		return Result{}, fmt.Errorf("unexpected rate limit script reply %v", reply)
+	}
+	allowed, _ := values[0].(int64)
+	remaining, _ := values[1].(int64)
+	retryAfter, _ := values[2].(int64)
+	reset, _ := values[3].(int64)
+	return Result{
+		Allowed:    allowed == 1,
+		Limit:      r.limit.Burst,
+		Remaining:  int(remaining),
+		RetryAfter: time.Duration(retryAfter) * time.Microsecond,
+		Reset:      time.Duration(reset) * time.Microsecond,
+	}, nil
+}
+
+// Middleware rejects requests over their limit with 429. Every counted
+// response tells the client its limit in X-RateLimit-Limit, the requests
+// left in X-RateLimit-Remaining and the seconds until its bucket is full
+// again in X-RateLimit-Reset. bucket returns the limiter a request is
+// counted by and its key there; a nil limiter lets the request through
+// uncounted. When the limiter fails, e.g. because Redis is down, requests
+// are let through rather than taking the API down with it.
+func Middleware(bucket func(*http.Request) (Limiter, string)) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			l, key := bucket(r)
+			if l == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+			result, err := l.Allow(r.Context(), key)
+			if err != nil {
+				log.Printf("Rate limiter failed, allowing request: %v", err)
+				next.ServeHTTP(w, r)
+				return
+			}
+			w.Header().Set("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+			w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+			w.Header().Set("X-RateLimit-Reset", seconds(result.Reset))
+			if !result.Allowed {
+				w.Header().Set("Retry-After", seconds(result.RetryAfter))
+				problem.Error(w, r, http.StatusTooManyRequests, "Too many requests, please try again later")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// seconds rounds d up to whole seconds for a header.
+func seconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
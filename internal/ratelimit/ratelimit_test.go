@@ -0,0 +1,116 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestMemoryAllowsBurstThenRate(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	m := NewMemory(Limit{Requests: 60, Period: time.Minute, Burst: 3})
+	m.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	for i, wantRemaining := range []int{2, 1, 0} {
+		result, _ := m.Allow(ctx, "a")
+		if !result.Allowed || result.Remaining != wantRemaining {
+			t.Fatalf("request %d = %+v, want allowed with %d remaining", i, result, wantRemaining)
+		}
+	}
+	result, _ := m.Allow(ctx, "a")
+	if result.Allowed || result.Limit != 3 || result.RetryAfter != time.Second || result.Reset != 3*time.Second {
+		t.Fatalf("4th request = %+v, want denied for 1s and full again in 3s", result)
+	}
+	if result, _ := m.Allow(ctx, "b"); !result.Allowed {
+		t.Error("keys should be limited independently")
+	}
+
+	now = now.Add(time.Second)
+	if result, _ := m.Allow(ctx, "a"); !result.Allowed || result.Remaining != 0 {
+		t.Errorf("after 1s = %+v, want one request allowed", result)
+	}
+	now = now.Add(time.Hour)
+	if result, _ := m.Allow(ctx, "a"); result.Remaining != 2 {
+		t.Errorf("after an idle hour = %+v, want a full burst", result)
+	}
+	if _, ok := m.tats["b"]; ok {
+		t.Error("idle keys should be pruned")
+	}
+}
+
+type fakeLimiter struct {
+	result Result
+	err    error
+}
+
+func (f fakeLimiter) Allow(context.Context, string) (Result, error) { return f.result, f.err }
+
+func TestMiddleware(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusNoContent) })
+
+	tests := []struct {
+		name       string
+		limiter    Limiter
+		wantStatus int
+		// wantHeaders are Retry-After and X-RateLimit-Limit, -Remaining
+		// and -Reset
+		wantHeaders [4]string
+	}{
+		{"allowed", fakeLimiter{result: Result{Allowed: true, Limit: 10, Remaining: 9, Reset: 6 * time.Second}},
+			http.StatusNoContent, [4]string{"", "10", "9", "6"}},
+		{"denied", fakeLimiter{result: Result{Limit: 10, RetryAfter: 1500 * time.Millisecond, Reset: time.Minute}},
+			http.StatusTooManyRequests, [4]string{"2", "10", "0", "60"}},
+		{"limiter down", fakeLimiter{err: errors.New("connection refused")}, http.StatusNoContent, [4]string{}},
+		{"unlimited", nil, http.StatusNoContent, [4]string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bucket := func(*http.Request) (Limiter, string) { return tt.limiter, "k" }
+			rec := httptest.NewRecorder()
+			Middleware(bucket)(ok).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/todos", nil))
+			h := rec.Header()
+			got := [4]string{h.Get("Retry-After"), h.Get("X-RateLimit-Limit"), h.Get("X-RateLimit-Remaining"), h.Get("X-RateLimit-Reset")}
+			if rec.Code != tt.wantStatus || got != tt.wantHeaders {
+				t.Errorf("got %d with headers %q, want %d with %q", rec.Code, got, tt.wantStatus, tt.wantHeaders)
+			}
+		})
+	}
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	for _, name := range []string{"REQUESTS", "PERIOD", "BURST"} {
+		t.Setenv("RATE_LIMIT_"+name, "")
+		t.Setenv("RATE_LIMIT_USER_"+name, "")
+	}
+	if cfg, err := ConfigFromEnv(); err != nil || cfg.Anonymous.Enabled() || cfg.Authenticated.Enabled() {
+		t.Errorf("ConfigFromEnv() = %+v, %v; want no limits", cfg, err)
+	}
+
+	t.Setenv("RATE_LIMIT_REQUESTS", "60")
+	anonymous := Limit{Requests: 60, Period: time.Minute, Burst: 60}
+	if cfg, err := ConfigFromEnv(); err != nil || cfg.Anonymous != anonymous || cfg.Authenticated != anonymous {
+		t.Errorf("ConfigFromEnv() = %+v, %v; want %+v for everyone", cfg, err, anonymous)
+	}
+
+	t.Setenv("RATE_LIMIT_USER_REQUESTS", "600")
+	t.Setenv("RATE_LIMIT_USER_BURST", "100")
+	authenticated := Limit{Requests: 600, Period: time.Minute, Burst: 100}
+	if cfg, err := ConfigFromEnv(); err != nil || cfg.Anonymous != anonymous || cfg.Authenticated != authenticated {
+		t.Errorf("ConfigFromEnv() = %+v, %v; want %+v for signed-in users", cfg, err, authenticated)
+	}
+
+	t.Setenv("RATE_LIMIT_USER_REQUESTS", "0")
+	if cfg, err := ConfigFromEnv(); err != nil || cfg.Authenticated.Enabled() {
+		t.Errorf("ConfigFromEnv() = %+v, %v; want signed-in users unlimited", cfg, err)
+	}
+
+	t.Setenv("RATE_LIMIT_USER_REQUESTS", "10")
+	t.Setenv("RATE_LIMIT_USER_PERIOD", "soon")
+	if _, err := ConfigFromEnv(); err == nil {
+		t.Error("expected an error for RATE_LIMIT_USER_PERIOD=soon")
+	}
+}
@@ -0,0 +1,106 @@
+// Package readonly implements a runtime read-only mode: while enabled,
+// write requests are rejected with 503 and background writers are paused,
+// but reads keep being served. It is meant for failovers and migrations of
+// the primary database.
+package readonly
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Tomlord1122/todo-backend/internal/pathprefix"
+	"github.com/Tomlord1122/todo-backend/internal/problem"
+)
+
+// Status describes the current mode.
+type Status struct {
+	Enabled bool       `json:"enabled"`
+	Reason  string     `json:"reason,omitempty"`
+	Since   *time.Time `json:"since,omitempty"`
+}
+
+// Mode is a process-wide read-only switch, safe for concurrent use.
+type Mode struct {
+	mu     sync.RWMutex
+	status Status
+}
+
+// New creates a Mode, initially enabled when READ_ONLY is true.
+func New() *Mode {
+	m := &Mode{}
+	if enabled, _ := strconv.ParseBool(os.Getenv("READ_ONLY")); enabled {
+		m.Set(true, "READ_ONLY is set")
+	}
+	return m
+}
+
+// Enabled reports whether writes are currently rejected.
+func (m *Mode) Enabled() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.status.Enabled
+}
+
+// Status returns the current mode.
+func (m *Mode) Status() Status {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.status
+}
+
+// Set turns read-only mode on or off.
+func (m *Mode) Set(enabled bool, reason string) Status {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if enabled == m.status.Enabled {
+		if enabled {
+			m.status.Reason = reason
+		}
+		return m.status
+	}
+	m.status = Status{Enabled: enabled}
+	if enabled {
+		now := time.Now().UTC()
+		m.status.Reason = reason
+		m.status.Since = &now
+	}
+	return m.status
+}
+
+// Middleware rejects requests that may write while read-only mode is on.
+// Safe methods always pass, as do paths under exemptPrefixes (e.g. the
+// admin API, so the mode can be switched off again).
+func (m *Mode) Middleware(exemptPrefixes ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !m.Enabled() || isSafeMethod(r.Method) || pathprefix.HasAny(r.URL.Path, exemptPrefixes) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			w.Header().Set("Retry-After", "60")
+			problem.Error(w, r, http.StatusServiceUnavailable, "The service is in read-only mode, please try again later")
+		})
+	}
+}
+
+// Guard wraps a background task so it is skipped while read-only mode is on.
+func (m *Mode) Guard(task func(ctx context.Context) error) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		if m.Enabled() {
+			return nil
+		}
+		return task(ctx)
+	}
+}
+
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,58 @@
+package readonly
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMiddleware(t *testing.T) {
+	m := &Mode{}
+	handler := m.Middleware("/admin")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	serve := func(method, path string) int {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(method, path, nil))
+		return rec.Code
+	}
+
+	if code := serve(http.MethodPost, "/todos"); code != http.StatusNoContent {
+		t.Errorf("writes should pass when disabled, got %d", code)
+	}
+
+	status := m.Set(true, "failover")
+	if !status.Enabled || status.Since == nil || status.Reason != "failover" {
+		t.Errorf("unexpected status %+v", status)
+	}
+	cases := map[string]int{
+		http.MethodGet + " /todos":                 http.StatusNoContent,
+		http.MethodHead + " /todos":                http.StatusNoContent,
+		http.MethodPost + " /todos":                http.StatusServiceUnavailable,
+		http.MethodDelete + " /todos/1":            http.StatusServiceUnavailable,
+		http.MethodPut + " /admin/read-only":       http.StatusNoContent,
+		http.MethodPatch + " /attachments/1/thumb": http.StatusServiceUnavailable,
+	}
+	for req, want := range cases {
+		method, path, _ := strings.Cut(req, " ")
+		if code := serve(method, path); code != want {
+			t.Errorf("%s: got %d, want %d", req, code, want)
+		}
+	}
+}
+
+func TestGuard(t *testing.T) {
+	m := &Mode{}
+	ran := 0
+	task := m.Guard(func(context.Context) error { ran++; return nil })
+
+	_ = task(context.Background())
+	m.Set(true, "migration")
+	_ = task(context.Background())
+	if ran != 1 {
+		t.Errorf("task ran %d times, want 1", ran)
+	}
+}
@@ -0,0 +1,234 @@
+package realtime
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Tomlord1122/todo-backend/internal/redis"
+)
+
+// Presence event types. Their data is a PresenceChange.
+const (
+	// PresenceJoined means a user opened the resource.
+	PresenceJoined = "presence.joined"
+	// PresenceTyping means a user started or stopped editing; State says
+	// which.
+	PresenceTyping = "presence.typing"
+	// PresenceLeft means a user closed the resource.
+	PresenceLeft = "presence.left"
+)
+
+// Presence states
+const (
+	StateViewing = "viewing"
+	StateEditing = "editing"
+)
+
+// PresenceChange is the data of presence events.
+type PresenceChange struct {
+	// Resource is what the user has open, e.g. "todo:7" or "list:3"
+	Resource string `json:"resource"`
+	UserID   uint   `json:"user_id"`
+	// State is the user's new state; empty once they left
+	State string `json:"state,omitempty"`
+}
+
+// Viewer is a user who has a resource open.
+type Viewer struct {
+	UserID    uint
+	State     string
+	ExpiresAt time.Time
+}
+
+// PresenceStore remembers who has which resource open. Entries expire
+// unless refreshed, so clients that vanish without saying goodbye drop
+// out on their own.
+type PresenceStore interface {
+	// Set records userID in state on resource until expires. It returns
+	// the user's previous state, empty if they didn't have it open.
+	Set(ctx context.Context, resource string, userID uint, state string, expires, now time.Time) (previous string, err error)
+	// Remove forgets userID on resource and returns their previous state.
+	Remove(ctx context.Context, resource string, userID uint, now time.Time) (previous string, err error)
+	// List returns the users who have resource open, ordered by user ID.
+	List(ctx context.Context, resource string, now time.Time) ([]Viewer, error)
+}
+
+func byUserID(a, b Viewer) int {
+	return cmp.Compare(a.UserID, b.UserID)
+}
+
+// MemoryPresence is a PresenceStore for a single instance. It is safe for
+// concurrent use.
+type MemoryPresence struct {
+	mu        sync.Mutex
+	resources map[string]map[uint]Viewer
+}
+
+// NewMemoryPresence creates an empty store.
+func NewMemoryPresence() *MemoryPresence {
+	return &MemoryPresence{resources: make(map[string]map[uint]Viewer)}
+}
+
+// live returns the unexpired entry of userID, dropping it if it expired.
+func (m *MemoryPresence) live(resource string, userID uint, now time.Time) (Viewer, bool) {
+	viewer, ok := m.resources[resource][userID]
+	if ok && !viewer.ExpiresAt.After(now) {
+		m.drop(resource, userID)
+		return Viewer{}, false
+	}
+	return viewer, ok
+}
+
+func (m *MemoryPresence) drop(resource string, userID uint) {
+	delete(m.resources[resource], userID)
+	if len(m.resources[resource]) == 0 {
+		delete(m.resources, resource)
+	}
+}
+
+// Set implements PresenceStore.
+func (m *MemoryPresence) Set(ctx context.Context, resource string, userID uint, state string, expires, now time.Time) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	previous, _ := m.live(resource, userID, now)
+	if m.resources[resource] == nil {
+		m.resources[resource] = make(map[uint]Viewer)
+	}
+	m.resources[resource][userID] = Viewer{UserID: userID, State: state, ExpiresAt: expires}
+	return previous.State, nil
+}
+
+// Remove implements PresenceStore.
+func (m *MemoryPresence) Remove(ctx context.Context, resource string, userID uint, now time.Time) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	previous, _ := m.live(resource, userID, now)
+	m.drop(resource, userID)
+	return previous.State, nil
+}
+
+// List implements PresenceStore.
+func (m *MemoryPresence) List(ctx context.Context, resource string, now time.Time) ([]Viewer, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var viewers []Viewer
+	for userID := range m.resources[resource] {
+		if viewer, ok := m.live(resource, userID, now); ok {
+			viewers = append(viewers, viewer)
+		}
+	}
+	slices.SortFunc(viewers, byUserID)
+	return viewers, nil
+}
+
+// presenceSetScript stores a viewer as "state:expiresMillis" in the hash of
+// the resource and returns the previous value. The hash lives as long as
+// its longest-lived viewer.
+var presenceSetScript = redis.NewScript(`
+local previous = redis.call('HGET', KEYS[1], ARGV[1])
+redis.call('HSET', KEYS[1], ARGV[1], ARGV[2])
+local ttl = tonumber(ARGV[3])
+if redis.call('PTTL', KEYS[1]) < ttl then
+  redis.call('PEXPIRE', KEYS[1], ttl)
+end
+return previous or ''
+`)
+
+// presenceRemoveScript deletes a viewer and returns their previous value.
+var presenceRemoveScript = redis.NewScript(`
+local previous = redis.call('HGET', KEYS[1], ARGV[1])
+redis.call('HDEL', KEYS[1], ARGV[1])
+return previous or ''
+`)
+
+// RedisPresence is a PresenceStore shared by every instance using the
+// Redis server, so users see each other whichever replica they reach.
+type RedisPresence struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewRedisPresence creates a Redis-backed store. Keys are stored under
+// prefix.
+func NewRedisPresence(client *redis.Client, prefix string) *RedisPresence {
+	return &RedisPresence{client: client, prefix: prefix}
+}
+
+// presenceField is the hash field of a viewer.
+func presenceField(userID uint) string {
+	return strconv.FormatUint(uint64(userID), 10)
+}
+
+// parseViewer decodes a hash value written by presenceSetScript. ok is
+// false for missing, malformed and expired entries.
+func parseViewer(userID uint, value string, now time.Time) (Viewer, bool) {
+	state, millis, found := strings.Cut(value, ":")
+	expires, err := strconv.ParseInt(millis, 10, 64)
+	if !found || err != nil || !time.UnixMilli(expires).After(now) {
+		return Viewer{}, false
+	}
+	return Viewer{UserID: userID, State: state, ExpiresAt: time.UnixMilli(expires)}, true
+}
+
+// Set implements PresenceStore.
+func (r *RedisPresence) Set(ctx context.Context, resource string, userID uint, state string, expires, now time.Time) (string, error) {
+	value := state + ":" + strconv.FormatInt(expires.UnixMilli(), 10)
+	ttl := max(expires.Sub(now).Milliseconds(), 1)
+	reply, err := presenceSetScript.Run(ctx, r.client, []string{r.prefix + resource}, presenceField(userID), value, ttl)
+	if err != nil {
+		return "", fmt.Errorf("storing presence: %w", err)
+	}
+	previous, _ := parseViewer(userID, fmt.Sprint(reply), now)
+	return previous.State, nil
+}
+
+// Remove implements PresenceStore.
+func (r *RedisPresence) Remove(ctx context.Context, resource string, userID uint, now time.Time) (string, error) {
+	reply, err := presenceRemoveScript.Run(ctx, r.client, []string{r.prefix + resource}, presenceField(userID))
+	if err != nil {
+		return "", fmt.Errorf("removing presence: %w", err)
+	}
+	previous, _ := parseViewer(userID, fmt.Sprint(reply), now)
+	return previous.State, nil
+}
+
+// List implements PresenceStore. Expired viewers are cleaned up on the
+// way.
+func (r *RedisPresence) List(ctx context.Context, resource string, now time.Time) ([]Viewer, error) {
+	key := r.prefix + resource
+	reply, err := r.client.Do(ctx, "HGETALL", key)
+	if err != nil {
+		return nil, fmt.Errorf("listing presence: %w", err)
+	}
+	fields, _ := reply.([]any)
+	var viewers []Viewer
+	expired := []any{"HDEL", key}
+	for i := 0; i+1 < len(fields); i += 2 {
+		field, _ := fields[i].(string)
+		value, _ := fields[i+1].(string)
+		userID, err := strconv.ParseUint(field, 10, 32)
+		if err != nil {
+			continue
+		}
+		if viewer, ok := parseViewer(uint(userID), value, now); ok {
+			viewers = append(viewers, viewer)
+		} else {
+			expired = append(expired, field)
+		}
+	}
+	if len(expired) > 2 {
+		if _, err := r.client.Do(ctx, expired...); err != nil {
+			// Harmless: they're filtered out again next time
+			log.Printf("Error removing expired presence of %s: %v", resource, err)
+		}
+	}
+	slices.SortFunc(viewers, byUserID)
+	return viewers, nil
+}
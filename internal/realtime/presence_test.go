@@ -0,0 +1,57 @@
+package realtime
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryPresence(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryPresence()
+	now := time.Unix(1000, 0)
+
+	if previous, _ := store.Set(ctx, "todo:1", 2, StateViewing, now.Add(30*time.Second), now); previous != "" {
+		t.Errorf("first Set: previous = %q, want empty", previous)
+	}
+	store.Set(ctx, "todo:1", 1, StateViewing, now.Add(10*time.Second), now)
+	if previous, _ := store.Set(ctx, "todo:1", 2, StateEditing, now.Add(30*time.Second), now); previous != StateViewing {
+		t.Errorf("refresh: previous = %q, want viewing", previous)
+	}
+
+	viewers, _ := store.List(ctx, "todo:1", now)
+	if len(viewers) != 2 || viewers[0].UserID != 1 || viewers[1].State != StateEditing {
+		t.Errorf("List = %+v", viewers)
+	}
+	if viewers, _ := store.List(ctx, "todo:2", now); len(viewers) != 0 {
+		t.Errorf("other resource: List = %+v", viewers)
+	}
+
+	// User 1 stopped sending heartbeats
+	later := now.Add(20 * time.Second)
+	if viewers, _ := store.List(ctx, "todo:1", later); len(viewers) != 1 || viewers[0].UserID != 2 {
+		t.Errorf("after expiry: List = %+v", viewers)
+	}
+	if previous, _ := store.Set(ctx, "todo:1", 1, StateViewing, later.Add(30*time.Second), later); previous != "" {
+		t.Errorf("rejoining after expiry: previous = %q, want empty", previous)
+	}
+
+	if previous, _ := store.Remove(ctx, "todo:1", 2, later); previous != StateEditing {
+		t.Errorf("Remove: previous = %q, want editing", previous)
+	}
+	if previous, _ := store.Remove(ctx, "todo:1", 2, later); previous != "" {
+		t.Errorf("second Remove: previous = %q, want empty", previous)
+	}
+}
+
+func TestParseViewer(t *testing.T) {
+	now := time.UnixMilli(5000)
+	if viewer, ok := parseViewer(3, "editing:6000", now); !ok || viewer.State != StateEditing || !viewer.ExpiresAt.Equal(time.UnixMilli(6000)) {
+		t.Errorf("parseViewer = %+v, %v", viewer, ok)
+	}
+	for _, value := range []string{"", "editing", "editing:abc", "viewing:5000"} {
+		if _, ok := parseViewer(3, value, now); ok {
+			t.Errorf("parseViewer(%q) should fail", value)
+		}
+	}
+}
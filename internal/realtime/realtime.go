@@ -0,0 +1,247 @@
+// Package realtime delivers change events to the clients of the user they
+// belong to. A Hub fans events out within one instance; with several
+// replicas a RedisBridge carries every event to every instance's hub, so
+// a client connected to one replica sees writes handled by another.
+// Events are numbered, and hubs keep each user's latest ones so clients
+// can resume after reconnecting.
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/Tomlord1122/todo-backend/internal/redis"
+)
+
+// Event types
+const (
+	TodoCreated = "todo.created"
+	TodoUpdated = "todo.updated"
+	TodoDeleted = "todo.deleted"
+)
+
+// subscriptionBuffer is how many events a subscriber may fall behind
+// before further events are dropped for it.
+const subscriptionBuffer = 64
+
+// historySize is how many of a user's latest events a hub keeps for
+// clients resuming with SubscribeSince.
+const historySize = 256
+
+// Event is a change to one of a user's resources. Data is the resource as
+// the API returns it, or just its ID for deletions. ID is assigned when
+// the event is published and increases with every event.
+type Event struct {
+	ID     uint64          `json:"id,omitempty"`
+	Type   string          `json:"type"`
+	UserID uint            `json:"user_id"`
+	Data   json.RawMessage `json:"data"`
+}
+
+// Publisher sends events to the subscribers of their user, wherever they
+// are connected. Publishing never fails the write that caused the event.
+type Publisher interface {
+	Publish(ctx context.Context, event Event)
+}
+
+// Subscription receives the events of one user.
+type Subscription struct {
+	hub    *Hub
+	userID uint
+	events chan Event
+}
+
+// Events returns the channel events are delivered on. It is closed when
+// the subscription is closed.
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Close ends the subscription.
+func (s *Subscription) Close() {
+	s.hub.unsubscribe(s)
+}
+
+// Hub delivers events to the subscribers connected to this instance. It
+// is safe for concurrent use.
+type Hub struct {
+	mu      sync.Mutex
+	subs    map[uint]map[*Subscription]struct{}
+	lastID  uint64
+	history map[uint][]Event
+}
+
+// NewHub creates a hub without subscribers.
+func NewHub() *Hub {
+	return &Hub{subs: make(map[uint]map[*Subscription]struct{}), history: make(map[uint][]Event)}
+}
+
+// Subscribe starts receiving the events of userID.
+func (h *Hub) Subscribe(userID uint) *Subscription {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.subscribe(userID)
+}
+
+// SubscribeSince is Subscribe for a client that last saw the event with
+// ID lastID. It also returns the events of userID published after that
+// one, oldest first, without gaps or duplicates between them and the
+// subscription. When the hub no longer has that event, there is nothing
+// to resume from and no events are returned.
+func (h *Hub) SubscribeSince(userID uint, lastID uint64) (*Subscription, []Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var missed []Event
+	history := h.history[userID]
+	// IDs follow publishing order, but events may arrive slightly out of
+	// order through Redis, so resume from where the event was delivered
+	if i := slices.IndexFunc(history, func(e Event) bool { return e.ID == lastID }); i >= 0 {
+		missed = slices.Clone(history[i+1:])
+	}
+	return h.subscribe(userID), missed
+}
+
+func (h *Hub) subscribe(userID uint) *Subscription {
+	sub := &Subscription{hub: h, userID: userID, events: make(chan Event, subscriptionBuffer)}
+	if h.subs[userID] == nil {
+		h.subs[userID] = make(map[*Subscription]struct{})
+	}
+	h.subs[userID][sub] = struct{}{}
+	return sub
+}
+
+func (h *Hub) unsubscribe(sub *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.subs[sub.userID][sub]; !ok {
+		return
+	}
+	delete(h.subs[sub.userID], sub)
+	if len(h.subs[sub.userID]) == 0 {
+		delete(h.subs, sub.userID)
+	}
+	close(sub.events)
+}
+
+// Publish implements Publisher for a single instance. Events without an
+// ID are numbered after the latest one. Subscribers that don't keep up
+// miss events rather than blocking the publisher.
+func (h *Hub) Publish(ctx context.Context, event Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if event.ID == 0 {
+		event.ID = h.lastID + 1
+	}
+	h.lastID = max(h.lastID, event.ID)
+	history := append(h.history[event.UserID], event)
+	if len(history) > historySize {
+		history = slices.Delete(history, 0, len(history)-historySize)
+	}
+	h.history[event.UserID] = history
+	for sub := range h.subs[event.UserID] {
+		select {
+		case sub.events <- event:
+		default:
+			log.Printf("Realtime subscriber of user %d is too slow, dropping %s event", event.UserID, event.Type)
+		}
+	}
+}
+
+// RedisBridge publishes events through a Redis channel that every
+// instance subscribes to, and delivers what arrives to the local hub.
+type RedisBridge struct {
+	client  *redis.Client
+	channel string
+	hub     *Hub
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewRedisBridge creates a bridge between hub and channel. Call Start to
+// begin receiving.
+func NewRedisBridge(client *redis.Client, channel string, hub *Hub) *RedisBridge {
+	return &RedisBridge{client: client, channel: channel, hub: hub}
+}
+
+// Publish implements Publisher. The event reaches this instance's
+// subscribers through the channel too. Events are numbered by a counter
+// in Redis, so they have the same IDs on every instance and clients can
+// resume on whichever they reconnect to. When Redis is unreachable they
+// are delivered locally only.
+func (b *RedisBridge) Publish(ctx context.Context, event Event) {
+	id, err := b.client.Do(ctx, "INCR", b.channel+":id")
+	if err != nil {
+		log.Printf("Error numbering %s event in Redis, delivering locally only: %v", event.Type, err)
+		b.hub.Publish(ctx, event)
+		return
+	}
+	if n, ok := id.(int64); ok {
+		event.ID = uint64(n)
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("Error encoding %s event: %v", event.Type, err)
+		return
+	}
+	if _, err := b.client.Do(ctx, "PUBLISH", b.channel, payload); err != nil {
+		log.Printf("Error publishing %s event to Redis, delivering locally only: %v", event.Type, err)
+		b.hub.Publish(ctx, event)
+	}
+}
+
+// Start subscribes to the channel in the background, reconnecting with
+// backoff when the connection drops.
+func (b *RedisBridge) Start(ctx context.Context) {
+	ctx, b.cancel = context.WithCancel(ctx)
+	b.done = make(chan struct{})
+	go func() {
+		defer close(b.done)
+		backoff := time.Second
+		for {
+			started := time.Now()
+			err := b.client.Subscribe(ctx, b.channel, b.deliver)
+			if ctx.Err() != nil {
+				return
+			}
+			if time.Since(started) > time.Minute {
+				backoff = time.Second
+			}
+			log.Printf("Realtime subscription to Redis lost, retrying in %s: %v", backoff, err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(backoff):
+			}
+			backoff = min(2*backoff, 30*time.Second)
+		}
+	}()
+}
+
+func (b *RedisBridge) deliver(payload string) {
+	var event Event
+	if err := json.Unmarshal([]byte(payload), &event); err != nil {
+		log.Printf("Ignoring malformed realtime event from Redis: %v", err)
+		return
+	}
+	b.hub.Publish(context.Background(), event)
+}
+
+// Stop ends the subscription and waits for it to finish, or until ctx
+// expires.
+func (b *RedisBridge) Stop(ctx context.Context) error {
+	if b.cancel == nil {
+		return nil
+	}
+	b.cancel()
+	select {
+	case <-b.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,90 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+func TestHubDeliversPerUser(t *testing.T) {
+	hub := NewHub()
+	alice := hub.Subscribe(1)
+	aliceTab := hub.Subscribe(1)
+	bob := hub.Subscribe(2)
+	defer bob.Close()
+
+	hub.Publish(context.Background(), Event{Type: TodoCreated, UserID: 1, Data: json.RawMessage(`{"id":7}`)})
+
+	for _, sub := range []*Subscription{alice, aliceTab} {
+		select {
+		case event := <-sub.Events():
+			if event.Type != TodoCreated || string(event.Data) != `{"id":7}` {
+				t.Errorf("got %+v", event)
+			}
+		default:
+			t.Error("every subscription of the user should get the event")
+		}
+	}
+	select {
+	case event := <-bob.Events():
+		t.Errorf("another user got %+v", event)
+	default:
+	}
+
+	alice.Close()
+	alice.Close() // closing twice is harmless
+	if _, open := <-alice.Events(); open {
+		t.Error("the channel should be closed")
+	}
+	hub.Publish(context.Background(), Event{Type: TodoDeleted, UserID: 1})
+	if len(aliceTab.Events()) != 1 {
+		t.Error("the remaining subscription should still get events")
+	}
+}
+
+func TestHubDropsForSlowSubscribers(t *testing.T) {
+	hub := NewHub()
+	sub := hub.Subscribe(1)
+	for range subscriptionBuffer + 10 {
+		hub.Publish(context.Background(), Event{Type: TodoUpdated, UserID: 1})
+	}
+	if len(sub.Events()) != subscriptionBuffer {
+		t.Errorf("buffered %d events, want %d", len(sub.Events()), subscriptionBuffer)
+	}
+}
+
+func TestHubResumesAfterLastEvent(t *testing.T) {
+	hub := NewHub()
+	for _, event := range []Event{{Type: TodoCreated, UserID: 1}, {Type: TodoCreated, UserID: 2}, {Type: TodoUpdated, UserID: 1}, {Type: TodoDeleted, UserID: 1}} {
+		hub.Publish(context.Background(), event)
+	}
+
+	sub, missed := hub.SubscribeSince(1, 1)
+	defer sub.Close()
+	if len(missed) != 2 || missed[0].ID != 3 || missed[0].Type != TodoUpdated || missed[1].ID != 4 {
+		t.Errorf("missed = %+v, want events 3 and 4", missed)
+	}
+	hub.Publish(context.Background(), Event{Type: TodoCreated, UserID: 1})
+	if event := <-sub.Events(); event.ID != 5 {
+		t.Errorf("next event = %+v, want 5", event)
+	}
+
+	// Events from Redis keep their IDs and later local ones follow them
+	hub.Publish(context.Background(), Event{ID: 42, Type: TodoCreated, UserID: 1})
+	hub.Publish(context.Background(), Event{Type: TodoCreated, UserID: 1})
+	if _, missed := hub.SubscribeSince(1, 42); len(missed) != 1 || missed[0].ID != 43 {
+		t.Errorf("missed after 42 = %+v, want 43", missed)
+	}
+
+	for _, lastID := range []uint64{2, 99} {
+		if _, missed := hub.SubscribeSince(1, lastID); missed != nil {
+			t.Errorf("resuming from another user's or an unknown event %d = %+v, want nothing", lastID, missed)
+		}
+	}
+	for range historySize {
+		hub.Publish(context.Background(), Event{Type: TodoUpdated, UserID: 1})
+	}
+	if _, missed := hub.SubscribeSince(1, 3); missed != nil {
+		t.Errorf("resuming from an evicted event = %d events, want nothing", len(missed))
+	}
+}
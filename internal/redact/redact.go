@@ -0,0 +1,97 @@
+// Package redact removes personal data and secrets from log lines: todo
+// titles and descriptions, email addresses and tokens. It works on
+// formatted text, so it applies to any log output it is put in front of.
+package redact
+
+import (
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Placeholder replaces redacted values.
+const Placeholder = "[redacted]"
+
+// sensitiveKeys are field and query parameter names whose values are
+// always redacted.
+const sensitiveKeys = `title|description|subject|email|token|secret|password|code|q|access_token|refresh_token`
+
+var (
+	// "title":"Buy milk" in JSON
+	jsonField = regexp.MustCompile(`("(?:` + sensitiveKeys + `)"\s*:\s*)"(?:[^"\\]|\\.)*"`)
+	// title="Buy milk" and subject="..." in logfmt-style lines
+	quotedField = regexp.MustCompile(`\b((?:` + sensitiveKeys + `)=)"(?:[^"\\]|\\.)*"`)
+	// ?q=milk&token=abc in URLs, and title=milk in logfmt-style lines
+	plainField = regexp.MustCompile(`\b((?:` + sensitiveKeys + `)=)[^\s&"]+`)
+	// Tokens in URL paths: /feeds/{token}/..., /hooks/inbound/{token}, but
+	// not the /feeds/tokens route
+	tokenPath = regexp.MustCompile(`(/(?:feeds|hooks/inbound)/)([^/\s?"]+)`)
+	// Authorization: Bearer ...
+	bearer = regexp.MustCompile(`(?i)\b(bearer\s+)[^\s"]+`)
+	email  = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+	// Long opaque strings like API keys and generated tokens
+	longToken = regexp.MustCompile(`\b[A-Za-z0-9_-]{32,}\b`)
+	// trace_id=... and request_id=..., or "trace_id":"..." in JSON, hold
+	// IDs that may look like tokens but are kept to find a log line's
+	// trace and request
+	correlationID = regexp.MustCompile(`(?:\b(?:trace|request)_id=|"(?:trace|request)_id"\s*:\s*")[^\s"]+`)
+)
+
+// Enabled reads LOG_REDACT; redaction is on unless it is set to false,
+// which is meant for local debugging only.
+func Enabled() bool {
+	enabled, err := strconv.ParseBool(os.Getenv("LOG_REDACT"))
+	return err != nil || enabled
+}
+
+// String redacts s.
+func String(s string) string {
+	s = jsonField.ReplaceAllString(s, `$1"`+Placeholder+`"`)
+	s = quotedField.ReplaceAllString(s, `$1"`+Placeholder+`"`)
+	s = plainField.ReplaceAllString(s, `${1}`+Placeholder)
+	s = tokenPath.ReplaceAllStringFunc(s, func(m string) string {
+		parts := tokenPath.FindStringSubmatch(m)
+		if parts[2] == "tokens" {
+			return m
+		}
+		return parts[1] + Placeholder
+	})
+	s = bearer.ReplaceAllString(s, `${1}`+Placeholder)
+	s = email.ReplaceAllString(s, Placeholder)
+	return redactLongTokens(s)
+}
+
+// redactLongTokens redacts long tokens anywhere but in trace and request
+// IDs.
+func redactLongTokens(s string) string {
+	var b strings.Builder
+	last := 0
+	for _, m := range correlationID.FindAllStringIndex(s, -1) {
+		b.WriteString(longToken.ReplaceAllString(s[last:m[0]], Placeholder))
+		b.WriteString(s[m[0]:m[1]])
+		last = m[1]
+	}
+	b.WriteString(longToken.ReplaceAllString(s[last:], Placeholder))
+	return b.String()
+}
+
+// Writer redacts everything written to it before passing it on. Loggers
+// write whole lines at once, which is what it relies on.
+type Writer struct {
+	w io.Writer
+}
+
+// NewWriter wraps w.
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w}
+}
+
+// Write implements io.Writer.
+func (w *Writer) Write(p []byte) (int, error) {
+	if _, err := io.WriteString(w.w, String(string(p))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
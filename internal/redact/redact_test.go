@@ -0,0 +1,65 @@
+package redact
+
+import (
+	"bytes"
+	"log"
+	"strings"
+	"testing"
+)
+
+func TestString(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{
+			`"GET http://localhost/todos/search?q=divorce+lawyer&user_id=1 HTTP/1.1" - 200`,
+			`"GET http://localhost/todos/search?q=[redacted]&user_id=1 HTTP/1.1" - 200`,
+		},
+		{
+			`"GET http://localhost/feeds/Zm9vYmFy/today.xml HTTP/1.1"`,
+			`"GET http://localhost/feeds/[redacted]/today.xml HTTP/1.1"`,
+		},
+		{`"POST http://localhost/feeds/tokens HTTP/1.1"`, `"POST http://localhost/feeds/tokens HTTP/1.1"`},
+		{`POST /hooks/inbound/abc123 failed`, `POST /hooks/inbound/[redacted] failed`},
+		{
+			`body {"title":"Call \"Dr\" Smith","priority":"high","description":"re: results"}`,
+			`body {"title":"[redacted]","priority":"high","description":"[redacted]"}`,
+		},
+		{`notify[log] to=jane@example.com subject="Overdue: pay rent" attachments=0`, `notify[log] to=[redacted] subject="[redacted]" attachments=0`},
+		{`sending to jane.doe+todo@mail.example.org failed`, `sending to [redacted] failed`},
+		{`Authorization: Bearer eyJhbGciOi.abc`, `Authorization: Bearer [redacted]`},
+		{`refresh with 1//0gAbCdEfGhIjKlMnOpQrStUvWxYz012345`, `refresh with 1//[redacted]`},
+		{`Error fetching todo 42: record not found`, `Error fetching todo 42: record not found`},
+		{
+			`level=INFO msg=request key=0123456789abcdef0123456789abcdef trace_id=4bf92f3577b34da6a3ce929d0e0e4736`,
+			`level=INFO msg=request key=[redacted] trace_id=4bf92f3577b34da6a3ce929d0e0e4736`,
+		},
+		{
+			`{"msg":"request","request_id":"0a1b2c3d4e5f60718293a4b5c6d7e8f9","trace_id":"4bf92f3577b34da6a3ce929d0e0e4736"}`,
+			`{"msg":"request","request_id":"0a1b2c3d4e5f60718293a4b5c6d7e8f9","trace_id":"4bf92f3577b34da6a3ce929d0e0e4736"}`,
+		},
+	}
+	for _, tt := range tests {
+		if got := String(tt.in); got != tt.want {
+			t.Errorf("String(%q)\n got %q\nwant %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestWriter(t *testing.T) {
+	var buf bytes.Buffer
+	logger := log.New(NewWriter(&buf), "", 0)
+	logger.Printf("reminder for %s", "jane@example.com")
+	if got := strings.TrimSpace(buf.String()); got != "reminder for [redacted]" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestEnabled(t *testing.T) {
+	for value, want := range map[string]bool{"": true, "true": true, "false": false, "0": false, "nonsense": true} {
+		t.Setenv("LOG_REDACT", value)
+		if got := Enabled(); got != want {
+			t.Errorf("LOG_REDACT=%q: Enabled() = %v, want %v", value, got, want)
+		}
+	}
+}
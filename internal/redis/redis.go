@@ -0,0 +1,340 @@
+// Package redis is a small Redis client speaking RESP2 over a pooled set
+// of connections. It implements only what the application needs: sending
+// commands and running Lua scripts.
+package redis
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config holds the address of a Redis server.
+type Config struct {
+	// Network is "tcp" or "unix".
+	Network  string
+	Address  string
+	Password string
+	DB       int
+	// PoolSize is how many idle connections are kept.
+	PoolSize int
+	// Timeout bounds each command when the context has no earlier deadline.
+	Timeout time.Duration
+}
+
+// ConfigFromEnv reads REDIS_URL, either "redis://[:password@]host:port[/db]"
+// or "unix:///path/to/redis.sock". ok is false when it is unset, meaning
+// Redis-backed features fall back to their single-instance versions.
+func ConfigFromEnv() (cfg Config, ok bool, err error) {
+	raw := os.Getenv("REDIS_URL")
+	if raw == "" {
+		return cfg, false, nil
+	}
+	cfg, err = ParseURL(raw)
+	if err != nil {
+		return cfg, false, err
+	}
+	return cfg, true, nil
+}
+
+// ParseURL parses a redis:// or unix:// URL.
+func ParseURL(raw string) (Config, error) {
+	cfg := Config{PoolSize: 10, Timeout: 5 * time.Second}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return cfg, fmt.Errorf("invalid REDIS_URL: %w", err)
+	}
+	if u.User != nil {
+		cfg.Password, _ = u.User.Password()
+	}
+	switch u.Scheme {
+	case "redis":
+		cfg.Network, cfg.Address = "tcp", u.Host
+		if u.Port() == "" {
+			cfg.Address = net.JoinHostPort(u.Hostname(), "6379")
+		}
+		if db := strings.TrimPrefix(u.Path, "/"); db != "" {
+			if cfg.DB, err = strconv.Atoi(db); err != nil || cfg.DB < 0 {
+				return cfg, fmt.Errorf("invalid REDIS_URL database %q", db)
+			}
+		}
+	case "unix":
+		cfg.Network, cfg.Address = "unix", u.Path
+	default:
+		return cfg, fmt.Errorf("invalid REDIS_URL %q, expected redis://host:port/db or unix:///path", raw)
+	}
+	if cfg.Address == "" || cfg.Address == ":6379" {
+		return cfg, fmt.Errorf("invalid REDIS_URL %q: missing address", raw)
+	}
+	return cfg, nil
+}
+
+// Error is an error reply from the server, e.g. "WRONGTYPE ...".
+type Error string
+
+func (e Error) Error() string { return string(e) }
+
+// Client sends commands to one Redis server. It is safe for concurrent use.
+type Client struct {
+	cfg    Config
+	dialer net.Dialer
+	idle   chan *conn
+}
+
+// NewClient creates a client for the configured server. Connections are
+// opened on demand.
+func NewClient(cfg Config) *Client {
+	if cfg.PoolSize <= 0 {
+		cfg.PoolSize = 10
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 5 * time.Second
+	}
+	return &Client{cfg: cfg, dialer: net.Dialer{Timeout: 5 * time.Second}, idle: make(chan *conn, cfg.PoolSize)}
+}
+
+type conn struct {
+	net.Conn
+	r *bufio.Reader
+	w *bufio.Writer
+}
+
+// Do sends a command and returns its reply: string for simple and bulk
+// strings, int64 for integers, []any for arrays and nil for null replies.
+// Error replies are returned as an Error.
+func (c *Client) Do(ctx context.Context, args ...any) (any, error) {
+	cn, err := c.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	reply, err := cn.do(ctx, c.cfg.Timeout, args)
+	var replyErr Error
+	if err != nil && !errors.As(err, &replyErr) {
+		// The connection may be out of sync with the server
+		cn.Close()
+		return nil, err
+	}
+	c.put(cn)
+	return reply, err
+}
+
+// Ping checks that the server answers.
+func (c *Client) Ping(ctx context.Context) error {
+	_, err := c.Do(ctx, "PING")
+	return err
+}
+
+// Close closes the idle connections.
+func (c *Client) Close() error {
+	for {
+		select {
+		case cn := <-c.idle:
+			cn.Close()
+		default:
+			return nil
+		}
+	}
+}
+
+func (c *Client) get(ctx context.Context) (*conn, error) {
+	select {
+	case cn := <-c.idle:
+		return cn, nil
+	default:
+	}
+	nc, err := c.dialer.DialContext(ctx, c.cfg.Network, c.cfg.Address)
+	if err != nil {
+		return nil, fmt.Errorf("connecting to redis: %w", err)
+	}
+	cn := &conn{Conn: nc, r: bufio.NewReader(nc), w: bufio.NewWriter(nc)}
+	if c.cfg.Password != "" {
+		if _, err := cn.do(ctx, c.cfg.Timeout, []any{"AUTH", c.cfg.Password}); err != nil {
+			cn.Close()
+			return nil, fmt.Errorf("authenticating to redis: %w", err)
+		}
+	}
+	if c.cfg.DB != 0 {
+		if _, err := cn.do(ctx, c.cfg.Timeout, []any{"SELECT", c.cfg.DB}); err != nil {
+			cn.Close()
+			return nil, fmt.Errorf("selecting redis database: %w", err)
+		}
+	}
+	return cn, nil
+}
+
+func (c *Client) put(cn *conn) {
+	select {
+	case c.idle <- cn:
+	default:
+		cn.Close()
+	}
+}
+
+func (cn *conn) do(ctx context.Context, timeout time.Duration, args []any) (any, error) {
+	deadline := time.Now().Add(timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	_ = cn.SetDeadline(deadline)
+	if err := writeCommand(cn.w, args); err != nil {
+		return nil, err
+	}
+	if err := cn.w.Flush(); err != nil {
+		return nil, err
+	}
+	return readReply(cn.r)
+}
+
+// writeCommand encodes args as a RESP array of bulk strings.
+func writeCommand(w *bufio.Writer, args []any) error {
+	fmt.Fprintf(w, "*%d\r\n", len(args))
+	for _, arg := range args {
+		var s string
+		switch v := arg.(type) {
+		case string:
+			s = v
+		case []byte:
+			s = string(v)
+		case int:
+			s = strconv.Itoa(v)
+		case int64:
+			s = strconv.FormatInt(v, 10)
+		case float64:
+			s = strconv.FormatFloat(v, 'f', -1, 64)
+		default:
+			return fmt.Errorf("redis: unsupported argument type %T", arg)
+		}
+		fmt.Fprintf(w, "$%d\r\n%s\r\n", len(s), s)
+	}
+	return nil
+}
+
+// readReply decodes one RESP2 reply.
+func readReply(r *bufio.Reader) (any, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 || !strings.HasSuffix(line, "\r\n") {
+		return nil, fmt.Errorf("redis: malformed reply %q", line)
+	}
+	kind, body := line[0], line[1:len(line)-2]
+	switch kind {
+	case '+':
+		return body, nil
+	case '-':
+		return nil, Error(body)
+	case ':':
+		return strconv.ParseInt(body, 10, 64)
+	case '$':
+		n, err := strconv.Atoi(body)
+		if err != nil {
+			return nil, fmt.Errorf("redis: malformed bulk length %q", body)
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		return string(buf[:n]), nil
+	case '*':
+		n, err := strconv.Atoi(body)
+		if err != nil {
+			return nil, fmt.Errorf("redis: malformed array length %q", body)
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		items := make([]any, n)
+		for i := range items {
+			item, err := readReply(r)
+			var replyErr Error
+			if errors.As(err, &replyErr) {
+				// Errors inside arrays (e.g. from EXEC) are values
+				items[i] = replyErr
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			items[i] = item
+		}
+		return items, nil
+	}
+	return nil, fmt.Errorf("redis: unknown reply type %q", kind)
+}
+
+// Script is a Lua script run with EVALSHA, falling back to EVAL the first
+// time a server hasn't cached it yet.
+type Script struct {
+	src  string
+	hash string
+}
+
+// NewScript creates a script.
+func NewScript(src string) *Script {
+	sum := sha1.Sum([]byte(src))
+	return &Script{src: src, hash: hex.EncodeToString(sum[:])}
+}
+
+// Run runs the script with the given keys and arguments.
+func (s *Script) Run(ctx context.Context, c *Client, keys []string, args ...any) (any, error) {
+	params := make([]any, 0, 3+len(keys)+len(args))
+	params = append(params, "EVALSHA", s.hash, len(keys))
+	for _, key := range keys {
+		params = append(params, key)
+	}
+	params = append(params, args...)
+	reply, err := c.Do(ctx, params...)
+	if err != nil && strings.HasPrefix(err.Error(), "NOSCRIPT") {
+		params[0], params[1] = "EVAL", s.src
+		return c.Do(ctx, params...)
+	}
+	return reply, err
+}
+
+// Subscribe listens on channel on a dedicated connection and calls handle
+// with every message, until ctx is canceled or the connection fails. It
+// always returns a non-nil error; callers reconnect by calling it again.
+// Messages published while no subscription is active are not delivered.
+func (c *Client) Subscribe(ctx context.Context, channel string, handle func(payload string)) error {
+	cn, err := c.get(ctx)
+	if err != nil {
+		return err
+	}
+	defer cn.Close()
+	stop := context.AfterFunc(ctx, func() { cn.Close() })
+	defer stop()
+
+	if _, err := cn.do(ctx, c.cfg.Timeout, []any{"SUBSCRIBE", channel}); err != nil {
+		return fmt.Errorf("subscribing to %s: %w", channel, err)
+	}
+	// Messages arrive whenever they are published
+	_ = cn.SetDeadline(time.Time{})
+	for {
+		reply, err := readReply(cn.r)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return fmt.Errorf("reading from %s: %w", channel, err)
+		}
+		if msg, ok := reply.([]any); ok && len(msg) == 3 && msg[0] == "message" {
+			if payload, ok := msg[2].(string); ok {
+				handle(payload)
+			}
+		}
+	}
+}
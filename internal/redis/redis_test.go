@@ -0,0 +1,127 @@
+package redis
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseURL(t *testing.T) {
+	cfg, err := ParseURL("redis://:s3cret@cache:6380/2")
+	if err != nil {
+		t.Fatalf("ParseURL returned error: %v", err)
+	}
+	if cfg.Network != "tcp" || cfg.Address != "cache:6380" || cfg.Password != "s3cret" || cfg.DB != 2 {
+		t.Errorf("got %+v", cfg)
+	}
+	if cfg, _ := ParseURL("redis://cache"); cfg.Address != "cache:6379" {
+		t.Errorf("default port: got %q", cfg.Address)
+	}
+	if cfg, _ := ParseURL("unix:///run/redis.sock"); cfg.Network != "unix" || cfg.Address != "/run/redis.sock" {
+		t.Errorf("unix socket: got %+v", cfg)
+	}
+	for _, raw := range []string{"http://cache", "redis://cache/x", "redis://"} {
+		if _, err := ParseURL(raw); err == nil {
+			t.Errorf("ParseURL(%q) should fail", raw)
+		}
+	}
+}
+
+func TestReadReply(t *testing.T) {
+	input := "+OK\r\n-ERR boom\r\n:42\r\n$5\r\nhello\r\n$-1\r\n*3\r\n:1\r\n$1\r\nx\r\n-ERR in array\r\n"
+	r := bufio.NewReader(strings.NewReader(input))
+
+	want := []any{"OK", nil, int64(42), "hello", nil, []any{int64(1), "x", Error("ERR in array")}}
+	for i, w := range want {
+		got, err := readReply(r)
+		if i == 1 {
+			var replyErr Error
+			if !errors.As(err, &replyErr) || replyErr != "ERR boom" {
+				t.Errorf("reply %d: err = %v, want the error reply", i, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("reply %d: %v", i, err)
+		}
+		if !reflect.DeepEqual(got, w) {
+			t.Errorf("reply %d = %#v, want %#v", i, got, w)
+		}
+	}
+}
+
+// fakeServer answers each command with the next canned reply and records
+// the command names it received.
+func fakeServer(t *testing.T, replies ...string) (*Client, *[]string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	var received []string
+	go func() {
+		nc, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer nc.Close()
+		r := bufio.NewReader(nc)
+		for _, reply := range replies {
+			cmd, err := readReply(r)
+			if err != nil {
+				return
+			}
+			received = append(received, cmd.([]any)[0].(string))
+			if _, err := nc.Write([]byte(reply)); err != nil {
+				return
+			}
+		}
+	}()
+	return NewClient(Config{Network: "tcp", Address: ln.Addr().String()}), &received
+}
+
+func TestScriptFallsBackToEval(t *testing.T) {
+	client, received := fakeServer(t, "-NOSCRIPT No matching script\r\n", ":7\r\n", ":8\r\n")
+	script := NewScript("return 7")
+
+	got, err := script.Run(context.Background(), client, []string{"k"}, 1)
+	if err != nil || got != int64(7) {
+		t.Fatalf("Run = %v, %v", got, err)
+	}
+	// The connection is reused for the next command
+	if got, err := client.Do(context.Background(), "INCR", "k"); err != nil || got != int64(8) {
+		t.Fatalf("Do = %v, %v", got, err)
+	}
+	if want := []string{"EVALSHA", "EVAL", "INCR"}; !reflect.DeepEqual(*received, want) {
+		t.Errorf("commands = %v, want %v", *received, want)
+	}
+}
+
+func TestSubscribe(t *testing.T) {
+	client, received := fakeServer(t,
+		"*3\r\n$9\r\nsubscribe\r\n$1\r\nc\r\n:1\r\n"+
+			"*3\r\n$7\r\nmessage\r\n$1\r\nc\r\n$5\r\nfirst\r\n"+
+			"*3\r\n$7\r\nmessage\r\n$1\r\nc\r\n$6\r\nsecond\r\n")
+	ctx, cancel := context.WithCancel(context.Background())
+	var got []string
+	err := client.Subscribe(ctx, "c", func(payload string) {
+		got = append(got, payload)
+		if len(got) == 2 {
+			cancel()
+		}
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Subscribe returned %v, want context.Canceled", err)
+	}
+	if !reflect.DeepEqual(got, []string{"first", "second"}) {
+		t.Errorf("messages = %v", got)
+	}
+	if want := []string{"SUBSCRIBE"}; !reflect.DeepEqual(*received, want) {
+		t.Errorf("commands = %v, want %v", *received, want)
+	}
+}
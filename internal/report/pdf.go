@@ -0,0 +1,214 @@
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// The PDF writer below is intentionally tiny: text-only pages using the
+// standard Helvetica fonts, which every PDF viewer ships with, so no font
+// embedding or third-party library is needed.
+
+const (
+	pageWidth    = 595 // A4 in points
+	pageHeight   = 842
+	pageMargin   = 50
+	lineHeight   = 14
+	maxLineChars = 95 // roughly what fits in the text width at 10pt Helvetica
+)
+
+type pdfFont string
+
+const (
+	fontRegular pdfFont = "F1"
+	fontBold    pdfFont = "F2"
+)
+
+// pdfLine is a single line of laid-out text.
+type pdfLine struct {
+	font   pdfFont
+	size   int
+	indent int
+	text   string
+}
+
+// WritePDF renders the report as a PDF document.
+func WritePDF(w io.Writer, r *Report) error {
+	pages := paginate(layout(r))
+
+	var buf bytes.Buffer
+	var offsets []int
+	beginObj := func() int {
+		offsets = append(offsets, buf.Len())
+		n := len(offsets)
+		fmt.Fprintf(&buf, "%d 0 obj\n", n)
+		return n
+	}
+
+	buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// Fixed objects: 1 catalog, 2 page tree, 3-4 fonts. Page objects follow,
+	// each immediately followed by its content stream.
+	beginObj()
+	buf.WriteString("<< /Type /Catalog /Pages 2 0 R >>\nendobj\n")
+
+	beginObj()
+	buf.WriteString("<< /Type /Pages /Kids [")
+	for i := range pages {
+		fmt.Fprintf(&buf, " %d 0 R", 5+2*i)
+	}
+	fmt.Fprintf(&buf, " ] /Count %d >>\nendobj\n", len(pages))
+
+	beginObj()
+	buf.WriteString("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>\nendobj\n")
+	beginObj()
+	buf.WriteString("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>\nendobj\n")
+
+	for i, lines := range pages {
+		content := pageContent(lines, i+1, len(pages))
+
+		page := beginObj()
+		fmt.Fprintf(&buf, "<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] "+
+			"/Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>\nendobj\n",
+			pageWidth, pageHeight, page+1)
+
+		beginObj()
+		fmt.Fprintf(&buf, "<< /Length %d >>\nstream\n", len(content))
+		buf.Write(content)
+		buf.WriteString("\nendstream\nendobj\n")
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R /Info << /Title (%s) /CreationDate (D:%s) >> >>\nstartxref\n%d\n%%%%EOF\n",
+		len(offsets)+1, pdfEscape(r.Title), r.GeneratedAt.UTC().Format("20060102150405Z"), xref)
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// layout flattens the report into lines, wrapping long entries.
+func layout(r *Report) []pdfLine {
+	completed, outstanding := r.Totals()
+	lines := []pdfLine{
+		{font: fontBold, size: 18, text: r.Title},
+		{font: fontRegular, size: 10, text: fmt.Sprintf("%s  |  %d completed  |  %d outstanding", r.periodLabel(), completed, outstanding)},
+		{},
+	}
+
+	addEntries := func(heading string, entries []Entry, mark string) {
+		lines = append(lines, pdfLine{font: fontBold, size: 11, indent: 10, text: fmt.Sprintf("%s (%d)", heading, len(entries))})
+		if len(entries) == 0 {
+			lines = append(lines, pdfLine{font: fontRegular, size: 10, indent: 20, text: "None"})
+		}
+		for _, entry := range entries {
+			text := mark + " " + entry.Title
+			if entry.Note != "" {
+				text += " - " + entry.Note
+			}
+			for i, chunk := range wrap(text, maxLineChars) {
+				indent := 20
+				if i > 0 {
+					indent = 30
+				}
+				lines = append(lines, pdfLine{font: fontRegular, size: 10, indent: indent, text: chunk})
+			}
+		}
+	}
+
+	for _, section := range r.Sections {
+		lines = append(lines, pdfLine{font: fontBold, size: 14, text: section.Name})
+		addEntries("Completed", section.Completed, "[x]")
+		addEntries("Outstanding", section.Outstanding, "[ ]")
+		lines = append(lines, pdfLine{})
+	}
+
+	lines = append(lines, pdfLine{font: fontRegular, size: 8, text: "Generated " + r.GeneratedAt.Format(time.RFC1123)})
+	return lines
+}
+
+// paginate splits lines into pages, reserving room for the footer.
+func paginate(lines []pdfLine) [][]pdfLine {
+	perPage := (pageHeight - 2*pageMargin - lineHeight) / lineHeight
+	var pages [][]pdfLine
+	for len(lines) > perPage {
+		pages = append(pages, lines[:perPage])
+		lines = lines[perPage:]
+	}
+	return append(pages, lines)
+}
+
+// pageContent builds the content stream for one page.
+func pageContent(lines []pdfLine, pageNum, pageCount int) []byte {
+	var c bytes.Buffer
+	y := pageHeight - pageMargin
+	for _, line := range lines {
+		if line.text != "" {
+			fmt.Fprintf(&c, "BT /%s %d Tf %d %d Td (%s) Tj ET\n", line.font, line.size, pageMargin+line.indent, y, pdfEscape(line.text))
+		}
+		y -= lineHeight
+	}
+	fmt.Fprintf(&c, "BT /F1 8 Tf %d %d Td (Page %d of %d) Tj ET", pageWidth-pageMargin-60, pageMargin/2, pageNum, pageCount)
+	return c.Bytes()
+}
+
+// wrap breaks text into chunks of at most width characters at word boundaries.
+func wrap(text string, width int) []string {
+	words := strings.Fields(text)
+	if len(words) == 0 {
+		return []string{""}
+	}
+	var chunks []string
+	current := ""
+	for _, word := range words {
+		for len([]rune(word)) > width {
+			if current != "" {
+				chunks = append(chunks, current)
+				current = ""
+			}
+			runes := []rune(word)
+			chunks = append(chunks, string(runes[:width]))
+			word = string(runes[width:])
+		}
+		switch {
+		case current == "":
+			current = word
+		case len([]rune(current))+1+len([]rune(word)) <= width:
+			current += " " + word
+		default:
+			chunks = append(chunks, current)
+			current = word
+		}
+	}
+	return append(chunks, current)
+}
+
+// pdfEscape converts text to a WinAnsi literal string body. Characters
+// outside Latin-1 can't be shown by the standard fonts and become '?'.
+func pdfEscape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '\\' || r == '(' || r == ')':
+			b.WriteByte('\\')
+			b.WriteByte(byte(r))
+		case r == '\n' || r == '\r' || r == '\t':
+			b.WriteByte(' ')
+		case r < 0x20:
+			// drop other control characters
+		case r < 0x80:
+			b.WriteByte(byte(r))
+		case r >= 0xa0 && r <= 0xff:
+			fmt.Fprintf(&b, "\\%03o", r)
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,131 @@
+// Package report renders status reports (Markdown or PDF) from a
+// format-neutral Report model built by the service layer.
+package report
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Report is a status report covering a period, grouped into sections (one per list).
+type Report struct {
+	Title       string
+	PeriodStart time.Time
+	PeriodEnd   time.Time // exclusive
+	GeneratedAt time.Time
+	Sections    []Section
+}
+
+// Section holds the items of a single list.
+type Section struct {
+	Name        string
+	Completed   []Entry
+	Outstanding []Entry
+}
+
+// Entry is a single todo line in a report.
+type Entry struct {
+	Title string
+	Note  string // e.g. "completed Mon Apr 7" or "due Fri Apr 11"
+}
+
+// Totals returns the number of completed and outstanding entries across all sections.
+func (r *Report) Totals() (completed, outstanding int) {
+	for _, section := range r.Sections {
+		completed += len(section.Completed)
+		outstanding += len(section.Outstanding)
+	}
+	return completed, outstanding
+}
+
+// periodLabel formats the report period, e.g. "Apr 7 - Apr 13, 2025".
+func (r *Report) periodLabel() string {
+	last := r.PeriodEnd.AddDate(0, 0, -1)
+	return fmt.Sprintf("%s - %s", r.PeriodStart.Format("Jan 2"), last.Format("Jan 2, 2006"))
+}
+
+// Format identifies an output format.
+type Format string
+
+const (
+	FormatMarkdown Format = "md"
+	FormatPDF      Format = "pdf"
+)
+
+// ContentType returns the MIME type for the format.
+func (f Format) ContentType() string {
+	switch f {
+	case FormatPDF:
+		return "application/pdf"
+	default:
+		return "text/markdown; charset=utf-8"
+	}
+}
+
+// ParseFormat validates a format name, defaulting to Markdown when empty.
+func ParseFormat(s string) (Format, error) {
+	switch Format(strings.ToLower(s)) {
+	case "", FormatMarkdown:
+		return FormatMarkdown, nil
+	case FormatPDF:
+		return FormatPDF, nil
+	default:
+		return "", fmt.Errorf("unsupported report format %q", s)
+	}
+}
+
+// Write renders the report in the given format.
+func Write(w io.Writer, r *Report, format Format) error {
+	switch format {
+	case FormatPDF:
+		return WritePDF(w, r)
+	default:
+		return WriteMarkdown(w, r)
+	}
+}
+
+// WriteMarkdown renders the report as GitHub-flavoured Markdown.
+func WriteMarkdown(w io.Writer, r *Report) error {
+	var b strings.Builder
+	completed, outstanding := r.Totals()
+
+	fmt.Fprintf(&b, "# %s\n\n", escapeMarkdown(r.Title))
+	fmt.Fprintf(&b, "_%s_ · %d completed · %d outstanding\n", r.periodLabel(), completed, outstanding)
+
+	for _, section := range r.Sections {
+		fmt.Fprintf(&b, "\n## %s\n", escapeMarkdown(section.Name))
+		writeMarkdownEntries(&b, "Completed", section.Completed, "x")
+		writeMarkdownEntries(&b, "Outstanding", section.Outstanding, " ")
+	}
+
+	fmt.Fprintf(&b, "\n---\nGenerated %s\n", r.GeneratedAt.Format(time.RFC1123))
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writeMarkdownEntries(b *strings.Builder, heading string, entries []Entry, mark string) {
+	fmt.Fprintf(b, "\n### %s (%d)\n\n", heading, len(entries))
+	if len(entries) == 0 {
+		b.WriteString("_None_\n")
+		return
+	}
+	for _, entry := range entries {
+		fmt.Fprintf(b, "- [%s] %s", mark, escapeMarkdown(entry.Title))
+		if entry.Note != "" {
+			fmt.Fprintf(b, " — %s", entry.Note)
+		}
+		b.WriteString("\n")
+	}
+}
+
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", "*", `\*`, "_", `\_`, "[", `\[`, "]", `\]`, "#", `\#`, "<", `&lt;`, ">", `&gt;`,
+)
+
+// escapeMarkdown keeps user-provided titles from being interpreted as markup.
+func escapeMarkdown(s string) string {
+	return markdownEscaper.Replace(s)
+}
@@ -0,0 +1,109 @@
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func sampleReport(entries int) *Report {
+	start := time.Date(2025, 4, 7, 0, 0, 0, 0, time.UTC)
+	r := &Report{
+		Title:       "Weekly report",
+		PeriodStart: start,
+		PeriodEnd:   start.AddDate(0, 0, 7),
+		GeneratedAt: start.Add(100 * time.Hour),
+		Sections: []Section{
+			{Name: "Work", Completed: []Entry{{Title: "Write *spec*", Note: "completed Mon Apr 7"}}},
+			{Name: "Inbox"},
+		},
+	}
+	for i := 0; i < entries; i++ {
+		r.Sections[1].Outstanding = append(r.Sections[1].Outstanding, Entry{Title: fmt.Sprintf("Task (%d)", i)})
+	}
+	return r
+}
+
+func TestParseFormat(t *testing.T) {
+	cases := map[string]Format{"": FormatMarkdown, "md": FormatMarkdown, "PDF": FormatPDF}
+	for in, want := range cases {
+		got, err := ParseFormat(in)
+		if err != nil || got != want {
+			t.Errorf("ParseFormat(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if _, err := ParseFormat("docx"); err == nil {
+		t.Error("expected error for unsupported format")
+	}
+}
+
+func TestWriteMarkdown(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteMarkdown(&buf, sampleReport(2)); err != nil {
+		t.Fatalf("WriteMarkdown returned error: %v", err)
+	}
+	out := buf.String()
+
+	for _, want := range []string{
+		"# Weekly report",
+		"Apr 7 - Apr 13, 2025",
+		"1 completed · 2 outstanding",
+		"## Work",
+		`- [x] Write \*spec\* — completed Mon Apr 7`,
+		"### Outstanding (2)",
+		"- [ ] Task (1)",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected markdown to contain %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestWritePDF(t *testing.T) {
+	var buf bytes.Buffer
+	// Enough entries to force a second page
+	if err := WritePDF(&buf, sampleReport(80)); err != nil {
+		t.Fatalf("WritePDF returned error: %v", err)
+	}
+	out := buf.Bytes()
+
+	if !bytes.HasPrefix(out, []byte("%PDF-1.4")) {
+		t.Fatalf("missing PDF header")
+	}
+	if !bytes.Contains(out, []byte("/Count 2")) {
+		t.Errorf("expected two pages")
+	}
+	if !bytes.Contains(out, []byte(`([ ] Task \(3\)) Tj`)) {
+		t.Errorf("expected escaped entry text in content stream")
+	}
+
+	// Every xref entry must point at the start of its object
+	m := regexp.MustCompile(`startxref\n(\d+)\n`).FindSubmatch(out)
+	if m == nil {
+		t.Fatal("missing startxref")
+	}
+	xref, _ := strconv.Atoi(string(m[1]))
+	if !bytes.HasPrefix(out[xref:], []byte("xref\n")) {
+		t.Fatalf("startxref does not point at xref table")
+	}
+	entries := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllSubmatch(out[xref:], -1)
+	for i, e := range entries {
+		off, _ := strconv.Atoi(string(e[1]))
+		want := fmt.Sprintf("%d 0 obj", i+1)
+		if !bytes.HasPrefix(out[off:], []byte(want)) {
+			t.Errorf("xref entry %d points at %q, want %q", i+1, out[off:off+10], want)
+		}
+	}
+}
+
+func TestWrap(t *testing.T) {
+	got := wrap("aaa bbb ccccccccc dd", 7)
+	want := []string{"aaa bbb", "ccccccc", "cc dd"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("wrap = %q, want %q", got, want)
+	}
+}
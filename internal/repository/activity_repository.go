@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"strconv"
+
+	"github.com/Tomlord1122/todo-backend/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+// ActivityRepository defines the interface for todo history operations
+type ActivityRepository interface {
+	Create(activity *domain.Activity) error
+	FindByTodoID(todoID uint) ([]domain.Activity, error)
+	FindForList(listID uint, kinds []string) ([]domain.Activity, error)
+}
+
+// gormActivityRepository implements ActivityRepository using GORM
+type gormActivityRepository struct {
+	db *gorm.DB
+}
+
+// NewGormActivityRepository creates a new GORM activity repository
+func NewGormActivityRepository(db *gorm.DB) ActivityRepository {
+	return &gormActivityRepository{db: db}
+}
+
+// Create records an activity
+func (r *gormActivityRepository) Create(activity *domain.Activity) error {
+	return r.db.Create(activity).Error
+}
+
+// FindByTodoID retrieves a todo's history, oldest first
+func (r *gormActivityRepository) FindByTodoID(todoID uint) ([]domain.Activity, error) {
+	var activities []domain.Activity
+	result := r.db.Where("todo_id = ?", todoID).Order("id ASC").Find(&activities)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return activities, nil
+}
+
+// FindForList retrieves the activities of the given kinds that happened in
+// a list, including todos moving out of it, oldest first
+func (r *gormActivityRepository) FindForList(listID uint, kinds []string) ([]domain.Activity, error) {
+	var activities []domain.Activity
+	result := r.db.
+		Where("kind IN ?", kinds).
+		Where("list_id = ? OR (kind = ? AND old_value = ?)", listID, domain.ActivityMoved, strconv.FormatUint(uint64(listID), 10)).
+		Order("id ASC").
+		Find(&activities)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return activities, nil
+}
@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/Tomlord1122/todo-backend/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+// APIKeyRepository defines the interface for API key data operations
+type APIKeyRepository interface {
+	Create(key *domain.APIKey) error
+	FindByHash(keyHash string) (*domain.APIKey, error)
+	FindByUserID(userID uint) ([]domain.APIKey, error)
+	// Delete deletes one of a user's keys, returning gorm.ErrRecordNotFound
+	// if the user has no key with that ID
+	Delete(id, userID uint) error
+	// Touch records that a key was used at the given time
+	Touch(id uint, at time.Time) error
+}
+
+// gormAPIKeyRepository implements APIKeyRepository using GORM
+type gormAPIKeyRepository struct {
+	db *gorm.DB
+}
+
+// NewGormAPIKeyRepository creates a new GORM API key repository
+func NewGormAPIKeyRepository(db *gorm.DB) APIKeyRepository {
+	return &gormAPIKeyRepository{db: db}
+}
+
+// Create stores a new API key
+func (r *gormAPIKeyRepository) Create(key *domain.APIKey) error {
+	return r.db.Create(key).Error
+}
+
+// FindByHash retrieves an API key by the hash of the key
+func (r *gormAPIKeyRepository) FindByHash(keyHash string) (*domain.APIKey, error) {
+	var key domain.APIKey
+	if err := r.db.Where("key_hash = ?", keyHash).First(&key).Error; err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// FindByUserID retrieves the API keys of a user, oldest first
+func (r *gormAPIKeyRepository) FindByUserID(userID uint) ([]domain.APIKey, error) {
+	var keys []domain.APIKey
+	result := r.db.Where("user_id = ?", userID).Order("id ASC").Find(&keys)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return keys, nil
+}
+
+// Delete deletes one of a user's API keys
+func (r *gormAPIKeyRepository) Delete(id, userID uint) error {
+	result := r.db.Where("user_id = ?", userID).Delete(&domain.APIKey{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// Touch sets when an API key was last used
+func (r *gormAPIKeyRepository) Touch(id uint, at time.Time) error {
+	return r.db.Model(&domain.APIKey{}).Where("id = ?", id).UpdateColumn("last_used_at", at).Error
+}
@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/Tomlord1122/todo-backend/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+// AttachmentRepository defines the interface for attachment data operations
+type AttachmentRepository interface {
+	Create(attachment *domain.Attachment) error
+	FindByID(id uint) (*domain.Attachment, error)
+	FindByTodoID(todoID uint, status string) ([]domain.Attachment, error)
+	FindByStatusBefore(status string, before time.Time, limit int) ([]domain.Attachment, error)
+	FindByThumbnailStatus(status string, limit int) ([]domain.Attachment, error)
+	FindByScanStatus(status string, limit int) ([]domain.Attachment, error)
+	Update(attachment *domain.Attachment) error
+	Delete(id uint) error
+}
+
+// gormAttachmentRepository implements AttachmentRepository using GORM
+type gormAttachmentRepository struct {
+	db *gorm.DB
+}
+
+// NewGormAttachmentRepository creates a new GORM attachment repository
+func NewGormAttachmentRepository(db *gorm.DB) AttachmentRepository {
+	return &gormAttachmentRepository{db: db}
+}
+
+// Create adds a new attachment record
+func (r *gormAttachmentRepository) Create(attachment *domain.Attachment) error {
+	return r.db.Create(attachment).Error
+}
+
+// FindByID retrieves an attachment by its ID
+func (r *gormAttachmentRepository) FindByID(id uint) (*domain.Attachment, error) {
+	var attachment domain.Attachment
+	result := r.db.First(&attachment, id)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &attachment, nil
+}
+
+// FindByTodoID retrieves a todo's attachments, optionally filtered by status
+func (r *gormAttachmentRepository) FindByTodoID(todoID uint, status string) ([]domain.Attachment, error) {
+	var attachments []domain.Attachment
+	query := r.db.Where("todo_id = ?", todoID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	result := query.Order("id ASC").Find(&attachments)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return attachments, nil
+}
+
+// FindByStatusBefore retrieves attachments in a status that were created before a cutoff
+func (r *gormAttachmentRepository) FindByStatusBefore(status string, before time.Time, limit int) ([]domain.Attachment, error) {
+	var attachments []domain.Attachment
+	result := r.db.Where("status = ? AND created_at < ?", status, before).Order("id ASC").Limit(limit).Find(&attachments)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return attachments, nil
+}
+
+// FindByThumbnailStatus retrieves attachments whose thumbnails are in the given status
+func (r *gormAttachmentRepository) FindByThumbnailStatus(status string, limit int) ([]domain.Attachment, error) {
+	var attachments []domain.Attachment
+	result := r.db.Where("thumbnail_status = ?", status).Order("id ASC").Limit(limit).Find(&attachments)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return attachments, nil
+}
+
+// FindByScanStatus retrieves attachments whose malware scan is in the given status
+func (r *gormAttachmentRepository) FindByScanStatus(status string, limit int) ([]domain.Attachment, error) {
+	var attachments []domain.Attachment
+	result := r.db.Where("scan_status = ?", status).Order("id ASC").Limit(limit).Find(&attachments)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return attachments, nil
+}
+
+// Update saves changes to an attachment
+func (r *gormAttachmentRepository) Update(attachment *domain.Attachment) error {
+	return r.db.Save(attachment).Error
+}
+
+// Delete removes an attachment record by its ID
+func (r *gormAttachmentRepository) Delete(id uint) error {
+	return r.db.Delete(&domain.Attachment{}, id).Error
+}
@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/Tomlord1122/todo-backend/internal/domain"
+)
+
+// seedTodos fills a memory repository with n todos spread over 10 users.
+func seedTodos(b *testing.B, n int) TodoRepository {
+	b.Helper()
+	repo := NewMemoryRepositories().Todos
+	for i := 0; i < n; i++ {
+		todo := &domain.Todo{Title: fmt.Sprintf("Todo %d: water the plants", i), UserID: uint(i%10 + 1)}
+		if err := repo.Create(todo); err != nil {
+			b.Fatal(err)
+		}
+	}
+	return repo
+}
+
+func BenchmarkTodoCreate(b *testing.B) {
+	repo := NewMemoryRepositories().Todos
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if err := repo.Create(&domain.Todo{Title: "Benchmark", UserID: 1}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkTodoFindByID(b *testing.B) {
+	repo := seedTodos(b, 10000)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := repo.FindByID(uint(i%10000 + 1)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkTodoFind(b *testing.B) {
+	repo := seedTodos(b, 10000)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := repo.Find(TodoFilter{Offset: (i % 100) * 50, Limit: 50}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkTodoSearch(b *testing.B) {
+	repo := seedTodos(b, 10000)
+	for _, fuzzy := range []bool{false, true} {
+		b.Run(fmt.Sprintf("fuzzy=%t", fuzzy), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, _, err := repo.Search("plants", TodoSearch{TodoFilter: TodoFilter{Limit: 20}, Fuzzy: fuzzy, Threshold: 0.3}); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
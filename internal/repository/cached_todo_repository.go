@@ -0,0 +1,478 @@
+package repository
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Tomlord1122/todo-backend/internal/cache"
+	"github.com/Tomlord1122/todo-backend/internal/domain"
+	"github.com/Tomlord1122/todo-backend/internal/logging"
+)
+
+// generationTTL is how long a user's list generation is kept. Any TTL is
+// safe: when it expires, the user gets a new generation and their cached
+// lists are simply missed.
+const generationTTL = 24 * time.Hour
+
+// cachedTodoRepository is a cache-aside TodoRepository. FindByID, and Find
+// and Count for a single user, are answered from the cache when they can
+// be and cached for ttl when they can't. Writes evict the todos they
+// change and start a new generation of their users' cached lists, once
+// their transaction commits. Cache errors are logged and the database is
+// used as if there were no cache, so a cached read is at most ttl stale
+// when an eviction fails.
+type cachedTodoRepository struct {
+	TodoRepository
+	cache cache.Cache
+	ttl   time.Duration
+	ctx   context.Context
+	// pending collects the evictions of a transaction until it commits.
+	// Reads in a transaction skip the cache.
+	pending *evictions
+}
+
+// evictions are the todos and the users' lists a write changed.
+type evictions struct {
+	todoIDs []uint
+	userIDs []uint
+}
+
+// WithCache caches todo reads in c for ttl. Subtask, tag and list writes
+// evict the todos they change too.
+func (r *Repositories) WithCache(c cache.Cache, ttl time.Duration) {
+	todos := &cachedTodoRepository{TodoRepository: r.Todos, cache: c, ttl: ttl, ctx: context.Background()}
+	r.Todos = todos
+	r.Subtasks = &cachedSubtaskRepository{SubtaskRepository: r.Subtasks, todos: todos}
+	r.Tags = &cachedTagRepository{TagRepository: r.Tags, todos: todos}
+	r.Lists = &cachedListRepository{ListRepository: r.Lists, todos: todos}
+}
+
+func todoCacheKey(id uint) string {
+	return fmt.Sprintf("todo:%d", id)
+}
+
+func generationCacheKey(userID uint) string {
+	return fmt.Sprintf("todos:user:%d:generation", userID)
+}
+
+// FindByID implements TodoRepository.
+func (r *cachedTodoRepository) FindByID(id uint) (*domain.Todo, error) {
+	if r.pending != nil {
+		return r.TodoRepository.FindByID(id)
+	}
+	key := todoCacheKey(id)
+	var todo domain.Todo
+	if r.get(key, &todo) {
+		return &todo, nil
+	}
+	found, err := r.TodoRepository.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	r.set(key, found, r.ttl)
+	return found, nil
+}
+
+// Find implements TodoRepository.
+func (r *cachedTodoRepository) Find(filter TodoFilter) ([]domain.Todo, error) {
+	key, ok := r.listKey("find", filter)
+	var todos []domain.Todo
+	if ok && r.get(key, &todos) {
+		return todos, nil
+	}
+	todos, err := r.TodoRepository.Find(filter)
+	if err == nil && ok {
+		r.set(key, todos, r.ttl)
+	}
+	return todos, err
+}
+
+// Count implements TodoRepository.
+func (r *cachedTodoRepository) Count(filter TodoFilter) (int64, error) {
+	key, ok := r.listKey("count", filter)
+	var count int64
+	if ok && r.get(key, &count) {
+		return count, nil
+	}
+	count, err := r.TodoRepository.Count(filter)
+	if err == nil && ok {
+		r.set(key, count, r.ttl)
+	}
+	return count, err
+}
+
+// listKey returns the key of a Find or Count with filter, in the current
+// generation of the user's lists. ok is false when the result isn't
+// cached: in transactions, for filters spanning users and when the cache
+// fails.
+func (r *cachedTodoRepository) listKey(kind string, filter TodoFilter) (key string, ok bool) {
+	if r.pending != nil || filter.UserID == nil {
+		return "", false
+	}
+	userID := *filter.UserID
+	var generation string
+	if !r.get(generationCacheKey(userID), &generation) {
+		// The first list read since the user's generation expired or was
+		// evicted; a concurrent reader starting another one only costs a miss
+		generation = newGeneration()
+		if !r.set(generationCacheKey(userID), generation, generationTTL) {
+			return "", false
+		}
+	}
+	encoded, err := json.Marshal(filter)
+	if err != nil {
+		return "", false
+	}
+	sum := sha256.Sum256(encoded)
+	return fmt.Sprintf("todos:user:%d:%s:%s:%s", userID, generation, kind, hex.EncodeToString(sum[:16])), true
+}
+
+func newGeneration() string {
+	b := make([]byte, 8)
+	// crypto/rand.Read never fails on the supported platforms
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// get reads key into v, reporting whether it was cached.
+func (r *cachedTodoRepository) get(key string, v any) bool {
+	value, ok, err := r.cache.Get(r.ctx, key)
+	if err != nil {
+		r.logError("Reading todo cache failed", key, err)
+		return false
+	}
+	if !ok {
+		return false
+	}
+	if err := json.Unmarshal(value, v); err != nil {
+		r.logError("Decoding cached todos failed", key, err)
+		return false
+	}
+	return true
+}
+
+// set caches v under key, reporting whether it was stored.
+func (r *cachedTodoRepository) set(key string, v any, ttl time.Duration) bool {
+	value, err := json.Marshal(v)
+	if err == nil {
+		err = r.cache.Set(r.ctx, key, value, ttl)
+	}
+	if err != nil {
+		r.logError("Writing todo cache failed", key, err)
+		return false
+	}
+	return true
+}
+
+// logError logs a cache failure, except while the cache is known to be
+// unavailable, which was logged when it failed.
+func (r *cachedTodoRepository) logError(msg, key string, err error) {
+	if !errors.Is(err, cache.ErrUnavailable) {
+		logging.FromContext(r.ctx).Warn(msg, "key", key, "err", err)
+	}
+}
+
+// changed evicts what a write changed, or records it for after the commit
+// in a transaction. Users of 0 are skipped.
+func (r *cachedTodoRepository) changed(todoIDs []uint, userIDs ...uint) {
+	if r.pending != nil {
+		r.pending.todoIDs = append(r.pending.todoIDs, todoIDs...)
+		r.pending.userIDs = append(r.pending.userIDs, userIDs...)
+		return
+	}
+	r.evict(evictions{todoIDs: todoIDs, userIDs: userIDs})
+}
+
+// evict removes todos from the cache and starts new generations of their
+// users' lists.
+func (r *cachedTodoRepository) evict(e evictions) {
+	if len(e.todoIDs) > 0 {
+		keys := make([]string, len(e.todoIDs))
+		for i, id := range e.todoIDs {
+			keys[i] = todoCacheKey(id)
+		}
+		if err := r.cache.Delete(r.ctx, keys...); err != nil {
+			r.logError("Evicting cached todos failed", keys[0], err)
+		}
+	}
+	seen := make(map[uint]bool)
+	for _, userID := range e.userIDs {
+		if userID == 0 || seen[userID] {
+			continue
+		}
+		seen[userID] = true
+		r.set(generationCacheKey(userID), newGeneration(), generationTTL)
+	}
+}
+
+// owner returns the user of a live or trashed todo, or 0 when there is no
+// such todo.
+func (r *cachedTodoRepository) owner(id uint) uint {
+	if todo, err := r.TodoRepository.FindByID(id); err == nil {
+		return todo.UserID
+	}
+	if todo, err := r.TodoRepository.FindTrashedByID(id); err == nil {
+		return todo.UserID
+	}
+	return 0
+}
+
+// Create implements TodoRepository.
+func (r *cachedTodoRepository) Create(todo *domain.Todo) error {
+	if err := r.TodoRepository.Create(todo); err != nil {
+		return err
+	}
+	r.changed(nil, todo.UserID)
+	return nil
+}
+
+// Update implements TodoRepository.
+func (r *cachedTodoRepository) Update(todo *domain.Todo) error {
+	if err := r.TodoRepository.Update(todo); err != nil {
+		return err
+	}
+	r.changed([]uint{todo.ID}, todo.UserID)
+	return nil
+}
+
+// SetTags implements TodoRepository.
+func (r *cachedTodoRepository) SetTags(todoID uint, tags []domain.Tag) error {
+	if err := r.TodoRepository.SetTags(todoID, tags); err != nil {
+		return err
+	}
+	// Tags belong to the todo's user
+	if len(tags) > 0 {
+		r.changed([]uint{todoID}, tags[0].UserID)
+	} else {
+		r.changed([]uint{todoID}, r.owner(todoID))
+	}
+	return nil
+}
+
+// Delete implements TodoRepository.
+func (r *cachedTodoRepository) Delete(todo *domain.Todo) error {
+	if err := r.TodoRepository.Delete(todo); err != nil {
+		return err
+	}
+	r.changed([]uint{todo.ID}, todo.UserID)
+	return nil
+}
+
+// CreateMany implements TodoRepository.
+func (r *cachedTodoRepository) CreateMany(todos []*domain.Todo) error {
+	if err := r.TodoRepository.CreateMany(todos); err != nil {
+		return err
+	}
+	userIDs := make([]uint, len(todos))
+	for i, todo := range todos {
+		userIDs[i] = todo.UserID
+	}
+	r.changed(nil, userIDs...)
+	return nil
+}
+
+// UpdateMany implements TodoRepository.
+func (r *cachedTodoRepository) UpdateMany(todos []*domain.Todo) error {
+	if err := r.TodoRepository.UpdateMany(todos); err != nil {
+		return err
+	}
+	ids := make([]uint, len(todos))
+	userIDs := make([]uint, len(todos))
+	for i, todo := range todos {
+		ids[i], userIDs[i] = todo.ID, todo.UserID
+	}
+	r.changed(ids, userIDs...)
+	return nil
+}
+
+// DeleteMany implements TodoRepository.
+func (r *cachedTodoRepository) DeleteMany(ids []uint) error {
+	userIDs := make([]uint, len(ids))
+	for i, id := range ids {
+		userIDs[i] = r.owner(id)
+	}
+	if err := r.TodoRepository.DeleteMany(ids); err != nil {
+		return err
+	}
+	r.changed(ids, userIDs...)
+	return nil
+}
+
+// Restore implements TodoRepository.
+func (r *cachedTodoRepository) Restore(id uint) (bool, error) {
+	restored, err := r.TodoRepository.Restore(id)
+	if restored {
+		r.changed([]uint{id}, r.owner(id))
+	}
+	return restored, err
+}
+
+// Purge implements TodoRepository.
+func (r *cachedTodoRepository) Purge(id uint) error {
+	userID := r.owner(id)
+	if err := r.TodoRepository.Purge(id); err != nil {
+		return err
+	}
+	r.changed([]uint{id}, userID)
+	return nil
+}
+
+// ClaimOverdueNotification implements TodoRepository.
+func (r *cachedTodoRepository) ClaimOverdueNotification(id uint, at time.Time) (bool, error) {
+	claimed, err := r.TodoRepository.ClaimOverdueNotification(id, at)
+	if claimed {
+		r.changed([]uint{id}, r.owner(id))
+	}
+	return claimed, err
+}
+
+// CreateOccurrence implements TodoRepository.
+func (r *cachedTodoRepository) CreateOccurrence(fromID uint, next *domain.Todo) (bool, error) {
+	created, err := r.TodoRepository.CreateOccurrence(fromID, next)
+	if created {
+		r.changed([]uint{fromID}, next.UserID)
+	}
+	return created, err
+}
+
+// Transaction implements TodoRepository. The writes in fn are evicted
+// once the transaction commits, so a concurrent read can't cache what is
+// about to change.
+func (r *cachedTodoRepository) Transaction(fn func(todos TodoRepository, outbox OutboxRepository) error) error {
+	pending := r.pending
+	if pending == nil {
+		pending = &evictions{}
+	}
+	err := r.TodoRepository.Transaction(func(todos TodoRepository, outbox OutboxRepository) error {
+		return fn(&cachedTodoRepository{TodoRepository: todos, cache: r.cache, ttl: r.ttl, ctx: r.ctx, pending: pending}, outbox)
+	})
+	if err == nil && r.pending == nil {
+		r.evict(*pending)
+	}
+	return err
+}
+
+// WithContext implements TodoRepository.
+func (r *cachedTodoRepository) WithContext(ctx context.Context) TodoRepository {
+	scoped := *r
+	scoped.TodoRepository = r.TodoRepository.WithContext(ctx)
+	scoped.ctx = ctx
+	return &scoped
+}
+
+// cachedSubtaskRepository evicts the todos whose subtasks change, since
+// FindByID includes them.
+type cachedSubtaskRepository struct {
+	SubtaskRepository
+	todos *cachedTodoRepository
+}
+
+// Create implements SubtaskRepository.
+func (r *cachedSubtaskRepository) Create(subtask *domain.Subtask) error {
+	if err := r.SubtaskRepository.Create(subtask); err != nil {
+		return err
+	}
+	r.todos.changed([]uint{subtask.TodoID})
+	return nil
+}
+
+// Update implements SubtaskRepository.
+func (r *cachedSubtaskRepository) Update(subtask *domain.Subtask) error {
+	if err := r.SubtaskRepository.Update(subtask); err != nil {
+		return err
+	}
+	r.todos.changed([]uint{subtask.TodoID})
+	return nil
+}
+
+// Delete implements SubtaskRepository.
+func (r *cachedSubtaskRepository) Delete(id uint) error {
+	subtask, err := r.SubtaskRepository.FindByID(id)
+	if err != nil {
+		return r.SubtaskRepository.Delete(id)
+	}
+	if err := r.SubtaskRepository.Delete(id); err != nil {
+		return err
+	}
+	r.todos.changed([]uint{subtask.TodoID})
+	return nil
+}
+
+// cachedTagRepository evicts the todos of tags that are renamed or
+// deleted.
+type cachedTagRepository struct {
+	TagRepository
+	todos *cachedTodoRepository
+}
+
+// Update implements TagRepository.
+func (r *cachedTagRepository) Update(tag *domain.Tag) error {
+	var tagged []uint
+	if stored, err := r.TagRepository.FindByID(tag.ID); err == nil {
+		tagged = r.taggedTodos(stored)
+	}
+	if err := r.TagRepository.Update(tag); err != nil {
+		return err
+	}
+	r.todos.changed(tagged, tag.UserID)
+	return nil
+}
+
+// Delete implements TagRepository.
+func (r *cachedTagRepository) Delete(id uint) error {
+	tag, err := r.TagRepository.FindByID(id)
+	if err != nil {
+		return r.TagRepository.Delete(id)
+	}
+	tagged := r.taggedTodos(tag)
+	if err := r.TagRepository.Delete(id); err != nil {
+		return err
+	}
+	r.todos.changed(tagged, tag.UserID)
+	return nil
+}
+
+// taggedTodos returns the IDs of the todos carrying tag.
+func (r *cachedTagRepository) taggedTodos(tag *domain.Tag) []uint {
+	todos, err := r.todos.TodoRepository.Find(TodoFilter{UserID: &tag.UserID, Tags: []string{tag.Name}})
+	if err != nil {
+		return nil
+	}
+	ids := make([]uint, len(todos))
+	for i, todo := range todos {
+		ids[i] = todo.ID
+	}
+	return ids
+}
+
+// cachedListRepository evicts the todos of deleted lists, which are moved
+// out of them.
+type cachedListRepository struct {
+	ListRepository
+	todos *cachedTodoRepository
+}
+
+// Delete implements ListRepository.
+func (r *cachedListRepository) Delete(id uint) error {
+	list, err := r.ListRepository.FindByID(id)
+	if err != nil {
+		return r.ListRepository.Delete(id)
+	}
+	var ids []uint
+	if todos, err := r.todos.TodoRepository.FindByListID(id); err == nil {
+		for _, todo := range todos {
+			ids = append(ids, todo.ID)
+		}
+	}
+	if err := r.ListRepository.Delete(id); err != nil {
+		return err
+	}
+	r.todos.changed(ids, list.UserID)
+	return nil
+}
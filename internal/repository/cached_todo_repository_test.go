@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/Tomlord1122/todo-backend/internal/cache"
+	"github.com/Tomlord1122/todo-backend/internal/domain"
+)
+
+func TestCachedTodoRepository(t *testing.T) {
+	repos := NewMemoryRepositories()
+	uncached := repos.Todos
+	repos.WithCache(cache.NewMemory(), time.Minute)
+	todos := repos.Todos
+
+	todo := &domain.Todo{Title: "Ship it", UserID: 1}
+	if err := todos.Create(todo); err != nil {
+		t.Fatal(err)
+	}
+	userID := uint(1)
+	mine := TodoFilter{UserID: &userID}
+	if found, _ := todos.Find(mine); len(found) != 1 {
+		t.Fatalf("Find = %d todos, want 1", len(found))
+	}
+	if _, err := todos.FindByID(todo.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	// Writes bypassing the cache aren't seen until it expires
+	changed := *todo
+	changed.Title = "Changed behind the cache's back"
+	if err := uncached.Update(&changed); err != nil {
+		t.Fatal(err)
+	}
+	_ = uncached.Create(&domain.Todo{Title: "Unseen", UserID: 1})
+	if found, _ := todos.FindByID(todo.ID); found.Title != "Ship it" {
+		t.Errorf("FindByID = %q, want the cached todo", found.Title)
+	}
+	if found, _ := todos.Find(mine); len(found) != 1 {
+		t.Errorf("Find = %d todos, want the cached 1", len(found))
+	}
+
+	// Writes through it evict, once their transaction commits
+	err := todos.Transaction(func(tx TodoRepository, _ OutboxRepository) error {
+		current, err := tx.FindByID(todo.ID)
+		if err != nil {
+			return err
+		}
+		current.Title = "Updated"
+		if err := tx.Update(current); err != nil {
+			return err
+		}
+		if found, _ := todos.FindByID(todo.ID); found.Title == "Updated" {
+			t.Error("the update was evicted before the commit")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found, _ := todos.FindByID(todo.ID); found.Title != "Updated" {
+		t.Errorf("FindByID after the update = %q, want Updated", found.Title)
+	}
+	if count, _ := todos.Count(mine); count != 2 {
+		t.Errorf("Count after the update = %d, want 2", count)
+	}
+
+	// Other users' lists stay cached
+	otherID := uint(2)
+	theirs := TodoFilter{UserID: &otherID}
+	_, _ = todos.Find(theirs)
+	_ = uncached.Create(&domain.Todo{Title: "Theirs", UserID: 2})
+	_ = todos.Create(&domain.Todo{Title: "Mine", UserID: 1})
+	if found, _ := todos.Find(theirs); len(found) != 0 {
+		t.Errorf("user 2's cached list was evicted by user 1's write")
+	}
+
+	// Subtasks are part of the cached todo
+	if err := repos.Subtasks.Create(&domain.Subtask{TodoID: todo.ID, Title: "Test"}); err != nil {
+		t.Fatal(err)
+	}
+	current, _ := todos.FindByID(todo.ID)
+	if len(current.Subtasks) != 1 {
+		t.Errorf("FindByID after adding a subtask = %d subtasks, want 1", len(current.Subtasks))
+	}
+
+	if err := todos.Delete(current); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := todos.FindByID(todo.ID); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("FindByID after Delete = %v, want gorm.ErrRecordNotFound", err)
+	}
+}
@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"github.com/Tomlord1122/todo-backend/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+// CalendarRepository defines the interface for calendar sync data operations
+type CalendarRepository interface {
+	CreateConnection(conn *domain.CalendarConnection) error
+	FindConnectionByUser(userID uint) (*domain.CalendarConnection, error)
+	FindConnections() ([]domain.CalendarConnection, error)
+	UpdateConnection(conn *domain.CalendarConnection) error
+	// DeleteConnection deletes a connection and its event mapping
+	DeleteConnection(id uint) error
+	CreateEvent(event *domain.CalendarEvent) error
+	FindEvents(connectionID uint) ([]domain.CalendarEvent, error)
+	UpdateEvent(event *domain.CalendarEvent) error
+	DeleteEvent(id uint) error
+}
+
+// gormCalendarRepository implements CalendarRepository using GORM
+type gormCalendarRepository struct {
+	db *gorm.DB
+}
+
+// NewGormCalendarRepository creates a new GORM calendar sync repository
+func NewGormCalendarRepository(db *gorm.DB) CalendarRepository {
+	return &gormCalendarRepository{db: db}
+}
+
+// CreateConnection stores a new calendar connection
+func (r *gormCalendarRepository) CreateConnection(conn *domain.CalendarConnection) error {
+	return r.db.Create(conn).Error
+}
+
+// FindConnectionByUser retrieves a user's calendar connection
+func (r *gormCalendarRepository) FindConnectionByUser(userID uint) (*domain.CalendarConnection, error) {
+	var conn domain.CalendarConnection
+	result := r.db.Where("user_id = ?", userID).First(&conn)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &conn, nil
+}
+
+// FindConnections retrieves all calendar connections
+func (r *gormCalendarRepository) FindConnections() ([]domain.CalendarConnection, error) {
+	var conns []domain.CalendarConnection
+	result := r.db.Order("id ASC").Find(&conns)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return conns, nil
+}
+
+// UpdateConnection saves changes to a connection
+func (r *gormCalendarRepository) UpdateConnection(conn *domain.CalendarConnection) error {
+	return r.db.Save(conn).Error
+}
+
+// DeleteConnection deletes a connection and its event mapping
+func (r *gormCalendarRepository) DeleteConnection(id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("connection_id = ?", id).Delete(&domain.CalendarEvent{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&domain.CalendarConnection{}, id).Error
+	})
+}
+
+// CreateEvent stores the event created for a todo
+func (r *gormCalendarRepository) CreateEvent(event *domain.CalendarEvent) error {
+	return r.db.Create(event).Error
+}
+
+// FindEvents retrieves the events of a connection
+func (r *gormCalendarRepository) FindEvents(connectionID uint) ([]domain.CalendarEvent, error) {
+	var events []domain.CalendarEvent
+	result := r.db.Where("connection_id = ?", connectionID).Order("id ASC").Find(&events)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return events, nil
+}
+
+// UpdateEvent saves the synced state of an event
+func (r *gormCalendarRepository) UpdateEvent(event *domain.CalendarEvent) error {
+	return r.db.Save(event).Error
+}
+
+// DeleteEvent forgets an event
+func (r *gormCalendarRepository) DeleteEvent(id uint) error {
+	return r.db.Delete(&domain.CalendarEvent{}, id).Error
+}
@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"github.com/Tomlord1122/todo-backend/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+// ChecklistRepository defines the interface for checklist item data operations
+type ChecklistRepository interface {
+	Create(item *domain.ChecklistItem) error
+	FindByID(id uint) (*domain.ChecklistItem, error)
+	FindByTodoID(todoID uint) ([]domain.ChecklistItem, error)
+	Update(item *domain.ChecklistItem) error
+	Delete(id uint) error
+}
+
+// gormChecklistRepository implements ChecklistRepository using GORM
+type gormChecklistRepository struct {
+	db *gorm.DB
+}
+
+// NewGormChecklistRepository creates a new GORM checklist repository
+func NewGormChecklistRepository(db *gorm.DB) ChecklistRepository {
+	return &gormChecklistRepository{db: db}
+}
+
+// Create adds a new checklist item
+func (r *gormChecklistRepository) Create(item *domain.ChecklistItem) error {
+	return r.db.Create(item).Error
+}
+
+// FindByID retrieves a checklist item by its ID
+func (r *gormChecklistRepository) FindByID(id uint) (*domain.ChecklistItem, error) {
+	var item domain.ChecklistItem
+	result := r.db.First(&item, id)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &item, nil
+}
+
+// FindByTodoID retrieves a todo's checklist in order
+func (r *gormChecklistRepository) FindByTodoID(todoID uint) ([]domain.ChecklistItem, error) {
+	var items []domain.ChecklistItem
+	result := r.db.Where("todo_id = ?", todoID).Order("position ASC, id ASC").Find(&items)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return items, nil
+}
+
+// Update saves changes to a checklist item
+func (r *gormChecklistRepository) Update(item *domain.ChecklistItem) error {
+	return r.db.Save(item).Error
+}
+
+// Delete removes a checklist item by its ID
+func (r *gormChecklistRepository) Delete(id uint) error {
+	return r.db.Delete(&domain.ChecklistItem{}, id).Error
+}
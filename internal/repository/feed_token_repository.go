@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"github.com/Tomlord1122/todo-backend/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+// FeedTokenRepository defines the interface for feed token data operations
+type FeedTokenRepository interface {
+	Create(token *domain.FeedToken) error
+	FindByToken(token string) (*domain.FeedToken, error)
+	DeleteByToken(token string) error
+}
+
+// gormFeedTokenRepository implements FeedTokenRepository using GORM
+type gormFeedTokenRepository struct {
+	db *gorm.DB
+}
+
+// NewGormFeedTokenRepository creates a new GORM feed token repository
+func NewGormFeedTokenRepository(db *gorm.DB) FeedTokenRepository {
+	return &gormFeedTokenRepository{db: db}
+}
+
+// Create stores a new feed token
+func (r *gormFeedTokenRepository) Create(token *domain.FeedToken) error {
+	return r.db.Create(token).Error
+}
+
+// FindByToken looks up a feed token by its secret value
+func (r *gormFeedTokenRepository) FindByToken(token string) (*domain.FeedToken, error) {
+	var feedToken domain.FeedToken
+	result := r.db.Where("token = ?", token).First(&feedToken)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &feedToken, nil
+}
+
+// DeleteByToken revokes a feed token. It returns gorm.ErrRecordNotFound
+// when no token matched so callers can report it.
+func (r *gormFeedTokenRepository) DeleteByToken(token string) error {
+	result := r.db.Where("token = ?", token).Delete(&domain.FeedToken{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
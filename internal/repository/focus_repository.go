@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/Tomlord1122/todo-backend/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+// FocusRepository defines the interface for focus session data operations
+type FocusRepository interface {
+	Create(session *domain.FocusSession) error
+	FindRunning(userID uint) (*domain.FocusSession, error)
+	FindByUserBetween(userID uint, from, to time.Time) ([]domain.FocusSession, error)
+	Update(session *domain.FocusSession) error
+}
+
+// gormFocusRepository implements FocusRepository using GORM
+type gormFocusRepository struct {
+	db *gorm.DB
+}
+
+// NewGormFocusRepository creates a new GORM focus session repository
+func NewGormFocusRepository(db *gorm.DB) FocusRepository {
+	return &gormFocusRepository{db: db}
+}
+
+// Create adds a new focus session
+func (r *gormFocusRepository) Create(session *domain.FocusSession) error {
+	return r.db.Create(session).Error
+}
+
+// FindRunning retrieves a user's session that hasn't been stopped
+func (r *gormFocusRepository) FindRunning(userID uint) (*domain.FocusSession, error) {
+	var session domain.FocusSession
+	result := r.db.Where("user_id = ? AND ended_at IS NULL", userID).First(&session)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &session, nil
+}
+
+// FindByUserBetween retrieves a user's sessions that started before to and
+// were still running at or after from
+func (r *gormFocusRepository) FindByUserBetween(userID uint, from, to time.Time) ([]domain.FocusSession, error) {
+	var sessions []domain.FocusSession
+	result := r.db.
+		Where("user_id = ? AND started_at < ? AND (ended_at IS NULL OR ended_at >= ?)", userID, to, from).
+		Order("started_at ASC").
+		Find(&sessions)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return sessions, nil
+}
+
+// Update saves changes to a focus session
+func (r *gormFocusRepository) Update(session *domain.FocusSession) error {
+	return r.db.Save(session).Error
+}
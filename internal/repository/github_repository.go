@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"github.com/Tomlord1122/todo-backend/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+// GitHubRepository defines the interface for GitHub sync data operations
+type GitHubRepository interface {
+	CreateLink(link *domain.GitHubLink) error
+	FindLinkByList(listID uint) (*domain.GitHubLink, error)
+	FindLinks() ([]domain.GitHubLink, error)
+	FindLinksByRepo(owner, repo string) ([]domain.GitHubLink, error)
+	UpdateLink(link *domain.GitHubLink) error
+	// DeleteLink deletes a link and its sync state; the todos stay
+	DeleteLink(id uint) error
+	CreateSync(sync *domain.GitHubIssueSync) error
+	FindSyncs(linkID uint) ([]domain.GitHubIssueSync, error)
+	UpdateSync(sync *domain.GitHubIssueSync) error
+}
+
+// gormGitHubRepository implements GitHubRepository using GORM
+type gormGitHubRepository struct {
+	db *gorm.DB
+}
+
+// NewGormGitHubRepository creates a new GORM GitHub sync repository
+func NewGormGitHubRepository(db *gorm.DB) GitHubRepository {
+	return &gormGitHubRepository{db: db}
+}
+
+// CreateLink stores a new link
+func (r *gormGitHubRepository) CreateLink(link *domain.GitHubLink) error {
+	return r.db.Create(link).Error
+}
+
+// FindLinkByList retrieves the link of a list
+func (r *gormGitHubRepository) FindLinkByList(listID uint) (*domain.GitHubLink, error) {
+	var link domain.GitHubLink
+	result := r.db.Where("list_id = ?", listID).First(&link)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &link, nil
+}
+
+// FindLinks retrieves all links
+func (r *gormGitHubRepository) FindLinks() ([]domain.GitHubLink, error) {
+	var links []domain.GitHubLink
+	result := r.db.Order("id ASC").Find(&links)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return links, nil
+}
+
+// FindLinksByRepo retrieves the links to a repository, ignoring case as
+// GitHub does
+func (r *gormGitHubRepository) FindLinksByRepo(owner, repo string) ([]domain.GitHubLink, error) {
+	var links []domain.GitHubLink
+	result := r.db.Where("LOWER(owner) = LOWER(?) AND LOWER(repo) = LOWER(?)", owner, repo).Order("id ASC").Find(&links)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return links, nil
+}
+
+// UpdateLink saves changes to a link
+func (r *gormGitHubRepository) UpdateLink(link *domain.GitHubLink) error {
+	return r.db.Save(link).Error
+}
+
+// DeleteLink deletes a link and its sync state
+func (r *gormGitHubRepository) DeleteLink(id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("link_id = ?", id).Delete(&domain.GitHubIssueSync{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&domain.GitHubLink{}, id).Error
+	})
+}
+
+// CreateSync stores the sync state of a newly imported issue
+func (r *gormGitHubRepository) CreateSync(sync *domain.GitHubIssueSync) error {
+	return r.db.Create(sync).Error
+}
+
+// FindSyncs retrieves the sync state of every issue imported by a link
+func (r *gormGitHubRepository) FindSyncs(linkID uint) ([]domain.GitHubIssueSync, error) {
+	var syncs []domain.GitHubIssueSync
+	result := r.db.Where("link_id = ?", linkID).Order("id ASC").Find(&syncs)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return syncs, nil
+}
+
+// UpdateSync saves the state both sides agreed on
+func (r *gormGitHubRepository) UpdateSync(sync *domain.GitHubIssueSync) error {
+	return r.db.Save(sync).Error
+}
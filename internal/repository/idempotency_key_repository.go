@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/Tomlord1122/todo-backend/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+// IdempotencyKeyRepository defines the interface for idempotency key
+// operations
+type IdempotencyKeyRepository interface {
+	// Create reserves a key, failing with gorm.ErrDuplicatedKey when the
+	// user has it already, expired or not
+	Create(key *domain.IdempotencyKey) error
+	// Find retrieves a key of a user
+	Find(userID uint, key string) (*domain.IdempotencyKey, error)
+	// SaveResponse stores the response of a reserved key
+	SaveResponse(key *domain.IdempotencyKey) error
+	// Delete removes a key of a user
+	Delete(userID uint, key string) error
+	// DeleteExpired removes the keys that expired before now, reporting
+	// how many
+	DeleteExpired(now time.Time) (int64, error)
+}
+
+// gormIdempotencyKeyRepository implements IdempotencyKeyRepository using GORM
+type gormIdempotencyKeyRepository struct {
+	db *gorm.DB
+}
+
+// NewGormIdempotencyKeyRepository creates a new GORM idempotency key
+// repository
+func NewGormIdempotencyKeyRepository(db *gorm.DB) IdempotencyKeyRepository {
+	return &gormIdempotencyKeyRepository{db: db}
+}
+
+func (r *gormIdempotencyKeyRepository) Create(key *domain.IdempotencyKey) error {
+	return r.db.Create(key).Error
+}
+
+// Conditions on key are maps, which GORM quotes, as KEY is a reserved word
+// in MySQL.
+func (r *gormIdempotencyKeyRepository) Find(userID uint, key string) (*domain.IdempotencyKey, error) {
+	var found domain.IdempotencyKey
+	if err := r.db.Where(map[string]any{"user_id": userID, "key": key}).First(&found).Error; err != nil {
+		return nil, err
+	}
+	return &found, nil
+}
+
+func (r *gormIdempotencyKeyRepository) SaveResponse(key *domain.IdempotencyKey) error {
+	return r.db.Model(&domain.IdempotencyKey{}).Where(map[string]any{"user_id": key.UserID, "key": key.Key}).
+		Select("status_code", "header", "body").Updates(key).Error
+}
+
+func (r *gormIdempotencyKeyRepository) Delete(userID uint, key string) error {
+	return r.db.Where(map[string]any{"user_id": userID, "key": key}).Delete(&domain.IdempotencyKey{}).Error
+}
+
+func (r *gormIdempotencyKeyRepository) DeleteExpired(now time.Time) (int64, error) {
+	result := r.db.Where("expires_at < ?", now).Delete(&domain.IdempotencyKey{})
+	return result.RowsAffected, result.Error
+}
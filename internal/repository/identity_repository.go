@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"github.com/Tomlord1122/todo-backend/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+// IdentityRepository defines the interface for external identity data
+// operations
+type IdentityRepository interface {
+	Create(identity *domain.ExternalIdentity) error
+	FindByID(id uint) (*domain.ExternalIdentity, error)
+	FindBySubject(provider, subject string) (*domain.ExternalIdentity, error)
+	FindByUserID(userID uint) ([]domain.ExternalIdentity, error)
+	Update(identity *domain.ExternalIdentity) error
+	// Delete deletes an identity and ends the sessions created with it
+	Delete(id uint) error
+}
+
+// gormIdentityRepository implements IdentityRepository using GORM
+type gormIdentityRepository struct {
+	db *gorm.DB
+}
+
+// NewGormIdentityRepository creates a new GORM external identity repository
+func NewGormIdentityRepository(db *gorm.DB) IdentityRepository {
+	return &gormIdentityRepository{db: db}
+}
+
+// Create stores a new identity
+func (r *gormIdentityRepository) Create(identity *domain.ExternalIdentity) error {
+	return r.db.Create(identity).Error
+}
+
+// FindByID retrieves an identity by its ID
+func (r *gormIdentityRepository) FindByID(id uint) (*domain.ExternalIdentity, error) {
+	var identity domain.ExternalIdentity
+	result := r.db.First(&identity, id)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &identity, nil
+}
+
+// FindBySubject retrieves the identity of a provider's user
+func (r *gormIdentityRepository) FindBySubject(provider, subject string) (*domain.ExternalIdentity, error) {
+	var identity domain.ExternalIdentity
+	result := r.db.Where("provider = ? AND subject = ?", provider, subject).First(&identity)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &identity, nil
+}
+
+// FindByUserID retrieves the identities of a user, oldest first
+func (r *gormIdentityRepository) FindByUserID(userID uint) ([]domain.ExternalIdentity, error) {
+	var identities []domain.ExternalIdentity
+	result := r.db.Where("user_id = ?", userID).Order("id ASC").Find(&identities)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return identities, nil
+}
+
+// Update saves changes to an identity
+func (r *gormIdentityRepository) Update(identity *domain.ExternalIdentity) error {
+	return r.db.Save(identity).Error
+}
+
+// Delete deletes an identity and its sessions
+func (r *gormIdentityRepository) Delete(id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("identity_id = ?", id).Delete(&domain.AuthSession{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&domain.ExternalIdentity{}, id).Error
+	})
+}
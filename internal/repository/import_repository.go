@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/Tomlord1122/todo-backend/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+// ImportRepository defines the interface for import data operations
+type ImportRepository interface {
+	Create(imp *domain.Import) error
+	FindByID(id uint) (*domain.Import, error)
+	// FindRunnable retrieves up to limit imports that are pending or were
+	// left running without progress since staleBefore, oldest first
+	FindRunnable(staleBefore time.Time, limit int) ([]domain.Import, error)
+	// Claim moves a runnable import to running. Only the first caller gets
+	// true, so concurrent instances don't import it twice.
+	Claim(id uint, staleBefore time.Time) (bool, error)
+	// SaveChunk creates the todos and errors of a chunk and saves the
+	// import's progress in one transaction, so a crash never imports a row
+	// twice or loses one
+	SaveChunk(imp *domain.Import, todos []domain.Todo, errs []domain.ImportError) error
+	Update(imp *domain.Import) error
+	FindErrors(importID uint) ([]domain.ImportError, error)
+}
+
+// gormImportRepository implements ImportRepository using GORM
+type gormImportRepository struct {
+	db *gorm.DB
+}
+
+// NewGormImportRepository creates a new GORM import repository
+func NewGormImportRepository(db *gorm.DB) ImportRepository {
+	return &gormImportRepository{db: db}
+}
+
+// Create stores a new import
+func (r *gormImportRepository) Create(imp *domain.Import) error {
+	return r.db.Create(imp).Error
+}
+
+// FindByID retrieves an import
+func (r *gormImportRepository) FindByID(id uint) (*domain.Import, error) {
+	var imp domain.Import
+	result := r.db.First(&imp, id)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &imp, nil
+}
+
+// FindRunnable retrieves imports that are pending or abandoned
+func (r *gormImportRepository) FindRunnable(staleBefore time.Time, limit int) ([]domain.Import, error) {
+	var imports []domain.Import
+	result := r.db.Where("status = ? OR (status = ? AND updated_at < ?)", domain.ImportPending, domain.ImportRunning, staleBefore).
+		Order("id ASC").Limit(limit).Find(&imports)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return imports, nil
+}
+
+// Claim moves a runnable import to running
+func (r *gormImportRepository) Claim(id uint, staleBefore time.Time) (bool, error) {
+	result := r.db.Model(&domain.Import{}).
+		Where("id = ? AND (status = ? OR (status = ? AND updated_at < ?))", id, domain.ImportPending, domain.ImportRunning, staleBefore).
+		Update("status", domain.ImportRunning)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// SaveChunk creates a chunk's todos and errors and saves the progress
+func (r *gormImportRepository) SaveChunk(imp *domain.Import, todos []domain.Todo, errs []domain.ImportError) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if len(todos) > 0 {
+			if err := tx.Create(&todos).Error; err != nil {
+				return err
+			}
+		}
+		if len(errs) > 0 {
+			if err := tx.Create(&errs).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Save(imp).Error
+	})
+}
+
+// Update saves changes to an import
+func (r *gormImportRepository) Update(imp *domain.Import) error {
+	return r.db.Save(imp).Error
+}
+
+// FindErrors retrieves the errors of an import in row order
+func (r *gormImportRepository) FindErrors(importID uint) ([]domain.ImportError, error) {
+	var errs []domain.ImportError
+	result := r.db.Where("import_id = ?", importID).Order("line ASC").Find(&errs)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return errs, nil
+}
@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"github.com/Tomlord1122/todo-backend/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+// InboundHookRepository defines the interface for inbound hook data operations
+type InboundHookRepository interface {
+	Create(hook *domain.InboundHook) error
+	FindByToken(token string) (*domain.InboundHook, error)
+	DeleteByToken(token string) error
+}
+
+// gormInboundHookRepository implements InboundHookRepository using GORM
+type gormInboundHookRepository struct {
+	db *gorm.DB
+}
+
+// NewGormInboundHookRepository creates a new GORM inbound hook repository
+func NewGormInboundHookRepository(db *gorm.DB) InboundHookRepository {
+	return &gormInboundHookRepository{db: db}
+}
+
+// Create stores a new inbound hook
+func (r *gormInboundHookRepository) Create(hook *domain.InboundHook) error {
+	return r.db.Create(hook).Error
+}
+
+// FindByToken looks up an inbound hook by its secret token
+func (r *gormInboundHookRepository) FindByToken(token string) (*domain.InboundHook, error) {
+	var hook domain.InboundHook
+	result := r.db.Where("token = ?", token).First(&hook)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &hook, nil
+}
+
+// DeleteByToken revokes an inbound hook. It returns gorm.ErrRecordNotFound
+// when no hook matched so callers can report it.
+func (r *gormInboundHookRepository) DeleteByToken(token string) error {
+	result := r.db.Where("token = ?", token).Delete(&domain.InboundHook{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/Tomlord1122/todo-backend/internal/domain"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// JobRepository defines the interface for job queue operations
+type JobRepository interface {
+	// Enqueue stores a new pending job. A job whose Key is taken is
+	// skipped, so enqueueing it again is harmless.
+	Enqueue(job *domain.Job) error
+	FindByID(id uint) (*domain.Job, error)
+	// Claim claims up to limit jobs of the given kinds due at now until
+	// until, oldest first, and counts the attempt. Running jobs whose claim
+	// ran out are due again. Concurrent callers never claim the same job.
+	Claim(kinds []string, now, until time.Time, limit int) ([]domain.Job, error)
+	// Extend extends the claims of running jobs until until
+	Extend(ids []uint, until time.Time) error
+	Update(job *domain.Job) error
+	// DeleteFinishedBefore removes the jobs done or failed before before
+	// for good and returns how many it removed
+	DeleteFinishedBefore(before time.Time) (int64, error)
+}
+
+// gormJobRepository implements JobRepository using GORM
+type gormJobRepository struct {
+	db *gorm.DB
+}
+
+// NewGormJobRepository creates a new GORM job repository
+func NewGormJobRepository(db *gorm.DB) JobRepository {
+	return &gormJobRepository{db: db}
+}
+
+// Enqueue stores a new job, skipping it if its key is taken
+func (r *gormJobRepository) Enqueue(job *domain.Job) error {
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(job).Error
+}
+
+// FindByID retrieves a job by its ID
+func (r *gormJobRepository) FindByID(id uint) (*domain.Job, error) {
+	var job domain.Job
+	result := r.db.First(&job, id)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &job, nil
+}
+
+// Claim locks the due jobs with FOR UPDATE SKIP LOCKED, so concurrent
+// claims pass over each other's rows instead of waiting for them, and
+// marks them running
+func (r *gormJobRepository) Claim(kinds []string, now, until time.Time, limit int) ([]domain.Job, error) {
+	var jobs []domain.Job
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("kind IN ? AND ((status = ? AND run_at <= ?) OR (status = ? AND locked_until < ?))", kinds, domain.JobPending, now, domain.JobRunning, now).
+			Order("run_at ASC, id ASC").Limit(limit).Find(&jobs).Error
+		if err != nil || len(jobs) == 0 {
+			return err
+		}
+		ids := make([]uint, len(jobs))
+		for i := range jobs {
+			ids[i] = jobs[i].ID
+		}
+		return tx.Model(&domain.Job{}).Where("id IN ?", ids).
+			Updates(map[string]any{"status": domain.JobRunning, "locked_until": until, "attempts": gorm.Expr("attempts + 1")}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	for i := range jobs {
+		jobs[i].Status, jobs[i].LockedUntil = domain.JobRunning, &until
+		jobs[i].Attempts++
+	}
+	return jobs, nil
+}
+
+// Extend extends the claims of running jobs
+func (r *gormJobRepository) Extend(ids []uint, until time.Time) error {
+	return r.db.Model(&domain.Job{}).Where("id IN ? AND status = ?", ids, domain.JobRunning).
+		Update("locked_until", until).Error
+}
+
+// Update saves changes to a job
+func (r *gormJobRepository) Update(job *domain.Job) error {
+	return r.db.Save(job).Error
+}
+
+// DeleteFinishedBefore hard-deletes old finished jobs
+func (r *gormJobRepository) DeleteFinishedBefore(before time.Time) (int64, error) {
+	result := r.db.Unscoped().Where("status IN ? AND finished_at < ?", []string{domain.JobDone, domain.JobFailed}, before).Delete(&domain.Job{})
+	return result.RowsAffected, result.Error
+}
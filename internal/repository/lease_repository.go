@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/Tomlord1122/todo-backend/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+// LeaseRepository defines the interface for lease operations
+type LeaseRepository interface {
+	// Acquire takes the lease name for holder, or extends it when holder
+	// already has it, until ttl from now. It reports false while another
+	// holder's lease hasn't expired.
+	Acquire(name, holder string, ttl time.Duration) (bool, error)
+	// Release gives the lease up if holder has it
+	Release(name, holder string) error
+}
+
+// gormLeaseRepository implements LeaseRepository using GORM
+type gormLeaseRepository struct {
+	db *gorm.DB
+}
+
+// NewGormLeaseRepository creates a new GORM lease repository
+func NewGormLeaseRepository(db *gorm.DB) LeaseRepository {
+	return &gormLeaseRepository{db: db}
+}
+
+// Acquire takes or extends a lease in one upsert. Expiry is computed with
+// the database's clock, so instances with skewed clocks agree on it.
+func (r *gormLeaseRepository) Acquire(name, holder string, ttl time.Duration) (bool, error) {
+	if isMySQL(r.db) {
+		return r.acquireMySQL(name, holder, ttl)
+	}
+	result := r.db.Exec(`INSERT INTO leases (name, holder, expires_at) VALUES (?, ?, now() + make_interval(secs => ?))
+		ON CONFLICT (name) DO UPDATE SET holder = EXCLUDED.holder, expires_at = EXCLUDED.expires_at
+		WHERE leases.holder = EXCLUDED.holder OR leases.expires_at < now()`, name, holder, ttl.Seconds())
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// acquireMySQL is Acquire on MySQL, whose upsert has no WHERE clause, so
+// each column keeps its value unless the lease may be taken. Assignments
+// see the ones before them: holder must be set first, while the
+// condition still reads the old row. A row left unchanged counts as not
+// affected, which is also the case when holder renews within the same
+// millisecond, so that is checked separately.
+func (r *gormLeaseRepository) acquireMySQL(name, holder string, ttl time.Duration) (bool, error) {
+	result := r.db.Exec(`INSERT INTO leases (name, holder, expires_at) VALUES (?, ?, NOW(3) + INTERVAL ? MICROSECOND)
+		ON DUPLICATE KEY UPDATE
+		holder = IF(holder = VALUES(holder) OR expires_at < NOW(3), VALUES(holder), holder),
+		expires_at = IF(holder = VALUES(holder), VALUES(expires_at), expires_at)`, name, holder, ttl.Microseconds())
+	if result.Error != nil || result.RowsAffected > 0 {
+		return result.Error == nil, result.Error
+	}
+	var held int64
+	err := r.db.Model(&domain.Lease{}).Where("name = ? AND holder = ?", name, holder).Count(&held).Error
+	return held > 0, err
+}
+
+// Release deletes the lease if holder has it
+func (r *gormLeaseRepository) Release(name, holder string) error {
+	return r.db.Where("name = ? AND holder = ?", name, holder).Delete(&domain.Lease{}).Error
+}
@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Tomlord1122/todo-backend/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+// ListRepository defines the interface for list data operations
+type ListRepository interface {
+	Create(list *domain.List) error
+	FindByID(id uint) (*domain.List, error)
+	FindByUserID(userID uint, opts ListOptions) ([]domain.List, error)
+	Update(list *domain.List) error
+	Delete(id uint) error
+}
+
+// gormListRepository implements ListRepository using GORM
+type gormListRepository struct {
+	db *gorm.DB
+}
+
+// NewGormListRepository creates a new GORM list repository
+func NewGormListRepository(db *gorm.DB) ListRepository {
+	return &gormListRepository{db: db}
+}
+
+// Create adds a new list to the database
+func (r *gormListRepository) Create(list *domain.List) error {
+	return r.db.Create(list).Error
+}
+
+// FindByID retrieves a list by its ID
+func (r *gormListRepository) FindByID(id uint) (*domain.List, error) {
+	var list domain.List
+	result := r.db.First(&list, id)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &list, nil
+}
+
+// FindByUserID retrieves all lists owned by a user, ordered by name
+func (r *gormListRepository) FindByUserID(userID uint, opts ListOptions) ([]domain.List, error) {
+	var lists []domain.List
+	result := opts.scope(r.db).Where("user_id = ?", userID).Order("name ASC").Find(&lists)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return lists, nil
+}
+
+// Update saves changes to an existing list
+func (r *gormListRepository) Update(list *domain.List) error {
+	return r.db.Save(list).Error
+}
+
+// Delete removes a list and moves its todos out of it, in one transaction
+func (r *gormListRepository) Delete(id uint) error {
+	return RunInTransaction(context.Background(), r.db, func(tx *gorm.DB) error {
+		if err := tx.Model(&domain.Todo{}).Where("list_id = ?", id).Update("list_id", nil).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&domain.List{}, id).Error
+	})
+}
@@ -0,0 +1,1865 @@
+package repository
+
+import (
+	"cmp"
+	"context"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"gorm.io/gorm"
+
+	"github.com/Tomlord1122/todo-backend/internal/domain"
+	"github.com/Tomlord1122/todo-backend/internal/geo"
+)
+
+// memoryTable is a goroutine-safe in-memory table for models embedding
+// gorm.Model. It assigns IDs and timestamps and soft-deletes the way GORM
+// would and, like the GORM repositories, reports missing rows as
+// gorm.ErrRecordNotFound.
+// Rows are stored by value so callers can't modify them behind its back.
+type memoryTable[T any] struct {
+	mu     sync.RWMutex
+	nextID uint
+	rows   map[uint]T
+	model  func(*T) *gorm.Model
+}
+
+func newMemoryTable[T any](model func(*T) *gorm.Model) *memoryTable[T] {
+	return &memoryTable[T]{nextID: 1, rows: make(map[uint]T), model: model}
+}
+
+func (t *memoryTable[T]) create(row *T) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	m := t.model(row)
+	if m.ID == 0 {
+		m.ID = t.nextID
+	}
+	t.nextID = max(t.nextID, m.ID+1)
+	now := time.Now()
+	if m.CreatedAt.IsZero() {
+		m.CreatedAt = now
+	}
+	m.UpdatedAt = now
+	t.rows[m.ID] = *row
+	return nil
+}
+
+func (t *memoryTable[T]) find(id uint) (*T, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	row, ok := t.rows[id]
+	if !ok || t.model(&row).DeletedAt.Valid {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &row, nil
+}
+
+// save updates a row, or inserts it when the ID is zero, like gorm's Save.
+func (t *memoryTable[T]) save(row *T) error {
+	if t.model(row).ID == 0 {
+		return t.create(row)
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.model(row).UpdatedAt = time.Now()
+	t.rows[t.model(row).ID] = *row
+	return nil
+}
+
+// saveIf saves an existing row if match approves of the stored one,
+// like a conditional UPDATE, reporting whether it did. match may copy
+// stored fields to row.
+func (t *memoryTable[T]) saveIf(row *T, match func(stored *T) bool) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	stored, ok := t.rows[t.model(row).ID]
+	if !ok || t.model(&stored).DeletedAt.Valid || !match(&stored) {
+		return false
+	}
+	t.model(row).UpdatedAt = time.Now()
+	t.rows[t.model(row).ID] = *row
+	return true
+}
+
+// delete soft-deletes a row, keeping it as a tombstone like GORM does.
+func (t *memoryTable[T]) delete(id uint) bool {
+	return t.deleteIf(id, func(*T) bool { return true })
+}
+
+// deleteIf soft-deletes a row if match approves of it.
+func (t *memoryTable[T]) deleteIf(id uint, match func(*T) bool) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	row, ok := t.rows[id]
+	if !ok || t.model(&row).DeletedAt.Valid || !match(&row) {
+		return false
+	}
+	t.model(&row).DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+	t.rows[id] = row
+	return true
+}
+
+// findDeleted returns a soft-deleted row, like an Unscoped First on
+// deleted_at IS NOT NULL.
+func (t *memoryTable[T]) findDeleted(id uint) (*T, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	row, ok := t.rows[id]
+	if !ok || !t.model(&row).DeletedAt.Valid {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &row, nil
+}
+
+// restore undeletes a soft-deleted row.
+func (t *memoryTable[T]) restore(id uint) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	row, ok := t.rows[id]
+	if !ok || !t.model(&row).DeletedAt.Valid {
+		return false
+	}
+	t.model(&row).DeletedAt = gorm.DeletedAt{}
+	t.rows[id] = row
+	return true
+}
+
+// purge removes the matching rows for good, tombstones included, and
+// returns how many it removed.
+func (t *memoryTable[T]) purge(match func(*T) bool) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	purged := 0
+	for id, row := range t.rows {
+		if match(&row) {
+			delete(t.rows, id)
+			purged++
+		}
+	}
+	return purged
+}
+
+// where returns the matching live rows ordered by ID.
+func (t *memoryTable[T]) where(match func(*T) bool) []T {
+	return t.whereWith(ListOptions{}, match)
+}
+
+// whereWith is where, also returning tombstones when opts asks for them.
+func (t *memoryTable[T]) whereWith(opts ListOptions, match func(*T) bool) []T {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	var rows []T
+	for _, row := range t.rows {
+		if deleted := t.model(&row).DeletedAt; deleted.Valid &&
+			(opts.DeletedSince == nil || !deleted.Time.After(*opts.DeletedSince)) {
+			continue
+		}
+		if match(&row) {
+			rows = append(rows, row)
+		}
+	}
+	slices.SortFunc(rows, func(a, b T) int {
+		return cmp.Compare(t.model(&a).ID, t.model(&b).ID)
+	})
+	return rows
+}
+
+// update applies fn to every matching live row in place and returns how
+// many rows it changed.
+func (t *memoryTable[T]) update(match func(*T) bool, fn func(*T)) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	updated := 0
+	for id, row := range t.rows {
+		if !t.model(&row).DeletedAt.Valid && match(&row) {
+			fn(&row)
+			t.rows[id] = row
+			updated++
+		}
+	}
+	return updated
+}
+
+// reset deletes every row and restarts IDs at 1.
+func (t *memoryTable[T]) reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.nextID = 1
+	clear(t.rows)
+}
+
+func limitRows[T any](rows []T, limit int) []T {
+	if limit > 0 && len(rows) > limit {
+		return rows[:limit]
+	}
+	return rows
+}
+
+// NewMemoryRepositories creates an empty set of in-memory repositories.
+// They share state (deleting a list detaches its todos) and need no
+// database, which makes them suitable for demos and tests.
+func NewMemoryRepositories() *Repositories {
+	todos := newMemoryTodoRepository()
+	subtasks := &memorySubtaskRepository{table: todos.subtasks}
+	outbox := todos.outbox
+	lists := &memoryListRepository{table: newMemoryTable(func(l *domain.List) *gorm.Model { return &l.Model }), todos: todos}
+	feedTokens := &memoryFeedTokenRepository{table: newMemoryTable(func(f *domain.FeedToken) *gorm.Model { return &f.Model })}
+	schedules := &memoryReportScheduleRepository{table: newMemoryTable(func(s *domain.ReportSchedule) *gorm.Model { return &s.Model })}
+	prefs := &memoryPreferenceRepository{prefs: make(map[uint]domain.UserPreference)}
+	attachments := &memoryAttachmentRepository{table: newMemoryTable(func(a *domain.Attachment) *gorm.Model { return &a.Model })}
+	checklists := &memoryChecklistRepository{table: newMemoryTable(func(c *domain.ChecklistItem) *gorm.Model { return &c.Model })}
+	reactions := &memoryReactionRepository{table: newMemoryTable(func(r *domain.Reaction) *gorm.Model { return &r.Model })}
+	activities := &memoryActivityRepository{table: newMemoryTable(func(a *domain.Activity) *gorm.Model { return &a.Model })}
+	focus := &memoryFocusRepository{table: newMemoryTable(func(f *domain.FocusSession) *gorm.Model { return &f.Model })}
+	hooks := &memoryInboundHookRepository{table: newMemoryTable(func(h *domain.InboundHook) *gorm.Model { return &h.Model })}
+	github := &memoryGitHubRepository{
+		links: newMemoryTable(func(l *domain.GitHubLink) *gorm.Model { return &l.Model }),
+		syncs: newMemoryTable(func(s *domain.GitHubIssueSync) *gorm.Model { return &s.Model }),
+	}
+	calendars := &memoryCalendarRepository{
+		conns:  newMemoryTable(func(c *domain.CalendarConnection) *gorm.Model { return &c.Model }),
+		events: newMemoryTable(func(e *domain.CalendarEvent) *gorm.Model { return &e.Model }),
+	}
+	notionExports := &memoryNotionExportRepository{
+		exports: newMemoryTable(func(e *domain.NotionExport) *gorm.Model { return &e.Model }),
+		rows:    newMemoryTable(func(r *domain.NotionExportRow) *gorm.Model { return &r.Model }),
+	}
+	imports := &memoryImportRepository{
+		imports: newMemoryTable(func(i *domain.Import) *gorm.Model { return &i.Model }),
+		errors:  newMemoryTable(func(e *domain.ImportError) *gorm.Model { return &e.Model }),
+		todos:   todos,
+	}
+	leases := &memoryLeaseRepository{leases: make(map[string]domain.Lease)}
+	idempotencyKeys := &memoryIdempotencyKeyRepository{keys: make(map[idempotencyKeyID]domain.IdempotencyKey)}
+	sessions := &memorySessionRepository{table: newMemoryTable(func(s *domain.AuthSession) *gorm.Model { return &s.Model })}
+	passkeys := &memoryPasskeyRepository{
+		table:    newMemoryTable(func(p *domain.Passkey) *gorm.Model { return &p.Model }),
+		sessions: sessions.table,
+	}
+	watchers := &memoryWatcherRepository{table: newMemoryTable(func(w *domain.TodoWatcher) *gorm.Model { return &w.Model })}
+	users := &memoryUserRepository{table: newMemoryTable(func(u *domain.User) *gorm.Model { return &u.Model })}
+	tags := &memoryTagRepository{
+		table: newMemoryTable(func(t *domain.Tag) *gorm.Model { return &t.Model }),
+		todos: todos.table,
+	}
+	reminders := &memoryReminderRepository{table: newMemoryTable(func(r *domain.Reminder) *gorm.Model { return &r.Model })}
+	todos.purgeDependents = func(todoID uint) {
+		checklists.table.purge(func(c *domain.ChecklistItem) bool { return c.TodoID == todoID })
+		reminders.table.purge(func(r *domain.Reminder) bool { return r.TodoID == todoID })
+		reactions.table.purge(func(r *domain.Reaction) bool { return r.TodoID == todoID })
+		watchers.table.purge(func(w *domain.TodoWatcher) bool { return w.TodoID == todoID })
+	}
+	apiKeys := &memoryAPIKeyRepository{table: newMemoryTable(func(k *domain.APIKey) *gorm.Model { return &k.Model })}
+	webhooks := &memoryWebhookRepository{
+		webhooks:   newMemoryTable(func(w *domain.Webhook) *gorm.Model { return &w.Model }),
+		deliveries: newMemoryTable(func(d *domain.WebhookDelivery) *gorm.Model { return &d.Model }),
+	}
+	jobs := &memoryJobRepository{table: newMemoryTable(func(j *domain.Job) *gorm.Model { return &j.Model })}
+	identities := &memoryIdentityRepository{
+		table:    newMemoryTable(func(i *domain.ExternalIdentity) *gorm.Model { return &i.Model }),
+		sessions: sessions.table,
+	}
+	return &Repositories{
+		Todos:           todos,
+		Lists:           lists,
+		FeedTokens:      feedTokens,
+		ReportSchedules: schedules,
+		Preferences:     prefs,
+		Attachments:     attachments,
+		Checklists:      checklists,
+		Reactions:       reactions,
+		Activities:      activities,
+		FocusSessions:   focus,
+		InboundHooks:    hooks,
+		GitHub:          github,
+		Calendars:       calendars,
+		NotionExports:   notionExports,
+		Imports:         imports,
+		Leases:          leases,
+		Passkeys:        passkeys,
+		Sessions:        sessions,
+		Identities:      identities,
+		Watchers:        watchers,
+		Users:           users,
+		APIKeys:         apiKeys,
+		Tags:            tags,
+		Subtasks:        subtasks,
+		Reminders:       reminders,
+		IdempotencyKeys: idempotencyKeys,
+		Outbox:          outbox,
+		Webhooks:        webhooks,
+		Jobs:            jobs,
+		reset: func() error {
+			todos.table.reset()
+			lists.table.reset()
+			feedTokens.table.reset()
+			schedules.table.reset()
+			attachments.table.reset()
+			checklists.table.reset()
+			subtasks.table.reset()
+			reminders.table.reset()
+			outbox.table.reset()
+			reactions.table.reset()
+			activities.table.reset()
+			focus.table.reset()
+			hooks.table.reset()
+			github.links.reset()
+			github.syncs.reset()
+			calendars.conns.reset()
+			calendars.events.reset()
+			notionExports.exports.reset()
+			notionExports.rows.reset()
+			imports.imports.reset()
+			imports.errors.reset()
+			passkeys.table.reset()
+			sessions.table.reset()
+			identities.table.reset()
+			watchers.table.reset()
+			users.table.reset()
+			apiKeys.table.reset()
+			webhooks.webhooks.reset()
+			webhooks.deliveries.reset()
+			jobs.table.reset()
+			tags.table.reset()
+			prefs.mu.Lock()
+			clear(prefs.prefs)
+			prefs.mu.Unlock()
+			leases.mu.Lock()
+			clear(leases.leases)
+			leases.mu.Unlock()
+			idempotencyKeys.mu.Lock()
+			clear(idempotencyKeys.keys)
+			idempotencyKeys.mu.Unlock()
+			return nil
+		},
+	}
+}
+
+// NewInMemoryTodoRepository creates an empty TodoRepository in memory, for
+// tests of code that only needs todos. Its subtasks and outbox events are
+// kept but can't be reached through other repositories; use
+// NewMemoryRepositories when they must be.
+func NewInMemoryTodoRepository() TodoRepository {
+	return newMemoryTodoRepository()
+}
+
+func newMemoryTodoRepository() *memoryTodoRepository {
+	return &memoryTodoRepository{
+		table:    newMemoryTable(func(t *domain.Todo) *gorm.Model { return &t.Model }),
+		subtasks: newMemoryTable(func(s *domain.Subtask) *gorm.Model { return &s.Model }),
+		outbox:   &memoryOutboxRepository{table: newMemoryTable(func(e *domain.OutboxEvent) *gorm.Model { return &e.Model })},
+	}
+}
+
+// memoryTodoRepository implements TodoRepository in memory
+type memoryTodoRepository struct {
+	table    *memoryTable[domain.Todo]
+	subtasks *memoryTable[domain.Subtask]
+	outbox   *memoryOutboxRepository
+	// purgeDependents removes the other rows of a purged todo
+	purgeDependents func(todoID uint)
+}
+
+func (r *memoryTodoRepository) Create(todo *domain.Todo) error {
+	if todo.Priority == "" {
+		todo.Priority = "normal"
+	}
+	if todo.Version == 0 {
+		todo.Version = 1
+	}
+	return r.table.create(todo)
+}
+
+func (r *memoryTodoRepository) FindByID(id uint) (*domain.Todo, error) {
+	todo, err := r.table.find(id)
+	if err != nil {
+		return nil, err
+	}
+	todo.Subtasks = r.subtasks.where(func(s *domain.Subtask) bool { return s.TodoID == id })
+	return todo, nil
+}
+
+func (r *memoryTodoRepository) GetAll() ([]domain.Todo, error) {
+	return r.table.where(func(*domain.Todo) bool { return true }), nil
+}
+
+func (r *memoryTodoRepository) Find(filter TodoFilter) ([]domain.Todo, error) {
+	todos := r.table.whereWith(filter.ListOptions, func(t *domain.Todo) bool {
+		return t.ID > filter.AfterID && filter.matches(t)
+	})
+	slices.SortStableFunc(todos, compareTodos(filter.Sort))
+	todos = todos[min(filter.Offset, len(todos)):]
+	return limitRows(todos, filter.Limit), nil
+}
+
+func (r *memoryTodoRepository) Count(filter TodoFilter) (int64, error) {
+	return int64(len(r.table.whereWith(filter.ListOptions, filter.matches))), nil
+}
+
+func (r *memoryTodoRepository) Update(todo *domain.Todo) error {
+	// Like the GORM repository, leave the tags to SetTags and never store
+	// the subtasks FindByID loaded
+	updated := *todo
+	updated.Subtasks = nil
+	updated.Version++
+	saved := r.table.saveIf(&updated, func(stored *domain.Todo) bool {
+		updated.Tags = stored.Tags
+		return stored.Version == todo.Version
+	})
+	if !saved {
+		return ErrVersionConflict
+	}
+	todo.Version, todo.UpdatedAt = updated.Version, updated.UpdatedAt
+	return nil
+}
+
+func (r *memoryTodoRepository) SetTags(todoID uint, tags []domain.Tag) error {
+	tags = slices.Clone(tags)
+	slices.SortFunc(tags, func(a, b domain.Tag) int { return strings.Compare(a.Name, b.Name) })
+	r.table.update(func(t *domain.Todo) bool { return t.ID == todoID }, func(t *domain.Todo) { t.Tags = tags })
+	return nil
+}
+
+func (r *memoryTodoRepository) Delete(todo *domain.Todo) error {
+	if !r.table.deleteIf(todo.ID, func(stored *domain.Todo) bool { return stored.Version == todo.Version }) {
+		return ErrVersionConflict
+	}
+	return nil
+}
+
+func (r *memoryTodoRepository) CreateMany(todos []*domain.Todo) error {
+	for _, todo := range todos {
+		if err := r.Create(todo); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *memoryTodoRepository) UpdateMany(todos []*domain.Todo) error {
+	for _, todo := range todos {
+		if err := r.Update(todo); err != nil {
+			return err
+		}
+		if err := r.SetTags(todo.ID, todo.Tags); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *memoryTodoRepository) DeleteMany(ids []uint) error {
+	for _, id := range ids {
+		r.table.delete(id)
+	}
+	return nil
+}
+
+func (r *memoryTodoRepository) FindTrashed(userID uint) ([]domain.Todo, error) {
+	todos := r.table.whereWith(ListOptions{DeletedSince: &time.Time{}}, func(t *domain.Todo) bool {
+		return t.UserID == userID && t.DeletedAt.Valid
+	})
+	slices.SortStableFunc(todos, func(a, b domain.Todo) int {
+		return cmp.Or(b.DeletedAt.Time.Compare(a.DeletedAt.Time), cmp.Compare(b.ID, a.ID))
+	})
+	return todos, nil
+}
+
+func (r *memoryTodoRepository) FindTrashedByID(id uint) (*domain.Todo, error) {
+	return r.table.findDeleted(id)
+}
+
+func (r *memoryTodoRepository) Restore(id uint) (bool, error) {
+	return r.table.restore(id), nil
+}
+
+func (r *memoryTodoRepository) Purge(id uint) error {
+	r.table.purge(func(t *domain.Todo) bool { return t.ID == id })
+	r.subtasks.purge(func(s *domain.Subtask) bool { return s.TodoID == id })
+	if r.purgeDependents != nil {
+		r.purgeDependents(id)
+	}
+	return nil
+}
+
+func (r *memoryTodoRepository) FindDueBetween(userID uint, from, to time.Time) ([]domain.Todo, error) {
+	todos := r.table.where(func(t *domain.Todo) bool {
+		return t.UserID == userID && !t.Completed && t.DueDate != nil && !t.DueDate.Before(from) && t.DueDate.Before(to)
+	})
+	slices.SortStableFunc(todos, func(a, b domain.Todo) int { return a.DueDate.Compare(*b.DueDate) })
+	return todos, nil
+}
+
+func (r *memoryTodoRepository) FindCompletedSince(userID uint, since time.Time) ([]domain.Todo, error) {
+	todos := r.table.where(func(t *domain.Todo) bool {
+		return t.UserID == userID && t.Completed && t.CompletedAt != nil && !t.CompletedAt.Before(since)
+	})
+	slices.SortStableFunc(todos, func(a, b domain.Todo) int { return b.CompletedAt.Compare(*a.CompletedAt) })
+	return todos, nil
+}
+
+func (r *memoryTodoRepository) FindCompletedBetween(userID uint, from, to time.Time) ([]domain.Todo, error) {
+	todos := r.table.where(func(t *domain.Todo) bool {
+		return t.UserID == userID && t.Completed && t.CompletedAt != nil && !t.CompletedAt.Before(from) && t.CompletedAt.Before(to)
+	})
+	slices.SortStableFunc(todos, func(a, b domain.Todo) int { return a.CompletedAt.Compare(*b.CompletedAt) })
+	return todos, nil
+}
+
+func (r *memoryTodoRepository) FindOpenByUser(userID uint) ([]domain.Todo, error) {
+	todos := r.table.where(func(t *domain.Todo) bool { return t.UserID == userID && !t.Completed })
+	// Todos without a due date sort last
+	slices.SortStableFunc(todos, func(a, b domain.Todo) int {
+		switch {
+		case a.DueDate == nil && b.DueDate == nil:
+			return 0
+		case a.DueDate == nil:
+			return 1
+		case b.DueDate == nil:
+			return -1
+		}
+		return a.DueDate.Compare(*b.DueDate)
+	})
+	return todos, nil
+}
+
+func (r *memoryTodoRepository) FindByListID(listID uint) ([]domain.Todo, error) {
+	return r.table.where(func(t *domain.Todo) bool { return t.ListID != nil && *t.ListID == listID }), nil
+}
+
+func (r *memoryTodoRepository) FindScheduledByUser(userID uint) ([]domain.Todo, error) {
+	return r.table.where(func(t *domain.Todo) bool { return t.UserID == userID && t.DueDate != nil }), nil
+}
+
+func (r *memoryTodoRepository) ClaimOverdueNotification(id uint, at time.Time) (bool, error) {
+	claimed := r.table.update(func(t *domain.Todo) bool {
+		return t.ID == id && t.OverdueNotifiedAt == nil
+	}, func(t *domain.Todo) {
+		t.OverdueNotifiedAt = &at
+	})
+	return claimed > 0, nil
+}
+
+func (r *memoryTodoRepository) FindPendingOccurrences(limit int) ([]domain.Todo, error) {
+	todos := r.table.where(func(t *domain.Todo) bool { return t.NextOccurrence != nil })
+	return todos[:min(limit, len(todos))], nil
+}
+
+func (r *memoryTodoRepository) CreateOccurrence(fromID uint, next *domain.Todo) (bool, error) {
+	claimed := r.table.update(func(t *domain.Todo) bool {
+		return t.ID == fromID && t.NextOccurrence != nil
+	}, func(t *domain.Todo) {
+		t.Recurrence, t.NextOccurrence = "", nil
+	})
+	if claimed == 0 {
+		return false, nil
+	}
+	return true, r.Create(next)
+}
+
+// Transaction implements TodoRepository. Memory repositories have no
+// rollback, so changes fn made before failing are kept.
+func (r *memoryTodoRepository) Transaction(fn func(todos TodoRepository, outbox OutboxRepository) error) error {
+	return fn(r, r.outbox)
+}
+
+// WithContext returns r; in-memory queries neither block nor get traced
+func (r *memoryTodoRepository) WithContext(ctx context.Context) TodoRepository {
+	return r
+}
+
+func (r *memoryTodoRepository) Search(query string, filter TodoSearch) ([]TodoMatch, int64, error) {
+	var matches []TodoMatch
+	queryTrigrams, queryWords := trigrams(query), words(query)
+	for _, todo := range r.table.whereWith(filter.ListOptions, filter.matches) {
+		var score float64
+		if filter.Fuzzy {
+			score = wordSimilarity(queryTrigrams, trigrams(todo.Title))
+		} else {
+			score = textRank(queryWords, words(todo.Title), words(todo.Description))
+		}
+		if score > 0 && score >= filter.Threshold {
+			matches = append(matches, TodoMatch{Todo: todo, Score: score})
+		}
+	}
+	slices.SortStableFunc(matches, func(a, b TodoMatch) int { return cmp.Compare(b.Score, a.Score) })
+	total := int64(len(matches))
+	matches = matches[min(filter.Offset, len(matches)):]
+	return limitRows(matches, filter.Limit), total, nil
+}
+
+func (r *memoryTodoRepository) FindNearby(lat, lng, radius float64, userID uint, limit int) ([]TodoDistance, error) {
+	var nearby []TodoDistance
+	for _, todo := range r.table.where(func(t *domain.Todo) bool {
+		return !t.Completed && t.Latitude != nil && t.Longitude != nil && (userID == 0 || t.UserID == userID)
+	}) {
+		distance := geo.Distance(lat, lng, *todo.Latitude, *todo.Longitude)
+		within := radius
+		if within == 0 {
+			within = geo.DefaultRadius
+			if todo.RadiusMeters != nil {
+				within = *todo.RadiusMeters
+			}
+		}
+		if distance <= within {
+			nearby = append(nearby, TodoDistance{Todo: todo, Distance: distance})
+		}
+	}
+	slices.SortStableFunc(nearby, func(a, b TodoDistance) int { return cmp.Compare(a.Distance, b.Distance) })
+	return limitRows(nearby, limit), nil
+}
+
+// words splits s into lower-cased words.
+func words(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// textRank approximates full-text search: every query word must appear in
+// the title or description, and title words count more, like the weights
+// of the search_vector column. There is no stemming or query syntax.
+func textRank(query, title, description []string) float64 {
+	if len(query) == 0 {
+		return 0
+	}
+	var rank float64
+	for _, word := range query {
+		switch {
+		case slices.Contains(title, word):
+			rank += 1
+		case slices.Contains(description, word):
+			rank += 0.4
+		default:
+			return 0
+		}
+	}
+	return rank / float64(len(query))
+}
+
+// trigrams returns the pg_trgm trigram set of s: every word is lower-cased
+// and padded with two spaces in front and one behind.
+func trigrams(s string) map[string]bool {
+	set := make(map[string]bool)
+	for _, word := range words(s) {
+		padded := []rune("  " + word + " ")
+		for i := 0; i+3 <= len(padded); i++ {
+			set[string(padded[i:i+3])] = true
+		}
+	}
+	return set
+}
+
+// wordSimilarity approximates pg_trgm's word_similarity: the share of the
+// query's trigrams that appear in the text.
+func wordSimilarity(query, text map[string]bool) float64 {
+	if len(query) == 0 {
+		return 0
+	}
+	shared := 0
+	for trigram := range query {
+		if text[trigram] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(query))
+}
+
+// memoryListRepository implements ListRepository in memory
+type memoryListRepository struct {
+	table *memoryTable[domain.List]
+	todos *memoryTodoRepository
+}
+
+func (r *memoryListRepository) Create(list *domain.List) error {
+	return r.table.create(list)
+}
+
+func (r *memoryListRepository) FindByID(id uint) (*domain.List, error) {
+	return r.table.find(id)
+}
+
+func (r *memoryListRepository) FindByUserID(userID uint, opts ListOptions) ([]domain.List, error) {
+	lists := r.table.whereWith(opts, func(l *domain.List) bool { return l.UserID == userID })
+	slices.SortStableFunc(lists, func(a, b domain.List) int { return cmp.Compare(a.Name, b.Name) })
+	return lists, nil
+}
+
+func (r *memoryListRepository) Update(list *domain.List) error {
+	return r.table.save(list)
+}
+
+func (r *memoryListRepository) Delete(id uint) error {
+	r.todos.table.update(func(t *domain.Todo) bool { return t.ListID != nil && *t.ListID == id }, func(t *domain.Todo) {
+		t.ListID = nil
+	})
+	r.table.delete(id)
+	return nil
+}
+
+// memoryFeedTokenRepository implements FeedTokenRepository in memory
+type memoryFeedTokenRepository struct {
+	table *memoryTable[domain.FeedToken]
+}
+
+func (r *memoryFeedTokenRepository) Create(token *domain.FeedToken) error {
+	return r.table.create(token)
+}
+
+func (r *memoryFeedTokenRepository) FindByToken(token string) (*domain.FeedToken, error) {
+	tokens := r.table.where(func(f *domain.FeedToken) bool { return f.Token == token })
+	if len(tokens) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &tokens[0], nil
+}
+
+func (r *memoryFeedTokenRepository) DeleteByToken(token string) error {
+	found, err := r.FindByToken(token)
+	if err != nil {
+		return err
+	}
+	r.table.delete(found.ID)
+	return nil
+}
+
+// memoryInboundHookRepository implements InboundHookRepository in memory
+type memoryInboundHookRepository struct {
+	table *memoryTable[domain.InboundHook]
+}
+
+func (r *memoryInboundHookRepository) Create(hook *domain.InboundHook) error {
+	return r.table.create(hook)
+}
+
+func (r *memoryInboundHookRepository) FindByToken(token string) (*domain.InboundHook, error) {
+	hooks := r.table.where(func(h *domain.InboundHook) bool { return h.Token == token })
+	if len(hooks) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &hooks[0], nil
+}
+
+func (r *memoryInboundHookRepository) DeleteByToken(token string) error {
+	found, err := r.FindByToken(token)
+	if err != nil {
+		return err
+	}
+	r.table.delete(found.ID)
+	return nil
+}
+
+// memoryGitHubRepository implements GitHubRepository in memory
+type memoryGitHubRepository struct {
+	links *memoryTable[domain.GitHubLink]
+	syncs *memoryTable[domain.GitHubIssueSync]
+}
+
+func (r *memoryGitHubRepository) CreateLink(link *domain.GitHubLink) error {
+	return r.links.create(link)
+}
+
+func (r *memoryGitHubRepository) FindLinkByList(listID uint) (*domain.GitHubLink, error) {
+	links := r.links.where(func(l *domain.GitHubLink) bool { return l.ListID == listID })
+	if len(links) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &links[0], nil
+}
+
+func (r *memoryGitHubRepository) FindLinks() ([]domain.GitHubLink, error) {
+	return r.links.where(func(*domain.GitHubLink) bool { return true }), nil
+}
+
+func (r *memoryGitHubRepository) FindLinksByRepo(owner, repo string) ([]domain.GitHubLink, error) {
+	return r.links.where(func(l *domain.GitHubLink) bool {
+		return strings.EqualFold(l.Owner, owner) && strings.EqualFold(l.Repo, repo)
+	}), nil
+}
+
+func (r *memoryGitHubRepository) UpdateLink(link *domain.GitHubLink) error {
+	return r.links.save(link)
+}
+
+func (r *memoryGitHubRepository) DeleteLink(id uint) error {
+	for _, sync := range r.syncs.where(func(s *domain.GitHubIssueSync) bool { return s.LinkID == id }) {
+		r.syncs.delete(sync.ID)
+	}
+	r.links.delete(id)
+	return nil
+}
+
+func (r *memoryGitHubRepository) CreateSync(sync *domain.GitHubIssueSync) error {
+	return r.syncs.create(sync)
+}
+
+func (r *memoryGitHubRepository) FindSyncs(linkID uint) ([]domain.GitHubIssueSync, error) {
+	return r.syncs.where(func(s *domain.GitHubIssueSync) bool { return s.LinkID == linkID }), nil
+}
+
+func (r *memoryGitHubRepository) UpdateSync(sync *domain.GitHubIssueSync) error {
+	return r.syncs.save(sync)
+}
+
+// memoryCalendarRepository implements CalendarRepository in memory
+type memoryCalendarRepository struct {
+	conns  *memoryTable[domain.CalendarConnection]
+	events *memoryTable[domain.CalendarEvent]
+}
+
+func (r *memoryCalendarRepository) CreateConnection(conn *domain.CalendarConnection) error {
+	return r.conns.create(conn)
+}
+
+func (r *memoryCalendarRepository) FindConnectionByUser(userID uint) (*domain.CalendarConnection, error) {
+	conns := r.conns.where(func(c *domain.CalendarConnection) bool { return c.UserID == userID })
+	if len(conns) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &conns[0], nil
+}
+
+func (r *memoryCalendarRepository) FindConnections() ([]domain.CalendarConnection, error) {
+	return r.conns.where(func(*domain.CalendarConnection) bool { return true }), nil
+}
+
+func (r *memoryCalendarRepository) UpdateConnection(conn *domain.CalendarConnection) error {
+	return r.conns.save(conn)
+}
+
+func (r *memoryCalendarRepository) DeleteConnection(id uint) error {
+	for _, event := range r.events.where(func(e *domain.CalendarEvent) bool { return e.ConnectionID == id }) {
+		r.events.delete(event.ID)
+	}
+	r.conns.delete(id)
+	return nil
+}
+
+func (r *memoryCalendarRepository) CreateEvent(event *domain.CalendarEvent) error {
+	return r.events.create(event)
+}
+
+func (r *memoryCalendarRepository) FindEvents(connectionID uint) ([]domain.CalendarEvent, error) {
+	return r.events.where(func(e *domain.CalendarEvent) bool { return e.ConnectionID == connectionID }), nil
+}
+
+func (r *memoryCalendarRepository) UpdateEvent(event *domain.CalendarEvent) error {
+	return r.events.save(event)
+}
+
+func (r *memoryCalendarRepository) DeleteEvent(id uint) error {
+	r.events.delete(id)
+	return nil
+}
+
+// memoryNotionExportRepository implements NotionExportRepository in memory
+type memoryNotionExportRepository struct {
+	exports *memoryTable[domain.NotionExport]
+	rows    *memoryTable[domain.NotionExportRow]
+}
+
+func (r *memoryNotionExportRepository) Create(export *domain.NotionExport, rows []domain.NotionExportRow) error {
+	if err := r.exports.create(export); err != nil {
+		return err
+	}
+	for i := range rows {
+		rows[i].ExportID = export.ID
+		if err := r.rows.create(&rows[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *memoryNotionExportRepository) FindByID(id uint) (*domain.NotionExport, error) {
+	return r.exports.find(id)
+}
+
+func (r *memoryNotionExportRepository) Update(export *domain.NotionExport) error {
+	return r.exports.save(export)
+}
+
+func (r *memoryNotionExportRepository) FindRows(exportID uint) ([]domain.NotionExportRow, error) {
+	return r.rows.where(func(row *domain.NotionExportRow) bool { return row.ExportID == exportID }), nil
+}
+
+func (r *memoryNotionExportRepository) UpdateRow(row *domain.NotionExportRow) error {
+	return r.rows.save(row)
+}
+
+// memoryImportRepository implements ImportRepository in memory. Unlike
+// GORM, SaveChunk isn't atomic, but nothing survives a crash here anyway.
+type memoryImportRepository struct {
+	imports *memoryTable[domain.Import]
+	errors  *memoryTable[domain.ImportError]
+	todos   *memoryTodoRepository
+}
+
+func (r *memoryImportRepository) Create(imp *domain.Import) error {
+	return r.imports.create(imp)
+}
+
+func (r *memoryImportRepository) FindByID(id uint) (*domain.Import, error) {
+	return r.imports.find(id)
+}
+
+func runnableImport(staleBefore time.Time) func(*domain.Import) bool {
+	return func(i *domain.Import) bool {
+		return i.Status == domain.ImportPending || (i.Status == domain.ImportRunning && i.UpdatedAt.Before(staleBefore))
+	}
+}
+
+func (r *memoryImportRepository) FindRunnable(staleBefore time.Time, limit int) ([]domain.Import, error) {
+	imports := r.imports.where(runnableImport(staleBefore))
+	return imports[:min(limit, len(imports))], nil
+}
+
+func (r *memoryImportRepository) Claim(id uint, staleBefore time.Time) (bool, error) {
+	runnable := runnableImport(staleBefore)
+	claimed := r.imports.update(func(i *domain.Import) bool {
+		return i.ID == id && runnable(i)
+	}, func(i *domain.Import) {
+		i.Status = domain.ImportRunning
+		i.UpdatedAt = time.Now()
+	})
+	return claimed > 0, nil
+}
+
+func (r *memoryImportRepository) SaveChunk(imp *domain.Import, todos []domain.Todo, errs []domain.ImportError) error {
+	for i := range todos {
+		if err := r.todos.Create(&todos[i]); err != nil {
+			return err
+		}
+	}
+	for i := range errs {
+		if err := r.errors.create(&errs[i]); err != nil {
+			return err
+		}
+	}
+	return r.imports.save(imp)
+}
+
+func (r *memoryImportRepository) Update(imp *domain.Import) error {
+	return r.imports.save(imp)
+}
+
+func (r *memoryImportRepository) FindErrors(importID uint) ([]domain.ImportError, error) {
+	errs := r.errors.where(func(e *domain.ImportError) bool { return e.ImportID == importID })
+	slices.SortStableFunc(errs, func(a, b domain.ImportError) int { return cmp.Compare(a.Line, b.Line) })
+	return errs, nil
+}
+
+// memoryPasskeyRepository implements PasskeyRepository in memory
+type memoryPasskeyRepository struct {
+	table *memoryTable[domain.Passkey]
+	// sessions is shared with memorySessionRepository
+	sessions *memoryTable[domain.AuthSession]
+}
+
+func (r *memoryPasskeyRepository) Create(passkey *domain.Passkey) error {
+	return r.table.create(passkey)
+}
+
+func (r *memoryPasskeyRepository) FindByID(id uint) (*domain.Passkey, error) {
+	return r.table.find(id)
+}
+
+func (r *memoryPasskeyRepository) FindByCredentialID(credentialID string) (*domain.Passkey, error) {
+	passkeys := r.table.where(func(p *domain.Passkey) bool { return p.CredentialID == credentialID })
+	if len(passkeys) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &passkeys[0], nil
+}
+
+func (r *memoryPasskeyRepository) FindByUserID(userID uint) ([]domain.Passkey, error) {
+	return r.table.where(func(p *domain.Passkey) bool { return p.UserID == userID }), nil
+}
+
+func (r *memoryPasskeyRepository) Update(passkey *domain.Passkey) error {
+	return r.table.save(passkey)
+}
+
+func (r *memoryPasskeyRepository) Delete(id uint) error {
+	for _, session := range r.sessions.where(func(s *domain.AuthSession) bool { return s.PasskeyID != nil && *s.PasskeyID == id }) {
+		r.sessions.delete(session.ID)
+	}
+	r.table.delete(id)
+	return nil
+}
+
+// memoryWatcherRepository implements WatcherRepository in memory
+type memoryWatcherRepository struct {
+	table *memoryTable[domain.TodoWatcher]
+}
+
+func (r *memoryWatcherRepository) Create(watcher *domain.TodoWatcher) error {
+	return r.table.create(watcher)
+}
+
+func (r *memoryWatcherRepository) Find(todoID, userID uint) (*domain.TodoWatcher, error) {
+	watchers := r.table.where(func(w *domain.TodoWatcher) bool { return w.TodoID == todoID && w.UserID == userID })
+	if len(watchers) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &watchers[0], nil
+}
+
+func (r *memoryWatcherRepository) FindByTodoID(todoID uint) ([]domain.TodoWatcher, error) {
+	return r.table.where(func(w *domain.TodoWatcher) bool { return w.TodoID == todoID }), nil
+}
+
+func (r *memoryWatcherRepository) FindByUserID(userID uint) ([]domain.TodoWatcher, error) {
+	watchers := r.table.where(func(w *domain.TodoWatcher) bool { return w.UserID == userID })
+	slices.Reverse(watchers)
+	return watchers, nil
+}
+
+func (r *memoryWatcherRepository) Delete(todoID, userID uint) error {
+	watcher, err := r.Find(todoID, userID)
+	if err != nil {
+		return err
+	}
+	r.table.delete(watcher.ID)
+	return nil
+}
+
+func (r *memoryWatcherRepository) DeleteByTodoID(todoID uint) error {
+	for _, watcher := range r.table.where(func(w *domain.TodoWatcher) bool { return w.TodoID == todoID }) {
+		r.table.delete(watcher.ID)
+	}
+	return nil
+}
+
+// memoryUserRepository implements UserRepository in memory
+type memoryUserRepository struct {
+	mu    sync.Mutex // Makes the email check and insert atomic
+	table *memoryTable[domain.User]
+}
+
+func (r *memoryUserRepository) Create(user *domain.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, err := r.FindByEmail(user.Email); err == nil {
+		return gorm.ErrDuplicatedKey
+	}
+	return r.table.create(user)
+}
+
+func (r *memoryUserRepository) FindByID(id uint) (*domain.User, error) {
+	return r.table.find(id)
+}
+
+func (r *memoryUserRepository) FindByIDs(ids []uint) ([]domain.User, error) {
+	return r.table.where(func(u *domain.User) bool { return slices.Contains(ids, u.ID) }), nil
+}
+
+func (r *memoryUserRepository) FindByEmail(email string) (*domain.User, error) {
+	users := r.table.where(func(u *domain.User) bool { return u.Email == email })
+	if len(users) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &users[0], nil
+}
+
+func (r *memoryUserRepository) FindAll() ([]domain.User, error) {
+	return r.table.where(func(u *domain.User) bool { return true }), nil
+}
+
+func (r *memoryUserRepository) CountByRole(role domain.Role) (int64, error) {
+	users := r.table.where(func(u *domain.User) bool { return role == "" || u.Role == role })
+	return int64(len(users)), nil
+}
+
+func (r *memoryUserRepository) Update(user *domain.User) error {
+	return r.table.save(user)
+}
+
+// memoryTagRepository implements TagRepository in memory
+type memoryTagRepository struct {
+	mu    sync.Mutex // Makes the name check and insert atomic
+	table *memoryTable[domain.Tag]
+	// todos is shared with memoryTodoRepository, whose todos hold copies
+	// of their tags
+	todos *memoryTable[domain.Todo]
+}
+
+func (r *memoryTagRepository) Create(tag *domain.Tag) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.named(tag.UserID, tag.Name)) > 0 {
+		return gorm.ErrDuplicatedKey
+	}
+	return r.table.create(tag)
+}
+
+func (r *memoryTagRepository) named(userID uint, names ...string) []domain.Tag {
+	return r.table.where(func(t *domain.Tag) bool { return t.UserID == userID && slices.Contains(names, t.Name) })
+}
+
+func (r *memoryTagRepository) FindByID(id uint) (*domain.Tag, error) {
+	return r.table.find(id)
+}
+
+func (r *memoryTagRepository) FindByUserID(userID uint) ([]domain.Tag, error) {
+	tags := r.table.where(func(t *domain.Tag) bool { return t.UserID == userID })
+	slices.SortFunc(tags, func(a, b domain.Tag) int { return strings.Compare(a.Name, b.Name) })
+	return tags, nil
+}
+
+func (r *memoryTagRepository) FindByUserIDs(userIDs []uint) ([]domain.Tag, error) {
+	tags := r.table.where(func(t *domain.Tag) bool { return slices.Contains(userIDs, t.UserID) })
+	slices.SortFunc(tags, func(a, b domain.Tag) int {
+		if a.UserID != b.UserID {
+			return cmp.Compare(a.UserID, b.UserID)
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+	return tags, nil
+}
+
+func (r *memoryTagRepository) FindOrCreate(userID uint, names []string) ([]domain.Tag, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, name := range names {
+		if len(r.named(userID, name)) == 0 {
+			if err := r.table.create(&domain.Tag{UserID: userID, Name: name}); err != nil {
+				return nil, err
+			}
+		}
+	}
+	tags := r.named(userID, names...)
+	slices.SortFunc(tags, func(a, b domain.Tag) int { return strings.Compare(a.Name, b.Name) })
+	return tags, nil
+}
+
+func (r *memoryTagRepository) Update(tag *domain.Tag) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if slices.ContainsFunc(r.named(tag.UserID, tag.Name), func(t domain.Tag) bool { return t.ID != tag.ID }) {
+		return gorm.ErrDuplicatedKey
+	}
+	if err := r.table.save(tag); err != nil {
+		return err
+	}
+	r.todos.update(func(t *domain.Todo) bool { return hasTagID(t, tag.ID) }, func(t *domain.Todo) {
+		tags := slices.Clone(t.Tags)
+		for i := range tags {
+			if tags[i].ID == tag.ID {
+				tags[i] = *tag
+			}
+		}
+		slices.SortFunc(tags, func(a, b domain.Tag) int { return strings.Compare(a.Name, b.Name) })
+		t.Tags = tags
+	})
+	return nil
+}
+
+func (r *memoryTagRepository) Delete(id uint) error {
+	r.todos.update(func(t *domain.Todo) bool { return hasTagID(t, id) }, func(t *domain.Todo) {
+		t.Tags = slices.DeleteFunc(slices.Clone(t.Tags), func(tag domain.Tag) bool { return tag.ID == id })
+	})
+	r.table.delete(id)
+	return nil
+}
+
+func hasTagID(todo *domain.Todo, id uint) bool {
+	return slices.ContainsFunc(todo.Tags, func(tag domain.Tag) bool { return tag.ID == id })
+}
+
+// memoryAPIKeyRepository implements APIKeyRepository in memory
+type memoryAPIKeyRepository struct {
+	table *memoryTable[domain.APIKey]
+}
+
+func (r *memoryAPIKeyRepository) Create(key *domain.APIKey) error {
+	return r.table.create(key)
+}
+
+func (r *memoryAPIKeyRepository) FindByHash(keyHash string) (*domain.APIKey, error) {
+	keys := r.table.where(func(k *domain.APIKey) bool { return k.KeyHash == keyHash })
+	if len(keys) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &keys[0], nil
+}
+
+func (r *memoryAPIKeyRepository) FindByUserID(userID uint) ([]domain.APIKey, error) {
+	return r.table.where(func(k *domain.APIKey) bool { return k.UserID == userID }), nil
+}
+
+func (r *memoryAPIKeyRepository) Delete(id, userID uint) error {
+	key, err := r.table.find(id)
+	if err != nil {
+		return err
+	}
+	if key.UserID != userID {
+		return gorm.ErrRecordNotFound
+	}
+	r.table.delete(id)
+	return nil
+}
+
+func (r *memoryAPIKeyRepository) Touch(id uint, at time.Time) error {
+	r.table.update(func(k *domain.APIKey) bool { return k.ID == id }, func(k *domain.APIKey) { k.LastUsedAt = &at })
+	return nil
+}
+
+// memoryWebhookRepository implements WebhookRepository in memory
+type memoryWebhookRepository struct {
+	webhooks   *memoryTable[domain.Webhook]
+	deliveries *memoryTable[domain.WebhookDelivery]
+}
+
+func (r *memoryWebhookRepository) Create(webhook *domain.Webhook) error {
+	return r.webhooks.create(webhook)
+}
+
+func (r *memoryWebhookRepository) FindByID(id uint) (*domain.Webhook, error) {
+	return r.webhooks.find(id)
+}
+
+func (r *memoryWebhookRepository) FindByUserID(userID uint) ([]domain.Webhook, error) {
+	return r.webhooks.where(func(w *domain.Webhook) bool { return w.UserID == userID }), nil
+}
+
+func (r *memoryWebhookRepository) Update(webhook *domain.Webhook) error {
+	return r.webhooks.save(webhook)
+}
+
+func (r *memoryWebhookRepository) Delete(id uint) error {
+	r.webhooks.delete(id)
+	return nil
+}
+
+func (r *memoryWebhookRepository) AddDeliveries(deliveries []domain.WebhookDelivery) error {
+	for i := range deliveries {
+		d := &deliveries[i]
+		exists := r.deliveries.where(func(stored *domain.WebhookDelivery) bool {
+			return stored.WebhookID == d.WebhookID && stored.EventID == d.EventID
+		})
+		if len(exists) > 0 {
+			continue
+		}
+		if err := r.deliveries.create(d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *memoryWebhookRepository) FindDelivery(id uint) (*domain.WebhookDelivery, error) {
+	return r.deliveries.find(id)
+}
+
+func (r *memoryWebhookRepository) FindDeliveries(webhookID uint, limit int) ([]domain.WebhookDelivery, error) {
+	deliveries := r.deliveries.where(func(d *domain.WebhookDelivery) bool { return d.WebhookID == webhookID })
+	slices.Reverse(deliveries)
+	return deliveries[:min(limit, len(deliveries))], nil
+}
+
+func (r *memoryWebhookRepository) FindDeliveryByEvent(webhookID uint, eventID string) (*domain.WebhookDelivery, error) {
+	deliveries := r.deliveries.where(func(d *domain.WebhookDelivery) bool { return d.WebhookID == webhookID && d.EventID == eventID })
+	if len(deliveries) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &deliveries[0], nil
+}
+
+func (r *memoryWebhookRepository) UpdateDelivery(delivery *domain.WebhookDelivery) error {
+	return r.deliveries.save(delivery)
+}
+
+// memoryIdentityRepository implements IdentityRepository in memory
+type memoryIdentityRepository struct {
+	table *memoryTable[domain.ExternalIdentity]
+	// sessions is shared with memorySessionRepository
+	sessions *memoryTable[domain.AuthSession]
+}
+
+func (r *memoryIdentityRepository) Create(identity *domain.ExternalIdentity) error {
+	return r.table.create(identity)
+}
+
+func (r *memoryIdentityRepository) FindByID(id uint) (*domain.ExternalIdentity, error) {
+	return r.table.find(id)
+}
+
+func (r *memoryIdentityRepository) FindBySubject(provider, subject string) (*domain.ExternalIdentity, error) {
+	identities := r.table.where(func(i *domain.ExternalIdentity) bool { return i.Provider == provider && i.Subject == subject })
+	if len(identities) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &identities[0], nil
+}
+
+func (r *memoryIdentityRepository) FindByUserID(userID uint) ([]domain.ExternalIdentity, error) {
+	return r.table.where(func(i *domain.ExternalIdentity) bool { return i.UserID == userID }), nil
+}
+
+func (r *memoryIdentityRepository) Update(identity *domain.ExternalIdentity) error {
+	return r.table.save(identity)
+}
+
+func (r *memoryIdentityRepository) Delete(id uint) error {
+	for _, session := range r.sessions.where(func(s *domain.AuthSession) bool { return s.IdentityID != nil && *s.IdentityID == id }) {
+		r.sessions.delete(session.ID)
+	}
+	r.table.delete(id)
+	return nil
+}
+
+// memorySessionRepository implements SessionRepository in memory
+type memorySessionRepository struct {
+	table *memoryTable[domain.AuthSession]
+}
+
+func (r *memorySessionRepository) Create(session *domain.AuthSession) error {
+	return r.table.create(session)
+}
+
+func (r *memorySessionRepository) Find(tokenHash string, now time.Time) (*domain.AuthSession, error) {
+	sessions := r.table.where(func(s *domain.AuthSession) bool {
+		return s.TokenHash == tokenHash && s.ExpiresAt.After(now)
+	})
+	if len(sessions) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &sessions[0], nil
+}
+
+func (r *memorySessionRepository) Delete(tokenHash string) error {
+	sessions := r.table.where(func(s *domain.AuthSession) bool { return s.TokenHash == tokenHash })
+	if len(sessions) == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	r.table.delete(sessions[0].ID)
+	return nil
+}
+
+// memoryLeaseRepository implements LeaseRepository in memory
+type memoryLeaseRepository struct {
+	mu     sync.Mutex
+	leases map[string]domain.Lease
+}
+
+func (r *memoryLeaseRepository) Acquire(name, holder string, ttl time.Duration) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	if lease, ok := r.leases[name]; ok && lease.Holder != holder && !lease.ExpiresAt.Before(now) {
+		return false, nil
+	}
+	r.leases[name] = domain.Lease{Name: name, Holder: holder, ExpiresAt: now.Add(ttl)}
+	return true, nil
+}
+
+func (r *memoryLeaseRepository) Release(name, holder string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.leases[name].Holder == holder {
+		delete(r.leases, name)
+	}
+	return nil
+}
+
+// idempotencyKeyID is the primary key of an idempotency key.
+type idempotencyKeyID struct {
+	userID uint
+	key    string
+}
+
+// memoryIdempotencyKeyRepository implements IdempotencyKeyRepository in
+// memory
+type memoryIdempotencyKeyRepository struct {
+	mu   sync.Mutex
+	keys map[idempotencyKeyID]domain.IdempotencyKey
+}
+
+func (r *memoryIdempotencyKeyRepository) Create(key *domain.IdempotencyKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	id := idempotencyKeyID{key.UserID, key.Key}
+	if _, ok := r.keys[id]; ok {
+		return gorm.ErrDuplicatedKey
+	}
+	r.keys[id] = *key
+	return nil
+}
+
+func (r *memoryIdempotencyKeyRepository) Find(userID uint, key string) (*domain.IdempotencyKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	found, ok := r.keys[idempotencyKeyID{userID, key}]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &found, nil
+}
+
+func (r *memoryIdempotencyKeyRepository) SaveResponse(key *domain.IdempotencyKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	id := idempotencyKeyID{key.UserID, key.Key}
+	if stored, ok := r.keys[id]; ok {
+		stored.StatusCode, stored.Header, stored.Body = key.StatusCode, maps.Clone(key.Header), slices.Clone(key.Body)
+		r.keys[id] = stored
+	}
+	return nil
+}
+
+func (r *memoryIdempotencyKeyRepository) Delete(userID uint, key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.keys, idempotencyKeyID{userID, key})
+	return nil
+}
+
+func (r *memoryIdempotencyKeyRepository) DeleteExpired(now time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var deleted int64
+	for id, key := range r.keys {
+		if key.ExpiresAt.Before(now) {
+			delete(r.keys, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// memoryReportScheduleRepository implements ReportScheduleRepository in memory
+type memoryReportScheduleRepository struct {
+	table *memoryTable[domain.ReportSchedule]
+}
+
+func (r *memoryReportScheduleRepository) Create(schedule *domain.ReportSchedule) error {
+	return r.table.create(schedule)
+}
+
+func (r *memoryReportScheduleRepository) FindByID(id uint) (*domain.ReportSchedule, error) {
+	return r.table.find(id)
+}
+
+func (r *memoryReportScheduleRepository) FindByUserID(userID uint) ([]domain.ReportSchedule, error) {
+	return r.table.where(func(s *domain.ReportSchedule) bool { return s.UserID == userID }), nil
+}
+
+func (r *memoryReportScheduleRepository) FindDue(now time.Time, limit int) ([]domain.ReportSchedule, error) {
+	schedules := r.table.where(func(s *domain.ReportSchedule) bool { return !s.NextRunAt.After(now) })
+	slices.SortStableFunc(schedules, func(a, b domain.ReportSchedule) int { return a.NextRunAt.Compare(b.NextRunAt) })
+	return limitRows(schedules, limit), nil
+}
+
+func (r *memoryReportScheduleRepository) Update(schedule *domain.ReportSchedule) error {
+	return r.table.save(schedule)
+}
+
+func (r *memoryReportScheduleRepository) Delete(id uint) error {
+	r.table.delete(id)
+	return nil
+}
+
+// memoryPreferenceRepository implements PreferenceRepository in memory
+type memoryPreferenceRepository struct {
+	mu    sync.RWMutex
+	prefs map[uint]domain.UserPreference
+}
+
+func (r *memoryPreferenceRepository) FindByUserID(userID uint) (*domain.UserPreference, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	pref, ok := r.prefs[userID]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &pref, nil
+}
+
+func (r *memoryPreferenceRepository) FindEscalating() ([]domain.UserPreference, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var prefs []domain.UserPreference
+	for _, pref := range r.prefs {
+		if pref.EscalationMode != "" {
+			prefs = append(prefs, pref)
+		}
+	}
+	slices.SortFunc(prefs, func(a, b domain.UserPreference) int { return cmp.Compare(a.UserID, b.UserID) })
+	return prefs, nil
+}
+
+func (r *memoryPreferenceRepository) FindOverdueReminders() ([]domain.UserPreference, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var prefs []domain.UserPreference
+	for _, pref := range r.prefs {
+		if pref.NotifyOverdue {
+			prefs = append(prefs, pref)
+		}
+	}
+	slices.SortFunc(prefs, func(a, b domain.UserPreference) int { return cmp.Compare(a.UserID, b.UserID) })
+	return prefs, nil
+}
+
+func (r *memoryPreferenceRepository) Save(pref *domain.UserPreference) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	if existing, ok := r.prefs[pref.UserID]; ok {
+		pref.CreatedAt = existing.CreatedAt
+	} else if pref.CreatedAt.IsZero() {
+		pref.CreatedAt = now
+	}
+	pref.UpdatedAt = now
+	r.prefs[pref.UserID] = *pref
+	return nil
+}
+
+// memoryAttachmentRepository implements AttachmentRepository in memory
+type memoryAttachmentRepository struct {
+	table *memoryTable[domain.Attachment]
+}
+
+func (r *memoryAttachmentRepository) Create(attachment *domain.Attachment) error {
+	return r.table.create(attachment)
+}
+
+func (r *memoryAttachmentRepository) FindByID(id uint) (*domain.Attachment, error) {
+	return r.table.find(id)
+}
+
+func (r *memoryAttachmentRepository) FindByTodoID(todoID uint, status string) ([]domain.Attachment, error) {
+	return r.table.where(func(a *domain.Attachment) bool {
+		return a.TodoID == todoID && (status == "" || a.Status == status)
+	}), nil
+}
+
+func (r *memoryAttachmentRepository) FindByStatusBefore(status string, before time.Time, limit int) ([]domain.Attachment, error) {
+	return limitRows(r.table.where(func(a *domain.Attachment) bool {
+		return a.Status == status && a.CreatedAt.Before(before)
+	}), limit), nil
+}
+
+func (r *memoryAttachmentRepository) FindByThumbnailStatus(status string, limit int) ([]domain.Attachment, error) {
+	return limitRows(r.table.where(func(a *domain.Attachment) bool { return a.ThumbnailStatus == status }), limit), nil
+}
+
+func (r *memoryAttachmentRepository) FindByScanStatus(status string, limit int) ([]domain.Attachment, error) {
+	return limitRows(r.table.where(func(a *domain.Attachment) bool { return a.ScanStatus == status }), limit), nil
+}
+
+func (r *memoryAttachmentRepository) Update(attachment *domain.Attachment) error {
+	return r.table.save(attachment)
+}
+
+func (r *memoryAttachmentRepository) Delete(id uint) error {
+	r.table.delete(id)
+	return nil
+}
+
+// memoryChecklistRepository implements ChecklistRepository in memory
+type memoryChecklistRepository struct {
+	table *memoryTable[domain.ChecklistItem]
+}
+
+func (r *memoryChecklistRepository) Create(item *domain.ChecklistItem) error {
+	return r.table.create(item)
+}
+
+func (r *memoryChecklistRepository) FindByID(id uint) (*domain.ChecklistItem, error) {
+	return r.table.find(id)
+}
+
+func (r *memoryChecklistRepository) FindByTodoID(todoID uint) ([]domain.ChecklistItem, error) {
+	items := r.table.where(func(c *domain.ChecklistItem) bool { return c.TodoID == todoID })
+	slices.SortStableFunc(items, func(a, b domain.ChecklistItem) int { return cmp.Compare(a.Position, b.Position) })
+	return items, nil
+}
+
+func (r *memoryChecklistRepository) Update(item *domain.ChecklistItem) error {
+	return r.table.save(item)
+}
+
+func (r *memoryChecklistRepository) Delete(id uint) error {
+	r.table.delete(id)
+	return nil
+}
+
+// memorySubtaskRepository implements SubtaskRepository in memory
+type memorySubtaskRepository struct {
+	table *memoryTable[domain.Subtask]
+}
+
+func (r *memorySubtaskRepository) Create(subtask *domain.Subtask) error {
+	return r.table.create(subtask)
+}
+
+func (r *memorySubtaskRepository) FindByID(id uint) (*domain.Subtask, error) {
+	return r.table.find(id)
+}
+
+func (r *memorySubtaskRepository) FindByTodoID(todoID uint) ([]domain.Subtask, error) {
+	return r.table.where(func(s *domain.Subtask) bool { return s.TodoID == todoID }), nil
+}
+
+func (r *memorySubtaskRepository) Update(subtask *domain.Subtask) error {
+	return r.table.save(subtask)
+}
+
+func (r *memorySubtaskRepository) Delete(id uint) error {
+	r.table.delete(id)
+	return nil
+}
+
+// memoryReminderRepository implements ReminderRepository in memory
+type memoryReminderRepository struct {
+	table *memoryTable[domain.Reminder]
+}
+
+func (r *memoryReminderRepository) Create(reminder *domain.Reminder) error {
+	if reminder.Status == "" {
+		reminder.Status = domain.ReminderPending
+	}
+	return r.table.create(reminder)
+}
+
+func (r *memoryReminderRepository) FindByID(id uint) (*domain.Reminder, error) {
+	return r.table.find(id)
+}
+
+func (r *memoryReminderRepository) FindByTodoID(todoID uint) ([]domain.Reminder, error) {
+	reminders := r.table.where(func(m *domain.Reminder) bool { return m.TodoID == todoID })
+	slices.SortStableFunc(reminders, func(a, b domain.Reminder) int { return a.RemindAt.Compare(b.RemindAt) })
+	return reminders, nil
+}
+
+func (r *memoryReminderRepository) FindDue(now time.Time, limit int) ([]domain.Reminder, error) {
+	reminders := r.table.where(func(m *domain.Reminder) bool {
+		return m.Status == domain.ReminderPending && !m.RemindAt.After(now)
+	})
+	slices.SortStableFunc(reminders, func(a, b domain.Reminder) int { return a.RemindAt.Compare(b.RemindAt) })
+	return reminders[:min(limit, len(reminders))], nil
+}
+
+func (r *memoryReminderRepository) Update(reminder *domain.Reminder) error {
+	return r.table.save(reminder)
+}
+
+func (r *memoryReminderRepository) Delete(id uint) error {
+	r.table.delete(id)
+	return nil
+}
+
+// memoryOutboxRepository implements OutboxRepository in memory
+type memoryOutboxRepository struct {
+	table *memoryTable[domain.OutboxEvent]
+}
+
+func (r *memoryOutboxRepository) Add(event *domain.OutboxEvent) error {
+	if event.Status == "" {
+		event.Status = domain.OutboxPending
+	}
+	return r.table.create(event)
+}
+
+func (r *memoryOutboxRepository) FindByID(id uint) (*domain.OutboxEvent, error) {
+	return r.table.find(id)
+}
+
+// outboxClaimable reports whether an event is due at now and not claimed.
+func outboxClaimable(e *domain.OutboxEvent, now time.Time) bool {
+	return e.Status == domain.OutboxPending && !e.NextAttemptAt.After(now) &&
+		(e.ClaimedUntil == nil || e.ClaimedUntil.Before(now))
+}
+
+func (r *memoryOutboxRepository) FindDue(now time.Time, limit int) ([]domain.OutboxEvent, error) {
+	events := r.table.where(func(e *domain.OutboxEvent) bool { return outboxClaimable(e, now) })
+	return events[:min(limit, len(events))], nil
+}
+
+func (r *memoryOutboxRepository) Claim(id uint, now, until time.Time) (bool, error) {
+	claimed := r.table.update(func(e *domain.OutboxEvent) bool {
+		return e.ID == id && outboxClaimable(e, now)
+	}, func(e *domain.OutboxEvent) {
+		e.ClaimedUntil = &until
+		e.Attempts++
+	})
+	return claimed > 0, nil
+}
+
+func (r *memoryOutboxRepository) Update(event *domain.OutboxEvent) error {
+	return r.table.save(event)
+}
+
+func (r *memoryOutboxRepository) DeletePublishedBefore(before time.Time) (int64, error) {
+	removed := r.table.purge(func(e *domain.OutboxEvent) bool {
+		return e.Status == domain.OutboxPublished && e.PublishedAt != nil && e.PublishedAt.Before(before)
+	})
+	return int64(removed), nil
+}
+
+// memoryJobRepository implements JobRepository in memory
+type memoryJobRepository struct {
+	table *memoryTable[domain.Job]
+}
+
+func (r *memoryJobRepository) Enqueue(job *domain.Job) error {
+	if job.Key != nil {
+		taken := r.table.where(func(j *domain.Job) bool { return j.Key != nil && *j.Key == *job.Key })
+		if len(taken) > 0 {
+			return nil
+		}
+	}
+	if job.Status == "" {
+		job.Status = domain.JobPending
+	}
+	return r.table.create(job)
+}
+
+func (r *memoryJobRepository) FindByID(id uint) (*domain.Job, error) {
+	return r.table.find(id)
+}
+
+// jobDue reports whether a job of one of kinds can be claimed at now.
+func jobDue(j *domain.Job, kinds []string, now time.Time) bool {
+	if !slices.Contains(kinds, j.Kind) {
+		return false
+	}
+	return (j.Status == domain.JobPending && !j.RunAt.After(now)) ||
+		(j.Status == domain.JobRunning && j.LockedUntil != nil && j.LockedUntil.Before(now))
+}
+
+func (r *memoryJobRepository) Claim(kinds []string, now, until time.Time, limit int) ([]domain.Job, error) {
+	due := r.table.where(func(j *domain.Job) bool { return jobDue(j, kinds, now) })
+	slices.SortStableFunc(due, func(a, b domain.Job) int { return a.RunAt.Compare(b.RunAt) })
+
+	var claimed []domain.Job
+	for i := range due {
+		if len(claimed) == limit {
+			break
+		}
+		id := due[i].ID
+		n := r.table.update(func(j *domain.Job) bool {
+			return j.ID == id && jobDue(j, kinds, now)
+		}, func(j *domain.Job) {
+			j.Status, j.LockedUntil = domain.JobRunning, &until
+			j.Attempts++
+		})
+		if n == 0 {
+			continue
+		}
+		job, err := r.table.find(id)
+		if err != nil {
+			return nil, err
+		}
+		claimed = append(claimed, *job)
+	}
+	return claimed, nil
+}
+
+func (r *memoryJobRepository) Extend(ids []uint, until time.Time) error {
+	r.table.update(func(j *domain.Job) bool {
+		return j.Status == domain.JobRunning && slices.Contains(ids, j.ID)
+	}, func(j *domain.Job) {
+		j.LockedUntil = &until
+	})
+	return nil
+}
+
+func (r *memoryJobRepository) Update(job *domain.Job) error {
+	return r.table.save(job)
+}
+
+func (r *memoryJobRepository) DeleteFinishedBefore(before time.Time) (int64, error) {
+	removed := r.table.purge(func(j *domain.Job) bool {
+		return (j.Status == domain.JobDone || j.Status == domain.JobFailed) && j.FinishedAt != nil && j.FinishedAt.Before(before)
+	})
+	return int64(removed), nil
+}
+
+// memoryReactionRepository implements ReactionRepository in memory
+type memoryReactionRepository struct {
+	table *memoryTable[domain.Reaction]
+}
+
+func (r *memoryReactionRepository) Create(reaction *domain.Reaction) error {
+	return r.table.create(reaction)
+}
+
+func (r *memoryReactionRepository) Find(todoID, userID uint, emoji string) (*domain.Reaction, error) {
+	matches := r.table.where(func(re *domain.Reaction) bool {
+		return re.TodoID == todoID && re.UserID == userID && re.Emoji == emoji
+	})
+	if len(matches) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &matches[0], nil
+}
+
+func (r *memoryReactionRepository) FindByTodoID(todoID uint) ([]domain.Reaction, error) {
+	return r.table.where(func(re *domain.Reaction) bool { return re.TodoID == todoID }), nil
+}
+
+func (r *memoryReactionRepository) Delete(id uint) error {
+	r.table.delete(id)
+	return nil
+}
+
+// memoryActivityRepository implements ActivityRepository in memory
+type memoryActivityRepository struct {
+	table *memoryTable[domain.Activity]
+}
+
+func (r *memoryActivityRepository) Create(activity *domain.Activity) error {
+	return r.table.create(activity)
+}
+
+func (r *memoryActivityRepository) FindByTodoID(todoID uint) ([]domain.Activity, error) {
+	return r.table.where(func(a *domain.Activity) bool { return a.TodoID == todoID }), nil
+}
+
+func (r *memoryActivityRepository) FindForList(listID uint, kinds []string) ([]domain.Activity, error) {
+	id := strconv.FormatUint(uint64(listID), 10)
+	return r.table.where(func(a *domain.Activity) bool {
+		if !slices.Contains(kinds, a.Kind) {
+			return false
+		}
+		return (a.ListID != nil && *a.ListID == listID) || (a.Kind == domain.ActivityMoved && a.OldValue == id)
+	}), nil
+}
+
+// memoryFocusRepository implements FocusRepository in memory
+type memoryFocusRepository struct {
+	table *memoryTable[domain.FocusSession]
+}
+
+func (r *memoryFocusRepository) Create(session *domain.FocusSession) error {
+	return r.table.create(session)
+}
+
+func (r *memoryFocusRepository) FindRunning(userID uint) (*domain.FocusSession, error) {
+	running := r.table.where(func(f *domain.FocusSession) bool { return f.UserID == userID && f.EndedAt == nil })
+	if len(running) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &running[0], nil
+}
+
+func (r *memoryFocusRepository) FindByUserBetween(userID uint, from, to time.Time) ([]domain.FocusSession, error) {
+	sessions := r.table.where(func(f *domain.FocusSession) bool {
+		return f.UserID == userID && f.StartedAt.Before(to) && (f.EndedAt == nil || !f.EndedAt.Before(from))
+	})
+	slices.SortStableFunc(sessions, func(a, b domain.FocusSession) int { return a.StartedAt.Compare(b.StartedAt) })
+	return sessions, nil
+}
+
+func (r *memoryFocusRepository) Update(session *domain.FocusSession) error {
+	return r.table.save(session)
+}
@@ -0,0 +1,421 @@
+package repository
+
+import (
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/Tomlord1122/todo-backend/internal/domain"
+)
+
+func TestMemoryRepositories(t *testing.T) {
+	repos := NewMemoryRepositories()
+
+	list := &domain.List{Name: "Work", UserID: 1}
+	if err := repos.Lists.Create(list); err != nil {
+		t.Fatal(err)
+	}
+	due := time.Now().Add(time.Hour)
+	todo := &domain.Todo{Title: "Ship it", UserID: 1, ListID: &list.ID, DueDate: &due}
+	if err := repos.Todos.Create(todo); err != nil {
+		t.Fatal(err)
+	}
+	if todo.ID == 0 || todo.CreatedAt.IsZero() || todo.Priority != "normal" {
+		t.Fatalf("Create didn't fill in defaults: %+v", todo)
+	}
+
+	// Callers get copies, so changes only stick through Update
+	found, err := repos.Todos.FindByID(todo.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	found.Title = "Changed"
+	if again, _ := repos.Todos.FindByID(todo.ID); again.Title != "Ship it" {
+		t.Errorf("stored todo was modified without Update: %q", again.Title)
+	}
+
+	matches, _, _ := repos.Todos.Search("shpi", TodoSearch{Fuzzy: true, Threshold: 0.3})
+	if len(matches) != 1 || matches[0].ID != todo.ID || matches[0].Score <= 0 {
+		t.Errorf("fuzzy Search(shpi) = %+v, want the todo with a score", matches)
+	}
+	if matches, total, _ := repos.Todos.Search("SHIP", TodoSearch{}); len(matches) != 1 || total != 1 {
+		t.Errorf("Search(SHIP) = %d matches of %d, want 1", len(matches), total)
+	}
+
+	// FindByID loads the subtasks, like Preload, and Update leaves them alone
+	if err := repos.Subtasks.Create(&domain.Subtask{TodoID: todo.ID, Title: "Test it"}); err != nil {
+		t.Fatal(err)
+	}
+	found, _ = repos.Todos.FindByID(todo.ID)
+	if len(found.Subtasks) != 1 || found.Subtasks[0].Title != "Test it" {
+		t.Errorf("FindByID subtasks = %+v, want the subtask", found.Subtasks)
+	}
+	if err := repos.Todos.Update(found); err != nil {
+		t.Fatal(err)
+	}
+	if all, _ := repos.Todos.GetAll(); len(all) != 1 || all[0].Subtasks != nil {
+		t.Errorf("Update stored the subtasks on the todo: %+v", all)
+	}
+
+	// Saves only apply to the version they were read at
+	if found.Version != 2 {
+		t.Errorf("Version after Update = %d, want 2", found.Version)
+	}
+	stale := *found
+	stale.Version = 1
+	if err := repos.Todos.Update(&stale); !errors.Is(err, ErrVersionConflict) {
+		t.Errorf("Update of a stale todo = %v, want ErrVersionConflict", err)
+	}
+	if err := repos.Todos.Delete(&stale); !errors.Is(err, ErrVersionConflict) {
+		t.Errorf("Delete of a stale todo = %v, want ErrVersionConflict", err)
+	}
+
+	// A reminder is due until it is delivered
+	now := time.Now()
+	reminder := &domain.Reminder{TodoID: todo.ID, RemindAt: now.Add(-time.Minute), Channel: "log"}
+	if err := repos.Reminders.Create(reminder); err != nil {
+		t.Fatal(err)
+	}
+	if due, _ := repos.Reminders.FindDue(now, 10); len(due) != 1 || due[0].Status != domain.ReminderPending {
+		t.Fatalf("FindDue = %+v, want the pending reminder", due)
+	}
+	reminder.Status = domain.ReminderDelivered
+	if err := repos.Reminders.Update(reminder); err != nil {
+		t.Fatal(err)
+	}
+	if due, _ := repos.Reminders.FindDue(now, 10); len(due) != 0 {
+		t.Errorf("FindDue after delivery = %+v, want none", due)
+	}
+
+	open, _ := repos.Todos.FindOpenByUser(1)
+	if len(open) != 1 {
+		t.Fatalf("FindOpenByUser = %d todos, want 1", len(open))
+	}
+
+	// Deleting a list detaches its todos, like the GORM repository
+	if err := repos.Lists.Delete(list.ID); err != nil {
+		t.Fatal(err)
+	}
+	found, _ = repos.Todos.FindByID(todo.ID)
+	if found.ListID != nil {
+		t.Errorf("todo still in deleted list %d", *found.ListID)
+	}
+
+	if _, err := repos.Lists.FindByID(list.ID); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("FindByID on deleted list = %v, want ErrRecordNotFound", err)
+	}
+
+	// Deleted rows are kept as tombstones for sync clients
+	if lists, _ := repos.Lists.FindByUserID(1, ListOptions{}); len(lists) != 0 {
+		t.Errorf("FindByUserID returned %d lists, want the deleted list hidden", len(lists))
+	}
+	before := list.CreatedAt.Add(-time.Second)
+	lists, _ := repos.Lists.FindByUserID(1, ListOptions{DeletedSince: &before})
+	if len(lists) != 1 || !lists[0].DeletedAt.Valid {
+		t.Errorf("FindByUserID(DeletedSince) = %+v, want the tombstone", lists)
+	}
+	after := time.Now().Add(time.Second)
+	if lists, _ := repos.Lists.FindByUserID(1, ListOptions{DeletedSince: &after}); len(lists) != 0 {
+		t.Errorf("FindByUserID returned a list deleted before DeletedSince")
+	}
+	if err := repos.FeedTokens.DeleteByToken("missing"); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("DeleteByToken on missing token = %v, want ErrRecordNotFound", err)
+	}
+}
+
+func TestMemoryTodoFind(t *testing.T) {
+	repos := NewMemoryRepositories()
+	soon := time.Now().Add(time.Hour)
+	for _, todo := range []domain.Todo{
+		{Title: "b", UserID: 1, Priority: "low"},
+		{Title: "a", UserID: 1, Priority: "high", DueDate: &soon},
+		{Title: "c", UserID: 2, Priority: "high", Completed: true},
+		{Title: "d", UserID: 1, Priority: "normal"},
+	} {
+		if err := repos.Todos.Create(&todo); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ids := func(filter TodoFilter) []uint {
+		t.Helper()
+		todos, err := repos.Todos.Find(filter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var ids []uint
+		for _, todo := range todos {
+			ids = append(ids, todo.ID)
+		}
+		return ids
+	}
+
+	tags, err := repos.Tags.FindOrCreate(1, []string{"home", "work"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := repos.Todos.SetTags(1, tags); err != nil {
+		t.Fatal(err)
+	}
+	if err := repos.Todos.SetTags(4, tags[1:]); err != nil {
+		t.Fatal(err)
+	}
+
+	open, user := false, uint(1)
+	later := soon.Add(time.Hour)
+	cases := []struct {
+		name   string
+		filter TodoFilter
+		want   []uint
+	}{
+		{"all", TodoFilter{}, []uint{1, 2, 3, 4}},
+		{"open of user 1", TodoFilter{Completed: &open, UserID: &user}, []uint{1, 2, 4}},
+		{"after and limit", TodoFilter{AfterID: 1, Limit: 2}, []uint{2, 3}},
+		{"by priority", TodoFilter{Sort: []TodoSort{{Field: "priority", Desc: true}}}, []uint{2, 3, 4, 1}},
+		{"by title", TodoFilter{Sort: []TodoSort{{Field: "title"}}, Offset: 1}, []uint{1, 3, 4}},
+		// Todos without a due date come last, like NULLs in Postgres
+		{"by due date", TodoFilter{Sort: []TodoSort{{Field: "due_date"}}}, []uint{2, 1, 3, 4}},
+		{"due before", TodoFilter{DueBefore: &later}, []uint{2}},
+		{"due after", TodoFilter{DueAfter: &soon}, nil},
+		{"tagged work", TodoFilter{Tags: []string{"work"}}, []uint{1, 4}},
+		{"tagged work and home", TodoFilter{Tags: []string{"work", "home"}}, []uint{1}},
+	}
+	for _, c := range cases {
+		if got := ids(c.filter); !slices.Equal(got, c.want) {
+			t.Errorf("%s: Find = %v, want %v", c.name, got, c.want)
+		}
+	}
+	if n, _ := repos.Todos.Count(TodoFilter{UserID: &user, Limit: 1}); n != 3 {
+		t.Errorf("Count = %d, want 3 ignoring the limit", n)
+	}
+}
+
+func TestNewInMemoryTodoRepository(t *testing.T) {
+	todos := NewInMemoryTodoRepository()
+
+	// Safe for concurrent use, like the GORM repository
+	var wg sync.WaitGroup
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := todos.Create(&domain.Todo{Title: "Concurrent", UserID: 1}); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	all, err := todos.GetAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := todoIDs(all); len(ids) != 20 || len(slices.Compact(slices.Sorted(slices.Values(ids)))) != 20 {
+		t.Errorf("concurrent Creates stored todos %v, want 20 with distinct IDs", ids)
+	}
+
+	err = todos.Transaction(func(todos TodoRepository, outbox OutboxRepository) error {
+		todo := &domain.Todo{Title: "With event", UserID: 1}
+		if err := todos.Create(todo); err != nil {
+			return err
+		}
+		return outbox.Add(&domain.OutboxEvent{EventID: "e1", Type: "todo.created", UserID: 1, TodoID: todo.ID})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found, err := todos.FindByID(21); err != nil || found.Title != "With event" {
+		t.Errorf("FindByID(21) = %+v, %v, want the todo created in the transaction", found, err)
+	}
+}
+
+func TestMemoryTodoSearch(t *testing.T) {
+	repos := NewMemoryRepositories()
+	for _, todo := range []domain.Todo{
+		{Title: "Water the plants", Description: "Also the garden"},
+		{Title: "Garden party", Description: "Buy plants"},
+		{Title: "Call mum"},
+	} {
+		if err := repos.Todos.Create(&todo); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	matches, total, err := repos.Todos.Search("Plants", TodoSearch{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 2 || len(matches) != 2 || matches[0].ID != 1 || matches[0].Score <= matches[1].Score {
+		t.Errorf("Search(plants) = %+v of %d, want the title match first", matches, total)
+	}
+	// Descriptions match too, and every word must appear
+	if matches, _, _ := repos.Todos.Search("garden plants", TodoSearch{TodoFilter: TodoFilter{Offset: 1}}); len(matches) != 1 {
+		t.Errorf("Search(garden plants) with offset 1 = %d matches, want 1", len(matches))
+	}
+	if _, total, _ := repos.Todos.Search("plants mum", TodoSearch{}); total != 0 {
+		t.Errorf("Search(plants mum) = %d matches, want none", total)
+	}
+}
+
+func TestMemoryTodoTrash(t *testing.T) {
+	repos := NewMemoryRepositories()
+	todo := &domain.Todo{Title: "Old", UserID: 1}
+	if err := repos.Todos.Create(todo); err != nil {
+		t.Fatal(err)
+	}
+	if err := repos.Subtasks.Create(&domain.Subtask{TodoID: todo.ID, Title: "Step"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if restored, _ := repos.Todos.Restore(todo.ID); restored {
+		t.Error("Restore of a live todo succeeded")
+	}
+	if err := repos.Todos.Delete(todo); err != nil {
+		t.Fatal(err)
+	}
+	if trash, _ := repos.Todos.FindTrashed(1); len(trash) != 1 || !trash[0].DeletedAt.Valid {
+		t.Fatalf("FindTrashed = %+v, want the deleted todo", trash)
+	}
+	if restored, _ := repos.Todos.Restore(todo.ID); !restored {
+		t.Fatal("Restore of a deleted todo failed")
+	}
+	if _, err := repos.Todos.FindByID(todo.ID); err != nil {
+		t.Errorf("FindByID after Restore = %v", err)
+	}
+
+	// Purging removes the todo and its subtasks for good
+	if err := repos.Todos.Purge(todo.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repos.Todos.FindTrashedByID(todo.ID); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("FindTrashedByID after Purge = %v, want ErrRecordNotFound", err)
+	}
+	if subtasks, _ := repos.Subtasks.FindByTodoID(todo.ID); len(subtasks) != 0 {
+		t.Errorf("Purge left %d subtasks", len(subtasks))
+	}
+}
+
+func TestMemoryOutbox(t *testing.T) {
+	repos := NewMemoryRepositories()
+	now := time.Now()
+	todo := &domain.Todo{Title: "Ship it", UserID: 1}
+	err := repos.Todos.Transaction(func(todos TodoRepository, outbox OutboxRepository) error {
+		if err := todos.Create(todo); err != nil {
+			return err
+		}
+		return outbox.Add(&domain.OutboxEvent{EventID: "e1", Type: "todo.created", UserID: 1, TodoID: todo.ID, NextAttemptAt: now})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	due, _ := repos.Outbox.FindDue(now, 10)
+	if len(due) != 1 || due[0].TodoID != todo.ID || due[0].Status != domain.OutboxPending {
+		t.Fatalf("FindDue = %+v, want the pending event", due)
+	}
+	// Only the first claim counts until it runs out
+	if claimed, _ := repos.Outbox.Claim(due[0].ID, now, now.Add(time.Minute)); !claimed {
+		t.Fatal("first Claim = false")
+	}
+	if claimed, _ := repos.Outbox.Claim(due[0].ID, now, now.Add(time.Minute)); claimed {
+		t.Error("second Claim = true while the first lasts")
+	}
+	if claimed, _ := repos.Outbox.Claim(due[0].ID, now.Add(2*time.Minute), now.Add(3*time.Minute)); !claimed {
+		t.Error("Claim after the first ran out = false")
+	}
+
+	event, _ := repos.Outbox.FindByID(due[0].ID)
+	published := now.Add(-time.Hour)
+	event.Status, event.PublishedAt = domain.OutboxPublished, &published
+	if err := repos.Outbox.Update(event); err != nil {
+		t.Fatal(err)
+	}
+	if due, _ := repos.Outbox.FindDue(now.Add(time.Hour), 10); len(due) != 0 || event.Attempts != 2 {
+		t.Errorf("published event: due = %+v, attempts = %d, want none and 2", due, event.Attempts)
+	}
+	if deleted, _ := repos.Outbox.DeletePublishedBefore(now); deleted != 1 {
+		t.Errorf("DeletePublishedBefore = %d, want 1", deleted)
+	}
+}
+
+func TestMemoryWebhookDeliveries(t *testing.T) {
+	repos := NewMemoryRepositories()
+	hook := &domain.Webhook{UserID: 1, URL: "https://example.com/hooks", Secret: "whsec_test", Active: true}
+	if err := repos.Webhooks.Create(hook); err != nil {
+		t.Fatal(err)
+	}
+	delivery := domain.WebhookDelivery{WebhookID: hook.ID, EventID: "e1", EventType: "todo.created", Status: domain.WebhookDeliveryPending}
+
+	// Adding an event's delivery again, as a relay retry does, is a no-op
+	for range 2 {
+		if err := repos.Webhooks.AddDeliveries([]domain.WebhookDelivery{delivery}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	stored, err := repos.Webhooks.FindDeliveryByEvent(hook.ID, "e1")
+	if err != nil {
+		t.Fatalf("FindDeliveryByEvent: %v", err)
+	}
+	stored.Attempts = 1
+	if err := repos.Webhooks.UpdateDelivery(stored); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repos.Webhooks.FindDeliveryByEvent(hook.ID, "e2"); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("FindDeliveryByEvent of another event = %v, want ErrRecordNotFound", err)
+	}
+	if log, _ := repos.Webhooks.FindDeliveries(hook.ID, 10); len(log) != 1 || log[0].Attempts != 1 {
+		t.Errorf("FindDeliveries = %+v, want the delivery with one attempt", log)
+	}
+}
+
+func TestMemoryJobs(t *testing.T) {
+	repos := NewMemoryRepositories()
+	now := time.Now()
+	key := "reminder:1"
+
+	// Enqueueing a job with a taken key is a no-op
+	for range 2 {
+		if err := repos.Jobs.Enqueue(&domain.Job{Kind: "reminder.send", Key: &key, Payload: "{}", RunAt: now}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := repos.Jobs.Enqueue(&domain.Job{Kind: "reminder.send", Payload: "{}", RunAt: now.Add(time.Hour)}); err != nil {
+		t.Fatal(err)
+	}
+	if err := repos.Jobs.Enqueue(&domain.Job{Kind: "other", Payload: "{}", RunAt: now}); err != nil {
+		t.Fatal(err)
+	}
+
+	kinds := []string{"reminder.send"}
+	claimed, _ := repos.Jobs.Claim(kinds, now, now.Add(time.Minute), 10)
+	if len(claimed) != 1 || claimed[0].Status != domain.JobRunning || claimed[0].Attempts != 1 {
+		t.Fatalf("Claim = %+v, want the one due job, running on its first attempt", claimed)
+	}
+	if again, _ := repos.Jobs.Claim(kinds, now, now.Add(time.Minute), 10); len(again) != 0 {
+		t.Errorf("Claim while the first claim lasts = %+v, want none", again)
+	}
+
+	// Extended claims last; claims that run out are taken over
+	if err := repos.Jobs.Extend([]uint{claimed[0].ID}, now.Add(3*time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := repos.Jobs.Claim(kinds, now.Add(2*time.Minute), now.Add(3*time.Minute), 10); len(again) != 0 {
+		t.Errorf("Claim while the extended claim lasts = %+v, want none", again)
+	}
+	again, _ := repos.Jobs.Claim(kinds, now.Add(4*time.Minute), now.Add(5*time.Minute), 10)
+	if len(again) != 1 || again[0].Attempts != 2 {
+		t.Fatalf("Claim after the claim ran out = %+v, want the job on its second attempt", again)
+	}
+
+	job := &again[0]
+	finished := now.Add(-time.Minute)
+	job.Status, job.FinishedAt, job.LockedUntil = domain.JobDone, &finished, nil
+	if err := repos.Jobs.Update(job); err != nil {
+		t.Fatal(err)
+	}
+	if deleted, _ := repos.Jobs.DeleteFinishedBefore(now); deleted != 1 {
+		t.Errorf("DeleteFinishedBefore = %d, want 1", deleted)
+	}
+}
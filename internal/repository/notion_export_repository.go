@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"github.com/Tomlord1122/todo-backend/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+// NotionExportRepository defines the interface for Notion export data operations
+type NotionExportRepository interface {
+	// Create stores an export together with its rows
+	Create(export *domain.NotionExport, rows []domain.NotionExportRow) error
+	FindByID(id uint) (*domain.NotionExport, error)
+	Update(export *domain.NotionExport) error
+	FindRows(exportID uint) ([]domain.NotionExportRow, error)
+	UpdateRow(row *domain.NotionExportRow) error
+}
+
+// gormNotionExportRepository implements NotionExportRepository using GORM
+type gormNotionExportRepository struct {
+	db *gorm.DB
+}
+
+// NewGormNotionExportRepository creates a new GORM Notion export repository
+func NewGormNotionExportRepository(db *gorm.DB) NotionExportRepository {
+	return &gormNotionExportRepository{db: db}
+}
+
+// Create stores an export together with its rows
+func (r *gormNotionExportRepository) Create(export *domain.NotionExport, rows []domain.NotionExportRow) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(export).Error; err != nil {
+			return err
+		}
+		for i := range rows {
+			rows[i].ExportID = export.ID
+		}
+		return tx.CreateInBatches(rows, 500).Error
+	})
+}
+
+// FindByID retrieves an export
+func (r *gormNotionExportRepository) FindByID(id uint) (*domain.NotionExport, error) {
+	var export domain.NotionExport
+	result := r.db.First(&export, id)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &export, nil
+}
+
+// Update saves changes to an export
+func (r *gormNotionExportRepository) Update(export *domain.NotionExport) error {
+	return r.db.Save(export).Error
+}
+
+// FindRows retrieves the rows of an export in the order they were added
+func (r *gormNotionExportRepository) FindRows(exportID uint) ([]domain.NotionExportRow, error) {
+	var rows []domain.NotionExportRow
+	result := r.db.Where("export_id = ?", exportID).Order("id ASC").Find(&rows)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return rows, nil
+}
+
+// UpdateRow saves the outcome of a row
+func (r *gormNotionExportRepository) UpdateRow(row *domain.NotionExportRow) error {
+	return r.db.Save(row).Error
+}
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/Tomlord1122/todo-backend/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+// OutboxRepository defines the interface for the domain events waiting to
+// be published. Events are added through TodoRepository.Transaction, in
+// the transaction of the change they describe.
+type OutboxRepository interface {
+	Add(event *domain.OutboxEvent) error
+	FindByID(id uint) (*domain.OutboxEvent, error)
+	// FindDue retrieves up to limit pending events due at now that aren't
+	// claimed, oldest first
+	FindDue(now time.Time, limit int) ([]domain.OutboxEvent, error)
+	// Claim claims a due event until until and counts the attempt. Only
+	// the first caller gets true, so concurrent relays don't publish it
+	// twice while the claim lasts.
+	Claim(id uint, now, until time.Time) (bool, error)
+	Update(event *domain.OutboxEvent) error
+	// DeletePublishedBefore removes the events published before before
+	// for good and returns how many it removed
+	DeletePublishedBefore(before time.Time) (int64, error)
+}
+
+// gormOutboxRepository implements OutboxRepository using GORM
+type gormOutboxRepository struct {
+	db *gorm.DB
+}
+
+// NewGormOutboxRepository creates a new GORM outbox repository
+func NewGormOutboxRepository(db *gorm.DB) OutboxRepository {
+	return &gormOutboxRepository{db: db}
+}
+
+// Add stores a new pending event
+func (r *gormOutboxRepository) Add(event *domain.OutboxEvent) error {
+	return r.db.Create(event).Error
+}
+
+// FindByID retrieves an event by its ID
+func (r *gormOutboxRepository) FindByID(id uint) (*domain.OutboxEvent, error) {
+	var event domain.OutboxEvent
+	result := r.db.First(&event, id)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &event, nil
+}
+
+// FindDue retrieves the pending events that are due and not claimed
+func (r *gormOutboxRepository) FindDue(now time.Time, limit int) ([]domain.OutboxEvent, error) {
+	var events []domain.OutboxEvent
+	err := r.db.Where("status = ? AND next_attempt_at <= ? AND (claimed_until IS NULL OR claimed_until < ?)", domain.OutboxPending, now, now).
+		Order("id ASC").Limit(limit).Find(&events).Error
+	return events, err
+}
+
+// Claim claims an event that is due and not claimed
+func (r *gormOutboxRepository) Claim(id uint, now, until time.Time) (bool, error) {
+	result := r.db.Model(&domain.OutboxEvent{}).
+		Where("id = ? AND status = ? AND next_attempt_at <= ? AND (claimed_until IS NULL OR claimed_until < ?)", id, domain.OutboxPending, now, now).
+		Updates(map[string]any{"claimed_until": until, "attempts": gorm.Expr("attempts + 1")})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// Update saves changes to an event
+func (r *gormOutboxRepository) Update(event *domain.OutboxEvent) error {
+	return r.db.Save(event).Error
+}
+
+// DeletePublishedBefore hard-deletes old published events
+func (r *gormOutboxRepository) DeletePublishedBefore(before time.Time) (int64, error) {
+	result := r.db.Unscoped().Where("status = ? AND published_at < ?", domain.OutboxPublished, before).Delete(&domain.OutboxEvent{})
+	return result.RowsAffected, result.Error
+}
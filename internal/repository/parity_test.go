@@ -0,0 +1,235 @@
+package repository
+
+import (
+	"context"
+	"os"
+	"slices"
+	"testing"
+	"time"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/Tomlord1122/todo-backend/internal/database"
+	"github.com/Tomlord1122/todo-backend/internal/domain"
+)
+
+// forEachBackend runs test against every storage backend: the in-memory
+// repositories, and the GORM ones on Postgres and MySQL when
+// TEST_POSTGRES_DSN or TEST_MYSQL_DSN points at a database the tests may
+// wipe. The MySQL DSN needs parseTime=true, loc=UTC, multiStatements=true
+// and time_zone='+00:00', like the one the API builds. CI runs both.
+func forEachBackend(t *testing.T, test func(t *testing.T, repos *Repositories)) {
+	t.Run("memory", func(t *testing.T) {
+		test(t, NewMemoryRepositories())
+	})
+	backends := []struct {
+		name, env string
+		open      func(dsn string) gorm.Dialector
+	}{
+		{"postgres", "TEST_POSTGRES_DSN", postgres.Open},
+		{"mysql", "TEST_MYSQL_DSN", mysql.Open},
+	}
+	for _, backend := range backends {
+		t.Run(backend.name, func(t *testing.T) {
+			dsn := os.Getenv(backend.env)
+			if dsn == "" {
+				t.Skipf("%s isn't set", backend.env)
+			}
+			db, err := gorm.Open(backend.open(dsn), &gorm.Config{
+				TranslateError: true,
+				Logger:         logger.Default.LogMode(logger.Silent),
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			sqlDB, err := db.DB()
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { sqlDB.Close() })
+			if err := database.Migrate(context.Background(), db); err != nil {
+				t.Fatalf("migrating: %v", err)
+			}
+			repos := NewGormRepositories(db)
+			if err := repos.Reset(); err != nil {
+				t.Fatalf("resetting: %v", err)
+			}
+			test(t, repos)
+		})
+	}
+}
+
+func TestParityTodoOrder(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, repos *Repositories) {
+		soon := time.Now().Add(time.Hour).Truncate(time.Second)
+		later := soon.Add(24 * time.Hour)
+		var ids []uint
+		for _, due := range []*time.Time{&later, nil, &soon} {
+			todo := &domain.Todo{Title: "Todo", UserID: 1, DueDate: due}
+			if err := repos.Todos.Create(todo); err != nil {
+				t.Fatal(err)
+			}
+			ids = append(ids, todo.ID)
+		}
+		withDue, withoutDue := []uint{ids[2], ids[0]}, []uint{ids[1]}
+
+		tests := []struct {
+			name string
+			find func() ([]domain.Todo, error)
+			want []uint
+		}{
+			{"due date ascending", func() ([]domain.Todo, error) {
+				return repos.Todos.Find(TodoFilter{Sort: []TodoSort{{Field: "due_date"}}})
+			}, slices.Concat(withDue, withoutDue)},
+			{"due date descending", func() ([]domain.Todo, error) {
+				return repos.Todos.Find(TodoFilter{Sort: []TodoSort{{Field: "due_date", Desc: true}}})
+			}, slices.Concat(withoutDue, []uint{ids[0], ids[2]})},
+			{"open todos", func() ([]domain.Todo, error) {
+				return repos.Todos.FindOpenByUser(1)
+			}, slices.Concat(withDue, withoutDue)},
+		}
+		for _, tt := range tests {
+			todos, err := tt.find()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := todoIDs(todos); !slices.Equal(got, tt.want) {
+				t.Errorf("%s: got todos %v, want %v", tt.name, got, tt.want)
+			}
+		}
+	})
+}
+
+func TestParityTodoSearch(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, repos *Repositories) {
+		var ids []uint
+		for _, todo := range []*domain.Todo{
+			{Title: "Buy milk", UserID: 1},
+			{Title: "Call the plumber", Description: "The kitchen sink is leaking", UserID: 1},
+			{Title: "Water the garden", Description: "Use the milk crate as a step", UserID: 1},
+			{Title: "Buy milk", UserID: 2},
+		} {
+			if err := repos.Todos.Create(todo); err != nil {
+				t.Fatal(err)
+			}
+			ids = append(ids, todo.ID)
+		}
+		userID := uint(1)
+		mine := TodoFilter{UserID: &userID}
+
+		tests := []struct {
+			name   string
+			query  string
+			search TodoSearch
+			want   []uint
+		}{
+			{"title before description", "milk", TodoSearch{TodoFilter: mine}, []uint{ids[0], ids[2]}},
+			{"description", "kitchen", TodoSearch{TodoFilter: mine}, []uint{ids[1]}},
+			{"every word", "plumber kitchen", TodoSearch{TodoFilter: mine}, []uint{ids[1]}},
+			{"no match", "groceries", TodoSearch{TodoFilter: mine}, nil},
+			{"fuzzy", "plumbr", TodoSearch{TodoFilter: mine, Fuzzy: true, Threshold: 0.3}, []uint{ids[1]}},
+		}
+		for _, tt := range tests {
+			matches, total, err := repos.Todos.Search(tt.query, tt.search)
+			if err != nil {
+				t.Fatalf("%s: %v", tt.name, err)
+			}
+			var got []uint
+			for _, match := range matches {
+				got = append(got, match.ID)
+			}
+			if !slices.Equal(got, tt.want) || total != int64(len(tt.want)) {
+				t.Errorf("%s: Search(%q) = %v of %d, want %v", tt.name, tt.query, got, total, tt.want)
+			}
+		}
+	})
+}
+
+func TestParityTags(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, repos *Repositories) {
+		first, err := repos.Tags.FindOrCreate(1, []string{"work", "home"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		again, err := repos.Tags.FindOrCreate(1, []string{"home", "work", "errands"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(first) != 2 || len(again) != 3 || again[1].ID != first[0].ID || again[2].ID != first[1].ID {
+			t.Errorf("FindOrCreate = %+v then %+v, want the existing tags reused, ordered by name", first, again)
+		}
+		// Deleted tags free their name
+		if err := repos.Tags.Delete(first[0].ID); err != nil {
+			t.Fatal(err)
+		}
+		recreated, err := repos.Tags.FindOrCreate(1, []string{first[0].Name})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(recreated) != 1 || recreated[0].ID == first[0].ID {
+			t.Errorf("FindOrCreate of a deleted tag's name = %+v, want a new tag", recreated)
+		}
+	})
+}
+
+func TestParityLeases(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, repos *Repositories) {
+		steps := []struct {
+			holder string
+			want   bool
+		}{
+			{"a", true},
+			{"b", false},
+			{"a", true},
+		}
+		for _, step := range steps {
+			if ok, err := repos.Leases.Acquire("scheduler", step.holder, time.Minute); err != nil || ok != step.want {
+				t.Fatalf("Acquire by %s = %t, %v, want %t", step.holder, ok, err, step.want)
+			}
+		}
+		if err := repos.Leases.Release("scheduler", "a"); err != nil {
+			t.Fatal(err)
+		}
+		if ok, err := repos.Leases.Acquire("scheduler", "b", time.Minute); err != nil || !ok {
+			t.Errorf("Acquire after Release = %t, %v, want true", ok, err)
+		}
+	})
+}
+
+func TestParityIdempotencyKeys(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, repos *Repositories) {
+		key := &domain.IdempotencyKey{UserID: 1, Key: "retry-1", Fingerprint: "abc", ExpiresAt: time.Now().Add(time.Hour)}
+		if err := repos.IdempotencyKeys.Create(key); err != nil {
+			t.Fatal(err)
+		}
+		key.StatusCode = 201
+		key.Body = []byte(`{"id":1}`)
+		if err := repos.IdempotencyKeys.SaveResponse(key); err != nil {
+			t.Fatal(err)
+		}
+		found, err := repos.IdempotencyKeys.Find(1, "retry-1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if found.StatusCode != 201 || string(found.Body) != `{"id":1}` {
+			t.Errorf("Find = %+v, want the saved response", found)
+		}
+		if err := repos.IdempotencyKeys.Delete(1, "retry-1"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := repos.IdempotencyKeys.Find(1, "retry-1"); err == nil {
+			t.Error("Find after Delete found the key")
+		}
+	})
+}
+
+func todoIDs(todos []domain.Todo) []uint {
+	ids := make([]uint, 0, len(todos))
+	for _, todo := range todos {
+		ids = append(ids, todo.ID)
+	}
+	return ids
+}
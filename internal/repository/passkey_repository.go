@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"github.com/Tomlord1122/todo-backend/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+// PasskeyRepository defines the interface for passkey data operations
+type PasskeyRepository interface {
+	Create(passkey *domain.Passkey) error
+	FindByID(id uint) (*domain.Passkey, error)
+	FindByCredentialID(credentialID string) (*domain.Passkey, error)
+	FindByUserID(userID uint) ([]domain.Passkey, error)
+	Update(passkey *domain.Passkey) error
+	// Delete deletes a passkey and ends the sessions created with it
+	Delete(id uint) error
+}
+
+// gormPasskeyRepository implements PasskeyRepository using GORM
+type gormPasskeyRepository struct {
+	db *gorm.DB
+}
+
+// NewGormPasskeyRepository creates a new GORM passkey repository
+func NewGormPasskeyRepository(db *gorm.DB) PasskeyRepository {
+	return &gormPasskeyRepository{db: db}
+}
+
+// Create stores a new passkey
+func (r *gormPasskeyRepository) Create(passkey *domain.Passkey) error {
+	return r.db.Create(passkey).Error
+}
+
+// FindByID retrieves a passkey by its ID
+func (r *gormPasskeyRepository) FindByID(id uint) (*domain.Passkey, error) {
+	var passkey domain.Passkey
+	result := r.db.First(&passkey, id)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &passkey, nil
+}
+
+// FindByCredentialID retrieves a passkey by its credential ID
+func (r *gormPasskeyRepository) FindByCredentialID(credentialID string) (*domain.Passkey, error) {
+	var passkey domain.Passkey
+	result := r.db.Where("credential_id = ?", credentialID).First(&passkey)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &passkey, nil
+}
+
+// FindByUserID retrieves the passkeys of a user, oldest first
+func (r *gormPasskeyRepository) FindByUserID(userID uint) ([]domain.Passkey, error) {
+	var passkeys []domain.Passkey
+	result := r.db.Where("user_id = ?", userID).Order("id ASC").Find(&passkeys)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return passkeys, nil
+}
+
+// Update saves changes to a passkey
+func (r *gormPasskeyRepository) Update(passkey *domain.Passkey) error {
+	return r.db.Save(passkey).Error
+}
+
+// Delete deletes a passkey and its sessions
+func (r *gormPasskeyRepository) Delete(id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("passkey_id = ?", id).Delete(&domain.AuthSession{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&domain.Passkey{}, id).Error
+	})
+}
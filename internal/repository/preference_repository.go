@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"github.com/Tomlord1122/todo-backend/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+// PreferenceRepository defines the interface for user preference data operations
+type PreferenceRepository interface {
+	FindByUserID(userID uint) (*domain.UserPreference, error)
+	Save(pref *domain.UserPreference) error
+	FindEscalating() ([]domain.UserPreference, error)
+	FindOverdueReminders() ([]domain.UserPreference, error)
+}
+
+// gormPreferenceRepository implements PreferenceRepository using GORM
+type gormPreferenceRepository struct {
+	db *gorm.DB
+}
+
+// NewGormPreferenceRepository creates a new GORM preference repository
+func NewGormPreferenceRepository(db *gorm.DB) PreferenceRepository {
+	return &gormPreferenceRepository{db: db}
+}
+
+// FindByUserID retrieves a user's preferences, or gorm.ErrRecordNotFound if
+// the user never saved any
+func (r *gormPreferenceRepository) FindByUserID(userID uint) (*domain.UserPreference, error) {
+	var pref domain.UserPreference
+	result := r.db.First(&pref, "user_id = ?", userID)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &pref, nil
+}
+
+// Save inserts or updates a user's preferences (upsert on the user ID)
+func (r *gormPreferenceRepository) Save(pref *domain.UserPreference) error {
+	return r.db.Save(pref).Error
+}
+
+// FindEscalating retrieves the preferences of users who opted into overdue escalation
+func (r *gormPreferenceRepository) FindEscalating() ([]domain.UserPreference, error) {
+	var prefs []domain.UserPreference
+	result := r.db.Where("escalation_mode <> ''").Order("user_id ASC").Find(&prefs)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return prefs, nil
+}
+
+// FindOverdueReminders retrieves the preferences of users who want a reminder when todos become overdue
+func (r *gormPreferenceRepository) FindOverdueReminders() ([]domain.UserPreference, error) {
+	var prefs []domain.UserPreference
+	result := r.db.Where("notify_overdue = ?", true).Order("user_id ASC").Find(&prefs)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return prefs, nil
+}
@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"github.com/Tomlord1122/todo-backend/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+// ReactionRepository defines the interface for reaction data operations
+type ReactionRepository interface {
+	Create(reaction *domain.Reaction) error
+	Find(todoID, userID uint, emoji string) (*domain.Reaction, error)
+	FindByTodoID(todoID uint) ([]domain.Reaction, error)
+	Delete(id uint) error
+}
+
+// gormReactionRepository implements ReactionRepository using GORM
+type gormReactionRepository struct {
+	db *gorm.DB
+}
+
+// NewGormReactionRepository creates a new GORM reaction repository
+func NewGormReactionRepository(db *gorm.DB) ReactionRepository {
+	return &gormReactionRepository{db: db}
+}
+
+// Create adds a new reaction
+func (r *gormReactionRepository) Create(reaction *domain.Reaction) error {
+	return r.db.Create(reaction).Error
+}
+
+// Find retrieves a user's reaction to a todo with a given emoji
+func (r *gormReactionRepository) Find(todoID, userID uint, emoji string) (*domain.Reaction, error) {
+	var reaction domain.Reaction
+	result := r.db.Where("todo_id = ? AND user_id = ? AND emoji = ?", todoID, userID, emoji).First(&reaction)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &reaction, nil
+}
+
+// FindByTodoID retrieves every reaction to a todo, oldest first
+func (r *gormReactionRepository) FindByTodoID(todoID uint) ([]domain.Reaction, error) {
+	var reactions []domain.Reaction
+	result := r.db.Where("todo_id = ?", todoID).Order("id ASC").Find(&reactions)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return reactions, nil
+}
+
+// Delete removes a reaction by its ID
+func (r *gormReactionRepository) Delete(id uint) error {
+	return r.db.Delete(&domain.Reaction{}, id).Error
+}
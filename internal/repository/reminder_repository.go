@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/Tomlord1122/todo-backend/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+// ReminderRepository defines the interface for reminder data operations
+type ReminderRepository interface {
+	Create(reminder *domain.Reminder) error
+	FindByID(id uint) (*domain.Reminder, error)
+	// FindByTodoID retrieves a todo's reminders, soonest first
+	FindByTodoID(todoID uint) ([]domain.Reminder, error)
+	// FindDue retrieves up to limit pending reminders due at now, soonest
+	// first
+	FindDue(now time.Time, limit int) ([]domain.Reminder, error)
+	Update(reminder *domain.Reminder) error
+	Delete(id uint) error
+}
+
+// gormReminderRepository implements ReminderRepository using GORM
+type gormReminderRepository struct {
+	db *gorm.DB
+}
+
+// NewGormReminderRepository creates a new GORM reminder repository
+func NewGormReminderRepository(db *gorm.DB) ReminderRepository {
+	return &gormReminderRepository{db: db}
+}
+
+// Create stores a new reminder
+func (r *gormReminderRepository) Create(reminder *domain.Reminder) error {
+	return r.db.Create(reminder).Error
+}
+
+// FindByID retrieves a reminder by its ID
+func (r *gormReminderRepository) FindByID(id uint) (*domain.Reminder, error) {
+	var reminder domain.Reminder
+	result := r.db.First(&reminder, id)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &reminder, nil
+}
+
+// FindByTodoID retrieves the reminders of a todo
+func (r *gormReminderRepository) FindByTodoID(todoID uint) ([]domain.Reminder, error) {
+	var reminders []domain.Reminder
+	result := r.db.Where("todo_id = ?", todoID).Order("remind_at ASC, id ASC").Find(&reminders)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return reminders, nil
+}
+
+// FindDue retrieves the reminders to send
+func (r *gormReminderRepository) FindDue(now time.Time, limit int) ([]domain.Reminder, error) {
+	var reminders []domain.Reminder
+	result := r.db.Where("status = ? AND remind_at <= ?", domain.ReminderPending, now).
+		Order("remind_at ASC, id ASC").Limit(limit).Find(&reminders)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return reminders, nil
+}
+
+// Update saves changes to a reminder
+func (r *gormReminderRepository) Update(reminder *domain.Reminder) error {
+	return r.db.Save(reminder).Error
+}
+
+// Delete removes a reminder by its ID
+func (r *gormReminderRepository) Delete(id uint) error {
+	return r.db.Delete(&domain.Reminder{}, id).Error
+}
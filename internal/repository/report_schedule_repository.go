@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/Tomlord1122/todo-backend/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+// ReportScheduleRepository defines the interface for report schedule data operations
+type ReportScheduleRepository interface {
+	Create(schedule *domain.ReportSchedule) error
+	FindByID(id uint) (*domain.ReportSchedule, error)
+	FindByUserID(userID uint) ([]domain.ReportSchedule, error)
+	FindDue(now time.Time, limit int) ([]domain.ReportSchedule, error)
+	Update(schedule *domain.ReportSchedule) error
+	Delete(id uint) error
+}
+
+// gormReportScheduleRepository implements ReportScheduleRepository using GORM
+type gormReportScheduleRepository struct {
+	db *gorm.DB
+}
+
+// NewGormReportScheduleRepository creates a new GORM report schedule repository
+func NewGormReportScheduleRepository(db *gorm.DB) ReportScheduleRepository {
+	return &gormReportScheduleRepository{db: db}
+}
+
+// Create adds a new schedule to the database
+func (r *gormReportScheduleRepository) Create(schedule *domain.ReportSchedule) error {
+	return r.db.Create(schedule).Error
+}
+
+// FindByID retrieves a schedule by its ID
+func (r *gormReportScheduleRepository) FindByID(id uint) (*domain.ReportSchedule, error) {
+	var schedule domain.ReportSchedule
+	result := r.db.First(&schedule, id)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &schedule, nil
+}
+
+// FindByUserID retrieves all schedules owned by a user
+func (r *gormReportScheduleRepository) FindByUserID(userID uint) ([]domain.ReportSchedule, error) {
+	var schedules []domain.ReportSchedule
+	result := r.db.Where("user_id = ?", userID).Order("id ASC").Find(&schedules)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return schedules, nil
+}
+
+// FindDue retrieves schedules whose next run is at or before now, oldest first
+func (r *gormReportScheduleRepository) FindDue(now time.Time, limit int) ([]domain.ReportSchedule, error) {
+	var schedules []domain.ReportSchedule
+	result := r.db.Where("next_run_at <= ?", now).Order("next_run_at ASC").Limit(limit).Find(&schedules)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return schedules, nil
+}
+
+// Update saves changes to an existing schedule
+func (r *gormReportScheduleRepository) Update(schedule *domain.ReportSchedule) error {
+	return r.db.Save(schedule).Error
+}
+
+// Delete removes a schedule by its ID
+func (r *gormReportScheduleRepository) Delete(id uint) error {
+	return r.db.Delete(&domain.ReportSchedule{}, id).Error
+}
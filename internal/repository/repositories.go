@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ListOptions adjust listing queries.
+type ListOptions struct {
+	// DeletedSince also returns rows soft-deleted after this time, so sync
+	// clients can learn what disappeared. Nil returns live rows only.
+	DeletedSince *time.Time
+}
+
+// scope applies the options to a query on a soft-deleted model.
+func (o ListOptions) scope(db *gorm.DB) *gorm.DB {
+	if o.DeletedSince == nil {
+		return db
+	}
+	return db.Unscoped().Where("(deleted_at IS NULL OR deleted_at > ?)", *o.DeletedSince)
+}
+
+// Repositories bundles one implementation of every repository, so the
+// storage backend can be chosen in one place.
+type Repositories struct {
+	Todos           TodoRepository
+	Lists           ListRepository
+	FeedTokens      FeedTokenRepository
+	ReportSchedules ReportScheduleRepository
+	Preferences     PreferenceRepository
+	Attachments     AttachmentRepository
+	Checklists      ChecklistRepository
+	Reactions       ReactionRepository
+	Activities      ActivityRepository
+	FocusSessions   FocusRepository
+	InboundHooks    InboundHookRepository
+	GitHub          GitHubRepository
+	Calendars       CalendarRepository
+	NotionExports   NotionExportRepository
+	Imports         ImportRepository
+	Leases          LeaseRepository
+	Passkeys        PasskeyRepository
+	Sessions        SessionRepository
+	Identities      IdentityRepository
+	Watchers        WatcherRepository
+	Users           UserRepository
+	APIKeys         APIKeyRepository
+	Tags            TagRepository
+	Subtasks        SubtaskRepository
+	Reminders       ReminderRepository
+	IdempotencyKeys IdempotencyKeyRepository
+	Outbox          OutboxRepository
+	Webhooks        WebhookRepository
+	Jobs            JobRepository
+
+	reset func() error
+}
+
+// Reset deletes every row in every repository. It exists for test and
+// development fixtures; nothing in the application calls it.
+func (r *Repositories) Reset() error {
+	return r.reset()
+}
+
+// NewGormRepositories creates the GORM-backed repositories for db.
+func NewGormRepositories(db *gorm.DB) *Repositories {
+	return &Repositories{
+		Todos:           NewGormTodoRepository(db),
+		Lists:           NewGormListRepository(db),
+		FeedTokens:      NewGormFeedTokenRepository(db),
+		ReportSchedules: NewGormReportScheduleRepository(db),
+		Preferences:     NewGormPreferenceRepository(db),
+		Attachments:     NewGormAttachmentRepository(db),
+		Checklists:      NewGormChecklistRepository(db),
+		Reactions:       NewGormReactionRepository(db),
+		Activities:      NewGormActivityRepository(db),
+		FocusSessions:   NewGormFocusRepository(db),
+		InboundHooks:    NewGormInboundHookRepository(db),
+		GitHub:          NewGormGitHubRepository(db),
+		Calendars:       NewGormCalendarRepository(db),
+		NotionExports:   NewGormNotionExportRepository(db),
+		Imports:         NewGormImportRepository(db),
+		Leases:          NewGormLeaseRepository(db),
+		Passkeys:        NewGormPasskeyRepository(db),
+		Sessions:        NewGormSessionRepository(db),
+		Identities:      NewGormIdentityRepository(db),
+		Watchers:        NewGormWatcherRepository(db),
+		Users:           NewGormUserRepository(db),
+		APIKeys:         NewGormAPIKeyRepository(db),
+		Tags:            NewGormTagRepository(db),
+		Subtasks:        NewGormSubtaskRepository(db),
+		Reminders:       NewGormReminderRepository(db),
+		IdempotencyKeys: NewGormIdempotencyKeyRepository(db),
+		Outbox:          NewGormOutboxRepository(db),
+		Webhooks:        NewGormWebhookRepository(db),
+		Jobs:            NewGormJobRepository(db),
+		reset: func() error {
+			if isMySQL(db) {
+				return truncateMySQL(db)
+			}
+			return db.Exec("TRUNCATE TABLE " + strings.Join(tables, ", ") + " RESTART IDENTITY").Error
+		},
+	}
+}
+
+// tables are the tables Reset empties. Keep in sync with the tables created
+// in migrations/.
+var tables = []string{"todos", "lists", "feed_tokens", "report_schedules", "user_preferences", "attachments", "checklist_items", "reactions", "activities", "focus_sessions", "inbound_hooks", "github_links", "github_issue_syncs", "calendar_connections", "calendar_events", "notion_exports", "notion_export_rows", "imports", "import_errors", "leases", "passkeys", "auth_sessions", "external_identities", "todo_watchers", "users", "api_keys", "tags", "todo_tags", "subtasks", "reminders", "idempotency_keys", "outbox_events", "webhooks", "webhook_deliveries", "jobs"}
+
+// truncateMySQL empties tables on MySQL, which truncates one table at a
+// time and refuses tables referenced by foreign keys unless their checks
+// are off. The setting belongs to the session, so everything runs on one
+// connection.
+func truncateMySQL(db *gorm.DB) error {
+	return db.Connection(func(conn *gorm.DB) error {
+		if err := conn.Exec("SET FOREIGN_KEY_CHECKS = 0").Error; err != nil {
+			return err
+		}
+		defer conn.Exec("SET FOREIGN_KEY_CHECKS = 1")
+		for _, table := range tables {
+			if err := conn.Exec("TRUNCATE TABLE " + table).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// isMySQL reports whether db is a MySQL (or MariaDB) database, whose SQL
+// differs from Postgres' in places.
+func isMySQL(db *gorm.DB) bool {
+	return db.Dialector.Name() == "mysql"
+}
@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/Tomlord1122/todo-backend/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+// SessionRepository defines the interface for sign-in session data
+// operations
+type SessionRepository interface {
+	Create(session *domain.AuthSession) error
+	// Find returns the unexpired session with the token hash
+	Find(tokenHash string, now time.Time) (*domain.AuthSession, error)
+	// Delete ends a session. It returns gorm.ErrRecordNotFound when no
+	// session matched.
+	Delete(tokenHash string) error
+}
+
+// gormSessionRepository implements SessionRepository using GORM
+type gormSessionRepository struct {
+	db *gorm.DB
+}
+
+// NewGormSessionRepository creates a new GORM session repository
+func NewGormSessionRepository(db *gorm.DB) SessionRepository {
+	return &gormSessionRepository{db: db}
+}
+
+// Create stores a new session
+func (r *gormSessionRepository) Create(session *domain.AuthSession) error {
+	return r.db.Create(session).Error
+}
+
+// Find retrieves an unexpired session by its token hash
+func (r *gormSessionRepository) Find(tokenHash string, now time.Time) (*domain.AuthSession, error) {
+	var session domain.AuthSession
+	result := r.db.Where("token_hash = ? AND expires_at > ?", tokenHash, now).First(&session)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &session, nil
+}
+
+// Delete ends a session
+func (r *gormSessionRepository) Delete(tokenHash string) error {
+	result := r.db.Where("token_hash = ?", tokenHash).Delete(&domain.AuthSession{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
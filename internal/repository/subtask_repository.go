@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"github.com/Tomlord1122/todo-backend/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+// SubtaskRepository defines the interface for subtask data operations
+type SubtaskRepository interface {
+	Create(subtask *domain.Subtask) error
+	FindByID(id uint) (*domain.Subtask, error)
+	// FindByTodoID retrieves a todo's subtasks, oldest first
+	FindByTodoID(todoID uint) ([]domain.Subtask, error)
+	Update(subtask *domain.Subtask) error
+	Delete(id uint) error
+}
+
+// gormSubtaskRepository implements SubtaskRepository using GORM
+type gormSubtaskRepository struct {
+	db *gorm.DB
+}
+
+// NewGormSubtaskRepository creates a new GORM subtask repository
+func NewGormSubtaskRepository(db *gorm.DB) SubtaskRepository {
+	return &gormSubtaskRepository{db: db}
+}
+
+// Create adds a new subtask
+func (r *gormSubtaskRepository) Create(subtask *domain.Subtask) error {
+	return r.db.Create(subtask).Error
+}
+
+// FindByID retrieves a subtask by its ID
+func (r *gormSubtaskRepository) FindByID(id uint) (*domain.Subtask, error) {
+	var subtask domain.Subtask
+	result := r.db.First(&subtask, id)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &subtask, nil
+}
+
+// FindByTodoID retrieves the subtasks of a todo
+func (r *gormSubtaskRepository) FindByTodoID(todoID uint) ([]domain.Subtask, error) {
+	var subtasks []domain.Subtask
+	result := r.db.Where("todo_id = ?", todoID).Order("id ASC").Find(&subtasks)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return subtasks, nil
+}
+
+// Update saves changes to a subtask
+func (r *gormSubtaskRepository) Update(subtask *domain.Subtask) error {
+	return r.db.Save(subtask).Error
+}
+
+// Delete removes a subtask by its ID
+func (r *gormSubtaskRepository) Delete(id uint) error {
+	return r.db.Delete(&domain.Subtask{}, id).Error
+}
@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"github.com/Tomlord1122/todo-backend/internal/domain"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// TagRepository defines the interface for tag data operations
+type TagRepository interface {
+	Create(tag *domain.Tag) error
+	FindByID(id uint) (*domain.Tag, error)
+	// FindByUserID retrieves a user's tags, ordered by name
+	FindByUserID(userID uint) ([]domain.Tag, error)
+	// FindByUserIDs retrieves the tags of several users, ordered by user
+	// and name
+	FindByUserIDs(userIDs []uint) ([]domain.Tag, error)
+	// FindOrCreate returns the user's tags with the given names, creating
+	// the missing ones, ordered by name
+	FindOrCreate(userID uint, names []string) ([]domain.Tag, error)
+	Update(tag *domain.Tag) error
+	// Delete deletes a tag and removes it from its todos
+	Delete(id uint) error
+}
+
+// gormTagRepository implements TagRepository using GORM
+type gormTagRepository struct {
+	db *gorm.DB
+}
+
+// NewGormTagRepository creates a new GORM tag repository
+func NewGormTagRepository(db *gorm.DB) TagRepository {
+	return &gormTagRepository{db: db}
+}
+
+// Create stores a new tag
+func (r *gormTagRepository) Create(tag *domain.Tag) error {
+	return r.db.Create(tag).Error
+}
+
+// FindByID retrieves a tag by its ID
+func (r *gormTagRepository) FindByID(id uint) (*domain.Tag, error) {
+	var tag domain.Tag
+	if err := r.db.First(&tag, id).Error; err != nil {
+		return nil, err
+	}
+	return &tag, nil
+}
+
+// FindByUserID retrieves the tags of a user
+func (r *gormTagRepository) FindByUserID(userID uint) ([]domain.Tag, error) {
+	var tags []domain.Tag
+	result := r.db.Where("user_id = ?", userID).Order("name ASC").Find(&tags)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return tags, nil
+}
+
+// FindByUserIDs retrieves the tags of several users at once
+func (r *gormTagRepository) FindByUserIDs(userIDs []uint) ([]domain.Tag, error) {
+	if len(userIDs) == 0 {
+		return nil, nil
+	}
+	var tags []domain.Tag
+	result := r.db.Where("user_id IN ?", userIDs).Order("user_id ASC, name ASC").Find(&tags)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return tags, nil
+}
+
+// FindOrCreate inserts the missing tags, leaving ones created concurrently
+// alone, then reads them all back
+func (r *gormTagRepository) FindOrCreate(userID uint, names []string) ([]domain.Tag, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+	tags := make([]domain.Tag, 0, len(names))
+	for _, name := range names {
+		tags = append(tags, domain.Tag{UserID: userID, Name: name})
+	}
+	if err := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&tags).Error; err != nil {
+		return nil, err
+	}
+	var found []domain.Tag
+	result := r.db.Where("user_id = ? AND name IN ?", userID, names).Order("name ASC").Find(&found)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return found, nil
+}
+
+// Update saves changes to a tag
+func (r *gormTagRepository) Update(tag *domain.Tag) error {
+	return r.db.Save(tag).Error
+}
+
+// Delete removes a tag from its todos and deletes it, in one transaction
+func (r *gormTagRepository) Delete(id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM todo_tags WHERE tag_id = ?", id).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&domain.Tag{}, id).Error
+	})
+}
@@ -1,18 +1,273 @@
 package repository
 
 import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Tomlord1122/todo-backend/internal/database"
 	"github.com/Tomlord1122/todo-backend/internal/domain"
+	"github.com/Tomlord1122/todo-backend/internal/geo"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/plugin/dbresolver"
 )
 
+// ErrVersionConflict is returned by todo writes when the todo was saved by
+// someone else since it was read.
+var ErrVersionConflict = errors.New("todo was changed concurrently")
+
 // TodoRepository defines the interface for todo data operations
 type TodoRepository interface {
 	Create(todo *domain.Todo) error
+	// FindByID retrieves a todo with its tags and subtasks
 	FindByID(id uint) (*domain.Todo, error)
 	GetAll() ([]domain.Todo, error)
+	Find(filter TodoFilter) ([]domain.Todo, error)
+	Count(filter TodoFilter) (int64, error)
+	// Update saves a todo's own fields and moves it to the next Version.
+	// It fails with ErrVersionConflict when the todo was saved since it
+	// was read. Its tags are changed with SetTags.
 	Update(todo *domain.Todo) error
-	Delete(id uint) error
+	// SetTags replaces the tags of a todo
+	SetTags(todoID uint, tags []domain.Tag) error
+	// Delete moves a todo to the trash by soft-deleting it, failing with
+	// ErrVersionConflict when it was saved since it was read
+	Delete(todo *domain.Todo) error
+	// CreateMany creates todos, with their tags, in one transaction
+	CreateMany(todos []*domain.Todo) error
+	// UpdateMany saves the fields and tags of todos in one transaction,
+	// checking their versions like Update
+	UpdateMany(todos []*domain.Todo) error
+	// DeleteMany moves todos to the trash in one statement
+	DeleteMany(ids []uint) error
+	// FindTrashed retrieves a user's deleted todos, most recently deleted
+	// first
+	FindTrashed(userID uint) ([]domain.Todo, error)
+	// FindTrashedByID retrieves a deleted todo
+	FindTrashedByID(id uint) (*domain.Todo, error)
+	// Restore takes a todo out of the trash, reporting false if it wasn't
+	// deleted
+	Restore(id uint) (bool, error)
+	// Purge permanently deletes a todo along with its tag links, subtasks,
+	// checklist, reminders, reactions and followers. Its history is kept.
+	Purge(id uint) error
+	FindDueBetween(userID uint, from, to time.Time) ([]domain.Todo, error)
+	FindCompletedSince(userID uint, since time.Time) ([]domain.Todo, error)
+	FindCompletedBetween(userID uint, from, to time.Time) ([]domain.Todo, error)
+	FindOpenByUser(userID uint) ([]domain.Todo, error)
+	FindByListID(listID uint) ([]domain.Todo, error)
+	FindScheduledByUser(userID uint) ([]domain.Todo, error)
+	Search(query string, filter TodoSearch) ([]TodoMatch, int64, error)
+	FindNearby(lat, lng, radius float64, userID uint, limit int) ([]TodoDistance, error)
+	ClaimOverdueNotification(id uint, at time.Time) (bool, error)
+	// FindPendingOccurrences retrieves recurring todos whose next
+	// occurrence is due to be created, oldest first
+	FindPendingOccurrences(limit int) ([]domain.Todo, error)
+	// CreateOccurrence creates next, the next occurrence of the todo with
+	// ID fromID, and ends fromID's part in the series. Only the first
+	// caller gets true, so an occurrence is never created twice.
+	CreateOccurrence(fromID uint, next *domain.Todo) (bool, error)
+	// Transaction calls fn with repositories sharing one transaction, so
+	// todo writes and the outbox events describing them are saved
+	// together or not at all. It commits when fn returns nil.
+	Transaction(fn func(todos TodoRepository, outbox OutboxRepository) error) error
+	// WithContext returns the repository running its queries with ctx, so
+	// they are canceled with it and traced as part of its span
+	WithContext(ctx context.Context) TodoRepository
+}
+
+// TodoFilter selects todos for Find and Count. Nil fields match any
+// todo. Count ignores the paging fields.
+type TodoFilter struct {
+	ListOptions
+	Completed *bool
+	UserID    *uint
+	// DueBefore and DueAfter keep only todos due strictly before or after
+	// a time. Todos without a due date match neither.
+	DueBefore *time.Time
+	DueAfter  *time.Time
+	// Tags keeps only todos with every one of these tag names
+	Tags []string
+
+	// AfterID skips todos up to this ID. Seeking on the primary key stays
+	// as fast on deep pages as on the first, unlike Offset.
+	AfterID uint
+	Offset  int
+	// Limit caps the number of todos, zero means no cap
+	Limit int
+	// Sort orders the todos before ID, which always breaks ties
+	Sort []TodoSort
+}
+
+// TodoSort orders todos by one field.
+type TodoSort struct {
+	// Field is one of the keys of todoSortColumns
+	Field string
+	Desc  bool
+}
+
+// todoSortColumns maps sort fields to what they order by. Priority is
+// ranked rather than ordered alphabetically. Todos without a due date sort
+// last, or first in descending order, on MySQL too, which would otherwise
+// put NULLs the other way around.
+var todoSortColumns = map[string][]string{
+	"id":         {"id"},
+	"title":      {"title"},
+	"completed":  {"completed"},
+	"priority":   {"CASE priority WHEN 'low' THEN 0 WHEN 'normal' THEN 1 ELSE 2 END"},
+	"due_date":   {"due_date IS NULL", "due_date"},
+	"created_at": {"created_at"},
+	"updated_at": {"updated_at"},
+}
+
+// priorityRank is the in-memory equivalent of the priority sort column.
+func priorityRank(priority string) int {
+	switch priority {
+	case "low":
+		return 0
+	case "normal":
+		return 1
+	}
+	return 2
+}
+
+// compareNullableTimes orders nil after every time, like Find orders
+// NULLs.
+func compareNullableTimes(a, b *time.Time) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return 1
+	case b == nil:
+		return -1
+	}
+	return a.Compare(*b)
+}
+
+// compareTodos is the in-memory equivalent of the order of Find.
+func compareTodos(sorts []TodoSort) func(a, b domain.Todo) int {
+	return func(a, b domain.Todo) int {
+		for _, sort := range sorts {
+			var c int
+			switch sort.Field {
+			case "title":
+				c = strings.Compare(a.Title, b.Title)
+			case "completed":
+				c = compareBools(a.Completed, b.Completed)
+			case "priority":
+				c = cmp.Compare(priorityRank(a.Priority), priorityRank(b.Priority))
+			case "due_date":
+				c = compareNullableTimes(a.DueDate, b.DueDate)
+			case "created_at":
+				c = a.CreatedAt.Compare(b.CreatedAt)
+			case "updated_at":
+				c = a.UpdatedAt.Compare(b.UpdatedAt)
+			case "id":
+				c = cmp.Compare(a.ID, b.ID)
+			}
+			if sort.Desc {
+				c = -c
+			}
+			if c != 0 {
+				return c
+			}
+		}
+		return cmp.Compare(a.ID, b.ID)
+	}
+}
+
+func compareBools(a, b bool) int {
+	switch {
+	case a == b:
+		return 0
+	case a:
+		return 1
+	}
+	return -1
+}
+
+// where applies the conditions of the filter, not the paging.
+func (f TodoFilter) where(db *gorm.DB) *gorm.DB {
+	db = f.scope(db)
+	if f.Completed != nil {
+		db = db.Where("completed = ?", *f.Completed)
+	}
+	if f.UserID != nil {
+		db = db.Where("user_id = ?", *f.UserID)
+	}
+	if f.DueBefore != nil {
+		db = db.Where("due_date < ?", *f.DueBefore)
+	}
+	if f.DueAfter != nil {
+		db = db.Where("due_date > ?", *f.DueAfter)
+	}
+	if len(f.Tags) > 0 {
+		tagged := db.Session(&gorm.Session{NewDB: true}).Table("todo_tags").
+			Select("todo_tags.todo_id").
+			Joins("JOIN tags ON tags.id = todo_tags.tag_id AND tags.deleted_at IS NULL").
+			Where("tags.name IN ?", f.Tags).
+			Group("todo_tags.todo_id").
+			Having("COUNT(DISTINCT tags.name) = ?", len(f.Tags))
+		db = db.Where("todos.id IN (?)", tagged)
+	}
+	return db
+}
+
+// matches is the in-memory equivalent of where, minus ListOptions.
+func (f TodoFilter) matches(t *domain.Todo) bool {
+	return (f.Completed == nil || t.Completed == *f.Completed) &&
+		(f.UserID == nil || t.UserID == *f.UserID) &&
+		(f.DueBefore == nil || (t.DueDate != nil && t.DueDate.Before(*f.DueBefore))) &&
+		(f.DueAfter == nil || (t.DueDate != nil && t.DueDate.After(*f.DueAfter))) &&
+		hasTags(t, f.Tags)
+}
+
+// hasTags reports whether todo has every one of the tag names.
+func hasTags(todo *domain.Todo, names []string) bool {
+	for _, name := range names {
+		if !slices.ContainsFunc(todo.Tags, func(tag domain.Tag) bool { return tag.Name == name }) {
+			return false
+		}
+	}
+	return true
+}
+
+// TodoDistance is a todo found by location, with its distance in meters.
+type TodoDistance struct {
+	domain.Todo
+	Distance float64
+}
+
+// TodoSearch selects todos for Search. The conditions and paging of the
+// TodoFilter apply; results are ordered by relevance, so Sort and AfterID
+// are ignored.
+type TodoSearch struct {
+	TodoFilter
+	// Fuzzy matches titles by trigram similarity, so typos still match,
+	// instead of full-text searching titles and descriptions
+	Fuzzy bool
+	// Threshold is the minimum similarity of fuzzy matches
+	Threshold float64
+}
+
+// todoSearchConfig is the text search configuration of the search_vector
+// column, created by migrations/000001_initial_schema.up.sql; queries must
+// be parsed with the same one.
+const todoSearchConfig = "english"
+
+// TodoMatch is a search result. Score is the trigram similarity for fuzzy
+// searches and the full-text rank otherwise; higher is more relevant.
+type TodoMatch struct {
+	domain.Todo
+	Score float64
 }
 
 // gormTodoRepository implements TodoRepository using GORM
@@ -25,6 +280,51 @@ func NewGormTodoRepository(db *gorm.DB) TodoRepository {
 	return &gormTodoRepository{db: db}
 }
 
+// replica returns the repository reading from a read replica, when the
+// database has them. Replicas lag behind the primary a little, so only reads
+// that can be slightly stale use it: a todo read to update it may be an
+// older version, which fails the version check as a conflict the client
+// retries.
+func (r *gormTodoRepository) replica() *gormTodoRepository {
+	return &gormTodoRepository{db: r.db.Clauses(dbresolver.Use(database.ReplicaResolver))}
+}
+
+// withTags loads the tags of the todos a query finds, in one extra query
+// rather than one per todo.
+func (r *gormTodoRepository) withTags() *gorm.DB {
+	return r.db.Preload("Tags", func(db *gorm.DB) *gorm.DB { return db.Order("tags.name ASC") })
+}
+
+// loadTags fills in the tags of todos read without Preload, as by Scan, in
+// one query.
+func (r *gormTodoRepository) loadTags(todos []*domain.Todo) error {
+	if len(todos) == 0 {
+		return nil
+	}
+	byID := make(map[uint]*domain.Todo, len(todos))
+	for _, todo := range todos {
+		byID[todo.ID] = todo
+	}
+	var rows []struct {
+		TodoID uint
+		domain.Tag
+	}
+	err := r.db.Table("tags").
+		Select("todo_tags.todo_id, tags.*").
+		Joins("JOIN todo_tags ON todo_tags.tag_id = tags.id").
+		Where("todo_tags.todo_id IN ? AND tags.deleted_at IS NULL", slices.Collect(maps.Keys(byID))).
+		Order("tags.name ASC").
+		Scan(&rows).Error
+	if err != nil {
+		return err
+	}
+	for _, row := range rows {
+		todo := byID[row.TodoID]
+		todo.Tags = append(todo.Tags, row.Tag)
+	}
+	return nil
+}
+
 // Create adds a new todo to the database
 func (r *gormTodoRepository) Create(todo *domain.Todo) error {
 	// GORM's Create method handles inserting the record
@@ -36,7 +336,9 @@ func (r *gormTodoRepository) Create(todo *domain.Todo) error {
 func (r *gormTodoRepository) FindByID(id uint) (*domain.Todo, error) {
 	var todo domain.Todo
 	// GORM's First method finds the first record matching the condition (ID)
-	result := r.db.First(&todo, id) // Find by primary key
+	result := r.replica().withTags().
+		Preload("Subtasks", func(db *gorm.DB) *gorm.DB { return db.Order("subtasks.id ASC") }).
+		First(&todo, id) // Find by primary key
 	if result.Error != nil {
 		// Handle potential errors, like gorm.ErrRecordNotFound
 		return nil, result.Error
@@ -48,25 +350,501 @@ func (r *gormTodoRepository) FindByID(id uint) (*domain.Todo, error) {
 func (r *gormTodoRepository) GetAll() ([]domain.Todo, error) {
 	var todos []domain.Todo
 	// GORM's Find method retrieves all records into the slice
-	result := r.db.Find(&todos)
+	result := r.replica().withTags().Find(&todos)
 	if result.Error != nil {
 		return nil, result.Error
 	}
 	return todos, nil
 }
 
+// Find retrieves the todos matching filter, ordered by filter.Sort and ID
+func (r *gormTodoRepository) Find(filter TodoFilter) ([]domain.Todo, error) {
+	query := filter.where(r.withTags())
+	for _, sort := range filter.Sort {
+		columns, ok := todoSortColumns[sort.Field]
+		if !ok {
+			return nil, fmt.Errorf("unknown todo sort field %q", sort.Field)
+		}
+		for _, column := range columns {
+			if sort.Desc {
+				column += " DESC"
+			}
+			query = query.Order(column)
+		}
+	}
+	query = query.Order("id ASC")
+	if filter.AfterID > 0 {
+		query = query.Where("id > ?", filter.AfterID)
+	}
+	if filter.Offset > 0 {
+		query = query.Offset(filter.Offset)
+	}
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+	var todos []domain.Todo
+	if err := query.Find(&todos).Error; err != nil {
+		return nil, err
+	}
+	return todos, nil
+}
+
+// Count returns the number of todos matching filter
+func (r *gormTodoRepository) Count(filter TodoFilter) (int64, error) {
+	var total int64
+	err := filter.where(r.db.Model(&domain.Todo{})).Count(&total).Error
+	return total, err
+}
+
 // Update modifies an existing todo
 func (r *gormTodoRepository) Update(todo *domain.Todo) error {
-	// GORM's Save method updates all fields or inserts if primary key is zero
-	// Or use Updates to update specific fields: r.db.Model(todo).Updates(updatesMap)
-	result := r.db.Save(todo)
+	return saveVersioned(r.db, todo)
+}
+
+// saveVersioned saves all fields of todo with an UPDATE conditional on the
+// version it was read at, and moves it to the next version. Save can't be
+// used: it falls back to an upsert when no row matches.
+func saveVersioned(db *gorm.DB, todo *domain.Todo) error {
+	read := todo.Version
+	todo.Version++
+	result := db.Model(todo).Omit(clause.Associations).Select("*").Where("version = ?", read).Updates(todo)
+	if result.Error == nil && result.RowsAffected == 0 {
+		result.Error = ErrVersionConflict
+	}
+	if result.Error != nil {
+		todo.Version = read
+	}
 	return result.Error
 }
 
+// SetTags replaces the tags of a todo
+func (r *gormTodoRepository) SetTags(todoID uint, tags []domain.Tag) error {
+	todo := &domain.Todo{Model: gorm.Model{ID: todoID}}
+	return r.db.Model(todo).Association("Tags").Replace(tags)
+}
+
 // Delete removes a todo by its ID
-func (r *gormTodoRepository) Delete(id uint) error {
+func (r *gormTodoRepository) Delete(todo *domain.Todo) error {
 	// GORM's Delete method performs a soft delete if the model includes gorm.Model
 	// To permanently delete: r.db.Unscoped().Delete(&domain.Todo{}, id)
-	result := r.db.Delete(&domain.Todo{}, id)
+	result := r.db.Where("version = ?", todo.Version).Delete(&domain.Todo{}, todo.ID)
+	if result.Error == nil && result.RowsAffected == 0 {
+		return ErrVersionConflict
+	}
 	return result.Error
 }
+
+// CreateMany adds todos in a transaction, so either all or none are created
+func (r *gormTodoRepository) CreateMany(todos []*domain.Todo) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for _, todo := range todos {
+			if err := tx.Create(todo).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// UpdateMany saves todos in a transaction, replacing their tags
+func (r *gormTodoRepository) UpdateMany(todos []*domain.Todo) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for _, todo := range todos {
+			if err := saveVersioned(tx, todo); err != nil {
+				return err
+			}
+			if err := tx.Model(todo).Association("Tags").Replace(todo.Tags); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// DeleteMany soft-deletes todos by their IDs
+func (r *gormTodoRepository) DeleteMany(ids []uint) error {
+	return r.db.Delete(&domain.Todo{}, ids).Error
+}
+
+// FindTrashed retrieves the soft-deleted todos of a user
+func (r *gormTodoRepository) FindTrashed(userID uint) ([]domain.Todo, error) {
+	var todos []domain.Todo
+	result := r.withTags().Unscoped().
+		Where("user_id = ? AND deleted_at IS NOT NULL", userID).
+		Order("deleted_at DESC, id DESC").
+		Find(&todos)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return todos, nil
+}
+
+// FindTrashedByID retrieves a soft-deleted todo by its ID
+func (r *gormTodoRepository) FindTrashedByID(id uint) (*domain.Todo, error) {
+	var todo domain.Todo
+	result := r.withTags().Unscoped().Where("deleted_at IS NOT NULL").First(&todo, id)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &todo, nil
+}
+
+// Restore clears the soft-delete timestamp of a todo
+func (r *gormTodoRepository) Restore(id uint) (bool, error) {
+	result := r.db.Unscoped().Model(&domain.Todo{}).
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		Update("deleted_at", nil)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// Purge hard-deletes a todo and the rows that only belong to it in one
+// transaction
+func (r *gormTodoRepository) Purge(id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM todo_tags WHERE todo_id = ?", id).Error; err != nil {
+			return err
+		}
+		for _, model := range []any{&domain.Subtask{}, &domain.ChecklistItem{}, &domain.Reminder{}, &domain.Reaction{}, &domain.TodoWatcher{}} {
+			if err := tx.Unscoped().Where("todo_id = ?", id).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Unscoped().Delete(&domain.Todo{}, id).Error
+	})
+}
+
+// FindDueBetween retrieves a user's open todos with a due date in [from, to)
+func (r *gormTodoRepository) FindDueBetween(userID uint, from, to time.Time) ([]domain.Todo, error) {
+	var todos []domain.Todo
+	result := r.withTags().
+		Where("user_id = ? AND completed = ? AND due_date >= ? AND due_date < ?", userID, false, from, to).
+		Order("due_date ASC").
+		Find(&todos)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return todos, nil
+}
+
+// FindCompletedSince retrieves a user's todos completed at or after the given time
+func (r *gormTodoRepository) FindCompletedSince(userID uint, since time.Time) ([]domain.Todo, error) {
+	var todos []domain.Todo
+	result := r.withTags().
+		Where("user_id = ? AND completed = ? AND completed_at >= ?", userID, true, since).
+		Order("completed_at DESC").
+		Find(&todos)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return todos, nil
+}
+
+// FindCompletedBetween retrieves a user's todos completed in [from, to)
+func (r *gormTodoRepository) FindCompletedBetween(userID uint, from, to time.Time) ([]domain.Todo, error) {
+	var todos []domain.Todo
+	result := r.withTags().
+		Where("user_id = ? AND completed = ? AND completed_at >= ? AND completed_at < ?", userID, true, from, to).
+		Order("completed_at ASC").
+		Find(&todos)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return todos, nil
+}
+
+// FindOpenByUser retrieves all of a user's todos that are not completed yet
+func (r *gormTodoRepository) FindOpenByUser(userID uint) ([]domain.Todo, error) {
+	var todos []domain.Todo
+	// Todos without a due date sort last
+	result := r.withTags().
+		Where("user_id = ? AND completed = ?", userID, false).
+		Order("due_date IS NULL, due_date ASC, id ASC").
+		Find(&todos)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return todos, nil
+}
+
+// FindByListID retrieves all todos in a list
+func (r *gormTodoRepository) FindByListID(listID uint) ([]domain.Todo, error) {
+	var todos []domain.Todo
+	result := r.withTags().Where("list_id = ?", listID).Order("id ASC").Find(&todos)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return todos, nil
+}
+
+// FindScheduledByUser retrieves all of a user's todos that have a due date
+func (r *gormTodoRepository) FindScheduledByUser(userID uint) ([]domain.Todo, error) {
+	var todos []domain.Todo
+	result := r.withTags().Where("user_id = ? AND due_date IS NOT NULL", userID).Order("id ASC").Find(&todos)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return todos, nil
+}
+
+// ClaimOverdueNotification marks a todo's overdue reminder as sent. Only the
+// first caller gets true, so concurrent instances don't send it twice.
+func (r *gormTodoRepository) ClaimOverdueNotification(id uint, at time.Time) (bool, error) {
+	result := r.db.Model(&domain.Todo{}).
+		Where("id = ? AND overdue_notified_at IS NULL", id).
+		Update("overdue_notified_at", at)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// FindPendingOccurrences retrieves the todos with a next occurrence to create
+func (r *gormTodoRepository) FindPendingOccurrences(limit int) ([]domain.Todo, error) {
+	var todos []domain.Todo
+	result := r.withTags().Where("next_occurrence IS NOT NULL").Order("id ASC").Limit(limit).Find(&todos)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return todos, nil
+}
+
+// CreateOccurrence claims fromID's next occurrence and creates it in one
+// transaction
+func (r *gormTodoRepository) CreateOccurrence(fromID uint, next *domain.Todo) (bool, error) {
+	created := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&domain.Todo{}).
+			Where("id = ? AND next_occurrence IS NOT NULL", fromID).
+			Updates(map[string]any{"recurrence": "", "next_occurrence": nil})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		if err := tx.Create(next).Error; err != nil {
+			return err
+		}
+		created = true
+		return nil
+	})
+	return created, err
+}
+
+// Search finds todos matching query, most relevant first, along with the
+// total number of matches. By default it full-text searches titles and
+// descriptions, with web search syntax ("quoted phrases", or, -excluded),
+// ranking title matches above description matches. With Fuzzy set it
+// instead ranks titles by pg_trgm word similarity and drops matches
+// scoring below Threshold. MySQL has no trigrams, so there fuzzy search
+// scores the titles of every todo matching the filter here, like the
+// in-memory repository does.
+func (r *gormTodoRepository) Search(query string, filter TodoSearch) ([]TodoMatch, int64, error) {
+	r = r.replica()
+	var match, score clause.Expr
+	switch {
+	case filter.Fuzzy && isMySQL(r.db):
+		return r.searchTitles(query, filter)
+	case filter.Fuzzy:
+		match = gorm.Expr("word_similarity(?, title) >= ?", query, filter.Threshold)
+		score = gorm.Expr("word_similarity(?, title)", query)
+	case isMySQL(r.db):
+		terms := booleanModeQuery(query)
+		match = gorm.Expr("MATCH(title, description) AGAINST (? IN BOOLEAN MODE)", terms)
+		score = gorm.Expr("MATCH(title) AGAINST (? IN BOOLEAN MODE) + MATCH(title, description) AGAINST (? IN BOOLEAN MODE)", terms, terms)
+	default:
+		match = gorm.Expr("search_vector @@ websearch_to_tsquery(?, ?)", todoSearchConfig, query)
+		score = gorm.Expr("ts_rank(search_vector, websearch_to_tsquery(?, ?))", todoSearchConfig, query)
+	}
+	where := func(db *gorm.DB) *gorm.DB {
+		return filter.where(db.Model(&domain.Todo{})).Where(match)
+	}
+
+	var total int64
+	if err := where(r.db).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	matches := where(r.db).Select("todos.*, ? AS score", score).Order("score DESC, id ASC").Offset(filter.Offset)
+	if filter.Limit > 0 {
+		matches = matches.Limit(filter.Limit)
+	}
+	var results []TodoMatch
+	if err := matches.Scan(&results).Error; err != nil {
+		return nil, 0, err
+	}
+	todos := make([]*domain.Todo, 0, len(results))
+	for i := range results {
+		todos = append(todos, &results[i].Todo)
+	}
+	if err := r.loadTags(todos); err != nil {
+		return nil, 0, err
+	}
+	return results, total, nil
+}
+
+// searchTitles is fuzzy Search scoring titles in Go rather than in the
+// database: it reads the ID and title of every todo matching the filter,
+// then loads the page of best matches.
+func (r *gormTodoRepository) searchTitles(query string, filter TodoSearch) ([]TodoMatch, int64, error) {
+	var candidates []struct {
+		ID    uint
+		Title string
+	}
+	if err := filter.where(r.db.Model(&domain.Todo{})).Select("id, title").Scan(&candidates).Error; err != nil {
+		return nil, 0, err
+	}
+	queryTrigrams := trigrams(query)
+	scores := make(map[uint]float64)
+	var ids []uint
+	for _, candidate := range candidates {
+		if score := wordSimilarity(queryTrigrams, trigrams(candidate.Title)); score >= filter.Threshold {
+			scores[candidate.ID] = score
+			ids = append(ids, candidate.ID)
+		}
+	}
+	slices.SortFunc(ids, func(a, b uint) int {
+		return cmp.Or(cmp.Compare(scores[b], scores[a]), cmp.Compare(a, b))
+	})
+	total := int64(len(ids))
+	ids = limitRows(ids[min(filter.Offset, len(ids)):], filter.Limit)
+	if len(ids) == 0 {
+		return nil, total, nil
+	}
+
+	var todos []domain.Todo
+	if err := filter.scope(r.withTags()).Where("id IN ?", ids).Find(&todos).Error; err != nil {
+		return nil, 0, err
+	}
+	byID := make(map[uint]domain.Todo, len(todos))
+	for _, todo := range todos {
+		byID[todo.ID] = todo
+	}
+	results := make([]TodoMatch, 0, len(ids))
+	for _, id := range ids {
+		if todo, ok := byID[id]; ok {
+			results = append(results, TodoMatch{Todo: todo, Score: scores[id]})
+		}
+	}
+	return results, total, nil
+}
+
+// booleanModeQuery translates web search syntax, as websearch_to_tsquery
+// reads it, to a MySQL boolean mode query: words and "quoted phrases" are
+// required, -excluded ones must be missing, and or separates alternatives,
+// any of which may match. Other characters MySQL treats as operators are
+// dropped.
+func booleanModeQuery(query string) string {
+	var groups [][]string
+	var group []string
+	for {
+		query = strings.TrimLeft(query, " \t\n\r")
+		if query == "" {
+			break
+		}
+		op := "+"
+		if query[0] == '-' {
+			op, query = "-", query[1:]
+		}
+		var raw string
+		quoted := strings.HasPrefix(query, `"`)
+		if quoted {
+			end := strings.IndexByte(query[1:], '"')
+			if end < 0 {
+				raw, query = query[1:], ""
+			} else {
+				raw, query = query[1:end+1], query[end+2:]
+			}
+		} else {
+			end := strings.IndexAny(query, " \t\n\r\"")
+			if end < 0 {
+				end = len(query)
+			}
+			raw, query = query[:end], query[end:]
+		}
+		if !quoted && op == "+" && strings.EqualFold(raw, "or") {
+			if len(group) > 0 {
+				groups, group = append(groups, group), nil
+			}
+			continue
+		}
+		switch terms := words(raw); len(terms) {
+		case 0:
+		case 1:
+			group = append(group, op+terms[0])
+		default:
+			group = append(group, op+`"`+strings.Join(terms, " ")+`"`)
+		}
+	}
+	if len(group) > 0 {
+		groups = append(groups, group)
+	}
+	if len(groups) == 1 {
+		return strings.Join(groups[0], " ")
+	}
+	alternatives := make([]string, len(groups))
+	for i, group := range groups {
+		alternatives[i] = "(" + strings.Join(group, " ") + ")"
+	}
+	return strings.Join(alternatives, " ")
+}
+
+// haversineSQL computes the distance in meters from the point given by the
+// (lat, lat, lng) arguments to a todo's location; see geo.Distance.
+const haversineSQL = `2 * 6371000 * asin(least(1, sqrt(
+	power(sin(radians(latitude - ?) / 2), 2) +
+	cos(radians(?)) * cos(radians(latitude)) * power(sin(radians(longitude - ?) / 2), 2))))`
+
+// FindNearby retrieves open todos with a location near (lat, lng), closest
+// first. With a radius every todo within it matches; with radius 0 a todo
+// matches when the point is inside its own geofence. A non-zero userID
+// keeps only that user's todos.
+func (r *gormTodoRepository) FindNearby(lat, lng, radius float64, userID uint, limit int) ([]TodoDistance, error) {
+	// A bounding box on the location index narrows the rows before the
+	// exact distance is computed
+	box := geo.BoundingBox(lat, lng, cmp.Or(radius, geo.MaxRadius))
+	candidates := r.db.Model(&domain.Todo{}).
+		Select("todos.*, "+haversineSQL+" AS distance", lat, lat, lng).
+		Where("completed = ? AND latitude BETWEEN ? AND ?", false, box.MinLat, box.MaxLat)
+	if !box.WrapsLng {
+		candidates = candidates.Where("longitude BETWEEN ? AND ?", box.MinLng, box.MaxLng)
+	}
+	if userID != 0 {
+		candidates = candidates.Where("user_id = ?", userID)
+	}
+
+	within := "distance <= COALESCE(radius_meters, ?)"
+	args := []interface{}{geo.DefaultRadius}
+	if radius > 0 {
+		within, args = "distance <= ?", []interface{}{radius}
+	}
+
+	var todos []TodoDistance
+	result := r.db.Table("(?) AS nearby", candidates).
+		Where(within, args...).
+		Order("distance ASC, id ASC").
+		Limit(limit).
+		Scan(&todos)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	found := make([]*domain.Todo, 0, len(todos))
+	for i := range todos {
+		found = append(found, &todos[i].Todo)
+	}
+	if err := r.loadTags(found); err != nil {
+		return nil, err
+	}
+	return todos, nil
+}
+
+// WithContext returns a copy of the repository whose queries use ctx
+func (r *gormTodoRepository) WithContext(ctx context.Context) TodoRepository {
+	return &gormTodoRepository{db: r.db.WithContext(ctx)}
+}
+
+// Transaction implements TodoRepository.
+func (r *gormTodoRepository) Transaction(fn func(todos TodoRepository, outbox OutboxRepository) error) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return fn(NewGormTodoRepository(tx), NewGormOutboxRepository(tx))
+	})
+}
@@ -0,0 +1,25 @@
+package repository
+
+import "testing"
+
+func TestBooleanModeQuery(t *testing.T) {
+	tests := []struct {
+		query, want string
+	}{
+		{"buy milk", "+buy +milk"},
+		{`"kitchen sink" -plumber`, `+"kitchen sink" -plumber`},
+		{"milk or eggs", "(+milk) (+eggs)"},
+		{"buy milk OR call plumber", "(+buy +milk) (+call +plumber)"},
+		{`-"not this"`, `-"not this"`},
+		{"e-mail", `+"e mail"`},
+		{`unclosed "quote here`, `+unclosed +"quote here"`},
+		{"+boost* (group) ~less", "+boost +group +less"},
+		{"or milk or", "+milk"},
+		{"  ", ""},
+	}
+	for _, tt := range tests {
+		if got := booleanModeQuery(tt.query); got != tt.want {
+			t.Errorf("booleanModeQuery(%q) = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+}
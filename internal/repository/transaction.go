@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+)
+
+// Postgres error codes that mean "try the whole transaction again".
+const (
+	pgSerializationFailure = "40001"
+	pgDeadlockDetected     = "40P01"
+)
+
+// mysqlDeadlock is the MySQL error number of a transaction rolled back as
+// a deadlock victim, which it also reports for serialization conflicts.
+const mysqlDeadlock = 1213
+
+const (
+	txMaxAttempts = 4
+	txBaseBackoff = 20 * time.Millisecond
+	txMaxBackoff  = 500 * time.Millisecond
+)
+
+// RunInTransaction runs fn in a transaction, retrying it from the start
+// when the database aborts it with a serialization failure or deadlock. Both
+// are expected under concurrent bulk updates and are safe to retry, since
+// the aborted attempt was rolled back. fn must therefore not have side
+// effects outside tx.
+func RunInTransaction(ctx context.Context, db *gorm.DB, fn func(tx *gorm.DB) error) error {
+	return retryTransaction(ctx, txMaxAttempts, func() error {
+		return db.WithContext(ctx).Transaction(fn)
+	})
+}
+
+// retryTransaction calls attempt until it succeeds, fails with a
+// non-retryable error, or runs out of attempts, backing off with jitter in
+// between so the conflicting transactions don't collide again.
+func retryTransaction(ctx context.Context, maxAttempts int, attempt func() error) error {
+	backoff := txBaseBackoff
+	for i := 1; ; i++ {
+		err := attempt()
+		if err == nil || !isRetryableTxError(err) || i == maxAttempts {
+			return err
+		}
+
+		// Full jitter: sleep a random duration up to the current backoff
+		timer := time.NewTimer(rand.N(backoff) + time.Millisecond)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return errors.Join(err, ctx.Err())
+		case <-timer.C:
+		}
+		backoff = min(2*backoff, txMaxBackoff)
+	}
+}
+
+// isRetryableTxError reports whether err is a serialization failure or deadlock.
+func isRetryableTxError(err error) bool {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code == pgSerializationFailure || pgErr.Code == pgDeadlockDetected
+	}
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlDeadlock
+}
@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestRetryTransaction(t *testing.T) {
+	deadlock := fmt.Errorf("update todos: %w", &pgconn.PgError{Code: pgDeadlockDetected})
+	serialization := &pgconn.PgError{Code: pgSerializationFailure}
+
+	cases := []struct {
+		name      string
+		errs      []error // returned by successive attempts
+		wantCalls int
+		wantErr   bool
+	}{
+		{"succeeds first time", []error{nil}, 1, false},
+		{"retries deadlock then succeeds", []error{deadlock, serialization, nil}, 3, false},
+		{"gives up after max attempts", []error{deadlock, deadlock, deadlock, deadlock, nil}, 4, true},
+		{"doesn't retry other errors", []error{&pgconn.PgError{Code: "23505"}, nil}, 1, true},
+		{"retries MySQL deadlocks", []error{&mysql.MySQLError{Number: mysqlDeadlock}, nil}, 2, false},
+		{"doesn't retry other MySQL errors", []error{&mysql.MySQLError{Number: 1062}, nil}, 1, true},
+		{"doesn't retry non-database errors", []error{errors.New("boom"), nil}, 1, true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			calls := 0
+			err := retryTransaction(context.Background(), 4, func() error {
+				calls++
+				return tc.errs[calls-1]
+			})
+			if calls != tc.wantCalls {
+				t.Errorf("attempts = %d, want %d", calls, tc.wantCalls)
+			}
+			if (err != nil) != tc.wantErr {
+				t.Errorf("err = %v, wantErr %t", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestRetryTransactionStopsWhenContextDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	calls := 0
+	err := retryTransaction(ctx, 4, func() error {
+		calls++
+		return &pgconn.PgError{Code: pgDeadlockDetected}
+	})
+	if calls != 1 || !errors.Is(err, context.Canceled) {
+		t.Errorf("calls = %d, err = %v; want 1 call and context.Canceled", calls, err)
+	}
+}
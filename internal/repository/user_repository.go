@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"github.com/Tomlord1122/todo-backend/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+// UserRepository defines the interface for user account data operations
+type UserRepository interface {
+	Create(user *domain.User) error
+	FindByID(id uint) (*domain.User, error)
+	// FindByIDs retrieves the users with the given IDs, skipping unknown ones
+	FindByIDs(ids []uint) ([]domain.User, error)
+	// FindByEmail looks up a user by their lower-cased email address
+	FindByEmail(email string) (*domain.User, error)
+	// FindAll retrieves every user, oldest first
+	FindAll() ([]domain.User, error)
+	// CountByRole counts the users with a role, or all users for ""
+	CountByRole(role domain.Role) (int64, error)
+	Update(user *domain.User) error
+}
+
+// gormUserRepository implements UserRepository using GORM
+type gormUserRepository struct {
+	db *gorm.DB
+}
+
+// NewGormUserRepository creates a new GORM user repository
+func NewGormUserRepository(db *gorm.DB) UserRepository {
+	return &gormUserRepository{db: db}
+}
+
+// Create adds a new user to the database
+func (r *gormUserRepository) Create(user *domain.User) error {
+	return r.db.Create(user).Error
+}
+
+// FindByID retrieves a user by their ID
+func (r *gormUserRepository) FindByID(id uint) (*domain.User, error) {
+	var user domain.User
+	if err := r.db.First(&user, id).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// FindByIDs retrieves the users with the given IDs
+func (r *gormUserRepository) FindByIDs(ids []uint) ([]domain.User, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	var users []domain.User
+	if err := r.db.Where("id IN ?", ids).Order("id ASC").Find(&users).Error; err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// FindByEmail retrieves a user by their email address
+func (r *gormUserRepository) FindByEmail(email string) (*domain.User, error) {
+	var user domain.User
+	if err := r.db.Where("email = ?", email).First(&user).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// FindAll retrieves every user
+func (r *gormUserRepository) FindAll() ([]domain.User, error) {
+	var users []domain.User
+	if err := r.db.Order("id ASC").Find(&users).Error; err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// CountByRole counts the users with a role
+func (r *gormUserRepository) CountByRole(role domain.Role) (int64, error) {
+	query := r.db.Model(&domain.User{})
+	if role != "" {
+		query = query.Where("role = ?", role)
+	}
+	var count int64
+	err := query.Count(&count).Error
+	return count, err
+}
+
+// Update saves changes to a user
+func (r *gormUserRepository) Update(user *domain.User) error {
+	return r.db.Save(user).Error
+}
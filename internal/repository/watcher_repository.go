@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"github.com/Tomlord1122/todo-backend/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+// WatcherRepository defines the interface for todo follower data operations
+type WatcherRepository interface {
+	Create(watcher *domain.TodoWatcher) error
+	Find(todoID, userID uint) (*domain.TodoWatcher, error)
+	FindByTodoID(todoID uint) ([]domain.TodoWatcher, error)
+	FindByUserID(userID uint) ([]domain.TodoWatcher, error)
+	// Delete stops userID following todoID. It returns
+	// gorm.ErrRecordNotFound when the user wasn't following it.
+	Delete(todoID, userID uint) error
+	DeleteByTodoID(todoID uint) error
+}
+
+// gormWatcherRepository implements WatcherRepository using GORM
+type gormWatcherRepository struct {
+	db *gorm.DB
+}
+
+// NewGormWatcherRepository creates a new GORM watcher repository
+func NewGormWatcherRepository(db *gorm.DB) WatcherRepository {
+	return &gormWatcherRepository{db: db}
+}
+
+// Create stores a new follower
+func (r *gormWatcherRepository) Create(watcher *domain.TodoWatcher) error {
+	return r.db.Create(watcher).Error
+}
+
+// Find retrieves a user's follow of a todo
+func (r *gormWatcherRepository) Find(todoID, userID uint) (*domain.TodoWatcher, error) {
+	var watcher domain.TodoWatcher
+	result := r.db.Where("todo_id = ? AND user_id = ?", todoID, userID).First(&watcher)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &watcher, nil
+}
+
+// FindByTodoID retrieves the followers of a todo
+func (r *gormWatcherRepository) FindByTodoID(todoID uint) ([]domain.TodoWatcher, error) {
+	var watchers []domain.TodoWatcher
+	result := r.db.Where("todo_id = ?", todoID).Order("id ASC").Find(&watchers)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return watchers, nil
+}
+
+// FindByUserID retrieves the todos a user follows, most recently followed
+// first
+func (r *gormWatcherRepository) FindByUserID(userID uint) ([]domain.TodoWatcher, error) {
+	var watchers []domain.TodoWatcher
+	result := r.db.Where("user_id = ?", userID).Order("id DESC").Find(&watchers)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return watchers, nil
+}
+
+// Delete removes a user's follow of a todo
+func (r *gormWatcherRepository) Delete(todoID, userID uint) error {
+	result := r.db.Where("todo_id = ? AND user_id = ?", todoID, userID).Delete(&domain.TodoWatcher{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// DeleteByTodoID removes all followers of a todo
+func (r *gormWatcherRepository) DeleteByTodoID(todoID uint) error {
+	return r.db.Where("todo_id = ?", todoID).Delete(&domain.TodoWatcher{}).Error
+}
@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"github.com/Tomlord1122/todo-backend/internal/domain"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// WebhookRepository defines the interface for webhook and webhook delivery
+// data operations
+type WebhookRepository interface {
+	Create(webhook *domain.Webhook) error
+	FindByID(id uint) (*domain.Webhook, error)
+	// FindByUserID retrieves the webhooks of a user, oldest first
+	FindByUserID(userID uint) ([]domain.Webhook, error)
+	Update(webhook *domain.Webhook) error
+	Delete(id uint) error
+
+	// AddDeliveries stores new pending deliveries. Deliveries of an event
+	// a webhook already has are skipped, so adding them again is harmless.
+	AddDeliveries(deliveries []domain.WebhookDelivery) error
+	FindDelivery(id uint) (*domain.WebhookDelivery, error)
+	// FindDeliveries retrieves up to limit deliveries of a webhook, newest
+	// first
+	FindDeliveries(webhookID uint, limit int) ([]domain.WebhookDelivery, error)
+	// FindDeliveryByEvent retrieves the delivery of an event to a webhook
+	FindDeliveryByEvent(webhookID uint, eventID string) (*domain.WebhookDelivery, error)
+	UpdateDelivery(delivery *domain.WebhookDelivery) error
+}
+
+// gormWebhookRepository implements WebhookRepository using GORM
+type gormWebhookRepository struct {
+	db *gorm.DB
+}
+
+// NewGormWebhookRepository creates a new GORM webhook repository
+func NewGormWebhookRepository(db *gorm.DB) WebhookRepository {
+	return &gormWebhookRepository{db: db}
+}
+
+// Create stores a new webhook
+func (r *gormWebhookRepository) Create(webhook *domain.Webhook) error {
+	return r.db.Create(webhook).Error
+}
+
+// FindByID retrieves a webhook by its ID
+func (r *gormWebhookRepository) FindByID(id uint) (*domain.Webhook, error) {
+	var webhook domain.Webhook
+	result := r.db.First(&webhook, id)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &webhook, nil
+}
+
+// FindByUserID retrieves the webhooks of a user
+func (r *gormWebhookRepository) FindByUserID(userID uint) ([]domain.Webhook, error) {
+	var webhooks []domain.Webhook
+	result := r.db.Where("user_id = ?", userID).Order("id ASC").Find(&webhooks)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return webhooks, nil
+}
+
+// Update saves changes to a webhook
+func (r *gormWebhookRepository) Update(webhook *domain.Webhook) error {
+	return r.db.Save(webhook).Error
+}
+
+// Delete removes a webhook by its ID. Its deliveries are kept as a log.
+func (r *gormWebhookRepository) Delete(id uint) error {
+	return r.db.Delete(&domain.Webhook{}, id).Error
+}
+
+// AddDeliveries stores new deliveries, skipping those already stored
+func (r *gormWebhookRepository) AddDeliveries(deliveries []domain.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&deliveries).Error
+}
+
+// FindDelivery retrieves a delivery by its ID
+func (r *gormWebhookRepository) FindDelivery(id uint) (*domain.WebhookDelivery, error) {
+	var delivery domain.WebhookDelivery
+	result := r.db.First(&delivery, id)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &delivery, nil
+}
+
+// FindDeliveries retrieves the latest deliveries of a webhook
+func (r *gormWebhookRepository) FindDeliveries(webhookID uint, limit int) ([]domain.WebhookDelivery, error) {
+	var deliveries []domain.WebhookDelivery
+	result := r.db.Where("webhook_id = ?", webhookID).Order("id DESC").Limit(limit).Find(&deliveries)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return deliveries, nil
+}
+
+// FindDeliveryByEvent retrieves the delivery of an event to a webhook
+func (r *gormWebhookRepository) FindDeliveryByEvent(webhookID uint, eventID string) (*domain.WebhookDelivery, error) {
+	var delivery domain.WebhookDelivery
+	result := r.db.Where("webhook_id = ? AND event_id = ?", webhookID, eventID).First(&delivery)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &delivery, nil
+}
+
+// UpdateDelivery saves changes to a delivery
+func (r *gormWebhookRepository) UpdateDelivery(delivery *domain.WebhookDelivery) error {
+	return r.db.Save(delivery).Error
+}
@@ -0,0 +1,69 @@
+// Package requestid gives every request an ID, the caller's X-Request-ID
+// or a new one, and carries it in the request context. The ID is sent back
+// with the response and passed on with everything the request causes:
+// log lines, error bodies, published events and webhook deliveries, so a
+// flow across services can be followed by one ID.
+package requestid
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+)
+
+// Header is the header requests and responses carry the ID in.
+const Header = "X-Request-ID"
+
+// maxLen bounds IDs taken from callers; longer ones are replaced.
+const maxLen = 128
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying id.
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext returns the ID stored by NewContext, or "" if there is none.
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
+
+// New returns a random ID.
+func New() string {
+	b := make([]byte, 16)
+	// crypto/rand.Read never fails on the supported platforms
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// Middleware takes the request's ID from its X-Request-ID header, or
+// generates one when it has none or it isn't a sane ID, stores it in the
+// request context and sets it on the response.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(Header)
+		if !valid(id) {
+			id = New()
+		}
+		w.Header().Set(Header, id)
+		next.ServeHTTP(w, r.WithContext(NewContext(r.Context(), id)))
+	})
+}
+
+// valid reports whether id is non-empty, at most maxLen long and only
+// made of printable ASCII without spaces or quotes, so it can be logged
+// and passed on as is.
+func valid(id string) bool {
+	if id == "" || len(id) > maxLen {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if c := id[i]; c <= ' ' || c > '~' || c == '"' || c == '\\' {
+			return false
+		}
+	}
+	return true
+}
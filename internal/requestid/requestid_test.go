@@ -0,0 +1,52 @@
+package requestid
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMiddleware(t *testing.T) {
+	tests := []struct {
+		name, header string
+		// keep is whether the caller's ID is used
+		keep bool
+	}{
+		{"caller's ID", "3f1c9a6e-req", true},
+		{"no ID", "", false},
+		{"too long", strings.Repeat("a", maxLen+1), false},
+		{"with spaces", "not an id", false},
+		{"with quotes", `a"b`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var seen string
+			handler := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				seen = FromContext(r.Context())
+			}))
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				req.Header.Set(Header, tt.header)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if seen == "" {
+				t.Fatal("no request ID in the context")
+			}
+			if got := rec.Header().Get(Header); got != seen {
+				t.Errorf("response %s = %q, want the context's %q", Header, got, seen)
+			}
+			if kept := seen == tt.header; kept != tt.keep {
+				t.Errorf("request ID = %q for header %q, want the header kept: %t", seen, tt.header, tt.keep)
+			}
+		})
+	}
+}
+
+func TestNewIsUnique(t *testing.T) {
+	if a, b := New(), New(); a == b || len(a) != 32 {
+		t.Errorf("New() returned %q and %q, want two different 32 character IDs", a, b)
+	}
+}
@@ -0,0 +1,332 @@
+// Package rrule parses iCalendar recurrence rules (RFC 5545 RRULE) and
+// computes their occurrences. It supports the parts recurring todos need:
+// FREQ (DAILY, WEEKLY, MONTHLY or YEARLY), INTERVAL, COUNT, UNTIL, BYDAY
+// without ordinals, BYMONTHDAY, BYMONTH and WKST.
+package rrule
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Frequency is how often a rule repeats.
+type Frequency string
+
+const (
+	Daily   Frequency = "DAILY"
+	Weekly  Frequency = "WEEKLY"
+	Monthly Frequency = "MONTHLY"
+	Yearly  Frequency = "YEARLY"
+)
+
+// maxPeriods bounds the search for the next occurrence, so rules that
+// rarely or never match, such as February 30th, can't loop forever.
+const maxPeriods = 10000
+
+var weekdays = map[string]time.Weekday{
+	"SU": time.Sunday, "MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday,
+	"TH": time.Thursday, "FR": time.Friday, "SA": time.Saturday,
+}
+
+// Rule is a parsed recurrence rule.
+type Rule struct {
+	Freq     Frequency
+	Interval int
+	// Count is the number of occurrences, zero for no limit
+	Count int
+	// Until is the last time an occurrence may fall on, if set
+	Until      *time.Time
+	ByDay      []time.Weekday
+	ByMonthDay []int // 1 to 31, or -1 to -31 counting from the month's end
+	ByMonth    []int
+	WeekStart  time.Weekday
+}
+
+// Parse parses a rule such as "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,FR". An
+// "RRULE:" prefix is allowed.
+func Parse(s string) (Rule, error) {
+	rule := Rule{Interval: 1, WeekStart: time.Monday}
+	s = strings.TrimPrefix(strings.TrimSpace(s), "RRULE:")
+	if s == "" {
+		return rule, errors.New("empty rule")
+	}
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(s, ";") {
+		name, value, ok := strings.Cut(part, "=")
+		name = strings.ToUpper(name)
+		if !ok || value == "" {
+			return rule, fmt.Errorf("malformed part %q", part)
+		}
+		if seen[name] {
+			return rule, fmt.Errorf("%s is given twice", name)
+		}
+		seen[name] = true
+
+		var err error
+		switch name {
+		case "FREQ":
+			rule.Freq = Frequency(strings.ToUpper(value))
+			if !slices.Contains([]Frequency{Daily, Weekly, Monthly, Yearly}, rule.Freq) {
+				err = fmt.Errorf("unsupported FREQ %s", value)
+			}
+		case "INTERVAL":
+			rule.Interval, err = parseInt(name, value, 1, 1000)
+		case "COUNT":
+			rule.Count, err = parseInt(name, value, 1, 100000)
+		case "UNTIL":
+			var until time.Time
+			until, err = parseUntil(value)
+			rule.Until = &until
+		case "BYDAY":
+			for _, day := range strings.Split(value, ",") {
+				weekday, ok := weekdays[strings.ToUpper(day)]
+				if !ok {
+					err = fmt.Errorf("unsupported BYDAY %s, ordinals aren't supported", day)
+					break
+				}
+				rule.ByDay = append(rule.ByDay, weekday)
+			}
+		case "BYMONTHDAY":
+			rule.ByMonthDay, err = parseInts(name, value, -31, 31)
+		case "BYMONTH":
+			rule.ByMonth, err = parseInts(name, value, 1, 12)
+		case "WKST":
+			var ok bool
+			if rule.WeekStart, ok = weekdays[strings.ToUpper(value)]; !ok {
+				err = fmt.Errorf("invalid WKST %s", value)
+			}
+		default:
+			err = fmt.Errorf("unsupported part %s", name)
+		}
+		if err != nil {
+			return rule, err
+		}
+	}
+
+	if rule.Freq == "" {
+		return rule, errors.New("FREQ is required")
+	}
+	if rule.Count > 0 && rule.Until != nil {
+		return rule, errors.New("COUNT and UNTIL can't be combined")
+	}
+	if slices.Contains(rule.ByMonthDay, 0) {
+		return rule, errors.New("BYMONTHDAY must not be 0")
+	}
+	if rule.Freq == Weekly && len(rule.ByMonthDay) > 0 {
+		return rule, errors.New("BYMONTHDAY can't be used with FREQ=WEEKLY")
+	}
+	return rule, nil
+}
+
+func parseInt(name, value string, lo, hi int) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < lo || n > hi {
+		return 0, fmt.Errorf("%s must be a number from %d to %d", name, lo, hi)
+	}
+	return n, nil
+}
+
+func parseInts(name, value string, lo, hi int) ([]int, error) {
+	var ns []int
+	for _, v := range strings.Split(value, ",") {
+		n, err := parseInt(name, v, lo, hi)
+		if err != nil {
+			return nil, err
+		}
+		ns = append(ns, n)
+	}
+	return ns, nil
+}
+
+// parseUntil accepts a date (20260131) or a date-time, in UTC
+// (20260131T090000Z) or floating (20260131T090000), which is taken as UTC.
+func parseUntil(value string) (time.Time, error) {
+	for _, layout := range []string{"20060102T150405Z", "20060102T150405", "20060102"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			if layout == "20060102" {
+				// A date includes the whole day
+				t = t.Add(24*time.Hour - time.Second)
+			}
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid UNTIL %s, expected a date like 20260131 or 20260131T090000Z", value)
+}
+
+// String formats the rule in canonical form, e.g. "FREQ=WEEKLY;BYDAY=MO".
+// Defaults are left out.
+func (r Rule) String() string {
+	parts := []string{"FREQ=" + string(r.Freq)}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if r.Until != nil {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format("20060102T150405Z"))
+	}
+	if len(r.ByDay) > 0 {
+		var days []string
+		for _, day := range r.ByDay {
+			days = append(days, strings.ToUpper(day.String()[:2]))
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if len(r.ByMonthDay) > 0 {
+		parts = append(parts, "BYMONTHDAY="+joinInts(r.ByMonthDay))
+	}
+	if len(r.ByMonth) > 0 {
+		parts = append(parts, "BYMONTH="+joinInts(r.ByMonth))
+	}
+	if r.WeekStart != time.Monday {
+		parts = append(parts, "WKST="+strings.ToUpper(r.WeekStart.String()[:2]))
+	}
+	return strings.Join(parts, ";")
+}
+
+func joinInts(ns []int) string {
+	s := make([]string, len(ns))
+	for i, n := range ns {
+		s[i] = strconv.Itoa(n)
+	}
+	return strings.Join(s, ",")
+}
+
+// Next returns the first occurrence of the rule started at start that
+// falls strictly after after, at start's time of day and in its location.
+// Count is not applied, since only the caller knows how many occurrences
+// have passed; see Advance. It returns false when the rule has ended.
+func (r Rule) Next(start, after time.Time) (time.Time, bool) {
+	for period := 0; period < maxPeriods; period++ {
+		candidates := r.candidates(start, period*r.Interval)
+		if len(candidates) == 0 {
+			continue
+		}
+		for _, t := range candidates {
+			if t.Before(start) || !t.After(after) {
+				continue
+			}
+			if r.Until != nil && t.After(*r.Until) {
+				return time.Time{}, false
+			}
+			return t, true
+		}
+		if r.Until != nil && candidates[0].After(*r.Until) {
+			return time.Time{}, false
+		}
+	}
+	return time.Time{}, false
+}
+
+// Advance returns the rule of the occurrence after this one: the same
+// rule with one occurrence less to go. It returns false when this
+// occurrence was the last one counted.
+func (r Rule) Advance() (Rule, bool) {
+	switch {
+	case r.Count == 0:
+		return r, true
+	case r.Count == 1:
+		return r, false
+	}
+	r.Count--
+	return r, true
+}
+
+// candidates returns the sorted occurrences in the period offset periods
+// after the one containing start.
+func (r Rule) candidates(start time.Time, offset int) []time.Time {
+	year, month, day := start.Date()
+	var days []time.Time
+	switch r.Freq {
+	case Daily:
+		days = []time.Time{r.date(start, year, month, day+offset)}
+	case Weekly:
+		weekStart := day - int((start.Weekday()-r.WeekStart+7)%7) + 7*offset
+		weekdays := r.ByDay
+		if len(weekdays) == 0 {
+			weekdays = []time.Weekday{start.Weekday()}
+		}
+		for i := range 7 {
+			d := r.date(start, year, month, weekStart+i)
+			if slices.Contains(weekdays, d.Weekday()) {
+				days = append(days, d)
+			}
+		}
+	case Monthly:
+		days = r.monthDays(start, year, month+time.Month(offset), day)
+	case Yearly:
+		months := r.ByMonth
+		if len(months) == 0 {
+			months = []int{int(month)}
+		}
+		for _, m := range months {
+			days = append(days, r.monthDays(start, year+offset, time.Month(m), day)...)
+		}
+	}
+
+	var matched []time.Time
+	for _, d := range days {
+		if r.matches(d) {
+			matched = append(matched, d)
+		}
+	}
+	slices.SortFunc(matched, time.Time.Compare)
+	return slices.Compact(matched)
+}
+
+// monthDays returns the days in a month the rule picks: BYMONTHDAY, else
+// the BYDAY weekdays, else the day of the month start fell on. Months too
+// short for a day are skipped, as RFC 5545 requires.
+func (r Rule) monthDays(start time.Time, year int, month time.Month, day int) []time.Time {
+	first := r.date(start, year, month, 1)
+	year, month = first.Year(), first.Month()
+	length := time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+
+	var days []time.Time
+	switch {
+	case len(r.ByMonthDay) > 0:
+		for _, d := range r.ByMonthDay {
+			if d < 0 {
+				d += length + 1
+			}
+			if d >= 1 && d <= length {
+				days = append(days, r.date(start, year, month, d))
+			}
+		}
+	case len(r.ByDay) > 0:
+		for d := 1; d <= length; d++ {
+			days = append(days, r.date(start, year, month, d))
+		}
+	case day <= length:
+		days = append(days, r.date(start, year, month, day))
+	}
+	return days
+}
+
+// matches applies the BY rules that filter rather than expand.
+func (r Rule) matches(t time.Time) bool {
+	if len(r.ByMonth) > 0 && !slices.Contains(r.ByMonth, int(t.Month())) {
+		return false
+	}
+	if len(r.ByDay) > 0 && !slices.Contains(r.ByDay, t.Weekday()) {
+		return false
+	}
+	if len(r.ByMonthDay) > 0 && r.Freq == Daily {
+		length := time.Date(t.Year(), t.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+		if !slices.Contains(r.ByMonthDay, t.Day()) && !slices.Contains(r.ByMonthDay, t.Day()-length-1) {
+			return false
+		}
+	}
+	return true
+}
+
+// date returns the day at start's time of day, normalizing out-of-range
+// months and days like time.Date.
+func (r Rule) date(start time.Time, year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, start.Hour(), start.Minute(), start.Second(), 0, start.Location())
+}
@@ -0,0 +1,120 @@
+package rrule
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in, want, err string
+	}{
+		{in: "FREQ=DAILY", want: "FREQ=DAILY"},
+		{in: "RRULE:freq=weekly;byday=mo,fr;interval=2", want: "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,FR"},
+		{in: "FREQ=MONTHLY;BYMONTHDAY=1,-1;COUNT=6", want: "FREQ=MONTHLY;COUNT=6;BYMONTHDAY=1,-1"},
+		{in: "FREQ=YEARLY;BYMONTH=3;UNTIL=20300101", want: "FREQ=YEARLY;UNTIL=20300101T235959Z;BYMONTH=3"},
+		{in: "FREQ=WEEKLY;INTERVAL=1;WKST=SU", want: "FREQ=WEEKLY;WKST=SU"},
+		{in: "", err: "empty rule"},
+		{in: "INTERVAL=2", err: "FREQ is required"},
+		{in: "FREQ=HOURLY", err: "unsupported FREQ HOURLY"},
+		{in: "FREQ=DAILY;FREQ=WEEKLY", err: "FREQ is given twice"},
+		{in: "FREQ=DAILY;INTERVAL=0", err: "INTERVAL must be a number from 1 to 1000"},
+		{in: "FREQ=MONTHLY;BYDAY=1MO", err: "unsupported BYDAY 1MO, ordinals aren't supported"},
+		{in: "FREQ=MONTHLY;BYMONTHDAY=0", err: "BYMONTHDAY must not be 0"},
+		{in: "FREQ=DAILY;COUNT=2;UNTIL=20300101", err: "COUNT and UNTIL can't be combined"},
+		{in: "FREQ=DAILY;UNTIL=tomorrow", err: "invalid UNTIL tomorrow, expected a date like 20260131 or 20260131T090000Z"},
+		{in: "FREQ=DAILY;BYSETPOS=1", err: "unsupported part BYSETPOS"},
+		{in: "FREQ=DAILY;COUNT", err: `malformed part "COUNT"`},
+	}
+	for _, tt := range tests {
+		rule, err := Parse(tt.in)
+		switch {
+		case tt.err != "":
+			if err == nil || err.Error() != tt.err {
+				t.Errorf("Parse(%q) error = %v, want %q", tt.in, err, tt.err)
+			}
+		case err != nil:
+			t.Errorf("Parse(%q) = %v", tt.in, err)
+		case rule.String() != tt.want:
+			t.Errorf("Parse(%q) = %q, want %q", tt.in, rule.String(), tt.want)
+		}
+	}
+}
+
+func TestNext(t *testing.T) {
+	// Wednesday, January 7th 2026
+	start := time.Date(2026, 1, 7, 9, 30, 0, 0, time.UTC)
+	day := func(month time.Month, d int) time.Time {
+		return time.Date(2026, month, d, 9, 30, 0, 0, time.UTC)
+	}
+	tests := []struct {
+		rule  string
+		after time.Time
+		want  time.Time
+	}{
+		{"FREQ=DAILY", start, day(1, 8)},
+		{"FREQ=DAILY;INTERVAL=3", day(1, 20), day(1, 22)},
+		// Before the start, the start is the first occurrence
+		{"FREQ=DAILY", start.Add(-time.Hour), start},
+		{"FREQ=DAILY;BYDAY=MO,TU,WE,TH,FR", day(1, 9), day(1, 12)},
+		{"FREQ=WEEKLY", start, day(1, 14)},
+		{"FREQ=WEEKLY;BYDAY=MO,FR", start, day(1, 9)},
+		{"FREQ=WEEKLY;INTERVAL=2;BYDAY=MO", start, day(1, 19)},
+		{"FREQ=MONTHLY", start, day(2, 7)},
+		{"FREQ=MONTHLY;BYMONTHDAY=-1", start, day(1, 31)},
+		{"FREQ=MONTHLY;BYMONTHDAY=-1", day(1, 31), day(2, 28)},
+		{"FREQ=MONTHLY;BYDAY=FR", start, day(1, 9)},
+		{"FREQ=YEARLY", start, time.Date(2027, 1, 7, 9, 30, 0, 0, time.UTC)},
+		{"FREQ=YEARLY;BYMONTH=3,9", start, day(3, 7)},
+		{"FREQ=DAILY;BYMONTHDAY=15", start, day(1, 15)},
+	}
+	for _, tt := range tests {
+		rule, err := Parse(tt.rule)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, ok := rule.Next(start, tt.after)
+		if !ok || !got.Equal(tt.want) {
+			t.Errorf("%s: Next(after %s) = %s, %v, want %s", tt.rule, tt.after.Format(time.DateOnly), got, ok, tt.want)
+		}
+	}
+}
+
+func TestNextSkipsShortMonths(t *testing.T) {
+	start := time.Date(2026, 1, 31, 8, 0, 0, 0, time.UTC)
+	rule, _ := Parse("FREQ=MONTHLY")
+	got, _ := rule.Next(start, start)
+	if want := time.Date(2026, 3, 31, 8, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("Next = %s, want %s skipping February", got, want)
+	}
+}
+
+func TestNextEnds(t *testing.T) {
+	start := time.Date(2026, 1, 7, 9, 0, 0, 0, time.UTC)
+	rule, _ := Parse("FREQ=WEEKLY;UNTIL=20260120")
+	if got, ok := rule.Next(start, start); !ok || got.Day() != 14 {
+		t.Errorf("Next = %s, %v, want January 14th", got, ok)
+	}
+	if got, ok := rule.Next(start, start.AddDate(0, 0, 7)); ok {
+		t.Errorf("Next after UNTIL = %s, want none", got)
+	}
+	never, _ := Parse("FREQ=YEARLY;BYMONTH=2;BYMONTHDAY=30")
+	if got, ok := never.Next(start, start); ok {
+		t.Errorf("Next of February 30th = %s, want none", got)
+	}
+}
+
+func TestAdvance(t *testing.T) {
+	rule, _ := Parse("FREQ=DAILY;COUNT=2")
+	next, ok := rule.Advance()
+	if !ok || next.Count != 1 {
+		t.Fatalf("Advance = %+v, %v, want one occurrence left", next, ok)
+	}
+	if _, ok := next.Advance(); ok {
+		t.Error("Advance past the last occurrence succeeded")
+	}
+	forever, _ := Parse("FREQ=DAILY")
+	if next, ok := forever.Advance(); !ok || next.Count != 0 {
+		t.Errorf("Advance without COUNT = %+v, %v", next, ok)
+	}
+}
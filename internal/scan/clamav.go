@@ -0,0 +1,136 @@
+package scan
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"time"
+)
+
+// chunkSize is the size of INSTREAM chunks sent to clamd. clamd rejects
+// streams larger than its StreamMaxLength setting regardless of chunking.
+const chunkSize = 64 << 10
+
+// ClamAVConfig holds the address of a clamd daemon.
+type ClamAVConfig struct {
+	// Network is "tcp" or "unix".
+	Network string
+	Address string
+	Timeout time.Duration
+}
+
+// ClamAVConfigFromEnv reads CLAMAV_ADDR, either "tcp://host:port" or
+// "unix:///path/to/clamd.sock". ok is false when it is unset, meaning
+// uploads are not scanned.
+func ClamAVConfigFromEnv() (cfg ClamAVConfig, ok bool, err error) {
+	addr := os.Getenv("CLAMAV_ADDR")
+	if addr == "" {
+		return cfg, false, nil
+	}
+	network, address, found := strings.Cut(addr, "://")
+	if !found || (network != "tcp" && network != "unix") || address == "" {
+		return cfg, false, fmt.Errorf("invalid CLAMAV_ADDR %q, expected tcp://host:port or unix:///path", addr)
+	}
+	return ClamAVConfig{Network: network, Address: address, Timeout: 2 * time.Minute}, true, nil
+}
+
+// ClamAVScanner scans files with clamd using the INSTREAM command.
+type ClamAVScanner struct {
+	cfg    ClamAVConfig
+	dialer net.Dialer
+}
+
+// NewClamAVScanner creates a scanner for the configured clamd daemon.
+func NewClamAVScanner(cfg ClamAVConfig) *ClamAVScanner {
+	return &ClamAVScanner{cfg: cfg, dialer: net.Dialer{Timeout: 10 * time.Second}}
+}
+
+// Scan implements Scanner.
+func (c *ClamAVScanner) Scan(ctx context.Context, r io.Reader) (Result, error) {
+	conn, err := c.dialer.DialContext(ctx, c.cfg.Network, c.cfg.Address)
+	if err != nil {
+		return Result{}, fmt.Errorf("connecting to clamd: %w", err)
+	}
+	defer conn.Close()
+
+	deadline := time.Now().Add(c.cfg.Timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	_ = conn.SetDeadline(deadline)
+
+	// The z prefix makes clamd use NUL-terminated commands and replies
+	if _, err := io.WriteString(conn, "zINSTREAM\x00"); err != nil {
+		return Result{}, fmt.Errorf("sending INSTREAM: %w", err)
+	}
+	buf := make([]byte, 4+chunkSize)
+	for {
+		n, readErr := r.Read(buf[4:])
+		if n > 0 {
+			binary.BigEndian.PutUint32(buf[:4], uint32(n))
+			if _, err := conn.Write(buf[:4+n]); err != nil {
+				// clamd closes the connection early when the stream is too long;
+				// its reply explains why
+				break
+			}
+		}
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			return Result{}, fmt.Errorf("reading file: %w", readErr)
+		}
+	}
+	// A zero-length chunk ends the stream
+	_, _ = conn.Write([]byte{0, 0, 0, 0})
+
+	reply, err := bufio.NewReader(conn).ReadString(0)
+	if err != nil && !(errors.Is(err, io.EOF) && reply != "") {
+		return Result{}, fmt.Errorf("reading clamd reply: %w", err)
+	}
+	return parseReply(strings.TrimRight(reply, "\x00\n"))
+}
+
+// Ping checks that clamd answers the PING command.
+func (c *ClamAVScanner) Ping(ctx context.Context) error {
+	conn, err := c.dialer.DialContext(ctx, c.cfg.Network, c.cfg.Address)
+	if err != nil {
+		return fmt.Errorf("connecting to clamd: %w", err)
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	if _, err := io.WriteString(conn, "zPING\x00"); err != nil {
+		return fmt.Errorf("sending PING: %w", err)
+	}
+	reply, err := bufio.NewReader(conn).ReadString(0)
+	if err != nil && !(errors.Is(err, io.EOF) && reply != "") {
+		return fmt.Errorf("reading clamd reply: %w", err)
+	}
+	if reply = strings.TrimRight(reply, "\x00\n"); reply != "PONG" {
+		return fmt.Errorf("unexpected clamd reply %q", reply)
+	}
+	return nil
+}
+
+// parseReply interprets clamd replies such as "stream: OK" or
+// "stream: Eicar-Test-Signature FOUND".
+func parseReply(reply string) (Result, error) {
+	verdict := strings.TrimSpace(strings.TrimPrefix(reply, "stream:"))
+	switch {
+	case verdict == "OK":
+		return Result{}, nil
+	case strings.HasSuffix(verdict, " FOUND"):
+		return Result{Infected: true, Signature: strings.TrimSuffix(verdict, " FOUND")}, nil
+	default:
+		return Result{}, fmt.Errorf("clamd: %s", reply)
+	}
+}
@@ -0,0 +1,131 @@
+package scan
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeClamd accepts one INSTREAM session and replies with reply(received bytes).
+func fakeClamd(t *testing.T, reply func([]byte) string) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		if cmd, _ := r.ReadString(0); cmd != "zINSTREAM\x00" {
+			t.Errorf("unexpected command %q", cmd)
+			return
+		}
+		var data []byte
+		for {
+			var size uint32
+			if err := binary.Read(r, binary.BigEndian, &size); err != nil {
+				t.Errorf("reading chunk size: %v", err)
+				return
+			}
+			if size == 0 {
+				break
+			}
+			chunk := make([]byte, size)
+			if _, err := io.ReadFull(r, chunk); err != nil {
+				t.Errorf("reading chunk: %v", err)
+				return
+			}
+			data = append(data, chunk...)
+		}
+		io.WriteString(conn, reply(data)+"\x00")
+	}()
+	return ln.Addr().String()
+}
+
+func TestClamAVScanner(t *testing.T) {
+	cases := map[string]Result{
+		"clean file":                         {},
+		"X5O!P%@AP[4\\PZX54(P^)7CC)7}$EICAR": {Infected: true, Signature: "Eicar-Test-Signature"},
+	}
+	for input, want := range cases {
+		addr := fakeClamd(t, func(data []byte) string {
+			if bytes.Contains(data, []byte("EICAR")) {
+				return "stream: Eicar-Test-Signature FOUND"
+			}
+			return "stream: OK"
+		})
+		scanner := NewClamAVScanner(ClamAVConfig{Network: "tcp", Address: addr, Timeout: 5 * time.Second})
+
+		got, err := scanner.Scan(context.Background(), strings.NewReader(input))
+		if err != nil {
+			t.Fatalf("Scan(%q) returned error: %v", input, err)
+		}
+		if got != want {
+			t.Errorf("Scan(%q) = %+v, want %+v", input, got, want)
+		}
+	}
+}
+
+func TestClamAVScannerStreamsLargeFiles(t *testing.T) {
+	input := bytes.Repeat([]byte("a"), 3*chunkSize+17)
+	addr := fakeClamd(t, func(data []byte) string {
+		if !bytes.Equal(data, input) {
+			t.Errorf("clamd received %d bytes, want %d", len(data), len(input))
+		}
+		return "stream: OK"
+	})
+	scanner := NewClamAVScanner(ClamAVConfig{Network: "tcp", Address: addr, Timeout: 5 * time.Second})
+	if _, err := scanner.Scan(context.Background(), bytes.NewReader(input)); err != nil {
+		t.Fatalf("Scan returned error: %v", err)
+	}
+}
+
+func TestParseReplyError(t *testing.T) {
+	if _, err := parseReply("INSTREAM size limit exceeded. ERROR"); err == nil {
+		t.Error("expected error for clamd error reply")
+	}
+}
+
+func TestClamAVPing(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			if cmd, _ := bufio.NewReader(conn).ReadString(0); cmd == "zPING\x00" {
+				conn.Write([]byte("PONG\x00"))
+			}
+			conn.Close()
+		}
+	}()
+
+	scanner := NewClamAVScanner(ClamAVConfig{Network: "tcp", Address: ln.Addr().String(), Timeout: time.Second})
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := scanner.Ping(ctx); err != nil {
+		t.Errorf("Ping = %v, want nil", err)
+	}
+
+	ln.Close()
+	if err := scanner.Ping(ctx); err == nil {
+		t.Error("Ping succeeded with clamd gone")
+	}
+}
@@ -0,0 +1,20 @@
+// Package scan checks uploaded files for malware.
+package scan
+
+import (
+	"context"
+	"io"
+)
+
+// Result is the verdict for one scanned file.
+type Result struct {
+	Infected bool
+	// Signature names the detected malware when Infected is set.
+	Signature string
+}
+
+// Scanner inspects file contents for malware. An error means no verdict
+// could be reached and the scan should be retried.
+type Scanner interface {
+	Scan(ctx context.Context, r io.Reader) (Result, error)
+}
@@ -0,0 +1,196 @@
+// Package seed fills the repositories with generated, realistic looking
+// data: users with lists, tagged todos and subtasks, in whatever volume a
+// load test or demo needs. Unlike the fixed sets in package fixtures, the
+// data is random, though reproducible for a given seed.
+package seed
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Tomlord1122/todo-backend/internal/domain"
+	"github.com/Tomlord1122/todo-backend/internal/repository"
+	"github.com/brianvoe/gofakeit/v6"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// DefaultPassword is the password of generated users when Config leaves it
+// empty, so demo accounts can sign in.
+const DefaultPassword = "seed-password"
+
+// Config sets how much data Run generates.
+type Config struct {
+	// Users is how many users to create, at least 1
+	Users int
+	// Todos is how many todos to create, spread evenly over the users
+	Todos int
+	// Truncate deletes all existing data first
+	Truncate bool
+	// Password is every generated user's password, DefaultPassword if empty
+	Password string
+	// Seed makes the data reproducible; 0 picks a random one
+	Seed int64
+}
+
+// Counts summarizes what Run created.
+type Counts struct {
+	Users    int
+	Lists    int
+	Todos    int
+	Tags     int
+	Subtasks int
+}
+
+// listNames and tagNames are what users name their lists and tags.
+var (
+	listNames = []string{"Work", "Home", "Errands", "Side project", "Reading", "Fitness", "Travel", "Finances", "Garden", "Learning"}
+	tagNames  = []string{"urgent", "waiting", "quick", "meeting", "email", "phone", "research", "review", "someday", "weekend", "family", "health"}
+)
+
+// Run generates users, each with a few lists and tags, and cfg.Todos todos
+// between them. Dates are relative to now: todos are due from a month ago
+// to two months ahead and about a third are done. Many todos are tagged
+// and some have subtasks.
+func Run(repos *repository.Repositories, cfg Config, now time.Time) (Counts, error) {
+	if cfg.Users < 1 {
+		return Counts{}, fmt.Errorf("seed: invalid number of users %d, expected at least 1", cfg.Users)
+	}
+	if cfg.Todos < 0 {
+		return Counts{}, fmt.Errorf("seed: invalid number of todos %d", cfg.Todos)
+	}
+	if cfg.Password == "" {
+		cfg.Password = DefaultPassword
+	}
+	if cfg.Truncate {
+		if err := repos.Reset(); err != nil {
+			return Counts{}, fmt.Errorf("seed: resetting data: %w", err)
+		}
+	}
+	// bcrypt is slow on purpose, so every user shares one hash
+	hash, err := bcrypt.GenerateFromPassword([]byte(cfg.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return Counts{}, fmt.Errorf("seed: hashing the password: %w", err)
+	}
+
+	g := &generator{repos: repos, faker: gofakeit.New(cfg.Seed), now: now, passwordHash: string(hash)}
+	for i := range cfg.Users {
+		todos := cfg.Todos / cfg.Users
+		if i < cfg.Todos%cfg.Users {
+			todos++
+		}
+		if err := g.user(todos); err != nil {
+			return g.counts, fmt.Errorf("seed: %w", err)
+		}
+	}
+	return g.counts, nil
+}
+
+type generator struct {
+	repos        *repository.Repositories
+	faker        *gofakeit.Faker
+	now          time.Time
+	passwordHash string
+	counts       Counts
+}
+
+// user creates a user with their lists, tags and todos.
+func (g *generator) user(todos int) error {
+	f := g.faker
+	first, last := f.FirstName(), f.LastName()
+	user := &domain.User{
+		// The random part keeps addresses unique across runs
+		Email:        strings.ToLower(fmt.Sprintf("%s.%s.%s@example.com", first, last, f.LetterN(6))),
+		Name:         first + " " + last,
+		PasswordHash: g.passwordHash,
+		Role:         domain.RoleMember,
+	}
+	if err := g.repos.Users.Create(user); err != nil {
+		return fmt.Errorf("creating user: %w", err)
+	}
+	g.counts.Users++
+
+	names := pick(f, listNames, f.IntRange(2, 5))
+	lists := make([]*domain.List, 0, len(names))
+	for _, name := range names {
+		list := &domain.List{Name: name, UserID: user.ID}
+		if err := g.repos.Lists.Create(list); err != nil {
+			return fmt.Errorf("creating list: %w", err)
+		}
+		lists = append(lists, list)
+		g.counts.Lists++
+	}
+	tags, err := g.repos.Tags.FindOrCreate(user.ID, pick(f, tagNames, f.IntRange(4, 8)))
+	if err != nil {
+		return fmt.Errorf("creating tags: %w", err)
+	}
+	g.counts.Tags += len(tags)
+
+	for range todos {
+		if err := g.todo(user.ID, lists, tags); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// todo creates a todo, most in one of lists, tagged with up to three of
+// tags, and sometimes subtasks.
+func (g *generator) todo(userID uint, lists []*domain.List, tags []domain.Tag) error {
+	f := g.faker
+	todo := &domain.Todo{
+		UserID:   userID,
+		Title:    title(f),
+		Priority: f.RandomString([]string{"low", "normal", "normal", "normal", "high"}),
+	}
+	if f.IntRange(0, 1) == 0 {
+		todo.Description = f.SentenceSimple()
+	}
+	if f.IntRange(0, 4) > 0 {
+		todo.ListID = &lists[f.IntRange(0, len(lists)-1)].ID
+	}
+	if f.IntRange(0, 2) == 0 {
+		todo.Completed = true
+		todo.CompletedAt = g.day(-f.IntRange(0, 30))
+	} else if f.IntRange(0, 3) > 0 {
+		todo.DueDate = g.day(f.IntRange(-30, 60))
+	}
+	todo.Tags = append([]domain.Tag(nil), tags...)
+	f.ShuffleAnySlice(todo.Tags)
+	todo.Tags = todo.Tags[:f.IntRange(0, min(3, len(tags)))]
+	if err := g.repos.Todos.Create(todo); err != nil {
+		return fmt.Errorf("creating todo: %w", err)
+	}
+	g.counts.Todos++
+
+	if f.IntRange(0, 3) > 0 {
+		return nil
+	}
+	for range f.IntRange(1, 5) {
+		subtask := &domain.Subtask{TodoID: todo.ID, Title: title(f), Completed: todo.Completed || f.Bool()}
+		if err := g.repos.Subtasks.Create(subtask); err != nil {
+			return fmt.Errorf("creating subtask: %w", err)
+		}
+		g.counts.Subtasks++
+	}
+	return nil
+}
+
+// day returns the current hour offset by the given number of days.
+func (g *generator) day(offset int) *time.Time {
+	t := g.now.Truncate(time.Hour).AddDate(0, 0, offset)
+	return &t
+}
+
+// title makes a todo title like "Repair the broken fence".
+func title(f *gofakeit.Faker) string {
+	verb := f.VerbAction()
+	return strings.ToUpper(verb[:1]) + verb[1:] + " the " + f.AdjectiveDescriptive() + " " + f.NounConcrete()
+}
+
+// pick returns n different names, in random order.
+func pick(f *gofakeit.Faker, names []string, n int) []string {
+	picked := append([]string(nil), names...)
+	f.ShuffleStrings(picked)
+	return picked[:min(n, len(picked))]
+}
@@ -0,0 +1,68 @@
+package seed
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Tomlord1122/todo-backend/internal/domain"
+	"github.com/Tomlord1122/todo-backend/internal/repository"
+)
+
+func TestRun(t *testing.T) {
+	repos := repository.NewMemoryRepositories()
+	now := time.Date(2025, 5, 14, 9, 30, 0, 0, time.UTC)
+	cfg := Config{Users: 3, Todos: 50, Truncate: true, Seed: 1}
+
+	counts, err := Run(repos, cfg, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if counts.Users != 3 || counts.Todos != 50 || counts.Lists < 6 || counts.Tags < 12 {
+		t.Errorf("Run() = %+v, want 3 users with 50 todos, at least 2 lists and 4 tags each", counts)
+	}
+	todos, _ := repos.Todos.GetAll()
+	if len(todos) != 50 {
+		t.Fatalf("repositories hold %d todos, want 50", len(todos))
+	}
+	tagged, subtasks := 0, 0
+	for _, todo := range todos {
+		if len(todo.Tags) > 0 {
+			tagged++
+		}
+		found, _ := repos.Subtasks.FindByTodoID(todo.ID)
+		subtasks += len(found)
+	}
+	if tagged == 0 || subtasks != counts.Subtasks || subtasks == 0 {
+		t.Errorf("%d todos tagged and %d subtasks stored, want some of both and %d subtasks", tagged, subtasks, counts.Subtasks)
+	}
+
+	// The same seed generates the same data
+	again, err := Run(repos, cfg, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	regenerated, _ := repos.Todos.GetAll()
+	if again != counts || len(regenerated) != 50 || regenerated[0].Title != todos[0].Title {
+		t.Errorf("second run with the same seed created %+v, first todo %q; want %+v, %q", again, regenerated[0].Title, counts, todos[0].Title)
+	}
+}
+
+func TestRunKeepsDataWithoutTruncate(t *testing.T) {
+	repos := repository.NewMemoryRepositories()
+	if err := repos.Users.Create(&domain.User{Email: "existing@example.com", PasswordHash: "x"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Run(repos, Config{Users: 2, Todos: 4}, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	users, _ := repos.Users.FindAll()
+	if len(users) != 3 {
+		t.Errorf("%d users after seeding 2 more, want 3", len(users))
+	}
+}
+
+func TestRunRejectsNoUsers(t *testing.T) {
+	if _, err := Run(repository.NewMemoryRepositories(), Config{Todos: 10}, time.Now()); err == nil {
+		t.Error("Run() without users succeeded, want an error")
+	}
+}
@@ -0,0 +1,20 @@
+package server
+
+import (
+	"net/http"
+)
+
+func (s *Server) listActivityHandler(w http.ResponseWriter, r *http.Request) {
+	todoID, ok := parseIDParam(w, r, "id", "todo")
+	if !ok {
+		return
+	}
+
+	activities, err := s.activityService.ListByTodo(r.Context(), todoID)
+	if err != nil {
+		respondWithServiceError(w, r, err, "ListByTodo", "Failed to retrieve activity")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, activities)
+}
@@ -0,0 +1,152 @@
+package server
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Tomlord1122/todo-backend/internal/clientip"
+	"github.com/Tomlord1122/todo-backend/internal/database"
+	"github.com/Tomlord1122/todo-backend/internal/logging"
+	"github.com/Tomlord1122/todo-backend/internal/requestid"
+)
+
+// adminAuditSize is how many admin changes the audit trail keeps.
+const adminAuditSize = 200
+
+// adminAuditEntry records one change made through the admin API.
+type adminAuditEntry struct {
+	At        time.Time   `json:"at"`
+	Action    string      `json:"action"`
+	ClientIP  string      `json:"client_ip"`
+	RequestID string      `json:"request_id,omitempty"`
+	Reason    string      `json:"reason,omitempty"`
+	Before    interface{} `json:"before"`
+	After     interface{} `json:"after"`
+}
+
+// adminAudit keeps the most recent admin changes of this instance, newest
+// first. Every change is also written to the log, which outlives restarts.
+type adminAudit struct {
+	mu      sync.Mutex
+	entries []adminAuditEntry
+}
+
+func (a *adminAudit) record(r *http.Request, action, reason string, before, after interface{}) {
+	entry := adminAuditEntry{
+		At:        time.Now(),
+		Action:    action,
+		ClientIP:  clientip.FromRequest(r),
+		RequestID: requestid.FromContext(r.Context()),
+		Reason:    reason,
+		Before:    before,
+		After:     after,
+	}
+	logging.FromContext(r.Context()).Info("Admin audit", "action", action, "client_ip", entry.ClientIP,
+		"reason", reason, "before", before, "after", after)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.entries = append([]adminAuditEntry{entry}, a.entries...)
+	if len(a.entries) > adminAuditSize {
+		a.entries = a.entries[:adminAuditSize]
+	}
+}
+
+func (a *adminAudit) snapshot() []adminAuditEntry {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return append([]adminAuditEntry{}, a.entries...)
+}
+
+// requireAdmin guards operational endpoints with the ADMIN_TOKEN bearer
+// token. Without a configured token the admin API is disabled entirely.
+func (s *Server) requireAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.adminToken == "" {
+			respondWithError(w, r, http.StatusNotFound, "Not found")
+			return
+		}
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(s.adminToken)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="admin"`)
+			respondWithError(w, r, http.StatusUnauthorized, "Invalid admin token")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// slowQueriesHandler lists the captured slow queries. Only those run with
+// the request's context, todo queries for now, carry its request ID.
+func (s *Server) slowQueriesHandler(w http.ResponseWriter, r *http.Request) {
+	page, ok := parsePageQuery(w, r)
+	if !ok {
+		return
+	}
+	limit, err := s.pages.PageSize(page.Limit)
+	if err != nil {
+		respondWithError(w, r, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, s.db.SlowQueries().Snapshot(limit))
+}
+
+func (s *Server) getReadOnlyHandler(w http.ResponseWriter, r *http.Request) {
+	respondWithJSON(w, http.StatusOK, s.readOnly.Status())
+}
+
+type setReadOnlyRequest struct {
+	Enabled *bool  `json:"enabled"`
+	Reason  string `json:"reason"`
+}
+
+func (s *Server) setReadOnlyHandler(w http.ResponseWriter, r *http.Request) {
+	var req setReadOnlyRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+	if req.Enabled == nil {
+		respondWithError(w, r, http.StatusBadRequest, "enabled is required")
+		return
+	}
+
+	before := s.readOnly.Status()
+	status := s.readOnly.Set(*req.Enabled, req.Reason)
+	s.audit.record(r, "read_only", req.Reason, before, status)
+	respondWithJSON(w, http.StatusOK, status)
+}
+
+func (s *Server) getDBSettingsHandler(w http.ResponseWriter, r *http.Request) {
+	respondWithJSON(w, http.StatusOK, s.db.Settings())
+}
+
+type setDBSettingsRequest struct {
+	database.SettingsUpdate
+	Reason string `json:"reason"`
+}
+
+// setDBSettingsHandler serves PUT /admin/db-settings. Only the fields sent
+// change, e.g. {"max_open_conns": 20, "reason": "db CPU at 100%"}.
+func (s *Server) setDBSettingsHandler(w http.ResponseWriter, r *http.Request) {
+	var req setDBSettingsRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+
+	before := s.db.Settings()
+	settings, err := s.db.UpdateSettings(req.SettingsUpdate)
+	if err != nil {
+		respondWithServiceError(w, r, err, "UpdateSettings", "Failed to update database settings")
+		return
+	}
+	s.audit.record(r, "db_settings", req.Reason, before, settings)
+	respondWithJSON(w, http.StatusOK, settings)
+}
+
+func (s *Server) adminAuditHandler(w http.ResponseWriter, r *http.Request) {
+	respondWithJSON(w, http.StatusOK, s.audit.snapshot())
+}
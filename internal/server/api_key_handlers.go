@@ -0,0 +1,46 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/Tomlord1122/todo-backend/internal/service"
+)
+
+func (s *Server) createAPIKeyHandler(w http.ResponseWriter, r *http.Request) {
+	var req service.CreateAPIKeyRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+
+	key, err := s.apiKeyService.CreateAPIKey(r.Context(), sessionUserFrom(r), req)
+	if err != nil {
+		respondWithServiceError(w, r, err, "CreateAPIKey", "Failed to create API key")
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, key)
+}
+
+func (s *Server) listAPIKeysHandler(w http.ResponseWriter, r *http.Request) {
+	keys, err := s.apiKeyService.ListAPIKeys(r.Context(), sessionUserFrom(r))
+	if err != nil {
+		respondWithServiceError(w, r, err, "ListAPIKeys", "Failed to retrieve API keys")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, keys)
+}
+
+func (s *Server) revokeAPIKeyHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseIDParam(w, r, "id", "API key")
+	if !ok {
+		return
+	}
+
+	if err := s.apiKeyService.RevokeAPIKey(r.Context(), sessionUserFrom(r), id); err != nil {
+		respondWithServiceError(w, r, err, "RevokeAPIKey", "Failed to revoke API key")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,121 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Tomlord1122/todo-backend/internal/service"
+)
+
+// respondWithAttachmentError maps attachment service errors to HTTP responses.
+func respondWithAttachmentError(w http.ResponseWriter, r *http.Request, err error, operation, fallback string) {
+	switch {
+	case errors.Is(err, service.ErrThumbnailPending):
+		w.Header().Set("Retry-After", "15")
+	case errors.Is(err, service.ErrAttachmentScanning):
+		w.Header().Set("Retry-After", "30")
+	}
+	respondWithServiceError(w, r, err, operation, fallback)
+}
+
+func (s *Server) presignAttachmentHandler(w http.ResponseWriter, r *http.Request) {
+	todoID, ok := parseIDParam(w, r, "id", "todo")
+	if !ok {
+		return
+	}
+
+	var req service.PresignAttachmentRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+
+	presigned, err := s.attachmentService.Presign(r.Context(), todoID, req)
+	if err != nil {
+		respondWithAttachmentError(w, r, err, "Presign", "Failed to create attachment")
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, presigned)
+}
+
+func (s *Server) confirmAttachmentHandler(w http.ResponseWriter, r *http.Request) {
+	todoID, ok := parseIDParam(w, r, "id", "todo")
+	if !ok {
+		return
+	}
+	attachmentID, ok := parseIDParam(w, r, "attachmentID", "attachment")
+	if !ok {
+		return
+	}
+
+	attachment, err := s.attachmentService.Confirm(r.Context(), todoID, attachmentID)
+	if err != nil {
+		respondWithAttachmentError(w, r, err, "Confirm", "Failed to confirm attachment")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, attachment)
+}
+
+func (s *Server) listAttachmentsHandler(w http.ResponseWriter, r *http.Request) {
+	todoID, ok := parseIDParam(w, r, "id", "todo")
+	if !ok {
+		return
+	}
+
+	attachments, err := s.attachmentService.ListByTodo(r.Context(), todoID)
+	if err != nil {
+		respondWithAttachmentError(w, r, err, "ListByTodo", "Failed to retrieve attachments")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, attachments)
+}
+
+func (s *Server) downloadAttachmentHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseIDParam(w, r, "id", "attachment")
+	if !ok {
+		return
+	}
+
+	url, err := s.attachmentService.DownloadURL(r.Context(), id)
+	if err != nil {
+		respondWithAttachmentError(w, r, err, "DownloadURL", "Failed to create download URL")
+		return
+	}
+
+	// The presigned URL expires quickly, don't let clients cache the redirect
+	w.Header().Set("Cache-Control", "no-store")
+	http.Redirect(w, r, url, http.StatusFound)
+}
+
+func (s *Server) attachmentThumbnailHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseIDParam(w, r, "id", "attachment")
+	if !ok {
+		return
+	}
+
+	url, err := s.attachmentService.ThumbnailURL(r.Context(), id, r.URL.Query().Get("size"))
+	if err != nil {
+		respondWithAttachmentError(w, r, err, "ThumbnailURL", "Failed to create thumbnail URL")
+		return
+	}
+
+	// Thumbnails never change, but the presigned URL expires
+	w.Header().Set("Cache-Control", "no-store")
+	http.Redirect(w, r, url, http.StatusFound)
+}
+
+func (s *Server) deleteAttachmentHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseIDParam(w, r, "id", "attachment")
+	if !ok {
+		return
+	}
+
+	if err := s.attachmentService.Delete(r.Context(), id); err != nil {
+		respondWithAttachmentError(w, r, err, "Delete", "Failed to delete attachment")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,38 @@
+package server
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/Tomlord1122/todo-backend/internal/service"
+)
+
+func (s *Server) registerHandler(w http.ResponseWriter, r *http.Request) {
+	var req service.RegisterRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+
+	token, err := s.authService.Register(r.Context(), req, time.Now())
+	if err != nil {
+		respondWithServiceError(w, r, err, "Register", "Failed to register")
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, token)
+}
+
+func (s *Server) loginHandler(w http.ResponseWriter, r *http.Request) {
+	var req service.LoginRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+
+	token, err := s.authService.Login(r.Context(), req, time.Now())
+	if err != nil {
+		respondWithServiceError(w, r, err, "Login", "Failed to sign in")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, token)
+}
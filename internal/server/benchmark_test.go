@@ -0,0 +1,89 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/Tomlord1122/todo-backend/internal/apispec"
+)
+
+// benchmarkServer returns the full handler stack, middleware included,
+// with n todos already created, and the access token of their owner.
+func benchmarkServer(b *testing.B, n int) (http.Handler, string) {
+	b.Helper()
+	b.Setenv("READ_ONLY", "")
+	// The access log still formats every line, but to /dev/null
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() { devNull.Close() })
+	stdout := os.Stdout
+	os.Stdout = devNull
+	handler := newGoldenServer()
+	os.Stdout = stdout
+	rec := benchmarkRequest(handler, "", "POST", "/auth/register", `{"email":"bench@example.com","password":"benchmark"}`)
+	var login struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &login); err != nil || login.AccessToken == "" {
+		b.Fatalf("registering = %d %s", rec.Code, rec.Body)
+	}
+	for i := 0; i < n; i++ {
+		body := fmt.Sprintf(`{"title":"Todo %d: water the plants"}`, i)
+		if rec := benchmarkRequest(handler, login.AccessToken, "POST", "/todos", body); rec.Code != http.StatusCreated {
+			b.Fatalf("seeding todo %d = %d %s", i, rec.Code, rec.Body)
+		}
+	}
+	return handler, login.AccessToken
+}
+
+// benchmarkRequest sends a request to the API path, relative to apispec.BasePath.
+func benchmarkRequest(handler http.Handler, token, method, path, body string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(method, apispec.BasePath+path, strings.NewReader(body))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	if method == "PUT" || method == "DELETE" {
+		req.Header.Set("If-Match", "*")
+	}
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func BenchmarkHandlers(b *testing.B) {
+	handler, token := benchmarkServer(b, 1000)
+	cases := []struct {
+		name, method, path, body string
+		wantCode                 int
+	}{
+		{"CreateTodo", "POST", "/todos", `{"title":"Benchmark","description":"created by a benchmark","user_id":1,"priority":"high"}`, http.StatusCreated},
+		{"GetTodo", "GET", "/todos/500", "", http.StatusOK},
+		{"ListTodos", "GET", "/todos?limit=50&offset=100", "", http.StatusOK},
+		{"SearchTodos", "GET", "/todos/search?q=plants&limit=20", "", http.StatusOK},
+		// %d is replaced by the iteration so every update changes something
+		{"UpdateTodo", "PUT", "/todos/500", `{"title":"Renamed %d"}`, http.StatusOK},
+		{"NotFound", "GET", "/todos/999999", "", http.StatusNotFound},
+		{"InvalidBody", "POST", "/todos", `{"title":"x","colour":"red"}`, http.StatusUnprocessableEntity},
+	}
+	for _, tc := range cases {
+		b.Run(tc.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				body := tc.body
+				if strings.Contains(body, "%d") {
+					body = fmt.Sprintf(body, i)
+				}
+				if rec := benchmarkRequest(handler, token, tc.method, tc.path, body); rec.Code != tc.wantCode {
+					b.Fatalf("%s %s = %d, want %d", tc.method, tc.path, rec.Code, tc.wantCode)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Tomlord1122/todo-backend/internal/authz"
+	"github.com/Tomlord1122/todo-backend/internal/logging"
+	"github.com/Tomlord1122/todo-backend/internal/service"
+)
+
+// respondWithBulkResults writes the per-item results of a bulk request.
+// The request as a whole succeeded, so the response is a 200 even when
+// items failed; each item carries the status it would have had on its own,
+// with ok for the items that succeeded.
+func respondWithBulkResults(w http.ResponseWriter, r *http.Request, results []service.BulkResult, ok int) {
+	for i := range results {
+		result := &results[i]
+		switch err := result.Err; {
+		case err == nil:
+			result.Status = ok
+			continue
+		case serviceErrorStatus(err) != 0:
+			result.Status = serviceErrorStatus(err)
+		default:
+			logging.FromContext(r.Context()).Error("Error in bulk item", "index", result.Index, "err", err)
+			result.Status = http.StatusInternalServerError
+			result.Error = "Failed to process item"
+			continue
+		}
+		result.Error = result.Err.Error()
+	}
+	respondWithJSON(w, http.StatusOK, results)
+}
+
+// respondWithBulkError maps whole-request errors of the bulk todo
+// operations to HTTP responses.
+func respondWithBulkError(w http.ResponseWriter, r *http.Request, err error, operation, fallback string) {
+	// A todo changing while the request runs is a conflict of the request
+	// as a whole, not a failed precondition of the client
+	if errors.Is(err, service.ErrTodoChanged) {
+		respondWithError(w, r, http.StatusConflict, err.Error())
+		return
+	}
+	respondWithServiceError(w, r, err, operation, fallback)
+}
+
+// bulkOwner is the user whose todos a bulk request may change: the
+// signed-in user, or anyone's (0) for admins.
+func bulkOwner(r *http.Request) uint {
+	if authz.Check(r.Context(), authz.AccessAllTodos) == nil {
+		return 0
+	}
+	return sessionUserFrom(r)
+}
+
+// bulkCreateTodosHandler serves POST /todos/bulk. All todos are created
+// for the signed-in user.
+func (s *Server) bulkCreateTodosHandler(w http.ResponseWriter, r *http.Request) {
+	var reqs []service.CreateTodoRequest
+	if !decodeJSONBody(w, r, &reqs) {
+		return
+	}
+	for i := range reqs {
+		reqs[i].UserID = sessionUserFrom(r)
+	}
+
+	results, err := s.todoService.BulkCreateTodos(r.Context(), reqs)
+	if err != nil {
+		respondWithBulkError(w, r, err, "BulkCreateTodos", "Failed to create todos")
+		return
+	}
+
+	respondWithBulkResults(w, r, results, http.StatusCreated)
+}
+
+func (s *Server) bulkUpdateTodosHandler(w http.ResponseWriter, r *http.Request) {
+	var items []service.BulkUpdateItem
+	if !decodeJSONBody(w, r, &items) {
+		return
+	}
+
+	results, err := s.todoService.BulkUpdateTodos(r.Context(), bulkOwner(r), items)
+	if err != nil {
+		respondWithBulkError(w, r, err, "BulkUpdateTodos", "Failed to update todos")
+		return
+	}
+
+	respondWithBulkResults(w, r, results, http.StatusOK)
+}
+
+func (s *Server) bulkDeleteTodosHandler(w http.ResponseWriter, r *http.Request) {
+	var ids []uint
+	if !decodeJSONBody(w, r, &ids) {
+		return
+	}
+
+	results, err := s.todoService.BulkDeleteTodos(r.Context(), bulkOwner(r), ids)
+	if err != nil {
+		respondWithBulkError(w, r, err, "BulkDeleteTodos", "Failed to delete todos")
+		return
+	}
+
+	respondWithBulkResults(w, r, results, http.StatusNoContent)
+}
@@ -0,0 +1,55 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/Tomlord1122/todo-backend/internal/service"
+)
+
+func (s *Server) connectCalendarHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := parseIDParam(w, r, "id", "user")
+	if !ok {
+		return
+	}
+	var req service.ConnectCalendarRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+
+	conn, err := s.calendarService.Connect(r.Context(), userID, req)
+	if err != nil {
+		respondWithServiceError(w, r, err, "ConnectCalendar", "Failed to connect calendar")
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, conn)
+}
+
+func (s *Server) getCalendarHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := parseIDParam(w, r, "id", "user")
+	if !ok {
+		return
+	}
+
+	conn, err := s.calendarService.GetConnection(r.Context(), userID)
+	if err != nil {
+		respondWithServiceError(w, r, err, "GetCalendarConnection", "Failed to retrieve calendar connection")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, conn)
+}
+
+func (s *Server) disconnectCalendarHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := parseIDParam(w, r, "id", "user")
+	if !ok {
+		return
+	}
+
+	if err := s.calendarService.Disconnect(r.Context(), userID); err != nil {
+		respondWithServiceError(w, r, err, "DisconnectCalendar", "Failed to disconnect calendar")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
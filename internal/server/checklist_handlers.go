@@ -0,0 +1,84 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/Tomlord1122/todo-backend/internal/service"
+)
+
+func (s *Server) listChecklistHandler(w http.ResponseWriter, r *http.Request) {
+	todoID, ok := parseIDParam(w, r, "id", "todo")
+	if !ok {
+		return
+	}
+
+	items, err := s.checklistService.List(r.Context(), todoID)
+	if err != nil {
+		respondWithServiceError(w, r, err, "List", "Failed to retrieve checklist")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, items)
+}
+
+func (s *Server) addChecklistItemHandler(w http.ResponseWriter, r *http.Request) {
+	todoID, ok := parseIDParam(w, r, "id", "todo")
+	if !ok {
+		return
+	}
+
+	var req service.CreateChecklistItemRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+
+	item, err := s.checklistService.AddItem(r.Context(), todoID, req)
+	if err != nil {
+		respondWithServiceError(w, r, err, "AddItem", "Failed to add checklist item")
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, item)
+}
+
+func (s *Server) updateChecklistItemHandler(w http.ResponseWriter, r *http.Request) {
+	todoID, ok := parseIDParam(w, r, "id", "todo")
+	if !ok {
+		return
+	}
+	itemID, ok := parseIDParam(w, r, "itemID", "checklist item")
+	if !ok {
+		return
+	}
+
+	var req service.UpdateChecklistItemRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+
+	item, err := s.checklistService.UpdateItem(r.Context(), todoID, itemID, req)
+	if err != nil {
+		respondWithServiceError(w, r, err, "UpdateItem", "Failed to update checklist item")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, item)
+}
+
+func (s *Server) deleteChecklistItemHandler(w http.ResponseWriter, r *http.Request) {
+	todoID, ok := parseIDParam(w, r, "id", "todo")
+	if !ok {
+		return
+	}
+	itemID, ok := parseIDParam(w, r, "itemID", "checklist item")
+	if !ok {
+		return
+	}
+
+	if err := s.checklistService.DeleteItem(r.Context(), todoID, itemID); err != nil {
+		respondWithServiceError(w, r, err, "DeleteItem", "Failed to delete checklist item")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,22 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/Tomlord1122/todo-backend/internal/service"
+)
+
+func (s *Server) loadFixturesHandler(w http.ResponseWriter, r *http.Request) {
+	var req service.LoadFixturesRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+
+	loaded, err := s.fixtureService.LoadFixtures(r.Context(), req)
+	if err != nil {
+		respondWithServiceError(w, r, err, "LoadFixtures", "Failed to load fixtures")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, loaded)
+}
@@ -0,0 +1,159 @@
+package server
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Tomlord1122/todo-backend/internal/problem"
+	"github.com/Tomlord1122/todo-backend/internal/requestid"
+	"github.com/Tomlord1122/todo-backend/internal/service"
+)
+
+// envelopeProfile is the Accept profile that opts a request into enveloped
+// responses, e.g. Accept: application/json; profile="envelope".
+const envelopeProfile = "envelope"
+
+// envelope is the enveloped response format. Exactly one of Data and Errors
+// is set.
+type envelope struct {
+	Data   json.RawMessage `json:"data"`
+	Meta   envelopeMeta    `json:"meta"`
+	Errors []envelopeError `json:"errors,omitempty"`
+}
+
+type envelopeMeta struct {
+	RequestID  string            `json:"request_id,omitempty"`
+	Pagination *service.PageInfo `json:"pagination,omitempty"`
+}
+
+type envelopeMetaKey struct{}
+
+// envelopeMetaFrom returns the meta of the envelope being built for r, or
+// nil when the response isn't enveloped. Handlers fill in what they know.
+func envelopeMetaFrom(r *http.Request) *envelopeMeta {
+	meta, _ := r.Context().Value(envelopeMetaKey{}).(*envelopeMeta)
+	return meta
+}
+
+type envelopeError struct {
+	Message string `json:"message"`
+	// Field is the request field the error is about, if any
+	Field string `json:"field,omitempty"`
+}
+
+// envelopeResponses wraps JSON responses in an envelope when enabled for
+// every request (RESPONSE_ENVELOPE) or requested through the Accept profile.
+// Other content types (feeds, reports, metrics, the UI), GraphQL
+// responses, which have data and errors of their own, and WebSockets pass
+// through.
+func (s *Server) envelopeResponses(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == graphqlPath || r.URL.Path == websocketPath {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if !s.envelope {
+			// The format depends on Accept, so caches must keep both
+			w.Header().Add("Vary", "Accept")
+			if !acceptsEnvelope(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+		}
+		ew := &envelopeWriter{ResponseWriter: w, meta: &envelopeMeta{RequestID: requestid.FromContext(r.Context())}}
+		next.ServeHTTP(ew, r.WithContext(context.WithValue(r.Context(), envelopeMetaKey{}, ew.meta)))
+		ew.finish()
+	})
+}
+
+// acceptsEnvelope reports whether any Accept entry carries the envelope profile.
+func acceptsEnvelope(r *http.Request) bool {
+	for _, accept := range r.Header.Values("Accept") {
+		for _, part := range strings.Split(accept, ",") {
+			_, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+			if err == nil && params["profile"] == envelopeProfile {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// envelopeWriter buffers JSON bodies so they can be wrapped once the
+// handler is done; anything else is written straight through.
+type envelopeWriter struct {
+	http.ResponseWriter
+	meta        *envelopeMeta
+	code        int
+	wroteHeader bool
+	buffering   bool
+	buf         bytes.Buffer
+}
+
+func (ew *envelopeWriter) WriteHeader(code int) {
+	if ew.wroteHeader {
+		return
+	}
+	ew.wroteHeader = true
+	ew.code = code
+	mediaType, _, _ := mime.ParseMediaType(ew.Header().Get("Content-Type"))
+	ew.buffering = (mediaType == "application/json" || mediaType == problem.ContentType) && code != http.StatusNoContent && code != http.StatusNotModified
+	if !ew.buffering {
+		ew.ResponseWriter.WriteHeader(code)
+	}
+}
+
+func (ew *envelopeWriter) Write(b []byte) (int, error) {
+	if !ew.wroteHeader {
+		ew.WriteHeader(http.StatusOK)
+	}
+	if !ew.buffering {
+		return ew.ResponseWriter.Write(b)
+	}
+	return ew.buf.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (ew *envelopeWriter) Unwrap() http.ResponseWriter {
+	return ew.ResponseWriter
+}
+
+// finish writes the buffered body inside an envelope. Problem responses
+// become an errors entry with null data, or one entry per invalid field.
+func (ew *envelopeWriter) finish() {
+	if !ew.buffering {
+		return
+	}
+
+	env := envelope{Data: json.RawMessage("null"), Meta: *ew.meta}
+	var details problem.Details
+	mediaType, _, _ := mime.ParseMediaType(ew.Header().Get("Content-Type"))
+	if mediaType == problem.ContentType && json.Unmarshal(ew.buf.Bytes(), &details) == nil {
+		if len(details.InvalidParams) > 0 {
+			for _, param := range details.InvalidParams {
+				env.Errors = append(env.Errors, envelopeError{Message: param.Reason, Field: param.Name})
+			}
+		} else {
+			env.Errors = []envelopeError{{Message: cmp.Or(details.Detail, details.Title)}}
+		}
+		// The envelope itself is plain JSON
+		ew.Header().Set("Content-Type", "application/json; charset=utf-8")
+	} else {
+		env.Data = ew.buf.Bytes()
+	}
+
+	body, err := json.Marshal(env)
+	if err != nil {
+		// The handler wrote invalid JSON; send it unwrapped rather than lose it
+		body = ew.buf.Bytes()
+	}
+	ew.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	ew.ResponseWriter.WriteHeader(ew.code)
+	_, _ = ew.ResponseWriter.Write(body)
+}
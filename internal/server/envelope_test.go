@@ -0,0 +1,54 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/Tomlord1122/todo-backend/internal/requestid"
+)
+
+func TestEnvelopeResponses(t *testing.T) {
+	s := &Server{}
+	r := chi.NewRouter()
+	r.Use(requestid.Middleware)
+	r.Use(s.envelopeResponses)
+	r.Get("/ok", func(w http.ResponseWriter, r *http.Request) {
+		respondWithJSON(w, http.StatusOK, map[string]int{"id": 1})
+	})
+	r.Get("/fail", func(w http.ResponseWriter, r *http.Request) {
+		respondWithError(w, r, http.StatusNotFound, "todo not found")
+	})
+	r.Get("/text", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte("plain"))
+	})
+
+	cases := []struct {
+		path, accept string
+		envelope     bool
+		wantCode     int
+		wantBody     string
+	}{
+		{"/ok", "application/json", false, 200, `{"id":1}`},
+		{"/ok", `application/json; profile="envelope"`, false, 200, `{"data":{"id":1},"meta":{"request_id":"req-1"}}`},
+		{"/ok", "", true, 200, `{"data":{"id":1},"meta":{"request_id":"req-1"}}`},
+		{"/fail", "", false, 404, `{"type":"about:blank","title":"Not Found","status":404,"detail":"todo not found","instance":"/fail","request_id":"req-1"}`},
+		{"/fail", "", true, 404, `{"data":null,"meta":{"request_id":"req-1"},"errors":[{"message":"todo not found"}]}`},
+		{"/text", "", true, 200, "plain"},
+	}
+	for _, tc := range cases {
+		s.envelope = tc.envelope
+		req := httptest.NewRequest("GET", tc.path, nil)
+		req.Header.Set("Accept", tc.accept)
+		req.Header.Set(requestid.Header, "req-1")
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		if rec.Code != tc.wantCode || rec.Body.String() != tc.wantBody {
+			t.Errorf("GET %s (Accept %q, envelope %t) = %d %s, want %d %s",
+				tc.path, tc.accept, tc.envelope, rec.Code, rec.Body, tc.wantCode, tc.wantBody)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/Tomlord1122/todo-backend/internal/apperror"
+	"github.com/Tomlord1122/todo-backend/internal/logging"
+	"github.com/Tomlord1122/todo-backend/internal/problem"
+)
+
+// codeStatus maps error codes to HTTP statuses.
+var codeStatus = map[apperror.Code]int{
+	apperror.CodeInvalid:            http.StatusBadRequest,
+	apperror.CodeUnauthenticated:    http.StatusUnauthorized,
+	apperror.CodeForbidden:          http.StatusForbidden,
+	apperror.CodeNotFound:           http.StatusNotFound,
+	apperror.CodeConflict:           http.StatusConflict,
+	apperror.CodePreconditionFailed: http.StatusPreconditionFailed,
+	apperror.CodeUnprocessable:      http.StatusUnprocessableEntity,
+	apperror.CodePending:            http.StatusAccepted,
+	apperror.CodeUnavailable:        http.StatusServiceUnavailable,
+}
+
+// serviceErrorStatus returns the HTTP status of a service error that the
+// client can act on, or 0 for internal errors.
+func serviceErrorStatus(err error) int {
+	return codeStatus[apperror.CodeOf(err)]
+}
+
+// respondWithServiceError maps an error returned by a service to a problem
+// response. Errors the client can act on are sent with their message and
+// code; other errors are logged, naming the failed service operation, and
+// answered with a 500 and fallback as the detail.
+func respondWithServiceError(w http.ResponseWriter, r *http.Request, err error, operation, fallback string) {
+	status := serviceErrorStatus(err)
+	if status == 0 {
+		logging.FromContext(r.Context()).Error("Error calling service", "operation", operation, "err", err)
+		respondWithError(w, r, http.StatusInternalServerError, fallback)
+		return
+	}
+	if status == http.StatusUnauthorized {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="todo"`)
+	}
+	d := problem.New(r, status, err.Error())
+	d.Code = string(apperror.CodeOf(err))
+	problem.Write(w, d)
+}
@@ -0,0 +1,124 @@
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// todoETag is the entity tag of a version of a todo.
+func todoETag(version uint) string {
+	return strconv.Quote(strconv.FormatUint(uint64(version), 10))
+}
+
+// renderedTodoETag is the entity tag of a version of a todo rendered as
+// render, e.g. html. It differs from todoETag, so the rendered and the
+// plain representation are never taken for one another, and is weak, as
+// the rendering of the same version may change with the renderer; If-Match
+// never accepts it.
+func renderedTodoETag(version uint, render string) string {
+	return `W/"` + strconv.FormatUint(uint64(version), 10) + "-" + render + `"`
+}
+
+// parseIfMatch reads the If-Match header that writes to a todo require: the
+// ETag of the version the client read, or * for whatever version is
+// current (0). Without the header it responds with a 428, so clients can't
+// overwrite changes they haven't seen by accident. A header that isn't a
+// single ETag of ours can never match and gets a 412.
+func parseIfMatch(w http.ResponseWriter, r *http.Request) (uint, bool) {
+	value := strings.TrimSpace(r.Header.Get("If-Match"))
+	if value == "" {
+		respondWithError(w, r, http.StatusPreconditionRequired, "If-Match header is required, send the ETag of the todo or *")
+		return 0, false
+	}
+	if value == "*" {
+		return 0, true
+	}
+	tag, err := strconv.Unquote(value)
+	if err == nil && !strings.HasPrefix(value, `"`) {
+		err = strconv.ErrSyntax
+	}
+	version, parseErr := strconv.ParseUint(tag, 10, 64)
+	if err != nil || parseErr != nil || version == 0 {
+		respondWithError(w, r, http.StatusPreconditionFailed, "If-Match doesn't match the current ETag of the todo")
+		return 0, false
+	}
+	return uint(version), true
+}
+
+// Cache-Control policies. API responses are users' own data: browsers may
+// keep them but must check with us before using them again, which the
+// validators below make cheap, and shared caches like CDNs must not keep
+// them at all. Credentials and secrets aren't kept anywhere.
+const (
+	cachePrivate = "private, no-cache"
+	cacheNoStore = "no-store"
+)
+
+// cacheControl sets the Cache-Control policy of the responses of a route.
+// Handlers may override it.
+func cacheControl(policy string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Cache-Control", policy)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// respondWithValidatedJSON writes payload like respondWithJSON, with etag
+// and, unless it is zero, lastModified as validators. An empty etag is
+// derived from the body; it is weak, as enveloping changes the bytes sent
+// but not what they mean. When the request's If-None-Match, or without
+// one its If-Modified-Since, shows the client has the payload already,
+// the response is a 304 Not Modified without a body instead.
+func respondWithValidatedJSON(w http.ResponseWriter, r *http.Request, payload any, etag string, lastModified time.Time) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		respondWithJSON(w, http.StatusOK, payload)
+		return
+	}
+	if etag == "" {
+		sum := sha256.Sum256(body)
+		etag = `W/"` + hex.EncodeToString(sum[:16]) + `"`
+	}
+	w.Header().Set("ETag", etag)
+	if !lastModified.IsZero() {
+		w.Header().Set("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+	if notModified(r, etag, lastModified) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, json.RawMessage(body))
+}
+
+// notModified evaluates the request's If-None-Match, or If-Modified-Since
+// when it has none, against a representation with etag and lastModified.
+// ETags are compared weakly, as RFC 9110 asks for If-None-Match.
+func notModified(r *http.Request, etag string, lastModified time.Time) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+	if values := r.Header.Values("If-None-Match"); len(values) > 0 {
+		for _, value := range values {
+			for _, tag := range strings.Split(value, ",") {
+				tag = strings.TrimSpace(tag)
+				if tag == "*" || strings.TrimPrefix(tag, "W/") == strings.TrimPrefix(etag, "W/") {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	since, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	if err != nil || lastModified.IsZero() {
+		return false
+	}
+	// HTTP dates have whole seconds
+	return !lastModified.Truncate(time.Second).After(since)
+}
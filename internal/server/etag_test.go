@@ -0,0 +1,82 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRespondWithValidatedJSON(t *testing.T) {
+	modified := time.Date(2026, 3, 1, 12, 0, 0, 500, time.UTC)
+	tests := []struct {
+		name, header, value string
+		want                int
+	}{
+		{"unconditional", "", "", http.StatusOK},
+		{"matching ETag", "If-None-Match", `"other", "4"`, http.StatusNotModified},
+		{"weakly matching ETag", "If-None-Match", `W/"4"`, http.StatusNotModified},
+		{"any ETag", "If-None-Match", "*", http.StatusNotModified},
+		{"other ETag", "If-None-Match", `"3"`, http.StatusOK},
+		{"not modified since", "If-Modified-Since", "Sun, 01 Mar 2026 12:00:00 GMT", http.StatusNotModified},
+		{"modified since", "If-Modified-Since", "Sun, 01 Mar 2026 11:59:59 GMT", http.StatusOK},
+		{"bad date", "If-Modified-Since", "yesterday", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/todos/1", nil)
+			if tt.header != "" {
+				req.Header.Set(tt.header, tt.value)
+			}
+			rec := httptest.NewRecorder()
+			respondWithValidatedJSON(rec, req, map[string]string{"title": "Ship it"}, todoETag(4), modified)
+
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.want)
+			}
+			if rec.Header().Get("ETag") != `"4"` || rec.Header().Get("Last-Modified") != "Sun, 01 Mar 2026 12:00:00 GMT" {
+				t.Errorf("validators = %q and %q", rec.Header().Get("ETag"), rec.Header().Get("Last-Modified"))
+			}
+			if tt.want == http.StatusNotModified && rec.Body.Len() != 0 {
+				t.Errorf("304 with body %q", rec.Body)
+			}
+		})
+	}
+
+	// Without an ETag of its own, the body's digest is the ETag
+	rec := httptest.NewRecorder()
+	respondWithValidatedJSON(rec, httptest.NewRequest(http.MethodGet, "/todos", nil), []int{1, 2}, "", time.Time{})
+	etag := rec.Header().Get("ETag")
+	if len(etag) < 4 || etag[:3] != `W/"` || rec.Header().Get("Last-Modified") != "" {
+		t.Fatalf("ETag = %q, Last-Modified = %q; want a weak ETag only", etag, rec.Header().Get("Last-Modified"))
+	}
+	req := httptest.NewRequest(http.MethodGet, "/todos", nil)
+	req.Header.Set("If-None-Match", etag)
+	rec = httptest.NewRecorder()
+	respondWithValidatedJSON(rec, req, []int{1, 2}, "", time.Time{})
+	if rec.Code != http.StatusNotModified {
+		t.Errorf("revalidating an unchanged list = %d, want 304", rec.Code)
+	}
+}
+
+func TestRenderedTodoETag(t *testing.T) {
+	plain, rendered := todoETag(4), renderedTodoETag(4, "html")
+	if rendered != `W/"4-html"` {
+		t.Fatalf("renderedTodoETag = %q", rendered)
+	}
+	// The plain representation doesn't validate the rendered one
+	req := httptest.NewRequest(http.MethodGet, "/todos/1?render=html", nil)
+	req.Header.Set("If-None-Match", plain)
+	rec := httptest.NewRecorder()
+	respondWithValidatedJSON(rec, req, map[string]string{"description_html": "<p>Ship it</p>"}, rendered, time.Time{})
+	if rec.Code != http.StatusOK {
+		t.Errorf("rendered todo with the plain ETag = %d, want 200", rec.Code)
+	}
+	// Nor is it good for writes
+	req = httptest.NewRequest(http.MethodPut, "/todos/1", nil)
+	req.Header.Set("If-Match", rendered)
+	rec = httptest.NewRecorder()
+	if _, ok := parseIfMatch(rec, req); ok || rec.Code != http.StatusPreconditionFailed {
+		t.Errorf("If-Match with the rendered ETag = %d, want 412", rec.Code)
+	}
+}
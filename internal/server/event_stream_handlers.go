@@ -0,0 +1,81 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Tomlord1122/todo-backend/internal/realtime"
+)
+
+// eventStreamHeartbeat is how often an idle event stream gets a comment, so
+// proxies don't close it for inactivity.
+const eventStreamHeartbeat = 15 * time.Second
+
+// todoEventsHandler streams the signed-in user's todo changes as
+// Server-Sent Events named after the realtime event types, e.g.
+// todo.created, with the todo (or its ID for deletions) as data. Clients
+// reconnecting with Last-Event-ID first get the changes they missed, as
+// far as the server still has them.
+func (s *Server) todoEventsHandler(w http.ResponseWriter, r *http.Request) {
+	userID := sessionUserFrom(r)
+	var sub *realtime.Subscription
+	var missed []realtime.Event
+	if lastID, err := strconv.ParseUint(r.Header.Get("Last-Event-ID"), 10, 64); err == nil {
+		sub, missed = s.events.SubscribeSince(userID, lastID)
+	} else {
+		sub = s.events.Subscribe(userID)
+	}
+	defer sub.Close()
+
+	rc := http.NewResponseController(w)
+	// The stream outlives the server's write timeout
+	_ = rc.SetWriteDeadline(time.Time{})
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	// Keep proxies like nginx from buffering the events
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	for _, event := range missed {
+		writeTodoEvent(w, event)
+	}
+	if rc.Flush() != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(eventStreamHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case event, ok := <-sub.Events():
+			if !ok {
+				return
+			}
+			if !writeTodoEvent(w, event) {
+				continue
+			}
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": heartbeat\n\n")
+		case <-r.Context().Done():
+			return
+		case <-s.streams.Done():
+			return
+		}
+		if rc.Flush() != nil {
+			return
+		}
+	}
+}
+
+// writeTodoEvent writes event if it is about a todo and reports whether it
+// did. Presence events are only sent over the WebSocket.
+func writeTodoEvent(w http.ResponseWriter, event realtime.Event) bool {
+	if !strings.HasPrefix(event.Type, "todo.") {
+		return false
+	}
+	// Data is compact JSON, so it fits on one data line
+	fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, event.Data)
+	return true
+}
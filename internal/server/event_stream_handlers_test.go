@@ -0,0 +1,92 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/Tomlord1122/todo-backend/internal/realtime"
+	"github.com/Tomlord1122/todo-backend/internal/service"
+)
+
+type sseEvent struct {
+	id, event, data string
+}
+
+// openEventStream connects to the todo event stream, resuming after
+// lastEventID when set.
+func (s *streamServer) openEventStream(token, lastEventID string) (*http.Response, *bufio.Reader) {
+	s.t.Helper()
+	req, _ := http.NewRequest("GET", s.URL+"/api/v1/todos/events?access_token="+token, nil)
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	s.t.Cleanup(func() { resp.Body.Close() })
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+		s.t.Fatalf("opening the stream = %s %s", resp.Status, resp.Header.Get("Content-Type"))
+	}
+	return resp, bufio.NewReader(resp.Body)
+}
+
+// readEvent reads the next event, skipping comments.
+func readEvent(t *testing.T, r *bufio.Reader) sseEvent {
+	t.Helper()
+	var event sseEvent
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("reading event: %v", err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+		if line == "" && event.event != "" {
+			return event
+		}
+		field, value, _ := strings.Cut(line, ": ")
+		switch field {
+		case "id":
+			event.id = value
+		case "event":
+			event.event = value
+		case "data":
+			event.data = value
+		}
+	}
+}
+
+func TestTodoEventStream(t *testing.T) {
+	s := newStreamServer(t)
+	alice, bob := s.register("alice@example.com"), s.register("bob@example.com")
+
+	resp, stream := s.openEventStream(alice, "")
+	s.createTodo(bob, "Bob's todo")
+	first := s.createTodo(alice, "Buy milk")
+	event := readEvent(t, stream)
+	var todo service.TodoResponse
+	if event.event != realtime.TodoCreated || json.Unmarshal([]byte(event.data), &todo) != nil || todo.ID != first.ID {
+		t.Errorf("event = %+v, want %s of todo %d", event, realtime.TodoCreated, first.ID)
+	}
+	resp.Body.Close()
+
+	// Reconnecting replays what was missed in between
+	missed := s.createTodo(alice, "Call mum")
+	_, stream = s.openEventStream(alice, event.id)
+	if event := readEvent(t, stream); json.Unmarshal([]byte(event.data), &todo) != nil || todo.ID != missed.ID {
+		t.Errorf("replayed event = %+v, want todo %d", event, missed.ID)
+	}
+
+	// Shutting down ends the stream rather than waiting for it
+	if err := s.Config.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.ReadAll(stream); err != nil {
+		t.Errorf("reading after shutdown = %v, want the end of the stream", err)
+	}
+}
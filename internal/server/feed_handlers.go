@@ -0,0 +1,88 @@
+package server
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/Tomlord1122/todo-backend/internal/feed"
+	"github.com/Tomlord1122/todo-backend/internal/logging"
+)
+
+// createFeedTokenHandler serves POST /feeds/tokens, issuing a token for the
+// signed-in user's feeds.
+func (s *Server) createFeedTokenHandler(w http.ResponseWriter, r *http.Request) {
+	tokenResp, err := s.feedService.CreateToken(r.Context(), sessionUserFrom(r))
+	if err != nil {
+		respondWithServiceError(w, r, err, "CreateToken", "Failed to create feed token")
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, tokenResp)
+}
+
+func (s *Server) revokeFeedTokenHandler(w http.ResponseWriter, r *http.Request) {
+	err := s.feedService.RevokeToken(r.Context(), sessionUserFrom(r), chi.URLParam(r, "token"))
+	if err != nil {
+		respondWithServiceError(w, r, err, "RevokeToken", "Failed to revoke feed token")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) todayRSSFeedHandler(w http.ResponseWriter, r *http.Request) {
+	s.serveTodayFeed(w, r, feed.RSSContentType, feed.WriteRSS)
+}
+
+func (s *Server) todayAtomFeedHandler(w http.ResponseWriter, r *http.Request) {
+	s.serveTodayFeed(w, r, feed.AtomContentType, feed.WriteAtom)
+}
+
+// serveTodayFeed resolves the feed for the token in the URL and writes it
+// using the given encoder. An optional ?tz= selects the user's time zone.
+func (s *Server) serveTodayFeed(w http.ResponseWriter, r *http.Request, contentType string, write func(io.Writer, feed.Feed) error) {
+	loc := time.Local
+	if tz := r.URL.Query().Get("tz"); tz != "" {
+		var err error
+		loc, err = time.LoadLocation(tz)
+		if err != nil {
+			respondWithError(w, r, http.StatusBadRequest, "Invalid tz parameter")
+			return
+		}
+	}
+
+	f, err := s.feedService.TodayFeed(r.Context(), chi.URLParam(r, "token"), loc, requestBaseURL(r))
+	if err != nil {
+		respondWithServiceError(w, r, err, "TodayFeed", "Failed to build feed")
+		return
+	}
+
+	// Render into a buffer first so encoding errors can still produce a 500
+	var buf bytes.Buffer
+	if err := write(&buf, *f); err != nil {
+		logging.FromContext(r.Context()).Error("Error encoding feed", "err", err)
+		respondWithError(w, r, http.StatusInternalServerError, "Failed to build feed")
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	// Feed URLs carry a secret token, keep them out of shared caches
+	w.Header().Set("Cache-Control", "private, max-age=300")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(buf.Bytes())
+}
+
+// requestBaseURL reconstructs the scheme and host the client used to reach us.
+func requestBaseURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	} else if proto := r.Header.Get("X-Forwarded-Proto"); proto == "https" || proto == "http" {
+		scheme = proto
+	}
+	return scheme + "://" + r.Host
+}
@@ -0,0 +1,77 @@
+package server
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/Tomlord1122/todo-backend/internal/service"
+)
+
+func (s *Server) startFocusHandler(w http.ResponseWriter, r *http.Request) {
+	var req service.StartFocusRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+	req.UserID = sessionUserFrom(r)
+
+	session, err := s.focusService.Start(r.Context(), req, time.Now())
+	if err != nil {
+		respondWithServiceError(w, r, err, "Start", "Failed to start focus session")
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, session)
+}
+
+func (s *Server) stopFocusHandler(w http.ResponseWriter, r *http.Request) {
+	var req service.StopFocusRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+	req.UserID = sessionUserFrom(r)
+
+	session, err := s.focusService.Stop(r.Context(), req, time.Now())
+	if err != nil {
+		respondWithServiceError(w, r, err, "Stop", "Failed to stop focus session")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, session)
+}
+
+func (s *Server) currentFocusHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := parseOwnUserIDQuery(w, r)
+	if !ok {
+		return
+	}
+
+	session, err := s.focusService.Current(r.Context(), userID, time.Now())
+	if err != nil {
+		respondWithServiceError(w, r, err, "Current", "Failed to retrieve focus session")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, session)
+}
+
+// statsHandler serves GET /stats?user_id=&from=&to=&tz=.
+func (s *Server) statsHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := parseUserIDQuery(w, r)
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	stats, err := s.statsService.GetStats(r.Context(), service.StatsRequest{
+		UserID:   userID,
+		From:     query.Get("from"),
+		To:       query.Get("to"),
+		Timezone: query.Get("tz"),
+	}, time.Now())
+	if err != nil {
+		respondWithServiceError(w, r, err, "GetStats", "Failed to compute stats")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, stats)
+}
@@ -0,0 +1,50 @@
+package server
+
+import (
+	"net/http"
+)
+
+// followTodoHandler responds 201 when the signed-in user starts following
+// the todo and 200 when they already did.
+func (s *Server) followTodoHandler(w http.ResponseWriter, r *http.Request) {
+	todoID, ok := parseIDParam(w, r, "id", "todo")
+	if !ok {
+		return
+	}
+
+	following, created, err := s.followService.Follow(r.Context(), sessionUserFrom(r), todoID)
+	if err != nil {
+		respondWithServiceError(w, r, err, "Follow", "Failed to follow todo")
+		return
+	}
+
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	respondWithJSON(w, status, following)
+}
+
+func (s *Server) unfollowTodoHandler(w http.ResponseWriter, r *http.Request) {
+	todoID, ok := parseIDParam(w, r, "id", "todo")
+	if !ok {
+		return
+	}
+
+	if err := s.followService.Unfollow(r.Context(), sessionUserFrom(r), todoID); err != nil {
+		respondWithServiceError(w, r, err, "Unfollow", "Failed to unfollow todo")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) listFollowingHandler(w http.ResponseWriter, r *http.Request) {
+	following, err := s.followService.ListFollowing(r.Context(), sessionUserFrom(r))
+	if err != nil {
+		respondWithServiceError(w, r, err, "ListFollowing", "Failed to retrieve followed todos")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, following)
+}
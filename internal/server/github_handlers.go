@@ -0,0 +1,78 @@
+package server
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/Tomlord1122/todo-backend/internal/service"
+)
+
+// maxGitHubWebhookPayload caps webhook bodies; GitHub sends at most 25 MB
+// but issue events are far smaller.
+const maxGitHubWebhookPayload = 5 << 20
+
+func (s *Server) linkGitHubHandler(w http.ResponseWriter, r *http.Request) {
+	listID, ok := parseIDParam(w, r, "id", "list")
+	if !ok {
+		return
+	}
+	var req service.LinkGitHubRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+
+	link, err := s.gitHubService.Link(r.Context(), listID, req)
+	if err != nil {
+		respondWithServiceError(w, r, err, "LinkGitHub", "Failed to link list")
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, link)
+}
+
+func (s *Server) getGitHubLinkHandler(w http.ResponseWriter, r *http.Request) {
+	listID, ok := parseIDParam(w, r, "id", "list")
+	if !ok {
+		return
+	}
+
+	link, err := s.gitHubService.GetLink(r.Context(), listID)
+	if err != nil {
+		respondWithServiceError(w, r, err, "GetGitHubLink", "Failed to retrieve GitHub link")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, link)
+}
+
+func (s *Server) unlinkGitHubHandler(w http.ResponseWriter, r *http.Request) {
+	listID, ok := parseIDParam(w, r, "id", "list")
+	if !ok {
+		return
+	}
+
+	if err := s.gitHubService.Unlink(r.Context(), listID); err != nil {
+		respondWithServiceError(w, r, err, "UnlinkGitHub", "Failed to unlink list")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// gitHubWebhookHandler serves POST /integrations/github/webhook. The raw
+// body is needed to check the signature, so it isn't decoded here.
+func (s *Server) gitHubWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxGitHubWebhookPayload))
+	if err != nil {
+		respondWithError(w, r, http.StatusRequestEntityTooLarge, "Request body is too large")
+		return
+	}
+
+	err = s.gitHubService.HandleWebhook(r.Context(), r.Header.Get("X-GitHub-Event"), body, r.Header.Get("X-Hub-Signature-256"))
+	if err != nil {
+		respondWithServiceError(w, r, err, "HandleGitHubWebhook", "Failed to process webhook")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,470 @@
+package server
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Tomlord1122/todo-backend/internal/apispec"
+	"github.com/Tomlord1122/todo-backend/internal/jwt"
+	"github.com/Tomlord1122/todo-backend/internal/notify"
+	"github.com/Tomlord1122/todo-backend/internal/pagination"
+	"github.com/Tomlord1122/todo-backend/internal/realtime"
+	"github.com/Tomlord1122/todo-backend/internal/repository"
+	"github.com/Tomlord1122/todo-backend/internal/requestid"
+	"github.com/Tomlord1122/todo-backend/internal/service"
+	"github.com/Tomlord1122/todo-backend/internal/suggest"
+	"github.com/Tomlord1122/todo-backend/internal/thumbnail"
+	"github.com/Tomlord1122/todo-backend/internal/webhook"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite the golden files in testdata/golden")
+
+// goldenHeaders are the response headers that are part of the contract.
+var goldenHeaders = []string{"Accept-Patch", "API-Version", "Allow", "Cache-Control", "Content-Type", "Deprecation", "ETag", "Idempotent-Replayed", "Last-Modified", "Link", "Location", "Retry-After", "X-Total-Count"}
+
+var (
+	timestampPattern = regexp.MustCompile(`\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:\d{2})`)
+	datePattern      = regexp.MustCompile(`"\d{4}-\d{2}-\d{2}"`)
+	httpDatePattern  = regexp.MustCompile(`[A-Z][a-z]{2}, \d{2} [A-Z][a-z]{2} \d{4} \d{2}:\d{2}:\d{2} GMT`)
+	// Weak ETags are digests of bodies, which have timestamps
+	weakETagPattern = regexp.MustCompile(`W/\\"[0-9a-f]+\\"`)
+)
+
+// goldenCase is one request of the contract suite. Cases run in order
+// against one server, so later cases see what earlier ones created.
+type goldenCase struct {
+	// name is the golden file name; endpoint the apispec operation covered
+	name, endpoint string
+	method, path   string
+	body           string
+	// contentType is sent as the Content-Type when set
+	contentType string
+	// ifMatch is sent as the If-Match header when set
+	ifMatch string
+	// idempotencyKey is sent as the Idempotency-Key header when set
+	idempotencyKey string
+	// capture saves top-level response fields for later cases: $var in a
+	// path or body is replaced by the value, and the value is masked as
+	// <var> in golden files since it is random (tokens)
+	capture map[string]string
+	// auth is sent as the bearer token, after substitution; a value with
+	// a space names its own scheme, as in "ApiKey $key"
+	auth string
+	// unversioned sends path as is; other paths are under apispec.BasePath
+	unversioned bool
+}
+
+var goldenCases = []goldenCase{
+	{name: "register", endpoint: "register", method: "POST", path: "/auth/register", body: `{"email":"Ada@example.com","password":"correct horse","name":"Ada"}`, capture: map[string]string{"access_token": "access_token"}},
+	{name: "register_taken", endpoint: "register", method: "POST", path: "/auth/register", body: `{"email":"ada@example.com","password":"battery staple"}`},
+	{name: "login", endpoint: "login", method: "POST", path: "/auth/login", body: `{"email":"ada@example.com","password":"correct horse"}`, capture: map[string]string{"login_token": "access_token"}},
+	{name: "register_second", endpoint: "register", method: "POST", path: "/auth/register", body: `{"email":"bob@example.com","password":"hunter2hunter2"}`, capture: map[string]string{"bob_token": "access_token"}},
+	{name: "login_wrongPassword", endpoint: "login", method: "POST", path: "/auth/login", body: `{"email":"ada@example.com","password":"wrong horse"}`},
+	{name: "listUsers", endpoint: "listUsers", method: "GET", path: "/users", auth: "$access_token"},
+	{name: "listUsers_forbidden", endpoint: "listUsers", method: "GET", path: "/users", auth: "$bob_token"},
+	{name: "updateUserRole", endpoint: "updateUserRole", method: "PUT", path: "/users/2/role", body: `{"role":"viewer"}`, auth: "$access_token"},
+	{name: "createTodo_viewer", endpoint: "createTodo", method: "POST", path: "/todos", body: `{"title":"Read only"}`, auth: "$bob_token"},
+	{name: "updateUserRole_badRole", endpoint: "updateUserRole", method: "PUT", path: "/users/2/role", body: `{"role":"owner"}`, auth: "$access_token"},
+	{name: "updateUserRole_lastAdmin", endpoint: "updateUserRole", method: "PUT", path: "/users/1/role", body: `{"role":"member"}`, auth: "$access_token"},
+	{name: "updateUserRole_member", endpoint: "updateUserRole", method: "PUT", path: "/users/2/role", body: `{"role":"member"}`, auth: "$access_token"},
+	{name: "createAPIKey", endpoint: "createAPIKey", method: "POST", path: "/apikeys", body: `{"name":"CI"}`, auth: "$access_token", capture: map[string]string{"api_key": "key", "api_key_prefix": "prefix"}},
+	{name: "createAPIKey_missingName", endpoint: "createAPIKey", method: "POST", path: "/apikeys", body: `{"name":" "}`, auth: "$access_token"},
+	{name: "createAPIKey_unauthenticated", endpoint: "createAPIKey", method: "POST", path: "/apikeys", body: `{"name":"CI"}`},
+	{name: "listAPIKeys", endpoint: "listAPIKeys", method: "GET", path: "/apikeys", auth: "$access_token"},
+	{name: "createList", endpoint: "createList", method: "POST", path: "/lists", body: `{"name":"Groceries"}`, auth: "$access_token"},
+	{name: "createList_unauthenticated", endpoint: "createList", method: "POST", path: "/lists", body: `{"name":"Groceries"}`},
+	{name: "createList_missingName", endpoint: "createList", method: "POST", path: "/lists", body: `{}`, auth: "$access_token"},
+	{name: "listLists", endpoint: "listLists", method: "GET", path: "/lists", auth: "$access_token"},
+	{name: "getList", endpoint: "getList", method: "GET", path: "/lists/1", auth: "$access_token"},
+	{name: "getList_otherUser", endpoint: "getList", method: "GET", path: "/lists/1", auth: "$bob_token"},
+	{name: "getList_notFound", endpoint: "getList", method: "GET", path: "/lists/99", auth: "$access_token"},
+	{name: "updateList", endpoint: "updateList", method: "PUT", path: "/lists/1", body: `{"name":"Shopping"}`, auth: "$access_token"},
+
+	{name: "createTodo", endpoint: "createTodo", method: "POST", path: "/todos", body: `{"title":"Buy milk","description":"2 litres","user_id":1,"list_id":1,"priority":"high","estimate":2}`, auth: "$access_token"},
+	{name: "createTodo_second", endpoint: "createTodo", method: "POST", path: "/todos", body: `{"title":"Call the plumber","user_id":1,"depends_on":[1],"location":{"latitude":52.52,"longitude":13.405,"radius_meters":500}}`, auth: "$access_token"},
+	{name: "createTodo_unknownField", endpoint: "createTodo", method: "POST", path: "/todos", body: `{"title":"x","colour":"red"}`, auth: "$access_token"},
+	{name: "createTodo_invalidFields", endpoint: "createTodo", method: "POST", path: "/todos", body: `{"title":" ","priority":"urgent"}`, auth: "$access_token"},
+	{name: "createTodo_startAfterDue", endpoint: "createTodo", method: "POST", path: "/todos", body: `{"title":"Pack","start_date":"2026-01-09T00:00:00Z","due_date":"2026-01-08T00:00:00Z"}`, auth: "$access_token"},
+	{name: "suggestTodo", endpoint: "suggestTodo", method: "POST", path: "/todos/suggest", body: `{"title":"urgent: pay rent","timezone":"UTC"}`, auth: "$access_token"},
+	{name: "listTodos", endpoint: "listTodos", method: "GET", path: "/todos?limit=1", auth: "$access_token"},
+	{name: "listTodos_cursor", endpoint: "listTodos", method: "GET", path: "/todos?limit=1&cursor=eyJpZCI6MX0", auth: "$access_token"},
+	{name: "listTodos_badCursor", endpoint: "listTodos", method: "GET", path: "/todos?cursor=nope", auth: "$access_token"},
+	{name: "listTodos_filtered", endpoint: "listTodos", method: "GET", path: "/todos?completed=false&user_id=1", auth: "$access_token"},
+	{name: "listTodos_badCompleted", endpoint: "listTodos", method: "GET", path: "/todos?completed=maybe", auth: "$access_token"},
+	{name: "listTodos_sorted", endpoint: "listTodos", method: "GET", path: "/todos?sort=-title,priority", auth: "$access_token"},
+	{name: "listTodos_badSort", endpoint: "listTodos", method: "GET", path: "/todos?sort=colour", auth: "$access_token"},
+	{name: "listTodos_unauthenticated", endpoint: "listTodos", method: "GET", path: "/todos"},
+	{name: "listTodos_otherUser", endpoint: "listTodos", method: "GET", path: "/todos?user_id=1", auth: "$bob_token"},
+	{name: "listTodos_unknownParam", endpoint: "listTodos", method: "GET", path: "/todos?page=2", auth: "$access_token"},
+	{name: "listTodos_apiKey", endpoint: "listTodos", method: "GET", path: "/todos?limit=1", auth: "ApiKey $api_key"},
+	{name: "listTodos_badAPIKey", endpoint: "listTodos", method: "GET", path: "/todos", auth: "ApiKey tdk_nope"},
+	{name: "revokeAPIKey_otherUser", endpoint: "revokeAPIKey", method: "DELETE", path: "/apikeys/1", auth: "$bob_token"},
+	{name: "revokeAPIKey", endpoint: "revokeAPIKey", method: "DELETE", path: "/apikeys/1", auth: "$access_token"},
+	{name: "listTodos_revokedAPIKey", endpoint: "listTodos", method: "GET", path: "/todos", auth: "ApiKey $api_key"},
+	{name: "searchTodos", endpoint: "searchTodos", method: "GET", path: "/todos/search?q=milk", auth: "$access_token"},
+	{name: "overdueTodos", endpoint: "overdueTodos", method: "GET", path: "/todos/overdue?user_id=1&tz=UTC", auth: "$access_token"},
+	{name: "nearbyTodos", endpoint: "nearbyTodos", method: "GET", path: "/todos/nearby?lat=52.52&lng=13.40", auth: "$access_token"},
+	{name: "getTodo", endpoint: "getTodo", method: "GET", path: "/todos/1", auth: "$access_token"},
+	{name: "getTodo_invalidID", endpoint: "getTodo", method: "GET", path: "/todos/abc", auth: "$access_token"},
+	{name: "getTodo_otherUser", endpoint: "getTodo", method: "GET", path: "/todos/1", auth: "$bob_token"},
+	{name: "updateTodo", endpoint: "updateTodo", method: "PUT", path: "/todos/1", body: `{"completed":true}`, ifMatch: "*", auth: "$access_token"},
+	{name: "updateTodo_dueDate", endpoint: "updateTodo", method: "PUT", path: "/todos/2", body: `{"due_date":"2026-01-02T09:00:00Z"}`, ifMatch: "*", auth: "$access_token"},
+	{name: "listTodos_overdue", endpoint: "listTodos", method: "GET", path: "/todos?overdue=true", auth: "$access_token"},
+	{name: "listTodos_dueRange", endpoint: "listTodos", method: "GET", path: "/todos?due_after=2026-01-01&due_before=2026-01-02T12:00:00Z", auth: "$access_token"},
+	{name: "listTodos_dueAfter", endpoint: "listTodos", method: "GET", path: "/todos?due_after=2026-01-03", auth: "$access_token"},
+	{name: "listTodos_badDueBefore", endpoint: "listTodos", method: "GET", path: "/todos?due_before=tomorrow", auth: "$access_token"},
+	{name: "createTag", endpoint: "createTag", method: "POST", path: "/tags", body: `{"name":" Work "}`, auth: "$access_token"},
+	{name: "createTag_exists", endpoint: "createTag", method: "POST", path: "/tags", body: `{"name":"work"}`, auth: "$access_token"},
+	{name: "createTag_badName", endpoint: "createTag", method: "POST", path: "/tags", body: `{"name":"a,b"}`, auth: "$access_token"},
+	{name: "updateTodo_tags", endpoint: "updateTodo", method: "PUT", path: "/todos/2", body: `{"tags":["work","Home"]}`, ifMatch: "*", auth: "$access_token"},
+	{name: "createTodo_tags", endpoint: "createTodo", method: "POST", path: "/todos", body: `{"title":"Weekly review","tags":["work"]}`, auth: "$access_token"},
+	{name: "listTodos_tags", endpoint: "listTodos", method: "GET", path: "/todos?tags=work,home", auth: "$access_token"},
+	{name: "listTags", endpoint: "listTags", method: "GET", path: "/tags", auth: "$access_token"},
+	{name: "getTag", endpoint: "getTag", method: "GET", path: "/tags/1", auth: "$access_token"},
+	{name: "getTag_otherUser", endpoint: "getTag", method: "GET", path: "/tags/1", auth: "$bob_token"},
+	{name: "updateTag", endpoint: "updateTag", method: "PUT", path: "/tags/2", body: `{"name":"house"}`, auth: "$access_token"},
+	{name: "updateTag_exists", endpoint: "updateTag", method: "PUT", path: "/tags/2", body: `{"name":"work"}`, auth: "$access_token"},
+	{name: "deleteTag", endpoint: "deleteTag", method: "DELETE", path: "/tags/1", auth: "$access_token"},
+	{name: "getTodo_tagsAfterDelete", endpoint: "getTodo", method: "GET", path: "/todos/2", auth: "$access_token"},
+	{name: "updateTodo_markdown", endpoint: "updateTodo", method: "PUT", path: "/todos/3", body: `{"description":"## Agenda\n\n- **Wins** of the week\n- [Board](https://example.com/board)\n\n<script>alert(1)</script>"}`, ifMatch: "*", auth: "$access_token"},
+	{name: "getTodo_renderHTML", endpoint: "getTodo", method: "GET", path: "/todos/3?render=html", auth: "$access_token"},
+	{name: "getTodo_badRender", endpoint: "getTodo", method: "GET", path: "/todos/3?render=pdf", auth: "$access_token"},
+	{name: "updateTodo_recurrence", endpoint: "updateTodo", method: "PUT", path: "/todos/3", body: `{"due_date":"2026-01-05T09:00:00Z","recurrence":"freq=weekly;byday=mo,th;interval=1"}`, ifMatch: "*", auth: "$access_token"},
+	{name: "updateTodo_completeRecurring", endpoint: "updateTodo", method: "PUT", path: "/todos/3", body: `{"completed":true}`, ifMatch: "*", auth: "$access_token"},
+	{name: "updateTodo_badRecurrence", endpoint: "updateTodo", method: "PUT", path: "/todos/3", body: `{"recurrence":"FREQ=HOURLY"}`, ifMatch: "*", auth: "$access_token"},
+	{name: "patchTodo_merge", endpoint: "patchTodo", method: "PATCH", path: "/todos/3", body: `{"title":"Weekly review and planning","recurrence":null,"estimate":1.5}`, contentType: "application/merge-patch+json", ifMatch: `"4"`, auth: "$access_token"},
+	{name: "patchTodo_jsonPatch", endpoint: "patchTodo", method: "PATCH", path: "/todos/3", body: `[{"op":"test","path":"/recurrence","value":""},{"op":"add","path":"/tags/-","value":"planning"},{"op":"replace","path":"/priority","value":"high"},{"op":"remove","path":"/estimate"}]`, contentType: "application/json-patch+json", ifMatch: `"5"`, auth: "$access_token"},
+	{name: "updateTodo_staleETag", endpoint: "updateTodo", method: "PUT", path: "/todos/3", body: `{"title":"Stale"}`, ifMatch: `"5"`, auth: "$access_token"},
+	{name: "updateTodo_invalidFields", endpoint: "updateTodo", method: "PUT", path: "/todos/3", body: `{"priority":"urgent","estimate":-1}`, ifMatch: "*", auth: "$access_token"},
+	{name: "updateTodo_noIfMatch", endpoint: "updateTodo", method: "PUT", path: "/todos/3", body: `{"title":"Blind"}`, auth: "$access_token"},
+	{name: "deleteTodo_staleETag", endpoint: "deleteTodo", method: "DELETE", path: "/todos/3", ifMatch: `"5"`, auth: "$access_token"},
+	{name: "patchTodo_testFailed", endpoint: "patchTodo", method: "PATCH", path: "/todos/3", body: `[{"op":"test","path":"/priority","value":"low"},{"op":"replace","path":"/title","value":"Stale"}]`, contentType: "application/json-patch+json", ifMatch: "*", auth: "$access_token"},
+	{name: "patchTodo_invalidResult", endpoint: "patchTodo", method: "PATCH", path: "/todos/3", body: `{"priority":"urgent"}`, contentType: "application/merge-patch+json", ifMatch: "*", auth: "$access_token"},
+	{name: "patchTodo_removeTitle", endpoint: "patchTodo", method: "PATCH", path: "/todos/3", body: `[{"op":"remove","path":"/title"}]`, contentType: "application/json-patch+json", ifMatch: "*", auth: "$access_token"},
+	{name: "patchTodo_unknownMember", endpoint: "patchTodo", method: "PATCH", path: "/todos/3", body: `{"owner":2}`, contentType: "application/merge-patch+json", ifMatch: "*", auth: "$access_token"},
+	{name: "patchTodo_unsupportedType", endpoint: "patchTodo", method: "PATCH", path: "/todos/3", body: `{"title":"x"}`, contentType: "application/json", ifMatch: "*", auth: "$access_token"},
+	{name: "patchTodo_otherUser", endpoint: "patchTodo", method: "PATCH", path: "/todos/3", body: `{"title":"Mine"}`, contentType: "application/merge-patch+json", ifMatch: "*", auth: "$bob_token"},
+	{name: "createTodo_recurrenceWithoutDueDate", endpoint: "createTodo", method: "POST", path: "/todos", body: `{"title":"Water plants","recurrence":"FREQ=DAILY"}`, auth: "$access_token"},
+
+	{name: "addChecklistItem", endpoint: "addChecklistItem", method: "POST", path: "/todos/2/checklist", body: `{"text":"Find the number"}`, auth: "$access_token"},
+	{name: "listChecklist", endpoint: "listChecklist", method: "GET", path: "/todos/2/checklist", auth: "$access_token"},
+	{name: "updateChecklistItem", endpoint: "updateChecklistItem", method: "PUT", path: "/todos/2/checklist/1", body: `{"done":true}`, auth: "$access_token"},
+	{name: "deleteChecklistItem", endpoint: "deleteChecklistItem", method: "DELETE", path: "/todos/2/checklist/1", auth: "$access_token"},
+	{name: "createSubtask", endpoint: "createSubtask", method: "POST", path: "/todos/2/subtasks", body: `{"title":"Find the number"}`, auth: "$access_token"},
+	{name: "createSubtask_second", endpoint: "createSubtask", method: "POST", path: "/todos/2/subtasks", body: `{"title":"Book a slot"}`, auth: "$access_token"},
+	{name: "createSubtask_missingTitle", endpoint: "createSubtask", method: "POST", path: "/todos/2/subtasks", body: `{"title":" "}`, auth: "$access_token"},
+	{name: "updateSubtask", endpoint: "updateSubtask", method: "PATCH", path: "/todos/2/subtasks/1", body: `{"completed":true}`, auth: "$access_token"},
+	{name: "updateSubtask_otherTodo", endpoint: "updateSubtask", method: "PATCH", path: "/todos/1/subtasks/1", body: `{"completed":true}`, auth: "$access_token"},
+	{name: "listSubtasks", endpoint: "listSubtasks", method: "GET", path: "/todos/2/subtasks", auth: "$access_token"},
+	{name: "getTodo_subtasks", endpoint: "getTodo", method: "GET", path: "/todos/2", auth: "$access_token"},
+	{name: "deleteSubtask", endpoint: "deleteSubtask", method: "DELETE", path: "/todos/2/subtasks/2", auth: "$access_token"},
+	{name: "createReminder", endpoint: "createReminder", method: "POST", path: "/todos/2/reminders", body: `{"remind_at":"2099-03-01T09:00:00+01:00"}`, auth: "$access_token"},
+	{name: "createReminder_webhook", endpoint: "createReminder", method: "POST", path: "/todos/2/reminders", body: `{"remind_at":"2099-02-01T09:00:00Z","channel":"webhook","target":"https://hooks.example.com/todo"}`, auth: "$access_token"},
+	{name: "createReminder_past", endpoint: "createReminder", method: "POST", path: "/todos/2/reminders", body: `{"remind_at":"2020-01-01T09:00:00Z"}`, auth: "$access_token"},
+	{name: "createReminder_badChannel", endpoint: "createReminder", method: "POST", path: "/todos/2/reminders", body: `{"remind_at":"2099-02-01T09:00:00Z","channel":"pager"}`, auth: "$access_token"},
+	{name: "createReminder_missingTarget", endpoint: "createReminder", method: "POST", path: "/todos/2/reminders", body: `{"remind_at":"2099-02-01T09:00:00Z","channel":"webhook"}`, auth: "$access_token"},
+	{name: "listReminders", endpoint: "listReminders", method: "GET", path: "/todos/2/reminders", auth: "$access_token"},
+	{name: "deleteReminder_otherTodo", endpoint: "deleteReminder", method: "DELETE", path: "/todos/1/reminders/1", auth: "$access_token"},
+	{name: "deleteReminder", endpoint: "deleteReminder", method: "DELETE", path: "/todos/2/reminders/1", auth: "$access_token"},
+	{name: "addReaction", endpoint: "addReaction", method: "POST", path: "/todos/2/reactions", body: `{"emoji":"👍"}`, auth: "$access_token"},
+	{name: "listReactions", endpoint: "listReactions", method: "GET", path: "/todos/2/reactions", auth: "$access_token"},
+	{name: "removeReaction", endpoint: "removeReaction", method: "DELETE", path: "/todos/2/reactions?emoji=%F0%9F%91%8D", auth: "$access_token"},
+	{name: "listActivity", endpoint: "listActivity", method: "GET", path: "/todos/1/activity", auth: "$access_token"},
+	{name: "listAttachments", endpoint: "listAttachments", method: "GET", path: "/todos/1/attachments", auth: "$access_token"},
+	{name: "presignAttachment", endpoint: "presignAttachment", method: "POST", path: "/todos/1/attachments/presign", body: `{"filename":"receipt.pdf","content_type":"application/pdf","size":1024}`, auth: "$access_token"},
+	{name: "confirmAttachment", endpoint: "confirmAttachment", method: "POST", path: "/todos/1/attachments/1/confirm", auth: "$access_token"},
+	{name: "deleteAttachment", endpoint: "deleteAttachment", method: "DELETE", path: "/attachments/1", auth: "$access_token"},
+	{name: "deleteAttachment_unauthenticated", endpoint: "deleteAttachment", method: "DELETE", path: "/attachments/1"},
+
+	{name: "startFocus", endpoint: "startFocus", method: "POST", path: "/focus/start", body: `{"todo_id":2,"planned_minutes":25}`, auth: "$access_token"},
+	{name: "startFocus_running", endpoint: "startFocus", method: "POST", path: "/focus/start", body: `{}`, auth: "$access_token"},
+	{name: "currentFocus", endpoint: "currentFocus", method: "GET", path: "/focus/current", auth: "$access_token"},
+	{name: "stopFocus", endpoint: "stopFocus", method: "POST", path: "/focus/stop", body: `{}`, auth: "$access_token"},
+	{name: "getStats", endpoint: "getStats", method: "GET", path: "/stats?user_id=1&from=2026-01-05&to=2026-01-11&tz=UTC", auth: "$access_token"},
+	{name: "getStats_forbidden", endpoint: "getStats", method: "GET", path: "/stats?user_id=2", auth: "$bob_token"},
+	{name: "getPreferences", endpoint: "getPreferences", method: "GET", path: "/users/1/preferences", auth: "$access_token"},
+	{name: "getPreferences_otherUser", endpoint: "getPreferences", method: "GET", path: "/users/1/preferences", auth: "$bob_token"},
+	{name: "updatePreferences", endpoint: "updatePreferences", method: "PUT", path: "/users/1/preferences", body: `{"timezone":"Europe/Berlin"}`, auth: "$access_token"},
+	{name: "connectGoogleCalendar", endpoint: "connectGoogleCalendar", method: "POST", path: "/users/1/google-calendar", body: `{"code":"abc","redirect_uri":"https://example.com/callback"}`, auth: "$access_token"},
+	{name: "getGoogleCalendar", endpoint: "getGoogleCalendar", method: "GET", path: "/users/1/google-calendar", auth: "$access_token"},
+	{name: "disconnectGoogleCalendar", endpoint: "disconnectGoogleCalendar", method: "DELETE", path: "/users/1/google-calendar", auth: "$access_token"},
+
+	{name: "beginPasskeyLogin", endpoint: "beginPasskeyLogin", method: "POST", path: "/auth/passkeys/login/begin"},
+	{name: "finishPasskeyLogin", endpoint: "finishPasskeyLogin", method: "POST", path: "/auth/passkeys/login/finish", body: `{"credential":{"id":"a","rawId":"a","type":"public-key","response":{"clientDataJSON":"e30","authenticatorData":"","signature":""}}}`},
+	{name: "logout", endpoint: "logout", method: "POST", path: "/auth/logout"},
+	{name: "beginPasskeyRegistration", endpoint: "beginPasskeyRegistration", method: "POST", path: "/me/passkeys/register/begin", auth: "$access_token"},
+	{name: "finishPasskeyRegistration", endpoint: "finishPasskeyRegistration", method: "POST", path: "/me/passkeys/register/finish", body: `{"name":"Laptop","credential":{"id":"a","rawId":"a","type":"public-key","response":{"clientDataJSON":"e30","attestationObject":""}}}`, auth: "$access_token"},
+	{name: "listPasskeys", endpoint: "listPasskeys", method: "GET", path: "/me/passkeys"},
+	{name: "updatePasskey", endpoint: "updatePasskey", method: "PUT", path: "/me/passkeys/1", body: `{"name":"Phone"}`},
+	{name: "deletePasskey", endpoint: "deletePasskey", method: "DELETE", path: "/me/passkeys/1"},
+	{name: "listSSOProviders", endpoint: "listSSOProviders", method: "GET", path: "/auth/oidc/providers"},
+	{name: "beginSSOLogin", endpoint: "beginSSOLogin", method: "POST", path: "/auth/oidc/okta/begin"},
+	{name: "finishSSOLogin", endpoint: "finishSSOLogin", method: "POST", path: "/auth/oidc/okta/callback", body: `{"code":"abc","state":"xyz"}`},
+	{name: "listIdentities", endpoint: "listIdentities", method: "GET", path: "/me/identities"},
+	{name: "deleteIdentity", endpoint: "deleteIdentity", method: "DELETE", path: "/me/identities/1"},
+	{name: "followTodo_otherUser", endpoint: "followTodo", method: "POST", path: "/todos/1/follow", auth: "$bob_token"},
+	{name: "unfollowTodo_notFollowing", endpoint: "unfollowTodo", method: "DELETE", path: "/todos/1/follow", auth: "$bob_token"},
+	{name: "listFollowing", endpoint: "listFollowing", method: "GET", path: "/me/following"},
+	{name: "listTodoPresence", endpoint: "listTodoPresence", method: "GET", path: "/todos/1/presence", auth: "$access_token"},
+	{name: "updateTodoPresence", endpoint: "updateTodoPresence", method: "PUT", path: "/todos/1/presence", body: `{"state":"editing"}`, auth: "$access_token"},
+	{name: "leaveTodoPresence", endpoint: "leaveTodoPresence", method: "DELETE", path: "/todos/1/presence", auth: "$access_token"},
+	{name: "listListPresence", endpoint: "listListPresence", method: "GET", path: "/lists/1/presence", auth: "$access_token"},
+	{name: "updateListPresence", endpoint: "updateListPresence", method: "PUT", path: "/lists/1/presence", body: `{}`, auth: "$access_token"},
+	{name: "leaveListPresence", endpoint: "leaveListPresence", method: "DELETE", path: "/lists/1/presence", auth: "$access_token"},
+
+	{name: "getListBurndown", endpoint: "getListBurndown", method: "GET", path: "/lists/1/burndown?from=2026-01-05&to=2026-01-07&tz=UTC", auth: "$access_token"},
+	{name: "getListTimeline", endpoint: "getListTimeline", method: "GET", path: "/lists/1/timeline", auth: "$access_token"},
+	{name: "getListTimeline_invalidID", endpoint: "getListTimeline", method: "GET", path: "/lists/abc/timeline", auth: "$access_token"},
+	{name: "getListTimeline_notFound", endpoint: "getListTimeline", method: "GET", path: "/lists/99/timeline", auth: "$access_token"},
+	{name: "linkGitHub", endpoint: "linkGitHub", method: "POST", path: "/lists/1/github", body: `{"owner":"octo","repo":"todos","code":"4f2b8c","redirect_uri":"https://app.example.com/github/callback"}`, auth: "$access_token"},
+	{name: "getGitHubLink", endpoint: "getGitHubLink", method: "GET", path: "/lists/1/github", auth: "$access_token"},
+	{name: "unlinkGitHub", endpoint: "unlinkGitHub", method: "DELETE", path: "/lists/1/github", auth: "$access_token"},
+
+	{name: "createReportSchedule", endpoint: "createReportSchedule", method: "POST", path: "/reports/schedules", body: `{"channel":"webhook","target":"https://example.com/hook","weekday":"monday","hour":9}`, auth: "$access_token"},
+	{name: "listReportSchedules", endpoint: "listReportSchedules", method: "GET", path: "/reports/schedules", auth: "$access_token"},
+	{name: "getReportSchedule", endpoint: "getReportSchedule", method: "GET", path: "/reports/schedules/1", auth: "$access_token"},
+	{name: "getReportSchedule_otherUser", endpoint: "getReportSchedule", method: "GET", path: "/reports/schedules/1", auth: "$bob_token"},
+	{name: "updateReportSchedule", endpoint: "updateReportSchedule", method: "PUT", path: "/reports/schedules/1", body: `{"hour":17}`, auth: "$access_token"},
+	{name: "deleteReportSchedule", endpoint: "deleteReportSchedule", method: "DELETE", path: "/reports/schedules/1", auth: "$access_token"},
+
+	{name: "createWebhook", endpoint: "createWebhook", method: "POST", path: "/webhooks", body: `{"url":"https://example.com/hooks/todos","events":["todo.updated","todo.created","todo.updated"]}`, auth: "$access_token", capture: map[string]string{"webhook_secret": "secret"}},
+	{name: "createWebhook_badURL", endpoint: "createWebhook", method: "POST", path: "/webhooks", body: `{"url":"ftp://example.com/hooks"}`, auth: "$access_token"},
+	{name: "createWebhook_privateURL", endpoint: "createWebhook", method: "POST", path: "/webhooks", body: `{"url":"http://169.254.169.254/latest/meta-data"}`, auth: "$access_token"},
+	{name: "createWebhook_unknownEvent", endpoint: "createWebhook", method: "POST", path: "/webhooks", body: `{"url":"https://example.com/hooks","events":["todo.archived"]}`, auth: "$access_token"},
+	{name: "createWebhook_unauthenticated", endpoint: "createWebhook", method: "POST", path: "/webhooks", body: `{"url":"https://example.com/hooks"}`},
+	{name: "listWebhooks", endpoint: "listWebhooks", method: "GET", path: "/webhooks", auth: "$access_token"},
+	{name: "getWebhook", endpoint: "getWebhook", method: "GET", path: "/webhooks/1", auth: "$access_token"},
+	{name: "getWebhook_otherUser", endpoint: "getWebhook", method: "GET", path: "/webhooks/1", auth: "$bob_token"},
+	{name: "updateWebhook", endpoint: "updateWebhook", method: "PUT", path: "/webhooks/1", body: `{"events":[],"active":false}`, auth: "$access_token"},
+	{name: "listWebhookDeliveries", endpoint: "listWebhookDeliveries", method: "GET", path: "/webhooks/1/deliveries", auth: "$access_token"},
+	{name: "deleteWebhook", endpoint: "deleteWebhook", method: "DELETE", path: "/webhooks/1", auth: "$access_token"},
+	{name: "deleteWebhook_again", endpoint: "deleteWebhook", method: "DELETE", path: "/webhooks/1", auth: "$access_token"},
+	{name: "createFeedToken", endpoint: "createFeedToken", method: "POST", path: "/feeds/tokens", auth: "$access_token", capture: map[string]string{"feed_token": "token"}},
+	{name: "createFeedToken_unauthenticated", endpoint: "createFeedToken", method: "POST", path: "/feeds/tokens"},
+	{name: "revokeFeedToken_otherUser", endpoint: "revokeFeedToken", method: "DELETE", path: "/feeds/tokens/$feed_token", auth: "$bob_token"},
+	{name: "revokeFeedToken", endpoint: "revokeFeedToken", method: "DELETE", path: "/feeds/tokens/$feed_token", auth: "$access_token"},
+
+	{name: "createNotionExport", endpoint: "createNotionExport", method: "POST", path: "/export/notion", body: `{"database_id":"db1"}`, auth: "$access_token"},
+	{name: "getNotionExport", endpoint: "getNotionExport", method: "GET", path: "/export/notion/1", auth: "$access_token"},
+	{name: "getNotionExport_otherUser", endpoint: "getNotionExport", method: "GET", path: "/export/notion/1", auth: "$bob_token"},
+
+	{name: "createImport", endpoint: "createImport", method: "POST", path: "/imports?format=csv", body: "title,completed\nWater plants,false\n", auth: "$access_token"},
+	{name: "getImport", endpoint: "getImport", method: "GET", path: "/imports/1", auth: "$access_token"},
+	{name: "getImport_otherUser", endpoint: "getImport", method: "GET", path: "/imports/1", auth: "$bob_token"},
+
+	{name: "createInboundHook", endpoint: "createInboundHook", method: "POST", path: "/hooks/inbound", body: `{"list_id":1,"title_template":"Deploy {{service}}"}`, auth: "$access_token", capture: map[string]string{"hook_token": "token"}},
+	{name: "triggerInboundHook", endpoint: "triggerInboundHook", method: "POST", path: "/hooks/inbound/$hook_token", body: `{"service":"api"}`},
+	{name: "revokeInboundHook_otherUser", endpoint: "revokeInboundHook", method: "DELETE", path: "/hooks/inbound/$hook_token", auth: "$bob_token"},
+	{name: "revokeInboundHook", endpoint: "revokeInboundHook", method: "DELETE", path: "/hooks/inbound/$hook_token", auth: "$access_token"},
+
+	{name: "deleteTodo", endpoint: "deleteTodo", method: "DELETE", path: "/todos/2", ifMatch: "*", auth: "$access_token"},
+	{name: "listTrash", endpoint: "listTrash", method: "GET", path: "/todos/trash", auth: "$access_token"},
+	{name: "restoreTodo_otherUser", endpoint: "restoreTodo", method: "POST", path: "/todos/2/restore", auth: "$bob_token"},
+	{name: "restoreTodo", endpoint: "restoreTodo", method: "POST", path: "/todos/2/restore", auth: "$access_token"},
+	{name: "restoreTodo_notDeleted", endpoint: "restoreTodo", method: "POST", path: "/todos/2/restore", auth: "$access_token"},
+	{name: "purgeTodo_notDeleted", endpoint: "purgeTodo", method: "DELETE", path: "/todos/2/purge", auth: "$access_token"},
+	{name: "deleteTodo_again", endpoint: "deleteTodo", method: "DELETE", path: "/todos/2", ifMatch: "*", auth: "$access_token"},
+	{name: "purgeTodo", endpoint: "purgeTodo", method: "DELETE", path: "/todos/2/purge", auth: "$access_token"},
+	{name: "listTrash_afterPurge", endpoint: "listTrash", method: "GET", path: "/todos/trash", auth: "$access_token"},
+	{name: "bulkCreateTodos", endpoint: "bulkCreateTodos", method: "POST", path: "/todos/bulk", body: `[{"title":"Pack"},{"title":""},{"title":"Unpack","priority":"urgent"},{"title":"Travel","priority":"low"}]`, auth: "$access_token"},
+	{name: "bulkCreateTodos_empty", endpoint: "bulkCreateTodos", method: "POST", path: "/todos/bulk", body: `[]`, auth: "$access_token"},
+	{name: "bulkUpdateTodos", endpoint: "bulkUpdateTodos", method: "PATCH", path: "/todos/bulk", body: `[{"id":5,"completed":true},{"id":2,"title":"Purged"},{"id":5,"title":"Twice"},{"id":6,"priority":"urgent"},{"id":6}]`, auth: "$access_token"},
+	{name: "bulkUpdateTodos_otherUser", endpoint: "bulkUpdateTodos", method: "PATCH", path: "/todos/bulk", body: `[{"id":5,"title":"Mine now"}]`, auth: "$bob_token"},
+	{name: "bulkDeleteTodos", endpoint: "bulkDeleteTodos", method: "DELETE", path: "/todos/bulk", body: `[5,6,999]`, auth: "$access_token"},
+	{name: "bulkDeleteTodos_unknownField", endpoint: "bulkDeleteTodos", method: "DELETE", path: "/todos/bulk", body: `{"ids":[5]}`, auth: "$access_token"},
+	{name: "createTodo_idempotencyKey", endpoint: "createTodo", method: "POST", path: "/todos", body: `{"title":"Pay rent"}`, idempotencyKey: "rent-2026-10", auth: "$access_token"},
+	{name: "createTodo_idempotencyReplay", endpoint: "createTodo", method: "POST", path: "/todos", body: `{"title":"Pay rent"}`, idempotencyKey: "rent-2026-10", auth: "$access_token"},
+	{name: "createTodo_idempotencyKeyReused", endpoint: "createTodo", method: "POST", path: "/todos", body: `{"title":"Pay rent twice"}`, idempotencyKey: "rent-2026-10", auth: "$access_token"},
+	{name: "createTodo_idempotencyKeyOtherUser", endpoint: "createTodo", method: "POST", path: "/todos", body: `{"title":"Pay rent"}`, idempotencyKey: "rent-2026-10", auth: "$bob_token"},
+	{name: "listTags_legacyPath", endpoint: "listTags", method: "GET", path: "/tags", auth: "$access_token", unversioned: true},
+	{name: "listTags_unknownVersion", endpoint: "listTags", method: "GET", path: "/api/v2/tags", auth: "$access_token", unversioned: true},
+	{name: "deleteList", endpoint: "deleteList", method: "DELETE", path: "/lists/1", auth: "$access_token"},
+}
+
+// newGoldenServer wires the API like demo mode: in-memory repositories and
+// no external integrations.
+func newGoldenServer() http.Handler {
+	repos := repository.NewMemoryRepositories()
+	pages := pagination.DefaultConfig()
+	notifier := notify.NewRegistry(notify.LogChannel{}, notify.NewWebhookChannel(nil))
+	suggester := suggest.NewKeywordSuggester(suggest.DefaultKeywordRules())
+
+	auth, err := service.NewAuthService(repos.Users, service.AuthConfig{Secret: []byte(strings.Repeat("k", jwt.MinKeyLength)), TTL: time.Hour})
+	if err != nil {
+		panic(err)
+	}
+	follow := service.NewFollowService(repos.Watchers, repos.Todos, repos.Preferences, repos.Users, notifier)
+	todos := service.NewTodoService(repos.Todos, repos.Tags, repos.Preferences, repos.Activities, suggester, realtime.NewHub(), follow, service.TodoConfig{Limits: pages, FuzzyThreshold: 0.3})
+	reports := service.NewReportService(repos.Todos, repos.Lists, pages)
+	httpServer := NewServer(Config{}, Services{
+		Todo:           todos,
+		Feed:           service.NewFeedService(repos.FeedTokens, repos.Todos),
+		List:           service.NewListService(repos.Lists),
+		Report:         reports,
+		ReportSchedule: service.NewReportScheduleService(repos.ReportSchedules, reports, notifier),
+		Suggestion:     service.NewSuggestionService(suggester),
+		Preference:     service.NewPreferenceService(repos.Preferences, notifier),
+		Attachment:     service.NewAttachmentService(repos.Attachments, repos.Todos, nil, &thumbnail.Generator{}, nil, notifier, service.AttachmentConfigFromEnv()),
+		Checklist:      service.NewChecklistService(repos.Checklists, repos.Todos),
+		Reaction:       service.NewReactionService(repos.Reactions, repos.Todos),
+		Activity:       service.NewActivityService(repos.Activities, repos.Todos),
+		Overdue:        service.NewOverdueService(repos.Todos, repos.Preferences, notifier),
+		Focus:          service.NewFocusService(repos.FocusSessions, repos.Todos),
+		Stats:          service.NewStatsService(repos.FocusSessions, repos.Todos, repos.Preferences),
+		Burndown:       service.NewBurndownService(repos.Lists, repos.Activities, repos.Preferences),
+		Timeline:       service.NewTimelineService(repos.Lists, repos.Todos),
+		InboundHook:    service.NewInboundHookService(repos.InboundHooks, todos),
+		GitHub:         service.NewGitHubService(repos.GitHub, repos.Lists, repos.Todos, todos, nil),
+		Calendar:       service.NewCalendarService(repos.Calendars, repos.Todos, todos, nil),
+		NotionExport:   service.NewNotionExportService(repos.NotionExports, repos.Todos, repos.Lists, repos.Jobs, nil, pages),
+		Import:         service.NewImportService(repos.Imports, repos.Lists),
+		Passkey:        service.NewPasskeyService(repos.Passkeys, repos.Sessions, nil, service.SessionConfig{TTL: time.Hour}),
+		Session:        service.NewSessionService(repos.Sessions),
+		Auth:           auth,
+		APIKeys:        service.NewAPIKeyService(repos.APIKeys),
+		Webhooks:       service.NewWebhookService(repos.Webhooks, repos.Jobs, webhook.NewSender(nil)),
+		Tags:           service.NewTagService(repos.Tags),
+		Subtasks:       service.NewSubtaskService(repos.Subtasks, repos.Todos),
+		Reminders:      service.NewReminderService(repos.Reminders, repos.Todos, repos.Preferences, repos.Jobs, notifier),
+		Users:          service.NewUserService(repos.Users),
+		SSO:            service.NewSSOService(repos.Identities, repos.Sessions, nil, service.SessionConfig{TTL: time.Hour}),
+		Follow:         follow,
+		Presence:       service.NewPresenceService(realtime.NewMemoryPresence(), repos.Todos, repos.Lists, realtime.NewHub(), service.PresenceConfig{TTL: 30 * time.Second}),
+		Idempotency:    service.NewIdempotencyService(repos.IdempotencyKeys, service.IdempotencyConfig{TTL: time.Hour}),
+	}, nil)
+	return httpServer.Handler
+}
+
+// TestGoldenResponses replays goldenCases and compares each response with
+// testdata/golden/<name>.json. Run with -update after an intended contract
+// change and review the diff.
+func TestGoldenResponses(t *testing.T) {
+	t.Setenv("READ_ONLY", "")
+	handler := newGoldenServer()
+	vars := map[string]string{}
+
+	for _, tc := range goldenCases {
+		substitute := func(s string) string {
+			for name, value := range vars {
+				s = strings.ReplaceAll(s, "$"+name, value)
+			}
+			return s
+		}
+		target := tc.path
+		if !tc.unversioned {
+			target = apispec.BasePath + target
+		}
+		req := httptest.NewRequest(tc.method, substitute(target), strings.NewReader(substitute(tc.body)))
+		if auth := substitute(tc.auth); strings.Contains(auth, " ") {
+			req.Header.Set("Authorization", auth)
+		} else if auth != "" {
+			req.Header.Set("Authorization", "Bearer "+auth)
+		}
+		// A fixed request ID keeps the IDs in error bodies stable
+		req.Header.Set(requestid.Header, "golden")
+		if tc.contentType != "" {
+			req.Header.Set("Content-Type", tc.contentType)
+		}
+		if tc.ifMatch != "" {
+			req.Header.Set("If-Match", tc.ifMatch)
+		}
+		if tc.idempotencyKey != "" {
+			req.Header.Set("Idempotency-Key", tc.idempotencyKey)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		for name, field := range tc.capture {
+			var fields map[string]interface{}
+			if err := json.Unmarshal(rec.Body.Bytes(), &fields); err != nil {
+				t.Fatalf("%s: capturing %s: %v", tc.name, field, err)
+			}
+			vars[name] = fmt.Sprint(fields[field])
+		}
+
+		got := goldenRecord(t, rec, vars)
+		path := filepath.Join("testdata", "golden", tc.name+".json")
+		if *updateGolden {
+			if err := os.WriteFile(path, got, 0o644); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		want, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("%s: %v (run go test -run TestGoldenResponses -update)", tc.name, err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s %s: response changed\n got: %s\nwant: %s", tc.method, tc.path, got, want)
+		}
+	}
+}
+
+// TestGoldenCoverage makes sure every catalogued endpoint has a golden case.
+func TestGoldenCoverage(t *testing.T) {
+	covered := map[string]bool{}
+	for _, tc := range goldenCases {
+		covered[tc.endpoint] = true
+	}
+	for _, e := range apispec.Endpoints {
+		if !covered[e.Name] {
+			t.Errorf("%s (%s %s) has no golden case", e.Name, e.Method, e.Path)
+		}
+	}
+}
+
+// goldenRecord renders a response as a stable JSON document: contract
+// headers, status and indented body, with timestamps, dates, weak ETags
+// and captured values masked.
+func goldenRecord(t *testing.T, rec *httptest.ResponseRecorder, vars map[string]string) []byte {
+	t.Helper()
+	headers := map[string]string{}
+	for _, name := range goldenHeaders {
+		if v := rec.Header().Get(name); v != "" {
+			headers[name] = v
+		}
+	}
+	record := struct {
+		Status  int               `json:"status"`
+		Headers map[string]string `json:"headers"`
+		Body    json.RawMessage   `json:"body,omitempty"`
+	}{Status: rec.Code, Headers: headers}
+	if body := bytes.TrimSpace(rec.Body.Bytes()); len(body) > 0 {
+		if !json.Valid(body) {
+			body, _ = json.Marshal(string(body))
+		}
+		record.Body = body
+	}
+
+	var out bytes.Buffer
+	encoder := json.NewEncoder(&out)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(record); err != nil {
+		t.Fatal(err)
+	}
+	s := timestampPattern.ReplaceAllString(out.String(), "<timestamp>")
+	s = datePattern.ReplaceAllString(s, `"<date>"`)
+	s = httpDatePattern.ReplaceAllString(s, "<http-date>")
+	s = weakETagPattern.ReplaceAllString(s, `W/\"<digest>\"`)
+	// Longest first, as one captured value may contain another
+	names := slices.Collect(maps.Keys(vars))
+	slices.SortFunc(names, func(a, b string) int { return cmp.Or(len(vars[b])-len(vars[a]), strings.Compare(a, b)) })
+	for _, name := range names {
+		s = strings.ReplaceAll(s, vars[name], "<"+name+">")
+	}
+	return []byte(s)
+}
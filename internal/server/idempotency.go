@@ -0,0 +1,137 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+
+	"github.com/Tomlord1122/todo-backend/internal/logging"
+	"github.com/Tomlord1122/todo-backend/internal/service"
+)
+
+// maxIdempotencyKeyLength bounds the Idempotency-Key header; clients
+// usually send a UUID.
+const maxIdempotencyKeyLength = 255
+
+// idempotencyReplayedHeader marks a response replayed for a retried request.
+const idempotencyReplayedHeader = "Idempotent-Replayed"
+
+// idempotentHeaders are the response headers stored with a response, so a
+// replay looks like the original.
+var idempotentHeaders = []string{"Content-Type", "ETag", "Location"}
+
+// idempotent lets clients retry requests safely: requests sent with an
+// Idempotency-Key header are handled once per signed-in user and key, and
+// retries get the stored response. It must run after requireSession.
+// Requests without the header, or without an idempotency service, are
+// handled as usual.
+func (s *Server) idempotent(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get("Idempotency-Key")
+		if key == "" || s.idempotencyService == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			respondWithError(w, r, http.StatusBadRequest, "Idempotency-Key must be at most 255 characters")
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			respondWithError(w, r, http.StatusBadRequest, "Failed to read the request body")
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		userID := sessionUserFrom(r)
+		stored, err := s.idempotencyService.Begin(r.Context(), userID, key, requestFingerprint(r, body))
+		if err != nil {
+			respondWithServiceError(w, r, err, "Begin idempotency", "Failed to check the idempotency key")
+			return
+		}
+		if stored != nil {
+			for name, value := range stored.Header {
+				w.Header().Set(name, value)
+			}
+			w.Header().Set(idempotencyReplayedHeader, "true")
+			w.WriteHeader(stored.StatusCode)
+			_, _ = w.Write(stored.Body)
+			return
+		}
+
+		// The response is stored even when the client is gone, since that is
+		// when it retries
+		ctx := context.WithoutCancel(r.Context())
+		rec := &idempotencyRecorder{ResponseWriter: w}
+		completed := false
+		defer func() {
+			// Server errors and panics free the key so the request can be
+			// retried
+			if !completed {
+				if err := s.idempotencyService.Release(ctx, userID, key); err != nil {
+					logging.FromContext(ctx).Error("Error calling service", "operation", "Release idempotency", "err", err)
+				}
+			}
+		}()
+		next.ServeHTTP(rec, r)
+
+		if rec.code == 0 {
+			// net/http sends a 200 for handlers that write nothing
+			rec.code = http.StatusOK
+		}
+		if rec.code >= http.StatusInternalServerError {
+			return
+		}
+		resp := service.StoredResponse{StatusCode: rec.code, Header: map[string]string{}, Body: rec.body.Bytes()}
+		for _, name := range idempotentHeaders {
+			if v := rec.Header().Get(name); v != "" {
+				resp.Header[name] = v
+			}
+		}
+		if err := s.idempotencyService.Complete(ctx, userID, key, resp); err != nil {
+			logging.FromContext(ctx).Error("Error calling service", "operation", "Complete idempotency", "err", err)
+			return
+		}
+		completed = true
+	})
+}
+
+// requestFingerprint identifies a request by method, path and body, to
+// detect a key reused for a different request.
+func requestFingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(r.Method + " " + r.URL.Path + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// idempotencyRecorder writes a response through while keeping a copy.
+type idempotencyRecorder struct {
+	http.ResponseWriter
+	code int
+	body bytes.Buffer
+}
+
+func (rec *idempotencyRecorder) WriteHeader(code int) {
+	if rec.code == 0 {
+		rec.code = code
+	}
+	rec.ResponseWriter.WriteHeader(code)
+}
+
+func (rec *idempotencyRecorder) Write(b []byte) (int, error) {
+	if rec.code == 0 {
+		rec.WriteHeader(http.StatusOK)
+	}
+	rec.body.Write(b)
+	return rec.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (rec *idempotencyRecorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
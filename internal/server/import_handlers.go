@@ -0,0 +1,68 @@
+package server
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/Tomlord1122/todo-backend/internal/service"
+)
+
+// maxImportFile caps uploaded import files.
+const maxImportFile = 10 << 20
+
+// createImportHandler serves POST /imports?user_id=&format=&list_id=. The
+// body is the exported file itself. The import runs in the background;
+// poll GET /imports/{id} for progress.
+func (s *Server) createImportHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := parseOwnUserIDQuery(w, r)
+	if !ok {
+		return
+	}
+	req := service.CreateImportRequest{UserID: userID, Format: r.URL.Query().Get("format")}
+	if v := r.URL.Query().Get("list_id"); v != "" {
+		listID, err := strconv.ParseUint(v, 10, 64)
+		if err != nil || listID == 0 {
+			respondWithError(w, r, http.StatusBadRequest, "Invalid list_id query parameter")
+			return
+		}
+		id := uint(listID)
+		req.ListID = &id
+	}
+
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxImportFile))
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			respondWithError(w, r, http.StatusRequestEntityTooLarge, "Import file is too large")
+			return
+		}
+		respondWithError(w, r, http.StatusBadRequest, "Failed to read import file")
+		return
+	}
+	req.Data = data
+
+	imp, err := s.importService.Create(r.Context(), req)
+	if err != nil {
+		respondWithServiceError(w, r, err, "CreateImport", "Failed to create import")
+		return
+	}
+
+	respondWithJSON(w, http.StatusAccepted, imp)
+}
+
+func (s *Server) getImportHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseIDParam(w, r, "id", "import")
+	if !ok {
+		return
+	}
+
+	imp, err := s.importService.Get(r.Context(), id)
+	if err != nil {
+		respondWithServiceError(w, r, err, "GetImport", "Failed to retrieve import")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, imp)
+}
@@ -0,0 +1,61 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/Tomlord1122/todo-backend/internal/service"
+)
+
+// maxInboundPayload caps the JSON automation tools may post to a hook.
+const maxInboundPayload = 1 << 20
+
+func (s *Server) createInboundHookHandler(w http.ResponseWriter, r *http.Request) {
+	var req service.CreateInboundHookRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+	req.UserID = sessionUserFrom(r)
+
+	hook, err := s.inboundHookService.Create(r.Context(), req)
+	if err != nil {
+		respondWithServiceError(w, r, err, "CreateInboundHook", "Failed to create inbound hook")
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, hook)
+}
+
+func (s *Server) revokeInboundHookHandler(w http.ResponseWriter, r *http.Request) {
+	err := s.inboundHookService.Revoke(r.Context(), sessionUserFrom(r), chi.URLParam(r, "token"))
+	if err != nil {
+		respondWithServiceError(w, r, err, "RevokeInboundHook", "Failed to revoke inbound hook")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// triggerInboundHookHandler serves POST /hooks/inbound/{token}. The body
+// is whatever JSON the automation tool sends; the hook's templates decide
+// what ends up in the todo.
+func (s *Server) triggerInboundHookHandler(w http.ResponseWriter, r *http.Request) {
+	// Numbers are kept as written so IDs render exactly in templates
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxInboundPayload))
+	decoder.UseNumber()
+	var payload any
+	if err := decoder.Decode(&payload); err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "Request body must be a JSON document of at most 1 MiB")
+		return
+	}
+
+	todo, err := s.inboundHookService.Trigger(r.Context(), chi.URLParam(r, "token"), payload)
+	if err != nil {
+		respondWithServiceError(w, r, err, "TriggerInboundHook", "Failed to run inbound hook")
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, todo)
+}
@@ -0,0 +1,133 @@
+package server
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/Tomlord1122/todo-backend/internal/logging"
+	"github.com/Tomlord1122/todo-backend/internal/service"
+)
+
+func (s *Server) createListHandler(w http.ResponseWriter, r *http.Request) {
+	var req service.CreateListRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+	req.UserID = sessionUserFrom(r)
+
+	list, err := s.listService.CreateList(r.Context(), req)
+	if err != nil {
+		respondWithServiceError(w, r, err, "CreateList", "Failed to create list")
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, list)
+}
+
+func (s *Server) getListsHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := parseOwnUserIDQuery(w, r)
+	if !ok {
+		return
+	}
+
+	deletedSince, ok := parseDeletedSinceQuery(w, r)
+	if !ok {
+		return
+	}
+
+	lists, err := s.listService.GetListsByUser(r.Context(), userID, deletedSince)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Error calling service", "operation", "GetListsByUser", "err", err)
+		respondWithError(w, r, http.StatusInternalServerError, "Failed to retrieve lists")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, lists)
+}
+
+func (s *Server) getListByIDHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseIDParam(w, r, "id", "list")
+	if !ok {
+		return
+	}
+
+	list, err := s.listService.GetListByID(r.Context(), id)
+	if err != nil {
+		respondWithServiceError(w, r, err, "GetListByID", "Failed to retrieve list")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, list)
+}
+
+func (s *Server) updateListHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseIDParam(w, r, "id", "list")
+	if !ok {
+		return
+	}
+
+	var req service.UpdateListRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+
+	list, err := s.listService.UpdateList(r.Context(), id, req)
+	if err != nil {
+		respondWithServiceError(w, r, err, "UpdateList", "Failed to update list")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, list)
+}
+
+func (s *Server) deleteListHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseIDParam(w, r, "id", "list")
+	if !ok {
+		return
+	}
+
+	err := s.listService.DeleteList(r.Context(), id)
+	if err != nil {
+		respondWithServiceError(w, r, err, "DeleteList", "Failed to delete list")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// listBurndownHandler serves GET /lists/{id}/burndown?from=&to=&tz=.
+func (s *Server) listBurndownHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseIDParam(w, r, "id", "list")
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	burndown, err := s.burndownService.GetBurndown(r.Context(), id, service.BurndownRequest{
+		From:     query.Get("from"),
+		To:       query.Get("to"),
+		Timezone: query.Get("tz"),
+	}, time.Now())
+	if err != nil {
+		respondWithServiceError(w, r, err, "GetBurndown", "Failed to compute burndown")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, burndown)
+}
+
+// listTimelineHandler serves GET /lists/{id}/timeline.
+func (s *Server) listTimelineHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseIDParam(w, r, "id", "list")
+	if !ok {
+		return
+	}
+
+	timeline, err := s.timelineService.GetTimeline(r.Context(), id)
+	if err != nil {
+		respondWithServiceError(w, r, err, "GetTimeline", "Failed to build timeline")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, timeline)
+}
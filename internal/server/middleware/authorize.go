@@ -0,0 +1,34 @@
+// Package middleware holds HTTP middleware of the API that is independent
+// of the Server type.
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/Tomlord1122/todo-backend/internal/authz"
+	"github.com/Tomlord1122/todo-backend/internal/problem"
+)
+
+// Authorize lets a request through only if the principal stored in its
+// context by authz.NewContext has every one of permissions. It must run
+// after the session middleware: requests without a principal get 401,
+// principals lacking a permission get 403.
+func Authorize(permissions ...authz.Permission) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			p, ok := authz.FromContext(r.Context())
+			if !ok {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="todo"`)
+				problem.Error(w, r, http.StatusUnauthorized, "authentication required")
+				return
+			}
+			for _, permission := range permissions {
+				if !authz.Can(p.Role, permission) {
+					problem.Error(w, r, http.StatusForbidden, authz.ErrForbidden.Error())
+					return
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Tomlord1122/todo-backend/internal/authz"
+	"github.com/Tomlord1122/todo-backend/internal/domain"
+)
+
+func TestAuthorize(t *testing.T) {
+	handler := Authorize(authz.ManageUsers)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	cases := []struct {
+		name      string
+		principal *authz.Principal
+		want      int
+	}{
+		{"anonymous", nil, http.StatusUnauthorized},
+		{"member", &authz.Principal{UserID: 2, Role: domain.RoleMember}, http.StatusForbidden},
+		{"admin", &authz.Principal{UserID: 1, Role: domain.RoleAdmin}, http.StatusNoContent},
+	}
+	for _, c := range cases {
+		req := httptest.NewRequest("GET", "/users", nil)
+		if c.principal != nil {
+			req = req.WithContext(authz.NewContext(req.Context(), *c.principal))
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != c.want {
+			t.Errorf("%s: status = %d, want %d", c.name, rec.Code, c.want)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package server
+
+import (
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// routeMethods are the methods checked when looking for a matching route.
+var routeMethods = []string{http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete}
+
+// normalizePaths routes slightly-off paths to the route they were meant for:
+// duplicate and trailing slashes are dropped, and if that still matches
+// nothing the path is retried in lower case. The request is routed on the
+// canonical path rather than redirected, since clients that mangle paths
+// rarely follow redirects for non-GET requests. Paths that match no route
+// either way are left alone (route parameters such as feed tokens stay case
+// sensitive).
+//
+// It also answers plain OPTIONS requests with the methods the route allows;
+// CORS preflights are passed on to the CORS handler.
+func normalizePaths(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rctx := chi.RouteContext(r.Context())
+		if rctx == nil || rctx.Routes == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		routePath := rctx.RoutePath
+		if routePath == "" {
+			routePath = r.URL.Path
+			if r.URL.RawPath != "" {
+				routePath = r.URL.RawPath
+			}
+		}
+
+		allowed := allowedMethods(rctx.Routes, routePath)
+		if len(allowed) == 0 {
+			for _, candidate := range candidatePaths(routePath) {
+				if allowed = allowedMethods(rctx.Routes, candidate); len(allowed) > 0 {
+					rctx.RoutePath = candidate
+					break
+				}
+			}
+		}
+
+		if r.Method == http.MethodOptions && len(allowed) > 0 && r.Header.Get("Access-Control-Request-Method") == "" {
+			w.Header().Set("Allow", strings.Join(append(allowed, http.MethodOptions), ", "))
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// candidatePaths returns the cleaned path, then its lower-case form,
+// skipping any that equal p.
+func candidatePaths(p string) []string {
+	cleaned := path.Clean("/" + p)
+	var candidates []string
+	for _, c := range []string{cleaned, strings.ToLower(cleaned)} {
+		if c != p && (len(candidates) == 0 || candidates[len(candidates)-1] != c) {
+			candidates = append(candidates, c)
+		}
+	}
+	return candidates
+}
+
+// allowedMethods lists the methods routes has a handler for at p.
+func allowedMethods(routes chi.Routes, p string) []string {
+	var allowed []string
+	for _, method := range routeMethods {
+		if routes.Find(chi.NewRouteContext(), method, p) != "" {
+			allowed = append(allowed, method)
+		}
+	}
+	return allowed
+}
@@ -0,0 +1,51 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestNormalizePaths(t *testing.T) {
+	r := chi.NewRouter()
+	r.Use(normalizePaths)
+	r.Route("/todos", func(r chi.Router) {
+		r.Get("/", func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("list")) })
+		r.Get("/{id}", func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("todo " + chi.URLParam(r, "id"))) })
+		r.Delete("/{id}", func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusNoContent) })
+	})
+	r.Get("/feeds/{token}/today.xml", func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("feed " + chi.URLParam(r, "token"))) })
+
+	cases := []struct {
+		method, path string
+		wantCode     int
+		wantBody     string
+	}{
+		{"GET", "/todos", 200, "list"},
+		{"GET", "/todos/", 200, "list"},
+		{"GET", "/todos/7/", 200, "todo 7"},
+		{"GET", "//todos//7", 200, "todo 7"},
+		{"GET", "/TODOS/7", 200, "todo 7"},
+		// Parameters keep their case when the path already matches
+		{"GET", "/feeds/AbC/today.xml", 200, "feed AbC"},
+		{"GET", "/nope", 404, ""},
+	}
+	for _, tc := range cases {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(tc.method, tc.path, nil))
+		if rec.Code != tc.wantCode {
+			t.Errorf("%s %s = %d, want %d", tc.method, tc.path, rec.Code, tc.wantCode)
+		}
+		if tc.wantBody != "" && rec.Body.String() != tc.wantBody {
+			t.Errorf("%s %s body = %q, want %q", tc.method, tc.path, rec.Body.String(), tc.wantBody)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest("OPTIONS", "/todos/7/", nil))
+	if rec.Code != http.StatusNoContent || rec.Header().Get("Allow") != "GET, DELETE, OPTIONS" {
+		t.Errorf("OPTIONS = %d with Allow %q, want 204 with GET, DELETE, OPTIONS", rec.Code, rec.Header().Get("Allow"))
+	}
+}
@@ -0,0 +1,40 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/Tomlord1122/todo-backend/internal/service"
+)
+
+// createNotionExportHandler serves POST /export/notion. The export runs in
+// the background; poll GET /export/notion/{id} for progress.
+func (s *Server) createNotionExportHandler(w http.ResponseWriter, r *http.Request) {
+	var req service.CreateNotionExportRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+	req.UserID = sessionUserFrom(r)
+
+	export, err := s.notionExportService.Create(r.Context(), req)
+	if err != nil {
+		respondWithServiceError(w, r, err, "CreateNotionExport", "Failed to create export")
+		return
+	}
+
+	respondWithJSON(w, http.StatusAccepted, export)
+}
+
+func (s *Server) getNotionExportHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseIDParam(w, r, "id", "export")
+	if !ok {
+		return
+	}
+
+	export, err := s.notionExportService.Get(r.Context(), id)
+	if err != nil {
+		respondWithServiceError(w, r, err, "GetNotionExport", "Failed to retrieve export")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, export)
+}
@@ -0,0 +1,101 @@
+package server
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/Tomlord1122/todo-backend/internal/service"
+)
+
+func (s *Server) beginPasskeyLoginHandler(w http.ResponseWriter, r *http.Request) {
+	options, err := s.passkeyService.BeginLogin(r.Context())
+	if err != nil {
+		respondWithServiceError(w, r, err, "BeginLogin", "Failed to start passkey login")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, options)
+}
+
+func (s *Server) finishPasskeyLoginHandler(w http.ResponseWriter, r *http.Request) {
+	var req service.FinishPasskeyLoginRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+
+	session, err := s.passkeyService.FinishLogin(r.Context(), req, time.Now())
+	if err != nil {
+		respondWithServiceError(w, r, err, "FinishLogin", "Failed to sign in")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, session)
+}
+
+func (s *Server) beginPasskeyRegistrationHandler(w http.ResponseWriter, r *http.Request) {
+	options, err := s.passkeyService.BeginRegistration(r.Context(), sessionUserFrom(r))
+	if err != nil {
+		respondWithServiceError(w, r, err, "BeginRegistration", "Failed to start passkey registration")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, options)
+}
+
+func (s *Server) finishPasskeyRegistrationHandler(w http.ResponseWriter, r *http.Request) {
+	var req service.FinishPasskeyRegistrationRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+
+	passkey, err := s.passkeyService.FinishRegistration(r.Context(), sessionUserFrom(r), req)
+	if err != nil {
+		respondWithServiceError(w, r, err, "FinishRegistration", "Failed to register passkey")
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, passkey)
+}
+
+func (s *Server) listPasskeysHandler(w http.ResponseWriter, r *http.Request) {
+	passkeys, err := s.passkeyService.ListPasskeys(r.Context(), sessionUserFrom(r))
+	if err != nil {
+		respondWithServiceError(w, r, err, "ListPasskeys", "Failed to retrieve passkeys")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, passkeys)
+}
+
+func (s *Server) updatePasskeyHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseIDParam(w, r, "id", "passkey")
+	if !ok {
+		return
+	}
+	var req service.UpdatePasskeyRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+
+	passkey, err := s.passkeyService.RenamePasskey(r.Context(), sessionUserFrom(r), id, req)
+	if err != nil {
+		respondWithServiceError(w, r, err, "RenamePasskey", "Failed to update passkey")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, passkey)
+}
+
+func (s *Server) deletePasskeyHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseIDParam(w, r, "id", "passkey")
+	if !ok {
+		return
+	}
+
+	if err := s.passkeyService.DeletePasskey(r.Context(), sessionUserFrom(r), id); err != nil {
+		respondWithServiceError(w, r, err, "DeletePasskey", "Failed to delete passkey")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,56 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+
+	"github.com/Tomlord1122/todo-backend/internal/jsonpatch"
+)
+
+// acceptPatch lists the patch formats PATCH /todos/{id} accepts.
+const acceptPatch = jsonpatch.MergePatchType + ", " + jsonpatch.PatchType
+
+// patchTodoHandler serves PATCH /todos/{id}. The body is a JSON Merge Patch
+// or a JSON Patch, as told by the Content-Type; other types get a 415 with
+// an Accept-Patch header listing the two. A JSON Patch whose test
+// operation fails is a 409, since the todo changed under the client.
+// Like PUT, it requires If-Match.
+func (s *Server) patchTodoHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseIDParam(w, r, "id", "todo")
+	if !ok {
+		return
+	}
+	version, ok := parseIfMatch(w, r)
+	if !ok {
+		return
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != jsonpatch.MergePatchType && mediaType != jsonpatch.PatchType {
+		w.Header().Set("Accept-Patch", acceptPatch)
+		respondWithError(w, r, http.StatusUnsupportedMediaType, "Content-Type must be one of "+acceptPatch)
+		return
+	}
+	patch, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxValidatedBody))
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			respondWithError(w, r, http.StatusRequestEntityTooLarge, fmt.Sprintf("Request body must not be larger than %d bytes", maxValidatedBody))
+		} else {
+			respondWithError(w, r, http.StatusBadRequest, "Failed to read request body")
+		}
+		return
+	}
+
+	todo, err := s.todoService.PatchTodo(r.Context(), id, version, mediaType, patch)
+	if err != nil {
+		respondWithServiceError(w, r, err, "PatchTodo", "Failed to update todo")
+		return
+	}
+
+	w.Header().Set("ETag", todoETag(todo.Version))
+	respondWithJSON(w, http.StatusOK, todo)
+}
@@ -0,0 +1,63 @@
+package server
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/Tomlord1122/todo-backend/internal/service"
+)
+
+// presenceHandlers serves the presence endpoints of a todo or list: PUT is
+// a heartbeat, DELETE leaves and GET lists who has it open.
+type presenceHandlers struct {
+	s    *Server
+	kind string
+}
+
+func (h presenceHandlers) heartbeat(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseIDParam(w, r, "id", h.kind)
+	if !ok {
+		return
+	}
+	var req service.PresenceRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+
+	viewers, err := h.s.presenceService.Heartbeat(r.Context(), sessionUserFrom(r), h.kind, id, req, time.Now())
+	if err != nil {
+		respondWithServiceError(w, r, err, "Heartbeat", "Failed to update presence")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, viewers)
+}
+
+func (h presenceHandlers) leave(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseIDParam(w, r, "id", h.kind)
+	if !ok {
+		return
+	}
+
+	if err := h.s.presenceService.Leave(r.Context(), sessionUserFrom(r), h.kind, id, time.Now()); err != nil {
+		respondWithServiceError(w, r, err, "Leave", "Failed to update presence")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h presenceHandlers) list(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseIDParam(w, r, "id", h.kind)
+	if !ok {
+		return
+	}
+
+	viewers, err := h.s.presenceService.List(r.Context(), sessionUserFrom(r), h.kind, id, time.Now())
+	if err != nil {
+		respondWithServiceError(w, r, err, "List", "Failed to retrieve presence")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, viewers)
+}
@@ -0,0 +1,32 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/Tomlord1122/todo-backend/internal/clientip"
+	"github.com/Tomlord1122/todo-backend/internal/ratelimit"
+)
+
+// limitRate rejects requests over their rate limit, see rateLimitBucket.
+// The credentials it checks are remembered for requireSession.
+func (s *Server) limitRate(next http.Handler) http.Handler {
+	limited := ratelimit.Middleware(s.rateLimitBucket)(next)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "" {
+			r = s.rememberSessionUser(r)
+		}
+		limited.ServeHTTP(w, r)
+	})
+}
+
+// rateLimitBucket counts signed-in users' requests per user against
+// userRateLimiter and everyone else's per client IP against rateLimiter.
+// Requests with invalid credentials count as anonymous, so guessing tokens
+// is limited like any other anonymous traffic.
+func (s *Server) rateLimitBucket(r *http.Request) (ratelimit.Limiter, string) {
+	if userID, err := s.sessionUser(r); err == nil && userID != 0 {
+		return s.userRateLimiter, strconv.FormatUint(uint64(userID), 10)
+	}
+	return s.rateLimiter, clientip.FromRequest(r)
+}
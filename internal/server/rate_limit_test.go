@@ -0,0 +1,68 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Tomlord1122/todo-backend/internal/ratelimit"
+	"github.com/Tomlord1122/todo-backend/internal/service"
+)
+
+// fakeSessions signs in user 7 with the token "good" and counts checks.
+type fakeSessions struct {
+	service.SessionService
+	checks int
+}
+
+func (f *fakeSessions) Authenticate(_ context.Context, token string, _ time.Time) (uint, error) {
+	f.checks++
+	if token == "good" {
+		return 7, nil
+	}
+	return 0, service.ErrUnauthenticated
+}
+
+func TestLimitRate(t *testing.T) {
+	sessions := &fakeSessions{}
+	s := &Server{
+		sessionService:  sessions,
+		rateLimiter:     ratelimit.NewMemory(ratelimit.Limit{Requests: 1, Period: time.Minute, Burst: 1}),
+		userRateLimiter: ratelimit.NewMemory(ratelimit.Limit{Requests: 3, Period: time.Minute, Burst: 3}),
+	}
+	handler := s.limitRate(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The credentials the limiter checked aren't checked again
+		_, _ = s.sessionUser(r)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	serve := func(token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/todos", nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := serve(""); rec.Code != http.StatusNoContent || rec.Header().Get("X-RateLimit-Limit") != "1" {
+		t.Fatalf("first anonymous request = %d with limit %q, want 204 with limit 1", rec.Code, rec.Header().Get("X-RateLimit-Limit"))
+	}
+	// Invalid credentials share the anonymous bucket of the client's IP
+	if rec := serve("bad"); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("request with a bad token = %d, want 429", rec.Code)
+	}
+	for i := range 3 {
+		if rec := serve("good"); rec.Code != http.StatusNoContent || rec.Header().Get("X-RateLimit-Limit") != "3" {
+			t.Fatalf("signed-in request %d = %d with limit %q, want 204 with limit 3", i, rec.Code, rec.Header().Get("X-RateLimit-Limit"))
+		}
+	}
+	if rec := serve("good"); rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") == "" {
+		t.Errorf("4th signed-in request = %d, want 429 with Retry-After", rec.Code)
+	}
+	if sessions.checks != 5 {
+		t.Errorf("credentials were checked %d times for 5 requests with credentials", sessions.checks)
+	}
+}
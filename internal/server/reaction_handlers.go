@@ -0,0 +1,70 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/Tomlord1122/todo-backend/internal/service"
+)
+
+func (s *Server) listReactionsHandler(w http.ResponseWriter, r *http.Request) {
+	todoID, ok := parseIDParam(w, r, "id", "todo")
+	if !ok {
+		return
+	}
+
+	summary, err := s.reactionService.List(r.Context(), todoID)
+	if err != nil {
+		respondWithServiceError(w, r, err, "List", "Failed to retrieve reactions")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, summary)
+}
+
+// addReactionHandler responds 201 when the reaction is new and 200 when the
+// user had already reacted with that emoji.
+func (s *Server) addReactionHandler(w http.ResponseWriter, r *http.Request) {
+	todoID, ok := parseIDParam(w, r, "id", "todo")
+	if !ok {
+		return
+	}
+
+	var req service.AddReactionRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+	req.UserID = sessionUserFrom(r)
+
+	summary, created, err := s.reactionService.Add(r.Context(), todoID, req)
+	if err != nil {
+		respondWithServiceError(w, r, err, "Add", "Failed to add reaction")
+		return
+	}
+
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	respondWithJSON(w, status, summary)
+}
+
+// removeReactionHandler serves DELETE /todos/{id}/reactions?emoji=.
+// The emoji is a query parameter since emoji are awkward in a path segment.
+func (s *Server) removeReactionHandler(w http.ResponseWriter, r *http.Request) {
+	todoID, ok := parseIDParam(w, r, "id", "todo")
+	if !ok {
+		return
+	}
+	emoji := r.URL.Query().Get("emoji")
+	if emoji == "" {
+		respondWithError(w, r, http.StatusBadRequest, "Missing emoji query parameter")
+		return
+	}
+
+	if err := s.reactionService.Remove(r.Context(), todoID, sessionUserFrom(r), emoji); err != nil {
+		respondWithServiceError(w, r, err, "Remove", "Failed to remove reaction")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,60 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/Tomlord1122/todo-backend/internal/service"
+)
+
+func (s *Server) listRemindersHandler(w http.ResponseWriter, r *http.Request) {
+	todoID, ok := parseIDParam(w, r, "id", "todo")
+	if !ok {
+		return
+	}
+
+	reminders, err := s.reminderService.List(r.Context(), todoID)
+	if err != nil {
+		respondWithServiceError(w, r, err, "ListReminders", "Failed to retrieve reminders")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, reminders)
+}
+
+func (s *Server) createReminderHandler(w http.ResponseWriter, r *http.Request) {
+	todoID, ok := parseIDParam(w, r, "id", "todo")
+	if !ok {
+		return
+	}
+
+	var req service.CreateReminderRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+
+	reminder, err := s.reminderService.Create(r.Context(), todoID, req)
+	if err != nil {
+		respondWithServiceError(w, r, err, "CreateReminder", "Failed to create reminder")
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, reminder)
+}
+
+func (s *Server) deleteReminderHandler(w http.ResponseWriter, r *http.Request) {
+	todoID, ok := parseIDParam(w, r, "id", "todo")
+	if !ok {
+		return
+	}
+	reminderID, ok := parseIDParam(w, r, "reminderID", "reminder")
+	if !ok {
+		return
+	}
+
+	if err := s.reminderService.Delete(r.Context(), todoID, reminderID); err != nil {
+		respondWithServiceError(w, r, err, "DeleteReminder", "Failed to delete reminder")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
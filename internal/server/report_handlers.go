@@ -0,0 +1,63 @@
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Tomlord1122/todo-backend/internal/logging"
+	"github.com/Tomlord1122/todo-backend/internal/report"
+)
+
+// weeklyReportHandler serves GET /reports/weekly?user_id=&format=pdf|md&week_of=YYYY-MM-DD&tz=
+func (s *Server) weeklyReportHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := parseOwnUserIDQuery(w, r)
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	format, err := report.ParseFormat(query.Get("format"))
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "Invalid format, expected pdf or md")
+		return
+	}
+
+	loc := time.Local
+	if tz := query.Get("tz"); tz != "" {
+		loc, err = time.LoadLocation(tz)
+		if err != nil {
+			respondWithError(w, r, http.StatusBadRequest, "Invalid tz parameter")
+			return
+		}
+	}
+
+	weekOf := time.Now().In(loc)
+	if raw := query.Get("week_of"); raw != "" {
+		weekOf, err = time.ParseInLocation("2006-01-02", raw, loc)
+		if err != nil {
+			respondWithError(w, r, http.StatusBadRequest, "Invalid week_of, expected YYYY-MM-DD")
+			return
+		}
+	}
+
+	rep, err := s.reportService.WeeklyReport(r.Context(), userID, weekOf)
+	if err != nil {
+		respondWithServiceError(w, r, err, "WeeklyReport", "Failed to generate report")
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := report.Write(&buf, rep, format); err != nil {
+		logging.FromContext(r.Context()).Error("Error rendering weekly report", "err", err)
+		respondWithError(w, r, http.StatusInternalServerError, "Failed to generate report")
+		return
+	}
+
+	filename := fmt.Sprintf("weekly-report-%s.%s", rep.PeriodStart.Format("2006-01-02"), format)
+	w.Header().Set("Content-Type", format.ContentType())
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(buf.Bytes())
+}
@@ -0,0 +1,90 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/Tomlord1122/todo-backend/internal/logging"
+	"github.com/Tomlord1122/todo-backend/internal/service"
+)
+
+func (s *Server) createReportScheduleHandler(w http.ResponseWriter, r *http.Request) {
+	var req service.CreateReportScheduleRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+	req.UserID = sessionUserFrom(r)
+
+	schedule, err := s.reportScheduleService.CreateSchedule(r.Context(), req)
+	if err != nil {
+		respondWithServiceError(w, r, err, "CreateSchedule", "Failed to create report schedule")
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, schedule)
+}
+
+func (s *Server) getReportSchedulesHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := parseOwnUserIDQuery(w, r)
+	if !ok {
+		return
+	}
+
+	schedules, err := s.reportScheduleService.GetSchedulesByUser(r.Context(), userID)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Error calling service", "operation", "GetSchedulesByUser", "err", err)
+		respondWithError(w, r, http.StatusInternalServerError, "Failed to retrieve report schedules")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, schedules)
+}
+
+func (s *Server) getReportScheduleByIDHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseIDParam(w, r, "id", "schedule")
+	if !ok {
+		return
+	}
+
+	schedule, err := s.reportScheduleService.GetScheduleByID(r.Context(), id)
+	if err != nil {
+		respondWithServiceError(w, r, err, "GetScheduleByID", "Failed to retrieve report schedule")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, schedule)
+}
+
+func (s *Server) updateReportScheduleHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseIDParam(w, r, "id", "schedule")
+	if !ok {
+		return
+	}
+
+	var req service.UpdateReportScheduleRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+
+	schedule, err := s.reportScheduleService.UpdateSchedule(r.Context(), id, req)
+	if err != nil {
+		respondWithServiceError(w, r, err, "UpdateSchedule", "Failed to update report schedule")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, schedule)
+}
+
+func (s *Server) deleteReportScheduleHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseIDParam(w, r, "id", "schedule")
+	if !ok {
+		return
+	}
+
+	err := s.reportScheduleService.DeleteSchedule(r.Context(), id)
+	if err != nil {
+		respondWithServiceError(w, r, err, "DeleteSchedule", "Failed to delete report schedule")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -5,111 +5,581 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/cors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/riandyrn/otelchi"
 
+	"github.com/Tomlord1122/todo-backend/internal/apispec"
+	"github.com/Tomlord1122/todo-backend/internal/authz"
+	"github.com/Tomlord1122/todo-backend/internal/health"
+	"github.com/Tomlord1122/todo-backend/internal/logging"
+	"github.com/Tomlord1122/todo-backend/internal/markdown"
+	"github.com/Tomlord1122/todo-backend/internal/problem"
+	"github.com/Tomlord1122/todo-backend/internal/requestid"
+	authorize "github.com/Tomlord1122/todo-backend/internal/server/middleware"
 	"github.com/Tomlord1122/todo-backend/internal/service"
+	"github.com/Tomlord1122/todo-backend/internal/tracing"
+)
+
+// logRequests gives every request a logger carrying its request ID, method
+// and route, and logs one line per request once it is served. Handlers
+// and services log through logging.FromContext; requireSession adds the
+// user.
+func logRequests(routes chi.Routes) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			// Routing happens later, match the route up front like otelchi
+			route := ""
+			if rctx := chi.NewRouteContext(); routes.Match(rctx, r.Method, r.URL.Path) {
+				route = rctx.RoutePattern()
+			}
+			ctx := logging.NewContext(r.Context(), slog.Default().With(
+				slog.String("request_id", requestid.FromContext(r.Context())),
+				slog.String("method", r.Method),
+				slog.String("route", route),
+			))
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			next.ServeHTTP(ww, r.WithContext(ctx))
+
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+			logging.FromContext(ctx).LogAttrs(ctx, slog.LevelInfo, "request",
+				slog.String("uri", r.URL.RequestURI()),
+				slog.String("remote_addr", r.RemoteAddr),
+				slog.Int("status", status),
+				slog.Int("bytes", ww.BytesWritten()),
+				slog.Duration("duration", time.Since(start)),
+			)
+		})
+	}
+}
+
+const (
+	// graphqlPath serves the GraphQL API
+	graphqlPath = "/graphql"
+	// websocketPath streams todo changes over a WebSocket
+	websocketPath = "/ws"
+	// gatewayPath serves the gRPC API as JSON/HTTP
+	gatewayPath = "/rpc"
 )
 
 func (s *Server) RegisterRoutes() http.Handler {
 	r := chi.NewRouter()
-	r.Use(middleware.Logger)
+	if s.clientIP != nil {
+		// Resolve the real client IP first so the access log and everything
+		// after it agree on who the client is
+		r.Use(s.clientIP.Middleware)
+	}
+	// Take the caller's X-Request-ID or make one up, and send it back
+	r.Use(requestid.Middleware)
+	// Start the request's span, continuing the caller's trace if there is
+	// one, and send its ID back in X-Trace-Id
+	r.Use(otelchi.Middleware(tracing.ServiceName, otelchi.WithChiRoutes(r),
+		otelchi.WithTraceResponseHeaders(otelchi.TraceHeaderConfig{})))
+	r.Use(logRequests(r))
 	r.Use(middleware.Recoverer)
+	// During shutdown new requests, /readyz included, get 503 so load
+	// balancers move on; the liveness probe keeps passing
+	r.Use(s.drain.Middleware("/healthz"))
+	if s.rateLimiter != nil || s.userRateLimiter != nil {
+		r.Use(s.limitRate)
+	}
+	r.Use(normalizePaths)
+	r.Use(s.envelopeResponses)
+	// The admin API stays writable so read-only mode can be switched off;
+	// GraphQL queries are POSTs too, so it rejects mutations itself
+	r.Use(s.readOnly.Middleware("/admin/", apispec.BasePath+"/admin/", graphqlPath))
 
 	r.Use(cors.Handler(cors.Options{
-		AllowedOrigins:   []string{"https://*", "http://*"},
+		AllowedOrigins:   s.allowedOrigins,
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS", "PATCH"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
-		ExposedHeaders:   []string{"Link"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "Idempotency-Key", "If-Match", "If-Modified-Since", "If-None-Match", "X-CSRF-Token", "X-Request-ID"},
+		ExposedHeaders:   []string{"API-Version", "Deprecation", "ETag", "Idempotent-Replayed", "Link", "Retry-After", "X-RateLimit-Limit", "X-RateLimit-Remaining", "X-RateLimit-Reset", "X-Request-ID", "X-Total-Count", "X-Trace-Id", "X-Trace-Sampled"},
 		AllowCredentials: true,
 		MaxAge:           300,
 	}))
+	r.Use(validateRequests)
+
+	if s.web != nil {
+		// The bundled UI owns / and any path the API doesn't
+		r.Get("/", s.web.ServeHTTP)
+		r.NotFound(s.web.ServeHTTP)
+	} else {
+		r.Get("/", s.HelloWorldHandler)
+	}
+
+	// Liveness says the process is running; readiness checks its
+	// dependencies, so a database outage takes the instance out of the load
+	// balancer without getting it restarted
+	r.With(cacheControl(cacheNoStore)).Get("/healthz", s.healthzHandler)
+	r.With(cacheControl(cacheNoStore)).Get("/readyz", s.readyzHandler)
+
+	if s.metrics != nil {
+		r.Handle("/metrics", promhttp.HandlerFor(s.metrics, promhttp.HandlerOpts{}))
+	}
 
-	r.Get("/", s.HelloWorldHandler)
+	// GraphQL has its own, additive, schema evolution instead of versions
+	if s.graphql != nil {
+		r.With(s.requireSession).Handle(graphqlPath, s.graphql)
+	}
+	if s.events != nil {
+		r.With(tokenFromQuery, s.requireSession).Get(websocketPath, s.websocketHandler)
+	}
+	// The gateway's calls are authenticated by the gRPC server
+	if s.gateway != nil {
+		r.Mount(gatewayPath, http.StripPrefix(gatewayPath, s.gateway))
+	}
 
-	r.Get("/health", s.healthHandler)
+	// The API is served under /api/v1. The unversioned paths it had before
+	// stay as deprecated aliases while clients migrate
+	r.Route("/api", func(r chi.Router) {
+		r.With(withAPIVersion(apispec.Version)).Route("/"+apispec.Version, s.apiRoutes)
+		r.HandleFunc("/{version}/*", unknownAPIVersionHandler)
+	})
+	if s.legacyRoutes {
+		r.Group(func(r chi.Router) {
+			r.Use(deprecatedAlias)
+			s.apiRoutes(r)
+		})
+	}
 
+	return r
+}
+
+// apiRoutes registers the API on r, relative to its base path.
+func (s *Server) apiRoutes(r chi.Router) {
+	r.Use(cacheControl(cachePrivate))
+	// The event stream takes its token from the URL too, which the other
+	// todo routes don't
+	if s.events != nil {
+		r.With(tokenFromQuery, s.requireSession).Get("/todos/events", s.todoEventsHandler)
+	}
+	// Todos belong to the signed-in user; following and presence check who
+	// may see the todo themselves
 	r.Route("/todos", func(r chi.Router) {
-		r.Post("/", s.createTodoHandler)
+		r.Use(s.requireSession)
+		r.With(s.idempotent).Post("/", s.createTodoHandler)
+		r.Post("/suggest", s.suggestTodoHandler)
 		r.Get("/", s.getAllTodosHandler)
-		r.Get("/{id}", s.getTodoByIDHandler)
-		r.Put("/{id}", s.updateTodoHandler)
-		r.Delete("/{id}", s.deleteTodoHandler)
+		r.Get("/search", s.searchTodosHandler)
+		r.Get("/nearby", s.nearbyTodosHandler)
+		r.Get("/overdue", s.overdueTodosHandler)
+		r.Get("/trash", s.listTrashHandler)
+		r.Post("/bulk", s.bulkCreateTodosHandler)
+		r.Patch("/bulk", s.bulkUpdateTodosHandler)
+		r.Delete("/bulk", s.bulkDeleteTodosHandler)
+		r.Post("/{id}/follow", s.followTodoHandler)
+		r.Delete("/{id}/follow", s.unfollowTodoHandler)
+		todoPresence := presenceHandlers{s: s, kind: service.PresenceTodo}
+		r.Get("/{id}/presence", todoPresence.list)
+		r.Put("/{id}/presence", todoPresence.heartbeat)
+		r.Delete("/{id}/presence", todoPresence.leave)
+
+		r.Group(func(r chi.Router) {
+			r.Use(s.requireTrashedTodoOwner)
+			r.Post("/{id}/restore", s.restoreTodoHandler)
+			r.Delete("/{id}/purge", s.purgeTodoHandler)
+		})
+		r.Group(func(r chi.Router) {
+			r.Use(s.requireTodoOwner)
+			r.Get("/{id}", s.getTodoByIDHandler)
+			r.Put("/{id}", s.updateTodoHandler)
+			r.Patch("/{id}", s.patchTodoHandler)
+			r.Delete("/{id}", s.deleteTodoHandler)
+
+			r.Get("/{id}/checklist", s.listChecklistHandler)
+			r.Post("/{id}/checklist", s.addChecklistItemHandler)
+			r.Put("/{id}/checklist/{itemID}", s.updateChecklistItemHandler)
+			r.Delete("/{id}/checklist/{itemID}", s.deleteChecklistItemHandler)
+			r.Get("/{id}/subtasks", s.listSubtasksHandler)
+			r.Post("/{id}/subtasks", s.createSubtaskHandler)
+			r.Patch("/{id}/subtasks/{subtaskID}", s.updateSubtaskHandler)
+			r.Delete("/{id}/subtasks/{subtaskID}", s.deleteSubtaskHandler)
+			r.Get("/{id}/reminders", s.listRemindersHandler)
+			r.Post("/{id}/reminders", s.createReminderHandler)
+			r.Delete("/{id}/reminders/{reminderID}", s.deleteReminderHandler)
+			r.Get("/{id}/reactions", s.listReactionsHandler)
+			r.Post("/{id}/reactions", s.addReactionHandler)
+			r.Delete("/{id}/reactions", s.removeReactionHandler)
+			r.Get("/{id}/activity", s.listActivityHandler)
+			r.Get("/{id}/attachments", s.listAttachmentsHandler)
+			r.Post("/{id}/attachments/presign", s.presignAttachmentHandler)
+			r.Post("/{id}/attachments/{attachmentID}/confirm", s.confirmAttachmentHandler)
+		})
+	})
+
+	// Attachments belong to their todo's owner
+	r.Route("/attachments", func(r chi.Router) {
+		r.Use(s.requireSession)
+		r.Group(func(r chi.Router) {
+			r.Use(s.requireAttachmentOwner)
+			r.Get("/{id}/download", s.downloadAttachmentHandler)
+			r.Get("/{id}/thumbnail", s.attachmentThumbnailHandler)
+			r.Delete("/{id}", s.deleteAttachmentHandler)
+		})
+	})
+
+	r.Group(func(r chi.Router) {
+		r.Use(s.requireSession, authorize.Authorize(authz.ManageUsers))
+		r.Get("/users", s.listUsersHandler)
+		r.Put("/users/{id}/role", s.updateUserRoleHandler)
+	})
+	r.Group(func(r chi.Router) {
+		r.Use(s.requireSession, s.requireSelf)
+		r.Get("/users/{id}/preferences", s.getPreferencesHandler)
+		r.Put("/users/{id}/preferences", s.updatePreferencesHandler)
+		r.Post("/users/{id}/google-calendar", s.connectCalendarHandler)
+		r.Get("/users/{id}/google-calendar", s.getCalendarHandler)
+		r.Delete("/users/{id}/google-calendar", s.disconnectCalendarHandler)
+	})
+
+	// Signing in is public. So are the feeds, triggering inbound hooks and
+	// the GitHub webhook further down, which authenticate with their own
+	// secrets, and /dev/fixtures, which only development servers have.
+	// Every other route takes a session.
+	r.Route("/auth", func(r chi.Router) {
+		r.Use(cacheControl(cacheNoStore))
+		r.Post("/register", s.registerHandler)
+		r.Post("/login", s.loginHandler)
+		r.Post("/passkeys/login/begin", s.beginPasskeyLoginHandler)
+		r.Post("/passkeys/login/finish", s.finishPasskeyLoginHandler)
+		r.Post("/logout", s.logoutHandler)
+		// The providers only change with the configuration
+		r.With(cacheControl("public, max-age=300")).Get("/oidc/providers", s.listSSOProvidersHandler)
+		r.Post("/oidc/{provider}/begin", s.beginSSOLoginHandler)
+		r.Post("/oidc/{provider}/callback", s.finishSSOLoginHandler)
+	})
+	r.Route("/me/passkeys", func(r chi.Router) {
+		r.Use(cacheControl(cacheNoStore), s.requireSession)
+		r.Post("/register/begin", s.beginPasskeyRegistrationHandler)
+		r.Post("/register/finish", s.finishPasskeyRegistrationHandler)
+		r.Get("/", s.listPasskeysHandler)
+		r.Put("/{id}", s.updatePasskeyHandler)
+		r.Delete("/{id}", s.deletePasskeyHandler)
+	})
+	r.With(s.requireSession).Get("/me/following", s.listFollowingHandler)
+	r.Route("/apikeys", func(r chi.Router) {
+		r.Use(s.requireSession, cacheControl(cacheNoStore))
+		r.Post("/", s.createAPIKeyHandler)
+		r.Get("/", s.listAPIKeysHandler)
+		r.Delete("/{id}", s.revokeAPIKeyHandler)
+	})
+	r.Route("/webhooks", func(r chi.Router) {
+		r.Use(s.requireSession, cacheControl(cacheNoStore))
+		r.Post("/", s.createWebhookHandler)
+		r.Get("/", s.listWebhooksHandler)
+		r.Get("/{id}", s.getWebhookHandler)
+		r.Put("/{id}", s.updateWebhookHandler)
+		r.Delete("/{id}", s.deleteWebhookHandler)
+		r.Get("/{id}/deliveries", s.listWebhookDeliveriesHandler)
+	})
+	r.Route("/tags", func(r chi.Router) {
+		r.Use(s.requireSession)
+		r.Post("/", s.createTagHandler)
+		r.Get("/", s.listTagsHandler)
+		r.Get("/{id}", s.getTagHandler)
+		r.Put("/{id}", s.updateTagHandler)
+		r.Delete("/{id}", s.deleteTagHandler)
+	})
+	r.Route("/me/identities", func(r chi.Router) {
+		r.Use(s.requireSession)
+		r.Get("/", s.listIdentitiesHandler)
+		r.Delete("/{id}", s.deleteIdentityHandler)
+	})
+
+	// Lists belong to the signed-in user like todos; presence checks who may
+	// see the list itself
+	r.Route("/lists", func(r chi.Router) {
+		r.Use(s.requireSession)
+		r.Post("/", s.createListHandler)
+		r.Get("/", s.getListsHandler)
+		listPresence := presenceHandlers{s: s, kind: service.PresenceList}
+		r.Get("/{id}/presence", listPresence.list)
+		r.Put("/{id}/presence", listPresence.heartbeat)
+		r.Delete("/{id}/presence", listPresence.leave)
+		r.Group(func(r chi.Router) {
+			r.Use(s.requireListOwner)
+			r.Get("/{id}", s.getListByIDHandler)
+			r.Put("/{id}", s.updateListHandler)
+			r.Delete("/{id}", s.deleteListHandler)
+			r.Get("/{id}/burndown", s.listBurndownHandler)
+			r.Get("/{id}/timeline", s.listTimelineHandler)
+			r.Post("/{id}/github", s.linkGitHubHandler)
+			r.Get("/{id}/github", s.getGitHubLinkHandler)
+			r.Delete("/{id}/github", s.unlinkGitHubHandler)
+		})
+	})
+
+	r.Route("/focus", func(r chi.Router) {
+		r.Use(s.requireSession)
+		r.Post("/start", s.startFocusHandler)
+		r.Post("/stop", s.stopFocusHandler)
+		r.Get("/current", s.currentFocusHandler)
+	})
+	r.With(s.requireSession, authorize.Authorize(authz.ViewStats)).Get("/stats", s.statsHandler)
+
+	r.Route("/reports", func(r chi.Router) {
+		r.Use(s.requireSession)
+		r.Get("/weekly", s.weeklyReportHandler)
+		r.Route("/schedules", func(r chi.Router) {
+			r.Post("/", s.createReportScheduleHandler)
+			r.Get("/", s.getReportSchedulesHandler)
+			r.Group(func(r chi.Router) {
+				r.Use(s.requireReportScheduleOwner)
+				r.Get("/{id}", s.getReportScheduleByIDHandler)
+				r.Put("/{id}", s.updateReportScheduleHandler)
+				r.Delete("/{id}", s.deleteReportScheduleHandler)
+			})
+		})
+	})
+
+	r.Route("/admin", func(r chi.Router) {
+		r.Use(s.requireAdmin, cacheControl(cacheNoStore))
+		if s.db != nil {
+			r.Get("/slow-queries", s.slowQueriesHandler)
+			r.Get("/db-settings", s.getDBSettingsHandler)
+			r.Put("/db-settings", s.setDBSettingsHandler)
+		}
+		r.Get("/read-only", s.getReadOnlyHandler)
+		r.Put("/read-only", s.setReadOnlyHandler)
+		r.Get("/audit", s.adminAuditHandler)
+	})
+
+	if s.fixtureService != nil {
+		r.Post("/dev/fixtures", s.loadFixturesHandler)
+	}
+
+	// GitHub signs its deliveries instead of signing in
+	r.Post("/integrations/github/webhook", s.gitHubWebhookHandler)
+
+	r.Group(func(r chi.Router) {
+		r.Use(s.requireSession)
+		r.Post("/export/notion", s.createNotionExportHandler)
+		r.With(s.requireNotionExportOwner).Get("/export/notion/{id}", s.getNotionExportHandler)
+		r.Post("/imports", s.createImportHandler)
+		r.With(s.requireImportOwner).Get("/imports/{id}", s.getImportHandler)
+	})
+
+	// Automation tools trigger a hook with the secret token in its URL;
+	// managing hooks takes a session
+	r.Route("/hooks/inbound", func(r chi.Router) {
+		r.Use(cacheControl(cacheNoStore))
+		r.Group(func(r chi.Router) {
+			r.Use(s.requireSession)
+			r.Post("/", s.createInboundHookHandler)
+			r.Delete("/{token}", s.revokeInboundHookHandler)
+		})
+		r.Post("/{token}", s.triggerInboundHookHandler)
+	})
+
+	// Feed readers can't sign in, so the feeds authenticate with the
+	// secret token in their URL; managing tokens takes a session
+	r.Route("/feeds", func(r chi.Router) {
+		r.Group(func(r chi.Router) {
+			r.Use(s.requireSession, cacheControl(cacheNoStore))
+			r.Post("/tokens", s.createFeedTokenHandler)
+			r.Delete("/tokens/{token}", s.revokeFeedTokenHandler)
+		})
+		r.Get("/{token}/today.xml", s.todayRSSFeedHandler)
+		r.Get("/{token}/today.atom", s.todayAtomFeedHandler)
 	})
 
-	return r
 }
 
 func (s *Server) HelloWorldHandler(w http.ResponseWriter, r *http.Request) {
 	respondWithJSON(w, http.StatusOK, map[string]string{"message": "Hello World"})
 }
 
-func (s *Server) healthHandler(w http.ResponseWriter, r *http.Request) {
-	healthStats := s.db.Health()
-	if status, ok := healthStats["status"]; ok && status == "down" {
-		respondWithJSON(w, http.StatusServiceUnavailable, healthStats)
-		return
+// healthzHandler reports that the process is up and serving requests. It
+// checks no dependency, so it fails only when the process is stuck.
+func (s *Server) healthzHandler(w http.ResponseWriter, r *http.Request) {
+	respondWithJSON(w, http.StatusOK, map[string]string{"status": string(health.StatusUp)})
+}
+
+// readyzHandler reports whether the instance can serve traffic, with the
+// status and latency of each dependency. Only a down required dependency
+// makes it 503; a degraded instance keeps receiving traffic.
+func (s *Server) readyzHandler(w http.ResponseWriter, r *http.Request) {
+	report := s.health.Run(r.Context())
+	status := http.StatusOK
+	if report.Status == health.StatusDown {
+		status = http.StatusServiceUnavailable
 	}
-	respondWithJSON(w, http.StatusOK, healthStats)
+	respondWithJSON(w, status, report)
 }
 
 func (s *Server) createTodoHandler(w http.ResponseWriter, r *http.Request) {
 	var req service.CreateTodoRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+	req.UserID = sessionUserFrom(r)
 
-	decoder := json.NewDecoder(r.Body)
-	decoder.DisallowUnknownFields()
-	err := decoder.Decode(&req)
+	todoResp, err := s.todoService.CreateTodo(r.Context(), req)
 	if err != nil {
-		var syntaxError *json.SyntaxError
-		var unmarshalTypeError *json.UnmarshalTypeError
-		if errors.As(err, &syntaxError) {
-			msg := fmt.Sprintf("Request body contains badly-formed JSON (at position %d)", syntaxError.Offset)
-			respondWithError(w, http.StatusBadRequest, msg)
-		} else if errors.Is(err, io.ErrUnexpectedEOF) {
-			msg := "Request body contains badly-formed JSON"
-			respondWithError(w, http.StatusBadRequest, msg)
-		} else if errors.As(err, &unmarshalTypeError) {
-			msg := fmt.Sprintf("Request body contains an invalid value for the %q field (at position %d)", unmarshalTypeError.Field, unmarshalTypeError.Offset)
-			respondWithError(w, http.StatusBadRequest, msg)
-		} else if strings.HasPrefix(err.Error(), "json: unknown field ") {
-			fieldName := strings.TrimPrefix(err.Error(), "json: unknown field ")
-			msg := fmt.Sprintf("Request body contains unknown field %s", fieldName)
-			respondWithError(w, http.StatusBadRequest, msg)
-		} else if errors.Is(err, io.EOF) {
-			msg := "Request body must not be empty"
-			respondWithError(w, http.StatusBadRequest, msg)
-		} else {
-			log.Printf("Error decoding create todo request: %v", err)
-			respondWithError(w, http.StatusInternalServerError, "Error processing request")
+		respondWithServiceError(w, r, err, "CreateTodo", "Failed to create todo")
+		return
+	}
+
+	w.Header().Set("ETag", todoETag(todoResp.Version))
+	respondWithJSON(w, http.StatusCreated, todoResp)
+}
+
+func (s *Server) getAllTodosHandler(w http.ResponseWriter, r *http.Request) {
+	page, ok := parsePageQuery(w, r)
+	if !ok {
+		return
+	}
+	deletedSince, ok := parseDeletedSinceQuery(w, r)
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	filter := service.TodoFilter{DeletedSince: deletedSince, Sort: query.Get("sort")}
+	if v := query.Get("completed"); v != "" {
+		completed, err := strconv.ParseBool(v)
+		if err != nil {
+			respondWithError(w, r, http.StatusBadRequest, "Invalid completed query parameter, expected true or false")
+			return
 		}
+		filter.Completed = &completed
+	}
+	if filter.DueBefore, ok = parseTimeQuery(w, r, "due_before"); !ok {
+		return
+	}
+	if filter.DueAfter, ok = parseTimeQuery(w, r, "due_after"); !ok {
+		return
+	}
+	if v := query.Get("overdue"); v != "" {
+		overdue, err := strconv.ParseBool(v)
+		if err != nil {
+			respondWithError(w, r, http.StatusBadRequest, "Invalid overdue query parameter, expected true or false")
+			return
+		}
+		filter.Overdue = overdue
+	}
+	if v := query.Get("tags"); v != "" {
+		filter.Tags = strings.Split(v, ",")
+	}
+	userID, ok := parseOwnUserIDQuery(w, r)
+	if !ok {
 		return
 	}
+	filter.UserID = &userID
 
-	todoResp, err := s.todoService.CreateTodo(r.Context(), req)
+	todos, pageInfo, err := s.todoService.GetAllTodos(r.Context(), page, filter)
 	if err != nil {
-		if err.Error() == "title cannot be empty" {
-			respondWithError(w, http.StatusBadRequest, err.Error())
-		} else {
-			log.Printf("Error calling CreateTodo service: %v", err)
-			respondWithError(w, http.StatusInternalServerError, "Failed to create todo")
+		respondWithServiceError(w, r, err, "GetAllTodos", "Failed to retrieve todos")
+		return
+	}
+
+	respondWithPage(w, r, todos, pageInfo)
+}
+
+// searchTodosHandler serves GET /todos/search?q=&fuzzy=true&threshold=0.4&limit=&offset=
+func (s *Server) searchTodosHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	req := service.SearchTodosRequest{Query: query.Get("q")}
+	if v := query.Get("fuzzy"); v != "" {
+		fuzzy, err := strconv.ParseBool(v)
+		if err != nil {
+			respondWithError(w, r, http.StatusBadRequest, "Invalid fuzzy query parameter, expected true or false")
+			return
 		}
+		req.Fuzzy = fuzzy
+	}
+	if v := query.Get("threshold"); v != "" {
+		threshold, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			respondWithError(w, r, http.StatusBadRequest, "Invalid threshold query parameter, expected a number between 0 and 1")
+			return
+		}
+		req.Threshold = &threshold
+	}
+	page, ok := parsePageQuery(w, r)
+	if !ok {
 		return
 	}
+	req.Page = page
+	req.UserID = sessionUserFrom(r)
 
-	respondWithJSON(w, http.StatusCreated, todoResp)
+	results, pageInfo, err := s.todoService.SearchTodos(r.Context(), req)
+	if err != nil {
+		respondWithServiceError(w, r, err, "SearchTodos", "Failed to search todos")
+		return
+	}
+
+	respondWithPage(w, r, results, pageInfo)
 }
 
-func (s *Server) getAllTodosHandler(w http.ResponseWriter, r *http.Request) {
-	todos, err := s.todoService.GetAllTodos(r.Context())
+// nearbyTodosHandler serves GET /todos/nearby?lat=&lng=&radius=&limit=.
+// Without radius, todos match when the point is within their own radius.
+func (s *Server) nearbyTodosHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	var req service.NearbyTodosRequest
+	for _, param := range []struct {
+		name     string
+		dst      *float64
+		required bool
+	}{
+		{"lat", &req.Latitude, true},
+		{"lng", &req.Longitude, true},
+		{"radius", &req.RadiusMeters, false},
+	} {
+		v := query.Get(param.name)
+		if v == "" {
+			if param.required {
+				respondWithError(w, r, http.StatusBadRequest, fmt.Sprintf("Missing %s query parameter", param.name))
+				return
+			}
+			continue
+		}
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			respondWithError(w, r, http.StatusBadRequest, fmt.Sprintf("Invalid %s query parameter, expected a number", param.name))
+			return
+		}
+		*param.dst = f
+	}
+	page, ok := parsePageQuery(w, r)
+	if !ok {
+		return
+	}
+	req.Limit = page.Limit
+	req.UserID = sessionUserFrom(r)
+
+	results, err := s.todoService.FindNearbyTodos(r.Context(), req)
+	if err != nil {
+		respondWithServiceError(w, r, err, "FindNearbyTodos", "Failed to find nearby todos")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, results)
+}
+
+// overdueTodosHandler serves GET /todos/overdue?user_id=&tz=. Days are
+// counted in the user's timezone preference unless tz overrides it.
+func (s *Server) overdueTodosHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := parseOwnUserIDQuery(w, r)
+	if !ok {
+		return
+	}
+
+	todos, err := s.overdueService.ListOverdue(r.Context(), userID, r.URL.Query().Get("tz"), time.Now())
 	if err != nil {
-		log.Printf("Error calling GetAllTodos service: %v", err)
-		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve todos")
+		respondWithServiceError(w, r, err, "ListOverdue", "Failed to retrieve overdue todos")
 		return
 	}
 
@@ -120,29 +590,41 @@ func (s *Server) getTodoByIDHandler(w http.ResponseWriter, r *http.Request) {
 	idStr := chi.URLParam(r, "id")
 	id, err := strconv.ParseUint(idStr, 10, 64)
 	if err != nil || id == 0 {
-		respondWithError(w, http.StatusBadRequest, "Invalid todo ID provided")
+		respondWithError(w, r, http.StatusBadRequest, "Invalid todo ID provided")
+		return
+	}
+	render := r.URL.Query().Get("render")
+	if render != "" && render != "html" {
+		respondWithError(w, r, http.StatusBadRequest, "Invalid render query parameter, expected html")
 		return
 	}
 
 	todo, err := s.todoService.GetTodoByID(r.Context(), uint(id))
 	if err != nil {
-		if strings.Contains(err.Error(), "not found") {
-			respondWithError(w, http.StatusNotFound, err.Error())
-		} else {
-			log.Printf("Error calling GetTodoByID service: %v", err)
-			respondWithError(w, http.StatusInternalServerError, "Failed to retrieve todo")
-		}
+		respondWithServiceError(w, r, err, "GetTodoByID", "Failed to retrieve todo")
 		return
 	}
-
-	respondWithJSON(w, http.StatusOK, todo)
+	// Subtask changes move the todo to a new version too, so the version
+	// stands for everything in the response
+	etag := todoETag(todo.Version)
+	if render == "html" {
+		todo.DescriptionHTML = markdown.HTML(todo.Description)
+		etag = renderedTodoETag(todo.Version, render)
+	}
+	updatedAt, _ := time.Parse(time.RFC3339, todo.UpdatedAt)
+	respondWithValidatedJSON(w, r, todo, etag, updatedAt)
 }
 
 func (s *Server) updateTodoHandler(w http.ResponseWriter, r *http.Request) {
 	idStr := chi.URLParam(r, "id")
 	id, err := strconv.ParseUint(idStr, 10, 64)
 	if err != nil || id == 0 {
-		respondWithError(w, http.StatusBadRequest, "Invalid todo ID provided")
+		respondWithError(w, r, http.StatusBadRequest, "Invalid todo ID provided")
+		return
+	}
+
+	version, ok := parseIfMatch(w, r)
+	if !ok {
 		return
 	}
 
@@ -151,22 +633,19 @@ func (s *Server) updateTodoHandler(w http.ResponseWriter, r *http.Request) {
 	decoder.DisallowUnknownFields()
 	err = decoder.Decode(&req)
 	if err != nil {
-		log.Printf("Error decoding update todo request: %v", err)
-		respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		logging.FromContext(r.Context()).Info("Error decoding update todo request", "err", err)
+		respondWithError(w, r, http.StatusBadRequest, "Invalid request body")
 		return
 	}
+	req.Version = version
 
 	updatedTodo, err := s.todoService.UpdateTodo(r.Context(), uint(id), req)
 	if err != nil {
-		if strings.Contains(err.Error(), "not found") {
-			respondWithError(w, http.StatusNotFound, err.Error())
-		} else {
-			log.Printf("Error calling UpdateTodo service: %v", err)
-			respondWithError(w, http.StatusInternalServerError, "Failed to update todo")
-		}
+		respondWithServiceError(w, r, err, "UpdateTodo", "Failed to update todo")
 		return
 	}
 
+	w.Header().Set("ETag", todoETag(updatedTodo.Version))
 	respondWithJSON(w, http.StatusOK, updatedTodo)
 }
 
@@ -174,35 +653,210 @@ func (s *Server) deleteTodoHandler(w http.ResponseWriter, r *http.Request) {
 	idStr := chi.URLParam(r, "id")
 	id, err := strconv.ParseUint(idStr, 10, 64)
 	if err != nil || id == 0 {
-		respondWithError(w, http.StatusBadRequest, "Invalid todo ID provided")
+		respondWithError(w, r, http.StatusBadRequest, "Invalid todo ID provided")
 		return
 	}
 
-	err = s.todoService.DeleteTodo(r.Context(), uint(id))
+	version, ok := parseIfMatch(w, r)
+	if !ok {
+		return
+	}
+
+	err = s.todoService.DeleteTodo(r.Context(), uint(id), version)
 	if err != nil {
-		if strings.Contains(err.Error(), "not found") {
-			respondWithError(w, http.StatusNotFound, err.Error())
-		} else {
-			log.Printf("Error calling DeleteTodo service: %v", err)
-			respondWithError(w, http.StatusInternalServerError, "Failed to delete todo")
-		}
+		respondWithServiceError(w, r, err, "DeleteTodo", "Failed to delete todo")
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
 
-func respondWithError(w http.ResponseWriter, code int, message string) {
-	respondWithJSON(w, code, map[string]string{"error": message})
+// parseIDParam parses a positive numeric URL parameter. On failure it writes
+// a 400 response naming the resource and returns false.
+func parseIDParam(w http.ResponseWriter, r *http.Request, param, resource string) (uint, bool) {
+	id, err := strconv.ParseUint(chi.URLParam(r, param), 10, 64)
+	if err != nil || id == 0 {
+		respondWithError(w, r, http.StatusBadRequest, fmt.Sprintf("Invalid %s ID provided", resource))
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// parseUserIDQuery reads the required ?user_id= query parameter.
+func parseUserIDQuery(w http.ResponseWriter, r *http.Request) (uint, bool) {
+	id, err := strconv.ParseUint(r.URL.Query().Get("user_id"), 10, 64)
+	if err != nil || id == 0 {
+		respondWithError(w, r, http.StatusBadRequest, "A valid user_id query parameter is required")
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// parseOwnUserIDQuery reads the optional ?user_id= query parameter of
+// listings scoped to the signed-in user. It defaults to that user and
+// rejects anyone else with a 403, unless the user is an admin.
+func parseOwnUserIDQuery(w http.ResponseWriter, r *http.Request) (uint, bool) {
+	userID := sessionUserFrom(r)
+	if r.URL.Query().Get("user_id") == "" {
+		return userID, true
+	}
+	id, ok := parseUserIDQuery(w, r)
+	if ok && id != userID && authz.Check(r.Context(), authz.AccessAllTodos) != nil {
+		respondWithError(w, r, http.StatusForbidden, "You can only access your own todos")
+		return 0, false
+	}
+	return id, ok
+}
+
+// parsePageQuery reads the optional ?limit=, ?offset= and ?cursor= query
+// parameters.
+// Limits are enforced by the services, which know the configured maximum.
+func parsePageQuery(w http.ResponseWriter, r *http.Request) (service.PageRequest, bool) {
+	var page service.PageRequest
+	for _, param := range []struct {
+		name string
+		dst  *int
+	}{{"limit", &page.Limit}, {"offset", &page.Offset}} {
+		v := r.URL.Query().Get(param.name)
+		if v == "" {
+			continue
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			respondWithError(w, r, http.StatusBadRequest, fmt.Sprintf("Invalid %s query parameter, expected a non-negative integer", param.name))
+			return page, false
+		}
+		*param.dst = n
+	}
+	page.Cursor = r.URL.Query().Get("cursor")
+	return page, true
+}
+
+// parseTimeQuery reads an optional time query parameter, either an RFC
+// 3339 timestamp or a date, which means midnight UTC.
+func parseTimeQuery(w http.ResponseWriter, r *http.Request, name string) (*time.Time, bool) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return nil, true
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		if t, err = time.Parse(time.DateOnly, v); err != nil {
+			respondWithError(w, r, http.StatusBadRequest, fmt.Sprintf("Invalid %s query parameter, expected an RFC 3339 timestamp or a date", name))
+			return nil, false
+		}
+	}
+	return &t, true
+}
+
+// parseDeletedSinceQuery reads the optional sync parameters: ?deleted_since=
+// (RFC 3339) includes items deleted after that time, and ?include_deleted=true
+// includes every deleted item. It returns nil when neither is set.
+func parseDeletedSinceQuery(w http.ResponseWriter, r *http.Request) (*time.Time, bool) {
+	query := r.URL.Query()
+	if v := query.Get("deleted_since"); v != "" {
+		since, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			respondWithError(w, r, http.StatusBadRequest, "Invalid deleted_since query parameter, expected an RFC 3339 timestamp")
+			return nil, false
+		}
+		return &since, true
+	}
+	if v := query.Get("include_deleted"); v != "" {
+		include, err := strconv.ParseBool(v)
+		if err != nil {
+			respondWithError(w, r, http.StatusBadRequest, "Invalid include_deleted query parameter, expected true or false")
+			return nil, false
+		}
+		if include {
+			return &time.Time{}, true
+		}
+	}
+	return nil, true
+}
+
+// respondWithPage writes one page of a listing. The body stays a plain
+// array; the position is reported in X-Total-Count (not for cursor pages)
+// and a Link header with rel="next", and in the envelope's meta when
+// enveloping is on.
+func respondWithPage(w http.ResponseWriter, r *http.Request, items interface{}, page *service.PageInfo) {
+	if page.Total != nil {
+		w.Header().Set("X-Total-Count", strconv.FormatInt(*page.Total, 10))
+	}
+	// Cursor pages continue with a cursor, offset pages with an offset
+	next := *r.URL
+	query := next.Query()
+	hasNext := true
+	switch {
+	case query.Get("cursor") != "":
+		query.Set("cursor", page.NextCursor)
+		hasNext = page.NextCursor != ""
+	case page.NextOffset != nil:
+		query.Set("offset", strconv.Itoa(*page.NextOffset))
+	default:
+		hasNext = false
+	}
+	if hasNext {
+		query.Set("limit", strconv.Itoa(page.Limit))
+		next.RawQuery = query.Encode()
+		w.Header().Add("Link", fmt.Sprintf(`<%s>; rel="next"`, next.RequestURI()))
+	}
+	if meta := envelopeMetaFrom(r); meta != nil {
+		meta.Pagination = page
+	}
+	// Deleting a todo changes a list without changing any todo left in
+	// it, so only the ETag, which covers the whole page, can tell
+	respondWithValidatedJSON(w, r, items, "", time.Time{})
+}
+
+// decodeJSONBody strictly decodes the request body into dst. On failure it
+// writes a descriptive 400 (or 500) response and returns false.
+func decodeJSONBody(w http.ResponseWriter, r *http.Request, dst interface{}) bool {
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(dst)
+	if err != nil {
+		var syntaxError *json.SyntaxError
+		var unmarshalTypeError *json.UnmarshalTypeError
+		if errors.As(err, &syntaxError) {
+			msg := fmt.Sprintf("Request body contains badly-formed JSON (at position %d)", syntaxError.Offset)
+			respondWithError(w, r, http.StatusBadRequest, msg)
+		} else if errors.Is(err, io.ErrUnexpectedEOF) {
+			msg := "Request body contains badly-formed JSON"
+			respondWithError(w, r, http.StatusBadRequest, msg)
+		} else if errors.As(err, &unmarshalTypeError) {
+			msg := fmt.Sprintf("Request body contains an invalid value for the %q field (at position %d)", unmarshalTypeError.Field, unmarshalTypeError.Offset)
+			respondWithError(w, r, http.StatusBadRequest, msg)
+		} else if strings.HasPrefix(err.Error(), "json: unknown field ") {
+			fieldName := strings.TrimPrefix(err.Error(), "json: unknown field ")
+			msg := fmt.Sprintf("Request body contains unknown field %s", fieldName)
+			respondWithError(w, r, http.StatusBadRequest, msg)
+		} else if errors.Is(err, io.EOF) {
+			msg := "Request body must not be empty"
+			respondWithError(w, r, http.StatusBadRequest, msg)
+		} else {
+			logging.FromContext(r.Context()).Error("Error decoding request body", "err", err)
+			respondWithError(w, r, http.StatusInternalServerError, "Error processing request")
+		}
+		return false
+	}
+	return true
+}
+
+// respondWithError writes an RFC 7807 problem with the status code and
+// message as its detail.
+func respondWithError(w http.ResponseWriter, r *http.Request, code int, message string) {
+	problem.Error(w, r, code, message)
 }
 
 func respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
 	response, err := json.Marshal(payload)
 	if err != nil {
-		log.Printf("Error marshaling JSON response: %v", err)
-		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		// No request to log with here, so the line lacks its request_id
+		slog.Error("Error marshaling JSON response", "err", err)
+		w.Header().Set("Content-Type", problem.ContentType)
 		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte(`{"error":"Internal server error preparing response"}`))
+		w.Write([]byte(`{"type":"about:blank","title":"Internal Server Error","status":500,"detail":"Internal server error preparing response"}`))
 		return
 	}
 
@@ -1,10 +1,27 @@
 package server
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"io"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/Tomlord1122/todo-backend/internal/health"
+	"github.com/Tomlord1122/todo-backend/internal/jwt"
+	"github.com/Tomlord1122/todo-backend/internal/lifecycle"
+	"github.com/Tomlord1122/todo-backend/internal/logging"
+	"github.com/Tomlord1122/todo-backend/internal/repository"
+	"github.com/Tomlord1122/todo-backend/internal/service"
 )
 
 func TestHandler(t *testing.T) {
@@ -29,3 +46,184 @@ func TestHandler(t *testing.T) {
 		t.Errorf("expected response body to be %v; got %v", expected, string(body))
 	}
 }
+
+func TestHealthProbes(t *testing.T) {
+	var dbUp atomic.Bool
+	checker := health.NewChecker(time.Second)
+	checker.Add("postgres", true, func(context.Context) error {
+		if !dbUp.Load() {
+			return errors.New("connection refused")
+		}
+		return nil
+	})
+	drain := &lifecycle.Drain{}
+	handler := NewServer(Config{}, Services{Health: checker, Drain: drain}, nil).Handler
+
+	tests := []struct {
+		dbUp, draining      bool
+		wantLive, wantReady int
+	}{
+		{true, false, http.StatusOK, http.StatusOK},
+		// A database outage makes the instance unready, not dead
+		{false, false, http.StatusOK, http.StatusServiceUnavailable},
+		// So does shutting down
+		{true, true, http.StatusOK, http.StatusServiceUnavailable},
+	}
+	for _, tt := range tests {
+		dbUp.Store(tt.dbUp)
+		if tt.draining {
+			_ = drain.Start(context.Background(), 0)
+		}
+		for path, want := range map[string]int{"/healthz": tt.wantLive, "/readyz": tt.wantReady} {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+			if rec.Code != want {
+				t.Errorf("GET %s with the database up=%t, draining=%t: status %d, want %d", path, tt.dbUp, tt.draining, rec.Code, want)
+			}
+		}
+	}
+}
+
+func TestGatewayMount(t *testing.T) {
+	var gotPath string
+	gateway := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		w.WriteHeader(http.StatusNoContent)
+	})
+	handler := NewServer(Config{}, Services{Gateway: gateway}, nil).Handler
+
+	for _, path := range []string{"/v1/todos", "/v1/todos/42", "/v1/todos:search"} {
+		gotPath = ""
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/rpc"+path, nil))
+		if rec.Code != http.StatusNoContent || gotPath != path {
+			t.Errorf("GET /rpc%s: status %d, gateway saw %q", path, rec.Code, gotPath)
+		}
+	}
+}
+
+func TestLogRequests(t *testing.T) {
+	var buf bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(logging.New(&buf, logging.Config{JSON: true}))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+
+	r := chi.NewRouter()
+	r.Use(logRequests(r))
+	r.Get("/todos/{id}", func(w http.ResponseWriter, r *http.Request) {
+		logging.With(r.Context(), "user_id", 7)
+		logging.FromContext(r.Context()).Info("handling")
+		w.WriteHeader(http.StatusNoContent)
+	})
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/todos/42", nil))
+
+	var lines []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var entry map[string]any
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("log line %q isn't JSON: %v", line, err)
+		}
+		lines = append(lines, entry)
+	}
+	if len(lines) != 2 {
+		t.Fatalf("logged %d lines, want the handler's and the request's", len(lines))
+	}
+	for _, entry := range lines {
+		if entry["route"] != "/todos/{id}" || entry["method"] != "GET" || entry["user_id"] != float64(7) {
+			t.Errorf("log line %v lacks the request's route, method or user", entry)
+		}
+	}
+	if got := lines[1]; got["msg"] != "request" || got["status"] != float64(http.StatusNoContent) || got["uri"] != "/todos/42" {
+		t.Errorf("request line = %v, want msg request, status 204 and uri /todos/42", got)
+	}
+}
+
+// stubAttachments serves attachments owned by owner without object storage.
+type stubAttachments struct {
+	service.AttachmentService
+	owner uint
+}
+
+func (a stubAttachments) Owner(context.Context, uint) (uint, error) { return a.owner, nil }
+
+func (a stubAttachments) DownloadURL(context.Context, uint) (string, error) {
+	return "https://files.example.com/receipt.pdf", nil
+}
+
+func (a stubAttachments) Delete(context.Context, uint) error { return nil }
+
+// newSessionServer serves the API with in-memory repositories and
+// attachments, and returns the access tokens of two registered users, the
+// first of them an admin.
+func newSessionServer(t *testing.T, attachments service.AttachmentService) (handler http.Handler, ada, bob string) {
+	t.Helper()
+	repos := repository.NewMemoryRepositories()
+	auth, err := service.NewAuthService(repos.Users, service.AuthConfig{Secret: []byte(strings.Repeat("k", jwt.MinKeyLength)), TTL: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler = NewServer(Config{}, Services{
+		Attachment: attachments,
+		Session:    service.NewSessionService(repos.Sessions),
+		Auth:       auth,
+		Users:      service.NewUserService(repos.Users),
+	}, nil).Handler
+
+	register := func(email string) string {
+		rec := httptest.NewRecorder()
+		body := `{"email":"` + email + `","password":"correct horse battery"}`
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/auth/register", strings.NewReader(body)))
+		var token service.AccessTokenResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &token); err != nil || token.AccessToken == "" {
+			t.Fatalf("registering %s: status %d, body %s", email, rec.Code, rec.Body)
+		}
+		return token.AccessToken
+	}
+	return handler, register("ada@example.com"), register("bob@example.com")
+}
+
+func TestAttachmentRoutesRequireOwner(t *testing.T) {
+	// The attachments belong to Bob, so Ada may only reach them as an admin
+	handler, ada, bob := newSessionServer(t, stubAttachments{owner: 2})
+	carol := ""
+
+	tests := []struct {
+		method, path string
+		token        string
+		want         int
+	}{
+		{http.MethodGet, "/api/v1/attachments/1/download", carol, http.StatusUnauthorized},
+		{http.MethodDelete, "/api/v1/attachments/1", carol, http.StatusUnauthorized},
+		{http.MethodGet, "/api/v1/attachments/1/download", bob, http.StatusFound},
+		{http.MethodDelete, "/api/v1/attachments/1", bob, http.StatusNoContent},
+		{http.MethodGet, "/api/v1/attachments/1/download", ada, http.StatusFound},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.path, nil)
+		if tt.token != "" {
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != tt.want {
+			t.Errorf("%s %s: status %d, want %d", tt.method, tt.path, rec.Code, tt.want)
+		}
+	}
+
+	// A member who doesn't own the todo gets the same 404 as for a
+	// missing attachment
+	handler, _, bob = newSessionServer(t, stubAttachments{owner: 1})
+	for _, method := range []string{http.MethodGet, http.MethodDelete} {
+		path := "/api/v1/attachments/1"
+		if method == http.MethodGet {
+			path += "/download"
+		}
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("Authorization", "Bearer "+bob)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("%s %s by another user: status %d, want 404", method, path, rec.Code)
+		}
+	}
+}
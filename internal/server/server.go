@@ -1,48 +1,321 @@
 package server
 
 import (
+	"context"
+	"crypto/tls"
 	"fmt"
 	"net/http"
-	"os"
-	"strconv"
 	"time"
 
 	_ "github.com/joho/godotenv/autoload"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/quic-go/quic-go/http3"
 
+	"github.com/Tomlord1122/todo-backend/internal/clientip"
 	"github.com/Tomlord1122/todo-backend/internal/database"
+	"github.com/Tomlord1122/todo-backend/internal/graphapi"
+	"github.com/Tomlord1122/todo-backend/internal/health"
+	"github.com/Tomlord1122/todo-backend/internal/lifecycle"
+	"github.com/Tomlord1122/todo-backend/internal/pagination"
+	"github.com/Tomlord1122/todo-backend/internal/ratelimit"
+	"github.com/Tomlord1122/todo-backend/internal/readonly"
+	"github.com/Tomlord1122/todo-backend/internal/realtime"
 	"github.com/Tomlord1122/todo-backend/internal/service"
+	"github.com/Tomlord1122/todo-backend/internal/web"
 )
 
 type Server struct {
-	port        int
-	todoService service.TodoService
-	db          database.Service
+	port                  int
+	allowedOrigins        []string
+	todoService           service.TodoService
+	feedService           service.FeedService
+	listService           service.ListService
+	reportService         service.ReportService
+	reportScheduleService service.ReportScheduleService
+	suggestionService     service.SuggestionService
+	preferenceService     service.PreferenceService
+	attachmentService     service.AttachmentService
+	checklistService      service.ChecklistService
+	reactionService       service.ReactionService
+	activityService       service.ActivityService
+	overdueService        service.OverdueService
+	focusService          service.FocusService
+	statsService          service.StatsService
+	burndownService       service.BurndownService
+	timelineService       service.TimelineService
+	inboundHookService    service.InboundHookService
+	gitHubService         service.GitHubService
+	calendarService       service.CalendarService
+	notionExportService   service.NotionExportService
+	importService         service.ImportService
+	passkeyService        service.PasskeyService
+	sessionService        service.SessionService
+	authService           service.AuthService
+	apiKeyService         service.APIKeyService
+	webhookService        service.WebhookService
+	tagService            service.TagService
+	subtaskService        service.SubtaskService
+	reminderService       service.ReminderService
+	userService           service.UserService
+	ssoService            service.SSOService
+	followService         service.FollowService
+	presenceService       service.PresenceService
+	fixtureService        service.FixtureService
+	idempotencyService    service.IdempotencyService
+	clientIP              *clientip.Resolver
+	readOnly              *readonly.Mode
+	drain                 *lifecycle.Drain
+	health                *health.Checker
+	rateLimiter           ratelimit.Limiter
+	userRateLimiter       ratelimit.Limiter
+	metrics               prometheus.Gatherer
+	web                   http.Handler
+	graphql               http.Handler
+	gateway               http.Handler
+	events                *realtime.Hub
+	adminToken            string
+	audit                 adminAudit
+	envelope              bool
+	legacyRoutes          bool
+	pages                 pagination.Config
+	db                    database.Service
+
+	// streams is canceled when the server shuts down, ending the
+	// long-lived responses Shutdown doesn't wait for
+	streams context.Context
+}
+
+// Config is the HTTP server's own settings, from package config. Zero
+// fields take the defaults.
+type Config struct {
+	// Port only sets Addr; the caller opens the listeners
+	Port int
+	// ReadTimeout, WriteTimeout and IdleTimeout are the http.Server's
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+	IdleTimeout  time.Duration
+	// AllowedOrigins may call the API from browsers, with * as a wildcard;
+	// any http or https origin by default
+	AllowedOrigins []string
+	// AdminToken enables the admin API; empty disables it
+	AdminToken string
+	// ClientIP finds the client behind trusted proxies; nil ignores
+	// forwarding headers
+	ClientIP *clientip.Resolver
+	// Pages are the listing and export limits; the zero value uses
+	// pagination.DefaultConfig
+	Pages pagination.Config
+	// Envelope wraps every JSON response; clients can still opt in per
+	// request without it
+	Envelope bool
+	// NoLegacyRoutes drops the deprecated unversioned aliases of /api/v1
+	NoLegacyRoutes bool
+}
+
+// withDefaults returns c with the zero fields set to the defaults.
+func (c Config) withDefaults() Config {
+	if c.Port == 0 {
+		c.Port = 8080
+	}
+	if c.ReadTimeout == 0 {
+		c.ReadTimeout = 10 * time.Second
+	}
+	if c.WriteTimeout == 0 {
+		c.WriteTimeout = 30 * time.Second
+	}
+	if c.IdleTimeout == 0 {
+		c.IdleTimeout = time.Minute
+	}
+	if len(c.AllowedOrigins) == 0 {
+		c.AllowedOrigins = []string{"https://*", "http://*"}
+	}
+	if c.ClientIP == nil {
+		c.ClientIP, _ = clientip.NewResolver(nil)
+	}
+	if c.Pages == (pagination.Config{}) {
+		c.Pages = pagination.DefaultConfig()
+	}
+	return c
+}
+
+// Services bundles the application services the HTTP layer depends on.
+type Services struct {
+	Todo           service.TodoService
+	Feed           service.FeedService
+	List           service.ListService
+	Report         service.ReportService
+	ReportSchedule service.ReportScheduleService
+	Suggestion     service.SuggestionService
+	Preference     service.PreferenceService
+	Attachment     service.AttachmentService
+	Checklist      service.ChecklistService
+	Reaction       service.ReactionService
+	Activity       service.ActivityService
+	Overdue        service.OverdueService
+	Focus          service.FocusService
+	Stats          service.StatsService
+	Burndown       service.BurndownService
+	Timeline       service.TimelineService
+	InboundHook    service.InboundHookService
+	GitHub         service.GitHubService
+	Calendar       service.CalendarService
+	NotionExport   service.NotionExportService
+	Import         service.ImportService
+	Passkey        service.PasskeyService
+	Session        service.SessionService
+	Auth           service.AuthService
+	APIKeys        service.APIKeyService
+	Webhooks       service.WebhookService
+	Tags           service.TagService
+	Subtasks       service.SubtaskService
+	Reminders      service.ReminderService
+	Users          service.UserService
+	SSO            service.SSOService
+	Follow         service.FollowService
+	Presence       service.PresenceService
+	// Fixtures enables POST /dev/fixtures when set; development only
+	Fixtures service.FixtureService
+	// Idempotency lets POST /todos be retried with an Idempotency-Key when
+	// set
+	Idempotency service.IdempotencyService
+	// ReadOnly is shared with background jobs so they pause too
+	ReadOnly *readonly.Mode
+	// Drain turns new requests away during shutdown; nil never drains
+	Drain *lifecycle.Drain
+	// Health checks the dependencies for /readyz; nil checks nothing
+	Health *health.Checker
+	// RateLimiter limits anonymous requests per client IP when set
+	RateLimiter ratelimit.Limiter
+	// UserRateLimiter limits signed-in users' requests per user when set
+	UserRateLimiter ratelimit.Limiter
+	// Metrics is served at /metrics when set
+	Metrics prometheus.Gatherer
+	// Events are streamed to clients at /ws when set
+	Events *realtime.Hub
+	// Gateway, the gRPC API transcoded to JSON/HTTP, is served under /rpc
+	// when set
+	Gateway http.Handler
 }
 
-func NewServer(todoService service.TodoService, dbService database.Service) *http.Server {
-	portStr := os.Getenv("PORT")
-	if portStr == "" {
-		portStr = "8080"
+// NewServer builds the HTTP server. dbService may be nil when the services
+// don't use a database (demo mode); health then always reports up.
+func NewServer(cfg Config, services Services, dbService database.Service) *http.Server {
+	cfg = cfg.withDefaults()
+
+	if services.ReadOnly == nil {
+		services.ReadOnly = readonly.New()
 	}
-	port, err := strconv.Atoi(portStr)
-	if err != nil {
-		fmt.Printf("Warning: Invalid PORT environment variable '%s'. Using default 8080. Error: %v", portStr, err)
-		port = 8080
+	if services.Drain == nil {
+		services.Drain = &lifecycle.Drain{}
+	}
+	if services.Health == nil {
+		services.Health = health.NewChecker(time.Second)
 	}
 
 	appServer := &Server{
-		port:        port,
-		todoService: todoService,
-		db:          dbService,
+		port:                  cfg.Port,
+		allowedOrigins:        cfg.AllowedOrigins,
+		todoService:           services.Todo,
+		feedService:           services.Feed,
+		listService:           services.List,
+		reportService:         services.Report,
+		reportScheduleService: services.ReportSchedule,
+		suggestionService:     services.Suggestion,
+		preferenceService:     services.Preference,
+		attachmentService:     services.Attachment,
+		checklistService:      services.Checklist,
+		reactionService:       services.Reaction,
+		activityService:       services.Activity,
+		overdueService:        services.Overdue,
+		focusService:          services.Focus,
+		statsService:          services.Stats,
+		burndownService:       services.Burndown,
+		timelineService:       services.Timeline,
+		inboundHookService:    services.InboundHook,
+		gitHubService:         services.GitHub,
+		calendarService:       services.Calendar,
+		notionExportService:   services.NotionExport,
+		importService:         services.Import,
+		passkeyService:        services.Passkey,
+		sessionService:        services.Session,
+		authService:           services.Auth,
+		apiKeyService:         services.APIKeys,
+		webhookService:        services.Webhooks,
+		tagService:            services.Tags,
+		subtaskService:        services.Subtasks,
+		reminderService:       services.Reminders,
+		userService:           services.Users,
+		ssoService:            services.SSO,
+		followService:         services.Follow,
+		presenceService:       services.Presence,
+		fixtureService:        services.Fixtures,
+		idempotencyService:    services.Idempotency,
+		clientIP:              cfg.ClientIP,
+		readOnly:              services.ReadOnly,
+		drain:                 services.Drain,
+		health:                services.Health,
+		rateLimiter:           services.RateLimiter,
+		userRateLimiter:       services.UserRateLimiter,
+		metrics:               services.Metrics,
+		gateway:               services.Gateway,
+		events:                services.Events,
+		adminToken:            cfg.AdminToken,
+		envelope:              cfg.Envelope,
+		legacyRoutes:          !cfg.NoLegacyRoutes,
+		pages:                 cfg.Pages,
+		db:                    dbService,
 	}
 
+	if fsys, ok := web.FS(); ok {
+		appServer.web = web.NewSPAHandler(fsys)
+	}
+	if services.Todo != nil && services.Tags != nil && services.Users != nil {
+		appServer.graphql = graphapi.NewHandler(graphapi.Services{
+			Todo:     services.Todo,
+			Tags:     services.Tags,
+			Users:    services.Users,
+			ReadOnly: services.ReadOnly,
+		})
+	}
+
+	streams, endStreams := context.WithCancel(context.Background())
+	appServer.streams = streams
+
 	server := &http.Server{
 		Addr:         fmt.Sprintf(":%d", appServer.port),
 		Handler:      appServer.RegisterRoutes(),
-		IdleTimeout:  time.Minute,
-		ReadTimeout:  10 * time.Second,
-		WriteTimeout: 30 * time.Second,
+		IdleTimeout:  cfg.IdleTimeout,
+		ReadTimeout:  cfg.ReadTimeout,
+		WriteTimeout: cfg.WriteTimeout,
 	}
+	server.RegisterOnShutdown(endStreams)
 
 	return server
 }
+
+// WithH2C returns a server with the same handler and timeouts as base that
+// also accepts cleartext HTTP/2. Protocols are per http.Server, so listeners
+// with h2c enabled are served by their own server.
+func WithH2C(base *http.Server) *http.Server {
+	protocols := new(http.Protocols)
+	protocols.SetHTTP1(true)
+	protocols.SetUnencryptedHTTP2(true)
+	return &http.Server{
+		Addr:         base.Addr,
+		Handler:      base.Handler,
+		IdleTimeout:  base.IdleTimeout,
+		ReadTimeout:  base.ReadTimeout,
+		WriteTimeout: base.WriteTimeout,
+		Protocols:    protocols,
+	}
+}
+
+// WithHTTP3 returns an HTTP/3 server with the same handler and idle timeout
+// as base, serving over QUIC with tlsConfig's certificate.
+func WithHTTP3(base *http.Server, tlsConfig *tls.Config) *http3.Server {
+	return &http3.Server{
+		Handler:     base.Handler,
+		IdleTimeout: base.IdleTimeout,
+		TLSConfig:   http3.ConfigureTLSConfig(tlsConfig),
+	}
+}
@@ -0,0 +1,233 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/Tomlord1122/todo-backend/internal/apperror"
+	"github.com/Tomlord1122/todo-backend/internal/authz"
+	"github.com/Tomlord1122/todo-backend/internal/logging"
+	"github.com/Tomlord1122/todo-backend/internal/service"
+)
+
+// bearerToken returns the token of an "Authorization: Bearer" header.
+func bearerToken(r *http.Request) (string, bool) {
+	return strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+}
+
+// tokenFromQuery lets browsers, which can't set headers on WebSocket
+// handshakes or EventSource requests, send their bearer token as the
+// access_token query parameter. Only streaming endpoints accept it.
+func tokenFromQuery(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if token := r.URL.Query().Get("access_token"); token != "" && r.Header.Get("Authorization") == "" {
+			r.Header.Set("Authorization", "Bearer "+token)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// sessionUser returns the user signed in with the request's credentials,
+// or 0 when the request has none. A bearer token is either a JWT access
+// token from a password login or a session token from a passkey or single
+// sign-on login; machine clients send "Authorization: ApiKey <key>"
+// instead. Invalid credentials are an error rather than anonymous, so
+// clients notice an expired session or revoked key. Credentials already
+// checked for the request, see rememberSessionUser, aren't checked again.
+func (s *Server) sessionUser(r *http.Request) (uint, error) {
+	if m, ok := r.Context().Value(sessionUserKey{}).(sessionUserMemo); ok && m.authorization == r.Header.Get("Authorization") {
+		return m.userID, m.err
+	}
+	if key, ok := strings.CutPrefix(r.Header.Get("Authorization"), "ApiKey "); ok && s.apiKeyService != nil {
+		return s.apiKeyService.Authenticate(r.Context(), key, time.Now())
+	}
+	token, ok := bearerToken(r)
+	if !ok {
+		return 0, nil
+	}
+	// Session tokens are random and have no dots; JWTs have two
+	if s.authService != nil && strings.Count(token, ".") == 2 {
+		return s.authService.Authenticate(r.Context(), token, time.Now())
+	}
+	return s.sessionService.Authenticate(r.Context(), token, time.Now())
+}
+
+type sessionUserKey struct{}
+
+// sessionUserMemo is the outcome of sessionUser for the credentials in an
+// Authorization header.
+type sessionUserMemo struct {
+	authorization string
+	userID        uint
+	err           error
+}
+
+// rememberSessionUser checks the request's credentials and returns the
+// request with the outcome stored in its context, so middleware that needs
+// the user before requireSession runs doesn't cost a second check.
+func (s *Server) rememberSessionUser(r *http.Request) *http.Request {
+	userID, err := s.sessionUser(r)
+	memo := sessionUserMemo{authorization: r.Header.Get("Authorization"), userID: userID, err: err}
+	return r.WithContext(context.WithValue(r.Context(), sessionUserKey{}, memo))
+}
+
+// requireSession rejects requests without a valid access token, session
+// token or API key and makes the user and their role available to
+// handlers through sessionUserFrom and authz.FromContext.
+func (s *Server) requireSession(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userID, err := s.sessionUser(r)
+		if err == nil && userID == 0 {
+			err = service.ErrUnauthenticated
+		}
+		if err != nil {
+			respondWithServiceError(w, r, err, "Authenticate", "Failed to check session")
+			return
+		}
+		principal, err := s.userService.Principal(r.Context(), userID)
+		if err != nil {
+			respondWithServiceError(w, r, err, "Principal", "Failed to check session")
+			return
+		}
+		logging.With(r.Context(), "user_id", principal.UserID)
+		next.ServeHTTP(w, r.WithContext(authz.NewContext(r.Context(), principal)))
+	})
+}
+
+// sessionUserFrom returns the user requireSession found.
+func sessionUserFrom(r *http.Request) uint {
+	principal, _ := authz.FromContext(r.Context())
+	return principal.UserID
+}
+
+// requireTodoOwner lets requireSession's user through to the todo in the
+// {id} URL parameter only if they own it. Other users get the same 404 as
+// for a todo that doesn't exist, so IDs don't reveal whose todos exist.
+// Admins may access every todo. Invalid IDs and missing todos are left to the handler to report.
+func (s *Server) requireTodoOwner(next http.Handler) http.Handler {
+	return s.requireOwnerOf("GetTodoByID", s.todoOwner(service.TodoService.GetTodoByID), "todo with ID %d not found", next)
+}
+
+// requireTrashedTodoOwner is requireTodoOwner for todos in the trash.
+func (s *Server) requireTrashedTodoOwner(next http.Handler) http.Handler {
+	return s.requireOwnerOf("GetTrashedTodo", s.todoOwner(service.TodoService.GetTrashedTodo), "todo with ID %d not found in the trash", next)
+}
+
+// requireAttachmentOwner is requireTodoOwner for the attachment in the {id}
+// URL parameter, which belongs to its todo's owner.
+func (s *Server) requireAttachmentOwner(next http.Handler) http.Handler {
+	return s.requireOwnerOf("AttachmentOwner", func(ctx context.Context, id uint) (uint, error) {
+		return s.attachmentService.Owner(ctx, id)
+	}, "attachment with ID %d not found", next)
+}
+
+// requireSelf lets requireSession's user through to the user in the {id}
+// URL parameter only if it is themselves, or they are an admin.
+func (s *Server) requireSelf(next http.Handler) http.Handler {
+	return s.requireOwnerOf("", func(_ context.Context, id uint) (uint, error) {
+		return id, nil
+	}, "user with ID %d not found", next)
+}
+
+// requireListOwner is requireTodoOwner for the list in the {id} URL
+// parameter.
+func (s *Server) requireListOwner(next http.Handler) http.Handler {
+	return s.requireOwnerOf("GetListByID", func(ctx context.Context, id uint) (uint, error) {
+		list, err := s.listService.GetListByID(ctx, id)
+		if err != nil {
+			return 0, err
+		}
+		return list.UserID, nil
+	}, "list with ID %d not found", next)
+}
+
+// requireReportScheduleOwner is requireTodoOwner for the report schedule in
+// the {id} URL parameter.
+func (s *Server) requireReportScheduleOwner(next http.Handler) http.Handler {
+	return s.requireOwnerOf("GetScheduleByID", func(ctx context.Context, id uint) (uint, error) {
+		schedule, err := s.reportScheduleService.GetScheduleByID(ctx, id)
+		if err != nil {
+			return 0, err
+		}
+		return schedule.UserID, nil
+	}, "report schedule with ID %d not found", next)
+}
+
+// requireNotionExportOwner is requireTodoOwner for the export in the {id}
+// URL parameter.
+func (s *Server) requireNotionExportOwner(next http.Handler) http.Handler {
+	return s.requireOwnerOf("GetNotionExport", func(ctx context.Context, id uint) (uint, error) {
+		export, err := s.notionExportService.Get(ctx, id)
+		if err != nil {
+			return 0, err
+		}
+		return export.UserID, nil
+	}, "export with ID %d not found", next)
+}
+
+// requireImportOwner is requireTodoOwner for the import in the {id} URL
+// parameter.
+func (s *Server) requireImportOwner(next http.Handler) http.Handler {
+	return s.requireOwnerOf("GetImport", func(ctx context.Context, id uint) (uint, error) {
+		imp, err := s.importService.Get(ctx, id)
+		if err != nil {
+			return 0, err
+		}
+		return imp.UserID, nil
+	}, "import with ID %d not found", next)
+}
+
+// todoOwner turns a todo lookup into an owner lookup.
+func (s *Server) todoOwner(lookup func(service.TodoService, context.Context, uint) (*service.TodoResponse, error)) func(context.Context, uint) (uint, error) {
+	return func(ctx context.Context, id uint) (uint, error) {
+		todo, err := lookup(s.todoService, ctx, id)
+		if err != nil {
+			return 0, err
+		}
+		return todo.UserID, nil
+	}
+}
+
+// requireOwnerOf checks the user owner returns for the {id} URL parameter,
+// answering other users with notFound, a format taking the ID.
+func (s *Server) requireOwnerOf(operation string, owner func(context.Context, uint) (uint, error), notFound string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+		if err != nil || id == 0 || authz.Check(r.Context(), authz.AccessAllTodos) == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		ownerID, err := owner(r.Context(), uint(id))
+		if err != nil {
+			if !errors.Is(err, apperror.ErrNotFound) {
+				logging.FromContext(r.Context()).Error("Error calling service", "operation", operation, "err", err)
+				respondWithError(w, r, http.StatusInternalServerError, "Failed to check access")
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+		if ownerID != sessionUserFrom(r) {
+			respondWithError(w, r, http.StatusNotFound, fmt.Sprintf(notFound, id))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) logoutHandler(w http.ResponseWriter, r *http.Request) {
+	token, _ := bearerToken(r)
+	if err := s.sessionService.Logout(r.Context(), token); err != nil {
+		respondWithServiceError(w, r, err, "Logout", "Failed to sign out")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
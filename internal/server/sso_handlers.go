@@ -0,0 +1,70 @@
+package server
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/Tomlord1122/todo-backend/internal/service"
+)
+
+func (s *Server) listSSOProvidersHandler(w http.ResponseWriter, r *http.Request) {
+	respondWithJSON(w, http.StatusOK, s.ssoService.Providers(r.Context()))
+}
+
+func (s *Server) beginSSOLoginHandler(w http.ResponseWriter, r *http.Request) {
+	// Signed-in users link the provider account to themselves
+	userID, err := s.sessionUser(r)
+	if err != nil {
+		respondWithServiceError(w, r, err, "Authenticate", "Failed to check session")
+		return
+	}
+
+	login, err := s.ssoService.BeginLogin(r.Context(), userID, chi.URLParam(r, "provider"))
+	if err != nil {
+		respondWithServiceError(w, r, err, "BeginLogin", "Failed to start sign-in")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, login)
+}
+
+func (s *Server) finishSSOLoginHandler(w http.ResponseWriter, r *http.Request) {
+	var req service.FinishSSOLoginRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+
+	session, err := s.ssoService.FinishLogin(r.Context(), chi.URLParam(r, "provider"), req, time.Now())
+	if err != nil {
+		respondWithServiceError(w, r, err, "FinishLogin", "Failed to sign in")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, session)
+}
+
+func (s *Server) listIdentitiesHandler(w http.ResponseWriter, r *http.Request) {
+	identities, err := s.ssoService.ListIdentities(r.Context(), sessionUserFrom(r))
+	if err != nil {
+		respondWithServiceError(w, r, err, "ListIdentities", "Failed to retrieve identities")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, identities)
+}
+
+func (s *Server) deleteIdentityHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseIDParam(w, r, "id", "identity")
+	if !ok {
+		return
+	}
+
+	if err := s.ssoService.DeleteIdentity(r.Context(), sessionUserFrom(r), id); err != nil {
+		respondWithServiceError(w, r, err, "DeleteIdentity", "Failed to delete identity")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
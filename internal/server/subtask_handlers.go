@@ -0,0 +1,84 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/Tomlord1122/todo-backend/internal/service"
+)
+
+func (s *Server) listSubtasksHandler(w http.ResponseWriter, r *http.Request) {
+	todoID, ok := parseIDParam(w, r, "id", "todo")
+	if !ok {
+		return
+	}
+
+	subtasks, err := s.subtaskService.List(r.Context(), todoID)
+	if err != nil {
+		respondWithServiceError(w, r, err, "ListSubtasks", "Failed to retrieve subtasks")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, subtasks)
+}
+
+func (s *Server) createSubtaskHandler(w http.ResponseWriter, r *http.Request) {
+	todoID, ok := parseIDParam(w, r, "id", "todo")
+	if !ok {
+		return
+	}
+
+	var req service.CreateSubtaskRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+
+	subtask, err := s.subtaskService.Create(r.Context(), todoID, req)
+	if err != nil {
+		respondWithServiceError(w, r, err, "CreateSubtask", "Failed to create subtask")
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, subtask)
+}
+
+func (s *Server) updateSubtaskHandler(w http.ResponseWriter, r *http.Request) {
+	todoID, ok := parseIDParam(w, r, "id", "todo")
+	if !ok {
+		return
+	}
+	subtaskID, ok := parseIDParam(w, r, "subtaskID", "subtask")
+	if !ok {
+		return
+	}
+
+	var req service.UpdateSubtaskRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+
+	subtask, err := s.subtaskService.Update(r.Context(), todoID, subtaskID, req)
+	if err != nil {
+		respondWithServiceError(w, r, err, "UpdateSubtask", "Failed to update subtask")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, subtask)
+}
+
+func (s *Server) deleteSubtaskHandler(w http.ResponseWriter, r *http.Request) {
+	todoID, ok := parseIDParam(w, r, "id", "todo")
+	if !ok {
+		return
+	}
+	subtaskID, ok := parseIDParam(w, r, "subtaskID", "subtask")
+	if !ok {
+		return
+	}
+
+	if err := s.subtaskService.Delete(r.Context(), todoID, subtaskID); err != nil {
+		respondWithServiceError(w, r, err, "DeleteSubtask", "Failed to delete subtask")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
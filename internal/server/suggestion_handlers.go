@@ -0,0 +1,59 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/Tomlord1122/todo-backend/internal/logging"
+	"github.com/Tomlord1122/todo-backend/internal/service"
+)
+
+func (s *Server) suggestTodoHandler(w http.ResponseWriter, r *http.Request) {
+	var req service.SuggestTodoRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+
+	suggestion, err := s.suggestionService.Suggest(r.Context(), req)
+	if err != nil {
+		respondWithServiceError(w, r, err, "Suggest", "Failed to generate suggestions")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, suggestion)
+}
+
+func (s *Server) getPreferencesHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := parseIDParam(w, r, "id", "user")
+	if !ok {
+		return
+	}
+
+	prefs, err := s.preferenceService.GetPreferences(r.Context(), userID)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Error calling service", "operation", "GetPreferences", "err", err)
+		respondWithError(w, r, http.StatusInternalServerError, "Failed to retrieve preferences")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, prefs)
+}
+
+func (s *Server) updatePreferencesHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := parseIDParam(w, r, "id", "user")
+	if !ok {
+		return
+	}
+
+	var req service.UpdatePreferencesRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+
+	prefs, err := s.preferenceService.UpdatePreferences(r.Context(), userID, req)
+	if err != nil {
+		respondWithServiceError(w, r, err, "UpdatePreferences", "Failed to update preferences")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, prefs)
+}
@@ -0,0 +1,80 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/Tomlord1122/todo-backend/internal/service"
+)
+
+func (s *Server) createTagHandler(w http.ResponseWriter, r *http.Request) {
+	var req service.CreateTagRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+
+	tag, err := s.tagService.CreateTag(r.Context(), sessionUserFrom(r), req)
+	if err != nil {
+		respondWithServiceError(w, r, err, "CreateTag", "Failed to create tag")
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, tag)
+}
+
+func (s *Server) listTagsHandler(w http.ResponseWriter, r *http.Request) {
+	tags, err := s.tagService.ListTags(r.Context(), sessionUserFrom(r))
+	if err != nil {
+		respondWithServiceError(w, r, err, "ListTags", "Failed to retrieve tags")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, tags)
+}
+
+func (s *Server) getTagHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseIDParam(w, r, "id", "tag")
+	if !ok {
+		return
+	}
+
+	tag, err := s.tagService.GetTag(r.Context(), sessionUserFrom(r), id)
+	if err != nil {
+		respondWithServiceError(w, r, err, "GetTag", "Failed to retrieve tag")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, tag)
+}
+
+func (s *Server) updateTagHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseIDParam(w, r, "id", "tag")
+	if !ok {
+		return
+	}
+	var req service.UpdateTagRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+
+	tag, err := s.tagService.UpdateTag(r.Context(), sessionUserFrom(r), id, req)
+	if err != nil {
+		respondWithServiceError(w, r, err, "UpdateTag", "Failed to update tag")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, tag)
+}
+
+func (s *Server) deleteTagHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseIDParam(w, r, "id", "tag")
+	if !ok {
+		return
+	}
+
+	if err := s.tagService.DeleteTag(r.Context(), sessionUserFrom(r), id); err != nil {
+		respondWithServiceError(w, r, err, "DeleteTag", "Failed to delete tag")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,51 @@
+package server
+
+import (
+	"net/http"
+)
+
+// listTrashHandler serves GET /todos/trash, the deleted todos of the
+// signed-in user or, for admins, of ?user_id=.
+func (s *Server) listTrashHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := parseOwnUserIDQuery(w, r)
+	if !ok {
+		return
+	}
+
+	todos, err := s.todoService.ListTrash(r.Context(), userID)
+	if err != nil {
+		respondWithServiceError(w, r, err, "ListTrash", "Failed to retrieve the trash")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, todos)
+}
+
+func (s *Server) restoreTodoHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseIDParam(w, r, "id", "todo")
+	if !ok {
+		return
+	}
+
+	todo, err := s.todoService.RestoreTodo(r.Context(), id)
+	if err != nil {
+		respondWithServiceError(w, r, err, "RestoreTodo", "Failed to restore todo")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, todo)
+}
+
+func (s *Server) purgeTodoHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseIDParam(w, r, "id", "todo")
+	if !ok {
+		return
+	}
+
+	if err := s.todoService.PurgeTodo(r.Context(), id); err != nil {
+		respondWithServiceError(w, r, err, "PurgeTodo", "Failed to purge todo")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,36 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/Tomlord1122/todo-backend/internal/service"
+)
+
+func (s *Server) listUsersHandler(w http.ResponseWriter, r *http.Request) {
+	users, err := s.userService.ListUsers(r.Context())
+	if err != nil {
+		respondWithServiceError(w, r, err, "ListUsers", "Failed to retrieve users")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, users)
+}
+
+func (s *Server) updateUserRoleHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseIDParam(w, r, "id", "user")
+	if !ok {
+		return
+	}
+	var req service.UpdateUserRoleRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+
+	user, err := s.userService.UpdateRole(r.Context(), id, req)
+	if err != nil {
+		respondWithServiceError(w, r, err, "UpdateRole", "Failed to update user")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, user)
+}
@@ -0,0 +1,203 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/Tomlord1122/todo-backend/internal/apispec"
+	"github.com/Tomlord1122/todo-backend/internal/problem"
+	"github.com/Tomlord1122/todo-backend/internal/validate"
+)
+
+// maxValidatedBody caps JSON bodies buffered for validation.
+const maxValidatedBody = 1 << 20
+
+// specEndpoints indexes apispec.Endpoints by method and path.
+var specEndpoints = func() map[string]apispec.Endpoint {
+	index := make(map[string]apispec.Endpoint, len(apispec.Endpoints))
+	for _, e := range apispec.Endpoints {
+		index[e.Method+" "+e.Path] = e
+	}
+	return index
+}()
+
+// validateRequests checks requests for catalogued endpoints against
+// apispec.Endpoints before they reach the handler, so the published contract
+// is what the API actually accepts:
+//   - ID path parameters must be positive integers (400)
+//   - query parameters must be ones the endpoint declares (400)
+//   - JSON bodies must be well-formed (400), and must only use fields of the
+//     request type with values of the right type that pass its validate
+//     tags (422, listing the failed fields as invalid-params)
+//
+// Requests for routes outside the catalog (feeds, admin, webhooks) pass
+// through untouched.
+func validateRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rctx := chi.RouteContext(r.Context())
+		if rctx == nil || rctx.Routes == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		match := chi.NewRouteContext()
+		pattern := rctx.Routes.Find(match, r.Method, routePathOf(rctx, r))
+		if len(pattern) > 1 {
+			pattern = strings.TrimSuffix(pattern, "/")
+		}
+		// The catalog is the same for the versioned and legacy paths
+		pattern = strings.TrimPrefix(pattern, apispec.BasePath)
+		endpoint, ok := specEndpoints[r.Method+" "+pattern]
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		for i, key := range match.URLParams.Keys {
+			if !strings.HasSuffix(strings.ToLower(key), "id") {
+				continue
+			}
+			if id, err := strconv.ParseUint(match.URLParams.Values[i], 10, 64); err != nil || id == 0 {
+				respondWithError(w, r, http.StatusBadRequest, fmt.Sprintf("Invalid %s path parameter, expected a positive integer", key))
+				return
+			}
+		}
+
+		if unknown := unknownQueryParams(r, endpoint.Query); len(unknown) > 0 {
+			msg := fmt.Sprintf("Unknown query parameter %s", strings.Join(unknown, ", "))
+			if len(endpoint.Query) > 0 {
+				msg += fmt.Sprintf(", expected one of %s", strings.Join(endpoint.Query, ", "))
+			}
+			respondWithError(w, r, http.StatusBadRequest, msg)
+			return
+		}
+
+		if endpoint.Request != nil {
+			body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxValidatedBody))
+			if err != nil {
+				var maxErr *http.MaxBytesError
+				if errors.As(err, &maxErr) {
+					respondWithError(w, r, http.StatusRequestEntityTooLarge, fmt.Sprintf("Request body must not be larger than %d bytes", maxValidatedBody))
+				} else {
+					respondWithError(w, r, http.StatusBadRequest, "Failed to read request body")
+				}
+				return
+			}
+			if status, detail, params := validateBody(body, endpoint.Request); status != 0 {
+				d := problem.New(r, status, detail)
+				d.InvalidParams = params
+				problem.Write(w, d)
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// routePathOf returns the path chi routes r on, including any rewrite by
+// normalizePaths.
+func routePathOf(rctx *chi.Context, r *http.Request) string {
+	if rctx.RoutePath != "" {
+		return rctx.RoutePath
+	}
+	if r.URL.RawPath != "" {
+		return r.URL.RawPath
+	}
+	return r.URL.Path
+}
+
+// unknownQueryParams lists, sorted, the query parameters of r not in allowed.
+func unknownQueryParams(r *http.Request, allowed []string) []string {
+	var unknown []string
+	for name := range r.URL.Query() {
+		found := false
+		for _, a := range allowed {
+			if name == a {
+				found = true
+				break
+			}
+		}
+		if !found {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	return unknown
+}
+
+// validateBody checks body against the request type t. It returns a zero
+// status when the body is valid, otherwise the status and detail to
+// respond with, and the fields that failed their validate tags.
+func validateBody(body []byte, t reflect.Type) (int, string, []problem.InvalidParam) {
+	if len(bytes.TrimSpace(body)) == 0 {
+		return http.StatusBadRequest, "Request body must not be empty", nil
+	}
+	dst := reflect.New(t)
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(dst.Interface()); err != nil {
+		var syntaxError *json.SyntaxError
+		var unmarshalTypeError *json.UnmarshalTypeError
+		switch {
+		case errors.As(err, &syntaxError):
+			return http.StatusBadRequest, fmt.Sprintf("Request body contains badly-formed JSON (at position %d)", syntaxError.Offset), nil
+		case errors.Is(err, io.ErrUnexpectedEOF):
+			return http.StatusBadRequest, "Request body contains badly-formed JSON", nil
+		case errors.As(err, &unmarshalTypeError):
+			if unmarshalTypeError.Field == "" {
+				return http.StatusUnprocessableEntity, fmt.Sprintf("Request body must be a JSON %s", jsonKind(t)), nil
+			}
+			return http.StatusUnprocessableEntity, fmt.Sprintf("Request body contains an invalid value for the %q field", unmarshalTypeError.Field), nil
+		case strings.HasPrefix(err.Error(), "json: unknown field "):
+			return http.StatusUnprocessableEntity, "Request body contains unknown field " + strings.TrimPrefix(err.Error(), "json: unknown field "), nil
+		default:
+			// Custom unmarshalers (dates, enums) report their own errors
+			return http.StatusUnprocessableEntity, "Request body contains an invalid value: " + strings.TrimPrefix(err.Error(), "json: "), nil
+		}
+	}
+	if decoder.More() {
+		return http.StatusBadRequest, "Request body must only contain a single JSON value", nil
+	}
+	if t.Kind() != reflect.Struct {
+		// Items of bulk requests are validated, and fail, one by one
+		return 0, "", nil
+	}
+	if fields := validate.Struct(dst.Interface()); len(fields) > 0 {
+		params := make([]problem.InvalidParam, 0, len(fields))
+		reasons := make([]string, 0, len(fields))
+		for _, field := range fields {
+			params = append(params, problem.InvalidParam{Name: field.Field, Rule: field.Rule, Reason: field.Reason})
+			reasons = append(reasons, fmt.Sprintf("%q %s", field.Field, field.Reason))
+		}
+		detail := "Request body field "
+		if len(reasons) > 1 {
+			detail = "Request body fields "
+		}
+		return http.StatusUnprocessableEntity, detail + strings.Join(reasons, "; "), params
+	}
+	return 0, "", nil
+}
+
+// jsonKind names the JSON value a request type is decoded from.
+func jsonKind(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array:
+		return "array"
+	case reflect.Map, reflect.Struct:
+		return "object"
+	default:
+		return "value"
+	}
+}
@@ -0,0 +1,99 @@
+package server
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/Tomlord1122/todo-backend/internal/apispec"
+	"github.com/Tomlord1122/todo-backend/internal/problem"
+	"github.com/Tomlord1122/todo-backend/internal/readonly"
+	"github.com/Tomlord1122/todo-backend/internal/service"
+)
+
+func TestValidateRequests(t *testing.T) {
+	echo := func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Write(body)
+	}
+	r := chi.NewRouter()
+	r.Use(normalizePaths)
+	r.Use(validateRequests)
+	r.Route("/todos", func(r chi.Router) {
+		r.Post("/", echo)
+		r.Get("/", echo)
+		r.Get("/{id}", echo)
+		r.Delete("/{id}/checklist/{itemID}", echo)
+	})
+	r.Get("/feeds/{token}/today.xml", echo)
+
+	cases := []struct {
+		method, path, body string
+		wantCode           int
+		wantBody           string
+	}{
+		{"POST", "/todos", `{"title":"Buy milk","priority":"high"}`, 200, `{"title":"Buy milk","priority":"high"}`},
+		{"POST", "/todos/", `{"title":"Buy milk"}`, 200, `{"title":"Buy milk"}`},
+		{"POST", "/todos", `{"title":`, 400, "badly-formed JSON"},
+		{"POST", "/todos", ``, 400, "must not be empty"},
+		{"POST", "/todos", `{"title":"a"} {}`, 400, "single JSON value"},
+		{"POST", "/todos", `[]`, 422, "must be a JSON object"},
+		{"POST", "/todos", `{"title":"a","colour":"red"}`, 422, `unknown field \"colour\"`},
+		{"POST", "/todos", `{"title":"a","user_id":"me"}`, 422, `\"user_id\" field`},
+		{"POST", "/todos", `{"title":"a","due_date":"tomorrow"}`, 422, "invalid value"},
+		{"POST", "/todos", `{"title":"  "}`, 422, `\"title\" is required`},
+		{"POST", "/todos", `{"priority":"urgent","estimate":-1}`, 422, `fields \"title\" is required; \"priority\" must be one of low, normal, high; \"estimate\" must be at least 0`},
+		{"GET", "/todos?limit=5&offset=10", "", 200, ""},
+		{"GET", "/todos?limt=5", "", 400, "Unknown query parameter limt, expected one of limit"},
+		{"GET", "/todos/7", "", 200, ""},
+		{"GET", "/todos/seven", "", 400, "Invalid id path parameter"},
+		{"GET", "/todos/7?fields=title", "", 400, "Unknown query parameter fields"},
+		{"DELETE", "/todos/7/checklist/0", "", 400, "Invalid itemID path parameter"},
+		// Routes outside the catalog are not validated
+		{"GET", "/feeds/AbC/today.xml?tz=UTC", "", 200, ""},
+	}
+	for _, tc := range cases {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body)))
+		if rec.Code != tc.wantCode || !strings.Contains(rec.Body.String(), tc.wantBody) {
+			t.Errorf("%s %s %s = %d %s, want %d containing %s",
+				tc.method, tc.path, tc.body, rec.Code, rec.Body, tc.wantCode, tc.wantBody)
+		}
+	}
+}
+
+func TestValidateBodyInvalidParams(t *testing.T) {
+	body := `{"title":"` + strings.Repeat("a", 256) + `","priority":"urgent"}`
+	status, _, params := validateBody([]byte(body), reflect.TypeFor[service.UpdateTodoRequest]())
+	want := []problem.InvalidParam{
+		{Name: "title", Rule: "max", Reason: "must be at most 255 characters"},
+		{Name: "priority", Rule: "oneof", Reason: "must be one of low, normal, high"},
+	}
+	if status != http.StatusUnprocessableEntity || !reflect.DeepEqual(params, want) {
+		t.Errorf("validateBody = %d %+v, want 422 %+v", status, params, want)
+	}
+
+	// Omitted fields of an update are left alone
+	if status, detail, _ := validateBody([]byte(`{"completed":true}`), reflect.TypeFor[service.UpdateTodoRequest]()); status != 0 {
+		t.Errorf("validateBody = %d %s, want valid", status, detail)
+	}
+}
+
+func TestEndpointsAreRouted(t *testing.T) {
+	s := &Server{readOnly: readonly.New()}
+	routes := s.RegisterRoutes().(chi.Routes)
+	for _, e := range apispec.Endpoints {
+		path := apispec.BasePath + e.Path
+		for _, param := range []string{"{id}", "{itemID}", "{attachmentID}", "{token}"} {
+			path = strings.ReplaceAll(path, param, "1")
+		}
+		if routes.Find(chi.NewRouteContext(), e.Method, path) == "" {
+			t.Errorf("%s (%s %s) has no route", e.Name, e.Method, e.Path)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/Tomlord1122/todo-backend/internal/apispec"
+)
+
+// apiVersionHeader names the API version that served a response.
+const apiVersionHeader = "API-Version"
+
+// apiVersions lists the API versions served, by the path segment that
+// selects them under /api.
+var apiVersions = []string{apispec.Version}
+
+// withAPIVersion names version as the API version serving the response.
+// Versions are selected by path for now; clients that can't change paths
+// could later select one with a request header, answered the same way.
+func withAPIVersion(version string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set(apiVersionHeader, version)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// unknownAPIVersionHandler answers requests for a version under /api that
+// isn't served.
+func unknownAPIVersionHandler(w http.ResponseWriter, r *http.Request) {
+	respondWithError(w, r, http.StatusNotFound, fmt.Sprintf("Unknown API version %s, expected one of %s",
+		chi.URLParam(r, "version"), strings.Join(apiVersions, ", ")))
+}
+
+// deprecatedAlias marks responses of the unversioned routes, which predate
+// /api/v1 and serve the same API, as deprecated: clients get a Deprecation
+// header and a Link to the same path under the current version.
+func deprecatedAlias(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Deprecation", "true")
+		w.Header().Add("Link", fmt.Sprintf(`<%s%s>; rel="successor-version"`, apispec.BasePath, r.URL.RequestURI()))
+		withAPIVersion(apispec.Version)(next).ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,95 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/Tomlord1122/todo-backend/internal/service"
+)
+
+func (s *Server) createWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	var req service.CreateWebhookRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+
+	hook, err := s.webhookService.CreateWebhook(r.Context(), sessionUserFrom(r), req)
+	if err != nil {
+		respondWithServiceError(w, r, err, "CreateWebhook", "Failed to create webhook")
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, hook)
+}
+
+func (s *Server) listWebhooksHandler(w http.ResponseWriter, r *http.Request) {
+	hooks, err := s.webhookService.ListWebhooks(r.Context(), sessionUserFrom(r))
+	if err != nil {
+		respondWithServiceError(w, r, err, "ListWebhooks", "Failed to retrieve webhooks")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, hooks)
+}
+
+func (s *Server) getWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseIDParam(w, r, "id", "webhook")
+	if !ok {
+		return
+	}
+
+	hook, err := s.webhookService.GetWebhook(r.Context(), sessionUserFrom(r), id)
+	if err != nil {
+		respondWithServiceError(w, r, err, "GetWebhook", "Failed to retrieve webhook")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, hook)
+}
+
+func (s *Server) updateWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseIDParam(w, r, "id", "webhook")
+	if !ok {
+		return
+	}
+	var req service.UpdateWebhookRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+
+	hook, err := s.webhookService.UpdateWebhook(r.Context(), sessionUserFrom(r), id, req)
+	if err != nil {
+		respondWithServiceError(w, r, err, "UpdateWebhook", "Failed to update webhook")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, hook)
+}
+
+func (s *Server) deleteWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseIDParam(w, r, "id", "webhook")
+	if !ok {
+		return
+	}
+
+	if err := s.webhookService.DeleteWebhook(r.Context(), sessionUserFrom(r), id); err != nil {
+		respondWithServiceError(w, r, err, "DeleteWebhook", "Failed to delete webhook")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) listWebhookDeliveriesHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseIDParam(w, r, "id", "webhook")
+	if !ok {
+		return
+	}
+
+	deliveries, err := s.webhookService.ListDeliveries(r.Context(), sessionUserFrom(r), id)
+	if err != nil {
+		respondWithServiceError(w, r, err, "ListWebhookDeliveries", "Failed to retrieve webhook deliveries")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, deliveries)
+}
@@ -0,0 +1,89 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/Tomlord1122/todo-backend/internal/logging"
+)
+
+const (
+	// websocketWriteWait is how long a write to a client may take
+	websocketWriteWait = 10 * time.Second
+	// websocketPongWait is how long a client may go without answering pings
+	websocketPongWait = time.Minute
+	// websocketPingPeriod is how often clients are pinged; below the pong
+	// wait so a live client always answers in time
+	websocketPingPeriod = websocketPongWait * 9 / 10
+)
+
+// websocketUpgrader accepts connections from any origin, like CORS does:
+// clients authenticate with a token rather than cookies, so another site
+// can't open a connection on a user's behalf.
+var websocketUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	CheckOrigin:     func(*http.Request) bool { return true },
+}
+
+// websocketHandler streams the signed-in user's todo changes as JSON
+// realtime events until the client disconnects or the server shuts down.
+// Messages from the client are ignored.
+func (s *Server) websocketHandler(w http.ResponseWriter, r *http.Request) {
+	conn, err := websocketUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// The upgrader has answered the client already
+		return
+	}
+	defer conn.Close()
+
+	sub := s.events.Subscribe(sessionUserFrom(r))
+	defer sub.Close()
+
+	// Reading processes pongs and notices when the client goes away
+	ctx, cancel := context.WithCancel(s.streams)
+	defer cancel()
+	conn.SetReadLimit(512)
+	conn.SetReadDeadline(time.Now().Add(websocketPongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(websocketPongWait))
+	})
+	go func() {
+		defer cancel()
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	ping := time.NewTicker(websocketPingPeriod)
+	defer ping.Stop()
+	for {
+		select {
+		case event, ok := <-sub.Events():
+			if !ok {
+				return
+			}
+			conn.SetWriteDeadline(time.Now().Add(websocketWriteWait))
+			if err := conn.WriteJSON(event); err != nil {
+				logging.FromContext(r.Context()).Error("Error writing event to WebSocket", "type", event.Type, "err", err)
+				return
+			}
+		case <-ping.C:
+			conn.SetWriteDeadline(time.Now().Add(websocketWriteWait))
+			if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		case <-ctx.Done():
+			if s.streams.Err() != nil {
+				closing := websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down")
+				conn.WriteControl(websocket.CloseMessage, closing, time.Now().Add(websocketWriteWait))
+			}
+			return
+		}
+	}
+}
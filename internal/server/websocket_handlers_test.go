@@ -0,0 +1,126 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/Tomlord1122/todo-backend/internal/jwt"
+	"github.com/Tomlord1122/todo-backend/internal/notify"
+	"github.com/Tomlord1122/todo-backend/internal/pagination"
+	"github.com/Tomlord1122/todo-backend/internal/realtime"
+	"github.com/Tomlord1122/todo-backend/internal/repository"
+	"github.com/Tomlord1122/todo-backend/internal/service"
+	"github.com/Tomlord1122/todo-backend/internal/suggest"
+)
+
+// streamServer serves the todo API with realtime events on a real listener,
+// since streams need connections a recorder can't provide.
+type streamServer struct {
+	*httptest.Server
+	t *testing.T
+}
+
+func newStreamServer(t *testing.T) *streamServer {
+	t.Helper()
+	repos := repository.NewMemoryRepositories()
+	hub := realtime.NewHub()
+	auth, err := service.NewAuthService(repos.Users, service.AuthConfig{Secret: []byte(strings.Repeat("k", jwt.MinKeyLength)), TTL: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	follow := service.NewFollowService(repos.Watchers, repos.Todos, repos.Preferences, repos.Users, notify.NewRegistry(notify.LogChannel{}))
+	httpServer := NewServer(Config{}, Services{
+		Todo: service.NewTodoService(repos.Todos, repos.Tags, repos.Preferences, repos.Activities,
+			suggest.NewKeywordSuggester(suggest.DefaultKeywordRules()), hub, follow, service.TodoConfig{Limits: pagination.DefaultConfig(), FuzzyThreshold: 0.3}),
+		Session: service.NewSessionService(repos.Sessions),
+		Auth:    auth,
+		Users:   service.NewUserService(repos.Users),
+		Events:  hub,
+	}, nil)
+	ts := httptest.NewUnstartedServer(nil)
+	ts.Config = httpServer
+	ts.Start()
+	t.Cleanup(ts.Close)
+	return &streamServer{Server: ts, t: t}
+}
+
+// register signs up a user and returns their access token.
+func (s *streamServer) register(email string) string {
+	s.t.Helper()
+	var token service.AccessTokenResponse
+	s.call("POST", "/api/v1/auth/register", "", `{"email":"`+email+`","password":"correct horse battery"}`, &token)
+	return token.AccessToken
+}
+
+// createTodo creates a todo as the user of token.
+func (s *streamServer) createTodo(token, title string) service.TodoResponse {
+	s.t.Helper()
+	var todo service.TodoResponse
+	s.call("POST", "/api/v1/todos", token, `{"title":"`+title+`"}`, &todo)
+	return todo
+}
+
+func (s *streamServer) call(method, path, token, body string, out any) {
+	s.t.Helper()
+	req, _ := http.NewRequest(method, s.URL+path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		s.t.Fatalf("%s %s = %s", method, path, resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		s.t.Fatalf("%s %s: %v", method, path, err)
+	}
+}
+
+func TestWebSocketEvents(t *testing.T) {
+	s := newStreamServer(t)
+	alice, bob := s.register("alice@example.com"), s.register("bob@example.com")
+	url := "ws" + strings.TrimPrefix(s.URL, "http") + websocketPath
+
+	if _, resp, err := websocket.DefaultDialer.Dial(url, nil); err == nil || resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("dialing without a token = %v, want 401", err)
+	}
+	conn, _, err := websocket.DefaultDialer.Dial(url+"?access_token="+alice, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	// Only the user's own changes arrive
+	s.createTodo(bob, "Bob's todo")
+	created := s.createTodo(alice, "Buy milk")
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	var event struct {
+		Type string               `json:"type"`
+		Data service.TodoResponse `json:"data"`
+	}
+	if err := conn.ReadJSON(&event); err != nil {
+		t.Fatal(err)
+	}
+	if event.Type != realtime.TodoCreated || event.Data.ID != created.ID || event.Data.Title != "Buy milk" {
+		t.Errorf("event = %+v, want %s of todo %d", event, realtime.TodoCreated, created.ID)
+	}
+
+	// Shutting down closes the connection rather than waiting for it
+	if err := s.Config.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := conn.ReadMessage(); !websocket.IsCloseError(err, websocket.CloseGoingAway) {
+		t.Errorf("reading after shutdown = %v, want going away", err)
+	}
+}
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Tomlord1122/todo-backend/internal/apperror"
+	"github.com/Tomlord1122/todo-backend/internal/domain"
+	"github.com/Tomlord1122/todo-backend/internal/logging"
+	"github.com/Tomlord1122/todo-backend/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+// ActivityResponse is the representation of an Activity returned by the service.
+type ActivityResponse struct {
+	ID     uint   `json:"id"`
+	TodoID uint   `json:"todo_id"`
+	UserID uint   `json:"user_id,omitempty"` // omitted for background jobs
+	Kind   string `json:"kind"`
+	From   string `json:"from,omitempty"`
+	To     string `json:"to,omitempty"`
+	Detail string `json:"detail,omitempty"`
+	At     string `json:"at"`
+}
+
+// ActivityService reads todo history.
+type ActivityService interface {
+	// ListByTodo returns a todo's history, oldest first.
+	ListByTodo(ctx context.Context, todoID uint) ([]ActivityResponse, error)
+}
+
+type activityService struct {
+	repo  repository.ActivityRepository
+	todos repository.TodoRepository
+}
+
+// NewActivityService creates a new ActivityService.
+func NewActivityService(repo repository.ActivityRepository, todos repository.TodoRepository) ActivityService {
+	return &activityService{repo: repo, todos: todos}
+}
+
+func toActivityResponse(a *domain.Activity) ActivityResponse {
+	return ActivityResponse{
+		ID:     a.ID,
+		TodoID: a.TodoID,
+		UserID: a.UserID,
+		Kind:   a.Kind,
+		From:   a.OldValue,
+		To:     a.NewValue,
+		Detail: a.Detail,
+		At:     a.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+// newActivity builds a history entry for a change to todo, snapshotting the
+// list it is in and the estimate still open.
+func newActivity(todo *domain.Todo, kind, oldValue, newValue string) *domain.Activity {
+	activity := &domain.Activity{
+		TodoID:   todo.ID,
+		Kind:     kind,
+		OldValue: oldValue,
+		NewValue: newValue,
+		ListID:   todo.ListID,
+	}
+	if todo.Estimate != nil && !todo.Completed {
+		activity.OpenEstimate = *todo.Estimate
+	}
+	return activity
+}
+
+// ListByTodo implements ActivityService.
+func (s *activityService) ListByTodo(ctx context.Context, todoID uint) ([]ActivityResponse, error) {
+	if _, err := s.todos.FindByID(todoID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.NotFoundf("todo with ID %d not found", todoID)
+		}
+		logging.FromContext(ctx).Error("Error fetching todo for activity", "todo_id", todoID, "err", err)
+		return nil, errors.New("failed to retrieve activity")
+	}
+	activities, err := s.repo.FindByTodoID(todoID)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error fetching activity for todo", "todo_id", todoID, "err", err)
+		return nil, errors.New("failed to retrieve activity")
+	}
+
+	responses := make([]ActivityResponse, 0, len(activities))
+	for i := range activities {
+		responses = append(responses, toActivityResponse(&activities[i]))
+	}
+	return responses, nil
+}
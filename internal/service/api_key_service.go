@@ -0,0 +1,160 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/Tomlord1122/todo-backend/internal/apperror"
+	"github.com/Tomlord1122/todo-backend/internal/domain"
+	"github.com/Tomlord1122/todo-backend/internal/logging"
+	"github.com/Tomlord1122/todo-backend/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+// apiKeyPrefix starts every API key, so leaked keys are easy to spot in
+// logs and secret scanners.
+const apiKeyPrefix = "tdk_"
+
+// apiKeyVisiblePrefix is how much of a key is kept to identify it.
+const apiKeyVisiblePrefix = len(apiKeyPrefix) + 8
+
+// maxAPIKeyNameLength bounds API key names.
+const maxAPIKeyNameLength = 100
+
+// apiKeyTouchInterval limits how often LastUsedAt is written for a key
+// that is used many times a minute.
+const apiKeyTouchInterval = time.Minute
+
+// CreateAPIKeyRequest names a new API key after what will use it.
+type CreateAPIKeyRequest struct {
+	Name string `json:"name" validate:"notblank"`
+}
+
+// APIKeyResponse describes an API key. The key itself is only known when
+// it is created.
+type APIKeyResponse struct {
+	ID         uint    `json:"id"`
+	Name       string  `json:"name"`
+	Prefix     string  `json:"prefix"`
+	CreatedAt  string  `json:"created_at"`
+	LastUsedAt *string `json:"last_used_at,omitempty"`
+}
+
+// CreatedAPIKeyResponse is returned once, when a key is created. The key
+// is sent as "Authorization: ApiKey <key>".
+type CreatedAPIKeyResponse struct {
+	APIKeyResponse
+	Key string `json:"key"`
+}
+
+// APIKeyService manages long-lived keys that let scripts and CI jobs act
+// as a user without an interactive login.
+type APIKeyService interface {
+	// CreateAPIKey creates a key for a user. Only the returned response
+	// ever contains the key.
+	CreateAPIKey(ctx context.Context, userID uint, req CreateAPIKeyRequest) (*CreatedAPIKeyResponse, error)
+
+	// ListAPIKeys returns the keys of a user.
+	ListAPIKeys(ctx context.Context, userID uint) ([]APIKeyResponse, error)
+
+	// RevokeAPIKey deletes one of the user's keys.
+	RevokeAPIKey(ctx context.Context, userID, id uint) error
+
+	// Authenticate returns the user a key belongs to.
+	Authenticate(ctx context.Context, key string, now time.Time) (uint, error)
+}
+
+type apiKeyService struct {
+	repo repository.APIKeyRepository
+}
+
+// NewAPIKeyService creates a new APIKeyService
+func NewAPIKeyService(repo repository.APIKeyRepository) APIKeyService {
+	return &apiKeyService{repo: repo}
+}
+
+func toAPIKeyResponse(key *domain.APIKey) APIKeyResponse {
+	return APIKeyResponse{
+		ID:         key.ID,
+		Name:       key.Name,
+		Prefix:     key.Prefix,
+		CreatedAt:  key.CreatedAt.Format(time.RFC3339),
+		LastUsedAt: formatOptionalTime(key.LastUsedAt),
+	}
+}
+
+// CreateAPIKey implements APIKeyService.
+func (s *apiKeyService) CreateAPIKey(ctx context.Context, userID uint, req CreateAPIKeyRequest) (*CreatedAPIKeyResponse, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" || len(name) > maxAPIKeyNameLength {
+		return nil, apperror.Invalidf("invalid API key: name must be 1 to %d characters", maxAPIKeyNameLength)
+	}
+	token, err := generateToken()
+	if err != nil {
+		logging.FromContext(ctx).Error("Error generating API key", "err", err)
+		return nil, errors.New("failed to create API key")
+	}
+	key := apiKeyPrefix + token
+	apiKey := &domain.APIKey{
+		UserID:  userID,
+		Name:    name,
+		Prefix:  key[:apiKeyVisiblePrefix],
+		KeyHash: hashSessionToken(key),
+	}
+	if err := s.repo.Create(apiKey); err != nil {
+		logging.FromContext(ctx).Error("Error creating API key for user", "user_id", userID, "err", err)
+		return nil, errors.New("failed to create API key")
+	}
+	return &CreatedAPIKeyResponse{APIKeyResponse: toAPIKeyResponse(apiKey), Key: key}, nil
+}
+
+// ListAPIKeys implements APIKeyService.
+func (s *apiKeyService) ListAPIKeys(ctx context.Context, userID uint) ([]APIKeyResponse, error) {
+	keys, err := s.repo.FindByUserID(userID)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error fetching API keys of user", "user_id", userID, "err", err)
+		return nil, errors.New("failed to retrieve API keys")
+	}
+	resp := make([]APIKeyResponse, 0, len(keys))
+	for i := range keys {
+		resp = append(resp, toAPIKeyResponse(&keys[i]))
+	}
+	return resp, nil
+}
+
+// RevokeAPIKey implements APIKeyService.
+func (s *apiKeyService) RevokeAPIKey(ctx context.Context, userID, id uint) error {
+	if err := s.repo.Delete(id, userID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return apperror.NotFoundf("API key with ID %d not found", id)
+		}
+		logging.FromContext(ctx).Error("Error revoking API key", "api_key_id", id, "err", err)
+		return errors.New("failed to revoke API key")
+	}
+	return nil
+}
+
+// Authenticate implements APIKeyService.
+func (s *apiKeyService) Authenticate(ctx context.Context, key string, now time.Time) (uint, error) {
+	if !strings.HasPrefix(key, apiKeyPrefix) {
+		return 0, ErrUnauthenticated
+	}
+	apiKey, err := s.repo.FindByHash(hashSessionToken(key))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, ErrUnauthenticated
+		}
+		logging.FromContext(ctx).Error("Error looking up API key", "err", err)
+		return 0, errors.New("failed to check API key")
+	}
+	if apiKey.LastUsedAt == nil || now.Sub(*apiKey.LastUsedAt) >= apiKeyTouchInterval {
+		// Failing to record the use shouldn't fail the request
+		if err := s.repo.Touch(apiKey.ID, now); err != nil {
+			logging.FromContext(ctx).Error("Error recording use of API key", "api_key_id", apiKey.ID, "err", err)
+		}
+	}
+	return apiKey.UserID, nil
+}
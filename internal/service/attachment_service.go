@@ -0,0 +1,661 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Tomlord1122/todo-backend/internal/apperror"
+	"github.com/Tomlord1122/todo-backend/internal/authz"
+	"github.com/Tomlord1122/todo-backend/internal/domain"
+	"github.com/Tomlord1122/todo-backend/internal/logging"
+	"github.com/Tomlord1122/todo-backend/internal/notify"
+	"github.com/Tomlord1122/todo-backend/internal/repository"
+	"github.com/Tomlord1122/todo-backend/internal/scan"
+	"github.com/Tomlord1122/todo-backend/internal/storage"
+	"github.com/Tomlord1122/todo-backend/internal/thumbnail"
+
+	"gorm.io/gorm"
+)
+
+// ErrStorageNotConfigured is returned by attachment operations when no
+// object store is configured.
+var ErrStorageNotConfigured = apperror.New(apperror.CodeUnavailable, "attachment storage is not configured")
+
+// ErrThumbnailPending is returned when an attachment's thumbnails haven't
+// been generated yet; clients should retry shortly.
+var ErrThumbnailPending = apperror.New(apperror.CodePending, "thumbnail is not ready yet")
+
+// ErrAttachmentScanning is returned when downloading a file that is still
+// quarantined awaiting its malware scan.
+var ErrAttachmentScanning = apperror.New(apperror.CodeConflict, "attachment is still being scanned for malware")
+
+// ErrAttachmentInfected is returned when downloading a file the scanner flagged.
+var ErrAttachmentInfected = apperror.New(apperror.CodeForbidden, "attachment is quarantined because malware was detected")
+
+// AttachmentConfig holds attachment upload limits.
+type AttachmentConfig struct {
+	MaxSize             int64
+	AllowedContentTypes []string
+	UploadURLExpiry     time.Duration
+	DownloadURLExpiry   time.Duration
+	// PendingTTL is how long an unconfirmed upload is kept before cleanup.
+	PendingTTL time.Duration
+	// ThumbnailMaxSize is the largest file thumbnails are generated for.
+	ThumbnailMaxSize int64
+	// ScanAlertChannel and ScanAlertRecipient say where infected upload
+	// events are sent.
+	ScanAlertChannel   string
+	ScanAlertRecipient string
+}
+
+// AttachmentConfigFromEnv reads ATTACHMENT_* environment variables,
+// falling back to defaults for anything unset or invalid.
+func AttachmentConfigFromEnv() AttachmentConfig {
+	cfg := AttachmentConfig{
+		MaxSize: 100 << 20, // 100 MiB
+		AllowedContentTypes: []string{
+			"image/png", "image/jpeg", "image/gif", "image/webp",
+			"application/pdf", "text/plain", "text/csv", "application/zip",
+		},
+		UploadURLExpiry:   15 * time.Minute,
+		DownloadURLExpiry: 5 * time.Minute,
+		PendingTTL:        24 * time.Hour,
+		ThumbnailMaxSize:  25 << 20, // 25 MiB
+		ScanAlertChannel:  "log",
+	}
+	if v, err := strconv.ParseInt(os.Getenv("ATTACHMENT_MAX_BYTES"), 10, 64); err == nil && v > 0 {
+		cfg.MaxSize = v
+	}
+	if v := os.Getenv("ATTACHMENT_ALLOWED_TYPES"); v != "" {
+		cfg.AllowedContentTypes = nil
+		for _, t := range strings.Split(v, ",") {
+			if t = strings.TrimSpace(t); t != "" {
+				cfg.AllowedContentTypes = append(cfg.AllowedContentTypes, t)
+			}
+		}
+	}
+	if v := os.Getenv("ATTACHMENT_SCAN_ALERT_CHANNEL"); v != "" {
+		cfg.ScanAlertChannel = v
+	}
+	cfg.ScanAlertRecipient = os.Getenv("ATTACHMENT_SCAN_ALERT_RECIPIENT")
+	return cfg
+}
+
+// PresignAttachmentRequest describes the file a client wants to upload
+type PresignAttachmentRequest struct {
+	Filename    string `json:"filename"`
+	ContentType string `json:"content_type"`
+	Size        int64  `json:"size"`
+}
+
+// PresignAttachmentResponse tells the client how to upload the file
+// directly to object storage, and where to confirm it afterwards.
+type PresignAttachmentResponse struct {
+	Attachment AttachmentResponse `json:"attachment"`
+	Upload     struct {
+		Method    string            `json:"method"`
+		URL       string            `json:"url"`
+		Headers   map[string]string `json:"headers"`
+		ExpiresAt string            `json:"expires_at"`
+	} `json:"upload"`
+	ConfirmURL string `json:"confirm_url"`
+}
+
+// AttachmentResponse is the representation of an Attachment returned by the service.
+type AttachmentResponse struct {
+	ID          uint   `json:"id"`
+	TodoID      uint   `json:"todo_id"`
+	Filename    string `json:"filename"`
+	ContentType string `json:"content_type"`
+	Size        int64  `json:"size"`
+	Status      string `json:"status"`
+	// ScanStatus is scanning, clean or infected; empty when scanning is disabled
+	ScanStatus string `json:"scan_status,omitempty"`
+	// ThumbnailURL is set once thumbnails are available
+	ThumbnailURL *string `json:"thumbnail_url"`
+	CreatedAt    string  `json:"created_at"`
+}
+
+// AttachmentService manages files attached to todos. Uploads go straight to
+// object storage via presigned URLs; the API only issues and verifies them.
+type AttachmentService interface {
+	// Presign registers a pending attachment and returns an upload URL for it.
+	Presign(ctx context.Context, todoID uint, req PresignAttachmentRequest) (*PresignAttachmentResponse, error)
+	// Confirm verifies the uploaded object matches what was declared and
+	// finalizes the attachment.
+	Confirm(ctx context.Context, todoID, attachmentID uint) (*AttachmentResponse, error)
+	// ListByTodo returns a todo's finalized attachments.
+	ListByTodo(ctx context.Context, todoID uint) ([]AttachmentResponse, error)
+	// DownloadURL returns a short-lived URL to download an attachment.
+	DownloadURL(ctx context.Context, attachmentID uint) (string, error)
+	// Delete removes an attachment and its stored object.
+	Delete(ctx context.Context, attachmentID uint) error
+	// Owner returns the user owning the todo an attachment belongs to, or 0
+	// when that todo is gone.
+	Owner(ctx context.Context, attachmentID uint) (uint, error)
+	// CleanupPending removes uploads that were never confirmed.
+	CleanupPending(ctx context.Context, now time.Time) error
+	// ThumbnailURL returns a short-lived URL to download a thumbnail of an attachment.
+	ThumbnailURL(ctx context.Context, attachmentID uint, size string) (string, error)
+	// GenerateThumbnails renders thumbnails for attachments awaiting them.
+	GenerateThumbnails(ctx context.Context) error
+	// ScanPending runs the malware scan for quarantined attachments.
+	ScanPending(ctx context.Context) error
+}
+
+type attachmentService struct {
+	repo     repository.AttachmentRepository
+	todos    repository.TodoRepository
+	store    storage.ObjectStore
+	thumbs   *thumbnail.Generator
+	scanner  scan.Scanner
+	notifier *notify.Registry
+	cfg      AttachmentConfig
+}
+
+// NewAttachmentService creates a new AttachmentService. store may be nil, in
+// which case every operation returns ErrStorageNotConfigured. scanner may be
+// nil to serve uploads without a malware scan.
+func NewAttachmentService(repo repository.AttachmentRepository, todos repository.TodoRepository, store storage.ObjectStore, thumbs *thumbnail.Generator, scanner scan.Scanner, notifier *notify.Registry, cfg AttachmentConfig) AttachmentService {
+	return &attachmentService{
+		repo:     repo,
+		todos:    todos,
+		store:    store,
+		thumbs:   thumbs,
+		scanner:  scanner,
+		notifier: notifier,
+		cfg:      cfg,
+	}
+}
+
+func toAttachmentResponse(a *domain.Attachment) AttachmentResponse {
+	var thumbnailURL *string
+	if a.ThumbnailStatus == domain.ThumbnailReady {
+		u := fmt.Sprintf(apiBasePath+"/attachments/%d/thumbnail", a.ID)
+		thumbnailURL = &u
+	}
+	return AttachmentResponse{
+		ID:           a.ID,
+		TodoID:       a.TodoID,
+		Filename:     a.Filename,
+		ContentType:  a.ContentType,
+		Size:         a.Size,
+		Status:       a.Status,
+		ScanStatus:   a.ScanStatus,
+		ThumbnailURL: thumbnailURL,
+		CreatedAt:    a.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+// Presign implements AttachmentService.
+func (s *attachmentService) Presign(ctx context.Context, todoID uint, req PresignAttachmentRequest) (*PresignAttachmentResponse, error) {
+	if err := authz.Check(ctx, authz.WriteTodos); err != nil {
+		return nil, err
+	}
+	if s.store == nil {
+		return nil, ErrStorageNotConfigured
+	}
+
+	filename := sanitizeFilename(req.Filename)
+	if filename == "" {
+		return nil, apperror.Invalidf("invalid attachment: filename is required")
+	}
+	contentType, _, err := mime.ParseMediaType(req.ContentType)
+	if err != nil || !s.allowedContentType(contentType) {
+		return nil, apperror.Invalidf("invalid attachment: content type must be one of %s", strings.Join(s.cfg.AllowedContentTypes, ", "))
+	}
+	if req.Size <= 0 || req.Size > s.cfg.MaxSize {
+		return nil, apperror.Invalidf("invalid attachment: size must be between 1 and %d bytes", s.cfg.MaxSize)
+	}
+
+	if _, err := s.todos.FindByID(todoID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.NotFoundf("todo with ID %d not found", todoID)
+		}
+		logging.FromContext(ctx).Error("Error fetching todo for attachment", "todo_id", todoID, "err", err)
+		return nil, errors.New("failed to create attachment")
+	}
+
+	nonce := make([]byte, 12)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, errors.New("failed to create attachment")
+	}
+	attachment := &domain.Attachment{
+		TodoID:      todoID,
+		Filename:    filename,
+		ContentType: contentType,
+		Size:        req.Size,
+		// Random segment keeps keys unguessable and unique per upload
+		ObjectKey: fmt.Sprintf("todos/%d/%s/%s", todoID, hex.EncodeToString(nonce), filename),
+		Status:    domain.AttachmentPending,
+	}
+
+	presigned, err := s.store.PresignPut(ctx, attachment.ObjectKey, contentType, s.cfg.UploadURLExpiry)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error presigning upload for todo", "todo_id", todoID, "err", err)
+		return nil, errors.New("failed to create attachment")
+	}
+	if err := s.repo.Create(attachment); err != nil {
+		logging.FromContext(ctx).Error("Error creating attachment in repository", "err", err)
+		return nil, errors.New("failed to create attachment")
+	}
+
+	response := &PresignAttachmentResponse{
+		Attachment: toAttachmentResponse(attachment),
+		ConfirmURL: fmt.Sprintf(apiBasePath+"/todos/%d/attachments/%d/confirm", todoID, attachment.ID),
+	}
+	response.Upload.Method = presigned.Method
+	response.Upload.URL = presigned.URL
+	response.Upload.Headers = make(map[string]string, len(presigned.Header))
+	for name := range presigned.Header {
+		response.Upload.Headers[name] = presigned.Header.Get(name)
+	}
+	response.Upload.ExpiresAt = presigned.ExpiresAt.Format(time.RFC3339)
+	return response, nil
+}
+
+// Confirm implements AttachmentService.
+func (s *attachmentService) Confirm(ctx context.Context, todoID, attachmentID uint) (*AttachmentResponse, error) {
+	if err := authz.Check(ctx, authz.WriteTodos); err != nil {
+		return nil, err
+	}
+	if s.store == nil {
+		return nil, ErrStorageNotConfigured
+	}
+
+	attachment, err := s.find(ctx, attachmentID)
+	if err != nil {
+		return nil, err
+	}
+	if attachment.TodoID != todoID {
+		return nil, apperror.NotFoundf("attachment with ID %d not found", attachmentID)
+	}
+	if attachment.Status != domain.AttachmentPending {
+		// Confirming twice is harmless
+		response := toAttachmentResponse(attachment)
+		return &response, nil
+	}
+
+	info, err := s.store.Stat(ctx, attachment.ObjectKey)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, apperror.Errorf(apperror.CodeUnprocessable, "upload rejected: file has not been uploaded yet")
+		}
+		logging.FromContext(ctx).Error("Error checking uploaded object for attachment", "attachment_id", attachmentID, "err", err)
+		return nil, errors.New("failed to confirm attachment")
+	}
+
+	uploadedType, _, _ := mime.ParseMediaType(info.ContentType)
+	var rejection string
+	switch {
+	case info.Size != attachment.Size:
+		rejection = fmt.Sprintf("uploaded size %d does not match declared size %d", info.Size, attachment.Size)
+	case info.Size > s.cfg.MaxSize:
+		rejection = fmt.Sprintf("file exceeds the %d byte limit", s.cfg.MaxSize)
+	case uploadedType != attachment.ContentType:
+		rejection = fmt.Sprintf("uploaded content type %q does not match declared %q", info.ContentType, attachment.ContentType)
+	}
+	if rejection != "" {
+		// Don't keep bytes we won't serve; the client can presign again
+		if err := s.store.Delete(ctx, attachment.ObjectKey); err != nil {
+			logging.FromContext(ctx).Error("Error deleting rejected object for attachment", "attachment_id", attachmentID, "err", err)
+		}
+		if err := s.repo.Delete(attachment.ID); err != nil {
+			logging.FromContext(ctx).Error("Error deleting rejected attachment", "attachment_id", attachmentID, "err", err)
+		}
+		return nil, apperror.Errorf(apperror.CodeUnprocessable, "upload rejected: %s", rejection)
+	}
+
+	attachment.Status = domain.AttachmentUploaded
+	if s.scanner != nil {
+		// Quarantined until ScanPending reports it clean; thumbnails wait too
+		attachment.ScanStatus = domain.ScanScanning
+	} else {
+		s.queueThumbnails(attachment)
+	}
+	if err := s.repo.Update(attachment); err != nil {
+		logging.FromContext(ctx).Error("Error finalizing attachment", "attachment_id", attachmentID, "err", err)
+		return nil, errors.New("failed to confirm attachment")
+	}
+
+	response := toAttachmentResponse(attachment)
+	return &response, nil
+}
+
+// ListByTodo implements AttachmentService.
+func (s *attachmentService) ListByTodo(ctx context.Context, todoID uint) ([]AttachmentResponse, error) {
+	attachments, err := s.repo.FindByTodoID(todoID, domain.AttachmentUploaded)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error fetching attachments for todo", "todo_id", todoID, "err", err)
+		return nil, errors.New("failed to retrieve attachments")
+	}
+
+	responses := make([]AttachmentResponse, 0, len(attachments))
+	for i := range attachments {
+		responses = append(responses, toAttachmentResponse(&attachments[i]))
+	}
+	return responses, nil
+}
+
+// DownloadURL implements AttachmentService.
+func (s *attachmentService) DownloadURL(ctx context.Context, attachmentID uint) (string, error) {
+	if s.store == nil {
+		return "", ErrStorageNotConfigured
+	}
+
+	attachment, err := s.find(ctx, attachmentID)
+	if err != nil {
+		return "", err
+	}
+	if attachment.Status != domain.AttachmentUploaded {
+		return "", apperror.NotFoundf("attachment with ID %d not found", attachmentID)
+	}
+	switch attachment.ScanStatus {
+	case domain.ScanScanning:
+		return "", ErrAttachmentScanning
+	case domain.ScanInfected:
+		return "", ErrAttachmentInfected
+	}
+
+	presigned, err := s.store.PresignGet(ctx, attachment.ObjectKey, s.cfg.DownloadURLExpiry)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error presigning download for attachment", "attachment_id", attachmentID, "err", err)
+		return "", errors.New("failed to create download URL")
+	}
+	return presigned.URL, nil
+}
+
+// Delete implements AttachmentService.
+func (s *attachmentService) Delete(ctx context.Context, attachmentID uint) error {
+	if err := authz.Check(ctx, authz.WriteTodos); err != nil {
+		return err
+	}
+	if s.store == nil {
+		return ErrStorageNotConfigured
+	}
+
+	attachment, err := s.find(ctx, attachmentID)
+	if err != nil {
+		return err
+	}
+	if err := s.store.Delete(ctx, attachment.ObjectKey); err != nil {
+		logging.FromContext(ctx).Error("Error deleting object for attachment", "attachment_id", attachmentID, "err", err)
+		return errors.New("failed to delete attachment")
+	}
+	if attachment.ThumbnailStatus != "" {
+		for _, size := range thumbnail.Sizes {
+			if err := s.store.Delete(ctx, thumbnailKey(attachment, size)); err != nil {
+				// Orphaned thumbnails are harmless, don't fail the delete
+				logging.FromContext(ctx).Error("Error deleting thumbnail", "size", size.Name, "attachment_id", attachmentID, "err", err)
+			}
+		}
+	}
+	if err := s.repo.Delete(attachment.ID); err != nil {
+		logging.FromContext(ctx).Error("Error deleting attachment", "attachment_id", attachmentID, "err", err)
+		return errors.New("failed to delete attachment")
+	}
+	return nil
+}
+
+// Owner implements AttachmentService.
+func (s *attachmentService) Owner(ctx context.Context, attachmentID uint) (uint, error) {
+	attachment, err := s.find(ctx, attachmentID)
+	if err != nil {
+		return 0, err
+	}
+	// Attachments of a todo in the trash still belong to its owner
+	todo, err := s.todos.FindByID(attachment.TodoID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		todo, err = s.todos.FindTrashedByID(attachment.TodoID)
+	}
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, nil
+		}
+		logging.FromContext(ctx).Error("Error fetching todo of attachment", "attachment_id", attachmentID, "err", err)
+		return 0, errors.New("failed to retrieve attachment")
+	}
+	return todo.UserID, nil
+}
+
+// CleanupPending implements AttachmentService.
+func (s *attachmentService) CleanupPending(ctx context.Context, now time.Time) error {
+	if s.store == nil {
+		return nil
+	}
+
+	stale, err := s.repo.FindByStatusBefore(domain.AttachmentPending, now.Add(-s.cfg.PendingTTL), 100)
+	if err != nil {
+		return fmt.Errorf("fetching stale attachments: %w", err)
+	}
+	for i := range stale {
+		// The client may have uploaded without confirming
+		if err := s.store.Delete(ctx, stale[i].ObjectKey); err != nil {
+			return fmt.Errorf("deleting object for attachment %d: %w", stale[i].ID, err)
+		}
+		if err := s.repo.Delete(stale[i].ID); err != nil {
+			return fmt.Errorf("deleting attachment %d: %w", stale[i].ID, err)
+		}
+	}
+	return nil
+}
+
+// ThumbnailURL implements AttachmentService.
+func (s *attachmentService) ThumbnailURL(ctx context.Context, attachmentID uint, size string) (string, error) {
+	if s.store == nil {
+		return "", ErrStorageNotConfigured
+	}
+
+	thumbSize, err := thumbnail.ParseSize(size)
+	if err != nil {
+		return "", err
+	}
+	attachment, err := s.find(ctx, attachmentID)
+	if err != nil {
+		return "", err
+	}
+	switch {
+	case attachment.Status != domain.AttachmentUploaded:
+		return "", apperror.NotFoundf("attachment with ID %d not found", attachmentID)
+	case attachment.ThumbnailStatus == domain.ThumbnailPending:
+		return "", ErrThumbnailPending
+	case attachment.ThumbnailStatus != domain.ThumbnailReady:
+		return "", apperror.NotFoundf("thumbnail for attachment %d not found", attachmentID)
+	}
+
+	presigned, err := s.store.PresignGet(ctx, thumbnailKey(attachment, thumbSize), s.cfg.DownloadURLExpiry)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error presigning thumbnail for attachment", "attachment_id", attachmentID, "err", err)
+		return "", errors.New("failed to create thumbnail URL")
+	}
+	return presigned.URL, nil
+}
+
+// GenerateThumbnails implements AttachmentService.
+func (s *attachmentService) GenerateThumbnails(ctx context.Context) error {
+	if s.store == nil {
+		return nil
+	}
+
+	pending, err := s.repo.FindByThumbnailStatus(domain.ThumbnailPending, 20)
+	if err != nil {
+		return fmt.Errorf("fetching attachments awaiting thumbnails: %w", err)
+	}
+	for i := range pending {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		attachment := &pending[i]
+
+		attachment.ThumbnailStatus = domain.ThumbnailReady
+		if err := s.generateThumbnails(ctx, attachment); err != nil {
+			if ctx.Err() != nil {
+				// Shutting down; leave it pending for the next run
+				return ctx.Err()
+			}
+			logging.FromContext(ctx).Error("Error generating thumbnails for attachment", "attachment_id", attachment.ID, "err", err)
+			attachment.ThumbnailStatus = domain.ThumbnailFailed
+		}
+		if err := s.repo.Update(attachment); err != nil {
+			return fmt.Errorf("updating attachment %d: %w", attachment.ID, err)
+		}
+	}
+	return nil
+}
+
+func (s *attachmentService) generateThumbnails(ctx context.Context, attachment *domain.Attachment) error {
+	body, _, err := s.store.Get(ctx, attachment.ObjectKey)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+	src, err := io.ReadAll(io.LimitReader(body, s.cfg.ThumbnailMaxSize+1))
+	if err != nil {
+		return err
+	}
+	if int64(len(src)) > s.cfg.ThumbnailMaxSize {
+		return fmt.Errorf("file exceeds the %d byte thumbnail limit", s.cfg.ThumbnailMaxSize)
+	}
+
+	thumbs, err := s.thumbs.Generate(ctx, src, attachment.ContentType)
+	if err != nil {
+		return err
+	}
+	for _, size := range thumbnail.Sizes {
+		if err := s.store.Put(ctx, thumbnailKey(attachment, size), thumbnail.ContentType, thumbs[size.Name]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// queueThumbnails marks an attachment for GenerateThumbnails, which runs in
+// the background so confirming stays fast.
+func (s *attachmentService) queueThumbnails(attachment *domain.Attachment) {
+	if attachment.Size <= s.cfg.ThumbnailMaxSize && s.thumbs.Supports(attachment.ContentType) {
+		attachment.ThumbnailStatus = domain.ThumbnailPending
+	}
+}
+
+// ScanPending implements AttachmentService.
+func (s *attachmentService) ScanPending(ctx context.Context) error {
+	if s.store == nil || s.scanner == nil {
+		return nil
+	}
+
+	pending, err := s.repo.FindByScanStatus(domain.ScanScanning, 20)
+	if err != nil {
+		return fmt.Errorf("fetching attachments awaiting scan: %w", err)
+	}
+	for i := range pending {
+		attachment := &pending[i]
+
+		result, err := s.scanObject(ctx, attachment)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			// No verdict; the file stays quarantined and is retried next run
+			logging.FromContext(ctx).Error("Error scanning attachment", "attachment_id", attachment.ID, "err", err)
+			continue
+		}
+
+		if result.Infected {
+			attachment.ScanStatus = domain.ScanInfected
+			attachment.ScanSignature = result.Signature
+		} else {
+			attachment.ScanStatus = domain.ScanClean
+			s.queueThumbnails(attachment)
+		}
+		if err := s.repo.Update(attachment); err != nil {
+			return fmt.Errorf("updating attachment %d: %w", attachment.ID, err)
+		}
+		if result.Infected {
+			s.reportInfected(ctx, attachment)
+		}
+	}
+	return nil
+}
+
+func (s *attachmentService) scanObject(ctx context.Context, attachment *domain.Attachment) (scan.Result, error) {
+	body, _, err := s.store.Get(ctx, attachment.ObjectKey)
+	if err != nil {
+		return scan.Result{}, err
+	}
+	defer body.Close()
+	return s.scanner.Scan(ctx, body)
+}
+
+// reportInfected emits an event for an infected upload. The object is kept
+// in storage so it can be inspected, but is never served.
+func (s *attachmentService) reportInfected(ctx context.Context, attachment *domain.Attachment) {
+	msg := notify.Message{
+		Recipient: s.cfg.ScanAlertRecipient,
+		Subject:   fmt.Sprintf("Infected upload quarantined: %s", attachment.Filename),
+		Body: fmt.Sprintf("Attachment %d (%q, %s, %d bytes) on todo %d was flagged as %s and quarantined.\nObject key: %s\n",
+			attachment.ID, attachment.Filename, attachment.ContentType, attachment.Size, attachment.TodoID, attachment.ScanSignature, attachment.ObjectKey),
+	}
+	if err := s.notifier.Send(ctx, s.cfg.ScanAlertChannel, msg); err != nil {
+		logging.FromContext(ctx).Error("Error sending infected upload event for attachment", "attachment_id", attachment.ID, "err", err)
+	}
+}
+
+// thumbnailKey stores thumbnails next to the original object.
+func thumbnailKey(attachment *domain.Attachment, size thumbnail.Size) string {
+	return path.Dir(attachment.ObjectKey) + "/thumbnails/" + size.Name + ".jpg"
+}
+
+func (s *attachmentService) find(ctx context.Context, id uint) (*domain.Attachment, error) {
+	attachment, err := s.repo.FindByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.NotFoundf("attachment with ID %d not found", id)
+		}
+		logging.FromContext(ctx).Error("Error fetching attachment", "attachment_id", id, "err", err)
+		return nil, errors.New("failed to retrieve attachment")
+	}
+	return attachment, nil
+}
+
+func (s *attachmentService) allowedContentType(contentType string) bool {
+	for _, allowed := range s.cfg.AllowedContentTypes {
+		if strings.EqualFold(allowed, contentType) {
+			return true
+		}
+	}
+	return false
+}
+
+// sanitizeFilename strips directories and characters that are awkward in
+// object keys and Content-Disposition headers.
+func sanitizeFilename(name string) string {
+	name = path.Base(strings.ReplaceAll(strings.TrimSpace(name), `\`, "/"))
+	if name == "." || name == "/" {
+		return ""
+	}
+	name = strings.Map(func(r rune) rune {
+		switch {
+		case r < 0x20, r == 0x7f, strings.ContainsRune(`"<>|:*?`, r):
+			return '_'
+		}
+		return r
+	}, name)
+	if len(name) > 200 {
+		ext := path.Ext(name)
+		if len(ext) > 20 {
+			ext = ""
+		}
+		name = strings.ToValidUTF8(name[:200-len(ext)], "") + ext
+	}
+	return name
+}
@@ -0,0 +1,62 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Tomlord1122/todo-backend/internal/apperror"
+	"github.com/Tomlord1122/todo-backend/internal/authz"
+	"github.com/Tomlord1122/todo-backend/internal/domain"
+	"github.com/Tomlord1122/todo-backend/internal/repository"
+)
+
+func TestAttachmentWritesRequireWriteTodos(t *testing.T) {
+	repos := repository.NewMemoryRepositories()
+	attachments := NewAttachmentService(repos.Attachments, repos.Todos, nil, nil, nil, nil, AttachmentConfigFromEnv())
+	viewer := authz.NewContext(context.Background(), authz.Principal{UserID: 1, Role: domain.RoleViewer})
+
+	_, presignErr := attachments.Presign(viewer, 1, PresignAttachmentRequest{Filename: "a.pdf", ContentType: "application/pdf", Size: 1})
+	_, confirmErr := attachments.Confirm(viewer, 1, 1)
+	deleteErr := attachments.Delete(viewer, 1)
+	for name, err := range map[string]error{"Presign": presignErr, "Confirm": confirmErr, "Delete": deleteErr} {
+		if !errors.Is(err, apperror.ErrForbidden) {
+			t.Errorf("%s as a viewer = %v, want forbidden", name, err)
+		}
+	}
+}
+
+func TestAttachmentOwner(t *testing.T) {
+	repos := repository.NewMemoryRepositories()
+	attachments := NewAttachmentService(repos.Attachments, repos.Todos, nil, nil, nil, nil, AttachmentConfigFromEnv())
+	ctx := context.Background()
+
+	todo := &domain.Todo{Title: "Receipts", UserID: 7}
+	if err := repos.Todos.Create(todo); err != nil {
+		t.Fatal(err)
+	}
+	attachment := &domain.Attachment{TodoID: todo.ID, Filename: "a.pdf", ObjectKey: "todos/1/a.pdf"}
+	if err := repos.Attachments.Create(attachment); err != nil {
+		t.Fatal(err)
+	}
+
+	if owner, err := attachments.Owner(ctx, attachment.ID); err != nil || owner != 7 {
+		t.Errorf("Owner() = %d, %v, want the todo's owner 7", owner, err)
+	}
+	// Trashed todos keep their attachments' owner; purged ones leave none
+	if err := repos.Todos.Delete(todo); err != nil {
+		t.Fatal(err)
+	}
+	if owner, err := attachments.Owner(ctx, attachment.ID); err != nil || owner != 7 {
+		t.Errorf("Owner() of a trashed todo's attachment = %d, %v, want 7", owner, err)
+	}
+	if err := repos.Todos.Purge(todo.ID); err != nil {
+		t.Fatal(err)
+	}
+	if owner, err := attachments.Owner(ctx, attachment.ID); err != nil || owner != 0 {
+		t.Errorf("Owner() of a purged todo's attachment = %d, %v, want 0", owner, err)
+	}
+	if _, err := attachments.Owner(ctx, 99); !errors.Is(err, apperror.ErrNotFound) {
+		t.Errorf("Owner() of a missing attachment = %v, want not found", err)
+	}
+}
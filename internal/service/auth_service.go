@@ -0,0 +1,200 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/mail"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Tomlord1122/todo-backend/internal/apperror"
+	"github.com/Tomlord1122/todo-backend/internal/domain"
+	"github.com/Tomlord1122/todo-backend/internal/jwt"
+	"github.com/Tomlord1122/todo-backend/internal/logging"
+	"github.com/Tomlord1122/todo-backend/internal/repository"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrInvalidCredentials is returned for an unknown email address or a
+	// wrong password; which one is deliberately not told.
+	ErrInvalidCredentials = apperror.New(apperror.CodeUnauthenticated, "invalid email or password")
+	// ErrEmailTaken is returned when registering an email address that
+	// already has an account.
+	ErrEmailTaken = apperror.New(apperror.CodeConflict, "an account with this email address already exists")
+)
+
+// Password length limits. bcrypt ignores everything after 72 bytes.
+const (
+	minPasswordLength = 8
+	maxPasswordLength = 72
+)
+
+// RegisterRequest creates an account.
+type RegisterRequest struct {
+	Email    string `json:"email" validate:"notblank"`
+	Password string `json:"password" validate:"notblank"`
+	Name     string `json:"name,omitempty"`
+}
+
+// LoginRequest signs in with a password.
+type LoginRequest struct {
+	Email    string `json:"email" validate:"notblank"`
+	Password string `json:"password" validate:"notblank"`
+}
+
+// AccessTokenResponse is returned by register and login. The token is
+// sent as "Authorization: Bearer <token>" until it expires, then the user
+// logs in again.
+type AccessTokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresAt   string `json:"expires_at"`
+	UserID      uint   `json:"user_id"`
+}
+
+// AuthConfig tunes password logins.
+type AuthConfig struct {
+	// Secret signs access tokens. Every instance needs the same one.
+	Secret []byte
+	// TTL is how long an access token is valid.
+	TTL time.Duration
+}
+
+// tokenIssuer is the iss claim of access tokens.
+const tokenIssuer = "todo-backend"
+
+// AuthService registers users and signs them in with a password, issuing
+// short-lived JWT access tokens that are checked without a database
+// lookup.
+type AuthService interface {
+	// Register creates an account and signs it in. The first account is
+	// an admin, later ones are members.
+	Register(ctx context.Context, req RegisterRequest, now time.Time) (*AccessTokenResponse, error)
+
+	// Login signs in with an email address and password.
+	Login(ctx context.Context, req LoginRequest, now time.Time) (*AccessTokenResponse, error)
+
+	// Authenticate returns the user an access token was issued to.
+	Authenticate(ctx context.Context, token string, now time.Time) (uint, error)
+}
+
+type authService struct {
+	users  repository.UserRepository
+	signer *jwt.Signer
+	cfg    AuthConfig
+	// dummyHash is compared against for unknown users, so a login takes
+	// as long whether or not the email address has an account
+	dummyHash []byte
+}
+
+// NewAuthService creates a new AuthService. cfg.Secret must be at least
+// jwt.MinKeyLength bytes.
+func NewAuthService(users repository.UserRepository, cfg AuthConfig) (AuthService, error) {
+	signer, err := jwt.NewSigner(cfg.Secret, tokenIssuer)
+	if err != nil {
+		return nil, err
+	}
+	dummyHash, err := bcrypt.GenerateFromPassword([]byte("not a real password"), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+	return &authService{users: users, signer: signer, cfg: cfg, dummyHash: dummyHash}, nil
+}
+
+// normalizeEmail validates an email address and lower-cases it.
+func normalizeEmail(email string) (string, error) {
+	addr, err := mail.ParseAddress(strings.TrimSpace(email))
+	if err != nil || addr.Name != "" {
+		return "", apperror.Invalidf("invalid email address")
+	}
+	return strings.ToLower(addr.Address), nil
+}
+
+// Register implements AuthService.
+func (s *authService) Register(ctx context.Context, req RegisterRequest, now time.Time) (*AccessTokenResponse, error) {
+	email, err := normalizeEmail(req.Email)
+	if err != nil {
+		return nil, err
+	}
+	if len(req.Password) < minPasswordLength || len(req.Password) > maxPasswordLength {
+		return nil, apperror.Invalidf("invalid password, must be %d to %d bytes long", minPasswordLength, maxPasswordLength)
+	}
+
+	if _, err := s.users.FindByEmail(email); err == nil {
+		return nil, ErrEmailTaken
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		logging.FromContext(ctx).Error("Error checking for an existing account", "err", err)
+		return nil, errors.New("failed to register")
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error hashing password", "err", err)
+		return nil, errors.New("failed to register")
+	}
+	// The first account administers the others
+	role := domain.RoleMember
+	if users, err := s.users.CountByRole(""); err != nil {
+		logging.FromContext(ctx).Error("Error counting users", "err", err)
+		return nil, errors.New("failed to register")
+	} else if users == 0 {
+		role = domain.RoleAdmin
+	}
+	user := &domain.User{Email: email, Name: strings.TrimSpace(req.Name), PasswordHash: string(hash), Role: role}
+	if err := s.users.Create(user); err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return nil, ErrEmailTaken
+		}
+		logging.FromContext(ctx).Error("Error creating user", "err", err)
+		return nil, errors.New("failed to register")
+	}
+	return s.issue(user.ID, now), nil
+}
+
+// Login implements AuthService.
+func (s *authService) Login(ctx context.Context, req LoginRequest, now time.Time) (*AccessTokenResponse, error) {
+	email, err := normalizeEmail(req.Email)
+	if err != nil {
+		return nil, ErrInvalidCredentials
+	}
+	user, err := s.users.FindByEmail(email)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			logging.FromContext(ctx).Error("Error looking up user for login", "err", err)
+			return nil, errors.New("failed to sign in")
+		}
+		bcrypt.CompareHashAndPassword(s.dummyHash, []byte(req.Password))
+		return nil, ErrInvalidCredentials
+	}
+	if bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)) != nil {
+		return nil, ErrInvalidCredentials
+	}
+	return s.issue(user.ID, now), nil
+}
+
+// Authenticate implements AuthService.
+func (s *authService) Authenticate(ctx context.Context, token string, now time.Time) (uint, error) {
+	claims, err := s.signer.Verify(token, now)
+	if err != nil {
+		return 0, ErrUnauthenticated
+	}
+	userID, err := strconv.ParseUint(claims.Subject, 10, 64)
+	if err != nil || userID == 0 {
+		return 0, ErrUnauthenticated
+	}
+	return uint(userID), nil
+}
+
+func (s *authService) issue(userID uint, now time.Time) *AccessTokenResponse {
+	token, claims := s.signer.Issue(strconv.FormatUint(uint64(userID), 10), now, s.cfg.TTL)
+	return &AccessTokenResponse{
+		AccessToken: token,
+		TokenType:   "Bearer",
+		ExpiresAt:   time.Unix(claims.ExpiresAt, 0).UTC().Format(time.RFC3339),
+		UserID:      userID,
+	}
+}
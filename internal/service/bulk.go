@@ -0,0 +1,244 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Tomlord1122/todo-backend/internal/apperror"
+	"github.com/Tomlord1122/todo-backend/internal/authz"
+	"github.com/Tomlord1122/todo-backend/internal/domain"
+	"github.com/Tomlord1122/todo-backend/internal/events"
+	"github.com/Tomlord1122/todo-backend/internal/logging"
+	"github.com/Tomlord1122/todo-backend/internal/repository"
+	"github.com/Tomlord1122/todo-backend/internal/tracing"
+
+	"gorm.io/gorm"
+)
+
+// maxBulkItems bounds the items of one bulk request.
+const maxBulkItems = 100
+
+// BulkUpdateItem changes one todo in a bulk update.
+type BulkUpdateItem struct {
+	ID uint `json:"id"`
+	UpdateTodoRequest
+}
+
+// BulkResult is the outcome of one item of a bulk request, in request
+// order. Items fail on their own: a failed item doesn't stop the others.
+type BulkResult struct {
+	// Index is the item's position in the request
+	Index int  `json:"index"`
+	ID    uint `json:"id,omitempty"`
+	// Status is the HTTP status the item would have had on its own
+	Status int           `json:"status"`
+	Error  string        `json:"error,omitempty"`
+	Todo   *TodoResponse `json:"todo,omitempty"`
+	// Err is the item's failure, which the handler turns into Status and
+	// Error
+	Err error `json:"-"`
+}
+
+// bulkItem is a valid item of a bulk request, waiting to be written.
+type bulkItem struct {
+	index  int
+	todo   *domain.Todo
+	change todoChange
+}
+
+// checkBulkSize rejects empty and oversized bulk requests.
+func checkBulkSize(n int) error {
+	if n == 0 {
+		return apperror.Invalidf("invalid bulk request: no items given")
+	}
+	if n > maxBulkItems {
+		return apperror.Invalidf("invalid bulk request: at most %d items are allowed", maxBulkItems)
+	}
+	return nil
+}
+
+// BulkCreateTodos implements TodoService.
+func (s *todoService) BulkCreateTodos(ctx context.Context, reqs []CreateTodoRequest) ([]BulkResult, error) {
+	ctx, span := tracing.Start(ctx, "TodoService.BulkCreateTodos")
+	defer span.End()
+	if err := authz.Check(ctx, authz.WriteTodos); err != nil {
+		return nil, err
+	}
+	if err := checkBulkSize(len(reqs)); err != nil {
+		return nil, err
+	}
+
+	results := make([]BulkResult, len(reqs))
+	var items []bulkItem
+	var todos []*domain.Todo
+	for i, req := range reqs {
+		results[i].Index = i
+		todo, err := s.newTodo(ctx, req)
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+		items = append(items, bulkItem{index: i, todo: todo})
+		todos = append(todos, todo)
+	}
+	if len(todos) > 0 {
+		err := s.todoRepo(ctx).Transaction(func(repo repository.TodoRepository, outbox repository.OutboxRepository) error {
+			if err := repo.CreateMany(todos); err != nil {
+				return err
+			}
+			return addEvents(ctx, outbox, events.TodoCreated, todos)
+		})
+		if err != nil {
+			logging.FromContext(ctx).Error("Error creating todos", "count", len(todos), "err", err)
+			return nil, errors.New("failed to create todo items")
+		}
+	}
+
+	for _, item := range items {
+		response := s.created(ctx, item.todo)
+		results[item.index].ID = item.todo.ID
+		results[item.index].Todo = &response
+	}
+	return results, nil
+}
+
+// BulkUpdateTodos implements TodoService.
+func (s *todoService) BulkUpdateTodos(ctx context.Context, owner uint, reqs []BulkUpdateItem) ([]BulkResult, error) {
+	ctx, span := tracing.Start(ctx, "TodoService.BulkUpdateTodos")
+	defer span.End()
+	if err := authz.Check(ctx, authz.WriteTodos); err != nil {
+		return nil, err
+	}
+	if err := checkBulkSize(len(reqs)); err != nil {
+		return nil, err
+	}
+
+	results := make([]BulkResult, len(reqs))
+	seen := make(map[uint]bool, len(reqs))
+	var items []bulkItem
+	var todos []*domain.Todo
+	for i, req := range reqs {
+		results[i] = BulkResult{Index: i, ID: req.ID}
+		todo, err := s.findBulkTodo(ctx, req.ID, owner, seen, "update")
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+		change, err := s.applyUpdate(ctx, todo, req.UpdateTodoRequest)
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+		if !change.updated {
+			response := toTodoResponse(todo)
+			results[i].Todo = &response
+			continue
+		}
+		items = append(items, bulkItem{index: i, todo: todo, change: change})
+		todos = append(todos, todo)
+	}
+	if len(todos) > 0 {
+		err := s.todoRepo(ctx).Transaction(func(repo repository.TodoRepository, outbox repository.OutboxRepository) error {
+			if err := repo.UpdateMany(todos); err != nil {
+				return err
+			}
+			return addEvents(ctx, outbox, events.TodoUpdated, todos)
+		})
+		if errors.Is(err, repository.ErrVersionConflict) {
+			return nil, ErrTodoChanged
+		} else if err != nil {
+			logging.FromContext(ctx).Error("Error updating todos", "count", len(todos), "err", err)
+			return nil, errors.New("failed to update todo items")
+		}
+	}
+
+	for _, item := range items {
+		response := s.updated(ctx, item.todo, item.change)
+		results[item.index].Todo = &response
+	}
+	return results, nil
+}
+
+// BulkDeleteTodos implements TodoService.
+func (s *todoService) BulkDeleteTodos(ctx context.Context, owner uint, ids []uint) ([]BulkResult, error) {
+	ctx, span := tracing.Start(ctx, "TodoService.BulkDeleteTodos")
+	defer span.End()
+	if err := authz.Check(ctx, authz.WriteTodos); err != nil {
+		return nil, err
+	}
+	if err := checkBulkSize(len(ids)); err != nil {
+		return nil, err
+	}
+
+	results := make([]BulkResult, len(ids))
+	seen := make(map[uint]bool, len(ids))
+	var todos []*domain.Todo
+	var found []uint
+	for i, id := range ids {
+		results[i] = BulkResult{Index: i, ID: id}
+		todo, err := s.findBulkTodo(ctx, id, owner, seen, "delete")
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+		todos = append(todos, todo)
+		found = append(found, id)
+	}
+	if len(found) > 0 {
+		err := s.todoRepo(ctx).Transaction(func(repo repository.TodoRepository, outbox repository.OutboxRepository) error {
+			if err := repo.DeleteMany(found); err != nil {
+				return err
+			}
+			for _, todo := range todos {
+				if err := addEvent(ctx, outbox, events.TodoDeleted, todo, nil); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			logging.FromContext(ctx).Error("Error deleting todos", "count", len(found), "err", err)
+			return nil, errors.New("failed to delete todo items")
+		}
+	}
+
+	for _, todo := range todos {
+		s.deleted(ctx, todo)
+	}
+	return results, nil
+}
+
+// addEvents adds a domain event of eventType for each of todos to outbox,
+// with the todo as its data.
+func addEvents(ctx context.Context, outbox repository.OutboxRepository, eventType string, todos []*domain.Todo) error {
+	for _, todo := range todos {
+		if err := addEvent(ctx, outbox, eventType, todo, toTodoResponse(todo)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// findBulkTodo loads the todo of a bulk item for action. Todos of users
+// other than owner are reported as not found, unless owner is 0, and so
+// are todos already seen in the same request.
+func (s *todoService) findBulkTodo(ctx context.Context, id, owner uint, seen map[uint]bool, action string) (*domain.Todo, error) {
+	if id == 0 {
+		return nil, apperror.Invalidf("invalid item: id is required")
+	}
+	if seen[id] {
+		return nil, apperror.Invalidf("invalid item: todo %d is listed more than once", id)
+	}
+	seen[id] = true
+
+	todo, err := s.todoRepo(ctx).FindByID(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && owner != 0 && todo.UserID != owner) {
+		return nil, apperror.NotFoundf("todo with ID %d not found", id)
+	}
+	if err != nil {
+		logging.FromContext(ctx).Error("Error fetching todo", "todo_id", id, "action", action, "err", err)
+		return nil, fmt.Errorf("failed to %s todo item", action)
+	}
+	return todo, nil
+}
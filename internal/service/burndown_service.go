@@ -0,0 +1,138 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math"
+	"time"
+
+	"github.com/Tomlord1122/todo-backend/internal/apperror"
+	"github.com/Tomlord1122/todo-backend/internal/domain"
+	"github.com/Tomlord1122/todo-backend/internal/logging"
+	"github.com/Tomlord1122/todo-backend/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+// burndownKinds are the activities that change how much estimate is open.
+var burndownKinds = []string{
+	domain.ActivityCreated, domain.ActivityCompleted, domain.ActivityReopened,
+	domain.ActivityEstimated, domain.ActivityMoved, domain.ActivityDeleted,
+	domain.ActivityRestored,
+}
+
+// BurndownRequest selects the days of a burndown chart. Dates are
+// YYYY-MM-DD in Timezone, both inclusive; they default to the list's
+// creation day through today.
+type BurndownRequest struct {
+	From string
+	To   string
+	// Timezone overrides the list owner's timezone preference
+	Timezone string
+}
+
+// BurndownResponse is the open estimate of a list at the end of each day.
+type BurndownResponse struct {
+	ListID   uint            `json:"list_id"`
+	Timezone string          `json:"timezone"`
+	From     string          `json:"from"`
+	To       string          `json:"to"`
+	Points   []BurndownPoint `json:"points"`
+}
+
+// BurndownPoint is the estimate still open at the end of Date.
+type BurndownPoint struct {
+	Date      string  `json:"date"`
+	Remaining float64 `json:"remaining"`
+}
+
+// BurndownService computes burndown charts for lists.
+type BurndownService interface {
+	// GetBurndown replays the list's activity history. Todos changed only
+	// before history was recorded don't appear in it.
+	GetBurndown(ctx context.Context, listID uint, req BurndownRequest, now time.Time) (*BurndownResponse, error)
+}
+
+type burndownService struct {
+	lists      repository.ListRepository
+	activities repository.ActivityRepository
+	prefs      repository.PreferenceRepository
+}
+
+// NewBurndownService creates a new BurndownService.
+func NewBurndownService(lists repository.ListRepository, activities repository.ActivityRepository, prefs repository.PreferenceRepository) BurndownService {
+	return &burndownService{lists: lists, activities: activities, prefs: prefs}
+}
+
+// GetBurndown implements BurndownService.
+func (s *burndownService) GetBurndown(ctx context.Context, listID uint, req BurndownRequest, now time.Time) (*BurndownResponse, error) {
+	list, err := s.lists.FindByID(listID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.NotFoundf("list with ID %d not found", listID)
+		}
+		logging.FromContext(ctx).Error("Error fetching list for burndown", "list_id", listID, "err", err)
+		return nil, errors.New("failed to compute burndown")
+	}
+	pref, err := loadPreferences(s.prefs, list.UserID)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error fetching preferences for user", "user_id", list.UserID, "err", err)
+		return nil, errors.New("failed to compute burndown")
+	}
+	loc := userLocation(pref)
+	if req.Timezone != "" {
+		if loc, err = time.LoadLocation(req.Timezone); err != nil {
+			return nil, apperror.Invalidf("invalid timezone %q", req.Timezone)
+		}
+	}
+
+	from, to, err := parseDateRange(req.From, req.To, loc, now, func(to time.Time) time.Time {
+		y, m, d := list.CreatedAt.In(loc).Date()
+		created := time.Date(y, m, d, 0, 0, 0, 0, loc)
+		// Old lists default to the most recent days that fit in a chart
+		if earliest := to.AddDate(0, 0, 1-maxStatsDays); created.Before(earliest) {
+			return earliest
+		}
+		return created
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	activities, err := s.activities.FindForList(listID, burndownKinds)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error fetching activity for list", "list_id", listID, "err", err)
+		return nil, errors.New("failed to compute burndown")
+	}
+
+	// Replay the history day by day: each todo contributes the estimate
+	// open after its latest change, or nothing once it left the list
+	open := make(map[uint]float64)
+	next := 0
+	points := []BurndownPoint{}
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		end := day.AddDate(0, 0, 1)
+		for ; next < len(activities) && activities[next].CreatedAt.Before(end); next++ {
+			a := &activities[next]
+			if a.ListID != nil && *a.ListID == listID {
+				open[a.TodoID] = a.OpenEstimate
+			} else {
+				delete(open, a.TodoID)
+			}
+		}
+		var remaining float64
+		for _, estimate := range open {
+			remaining += estimate
+		}
+		// Round away float noise from summing fractional points
+		points = append(points, BurndownPoint{Date: day.Format(time.DateOnly), Remaining: math.Round(remaining*100) / 100})
+	}
+
+	return &BurndownResponse{
+		ListID:   listID,
+		Timezone: loc.String(),
+		From:     from.Format(time.DateOnly),
+		To:       to.Format(time.DateOnly),
+		Points:   points,
+	}, nil
+}
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/Tomlord1122/todo-backend/internal/apperror"
+	"github.com/Tomlord1122/todo-backend/internal/domain"
+	"github.com/Tomlord1122/todo-backend/internal/repository"
+)
+
+// newBurndownFixture creates a list on January 6th 2025 and the history of
+// three todos in it, and returns the service and the list.
+func newBurndownFixture(t *testing.T) (BurndownService, *domain.List) {
+	t.Helper()
+	repos := repository.NewMemoryRepositories()
+	at := func(day, hour int) gorm.Model {
+		return gorm.Model{CreatedAt: time.Date(2025, 1, day, hour, 0, 0, 0, time.UTC)}
+	}
+	list := &domain.List{Model: at(6, 9), Name: "Sprint", UserID: 1}
+	if err := repos.Lists.Create(list); err != nil {
+		t.Fatal(err)
+	}
+	other := uint(99)
+	for _, a := range []domain.Activity{
+		{Model: at(6, 10), TodoID: 1, Kind: domain.ActivityCreated, ListID: &list.ID, OpenEstimate: 3},
+		{Model: at(6, 11), TodoID: 2, Kind: domain.ActivityCreated, ListID: &list.ID, OpenEstimate: 5},
+		{Model: at(7, 9), TodoID: 1, Kind: domain.ActivityCompleted, ListID: &list.ID},
+		{Model: at(8, 9), TodoID: 2, Kind: domain.ActivityMoved, ListID: &other, OldValue: "1"},
+		{Model: at(8, 10), TodoID: 3, Kind: domain.ActivityCreated, ListID: &list.ID, OpenEstimate: 2.5},
+	} {
+		if err := repos.Activities.Create(&a); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return NewBurndownService(repos.Lists, repos.Activities, repos.Preferences), list
+}
+
+func TestBurndownDailySeries(t *testing.T) {
+	burndown, list := newBurndownFixture(t)
+	now := time.Date(2025, 1, 9, 12, 0, 0, 0, time.UTC)
+
+	// The range defaults to the list's creation day through today
+	got, err := burndown.GetBurndown(context.Background(), list.ID, BurndownRequest{}, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []BurndownPoint{
+		{Date: "2025-01-06", Remaining: 8},
+		// Completing a todo burns its estimate down
+		{Date: "2025-01-07", Remaining: 5},
+		// Moving one out of the list removes it; a new one adds to it
+		{Date: "2025-01-08", Remaining: 2.5},
+		{Date: "2025-01-09", Remaining: 2.5},
+	}
+	if got.From != "2025-01-06" || got.To != "2025-01-09" || !reflect.DeepEqual(got.Points, want) {
+		t.Errorf("GetBurndown = %s to %s %+v, want 2025-01-06 to 2025-01-09 %+v", got.From, got.To, got.Points, want)
+	}
+
+	// A later start still counts the history before it
+	got, err = burndown.GetBurndown(context.Background(), list.ID, BurndownRequest{From: "2025-01-07", To: "2025-01-08"}, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got.Points, want[1:3]) {
+		t.Errorf("Points from the 7th to the 8th = %+v, want %+v", got.Points, want[1:3])
+	}
+}
+
+func TestBurndownValidation(t *testing.T) {
+	burndown, list := newBurndownFixture(t)
+	now := time.Date(2025, 1, 9, 12, 0, 0, 0, time.UTC)
+
+	for _, req := range []BurndownRequest{
+		{From: "2025-01-08", To: "2025-01-07"},
+		{From: "Jan 7"},
+		{To: "2025-13-01"},
+		{From: "2023-01-01", To: "2025-01-09"},
+		{Timezone: "Nowhere/Special"},
+	} {
+		if _, err := burndown.GetBurndown(context.Background(), list.ID, req, now); !errors.Is(err, apperror.ErrInvalid) {
+			t.Errorf("GetBurndown(%+v) = %v, want invalid", req, err)
+		}
+	}
+	if _, err := burndown.GetBurndown(context.Background(), 999, BurndownRequest{}, now); !errors.Is(err, apperror.ErrNotFound) {
+		t.Errorf("GetBurndown of a missing list = %v, want not found", err)
+	}
+}
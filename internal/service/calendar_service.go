@@ -0,0 +1,341 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/Tomlord1122/todo-backend/internal/apperror"
+	"github.com/Tomlord1122/todo-backend/internal/domain"
+	"github.com/Tomlord1122/todo-backend/internal/gcal"
+	"github.com/Tomlord1122/todo-backend/internal/logging"
+	"github.com/Tomlord1122/todo-backend/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// calendarName is the summary of the calendar created for todos.
+	calendarName = "Todos"
+	// calendarEventLength is how long the event of a todo lasts.
+	calendarEventLength = 30 * time.Minute
+	// todoIDProperty tags events with the todo they belong to.
+	todoIDProperty = "todo_id"
+)
+
+// ErrCalendarNotConfigured is returned when the Google OAuth client isn't
+// configured.
+var ErrCalendarNotConfigured = apperror.New(apperror.CodeUnavailable, "Google Calendar sync is not configured")
+
+// ConnectCalendarRequest completes the OAuth consent flow: the client sends
+// the user to Google's consent screen with scope gcal.Scope and
+// access_type=offline, then passes the code it got back here.
+type ConnectCalendarRequest struct {
+	Code        string `json:"code"`
+	RedirectURI string `json:"redirect_uri"`
+}
+
+// CalendarConnectionResponse describes a user's calendar connection.
+type CalendarConnectionResponse struct {
+	UserID       uint    `json:"user_id"`
+	CalendarID   string  `json:"calendar_id"`
+	LastSyncedAt *string `json:"last_synced_at,omitempty"`
+	CreatedAt    string  `json:"created_at"`
+}
+
+// CalendarService syncs todos with due dates to a dedicated Google
+// Calendar. Todos are written as events; moving an event in the calendar
+// moves the todo's due date.
+type CalendarService interface {
+	// Connect stores the user's tokens and creates the calendar.
+	Connect(ctx context.Context, userID uint, req ConnectCalendarRequest) (*CalendarConnectionResponse, error)
+
+	// GetConnection returns the user's connection.
+	GetConnection(ctx context.Context, userID uint) (*CalendarConnectionResponse, error)
+
+	// Disconnect stops syncing. The calendar and its events are kept.
+	Disconnect(ctx context.Context, userID uint) error
+
+	// SyncAll syncs every connection.
+	SyncAll(ctx context.Context, now time.Time) error
+}
+
+type calendarService struct {
+	repo   repository.CalendarRepository
+	todos  repository.TodoRepository
+	todoSv TodoService
+	client *gcal.Client
+}
+
+// NewCalendarService creates a new CalendarService. client may be nil, in
+// which case connecting returns ErrCalendarNotConfigured and syncing does
+// nothing. Due date changes go through todoSv so they're recorded in the
+// history and restart overdue reminders.
+func NewCalendarService(repo repository.CalendarRepository, todos repository.TodoRepository, todoSv TodoService, client *gcal.Client) CalendarService {
+	return &calendarService{repo: repo, todos: todos, todoSv: todoSv, client: client}
+}
+
+func toCalendarConnectionResponse(conn *domain.CalendarConnection) *CalendarConnectionResponse {
+	return &CalendarConnectionResponse{
+		UserID:       conn.UserID,
+		CalendarID:   conn.CalendarID,
+		LastSyncedAt: formatOptionalTime(conn.LastSyncedAt),
+		CreatedAt:    conn.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+// Connect implements CalendarService.
+func (s *calendarService) Connect(ctx context.Context, userID uint, req ConnectCalendarRequest) (*CalendarConnectionResponse, error) {
+	if s.client == nil {
+		return nil, ErrCalendarNotConfigured
+	}
+	if req.Code == "" || req.RedirectURI == "" {
+		return nil, apperror.Invalidf("invalid request: code and redirect_uri are required")
+	}
+	if _, err := s.repo.FindConnectionByUser(userID); err == nil {
+		return nil, apperror.Invalidf("invalid request: user %d is already connected, disconnect first", userID)
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		logging.FromContext(ctx).Error("Error fetching calendar connection of user", "user_id", userID, "err", err)
+		return nil, errors.New("failed to connect calendar")
+	}
+
+	token, err := s.client.Exchange(ctx, req.Code, req.RedirectURI)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error exchanging Google authorization code for user", "user_id", userID, "err", err)
+		return nil, apperror.Invalidf("invalid code: Google rejected the authorization code")
+	}
+	if token.RefreshToken == "" {
+		return nil, apperror.Invalidf("invalid code: no refresh token was granted, request access_type=offline")
+	}
+	calendarID, err := s.client.CreateCalendar(ctx, token.AccessToken, calendarName)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error creating Google calendar for user", "user_id", userID, "err", err)
+		return nil, errors.New("failed to connect calendar")
+	}
+
+	conn := &domain.CalendarConnection{
+		UserID:            userID,
+		RefreshToken:      token.RefreshToken,
+		AccessToken:       token.AccessToken,
+		AccessTokenExpiry: &token.Expiry,
+		CalendarID:        calendarID,
+	}
+	if err := s.repo.CreateConnection(conn); err != nil {
+		logging.FromContext(ctx).Error("Error creating calendar connection for user", "user_id", userID, "err", err)
+		return nil, errors.New("failed to connect calendar")
+	}
+	// A failed first sync is retried by the job; the connection itself is fine
+	if err := s.syncConnection(ctx, conn, time.Now()); err != nil {
+		logging.FromContext(ctx).Error("Error running first calendar sync of user", "user_id", userID, "err", err)
+	}
+	return toCalendarConnectionResponse(conn), nil
+}
+
+// GetConnection implements CalendarService.
+func (s *calendarService) GetConnection(ctx context.Context, userID uint) (*CalendarConnectionResponse, error) {
+	conn, err := s.findConnection(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return toCalendarConnectionResponse(conn), nil
+}
+
+// Disconnect implements CalendarService.
+func (s *calendarService) Disconnect(ctx context.Context, userID uint) error {
+	conn, err := s.findConnection(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.DeleteConnection(conn.ID); err != nil {
+		logging.FromContext(ctx).Error("Error deleting calendar connection of user", "user_id", userID, "err", err)
+		return errors.New("failed to disconnect calendar")
+	}
+	return nil
+}
+
+func (s *calendarService) findConnection(ctx context.Context, userID uint) (*domain.CalendarConnection, error) {
+	conn, err := s.repo.FindConnectionByUser(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.NotFoundf("calendar connection of user %d not found", userID)
+		}
+		logging.FromContext(ctx).Error("Error fetching calendar connection of user", "user_id", userID, "err", err)
+		return nil, errors.New("failed to retrieve calendar connection")
+	}
+	return conn, nil
+}
+
+// SyncAll implements CalendarService. A failing connection doesn't stop
+// the others.
+func (s *calendarService) SyncAll(ctx context.Context, now time.Time) error {
+	if s.client == nil {
+		return nil
+	}
+	conns, err := s.repo.FindConnections()
+	if err != nil {
+		return fmt.Errorf("finding calendar connections: %w", err)
+	}
+	var failed int
+	for i := range conns {
+		if err := s.syncConnection(ctx, &conns[i], now); err != nil {
+			logging.FromContext(ctx).Error("Error syncing calendar of user", "user_id", conns[i].UserID, "err", err)
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d calendar connections failed to sync", failed, len(conns))
+	}
+	return nil
+}
+
+// syncConnection first applies event changes made in the calendar, then
+// writes todo changes to it, so when both sides moved the calendar wins.
+func (s *calendarService) syncConnection(ctx context.Context, conn *domain.CalendarConnection, now time.Time) error {
+	accessToken, err := s.accessToken(ctx, conn, now)
+	if err != nil {
+		return err
+	}
+	events, err := s.repo.FindEvents(conn.ID)
+	if err != nil {
+		return fmt.Errorf("finding synced events: %w", err)
+	}
+	byEvent := make(map[string]*domain.CalendarEvent, len(events))
+	for i := range events {
+		byEvent[events[i].EventID] = &events[i]
+	}
+
+	syncToken, err := s.pull(ctx, conn, accessToken, byEvent)
+	if err != nil {
+		return err
+	}
+	if err := s.push(ctx, conn, accessToken, byEvent); err != nil {
+		return err
+	}
+
+	conn.SyncToken = syncToken
+	conn.LastSyncedAt = &now
+	return s.repo.UpdateConnection(conn)
+}
+
+// accessToken returns a valid access token, refreshing it when it expires
+// within a minute.
+func (s *calendarService) accessToken(ctx context.Context, conn *domain.CalendarConnection, now time.Time) (string, error) {
+	if conn.AccessToken != "" && conn.AccessTokenExpiry != nil && conn.AccessTokenExpiry.After(now.Add(time.Minute)) {
+		return conn.AccessToken, nil
+	}
+	token, err := s.client.Refresh(ctx, conn.RefreshToken)
+	if err != nil {
+		return "", fmt.Errorf("refreshing access token: %w", err)
+	}
+	conn.AccessToken, conn.AccessTokenExpiry = token.AccessToken, &token.Expiry
+	if err := s.repo.UpdateConnection(conn); err != nil {
+		return "", err
+	}
+	return conn.AccessToken, nil
+}
+
+// pull moves todos whose events were moved in the calendar and forgets
+// events deleted there, so push recreates them. It returns the sync token
+// for the next pull.
+func (s *calendarService) pull(ctx context.Context, conn *domain.CalendarConnection, accessToken string, byEvent map[string]*domain.CalendarEvent) (string, error) {
+	changed, syncToken, err := s.client.ListEvents(ctx, accessToken, conn.CalendarID, conn.SyncToken)
+	if errors.Is(err, gcal.ErrSyncTokenExpired) {
+		changed, syncToken, err = s.client.ListEvents(ctx, accessToken, conn.CalendarID, "")
+	}
+	if err != nil {
+		return "", fmt.Errorf("listing events: %w", err)
+	}
+
+	for _, event := range changed {
+		synced := byEvent[event.ID]
+		if synced == nil {
+			continue
+		}
+		if event.Status == "cancelled" {
+			if err := s.repo.DeleteEvent(synced.ID); err != nil {
+				return "", err
+			}
+			delete(byEvent, event.ID)
+			continue
+		}
+		start, err := event.Start.Time()
+		if err != nil {
+			logging.FromContext(ctx).Error("Error reading start of event", "event_id", event.ID, "err", err)
+			continue
+		}
+		if start.Equal(synced.SyncedDue) {
+			continue
+		}
+		if _, err := s.todoSv.UpdateTodo(ctx, synced.TodoID, UpdateTodoRequest{DueDate: &start}); err != nil {
+			// E.g. the todo was deleted, or now starts after it's due;
+			// push puts the event back where the todo is
+			logging.FromContext(ctx).Error("Error moving todo to its event time", "todo_id", synced.TodoID, "err", err)
+			continue
+		}
+		synced.SyncedDue = start
+		if err := s.repo.UpdateEvent(synced); err != nil {
+			return "", err
+		}
+	}
+	return syncToken, nil
+}
+
+// push writes todos with due dates as events and deletes the events of
+// todos that were deleted or lost their due date.
+func (s *calendarService) push(ctx context.Context, conn *domain.CalendarConnection, accessToken string, byEvent map[string]*domain.CalendarEvent) error {
+	todos, err := s.todos.FindScheduledByUser(conn.UserID)
+	if err != nil {
+		return fmt.Errorf("finding todos with due dates: %w", err)
+	}
+	byTodo := make(map[uint]*domain.CalendarEvent, len(byEvent))
+	for _, synced := range byEvent {
+		byTodo[synced.TodoID] = synced
+	}
+
+	for i := range todos {
+		todo := &todos[i]
+		// The calendar API has second precision
+		due := todo.DueDate.Truncate(time.Second)
+		event := gcal.Event{
+			Summary: todo.Title,
+			Start:   gcal.NewEventTime(due),
+			End:     gcal.NewEventTime(due.Add(calendarEventLength)),
+		}
+
+		synced := byTodo[todo.ID]
+		delete(byTodo, todo.ID)
+		switch {
+		case synced == nil:
+			event.Description = todo.Description
+			event.ExtendedProperties = &gcal.ExtendedProperties{Private: map[string]string{todoIDProperty: strconv.FormatUint(uint64(todo.ID), 10)}}
+			created, err := s.client.InsertEvent(ctx, accessToken, conn.CalendarID, event)
+			if err != nil {
+				return fmt.Errorf("creating event for todo %d: %w", todo.ID, err)
+			}
+			if err := s.repo.CreateEvent(&domain.CalendarEvent{ConnectionID: conn.ID, TodoID: todo.ID, EventID: created.ID, SyncedDue: due, SyncedTitle: todo.Title}); err != nil {
+				return err
+			}
+		case !due.Equal(synced.SyncedDue) || todo.Title != synced.SyncedTitle:
+			if err := s.client.PatchEvent(ctx, accessToken, conn.CalendarID, synced.EventID, event); err != nil {
+				return fmt.Errorf("updating event of todo %d: %w", todo.ID, err)
+			}
+			synced.SyncedDue, synced.SyncedTitle = due, todo.Title
+			if err := s.repo.UpdateEvent(synced); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Whatever is left has no todo with a due date anymore
+	for _, synced := range byTodo {
+		if err := s.client.DeleteEvent(ctx, accessToken, conn.CalendarID, synced.EventID); err != nil {
+			return fmt.Errorf("deleting event of todo %d: %w", synced.TodoID, err)
+		}
+		if err := s.repo.DeleteEvent(synced.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,301 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Tomlord1122/todo-backend/internal/apperror"
+	"github.com/Tomlord1122/todo-backend/internal/authz"
+	"github.com/Tomlord1122/todo-backend/internal/domain"
+	"github.com/Tomlord1122/todo-backend/internal/logging"
+	"github.com/Tomlord1122/todo-backend/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+// maxChecklistTextLength bounds checklist item text; longer notes belong in
+// a subtask or the todo itself.
+const maxChecklistTextLength = 500
+
+// CreateChecklistItemRequest adds an item to a todo's checklist. Position
+// is 0-based; items are appended when it's omitted.
+type CreateChecklistItemRequest struct {
+	Text     string `json:"text"`
+	Done     bool   `json:"done"`
+	Position *int   `json:"position"`
+}
+
+// UpdateChecklistItemRequest changes a checklist item; nil fields are left
+// as they are. Changing Position moves the item, shifting the others.
+type UpdateChecklistItemRequest struct {
+	Text     *string `json:"text"`
+	Done     *bool   `json:"done"`
+	Position *int    `json:"position"`
+}
+
+// ChecklistItemResponse is the representation of a ChecklistItem returned by the service.
+type ChecklistItemResponse struct {
+	ID        uint   `json:"id"`
+	TodoID    uint   `json:"todo_id"`
+	Position  int    `json:"position"`
+	Text      string `json:"text"`
+	Done      bool   `json:"done"`
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+}
+
+// ChecklistService manages the ordered checklist inside a todo and keeps
+// the todo's completion counts in sync.
+type ChecklistService interface {
+	// List returns a todo's checklist in order.
+	List(ctx context.Context, todoID uint) ([]ChecklistItemResponse, error)
+	// AddItem adds an item to a todo's checklist.
+	AddItem(ctx context.Context, todoID uint, req CreateChecklistItemRequest) (*ChecklistItemResponse, error)
+	// UpdateItem edits, checks off or moves an item.
+	UpdateItem(ctx context.Context, todoID, itemID uint, req UpdateChecklistItemRequest) (*ChecklistItemResponse, error)
+	// DeleteItem removes an item, closing the gap in positions.
+	DeleteItem(ctx context.Context, todoID, itemID uint) error
+}
+
+type checklistService struct {
+	repo  repository.ChecklistRepository
+	todos repository.TodoRepository
+}
+
+// NewChecklistService creates a new ChecklistService.
+func NewChecklistService(repo repository.ChecklistRepository, todos repository.TodoRepository) ChecklistService {
+	return &checklistService{repo: repo, todos: todos}
+}
+
+func toChecklistItemResponse(item *domain.ChecklistItem) ChecklistItemResponse {
+	return ChecklistItemResponse{
+		ID:        item.ID,
+		TodoID:    item.TodoID,
+		Position:  item.Position,
+		Text:      item.Text,
+		Done:      item.Done,
+		CreatedAt: item.CreatedAt.Format(time.RFC3339),
+		UpdatedAt: item.UpdatedAt.Format(time.RFC3339),
+	}
+}
+
+// checklistCompletion returns the percentage of checklist items done,
+// rounded down, or nil when the todo has no checklist.
+func checklistCompletion(todo *domain.Todo) *int {
+	if todo.ChecklistTotal <= 0 {
+		return nil
+	}
+	percent := todo.ChecklistDone * 100 / todo.ChecklistTotal
+	return &percent
+}
+
+func validateChecklistText(text string) (string, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return "", apperror.Invalidf("invalid checklist item: text is required")
+	}
+	if len(text) > maxChecklistTextLength {
+		return "", apperror.Invalidf("invalid checklist item: text must be at most %d bytes", maxChecklistTextLength)
+	}
+	return text, nil
+}
+
+// List implements ChecklistService.
+func (s *checklistService) List(ctx context.Context, todoID uint) ([]ChecklistItemResponse, error) {
+	if _, err := s.findTodo(ctx, todoID, "list checklist"); err != nil {
+		return nil, err
+	}
+	items, err := s.repo.FindByTodoID(todoID)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error fetching checklist for todo", "todo_id", todoID, "err", err)
+		return nil, errors.New("failed to list checklist")
+	}
+
+	responses := make([]ChecklistItemResponse, 0, len(items))
+	for i := range items {
+		responses = append(responses, toChecklistItemResponse(&items[i]))
+	}
+	return responses, nil
+}
+
+// AddItem implements ChecklistService.
+func (s *checklistService) AddItem(ctx context.Context, todoID uint, req CreateChecklistItemRequest) (*ChecklistItemResponse, error) {
+	if err := authz.Check(ctx, authz.WriteTodos); err != nil {
+		return nil, err
+	}
+	text, err := validateChecklistText(req.Text)
+	if err != nil {
+		return nil, err
+	}
+	todo, err := s.findTodo(ctx, todoID, "add checklist item")
+	if err != nil {
+		return nil, err
+	}
+	items, err := s.repo.FindByTodoID(todoID)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error fetching checklist for todo", "todo_id", todoID, "err", err)
+		return nil, errors.New("failed to add checklist item")
+	}
+
+	position := len(items)
+	if req.Position != nil {
+		if *req.Position < 0 {
+			return nil, apperror.Invalidf("invalid checklist item: position must not be negative")
+		}
+		position = min(*req.Position, len(items))
+	}
+	item := &domain.ChecklistItem{TodoID: todoID, Position: position, Text: text, Done: req.Done}
+	if err := s.repo.Create(item); err != nil {
+		logging.FromContext(ctx).Error("Error creating checklist item in repository", "err", err)
+		return nil, errors.New("failed to add checklist item")
+	}
+
+	ordered := make([]domain.ChecklistItem, 0, len(items)+1)
+	ordered = append(ordered, items[:position]...)
+	ordered = append(ordered, *item)
+	ordered = append(ordered, items[position:]...)
+	if err := s.sync(ctx, todo, ordered); err != nil {
+		return nil, errors.New("failed to add checklist item")
+	}
+
+	response := toChecklistItemResponse(item)
+	return &response, nil
+}
+
+// UpdateItem implements ChecklistService.
+func (s *checklistService) UpdateItem(ctx context.Context, todoID, itemID uint, req UpdateChecklistItemRequest) (*ChecklistItemResponse, error) {
+	if err := authz.Check(ctx, authz.WriteTodos); err != nil {
+		return nil, err
+	}
+	todo, err := s.findTodo(ctx, todoID, "update checklist item")
+	if err != nil {
+		return nil, err
+	}
+	items, err := s.repo.FindByTodoID(todoID)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error fetching checklist for todo", "todo_id", todoID, "err", err)
+		return nil, errors.New("failed to update checklist item")
+	}
+	index := -1
+	for i := range items {
+		if items[i].ID == itemID {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return nil, apperror.NotFoundf("checklist item with ID %d not found", itemID)
+	}
+
+	item := items[index]
+	if req.Text != nil {
+		text, err := validateChecklistText(*req.Text)
+		if err != nil {
+			return nil, err
+		}
+		item.Text = text
+	}
+	if req.Done != nil {
+		item.Done = *req.Done
+	}
+	target := index
+	if req.Position != nil {
+		if *req.Position < 0 {
+			return nil, apperror.Invalidf("invalid checklist item: position must not be negative")
+		}
+		target = min(*req.Position, len(items)-1)
+	}
+	item.Position = target
+	if err := s.repo.Update(&item); err != nil {
+		logging.FromContext(ctx).Error("Error updating checklist item", "item_id", itemID, "err", err)
+		return nil, errors.New("failed to update checklist item")
+	}
+
+	ordered := append(items[:index:index], items[index+1:]...)
+	ordered = append(ordered[:target], append([]domain.ChecklistItem{item}, ordered[target:]...)...)
+	if err := s.sync(ctx, todo, ordered); err != nil {
+		return nil, errors.New("failed to update checklist item")
+	}
+
+	response := toChecklistItemResponse(&item)
+	return &response, nil
+}
+
+// DeleteItem implements ChecklistService.
+func (s *checklistService) DeleteItem(ctx context.Context, todoID, itemID uint) error {
+	if err := authz.Check(ctx, authz.WriteTodos); err != nil {
+		return err
+	}
+	todo, err := s.findTodo(ctx, todoID, "delete checklist item")
+	if err != nil {
+		return err
+	}
+	items, err := s.repo.FindByTodoID(todoID)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error fetching checklist for todo", "todo_id", todoID, "err", err)
+		return errors.New("failed to delete checklist item")
+	}
+	remaining := make([]domain.ChecklistItem, 0, len(items))
+	for _, item := range items {
+		if item.ID != itemID {
+			remaining = append(remaining, item)
+		}
+	}
+	if len(remaining) == len(items) {
+		return apperror.NotFoundf("checklist item with ID %d not found", itemID)
+	}
+
+	if err := s.repo.Delete(itemID); err != nil {
+		logging.FromContext(ctx).Error("Error deleting checklist item", "item_id", itemID, "err", err)
+		return errors.New("failed to delete checklist item")
+	}
+	if err := s.sync(ctx, todo, remaining); err != nil {
+		return errors.New("failed to delete checklist item")
+	}
+	return nil
+}
+
+// findTodo loads the todo owning a checklist, describing failures with action.
+func (s *checklistService) findTodo(ctx context.Context, todoID uint, action string) (*domain.Todo, error) {
+	todo, err := s.todos.FindByID(todoID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.NotFoundf("todo with ID %d not found", todoID)
+		}
+		logging.FromContext(ctx).Error("Error fetching todo", "todo_id", todoID, "action", action, "err", err)
+		return nil, fmt.Errorf("failed to %s", action)
+	}
+	return todo, nil
+}
+
+// sync renumbers ordered so positions are contiguous, saving the items that
+// moved, and stores the checklist counts on the todo.
+func (s *checklistService) sync(ctx context.Context, todo *domain.Todo, ordered []domain.ChecklistItem) error {
+	done := 0
+	for i := range ordered {
+		if ordered[i].Done {
+			done++
+		}
+		if ordered[i].Position == i {
+			continue
+		}
+		ordered[i].Position = i
+		if err := s.repo.Update(&ordered[i]); err != nil {
+			logging.FromContext(ctx).Error("Error renumbering checklist item", "item_id", ordered[i].ID, "err", err)
+			return err
+		}
+	}
+
+	if todo.ChecklistTotal == len(ordered) && todo.ChecklistDone == done {
+		return nil
+	}
+	todo.ChecklistTotal, todo.ChecklistDone = len(ordered), done
+	if err := s.todos.Update(todo); err != nil {
+		logging.FromContext(ctx).Error("Error updating checklist counts of todo", "todo_id", todo.ID, "err", err)
+		return err
+	}
+	return nil
+}
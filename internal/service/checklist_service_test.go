@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Tomlord1122/todo-backend/internal/apperror"
+	"github.com/Tomlord1122/todo-backend/internal/authz"
+	"github.com/Tomlord1122/todo-backend/internal/domain"
+	"github.com/Tomlord1122/todo-backend/internal/repository"
+)
+
+func TestChecklistWritesRequireWriteTodos(t *testing.T) {
+	repos := repository.NewMemoryRepositories()
+	checklist := NewChecklistService(repos.Checklists, repos.Todos)
+	viewer := authz.NewContext(context.Background(), authz.Principal{UserID: 1, Role: domain.RoleViewer})
+
+	text := "Milk"
+	_, addErr := checklist.AddItem(viewer, 1, CreateChecklistItemRequest{Text: text})
+	_, updateErr := checklist.UpdateItem(viewer, 1, 1, UpdateChecklistItemRequest{Text: &text})
+	deleteErr := checklist.DeleteItem(viewer, 1, 1)
+	for name, err := range map[string]error{"AddItem": addErr, "UpdateItem": updateErr, "DeleteItem": deleteErr} {
+		if !errors.Is(err, apperror.ErrForbidden) {
+			t.Errorf("%s as a viewer = %v, want forbidden", name, err)
+		}
+	}
+}
+
+func TestChecklistPositions(t *testing.T) {
+	repos := repository.NewMemoryRepositories()
+	checklist := NewChecklistService(repos.Checklists, repos.Todos)
+	ctx := context.Background()
+	todo := &domain.Todo{Title: "Groceries", UserID: 1}
+	if err := repos.Todos.Create(todo); err != nil {
+		t.Fatal(err)
+	}
+
+	ids := map[string]uint{}
+	add := func(text string, position *int) {
+		t.Helper()
+		item, err := checklist.AddItem(ctx, todo.ID, CreateChecklistItemRequest{Text: text, Position: position})
+		if err != nil {
+			t.Fatalf("AddItem(%q): %v", text, err)
+		}
+		ids[text] = item.ID
+	}
+	move := func(text string, position int) {
+		t.Helper()
+		if _, err := checklist.UpdateItem(ctx, todo.ID, ids[text], UpdateChecklistItemRequest{Position: &position}); err != nil {
+			t.Fatalf("moving %q to %d: %v", text, position, err)
+		}
+	}
+	// expect checks the order and that positions run 0, 1, 2, ... in it
+	expect := func(step string, want ...string) {
+		t.Helper()
+		items, err := checklist.List(ctx, todo.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for i, item := range items {
+			got = append(got, item.Text)
+			if item.Position != i {
+				t.Errorf("%s: %q at position %d, want %d", step, item.Text, item.Position, i)
+			}
+		}
+		if strings.Join(got, ",") != strings.Join(want, ",") {
+			t.Errorf("%s: checklist = %v, want %v", step, got, want)
+		}
+	}
+	at := func(position int) *int { return &position }
+
+	add("Milk", nil)
+	add("Eggs", nil)
+	add("Bread", at(0))
+	expect("inserting at the top", "Bread", "Milk", "Eggs")
+	add("Butter", at(99))
+	expect("inserting past the end", "Bread", "Milk", "Eggs", "Butter")
+
+	move("Butter", 1)
+	expect("moving up", "Bread", "Butter", "Milk", "Eggs")
+	move("Bread", 2)
+	expect("moving down", "Butter", "Milk", "Bread", "Eggs")
+	move("Butter", 99)
+	expect("moving past the end", "Milk", "Bread", "Eggs", "Butter")
+
+	if err := checklist.DeleteItem(ctx, todo.ID, ids["Bread"]); err != nil {
+		t.Fatal(err)
+	}
+	expect("deleting", "Milk", "Eggs", "Butter")
+
+	if _, err := checklist.AddItem(ctx, todo.ID, CreateChecklistItemRequest{Text: "Jam", Position: at(-1)}); !errors.Is(err, apperror.ErrInvalid) {
+		t.Errorf("AddItem at a negative position = %v, want invalid", err)
+	}
+	if _, err := checklist.UpdateItem(ctx, todo.ID, ids["Milk"], UpdateChecklistItemRequest{Position: at(-1)}); !errors.Is(err, apperror.ErrInvalid) {
+		t.Errorf("UpdateItem to a negative position = %v, want invalid", err)
+	}
+	expect("rejected moves", "Milk", "Eggs", "Butter")
+}
@@ -0,0 +1,170 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Tomlord1122/todo-backend/internal/domain"
+	"github.com/Tomlord1122/todo-backend/internal/logging"
+	"github.com/Tomlord1122/todo-backend/internal/notify"
+	"github.com/Tomlord1122/todo-backend/internal/repository"
+)
+
+// priorityOrder lists priorities from lowest to highest.
+var priorityOrder = []string{"low", "normal", "high"}
+
+// EscalationConfig holds how long past their due date todos are escalated.
+type EscalationConfig struct {
+	// Thresholds are ascending; passing each one escalates the todo once
+	Thresholds []time.Duration
+}
+
+// EscalationConfigFromEnv reads OVERDUE_ESCALATION_THRESHOLDS, a
+// comma-separated list of durations such as "24h,72h" (the default).
+func EscalationConfigFromEnv() (EscalationConfig, error) {
+	cfg := EscalationConfig{Thresholds: []time.Duration{24 * time.Hour, 72 * time.Hour}}
+	v := os.Getenv("OVERDUE_ESCALATION_THRESHOLDS")
+	if v == "" {
+		return cfg, nil
+	}
+	cfg.Thresholds = nil
+	for _, part := range strings.Split(v, ",") {
+		d, err := time.ParseDuration(strings.TrimSpace(part))
+		if err != nil || d < 0 {
+			return EscalationConfig{}, fmt.Errorf("OVERDUE_ESCALATION_THRESHOLDS: %q is not a non-negative duration", part)
+		}
+		cfg.Thresholds = append(cfg.Thresholds, d)
+	}
+	slices.Sort(cfg.Thresholds)
+	return cfg, nil
+}
+
+// EscalationService escalates overdue todos for users who opted in.
+type EscalationService interface {
+	// RunDue escalates every todo that passed a threshold since the last
+	// run. It is meant to be called periodically by the job scheduler.
+	RunDue(ctx context.Context, now time.Time) error
+}
+
+type escalationService struct {
+	todos      repository.TodoRepository
+	prefs      repository.PreferenceRepository
+	activities repository.ActivityRepository
+	channels   *notify.Registry
+	cfg        EscalationConfig
+}
+
+// NewEscalationService creates a new EscalationService.
+func NewEscalationService(todos repository.TodoRepository, prefs repository.PreferenceRepository, activities repository.ActivityRepository, channels *notify.Registry, cfg EscalationConfig) EscalationService {
+	return &escalationService{todos: todos, prefs: prefs, activities: activities, channels: channels, cfg: cfg}
+}
+
+// RunDue implements EscalationService.
+func (s *escalationService) RunDue(ctx context.Context, now time.Time) error {
+	if len(s.cfg.Thresholds) == 0 {
+		return nil
+	}
+	prefs, err := s.prefs.FindEscalating()
+	if err != nil {
+		return fmt.Errorf("fetching escalation preferences: %w", err)
+	}
+
+	var errs []error
+	for i := range prefs {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := s.escalateUser(ctx, &prefs[i], now); err != nil {
+			errs = append(errs, fmt.Errorf("user %d: %w", prefs[i].UserID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// escalateUser escalates one user's overdue todos.
+func (s *escalationService) escalateUser(ctx context.Context, pref *domain.UserPreference, now time.Time) error {
+	todos, err := s.todos.FindOpenByUser(pref.UserID)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for i := range todos {
+		todo := &todos[i]
+		if todo.DueDate == nil {
+			continue
+		}
+		level := s.level(now.Sub(*todo.DueDate))
+		if level <= todo.EscalationLevel {
+			continue
+		}
+		if err := s.escalate(ctx, pref, todo, level, now); err != nil {
+			errs = append(errs, fmt.Errorf("todo %d: %w", todo.ID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// level returns how many thresholds a todo overdue by overdue has passed.
+func (s *escalationService) level(overdue time.Duration) int {
+	if overdue <= 0 {
+		return 0
+	}
+	level := 0
+	for _, threshold := range s.cfg.Thresholds {
+		if overdue >= threshold {
+			level++
+		}
+	}
+	return level
+}
+
+// escalate raises the todo to level: it bumps the priority one step per
+// level gained and/or notifies the owner, then records the escalation.
+func (s *escalationService) escalate(ctx context.Context, pref *domain.UserPreference, todo *domain.Todo, level int, now time.Time) error {
+	from := todo.Priority
+	if pref.EscalationMode == EscalationPriority || pref.EscalationMode == EscalationBoth {
+		todo.Priority = raisePriority(todo.Priority, level-todo.EscalationLevel)
+	}
+	todo.EscalationLevel = level
+	if err := s.todos.Update(todo); err != nil {
+		return err
+	}
+
+	detail := fmt.Sprintf("overdue by more than %s", s.cfg.Thresholds[level-1])
+	if pref.EscalationMode == EscalationNotify || pref.EscalationMode == EscalationBoth {
+		msg := notify.Message{
+			Recipient: pref.NotificationTarget,
+			Subject:   fmt.Sprintf("Overdue: %s", todo.Title),
+			Body: fmt.Sprintf("%q was due %s and is %s. Its priority is %s.",
+				todo.Title, todo.DueDate.Format(time.RFC1123), detail, todo.Priority),
+		}
+		if err := s.channels.Send(ctx, pref.NotificationChannel, msg); err != nil {
+			// The escalation itself happened; note the failed reminder
+			// instead of retrying it on every run
+			logging.FromContext(ctx).Error("Error sending escalation for todo", "todo_id", todo.ID, "err", err)
+			detail += ", reminder failed"
+		} else {
+			detail += ", reminder sent"
+		}
+	}
+
+	activity := newActivity(todo, domain.ActivityEscalated, from, todo.Priority)
+	activity.Detail = detail
+	return s.activities.Create(activity)
+}
+
+// raisePriority moves priority up by steps, stopping at the highest.
+// Unknown priorities are left alone.
+func raisePriority(priority string, steps int) string {
+	i := slices.Index(priorityOrder, priority)
+	if i < 0 {
+		return priority
+	}
+	return priorityOrder[min(i+steps, len(priorityOrder)-1)]
+}
@@ -0,0 +1,106 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Tomlord1122/todo-backend/internal/domain"
+	"github.com/Tomlord1122/todo-backend/internal/notify"
+	"github.com/Tomlord1122/todo-backend/internal/repository"
+)
+
+// recordingChannel is a notify.Channel that keeps the messages it sends.
+type recordingChannel struct {
+	mu   sync.Mutex
+	sent []notify.Message
+}
+
+func (c *recordingChannel) Name() string { return "test" }
+
+func (c *recordingChannel) Send(_ context.Context, msg notify.Message) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sent = append(c.sent, msg)
+	return nil
+}
+
+func (c *recordingChannel) count() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.sent)
+}
+
+func TestEscalation(t *testing.T) {
+	repos := repository.NewMemoryRepositories()
+	channel := &recordingChannel{}
+	escalation := NewEscalationService(repos.Todos, repos.Preferences, repos.Activities, notify.NewRegistry(channel),
+		EscalationConfig{Thresholds: []time.Duration{24 * time.Hour, 72 * time.Hour}})
+	ctx := context.Background()
+	if err := repos.Preferences.Save(&domain.UserPreference{UserID: 1, EscalationMode: EscalationBoth, NotificationChannel: "test", NotificationTarget: "ada@example.com"}); err != nil {
+		t.Fatal(err)
+	}
+	due := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+	todo := &domain.Todo{Title: "File taxes", UserID: 1, Priority: "low", DueDate: &due}
+	if err := repos.Todos.Create(todo); err != nil {
+		t.Fatal(err)
+	}
+
+	steps := []struct {
+		name         string
+		now          time.Time
+		wantLevel    int
+		wantPriority string
+		wantSent     int
+	}{
+		{"just short of the first threshold", due.Add(24*time.Hour - time.Second), 0, "low", 0},
+		{"at the first threshold", due.Add(24 * time.Hour), 1, "normal", 1},
+		// Each threshold escalates once, however often the job runs
+		{"again past the first threshold", due.Add(48 * time.Hour), 1, "normal", 1},
+		{"at the second threshold", due.Add(72 * time.Hour), 2, "high", 2},
+		{"past the last threshold", due.Add(30 * 24 * time.Hour), 2, "high", 2},
+	}
+	for _, step := range steps {
+		if err := escalation.RunDue(ctx, step.now); err != nil {
+			t.Fatalf("%s: RunDue: %v", step.name, err)
+		}
+		got, err := repos.Todos.FindByID(todo.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.EscalationLevel != step.wantLevel || got.Priority != step.wantPriority || channel.count() != step.wantSent {
+			t.Errorf("%s: level %d, priority %s, %d sent; want level %d, priority %s, %d sent",
+				step.name, got.EscalationLevel, got.Priority, channel.count(), step.wantLevel, step.wantPriority, step.wantSent)
+		}
+	}
+
+	activities, err := repos.Activities.FindByTodoID(todo.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(activities) != 2 {
+		t.Errorf("recorded %d escalations, want 2", len(activities))
+	}
+}
+
+func TestEscalationSkipsUsersWhoDidNotOptIn(t *testing.T) {
+	repos := repository.NewMemoryRepositories()
+	escalation := NewEscalationService(repos.Todos, repos.Preferences, repos.Activities, notify.NewRegistry(),
+		EscalationConfig{Thresholds: []time.Duration{time.Hour}})
+	if err := repos.Preferences.Save(&domain.UserPreference{UserID: 1}); err != nil {
+		t.Fatal(err)
+	}
+	due := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+	todo := &domain.Todo{Title: "File taxes", UserID: 1, Priority: "low", DueDate: &due}
+	if err := repos.Todos.Create(todo); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := escalation.RunDue(context.Background(), due.Add(24*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := repos.Todos.FindByID(todo.ID); got.EscalationLevel != 0 || got.Priority != "low" {
+		t.Errorf("todo = level %d, priority %s; want it left alone", got.EscalationLevel, got.Priority)
+	}
+}
@@ -0,0 +1,176 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Tomlord1122/todo-backend/internal/apperror"
+	"github.com/Tomlord1122/todo-backend/internal/domain"
+	"github.com/Tomlord1122/todo-backend/internal/feed"
+	"github.com/Tomlord1122/todo-backend/internal/logging"
+	"github.com/Tomlord1122/todo-backend/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+// recentlyCompletedWindow controls how far back "recently completed" reaches.
+const recentlyCompletedWindow = 24 * time.Hour
+
+// FeedTokenResponse is returned when a feed token is issued.
+// The token is only ever shown here; treat it like a password.
+type FeedTokenResponse struct {
+	Token     string `json:"token"`
+	UserID    uint   `json:"user_id"`
+	TodayRSS  string `json:"today_rss"`
+	TodayAtom string `json:"today_atom"`
+	CreatedAt string `json:"created_at"`
+}
+
+// FeedService manages feed tokens and assembles the feeds they unlock.
+type FeedService interface {
+	// CreateToken issues a new feed token for a user.
+	CreateToken(ctx context.Context, userID uint) (*FeedTokenResponse, error)
+
+	// RevokeToken invalidates one of a user's feed tokens.
+	RevokeToken(ctx context.Context, userID uint, token string) error
+
+	// TodayFeed builds the "today" feed (due today, recently completed) for the
+	// owner of token. "Today" is evaluated in loc; links are rooted at baseURL.
+	TodayFeed(ctx context.Context, token string, loc *time.Location, baseURL string) (*feed.Feed, error)
+}
+
+type feedService struct {
+	tokens repository.FeedTokenRepository
+	todos  repository.TodoRepository
+}
+
+// NewFeedService creates a new FeedService.
+func NewFeedService(tokens repository.FeedTokenRepository, todos repository.TodoRepository) FeedService {
+	return &feedService{
+		tokens: tokens,
+		todos:  todos,
+	}
+}
+
+// CreateToken implements FeedService.
+func (s *feedService) CreateToken(ctx context.Context, userID uint) (*FeedTokenResponse, error) {
+	secret, err := generateToken()
+	if err != nil {
+		logging.FromContext(ctx).Error("Error generating feed token", "err", err)
+		return nil, errors.New("failed to create feed token")
+	}
+
+	feedToken := &domain.FeedToken{
+		UserID: userID,
+		Token:  secret,
+	}
+	if err := s.tokens.Create(feedToken); err != nil {
+		logging.FromContext(ctx).Error("Error creating feed token in repository", "err", err)
+		return nil, errors.New("failed to create feed token")
+	}
+
+	return &FeedTokenResponse{
+		Token:     feedToken.Token,
+		UserID:    feedToken.UserID,
+		TodayRSS:  fmt.Sprintf(apiBasePath+"/feeds/%s/today.xml", feedToken.Token),
+		TodayAtom: fmt.Sprintf(apiBasePath+"/feeds/%s/today.atom", feedToken.Token),
+		CreatedAt: feedToken.CreatedAt.Format(time.RFC3339),
+	}, nil
+}
+
+// RevokeToken implements FeedService.
+func (s *feedService) RevokeToken(ctx context.Context, userID uint, token string) error {
+	feedToken, err := s.tokens.FindByToken(token)
+	if err == nil && feedToken.UserID != userID {
+		// Other users' tokens look like missing ones
+		err = gorm.ErrRecordNotFound
+	}
+	if err == nil {
+		err = s.tokens.DeleteByToken(token)
+	}
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return apperror.NotFoundf("feed token not found")
+		}
+		logging.FromContext(ctx).Error("Error revoking feed token", "err", err)
+		return errors.New("failed to revoke feed token")
+	}
+	return nil
+}
+
+// TodayFeed implements FeedService.
+func (s *feedService) TodayFeed(ctx context.Context, token string, loc *time.Location, baseURL string) (*feed.Feed, error) {
+	feedToken, err := s.tokens.FindByToken(token)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.NotFoundf("feed not found")
+		}
+		logging.FromContext(ctx).Error("Error looking up feed token", "err", err)
+		return nil, errors.New("failed to load feed")
+	}
+
+	now := time.Now().In(loc)
+	startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	endOfDay := startOfDay.AddDate(0, 0, 1)
+
+	due, err := s.todos.FindDueBetween(feedToken.UserID, startOfDay, endOfDay)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error fetching due todos for feed", "err", err)
+		return nil, errors.New("failed to load feed")
+	}
+	completed, err := s.todos.FindCompletedSince(feedToken.UserID, now.Add(-recentlyCompletedWindow))
+	if err != nil {
+		logging.FromContext(ctx).Error("Error fetching completed todos for feed", "err", err)
+		return nil, errors.New("failed to load feed")
+	}
+
+	self := fmt.Sprintf("%s/feeds/%s/today", baseURL, token)
+	f := &feed.Feed{
+		ID:          self,
+		Title:       "Todos for " + startOfDay.Format("Monday, January 2"),
+		Link:        self,
+		Description: "Todos due today and recently completed",
+		Updated:     now,
+		Items:       make([]feed.Item, 0, len(due)+len(completed)),
+	}
+
+	for _, todo := range due {
+		link := fmt.Sprintf("%s/todos/%d", baseURL, todo.ID)
+		f.Items = append(f.Items, feed.Item{
+			ID:       link + "#due",
+			Title:    "Due: " + todo.Title,
+			Link:     link,
+			Summary:  "Due " + todo.DueDate.In(loc).Format(time.Kitchen),
+			Category: "due",
+			Updated:  todo.UpdatedAt,
+		})
+	}
+	for _, todo := range completed {
+		link := fmt.Sprintf("%s/todos/%d", baseURL, todo.ID)
+		completedAt := todo.CompletedAt.In(loc)
+		f.Items = append(f.Items, feed.Item{
+			// Include the completion time so re-completing a todo shows up as a new entry
+			ID:       fmt.Sprintf("%s#completed-%d", link, completedAt.Unix()),
+			Title:    "Completed: " + todo.Title,
+			Link:     link,
+			Summary:  "Completed " + completedAt.Format(time.Kitchen),
+			Category: "completed",
+			Updated:  completedAt,
+		})
+	}
+
+	return f, nil
+}
+
+// generateToken returns a random, URL-safe secret for feed and hook URLs.
+func generateToken() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,55 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/Tomlord1122/todo-backend/internal/apperror"
+	"github.com/Tomlord1122/todo-backend/internal/fixtures"
+	"github.com/Tomlord1122/todo-backend/internal/logging"
+	"github.com/Tomlord1122/todo-backend/internal/repository"
+)
+
+// LoadFixturesRequest names the fixture set to load.
+type LoadFixturesRequest struct {
+	Set string `json:"set"`
+}
+
+// FixturesResponse summarizes the data that was loaded.
+type FixturesResponse struct {
+	Set   string `json:"set"`
+	Users int    `json:"users"`
+	Lists int    `json:"lists"`
+	Todos int    `json:"todos"`
+}
+
+// FixtureService resets the data store to a named fixture set. It is
+// destructive and only meant for development and end-to-end tests.
+type FixtureService interface {
+	LoadFixtures(ctx context.Context, req LoadFixturesRequest) (*FixturesResponse, error)
+}
+
+type fixtureService struct {
+	repos *repository.Repositories
+}
+
+// NewFixtureService creates a new FixtureService.
+func NewFixtureService(repos *repository.Repositories) FixtureService {
+	return &fixtureService{repos: repos}
+}
+
+// LoadFixtures deletes all data and loads the requested set.
+func (s *fixtureService) LoadFixtures(ctx context.Context, req LoadFixturesRequest) (*FixturesResponse, error) {
+	counts, err := fixtures.Load(s.repos, req.Set, time.Now())
+	if err != nil {
+		if errors.Is(err, fixtures.ErrUnknownSet) {
+			return nil, apperror.Invalidf("invalid fixture set %q, must be one of %s", req.Set, strings.Join(fixtures.Sets, ", "))
+		}
+		logging.FromContext(ctx).Error("Error loading fixture set", "set", req.Set, "err", err)
+		return nil, errors.New("failed to load fixtures")
+	}
+
+	return &FixturesResponse{Set: req.Set, Users: counts.Users, Lists: counts.Lists, Todos: counts.Todos}, nil
+}
@@ -0,0 +1,184 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Tomlord1122/todo-backend/internal/apperror"
+	"github.com/Tomlord1122/todo-backend/internal/authz"
+	"github.com/Tomlord1122/todo-backend/internal/domain"
+	"github.com/Tomlord1122/todo-backend/internal/logging"
+	"github.com/Tomlord1122/todo-backend/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+// maxPlannedMinutes caps a Pomodoro's planned length at a working day.
+const maxPlannedMinutes = 8 * 60
+
+// ErrFocusRunning is returned when starting a session while another runs.
+var ErrFocusRunning = apperror.New(apperror.CodeConflict, "a focus session is already running, stop it first")
+
+// StartFocusRequest starts a focus session.
+type StartFocusRequest struct {
+	UserID uint  `json:"user_id"`
+	TodoID *uint `json:"todo_id"`
+	// PlannedMinutes ends the session automatically, e.g. 25 for a
+	// Pomodoro; omit it for an open-ended session
+	PlannedMinutes int `json:"planned_minutes"`
+}
+
+// StopFocusRequest stops a user's running session.
+type StopFocusRequest struct {
+	UserID uint `json:"user_id"`
+}
+
+// FocusSessionResponse is the representation of a FocusSession returned by the service.
+type FocusSessionResponse struct {
+	ID             uint    `json:"id"`
+	UserID         uint    `json:"user_id"`
+	TodoID         *uint   `json:"todo_id,omitempty"`
+	StartedAt      string  `json:"started_at"`
+	PlannedMinutes int     `json:"planned_minutes,omitempty"`
+	EndedAt        *string `json:"ended_at,omitempty"`
+	// FocusedSeconds counts up to now while the session is running
+	FocusedSeconds int64 `json:"focused_seconds"`
+}
+
+// FocusService starts and stops focus sessions.
+type FocusService interface {
+	Start(ctx context.Context, req StartFocusRequest, now time.Time) (*FocusSessionResponse, error)
+	Stop(ctx context.Context, req StopFocusRequest, now time.Time) (*FocusSessionResponse, error)
+	// Current returns the user's running session.
+	Current(ctx context.Context, userID uint, now time.Time) (*FocusSessionResponse, error)
+}
+
+type focusService struct {
+	repo  repository.FocusRepository
+	todos repository.TodoRepository
+}
+
+// NewFocusService creates a new FocusService.
+func NewFocusService(repo repository.FocusRepository, todos repository.TodoRepository) FocusService {
+	return &focusService{repo: repo, todos: todos}
+}
+
+func toFocusSessionResponse(session *domain.FocusSession, now time.Time) *FocusSessionResponse {
+	return &FocusSessionResponse{
+		ID:             session.ID,
+		UserID:         session.UserID,
+		TodoID:         session.TodoID,
+		StartedAt:      session.StartedAt.Format(time.RFC3339),
+		PlannedMinutes: session.PlannedMinutes,
+		EndedAt:        formatOptionalTime(session.EndedAt),
+		FocusedSeconds: int64(session.EffectiveEnd(now).Sub(session.StartedAt) / time.Second),
+	}
+}
+
+// Start implements FocusService.
+func (s *focusService) Start(ctx context.Context, req StartFocusRequest, now time.Time) (*FocusSessionResponse, error) {
+	if err := authz.Check(ctx, authz.WriteTodos); err != nil {
+		return nil, err
+	}
+	if req.UserID == 0 {
+		return nil, apperror.Invalidf("invalid focus session: user_id is required")
+	}
+	if req.PlannedMinutes < 0 || req.PlannedMinutes > maxPlannedMinutes {
+		return nil, apperror.Invalidf("invalid focus session: planned_minutes must be between 0 and %d", maxPlannedMinutes)
+	}
+	if req.TodoID != nil {
+		todo, err := s.todos.FindByID(*req.TodoID)
+		if err == nil && todo.UserID != req.UserID {
+			// Other users' todos look like missing ones
+			err = gorm.ErrRecordNotFound
+		}
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, apperror.Invalidf("invalid focus session: todo with ID %d not found", *req.TodoID)
+			}
+			logging.FromContext(ctx).Error("Error fetching todo for focus session", "todo_id", *req.TodoID, "err", err)
+			return nil, errors.New("failed to start focus session")
+		}
+	}
+
+	running, err := s.running(ctx, req.UserID, now)
+	if err != nil {
+		return nil, errors.New("failed to start focus session")
+	}
+	if running != nil {
+		return nil, ErrFocusRunning
+	}
+
+	session := &domain.FocusSession{UserID: req.UserID, TodoID: req.TodoID, StartedAt: now, PlannedMinutes: req.PlannedMinutes}
+	if err := s.repo.Create(session); err != nil {
+		// The unique index rejects a second running session started concurrently
+		if running, findErr := s.running(ctx, req.UserID, now); findErr == nil && running != nil {
+			return nil, ErrFocusRunning
+		}
+		logging.FromContext(ctx).Error("Error creating focus session in repository", "err", err)
+		return nil, errors.New("failed to start focus session")
+	}
+	return toFocusSessionResponse(session, now), nil
+}
+
+// Stop implements FocusService.
+func (s *focusService) Stop(ctx context.Context, req StopFocusRequest, now time.Time) (*FocusSessionResponse, error) {
+	if err := authz.Check(ctx, authz.WriteTodos); err != nil {
+		return nil, err
+	}
+	if req.UserID == 0 {
+		return nil, apperror.Invalidf("invalid focus session: user_id is required")
+	}
+	session, err := s.repo.FindRunning(req.UserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.NotFoundf("running focus session for user %d not found", req.UserID)
+		}
+		logging.FromContext(ctx).Error("Error fetching running focus session for user", "user_id", req.UserID, "err", err)
+		return nil, errors.New("failed to stop focus session")
+	}
+
+	// A Pomodoro stopped late only counts its planned length
+	end := session.EffectiveEnd(now)
+	session.EndedAt = &end
+	if err := s.repo.Update(session); err != nil {
+		logging.FromContext(ctx).Error("Error stopping focus session", "session_id", session.ID, "err", err)
+		return nil, errors.New("failed to stop focus session")
+	}
+	return toFocusSessionResponse(session, now), nil
+}
+
+// Current implements FocusService.
+func (s *focusService) Current(ctx context.Context, userID uint, now time.Time) (*FocusSessionResponse, error) {
+	running, err := s.running(ctx, userID, now)
+	if err != nil {
+		return nil, errors.New("failed to retrieve focus session")
+	}
+	if running == nil {
+		return nil, apperror.NotFoundf("running focus session for user %d not found", userID)
+	}
+	return toFocusSessionResponse(running, now), nil
+}
+
+// running returns the user's running session, or nil. A Pomodoro whose
+// planned length ran out is closed on the way.
+func (s *focusService) running(ctx context.Context, userID uint, now time.Time) (*domain.FocusSession, error) {
+	session, err := s.repo.FindRunning(userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		logging.FromContext(ctx).Error("Error fetching running focus session for user", "user_id", userID, "err", err)
+		return nil, err
+	}
+	if end := session.EffectiveEnd(now); end.Before(now) {
+		session.EndedAt = &end
+		if err := s.repo.Update(session); err != nil {
+			logging.FromContext(ctx).Error("Error closing elapsed focus session", "session_id", session.ID, "err", err)
+			return nil, err
+		}
+		return nil, nil
+	}
+	return session, nil
+}
@@ -0,0 +1,28 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Tomlord1122/todo-backend/internal/apperror"
+	"github.com/Tomlord1122/todo-backend/internal/authz"
+	"github.com/Tomlord1122/todo-backend/internal/domain"
+	"github.com/Tomlord1122/todo-backend/internal/repository"
+)
+
+func TestFocusWritesRequireWriteTodos(t *testing.T) {
+	repos := repository.NewMemoryRepositories()
+	focus := NewFocusService(repos.FocusSessions, repos.Todos)
+	viewer := authz.NewContext(context.Background(), authz.Principal{UserID: 1, Role: domain.RoleViewer})
+	now := time.Now()
+
+	_, startErr := focus.Start(viewer, StartFocusRequest{UserID: 1}, now)
+	_, stopErr := focus.Stop(viewer, StopFocusRequest{UserID: 1}, now)
+	for op, err := range map[string]error{"Start": startErr, "Stop": stopErr} {
+		if !errors.Is(err, apperror.ErrForbidden) {
+			t.Errorf("%s as a viewer = %v, want forbidden", op, err)
+		}
+	}
+}
@@ -0,0 +1,232 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Tomlord1122/todo-backend/internal/apperror"
+	"github.com/Tomlord1122/todo-backend/internal/authz"
+	"github.com/Tomlord1122/todo-backend/internal/domain"
+	"github.com/Tomlord1122/todo-backend/internal/logging"
+	"github.com/Tomlord1122/todo-backend/internal/notify"
+	"github.com/Tomlord1122/todo-backend/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+// followerNotificationTimeout bounds delivering one change to all
+// followers of a todo.
+const followerNotificationTimeout = time.Minute
+
+// FollowingResponse is a todo a user follows.
+type FollowingResponse struct {
+	TodoID     uint   `json:"todo_id"`
+	Title      string `json:"title"`
+	Completed  bool   `json:"completed"`
+	OwnerID    uint   `json:"owner_id"`
+	FollowedAt string `json:"followed_at"`
+}
+
+// FollowerNotifier tells the followers of a todo that it changed.
+type FollowerNotifier interface {
+	// NotifyFollowers sends changes, one line each, to the followers of
+	// todo. Delivery happens in the background and never fails the change
+	// itself. When the todo was deleted its followers are removed.
+	NotifyFollowers(ctx context.Context, todo *domain.Todo, changes []string, deleted bool)
+}
+
+// FollowService lets users follow todos they can see but don't own, which
+// takes AccessAllTodos. Followers are notified on the channel and target
+// set in their preferences whenever the todo changes.
+type FollowService interface {
+	FollowerNotifier
+
+	// Follow starts following a todo. Following twice is a no-op;
+	// created reports whether the user wasn't following it yet.
+	Follow(ctx context.Context, userID, todoID uint) (following *FollowingResponse, created bool, err error)
+
+	// Unfollow stops following a todo.
+	Unfollow(ctx context.Context, userID, todoID uint) error
+
+	// ListFollowing returns the todos a user follows and can still see,
+	// most recently followed first.
+	ListFollowing(ctx context.Context, userID uint) ([]FollowingResponse, error)
+}
+
+type followService struct {
+	repo     repository.WatcherRepository
+	todos    repository.TodoRepository
+	prefs    repository.PreferenceRepository
+	users    repository.UserRepository
+	channels *notify.Registry
+}
+
+// NewFollowService creates a new FollowService.
+func NewFollowService(repo repository.WatcherRepository, todos repository.TodoRepository, prefs repository.PreferenceRepository, users repository.UserRepository, channels *notify.Registry) FollowService {
+	return &followService{repo: repo, todos: todos, prefs: prefs, users: users, channels: channels}
+}
+
+// canSee reports whether userID, the caller in ctx, may see a todo or list
+// of ownerID: their own, or anyone's with AccessAllTodos.
+func canSee(ctx context.Context, userID, ownerID uint) bool {
+	return userID == ownerID || authz.Check(ctx, authz.AccessAllTodos) == nil
+}
+
+func toFollowingResponse(watcher *domain.TodoWatcher, todo *domain.Todo) FollowingResponse {
+	return FollowingResponse{
+		TodoID:     todo.ID,
+		Title:      todo.Title,
+		Completed:  todo.Completed,
+		OwnerID:    todo.UserID,
+		FollowedAt: watcher.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+// Follow implements FollowService.
+func (s *followService) Follow(ctx context.Context, userID, todoID uint) (*FollowingResponse, bool, error) {
+	todo, err := s.todos.FindByID(todoID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		logging.FromContext(ctx).Error("Error fetching todo to follow", "todo_id", todoID, "err", err)
+		return nil, false, errors.New("failed to follow todo")
+	}
+	// Todos the caller can't see are answered like missing ones
+	if err != nil || !canSee(ctx, userID, todo.UserID) {
+		return nil, false, apperror.NotFoundf("todo with ID %d not found", todoID)
+	}
+	if todo.UserID == userID {
+		return nil, false, apperror.Invalidf("invalid follow: you own this todo")
+	}
+
+	watcher, err := s.repo.Find(todoID, userID)
+	if err == nil {
+		resp := toFollowingResponse(watcher, todo)
+		return &resp, false, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		logging.FromContext(ctx).Error("Error checking follow of todo", "todo_id", todoID, "user_id", userID, "err", err)
+		return nil, false, errors.New("failed to follow todo")
+	}
+
+	watcher = &domain.TodoWatcher{TodoID: todoID, UserID: userID}
+	if err := s.repo.Create(watcher); err != nil {
+		logging.FromContext(ctx).Error("Error following todo", "todo_id", todoID, "user_id", userID, "err", err)
+		return nil, false, errors.New("failed to follow todo")
+	}
+	resp := toFollowingResponse(watcher, todo)
+	return &resp, true, nil
+}
+
+// Unfollow implements FollowService.
+func (s *followService) Unfollow(ctx context.Context, userID, todoID uint) error {
+	if err := s.repo.Delete(todoID, userID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return apperror.NotFoundf("follow of todo %d not found", todoID)
+		}
+		logging.FromContext(ctx).Error("Error unfollowing todo", "todo_id", todoID, "user_id", userID, "err", err)
+		return errors.New("failed to unfollow todo")
+	}
+	return nil
+}
+
+// ListFollowing implements FollowService.
+func (s *followService) ListFollowing(ctx context.Context, userID uint) ([]FollowingResponse, error) {
+	watchers, err := s.repo.FindByUserID(userID)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error fetching todos followed by user", "user_id", userID, "err", err)
+		return nil, errors.New("failed to retrieve followed todos")
+	}
+
+	resp := make([]FollowingResponse, 0, len(watchers))
+	for i := range watchers {
+		todo, err := s.todos.FindByID(watchers[i].TodoID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			continue
+		}
+		if err != nil {
+			logging.FromContext(ctx).Error("Error fetching followed todo", "todo_id", watchers[i].TodoID, "err", err)
+			return nil, errors.New("failed to retrieve followed todos")
+		}
+		if !canSee(ctx, userID, todo.UserID) {
+			continue
+		}
+		resp = append(resp, toFollowingResponse(&watchers[i], todo))
+	}
+	return resp, nil
+}
+
+// NotifyFollowers implements FollowerNotifier.
+func (s *followService) NotifyFollowers(ctx context.Context, todo *domain.Todo, changes []string, deleted bool) {
+	watchers, err := s.repo.FindByTodoID(todo.ID)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error fetching followers of todo", "todo_id", todo.ID, "err", err)
+		return
+	}
+	if deleted {
+		if err := s.repo.DeleteByTodoID(todo.ID); err != nil {
+			logging.FromContext(ctx).Error("Error removing followers of deleted todo", "todo_id", todo.ID, "err", err)
+		}
+	}
+	if len(watchers) == 0 {
+		return
+	}
+
+	subject := fmt.Sprintf("Updated: %s", todo.Title)
+	if deleted {
+		subject = fmt.Sprintf("Deleted: %s", todo.Title)
+	}
+	msg := notify.Message{
+		Subject: subject,
+		Body:    fmt.Sprintf("%q, which you follow, changed:\n\n- %s", todo.Title, strings.Join(changes, "\n- ")),
+	}
+
+	// Delivery may be slow (SMTP, webhooks); the request shouldn't wait
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), followerNotificationTimeout)
+	go func() {
+		defer cancel()
+		for _, userID := range s.allowedFollowers(ctx, todo, watchers) {
+			s.notify(ctx, userID, msg)
+		}
+	}()
+}
+
+// allowedFollowers returns the followers who may still see todo; a follow
+// outlives a change of role.
+func (s *followService) allowedFollowers(ctx context.Context, todo *domain.Todo, watchers []domain.TodoWatcher) []uint {
+	ids := make([]uint, 0, len(watchers))
+	for _, watcher := range watchers {
+		ids = append(ids, watcher.UserID)
+	}
+	users, err := s.users.FindByIDs(ids)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error fetching followers of todo", "todo_id", todo.ID, "err", err)
+		return nil
+	}
+	allowed := make([]uint, 0, len(users))
+	for _, user := range users {
+		if user.ID == todo.UserID || authz.Can(user.Role, authz.AccessAllTodos) {
+			allowed = append(allowed, user.ID)
+		}
+	}
+	return allowed
+}
+
+// notify sends msg to a follower, if they set up notifications.
+func (s *followService) notify(ctx context.Context, userID uint, msg notify.Message) {
+	pref, err := s.prefs.FindByUserID(userID)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			logging.FromContext(ctx).Error("Error fetching preferences of follower", "user_id", userID, "err", err)
+		}
+		return
+	}
+	if pref.NotificationChannel == "" {
+		return
+	}
+	msg.Recipient = pref.NotificationTarget
+	if err := s.channels.Send(ctx, pref.NotificationChannel, msg); err != nil {
+		logging.FromContext(ctx).Error("Error notifying follower", "user_id", userID, "err", err)
+	}
+}
@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Tomlord1122/todo-backend/internal/apperror"
+	"github.com/Tomlord1122/todo-backend/internal/authz"
+	"github.com/Tomlord1122/todo-backend/internal/domain"
+	"github.com/Tomlord1122/todo-backend/internal/notify"
+	"github.com/Tomlord1122/todo-backend/internal/realtime"
+	"github.com/Tomlord1122/todo-backend/internal/repository"
+)
+
+func TestOthersTodosLookMissingToFollowAndPresence(t *testing.T) {
+	repos := repository.NewMemoryRepositories()
+	follow := NewFollowService(repos.Watchers, repos.Todos, repos.Preferences, repos.Users, notify.NewRegistry())
+	presence := NewPresenceService(realtime.NewMemoryPresence(), repos.Todos, repos.Lists, nil, PresenceConfig{TTL: time.Minute})
+	todo := &domain.Todo{Title: "Pay rent", UserID: 1}
+	if err := repos.Todos.Create(todo); err != nil {
+		t.Fatal(err)
+	}
+	list := &domain.List{Name: "Home", UserID: 1}
+	if err := repos.Lists.Create(list); err != nil {
+		t.Fatal(err)
+	}
+	member := authz.NewContext(context.Background(), authz.Principal{UserID: 2, Role: domain.RoleMember})
+	admin := authz.NewContext(context.Background(), authz.Principal{UserID: 3, Role: domain.RoleAdmin})
+	now := time.Now()
+
+	// A todo of someone else answers like one that doesn't exist
+	_, _, othersErr := follow.Follow(member, 2, todo.ID)
+	_, _, missingErr := follow.Follow(member, 2, 999)
+	if !errors.Is(othersErr, apperror.ErrNotFound) || othersErr.Error() != "todo with ID 1 not found" {
+		t.Errorf("Follow of another user's todo = %v, want todo with ID 1 not found", othersErr)
+	}
+	if !errors.Is(missingErr, apperror.ErrNotFound) {
+		t.Errorf("Follow of a missing todo = %v, want not found", missingErr)
+	}
+	if _, err := presence.Heartbeat(member, 2, PresenceTodo, todo.ID, PresenceRequest{}, now); !errors.Is(err, apperror.ErrNotFound) {
+		t.Errorf("Heartbeat on another user's todo = %v, want not found", err)
+	}
+	if _, err := presence.List(member, 2, PresenceList, list.ID, now); !errors.Is(err, apperror.ErrNotFound) {
+		t.Errorf("List presence of another user's list = %v, want not found", err)
+	}
+
+	// Admins see every todo
+	if _, created, err := follow.Follow(admin, 3, todo.ID); err != nil || !created {
+		t.Fatalf("Follow as an admin = created %t, %v", created, err)
+	}
+	if _, err := presence.Heartbeat(admin, 3, PresenceList, list.ID, PresenceRequest{}, now); err != nil {
+		t.Errorf("Heartbeat on a list as an admin = %v", err)
+	}
+
+	// A follow left from before isn't listed to a user who can't see the todo
+	if err := repos.Watchers.Create(&domain.TodoWatcher{TodoID: todo.ID, UserID: 2}); err != nil {
+		t.Fatal(err)
+	}
+	if following, err := follow.ListFollowing(member, 2); err != nil || len(following) != 0 {
+		t.Errorf("ListFollowing as a member = %+v, %v, want none", following, err)
+	}
+	if following, err := follow.ListFollowing(admin, 3); err != nil || len(following) != 1 {
+		t.Errorf("ListFollowing as an admin = %+v, %v, want the todo", following, err)
+	}
+}
@@ -0,0 +1,383 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Tomlord1122/todo-backend/internal/apperror"
+	"github.com/Tomlord1122/todo-backend/internal/authz"
+	"github.com/Tomlord1122/todo-backend/internal/domain"
+	"github.com/Tomlord1122/todo-backend/internal/github"
+	"github.com/Tomlord1122/todo-backend/internal/logging"
+	"github.com/Tomlord1122/todo-backend/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrGitHubNotConfigured is returned when the GitHub OAuth app isn't
+	// configured.
+	ErrGitHubNotConfigured = apperror.New(apperror.CodeUnavailable, "GitHub sync is not configured")
+	// ErrInvalidWebhookSignature is returned for webhook deliveries that
+	// aren't signed with GITHUB_WEBHOOK_SECRET.
+	ErrInvalidWebhookSignature = apperror.New(apperror.CodeUnauthenticated, "invalid webhook signature")
+)
+
+// LinkGitHubRequest links a list to the issues in Owner/Repo assigned to
+// the user. Like connecting a calendar, it completes the OAuth consent
+// flow: the client sends the user to GitHub's consent screen with scope
+// github.Scope, then passes the code it got back here.
+type LinkGitHubRequest struct {
+	Owner       string `json:"owner"`
+	Repo        string `json:"repo"`
+	Code        string `json:"code"`
+	RedirectURI string `json:"redirect_uri"`
+}
+
+// GitHubLinkResponse describes a list's GitHub link.
+type GitHubLinkResponse struct {
+	ListID       uint    `json:"list_id"`
+	Owner        string  `json:"owner"`
+	Repo         string  `json:"repo"`
+	Login        string  `json:"login"`
+	LastSyncedAt *string `json:"last_synced_at,omitempty"`
+	CreatedAt    string  `json:"created_at"`
+}
+
+// GitHubService syncs lists with GitHub issues. Issues assigned to the
+// GitHub account that authorized the link become todos in the list;
+// completing or reopening either side is copied to the other, by webhook
+// from GitHub and by SyncAll toward it. GitHub is only called with that
+// account's token, so a link reaches no repository its user can't.
+type GitHubService interface {
+	// Link links a list to a repository the user may close issues in and
+	// imports its issues.
+	Link(ctx context.Context, listID uint, req LinkGitHubRequest) (*GitHubLinkResponse, error)
+
+	// GetLink returns the link of a list.
+	GetLink(ctx context.Context, listID uint) (*GitHubLinkResponse, error)
+
+	// Unlink stops syncing a list. Imported todos are kept.
+	Unlink(ctx context.Context, listID uint) error
+
+	// SyncAll imports new issues and reconciles issue and todo states of
+	// every link.
+	SyncAll(ctx context.Context, now time.Time) error
+
+	// HandleWebhook verifies and applies a webhook delivery. Events other
+	// than "issues" are ignored.
+	HandleWebhook(ctx context.Context, eventType string, body []byte, signature string) error
+}
+
+type gitHubService struct {
+	repo   repository.GitHubRepository
+	lists  repository.ListRepository
+	todos  repository.TodoRepository
+	todoSv TodoService
+	client *github.Client
+}
+
+// NewGitHubService creates a new GitHubService. client may be nil, in which
+// case linking returns ErrGitHubNotConfigured and syncing does nothing.
+// Todo changes go through todoSv so they're validated and recorded in
+// the history like any other.
+func NewGitHubService(repo repository.GitHubRepository, lists repository.ListRepository, todos repository.TodoRepository, todoSv TodoService, client *github.Client) GitHubService {
+	return &gitHubService{repo: repo, lists: lists, todos: todos, todoSv: todoSv, client: client}
+}
+
+func toGitHubLinkResponse(link *domain.GitHubLink) *GitHubLinkResponse {
+	return &GitHubLinkResponse{
+		ListID:       link.ListID,
+		Owner:        link.Owner,
+		Repo:         link.Repo,
+		Login:        link.Login,
+		LastSyncedAt: formatOptionalTime(link.LastSyncedAt),
+		CreatedAt:    link.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+// Link implements GitHubService.
+func (s *gitHubService) Link(ctx context.Context, listID uint, req LinkGitHubRequest) (*GitHubLinkResponse, error) {
+	if err := authz.Check(ctx, authz.WriteTodos); err != nil {
+		return nil, err
+	}
+	if s.client == nil {
+		return nil, ErrGitHubNotConfigured
+	}
+	req.Owner, req.Repo = strings.TrimSpace(req.Owner), strings.TrimSpace(req.Repo)
+	if req.Owner == "" || req.Repo == "" || req.Code == "" || req.RedirectURI == "" {
+		return nil, apperror.Invalidf("invalid link: owner, repo, code and redirect_uri are required")
+	}
+	list, err := s.lists.FindByID(listID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.NotFoundf("list with ID %d not found", listID)
+		}
+		logging.FromContext(ctx).Error("Error fetching list for GitHub link", "list_id", listID, "err", err)
+		return nil, errors.New("failed to link list")
+	}
+	if _, err := s.repo.FindLinkByList(listID); err == nil {
+		return nil, apperror.Invalidf("invalid link: list %d is already linked, unlink it first", listID)
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		logging.FromContext(ctx).Error("Error fetching GitHub link of list", "list_id", listID, "err", err)
+		return nil, errors.New("failed to link list")
+	}
+
+	token, err := s.client.Exchange(ctx, req.Code, req.RedirectURI)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error exchanging GitHub authorization code for list", "list_id", listID, "err", err)
+		return nil, apperror.Invalidf("invalid code: GitHub rejected the authorization code")
+	}
+	user, err := s.client.AuthenticatedUser(ctx, token.AccessToken)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error fetching GitHub user for list", "list_id", listID, "err", err)
+		return nil, errors.New("failed to link list")
+	}
+	// Issues are closed with the user's token when todos are completed,
+	// so reading the repository isn't enough
+	permissions, err := s.client.RepositoryPermissions(ctx, token.AccessToken, req.Owner, req.Repo)
+	if err != nil || !permissions.CanCloseIssues() {
+		if err != nil {
+			logging.FromContext(ctx).Error("Error fetching GitHub repository permissions for list", "list_id", listID, "owner", req.Owner, "repo", req.Repo, "err", err)
+		}
+		return nil, apperror.Invalidf("invalid link: GitHub user %s can't close issues in %s/%s", user.Login, req.Owner, req.Repo)
+	}
+
+	link := &domain.GitHubLink{
+		ListID:       list.ID,
+		UserID:       list.UserID,
+		Owner:        req.Owner,
+		Repo:         req.Repo,
+		Login:        user.Login,
+		AccessToken:  token.AccessToken,
+		RefreshToken: token.RefreshToken,
+	}
+	if !token.Expiry.IsZero() {
+		link.AccessTokenExpiry = &token.Expiry
+	}
+	if err := s.repo.CreateLink(link); err != nil {
+		logging.FromContext(ctx).Error("Error creating GitHub link for list", "list_id", listID, "err", err)
+		return nil, errors.New("failed to link list")
+	}
+	// A failed first sync is retried by the job; the link itself is fine
+	if err := s.syncLink(ctx, link, time.Now()); err != nil {
+		logging.FromContext(ctx).Error("Error running first GitHub sync of list", "list_id", listID, "err", err)
+	}
+	return toGitHubLinkResponse(link), nil
+}
+
+// GetLink implements GitHubService.
+func (s *gitHubService) GetLink(ctx context.Context, listID uint) (*GitHubLinkResponse, error) {
+	link, err := s.findLink(ctx, listID)
+	if err != nil {
+		return nil, err
+	}
+	return toGitHubLinkResponse(link), nil
+}
+
+// Unlink implements GitHubService.
+func (s *gitHubService) Unlink(ctx context.Context, listID uint) error {
+	if err := authz.Check(ctx, authz.WriteTodos); err != nil {
+		return err
+	}
+	link, err := s.findLink(ctx, listID)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.DeleteLink(link.ID); err != nil {
+		logging.FromContext(ctx).Error("Error deleting GitHub link of list", "list_id", listID, "err", err)
+		return errors.New("failed to unlink list")
+	}
+	return nil
+}
+
+func (s *gitHubService) findLink(ctx context.Context, listID uint) (*domain.GitHubLink, error) {
+	link, err := s.repo.FindLinkByList(listID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.NotFoundf("GitHub link of list %d not found", listID)
+		}
+		logging.FromContext(ctx).Error("Error fetching GitHub link of list", "list_id", listID, "err", err)
+		return nil, errors.New("failed to retrieve GitHub link")
+	}
+	return link, nil
+}
+
+// SyncAll implements GitHubService. A failing link doesn't stop the others.
+func (s *gitHubService) SyncAll(ctx context.Context, now time.Time) error {
+	if s.client == nil {
+		return nil
+	}
+	links, err := s.repo.FindLinks()
+	if err != nil {
+		return fmt.Errorf("finding GitHub links: %w", err)
+	}
+	var failed int
+	for i := range links {
+		if err := s.syncLink(ctx, &links[i], now); err != nil {
+			logging.FromContext(ctx).Error("Error syncing list", "list_id", links[i].ListID, "owner", links[i].Owner, "repo", links[i].Repo, "err", err)
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d GitHub links failed to sync", failed, len(links))
+	}
+	return nil
+}
+
+// syncLink reconciles every issue assigned to the link's login.
+func (s *gitHubService) syncLink(ctx context.Context, link *domain.GitHubLink, now time.Time) error {
+	accessToken, err := s.accessToken(ctx, link, now)
+	if err != nil {
+		return err
+	}
+	issues, err := s.client.ListAssignedIssues(ctx, accessToken, link.Owner, link.Repo, link.Login)
+	if err != nil {
+		return err
+	}
+	syncs, err := s.syncsByIssue(link.ID)
+	if err != nil {
+		return err
+	}
+	for _, issue := range issues {
+		if err := s.syncIssue(ctx, link, issue, syncs[issue.Number]); err != nil {
+			return fmt.Errorf("issue #%d: %w", issue.Number, err)
+		}
+	}
+	link.LastSyncedAt = &now
+	return s.repo.UpdateLink(link)
+}
+
+// accessToken returns a valid token of the link's user, refreshing an
+// expiring one when it expires within a minute.
+func (s *gitHubService) accessToken(ctx context.Context, link *domain.GitHubLink, now time.Time) (string, error) {
+	if link.AccessTokenExpiry == nil || link.AccessTokenExpiry.After(now.Add(time.Minute)) {
+		return link.AccessToken, nil
+	}
+	token, err := s.client.Refresh(ctx, link.RefreshToken)
+	if err != nil {
+		return "", fmt.Errorf("refreshing access token: %w", err)
+	}
+	link.AccessToken, link.RefreshToken, link.AccessTokenExpiry = token.AccessToken, token.RefreshToken, &token.Expiry
+	if err := s.repo.UpdateLink(link); err != nil {
+		return "", err
+	}
+	return link.AccessToken, nil
+}
+
+// HandleWebhook implements GitHubService.
+func (s *gitHubService) HandleWebhook(ctx context.Context, eventType string, body []byte, signature string) error {
+	if s.client == nil {
+		return ErrGitHubNotConfigured
+	}
+	if !s.client.VerifyWebhook(body, signature) {
+		return ErrInvalidWebhookSignature
+	}
+	if eventType != "issues" {
+		return nil
+	}
+	var event github.IssuesEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		return apperror.Invalidf("invalid payload: %w", err)
+	}
+	if event.Issue.PullRequest != nil {
+		return nil
+	}
+
+	links, err := s.repo.FindLinksByRepo(event.Repository.Owner.Login, event.Repository.Name)
+	if err != nil {
+		return fmt.Errorf("finding GitHub links: %w", err)
+	}
+	for i := range links {
+		syncs, err := s.syncsByIssue(links[i].ID)
+		if err != nil {
+			return err
+		}
+		sync := syncs[event.Issue.Number]
+		if sync == nil && !event.Issue.AssignedTo(links[i].Login) {
+			continue
+		}
+		if err := s.syncIssue(ctx, &links[i], event.Issue, sync); err != nil {
+			return fmt.Errorf("issue #%d: %w", event.Issue.Number, err)
+		}
+	}
+	return nil
+}
+
+func (s *gitHubService) syncsByIssue(linkID uint) (map[int]*domain.GitHubIssueSync, error) {
+	syncs, err := s.repo.FindSyncs(linkID)
+	if err != nil {
+		return nil, fmt.Errorf("finding synced issues: %w", err)
+	}
+	byIssue := make(map[int]*domain.GitHubIssueSync, len(syncs))
+	for i := range syncs {
+		byIssue[syncs[i].IssueNumber] = &syncs[i]
+	}
+	return byIssue, nil
+}
+
+// syncIssue imports an open issue that has no todo yet, or copies a state
+// change from whichever side changed since the last sync to the other.
+func (s *gitHubService) syncIssue(ctx context.Context, link *domain.GitHubLink, issue github.Issue, sync *domain.GitHubIssueSync) error {
+	if sync == nil {
+		if issue.Closed() {
+			return nil
+		}
+		return s.importIssue(ctx, link, issue)
+	}
+
+	todo, err := s.todos.FindByID(sync.TodoID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// The todo was deleted; leave the issue alone
+			return nil
+		}
+		return err
+	}
+	todoChanged := todo.Completed != sync.Closed
+	issueChanged := issue.Closed() != sync.Closed
+	switch {
+	case todoChanged && !issueChanged:
+		accessToken, err := s.accessToken(ctx, link, time.Now())
+		if err != nil {
+			return err
+		}
+		if err := s.client.SetIssueState(ctx, accessToken, link.Owner, link.Repo, issue.Number, todo.Completed); err != nil {
+			return err
+		}
+		sync.Closed = todo.Completed
+	case issueChanged && !todoChanged:
+		closed := issue.Closed()
+		if _, err := s.todoSv.UpdateTodo(ctx, todo.ID, UpdateTodoRequest{Completed: &closed}); err != nil {
+			return err
+		}
+		sync.Closed = closed
+	case todoChanged && issueChanged:
+		// Both sides made the same change
+		sync.Closed = todo.Completed
+	default:
+		return nil
+	}
+	return s.repo.UpdateSync(sync)
+}
+
+func (s *gitHubService) importIssue(ctx context.Context, link *domain.GitHubLink, issue github.Issue) error {
+	description := issue.HTMLURL
+	if body := strings.TrimSpace(issue.Body); body != "" {
+		description = body + "\n\n" + issue.HTMLURL
+	}
+	todo, err := s.todoSv.CreateTodo(ctx, CreateTodoRequest{
+		Title:       issue.Title,
+		Description: description,
+		UserID:      link.UserID,
+		ListID:      &link.ListID,
+	})
+	if err != nil {
+		return err
+	}
+	return s.repo.CreateSync(&domain.GitHubIssueSync{LinkID: link.ID, IssueNumber: issue.Number, TodoID: todo.ID})
+}
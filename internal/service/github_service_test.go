@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Tomlord1122/todo-backend/internal/apperror"
+	"github.com/Tomlord1122/todo-backend/internal/authz"
+	"github.com/Tomlord1122/todo-backend/internal/domain"
+	"github.com/Tomlord1122/todo-backend/internal/github"
+	"github.com/Tomlord1122/todo-backend/internal/notify"
+	"github.com/Tomlord1122/todo-backend/internal/pagination"
+	"github.com/Tomlord1122/todo-backend/internal/realtime"
+	"github.com/Tomlord1122/todo-backend/internal/repository"
+	"github.com/Tomlord1122/todo-backend/internal/suggest"
+)
+
+// fakeGitHub is a GitHub where the code "ada-code" signs in as ada, who may
+// close issues in ada/todos and only read octo/private.
+func fakeGitHub(t *testing.T) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /login/oauth/access_token", func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("code") != "ada-code" {
+			json.NewEncoder(w).Encode(map[string]string{"error": "bad_verification_code"})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"access_token": "ada-token"})
+	})
+	authorized := func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") != "Bearer ada-token" {
+				http.Error(w, "Bad credentials", http.StatusUnauthorized)
+				return
+			}
+			next(w, r)
+		}
+	}
+	mux.HandleFunc("GET /user", authorized(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(github.User{Login: "ada"})
+	}))
+	mux.HandleFunc("GET /repos/ada/todos", authorized(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{"permissions": github.Permissions{Push: true, Pull: true}})
+	}))
+	mux.HandleFunc("GET /repos/octo/private", authorized(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{"permissions": github.Permissions{Pull: true}})
+	}))
+	mux.HandleFunc("GET /repos/ada/todos/issues", authorized(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("assignee") != "ada" {
+			t.Errorf("issues listed for assignee %q, want ada", r.URL.Query().Get("assignee"))
+		}
+		json.NewEncoder(w).Encode([]github.Issue{{Number: 7, Title: "Fix the build", State: "open"}})
+	}))
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestGitHubLinkUsesTheUsersAccount(t *testing.T) {
+	server := fakeGitHub(t)
+	repos := repository.NewMemoryRepositories()
+	follow := NewFollowService(repos.Watchers, repos.Todos, repos.Preferences, repos.Users, notify.NewRegistry())
+	todos := NewTodoService(repos.Todos, repos.Tags, repos.Preferences, repos.Activities,
+		suggest.NewKeywordSuggester(suggest.DefaultKeywordRules()), realtime.NewHub(), follow, TodoConfig{Limits: pagination.DefaultConfig(), FuzzyThreshold: 0.3})
+	client := github.NewClient(github.Config{ClientID: "id", ClientSecret: "secret", APIURL: server.URL, TokenURL: server.URL + "/login/oauth/access_token"}, nil)
+	gitHub := NewGitHubService(repos.GitHub, repos.Lists, repos.Todos, todos, client)
+	ctx := context.Background()
+	list := &domain.List{Name: "Work", UserID: 1}
+	if err := repos.Lists.Create(list); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, req := range map[string]LinkGitHubRequest{
+		"without a code":          {Owner: "ada", Repo: "todos", RedirectURI: "https://app.example.com/cb"},
+		"with a rejected code":    {Owner: "ada", Repo: "todos", Code: "stolen", RedirectURI: "https://app.example.com/cb"},
+		"to a read-only repo":     {Owner: "octo", Repo: "private", Code: "ada-code", RedirectURI: "https://app.example.com/cb"},
+		"to an inaccessible repo": {Owner: "octo", Repo: "secret", Code: "ada-code", RedirectURI: "https://app.example.com/cb"},
+	} {
+		if _, err := gitHub.Link(ctx, list.ID, req); !errors.Is(err, apperror.ErrInvalid) {
+			t.Errorf("Link %s = %v, want invalid", name, err)
+		}
+	}
+
+	viewer := authz.NewContext(ctx, authz.Principal{UserID: 1, Role: domain.RoleViewer})
+	if _, err := gitHub.Link(viewer, list.ID, LinkGitHubRequest{Owner: "ada", Repo: "todos", Code: "ada-code", RedirectURI: "https://app.example.com/cb"}); !errors.Is(err, apperror.ErrForbidden) {
+		t.Errorf("Link as a viewer = %v, want forbidden", err)
+	}
+
+	link, err := gitHub.Link(ctx, list.ID, LinkGitHubRequest{Owner: "ada", Repo: "todos", Code: "ada-code", RedirectURI: "https://app.example.com/cb"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The login is the account that authorized the link, and the first
+	// sync ran with its token
+	if link.Login != "ada" || link.LastSyncedAt == nil {
+		t.Errorf("link = %+v, want ada's, synced", link)
+	}
+	imported, err := repos.Todos.FindByListID(list.ID)
+	if err != nil || len(imported) != 1 || imported[0].Title != "Fix the build" {
+		t.Errorf("imported todos = %+v, %v, want issue #7", imported, err)
+	}
+	if err := gitHub.Unlink(viewer, list.ID); !errors.Is(err, apperror.ErrForbidden) {
+		t.Errorf("Unlink as a viewer = %v, want forbidden", err)
+	}
+}
@@ -0,0 +1,146 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Tomlord1122/todo-backend/internal/apperror"
+	"github.com/Tomlord1122/todo-backend/internal/domain"
+	"github.com/Tomlord1122/todo-backend/internal/logging"
+	"github.com/Tomlord1122/todo-backend/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrIdempotencyKeyReused is returned for a key already used with a
+	// different request.
+	ErrIdempotencyKeyReused = apperror.New(apperror.CodeUnprocessable, "this Idempotency-Key was already used for a different request")
+
+	// ErrIdempotencyKeyInUse is returned while the first request with a key
+	// is still being handled.
+	ErrIdempotencyKeyInUse = apperror.New(apperror.CodeConflict, "a request with this Idempotency-Key is still being processed")
+)
+
+// IdempotencyConfig tunes idempotency keys.
+type IdempotencyConfig struct {
+	// TTL is how long a key and its response are kept.
+	TTL time.Duration
+}
+
+// StoredResponse is a response kept for an idempotency key.
+type StoredResponse struct {
+	StatusCode int
+	Header     map[string]string
+	Body       []byte
+}
+
+// IdempotencyService remembers the responses to requests sent with an
+// Idempotency-Key, so clients can retry them without repeating them.
+type IdempotencyService interface {
+	// Begin claims key of userID for the request identified by
+	// fingerprint. It returns the stored response when the same request
+	// was already made with the key. Otherwise it returns nil and the
+	// caller handles the request, then calls Complete or Release.
+	Begin(ctx context.Context, userID uint, key, fingerprint string) (*StoredResponse, error)
+
+	// Complete stores the response to the request that claimed key.
+	Complete(ctx context.Context, userID uint, key string, resp StoredResponse) error
+
+	// Release gives up a claimed key without a response, so the request
+	// can be retried.
+	Release(ctx context.Context, userID uint, key string) error
+
+	// DeleteExpired removes the keys whose TTL has run out. It runs as a
+	// background job.
+	DeleteExpired(ctx context.Context) error
+}
+
+type idempotencyService struct {
+	repo repository.IdempotencyKeyRepository
+	cfg  IdempotencyConfig
+}
+
+// NewIdempotencyService creates a new IdempotencyService.
+func NewIdempotencyService(repo repository.IdempotencyKeyRepository, cfg IdempotencyConfig) IdempotencyService {
+	return &idempotencyService{repo: repo, cfg: cfg}
+}
+
+func (s *idempotencyService) Begin(ctx context.Context, userID uint, key, fingerprint string) (*StoredResponse, error) {
+	now := time.Now()
+	// A second attempt is needed when the key expired but wasn't cleaned up
+	// yet, or was released in between
+	for attempt := 0; attempt < 2; attempt++ {
+		err := s.repo.Create(&domain.IdempotencyKey{
+			UserID:      userID,
+			Key:         key,
+			Fingerprint: fingerprint,
+			CreatedAt:   now,
+			ExpiresAt:   now.Add(s.cfg.TTL),
+		})
+		if err == nil {
+			return nil, nil
+		}
+		if !errors.Is(err, gorm.ErrDuplicatedKey) {
+			logging.FromContext(ctx).Error("Error claiming idempotency key of user", "user_id", userID, "err", err)
+			return nil, errors.New("failed to check the idempotency key")
+		}
+
+		existing, err := s.repo.Find(userID, key)
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			continue
+		case err != nil:
+			logging.FromContext(ctx).Error("Error fetching idempotency key of user", "user_id", userID, "err", err)
+			return nil, errors.New("failed to check the idempotency key")
+		case !existing.ExpiresAt.After(now):
+			if err := s.repo.Delete(userID, key); err != nil {
+				logging.FromContext(ctx).Error("Error deleting expired idempotency key of user", "user_id", userID, "err", err)
+				return nil, errors.New("failed to check the idempotency key")
+			}
+			continue
+		case existing.Fingerprint != fingerprint:
+			return nil, ErrIdempotencyKeyReused
+		case existing.StatusCode == 0:
+			return nil, ErrIdempotencyKeyInUse
+		}
+		return &StoredResponse{StatusCode: existing.StatusCode, Header: existing.Header, Body: existing.Body}, nil
+	}
+	return nil, ErrIdempotencyKeyInUse
+}
+
+func (s *idempotencyService) Complete(ctx context.Context, userID uint, key string, resp StoredResponse) error {
+	err := s.repo.SaveResponse(&domain.IdempotencyKey{
+		UserID:     userID,
+		Key:        key,
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		Body:       resp.Body,
+	})
+	if err != nil {
+		logging.FromContext(ctx).Error("Error storing the response of an idempotency key of user", "user_id", userID, "err", err)
+		return errors.New("failed to store the response for the idempotency key")
+	}
+	return nil
+}
+
+func (s *idempotencyService) Release(ctx context.Context, userID uint, key string) error {
+	if err := s.repo.Delete(userID, key); err != nil {
+		logging.FromContext(ctx).Error("Error releasing an idempotency key of user", "user_id", userID, "err", err)
+		return errors.New("failed to release the idempotency key")
+	}
+	return nil
+}
+
+func (s *idempotencyService) DeleteExpired(ctx context.Context) error {
+	deleted, err := s.repo.DeleteExpired(time.Now())
+	if err != nil {
+		return fmt.Errorf("deleting expired idempotency keys: %w", err)
+	}
+	if deleted > 0 {
+		logging.FromContext(ctx).Info("Deleted expired idempotency keys", "count", deleted)
+	}
+	return nil
+}
@@ -0,0 +1,271 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Tomlord1122/todo-backend/internal/apperror"
+	"github.com/Tomlord1122/todo-backend/internal/authz"
+	"github.com/Tomlord1122/todo-backend/internal/domain"
+	"github.com/Tomlord1122/todo-backend/internal/importer"
+	"github.com/Tomlord1122/todo-backend/internal/logging"
+	"github.com/Tomlord1122/todo-backend/internal/repository"
+	"github.com/Tomlord1122/todo-backend/internal/suggest"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// importBatch is how many imports one job run picks up.
+	importBatch = 5
+	// importChunkSize is how many rows are committed together; progress
+	// is saved after every chunk.
+	importChunkSize = 100
+	// importStaleAfter is how long a running import may go without saving
+	// progress before it counts as abandoned, e.g. because its instance
+	// crashed, and another run resumes it.
+	importStaleAfter = 2 * time.Minute
+)
+
+// CreateImportRequest is a file exported by another tool. Format is one
+// of importer.Formats.
+type CreateImportRequest struct {
+	UserID uint
+	Format string
+	ListID *uint
+	Data   []byte
+}
+
+// ImportResponse reports the progress of an import.
+type ImportResponse struct {
+	ID         uint                  `json:"id"`
+	UserID     uint                  `json:"user_id"`
+	ListID     *uint                 `json:"list_id,omitempty"`
+	Format     string                `json:"format"`
+	Status     string                `json:"status"`
+	Error      string                `json:"error,omitempty"`
+	Total      int                   `json:"total"`
+	Processed  int                   `json:"processed"`
+	Imported   int                   `json:"imported"`
+	Failed     int                   `json:"failed"`
+	Errors     []ImportErrorResponse `json:"errors"`
+	CreatedAt  string                `json:"created_at"`
+	StartedAt  *string               `json:"started_at,omitempty"`
+	FinishedAt *string               `json:"finished_at,omitempty"`
+}
+
+// ImportErrorResponse is a row that couldn't be imported. Line is the
+// line of CSV files, or the card's position in Trello exports.
+type ImportErrorResponse struct {
+	Line    int    `json:"line"`
+	Message string `json:"message"`
+}
+
+// ImportService creates todos from other tools' exports in the background.
+type ImportService interface {
+	// Create validates the file and queues the import.
+	Create(ctx context.Context, req CreateImportRequest) (*ImportResponse, error)
+
+	// Get reports an import's progress and failed rows.
+	Get(ctx context.Context, id uint) (*ImportResponse, error)
+
+	// RunPending runs queued imports and resumes abandoned ones.
+	RunPending(ctx context.Context, now time.Time) error
+}
+
+type importService struct {
+	repo  repository.ImportRepository
+	lists repository.ListRepository
+}
+
+// NewImportService creates a new ImportService.
+func NewImportService(repo repository.ImportRepository, lists repository.ListRepository) ImportService {
+	return &importService{repo: repo, lists: lists}
+}
+
+// Create implements ImportService.
+func (s *importService) Create(ctx context.Context, req CreateImportRequest) (*ImportResponse, error) {
+	if err := authz.Check(ctx, authz.WriteTodos); err != nil {
+		return nil, err
+	}
+	if req.UserID == 0 {
+		return nil, apperror.Invalidf("invalid import: user_id is required")
+	}
+	if len(req.Data) == 0 {
+		return nil, apperror.Invalidf("invalid import: the file is empty")
+	}
+	if req.ListID != nil {
+		list, err := s.lists.FindByID(*req.ListID)
+		if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && list.UserID != req.UserID) {
+			return nil, apperror.Invalidf("invalid import: list %d does not exist", *req.ListID)
+		}
+		if err != nil {
+			logging.FromContext(ctx).Error("Error fetching list for import", "list_id", *req.ListID, "err", err)
+			return nil, errors.New("failed to create import")
+		}
+	}
+	// Parsed up front so a malformed file is rejected now rather than
+	// failing in the background
+	records, err := importer.Parse(req.Format, req.Data)
+	if err != nil {
+		return nil, apperror.Invalidf("invalid import: %w", err)
+	}
+	if len(records) == 0 {
+		return nil, apperror.Invalidf("invalid import: the file has no rows to import")
+	}
+
+	imp := &domain.Import{
+		UserID: req.UserID,
+		ListID: req.ListID,
+		Format: req.Format,
+		Data:   req.Data,
+		Status: domain.ImportPending,
+		Total:  len(records),
+	}
+	if err := s.repo.Create(imp); err != nil {
+		logging.FromContext(ctx).Error("Error creating import", "err", err)
+		return nil, errors.New("failed to create import")
+	}
+	return toImportResponse(imp, nil), nil
+}
+
+// Get implements ImportService.
+func (s *importService) Get(ctx context.Context, id uint) (*ImportResponse, error) {
+	imp, err := s.repo.FindByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.NotFoundf("import with ID %d not found", id)
+		}
+		logging.FromContext(ctx).Error("Error fetching import", "import_id", id, "err", err)
+		return nil, errors.New("failed to retrieve import")
+	}
+	errs, err := s.repo.FindErrors(id)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error fetching errors of import", "import_id", id, "err", err)
+		return nil, errors.New("failed to retrieve import")
+	}
+	return toImportResponse(imp, errs), nil
+}
+
+func toImportResponse(imp *domain.Import, errs []domain.ImportError) *ImportResponse {
+	response := &ImportResponse{
+		ID:         imp.ID,
+		UserID:     imp.UserID,
+		ListID:     imp.ListID,
+		Format:     imp.Format,
+		Status:     imp.Status,
+		Error:      imp.Error,
+		Total:      imp.Total,
+		Processed:  imp.Processed,
+		Imported:   imp.Imported,
+		Failed:     imp.Failed,
+		Errors:     make([]ImportErrorResponse, 0, len(errs)),
+		CreatedAt:  imp.CreatedAt.Format(time.RFC3339),
+		StartedAt:  formatOptionalTime(imp.StartedAt),
+		FinishedAt: formatOptionalTime(imp.FinishedAt),
+	}
+	for _, e := range errs {
+		response.Errors = append(response.Errors, ImportErrorResponse{Line: e.Line, Message: e.Message})
+	}
+	return response
+}
+
+// RunPending implements ImportService.
+func (s *importService) RunPending(ctx context.Context, now time.Time) error {
+	staleBefore := now.Add(-importStaleAfter)
+	runnable, err := s.repo.FindRunnable(staleBefore, importBatch)
+	if err != nil {
+		return fmt.Errorf("fetching pending imports: %w", err)
+	}
+	for i := range runnable {
+		imp := &runnable[i]
+		claimed, err := s.repo.Claim(imp.ID, staleBefore)
+		if err != nil {
+			return fmt.Errorf("claiming import %d: %w", imp.ID, err)
+		}
+		if !claimed {
+			continue
+		}
+		if imp.Status == domain.ImportRunning {
+			logging.FromContext(ctx).Info("Resuming abandoned import", "import_id", imp.ID, "row", imp.Processed, "rows", imp.Total)
+		}
+		imp.Status = domain.ImportRunning
+		if imp.StartedAt == nil {
+			imp.StartedAt = &now
+		}
+		if err := s.run(ctx, imp); err != nil {
+			if ctx.Err() != nil {
+				// Left running; another run resumes it once it's stale
+				return ctx.Err()
+			}
+			imp.Status = domain.ImportFailed
+			imp.Error = err.Error()
+		} else {
+			imp.Status = domain.ImportDone
+		}
+		finished := time.Now()
+		imp.FinishedAt = &finished
+		// The file isn't needed anymore
+		imp.Data = nil
+		if err := s.repo.Update(imp); err != nil {
+			return fmt.Errorf("updating import %d: %w", imp.ID, err)
+		}
+	}
+	return nil
+}
+
+// run imports the rows after imp.Processed chunk by chunk.
+func (s *importService) run(ctx context.Context, imp *domain.Import) error {
+	records, err := importer.Parse(imp.Format, imp.Data)
+	if err != nil {
+		return fmt.Errorf("reading the file: %w", err)
+	}
+	for imp.Processed < len(records) {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		chunk := records[imp.Processed:min(imp.Processed+importChunkSize, len(records))]
+		var todos []domain.Todo
+		var errs []domain.ImportError
+		for _, record := range chunk {
+			if record.Err != nil {
+				errs = append(errs, domain.ImportError{ImportID: imp.ID, Line: record.Line, Message: record.Err.Error()})
+				continue
+			}
+			todos = append(todos, importedTodo(imp, record.Row))
+		}
+		imp.Processed += len(chunk)
+		imp.Imported += len(todos)
+		imp.Failed += len(errs)
+		if err := s.repo.SaveChunk(imp, todos, errs); err != nil {
+			return fmt.Errorf("saving rows up to %d: %w", imp.Processed, err)
+		}
+	}
+	return nil
+}
+
+// importedTodo converts a parsed row into a todo of the import's user.
+func importedTodo(imp *domain.Import, row importer.Row) domain.Todo {
+	todo := domain.Todo{
+		Title:       row.Title,
+		Description: row.Description,
+		Completed:   row.Completed,
+		Priority:    row.Priority,
+		UserID:      imp.UserID,
+		ListID:      imp.ListID,
+		DueDate:     row.DueDate,
+	}
+	if todo.Priority == "" {
+		todo.Priority = suggest.PriorityNormal
+	}
+	if row.Estimate != nil && *row.Estimate > 0 {
+		todo.Estimate = row.Estimate
+	}
+	if todo.Completed {
+		completedAt := time.Now()
+		todo.CompletedAt = &completedAt
+	}
+	return todo
+}
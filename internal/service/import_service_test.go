@@ -0,0 +1,23 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Tomlord1122/todo-backend/internal/apperror"
+	"github.com/Tomlord1122/todo-backend/internal/authz"
+	"github.com/Tomlord1122/todo-backend/internal/domain"
+	"github.com/Tomlord1122/todo-backend/internal/repository"
+)
+
+func TestImportRequiresWriteTodos(t *testing.T) {
+	repos := repository.NewMemoryRepositories()
+	imports := NewImportService(repos.Imports, repos.Lists)
+	viewer := authz.NewContext(context.Background(), authz.Principal{UserID: 1, Role: domain.RoleViewer})
+
+	// The import runs later without a principal, so it is checked up front
+	if _, err := imports.Create(viewer, CreateImportRequest{UserID: 1, Format: "csv", Data: []byte("title\nPay rent\n")}); !errors.Is(err, apperror.ErrForbidden) {
+		t.Errorf("Create as a viewer = %v, want forbidden", err)
+	}
+}
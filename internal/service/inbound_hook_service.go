@@ -0,0 +1,176 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Tomlord1122/todo-backend/internal/apperror"
+	"github.com/Tomlord1122/todo-backend/internal/authz"
+	"github.com/Tomlord1122/todo-backend/internal/domain"
+	"github.com/Tomlord1122/todo-backend/internal/logging"
+	"github.com/Tomlord1122/todo-backend/internal/mapping"
+	"github.com/Tomlord1122/todo-backend/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+// CreateInboundHookRequest configures an inbound hook. The templates use
+// {{path}} placeholders into the posted JSON, e.g. "{{issue.title}}".
+type CreateInboundHookRequest struct {
+	UserID              uint   `json:"user_id"`
+	ListID              *uint  `json:"list_id"`
+	Priority            string `json:"priority"`
+	TitleTemplate       string `json:"title_template"`
+	DescriptionTemplate string `json:"description_template"`
+}
+
+// InboundHookResponse is returned when an inbound hook is created. The
+// token is only ever shown here; treat the URL like a password.
+type InboundHookResponse struct {
+	Token               string `json:"token"`
+	URL                 string `json:"url"`
+	UserID              uint   `json:"user_id"`
+	ListID              *uint  `json:"list_id,omitempty"`
+	Priority            string `json:"priority,omitempty"`
+	TitleTemplate       string `json:"title_template"`
+	DescriptionTemplate string `json:"description_template,omitempty"`
+	CreatedAt           string `json:"created_at"`
+}
+
+// InboundHookService lets automation tools such as Zapier, Make or IFTTT
+// create todos by posting JSON to a secret URL.
+type InboundHookService interface {
+	// Create configures a new inbound hook.
+	Create(ctx context.Context, req CreateInboundHookRequest) (*InboundHookResponse, error)
+
+	// Revoke disables one of userID's inbound hooks.
+	Revoke(ctx context.Context, userID uint, token string) error
+
+	// Trigger creates a todo from payload, the decoded JSON body posted to
+	// the hook.
+	Trigger(ctx context.Context, token string, payload any) (*TodoResponse, error)
+}
+
+type inboundHookService struct {
+	repo  repository.InboundHookRepository
+	todos TodoService
+}
+
+// NewInboundHookService creates a new InboundHookService. Todos are created
+// through todos so they get the same validation and history as any other.
+func NewInboundHookService(repo repository.InboundHookRepository, todos TodoService) InboundHookService {
+	return &inboundHookService{repo: repo, todos: todos}
+}
+
+// Create implements InboundHookService.
+func (s *inboundHookService) Create(ctx context.Context, req CreateInboundHookRequest) (*InboundHookResponse, error) {
+	if err := authz.Check(ctx, authz.WriteTodos); err != nil {
+		return nil, err
+	}
+	if req.UserID == 0 {
+		return nil, apperror.Invalidf("invalid hook: user_id is required")
+	}
+	if strings.TrimSpace(req.TitleTemplate) == "" {
+		return nil, apperror.Invalidf("invalid hook: title_template is required")
+	}
+	if req.Priority != "" && !validPriority(req.Priority) {
+		return nil, apperror.Invalidf("invalid hook: priority must be low, normal or high")
+	}
+	if err := mapping.Validate(req.TitleTemplate); err != nil {
+		return nil, apperror.Invalidf("invalid hook: title_template: %w", err)
+	}
+	if err := mapping.Validate(req.DescriptionTemplate); err != nil {
+		return nil, apperror.Invalidf("invalid hook: description_template: %w", err)
+	}
+
+	secret, err := generateToken()
+	if err != nil {
+		logging.FromContext(ctx).Error("Error generating inbound hook token", "err", err)
+		return nil, errors.New("failed to create inbound hook")
+	}
+	hook := &domain.InboundHook{
+		UserID:              req.UserID,
+		Token:               secret,
+		ListID:              req.ListID,
+		Priority:            req.Priority,
+		TitleTemplate:       req.TitleTemplate,
+		DescriptionTemplate: req.DescriptionTemplate,
+	}
+	if err := s.repo.Create(hook); err != nil {
+		logging.FromContext(ctx).Error("Error creating inbound hook in repository", "err", err)
+		return nil, errors.New("failed to create inbound hook")
+	}
+
+	return &InboundHookResponse{
+		Token:               hook.Token,
+		URL:                 fmt.Sprintf(apiBasePath+"/hooks/inbound/%s", hook.Token),
+		UserID:              hook.UserID,
+		ListID:              hook.ListID,
+		Priority:            hook.Priority,
+		TitleTemplate:       hook.TitleTemplate,
+		DescriptionTemplate: hook.DescriptionTemplate,
+		CreatedAt:           hook.CreatedAt.Format(time.RFC3339),
+	}, nil
+}
+
+// Revoke implements InboundHookService.
+func (s *inboundHookService) Revoke(ctx context.Context, userID uint, token string) error {
+	if err := authz.Check(ctx, authz.WriteTodos); err != nil {
+		return err
+	}
+	hook, err := s.repo.FindByToken(token)
+	if err == nil && hook.UserID != userID {
+		// Other users' hooks look like missing ones
+		err = gorm.ErrRecordNotFound
+	}
+	if err == nil {
+		err = s.repo.DeleteByToken(token)
+	}
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return apperror.NotFoundf("inbound hook not found")
+		}
+		logging.FromContext(ctx).Error("Error revoking inbound hook", "err", err)
+		return errors.New("failed to revoke inbound hook")
+	}
+	return nil
+}
+
+// Trigger implements InboundHookService.
+func (s *inboundHookService) Trigger(ctx context.Context, token string, payload any) (*TodoResponse, error) {
+	hook, err := s.repo.FindByToken(token)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.NotFoundf("inbound hook not found")
+		}
+		logging.FromContext(ctx).Error("Error looking up inbound hook", "err", err)
+		return nil, errors.New("failed to run inbound hook")
+	}
+
+	// Templates were validated when the hook was created
+	title, err := mapping.Render(hook.TitleTemplate, payload)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error rendering title of inbound hook", "hook_id", hook.ID, "err", err)
+		return nil, errors.New("failed to run inbound hook")
+	}
+	title = strings.TrimSpace(title)
+	if title == "" {
+		return nil, apperror.Invalidf("invalid payload: the title template rendered an empty title")
+	}
+	description, err := mapping.Render(hook.DescriptionTemplate, payload)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error rendering description of inbound hook", "hook_id", hook.ID, "err", err)
+		return nil, errors.New("failed to run inbound hook")
+	}
+
+	return s.todos.CreateTodo(ctx, CreateTodoRequest{
+		Title:       title,
+		Description: strings.TrimSpace(description),
+		UserID:      hook.UserID,
+		Priority:    hook.Priority,
+		ListID:      hook.ListID,
+	})
+}
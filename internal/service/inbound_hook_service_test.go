@@ -0,0 +1,28 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Tomlord1122/todo-backend/internal/apperror"
+	"github.com/Tomlord1122/todo-backend/internal/authz"
+	"github.com/Tomlord1122/todo-backend/internal/domain"
+	"github.com/Tomlord1122/todo-backend/internal/repository"
+)
+
+func TestInboundHookWritesRequireWriteTodos(t *testing.T) {
+	repos := repository.NewMemoryRepositories()
+	hooks := NewInboundHookService(repos.InboundHooks, nil)
+	viewer := authz.NewContext(context.Background(), authz.Principal{UserID: 1, Role: domain.RoleViewer})
+
+	// Hooks create todos later without a principal, so a viewer mustn't
+	// set one up
+	_, createErr := hooks.Create(viewer, CreateInboundHookRequest{UserID: 1})
+	revokeErr := hooks.Revoke(viewer, 1, "token")
+	for op, err := range map[string]error{"Create": createErr, "Revoke": revokeErr} {
+		if !errors.Is(err, apperror.ErrForbidden) {
+			t.Errorf("%s as a viewer = %v, want forbidden", op, err)
+		}
+	}
+}
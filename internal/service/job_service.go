@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/Tomlord1122/todo-backend/internal/domain"
+	"github.com/Tomlord1122/todo-backend/internal/jobs"
+	"github.com/Tomlord1122/todo-backend/internal/logging"
+	"github.com/Tomlord1122/todo-backend/internal/repository"
+)
+
+// Job kinds on the job queue
+const (
+	jobSendReminder    = "reminder.send"
+	jobDeliverWebhook  = "webhook.deliver"
+	jobRunNotionExport = "notion_export.run"
+)
+
+// jobRetention is how long finished jobs are kept
+const jobRetention = 7 * 24 * time.Hour
+
+// JobService is the job queue. Services enqueue background work on it; a
+// jobs.Pool reading from it runs the work on every instance.
+type JobService interface {
+	jobs.Store
+	// DeleteFinished removes the jobs finished more than a week ago. It is
+	// meant to be called periodically by the job scheduler.
+	DeleteFinished(ctx context.Context) error
+}
+
+type jobService struct {
+	repo repository.JobRepository
+}
+
+// NewJobService creates a new JobService.
+func NewJobService(repo repository.JobRepository) JobService {
+	return &jobService{repo: repo}
+}
+
+// RegisterJobs registers the handlers of the services' jobs with pool.
+func RegisterJobs(pool *jobs.Pool, reminders ReminderService, webhooks WebhookService, notionExports NotionExportService) {
+	pool.Handle(jobSendReminder, jobs.Options{MaxAttempts: maxReminderAttempts, Backoff: time.Minute, MaxBackoff: 30 * time.Minute, Timeout: time.Minute}, reminders.Send)
+	pool.Handle(jobDeliverWebhook, jobs.Options{MaxAttempts: maxWebhookAttempts, Backoff: 30 * time.Second, MaxBackoff: time.Hour, Timeout: time.Minute}, webhooks.Deliver)
+	pool.Handle(jobRunNotionExport, jobs.Options{MaxAttempts: 3, Backoff: time.Minute, Timeout: time.Hour}, notionExports.Run)
+}
+
+// enqueueJob adds a job of kind with payload, due at runAt, to the queue.
+// A non-empty key makes enqueueing the same job again a no-op.
+func enqueueJob(repo repository.JobRepository, kind, key string, payload any, runAt time.Time) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("encoding %s job: %w", kind, err)
+	}
+	job := &domain.Job{Kind: kind, Payload: string(data), Status: domain.JobPending, RunAt: runAt}
+	if key != "" {
+		job.Key = &key
+	}
+	return repo.Enqueue(job)
+}
+
+// decodeJob decodes a job's payload into v. A payload that doesn't decode
+// never will, so the error is permanent.
+func decodeJob(job jobs.Job, v any) error {
+	if err := json.Unmarshal(job.Payload, v); err != nil {
+		return jobs.Permanent(fmt.Errorf("decoding %s job: %w", job.Kind, err))
+	}
+	return nil
+}
+
+// Claim implements jobs.Store.
+func (s *jobService) Claim(ctx context.Context, kinds []string, now, until time.Time, limit int) ([]jobs.Job, error) {
+	claimed, err := s.repo.Claim(kinds, now, until, limit)
+	if err != nil {
+		return nil, err
+	}
+	due := make([]jobs.Job, 0, len(claimed))
+	for _, job := range claimed {
+		due = append(due, jobs.Job{ID: job.ID, Kind: job.Kind, Payload: json.RawMessage(job.Payload), Attempts: job.Attempts})
+	}
+	return due, nil
+}
+
+// Extend implements jobs.Store.
+func (s *jobService) Extend(ctx context.Context, ids []uint, until time.Time) error {
+	return s.repo.Extend(ids, until)
+}
+
+// Complete implements jobs.Store.
+func (s *jobService) Complete(ctx context.Context, id uint, at time.Time) error {
+	return s.finish(id, domain.JobDone, at, nil)
+}
+
+// Retry implements jobs.Store.
+func (s *jobService) Retry(ctx context.Context, id uint, at time.Time, runErr error) error {
+	job, err := s.repo.FindByID(id)
+	if err != nil {
+		return err
+	}
+	job.Status, job.RunAt, job.LockedUntil = domain.JobPending, at, nil
+	job.LastError = runErr.Error()
+	return s.repo.Update(job)
+}
+
+// Fail implements jobs.Store.
+func (s *jobService) Fail(ctx context.Context, id uint, runErr error) error {
+	return s.finish(id, domain.JobFailed, time.Now(), runErr)
+}
+
+func (s *jobService) finish(id uint, status string, at time.Time, runErr error) error {
+	job, err := s.repo.FindByID(id)
+	if err != nil {
+		return err
+	}
+	job.Status, job.FinishedAt, job.LockedUntil = status, &at, nil
+	job.LastError = ""
+	if runErr != nil {
+		job.LastError = runErr.Error()
+	}
+	return s.repo.Update(job)
+}
+
+// DeleteFinished implements JobService.
+func (s *jobService) DeleteFinished(ctx context.Context) error {
+	deleted, err := s.repo.DeleteFinishedBefore(time.Now().Add(-jobRetention))
+	if err != nil {
+		return fmt.Errorf("deleting finished jobs: %w", err)
+	}
+	if deleted > 0 {
+		logging.FromContext(ctx).Info("Deleted finished jobs", "count", deleted)
+	}
+	return nil
+}
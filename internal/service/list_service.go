@@ -0,0 +1,172 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/Tomlord1122/todo-backend/internal/apperror"
+	"github.com/Tomlord1122/todo-backend/internal/authz"
+	"github.com/Tomlord1122/todo-backend/internal/domain"
+	"github.com/Tomlord1122/todo-backend/internal/logging"
+	"github.com/Tomlord1122/todo-backend/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+// CreateListRequest holds the data needed to create a new list
+type CreateListRequest struct {
+	Name   string `json:"name" validate:"notblank"`
+	UserID uint   `json:"user_id"`
+}
+
+// UpdateListRequest holds the data for renaming a list
+type UpdateListRequest struct {
+	Name *string `json:"name"`
+}
+
+// ListResponse is the representation of a List returned by the service.
+type ListResponse struct {
+	ID        uint   `json:"id"`
+	Name      string `json:"name"`
+	UserID    uint   `json:"user_id"`
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+	// DeletedAt is only set on deleted lists, which are listed on request
+	DeletedAt *string `json:"deleted_at,omitempty"`
+}
+
+// ListService defines the operations for managing todo lists.
+type ListService interface {
+	CreateList(ctx context.Context, req CreateListRequest) (*ListResponse, error)
+	GetListByID(ctx context.Context, id uint) (*ListResponse, error)
+	// GetListsByUser returns a user's lists. When deletedSince is set, lists
+	// deleted after it are included too, with DeletedAt set.
+	GetListsByUser(ctx context.Context, userID uint, deletedSince *time.Time) ([]ListResponse, error)
+	UpdateList(ctx context.Context, id uint, req UpdateListRequest) (*ListResponse, error)
+	// DeleteList removes a list. Its todos are kept and become unlisted.
+	DeleteList(ctx context.Context, id uint) error
+}
+
+type listService struct {
+	repo repository.ListRepository
+}
+
+// NewListService creates a new ListService.
+func NewListService(repo repository.ListRepository) ListService {
+	return &listService{repo: repo}
+}
+
+func toListResponse(list *domain.List) ListResponse {
+	return ListResponse{
+		ID:        list.ID,
+		Name:      list.Name,
+		UserID:    list.UserID,
+		CreatedAt: list.CreatedAt.Format(time.RFC3339),
+		UpdatedAt: list.UpdatedAt.Format(time.RFC3339),
+		DeletedAt: formatDeletedAt(list.DeletedAt),
+	}
+}
+
+// CreateList implements ListService.
+func (s *listService) CreateList(ctx context.Context, req CreateListRequest) (*ListResponse, error) {
+	if err := authz.Check(ctx, authz.WriteTodos); err != nil {
+		return nil, err
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, apperror.Invalidf("name cannot be empty")
+	}
+
+	list := &domain.List{Name: name, UserID: req.UserID}
+	if err := s.repo.Create(list); err != nil {
+		logging.FromContext(ctx).Error("Error creating list in repository", "err", err)
+		return nil, errors.New("failed to create list")
+	}
+
+	response := toListResponse(list)
+	return &response, nil
+}
+
+// GetListByID implements ListService.
+func (s *listService) GetListByID(ctx context.Context, id uint) (*ListResponse, error) {
+	list, err := s.repo.FindByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.NotFoundf("list with ID %d not found", id)
+		}
+		logging.FromContext(ctx).Error("Error fetching list", "list_id", id, "err", err)
+		return nil, errors.New("failed to retrieve list")
+	}
+
+	response := toListResponse(list)
+	return &response, nil
+}
+
+// GetListsByUser implements ListService.
+func (s *listService) GetListsByUser(ctx context.Context, userID uint, deletedSince *time.Time) ([]ListResponse, error) {
+	lists, err := s.repo.FindByUserID(userID, repository.ListOptions{DeletedSince: deletedSince})
+	if err != nil {
+		logging.FromContext(ctx).Error("Error fetching lists for user", "user_id", userID, "err", err)
+		return nil, errors.New("failed to retrieve lists")
+	}
+
+	responses := make([]ListResponse, 0, len(lists))
+	for i := range lists {
+		responses = append(responses, toListResponse(&lists[i]))
+	}
+	return responses, nil
+}
+
+// UpdateList implements ListService.
+func (s *listService) UpdateList(ctx context.Context, id uint, req UpdateListRequest) (*ListResponse, error) {
+	if err := authz.Check(ctx, authz.WriteTodos); err != nil {
+		return nil, err
+	}
+	list, err := s.repo.FindByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.NotFoundf("list with ID %d not found for update", id)
+		}
+		logging.FromContext(ctx).Error("Error fetching list for update", "list_id", id, "err", err)
+		return nil, errors.New("failed to retrieve list for update")
+	}
+
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" {
+			return nil, apperror.Invalidf("name cannot be empty")
+		}
+		if name != list.Name {
+			list.Name = name
+			if err := s.repo.Update(list); err != nil {
+				logging.FromContext(ctx).Error("Error updating list", "list_id", id, "err", err)
+				return nil, errors.New("failed to update list")
+			}
+		}
+	}
+
+	response := toListResponse(list)
+	return &response, nil
+}
+
+// DeleteList implements ListService.
+func (s *listService) DeleteList(ctx context.Context, id uint) error {
+	if err := authz.Check(ctx, authz.WriteTodos); err != nil {
+		return err
+	}
+	if _, err := s.repo.FindByID(id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return apperror.NotFoundf("list with ID %d not found for deletion", id)
+		}
+		logging.FromContext(ctx).Error("Error checking existence of list before delete", "list_id", id, "err", err)
+		return errors.New("failed to check list before deletion")
+	}
+
+	if err := s.repo.Delete(id); err != nil {
+		logging.FromContext(ctx).Error("Error deleting list", "list_id", id, "err", err)
+		return errors.New("failed to delete list")
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Tomlord1122/todo-backend/internal/apperror"
+	"github.com/Tomlord1122/todo-backend/internal/authz"
+	"github.com/Tomlord1122/todo-backend/internal/domain"
+	"github.com/Tomlord1122/todo-backend/internal/repository"
+)
+
+func TestListWritesRequireWriteTodos(t *testing.T) {
+	repos := repository.NewMemoryRepositories()
+	lists := NewListService(repos.Lists)
+	list := &domain.List{Name: "Home", UserID: 1}
+	if err := repos.Lists.Create(list); err != nil {
+		t.Fatal(err)
+	}
+	viewer := authz.NewContext(context.Background(), authz.Principal{UserID: 1, Role: domain.RoleViewer})
+	name := "Chores"
+
+	_, createErr := lists.CreateList(viewer, CreateListRequest{Name: name, UserID: 1})
+	_, updateErr := lists.UpdateList(viewer, list.ID, UpdateListRequest{Name: &name})
+	deleteErr := lists.DeleteList(viewer, list.ID)
+	for op, err := range map[string]error{"CreateList": createErr, "UpdateList": updateErr, "DeleteList": deleteErr} {
+		if !errors.Is(err, apperror.ErrForbidden) {
+			t.Errorf("%s as a viewer = %v, want forbidden", op, err)
+		}
+	}
+	if got, err := repos.Lists.FindByID(list.ID); err != nil || got.Name != "Home" {
+		t.Errorf("list after a viewer's writes = %+v, %v, want it unchanged", got, err)
+	}
+}
@@ -0,0 +1,414 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Tomlord1122/todo-backend/internal/apperror"
+	"github.com/Tomlord1122/todo-backend/internal/authz"
+	"github.com/Tomlord1122/todo-backend/internal/domain"
+	"github.com/Tomlord1122/todo-backend/internal/jobs"
+	"github.com/Tomlord1122/todo-backend/internal/logging"
+	"github.com/Tomlord1122/todo-backend/internal/notion"
+	"github.com/Tomlord1122/todo-backend/internal/pagination"
+	"github.com/Tomlord1122/todo-backend/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+// notionFields are the todo fields a Notion export can map, by name.
+var notionFields = []string{"title", "description", "completed", "priority", "due_date", "start_date", "estimate", "list"}
+
+// ErrNotionNotConfigured is returned when NOTION_TOKEN or
+// NOTION_DATABASE_IDS isn't set.
+var ErrNotionNotConfigured = apperror.New(apperror.CodeUnavailable, "Notion export is not configured")
+
+// CreateNotionExportRequest selects todos to copy into one of the Notion
+// databases configured for export. Mapping maps todo fields (title,
+// description, completed, priority, due_date, start_date, estimate, list)
+// to property names; it defaults to {"title": "Name"}.
+type CreateNotionExportRequest struct {
+	UserID     uint              `json:"user_id"`
+	DatabaseID string            `json:"database_id"`
+	ListIDs    []uint            `json:"list_ids"`
+	TodoIDs    []uint            `json:"todo_ids"`
+	Mapping    map[string]string `json:"mapping"`
+}
+
+// NotionExportResponse reports the progress of an export.
+type NotionExportResponse struct {
+	ID         uint                      `json:"id"`
+	UserID     uint                      `json:"user_id"`
+	DatabaseID string                    `json:"database_id"`
+	Mapping    map[string]string         `json:"mapping"`
+	Status     string                    `json:"status"`
+	Error      string                    `json:"error,omitempty"`
+	Total      int                       `json:"total"`
+	Exported   int                       `json:"exported"`
+	Failed     int                       `json:"failed"`
+	Rows       []NotionExportRowResponse `json:"rows"`
+	CreatedAt  string                    `json:"created_at"`
+	StartedAt  *string                   `json:"started_at,omitempty"`
+	FinishedAt *string                   `json:"finished_at,omitempty"`
+}
+
+// NotionExportRowResponse is the outcome for one todo.
+type NotionExportRowResponse struct {
+	TodoID uint   `json:"todo_id"`
+	Status string `json:"status"`
+	PageID string `json:"page_id,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// NotionExportService copies todos into Notion databases in the background.
+type NotionExportService interface {
+	// Create validates the selection and enqueues the export's job.
+	Create(ctx context.Context, req CreateNotionExportRequest) (*NotionExportResponse, error)
+
+	// Get reports an export's progress and per-row outcome.
+	Get(ctx context.Context, id uint) (*NotionExportResponse, error)
+
+	// Run runs an export job. It is registered with the worker pool by
+	// RegisterJobs.
+	Run(ctx context.Context, job jobs.Job) error
+}
+
+type notionExportService struct {
+	repo   repository.NotionExportRepository
+	todos  repository.TodoRepository
+	lists  repository.ListRepository
+	jobs   repository.JobRepository
+	client *notion.Client
+	limits pagination.Config
+}
+
+// NewNotionExportService creates a new NotionExportService. client may be
+// nil, in which case Create returns ErrNotionNotConfigured.
+func NewNotionExportService(repo repository.NotionExportRepository, todos repository.TodoRepository, lists repository.ListRepository, jobs repository.JobRepository, client *notion.Client, limits pagination.Config) NotionExportService {
+	return &notionExportService{repo: repo, todos: todos, lists: lists, jobs: jobs, client: client, limits: limits}
+}
+
+// notionExportJob is the payload of an export job.
+type notionExportJob struct {
+	ExportID uint `json:"export_id"`
+}
+
+// Create implements NotionExportService.
+func (s *notionExportService) Create(ctx context.Context, req CreateNotionExportRequest) (*NotionExportResponse, error) {
+	if err := authz.Check(ctx, authz.WriteTodos); err != nil {
+		return nil, err
+	}
+	if s.client == nil {
+		return nil, ErrNotionNotConfigured
+	}
+	req.DatabaseID = strings.TrimSpace(req.DatabaseID)
+	if req.UserID == 0 || req.DatabaseID == "" {
+		return nil, apperror.Invalidf("invalid export: user_id and database_id are required")
+	}
+	// The integration token may reach more databases than users should
+	// write to
+	if !s.client.AllowsDatabase(req.DatabaseID) {
+		return nil, apperror.Invalidf("invalid export: database %s is not open for export", req.DatabaseID)
+	}
+	if len(req.ListIDs) == 0 && len(req.TodoIDs) == 0 {
+		return nil, apperror.Invalidf("invalid export: select at least one list or todo")
+	}
+	mapping, err := validateNotionMapping(req.Mapping)
+	if err != nil {
+		return nil, err
+	}
+	todoIDs, err := s.selectTodos(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.limits.CheckExport(len(todoIDs), "select fewer lists or todos"); err != nil {
+		return nil, err
+	}
+
+	export := &domain.NotionExport{UserID: req.UserID, DatabaseID: req.DatabaseID, Mapping: mapping, Status: domain.NotionExportPending}
+	rows := make([]domain.NotionExportRow, 0, len(todoIDs))
+	for _, id := range todoIDs {
+		rows = append(rows, domain.NotionExportRow{TodoID: id, Status: domain.NotionRowPending})
+	}
+	if err := s.repo.Create(export, rows); err != nil {
+		logging.FromContext(ctx).Error("Error creating Notion export", "err", err)
+		return nil, errors.New("failed to create export")
+	}
+	key := fmt.Sprintf("notion-export:%d", export.ID)
+	if err := enqueueJob(s.jobs, jobRunNotionExport, key, notionExportJob{ExportID: export.ID}, time.Now()); err != nil {
+		logging.FromContext(ctx).Error("Error enqueueing Notion export", "export_id", export.ID, "err", err)
+		export.Status, export.Error = domain.NotionExportFailed, "the export could not be queued"
+		if err := s.repo.Update(export); err != nil {
+			logging.FromContext(ctx).Error("Error updating Notion export", "export_id", export.ID, "err", err)
+		}
+		return nil, errors.New("failed to create export")
+	}
+	return toNotionExportResponse(export, rows), nil
+}
+
+// validateNotionMapping checks the field names and fills in the default.
+func validateNotionMapping(mapping map[string]string) (map[string]string, error) {
+	if len(mapping) == 0 {
+		return map[string]string{"title": "Name"}, nil
+	}
+	cleaned := make(map[string]string, len(mapping))
+	for field, property := range mapping {
+		if !slices.Contains(notionFields, field) {
+			return nil, apperror.Invalidf("invalid mapping: unknown field %q, expected one of %s", field, strings.Join(notionFields, ", "))
+		}
+		if property = strings.TrimSpace(property); property == "" {
+			return nil, apperror.Invalidf("invalid mapping: field %q needs a property name", field)
+		}
+		cleaned[field] = property
+	}
+	if _, ok := cleaned["title"]; !ok {
+		return nil, apperror.Invalidf("invalid mapping: title must be mapped, Notion pages need one")
+	}
+	return cleaned, nil
+}
+
+// selectTodos resolves the selected lists and todos, which must belong to
+// the user, into sorted todo IDs without duplicates.
+func (s *notionExportService) selectTodos(ctx context.Context, req CreateNotionExportRequest) ([]uint, error) {
+	var ids []uint
+	for _, listID := range req.ListIDs {
+		list, err := s.lists.FindByID(listID)
+		if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && list.UserID != req.UserID) {
+			return nil, apperror.Invalidf("invalid export: list %d does not exist", listID)
+		}
+		if err != nil {
+			logging.FromContext(ctx).Error("Error fetching list for export", "list_id", listID, "err", err)
+			return nil, errors.New("failed to create export")
+		}
+		todos, err := s.todos.FindByListID(listID)
+		if err != nil {
+			logging.FromContext(ctx).Error("Error fetching todos of list for export", "list_id", listID, "err", err)
+			return nil, errors.New("failed to create export")
+		}
+		for _, todo := range todos {
+			ids = append(ids, todo.ID)
+		}
+	}
+	for _, todoID := range req.TodoIDs {
+		todo, err := s.todos.FindByID(todoID)
+		if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && todo.UserID != req.UserID) {
+			return nil, apperror.Invalidf("invalid export: todo %d does not exist", todoID)
+		}
+		if err != nil {
+			logging.FromContext(ctx).Error("Error fetching todo for export", "todo_id", todoID, "err", err)
+			return nil, errors.New("failed to create export")
+		}
+		ids = append(ids, todo.ID)
+	}
+	slices.Sort(ids)
+	ids = slices.Compact(ids)
+	if len(ids) == 0 {
+		return nil, apperror.Invalidf("invalid export: the selected lists have no todos")
+	}
+	return ids, nil
+}
+
+// Get implements NotionExportService.
+func (s *notionExportService) Get(ctx context.Context, id uint) (*NotionExportResponse, error) {
+	export, err := s.repo.FindByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.NotFoundf("export with ID %d not found", id)
+		}
+		logging.FromContext(ctx).Error("Error fetching Notion export", "export_id", id, "err", err)
+		return nil, errors.New("failed to retrieve export")
+	}
+	rows, err := s.repo.FindRows(id)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error fetching rows of Notion export", "export_id", id, "err", err)
+		return nil, errors.New("failed to retrieve export")
+	}
+	return toNotionExportResponse(export, rows), nil
+}
+
+func toNotionExportResponse(export *domain.NotionExport, rows []domain.NotionExportRow) *NotionExportResponse {
+	response := &NotionExportResponse{
+		ID:         export.ID,
+		UserID:     export.UserID,
+		DatabaseID: export.DatabaseID,
+		Mapping:    export.Mapping,
+		Status:     export.Status,
+		Error:      export.Error,
+		Total:      len(rows),
+		Rows:       make([]NotionExportRowResponse, 0, len(rows)),
+		CreatedAt:  export.CreatedAt.Format(time.RFC3339),
+		StartedAt:  formatOptionalTime(export.StartedAt),
+		FinishedAt: formatOptionalTime(export.FinishedAt),
+	}
+	for _, row := range rows {
+		switch row.Status {
+		case domain.NotionRowExported:
+			response.Exported++
+		case domain.NotionRowFailed:
+			response.Failed++
+		}
+		response.Rows = append(response.Rows, NotionExportRowResponse{TodoID: row.TodoID, Status: row.Status, PageID: row.PageID, Error: row.Error})
+	}
+	return response
+}
+
+// Run implements NotionExportService. An export interrupted by a
+// shutdown or a crash picks up where it stopped on the job's next attempt,
+// since only pending rows are written.
+func (s *notionExportService) Run(ctx context.Context, job jobs.Job) error {
+	if s.client == nil {
+		return jobs.Permanent(ErrNotionNotConfigured)
+	}
+	var payload notionExportJob
+	if err := decodeJob(job, &payload); err != nil {
+		return err
+	}
+	export, err := s.repo.FindByID(payload.ExportID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return jobs.Permanent(err)
+	}
+	if err != nil {
+		return err
+	}
+	if export.Status == domain.NotionExportDone || export.Status == domain.NotionExportFailed {
+		return nil
+	}
+
+	if export.StartedAt == nil {
+		now := time.Now()
+		export.StartedAt = &now
+	}
+	export.Status = domain.NotionExportRunning
+	if err := s.repo.Update(export); err != nil {
+		return fmt.Errorf("updating Notion export %d: %w", export.ID, err)
+	}
+	if err := s.run(ctx, export); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		export.Status = domain.NotionExportFailed
+		export.Error = err.Error()
+	} else {
+		export.Status = domain.NotionExportDone
+	}
+	finished := time.Now()
+	export.FinishedAt = &finished
+	if err := s.repo.Update(export); err != nil {
+		return fmt.Errorf("updating Notion export %d: %w", export.ID, err)
+	}
+	return nil
+}
+
+// run writes the export's rows. It returns an error only when no row can
+// be written, e.g. because the database is missing or doesn't fit the
+// mapping; problems with single rows are recorded on the row.
+func (s *notionExportService) run(ctx context.Context, export *domain.NotionExport) error {
+	// The allowed databases may have changed since the export was created
+	if !s.client.AllowsDatabase(export.DatabaseID) {
+		return fmt.Errorf("database %s is not open for export", export.DatabaseID)
+	}
+	types, err := s.client.DatabaseProperties(ctx, export.DatabaseID)
+	if err != nil {
+		return fmt.Errorf("reading the database: %w", err)
+	}
+	fields := make([]string, 0, len(export.Mapping))
+	for field, property := range export.Mapping {
+		propertyType, ok := types[property]
+		if !ok {
+			return fmt.Errorf("the database has no property %q", property)
+		}
+		if field == "title" && propertyType != "title" {
+			return fmt.Errorf("title must map to the database's title property, %q is a %s", property, propertyType)
+		}
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	rows, err := s.repo.FindRows(export.ID)
+	if err != nil {
+		return fmt.Errorf("fetching rows: %w", err)
+	}
+	listNames := make(map[uint]string)
+	for i := range rows {
+		row := &rows[i]
+		if row.Status != domain.NotionRowPending {
+			continue
+		}
+		pageID, err := s.exportRow(ctx, export, row.TodoID, fields, types, listNames)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			row.Status, row.Error = domain.NotionRowFailed, err.Error()
+		} else {
+			row.Status, row.PageID = domain.NotionRowExported, pageID
+		}
+		if err := s.repo.UpdateRow(row); err != nil {
+			return fmt.Errorf("updating row of todo %d: %w", row.TodoID, err)
+		}
+	}
+	return nil
+}
+
+// exportRow creates the page of one todo.
+func (s *notionExportService) exportRow(ctx context.Context, export *domain.NotionExport, todoID uint, fields []string, types map[string]string, listNames map[uint]string) (string, error) {
+	todo, err := s.todos.FindByID(todoID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", errors.New("the todo was deleted")
+		}
+		return "", err
+	}
+
+	properties := make(map[string]any, len(fields))
+	for _, field := range fields {
+		property := export.Mapping[field]
+		value, err := notion.Value(types[property], s.notionFieldValue(todo, field, listNames))
+		if err != nil {
+			return "", fmt.Errorf("property %q: %w", property, err)
+		}
+		properties[property] = value
+	}
+	return s.client.CreatePage(ctx, export.DatabaseID, properties)
+}
+
+// notionFieldValue returns a todo field in a form notion.Value accepts.
+func (s *notionExportService) notionFieldValue(todo *domain.Todo, field string, listNames map[uint]string) any {
+	switch field {
+	case "title":
+		return todo.Title
+	case "description":
+		return todo.Description
+	case "completed":
+		return todo.Completed
+	case "priority":
+		return todo.Priority
+	case "due_date":
+		return todo.DueDate
+	case "start_date":
+		return todo.StartDate
+	case "estimate":
+		if todo.Estimate == nil {
+			return nil
+		}
+		return *todo.Estimate
+	case "list":
+		if todo.ListID == nil {
+			return ""
+		}
+		name, ok := listNames[*todo.ListID]
+		if !ok {
+			if list, err := s.lists.FindByID(*todo.ListID); err == nil {
+				name = list.Name
+			}
+			listNames[*todo.ListID] = name
+		}
+		return name
+	}
+	return nil
+}
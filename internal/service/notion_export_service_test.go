@@ -0,0 +1,40 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Tomlord1122/todo-backend/internal/apperror"
+	"github.com/Tomlord1122/todo-backend/internal/authz"
+	"github.com/Tomlord1122/todo-backend/internal/domain"
+	"github.com/Tomlord1122/todo-backend/internal/notion"
+	"github.com/Tomlord1122/todo-backend/internal/pagination"
+	"github.com/Tomlord1122/todo-backend/internal/repository"
+)
+
+func TestNotionExportOnlyToAllowedDatabases(t *testing.T) {
+	repos := repository.NewMemoryRepositories()
+	client := notion.NewClient(notion.Config{Token: "secret", DatabaseIDs: []string{"team-db"}}, nil)
+	exports := NewNotionExportService(repos.NotionExports, repos.Todos, repos.Lists, repos.Jobs, client, pagination.DefaultConfig())
+	todo := &domain.Todo{Title: "File taxes", UserID: 1}
+	if err := repos.Todos.Create(todo); err != nil {
+		t.Fatal(err)
+	}
+
+	viewer := authz.NewContext(context.Background(), authz.Principal{UserID: 1, Role: domain.RoleViewer})
+	if _, err := exports.Create(viewer, CreateNotionExportRequest{UserID: 1, DatabaseID: "team-db", TodoIDs: []uint{todo.ID}}); !errors.Is(err, apperror.ErrForbidden) {
+		t.Errorf("Create as a viewer = %v, want forbidden", err)
+	}
+	// Other databases shared with the integration are off limits
+	if _, err := exports.Create(context.Background(), CreateNotionExportRequest{UserID: 1, DatabaseID: "someone-elses-db", TodoIDs: []uint{todo.ID}}); !errors.Is(err, apperror.ErrInvalid) {
+		t.Errorf("Create to a database that isn't allowed = %v, want invalid", err)
+	}
+	export, err := exports.Create(context.Background(), CreateNotionExportRequest{UserID: 1, DatabaseID: "team-db", TodoIDs: []uint{todo.ID}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if export.Status != domain.NotionExportPending || export.Total != 1 {
+		t.Errorf("export = %+v, want one pending row", export)
+	}
+}
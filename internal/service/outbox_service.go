@@ -0,0 +1,138 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Tomlord1122/todo-backend/internal/domain"
+	"github.com/Tomlord1122/todo-backend/internal/events"
+	"github.com/Tomlord1122/todo-backend/internal/jobs"
+	"github.com/Tomlord1122/todo-backend/internal/logging"
+	"github.com/Tomlord1122/todo-backend/internal/repository"
+	"github.com/Tomlord1122/todo-backend/internal/requestid"
+)
+
+const (
+	// maxOutboxAttempts is how often an event is published before it is
+	// given up
+	maxOutboxAttempts = 10
+	// outboxBaseBackoff is the wait after the first failed attempt; it
+	// doubles with every further one, up to outboxMaxBackoff.
+	outboxBaseBackoff = 10 * time.Second
+	outboxMaxBackoff  = time.Hour
+	// outboxRetention is how long published events are kept
+	outboxRetention = 7 * 24 * time.Hour
+)
+
+// OutboxService is the outbox of domain events. Changes to todos add their
+// events to it in the same transaction; an events.Relay reading from it
+// publishes them afterwards.
+type OutboxService interface {
+	events.Store
+	// DeletePublished removes the events published more than a week ago.
+	// It is meant to be called periodically by the job scheduler.
+	DeletePublished(ctx context.Context) error
+}
+
+type outboxService struct {
+	repo repository.OutboxRepository
+}
+
+// NewOutboxService creates a new OutboxService.
+func NewOutboxService(repo repository.OutboxRepository) OutboxService {
+	return &outboxService{repo: repo}
+}
+
+// addEvent adds a domain event about todo to outbox. It is called in the
+// transaction that saves the change, so the event is stored if and only if
+// the change is. The event carries the ID of the request in ctx, if any.
+func addEvent(ctx context.Context, outbox repository.OutboxRepository, eventType string, todo *domain.Todo, data any) error {
+	event, err := events.New(eventType, todo.UserID, todo.ID, data, time.Now())
+	if err != nil {
+		return err
+	}
+	return outbox.Add(&domain.OutboxEvent{
+		EventID:       event.ID,
+		RequestID:     requestid.FromContext(ctx),
+		Type:          event.Type,
+		UserID:        event.UserID,
+		TodoID:        event.TodoID,
+		OccurredAt:    event.OccurredAt,
+		Data:          string(event.Data),
+		Status:        domain.OutboxPending,
+		NextAttemptAt: event.OccurredAt,
+	})
+}
+
+// ClaimDue implements events.Store.
+func (s *outboxService) ClaimDue(ctx context.Context, now, until time.Time, limit int) ([]events.Pending, error) {
+	outboxEvents, err := s.repo.FindDue(now, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	var due []events.Pending
+	var errs []error
+	for i := range outboxEvents {
+		e := &outboxEvents[i]
+		claimed, err := s.repo.Claim(e.ID, now, until)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("event %d: %w", e.ID, err))
+			continue
+		}
+		if !claimed {
+			continue
+		}
+		event := events.Event{ID: e.EventID, Type: e.Type, OccurredAt: e.OccurredAt.UTC(), UserID: e.UserID, TodoID: e.TodoID, RequestID: e.RequestID}
+		if e.Data != "" {
+			event.Data = json.RawMessage(e.Data)
+		}
+		due = append(due, events.Pending{ID: e.ID, Event: event})
+	}
+	return due, errors.Join(errs...)
+}
+
+// MarkPublished implements events.Store.
+func (s *outboxService) MarkPublished(ctx context.Context, id uint, at time.Time) error {
+	event, err := s.repo.FindByID(id)
+	if err != nil {
+		return err
+	}
+	event.Status = domain.OutboxPublished
+	event.PublishedAt = &at
+	event.ClaimedUntil = nil
+	event.LastError = ""
+	return s.repo.Update(event)
+}
+
+// MarkFailed implements events.Store. The event is retried with exponential
+// backoff, until maxOutboxAttempts attempts failed.
+func (s *outboxService) MarkFailed(ctx context.Context, id uint, publishErr error) error {
+	event, err := s.repo.FindByID(id)
+	if err != nil {
+		return err
+	}
+	event.LastError = publishErr.Error()
+	event.ClaimedUntil = nil
+	if event.Attempts >= maxOutboxAttempts {
+		event.Status = domain.OutboxFailed
+	} else {
+		event.NextAttemptAt = time.Now().Add(jobs.Backoff(event.Attempts, outboxBaseBackoff, outboxMaxBackoff))
+	}
+	return s.repo.Update(event)
+}
+
+// DeletePublished implements OutboxService.
+func (s *outboxService) DeletePublished(ctx context.Context) error {
+	deleted, err := s.repo.DeletePublishedBefore(time.Now().Add(-outboxRetention))
+	if err != nil {
+		return fmt.Errorf("deleting published events: %w", err)
+	}
+	if deleted > 0 {
+		logging.FromContext(ctx).Info("Deleted published events", "count", deleted)
+	}
+	return nil
+}
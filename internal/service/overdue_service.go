@@ -0,0 +1,151 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Tomlord1122/todo-backend/internal/apperror"
+	"github.com/Tomlord1122/todo-backend/internal/domain"
+	"github.com/Tomlord1122/todo-backend/internal/logging"
+	"github.com/Tomlord1122/todo-backend/internal/notify"
+	"github.com/Tomlord1122/todo-backend/internal/repository"
+)
+
+// OverdueTodo is an overdue todo and how many days late it is.
+type OverdueTodo struct {
+	TodoResponse
+	DaysOverdue int `json:"days_overdue"`
+}
+
+// OverdueService finds overdue todos and reminds their owners. A todo is
+// overdue once its due date is before today in the owner's time zone, so
+// something due today is never overdue, wherever the user is.
+type OverdueService interface {
+	// ListOverdue returns a user's overdue todos, most overdue first. tz
+	// overrides the user's timezone preference when set.
+	ListOverdue(ctx context.Context, userID uint, tz string, now time.Time) ([]OverdueTodo, error)
+	// NotifyDue sends one reminder for every todo that became overdue, for
+	// users who asked for them. It is meant to be called periodically by the
+	// job scheduler and is safe to run on several instances at once.
+	NotifyDue(ctx context.Context, now time.Time) error
+}
+
+type overdueService struct {
+	todos    repository.TodoRepository
+	prefs    repository.PreferenceRepository
+	channels *notify.Registry
+}
+
+// NewOverdueService creates a new OverdueService.
+func NewOverdueService(todos repository.TodoRepository, prefs repository.PreferenceRepository, channels *notify.Registry) OverdueService {
+	return &overdueService{todos: todos, prefs: prefs, channels: channels}
+}
+
+// ListOverdue implements OverdueService.
+func (s *overdueService) ListOverdue(ctx context.Context, userID uint, tz string, now time.Time) ([]OverdueTodo, error) {
+	pref, err := loadPreferences(s.prefs, userID)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error fetching preferences for user", "user_id", userID, "err", err)
+		return nil, errors.New("failed to retrieve overdue todos")
+	}
+	loc := userLocation(pref)
+	if tz != "" {
+		if loc, err = time.LoadLocation(tz); err != nil {
+			return nil, apperror.Invalidf("invalid timezone %q", tz)
+		}
+	}
+
+	todos, err := s.todos.FindOpenByUser(userID)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error fetching open todos for user", "user_id", userID, "err", err)
+		return nil, errors.New("failed to retrieve overdue todos")
+	}
+
+	// Open todos come ordered by due date, so the most overdue are first
+	overdue := []OverdueTodo{}
+	for i := range todos {
+		if days := daysOverdue(&todos[i], now, loc); days > 0 {
+			overdue = append(overdue, OverdueTodo{TodoResponse: toTodoResponse(&todos[i]), DaysOverdue: days})
+		}
+	}
+	return overdue, nil
+}
+
+// NotifyDue implements OverdueService.
+func (s *overdueService) NotifyDue(ctx context.Context, now time.Time) error {
+	prefs, err := s.prefs.FindOverdueReminders()
+	if err != nil {
+		return fmt.Errorf("fetching overdue reminder preferences: %w", err)
+	}
+
+	var errs []error
+	for i := range prefs {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := s.notifyUser(ctx, &prefs[i], now); err != nil {
+			errs = append(errs, fmt.Errorf("user %d: %w", prefs[i].UserID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// notifyUser reminds one user of todos that became overdue.
+func (s *overdueService) notifyUser(ctx context.Context, pref *domain.UserPreference, now time.Time) error {
+	todos, err := s.todos.FindOpenByUser(pref.UserID)
+	if err != nil {
+		return err
+	}
+	loc := userLocation(pref)
+
+	var errs []error
+	for i := range todos {
+		todo := &todos[i]
+		if todo.OverdueNotifiedAt != nil || daysOverdue(todo, now, loc) == 0 {
+			continue
+		}
+		// Claim before sending: a reminder lost to a failed send is better
+		// than one sent by every instance
+		claimed, err := s.todos.ClaimOverdueNotification(todo.ID, now)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("todo %d: %w", todo.ID, err))
+			continue
+		}
+		if !claimed {
+			continue
+		}
+		msg := notify.Message{
+			Recipient: pref.NotificationTarget,
+			Subject:   fmt.Sprintf("Overdue: %s", todo.Title),
+			Body:      fmt.Sprintf("%q was due %s.", todo.Title, todo.DueDate.In(loc).Format("Monday, January 2")),
+		}
+		if err := s.channels.Send(ctx, pref.NotificationChannel, msg); err != nil {
+			errs = append(errs, fmt.Errorf("todo %d: %w", todo.ID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// daysOverdue returns how many calendar days in loc the todo's due date is
+// before today, or 0 if it isn't overdue.
+func daysOverdue(todo *domain.Todo, now time.Time, loc *time.Location) int {
+	if todo.Completed || todo.DueDate == nil {
+		return 0
+	}
+	// Compare calendar dates in loc, measured in UTC so DST changes don't
+	// make a day 23 or 25 hours long
+	today := calendarDate(now.In(loc))
+	due := calendarDate(todo.DueDate.In(loc))
+	if !due.Before(today) {
+		return 0
+	}
+	return int(today.Sub(due) / (24 * time.Hour))
+}
+
+// calendarDate returns t's date as midnight UTC.
+func calendarDate(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,58 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Tomlord1122/todo-backend/internal/domain"
+	"github.com/Tomlord1122/todo-backend/internal/notify"
+	"github.com/Tomlord1122/todo-backend/internal/repository"
+)
+
+func TestNotifyDueClaimsEachTodoOnce(t *testing.T) {
+	repos := repository.NewMemoryRepositories()
+	channel := &recordingChannel{}
+	if err := repos.Preferences.Save(&domain.UserPreference{UserID: 1, NotifyOverdue: true, NotificationChannel: "test", NotificationTarget: "ada@example.com"}); err != nil {
+		t.Fatal(err)
+	}
+	due := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+	for _, title := range []string{"File taxes", "Renew passport", "Call the bank"} {
+		if err := repos.Todos.Create(&domain.Todo{Title: title, UserID: 1, DueDate: &due}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	now := due.AddDate(0, 0, 2)
+
+	// Every instance runs the job at the same time; only one may remind
+	// the user of each todo
+	const instances = 8
+	var wg sync.WaitGroup
+	errs := make([]error, instances)
+	for i := range instances {
+		overdue := NewOverdueService(repos.Todos, repos.Preferences, notify.NewRegistry(channel))
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = overdue.NotifyDue(context.Background(), now)
+		}()
+	}
+	wg.Wait()
+	for i, err := range errs {
+		if err != nil {
+			t.Errorf("instance %d: NotifyDue: %v", i, err)
+		}
+	}
+	if got := channel.count(); got != 3 {
+		t.Errorf("sent %d reminders, want one for each of the 3 todos", got)
+	}
+
+	// Later runs find them claimed
+	if err := NewOverdueService(repos.Todos, repos.Preferences, notify.NewRegistry(channel)).NotifyDue(context.Background(), now.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if got := channel.count(); got != 3 {
+		t.Errorf("sent %d reminders after another run, want still 3", got)
+	}
+}
@@ -0,0 +1,61 @@
+package service
+
+import (
+	"encoding/base64"
+	"encoding/json"
+
+	"github.com/Tomlord1122/todo-backend/internal/apperror"
+)
+
+// PageRequest selects one page of a listing. A zero Limit means the
+// configured default page size. Listings that support it can be paged
+// with Cursor, the NextCursor of the previous page, instead of Offset.
+type PageRequest struct {
+	Limit  int
+	Offset int
+	Cursor string
+}
+
+// PageInfo describes the page that was returned.
+type PageInfo struct {
+	Limit  int `json:"limit"`
+	Offset int `json:"offset"`
+	// Total is the number of items, nil for cursor pages which skip
+	// counting
+	Total *int64 `json:"total,omitempty"`
+	// NextOffset is the offset of the next page, nil on the last page
+	NextOffset *int `json:"next_offset,omitempty"`
+	// NextCursor continues after this page, empty on the last page
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// newPageInfo fills in NextOffset from the page position and total.
+func newPageInfo(limit, offset int, total int64) *PageInfo {
+	info := &PageInfo{Limit: limit, Offset: offset, Total: &total}
+	if next := offset + limit; int64(next) < total {
+		info.NextOffset = &next
+	}
+	return info
+}
+
+// pageCursor is what a cursor encodes: the position after which the next
+// page starts.
+type pageCursor struct {
+	LastID uint `json:"id"`
+}
+
+// encodeCursor returns the opaque cursor for the page after lastID.
+func encodeCursor(lastID uint) string {
+	raw, _ := json.Marshal(pageCursor{LastID: lastID})
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// decodeCursor reverses encodeCursor.
+func decodeCursor(cursor string) (pageCursor, error) {
+	var c pageCursor
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || json.Unmarshal(raw, &c) != nil || c.LastID == 0 {
+		return c, apperror.Invalidf("invalid cursor, use the next_cursor of a previous page")
+	}
+	return c, nil
+}
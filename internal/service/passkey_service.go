@@ -0,0 +1,374 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Tomlord1122/todo-backend/internal/apperror"
+	"github.com/Tomlord1122/todo-backend/internal/domain"
+	"github.com/Tomlord1122/todo-backend/internal/logging"
+	"github.com/Tomlord1122/todo-backend/internal/repository"
+	"github.com/Tomlord1122/todo-backend/internal/webauthn"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrPasskeysNotConfigured is returned when WEBAUTHN_RP_ID isn't set.
+	ErrPasskeysNotConfigured = apperror.New(apperror.CodeUnavailable, "passkeys are not configured")
+	// ErrPasskeyLoginFailed is returned for any failed login, without
+	// saying why, so attackers learn nothing about the credential.
+	ErrPasskeyLoginFailed = apperror.New(apperror.CodeUnauthenticated, "passkey login failed")
+)
+
+// maxPasskeyNameLength bounds passkey names.
+const maxPasskeyNameLength = 100
+
+// PasskeyRegistrationOptions are passed to navigator.credentials.create.
+type PasskeyRegistrationOptions struct {
+	PublicKey webauthn.CreationOptions `json:"publicKey"`
+}
+
+// FinishPasskeyRegistrationRequest completes adding a passkey with the
+// browser's answer. Name defaults to "Passkey".
+type FinishPasskeyRegistrationRequest struct {
+	Name       string                        `json:"name,omitempty"`
+	Credential webauthn.RegistrationResponse `json:"credential"`
+}
+
+// UpdatePasskeyRequest renames a passkey.
+type UpdatePasskeyRequest struct {
+	Name string `json:"name" validate:"notblank"`
+}
+
+// PasskeyResponse describes a passkey. The key itself is never returned.
+type PasskeyResponse struct {
+	ID         uint     `json:"id"`
+	Name       string   `json:"name"`
+	Transports []string `json:"transports"`
+	BackedUp   bool     `json:"backed_up"`
+	CreatedAt  string   `json:"created_at"`
+	LastUsedAt *string  `json:"last_used_at,omitempty"`
+}
+
+// PasskeyLoginOptions are passed to navigator.credentials.get.
+type PasskeyLoginOptions struct {
+	PublicKey webauthn.RequestOptions `json:"publicKey"`
+}
+
+// FinishPasskeyLoginRequest completes a login with the browser's answer.
+type FinishPasskeyLoginRequest struct {
+	Credential webauthn.LoginResponse `json:"credential"`
+}
+
+// PasskeyService registers passkeys and signs users in with them.
+// Registration and login are two-step WebAuthn ceremonies: Begin returns
+// options for the browser and Finish verifies its answer. Ceremonies are
+// kept in memory, so both steps must reach the same instance.
+type PasskeyService interface {
+	// BeginRegistration starts adding a passkey for the signed-in user
+	// userID.
+	BeginRegistration(ctx context.Context, userID uint) (*PasskeyRegistrationOptions, error)
+
+	// FinishRegistration verifies and stores the new passkey of the user
+	// who began the registration, who must be userID.
+	FinishRegistration(ctx context.Context, userID uint, req FinishPasskeyRegistrationRequest) (*PasskeyResponse, error)
+
+	// ListPasskeys returns the passkeys of a user.
+	ListPasskeys(ctx context.Context, userID uint) ([]PasskeyResponse, error)
+
+	// RenamePasskey renames one of the user's passkeys.
+	RenamePasskey(ctx context.Context, userID, id uint, req UpdatePasskeyRequest) (*PasskeyResponse, error)
+
+	// DeletePasskey removes one of the user's passkeys and ends the
+	// sessions signed in with it.
+	DeletePasskey(ctx context.Context, userID, id uint) error
+
+	// BeginLogin starts a login with any passkey of this site.
+	BeginLogin(ctx context.Context) (*PasskeyLoginOptions, error)
+
+	// FinishLogin verifies the assertion and starts a session.
+	FinishLogin(ctx context.Context, req FinishPasskeyLoginRequest, now time.Time) (*SessionResponse, error)
+}
+
+// passkeyCeremony is a started registration or login.
+type passkeyCeremony struct {
+	// userID is the user registering a passkey; 0 for logins
+	userID  uint
+	expires time.Time
+}
+
+type passkeyService struct {
+	repo     repository.PasskeyRepository
+	sessions repository.SessionRepository
+	rp       *webauthn.RelyingParty
+	cfg      SessionConfig
+
+	mu sync.Mutex
+	// ceremonies are keyed by type and challenge, see ceremonyKey
+	ceremonies map[string]passkeyCeremony
+}
+
+// NewPasskeyService creates a new PasskeyService. rp may be nil, in which
+// case registration and login return ErrPasskeysNotConfigured.
+func NewPasskeyService(repo repository.PasskeyRepository, sessions repository.SessionRepository, rp *webauthn.RelyingParty, cfg SessionConfig) PasskeyService {
+	return &passkeyService{repo: repo, sessions: sessions, rp: rp, cfg: cfg, ceremonies: make(map[string]passkeyCeremony)}
+}
+
+func toPasskeyResponse(passkey *domain.Passkey) PasskeyResponse {
+	transports := []string{}
+	if passkey.Transports != "" {
+		transports = strings.Split(passkey.Transports, ",")
+	}
+	return PasskeyResponse{
+		ID:         passkey.ID,
+		Name:       passkey.Name,
+		Transports: transports,
+		BackedUp:   passkey.BackedUp,
+		CreatedAt:  passkey.CreatedAt.Format(time.RFC3339),
+		LastUsedAt: formatOptionalTime(passkey.LastUsedAt),
+	}
+}
+
+// ceremonyKey keeps a login challenge from completing a registration and
+// vice versa.
+func ceremonyKey(kind, challenge string) string {
+	return kind + ":" + challenge
+}
+
+// startCeremony issues a challenge and remembers the ceremony until it
+// times out.
+func (s *passkeyService) startCeremony(kind string, userID uint) (string, error) {
+	challenge, err := webauthn.NewChallenge()
+	if err != nil {
+		return "", err
+	}
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key, ceremony := range s.ceremonies {
+		if now.After(ceremony.expires) {
+			delete(s.ceremonies, key)
+		}
+	}
+	s.ceremonies[ceremonyKey(kind, challenge)] = passkeyCeremony{userID: userID, expires: now.Add(s.rp.Timeout())}
+	return challenge, nil
+}
+
+// takeCeremony returns and forgets the ceremony a client data challenge
+// answers, so every challenge is used at most once.
+func (s *passkeyService) takeCeremony(kind, clientDataJSON string) (string, passkeyCeremony, bool) {
+	challenge, err := webauthn.Challenge(clientDataJSON)
+	if err != nil {
+		return "", passkeyCeremony{}, false
+	}
+	key := ceremonyKey(kind, challenge)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ceremony, ok := s.ceremonies[key]
+	delete(s.ceremonies, key)
+	if !ok || time.Now().After(ceremony.expires) {
+		return "", passkeyCeremony{}, false
+	}
+	return challenge, ceremony, true
+}
+
+// BeginRegistration implements PasskeyService.
+func (s *passkeyService) BeginRegistration(ctx context.Context, userID uint) (*PasskeyRegistrationOptions, error) {
+	if s.rp == nil {
+		return nil, ErrPasskeysNotConfigured
+	}
+
+	existing, err := s.repo.FindByUserID(userID)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error fetching passkeys of user", "user_id", userID, "err", err)
+		return nil, errors.New("failed to start passkey registration")
+	}
+
+	challenge, err := s.startCeremony("create", userID)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error generating passkey challenge", "err", err)
+		return nil, errors.New("failed to start passkey registration")
+	}
+	exclude := make([]webauthn.CredentialDescriptor, 0, len(existing))
+	for _, passkey := range existing {
+		exclude = append(exclude, webauthn.CredentialDescriptor{Type: "public-key", ID: passkey.CredentialID})
+	}
+	name := fmt.Sprintf("user-%d", userID)
+	options := s.rp.CreationOptions(challenge, webauthn.User{
+		ID:          []byte(strconv.FormatUint(uint64(userID), 10)),
+		Name:        name,
+		DisplayName: name,
+	}, exclude)
+	return &PasskeyRegistrationOptions{PublicKey: options}, nil
+}
+
+// FinishRegistration implements PasskeyService.
+func (s *passkeyService) FinishRegistration(ctx context.Context, userID uint, req FinishPasskeyRegistrationRequest) (*PasskeyResponse, error) {
+	if s.rp == nil {
+		return nil, ErrPasskeysNotConfigured
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		name = "Passkey"
+	}
+	if len(name) > maxPasskeyNameLength {
+		return nil, apperror.Invalidf("invalid passkey: name must be at most %d characters", maxPasskeyNameLength)
+	}
+	challenge, ceremony, ok := s.takeCeremony("create", req.Credential.Response.ClientDataJSON)
+	if !ok || ceremony.userID != userID {
+		return nil, apperror.Invalidf("invalid passkey: the registration expired or was already completed, start again")
+	}
+
+	credential, err := s.rp.VerifyRegistration(req.Credential, challenge)
+	if err != nil {
+		return nil, apperror.Invalidf("invalid passkey: %v", err)
+	}
+	credentialID := webauthn.EncodeID(credential.ID)
+	if _, err := s.repo.FindByCredentialID(credentialID); err == nil {
+		return nil, apperror.Invalidf("invalid passkey: it is already registered")
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		logging.FromContext(ctx).Error("Error checking passkey credential", "err", err)
+		return nil, errors.New("failed to register passkey")
+	}
+
+	passkey := &domain.Passkey{
+		UserID:       ceremony.userID,
+		Name:         name,
+		CredentialID: credentialID,
+		PublicKey:    credential.PublicKey,
+		SignCount:    credential.SignCount,
+		Transports:   strings.Join(credential.Transports, ","),
+		BackedUp:     credential.BackedUp,
+	}
+	if err := s.repo.Create(passkey); err != nil {
+		logging.FromContext(ctx).Error("Error creating passkey for user", "user_id", ceremony.userID, "err", err)
+		return nil, errors.New("failed to register passkey")
+	}
+	resp := toPasskeyResponse(passkey)
+	return &resp, nil
+}
+
+// ListPasskeys implements PasskeyService.
+func (s *passkeyService) ListPasskeys(ctx context.Context, userID uint) ([]PasskeyResponse, error) {
+	passkeys, err := s.repo.FindByUserID(userID)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error fetching passkeys of user", "user_id", userID, "err", err)
+		return nil, errors.New("failed to retrieve passkeys")
+	}
+	resp := make([]PasskeyResponse, 0, len(passkeys))
+	for i := range passkeys {
+		resp = append(resp, toPasskeyResponse(&passkeys[i]))
+	}
+	return resp, nil
+}
+
+// findOwnPasskey returns the passkey if it belongs to userID. Other users'
+// passkeys are reported as not found.
+func (s *passkeyService) findOwnPasskey(ctx context.Context, userID, id uint) (*domain.Passkey, error) {
+	passkey, err := s.repo.FindByID(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && passkey.UserID != userID) {
+		return nil, apperror.NotFoundf("passkey with ID %d not found", id)
+	}
+	if err != nil {
+		logging.FromContext(ctx).Error("Error fetching passkey", "passkey_id", id, "err", err)
+		return nil, errors.New("failed to retrieve passkey")
+	}
+	return passkey, nil
+}
+
+// RenamePasskey implements PasskeyService.
+func (s *passkeyService) RenamePasskey(ctx context.Context, userID, id uint, req UpdatePasskeyRequest) (*PasskeyResponse, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" || len(name) > maxPasskeyNameLength {
+		return nil, apperror.Invalidf("invalid passkey: name must be 1 to %d characters", maxPasskeyNameLength)
+	}
+	passkey, err := s.findOwnPasskey(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	passkey.Name = name
+	if err := s.repo.Update(passkey); err != nil {
+		logging.FromContext(ctx).Error("Error renaming passkey", "passkey_id", id, "err", err)
+		return nil, errors.New("failed to update passkey")
+	}
+	resp := toPasskeyResponse(passkey)
+	return &resp, nil
+}
+
+// DeletePasskey implements PasskeyService.
+func (s *passkeyService) DeletePasskey(ctx context.Context, userID, id uint) error {
+	if _, err := s.findOwnPasskey(ctx, userID, id); err != nil {
+		return err
+	}
+	if err := s.repo.Delete(id); err != nil {
+		logging.FromContext(ctx).Error("Error deleting passkey", "passkey_id", id, "err", err)
+		return errors.New("failed to delete passkey")
+	}
+	return nil
+}
+
+// BeginLogin implements PasskeyService.
+func (s *passkeyService) BeginLogin(ctx context.Context) (*PasskeyLoginOptions, error) {
+	if s.rp == nil {
+		return nil, ErrPasskeysNotConfigured
+	}
+	challenge, err := s.startCeremony("get", 0)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error generating passkey challenge", "err", err)
+		return nil, errors.New("failed to start passkey login")
+	}
+	return &PasskeyLoginOptions{PublicKey: s.rp.RequestOptions(challenge)}, nil
+}
+
+// FinishLogin implements PasskeyService.
+func (s *passkeyService) FinishLogin(ctx context.Context, req FinishPasskeyLoginRequest, now time.Time) (*SessionResponse, error) {
+	if s.rp == nil {
+		return nil, ErrPasskeysNotConfigured
+	}
+	challenge, _, ok := s.takeCeremony("get", req.Credential.Response.ClientDataJSON)
+	if !ok {
+		return nil, ErrPasskeyLoginFailed
+	}
+	credentialID, err := req.Credential.CredentialID()
+	if err != nil {
+		return nil, ErrPasskeyLoginFailed
+	}
+	passkey, err := s.repo.FindByCredentialID(webauthn.EncodeID(credentialID))
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			logging.FromContext(ctx).Error("Error fetching passkey for login", "err", err)
+			return nil, errors.New("failed to sign in")
+		}
+		return nil, ErrPasskeyLoginFailed
+	}
+
+	assertion, err := s.rp.VerifyLogin(req.Credential, challenge, passkey.PublicKey, passkey.SignCount)
+	if err != nil {
+		logging.FromContext(ctx).Warn("Passkey login failed", "passkey_id", passkey.ID, "user_id", passkey.UserID, "err", err)
+		return nil, ErrPasskeyLoginFailed
+	}
+	if len(assertion.UserHandle) > 0 && string(assertion.UserHandle) != strconv.FormatUint(uint64(passkey.UserID), 10) {
+		logging.FromContext(ctx).Warn("Passkey login with a user handle not matching the passkey's user", "passkey_id", passkey.ID, "user_id", passkey.UserID)
+		return nil, ErrPasskeyLoginFailed
+	}
+
+	passkey.SignCount = assertion.SignCount
+	passkey.BackedUp = assertion.BackedUp
+	passkey.LastUsedAt = &now
+	if err := s.repo.Update(passkey); err != nil {
+		logging.FromContext(ctx).Error("Error updating passkey after login", "passkey_id", passkey.ID, "err", err)
+		return nil, errors.New("failed to sign in")
+	}
+
+	return startSession(ctx, s.sessions, &domain.AuthSession{
+		UserID:    passkey.UserID,
+		PasskeyID: &passkey.ID,
+		ExpiresAt: now.Add(s.cfg.TTL),
+	})
+}
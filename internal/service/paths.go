@@ -0,0 +1,6 @@
+package service
+
+// apiBasePath prefixes the API paths services hand out to clients, like
+// feed and attachment URLs. It matches apispec.BasePath, which can't be
+// used here since apispec imports this package.
+const apiBasePath = "/api/v1"
@@ -0,0 +1,175 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/Tomlord1122/todo-backend/internal/apperror"
+	"github.com/Tomlord1122/todo-backend/internal/domain"
+	"github.com/Tomlord1122/todo-backend/internal/logging"
+	"github.com/Tomlord1122/todo-backend/internal/notify"
+	"github.com/Tomlord1122/todo-backend/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+// PreferencesResponse is the representation of a user's preferences.
+type PreferencesResponse struct {
+	UserID               uint   `json:"user_id"`
+	AutoApplySuggestions bool   `json:"auto_apply_suggestions"`
+	Timezone             string `json:"timezone"`
+	EscalationMode       string `json:"escalation_mode"`
+	NotifyOverdue        bool   `json:"notify_overdue"`
+	NotificationChannel  string `json:"notification_channel,omitempty"`
+	NotificationTarget   string `json:"notification_target,omitempty"`
+}
+
+// UpdatePreferencesRequest holds the preferences to change.
+type UpdatePreferencesRequest struct {
+	AutoApplySuggestions *bool `json:"auto_apply_suggestions"`
+	// Timezone is an IANA name such as "Europe/Berlin"; empty means UTC
+	Timezone *string `json:"timezone"`
+	// EscalationMode is "priority", "notify", "both" or "" to opt out of
+	// overdue escalation
+	EscalationMode *string `json:"escalation_mode"`
+	NotifyOverdue  *bool   `json:"notify_overdue"`
+	// NotificationChannel and NotificationTarget are required when
+	// escalation or overdue reminders notify
+	NotificationChannel *string `json:"notification_channel"`
+	NotificationTarget  *string `json:"notification_target"`
+}
+
+// Escalation modes
+const (
+	EscalationPriority = "priority"
+	EscalationNotify   = "notify"
+	EscalationBoth     = "both"
+)
+
+// PreferenceService reads and updates per-user preferences.
+type PreferenceService interface {
+	GetPreferences(ctx context.Context, userID uint) (*PreferencesResponse, error)
+	UpdatePreferences(ctx context.Context, userID uint, req UpdatePreferencesRequest) (*PreferencesResponse, error)
+}
+
+type preferenceService struct {
+	repo     repository.PreferenceRepository
+	channels *notify.Registry
+}
+
+// NewPreferenceService creates a new PreferenceService. channels validates
+// where overdue reminders are sent.
+func NewPreferenceService(repo repository.PreferenceRepository, channels *notify.Registry) PreferenceService {
+	return &preferenceService{repo: repo, channels: channels}
+}
+
+// loadPreferences returns the stored preferences or the defaults.
+func loadPreferences(repo repository.PreferenceRepository, userID uint) (*domain.UserPreference, error) {
+	pref, err := repo.FindByUserID(userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &domain.UserPreference{UserID: userID}, nil
+	}
+	return pref, err
+}
+
+func toPreferencesResponse(pref *domain.UserPreference) *PreferencesResponse {
+	return &PreferencesResponse{
+		UserID:               pref.UserID,
+		AutoApplySuggestions: pref.AutoApplySuggestions,
+		Timezone:             timezoneName(pref.Timezone),
+		EscalationMode:       pref.EscalationMode,
+		NotifyOverdue:        pref.NotifyOverdue,
+		NotificationChannel:  pref.NotificationChannel,
+		NotificationTarget:   pref.NotificationTarget,
+	}
+}
+
+// GetPreferences implements PreferenceService.
+func (s *preferenceService) GetPreferences(ctx context.Context, userID uint) (*PreferencesResponse, error) {
+	pref, err := loadPreferences(s.repo, userID)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error fetching preferences for user", "user_id", userID, "err", err)
+		return nil, errors.New("failed to retrieve preferences")
+	}
+	return toPreferencesResponse(pref), nil
+}
+
+// UpdatePreferences implements PreferenceService.
+func (s *preferenceService) UpdatePreferences(ctx context.Context, userID uint, req UpdatePreferencesRequest) (*PreferencesResponse, error) {
+	pref, err := loadPreferences(s.repo, userID)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error fetching preferences for user", "user_id", userID, "err", err)
+		return nil, errors.New("failed to retrieve preferences")
+	}
+
+	if req.AutoApplySuggestions != nil {
+		pref.AutoApplySuggestions = *req.AutoApplySuggestions
+	}
+	if req.Timezone != nil {
+		pref.Timezone = strings.TrimSpace(*req.Timezone)
+		if _, err := time.LoadLocation(pref.Timezone); err != nil {
+			return nil, apperror.Invalidf("invalid timezone %q", pref.Timezone)
+		}
+	}
+	if req.NotifyOverdue != nil {
+		pref.NotifyOverdue = *req.NotifyOverdue
+	}
+	if req.EscalationMode != nil {
+		pref.EscalationMode = strings.ToLower(strings.TrimSpace(*req.EscalationMode))
+	}
+	if req.NotificationChannel != nil {
+		pref.NotificationChannel = *req.NotificationChannel
+	}
+	if req.NotificationTarget != nil {
+		pref.NotificationTarget = strings.TrimSpace(*req.NotificationTarget)
+	}
+	if err := s.validateNotifications(pref); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Save(pref); err != nil {
+		logging.FromContext(ctx).Error("Error saving preferences for user", "user_id", userID, "err", err)
+		return nil, errors.New("failed to update preferences")
+	}
+	return toPreferencesResponse(pref), nil
+}
+
+// validateNotifications checks the escalation and reminder settings; errors
+// start with "invalid" so handlers can map them to 400 responses.
+func (s *preferenceService) validateNotifications(pref *domain.UserPreference) error {
+	notifies := pref.NotifyOverdue
+	switch pref.EscalationMode {
+	case "", EscalationPriority:
+	case EscalationNotify, EscalationBoth:
+		notifies = true
+	default:
+		return apperror.Invalidf("invalid escalation: mode must be one of %s, %s, %s or empty", EscalationPriority, EscalationNotify, EscalationBoth)
+	}
+	if !notifies {
+		return nil
+	}
+	if err := validateDelivery(s.channels, pref.NotificationChannel, pref.NotificationTarget); err != nil {
+		return apperror.Invalidf("invalid notification settings: %w", err)
+	}
+	return nil
+}
+
+// userLocation returns the time zone of a user's preferences, UTC if unset
+// or unknown.
+func userLocation(pref *domain.UserPreference) *time.Location {
+	loc, err := time.LoadLocation(pref.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// timezoneName shows an unset timezone as the UTC it defaults to.
+func timezoneName(tz string) string {
+	if tz == "" {
+		return "UTC"
+	}
+	return tz
+}
@@ -0,0 +1,200 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Tomlord1122/todo-backend/internal/apperror"
+	"github.com/Tomlord1122/todo-backend/internal/domain"
+	"github.com/Tomlord1122/todo-backend/internal/logging"
+	"github.com/Tomlord1122/todo-backend/internal/realtime"
+	"github.com/Tomlord1122/todo-backend/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+// Resources presence is tracked for
+const (
+	PresenceTodo = "todo"
+	PresenceList = "list"
+)
+
+// PresenceConfig tunes presence tracking.
+type PresenceConfig struct {
+	// TTL is how long a heartbeat keeps a user present; clients send one
+	// about every TTL/2.
+	TTL time.Duration
+}
+
+// PresenceRequest is a heartbeat. State is "viewing" (the default) or
+// "editing" while the user is typing.
+type PresenceRequest struct {
+	State string `json:"state,omitempty"`
+}
+
+// ViewerResponse is a user who has a todo or list open.
+type ViewerResponse struct {
+	UserID    uint   `json:"user_id"`
+	State     string `json:"state"`
+	ExpiresAt string `json:"expires_at"`
+}
+
+// PresenceService tracks which users have a todo or list open, so
+// collaborative clients can show who else is looking or editing. Joining,
+// leaving and starting or stopping to type are published as realtime
+// events to the other users who have it open.
+type PresenceService interface {
+	// Heartbeat marks userID as having the resource open for the next TTL
+	// and returns everyone who has it open.
+	Heartbeat(ctx context.Context, userID uint, kind string, id uint, req PresenceRequest, now time.Time) ([]ViewerResponse, error)
+
+	// Leave marks userID as having closed the resource.
+	Leave(ctx context.Context, userID uint, kind string, id uint, now time.Time) error
+
+	// List returns everyone who has the resource open, for userID.
+	List(ctx context.Context, userID uint, kind string, id uint, now time.Time) ([]ViewerResponse, error)
+}
+
+type presenceService struct {
+	store  realtime.PresenceStore
+	todos  repository.TodoRepository
+	lists  repository.ListRepository
+	events realtime.Publisher
+	cfg    PresenceConfig
+}
+
+// NewPresenceService creates a new PresenceService. events may be nil.
+func NewPresenceService(store realtime.PresenceStore, todos repository.TodoRepository, lists repository.ListRepository, events realtime.Publisher, cfg PresenceConfig) PresenceService {
+	return &presenceService{store: store, todos: todos, lists: lists, events: events, cfg: cfg}
+}
+
+func toViewerResponses(viewers []realtime.Viewer) []ViewerResponse {
+	resp := make([]ViewerResponse, 0, len(viewers))
+	for _, v := range viewers {
+		resp = append(resp, ViewerResponse{UserID: v.UserID, State: v.State, ExpiresAt: v.ExpiresAt.Format(time.RFC3339)})
+	}
+	return resp
+}
+
+// resource checks that the todo or list exists and userID can see it, and
+// returns its presence key. Others get the same not found as for a missing
+// one.
+func (s *presenceService) resource(ctx context.Context, userID uint, kind string, id uint) (string, error) {
+	var ownerID uint
+	var err error
+	switch kind {
+	case PresenceTodo:
+		var todo *domain.Todo
+		if todo, err = s.todos.FindByID(id); err == nil {
+			ownerID = todo.UserID
+		}
+	case PresenceList:
+		var list *domain.List
+		if list, err = s.lists.FindByID(id); err == nil {
+			ownerID = list.UserID
+		}
+	default:
+		return "", apperror.Invalidf("invalid presence: unknown resource %q", kind)
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && !canSee(ctx, userID, ownerID)) {
+		return "", apperror.NotFoundf("%s with ID %d not found", kind, id)
+	}
+	if err != nil {
+		logging.FromContext(ctx).Error("Error fetching resource for presence", "kind", kind, "id", id, "err", err)
+		return "", errors.New("failed to check presence")
+	}
+	return fmt.Sprintf("%s:%d", kind, id), nil
+}
+
+// Heartbeat implements PresenceService.
+func (s *presenceService) Heartbeat(ctx context.Context, userID uint, kind string, id uint, req PresenceRequest, now time.Time) ([]ViewerResponse, error) {
+	state := req.State
+	if state == "" {
+		state = realtime.StateViewing
+	}
+	if state != realtime.StateViewing && state != realtime.StateEditing {
+		return nil, apperror.Invalidf("invalid presence: state must be %q or %q", realtime.StateViewing, realtime.StateEditing)
+	}
+	resource, err := s.resource(ctx, userID, kind, id)
+	if err != nil {
+		return nil, err
+	}
+
+	previous, err := s.store.Set(ctx, resource, userID, state, now.Add(s.cfg.TTL), now)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error storing presence of user", "user_id", userID, "resource", resource, "err", err)
+		return nil, errors.New("failed to update presence")
+	}
+	viewers, err := s.store.List(ctx, resource, now)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error listing presence", "resource", resource, "err", err)
+		return nil, errors.New("failed to retrieve presence")
+	}
+
+	switch {
+	case previous == "":
+		s.broadcast(ctx, viewers, realtime.PresenceJoined, realtime.PresenceChange{Resource: resource, UserID: userID, State: state})
+	case previous != state:
+		s.broadcast(ctx, viewers, realtime.PresenceTyping, realtime.PresenceChange{Resource: resource, UserID: userID, State: state})
+	}
+	return toViewerResponses(viewers), nil
+}
+
+// Leave implements PresenceService.
+func (s *presenceService) Leave(ctx context.Context, userID uint, kind string, id uint, now time.Time) error {
+	resource, err := s.resource(ctx, userID, kind, id)
+	if err != nil {
+		return err
+	}
+	previous, err := s.store.Remove(ctx, resource, userID, now)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error removing presence of user", "user_id", userID, "resource", resource, "err", err)
+		return errors.New("failed to update presence")
+	}
+	if previous == "" {
+		return nil
+	}
+	viewers, err := s.store.List(ctx, resource, now)
+	if err != nil {
+		// Leaving worked; the others just don't hear about it
+		logging.FromContext(ctx).Error("Error listing presence", "resource", resource, "err", err)
+		return nil
+	}
+	s.broadcast(ctx, viewers, realtime.PresenceLeft, realtime.PresenceChange{Resource: resource, UserID: userID})
+	return nil
+}
+
+// List implements PresenceService.
+func (s *presenceService) List(ctx context.Context, userID uint, kind string, id uint, now time.Time) ([]ViewerResponse, error) {
+	resource, err := s.resource(ctx, userID, kind, id)
+	if err != nil {
+		return nil, err
+	}
+	viewers, err := s.store.List(ctx, resource, now)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error listing presence", "resource", resource, "err", err)
+		return nil, errors.New("failed to retrieve presence")
+	}
+	return toViewerResponses(viewers), nil
+}
+
+// broadcast publishes a presence change to every viewer but the one it is
+// about.
+func (s *presenceService) broadcast(ctx context.Context, viewers []realtime.Viewer, eventType string, change realtime.PresenceChange) {
+	if s.events == nil {
+		return
+	}
+	payload, err := json.Marshal(change)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error encoding event", "type", eventType, "err", err)
+		return
+	}
+	for _, v := range viewers {
+		if v.UserID != change.UserID {
+			s.events.Publish(ctx, realtime.Event{Type: eventType, UserID: v.UserID, Data: payload})
+		}
+	}
+}
@@ -0,0 +1,248 @@
+package service
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/Tomlord1122/todo-backend/internal/apperror"
+	"github.com/Tomlord1122/todo-backend/internal/authz"
+	"github.com/Tomlord1122/todo-backend/internal/domain"
+	"github.com/Tomlord1122/todo-backend/internal/logging"
+	"github.com/Tomlord1122/todo-backend/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+// maxEmojiLength bounds a reaction in bytes; it leaves room for ZWJ
+// sequences such as family or profession emoji with skin tones.
+const maxEmojiLength = 32
+
+// AddReactionRequest reacts to a todo on behalf of a user.
+type AddReactionRequest struct {
+	UserID uint   `json:"user_id"`
+	Emoji  string `json:"emoji"`
+}
+
+// ReactionCount is how many users reacted to a todo with an emoji.
+type ReactionCount struct {
+	Emoji string `json:"emoji"`
+	Count int    `json:"count"`
+}
+
+// ReactionSummary is a ReactionCount that also says who reacted.
+type ReactionSummary struct {
+	ReactionCount
+	UserIDs []uint `json:"user_ids"`
+}
+
+// ReactionService manages emoji reactions on todos.
+type ReactionService interface {
+	// List returns a todo's reactions grouped by emoji, most used first.
+	List(ctx context.Context, todoID uint) ([]ReactionSummary, error)
+	// Add reacts to a todo. Reacting twice with the same emoji is a no-op;
+	// created reports whether a reaction was added.
+	Add(ctx context.Context, todoID uint, req AddReactionRequest) (summary []ReactionSummary, created bool, err error)
+	// Remove takes a user's reaction back.
+	Remove(ctx context.Context, todoID, userID uint, emoji string) error
+}
+
+type reactionService struct {
+	repo  repository.ReactionRepository
+	todos repository.TodoRepository
+}
+
+// NewReactionService creates a new ReactionService.
+func NewReactionService(repo repository.ReactionRepository, todos repository.TodoRepository) ReactionService {
+	return &reactionService{repo: repo, todos: todos}
+}
+
+// toReactionCounts orders a todo's reaction counts, most used first, ties
+// broken by emoji so the order is stable.
+func toReactionCounts(counts map[string]int) []ReactionCount {
+	if len(counts) == 0 {
+		return nil
+	}
+	result := make([]ReactionCount, 0, len(counts))
+	for emoji, count := range counts {
+		result = append(result, ReactionCount{Emoji: emoji, Count: count})
+	}
+	slices.SortFunc(result, func(a, b ReactionCount) int {
+		return cmp.Or(cmp.Compare(b.Count, a.Count), cmp.Compare(a.Emoji, b.Emoji))
+	})
+	return result
+}
+
+// validateEmoji accepts a single short emoji. Letters, punctuation and
+// whitespace are rejected so reactions can't be used as free-form comments;
+// ASCII digits, # and * are allowed for keycap sequences.
+func validateEmoji(emoji string) error {
+	if emoji == "" || len(emoji) > maxEmojiLength || !utf8.ValidString(emoji) {
+		return apperror.Invalidf("invalid reaction: emoji must be between 1 and %d bytes", maxEmojiLength)
+	}
+	for _, r := range emoji {
+		switch {
+		case r < utf8.RuneSelf && !unicode.IsDigit(r) && r != '#' && r != '*':
+			return apperror.Invalidf("invalid reaction: emoji must not contain text")
+		case unicode.IsLetter(r), unicode.IsSpace(r), unicode.IsControl(r):
+			return apperror.Invalidf("invalid reaction: emoji must not contain text")
+		}
+	}
+	return nil
+}
+
+// List implements ReactionService.
+func (s *reactionService) List(ctx context.Context, todoID uint) ([]ReactionSummary, error) {
+	if _, err := s.findTodo(ctx, todoID, "list reactions"); err != nil {
+		return nil, err
+	}
+	reactions, err := s.repo.FindByTodoID(todoID)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error fetching reactions for todo", "todo_id", todoID, "err", err)
+		return nil, errors.New("failed to list reactions")
+	}
+	return summarizeReactions(reactions), nil
+}
+
+// Add implements ReactionService.
+func (s *reactionService) Add(ctx context.Context, todoID uint, req AddReactionRequest) ([]ReactionSummary, bool, error) {
+	if err := authz.Check(ctx, authz.WriteTodos); err != nil {
+		return nil, false, err
+	}
+	if req.UserID == 0 {
+		return nil, false, apperror.Invalidf("invalid reaction: user_id is required")
+	}
+	if err := validateEmoji(req.Emoji); err != nil {
+		return nil, false, err
+	}
+	todo, err := s.findTodo(ctx, todoID, "add reaction")
+	if err != nil {
+		return nil, false, err
+	}
+
+	created := false
+	if _, err := s.repo.Find(todoID, req.UserID, req.Emoji); errors.Is(err, gorm.ErrRecordNotFound) {
+		if err := s.repo.Create(&domain.Reaction{TodoID: todoID, UserID: req.UserID, Emoji: req.Emoji}); err != nil {
+			// A concurrent request may have added the same reaction, which
+			// the unique index rejects; that's the outcome the caller wanted
+			if _, findErr := s.repo.Find(todoID, req.UserID, req.Emoji); findErr != nil {
+				logging.FromContext(ctx).Error("Error creating reaction in repository", "err", err)
+				return nil, false, errors.New("failed to add reaction")
+			}
+		} else {
+			created = true
+		}
+	} else if err != nil {
+		logging.FromContext(ctx).Error("Error fetching reaction for todo", "todo_id", todoID, "err", err)
+		return nil, false, errors.New("failed to add reaction")
+	}
+
+	summary, err := s.sync(ctx, todo)
+	if err != nil {
+		return nil, false, errors.New("failed to add reaction")
+	}
+	return summary, created, nil
+}
+
+// Remove implements ReactionService.
+func (s *reactionService) Remove(ctx context.Context, todoID, userID uint, emoji string) error {
+	if err := authz.Check(ctx, authz.WriteTodos); err != nil {
+		return err
+	}
+	todo, err := s.findTodo(ctx, todoID, "remove reaction")
+	if err != nil {
+		return err
+	}
+	reaction, err := s.repo.Find(todoID, userID, emoji)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return apperror.NotFoundf("reaction %s by user %d not found", emoji, userID)
+		}
+		logging.FromContext(ctx).Error("Error fetching reaction for todo", "todo_id", todoID, "err", err)
+		return errors.New("failed to remove reaction")
+	}
+	if err := s.repo.Delete(reaction.ID); err != nil {
+		logging.FromContext(ctx).Error("Error deleting reaction", "reaction_id", reaction.ID, "err", err)
+		return errors.New("failed to remove reaction")
+	}
+	if _, err := s.sync(ctx, todo); err != nil {
+		return errors.New("failed to remove reaction")
+	}
+	return nil
+}
+
+// findTodo loads the todo being reacted to, describing failures with action.
+func (s *reactionService) findTodo(ctx context.Context, todoID uint, action string) (*domain.Todo, error) {
+	todo, err := s.todos.FindByID(todoID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.NotFoundf("todo with ID %d not found", todoID)
+		}
+		logging.FromContext(ctx).Error("Error fetching todo", "todo_id", todoID, "action", action, "err", err)
+		return nil, fmt.Errorf("failed to %s", action)
+	}
+	return todo, nil
+}
+
+// syncAttempts bounds how often sync recounts a todo that was saved while
+// it was counting, e.g. by another reaction landing at the same time.
+const syncAttempts = 10
+
+// sync recounts a todo's reactions, stores the counts on the todo and
+// returns the summary. When the todo was saved since it was read, the
+// reactions are counted again against its latest version.
+func (s *reactionService) sync(ctx context.Context, todo *domain.Todo) ([]ReactionSummary, error) {
+	for attempt := 1; ; attempt++ {
+		reactions, err := s.repo.FindByTodoID(todo.ID)
+		if err != nil {
+			logging.FromContext(ctx).Error("Error fetching reactions for todo", "todo_id", todo.ID, "err", err)
+			return nil, err
+		}
+		summary := summarizeReactions(reactions)
+
+		counts := make(map[string]int, len(summary))
+		for _, r := range summary {
+			counts[r.Emoji] = r.Count
+		}
+		todo.ReactionCounts = counts
+		err = s.todos.Update(todo)
+		if err == nil {
+			return summary, nil
+		}
+		if !errors.Is(err, repository.ErrVersionConflict) || attempt == syncAttempts {
+			logging.FromContext(ctx).Error("Error updating reaction counts of todo", "todo_id", todo.ID, "err", err)
+			return nil, err
+		}
+		latest, err := s.todos.FindByID(todo.ID)
+		if err != nil {
+			logging.FromContext(ctx).Error("Error fetching todo", "todo_id", todo.ID, "action", "count reactions", "err", err)
+			return nil, err
+		}
+		todo = latest
+	}
+}
+
+// summarizeReactions groups reactions by emoji in the same order as
+// toReactionCounts.
+func summarizeReactions(reactions []domain.Reaction) []ReactionSummary {
+	summary := []ReactionSummary{}
+	index := make(map[string]int)
+	for _, r := range reactions {
+		i, ok := index[r.Emoji]
+		if !ok {
+			i = len(summary)
+			index[r.Emoji] = i
+			summary = append(summary, ReactionSummary{ReactionCount: ReactionCount{Emoji: r.Emoji}, UserIDs: []uint{}})
+		}
+		summary[i].Count++
+		summary[i].UserIDs = append(summary[i].UserIDs, r.UserID)
+	}
+	slices.SortFunc(summary, func(a, b ReactionSummary) int {
+		return cmp.Or(cmp.Compare(b.Count, a.Count), cmp.Compare(a.Emoji, b.Emoji))
+	})
+	return summary
+}
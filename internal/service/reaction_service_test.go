@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Tomlord1122/todo-backend/internal/apperror"
+	"github.com/Tomlord1122/todo-backend/internal/authz"
+	"github.com/Tomlord1122/todo-backend/internal/domain"
+	"github.com/Tomlord1122/todo-backend/internal/repository"
+)
+
+func TestReactionWritesRequireWriteTodos(t *testing.T) {
+	repos := repository.NewMemoryRepositories()
+	reactions := NewReactionService(repos.Reactions, repos.Todos)
+	viewer := authz.NewContext(context.Background(), authz.Principal{UserID: 1, Role: domain.RoleViewer})
+
+	_, _, addErr := reactions.Add(viewer, 1, AddReactionRequest{UserID: 1, Emoji: "👍"})
+	removeErr := reactions.Remove(viewer, 1, 1, "👍")
+	for name, err := range map[string]error{"Add": addErr, "Remove": removeErr} {
+		if !errors.Is(err, apperror.ErrForbidden) {
+			t.Errorf("%s as a viewer = %v, want forbidden", name, err)
+		}
+	}
+}
+
+func TestReactingTwiceStoresOneReaction(t *testing.T) {
+	repos := repository.NewMemoryRepositories()
+	reactions := NewReactionService(repos.Reactions, repos.Todos)
+	ctx := context.Background()
+	todo := &domain.Todo{Title: "Ship it", UserID: 1}
+	if err := repos.Todos.Create(todo); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, created, err := reactions.Add(ctx, todo.ID, AddReactionRequest{UserID: 2, Emoji: "🎉"}); err != nil || !created {
+		t.Fatalf("first Add = created %t, %v", created, err)
+	}
+	summary, created, err := reactions.Add(ctx, todo.ID, AddReactionRequest{UserID: 2, Emoji: "🎉"})
+	if err != nil || created {
+		t.Fatalf("second Add = created %t, %v, want a no-op", created, err)
+	}
+	if len(summary) != 1 || summary[0].Count != 1 || len(summary[0].UserIDs) != 1 {
+		t.Errorf("summary = %+v, want one 🎉 by user 2", summary)
+	}
+	stored, err := repos.Reactions.FindByTodoID(todo.ID)
+	if err != nil || len(stored) != 1 {
+		t.Errorf("stored reactions = %v, %v, want one", stored, err)
+	}
+
+	// The same user may still react with another emoji
+	if _, created, err := reactions.Add(ctx, todo.ID, AddReactionRequest{UserID: 2, Emoji: "👍"}); err != nil || !created {
+		t.Errorf("Add with another emoji = created %t, %v", created, err)
+	}
+}
+
+// racingReactions is a ReactionRepository where another reaction lands
+// while the first count is taken, saving the todo in between.
+type racingReactions struct {
+	repository.ReactionRepository
+	todos repository.TodoRepository
+	raced bool
+}
+
+func (r *racingReactions) FindByTodoID(todoID uint) ([]domain.Reaction, error) {
+	if !r.raced {
+		r.raced = true
+		if err := r.ReactionRepository.Create(&domain.Reaction{TodoID: todoID, UserID: 3, Emoji: "🎉"}); err != nil {
+			return nil, err
+		}
+		todo, err := r.todos.FindByID(todoID)
+		if err != nil {
+			return nil, err
+		}
+		if err := r.todos.Update(todo); err != nil {
+			return nil, err
+		}
+	}
+	return r.ReactionRepository.FindByTodoID(todoID)
+}
+
+func TestReactionCountsSurviveConcurrentSaves(t *testing.T) {
+	repos := repository.NewMemoryRepositories()
+	reactions := NewReactionService(&racingReactions{ReactionRepository: repos.Reactions, todos: repos.Todos}, repos.Todos)
+	todo := &domain.Todo{Title: "Ship it", UserID: 1}
+	if err := repos.Todos.Create(todo); err != nil {
+		t.Fatal(err)
+	}
+
+	// The todo is saved after Add read it; the counts are taken again
+	// rather than failing the reaction that was already stored
+	summary, created, err := reactions.Add(context.Background(), todo.ID, AddReactionRequest{UserID: 2, Emoji: "🎉"})
+	if err != nil || !created {
+		t.Fatalf("Add = created %t, %v", created, err)
+	}
+	if len(summary) != 1 || summary[0].Count != 2 {
+		t.Errorf("summary = %+v, want two 🎉", summary)
+	}
+	got, err := repos.Todos.FindByID(todo.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.ReactionCounts["🎉"] != 2 {
+		t.Errorf("ReactionCounts = %v, want two 🎉", got.ReactionCounts)
+	}
+}
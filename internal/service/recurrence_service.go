@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/Tomlord1122/todo-backend/internal/domain"
+	"github.com/Tomlord1122/todo-backend/internal/events"
+	"github.com/Tomlord1122/todo-backend/internal/logging"
+	"github.com/Tomlord1122/todo-backend/internal/realtime"
+	"github.com/Tomlord1122/todo-backend/internal/repository"
+	"github.com/Tomlord1122/todo-backend/internal/rrule"
+)
+
+// recurrenceBatchSize bounds the occurrences created per run.
+const recurrenceBatchSize = 100
+
+// RecurrenceService creates the next instance of recurring todos. The todo
+// service sets a todo's NextOccurrence when it is completed; the new
+// instance carries on the series, so completing it schedules the next one.
+type RecurrenceService interface {
+	// RunPending creates the pending occurrences. It is meant to be called
+	// periodically by the job scheduler and is safe to run on several
+	// instances at once.
+	RunPending(ctx context.Context) error
+}
+
+type recurrenceService struct {
+	todos      repository.TodoRepository
+	activities repository.ActivityRepository
+	events     realtime.Publisher
+}
+
+// NewRecurrenceService creates a new RecurrenceService. New instances are
+// published to events, which may be nil, and their domain events added to
+// the outbox of todos.
+func NewRecurrenceService(todos repository.TodoRepository, activities repository.ActivityRepository, events realtime.Publisher) RecurrenceService {
+	return &recurrenceService{todos: todos, activities: activities, events: events}
+}
+
+// nextOccurrence returns the due date of the instance after todo, or nil
+// once the rule has ended. It follows the later of the due date and now, so
+// a todo finished late doesn't leave an already overdue instance behind.
+// Weekdays and dates are those of loc, the owner's time zone.
+func nextOccurrence(rule rrule.Rule, todo *domain.Todo, loc *time.Location, now time.Time) *time.Time {
+	if !todo.Completed || todo.DueDate == nil {
+		return nil
+	}
+	if _, ok := rule.Advance(); !ok {
+		return nil
+	}
+	after := *todo.DueDate
+	if now.After(after) {
+		after = now
+	}
+	next, ok := rule.Next(todo.DueDate.In(loc), after)
+	if !ok {
+		return nil
+	}
+	next = next.UTC()
+	return &next
+}
+
+// RunPending implements RecurrenceService.
+func (s *recurrenceService) RunPending(ctx context.Context) error {
+	todos, err := s.todos.FindPendingOccurrences(recurrenceBatchSize)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error fetching pending occurrences", "err", err)
+		return errors.New("failed to create recurring todos")
+	}
+	for i := range todos {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err := s.createOccurrence(ctx, &todos[i]); err != nil {
+			logging.FromContext(ctx).Error("Error creating the next occurrence of todo", "todo_id", todos[i].ID, "err", err)
+		}
+	}
+	return nil
+}
+
+// createOccurrence copies todo into its next instance, due on its
+// NextOccurrence. Progress such as checklists and subtasks isn't copied.
+func (s *recurrenceService) createOccurrence(ctx context.Context, todo *domain.Todo) error {
+	rule, err := rrule.Parse(todo.Recurrence)
+	if err != nil {
+		return err
+	}
+	rule, _ = rule.Advance()
+	next := &domain.Todo{
+		Title:        todo.Title,
+		Description:  todo.Description,
+		Priority:     todo.Priority,
+		UserID:       todo.UserID,
+		ListID:       todo.ListID,
+		DueDate:      todo.NextOccurrence,
+		Latitude:     todo.Latitude,
+		Longitude:    todo.Longitude,
+		RadiusMeters: todo.RadiusMeters,
+		Tags:         todo.Tags,
+		Estimate:     todo.Estimate,
+		Recurrence:   rule.String(),
+	}
+	// The start date keeps its distance from the due date
+	if todo.StartDate != nil && todo.DueDate != nil {
+		start := next.DueDate.Add(todo.StartDate.Sub(*todo.DueDate))
+		next.StartDate = &start
+	}
+
+	created := false
+	err = s.todos.Transaction(func(todos repository.TodoRepository, outbox repository.OutboxRepository) error {
+		if created, err = todos.CreateOccurrence(todo.ID, next); err != nil || !created {
+			return err
+		}
+		return addEvent(ctx, outbox, events.TodoCreated, next, toTodoResponse(next))
+	})
+	if err != nil || !created {
+		return err
+	}
+	if err := s.activities.Create(newActivity(next, domain.ActivityCreated, "", "")); err != nil {
+		logging.FromContext(ctx).Error("Error recording created activity for todo", "todo_id", next.ID, "err", err)
+	}
+	response := toTodoResponse(next)
+	if s.events != nil {
+		payload, err := json.Marshal(response)
+		if err != nil {
+			return err
+		}
+		s.events.Publish(ctx, realtime.Event{Type: realtime.TodoCreated, UserID: next.UserID, Data: payload})
+	}
+	return nil
+}
@@ -0,0 +1,302 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Tomlord1122/todo-backend/internal/apperror"
+	"github.com/Tomlord1122/todo-backend/internal/authz"
+	"github.com/Tomlord1122/todo-backend/internal/domain"
+	"github.com/Tomlord1122/todo-backend/internal/jobs"
+	"github.com/Tomlord1122/todo-backend/internal/logging"
+	"github.com/Tomlord1122/todo-backend/internal/notify"
+	"github.com/Tomlord1122/todo-backend/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// maxReminderAttempts is how often a reminder is sent before it is
+	// given up as failed.
+	maxReminderAttempts = 5
+	// reminderBatchSize bounds the reminders enqueued per run.
+	reminderBatchSize = 100
+)
+
+// CreateReminderRequest sets a reminder on a todo.
+type CreateReminderRequest struct {
+	RemindAt time.Time `json:"remind_at"`
+	// Channel defaults to the owner's notification channel, else "log"
+	Channel string `json:"channel"`
+	// Target defaults to the owner's notification target
+	Target string `json:"target"`
+}
+
+// ReminderResponse is the representation of a Reminder returned by the service.
+type ReminderResponse struct {
+	ID          uint    `json:"id"`
+	TodoID      uint    `json:"todo_id"`
+	RemindAt    string  `json:"remind_at"`
+	Channel     string  `json:"channel"`
+	Target      string  `json:"target,omitempty"`
+	Status      string  `json:"status"`
+	Attempts    int     `json:"attempts"`
+	LastError   string  `json:"last_error,omitempty"`
+	DeliveredAt *string `json:"delivered_at,omitempty"`
+	CreatedAt   string  `json:"created_at"`
+}
+
+// ReminderService manages the reminders of a todo. Due reminders are sent
+// by jobs on the job queue.
+type ReminderService interface {
+	// List returns a todo's reminders, soonest first.
+	List(ctx context.Context, todoID uint) ([]ReminderResponse, error)
+	Create(ctx context.Context, todoID uint, req CreateReminderRequest) (*ReminderResponse, error)
+	Delete(ctx context.Context, todoID, reminderID uint) error
+
+	// EnqueueDue enqueues a job sending each reminder due at now; a
+	// reminder is only enqueued once. It is meant to be called periodically
+	// by the job scheduler.
+	EnqueueDue(ctx context.Context, now time.Time) error
+	// Send runs a reminder job, sending the reminder and recording the
+	// attempt. It is registered with the worker pool by RegisterJobs.
+	Send(ctx context.Context, job jobs.Job) error
+}
+
+type reminderService struct {
+	repo     repository.ReminderRepository
+	todos    repository.TodoRepository
+	prefs    repository.PreferenceRepository
+	jobs     repository.JobRepository
+	channels *notify.Registry
+}
+
+// NewReminderService creates a new ReminderService.
+func NewReminderService(repo repository.ReminderRepository, todos repository.TodoRepository, prefs repository.PreferenceRepository, jobs repository.JobRepository, channels *notify.Registry) ReminderService {
+	return &reminderService{repo: repo, todos: todos, prefs: prefs, jobs: jobs, channels: channels}
+}
+
+// reminderJob is the payload of a reminder job.
+type reminderJob struct {
+	ReminderID uint `json:"reminder_id"`
+}
+
+func toReminderResponse(reminder *domain.Reminder) ReminderResponse {
+	response := ReminderResponse{
+		ID:        reminder.ID,
+		TodoID:    reminder.TodoID,
+		RemindAt:  reminder.RemindAt.Format(time.RFC3339),
+		Channel:   reminder.Channel,
+		Target:    reminder.Target,
+		Status:    reminder.Status,
+		Attempts:  reminder.Attempts,
+		LastError: reminder.LastError,
+		CreatedAt: reminder.CreatedAt.Format(time.RFC3339),
+	}
+	if reminder.DeliveredAt != nil {
+		deliveredAt := reminder.DeliveredAt.Format(time.RFC3339)
+		response.DeliveredAt = &deliveredAt
+	}
+	return response
+}
+
+// List implements ReminderService.
+func (s *reminderService) List(ctx context.Context, todoID uint) ([]ReminderResponse, error) {
+	if _, err := s.findTodo(ctx, todoID, "list reminders"); err != nil {
+		return nil, err
+	}
+	reminders, err := s.repo.FindByTodoID(todoID)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error fetching reminders of todo", "todo_id", todoID, "err", err)
+		return nil, errors.New("failed to list reminders")
+	}
+	responses := make([]ReminderResponse, 0, len(reminders))
+	for i := range reminders {
+		responses = append(responses, toReminderResponse(&reminders[i]))
+	}
+	return responses, nil
+}
+
+// Create implements ReminderService.
+func (s *reminderService) Create(ctx context.Context, todoID uint, req CreateReminderRequest) (*ReminderResponse, error) {
+	if err := authz.Check(ctx, authz.WriteTodos); err != nil {
+		return nil, err
+	}
+	if req.RemindAt.IsZero() {
+		return nil, apperror.Invalidf("invalid reminder: remind_at is required")
+	}
+	if !req.RemindAt.After(time.Now()) {
+		return nil, apperror.Invalidf("invalid reminder: remind_at must be in the future")
+	}
+	todo, err := s.findTodo(ctx, todoID, "create reminder")
+	if err != nil {
+		return nil, err
+	}
+
+	pref, err := loadPreferences(s.prefs, todo.UserID)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error fetching preferences for user", "user_id", todo.UserID, "err", err)
+		return nil, errors.New("failed to create reminder")
+	}
+	channel, target := req.Channel, req.Target
+	if channel == "" {
+		channel = pref.NotificationChannel
+		if channel == "" {
+			channel = notify.LogChannel{}.Name()
+		}
+	}
+	// The owner's target is looked up again on delivery, so changing it in
+	// their preferences also redirects pending reminders
+	deliverTo := target
+	if deliverTo == "" && channel == pref.NotificationChannel {
+		deliverTo = pref.NotificationTarget
+	}
+	if err := validateDelivery(s.channels, channel, deliverTo); err != nil {
+		return nil, apperror.Invalidf("invalid reminder: %w", err)
+	}
+
+	reminder := &domain.Reminder{
+		TodoID:   todoID,
+		RemindAt: req.RemindAt.UTC(),
+		Channel:  channel,
+		Target:   target,
+		Status:   domain.ReminderPending,
+	}
+	if err := s.repo.Create(reminder); err != nil {
+		logging.FromContext(ctx).Error("Error creating reminder in repository", "err", err)
+		return nil, errors.New("failed to create reminder")
+	}
+	response := toReminderResponse(reminder)
+	return &response, nil
+}
+
+// Delete implements ReminderService.
+func (s *reminderService) Delete(ctx context.Context, todoID, reminderID uint) error {
+	if err := authz.Check(ctx, authz.WriteTodos); err != nil {
+		return err
+	}
+	reminder, err := s.repo.FindByID(reminderID)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && reminder.TodoID != todoID) {
+		return apperror.NotFoundf("reminder with ID %d not found", reminderID)
+	}
+	if err != nil {
+		logging.FromContext(ctx).Error("Error fetching reminder to delete reminder", "reminder_id", reminderID, "err", err)
+		return errors.New("failed to delete reminder")
+	}
+	if err := s.repo.Delete(reminderID); err != nil {
+		logging.FromContext(ctx).Error("Error deleting reminder", "reminder_id", reminderID, "err", err)
+		return errors.New("failed to delete reminder")
+	}
+	return nil
+}
+
+// findTodo loads a todo, describing failures with action.
+func (s *reminderService) findTodo(ctx context.Context, todoID uint, action string) (*domain.Todo, error) {
+	todo, err := s.todos.FindByID(todoID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.NotFoundf("todo with ID %d not found", todoID)
+		}
+		logging.FromContext(ctx).Error("Error fetching todo", "todo_id", todoID, "action", action, "err", err)
+		return nil, fmt.Errorf("failed to %s", action)
+	}
+	return todo, nil
+}
+
+// EnqueueDue implements ReminderService.
+func (s *reminderService) EnqueueDue(ctx context.Context, now time.Time) error {
+	due, err := s.repo.FindDue(now, reminderBatchSize)
+	if err != nil {
+		return fmt.Errorf("fetching due reminders: %w", err)
+	}
+	var errs []error
+	for _, reminder := range due {
+		key := fmt.Sprintf("reminder:%d", reminder.ID)
+		if err := enqueueJob(s.jobs, jobSendReminder, key, reminderJob{ReminderID: reminder.ID}, now); err != nil {
+			errs = append(errs, fmt.Errorf("reminder %d: %w", reminder.ID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Send implements ReminderService. Reminders of todos completed or deleted
+// before they were due are skipped. Failed attempts are retried by the job
+// queue, until maxReminderAttempts attempts failed.
+func (s *reminderService) Send(ctx context.Context, job jobs.Job) error {
+	var payload reminderJob
+	if err := decodeJob(job, &payload); err != nil {
+		return err
+	}
+	reminder, err := s.repo.FindByID(payload.ReminderID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if reminder.Status != domain.ReminderPending {
+		return nil
+	}
+	msg, ok, err := s.message(reminder)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		reminder.Status = domain.ReminderSkipped
+		return s.repo.Update(reminder)
+	}
+
+	sendErr := s.channels.Send(ctx, reminder.Channel, msg)
+	if sendErr != nil && ctx.Err() != nil {
+		// Interrupted, not failed: the job runs again
+		return ctx.Err()
+	}
+	reminder.Attempts = job.Attempts
+	switch {
+	case sendErr == nil:
+		at := time.Now()
+		reminder.Status, reminder.DeliveredAt, reminder.LastError = domain.ReminderDelivered, &at, ""
+	case job.LastAttempt:
+		reminder.Status, reminder.LastError = domain.ReminderFailed, sendErr.Error()
+	default:
+		reminder.LastError = sendErr.Error()
+	}
+	if err := s.repo.Update(reminder); err != nil {
+		return err
+	}
+	return sendErr
+}
+
+// message builds the notification for a reminder. It returns false when the
+// todo is gone or completed and the reminder should be skipped.
+func (s *reminderService) message(reminder *domain.Reminder) (notify.Message, bool, error) {
+	todo, err := s.todos.FindByID(reminder.TodoID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return notify.Message{}, false, nil
+	}
+	if err != nil {
+		return notify.Message{}, false, err
+	}
+	if todo.Completed {
+		return notify.Message{}, false, nil
+	}
+	pref, err := loadPreferences(s.prefs, todo.UserID)
+	if err != nil {
+		return notify.Message{}, false, err
+	}
+
+	msg := notify.Message{
+		Recipient: reminder.Target,
+		Subject:   fmt.Sprintf("Reminder: %s", todo.Title),
+		Body:      fmt.Sprintf("You asked to be reminded of %q.", todo.Title),
+	}
+	if msg.Recipient == "" {
+		msg.Recipient = pref.NotificationTarget
+	}
+	if todo.DueDate != nil {
+		msg.Body = fmt.Sprintf("%q is due %s.", todo.Title, todo.DueDate.In(userLocation(pref)).Format("Monday, January 2 at 15:04"))
+	}
+	return msg, true, nil
+}
@@ -0,0 +1,370 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/Tomlord1122/todo-backend/internal/apperror"
+	"github.com/Tomlord1122/todo-backend/internal/domain"
+	"github.com/Tomlord1122/todo-backend/internal/logging"
+	"github.com/Tomlord1122/todo-backend/internal/notify"
+	"github.com/Tomlord1122/todo-backend/internal/report"
+	"github.com/Tomlord1122/todo-backend/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+// dueSchedulesBatchSize caps how many schedules one RunDue call processes.
+const dueSchedulesBatchSize = 50
+
+// CreateReportScheduleRequest holds the data needed to schedule a recurring report
+type CreateReportScheduleRequest struct {
+	UserID   uint   `json:"user_id"`
+	Channel  string `json:"channel"`  // "email" or "webhook"
+	Target   string `json:"target"`   // email address or webhook URL
+	Format   string `json:"format"`   // "md" (default) or "pdf"
+	Weekday  string `json:"weekday"`  // e.g. "monday"
+	Hour     int    `json:"hour"`     // 0-23
+	Timezone string `json:"timezone"` // IANA name, defaults to UTC
+}
+
+// UpdateReportScheduleRequest holds the fields that can be changed on a schedule
+type UpdateReportScheduleRequest struct {
+	Channel  *string `json:"channel"`
+	Target   *string `json:"target"`
+	Format   *string `json:"format"`
+	Weekday  *string `json:"weekday"`
+	Hour     *int    `json:"hour"`
+	Timezone *string `json:"timezone"`
+}
+
+// ReportScheduleResponse is the representation of a ReportSchedule returned by the service.
+type ReportScheduleResponse struct {
+	ID        uint    `json:"id"`
+	UserID    uint    `json:"user_id"`
+	Channel   string  `json:"channel"`
+	Target    string  `json:"target"`
+	Format    string  `json:"format"`
+	Weekday   string  `json:"weekday"`
+	Hour      int     `json:"hour"`
+	Timezone  string  `json:"timezone"`
+	NextRunAt string  `json:"next_run_at"`
+	LastRunAt *string `json:"last_run_at,omitempty"`
+	LastError string  `json:"last_error,omitempty"`
+	CreatedAt string  `json:"created_at"`
+	UpdatedAt string  `json:"updated_at"`
+}
+
+// ReportScheduleService manages recurring report deliveries.
+type ReportScheduleService interface {
+	CreateSchedule(ctx context.Context, req CreateReportScheduleRequest) (*ReportScheduleResponse, error)
+	GetScheduleByID(ctx context.Context, id uint) (*ReportScheduleResponse, error)
+	GetSchedulesByUser(ctx context.Context, userID uint) ([]ReportScheduleResponse, error)
+	UpdateSchedule(ctx context.Context, id uint, req UpdateReportScheduleRequest) (*ReportScheduleResponse, error)
+	DeleteSchedule(ctx context.Context, id uint) error
+
+	// RunDue generates and delivers every report that is due at now.
+	// It is meant to be called periodically by the job scheduler.
+	RunDue(ctx context.Context, now time.Time) error
+}
+
+type reportScheduleService struct {
+	repo     repository.ReportScheduleRepository
+	reports  ReportService
+	channels *notify.Registry
+}
+
+// NewReportScheduleService creates a new ReportScheduleService.
+func NewReportScheduleService(repo repository.ReportScheduleRepository, reports ReportService, channels *notify.Registry) ReportScheduleService {
+	return &reportScheduleService{
+		repo:     repo,
+		reports:  reports,
+		channels: channels,
+	}
+}
+
+var weekdaysByName = map[string]time.Weekday{
+	"sunday": time.Sunday, "monday": time.Monday, "tuesday": time.Tuesday, "wednesday": time.Wednesday,
+	"thursday": time.Thursday, "friday": time.Friday, "saturday": time.Saturday,
+}
+
+func toReportScheduleResponse(schedule *domain.ReportSchedule) ReportScheduleResponse {
+	return ReportScheduleResponse{
+		ID:        schedule.ID,
+		UserID:    schedule.UserID,
+		Channel:   schedule.Channel,
+		Target:    schedule.Target,
+		Format:    schedule.Format,
+		Weekday:   strings.ToLower(time.Weekday(schedule.Weekday).String()),
+		Hour:      schedule.Hour,
+		Timezone:  schedule.Timezone,
+		NextRunAt: schedule.NextRunAt.Format(time.RFC3339),
+		LastRunAt: formatOptionalTime(schedule.LastRunAt),
+		LastError: schedule.LastError,
+		CreatedAt: schedule.CreatedAt.Format(time.RFC3339),
+		UpdatedAt: schedule.UpdatedAt.Format(time.RFC3339),
+	}
+}
+
+// CreateSchedule implements ReportScheduleService.
+func (s *reportScheduleService) CreateSchedule(ctx context.Context, req CreateReportScheduleRequest) (*ReportScheduleResponse, error) {
+	if req.UserID == 0 {
+		return nil, apperror.Invalidf("invalid schedule: user_id is required")
+	}
+
+	schedule := &domain.ReportSchedule{
+		UserID:   req.UserID,
+		Channel:  req.Channel,
+		Target:   strings.TrimSpace(req.Target),
+		Format:   req.Format,
+		Hour:     req.Hour,
+		Timezone: req.Timezone,
+	}
+	if schedule.Timezone == "" {
+		schedule.Timezone = "UTC"
+	}
+	if err := s.applyWeekday(schedule, req.Weekday); err != nil {
+		return nil, err
+	}
+	if err := s.validate(schedule); err != nil {
+		return nil, err
+	}
+	schedule.NextRunAt = nextScheduledRun(schedule, time.Now())
+
+	if err := s.repo.Create(schedule); err != nil {
+		logging.FromContext(ctx).Error("Error creating report schedule in repository", "err", err)
+		return nil, errors.New("failed to create report schedule")
+	}
+
+	response := toReportScheduleResponse(schedule)
+	return &response, nil
+}
+
+// GetScheduleByID implements ReportScheduleService.
+func (s *reportScheduleService) GetScheduleByID(ctx context.Context, id uint) (*ReportScheduleResponse, error) {
+	schedule, err := s.find(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	response := toReportScheduleResponse(schedule)
+	return &response, nil
+}
+
+// GetSchedulesByUser implements ReportScheduleService.
+func (s *reportScheduleService) GetSchedulesByUser(ctx context.Context, userID uint) ([]ReportScheduleResponse, error) {
+	schedules, err := s.repo.FindByUserID(userID)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error fetching report schedules for user", "user_id", userID, "err", err)
+		return nil, errors.New("failed to retrieve report schedules")
+	}
+
+	responses := make([]ReportScheduleResponse, 0, len(schedules))
+	for i := range schedules {
+		responses = append(responses, toReportScheduleResponse(&schedules[i]))
+	}
+	return responses, nil
+}
+
+// UpdateSchedule implements ReportScheduleService.
+func (s *reportScheduleService) UpdateSchedule(ctx context.Context, id uint, req UpdateReportScheduleRequest) (*ReportScheduleResponse, error) {
+	schedule, err := s.find(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Channel != nil {
+		schedule.Channel = *req.Channel
+	}
+	if req.Target != nil {
+		schedule.Target = strings.TrimSpace(*req.Target)
+	}
+	if req.Format != nil {
+		schedule.Format = *req.Format
+	}
+	if req.Weekday != nil {
+		if err := s.applyWeekday(schedule, *req.Weekday); err != nil {
+			return nil, err
+		}
+	}
+	if req.Hour != nil {
+		schedule.Hour = *req.Hour
+	}
+	if req.Timezone != nil {
+		schedule.Timezone = *req.Timezone
+	}
+	if err := s.validate(schedule); err != nil {
+		return nil, err
+	}
+	// Timing may have changed, so recompute from now
+	schedule.NextRunAt = nextScheduledRun(schedule, time.Now())
+
+	if err := s.repo.Update(schedule); err != nil {
+		logging.FromContext(ctx).Error("Error updating report schedule", "schedule_id", id, "err", err)
+		return nil, errors.New("failed to update report schedule")
+	}
+
+	response := toReportScheduleResponse(schedule)
+	return &response, nil
+}
+
+// DeleteSchedule implements ReportScheduleService.
+func (s *reportScheduleService) DeleteSchedule(ctx context.Context, id uint) error {
+	if _, err := s.find(ctx, id); err != nil {
+		return err
+	}
+	if err := s.repo.Delete(id); err != nil {
+		logging.FromContext(ctx).Error("Error deleting report schedule", "schedule_id", id, "err", err)
+		return errors.New("failed to delete report schedule")
+	}
+	return nil
+}
+
+// RunDue implements ReportScheduleService.
+func (s *reportScheduleService) RunDue(ctx context.Context, now time.Time) error {
+	due, err := s.repo.FindDue(now, dueSchedulesBatchSize)
+	if err != nil {
+		return fmt.Errorf("fetching due report schedules: %w", err)
+	}
+
+	for i := range due {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		schedule := &due[i]
+
+		deliveryErr := s.deliver(ctx, schedule, now)
+		if deliveryErr != nil {
+			logging.FromContext(ctx).Error("Error delivering report schedule", "schedule_id", schedule.ID, "err", deliveryErr)
+			schedule.LastError = deliveryErr.Error()
+		} else {
+			schedule.LastError = ""
+		}
+
+		// Advance even on failure so a broken target doesn't get retried every tick
+		runAt := now
+		schedule.LastRunAt = &runAt
+		schedule.NextRunAt = nextScheduledRun(schedule, now)
+		if err := s.repo.Update(schedule); err != nil {
+			return fmt.Errorf("updating report schedule %d: %w", schedule.ID, err)
+		}
+	}
+	return nil
+}
+
+// deliver builds the report for a schedule and sends it over its channel.
+func (s *reportScheduleService) deliver(ctx context.Context, schedule *domain.ReportSchedule, now time.Time) error {
+	loc, err := time.LoadLocation(schedule.Timezone)
+	if err != nil {
+		return err
+	}
+	format, err := report.ParseFormat(schedule.Format)
+	if err != nil {
+		return err
+	}
+
+	// Report on the week containing the previous day: a Monday morning run
+	// summarizes last week, a Friday evening run summarizes this one.
+	rep, err := s.reports.WeeklyReport(ctx, schedule.UserID, now.In(loc).AddDate(0, 0, -1))
+	if err != nil {
+		return err
+	}
+
+	var rendered bytes.Buffer
+	if err := report.Write(&rendered, rep, format); err != nil {
+		return err
+	}
+
+	completed, outstanding := rep.Totals()
+	msg := notify.Message{
+		Recipient: schedule.Target,
+		Subject:   fmt.Sprintf("Weekly report for %s", rep.PeriodStart.Format("Jan 2, 2006")),
+		Body:      fmt.Sprintf("%d completed, %d outstanding. The full report is attached.", completed, outstanding),
+		Attachments: []notify.Attachment{{
+			Filename:    fmt.Sprintf("weekly-report-%s.%s", rep.PeriodStart.Format("2006-01-02"), format),
+			ContentType: format.ContentType(),
+			Data:        rendered.Bytes(),
+		}},
+	}
+	return s.channels.Send(ctx, schedule.Channel, msg)
+}
+
+func (s *reportScheduleService) find(ctx context.Context, id uint) (*domain.ReportSchedule, error) {
+	schedule, err := s.repo.FindByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.NotFoundf("report schedule with ID %d not found", id)
+		}
+		logging.FromContext(ctx).Error("Error fetching report schedule", "schedule_id", id, "err", err)
+		return nil, errors.New("failed to retrieve report schedule")
+	}
+	return schedule, nil
+}
+
+func (s *reportScheduleService) applyWeekday(schedule *domain.ReportSchedule, name string) error {
+	weekday, ok := weekdaysByName[strings.ToLower(strings.TrimSpace(name))]
+	if !ok {
+		return apperror.Invalidf("invalid schedule: weekday must be a day name such as \"monday\"")
+	}
+	schedule.Weekday = int(weekday)
+	return nil
+}
+
+// validate checks a schedule's fields. Every error message starts with
+// "invalid" so handlers can map them to 400 responses.
+func (s *reportScheduleService) validate(schedule *domain.ReportSchedule) error {
+	if schedule.Hour < 0 || schedule.Hour > 23 {
+		return apperror.Invalidf("invalid schedule: hour must be between 0 and 23")
+	}
+	if _, err := time.LoadLocation(schedule.Timezone); err != nil {
+		return apperror.Invalidf("invalid schedule: unknown timezone %q", schedule.Timezone)
+	}
+	format, err := report.ParseFormat(schedule.Format)
+	if err != nil {
+		return apperror.Invalidf("invalid schedule: format must be md or pdf")
+	}
+	schedule.Format = string(format)
+
+	if err := validateDelivery(s.channels, schedule.Channel, schedule.Target); err != nil {
+		return apperror.Invalidf("invalid schedule: %w", err)
+	}
+	return nil
+}
+
+// validateDelivery checks that channel is registered and target is an
+// address it can deliver to.
+func validateDelivery(channels *notify.Registry, channel, target string) error {
+	if _, ok := channels.Get(channel); !ok {
+		return fmt.Errorf("channel must be one of %s", strings.Join(channels.Names(), ", "))
+	}
+	switch channel {
+	case "email":
+		if _, err := mail.ParseAddress(target); err != nil {
+			return errors.New("target must be an email address")
+		}
+	case "webhook":
+		if err := notify.ValidateWebhookURL(target); err != nil {
+			return errors.New("target must be an http(s) URL")
+		}
+	}
+	return nil
+}
+
+// nextScheduledRun returns the first weekday/hour slot strictly after the given time.
+func nextScheduledRun(schedule *domain.ReportSchedule, after time.Time) time.Time {
+	loc, err := time.LoadLocation(schedule.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	local := after.In(loc)
+	candidate := time.Date(local.Year(), local.Month(), local.Day(), schedule.Hour, 0, 0, 0, loc)
+	candidate = candidate.AddDate(0, 0, (schedule.Weekday-int(local.Weekday())+7)%7)
+	if !candidate.After(after) {
+		candidate = candidate.AddDate(0, 0, 7)
+	}
+	return candidate
+}
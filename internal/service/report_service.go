@@ -0,0 +1,133 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Tomlord1122/todo-backend/internal/apperror"
+	"github.com/Tomlord1122/todo-backend/internal/domain"
+	"github.com/Tomlord1122/todo-backend/internal/logging"
+	"github.com/Tomlord1122/todo-backend/internal/pagination"
+	"github.com/Tomlord1122/todo-backend/internal/report"
+	"github.com/Tomlord1122/todo-backend/internal/repository"
+)
+
+// unlistedSectionName is the report section for todos that aren't in any list.
+const unlistedSectionName = "Inbox"
+
+// ReportService builds status reports from a user's todos.
+type ReportService interface {
+	// WeeklyReport builds the report for the Monday-to-Sunday week containing
+	// weekOf, evaluated in weekOf's location.
+	WeeklyReport(ctx context.Context, userID uint, weekOf time.Time) (*report.Report, error)
+}
+
+type reportService struct {
+	todos  repository.TodoRepository
+	lists  repository.ListRepository
+	limits pagination.Config
+}
+
+// NewReportService creates a new ReportService. limits caps how many
+// todos one report may contain.
+func NewReportService(todos repository.TodoRepository, lists repository.ListRepository, limits pagination.Config) ReportService {
+	return &reportService{
+		todos:  todos,
+		lists:  lists,
+		limits: limits,
+	}
+}
+
+// WeeklyReport implements ReportService.
+func (s *reportService) WeeklyReport(ctx context.Context, userID uint, weekOf time.Time) (*report.Report, error) {
+	if userID == 0 {
+		return nil, apperror.Invalidf("user_id is required")
+	}
+
+	start := startOfWeek(weekOf)
+	end := start.AddDate(0, 0, 7)
+
+	lists, err := s.lists.FindByUserID(userID, repository.ListOptions{})
+	if err != nil {
+		logging.FromContext(ctx).Error("Error fetching lists for report", "err", err)
+		return nil, errors.New("failed to build report")
+	}
+	completed, err := s.todos.FindCompletedBetween(userID, start, end)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error fetching completed todos for report", "err", err)
+		return nil, errors.New("failed to build report")
+	}
+	outstanding, err := s.todos.FindOpenByUser(userID)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error fetching outstanding todos for report", "err", err)
+		return nil, errors.New("failed to build report")
+	}
+	if err := s.limits.CheckExport(len(completed)+len(outstanding), "the report lists every open todo, so complete or delete some first"); err != nil {
+		return nil, err
+	}
+
+	// One section per list (already sorted by name), plus the inbox last
+	sections := make([]report.Section, len(lists)+1)
+	sectionIndex := make(map[uint]int, len(lists))
+	for i, list := range lists {
+		sections[i].Name = list.Name
+		sectionIndex[list.ID] = i
+	}
+	sections[len(lists)].Name = unlistedSectionName
+	sectionFor := func(todo *domain.Todo) *report.Section {
+		if todo.ListID != nil {
+			if i, ok := sectionIndex[*todo.ListID]; ok {
+				return &sections[i]
+			}
+		}
+		return &sections[len(lists)]
+	}
+
+	loc := weekOf.Location()
+	for i := range completed {
+		todo := &completed[i]
+		section := sectionFor(todo)
+		section.Completed = append(section.Completed, report.Entry{
+			Title: todo.Title,
+			Note:  "completed " + todo.CompletedAt.In(loc).Format("Mon Jan 2"),
+		})
+	}
+	for i := range outstanding {
+		todo := &outstanding[i]
+		entry := report.Entry{Title: todo.Title}
+		if todo.DueDate != nil {
+			due := todo.DueDate.In(loc)
+			if due.Before(start) {
+				entry.Note = "overdue since " + due.Format("Mon Jan 2")
+			} else {
+				entry.Note = "due " + due.Format("Mon Jan 2")
+			}
+		}
+		section := sectionFor(todo)
+		section.Outstanding = append(section.Outstanding, entry)
+	}
+
+	// Drop sections with nothing to report so empty lists don't pad the output
+	nonEmpty := sections[:0]
+	for _, section := range sections {
+		if len(section.Completed) > 0 || len(section.Outstanding) > 0 {
+			nonEmpty = append(nonEmpty, section)
+		}
+	}
+
+	return &report.Report{
+		Title:       "Weekly report",
+		PeriodStart: start,
+		PeriodEnd:   end,
+		GeneratedAt: time.Now().In(loc),
+		Sections:    nonEmpty,
+	}, nil
+}
+
+// startOfWeek returns midnight on the Monday of t's week, in t's location.
+func startOfWeek(t time.Time) time.Time {
+	daysSinceMonday := (int(t.Weekday()) + 6) % 7
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	return day.AddDate(0, 0, -daysSinceMonday)
+}
@@ -0,0 +1,50 @@
+package service
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Tomlord1122/todo-backend/internal/domain"
+	"github.com/Tomlord1122/todo-backend/internal/pagination"
+	"github.com/Tomlord1122/todo-backend/internal/report"
+	"github.com/Tomlord1122/todo-backend/internal/repository"
+)
+
+func TestWeeklyReport(t *testing.T) {
+	todos := repository.NewInMemoryTodoRepository()
+	reports := NewReportService(todos, repository.NewMemoryRepositories().Lists, pagination.DefaultConfig())
+
+	// Wednesday; the week runs from Monday the 6th to Sunday the 12th
+	weekOf := time.Date(2025, 1, 8, 12, 0, 0, 0, time.UTC)
+	completedAt := time.Date(2025, 1, 7, 9, 0, 0, 0, time.UTC)
+	lastWeek := time.Date(2025, 1, 2, 9, 0, 0, 0, time.UTC)
+	due := time.Date(2025, 1, 10, 0, 0, 0, 0, time.UTC)
+	for _, todo := range []*domain.Todo{
+		{Title: "Pay rent", UserID: 1, Completed: true, CompletedAt: &completedAt},
+		{Title: "Old news", UserID: 1, Completed: true, CompletedAt: &lastWeek},
+		{Title: "File taxes", UserID: 1, DueDate: &due},
+		{Title: "Someone else's", UserID: 2},
+	} {
+		if err := todos.Create(todo); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := reports.WeeklyReport(context.Background(), 1, weekOf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.PeriodStart.Equal(time.Date(2025, 1, 6, 0, 0, 0, 0, time.UTC)) || !got.PeriodEnd.Equal(time.Date(2025, 1, 13, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("period = %s to %s, want the week of Monday Jan 6", got.PeriodStart, got.PeriodEnd)
+	}
+	want := report.Section{
+		Name:        unlistedSectionName,
+		Completed:   []report.Entry{{Title: "Pay rent", Note: "completed Tue Jan 7"}},
+		Outstanding: []report.Entry{{Title: "File taxes", Note: "due Fri Jan 10"}},
+	}
+	if len(got.Sections) != 1 || !reflect.DeepEqual(got.Sections[0], want) {
+		t.Errorf("Sections = %+v, want only %+v", got.Sections, want)
+	}
+}
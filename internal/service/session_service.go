@@ -0,0 +1,106 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/Tomlord1122/todo-backend/internal/apperror"
+	"github.com/Tomlord1122/todo-backend/internal/domain"
+	"github.com/Tomlord1122/todo-backend/internal/logging"
+	"github.com/Tomlord1122/todo-backend/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+// ErrUnauthenticated is returned for a missing, expired or revoked
+// session token.
+var ErrUnauthenticated = apperror.New(apperror.CodeUnauthenticated, "authentication required")
+
+// SessionResponse is returned by a login. The token is sent as
+// "Authorization: Bearer <token>" and is only ever shown here.
+type SessionResponse struct {
+	Token     string `json:"token"`
+	UserID    uint   `json:"user_id"`
+	ExpiresAt string `json:"expires_at"`
+}
+
+// SessionConfig tunes sign-in sessions.
+type SessionConfig struct {
+	// TTL is how long a login lasts.
+	TTL time.Duration
+}
+
+// SessionService resolves and ends the sessions that logins (passkey or
+// single sign-on) start.
+type SessionService interface {
+	// Authenticate returns the user signed in with token.
+	Authenticate(ctx context.Context, token string, now time.Time) (uint, error)
+
+	// Logout ends the session of token.
+	Logout(ctx context.Context, token string) error
+}
+
+type sessionService struct {
+	repo repository.SessionRepository
+}
+
+// NewSessionService creates a new SessionService.
+func NewSessionService(repo repository.SessionRepository) SessionService {
+	return &sessionService{repo: repo}
+}
+
+// Authenticate implements SessionService.
+func (s *sessionService) Authenticate(ctx context.Context, token string, now time.Time) (uint, error) {
+	if token == "" {
+		return 0, ErrUnauthenticated
+	}
+	session, err := s.repo.Find(hashSessionToken(token), now)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, ErrUnauthenticated
+		}
+		logging.FromContext(ctx).Error("Error looking up session", "err", err)
+		return 0, errors.New("failed to check session")
+	}
+	return session.UserID, nil
+}
+
+// Logout implements SessionService.
+func (s *sessionService) Logout(ctx context.Context, token string) error {
+	if token == "" {
+		return ErrUnauthenticated
+	}
+	if err := s.repo.Delete(hashSessionToken(token)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrUnauthenticated
+		}
+		logging.FromContext(ctx).Error("Error ending session", "err", err)
+		return errors.New("failed to sign out")
+	}
+	return nil
+}
+
+// startSession issues a token for session and stores it.
+func startSession(ctx context.Context, repo repository.SessionRepository, session *domain.AuthSession) (*SessionResponse, error) {
+	token, err := generateToken()
+	if err != nil {
+		logging.FromContext(ctx).Error("Error generating session token", "err", err)
+		return nil, errors.New("failed to sign in")
+	}
+	session.TokenHash = hashSessionToken(token)
+	if err := repo.Create(session); err != nil {
+		logging.FromContext(ctx).Error("Error creating session for user", "user_id", session.UserID, "err", err)
+		return nil, errors.New("failed to sign in")
+	}
+	return &SessionResponse{Token: token, UserID: session.UserID, ExpiresAt: session.ExpiresAt.Format(time.RFC3339)}, nil
+}
+
+// hashSessionToken returns what is stored for a session token, so a
+// database leak doesn't hand out sessions.
+func hashSessionToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,280 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/Tomlord1122/todo-backend/internal/apperror"
+	"github.com/Tomlord1122/todo-backend/internal/domain"
+	"github.com/Tomlord1122/todo-backend/internal/logging"
+	"github.com/Tomlord1122/todo-backend/internal/oidc"
+	"github.com/Tomlord1122/todo-backend/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrSSOLoginFailed is returned when the provider rejects the code or
+	// its ID token doesn't verify.
+	ErrSSOLoginFailed = apperror.New(apperror.CodeUnauthenticated, "single sign-on failed")
+	// ErrIdentityNotLinked is returned when a provider account signs in
+	// that belongs to no user yet.
+	ErrIdentityNotLinked = apperror.New(apperror.CodeForbidden, "this account is not linked to a user, sign in another way and link it first")
+	// ErrIdentityLinkedElsewhere is returned when linking a provider
+	// account that another user already linked.
+	ErrIdentityLinkedElsewhere = apperror.New(apperror.CodeConflict, "this account is already linked to another user")
+)
+
+// ssoLoginTimeout is how long a user has to complete the provider's login.
+const ssoLoginTimeout = 10 * time.Minute
+
+// SSOProviderResponse describes a configured identity provider.
+type SSOProviderResponse struct {
+	Name        string `json:"name"`
+	DisplayName string `json:"display_name"`
+}
+
+// SSOLoginResponse starts a login: send the user to AuthorizationURL.
+type SSOLoginResponse struct {
+	AuthorizationURL string `json:"authorization_url"`
+}
+
+// FinishSSOLoginRequest completes a login with the code and state the
+// provider sent to the redirect URL.
+type FinishSSOLoginRequest struct {
+	Code  string `json:"code" validate:"notblank"`
+	State string `json:"state" validate:"notblank"`
+}
+
+// IdentityResponse describes a linked provider account.
+type IdentityResponse struct {
+	ID          uint    `json:"id"`
+	Provider    string  `json:"provider"`
+	Subject     string  `json:"subject"`
+	Email       string  `json:"email,omitempty"`
+	Name        string  `json:"name,omitempty"`
+	CreatedAt   string  `json:"created_at"`
+	LastLoginAt *string `json:"last_login_at,omitempty"`
+}
+
+// SSOService signs users in with OpenID Connect providers and manages the
+// provider accounts linked to them. A provider account is linked by
+// starting a login while signed in, or on first login when the provider
+// is configured to say which user an account belongs to. Logins in
+// progress are kept in memory, so both steps must reach the same
+// instance.
+type SSOService interface {
+	// Providers returns the configured providers.
+	Providers(ctx context.Context) []SSOProviderResponse
+
+	// BeginLogin starts a login with provider. When sessionUserID is set
+	// the provider account is linked to that user.
+	BeginLogin(ctx context.Context, sessionUserID uint, provider string) (*SSOLoginResponse, error)
+
+	// FinishLogin redeems the provider's code and starts a session.
+	FinishLogin(ctx context.Context, provider string, req FinishSSOLoginRequest, now time.Time) (*SessionResponse, error)
+
+	// ListIdentities returns the provider accounts linked to a user.
+	ListIdentities(ctx context.Context, userID uint) ([]IdentityResponse, error)
+
+	// DeleteIdentity unlinks one of the user's provider accounts and ends
+	// the sessions signed in with it.
+	DeleteIdentity(ctx context.Context, userID, id uint) error
+}
+
+// ssoLogin is a started login.
+type ssoLogin struct {
+	provider string
+	// userID is the signed-in user linking an account; 0 for logins
+	userID          uint
+	nonce, verifier string
+	expires         time.Time
+}
+
+type ssoService struct {
+	repo      repository.IdentityRepository
+	sessions  repository.SessionRepository
+	providers map[string]*oidc.Provider
+	order     []string
+	cfg       SessionConfig
+
+	mu sync.Mutex
+	// logins are keyed by state
+	logins map[string]ssoLogin
+}
+
+// NewSSOService creates a new SSOService for the given providers, which
+// may be empty.
+func NewSSOService(repo repository.IdentityRepository, sessions repository.SessionRepository, providers []*oidc.Provider, cfg SessionConfig) SSOService {
+	s := &ssoService{repo: repo, sessions: sessions, providers: make(map[string]*oidc.Provider), cfg: cfg, logins: make(map[string]ssoLogin)}
+	for _, p := range providers {
+		s.providers[p.Name()] = p
+		s.order = append(s.order, p.Name())
+	}
+	return s
+}
+
+func toIdentityResponse(identity *domain.ExternalIdentity) IdentityResponse {
+	return IdentityResponse{
+		ID:          identity.ID,
+		Provider:    identity.Provider,
+		Subject:     identity.Subject,
+		Email:       identity.Email,
+		Name:        identity.Name,
+		CreatedAt:   identity.CreatedAt.Format(time.RFC3339),
+		LastLoginAt: formatOptionalTime(identity.LastLoginAt),
+	}
+}
+
+// Providers implements SSOService.
+func (s *ssoService) Providers(ctx context.Context) []SSOProviderResponse {
+	resp := make([]SSOProviderResponse, 0, len(s.order))
+	for _, name := range s.order {
+		resp = append(resp, SSOProviderResponse{Name: name, DisplayName: s.providers[name].DisplayName()})
+	}
+	return resp
+}
+
+func (s *ssoService) provider(name string) (*oidc.Provider, error) {
+	p, ok := s.providers[name]
+	if !ok {
+		return nil, apperror.NotFoundf("identity provider %q not found", name)
+	}
+	return p, nil
+}
+
+// BeginLogin implements SSOService.
+func (s *ssoService) BeginLogin(ctx context.Context, sessionUserID uint, provider string) (*SSOLoginResponse, error) {
+	p, err := s.provider(provider)
+	if err != nil {
+		return nil, err
+	}
+	var secrets [3]string
+	for i := range secrets {
+		if secrets[i], err = oidc.NewVerifier(); err != nil {
+			logging.FromContext(ctx).Error("Error generating login state", "err", err)
+			return nil, errors.New("failed to start sign-in")
+		}
+	}
+	state, nonce, verifier := secrets[0], secrets[1], secrets[2]
+
+	authURL, err := p.AuthURL(ctx, state, nonce, verifier)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error preparing login", "provider", provider, "err", err)
+		return nil, errors.New("failed to start sign-in")
+	}
+
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key, login := range s.logins {
+		if now.After(login.expires) {
+			delete(s.logins, key)
+		}
+	}
+	s.logins[state] = ssoLogin{provider: provider, userID: sessionUserID, nonce: nonce, verifier: verifier, expires: now.Add(ssoLoginTimeout)}
+	return &SSOLoginResponse{AuthorizationURL: authURL}, nil
+}
+
+// takeLogin returns and forgets the login of state, so every state is
+// used at most once.
+func (s *ssoService) takeLogin(provider, state string, now time.Time) (ssoLogin, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	login, ok := s.logins[state]
+	delete(s.logins, state)
+	if !ok || login.provider != provider || now.After(login.expires) {
+		return ssoLogin{}, false
+	}
+	return login, true
+}
+
+// FinishLogin implements SSOService.
+func (s *ssoService) FinishLogin(ctx context.Context, provider string, req FinishSSOLoginRequest, now time.Time) (*SessionResponse, error) {
+	p, err := s.provider(provider)
+	if err != nil {
+		return nil, err
+	}
+	login, ok := s.takeLogin(provider, req.State, now)
+	if !ok {
+		return nil, apperror.Invalidf("invalid sign-in: it expired or was already completed, start again")
+	}
+
+	claims, err := p.Exchange(ctx, req.Code, login.verifier, login.nonce, now)
+	if err != nil {
+		logging.FromContext(ctx).Warn("Sign-in failed", "provider", provider, "err", err)
+		return nil, ErrSSOLoginFailed
+	}
+
+	identity, err := s.repo.FindBySubject(provider, claims.Subject)
+	switch {
+	case err == nil:
+		if login.userID != 0 && identity.UserID != login.userID {
+			return nil, ErrIdentityLinkedElsewhere
+		}
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		userID := login.userID
+		if userID == 0 {
+			userID = claims.UserID
+		}
+		if userID == 0 {
+			return nil, ErrIdentityNotLinked
+		}
+		identity = &domain.ExternalIdentity{UserID: userID, Provider: provider, Subject: claims.Subject}
+	default:
+		logging.FromContext(ctx).Error("Error fetching identity", "provider", provider, "err", err)
+		return nil, errors.New("failed to sign in")
+	}
+
+	identity.Email = claims.Email
+	identity.Name = claims.Name
+	identity.LastLoginAt = &now
+	if identity.ID == 0 {
+		err = s.repo.Create(identity)
+	} else {
+		err = s.repo.Update(identity)
+	}
+	if err != nil {
+		logging.FromContext(ctx).Error("Error saving identity", "provider", provider, "user_id", identity.UserID, "err", err)
+		return nil, errors.New("failed to sign in")
+	}
+
+	return startSession(ctx, s.sessions, &domain.AuthSession{
+		UserID:     identity.UserID,
+		IdentityID: &identity.ID,
+		ExpiresAt:  now.Add(s.cfg.TTL),
+	})
+}
+
+// ListIdentities implements SSOService.
+func (s *ssoService) ListIdentities(ctx context.Context, userID uint) ([]IdentityResponse, error) {
+	identities, err := s.repo.FindByUserID(userID)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error fetching identities of user", "user_id", userID, "err", err)
+		return nil, errors.New("failed to retrieve identities")
+	}
+	resp := make([]IdentityResponse, 0, len(identities))
+	for i := range identities {
+		resp = append(resp, toIdentityResponse(&identities[i]))
+	}
+	return resp, nil
+}
+
+// DeleteIdentity implements SSOService.
+func (s *ssoService) DeleteIdentity(ctx context.Context, userID, id uint) error {
+	identity, err := s.repo.FindByID(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && identity.UserID != userID) {
+		return apperror.NotFoundf("identity with ID %d not found", id)
+	}
+	if err != nil {
+		logging.FromContext(ctx).Error("Error fetching identity", "identity_id", id, "err", err)
+		return errors.New("failed to retrieve identity")
+	}
+	if err := s.repo.Delete(id); err != nil {
+		logging.FromContext(ctx).Error("Error deleting identity", "identity_id", id, "err", err)
+		return errors.New("failed to delete identity")
+	}
+	return nil
+}
@@ -0,0 +1,205 @@
+package service
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/Tomlord1122/todo-backend/internal/apperror"
+	"github.com/Tomlord1122/todo-backend/internal/logging"
+	"github.com/Tomlord1122/todo-backend/internal/repository"
+)
+
+// maxStatsDays bounds the range of a stats request.
+const maxStatsDays = 366
+
+// StatsRequest selects whose stats to compute and over which days. Dates are
+// YYYY-MM-DD in Timezone, both inclusive; they default to the last 7 days.
+type StatsRequest struct {
+	UserID uint
+	From   string
+	To     string
+	// Timezone overrides the user's timezone preference
+	Timezone string
+}
+
+// StatsResponse summarizes a user's activity over a range of days.
+type StatsResponse struct {
+	UserID   uint       `json:"user_id"`
+	Timezone string     `json:"timezone"`
+	From     string     `json:"from"`
+	To       string     `json:"to"`
+	Focus    FocusStats `json:"focus"`
+}
+
+// FocusStats is time spent in focus sessions.
+type FocusStats struct {
+	TotalSeconds int64 `json:"total_seconds"`
+	Sessions     int   `json:"sessions"`
+	// ByDay has an entry for every day in the range, oldest first
+	ByDay []FocusDay `json:"by_day"`
+	// ByTodo is ordered by time spent, most first
+	ByTodo []FocusTodo `json:"by_todo"`
+}
+
+// FocusDay is focused time on one calendar day.
+type FocusDay struct {
+	Date    string `json:"date"`
+	Seconds int64  `json:"seconds"`
+}
+
+// FocusTodo is focused time on one todo; TodoID is nil for sessions not
+// linked to a todo.
+type FocusTodo struct {
+	TodoID  *uint  `json:"todo_id"`
+	Title   string `json:"title,omitempty"`
+	Seconds int64  `json:"seconds"`
+}
+
+// StatsService computes per-user statistics.
+type StatsService interface {
+	GetStats(ctx context.Context, req StatsRequest, now time.Time) (*StatsResponse, error)
+}
+
+type statsService struct {
+	focus repository.FocusRepository
+	todos repository.TodoRepository
+	prefs repository.PreferenceRepository
+}
+
+// NewStatsService creates a new StatsService.
+func NewStatsService(focus repository.FocusRepository, todos repository.TodoRepository, prefs repository.PreferenceRepository) StatsService {
+	return &statsService{focus: focus, todos: todos, prefs: prefs}
+}
+
+// GetStats implements StatsService.
+func (s *statsService) GetStats(ctx context.Context, req StatsRequest, now time.Time) (*StatsResponse, error) {
+	pref, err := loadPreferences(s.prefs, req.UserID)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error fetching preferences for user", "user_id", req.UserID, "err", err)
+		return nil, errors.New("failed to compute stats")
+	}
+	loc := userLocation(pref)
+	if req.Timezone != "" {
+		if loc, err = time.LoadLocation(req.Timezone); err != nil {
+			return nil, apperror.Invalidf("invalid timezone %q", req.Timezone)
+		}
+	}
+
+	from, to, err := parseDateRange(req.From, req.To, loc, now, func(to time.Time) time.Time { return to.AddDate(0, 0, -6) })
+	if err != nil {
+		return nil, err
+	}
+
+	focus, err := s.focusStats(ctx, req.UserID, from, to, now)
+	if err != nil {
+		return nil, errors.New("failed to compute stats")
+	}
+	return &StatsResponse{
+		UserID:   req.UserID,
+		Timezone: loc.String(),
+		From:     from.Format(time.DateOnly),
+		To:       to.Format(time.DateOnly),
+		Focus:    *focus,
+	}, nil
+}
+
+// focusStats splits focused time between the days from..to and between
+// todos. Sessions spanning midnight count towards both days.
+func (s *statsService) focusStats(ctx context.Context, userID uint, from, to, now time.Time) (*FocusStats, error) {
+	end := to.AddDate(0, 0, 1)
+	sessions, err := s.focus.FindByUserBetween(userID, from, end)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error fetching focus sessions for user", "user_id", userID, "err", err)
+		return nil, err
+	}
+
+	stats := &FocusStats{ByDay: []FocusDay{}, ByTodo: []FocusTodo{}}
+	dayIndex := make(map[string]int)
+	for day := from; day.Before(end); day = day.AddDate(0, 0, 1) {
+		dayIndex[day.Format(time.DateOnly)] = len(stats.ByDay)
+		stats.ByDay = append(stats.ByDay, FocusDay{Date: day.Format(time.DateOnly)})
+	}
+
+	byTodo := make(map[uint]int64) // 0 for sessions without a todo
+	for i := range sessions {
+		session := &sessions[i]
+		start, stop := session.StartedAt.In(from.Location()), session.EffectiveEnd(now).In(from.Location())
+		if start.Before(from) {
+			start = from
+		}
+		if stop.After(end) {
+			stop = end
+		}
+		if !start.Before(stop) {
+			continue
+		}
+		stats.Sessions++
+
+		// Walk the session day by day, splitting at local midnight
+		for start.Before(stop) {
+			y, m, d := start.Date()
+			midnight := time.Date(y, m, d+1, 0, 0, 0, 0, start.Location())
+			chunk := min(stop.Sub(start), midnight.Sub(start))
+			seconds := int64(chunk / time.Second)
+			stats.ByDay[dayIndex[start.Format(time.DateOnly)]].Seconds += seconds
+			stats.TotalSeconds += seconds
+			var todoID uint
+			if session.TodoID != nil {
+				todoID = *session.TodoID
+			}
+			byTodo[todoID] += seconds
+			start = midnight
+		}
+	}
+
+	for todoID, seconds := range byTodo {
+		entry := FocusTodo{Seconds: seconds}
+		if todoID != 0 {
+			entry.TodoID = &todoID
+			// Deleted todos keep their time but lose the title
+			if todo, err := s.todos.FindByID(todoID); err == nil {
+				entry.Title = todo.Title
+			}
+		}
+		stats.ByTodo = append(stats.ByTodo, entry)
+	}
+	slices.SortFunc(stats.ByTodo, func(a, b FocusTodo) int {
+		return cmp.Or(cmp.Compare(b.Seconds, a.Seconds), cmp.Compare(focusTodoKey(a), focusTodoKey(b)))
+	})
+	return stats, nil
+}
+
+func focusTodoKey(t FocusTodo) uint {
+	if t.TodoID == nil {
+		return 0
+	}
+	return *t.TodoID
+}
+
+// parseDateRange parses inclusive YYYY-MM-DD bounds in loc. to defaults to
+// today and from to defaultFrom(to). Ranges are capped at maxStatsDays.
+func parseDateRange(fromStr, toStr string, loc *time.Location, now time.Time, defaultFrom func(to time.Time) time.Time) (from, to time.Time, err error) {
+	y, m, d := now.In(loc).Date()
+	to = time.Date(y, m, d, 0, 0, 0, 0, loc)
+	if toStr != "" {
+		if to, err = time.ParseInLocation(time.DateOnly, toStr, loc); err != nil {
+			return from, to, apperror.Invalidf("invalid to date, expected YYYY-MM-DD")
+		}
+	}
+	from = defaultFrom(to)
+	if fromStr != "" {
+		if from, err = time.ParseInLocation(time.DateOnly, fromStr, loc); err != nil {
+			return from, to, apperror.Invalidf("invalid from date, expected YYYY-MM-DD")
+		}
+	}
+	if to.Before(from) {
+		return from, to, apperror.Invalidf("invalid range: from is after to")
+	}
+	if from.AddDate(0, 0, maxStatsDays).Before(to.AddDate(0, 0, 1)) {
+		return from, to, apperror.Invalidf("invalid range: at most %d days", maxStatsDays)
+	}
+	return from, to, nil
+}
@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Tomlord1122/todo-backend/internal/apperror"
+	"github.com/Tomlord1122/todo-backend/internal/domain"
+	"github.com/Tomlord1122/todo-backend/internal/repository"
+)
+
+func TestFocusStats(t *testing.T) {
+	repos := repository.NewMemoryRepositories()
+	stats := NewStatsService(repos.FocusSessions, repos.Todos, repos.Preferences)
+	now := time.Date(2025, 1, 10, 12, 0, 0, 0, time.UTC)
+	taxes := &domain.Todo{Title: "File taxes", UserID: 1}
+	report := &domain.Todo{Title: "Write report", UserID: 1}
+	for _, todo := range []*domain.Todo{taxes, report} {
+		if err := repos.Todos.Create(todo); err != nil {
+			t.Fatal(err)
+		}
+	}
+	at := func(day, hour, minute int) time.Time { return time.Date(2025, 1, day, hour, minute, 0, 0, time.UTC) }
+	session := func(userID uint, todo *domain.Todo, start, end time.Time) {
+		t.Helper()
+		s := &domain.FocusSession{UserID: userID, StartedAt: start, EndedAt: &end}
+		if todo != nil {
+			s.TodoID = &todo.ID
+		}
+		if err := repos.FocusSessions.Create(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Half of it is before the range
+	session(1, report, at(5, 23, 0), at(6, 1, 0))
+	session(1, taxes, at(6, 9, 0), at(6, 9, 30))
+	// Split between the 6th and the 7th
+	session(1, taxes, at(6, 23, 30), at(7, 0, 30))
+	session(1, nil, at(8, 8, 0), at(8, 8, 10))
+	// Other users' sessions and those after the range don't count
+	session(2, nil, at(7, 9, 0), at(7, 10, 0))
+	session(1, taxes, at(9, 9, 0), at(9, 10, 0))
+
+	got, err := stats.GetStats(context.Background(), StatsRequest{UserID: 1, From: "2025-01-06", To: "2025-01-08"}, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := FocusStats{
+		TotalSeconds: (60 + 30 + 60 + 10) * 60,
+		Sessions:     4,
+		ByDay: []FocusDay{
+			{Date: "2025-01-06", Seconds: (60 + 30 + 30) * 60},
+			{Date: "2025-01-07", Seconds: 30 * 60},
+			{Date: "2025-01-08", Seconds: 10 * 60},
+		},
+		ByTodo: []FocusTodo{
+			{TodoID: &taxes.ID, Title: "File taxes", Seconds: 90 * 60},
+			{TodoID: &report.ID, Title: "Write report", Seconds: 60 * 60},
+			{Seconds: 10 * 60},
+		},
+	}
+	if !reflect.DeepEqual(got.Focus, want) {
+		t.Errorf("Focus = %+v, want %+v", got.Focus, want)
+	}
+}
+
+func TestFocusStatsEmptyRange(t *testing.T) {
+	repos := repository.NewMemoryRepositories()
+	stats := NewStatsService(repos.FocusSessions, repos.Todos, repos.Preferences)
+	now := time.Date(2025, 1, 10, 12, 0, 0, 0, time.UTC)
+
+	got, err := stats.GetStats(context.Background(), StatsRequest{UserID: 1, From: "2025-01-01", To: "2025-01-02"}, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Days without focus are still listed, with zero seconds
+	want := FocusStats{
+		ByDay:  []FocusDay{{Date: "2025-01-01"}, {Date: "2025-01-02"}},
+		ByTodo: []FocusTodo{},
+	}
+	if !reflect.DeepEqual(got.Focus, want) {
+		t.Errorf("Focus = %+v, want %+v", got.Focus, want)
+	}
+
+	// The range defaults to the last 7 days
+	got, err = stats.GetStats(context.Background(), StatsRequest{UserID: 1}, now)
+	if err != nil || got.From != "2025-01-04" || got.To != "2025-01-10" || len(got.Focus.ByDay) != 7 {
+		t.Errorf("default range = %+v, %v, want 2025-01-04 to 2025-01-10", got, err)
+	}
+
+	for _, req := range []StatsRequest{
+		{UserID: 1, From: "2025-01-02", To: "2025-01-01"},
+		{UserID: 1, From: "yesterday"},
+		{UserID: 1, Timezone: "Mars/Olympus_Mons"},
+	} {
+		if _, err := stats.GetStats(context.Background(), req, now); !errors.Is(err, apperror.ErrInvalid) {
+			t.Errorf("GetStats(%+v) = %v, want invalid", req, err)
+		}
+	}
+}
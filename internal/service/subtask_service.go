@@ -0,0 +1,237 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Tomlord1122/todo-backend/internal/apperror"
+	"github.com/Tomlord1122/todo-backend/internal/authz"
+	"github.com/Tomlord1122/todo-backend/internal/domain"
+	"github.com/Tomlord1122/todo-backend/internal/logging"
+	"github.com/Tomlord1122/todo-backend/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+// maxSubtaskTitleLength bounds subtask titles.
+const maxSubtaskTitleLength = 200
+
+// CreateSubtaskRequest adds a subtask to a todo.
+type CreateSubtaskRequest struct {
+	Title     string `json:"title"`
+	Completed bool   `json:"completed"`
+}
+
+// UpdateSubtaskRequest changes a subtask; nil fields are left as they are.
+type UpdateSubtaskRequest struct {
+	Title     *string `json:"title"`
+	Completed *bool   `json:"completed"`
+}
+
+// SubtaskResponse is the representation of a Subtask returned by the service.
+type SubtaskResponse struct {
+	ID        uint   `json:"id"`
+	TodoID    uint   `json:"todo_id"`
+	Title     string `json:"title"`
+	Completed bool   `json:"completed"`
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+}
+
+// SubtaskService manages the subtasks of a todo. The todo's completion
+// percentage follows from them; see TodoResponse.SubtaskCompletion.
+type SubtaskService interface {
+	// List returns a todo's subtasks, oldest first.
+	List(ctx context.Context, todoID uint) ([]SubtaskResponse, error)
+	Create(ctx context.Context, todoID uint, req CreateSubtaskRequest) (*SubtaskResponse, error)
+	// Update renames or checks off a subtask.
+	Update(ctx context.Context, todoID, subtaskID uint, req UpdateSubtaskRequest) (*SubtaskResponse, error)
+	Delete(ctx context.Context, todoID, subtaskID uint) error
+}
+
+type subtaskService struct {
+	repo  repository.SubtaskRepository
+	todos repository.TodoRepository
+}
+
+// NewSubtaskService creates a new SubtaskService.
+func NewSubtaskService(repo repository.SubtaskRepository, todos repository.TodoRepository) SubtaskService {
+	return &subtaskService{repo: repo, todos: todos}
+}
+
+func toSubtaskResponse(subtask *domain.Subtask) SubtaskResponse {
+	return SubtaskResponse{
+		ID:        subtask.ID,
+		TodoID:    subtask.TodoID,
+		Title:     subtask.Title,
+		Completed: subtask.Completed,
+		CreatedAt: subtask.CreatedAt.Format(time.RFC3339),
+		UpdatedAt: subtask.UpdatedAt.Format(time.RFC3339),
+	}
+}
+
+// toSubtaskResponses converts subtasks, returning nil for none.
+func toSubtaskResponses(subtasks []domain.Subtask) []SubtaskResponse {
+	if len(subtasks) == 0 {
+		return nil
+	}
+	responses := make([]SubtaskResponse, 0, len(subtasks))
+	for i := range subtasks {
+		responses = append(responses, toSubtaskResponse(&subtasks[i]))
+	}
+	return responses
+}
+
+// subtaskCompletion returns the percentage of subtasks completed, rounded
+// down, or nil when there are none.
+func subtaskCompletion(subtasks []domain.Subtask) *int {
+	if len(subtasks) == 0 {
+		return nil
+	}
+	completed := 0
+	for _, subtask := range subtasks {
+		if subtask.Completed {
+			completed++
+		}
+	}
+	percent := completed * 100 / len(subtasks)
+	return &percent
+}
+
+func validateSubtaskTitle(title string) (string, error) {
+	title = strings.TrimSpace(title)
+	if title == "" {
+		return "", apperror.Invalidf("invalid subtask: title is required")
+	}
+	if len(title) > maxSubtaskTitleLength {
+		return "", apperror.Invalidf("invalid subtask: title must be at most %d bytes", maxSubtaskTitleLength)
+	}
+	return title, nil
+}
+
+// List implements SubtaskService.
+func (s *subtaskService) List(ctx context.Context, todoID uint) ([]SubtaskResponse, error) {
+	if err := s.checkTodo(ctx, todoID, "list subtasks"); err != nil {
+		return nil, err
+	}
+	subtasks, err := s.repo.FindByTodoID(todoID)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error fetching subtasks of todo", "todo_id", todoID, "err", err)
+		return nil, errors.New("failed to list subtasks")
+	}
+	if responses := toSubtaskResponses(subtasks); responses != nil {
+		return responses, nil
+	}
+	return []SubtaskResponse{}, nil
+}
+
+// Create implements SubtaskService.
+func (s *subtaskService) Create(ctx context.Context, todoID uint, req CreateSubtaskRequest) (*SubtaskResponse, error) {
+	if err := authz.Check(ctx, authz.WriteTodos); err != nil {
+		return nil, err
+	}
+	title, err := validateSubtaskTitle(req.Title)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkTodo(ctx, todoID, "create subtask"); err != nil {
+		return nil, err
+	}
+
+	subtask := &domain.Subtask{TodoID: todoID, Title: title, Completed: req.Completed}
+	if err := s.repo.Create(subtask); err != nil {
+		logging.FromContext(ctx).Error("Error creating subtask in repository", "err", err)
+		return nil, errors.New("failed to create subtask")
+	}
+	s.touchTodo(ctx, todoID)
+	response := toSubtaskResponse(subtask)
+	return &response, nil
+}
+
+// Update implements SubtaskService.
+func (s *subtaskService) Update(ctx context.Context, todoID, subtaskID uint, req UpdateSubtaskRequest) (*SubtaskResponse, error) {
+	if err := authz.Check(ctx, authz.WriteTodos); err != nil {
+		return nil, err
+	}
+	subtask, err := s.findSubtask(ctx, todoID, subtaskID, "update subtask")
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Title != nil {
+		title, err := validateSubtaskTitle(*req.Title)
+		if err != nil {
+			return nil, err
+		}
+		subtask.Title = title
+	}
+	if req.Completed != nil {
+		subtask.Completed = *req.Completed
+	}
+	if err := s.repo.Update(subtask); err != nil {
+		logging.FromContext(ctx).Error("Error updating subtask", "subtask_id", subtaskID, "err", err)
+		return nil, errors.New("failed to update subtask")
+	}
+	s.touchTodo(ctx, todoID)
+	response := toSubtaskResponse(subtask)
+	return &response, nil
+}
+
+// Delete implements SubtaskService.
+func (s *subtaskService) Delete(ctx context.Context, todoID, subtaskID uint) error {
+	if err := authz.Check(ctx, authz.WriteTodos); err != nil {
+		return err
+	}
+	if _, err := s.findSubtask(ctx, todoID, subtaskID, "delete subtask"); err != nil {
+		return err
+	}
+	if err := s.repo.Delete(subtaskID); err != nil {
+		logging.FromContext(ctx).Error("Error deleting subtask", "subtask_id", subtaskID, "err", err)
+		return errors.New("failed to delete subtask")
+	}
+	s.touchTodo(ctx, todoID)
+	return nil
+}
+
+// touchTodo moves a todo to its next version after its subtasks changed,
+// as the checklist does when its counts change, since the subtasks are
+// part of the todo's representation: clients holding its ETag or
+// Last-Modified learn it changed. The subtask change stands if this fails.
+func (s *subtaskService) touchTodo(ctx context.Context, todoID uint) {
+	todo, err := s.todos.FindByID(todoID)
+	if err == nil {
+		err = s.todos.Update(todo)
+	}
+	if err != nil {
+		logging.FromContext(ctx).Error("Error moving todo to a new version after its subtasks changed", "todo_id", todoID, "err", err)
+	}
+}
+
+// checkTodo reports whether the todo exists, describing failures with action.
+func (s *subtaskService) checkTodo(ctx context.Context, todoID uint, action string) error {
+	if _, err := s.todos.FindByID(todoID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return apperror.NotFoundf("todo with ID %d not found", todoID)
+		}
+		logging.FromContext(ctx).Error("Error fetching todo", "todo_id", todoID, "action", action, "err", err)
+		return fmt.Errorf("failed to %s", action)
+	}
+	return nil
+}
+
+// findSubtask loads a subtask of todoID. Subtasks of other todos are
+// reported as not found.
+func (s *subtaskService) findSubtask(ctx context.Context, todoID, subtaskID uint, action string) (*domain.Subtask, error) {
+	subtask, err := s.repo.FindByID(subtaskID)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && subtask.TodoID != todoID) {
+		return nil, apperror.NotFoundf("subtask with ID %d not found", subtaskID)
+	}
+	if err != nil {
+		logging.FromContext(ctx).Error("Error fetching subtask", "subtask_id", subtaskID, "action", action, "err", err)
+		return nil, fmt.Errorf("failed to %s", action)
+	}
+	return subtask, nil
+}
@@ -0,0 +1,77 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/Tomlord1122/todo-backend/internal/apperror"
+	"github.com/Tomlord1122/todo-backend/internal/logging"
+	"github.com/Tomlord1122/todo-backend/internal/suggest"
+)
+
+// SuggestTodoRequest holds the draft todo to get suggestions for
+type SuggestTodoRequest struct {
+	Title string `json:"title"`
+	// Timezone (IANA name) is used to resolve phrases like "tomorrow".
+	Timezone string `json:"timezone"`
+}
+
+// SuggestionResponse lists proposed metadata for a draft todo.
+type SuggestionResponse struct {
+	Tags     []string `json:"tags"`
+	Priority *string  `json:"priority,omitempty"`
+	DueDate  *string  `json:"due_date,omitempty"`
+	Reasons  []string `json:"reasons"`
+}
+
+// SuggestionService proposes tags, priority and due dates for todos.
+type SuggestionService interface {
+	Suggest(ctx context.Context, req SuggestTodoRequest) (*SuggestionResponse, error)
+}
+
+type suggestionService struct {
+	suggester suggest.Suggester
+}
+
+// NewSuggestionService creates a new SuggestionService backed by suggester.
+func NewSuggestionService(suggester suggest.Suggester) SuggestionService {
+	return &suggestionService{suggester: suggester}
+}
+
+// Suggest implements SuggestionService.
+func (s *suggestionService) Suggest(ctx context.Context, req SuggestTodoRequest) (*SuggestionResponse, error) {
+	if strings.TrimSpace(req.Title) == "" {
+		return nil, apperror.Invalidf("title cannot be empty")
+	}
+	loc := time.UTC
+	if req.Timezone != "" {
+		var err error
+		loc, err = time.LoadLocation(req.Timezone)
+		if err != nil {
+			return nil, apperror.Invalidf("invalid timezone %q", req.Timezone)
+		}
+	}
+
+	suggestion, err := s.suggester.Suggest(ctx, suggest.Input{Title: req.Title, Now: time.Now().In(loc)})
+	if err != nil {
+		logging.FromContext(ctx).Error("Error generating suggestions", "err", err)
+		return nil, errors.New("failed to generate suggestions")
+	}
+
+	response := &SuggestionResponse{
+		Tags:     suggestion.Tags,
+		Priority: suggestion.Priority,
+		DueDate:  formatOptionalTime(suggestion.DueDate),
+		Reasons:  suggestion.Reasons,
+	}
+	// Always render arrays, never null
+	if response.Tags == nil {
+		response.Tags = []string{}
+	}
+	if response.Reasons == nil {
+		response.Reasons = []string{}
+	}
+	return response, nil
+}
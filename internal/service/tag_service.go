@@ -0,0 +1,217 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Tomlord1122/todo-backend/internal/apperror"
+	"github.com/Tomlord1122/todo-backend/internal/authz"
+	"github.com/Tomlord1122/todo-backend/internal/domain"
+	"github.com/Tomlord1122/todo-backend/internal/logging"
+	"github.com/Tomlord1122/todo-backend/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+// ErrTagExists is returned when creating or renaming a tag to a name the
+// user already has.
+var ErrTagExists = apperror.New(apperror.CodeConflict, "a tag with this name already exists")
+
+// maxTagNameLength bounds tag names.
+const maxTagNameLength = 50
+
+// CreateTagRequest holds the data needed to create a tag
+type CreateTagRequest struct {
+	Name string `json:"name" validate:"notblank"`
+}
+
+// UpdateTagRequest renames a tag
+type UpdateTagRequest struct {
+	Name string `json:"name" validate:"notblank"`
+}
+
+// TagResponse is the representation of a Tag returned by the service.
+type TagResponse struct {
+	ID        uint   `json:"id"`
+	Name      string `json:"name"`
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+}
+
+// TagService manages a user's tags. Tags are also created on the fly when
+// a todo is tagged with a new name.
+type TagService interface {
+	CreateTag(ctx context.Context, userID uint, req CreateTagRequest) (*TagResponse, error)
+	// ListTags returns a user's tags, ordered by name
+	ListTags(ctx context.Context, userID uint) ([]TagResponse, error)
+	// ListTagsOfUsers returns the tags of several users in one lookup, by
+	// user, ordered by name
+	ListTagsOfUsers(ctx context.Context, userIDs []uint) (map[uint][]TagResponse, error)
+	GetTag(ctx context.Context, userID, id uint) (*TagResponse, error)
+	// UpdateTag renames a tag on every todo that has it
+	UpdateTag(ctx context.Context, userID, id uint, req UpdateTagRequest) (*TagResponse, error)
+	// DeleteTag deletes a tag and removes it from its todos
+	DeleteTag(ctx context.Context, userID, id uint) error
+}
+
+type tagService struct {
+	repo repository.TagRepository
+}
+
+// NewTagService creates a new TagService.
+func NewTagService(repo repository.TagRepository) TagService {
+	return &tagService{repo: repo}
+}
+
+func toTagResponse(tag *domain.Tag) TagResponse {
+	return TagResponse{
+		ID:        tag.ID,
+		Name:      tag.Name,
+		CreatedAt: tag.CreatedAt.Format(time.RFC3339),
+		UpdatedAt: tag.UpdatedAt.Format(time.RFC3339),
+	}
+}
+
+// normalizeTagName trims and lower-cases a tag name, so "Work" and "work "
+// are the same tag. Commas are rejected since ?tags= separates names with
+// them.
+func normalizeTagName(name string) (string, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" || len(name) > maxTagNameLength || strings.Contains(name, ",") {
+		return "", apperror.Invalidf("invalid tag %q, names must be 1 to %d characters without commas", name, maxTagNameLength)
+	}
+	return name, nil
+}
+
+// normalizeTagNames normalizes tag names and drops duplicates. The result
+// is sorted.
+func normalizeTagNames(names []string) ([]string, error) {
+	normalized := make([]string, 0, len(names))
+	for _, name := range names {
+		name, err := normalizeTagName(name)
+		if err != nil {
+			return nil, err
+		}
+		normalized = append(normalized, name)
+	}
+	slices.Sort(normalized)
+	return slices.Compact(normalized), nil
+}
+
+// CreateTag implements TagService.
+func (s *tagService) CreateTag(ctx context.Context, userID uint, req CreateTagRequest) (*TagResponse, error) {
+	if err := authz.Check(ctx, authz.WriteTodos); err != nil {
+		return nil, err
+	}
+	name, err := normalizeTagName(req.Name)
+	if err != nil {
+		return nil, err
+	}
+	tag := &domain.Tag{UserID: userID, Name: name}
+	if err := s.repo.Create(tag); err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return nil, ErrTagExists
+		}
+		logging.FromContext(ctx).Error("Error creating tag for user", "user_id", userID, "err", err)
+		return nil, errors.New("failed to create tag")
+	}
+	resp := toTagResponse(tag)
+	return &resp, nil
+}
+
+// ListTags implements TagService.
+func (s *tagService) ListTags(ctx context.Context, userID uint) ([]TagResponse, error) {
+	tags, err := s.repo.FindByUserID(userID)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error fetching tags of user", "user_id", userID, "err", err)
+		return nil, errors.New("failed to retrieve tags")
+	}
+	resp := make([]TagResponse, 0, len(tags))
+	for i := range tags {
+		resp = append(resp, toTagResponse(&tags[i]))
+	}
+	return resp, nil
+}
+
+// ListTagsOfUsers implements TagService.
+func (s *tagService) ListTagsOfUsers(ctx context.Context, userIDs []uint) (map[uint][]TagResponse, error) {
+	tags, err := s.repo.FindByUserIDs(userIDs)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error fetching tags of users", "user_ids", userIDs, "err", err)
+		return nil, errors.New("failed to retrieve tags")
+	}
+	resp := make(map[uint][]TagResponse, len(userIDs))
+	for i := range tags {
+		resp[tags[i].UserID] = append(resp[tags[i].UserID], toTagResponse(&tags[i]))
+	}
+	return resp, nil
+}
+
+// findOwnTag returns the tag if it belongs to userID. Other users' tags
+// are reported as not found.
+func (s *tagService) findOwnTag(ctx context.Context, userID, id uint) (*domain.Tag, error) {
+	tag, err := s.repo.FindByID(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && tag.UserID != userID) {
+		return nil, apperror.NotFoundf("tag with ID %d not found", id)
+	}
+	if err != nil {
+		logging.FromContext(ctx).Error("Error fetching tag", "tag_id", id, "err", err)
+		return nil, errors.New("failed to retrieve tag")
+	}
+	return tag, nil
+}
+
+// GetTag implements TagService.
+func (s *tagService) GetTag(ctx context.Context, userID, id uint) (*TagResponse, error) {
+	tag, err := s.findOwnTag(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	resp := toTagResponse(tag)
+	return &resp, nil
+}
+
+// UpdateTag implements TagService.
+func (s *tagService) UpdateTag(ctx context.Context, userID, id uint, req UpdateTagRequest) (*TagResponse, error) {
+	if err := authz.Check(ctx, authz.WriteTodos); err != nil {
+		return nil, err
+	}
+	name, err := normalizeTagName(req.Name)
+	if err != nil {
+		return nil, err
+	}
+	tag, err := s.findOwnTag(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if name != tag.Name {
+		tag.Name = name
+		if err := s.repo.Update(tag); err != nil {
+			if errors.Is(err, gorm.ErrDuplicatedKey) {
+				return nil, ErrTagExists
+			}
+			logging.FromContext(ctx).Error("Error renaming tag", "tag_id", id, "err", err)
+			return nil, errors.New("failed to update tag")
+		}
+	}
+	resp := toTagResponse(tag)
+	return &resp, nil
+}
+
+// DeleteTag implements TagService.
+func (s *tagService) DeleteTag(ctx context.Context, userID, id uint) error {
+	if err := authz.Check(ctx, authz.WriteTodos); err != nil {
+		return err
+	}
+	if _, err := s.findOwnTag(ctx, userID, id); err != nil {
+		return err
+	}
+	if err := s.repo.Delete(id); err != nil {
+		logging.FromContext(ctx).Error("Error deleting tag", "tag_id", id, "err", err)
+		return errors.New("failed to delete tag")
+	}
+	return nil
+}
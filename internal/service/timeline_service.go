@@ -0,0 +1,119 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	"github.com/Tomlord1122/todo-backend/internal/apperror"
+	"github.com/Tomlord1122/todo-backend/internal/domain"
+	"github.com/Tomlord1122/todo-backend/internal/logging"
+	"github.com/Tomlord1122/todo-backend/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+// TimelineResponse lays out a list for Gantt charts: one bar per todo and
+// an arrow per dependency.
+type TimelineResponse struct {
+	ListID       uint           `json:"list_id"`
+	Items        []TimelineItem `json:"items"`
+	Dependencies []TimelineEdge `json:"dependencies"`
+}
+
+// TimelineItem is a todo on the timeline. Start and due dates are optional;
+// scheduled todos come first, by start (or due) date.
+type TimelineItem struct {
+	ID        uint     `json:"id"`
+	Title     string   `json:"title"`
+	Completed bool     `json:"completed"`
+	Priority  string   `json:"priority"`
+	StartDate *string  `json:"start_date,omitempty"`
+	DueDate   *string  `json:"due_date,omitempty"`
+	Estimate  *float64 `json:"estimate,omitempty"`
+}
+
+// TimelineEdge means To can't start before From is done.
+type TimelineEdge struct {
+	From uint `json:"from"`
+	To   uint `json:"to"`
+}
+
+// TimelineService builds timeline views of lists.
+type TimelineService interface {
+	// GetTimeline returns the list's todos and the dependencies between
+	// them. Dependencies on todos outside the list are left out.
+	GetTimeline(ctx context.Context, listID uint) (*TimelineResponse, error)
+}
+
+type timelineService struct {
+	lists repository.ListRepository
+	todos repository.TodoRepository
+}
+
+// NewTimelineService creates a new TimelineService.
+func NewTimelineService(lists repository.ListRepository, todos repository.TodoRepository) TimelineService {
+	return &timelineService{lists: lists, todos: todos}
+}
+
+// GetTimeline implements TimelineService.
+func (s *timelineService) GetTimeline(ctx context.Context, listID uint) (*TimelineResponse, error) {
+	if _, err := s.lists.FindByID(listID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.NotFoundf("list with ID %d not found", listID)
+		}
+		logging.FromContext(ctx).Error("Error fetching list for timeline", "list_id", listID, "err", err)
+		return nil, errors.New("failed to build timeline")
+	}
+	todos, err := s.todos.FindByListID(listID)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error fetching todos of list", "list_id", listID, "err", err)
+		return nil, errors.New("failed to build timeline")
+	}
+	slices.SortStableFunc(todos, compareTimeline)
+
+	inList := make(map[uint]bool, len(todos))
+	for _, todo := range todos {
+		inList[todo.ID] = true
+	}
+	response := &TimelineResponse{ListID: listID, Items: make([]TimelineItem, 0, len(todos)), Dependencies: []TimelineEdge{}}
+	for i := range todos {
+		todo := &todos[i]
+		response.Items = append(response.Items, TimelineItem{
+			ID:        todo.ID,
+			Title:     todo.Title,
+			Completed: todo.Completed,
+			Priority:  todo.Priority,
+			StartDate: formatOptionalTime(todo.StartDate),
+			DueDate:   formatOptionalTime(todo.DueDate),
+			Estimate:  todo.Estimate,
+		})
+		for _, dependency := range todo.DependsOn {
+			if inList[dependency] {
+				response.Dependencies = append(response.Dependencies, TimelineEdge{From: dependency, To: todo.ID})
+			}
+		}
+	}
+	return response, nil
+}
+
+// compareTimeline orders todos by start date, falling back to the due date;
+// unscheduled todos go last.
+func compareTimeline(a, b domain.Todo) int {
+	aStart, bStart := a.StartDate, b.StartDate
+	if aStart == nil {
+		aStart = a.DueDate
+	}
+	if bStart == nil {
+		bStart = b.DueDate
+	}
+	switch {
+	case aStart == nil && bStart == nil:
+		return 0
+	case aStart == nil:
+		return 1
+	case bStart == nil:
+		return -1
+	}
+	return aStart.Compare(*bStart)
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Tomlord1122/todo-backend/internal/apperror"
+	"github.com/Tomlord1122/todo-backend/internal/domain"
+	"github.com/Tomlord1122/todo-backend/internal/notify"
+	"github.com/Tomlord1122/todo-backend/internal/pagination"
+	"github.com/Tomlord1122/todo-backend/internal/realtime"
+	"github.com/Tomlord1122/todo-backend/internal/repository"
+	"github.com/Tomlord1122/todo-backend/internal/suggest"
+)
+
+func TestTimeline(t *testing.T) {
+	repos := repository.NewMemoryRepositories()
+	timeline := NewTimelineService(repos.Lists, repos.Todos)
+	list := &domain.List{Name: "Move house", UserID: 1}
+	if err := repos.Lists.Create(list); err != nil {
+		t.Fatal(err)
+	}
+	day := func(d int) *time.Time {
+		date := time.Date(2025, 3, d, 0, 0, 0, 0, time.UTC)
+		return &date
+	}
+	outside := &domain.Todo{Title: "Get a quote", UserID: 1}
+	if err := repos.Todos.Create(outside); err != nil {
+		t.Fatal(err)
+	}
+	pack := &domain.Todo{Title: "Pack", UserID: 1, ListID: &list.ID, StartDate: day(3), DueDate: day(9)}
+	book := &domain.Todo{Title: "Book movers", UserID: 1, ListID: &list.ID, DueDate: day(2), DependsOn: []uint{outside.ID}}
+	clean := &domain.Todo{Title: "Clean", UserID: 1, ListID: &list.ID}
+	for _, todo := range []*domain.Todo{clean, pack, book} {
+		if err := repos.Todos.Create(todo); err != nil {
+			t.Fatal(err)
+		}
+	}
+	pack.DependsOn = []uint{book.ID}
+	clean.DependsOn = []uint{pack.ID}
+	for _, todo := range []*domain.Todo{pack, clean} {
+		if err := repos.Todos.Update(todo); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := timeline.GetTimeline(context.Background(), list.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Scheduled todos first by start, else due, date; unscheduled last
+	var titles []string
+	for _, item := range got.Items {
+		titles = append(titles, item.Title)
+	}
+	if want := []string{"Book movers", "Pack", "Clean"}; !reflect.DeepEqual(titles, want) {
+		t.Errorf("Items = %v, want %v", titles, want)
+	}
+	// The dependency on a todo outside the list is left out
+	wantEdges := []TimelineEdge{{From: book.ID, To: pack.ID}, {From: pack.ID, To: clean.ID}}
+	if !reflect.DeepEqual(got.Dependencies, wantEdges) {
+		t.Errorf("Dependencies = %+v, want %+v", got.Dependencies, wantEdges)
+	}
+
+	if _, err := timeline.GetTimeline(context.Background(), 999); !errors.Is(err, apperror.ErrNotFound) {
+		t.Errorf("GetTimeline of a missing list = %v, want not found", err)
+	}
+}
+
+func TestStartDateMustNotBeAfterDueDate(t *testing.T) {
+	repos := repository.NewMemoryRepositories()
+	follow := NewFollowService(repos.Watchers, repos.Todos, repos.Preferences, repos.Users, notify.NewRegistry())
+	todos := NewTodoService(repos.Todos, repos.Tags, repos.Preferences, repos.Activities,
+		suggest.NewKeywordSuggester(suggest.DefaultKeywordRules()), realtime.NewHub(), follow, TodoConfig{Limits: pagination.DefaultConfig(), FuzzyThreshold: 0.3})
+	ctx := context.Background()
+	early := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	late := time.Date(2025, 3, 9, 0, 0, 0, 0, time.UTC)
+
+	if _, err := todos.CreateTodo(ctx, CreateTodoRequest{Title: "Pack", UserID: 1, StartDate: &late, DueDate: &early}); !errors.Is(err, apperror.ErrInvalid) {
+		t.Errorf("CreateTodo starting after it's due = %v, want invalid", err)
+	}
+	// Starting on the due date is fine
+	todo, err := todos.CreateTodo(ctx, CreateTodoRequest{Title: "Pack", UserID: 1, StartDate: &early, DueDate: &early})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// An update is checked against the dates it leaves in place
+	if _, err := todos.UpdateTodo(ctx, todo.ID, UpdateTodoRequest{StartDate: &late}); !errors.Is(err, apperror.ErrInvalid) {
+		t.Errorf("UpdateTodo moving the start after the due date = %v, want invalid", err)
+	}
+	if _, err := todos.UpdateTodo(ctx, todo.ID, UpdateTodoRequest{StartDate: &late, DueDate: &late}); err != nil {
+		t.Errorf("UpdateTodo moving both dates = %v", err)
+	}
+}
@@ -0,0 +1,183 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Tomlord1122/todo-backend/internal/apperror"
+	"github.com/Tomlord1122/todo-backend/internal/authz"
+	"github.com/Tomlord1122/todo-backend/internal/domain"
+	"github.com/Tomlord1122/todo-backend/internal/jsonpatch"
+	"github.com/Tomlord1122/todo-backend/internal/logging"
+	"github.com/Tomlord1122/todo-backend/internal/tracing"
+
+	"gorm.io/gorm"
+)
+
+// todoDocument is the editable part of a todo that patches apply to. Its
+// members are those of UpdateTodoRequest and all of them are present, set
+// to null when empty, so JSON Patch paths like /due_date always resolve.
+type todoDocument struct {
+	Title       string           `json:"title"`
+	Description string           `json:"description"`
+	Completed   bool             `json:"completed"`
+	Priority    string           `json:"priority"`
+	ListID      *uint            `json:"list_id"`
+	DueDate     *time.Time       `json:"due_date"`
+	StartDate   *time.Time       `json:"start_date"`
+	Location    *LocationRequest `json:"location"`
+	DependsOn   []uint           `json:"depends_on"`
+	Estimate    *float64         `json:"estimate"`
+	Tags        []string         `json:"tags"`
+	Recurrence  string           `json:"recurrence"`
+}
+
+func newTodoDocument(todo *domain.Todo) todoDocument {
+	doc := todoDocument{
+		Title:       todo.Title,
+		Description: todo.Description,
+		Completed:   todo.Completed,
+		Priority:    todo.Priority,
+		ListID:      todo.ListID,
+		DueDate:     todo.DueDate,
+		StartDate:   todo.StartDate,
+		DependsOn:   append([]uint{}, todo.DependsOn...),
+		Estimate:    todo.Estimate,
+		Tags:        append([]string{}, tagNames(todo.Tags)...),
+		Recurrence:  todo.Recurrence,
+	}
+	if todo.Latitude != nil && todo.Longitude != nil {
+		doc.Location = &LocationRequest{Latitude: todo.Latitude, Longitude: todo.Longitude, RadiusMeters: todo.RadiusMeters}
+	}
+	return doc
+}
+
+// patchRemovals are the values that removing a member of the todo document
+// stands for in an UpdateTodoRequest. Members not listed can't be removed.
+var patchRemovals = map[string]json.RawMessage{
+	"description": json.RawMessage(`""`),
+	"list_id":     json.RawMessage(`0`),
+	"location":    json.RawMessage(`{}`),
+	"depends_on":  json.RawMessage(`[]`),
+	"estimate":    json.RawMessage(`0`),
+	"tags":        json.RawMessage(`[]`),
+	"recurrence":  json.RawMessage(`""`),
+}
+
+// PatchTodo implements TodoService.
+func (s *todoService) PatchTodo(ctx context.Context, id, version uint, mediaType string, patch []byte) (*TodoResponse, error) {
+	ctx, span := tracing.Start(ctx, "TodoService.PatchTodo")
+	defer span.End()
+	if err := authz.Check(ctx, authz.WriteTodos); err != nil {
+		return nil, err
+	}
+	todo, err := s.todoRepo(ctx).FindByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.NotFoundf("todo with ID %d not found for update", id)
+		}
+		logging.FromContext(ctx).Error("Error fetching todo for patch", "todo_id", id, "err", err)
+		return nil, errors.New("failed to retrieve todo item for update")
+	}
+	if version != 0 && version != todo.Version {
+		return nil, ErrTodoChanged
+	}
+
+	doc, err := json.Marshal(newTodoDocument(todo))
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode todo %d for patch: %w", id, err)
+	}
+	var patched []byte
+	switch mediaType {
+	case jsonpatch.MergePatchType:
+		patched, err = jsonpatch.Merge(doc, patch)
+	case jsonpatch.PatchType:
+		patched, err = jsonpatch.Apply(doc, patch)
+	default:
+		return nil, apperror.Invalidf("invalid patch: unsupported media type %q", mediaType)
+	}
+	if errors.Is(err, jsonpatch.ErrTestFailed) {
+		// The patch is valid, the todo just isn't in the state it expects
+		return nil, apperror.Conflictf("%w", err)
+	}
+	if err != nil {
+		return nil, apperror.Invalidf("invalid patch: %w", err)
+	}
+
+	req, err := patchRequest(doc, patched)
+	if err != nil {
+		return nil, err
+	}
+	// The patched document is validated like any update before it's saved,
+	// and only over the version the patch was applied to
+	req.Version = todo.Version
+	return s.UpdateTodo(ctx, id, req)
+}
+
+// patchRequest turns the members changed between the todo document before
+// and after a patch into an UpdateTodoRequest.
+func patchRequest(before, after []byte) (UpdateTodoRequest, error) {
+	var original map[string]json.RawMessage
+	if err := json.Unmarshal(before, &original); err != nil {
+		return UpdateTodoRequest{}, fmt.Errorf("failed to decode todo document: %w", err)
+	}
+	var patched map[string]json.RawMessage
+	if err := json.Unmarshal(after, &patched); err != nil {
+		return UpdateTodoRequest{}, apperror.Invalidf("invalid patch: the result must be a JSON object")
+	}
+	for name := range patched {
+		if _, ok := original[name]; !ok {
+			return UpdateTodoRequest{}, apperror.Invalidf("invalid patch: unknown member %q", name)
+		}
+	}
+
+	changes := make(map[string]json.RawMessage)
+	for _, name := range slices.Sorted(maps.Keys(original)) {
+		value, ok := patched[name]
+		if ok && sameJSON(original[name], value) {
+			continue
+		}
+		if !ok || string(value) == "null" {
+			removal, ok := patchRemovals[name]
+			if !ok {
+				return UpdateTodoRequest{}, apperror.Invalidf("invalid patch: %s can't be removed", name)
+			}
+			value = removal
+		}
+		changes[name] = value
+	}
+
+	body, err := json.Marshal(changes)
+	if err != nil {
+		return UpdateTodoRequest{}, fmt.Errorf("failed to encode patched todo: %w", err)
+	}
+	var req UpdateTodoRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			return UpdateTodoRequest{}, apperror.Invalidf("invalid patch: invalid value for %s", typeErr.Field)
+		}
+		return UpdateTodoRequest{}, apperror.Invalidf("invalid patch: %s", strings.TrimPrefix(err.Error(), "json: "))
+	}
+	// UpdateTodo ignores empty titles, but a patch asks for one explicitly
+	if req.Title != nil && strings.TrimSpace(*req.Title) == "" {
+		return UpdateTodoRequest{}, apperror.Invalidf("title cannot be empty")
+	}
+	return req, nil
+}
+
+// sameJSON reports whether two JSON values are equal, ignoring formatting.
+func sameJSON(a, b json.RawMessage) bool {
+	var x, y any
+	if json.Unmarshal(a, &x) != nil || json.Unmarshal(b, &y) != nil {
+		return false
+	}
+	return reflect.DeepEqual(x, y)
+}
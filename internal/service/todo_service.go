@@ -2,42 +2,318 @@ package service
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/Tomlord1122/todo-backend/internal/apperror"
+	"github.com/Tomlord1122/todo-backend/internal/authz"
 	"github.com/Tomlord1122/todo-backend/internal/domain"
+	"github.com/Tomlord1122/todo-backend/internal/events"
+	"github.com/Tomlord1122/todo-backend/internal/geo"
+	"github.com/Tomlord1122/todo-backend/internal/logging"
+	"github.com/Tomlord1122/todo-backend/internal/pagination"
+	"github.com/Tomlord1122/todo-backend/internal/realtime"
 	"github.com/Tomlord1122/todo-backend/internal/repository"
+	"github.com/Tomlord1122/todo-backend/internal/rrule"
+	"github.com/Tomlord1122/todo-backend/internal/suggest"
+	"github.com/Tomlord1122/todo-backend/internal/tracing"
 
 	"gorm.io/gorm"
 )
 
+// ErrTodoChanged is returned by writes that expect a version of a todo when
+// it has been saved since, by the client's expected version or concurrently.
+var ErrTodoChanged = apperror.New(apperror.CodePreconditionFailed, "todo has been changed since it was read")
+
 // Input/Output Structs (Data Transfer Objects - DTOs)
 // It's often good practice to use DTOs for input/output to decouple
 // the service layer from the HTTP layer and the database layer.
 
 // CreateTodoRequest holds the data needed to create a new todo
 type CreateTodoRequest struct {
-	Title  string `json:"title" validate:"required"`
-	UserID uint   `json:"user_id"`
+	Title       string     `json:"title" validate:"notblank,max=255"`
+	Description string     `json:"description"`
+	UserID      uint       `json:"user_id"`
+	Priority    string     `json:"priority" validate:"omitempty,oneof=low normal high"`
+	ListID      *uint      `json:"list_id"`
+	DueDate     *time.Time `json:"due_date"`
+	// StartDate must not be after DueDate
+	StartDate *time.Time       `json:"start_date"`
+	Location  *LocationRequest `json:"location"`
+	// DependsOn lists todos that must be done first
+	DependsOn []uint `json:"depends_on"`
+	// Estimate is the expected effort in minutes or points; use one unit
+	// consistently within a list so burndown charts add up
+	Estimate *float64 `json:"estimate" validate:"omitnil,gte=0"`
+	// Tags are tag names; tags the user doesn't have yet are created
+	Tags []string `json:"tags"`
+	// Recurrence repeats the todo, as an iCalendar RRULE such as
+	// "FREQ=WEEKLY;BYDAY=MO"; it needs a due date
+	Recurrence string `json:"recurrence"`
+}
+
+// LocationRequest places a todo. Latitude and longitude are required
+// together; the radius defaults to 100 meters. In an update, an empty
+// object removes the location.
+type LocationRequest struct {
+	Latitude     *float64 `json:"latitude"`
+	Longitude    *float64 `json:"longitude"`
+	RadiusMeters *float64 `json:"radius_meters"`
 }
 
 // UpdateTodoRequest holds the data for updating an existing todo.
 // Using pointers allows distinguishing between a field being omitted
 // vs. being set to its zero value (e.g., setting Completed to false).
 type UpdateTodoRequest struct {
-	Title     *string `json:"title"`
-	Completed *bool   `json:"completed"`
+	Title       *string          `json:"title" validate:"omitnil,notblank,max=255"`
+	Description *string          `json:"description"`
+	Completed   *bool            `json:"completed"`
+	Priority    *string          `json:"priority" validate:"omitnil,oneof=low normal high"`
+	ListID      *uint            `json:"list_id"`
+	DueDate     *time.Time       `json:"due_date"`
+	StartDate   *time.Time       `json:"start_date"`
+	Location    *LocationRequest `json:"location"`
+	// DependsOn replaces the dependencies; an empty list removes them
+	DependsOn *[]uint `json:"depends_on"`
+	// Estimate 0 removes the estimate
+	Estimate *float64 `json:"estimate" validate:"omitnil,gte=0"`
+	// Tags replaces the tags, by name; an empty list removes them
+	Tags *[]string `json:"tags"`
+	// Recurrence replaces the RRULE; an empty string stops the repeats
+	Recurrence *string `json:"recurrence"`
+	// Version, when set, only lets the update apply to this version of the
+	// todo, the one the client read
+	Version uint `json:"-"`
 }
 
 // TodoResponse is the standard representation of a Todo returned by the service.
 type TodoResponse struct {
-	ID        uint   `json:"id"`
-	Title     string `json:"title"`
-	Completed bool   `json:"completed"`
-	UserID    uint   `json:"user_id"` // Include relevant fields
-	CreatedAt string `json:"created_at"`
-	UpdatedAt string `json:"updated_at"`
+	ID          uint   `json:"id"`
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	// DescriptionHTML is the Description rendered from Markdown, only set
+	// when asked for with ?render=html
+	DescriptionHTML string        `json:"description_html,omitempty"`
+	Completed       bool          `json:"completed"`
+	Priority        string        `json:"priority"`
+	UserID          uint          `json:"user_id"` // Include relevant fields
+	ListID          *uint         `json:"list_id,omitempty"`
+	DueDate         *string       `json:"due_date,omitempty"`
+	StartDate       *string       `json:"start_date,omitempty"`
+	DependsOn       []uint        `json:"depends_on,omitempty"`
+	CompletedAt     *string       `json:"completed_at,omitempty"`
+	CreatedAt       string        `json:"created_at"`
+	UpdatedAt       string        `json:"updated_at"`
+	Location        *TodoLocation `json:"location,omitempty"`
+	Estimate        *float64      `json:"estimate,omitempty"`
+	Tags            []string      `json:"tags,omitempty"`
+	Recurrence      string        `json:"recurrence,omitempty"`
+	// NextOccurrence is the due date of the next instance of a completed
+	// recurring todo, until the instance is created
+	NextOccurrence *string `json:"next_occurrence,omitempty"`
+	// ChecklistCompletion is the percentage of checklist items done; it's
+	// omitted when the todo has no checklist
+	ChecklistCompletion *int `json:"checklist_completion,omitempty"`
+	// Subtasks are only included when a single todo is returned, together
+	// with SubtaskCompletion, the percentage of them completed
+	Subtasks          []SubtaskResponse `json:"subtasks,omitempty"`
+	SubtaskCompletion *int              `json:"subtask_completion,omitempty"`
+	// Reactions counts emoji reactions, most used first
+	Reactions []ReactionCount `json:"reactions,omitempty"`
+	// DeletedAt is only set on deleted todos, which are listed on request
+	DeletedAt *string `json:"deleted_at,omitempty"`
+	// Version is sent as the ETag header rather than in the body
+	Version uint `json:"-"`
+}
+
+// TodoLocation is where a todo applies.
+type TodoLocation struct {
+	Latitude     float64 `json:"latitude"`
+	Longitude    float64 `json:"longitude"`
+	RadiusMeters float64 `json:"radius_meters"`
+}
+
+// NearbyTodosRequest holds the parameters of a location search.
+type NearbyTodosRequest struct {
+	Latitude  float64
+	Longitude float64
+	// RadiusMeters matches todos within this distance; zero matches todos
+	// whose own radius contains the point instead
+	RadiusMeters float64
+	Limit        int
+	// UserID keeps only the todos of this user when set
+	UserID uint
+}
+
+// NearbyTodoResult is a todo found by location.
+type NearbyTodoResult struct {
+	TodoResponse
+	DistanceMeters float64 `json:"distance_meters"`
+}
+
+// SearchTodosRequest holds the parameters of a search. By default the
+// query is full-text searched in titles and descriptions.
+type SearchTodosRequest struct {
+	Query string
+	// Fuzzy ranks titles by trigram similarity so misspelled queries still match
+	Fuzzy bool
+	// Threshold is the minimum similarity (0-1) for fuzzy matches; nil uses the configured default
+	Threshold *float64
+	Page      PageRequest
+	// UserID keeps only the todos of this user when set
+	UserID uint
+}
+
+// TodoSearchResult is a todo matched by a search.
+type TodoSearchResult struct {
+	TodoResponse
+	// Score is the relevance of the match, higher is closer
+	Score float64 `json:"score,omitempty"`
+}
+
+// TodoFilter narrows and orders GetAllTodos.
+type TodoFilter struct {
+	// DeletedSince includes todos deleted after this time, with DeletedAt
+	// set, so sync clients learn what disappeared. Nil excludes them.
+	DeletedSince *time.Time
+	// Completed keeps only completed (true) or open (false) todos
+	Completed *bool
+	// UserID keeps only the todos of this user
+	UserID *uint
+	// DueBefore and DueAfter keep only todos due strictly before or after
+	// a time; todos without a due date match neither
+	DueBefore *time.Time
+	DueAfter  *time.Time
+	// Overdue keeps only open todos due before today in the time zone of
+	// UserID, the same todos ListOverdue returns
+	Overdue bool
+	// Tags keeps only todos with every one of these tag names
+	Tags []string
+	// Sort is a comma-separated list of todoSortFields, each optionally
+	// prefixed with - for descending, e.g. "-priority,due_date". Todos
+	// are ordered by ID by default and on ties.
+	Sort string
+}
+
+// todoSortFields are the fields GetAllTodos can sort by.
+var todoSortFields = []string{"id", "title", "completed", "priority", "due_date", "created_at", "updated_at"}
+
+// parseTodoSort validates a TodoFilter.Sort.
+func parseTodoSort(sort string) ([]repository.TodoSort, error) {
+	if sort == "" {
+		return nil, nil
+	}
+	var sorts []repository.TodoSort
+	for _, field := range strings.Split(sort, ",") {
+		field = strings.TrimSpace(field)
+		desc := strings.HasPrefix(field, "-")
+		field = strings.TrimPrefix(field, "-")
+		if !slices.Contains(todoSortFields, field) {
+			return nil, apperror.Invalidf("invalid sort field %q, expected one of %s", field, strings.Join(todoSortFields, ", "))
+		}
+		if slices.ContainsFunc(sorts, func(s repository.TodoSort) bool { return s.Field == field }) {
+			return nil, apperror.Invalidf("invalid sort, %s is listed twice", field)
+		}
+		sorts = append(sorts, repository.TodoSort{Field: field, Desc: desc})
+	}
+	return sorts, nil
+}
+
+// toTodoResponse converts a domain model into the response DTO.
+func toTodoResponse(todo *domain.Todo) TodoResponse {
+	return TodoResponse{
+		ID:                  todo.ID,
+		Title:               todo.Title,
+		Description:         todo.Description,
+		Completed:           todo.Completed,
+		Priority:            todo.Priority,
+		UserID:              todo.UserID,
+		ListID:              todo.ListID,
+		DueDate:             formatOptionalTime(todo.DueDate),
+		StartDate:           formatOptionalTime(todo.StartDate),
+		DependsOn:           todo.DependsOn,
+		CompletedAt:         formatOptionalTime(todo.CompletedAt),
+		CreatedAt:           todo.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:           todo.UpdatedAt.Format(time.RFC3339),
+		Location:            toTodoLocation(todo),
+		Estimate:            todo.Estimate,
+		Tags:                tagNames(todo.Tags),
+		Recurrence:          todo.Recurrence,
+		NextOccurrence:      formatOptionalTime(todo.NextOccurrence),
+		ChecklistCompletion: checklistCompletion(todo),
+		Subtasks:            toSubtaskResponses(todo.Subtasks),
+		SubtaskCompletion:   subtaskCompletion(todo.Subtasks),
+		Reactions:           toReactionCounts(todo.ReactionCounts),
+		DeletedAt:           formatDeletedAt(todo.DeletedAt),
+		Version:             todo.Version,
+	}
+}
+
+// tagNames returns the names of tags, or nil for none.
+func tagNames(tags []domain.Tag) []string {
+	var names []string
+	for _, tag := range tags {
+		names = append(names, tag.Name)
+	}
+	return names
+}
+
+// toTodoLocation returns the todo's location, or nil if it has none.
+func toTodoLocation(todo *domain.Todo) *TodoLocation {
+	if todo.Latitude == nil || todo.Longitude == nil {
+		return nil
+	}
+	location := &TodoLocation{Latitude: *todo.Latitude, Longitude: *todo.Longitude, RadiusMeters: geo.DefaultRadius}
+	if todo.RadiusMeters != nil {
+		location.RadiusMeters = *todo.RadiusMeters
+	}
+	return location
+}
+
+// applyLocation validates req and sets or, for an empty request, clears the
+// todo's location.
+func applyLocation(todo *domain.Todo, req *LocationRequest) error {
+	if req.Latitude == nil && req.Longitude == nil && req.RadiusMeters == nil {
+		todo.Latitude, todo.Longitude, todo.RadiusMeters = nil, nil, nil
+		return nil
+	}
+	if req.Latitude == nil || req.Longitude == nil {
+		return apperror.Invalidf("invalid location: latitude and longitude are both required")
+	}
+	if err := geo.Validate(*req.Latitude, *req.Longitude); err != nil {
+		return apperror.Invalidf("invalid location: %w", err)
+	}
+	radius := geo.DefaultRadius
+	if req.RadiusMeters != nil {
+		if err := geo.ValidateRadius(*req.RadiusMeters); err != nil {
+			return apperror.Invalidf("invalid location: %w", err)
+		}
+		radius = *req.RadiusMeters
+	}
+	todo.Latitude, todo.Longitude, todo.RadiusMeters = req.Latitude, req.Longitude, &radius
+	return nil
+}
+
+// formatOptionalTime renders a nullable timestamp as RFC3339, keeping nil as nil.
+func formatOptionalTime(t *time.Time) *string {
+	if t == nil {
+		return nil
+	}
+	formatted := t.Format(time.RFC3339)
+	return &formatted
+}
+
+// formatDeletedAt renders a soft-delete timestamp, nil for live rows.
+func formatDeletedAt(deletedAt gorm.DeletedAt) *string {
+	if !deletedAt.Valid {
+		return nil
+	}
+	return formatOptionalTime(&deletedAt.Time)
 }
 
 // --- Service Interface ---
@@ -51,15 +327,54 @@ type TodoService interface {
 	// GetTodoByID retrieves a single todo item by its ID.
 	GetTodoByID(ctx context.Context, id uint) (*TodoResponse, error)
 
-	// GetAllTodos retrieves a list of all todo items.
-	// Consider adding filtering/pagination parameters here later.
-	GetAllTodos(ctx context.Context) ([]TodoResponse, error)
+	// GetAllTodos retrieves one page of todo items matching filter.
+	GetAllTodos(ctx context.Context, page PageRequest, filter TodoFilter) ([]TodoResponse, *PageInfo, error)
+
+	// SearchTodos finds a page of todos by title and description, most
+	// relevant first.
+	SearchTodos(ctx context.Context, req SearchTodosRequest) ([]TodoSearchResult, *PageInfo, error)
+
+	// FindNearbyTodos finds open todos by location, closest first.
+	FindNearbyTodos(ctx context.Context, req NearbyTodosRequest) ([]NearbyTodoResult, error)
 
 	// UpdateTodo handles updating an existing todo item.
 	UpdateTodo(ctx context.Context, id uint, req UpdateTodoRequest) (*TodoResponse, error)
 
-	// DeleteTodo handles deleting a todo item by its ID.
-	DeleteTodo(ctx context.Context, id uint) error
+	// PatchTodo updates a todo with a JSON Merge Patch or a JSON Patch, as
+	// told by mediaType, applied to its editable fields: the members of
+	// UpdateTodoRequest. The result is validated like an update.
+	// The patch applies to the given version of the todo, or the current
+	// one when version is 0.
+	PatchTodo(ctx context.Context, id, version uint, mediaType string, patch []byte) (*TodoResponse, error)
+
+	// DeleteTodo handles deleting a todo item by its ID. Deleted todos go
+	// to the trash, from where they can be restored or purged. A version
+	// other than 0 only deletes that version of the todo.
+	DeleteTodo(ctx context.Context, id, version uint) error
+
+	// ListTrash retrieves a user's deleted todos, most recently deleted first.
+	ListTrash(ctx context.Context, userID uint) ([]TodoResponse, error)
+
+	// GetTrashedTodo retrieves a deleted todo by its ID.
+	GetTrashedTodo(ctx context.Context, id uint) (*TodoResponse, error)
+
+	// RestoreTodo takes a todo out of the trash.
+	RestoreTodo(ctx context.Context, id uint) (*TodoResponse, error)
+
+	// PurgeTodo permanently deletes a todo in the trash.
+	PurgeTodo(ctx context.Context, id uint) error
+
+	// BulkCreateTodos creates several todos in one transaction. Items that
+	// fail validation are reported in their result and left out.
+	BulkCreateTodos(ctx context.Context, reqs []CreateTodoRequest) ([]BulkResult, error)
+
+	// BulkUpdateTodos updates several todos of owner, or of any user when
+	// owner is 0, in one transaction.
+	BulkUpdateTodos(ctx context.Context, owner uint, items []BulkUpdateItem) ([]BulkResult, error)
+
+	// BulkDeleteTodos moves several todos of owner, or of any user when
+	// owner is 0, to the trash at once.
+	BulkDeleteTodos(ctx context.Context, owner uint, ids []uint) ([]BulkResult, error)
 }
 
 // --- Service Implementation ---
@@ -67,194 +382,925 @@ type TodoService interface {
 // todoService implements the TodoService interface.
 // It depends on a TodoRepository to interact with the data layer.
 type todoService struct {
-	repo repository.TodoRepository // Dependency on the repository interface
+	repo       repository.TodoRepository // Dependency on the repository interface
+	tags       repository.TagRepository
+	prefs      repository.PreferenceRepository
+	activities repository.ActivityRepository
+	suggester  suggest.Suggester
+	events     realtime.Publisher
+	followers  FollowerNotifier
+	cfg        TodoConfig
+}
+
+// TodoConfig holds the todo service settings.
+type TodoConfig struct {
+	// Limits bounds page sizes
+	Limits pagination.Config
+	// FuzzyThreshold is the default minimum similarity for fuzzy search
+	FuzzyThreshold float64
 }
 
 // NewTodoService creates a new instance of todoService.
 // It takes a TodoRepository as a dependency (Dependency Injection).
-func NewTodoService(repo repository.TodoRepository) TodoService {
+// prefs and suggester are used to auto-apply suggestions for users who
+// opted in; pass nil for either to disable that. Changes are published to
+// events and reported to followers, either of which may be nil; their
+// domain events are added to the outbox of repo.
+func NewTodoService(repo repository.TodoRepository, tags repository.TagRepository, prefs repository.PreferenceRepository, activities repository.ActivityRepository, suggester suggest.Suggester, events realtime.Publisher, followers FollowerNotifier, cfg TodoConfig) TodoService {
 	// We return the interface type, hiding the implementation detail.
 	return &todoService{
-		repo: repo,
+		repo:       repo,
+		tags:       tags,
+		prefs:      prefs,
+		activities: activities,
+		suggester:  suggester,
+		events:     events,
+		followers:  followers,
+		cfg:        cfg,
 	}
 }
 
+// validPriority reports whether p is one of the supported priority levels.
+func validPriority(p string) bool {
+	switch p {
+	case suggest.PriorityLow, suggest.PriorityNormal, suggest.PriorityHigh:
+		return true
+	}
+	return false
+}
+
 // --- Method Implementations ---
 
+// todoRepo returns the todo repository running its queries with ctx, so
+// they show up in the request's trace.
+func (s *todoService) todoRepo(ctx context.Context) repository.TodoRepository {
+	return s.repo.WithContext(ctx)
+}
+
 // CreateTodo implements the logic to create a new todo.
 func (s *todoService) CreateTodo(ctx context.Context, req CreateTodoRequest) (*TodoResponse, error) {
+	ctx, span := tracing.Start(ctx, "TodoService.CreateTodo")
+	defer span.End()
+	if err := authz.Check(ctx, authz.WriteTodos); err != nil {
+		return nil, err
+	}
+	newTodo, err := s.newTodo(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	// 3. Call Repository to save the new todo, with its domain event
+	err = s.todoRepo(ctx).Transaction(func(todos repository.TodoRepository, outbox repository.OutboxRepository) error {
+		if err := todos.Create(newTodo); err != nil { // Pass the domain model to the repository
+			return err
+		}
+		return addEvent(ctx, outbox, events.TodoCreated, newTodo, toTodoResponse(newTodo))
+	})
+	if err != nil {
+		// Log the error internally
+		logging.FromContext(ctx).Error("Error creating todo in repository", "err", err)
+		// Return a more generic error to the caller (handler)
+		return nil, errors.New("failed to create todo item")
+	}
+
+	// 4. Convert the created domain model to a response DTO
+	// GORM populates the ID and timestamps after creation
+	response := s.created(ctx, newTodo)
+	return &response, nil
+}
+
+// newTodo validates req and prepares the todo it creates.
+func (s *todoService) newTodo(ctx context.Context, req CreateTodoRequest) (*domain.Todo, error) {
 	// 1. Business Logic/Validation (Example: Check for empty title, although often done in handler/validation middleware)
 	if req.Title == "" {
 		// In a real app, input validation might happen earlier (e.g., in the handler)
 		// using a validation library. But some core business rules might live here.
-		return nil, errors.New("title cannot be empty")
+		return nil, apperror.Invalidf("title cannot be empty")
+	}
+	if req.Priority != "" && !validPriority(req.Priority) {
+		return nil, apperror.Invalidf("priority must be low, normal or high")
+	}
+	if req.Estimate != nil && *req.Estimate < 0 {
+		return nil, apperror.Invalidf("invalid estimate: must not be negative")
 	}
 
 	// 2. Prepare domain model
 	newTodo := &domain.Todo{
-		Title:     req.Title,
-		Completed: false,      // Default value
-		UserID:    req.UserID, // Assign user ID if provided
+		Title:       req.Title,
+		Description: req.Description,
+		Completed:   false, // Default value
+		Priority:    req.Priority,
+		UserID:      req.UserID, // Assign user ID if provided
+		ListID:      req.ListID,
+		DueDate:     req.DueDate,
+		StartDate:   req.StartDate,
+		Recurrence:  req.Recurrence,
 	}
+	if req.Estimate != nil && *req.Estimate > 0 {
+		newTodo.Estimate = req.Estimate
+	}
+	if req.Location != nil {
+		if err := applyLocation(newTodo, req.Location); err != nil {
+			return nil, err
+		}
+	}
+	if len(req.DependsOn) > 0 {
+		dependsOn, err := s.validateDependencies(ctx, newTodo, req.DependsOn)
+		if err != nil {
+			return nil, err
+		}
+		newTodo.DependsOn = dependsOn
+	}
+	if len(req.Tags) > 0 {
+		tags, err := s.resolveTags(ctx, newTodo.UserID, req.Tags)
+		if err != nil {
+			return nil, err
+		}
+		newTodo.Tags = tags
+	}
+	s.applySuggestions(ctx, newTodo)
+	if newTodo.Priority == "" {
+		newTodo.Priority = suggest.PriorityNormal
+	}
+	// Checked after suggestions, which may fill in the due date
+	if err := validateSchedule(newTodo); err != nil {
+		return nil, err
+	}
+	if err := s.scheduleRecurrence(ctx, newTodo, time.Now()); err != nil {
+		return nil, err
+	}
+	return newTodo, nil
+}
+
+// created records and publishes a newly saved todo.
+func (s *todoService) created(ctx context.Context, todo *domain.Todo) TodoResponse {
+	s.record(ctx, todo, domain.ActivityCreated, "", "")
+	response := toTodoResponse(todo)
+	s.publish(ctx, realtime.TodoCreated, todo, response)
+	return response
+}
 
-	// 3. Call Repository to save the new todo
-	err := s.repo.Create(newTodo) // Pass the domain model to the repository
+// applySuggestions fills in tags, priority and due date from the suggester when the
+// owner opted in and the client left them empty. Failures are logged and
+// ignored: suggestions must never block creating a todo.
+func (s *todoService) applySuggestions(ctx context.Context, todo *domain.Todo) {
+	if s.prefs == nil || s.suggester == nil || todo.UserID == 0 {
+		return
+	}
+	pref, err := loadPreferences(s.prefs, todo.UserID)
 	if err != nil {
-		// Log the error internally
-		fmt.Printf("Error creating todo in repository: %v\n", err)
-		// Return a more generic error to the caller (handler)
-		return nil, errors.New("failed to create todo item")
+		logging.FromContext(ctx).Error("Error loading preferences for user", "user_id", todo.UserID, "err", err)
+		return
+	}
+	if !pref.AutoApplySuggestions {
+		return
 	}
 
-	// 4. Convert the created domain model to a response DTO
-	response := &TodoResponse{
-		ID:        newTodo.ID, // GORM populates the ID after creation
-		Title:     newTodo.Title,
-		Completed: newTodo.Completed,
-		UserID:    newTodo.UserID,
-		CreatedAt: newTodo.CreatedAt.Format(time.RFC3339), // Format timestamp
-		UpdatedAt: newTodo.UpdatedAt.Format(time.RFC3339), // Format timestamp
+	suggestion, err := s.suggester.Suggest(ctx, suggest.Input{Title: todo.Title, Now: time.Now()})
+	if err != nil {
+		logging.FromContext(ctx).Error("Error generating suggestions for new todo", "err", err)
+		return
 	}
+	if len(todo.Tags) == 0 && len(suggestion.Tags) > 0 {
+		if tags, err := s.resolveTags(ctx, todo.UserID, suggestion.Tags); err != nil {
+			logging.FromContext(ctx).Error("Error applying suggested tags", "tags", suggestion.Tags, "err", err)
+		} else {
+			todo.Tags = tags
+		}
+	}
+	if todo.Priority == "" && suggestion.Priority != nil {
+		todo.Priority = *suggestion.Priority
+	}
+	if todo.DueDate == nil && suggestion.DueDate != nil {
+		todo.DueDate = suggestion.DueDate
+	}
+}
 
-	return response, nil
+// resolveTags returns the user's tags with the given names, creating the
+// missing ones.
+func (s *todoService) resolveTags(ctx context.Context, userID uint, names []string) ([]domain.Tag, error) {
+	names, err := normalizeTagNames(names)
+	if err != nil {
+		return nil, err
+	}
+	tags, err := s.tags.FindOrCreate(userID, names)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error resolving tags", "names", names, "user_id", userID, "err", err)
+		return nil, errors.New("failed to save tags")
+	}
+	return tags, nil
 }
 
 // GetTodoByID implements the logic to retrieve a todo by ID.
 func (s *todoService) GetTodoByID(ctx context.Context, id uint) (*TodoResponse, error) {
+	ctx, span := tracing.Start(ctx, "TodoService.GetTodoByID")
+	defer span.End()
 	// 1. Call Repository to find the todo
-	todo, err := s.repo.FindByID(id)
+	todo, err := s.todoRepo(ctx).FindByID(id)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) { // Check for specific GORM error
 			// Return a "not found" error that the handler can interpret (e.g., return HTTP 404)
-			return nil, fmt.Errorf("todo with ID %d not found", id) // Or define custom error types
+			return nil, apperror.NotFoundf("todo with ID %d not found", id)
 		}
 		// Log other unexpected errors
-		fmt.Printf("Error fetching todo %d from repository: %v\n", id, err)
+		logging.FromContext(ctx).Error("Error fetching todo", "todo_id", id, "err", err)
 		return nil, errors.New("failed to retrieve todo item")
 	}
 
 	// 2. Convert domain model to response DTO
-	response := &TodoResponse{
-		ID:        todo.ID,
-		Title:     todo.Title,
-		Completed: todo.Completed,
-		UserID:    todo.UserID,
-		CreatedAt: todo.CreatedAt.Format(time.RFC3339),
-		UpdatedAt: todo.UpdatedAt.Format(time.RFC3339),
-	}
+	response := toTodoResponse(todo)
 
-	return response, nil
+	return &response, nil
 }
 
-// GetAllTodos implements the logic to retrieve all todos.
-func (s *todoService) GetAllTodos(ctx context.Context) ([]TodoResponse, error) {
-	// 1. Call Repository to get all todos
-	todos, err := s.repo.GetAll()
+// GetAllTodos implements the logic to retrieve a page of todos.
+func (s *todoService) GetAllTodos(ctx context.Context, page PageRequest, filter TodoFilter) ([]TodoResponse, *PageInfo, error) {
+	ctx, span := tracing.Start(ctx, "TodoService.GetAllTodos")
+	defer span.End()
+	// 1. Validate the page against the configured limits
+	if page.Offset < 0 {
+		return nil, nil, apperror.Invalidf("invalid offset, must not be negative")
+	}
+	if page.Cursor != "" && page.Offset != 0 {
+		return nil, nil, apperror.Invalidf("invalid page, use either cursor or offset")
+	}
+	limit, err := s.cfg.Limits.PageSize(page.Limit)
 	if err != nil {
-		fmt.Printf("Error fetching all todos from repository: %v\n", err)
-		return nil, errors.New("failed to retrieve todo items")
+		return nil, nil, err
+	}
+	sorts, err := parseTodoSort(filter.Sort)
+	if err != nil {
+		return nil, nil, err
+	}
+	// Cursors only record the ID, so they can't continue other orders
+	if page.Cursor != "" && sorts != nil {
+		return nil, nil, apperror.Invalidf("invalid page, cursor paging only supports the default order")
+	}
+	where := repository.TodoFilter{
+		ListOptions: repository.ListOptions{DeletedSince: filter.DeletedSince},
+		Completed:   filter.Completed,
+		UserID:      filter.UserID,
+		DueBefore:   filter.DueBefore,
+		DueAfter:    filter.DueAfter,
+		Sort:        sorts,
+	}
+	if where.Tags, err = normalizeTagNames(filter.Tags); err != nil {
+		return nil, nil, err
+	}
+	if filter.Overdue {
+		if filter.Completed != nil && *filter.Completed {
+			return nil, nil, apperror.Invalidf("invalid filter, completed todos are never overdue")
+		}
+		today, err := s.startOfToday(ctx, filter.UserID, time.Now())
+		if err != nil {
+			return nil, nil, err
+		}
+		open := false
+		where.Completed = &open
+		if where.DueBefore == nil || today.Before(*where.DueBefore) {
+			where.DueBefore = &today
+		}
+	}
+
+	// 2. Call Repository to get the page
+	var todos []domain.Todo
+	var info *PageInfo
+	if page.Cursor != "" {
+		todos, info, err = s.todosAfter(ctx, page.Cursor, limit, where)
+		if err != nil {
+			return nil, nil, err
+		}
+	} else {
+		total, err := s.todoRepo(ctx).Count(where)
+		if err != nil {
+			logging.FromContext(ctx).Error("Error counting todos in repository", "err", err)
+			return nil, nil, errors.New("failed to retrieve todo items")
+		}
+		where.Offset, where.Limit = page.Offset, limit
+		todos, err = s.todoRepo(ctx).Find(where)
+		if err != nil {
+			logging.FromContext(ctx).Error("Error fetching todos from repository", "err", err)
+			return nil, nil, errors.New("failed to retrieve todo items")
+		}
+		info = newPageInfo(limit, page.Offset, total)
+		// Offset pages hand out a cursor too, so clients can switch over
+		if info.NextOffset != nil && len(todos) > 0 && sorts == nil {
+			info.NextCursor = encodeCursor(todos[len(todos)-1].ID)
+		}
 	}
 
-	// 2. Convert the slice of domain models to a slice of response DTOs
+	// 3. Convert the slice of domain models to a slice of response DTOs
 	responses := make([]TodoResponse, 0, len(todos)) // Pre-allocate slice capacity
-	for _, todo := range todos {
-		responses = append(responses, TodoResponse{
-			ID:        todo.ID,
-			Title:     todo.Title,
-			Completed: todo.Completed,
-			UserID:    todo.UserID,
-			CreatedAt: todo.CreatedAt.Format(time.RFC3339),
-			UpdatedAt: todo.UpdatedAt.Format(time.RFC3339),
-		})
+	for i := range todos {
+		responses = append(responses, toTodoResponse(&todos[i]))
 	}
 
-	return responses, nil
+	return responses, info, nil
+}
+
+// startOfToday returns midnight of now's date in the time zone of userID,
+// or in UTC without a user or preferences.
+func (s *todoService) startOfToday(ctx context.Context, userID *uint, now time.Time) (time.Time, error) {
+	loc := time.UTC
+	if userID != nil {
+		var err error
+		if loc, err = s.location(*userID); err != nil {
+			logging.FromContext(ctx).Error("Error fetching preferences for user", "user_id", *userID, "err", err)
+			return time.Time{}, errors.New("failed to retrieve todo items")
+		}
+	}
+	year, month, day := now.In(loc).Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, loc), nil
+}
+
+// location returns the time zone of userID, or UTC without preferences.
+func (s *todoService) location(userID uint) (*time.Location, error) {
+	if s.prefs == nil {
+		return time.UTC, nil
+	}
+	pref, err := loadPreferences(s.prefs, userID)
+	if err != nil {
+		return nil, err
+	}
+	return userLocation(pref), nil
+}
+
+// scheduleRecurrence validates the todo's recurrence, storing it in
+// canonical form, and sets or clears its next occurrence.
+func (s *todoService) scheduleRecurrence(ctx context.Context, todo *domain.Todo, now time.Time) error {
+	if todo.Recurrence == "" {
+		todo.NextOccurrence = nil
+		return nil
+	}
+	rule, err := rrule.Parse(todo.Recurrence)
+	if err != nil {
+		return apperror.Invalidf("invalid recurrence: %w", err)
+	}
+	if todo.DueDate == nil {
+		return apperror.Invalidf("invalid recurrence, a recurring todo needs a due date")
+	}
+	todo.Recurrence = rule.String()
+	loc, err := s.location(todo.UserID)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error fetching preferences for user", "user_id", todo.UserID, "err", err)
+		return errors.New("failed to schedule the next occurrence")
+	}
+	todo.NextOccurrence = nextOccurrence(rule, todo, loc, now)
+	return nil
+}
+
+// todosAfter fetches the cursor page of todos. One extra row is read to
+// tell whether another page follows.
+func (s *todoService) todosAfter(ctx context.Context, cursor string, limit int, where repository.TodoFilter) ([]domain.Todo, *PageInfo, error) {
+	after, err := decodeCursor(cursor)
+	if err != nil {
+		return nil, nil, err
+	}
+	where.AfterID, where.Limit = after.LastID, limit+1
+	todos, err := s.todoRepo(ctx).Find(where)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error fetching todos after cursor", "last_id", after.LastID, "err", err)
+		return nil, nil, errors.New("failed to retrieve todo items")
+	}
+	info := &PageInfo{Limit: limit}
+	if len(todos) > limit {
+		todos = todos[:limit]
+		info.NextCursor = encodeCursor(todos[limit-1].ID)
+	}
+	return todos, info, nil
+}
+
+// SearchTodos implements the logic to search todos.
+func (s *todoService) SearchTodos(ctx context.Context, req SearchTodosRequest) ([]TodoSearchResult, *PageInfo, error) {
+	ctx, span := tracing.Start(ctx, "TodoService.SearchTodos")
+	defer span.End()
+	query := strings.TrimSpace(req.Query)
+	if query == "" {
+		return nil, nil, apperror.Invalidf("invalid search, q cannot be empty")
+	}
+	threshold := s.cfg.FuzzyThreshold
+	if req.Threshold != nil {
+		if *req.Threshold < 0 || *req.Threshold > 1 {
+			return nil, nil, apperror.Invalidf("invalid threshold, must be between 0 and 1")
+		}
+		threshold = *req.Threshold
+	}
+	if req.Page.Offset < 0 {
+		return nil, nil, apperror.Invalidf("invalid offset, must not be negative")
+	}
+	limit, err := s.cfg.Limits.PageSize(req.Page.Limit)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	search := repository.TodoSearch{TodoFilter: repository.TodoFilter{Offset: req.Page.Offset, Limit: limit}, Fuzzy: req.Fuzzy}
+	if req.UserID != 0 {
+		search.UserID = &req.UserID
+	}
+	if req.Fuzzy {
+		search.Threshold = threshold
+	}
+	matches, total, err := s.todoRepo(ctx).Search(query, search)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error searching todos", "query", query, "err", err)
+		return nil, nil, errors.New("failed to search todos")
+	}
+
+	results := make([]TodoSearchResult, 0, len(matches))
+	for i := range matches {
+		results = append(results, TodoSearchResult{TodoResponse: toTodoResponse(&matches[i].Todo), Score: matches[i].Score})
+	}
+	return results, newPageInfo(limit, req.Page.Offset, total), nil
+}
+
+// FindNearbyTodos implements the logic to find todos by location.
+func (s *todoService) FindNearbyTodos(ctx context.Context, req NearbyTodosRequest) ([]NearbyTodoResult, error) {
+	ctx, span := tracing.Start(ctx, "TodoService.FindNearbyTodos")
+	defer span.End()
+	if err := geo.Validate(req.Latitude, req.Longitude); err != nil {
+		return nil, apperror.Invalidf("invalid location: %w", err)
+	}
+	if req.RadiusMeters != 0 {
+		if err := geo.ValidateRadius(req.RadiusMeters); err != nil {
+			return nil, apperror.Invalidf("invalid location: %w", err)
+		}
+	}
+	limit, err := s.cfg.Limits.PageSize(req.Limit)
+	if err != nil {
+		return nil, err
+	}
+
+	nearby, err := s.todoRepo(ctx).FindNearby(req.Latitude, req.Longitude, req.RadiusMeters, req.UserID, limit)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error finding nearby todos", "latitude", req.Latitude, "longitude", req.Longitude, "err", err)
+		return nil, errors.New("failed to find nearby todos")
+	}
+
+	results := make([]NearbyTodoResult, 0, len(nearby))
+	for i := range nearby {
+		results = append(results, NearbyTodoResult{TodoResponse: toTodoResponse(&nearby[i].Todo), DistanceMeters: nearby[i].Distance})
+	}
+	return results, nil
 }
 
 // UpdateTodo implements the logic to update an existing todo.
 func (s *todoService) UpdateTodo(ctx context.Context, id uint, req UpdateTodoRequest) (*TodoResponse, error) {
+	ctx, span := tracing.Start(ctx, "TodoService.UpdateTodo")
+	defer span.End()
+	if err := authz.Check(ctx, authz.WriteTodos); err != nil {
+		return nil, err
+	}
 	// 1. Fetch the existing todo to ensure it exists
-	existingTodo, err := s.repo.FindByID(id)
+	existingTodo, err := s.todoRepo(ctx).FindByID(id)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, fmt.Errorf("todo with ID %d not found for update", id)
+			return nil, apperror.NotFoundf("todo with ID %d not found for update", id)
 		}
-		fmt.Printf("Error fetching todo %d for update: %v\n", id, err)
+		logging.FromContext(ctx).Error("Error fetching todo for update", "todo_id", id, "err", err)
 		return nil, errors.New("failed to retrieve todo item for update")
 	}
+	if req.Version != 0 && req.Version != existingTodo.Version {
+		return nil, ErrTodoChanged
+	}
 
 	// 2. Apply updates from the request (only if fields are provided in the request)
-	updated := false
-	if req.Title != nil && *req.Title != "" && *req.Title != existingTodo.Title {
-		// Add business logic validation if needed, e.g., length checks
-		existingTodo.Title = *req.Title
-		updated = true
-	}
-	if req.Completed != nil && *req.Completed != existingTodo.Completed {
-		existingTodo.Completed = *req.Completed
-		updated = true
+	change, err := s.applyUpdate(ctx, existingTodo, req)
+	if err != nil {
+		return nil, err
 	}
 
 	// 3. If nothing was updated, maybe return early or just proceed
-	if !updated {
+	if !change.updated {
 		// Return the existing data without hitting the DB again
 		// Or you could choose to always call Update, GORM might handle it efficiently
-		fmt.Printf("No changes detected for todo %d\n", id)
+		logging.FromContext(ctx).Debug("No changes detected for todo", "todo_id", id)
 		// We still convert and return the existing one as if updated
-		response := &TodoResponse{
-			ID:        existingTodo.ID,
-			Title:     existingTodo.Title,
-			Completed: existingTodo.Completed,
-			UserID:    existingTodo.UserID,
-			CreatedAt: existingTodo.CreatedAt.Format(time.RFC3339),
-			UpdatedAt: existingTodo.UpdatedAt.Format(time.RFC3339), // GORM might update this anyway on Save
-		}
-		return response, nil
+		response := toTodoResponse(existingTodo)
+		return &response, nil
 		// Alternatively: return nil, errors.New("no update applied") - depends on desired API behavior
 	}
 
-	// 4. Call Repository to save the updated todo
+	// 4. Call Repository to save the updated todo, its tags and its domain event
 	// Note: GORM's Save updates all fields, including associations if loaded.
 	// Use Update or Updates for more targeted updates if needed.
-	err = s.repo.Update(existingTodo)
+	err = s.todoRepo(ctx).Transaction(func(todos repository.TodoRepository, outbox repository.OutboxRepository) error {
+		if err := todos.Update(existingTodo); err != nil {
+			return err
+		}
+		if change.tagsChanged {
+			if err := todos.SetTags(id, existingTodo.Tags); err != nil {
+				return err
+			}
+		}
+		return addEvent(ctx, outbox, events.TodoUpdated, existingTodo, toTodoResponse(existingTodo))
+	})
+	if errors.Is(err, repository.ErrVersionConflict) {
+		return nil, ErrTodoChanged
+	}
 	if err != nil {
-		fmt.Printf("Error updating todo %d in repository: %v\n", id, err)
+		logging.FromContext(ctx).Error("Error updating todo", "todo_id", id, "err", err)
 		return nil, errors.New("failed to update todo item")
 	}
 
 	// 5. Convert updated domain model to response DTO
-	response := &TodoResponse{
-		ID:        existingTodo.ID,
-		Title:     existingTodo.Title,
-		Completed: existingTodo.Completed,
-		UserID:    existingTodo.UserID,
-		CreatedAt: existingTodo.CreatedAt.Format(time.RFC3339),
-		UpdatedAt: existingTodo.UpdatedAt.Format(time.RFC3339), // GORM updates UpdatedAt automatically
+	// GORM updates UpdatedAt automatically
+	response := s.updated(ctx, existingTodo, change)
+	return &response, nil
+}
+
+// todoChange describes what applyUpdate changed on a todo.
+type todoChange struct {
+	updated     bool
+	tagsChanged bool
+	activities  []domain.Activity
+}
+
+// applyUpdate applies req to existingTodo in memory, validating it.
+func (s *todoService) applyUpdate(ctx context.Context, existingTodo *domain.Todo, req UpdateTodoRequest) (todoChange, error) {
+	updated := false
+	var activities []domain.Activity
+	if req.Title != nil && *req.Title != "" && *req.Title != existingTodo.Title {
+		// Add business logic validation if needed, e.g., length checks
+		existingTodo.Title = *req.Title
+		updated = true
+	}
+	if req.Description != nil && *req.Description != existingTodo.Description {
+		existingTodo.Description = *req.Description
+		updated = true
+	}
+	if req.Completed != nil && *req.Completed != existingTodo.Completed {
+		existingTodo.Completed = *req.Completed
+		if existingTodo.Completed {
+			now := time.Now()
+			existingTodo.CompletedAt = &now
+			activities = append(activities, domain.Activity{Kind: domain.ActivityCompleted})
+		} else {
+			existingTodo.CompletedAt = nil
+			activities = append(activities, domain.Activity{Kind: domain.ActivityReopened})
+		}
+		updated = true
+	}
+	if req.Priority != nil && *req.Priority != existingTodo.Priority {
+		if !validPriority(*req.Priority) {
+			return todoChange{}, apperror.Invalidf("priority must be low, normal or high")
+		}
+		existingTodo.Priority = *req.Priority
+		updated = true
 	}
+	if req.ListID != nil {
+		oldList := formatListID(existingTodo.ListID)
+		// list_id 0 moves the todo out of its list
+		switch {
+		case *req.ListID == 0 && existingTodo.ListID != nil:
+			existingTodo.ListID = nil
+			updated = true
+		case *req.ListID != 0 && (existingTodo.ListID == nil || *req.ListID != *existingTodo.ListID):
+			existingTodo.ListID = req.ListID
+			updated = true
+		}
+		if newList := formatListID(existingTodo.ListID); newList != oldList {
+			activities = append(activities, domain.Activity{Kind: domain.ActivityMoved, OldValue: oldList, NewValue: newList})
+		}
+	}
+	if req.Estimate != nil {
+		if *req.Estimate < 0 {
+			return todoChange{}, apperror.Invalidf("invalid estimate: must not be negative")
+		}
+		oldEstimate := formatEstimate(existingTodo.Estimate)
+		existingTodo.Estimate = nil
+		if *req.Estimate > 0 {
+			existingTodo.Estimate = req.Estimate
+		}
+		if newEstimate := formatEstimate(existingTodo.Estimate); newEstimate != oldEstimate {
+			activities = append(activities, domain.Activity{Kind: domain.ActivityEstimated, OldValue: oldEstimate, NewValue: newEstimate})
+			updated = true
+		}
+	}
+	if req.DueDate != nil && (existingTodo.DueDate == nil || !req.DueDate.Equal(*existingTodo.DueDate)) {
+		existingTodo.DueDate = req.DueDate
+		// A new deadline starts overdue escalation and reminders over
+		existingTodo.EscalationLevel = 0
+		existingTodo.OverdueNotifiedAt = nil
+		updated = true
+	}
+	if req.StartDate != nil && (existingTodo.StartDate == nil || !req.StartDate.Equal(*existingTodo.StartDate)) {
+		existingTodo.StartDate = req.StartDate
+		updated = true
+	}
+	if err := validateSchedule(existingTodo); err != nil {
+		return todoChange{}, err
+	}
+	if req.DependsOn != nil {
+		dependsOn, err := s.validateDependencies(ctx, existingTodo, *req.DependsOn)
+		if err != nil {
+			return todoChange{}, err
+		}
+		if !slices.Equal(dependsOn, existingTodo.DependsOn) {
+			existingTodo.DependsOn = dependsOn
+			updated = true
+		}
+	}
+	if req.Location != nil {
+		if err := applyLocation(existingTodo, req.Location); err != nil {
+			return todoChange{}, err
+		}
+		updated = true
+	}
+	if req.Recurrence != nil && *req.Recurrence != existingTodo.Recurrence {
+		existingTodo.Recurrence = *req.Recurrence
+		updated = true
+	}
+	if updated {
+		if err := s.scheduleRecurrence(ctx, existingTodo, time.Now()); err != nil {
+			return todoChange{}, err
+		}
+	}
+	tagsChanged := false
+	if req.Tags != nil {
+		tags, err := s.resolveTags(ctx, existingTodo.UserID, *req.Tags)
+		if err != nil {
+			return todoChange{}, err
+		}
+		if !slices.Equal(tagNames(tags), tagNames(existingTodo.Tags)) {
+			existingTodo.Tags = tags
+			tagsChanged, updated = true, true
+		}
+	}
+	return todoChange{updated: updated, tagsChanged: tagsChanged, activities: activities}, nil
+}
 
-	return response, nil
+// updated records, publishes and reports to followers a saved change.
+func (s *todoService) updated(ctx context.Context, todo *domain.Todo, change todoChange) TodoResponse {
+	for _, a := range change.activities {
+		s.record(ctx, todo, a.Kind, a.OldValue, a.NewValue)
+	}
+	response := toTodoResponse(todo)
+	s.publish(ctx, realtime.TodoUpdated, todo, response)
+	s.notifyFollowers(ctx, todo, describeChanges(change.activities), false)
+	return response
 }
 
 // DeleteTodo implements the logic to delete a todo.
-func (s *todoService) DeleteTodo(ctx context.Context, id uint) error {
+func (s *todoService) DeleteTodo(ctx context.Context, id, version uint) error {
+	ctx, span := tracing.Start(ctx, "TodoService.DeleteTodo")
+	defer span.End()
+	if err := authz.Check(ctx, authz.WriteTodos); err != nil {
+		return err
+	}
 	// 1. (Optional) Check if the record exists first if you want to return a specific "not found" error.
 	//    GORM's Delete usually doesn't error if the record doesn't exist, but RowsAffected will be 0.
-	_, err := s.repo.FindByID(id) // Check existence
+	todo, err := s.todoRepo(ctx).FindByID(id) // Check existence
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return fmt.Errorf("todo with ID %d not found for deletion", id)
+			return apperror.NotFoundf("todo with ID %d not found for deletion", id)
 		}
-		fmt.Printf("Error checking existence of todo %d before delete: %v\n", id, err)
+		logging.FromContext(ctx).Error("Error checking existence of todo before delete", "todo_id", id, "err", err)
 		return errors.New("failed to check todo item before deletion")
 	}
+	if version != 0 && version != todo.Version {
+		return ErrTodoChanged
+	}
 
-	// 2. Call Repository to delete the todo
-	err = s.repo.Delete(id)
+	// 2. Call Repository to delete the todo, with its domain event
+	err = s.todoRepo(ctx).Transaction(func(todos repository.TodoRepository, outbox repository.OutboxRepository) error {
+		if err := todos.Delete(todo); err != nil {
+			return err
+		}
+		return addEvent(ctx, outbox, events.TodoDeleted, todo, nil)
+	})
+	if errors.Is(err, repository.ErrVersionConflict) {
+		return ErrTodoChanged
+	}
 	if err != nil {
-		fmt.Printf("Error deleting todo %d from repository: %v\n", id, err)
+		logging.FromContext(ctx).Error("Error deleting todo", "todo_id", id, "err", err)
 		return errors.New("failed to delete todo item")
 	}
+	s.deleted(ctx, todo)
 
 	// Successfully deleted (or soft-deleted by GORM if using gorm.Model)
 	return nil
 }
+
+// deleted records, publishes and reports to followers a deleted todo.
+func (s *todoService) deleted(ctx context.Context, todo *domain.Todo) {
+	todo.Completed = true // nothing is left to do
+	s.record(ctx, todo, domain.ActivityDeleted, "", "")
+	s.publish(ctx, realtime.TodoDeleted, todo, map[string]uint{"id": todo.ID})
+	s.notifyFollowers(ctx, todo, []string{"it was deleted"}, true)
+}
+
+// ListTrash implements TodoService.
+func (s *todoService) ListTrash(ctx context.Context, userID uint) ([]TodoResponse, error) {
+	ctx, span := tracing.Start(ctx, "TodoService.ListTrash")
+	defer span.End()
+	todos, err := s.todoRepo(ctx).FindTrashed(userID)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error fetching deleted todos of user", "user_id", userID, "err", err)
+		return nil, errors.New("failed to retrieve the trash")
+	}
+	responses := make([]TodoResponse, 0, len(todos))
+	for i := range todos {
+		responses = append(responses, toTodoResponse(&todos[i]))
+	}
+	return responses, nil
+}
+
+// GetTrashedTodo implements TodoService.
+func (s *todoService) GetTrashedTodo(ctx context.Context, id uint) (*TodoResponse, error) {
+	ctx, span := tracing.Start(ctx, "TodoService.GetTrashedTodo")
+	defer span.End()
+	todo, err := s.findTrashed(ctx, id, "retrieve deleted todo")
+	if err != nil {
+		return nil, err
+	}
+	response := toTodoResponse(todo)
+	return &response, nil
+}
+
+// RestoreTodo implements TodoService.
+func (s *todoService) RestoreTodo(ctx context.Context, id uint) (*TodoResponse, error) {
+	ctx, span := tracing.Start(ctx, "TodoService.RestoreTodo")
+	defer span.End()
+	if err := authz.Check(ctx, authz.WriteTodos); err != nil {
+		return nil, err
+	}
+	var todo *domain.Todo
+	err := s.todoRepo(ctx).Transaction(func(todos repository.TodoRepository, outbox repository.OutboxRepository) error {
+		restored, err := todos.Restore(id)
+		if err != nil || !restored {
+			return err
+		}
+		if todo, err = todos.FindByID(id); err != nil {
+			return err
+		}
+		return addEvent(ctx, outbox, events.TodoCreated, todo, toTodoResponse(todo))
+	})
+	if err != nil {
+		logging.FromContext(ctx).Error("Error restoring todo", "todo_id", id, "err", err)
+		return nil, errors.New("failed to restore todo item")
+	}
+	if todo == nil {
+		return nil, apperror.NotFoundf("todo with ID %d not found in the trash", id)
+	}
+
+	response := toTodoResponse(todo)
+	s.record(ctx, todo, domain.ActivityRestored, "", "")
+	s.publish(ctx, realtime.TodoCreated, todo, response)
+	return &response, nil
+}
+
+// PurgeTodo implements TodoService.
+func (s *todoService) PurgeTodo(ctx context.Context, id uint) error {
+	ctx, span := tracing.Start(ctx, "TodoService.PurgeTodo")
+	defer span.End()
+	if err := authz.Check(ctx, authz.WriteTodos); err != nil {
+		return err
+	}
+	if _, err := s.findTrashed(ctx, id, "purge todo item"); err != nil {
+		return err
+	}
+	if err := s.todoRepo(ctx).Purge(id); err != nil {
+		logging.FromContext(ctx).Error("Error purging todo", "todo_id", id, "err", err)
+		return errors.New("failed to purge todo item")
+	}
+	return nil
+}
+
+// findTrashed loads a deleted todo, describing failures with action.
+func (s *todoService) findTrashed(ctx context.Context, id uint, action string) (*domain.Todo, error) {
+	todo, err := s.todoRepo(ctx).FindTrashedByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.NotFoundf("todo with ID %d not found in the trash", id)
+		}
+		logging.FromContext(ctx).Error("Error fetching deleted todo", "todo_id", id, "action", action, "err", err)
+		return nil, fmt.Errorf("failed to %s", action)
+	}
+	return todo, nil
+}
+
+// validateSchedule checks that a todo doesn't start after it's due.
+func validateSchedule(todo *domain.Todo) error {
+	if todo.StartDate != nil && todo.DueDate != nil && todo.StartDate.After(*todo.DueDate) {
+		return apperror.Invalidf("invalid start date: must not be after the due date")
+	}
+	return nil
+}
+
+// validateDependencies checks that ids name existing todos and that none of
+// them already depends on todo, directly or not, since a cycle could never
+// be scheduled. It returns the ids sorted without duplicates.
+func (s *todoService) validateDependencies(ctx context.Context, todo *domain.Todo, ids []uint) ([]uint, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	ids = slices.Clone(ids)
+	slices.Sort(ids)
+	ids = slices.Compact(ids)
+
+	visited := make(map[uint]bool)
+	pending := slices.Clone(ids)
+	for len(pending) > 0 {
+		id := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		if todo.ID != 0 && id == todo.ID {
+			return nil, apperror.Invalidf("invalid dependencies: a todo can't depend on itself, directly or not")
+		}
+		if visited[id] {
+			continue
+		}
+		visited[id] = true
+
+		dependency, err := s.todoRepo(ctx).FindByID(id)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				if slices.Contains(ids, id) {
+					return nil, apperror.Invalidf("invalid dependencies: todo %d does not exist", id)
+				}
+				// Deleted further up the chain; it can't close a cycle
+				continue
+			}
+			logging.FromContext(ctx).Error("Error fetching dependency", "dependency_id", id, "todo_id", todo.ID, "err", err)
+			return nil, errors.New("failed to check todo dependencies")
+		}
+		pending = append(pending, dependency.DependsOn...)
+	}
+	return ids, nil
+}
+
+// record adds an entry to the todo's history. History is best effort: a
+// failure is logged but doesn't fail the change itself.
+func (s *todoService) record(ctx context.Context, todo *domain.Todo, kind, oldValue, newValue string) {
+	activity := newActivity(todo, kind, oldValue, newValue)
+	if err := s.activities.Create(activity); err != nil {
+		logging.FromContext(ctx).Error("Error recording activity", "kind", kind, "todo_id", todo.ID, "err", err)
+	}
+}
+
+// publish sends a change to the owner's realtime subscribers. Its domain
+// event went to the outbox with the change.
+func (s *todoService) publish(ctx context.Context, eventType string, todo *domain.Todo, data any) {
+	if s.events == nil {
+		return
+	}
+	payload, err := json.Marshal(data)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error encoding event", "type", eventType, "err", err)
+		return
+	}
+	s.events.Publish(ctx, realtime.Event{Type: eventType, UserID: todo.UserID, Data: payload})
+}
+
+// notifyFollowers reports a change to the todo's followers.
+func (s *todoService) notifyFollowers(ctx context.Context, todo *domain.Todo, changes []string, deleted bool) {
+	if s.followers == nil {
+		return
+	}
+	s.followers.NotifyFollowers(ctx, todo, changes, deleted)
+}
+
+// describeChanges renders an update's history entries for followers. Edits
+// that aren't tracked in history, such as a new title, are summarized.
+func describeChanges(activities []domain.Activity) []string {
+	var changes []string
+	for _, a := range activities {
+		switch a.Kind {
+		case domain.ActivityCompleted:
+			changes = append(changes, "it was completed")
+		case domain.ActivityReopened:
+			changes = append(changes, "it was reopened")
+		case domain.ActivityMoved:
+			changes = append(changes, fmt.Sprintf("it moved from list %s to list %s", orNone(a.OldValue), orNone(a.NewValue)))
+		case domain.ActivityEstimated:
+			changes = append(changes, fmt.Sprintf("its estimate changed from %s to %s", orNone(a.OldValue), orNone(a.NewValue)))
+		}
+	}
+	if len(changes) == 0 {
+		changes = append(changes, "its details were edited")
+	}
+	return changes
+}
+
+// orNone renders an empty history value.
+func orNone(v string) string {
+	if v == "" {
+		return "none"
+	}
+	return v
+}
+
+// formatListID renders a list ID for activity history; empty means no list.
+func formatListID(id *uint) string {
+	if id == nil {
+		return ""
+	}
+	return strconv.FormatUint(uint64(*id), 10)
+}
+
+// formatEstimate renders an estimate for activity history; empty means none.
+func formatEstimate(estimate *float64) string {
+	if estimate == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*estimate, 'f', -1, 64)
+}
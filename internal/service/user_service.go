@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Tomlord1122/todo-backend/internal/apperror"
+	"github.com/Tomlord1122/todo-backend/internal/authz"
+	"github.com/Tomlord1122/todo-backend/internal/domain"
+	"github.com/Tomlord1122/todo-backend/internal/logging"
+	"github.com/Tomlord1122/todo-backend/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+// UserResponse describes an account to administrators.
+type UserResponse struct {
+	ID        uint        `json:"id"`
+	Email     string      `json:"email"`
+	Name      string      `json:"name,omitempty"`
+	Role      domain.Role `json:"role"`
+	CreatedAt string      `json:"created_at"`
+}
+
+// UpdateUserRoleRequest changes a user's role.
+type UpdateUserRoleRequest struct {
+	Role domain.Role `json:"role" validate:"notblank"`
+}
+
+// UserService looks up the roles of signed-in users and lets
+// administrators manage accounts.
+type UserService interface {
+	// Principal returns who userID acts as. Users without an account,
+	// from before password logins, are members.
+	Principal(ctx context.Context, userID uint) (authz.Principal, error)
+
+	// ListUsers returns every account. It needs authz.ManageUsers.
+	ListUsers(ctx context.Context) ([]UserResponse, error)
+
+	// GetUsers returns the accounts with the given IDs in one lookup,
+	// skipping unknown ones, e.g. to show the owners of a page of todos.
+	// Callers decide which users the caller may see.
+	GetUsers(ctx context.Context, ids []uint) ([]UserResponse, error)
+
+	// UpdateRole changes the role of an account. It needs
+	// authz.ManageUsers, and the last admin can't be demoted.
+	UpdateRole(ctx context.Context, id uint, req UpdateUserRoleRequest) (*UserResponse, error)
+}
+
+type userService struct {
+	users repository.UserRepository
+}
+
+// NewUserService creates a new UserService
+func NewUserService(users repository.UserRepository) UserService {
+	return &userService{users: users}
+}
+
+func toUserResponse(user *domain.User) UserResponse {
+	return UserResponse{
+		ID:        user.ID,
+		Email:     user.Email,
+		Name:      user.Name,
+		Role:      user.Role,
+		CreatedAt: user.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+// Principal implements UserService.
+func (s *userService) Principal(ctx context.Context, userID uint) (authz.Principal, error) {
+	principal := authz.Principal{UserID: userID, Role: domain.RoleMember}
+	user, err := s.users.FindByID(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return principal, nil
+		}
+		logging.FromContext(ctx).Error("Error fetching role of user", "user_id", userID, "err", err)
+		return principal, errors.New("failed to check session")
+	}
+	principal.Role = user.Role
+	return principal, nil
+}
+
+// ListUsers implements UserService.
+func (s *userService) ListUsers(ctx context.Context) ([]UserResponse, error) {
+	if err := authz.Check(ctx, authz.ManageUsers); err != nil {
+		return nil, err
+	}
+	users, err := s.users.FindAll()
+	if err != nil {
+		logging.FromContext(ctx).Error("Error fetching users", "err", err)
+		return nil, errors.New("failed to retrieve users")
+	}
+	resp := make([]UserResponse, 0, len(users))
+	for i := range users {
+		resp = append(resp, toUserResponse(&users[i]))
+	}
+	return resp, nil
+}
+
+// GetUsers implements UserService.
+func (s *userService) GetUsers(ctx context.Context, ids []uint) ([]UserResponse, error) {
+	users, err := s.users.FindByIDs(ids)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error fetching users", "ids", ids, "err", err)
+		return nil, errors.New("failed to retrieve users")
+	}
+	resp := make([]UserResponse, 0, len(users))
+	for i := range users {
+		resp = append(resp, toUserResponse(&users[i]))
+	}
+	return resp, nil
+}
+
+// UpdateRole implements UserService.
+func (s *userService) UpdateRole(ctx context.Context, id uint, req UpdateUserRoleRequest) (*UserResponse, error) {
+	if err := authz.Check(ctx, authz.ManageUsers); err != nil {
+		return nil, err
+	}
+	if !authz.ValidRole(req.Role) {
+		return nil, apperror.Invalidf("invalid role %q, must be one of %v", req.Role, domain.Roles)
+	}
+	user, err := s.users.FindByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.NotFoundf("user with ID %d not found", id)
+		}
+		logging.FromContext(ctx).Error("Error fetching user", "user_id", id, "err", err)
+		return nil, errors.New("failed to retrieve user")
+	}
+
+	if user.Role == domain.RoleAdmin && req.Role != domain.RoleAdmin {
+		admins, err := s.users.CountByRole(domain.RoleAdmin)
+		if err != nil {
+			logging.FromContext(ctx).Error("Error counting admins", "err", err)
+			return nil, errors.New("failed to update user")
+		}
+		if admins <= 1 {
+			return nil, apperror.Invalidf("invalid role change, the last admin can't be demoted")
+		}
+	}
+	user.Role = req.Role
+	if err := s.users.Update(user); err != nil {
+		logging.FromContext(ctx).Error("Error updating role of user", "user_id", id, "err", err)
+		return nil, errors.New("failed to update user")
+	}
+	resp := toUserResponse(user)
+	return &resp, nil
+}
@@ -0,0 +1,398 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Tomlord1122/todo-backend/internal/apperror"
+	"github.com/Tomlord1122/todo-backend/internal/authz"
+	"github.com/Tomlord1122/todo-backend/internal/domain"
+	"github.com/Tomlord1122/todo-backend/internal/events"
+	"github.com/Tomlord1122/todo-backend/internal/jobs"
+	"github.com/Tomlord1122/todo-backend/internal/logging"
+	"github.com/Tomlord1122/todo-backend/internal/notify"
+	"github.com/Tomlord1122/todo-backend/internal/repository"
+	"github.com/Tomlord1122/todo-backend/internal/webhook"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// webhookSecretPrefix starts every webhook secret, so leaked secrets
+	// are easy to spot.
+	webhookSecretPrefix = "whsec_"
+	// maxWebhooksPerUser bounds the webhooks of one user, since every
+	// event is sent to each of them.
+	maxWebhooksPerUser = 20
+	// maxWebhookAttempts is how often a delivery is sent before it is
+	// given up
+	maxWebhookAttempts = 8
+	// webhookDeliveryHistory is how many deliveries ListDeliveries returns.
+	webhookDeliveryHistory = 50
+)
+
+// CreateWebhookRequest registers a URL for todo events. Events limits the
+// event types sent; empty sends all of them.
+type CreateWebhookRequest struct {
+	URL    string   `json:"url" validate:"notblank"`
+	Events []string `json:"events"`
+}
+
+// UpdateWebhookRequest changes a webhook. Omitted fields are left as they
+// are.
+type UpdateWebhookRequest struct {
+	URL    *string   `json:"url"`
+	Events *[]string `json:"events"`
+	Active *bool     `json:"active"`
+}
+
+// WebhookResponse describes a webhook. The secret is only known when it is
+// created.
+type WebhookResponse struct {
+	ID        uint     `json:"id"`
+	URL       string   `json:"url"`
+	Events    []string `json:"events"`
+	Active    bool     `json:"active"`
+	CreatedAt string   `json:"created_at"`
+	UpdatedAt string   `json:"updated_at"`
+}
+
+// CreatedWebhookResponse is returned once, when a webhook is created.
+// Deliveries are signed with the secret, see package webhook.
+type CreatedWebhookResponse struct {
+	WebhookResponse
+	Secret string `json:"secret"`
+}
+
+// WebhookDeliveryResponse describes a delivery and its latest attempt.
+type WebhookDeliveryResponse struct {
+	ID             uint    `json:"id"`
+	EventID        string  `json:"event_id"`
+	EventType      string  `json:"event_type"`
+	Status         string  `json:"status"`
+	Attempts       int     `json:"attempts"`
+	ResponseStatus int     `json:"response_status,omitempty"`
+	LastError      string  `json:"last_error,omitempty"`
+	DeliveredAt    *string `json:"delivered_at,omitempty"`
+	CreatedAt      string  `json:"created_at"`
+}
+
+// WebhookService manages a user's webhooks and sends their todo events to
+// them. It is an events.Publisher: publishing an event enqueues a job
+// delivering it to each webhook of the event's user that subscribes to it.
+type WebhookService interface {
+	events.Publisher
+
+	// CreateWebhook registers a webhook for a user. Only the returned
+	// response ever contains its secret.
+	CreateWebhook(ctx context.Context, userID uint, req CreateWebhookRequest) (*CreatedWebhookResponse, error)
+	// ListWebhooks returns the webhooks of a user.
+	ListWebhooks(ctx context.Context, userID uint) ([]WebhookResponse, error)
+	GetWebhook(ctx context.Context, userID, id uint) (*WebhookResponse, error)
+	UpdateWebhook(ctx context.Context, userID, id uint, req UpdateWebhookRequest) (*WebhookResponse, error)
+	// DeleteWebhook deletes a webhook. Its deliveries are kept, and those
+	// still pending fail.
+	DeleteWebhook(ctx context.Context, userID, id uint) error
+	// ListDeliveries returns the latest deliveries of a webhook, newest
+	// first.
+	ListDeliveries(ctx context.Context, userID, id uint) ([]WebhookDeliveryResponse, error)
+
+	// Deliver runs a delivery job, sending a delivery and recording the
+	// attempt. It is registered with the worker pool by RegisterJobs.
+	Deliver(ctx context.Context, job jobs.Job) error
+}
+
+type webhookService struct {
+	repo   repository.WebhookRepository
+	jobs   repository.JobRepository
+	sender *webhook.Sender
+}
+
+// NewWebhookService creates a new WebhookService sending deliveries with
+// sender.
+func NewWebhookService(repo repository.WebhookRepository, jobs repository.JobRepository, sender *webhook.Sender) WebhookService {
+	return &webhookService{repo: repo, jobs: jobs, sender: sender}
+}
+
+// webhookDeliveryJob is the payload of a delivery job.
+type webhookDeliveryJob struct {
+	WebhookID uint   `json:"webhook_id"`
+	EventID   string `json:"event_id"`
+	// RequestID is passed on to the webhook
+	RequestID string `json:"request_id,omitempty"`
+}
+
+func toWebhookResponse(w *domain.Webhook) WebhookResponse {
+	return WebhookResponse{
+		ID:        w.ID,
+		URL:       w.URL,
+		Events:    append([]string{}, w.EventTypes()...),
+		Active:    w.Active,
+		CreatedAt: w.CreatedAt.Format(time.RFC3339),
+		UpdatedAt: w.UpdatedAt.Format(time.RFC3339),
+	}
+}
+
+func toWebhookDeliveryResponse(d *domain.WebhookDelivery) WebhookDeliveryResponse {
+	return WebhookDeliveryResponse{
+		ID:             d.ID,
+		EventID:        d.EventID,
+		EventType:      d.EventType,
+		Status:         d.Status,
+		Attempts:       d.Attempts,
+		ResponseStatus: d.ResponseStatus,
+		LastError:      d.LastError,
+		DeliveredAt:    formatOptionalTime(d.DeliveredAt),
+		CreatedAt:      d.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+// normalizeWebhookEvents checks the event types of a webhook and returns
+// them sorted, without duplicates, as stored.
+func normalizeWebhookEvents(types []string) (string, error) {
+	normalized := make([]string, 0, len(types))
+	for _, t := range types {
+		t = strings.TrimSpace(t)
+		if !slices.Contains(events.Types, t) {
+			return "", apperror.Invalidf("invalid webhook: unknown event %q, want one of %s", t, strings.Join(events.Types, ", "))
+		}
+		normalized = append(normalized, t)
+	}
+	slices.Sort(normalized)
+	return strings.Join(slices.Compact(normalized), ","), nil
+}
+
+// CreateWebhook implements WebhookService.
+func (s *webhookService) CreateWebhook(ctx context.Context, userID uint, req CreateWebhookRequest) (*CreatedWebhookResponse, error) {
+	if err := authz.Check(ctx, authz.WriteTodos); err != nil {
+		return nil, err
+	}
+	target := strings.TrimSpace(req.URL)
+	if err := notify.ValidateWebhookURL(target); err != nil {
+		return nil, apperror.Invalidf("invalid webhook: %w", err)
+	}
+	eventTypes, err := normalizeWebhookEvents(req.Events)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := s.repo.FindByUserID(userID)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error fetching webhooks of user", "user_id", userID, "err", err)
+		return nil, errors.New("failed to create webhook")
+	}
+	if len(existing) >= maxWebhooksPerUser {
+		return nil, apperror.Invalidf("invalid webhook: at most %d webhooks are allowed", maxWebhooksPerUser)
+	}
+	token, err := generateToken()
+	if err != nil {
+		logging.FromContext(ctx).Error("Error generating webhook secret", "err", err)
+		return nil, errors.New("failed to create webhook")
+	}
+
+	hook := &domain.Webhook{UserID: userID, URL: target, Secret: webhookSecretPrefix + token, Events: eventTypes, Active: true}
+	if err := s.repo.Create(hook); err != nil {
+		logging.FromContext(ctx).Error("Error creating webhook for user", "user_id", userID, "err", err)
+		return nil, errors.New("failed to create webhook")
+	}
+	return &CreatedWebhookResponse{WebhookResponse: toWebhookResponse(hook), Secret: hook.Secret}, nil
+}
+
+// ListWebhooks implements WebhookService.
+func (s *webhookService) ListWebhooks(ctx context.Context, userID uint) ([]WebhookResponse, error) {
+	hooks, err := s.repo.FindByUserID(userID)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error fetching webhooks of user", "user_id", userID, "err", err)
+		return nil, errors.New("failed to retrieve webhooks")
+	}
+	resp := make([]WebhookResponse, 0, len(hooks))
+	for i := range hooks {
+		resp = append(resp, toWebhookResponse(&hooks[i]))
+	}
+	return resp, nil
+}
+
+// findOwnWebhook loads one of the user's webhooks. Other users' webhooks
+// are reported as not found.
+func (s *webhookService) findOwnWebhook(ctx context.Context, userID, id uint) (*domain.Webhook, error) {
+	hook, err := s.repo.FindByID(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && hook.UserID != userID) {
+		return nil, apperror.NotFoundf("webhook with ID %d not found", id)
+	}
+	if err != nil {
+		logging.FromContext(ctx).Error("Error fetching webhook", "webhook_id", id, "err", err)
+		return nil, errors.New("failed to retrieve webhook")
+	}
+	return hook, nil
+}
+
+// GetWebhook implements WebhookService.
+func (s *webhookService) GetWebhook(ctx context.Context, userID, id uint) (*WebhookResponse, error) {
+	hook, err := s.findOwnWebhook(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	resp := toWebhookResponse(hook)
+	return &resp, nil
+}
+
+// UpdateWebhook implements WebhookService.
+func (s *webhookService) UpdateWebhook(ctx context.Context, userID, id uint, req UpdateWebhookRequest) (*WebhookResponse, error) {
+	if err := authz.Check(ctx, authz.WriteTodos); err != nil {
+		return nil, err
+	}
+	hook, err := s.findOwnWebhook(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if req.URL != nil {
+		target := strings.TrimSpace(*req.URL)
+		if err := notify.ValidateWebhookURL(target); err != nil {
+			return nil, apperror.Invalidf("invalid webhook: %w", err)
+		}
+		hook.URL = target
+	}
+	if req.Events != nil {
+		if hook.Events, err = normalizeWebhookEvents(*req.Events); err != nil {
+			return nil, err
+		}
+	}
+	if req.Active != nil {
+		hook.Active = *req.Active
+	}
+	if err := s.repo.Update(hook); err != nil {
+		logging.FromContext(ctx).Error("Error updating webhook", "webhook_id", id, "err", err)
+		return nil, errors.New("failed to update webhook")
+	}
+	resp := toWebhookResponse(hook)
+	return &resp, nil
+}
+
+// DeleteWebhook implements WebhookService.
+func (s *webhookService) DeleteWebhook(ctx context.Context, userID, id uint) error {
+	if err := authz.Check(ctx, authz.WriteTodos); err != nil {
+		return err
+	}
+	if _, err := s.findOwnWebhook(ctx, userID, id); err != nil {
+		return err
+	}
+	if err := s.repo.Delete(id); err != nil {
+		logging.FromContext(ctx).Error("Error deleting webhook", "webhook_id", id, "err", err)
+		return errors.New("failed to delete webhook")
+	}
+	return nil
+}
+
+// ListDeliveries implements WebhookService.
+func (s *webhookService) ListDeliveries(ctx context.Context, userID, id uint) ([]WebhookDeliveryResponse, error) {
+	if _, err := s.findOwnWebhook(ctx, userID, id); err != nil {
+		return nil, err
+	}
+	deliveries, err := s.repo.FindDeliveries(id, webhookDeliveryHistory)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error fetching deliveries of webhook", "webhook_id", id, "err", err)
+		return nil, errors.New("failed to retrieve webhook deliveries")
+	}
+	resp := make([]WebhookDeliveryResponse, 0, len(deliveries))
+	for i := range deliveries {
+		resp = append(resp, toWebhookDeliveryResponse(&deliveries[i]))
+	}
+	return resp, nil
+}
+
+// Publish implements events.Publisher. Deliveries and their jobs are keyed
+// by the event's ID, so publishing an event again doesn't send it twice.
+func (s *webhookService) Publish(ctx context.Context, event events.Event) error {
+	hooks, err := s.repo.FindByUserID(event.UserID)
+	if err != nil {
+		return fmt.Errorf("fetching webhooks of user %d: %w", event.UserID, err)
+	}
+	var deliveries []domain.WebhookDelivery
+	for i := range hooks {
+		if !hooks[i].Subscribes(event.Type) {
+			continue
+		}
+		payload, err := json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("encoding %s event: %w", event.Type, err)
+		}
+		deliveries = append(deliveries, domain.WebhookDelivery{
+			WebhookID: hooks[i].ID,
+			EventID:   event.ID,
+			EventType: event.Type,
+			Payload:   string(payload),
+			Status:    domain.WebhookDeliveryPending,
+		})
+	}
+	if err := s.repo.AddDeliveries(deliveries); err != nil {
+		return err
+	}
+	now := time.Now()
+	for _, d := range deliveries {
+		key := fmt.Sprintf("webhook:%d:%s", d.WebhookID, d.EventID)
+		if err := enqueueJob(s.jobs, jobDeliverWebhook, key, webhookDeliveryJob{WebhookID: d.WebhookID, EventID: d.EventID, RequestID: event.RequestID}, now); err != nil {
+			return fmt.Errorf("enqueueing delivery of event %s to webhook %d: %w", d.EventID, d.WebhookID, err)
+		}
+	}
+	return nil
+}
+
+// Deliver implements WebhookService. Delivery is at least once: a delivery
+// is only marked delivered after its endpoint answered with a 2xx, so
+// receivers should use the event ID to skip repeats. Failed attempts are
+// retried by the job queue, until maxWebhookAttempts attempts failed.
+func (s *webhookService) Deliver(ctx context.Context, job jobs.Job) error {
+	var payload webhookDeliveryJob
+	if err := decodeJob(job, &payload); err != nil {
+		return err
+	}
+	delivery, err := s.repo.FindDeliveryByEvent(payload.WebhookID, payload.EventID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return jobs.Permanent(err)
+	}
+	if err != nil {
+		return err
+	}
+	if delivery.Status != domain.WebhookDeliveryPending {
+		return nil
+	}
+	hook, err := s.repo.FindByID(delivery.WebhookID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		delivery.Status, delivery.LastError = domain.WebhookDeliveryFailed, "webhook was deleted"
+		return s.repo.UpdateDelivery(delivery)
+	}
+	if err != nil {
+		return err
+	}
+
+	status, sendErr := s.sender.Send(ctx, webhook.Delivery{
+		ID:        delivery.ID,
+		URL:       hook.URL,
+		Secret:    hook.Secret,
+		Event:     delivery.EventType,
+		Body:      []byte(delivery.Payload),
+		RequestID: payload.RequestID,
+	})
+	if sendErr != nil && ctx.Err() != nil {
+		// Interrupted, not failed: the job runs again
+		return ctx.Err()
+	}
+	delivery.Attempts, delivery.ResponseStatus = job.Attempts, status
+	switch {
+	case sendErr == nil:
+		at := time.Now()
+		delivery.Status, delivery.DeliveredAt, delivery.LastError = domain.WebhookDeliveryDelivered, &at, ""
+	case job.LastAttempt:
+		delivery.Status, delivery.LastError = domain.WebhookDeliveryFailed, sendErr.Error()
+	default:
+		delivery.LastError = sendErr.Error()
+	}
+	if err := s.repo.UpdateDelivery(delivery); err != nil {
+		return err
+	}
+	return sendErr
+}
@@ -0,0 +1,365 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// S3 request signing (AWS Signature Version 4) is implemented directly on
+// net/http; we only need four operations and this keeps the AWS SDK out of
+// the dependency tree. Works with AWS S3 and S3-compatible stores (MinIO, R2).
+
+const (
+	sigV4Algorithm     = "AWS4-HMAC-SHA256"
+	sigV4TimeFormat    = "20060102T150405Z"
+	sigV4DateFormat    = "20060102"
+	unsignedPayload    = "UNSIGNED-PAYLOAD"
+	emptyPayloadSHA256 = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+)
+
+// S3Config holds connection settings for an S3-compatible store.
+type S3Config struct {
+	Bucket          string
+	Region          string
+	AccessKeyID     string
+	SecretAccessKey string
+	// Endpoint overrides the AWS endpoint, e.g. "http://localhost:9000" for MinIO.
+	Endpoint string
+	// PathStyle addresses objects as {endpoint}/{bucket}/{key} instead of
+	// {bucket}.{endpoint}/{key}. Defaults to true when Endpoint is set.
+	PathStyle bool
+}
+
+// S3ConfigFromEnv reads S3_* environment variables. ok is false when no
+// bucket is configured, meaning attachments are disabled.
+func S3ConfigFromEnv() (cfg S3Config, ok bool) {
+	cfg = S3Config{
+		Bucket:          os.Getenv("S3_BUCKET"),
+		Region:          os.Getenv("S3_REGION"),
+		AccessKeyID:     os.Getenv("S3_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("S3_SECRET_ACCESS_KEY"),
+		Endpoint:        strings.TrimRight(os.Getenv("S3_ENDPOINT"), "/"),
+	}
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+	cfg.PathStyle = cfg.Endpoint != ""
+	if v, err := strconv.ParseBool(os.Getenv("S3_PATH_STYLE")); err == nil {
+		cfg.PathStyle = v
+	}
+	return cfg, cfg.Bucket != ""
+}
+
+// S3Store implements ObjectStore against S3.
+type S3Store struct {
+	cfg    S3Config
+	client *http.Client
+	now    func() time.Time
+}
+
+// NewS3Store creates an S3-backed ObjectStore. A nil client gets a default
+// one with a 30 second timeout.
+func NewS3Store(cfg S3Config, client *http.Client) *S3Store {
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	return &S3Store{cfg: cfg, client: client, now: time.Now}
+}
+
+// PresignPut implements ObjectStore.
+func (s *S3Store) PresignPut(ctx context.Context, key, contentType string, expires time.Duration) (*PresignedRequest, error) {
+	header := http.Header{}
+	if contentType != "" {
+		header.Set("Content-Type", contentType)
+	}
+	return s.presign(http.MethodPut, key, header, expires)
+}
+
+// PresignGet implements ObjectStore.
+func (s *S3Store) PresignGet(ctx context.Context, key string, expires time.Duration) (*PresignedRequest, error) {
+	return s.presign(http.MethodGet, key, http.Header{}, expires)
+}
+
+// Get implements ObjectStore.
+func (s *S3Store) Get(ctx context.Context, key string) (io.ReadCloser, *ObjectInfo, error) {
+	resp, err := s.do(ctx, http.MethodGet, key, "", nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+		resp.Body.Close()
+		if resp.StatusCode == http.StatusNotFound {
+			return nil, nil, ErrNotFound
+		}
+		return nil, nil, fmt.Errorf("s3 GET %s: unexpected status %d", key, resp.StatusCode)
+	}
+	return resp.Body, &ObjectInfo{
+		Size:        resp.ContentLength,
+		ContentType: resp.Header.Get("Content-Type"),
+	}, nil
+}
+
+// Put implements ObjectStore.
+func (s *S3Store) Put(ctx context.Context, key, contentType string, body []byte) error {
+	resp, err := s.do(ctx, http.MethodPut, key, contentType, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("s3 PUT %s: unexpected status %d", key, resp.StatusCode)
+	}
+	return nil
+}
+
+// Stat implements ObjectStore.
+func (s *S3Store) Stat(ctx context.Context, key string) (*ObjectInfo, error) {
+	resp, err := s.do(ctx, http.MethodHead, key, "", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, ErrNotFound
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("s3 HEAD %s: unexpected status %d", key, resp.StatusCode)
+	}
+	return &ObjectInfo{
+		Size:        resp.ContentLength,
+		ContentType: resp.Header.Get("Content-Type"),
+	}, nil
+}
+
+// Ping checks that the bucket exists and the credentials can access it.
+func (s *S3Store) Ping(ctx context.Context) error {
+	resp, err := s.do(ctx, http.MethodHead, "", "", nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("s3 HEAD bucket %s: unexpected status %d", s.cfg.Bucket, resp.StatusCode)
+	}
+	return nil
+}
+
+// Delete implements ObjectStore.
+func (s *S3Store) Delete(ctx context.Context, key string) error {
+	resp, err := s.do(ctx, http.MethodDelete, key, "", nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		return fmt.Errorf("s3 DELETE %s: unexpected status %d", key, resp.StatusCode)
+	}
+	return nil
+}
+
+// objectURL returns the URL of key, with the path already escaped for signing.
+func (s *S3Store) objectURL(key string) *url.URL {
+	escapedKey := escapePath(key)
+	var u *url.URL
+	switch {
+	case s.cfg.Endpoint != "" && s.cfg.PathStyle:
+		u, _ = url.Parse(s.cfg.Endpoint)
+		prefix := strings.TrimRight(u.Path, "/")
+		u.Path = prefix + "/" + s.cfg.Bucket + "/" + key
+		u.RawPath = escapePath(prefix) + "/" + escapePath(s.cfg.Bucket) + "/" + escapedKey
+	case s.cfg.Endpoint != "":
+		u, _ = url.Parse(s.cfg.Endpoint)
+		u.Host = s.cfg.Bucket + "." + u.Host
+		u.Path = "/" + key
+		u.RawPath = "/" + escapedKey
+	default:
+		host := s.cfg.Bucket + ".s3." + s.cfg.Region + ".amazonaws.com"
+		if s.cfg.Region == "us-east-1" {
+			host = s.cfg.Bucket + ".s3.amazonaws.com"
+		}
+		u = &url.URL{Scheme: "https", Host: host, Path: "/" + key, RawPath: "/" + escapedKey}
+	}
+	return u
+}
+
+// presign builds a query-string-authenticated URL.
+func (s *S3Store) presign(method, key string, header http.Header, expires time.Duration) (*PresignedRequest, error) {
+	if expires <= 0 || expires > 7*24*time.Hour {
+		return nil, fmt.Errorf("presign expiry must be between 1s and 7 days, got %s", expires)
+	}
+	now := s.now().UTC()
+	u := s.objectURL(key)
+
+	signedHeaders, canonicalHeaders := canonicalizeHeaders(u.Host, header)
+	query := url.Values{}
+	query.Set("X-Amz-Algorithm", sigV4Algorithm)
+	query.Set("X-Amz-Credential", s.cfg.AccessKeyID+"/"+s.scope(now))
+	query.Set("X-Amz-Date", now.Format(sigV4TimeFormat))
+	query.Set("X-Amz-Expires", strconv.Itoa(int(expires.Seconds())))
+	query.Set("X-Amz-SignedHeaders", signedHeaders)
+
+	canonicalRequest := strings.Join([]string{
+		method,
+		u.EscapedPath(),
+		canonicalQuery(query),
+		canonicalHeaders,
+		signedHeaders,
+		unsignedPayload,
+	}, "\n")
+	query.Set("X-Amz-Signature", s.signature(now, canonicalRequest))
+	u.RawQuery = canonicalQuery(query)
+
+	return &PresignedRequest{
+		Method:    method,
+		URL:       u.String(),
+		Header:    header,
+		ExpiresAt: now.Add(expires),
+	}, nil
+}
+
+// do performs a header-authenticated request. body may be nil.
+func (s *S3Store) do(ctx context.Context, method, key, contentType string, body []byte) (*http.Response, error) {
+	now := s.now().UTC()
+	u := s.objectURL(key)
+
+	payloadHash := emptyPayloadSHA256
+	if len(body) > 0 {
+		sum := sha256.Sum256(body)
+		payloadHash = hex.EncodeToString(sum[:])
+	}
+
+	header := http.Header{}
+	header.Set("X-Amz-Date", now.Format(sigV4TimeFormat))
+	header.Set("X-Amz-Content-Sha256", payloadHash)
+	if contentType != "" {
+		header.Set("Content-Type", contentType)
+	}
+	signedHeaders, canonicalHeaders := canonicalizeHeaders(u.Host, header)
+
+	canonicalRequest := strings.Join([]string{
+		method,
+		u.EscapedPath(),
+		"",
+		canonicalHeaders,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		sigV4Algorithm, s.cfg.AccessKeyID, s.scope(now), signedHeaders, s.signature(now, canonicalRequest)))
+
+	var reqBody io.Reader
+	if body != nil {
+		reqBody = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), reqBody)
+	if err != nil {
+		return nil, err
+	}
+	req.Header = header
+	return s.client.Do(req)
+}
+
+func (s *S3Store) scope(t time.Time) string {
+	return t.Format(sigV4DateFormat) + "/" + s.cfg.Region + "/s3/aws4_request"
+}
+
+func (s *S3Store) signature(t time.Time, canonicalRequest string) string {
+	hash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		sigV4Algorithm,
+		t.Format(sigV4TimeFormat),
+		s.scope(t),
+		hex.EncodeToString(hash[:]),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.cfg.SecretAccessKey), t.Format(sigV4DateFormat))
+	key = hmacSHA256(key, s.cfg.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	return hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// canonicalizeHeaders returns the SignedHeaders list and the canonical
+// header block (each line newline-terminated) for host plus header.
+func canonicalizeHeaders(host string, header http.Header) (signed, canonical string) {
+	values := map[string]string{"host": host}
+	for name, v := range header {
+		values[strings.ToLower(name)] = strings.TrimSpace(strings.Join(v, ","))
+	}
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		b.WriteString(name + ":" + values[name] + "\n")
+	}
+	return strings.Join(names, ";"), b.String()
+}
+
+// canonicalQuery encodes query parameters sorted by key with SigV4 escaping.
+func canonicalQuery(v url.Values) string {
+	keys := make([]string, 0, len(v))
+	for k := range v {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		for _, val := range v[k] {
+			parts = append(parts, uriEncode(k, true)+"="+uriEncode(val, true))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// escapePath escapes an object key for use in a URL path, keeping slashes.
+func escapePath(p string) string {
+	return uriEncode(p, false)
+}
+
+// uriEncode applies the RFC 3986 escaping SigV4 requires: everything except
+// unreserved characters is percent-encoded; '/' too when encodeSlash is set.
+func uriEncode(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,47 @@
+// Package storage abstracts the object store that holds attachment files.
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+)
+
+// ErrNotFound is returned when an object does not exist.
+var ErrNotFound = errors.New("object not found")
+
+// ObjectInfo describes a stored object.
+type ObjectInfo struct {
+	Size        int64
+	ContentType string
+}
+
+// PresignedRequest is a request the client can perform directly against the
+// object store without our credentials.
+type PresignedRequest struct {
+	Method string
+	URL    string
+	// Header lists headers the client must send verbatim, since they are
+	// part of the signature.
+	Header    http.Header
+	ExpiresAt time.Time
+}
+
+// ObjectStore is the subset of object storage operations the app needs.
+type ObjectStore interface {
+	// PresignPut returns a request that uploads one object with the given content type.
+	PresignPut(ctx context.Context, key, contentType string, expires time.Duration) (*PresignedRequest, error)
+	// PresignGet returns a request that downloads an object.
+	PresignGet(ctx context.Context, key string, expires time.Duration) (*PresignedRequest, error)
+	// Get opens an object for reading, or returns ErrNotFound. The caller
+	// must close the reader.
+	Get(ctx context.Context, key string) (io.ReadCloser, *ObjectInfo, error)
+	// Put stores an object, replacing any existing one.
+	Put(ctx context.Context, key, contentType string, body []byte) error
+	// Stat returns metadata for an object, or ErrNotFound.
+	Stat(ctx context.Context, key string) (*ObjectInfo, error)
+	// Delete removes an object. Deleting a missing object is not an error.
+	Delete(ctx context.Context, key string) error
+}
@@ -0,0 +1,162 @@
+package suggest
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// KeywordRules configures the KeywordSuggester.
+type KeywordRules struct {
+	// Tags maps a tag to the words that trigger it.
+	Tags map[string][]string
+	// HighPriority and LowPriority words set the priority.
+	HighPriority []string
+	LowPriority  []string
+	// DueHour is the local hour assigned to suggested due dates.
+	DueHour int
+}
+
+// DefaultKeywordRules returns a reasonable starting rule set.
+func DefaultKeywordRules() KeywordRules {
+	return KeywordRules{
+		Tags: map[string][]string{
+			"work":     {"meeting", "report", "deploy", "review", "client", "email", "presentation", "standup"},
+			"shopping": {"buy", "grocery", "groceries", "order", "pick up", "milk", "store"},
+			"home":     {"clean", "laundry", "dishes", "vacuum", "repair", "fix", "garden"},
+			"health":   {"doctor", "dentist", "gym", "run", "workout", "pharmacy", "appointment"},
+			"finance":  {"pay", "bill", "invoice", "tax", "taxes", "rent", "bank"},
+			"call":     {"call", "phone", "ring"},
+		},
+		HighPriority: []string{"urgent", "asap", "important", "critical", "immediately", "!!"},
+		LowPriority:  []string{"someday", "maybe", "eventually", "whenever", "low priority"},
+		DueHour:      17,
+	}
+}
+
+// KeywordSuggester is a rule-based Suggester driven by word lists.
+type KeywordSuggester struct {
+	rules KeywordRules
+}
+
+// NewKeywordSuggester creates a KeywordSuggester with the given rules.
+func NewKeywordSuggester(rules KeywordRules) *KeywordSuggester {
+	return &KeywordSuggester{rules: rules}
+}
+
+var (
+	inDaysPattern  = regexp.MustCompile(`\bin (\d{1,3}) days?\b`)
+	weekdayPattern = regexp.MustCompile(`\b(?:on |by |next )?(monday|tuesday|wednesday|thursday|friday|saturday|sunday)\b`)
+)
+
+// Suggest implements Suggester.
+func (s *KeywordSuggester) Suggest(ctx context.Context, in Input) (Suggestion, error) {
+	var out Suggestion
+	title := " " + strings.ToLower(in.Title) + " "
+
+	tagNames := make([]string, 0, len(s.rules.Tags))
+	for tag := range s.rules.Tags {
+		tagNames = append(tagNames, tag)
+	}
+	sort.Strings(tagNames)
+	for _, tag := range tagNames {
+		if word, ok := containsAny(title, s.rules.Tags[tag]); ok {
+			out.Tags = append(out.Tags, tag)
+			out.Reasons = append(out.Reasons, fmt.Sprintf("%q suggests tag %q", word, tag))
+		}
+	}
+
+	if word, ok := containsAny(title, s.rules.HighPriority); ok {
+		out.Priority = stringPtr(PriorityHigh)
+		out.Reasons = append(out.Reasons, fmt.Sprintf("%q suggests high priority", word))
+	} else if word, ok := containsAny(title, s.rules.LowPriority); ok {
+		out.Priority = stringPtr(PriorityLow)
+		out.Reasons = append(out.Reasons, fmt.Sprintf("%q suggests low priority", word))
+	}
+
+	if due, phrase, ok := s.dueDate(title, in.Now); ok {
+		out.DueDate = &due
+		out.Reasons = append(out.Reasons, fmt.Sprintf("%q suggests due %s", phrase, due.Format("Mon Jan 2")))
+	}
+
+	return out, nil
+}
+
+// dueDate recognizes simple relative date phrases in a lower-cased title.
+func (s *KeywordSuggester) dueDate(title string, now time.Time) (time.Time, string, bool) {
+	at := func(days int) time.Time {
+		d := now.AddDate(0, 0, days)
+		return time.Date(d.Year(), d.Month(), d.Day(), s.rules.DueHour, 0, 0, 0, now.Location())
+	}
+
+	switch {
+	case strings.Contains(title, " today ") || strings.Contains(title, " tonight "):
+		return at(0), "today", true
+	case strings.Contains(title, " tomorrow "):
+		return at(1), "tomorrow", true
+	case strings.Contains(title, " next week "):
+		// Monday of next week
+		return at(7 - (int(now.Weekday())+6)%7), "next week", true
+	case strings.Contains(title, " this weekend ") || strings.Contains(title, " weekend "):
+		return at((int(time.Saturday) - int(now.Weekday()) + 7) % 7), "weekend", true
+	}
+
+	if m := inDaysPattern.FindStringSubmatch(title); m != nil {
+		var days int
+		fmt.Sscanf(m[1], "%d", &days)
+		return at(days), m[0], true
+	}
+	if m := weekdayPattern.FindStringSubmatch(title); m != nil {
+		target := weekdayIndex(m[1])
+		days := (target - int(now.Weekday()) + 7) % 7
+		if days == 0 {
+			// "friday" said on a Friday means next week's
+			days = 7
+		}
+		return at(days), strings.TrimSpace(m[0]), true
+	}
+	return time.Time{}, "", false
+}
+
+// containsAny reports the first word from words that occurs in text as a
+// whole word. text must be lower-cased and padded with spaces.
+func containsAny(text string, words []string) (string, bool) {
+	for _, word := range words {
+		w := strings.ToLower(word)
+		idx := strings.Index(text, w)
+		for idx >= 0 {
+			before := text[idx-1]
+			after := byte(' ')
+			if end := idx + len(w); end < len(text) {
+				after = text[end]
+			}
+			if !isWordByte(before) && !isWordByte(after) {
+				return word, true
+			}
+			next := strings.Index(text[idx+1:], w)
+			if next < 0 {
+				break
+			}
+			idx += next + 1
+		}
+	}
+	return "", false
+}
+
+func isWordByte(b byte) bool {
+	return b >= 'a' && b <= 'z' || b >= '0' && b <= '9'
+}
+
+func weekdayIndex(name string) int {
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if strings.EqualFold(d.String(), name) {
+			return int(d)
+		}
+	}
+	return 0
+}
+
+func stringPtr(s string) *string { return &s }
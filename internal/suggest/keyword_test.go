@@ -0,0 +1,58 @@
+package suggest
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestKeywordSuggester(t *testing.T) {
+	// Wednesday
+	now := time.Date(2025, 4, 9, 10, 0, 0, 0, time.UTC)
+	s := NewKeywordSuggester(DefaultKeywordRules())
+
+	cases := []struct {
+		title    string
+		tags     []string
+		priority string
+		due      string
+	}{
+		{title: "Buy milk tomorrow", tags: []string{"shopping"}, due: "2025-04-10"},
+		{title: "URGENT: pay rent", tags: []string{"finance"}, priority: PriorityHigh},
+		{title: "Prepare client report by Friday", tags: []string{"work"}, due: "2025-04-11"},
+		{title: "Dentist appointment next week", tags: []string{"health"}, due: "2025-04-14"},
+		{title: "Maybe learn the banjo someday", priority: PriorityLow},
+		{title: "Call mom in 3 days", tags: []string{"call"}, due: "2025-04-12"},
+		{title: "Wednesday standup notes", tags: []string{"work"}, due: "2025-04-16"},
+		// Substrings must not match: "running" is not "run", "bills" is not "bill"
+		{title: "Stop running out of coffee"},
+	}
+
+	for _, tc := range cases {
+		got, err := s.Suggest(context.Background(), Input{Title: tc.title, Now: now})
+		if err != nil {
+			t.Fatalf("%q: unexpected error %v", tc.title, err)
+		}
+		if !reflect.DeepEqual(got.Tags, tc.tags) {
+			t.Errorf("%q: tags = %v, want %v", tc.title, got.Tags, tc.tags)
+		}
+		gotPriority := ""
+		if got.Priority != nil {
+			gotPriority = *got.Priority
+		}
+		if gotPriority != tc.priority {
+			t.Errorf("%q: priority = %q, want %q", tc.title, gotPriority, tc.priority)
+		}
+		gotDue := ""
+		if got.DueDate != nil {
+			gotDue = got.DueDate.Format("2006-01-02")
+			if got.DueDate.Hour() != 17 {
+				t.Errorf("%q: expected due hour 17, got %d", tc.title, got.DueDate.Hour())
+			}
+		}
+		if gotDue != tc.due {
+			t.Errorf("%q: due = %q, want %q", tc.title, gotDue, tc.due)
+		}
+	}
+}
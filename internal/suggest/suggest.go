@@ -0,0 +1,38 @@
+// Package suggest proposes metadata (tags, priority, due date) for new todos.
+// The Suggester interface keeps the rule-based implementation swappable for
+// a model-backed one later.
+package suggest
+
+import (
+	"context"
+	"time"
+)
+
+// Priority levels, matching the values stored on todos.
+const (
+	PriorityLow    = "low"
+	PriorityNormal = "normal"
+	PriorityHigh   = "high"
+)
+
+// Input is what a suggester looks at.
+type Input struct {
+	Title string
+	// Now anchors relative dates such as "tomorrow"; its location is used as
+	// the user's time zone.
+	Now time.Time
+}
+
+// Suggestion holds proposed values. Nil/empty fields mean "no opinion".
+type Suggestion struct {
+	Tags     []string
+	Priority *string
+	DueDate  *time.Time
+	// Reasons explains each proposal, e.g. `"urgent" suggests high priority`.
+	Reasons []string
+}
+
+// Suggester proposes metadata for a todo.
+type Suggester interface {
+	Suggest(ctx context.Context, in Input) (Suggestion, error)
+}
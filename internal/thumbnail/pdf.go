@@ -0,0 +1,70 @@
+package thumbnail
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// PopplerRenderer renders PDF pages with poppler's pdftoppm, which is far
+// more faithful than anything we could reasonably implement ourselves.
+type PopplerRenderer struct {
+	path    string
+	timeout time.Duration
+}
+
+// NewPopplerRenderer returns a renderer using pdftoppm from PATH, or nil
+// when it isn't installed so PDF previews are simply skipped.
+func NewPopplerRenderer() *PopplerRenderer {
+	path, err := exec.LookPath("pdftoppm")
+	if err != nil {
+		return nil
+	}
+	return &PopplerRenderer{path: path, timeout: 30 * time.Second}
+}
+
+// RenderFirstPage implements PDFRenderer.
+func (p *PopplerRenderer) RenderFirstPage(ctx context.Context, pdf []byte, maxEdge int) (image.Image, error) {
+	dir, err := os.MkdirTemp("", "thumbnail-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	input := filepath.Join(dir, "input.pdf")
+	if err := os.WriteFile(input, pdf, 0o600); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	outputRoot := filepath.Join(dir, "page")
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, p.path,
+		"-png", "-f", "1", "-l", "1", "-singlefile",
+		"-scale-to", strconv.Itoa(maxEdge),
+		input, outputRoot)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("thumbnail: pdftoppm failed: %w: %s", err, bytes.TrimSpace(stderr.Bytes()))
+	}
+
+	f, err := os.Open(outputRoot + ".png")
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	img, err := png.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("thumbnail: decoding rendered page: %w", err)
+	}
+	return img, nil
+}
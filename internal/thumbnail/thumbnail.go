@@ -0,0 +1,184 @@
+// Package thumbnail renders small JPEG previews of uploaded images and PDFs.
+package thumbnail
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"strings"
+
+	// Register decoders for image.Decode
+	_ "image/gif"
+	_ "image/png"
+)
+
+// ContentType is the MIME type of every generated thumbnail.
+const ContentType = "image/jpeg"
+
+// maxSourcePixels guards against decompression bombs: a tiny PNG can
+// declare enormous dimensions and exhaust memory when decoded.
+const maxSourcePixels = 50_000_000
+
+// ErrUnsupported is returned for content types that can't be previewed.
+var ErrUnsupported = errors.New("thumbnail: unsupported content type")
+
+// Size is a named thumbnail size; the value is the longest edge in pixels.
+type Size struct {
+	Name   string
+	Pixels int
+}
+
+// Sizes lists the thumbnail sizes generated for every attachment, smallest first.
+var Sizes = []Size{
+	{Name: "small", Pixels: 128},
+	{Name: "medium", Pixels: 256},
+	{Name: "large", Pixels: 512},
+}
+
+// ParseSize looks up a size by name, defaulting to "small" when empty.
+func ParseSize(name string) (Size, error) {
+	if name == "" {
+		return Sizes[0], nil
+	}
+	for _, size := range Sizes {
+		if strings.EqualFold(size.Name, name) {
+			return size, nil
+		}
+	}
+	names := make([]string, len(Sizes))
+	for i, size := range Sizes {
+		names[i] = size.Name
+	}
+	return Size{}, fmt.Errorf("invalid thumbnail size %q, must be one of %s", name, strings.Join(names, ", "))
+}
+
+// PDFRenderer rasterizes the first page of a PDF, at least maxEdge pixels
+// on its longest side.
+type PDFRenderer interface {
+	RenderFirstPage(ctx context.Context, pdf []byte, maxEdge int) (image.Image, error)
+}
+
+// Generator turns source files into thumbnails.
+type Generator struct {
+	// PDF renders PDF previews; PDFs are unsupported when nil.
+	PDF PDFRenderer
+}
+
+// Supports reports whether previews can be generated for contentType.
+func (g *Generator) Supports(contentType string) bool {
+	switch contentType {
+	case "image/png", "image/jpeg", "image/gif":
+		return true
+	case "application/pdf":
+		return g.PDF != nil
+	}
+	return false
+}
+
+// Generate decodes src once and returns a JPEG for every size, keyed by size name.
+func (g *Generator) Generate(ctx context.Context, src []byte, contentType string) (map[string][]byte, error) {
+	if !g.Supports(contentType) {
+		return nil, ErrUnsupported
+	}
+
+	var img image.Image
+	var err error
+	if contentType == "application/pdf" {
+		img, err = g.PDF.RenderFirstPage(ctx, src, Sizes[len(Sizes)-1].Pixels)
+	} else {
+		img, err = decodeImage(src)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	thumbs := make(map[string][]byte, len(Sizes))
+	for _, size := range Sizes {
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, Fit(img, size.Pixels), &jpeg.Options{Quality: 80}); err != nil {
+			return nil, fmt.Errorf("thumbnail: encoding %s: %w", size.Name, err)
+		}
+		thumbs[size.Name] = buf.Bytes()
+	}
+	return thumbs, nil
+}
+
+func decodeImage(src []byte) (image.Image, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(src))
+	if err != nil {
+		return nil, fmt.Errorf("thumbnail: reading image header: %w", err)
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > maxSourcePixels {
+		return nil, fmt.Errorf("thumbnail: image dimensions %dx%d are out of range", cfg.Width, cfg.Height)
+	}
+	img, _, err := image.Decode(bytes.NewReader(src))
+	if err != nil {
+		return nil, fmt.Errorf("thumbnail: decoding image: %w", err)
+	}
+	return img, nil
+}
+
+// Fit scales img down so its longest edge is at most maxEdge, keeping the
+// aspect ratio, and flattens transparency onto white since JPEG has no alpha.
+// Images already small enough are only flattened.
+func Fit(img image.Image, maxEdge int) image.Image {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w > maxEdge || h > maxEdge {
+		if w >= h {
+			w, h = maxEdge, max(1, h*maxEdge/w)
+		} else {
+			w, h = max(1, w*maxEdge/h), maxEdge
+		}
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	if w == b.Dx() && h == b.Dy() {
+		draw.Draw(dst, dst.Bounds(), img, b.Min, draw.Over)
+		return dst
+	}
+	scaleBox(dst, img)
+	return dst
+}
+
+// scaleBox downsamples src into dst by averaging every source pixel that
+// falls inside each destination pixel, which avoids the aliasing of
+// nearest-neighbour sampling without needing golang.org/x/image.
+func scaleBox(dst *image.RGBA, src image.Image) {
+	sb := src.Bounds()
+	dw, dh := dst.Bounds().Dx(), dst.Bounds().Dy()
+	sw, sh := sb.Dx(), sb.Dy()
+
+	for dy := 0; dy < dh; dy++ {
+		y0 := sb.Min.Y + dy*sh/dh
+		y1 := max(y0+1, sb.Min.Y+(dy+1)*sh/dh)
+		for dx := 0; dx < dw; dx++ {
+			x0 := sb.Min.X + dx*sw/dw
+			x1 := max(x0+1, sb.Min.X+(dx+1)*sw/dw)
+
+			var r, g, b, a, n uint64
+			for y := y0; y < y1; y++ {
+				for x := x0; x < x1; x++ {
+					cr, cg, cb, ca := src.At(x, y).RGBA()
+					r, g, b, a = r+uint64(cr), g+uint64(cg), b+uint64(cb), a+uint64(ca)
+					n++
+				}
+			}
+			// Colours are alpha-premultiplied; composite over white
+			r, g, b, a = r/n, g/n, b/n, a/n
+			white := 0xffff - a
+			dst.SetRGBA(dx, dy, color.RGBA{
+				R: uint8((r + white) >> 8),
+				G: uint8((g + white) >> 8),
+				B: uint8((b + white) >> 8),
+				A: 0xff,
+			})
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package thumbnail
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+func encodePNG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestParseSize(t *testing.T) {
+	if s, err := ParseSize(""); err != nil || s.Name != "small" {
+		t.Errorf("ParseSize(\"\") = %+v, %v; want small", s, err)
+	}
+	if s, err := ParseSize("LARGE"); err != nil || s.Pixels != 512 {
+		t.Errorf("ParseSize(\"LARGE\") = %+v, %v; want large", s, err)
+	}
+	if _, err := ParseSize("huge"); err == nil {
+		t.Error("expected error for unknown size")
+	}
+}
+
+func TestFitKeepsAspectRatio(t *testing.T) {
+	got := Fit(image.NewRGBA(image.Rect(0, 0, 1000, 250)), 128).Bounds()
+	if got.Dx() != 128 || got.Dy() != 32 {
+		t.Errorf("Fit 1000x250 to 128 = %dx%d, want 128x32", got.Dx(), got.Dy())
+	}
+	got = Fit(image.NewRGBA(image.Rect(0, 0, 40, 60)), 128).Bounds()
+	if got.Dx() != 40 || got.Dy() != 60 {
+		t.Errorf("Fit should not upscale, got %dx%d", got.Dx(), got.Dy())
+	}
+}
+
+func TestFitFlattensTransparencyOntoWhite(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 300, 300)) // fully transparent
+	for _, edge := range []int{100, 300} {
+		c := Fit(src, edge).At(0, 0).(color.RGBA)
+		if c.R != 0xff || c.G != 0xff || c.B != 0xff {
+			t.Errorf("edge %d: expected white, got %+v", edge, c)
+		}
+	}
+}
+
+func TestGenerateImage(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 800, 600))
+	for i := range src.Pix {
+		src.Pix[i] = 0x80
+	}
+
+	thumbs, err := (&Generator{}).Generate(context.Background(), encodePNG(t, src), "image/png")
+	if err != nil {
+		t.Fatalf("Generate returned error: %v", err)
+	}
+	for _, size := range Sizes {
+		img, err := jpeg.Decode(bytes.NewReader(thumbs[size.Name]))
+		if err != nil {
+			t.Fatalf("%s: invalid JPEG: %v", size.Name, err)
+		}
+		if img.Bounds().Dx() != size.Pixels {
+			t.Errorf("%s: width %d, want %d", size.Name, img.Bounds().Dx(), size.Pixels)
+		}
+	}
+}
+
+func TestGenerateRejectsUnsupported(t *testing.T) {
+	g := &Generator{}
+	if g.Supports("application/pdf") {
+		t.Error("PDF should be unsupported without a renderer")
+	}
+	if _, err := g.Generate(context.Background(), []byte("%PDF-1.4"), "application/pdf"); err != ErrUnsupported {
+		t.Errorf("expected ErrUnsupported, got %v", err)
+	}
+}
+
+func TestGenerateRejectsHugeDimensions(t *testing.T) {
+	// A valid PNG header claiming 100000x100000 pixels
+	var buf bytes.Buffer
+	_ = png.Encode(&buf, image.NewGray(image.Rect(0, 0, 1, 1)))
+	data := buf.Bytes()
+	copy(data[16:24], []byte{0, 1, 0x86, 0xa0, 0, 1, 0x86, 0xa0})
+
+	if _, err := (&Generator{}).Generate(context.Background(), data, "image/png"); err == nil {
+		t.Error("expected error for oversized image")
+	}
+}
@@ -0,0 +1,75 @@
+// Package tracing sets up OpenTelemetry tracing. Spans are started from
+// the request context, so a request's HTTP, service and database spans end
+// up in one trace, exported over OTLP/HTTP.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ServiceName names the service in traces unless OTEL_SERVICE_NAME is set.
+const ServiceName = "todo-backend"
+
+// tracer starts the application's own spans. It goes through the global
+// provider, so spans are dropped until Setup installed one.
+var tracer = otel.Tracer("github.com/Tomlord1122/todo-backend")
+
+// Enabled reports whether traces are exported: OTEL_EXPORTER_OTLP_ENDPOINT
+// or OTEL_EXPORTER_OTLP_TRACES_ENDPOINT is set.
+func Enabled() bool {
+	return os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") != "" || os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") != ""
+}
+
+// Setup installs a tracer provider exporting spans in batches to the OTLP
+// endpoint and propagating W3C trace context. The exporter and sampler are
+// configured with the standard OTEL_* variables, e.g.
+// OTEL_EXPORTER_OTLP_HEADERS or OTEL_TRACES_SAMPLER. The provider must be
+// shut down to flush the last spans.
+func Setup(ctx context.Context) (*sdktrace.TracerProvider, error) {
+	exporter, err := otlptracehttp.New(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("creating the OTLP exporter: %w", err)
+	}
+	// OTEL_SERVICE_NAME and OTEL_RESOURCE_ATTRIBUTES, read by the default
+	// resource, take precedence over the service name set here
+	res, err := resource.Merge(
+		resource.NewSchemaless(semconv.ServiceName(ServiceName)),
+		resource.Default(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("creating the trace resource: %w", err)
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	return provider, nil
+}
+
+// Start starts a span named name as a child of the span in ctx, if any.
+// The caller must end it.
+func Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return tracer.Start(ctx, name, opts...)
+}
+
+// TraceID returns the ID of the trace ctx belongs to, or "" when it isn't
+// traced.
+func TraceID(ctx context.Context) string {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.HasTraceID() {
+		return ""
+	}
+	return sc.TraceID().String()
+}
@@ -0,0 +1,36 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestStartNestsSpansAndReportsTheTraceID(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	otel.SetTracerProvider(provider)
+
+	if got := TraceID(context.Background()); got != "" {
+		t.Errorf("TraceID without a span = %q, want empty", got)
+	}
+
+	ctx, parent := Start(context.Background(), "parent")
+	_, child := Start(ctx, "child")
+	child.End()
+	parent.End()
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("recorded %d spans, want 2", len(spans))
+	}
+	if got, want := spans[0].Parent().SpanID(), spans[1].SpanContext().SpanID(); got != want {
+		t.Errorf("child's parent span = %s, want %s", got, want)
+	}
+	if got, want := TraceID(ctx), spans[1].SpanContext().TraceID().String(); got != want {
+		t.Errorf("TraceID = %q, want %q", got, want)
+	}
+}
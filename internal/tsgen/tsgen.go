@@ -0,0 +1,291 @@
+// Package tsgen generates TypeScript interfaces and a small fetch-based
+// client from the Go DTOs listed in apispec, so frontends stay in sync with
+// the API without hand-written types.
+package tsgen
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/Tomlord1122/todo-backend/internal/apispec"
+)
+
+// Header is written at the top of every generated file.
+const Header = "// Code generated by go run ./cmd/gen ts; DO NOT EDIT.\n"
+
+var timeType = reflect.TypeFor[time.Time]()
+
+type generator struct {
+	// named holds the TypeScript declaration for every emitted struct type
+	named map[string]string
+	// inputs are types used as request bodies; their pointer fields may be omitted
+	inputs map[reflect.Type]bool
+	seen   map[reflect.Type]bool
+}
+
+// Generate writes TypeScript declarations for every DTO reachable from
+// endpoints, followed by a client with one method per endpoint.
+func Generate(w io.Writer, endpoints []apispec.Endpoint) error {
+	g := &generator{
+		named:  make(map[string]string),
+		inputs: make(map[reflect.Type]bool),
+		seen:   make(map[reflect.Type]bool),
+	}
+	for _, ep := range endpoints {
+		if ep.Request != nil {
+			g.inputs[deref(ep.Request)] = true
+		}
+	}
+
+	type method struct {
+		ep            apispec.Endpoint
+		req, resp     string
+		params, query []string
+	}
+	methods := make([]method, 0, len(endpoints))
+	for _, ep := range endpoints {
+		m := method{ep: ep, req: "", resp: "void", params: pathParams(ep.Path), query: ep.Query}
+		if ep.Request != nil {
+			m.req = g.typeExpr(ep.Request)
+		}
+		if ep.Response != nil {
+			m.resp = g.typeExpr(ep.Response)
+		}
+		methods = append(methods, m)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(Header)
+
+	names := make([]string, 0, len(g.named))
+	for name := range g.named {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		buf.WriteString("\n")
+		buf.WriteString(g.named[name])
+	}
+
+	buf.WriteString(clientPrelude)
+	for _, m := range methods {
+		var args []string
+		for _, p := range m.params {
+			args = append(args, fmt.Sprintf("%s: %s", tsIdent(p), paramType(p)))
+		}
+		if m.req != "" {
+			args = append(args, "body: "+m.req)
+		}
+		if len(m.query) > 0 {
+			fields := make([]string, len(m.query))
+			for i, q := range m.query {
+				fields[i] = fmt.Sprintf("%s?: string | number", q)
+			}
+			args = append(args, fmt.Sprintf("query: { %s } = {}", strings.Join(fields, "; ")))
+		}
+
+		path := apispec.BasePath + m.ep.Path
+		for _, p := range m.params {
+			path = strings.ReplaceAll(path, "{"+p+"}", "${encodeURIComponent(String("+tsIdent(p)+"))}")
+		}
+		body, query := "undefined", "undefined"
+		if m.req != "" {
+			body = "body"
+		}
+		if len(m.query) > 0 {
+			query = "query"
+		}
+		fmt.Fprintf(&buf, "    %s: (%s) =>\n      request<%s>(%q, `%s`, %s, %s),\n",
+			m.ep.Name, strings.Join(args, ", "), m.resp, m.ep.Method, path, body, query)
+	}
+	buf.WriteString("  };\n}\n")
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// typeExpr returns the TypeScript type for t, declaring named structs as needed.
+func (g *generator) typeExpr(t reflect.Type) string {
+	if t == timeType {
+		return "string"
+	}
+	switch t.Kind() {
+	case reflect.Pointer:
+		return g.typeExpr(t.Elem()) + " | null"
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Slice, reflect.Array:
+		elem := g.typeExpr(t.Elem())
+		if strings.Contains(elem, " ") {
+			elem = "(" + elem + ")"
+		}
+		return elem + "[]"
+	case reflect.Map:
+		return fmt.Sprintf("Record<string, %s>", g.typeExpr(t.Elem()))
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.structBody(t, "")
+		}
+		if !g.seen[t] {
+			g.seen[t] = true
+			g.named[t.Name()] = fmt.Sprintf("export interface %s %s\n", t.Name(), g.structBody(t, ""))
+		}
+		return t.Name()
+	}
+	return "unknown"
+}
+
+// structBody renders the fields of t as a TypeScript object type.
+func (g *generator) structBody(t reflect.Type, indent string) string {
+	var b strings.Builder
+	b.WriteString("{\n")
+	g.writeFields(&b, t, g.inputs[t], indent)
+	b.WriteString(indent + "}")
+	return b.String()
+}
+
+// writeFields writes one line per JSON field of t. Embedded structs without
+// a JSON name are flattened, as encoding/json does.
+func (g *generator) writeFields(b *strings.Builder, t reflect.Type, input bool, indent string) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if f.Anonymous && name == "" && deref(f.Type).Kind() == reflect.Struct {
+			g.writeFields(b, deref(f.Type), input, indent)
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		optional := strings.Contains(opts, "omitempty") ||
+			(input && f.Type.Kind() == reflect.Pointer)
+
+		typ := g.typeExpr(f.Type)
+		if f.Type.Kind() == reflect.Struct && f.Type.Name() == "" {
+			typ = strings.ReplaceAll(typ, "\n", "\n  ")
+		}
+		b.WriteString(indent + "  " + name)
+		if optional {
+			b.WriteString("?")
+		}
+		b.WriteString(": " + typ + ";\n")
+	}
+}
+
+func deref(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	return t
+}
+
+// pathParams returns the {param} names in a chi route pattern, in order.
+func pathParams(path string) []string {
+	var params []string
+	for {
+		start := strings.IndexByte(path, '{')
+		if start < 0 {
+			return params
+		}
+		end := strings.IndexByte(path[start:], '}')
+		if end < 0 {
+			return params
+		}
+		params = append(params, path[start+1:start+end])
+		path = path[start+end+1:]
+	}
+}
+
+// paramType treats id parameters as numbers and everything else as strings.
+func paramType(param string) string {
+	if strings.HasSuffix(param, "id") || strings.HasSuffix(param, "ID") {
+		return "number"
+	}
+	return "string"
+}
+
+// tsIdent makes a path parameter usable as a TypeScript identifier.
+func tsIdent(param string) string {
+	var b strings.Builder
+	for _, r := range param {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+const clientPrelude = `
+export class ApiError extends Error {
+  constructor(
+    public readonly status: number,
+    message: string,
+    public readonly body?: unknown,
+  ) {
+    super(message);
+    this.name = "ApiError";
+  }
+}
+
+export interface ClientOptions {
+  baseUrl: string;
+  fetch?: typeof fetch;
+  headers?: Record<string, string> | (() => Record<string, string>);
+}
+
+export function createClient(options: ClientOptions) {
+  const doFetch = options.fetch ?? fetch;
+  const baseUrl = options.baseUrl.replace(/\/+$/, "");
+
+  async function request<T>(
+    method: string,
+    path: string,
+    body?: unknown,
+    query?: Record<string, string | number | undefined>,
+  ): Promise<T> {
+    const params = new URLSearchParams();
+    for (const [key, value] of Object.entries(query ?? {})) {
+      if (value !== undefined) params.set(key, String(value));
+    }
+    const qs = params.toString();
+    const extra = typeof options.headers === "function" ? options.headers() : options.headers;
+    const res = await doFetch(baseUrl + path + (qs ? "?" + qs : ""), {
+      method,
+      headers: {
+        Accept: "application/json",
+        ...(body !== undefined ? { "Content-Type": "application/json" } : {}),
+        ...extra,
+      },
+      body: body !== undefined ? JSON.stringify(body) : undefined,
+    });
+    if (!res.ok) {
+      const data = await res.json().catch(() => undefined);
+      // Errors are RFC 7807 problem details
+      const problem = data as { title?: string; detail?: string } | undefined;
+      const message = problem?.detail ?? problem?.title ?? res.statusText;
+      throw new ApiError(res.status, message, data);
+    }
+    if (res.status === 204) return undefined as T;
+    return (await res.json()) as T;
+  }
+
+  return {
+`
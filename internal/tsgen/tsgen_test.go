@@ -0,0 +1,56 @@
+package tsgen
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Tomlord1122/todo-backend/internal/apispec"
+)
+
+type widgetRequest struct {
+	Name  string  `json:"name"`
+	Color *string `json:"color"`
+}
+
+type widgetBase struct {
+	ID uint `json:"id"`
+}
+
+type widgetResponse struct {
+	widgetBase
+	Tags    []string          `json:"tags"`
+	Labels  map[string]string `json:"labels,omitempty"`
+	DoneAt  *time.Time        `json:"done_at"`
+	Hidden  string            `json:"-"`
+	private int
+}
+
+func TestGenerate(t *testing.T) {
+	var out strings.Builder
+	err := Generate(&out, []apispec.Endpoint{
+		{Name: "createWidget", Method: "POST", Path: "/widgets", Request: reflect.TypeFor[widgetRequest](), Response: reflect.TypeFor[widgetResponse]()},
+		{Name: "deleteWidget", Method: "DELETE", Path: "/widgets/{id}/parts/{slug}"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := out.String()
+
+	for _, want := range []string{
+		Header,
+		"export interface widgetRequest {\n  name: string;\n  color?: string | null;\n}",
+		"export interface widgetResponse {\n  id: number;\n  tags: string[];\n  labels?: Record<string, string>;\n  done_at: string | null;\n}",
+		"createWidget: (body: widgetRequest) =>\n      request<widgetResponse>(\"POST\", `/api/v1/widgets`, body, undefined)",
+		"deleteWidget: (id: number, slug: string) =>",
+		"request<void>(\"DELETE\", `/api/v1/widgets/${encodeURIComponent(String(id))}/parts/${encodeURIComponent(String(slug))}`",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output missing %q\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "Hidden") || strings.Contains(got, "private") {
+		t.Errorf("output includes skipped fields\n%s", got)
+	}
+}
@@ -0,0 +1,85 @@
+// Package validate checks the validate tags of request types, so every API
+// (HTTP, gRPC, GraphQL) accepts the same requests and describes invalid
+// fields the same way.
+package validate
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/go-playground/validator/v10/non-standard/validators"
+)
+
+// FieldError is a field that failed its validate tag.
+type FieldError struct {
+	// Field is the JSON name of the field, dotted for nested fields
+	Field string
+	// Rule is the failed tag, with notblank reported as required
+	Rule string
+	// Reason describes the rule to the client, e.g. "is required"
+	Reason string
+}
+
+// rules checks the tags. Besides the validator's own rules, notblank requires
+// a string that isn't blank, which is how required text fields are tagged.
+var rules = func() *validator.Validate {
+	v := validator.New(validator.WithRequiredStructEnabled())
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+	if err := v.RegisterValidation("notblank", validators.NotBlank); err != nil {
+		panic(err)
+	}
+	return v
+}()
+
+// Struct returns the fields of v, a struct, that fail their validate tags,
+// or nil when it is valid.
+func Struct(v any) []FieldError {
+	var invalid validator.ValidationErrors
+	if err := rules.Struct(v); errors.As(err, &invalid) {
+		fields := make([]FieldError, 0, len(invalid))
+		for _, fe := range invalid {
+			fields = append(fields, fieldError(fe))
+		}
+		return fields
+	} else if err != nil {
+		// Only a bad validate tag gets here, which tests catch
+		panic(err)
+	}
+	return nil
+}
+
+func fieldError(fe validator.FieldError) FieldError {
+	// The namespace starts with the request type's name
+	_, name, _ := strings.Cut(fe.Namespace(), ".")
+	field := FieldError{Field: name, Rule: fe.Tag()}
+	var unit string
+	switch fe.Kind() {
+	case reflect.String:
+		unit = " characters"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		unit = " items"
+	}
+	switch fe.Tag() {
+	case "required", "notblank":
+		// A blank string is as good as a missing one
+		field.Rule = "required"
+		field.Reason = "is required"
+	case "max", "lte":
+		field.Reason = "must be at most " + fe.Param() + unit
+	case "min", "gte":
+		field.Reason = "must be at least " + fe.Param() + unit
+	case "oneof":
+		field.Reason = "must be one of " + strings.ReplaceAll(fe.Param(), " ", ", ")
+	default:
+		field.Reason = "must satisfy " + fe.Tag()
+	}
+	return field
+}
@@ -0,0 +1,8 @@
+//go:build !embedui
+
+package web
+
+import "io/fs"
+
+// embedded is nil unless built with -tags embedui.
+var embedded fs.FS
@@ -0,0 +1,15 @@
+//go:build embedui
+
+package web
+
+import (
+	"embed"
+	"io/fs"
+)
+
+// Copy the frontend build output into dist before building with -tags embedui.
+//
+//go:embed all:dist
+var embeddedFiles embed.FS
+
+var embedded fs.FS = embeddedFiles
@@ -0,0 +1,120 @@
+// Package web serves a bundled single-page frontend alongside the API.
+//
+// The UI is optional. Build with -tags embedui to embed the contents of
+// internal/web/dist into the binary, or set WEB_DIR to serve a build from
+// disk (handy while developing the frontend). Without either, no UI routes
+// are registered.
+package web
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+// FS returns the frontend files: WEB_DIR when set, otherwise the embedded
+// build. ok is false when there is no UI to serve.
+func FS() (fsys fs.FS, ok bool) {
+	if dir := os.Getenv("WEB_DIR"); dir != "" {
+		return os.DirFS(dir), true
+	}
+	if embedded == nil {
+		return nil, false
+	}
+	sub, err := fs.Sub(embedded, "dist")
+	if err != nil {
+		return nil, false
+	}
+	// An embed with only the placeholder file counts as no UI
+	if _, err := fs.Stat(sub, "index.html"); err != nil {
+		return nil, false
+	}
+	return sub, true
+}
+
+// SPAHandler serves static files from fsys. Browser navigations to paths
+// that don't exist and look like client-side routes (no file extension)
+// get index.html, so deep links work after a reload. API clients still get
+// a plain 404 for unknown paths.
+type SPAHandler struct {
+	fsys fs.FS
+	// startedAt stands in for modification times, which embedded files lack
+	startedAt time.Time
+}
+
+// NewSPAHandler creates a handler serving fsys.
+func NewSPAHandler(fsys fs.FS) *SPAHandler {
+	return &SPAHandler{fsys: fsys, startedAt: time.Now()}
+}
+
+// ServeHTTP implements http.Handler.
+func (h *SPAHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.NotFound(w, r)
+		return
+	}
+
+	name := strings.TrimPrefix(path.Clean("/"+r.URL.Path), "/")
+	if name == "" {
+		name = "index.html"
+	}
+
+	f, err := h.fsys.Open(name)
+	if err == nil {
+		if info, statErr := f.Stat(); statErr != nil || info.IsDir() {
+			f.Close()
+			err = fs.ErrNotExist
+		}
+	}
+	if errors.Is(err, fs.ErrNotExist) && path.Ext(name) == "" && acceptsHTML(r) {
+		// Client-side route: let the SPA router handle it
+		name = "index.html"
+		f, err = h.fsys.Open(name)
+	}
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	defer f.Close()
+
+	w.Header().Set("Cache-Control", cacheControl(name))
+	modTime := h.startedAt
+	if info, err := f.Stat(); err == nil && !info.ModTime().IsZero() {
+		modTime = info.ModTime()
+	}
+	content, ok := f.(io.ReadSeeker)
+	if !ok {
+		data, err := io.ReadAll(f)
+		if err != nil {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		content = bytes.NewReader(data)
+	}
+	http.ServeContent(w, r, name, modTime, content)
+}
+
+// cacheControl lets browsers keep fingerprinted build assets forever, while
+// index.html (which references the current fingerprints) is always revalidated.
+func cacheControl(name string) string {
+	switch {
+	case name == "index.html":
+		return "no-cache"
+	case strings.HasPrefix(name, "assets/"):
+		// Vite, Svelte and friends emit content-hashed files here
+		return "public, max-age=31536000, immutable"
+	default:
+		return "public, max-age=3600"
+	}
+}
+
+// acceptsHTML reports whether the request is a browser navigation.
+func acceptsHTML(r *http.Request) bool {
+	return strings.Contains(r.Header.Get("Accept"), "text/html")
+}
@@ -0,0 +1,56 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestSPAHandler(t *testing.T) {
+	h := NewSPAHandler(fstest.MapFS{
+		"index.html":         {Data: []byte("<html>app</html>")},
+		"assets/app-1a2b.js": {Data: []byte("console.log(1)")},
+		"favicon.ico":        {Data: []byte("ico")},
+	})
+
+	cases := []struct {
+		path      string
+		wantCode  int
+		wantBody  string
+		wantCache string
+	}{
+		{"/", http.StatusOK, "<html>app</html>", "no-cache"},
+		{"/todos/42", http.StatusOK, "<html>app</html>", "no-cache"},
+		{"/assets/app-1a2b.js", http.StatusOK, "console.log(1)", "public, max-age=31536000, immutable"},
+		{"/favicon.ico", http.StatusOK, "ico", "public, max-age=3600"},
+		{"/assets/missing.js", http.StatusNotFound, "", ""},
+		{"/api-like/path", http.StatusNotFound, "", ""},                      // not a navigation
+		{"/../../etc/passwd", http.StatusOK, "<html>app</html>", "no-cache"}, // cleaned, never escapes fsys
+	}
+	for _, tc := range cases {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+		if tc.path != "/api-like/path" {
+			req.Header.Set("Accept", "text/html,application/xhtml+xml")
+		}
+		h.ServeHTTP(rec, req)
+		if rec.Code != tc.wantCode {
+			t.Errorf("%s: status %d, want %d", tc.path, rec.Code, tc.wantCode)
+			continue
+		}
+		if tc.wantBody != "" && !strings.Contains(rec.Body.String(), tc.wantBody) {
+			t.Errorf("%s: body %q, want %q", tc.path, rec.Body.String(), tc.wantBody)
+		}
+		if got := rec.Header().Get("Cache-Control"); got != tc.wantCache && tc.wantCache != "" {
+			t.Errorf("%s: Cache-Control %q, want %q", tc.path, got, tc.wantCache)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/todos/42", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("POST: status %d, want 404", rec.Code)
+	}
+}
@@ -0,0 +1,175 @@
+package webauthn
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// maxCBORDepth bounds nesting so hostile input can't exhaust the stack.
+const maxCBORDepth = 16
+
+var errCBORTruncated = errors.New("cbor: unexpected end of data")
+
+// decodeCBOR decodes the first CBOR item in data and returns it with the
+// bytes that follow it. It covers what authenticators produce: integers
+// (as int64), byte and text strings, arrays, maps (keyed by int64 or
+// string), tags (which are dropped), booleans, null and floats.
+// Indefinite-length items are rejected; CTAP2 requires definite lengths.
+func decodeCBOR(data []byte) (any, []byte, error) {
+	return decodeCBORItem(data, 0)
+}
+
+func decodeCBORItem(data []byte, depth int) (any, []byte, error) {
+	if depth > maxCBORDepth {
+		return nil, nil, errors.New("cbor: nested too deeply")
+	}
+	if len(data) == 0 {
+		return nil, nil, errCBORTruncated
+	}
+	major, info := data[0]>>5, data[0]&0x1f
+	data = data[1:]
+
+	if major == 7 {
+		return decodeCBORSimple(info, data)
+	}
+	arg, data, err := cborArgument(info, data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	switch major {
+	case 0:
+		if arg > math.MaxInt64 {
+			return nil, nil, errors.New("cbor: integer out of range")
+		}
+		return int64(arg), data, nil
+	case 1:
+		if arg > math.MaxInt64 {
+			return nil, nil, errors.New("cbor: integer out of range")
+		}
+		return -1 - int64(arg), data, nil
+	case 2, 3:
+		if uint64(len(data)) < arg {
+			return nil, nil, errCBORTruncated
+		}
+		if major == 2 {
+			return append([]byte(nil), data[:arg]...), data[arg:], nil
+		}
+		return string(data[:arg]), data[arg:], nil
+	case 4:
+		// Every item takes at least one byte, which bounds the allocation
+		if uint64(len(data)) < arg {
+			return nil, nil, errCBORTruncated
+		}
+		items := make([]any, 0, arg)
+		for i := uint64(0); i < arg; i++ {
+			var item any
+			item, data, err = decodeCBORItem(data, depth+1)
+			if err != nil {
+				return nil, nil, err
+			}
+			items = append(items, item)
+		}
+		return items, data, nil
+	case 5:
+		if uint64(len(data)) < 2*arg {
+			return nil, nil, errCBORTruncated
+		}
+		m := make(map[any]any, arg)
+		for i := uint64(0); i < arg; i++ {
+			var key, value any
+			key, data, err = decodeCBORItem(data, depth+1)
+			if err != nil {
+				return nil, nil, err
+			}
+			switch key.(type) {
+			case int64, string:
+			default:
+				return nil, nil, fmt.Errorf("cbor: unsupported map key type %T", key)
+			}
+			value, data, err = decodeCBORItem(data, depth+1)
+			if err != nil {
+				return nil, nil, err
+			}
+			if _, dup := m[key]; dup {
+				return nil, nil, fmt.Errorf("cbor: duplicate map key %v", key)
+			}
+			m[key] = value
+		}
+		return m, data, nil
+	default: // 6, a tag: the tagged item is all we need
+		return decodeCBORItem(data, depth+1)
+	}
+}
+
+// cborArgument reads the length or value that follows an initial byte.
+func cborArgument(info byte, data []byte) (uint64, []byte, error) {
+	switch {
+	case info < 24:
+		return uint64(info), data, nil
+	case info <= 27:
+		size := 1 << (info - 24)
+		if len(data) < size {
+			return 0, nil, errCBORTruncated
+		}
+		var arg uint64
+		for _, b := range data[:size] {
+			arg = arg<<8 | uint64(b)
+		}
+		return arg, data[size:], nil
+	case info == 31:
+		return 0, nil, errors.New("cbor: indefinite-length items are not supported")
+	default:
+		return 0, nil, fmt.Errorf("cbor: invalid additional information %d", info)
+	}
+}
+
+func decodeCBORSimple(info byte, data []byte) (any, []byte, error) {
+	switch info {
+	case 20:
+		return false, data, nil
+	case 21:
+		return true, data, nil
+	case 22, 23:
+		return nil, data, nil
+	case 25:
+		if len(data) < 2 {
+			return nil, nil, errCBORTruncated
+		}
+		return float64(halfToFloat(binary.BigEndian.Uint16(data))), data[2:], nil
+	case 26:
+		if len(data) < 4 {
+			return nil, nil, errCBORTruncated
+		}
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(data))), data[4:], nil
+	case 27:
+		if len(data) < 8 {
+			return nil, nil, errCBORTruncated
+		}
+		return math.Float64frombits(binary.BigEndian.Uint64(data)), data[8:], nil
+	default:
+		return nil, nil, fmt.Errorf("cbor: unsupported simple value %d", info)
+	}
+}
+
+// halfToFloat converts an IEEE 754 half-precision float.
+func halfToFloat(h uint16) float32 {
+	sign := uint32(h>>15) << 31
+	exp := uint32(h>>10) & 0x1f
+	frac := uint32(h) & 0x3ff
+	switch exp {
+	case 0:
+		// Subnormal: frac * 2^-24
+		f := float32(frac) / (1 << 24)
+		if sign != 0 {
+			f = -f
+		}
+		return f
+	case 0x1f:
+		return math.Float32frombits(sign | 0xff<<23 | frac<<13)
+	default:
+		return math.Float32frombits(sign | (exp+112)<<23 | frac<<13)
+	}
+}
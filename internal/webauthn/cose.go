@@ -0,0 +1,114 @@
+package webauthn
+
+import (
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// COSE algorithm identifiers of the supported public keys.
+const (
+	AlgES256 = -7
+	AlgEdDSA = -8
+	AlgRS256 = -257
+)
+
+// SupportedAlgorithms are offered to authenticators in order of preference.
+var SupportedAlgorithms = []int{AlgES256, AlgEdDSA, AlgRS256}
+
+// COSE key parameters (RFC 9052, RFC 9053).
+const (
+	coseKty = 1
+	coseAlg = 3
+	// The meaning of the negative labels depends on the key type
+	coseCrvOrN = -1
+	coseXOrE   = -2
+	coseY      = -3
+
+	coseKtyOKP = 1
+	coseKtyEC2 = 2
+	coseKtyRSA = 3
+
+	coseCrvP256    = 1
+	coseCrvEd25519 = 6
+)
+
+// publicKey verifies assertion signatures.
+type publicKey struct {
+	alg int64
+	key crypto.PublicKey
+}
+
+// parsePublicKey decodes a COSE_Key. It returns the key and the bytes that
+// follow it.
+func parsePublicKey(data []byte) (*publicKey, []byte, error) {
+	item, rest, err := decodeCBOR(data)
+	if err != nil {
+		return nil, nil, fmt.Errorf("public key: %w", err)
+	}
+	m, ok := item.(map[any]any)
+	if !ok {
+		return nil, nil, errors.New("public key is not a COSE key")
+	}
+	kty, _ := m[int64(coseKty)].(int64)
+	alg, _ := m[int64(coseAlg)].(int64)
+	crv, _ := m[int64(coseCrvOrN)].(int64)
+
+	switch {
+	case kty == coseKtyEC2 && alg == AlgES256:
+		x, _ := m[int64(coseXOrE)].([]byte)
+		y, _ := m[int64(coseY)].([]byte)
+		if crv != coseCrvP256 || len(x) != 32 || len(y) != 32 {
+			return nil, nil, errors.New("public key is not a valid P-256 key")
+		}
+		// crypto/ecdh checks the point is on the curve
+		point := append(append([]byte{4}, x...), y...)
+		if _, err := ecdh.P256().NewPublicKey(point); err != nil {
+			return nil, nil, fmt.Errorf("public key: %w", err)
+		}
+		key := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		return &publicKey{alg: alg, key: key}, rest, nil
+
+	case kty == coseKtyOKP && alg == AlgEdDSA:
+		x, _ := m[int64(coseXOrE)].([]byte)
+		if crv != coseCrvEd25519 || len(x) != ed25519.PublicKeySize {
+			return nil, nil, errors.New("public key is not a valid Ed25519 key")
+		}
+		return &publicKey{alg: alg, key: ed25519.PublicKey(x)}, rest, nil
+
+	case kty == coseKtyRSA && alg == AlgRS256:
+		n, _ := m[int64(coseCrvOrN)].([]byte)
+		e, _ := m[int64(coseXOrE)].([]byte)
+		if len(n) < 256 || len(e) == 0 || len(e) > 4 {
+			return nil, nil, errors.New("public key is not a valid RSA key of at least 2048 bits")
+		}
+		exponent := int(new(big.Int).SetBytes(e).Int64())
+		return &publicKey{alg: alg, key: &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: exponent}}, rest, nil
+
+	default:
+		return nil, nil, fmt.Errorf("public key type %d with algorithm %d is not supported", kty, alg)
+	}
+}
+
+// verify checks sig over data.
+func (k *publicKey) verify(data, sig []byte) bool {
+	switch key := k.key.(type) {
+	case *ecdsa.PublicKey:
+		digest := sha256.Sum256(data)
+		return ecdsa.VerifyASN1(key, digest[:], sig)
+	case ed25519.PublicKey:
+		return ed25519.Verify(key, data, sig)
+	case *rsa.PublicKey:
+		digest := sha256.Sum256(data)
+		return rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], sig) == nil
+	default:
+		return false
+	}
+}
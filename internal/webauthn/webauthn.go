@@ -0,0 +1,463 @@
+// Package webauthn runs the server side of WebAuthn (passkey) registration
+// and login: it builds the options passed to navigator.credentials.create
+// and .get and verifies what the browser sends back. Like the other
+// integrations it is written on the standard library. ES256, EdDSA and
+// RS256 keys are supported. Attestation is not requested ("none"), so any
+// authenticator is accepted, as is usual for passkeys.
+package webauthn
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Config identifies the relying party, the site passkeys are bound to.
+type Config struct {
+	// RPID is the domain passkeys are scoped to, e.g. "todo.example.com".
+	RPID string
+	// RPName is shown by the authenticator when creating a passkey.
+	RPName string
+	// Origins are the web origins allowed to use the passkeys, e.g.
+	// "https://todo.example.com".
+	Origins []string
+	// Timeout is how long the user has to complete a ceremony.
+	Timeout time.Duration
+}
+
+// RelyingParty creates and verifies ceremonies for one site.
+type RelyingParty struct {
+	cfg      Config
+	rpIDHash [32]byte
+}
+
+// NewRelyingParty creates a RelyingParty for cfg.
+func NewRelyingParty(cfg Config) *RelyingParty {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 5 * time.Minute
+	}
+	return &RelyingParty{cfg: cfg, rpIDHash: sha256.Sum256([]byte(cfg.RPID))}
+}
+
+// Timeout is how long a ceremony may take.
+func (rp *RelyingParty) Timeout() time.Duration {
+	return rp.cfg.Timeout
+}
+
+// NewChallenge returns a random base64url challenge.
+func NewChallenge() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return encode(b), nil
+}
+
+// User is the account a passkey is created for.
+type User struct {
+	// ID is the opaque user handle stored on the authenticator. It is
+	// returned on login, so it must not contain personal data.
+	ID          []byte
+	Name        string
+	DisplayName string
+}
+
+// CreationOptions is the JSON form of PublicKeyCredentialCreationOptions,
+// for PublicKeyCredential.parseCreationOptionsFromJSON.
+type CreationOptions struct {
+	Challenge              string                 `json:"challenge"`
+	RP                     RelyingPartyEntity     `json:"rp"`
+	User                   UserEntity             `json:"user"`
+	PubKeyCredParams       []CredentialParameter  `json:"pubKeyCredParams"`
+	Timeout                int64                  `json:"timeout"`
+	ExcludeCredentials     []CredentialDescriptor `json:"excludeCredentials"`
+	AuthenticatorSelection AuthenticatorSelection `json:"authenticatorSelection"`
+	Attestation            string                 `json:"attestation"`
+}
+
+// RequestOptions is the JSON form of PublicKeyCredentialRequestOptions,
+// for PublicKeyCredential.parseRequestOptionsFromJSON.
+type RequestOptions struct {
+	Challenge        string                 `json:"challenge"`
+	Timeout          int64                  `json:"timeout"`
+	RPID             string                 `json:"rpId"`
+	AllowCredentials []CredentialDescriptor `json:"allowCredentials"`
+	UserVerification string                 `json:"userVerification"`
+}
+
+// RelyingPartyEntity names the relying party.
+type RelyingPartyEntity struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// UserEntity describes the user to the authenticator; ID is base64url.
+type UserEntity struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+}
+
+// CredentialParameter is an accepted key type.
+type CredentialParameter struct {
+	Type string `json:"type"`
+	Alg  int    `json:"alg"`
+}
+
+// CredentialDescriptor refers to an existing credential; ID is base64url.
+type CredentialDescriptor struct {
+	Type       string   `json:"type"`
+	ID         string   `json:"id"`
+	Transports []string `json:"transports,omitempty"`
+}
+
+// AuthenticatorSelection states which authenticators may be used.
+type AuthenticatorSelection struct {
+	ResidentKey      string `json:"residentKey"`
+	UserVerification string `json:"userVerification"`
+}
+
+// CreationOptions returns the options to create a passkey for user. The
+// passkey is discoverable, so the user can later sign in without typing
+// a name. exclude lists the user's existing credentials, so an
+// authenticator isn't registered twice.
+func (rp *RelyingParty) CreationOptions(challenge string, user User, exclude []CredentialDescriptor) CreationOptions {
+	params := make([]CredentialParameter, 0, len(SupportedAlgorithms))
+	for _, alg := range SupportedAlgorithms {
+		params = append(params, CredentialParameter{Type: "public-key", Alg: alg})
+	}
+	if exclude == nil {
+		exclude = []CredentialDescriptor{}
+	}
+	return CreationOptions{
+		Challenge:              challenge,
+		RP:                     RelyingPartyEntity{ID: rp.cfg.RPID, Name: rp.cfg.RPName},
+		User:                   UserEntity{ID: encode(user.ID), Name: user.Name, DisplayName: user.DisplayName},
+		PubKeyCredParams:       params,
+		Timeout:                rp.cfg.Timeout.Milliseconds(),
+		ExcludeCredentials:     exclude,
+		AuthenticatorSelection: AuthenticatorSelection{ResidentKey: "required", UserVerification: "preferred"},
+		Attestation:            "none",
+	}
+}
+
+// RequestOptions returns the options to sign in with any passkey of this
+// site; the browser lets the user pick one.
+func (rp *RelyingParty) RequestOptions(challenge string) RequestOptions {
+	return RequestOptions{
+		Challenge:        challenge,
+		Timeout:          rp.cfg.Timeout.Milliseconds(),
+		RPID:             rp.cfg.RPID,
+		AllowCredentials: []CredentialDescriptor{},
+		UserVerification: "preferred",
+	}
+}
+
+// RegistrationResponse is PublicKeyCredential.toJSON() of a created
+// credential. Binary fields are base64url.
+type RegistrationResponse struct {
+	ID                      string                  `json:"id"`
+	RawID                   string                  `json:"rawId"`
+	Type                    string                  `json:"type"`
+	AuthenticatorAttachment string                  `json:"authenticatorAttachment,omitempty"`
+	ClientExtensionResults  map[string]any          `json:"clientExtensionResults,omitempty"`
+	Response                AttestationResponseJSON `json:"response"`
+}
+
+// AttestationResponseJSON is AuthenticatorAttestationResponseJSON. Only
+// ClientDataJSON and AttestationObject are used; the others duplicate
+// what the attestation object contains.
+type AttestationResponseJSON struct {
+	ClientDataJSON     string   `json:"clientDataJSON"`
+	AttestationObject  string   `json:"attestationObject"`
+	AuthenticatorData  string   `json:"authenticatorData,omitempty"`
+	Transports         []string `json:"transports,omitempty"`
+	PublicKey          string   `json:"publicKey,omitempty"`
+	PublicKeyAlgorithm int      `json:"publicKeyAlgorithm,omitempty"`
+}
+
+// LoginResponse is PublicKeyCredential.toJSON() of an assertion.
+type LoginResponse struct {
+	ID                      string                `json:"id"`
+	RawID                   string                `json:"rawId"`
+	Type                    string                `json:"type"`
+	AuthenticatorAttachment string                `json:"authenticatorAttachment,omitempty"`
+	ClientExtensionResults  map[string]any        `json:"clientExtensionResults,omitempty"`
+	Response                AssertionResponseJSON `json:"response"`
+}
+
+// AssertionResponseJSON is AuthenticatorAssertionResponseJSON.
+type AssertionResponseJSON struct {
+	ClientDataJSON    string `json:"clientDataJSON"`
+	AuthenticatorData string `json:"authenticatorData"`
+	Signature         string `json:"signature"`
+	UserHandle        string `json:"userHandle,omitempty"`
+}
+
+// Credential is a verified new passkey. PublicKey is the COSE_Key to keep
+// for VerifyLogin.
+type Credential struct {
+	ID           []byte
+	PublicKey    []byte
+	SignCount    uint32
+	Transports   []string
+	UserVerified bool
+	// BackedUp means the passkey is synced, e.g. by a password manager,
+	// and survives the loss of the device.
+	BackedUp bool
+}
+
+// Assertion is a verified login.
+type Assertion struct {
+	CredentialID []byte
+	UserHandle   []byte
+	SignCount    uint32
+	UserVerified bool
+	BackedUp     bool
+}
+
+// Verification errors. Errors returned by the Verify methods wrap one of
+// them; the wrapped message says what exactly was wrong.
+var (
+	// ErrInvalidResponse means the response is malformed or doesn't belong
+	// to this site or ceremony.
+	ErrInvalidResponse = errors.New("invalid WebAuthn response")
+	// ErrInvalidSignature means the assertion isn't signed by the passkey.
+	ErrInvalidSignature = errors.New("invalid WebAuthn signature")
+	// ErrCloned means the signature counter went backwards, which
+	// suggests the authenticator was cloned.
+	ErrCloned = errors.New("WebAuthn signature counter went backwards")
+)
+
+func invalid(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", ErrInvalidResponse, fmt.Sprintf(format, args...))
+}
+
+// clientData is CollectedClientData.
+type clientData struct {
+	Type        string `json:"type"`
+	Challenge   string `json:"challenge"`
+	Origin      string `json:"origin"`
+	CrossOrigin bool   `json:"crossOrigin"`
+}
+
+// Challenge returns the challenge a response answers, so the caller can
+// find the ceremony it belongs to. It is not verified.
+func Challenge(clientDataJSON string) (string, error) {
+	raw, err := decode(clientDataJSON)
+	if err != nil {
+		return "", invalid("clientDataJSON is not base64url")
+	}
+	var data clientData
+	if err := json.Unmarshal(raw, &data); err != nil || data.Challenge == "" {
+		return "", invalid("clientDataJSON has no challenge")
+	}
+	return data.Challenge, nil
+}
+
+// verifyClientData checks the client data belongs to this ceremony and
+// returns its raw bytes.
+func (rp *RelyingParty) verifyClientData(encoded, ceremony, challenge string) ([]byte, error) {
+	raw, err := decode(encoded)
+	if err != nil {
+		return nil, invalid("clientDataJSON is not base64url")
+	}
+	var data clientData
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return nil, invalid("clientDataJSON is not JSON")
+	}
+	switch {
+	case data.Type != ceremony:
+		return nil, invalid("client data type is %q, want %q", data.Type, ceremony)
+	case subtle.ConstantTimeCompare([]byte(data.Challenge), []byte(challenge)) != 1:
+		return nil, invalid("challenge does not match")
+	case !slices.Contains(rp.cfg.Origins, data.Origin):
+		return nil, invalid("origin %q is not allowed", data.Origin)
+	case data.CrossOrigin:
+		return nil, invalid("cross-origin ceremonies are not allowed")
+	}
+	return raw, nil
+}
+
+// Authenticator data flags.
+const (
+	flagUserPresent  = 0x01
+	flagUserVerified = 0x04
+	flagBackedUp     = 0x10
+	flagAttestedData = 0x40
+)
+
+// authData is the fixed part of authenticator data.
+type authData struct {
+	flags     byte
+	signCount uint32
+	// rest holds attested credential data and extensions
+	rest []byte
+}
+
+func (rp *RelyingParty) parseAuthData(raw []byte) (*authData, error) {
+	if len(raw) < 37 {
+		return nil, invalid("authenticator data is too short")
+	}
+	if subtle.ConstantTimeCompare(raw[:32], rp.rpIDHash[:]) != 1 {
+		return nil, invalid("passkey belongs to another site")
+	}
+	data := &authData{flags: raw[32], signCount: binary.BigEndian.Uint32(raw[33:37]), rest: raw[37:]}
+	if data.flags&flagUserPresent == 0 {
+		return nil, invalid("user presence was not confirmed")
+	}
+	return data, nil
+}
+
+// VerifyRegistration verifies a newly created credential against the
+// challenge of its ceremony.
+func (rp *RelyingParty) VerifyRegistration(resp RegistrationResponse, challenge string) (*Credential, error) {
+	if resp.Type != "public-key" {
+		return nil, invalid("credential type is %q, want public-key", resp.Type)
+	}
+	if _, err := rp.verifyClientData(resp.Response.ClientDataJSON, "webauthn.create", challenge); err != nil {
+		return nil, err
+	}
+
+	rawObject, err := decode(resp.Response.AttestationObject)
+	if err != nil {
+		return nil, invalid("attestationObject is not base64url")
+	}
+	item, _, err := decodeCBOR(rawObject)
+	if err != nil {
+		return nil, invalid("attestationObject: %v", err)
+	}
+	object, ok := item.(map[any]any)
+	if !ok {
+		return nil, invalid("attestationObject is not a map")
+	}
+	rawAuthData, ok := object["authData"].([]byte)
+	if !ok {
+		return nil, invalid("attestationObject has no authData")
+	}
+	data, err := rp.parseAuthData(rawAuthData)
+	if err != nil {
+		return nil, err
+	}
+	if data.flags&flagAttestedData == 0 {
+		return nil, invalid("authenticator data has no credential")
+	}
+
+	// Attested credential data: AAGUID, credential ID length and ID, then
+	// the COSE public key
+	rest := data.rest
+	if len(rest) < 18 {
+		return nil, invalid("attested credential data is too short")
+	}
+	idLen := int(binary.BigEndian.Uint16(rest[16:18]))
+	rest = rest[18:]
+	if idLen == 0 || idLen > 1023 || len(rest) < idLen {
+		return nil, invalid("credential ID has an invalid length")
+	}
+	credentialID := append([]byte(nil), rest[:idLen]...)
+	rest = rest[idLen:]
+	_, afterKey, err := parsePublicKey(rest)
+	if err != nil {
+		return nil, invalid("%v", err)
+	}
+	publicKey := rest[:len(rest)-len(afterKey)]
+
+	if rawID, err := decode(resp.RawID); err != nil || !bytes.Equal(rawID, credentialID) {
+		return nil, invalid("rawId does not match the credential")
+	}
+
+	return &Credential{
+		ID:           credentialID,
+		PublicKey:    append([]byte(nil), publicKey...),
+		SignCount:    data.signCount,
+		Transports:   resp.Response.Transports,
+		UserVerified: data.flags&flagUserVerified != 0,
+		BackedUp:     data.flags&flagBackedUp != 0,
+	}, nil
+}
+
+// CredentialID returns the decoded ID of the credential used to sign in.
+func (r LoginResponse) CredentialID() ([]byte, error) {
+	id, err := decode(r.RawID)
+	if err != nil || len(id) == 0 {
+		return nil, invalid("rawId is not base64url")
+	}
+	return id, nil
+}
+
+// VerifyLogin verifies an assertion against the challenge of its ceremony
+// and the stored public key and signature counter of the credential.
+func (rp *RelyingParty) VerifyLogin(resp LoginResponse, challenge string, storedKey []byte, storedCount uint32) (*Assertion, error) {
+	if resp.Type != "public-key" {
+		return nil, invalid("credential type is %q, want public-key", resp.Type)
+	}
+	credentialID, err := resp.CredentialID()
+	if err != nil {
+		return nil, err
+	}
+	rawClientData, err := rp.verifyClientData(resp.Response.ClientDataJSON, "webauthn.get", challenge)
+	if err != nil {
+		return nil, err
+	}
+	rawAuthData, err := decode(resp.Response.AuthenticatorData)
+	if err != nil {
+		return nil, invalid("authenticatorData is not base64url")
+	}
+	data, err := rp.parseAuthData(rawAuthData)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := decode(resp.Response.Signature)
+	if err != nil {
+		return nil, invalid("signature is not base64url")
+	}
+	userHandle, err := decode(resp.Response.UserHandle)
+	if err != nil {
+		return nil, invalid("userHandle is not base64url")
+	}
+
+	key, _, err := parsePublicKey(storedKey)
+	if err != nil {
+		return nil, fmt.Errorf("stored %w", err)
+	}
+	clientDataHash := sha256.Sum256(rawClientData)
+	signed := append(append([]byte(nil), rawAuthData...), clientDataHash[:]...)
+	if !key.verify(signed, signature) {
+		return nil, ErrInvalidSignature
+	}
+	// Authenticators that don't count always send 0
+	if (data.signCount != 0 || storedCount != 0) && data.signCount <= storedCount {
+		return nil, ErrCloned
+	}
+
+	return &Assertion{
+		CredentialID: credentialID,
+		UserHandle:   userHandle,
+		SignCount:    data.signCount,
+		UserVerified: data.flags&flagUserVerified != 0,
+		BackedUp:     data.flags&flagBackedUp != 0,
+	}, nil
+}
+
+func encode(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// decode accepts base64url with or without padding, as browsers differ.
+func decode(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+}
+
+// EncodeID encodes a credential ID or user handle as base64url.
+func EncodeID(b []byte) string {
+	return encode(b)
+}
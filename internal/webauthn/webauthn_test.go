@@ -0,0 +1,265 @@
+package webauthn
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+// cborPair is a map entry for encodeCBOR; entries keep their order.
+type cborPair struct {
+	key, value any
+}
+
+// encodeCBOR encodes the subset of CBOR that authenticators produce.
+func encodeCBOR(v any) []byte {
+	head := func(major byte, n uint64) []byte {
+		switch {
+		case n < 24:
+			return []byte{major<<5 | byte(n)}
+		case n < 1<<8:
+			return []byte{major<<5 | 24, byte(n)}
+		case n < 1<<16:
+			return binary.BigEndian.AppendUint16([]byte{major<<5 | 25}, uint16(n))
+		default:
+			return binary.BigEndian.AppendUint32([]byte{major<<5 | 26}, uint32(n))
+		}
+	}
+	switch v := v.(type) {
+	case int:
+		if v < 0 {
+			return head(1, uint64(-1-v))
+		}
+		return head(0, uint64(v))
+	case []byte:
+		return append(head(2, uint64(len(v))), v...)
+	case string:
+		return append(head(3, uint64(len(v))), v...)
+	case []cborPair:
+		out := head(5, uint64(len(v)))
+		for _, p := range v {
+			out = append(out, encodeCBOR(p.key)...)
+			out = append(out, encodeCBOR(p.value)...)
+		}
+		return out
+	default:
+		panic("encodeCBOR: unsupported type")
+	}
+}
+
+// authenticator is a software passkey for tests.
+type authenticator struct {
+	credentialID []byte
+	coseKey      []byte
+	sign         func(data []byte) []byte
+	count        uint32
+}
+
+func newES256Authenticator(t *testing.T) *authenticator {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecdhKey, err := key.ECDH()
+	if err != nil {
+		t.Fatal(err)
+	}
+	point := ecdhKey.PublicKey().Bytes()
+	return &authenticator{
+		credentialID: []byte("credential-es256"),
+		coseKey: encodeCBOR([]cborPair{
+			{coseKty, coseKtyEC2}, {coseAlg, AlgES256}, {coseCrvOrN, coseCrvP256},
+			{coseXOrE, point[1:33]}, {coseY, point[33:]},
+		}),
+		sign: func(data []byte) []byte {
+			digest := sha256.Sum256(data)
+			sig, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+			if err != nil {
+				t.Fatal(err)
+			}
+			return sig
+		},
+	}
+}
+
+func newEd25519Authenticator(t *testing.T) *authenticator {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &authenticator{
+		credentialID: []byte("credential-ed25519"),
+		coseKey:      encodeCBOR([]cborPair{{coseKty, coseKtyOKP}, {coseAlg, AlgEdDSA}, {coseCrvOrN, coseCrvEd25519}, {coseXOrE, []byte(pub)}}),
+		sign:         func(data []byte) []byte { return ed25519.Sign(priv, data) },
+	}
+}
+
+func (a *authenticator) authData(rpID string, flags byte, attested bool) []byte {
+	hash := sha256.Sum256([]byte(rpID))
+	data := append(hash[:], flags)
+	data = binary.BigEndian.AppendUint32(data, a.count)
+	if attested {
+		data = append(data, make([]byte, 16)...) // AAGUID
+		data = binary.BigEndian.AppendUint16(data, uint16(len(a.credentialID)))
+		data = append(data, a.credentialID...)
+		data = append(data, a.coseKey...)
+	}
+	return data
+}
+
+func clientDataJSON(typ, challenge, origin string) string {
+	data, _ := json.Marshal(map[string]any{"type": typ, "challenge": challenge, "origin": origin})
+	return encode(data)
+}
+
+// create answers a registration ceremony.
+func (a *authenticator) create(rpID, challenge, origin string) RegistrationResponse {
+	object := encodeCBOR([]cborPair{
+		{"fmt", "none"},
+		{"attStmt", []cborPair{}},
+		{"authData", a.authData(rpID, flagUserPresent|flagUserVerified|flagAttestedData, true)},
+	})
+	return RegistrationResponse{
+		ID:    encode(a.credentialID),
+		RawID: encode(a.credentialID),
+		Type:  "public-key",
+		Response: AttestationResponseJSON{
+			ClientDataJSON:    clientDataJSON("webauthn.create", challenge, origin),
+			AttestationObject: encode(object),
+			Transports:        []string{"internal"},
+		},
+	}
+}
+
+// get answers a login ceremony, counting the signature.
+func (a *authenticator) get(rpID, challenge, origin string) LoginResponse {
+	a.count++
+	authData := a.authData(rpID, flagUserPresent|flagUserVerified, false)
+	clientData := clientDataJSON("webauthn.get", challenge, origin)
+	rawClientData, _ := decode(clientData)
+	clientDataHash := sha256.Sum256(rawClientData)
+	return LoginResponse{
+		ID:    encode(a.credentialID),
+		RawID: encode(a.credentialID),
+		Type:  "public-key",
+		Response: AssertionResponseJSON{
+			ClientDataJSON:    clientData,
+			AuthenticatorData: encode(authData),
+			Signature:         encode(a.sign(append(authData, clientDataHash[:]...))),
+			UserHandle:        encode([]byte("user-1")),
+		},
+	}
+}
+
+func TestRegisterAndLogin(t *testing.T) {
+	rp := NewRelyingParty(Config{RPID: "todo.example.com", RPName: "Todo", Origins: []string{"https://todo.example.com"}})
+	const origin = "https://todo.example.com"
+
+	for name, newAuthenticator := range map[string]func(*testing.T) *authenticator{
+		"ES256": newES256Authenticator,
+		"EdDSA": newEd25519Authenticator,
+	} {
+		t.Run(name, func(t *testing.T) {
+			a := newAuthenticator(t)
+			cred, err := rp.VerifyRegistration(a.create("todo.example.com", "register-challenge", origin), "register-challenge")
+			if err != nil {
+				t.Fatalf("VerifyRegistration returned error: %v", err)
+			}
+			if string(cred.ID) != string(a.credentialID) || !cred.UserVerified || len(cred.Transports) != 1 {
+				t.Errorf("credential = %+v", cred)
+			}
+
+			assertion, err := rp.VerifyLogin(a.get("todo.example.com", "login-challenge", origin), "login-challenge", cred.PublicKey, cred.SignCount)
+			if err != nil {
+				t.Fatalf("VerifyLogin returned error: %v", err)
+			}
+			if assertion.SignCount != 1 || string(assertion.UserHandle) != "user-1" {
+				t.Errorf("assertion = %+v", assertion)
+			}
+
+			// A replayed assertion doesn't advance the counter
+			replay := a.get("todo.example.com", "login-challenge", origin)
+			if _, err := rp.VerifyLogin(replay, "login-challenge", cred.PublicKey, 2); !errors.Is(err, ErrCloned) {
+				t.Errorf("stale counter: err = %v, want ErrCloned", err)
+			}
+
+			forged := a.get("todo.example.com", "login-challenge", origin)
+			forged.Response.Signature = encode([]byte("not a signature"))
+			if _, err := rp.VerifyLogin(forged, "login-challenge", cred.PublicKey, 0); !errors.Is(err, ErrInvalidSignature) {
+				t.Errorf("forged signature: err = %v, want ErrInvalidSignature", err)
+			}
+		})
+	}
+}
+
+func TestVerifyRejectsForeignCeremonies(t *testing.T) {
+	rp := NewRelyingParty(Config{RPID: "todo.example.com", Origins: []string{"https://todo.example.com"}})
+	a := newES256Authenticator(t)
+	cred, err := rp.VerifyRegistration(a.create("todo.example.com", "c", "https://todo.example.com"), "c")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[string]LoginResponse{
+		"other challenge": a.get("todo.example.com", "other", "https://todo.example.com"),
+		"other origin":    a.get("todo.example.com", "c", "https://evil.example.com"),
+		"other site":      a.get("evil.example.com", "c", "https://todo.example.com"),
+	}
+	registration := a.create("todo.example.com", "c", "https://todo.example.com")
+	wrongType := a.get("todo.example.com", "c", "https://todo.example.com")
+	wrongType.Response.ClientDataJSON = registration.Response.ClientDataJSON
+	cases["registration client data"] = wrongType
+
+	for name, resp := range cases {
+		if _, err := rp.VerifyLogin(resp, "c", cred.PublicKey, 0); !errors.Is(err, ErrInvalidResponse) {
+			t.Errorf("%s: err = %v, want ErrInvalidResponse", name, err)
+		}
+	}
+
+	if _, err := rp.VerifyRegistration(a.create("todo.example.com", "other", "https://todo.example.com"), "c"); !errors.Is(err, ErrInvalidResponse) {
+		t.Errorf("registration with another challenge: err = %v, want ErrInvalidResponse", err)
+	}
+}
+
+func TestChallenge(t *testing.T) {
+	got, err := Challenge(clientDataJSON("webauthn.get", "abc", "https://todo.example.com"))
+	if err != nil || got != "abc" {
+		t.Errorf("Challenge = %q, %v, want abc", got, err)
+	}
+	if _, err := Challenge("!!"); !errors.Is(err, ErrInvalidResponse) {
+		t.Errorf("Challenge of garbage: err = %v, want ErrInvalidResponse", err)
+	}
+}
+
+func TestDecodeCBOR(t *testing.T) {
+	got, rest, err := decodeCBOR(append(encodeCBOR([]cborPair{{1, -7}, {"k", []byte{1, 2}}}), 0xff))
+	if err != nil {
+		t.Fatalf("decodeCBOR returned error: %v", err)
+	}
+	m := got.(map[any]any)
+	if m[int64(1)] != int64(-7) || string(m["k"].([]byte)) != "\x01\x02" || len(rest) != 1 {
+		t.Errorf("decodeCBOR = %#v, rest %x", got, rest)
+	}
+
+	for name, data := range map[string][]byte{
+		"truncated":         {0x42, 0x01},
+		"indefinite":        {0x5f},
+		"huge array":        {0x9b, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff},
+		"duplicate key":     {0xa2, 0x01, 0x01, 0x01, 0x02},
+		"deeply nested":     append(make([]byte, 0, 40), []byte("\x81\x81\x81\x81\x81\x81\x81\x81\x81\x81\x81\x81\x81\x81\x81\x81\x81\x81\x01")...),
+		"unsupported key":   {0xa1, 0x40, 0x01},
+		"reserved argument": {0x1c},
+	} {
+		if _, _, err := decodeCBOR(data); err == nil {
+			t.Errorf("%s: decodeCBOR succeeded, want an error", name)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+// Package webhook signs and sends the deliveries of outgoing webhooks.
+// Each delivery is a JSON POST whose body is signed with the webhook's
+// secret, the way GitHub signs its own: receivers compute the HMAC-SHA256
+// of the raw body and compare it to the X-Todo-Signature-256 header.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Tomlord1122/todo-backend/internal/egress"
+	"github.com/Tomlord1122/todo-backend/internal/requestid"
+)
+
+// Headers sent with every delivery
+const (
+	// SignatureHeader is "sha256=" and the hex HMAC-SHA256 of the body
+	SignatureHeader = "X-Todo-Signature-256"
+	// EventHeader is the event type, e.g. todo.created
+	EventHeader = "X-Todo-Event"
+	// DeliveryHeader identifies the delivery; retries send the same one
+	DeliveryHeader = "X-Todo-Delivery"
+)
+
+// userAgent identifies deliveries in receivers' logs.
+const userAgent = "todo-backend-webhooks"
+
+// Sign returns the SignatureHeader value of body for secret.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks a SignatureHeader value against body. An empty secret
+// never verifies.
+func Verify(secret string, body []byte, signature string) bool {
+	if secret == "" || !strings.HasPrefix(signature, "sha256=") {
+		return false
+	}
+	return hmac.Equal([]byte(signature), []byte(Sign(secret, body)))
+}
+
+// Delivery is one attempt to send an event to a webhook.
+type Delivery struct {
+	ID     uint
+	URL    string
+	Secret string
+	Event  string
+	// Body is the JSON sent, signed with Secret
+	Body []byte
+	// RequestID, if set, is sent as X-Request-ID: the ID of the request
+	// that caused the event
+	RequestID string
+}
+
+// Sender POSTs deliveries to their webhooks.
+type Sender struct {
+	client *http.Client
+}
+
+// NewSender creates a sender. A nil client gets an egress client with a
+// 10 second timeout, which can't reach the server's own network or follow
+// redirects.
+func NewSender(client *http.Client) *Sender {
+	if client == nil {
+		client = egress.NewClient(10 * time.Second)
+	}
+	return &Sender{client: client}
+}
+
+// Send POSTs d and returns the response status, or 0 if there was no
+// response. Any status outside 2xx is an error.
+func (s *Sender) Send(ctx context.Context, d Delivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.URL, bytes.NewReader(d.Body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set(EventHeader, d.Event)
+	req.Header.Set(DeliveryHeader, strconv.FormatUint(uint64(d.ID), 10))
+	req.Header.Set(SignatureHeader, Sign(d.Secret, d.Body))
+	if d.RequestID != "" {
+		req.Header.Set(requestid.Header, d.RequestID)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
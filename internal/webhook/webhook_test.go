@@ -0,0 +1,68 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Tomlord1122/todo-backend/internal/egress"
+)
+
+func TestSignAndVerify(t *testing.T) {
+	body := []byte(`{"type":"todo.created"}`)
+	// echo -n '{"type":"todo.created"}' | openssl dgst -sha256 -hmac s3cret
+	const want = "sha256=33711eb9b911f42756eeebd76dbbab12ff98234eede59e878fa84c90fd6e71dc"
+	got := Sign("s3cret", body)
+	if got != want {
+		t.Fatalf("Sign = %q, want %q", got, want)
+	}
+	if !Verify("s3cret", body, got) {
+		t.Error("Verify rejected its own signature")
+	}
+	for name, tc := range map[string]struct{ secret, body, signature string }{
+		"other secret": {"other", string(body), got},
+		"other body":   {"s3cret", `{"type":"todo.deleted"}`, got},
+		"no prefix":    {"s3cret", string(body), got[7:]},
+		"empty secret": {"", string(body), Sign("", body)},
+	} {
+		if Verify(tc.secret, []byte(tc.body), tc.signature) {
+			t.Errorf("%s: Verify = true", name)
+		}
+	}
+}
+
+func TestSend(t *testing.T) {
+	status := http.StatusNoContent
+	var got *http.Request
+	var gotBody []byte
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		gotBody, _ = io.ReadAll(r.Body)
+		w.WriteHeader(status)
+	}))
+	defer ts.Close()
+
+	d := Delivery{ID: 42, URL: ts.URL, Secret: "s3cret", Event: "todo.updated", Body: []byte(`{"id":"e1"}`), RequestID: "req-1"}
+	if code, err := NewSender(ts.Client()).Send(context.Background(), d); err != nil || code != http.StatusNoContent {
+		t.Fatalf("Send = %d, %v", code, err)
+	}
+	if got.Header.Get(EventHeader) != "todo.updated" || got.Header.Get(DeliveryHeader) != "42" ||
+		got.Header.Get("X-Request-ID") != "req-1" || !Verify("s3cret", gotBody, got.Header.Get(SignatureHeader)) {
+		t.Errorf("request headers = %v, body %s", got.Header, gotBody)
+	}
+
+	status = http.StatusServiceUnavailable
+	if code, err := NewSender(ts.Client()).Send(context.Background(), d); err == nil || code != status {
+		t.Errorf("Send to a failing endpoint = %d, %v; want %d and an error", code, err, status)
+	}
+
+	// The default client doesn't reach the server's own network
+	status = http.StatusNoContent
+	got = nil
+	if _, err := NewSender(nil).Send(context.Background(), d); !errors.Is(err, egress.ErrBlockedAddress) || got != nil {
+		t.Errorf("Send to a loopback address = %v, want it blocked", err)
+	}
+}
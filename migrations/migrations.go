@@ -0,0 +1,17 @@
+// Package migrations holds the versioned SQL migrations of the database
+// schema, one directory per database driver: postgres and mysql. Each
+// version is a pair of files, NNNNNN_name.up.sql applying it and
+// NNNNNN_name.down.sql reverting it; new versions take the next number,
+// in every directory, so all drivers go through the same versions.
+// Applied migrations are never edited, changes go in a new one.
+package migrations
+
+import "embed"
+
+// FS contains the migration files, under the directory of their driver.
+//
+//go:embed postgres/*.sql mysql/*.sql
+var FS embed.FS
+
+// Dirs are the directories of FS, named like the database drivers.
+var Dirs = []string{"postgres", "mysql"}
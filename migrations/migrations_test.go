@@ -0,0 +1,67 @@
+package migrations
+
+import (
+	"fmt"
+	"io/fs"
+	"regexp"
+	"slices"
+	"testing"
+)
+
+var fileName = regexp.MustCompile(`^(\d{6}_[a-z0-9_]+)\.(up|down)\.sql$`)
+
+// TestFilesArePaired checks every version has an up and a down file and
+// versions are numbered without gaps, so none is skipped by accident.
+func TestFilesArePaired(t *testing.T) {
+	for _, dir := range Dirs {
+		t.Run(dir, func(t *testing.T) {
+			versions, directions := readVersions(t, dir)
+			if len(versions) == 0 {
+				t.Fatal("no migrations")
+			}
+			for i, version := range versions {
+				if want := fmt.Sprintf("%06d", i+1); version[:6] != want {
+					t.Errorf("migration %d has version %s, want %s", i+1, version[:6], want)
+				}
+				if got := directions[version]; len(got) != 2 {
+					t.Errorf("version %s has files %v, want a down and an up", version, got)
+				}
+			}
+		})
+	}
+}
+
+// TestDriversHaveSameVersions checks every driver's directory has the same
+// migrations, so a change isn't made for one database only.
+func TestDriversHaveSameVersions(t *testing.T) {
+	want, _ := readVersions(t, Dirs[0])
+	for _, dir := range Dirs[1:] {
+		if got, _ := readVersions(t, dir); !slices.Equal(got, want) {
+			t.Errorf("%s has migrations %v, want the same as %s: %v", dir, got, Dirs[0], want)
+		}
+	}
+}
+
+// readVersions returns the versions in dir, with their names, and the
+// directions of each.
+func readVersions(t *testing.T, dir string) ([]string, map[string][]string) {
+	t.Helper()
+	entries, err := fs.ReadDir(FS, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	directions := map[string][]string{}
+	var versions []string
+	for _, entry := range entries {
+		m := fileName.FindStringSubmatch(entry.Name())
+		if m == nil {
+			t.Errorf("%s/%s isn't named NNNNNN_name.up.sql or NNNNNN_name.down.sql", dir, entry.Name())
+			continue
+		}
+		if directions[m[1]] == nil {
+			versions = append(versions, m[1])
+		}
+		directions[m[1]] = append(directions[m[1]], m[2])
+	}
+	return versions, directions
+}
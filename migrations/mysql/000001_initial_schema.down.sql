@@ -0,0 +1,36 @@
+DROP TABLE IF EXISTS
+    jobs,
+    webhook_deliveries,
+    webhooks,
+    outbox_events,
+    idempotency_keys,
+    reminders,
+    subtasks,
+    api_keys,
+    users,
+    todo_watchers,
+    external_identities,
+    auth_sessions,
+    passkeys,
+    leases,
+    import_errors,
+    imports,
+    notion_export_rows,
+    notion_exports,
+    calendar_events,
+    calendar_connections,
+    github_issue_syncs,
+    github_links,
+    inbound_hooks,
+    focus_sessions,
+    activities,
+    reactions,
+    checklist_items,
+    attachments,
+    user_preferences,
+    report_schedules,
+    feed_tokens,
+    lists,
+    todo_tags,
+    tags,
+    todos;
@@ -0,0 +1,607 @@
+-- The schema on MySQL and MariaDB. It matches the Postgres one, except:
+-- strings that are indexed are varchar(255); unique indexes that only
+-- cover rows that aren't deleted include a generated column that is NULL
+-- for deleted rows, since unique indexes allow any number of NULLs; and
+-- full-text search uses FULLTEXT indexes. DDL isn't transactional here, so
+-- everything is created only if missing and a failed run can be repeated.
+
+CREATE TABLE IF NOT EXISTS `todos` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `title` longtext NOT NULL,
+    `description` text,
+    `completed` boolean NOT NULL,
+    `priority` varchar(255) NOT NULL DEFAULT 'normal',
+    `user_id` bigint unsigned,
+    `list_id` bigint unsigned,
+    `due_date` datetime(3) NULL,
+    `start_date` datetime(3) NULL,
+    `completed_at` datetime(3) NULL,
+    `recurrence` longtext,
+    `next_occurrence` datetime(3) NULL,
+    `latitude` double,
+    `longitude` double,
+    `radius_meters` double,
+    `depends_on` longtext,
+    `estimate` double,
+    `checklist_total` bigint NOT NULL DEFAULT 0,
+    `checklist_done` bigint NOT NULL DEFAULT 0,
+    `reaction_counts` longtext,
+    `escalation_level` bigint NOT NULL DEFAULT 0,
+    `overdue_notified_at` datetime(3) NULL,
+    `version` bigint unsigned NOT NULL DEFAULT 1,
+    PRIMARY KEY (`id`),
+    INDEX `idx_todos_deleted_at` (`deleted_at`),
+    INDEX `idx_todos_list_id` (`list_id`),
+    INDEX `idx_todos_due_date` (`due_date`),
+    INDEX `idx_todos_next_occurrence` (`next_occurrence`),
+    INDEX `idx_todos_location` (`latitude`, `longitude`),
+    -- Full-text search over titles and descriptions, and titles alone to
+    -- rank title matches higher
+    FULLTEXT INDEX `idx_todos_search` (`title`, `description`),
+    FULLTEXT INDEX `idx_todos_title_search` (`title`)
+);
+
+CREATE TABLE IF NOT EXISTS `tags` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `user_id` bigint unsigned NOT NULL,
+    `name` varchar(255) NOT NULL,
+    `alive` boolean AS (IF(deleted_at IS NULL, true, NULL)) STORED,
+    PRIMARY KEY (`id`),
+    INDEX `idx_tags_deleted_at` (`deleted_at`),
+    UNIQUE INDEX `idx_tags_user_name` (`user_id`, `name`, `alive`)
+);
+
+CREATE TABLE IF NOT EXISTS `todo_tags` (
+    `todo_id` bigint unsigned,
+    `tag_id` bigint unsigned,
+    PRIMARY KEY (`todo_id`, `tag_id`),
+    CONSTRAINT `fk_todo_tags_todo` FOREIGN KEY (`todo_id`) REFERENCES `todos`(`id`),
+    CONSTRAINT `fk_todo_tags_tag` FOREIGN KEY (`tag_id`) REFERENCES `tags`(`id`)
+);
+
+CREATE TABLE IF NOT EXISTS `lists` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `name` longtext NOT NULL,
+    `user_id` bigint unsigned NOT NULL,
+    PRIMARY KEY (`id`),
+    INDEX `idx_lists_deleted_at` (`deleted_at`),
+    INDEX `idx_lists_user_id` (`user_id`)
+);
+
+CREATE TABLE IF NOT EXISTS `feed_tokens` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `user_id` bigint unsigned NOT NULL,
+    `token` varchar(255) NOT NULL,
+    PRIMARY KEY (`id`),
+    INDEX `idx_feed_tokens_deleted_at` (`deleted_at`),
+    INDEX `idx_feed_tokens_user_id` (`user_id`),
+    UNIQUE INDEX `idx_feed_tokens_token` (`token`)
+);
+
+CREATE TABLE IF NOT EXISTS `report_schedules` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `user_id` bigint unsigned NOT NULL,
+    `channel` longtext NOT NULL,
+    `target` longtext NOT NULL,
+    `format` longtext NOT NULL,
+    `weekday` bigint NOT NULL,
+    `hour` bigint NOT NULL,
+    `timezone` longtext NOT NULL,
+    `next_run_at` datetime(3) NOT NULL,
+    `last_run_at` datetime(3) NULL,
+    `last_error` longtext,
+    PRIMARY KEY (`id`),
+    INDEX `idx_report_schedules_deleted_at` (`deleted_at`),
+    INDEX `idx_report_schedules_user_id` (`user_id`),
+    INDEX `idx_report_schedules_next_run_at` (`next_run_at`)
+);
+
+CREATE TABLE IF NOT EXISTS `user_preferences` (
+    `user_id` bigint unsigned,
+    `auto_apply_suggestions` boolean NOT NULL DEFAULT false,
+    `timezone` longtext,
+    `escalation_mode` varchar(255) NOT NULL DEFAULT '',
+    `notify_overdue` boolean NOT NULL DEFAULT false,
+    `notification_channel` longtext,
+    `notification_target` longtext,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    PRIMARY KEY (`user_id`),
+    INDEX `idx_user_preferences_escalation_mode` (`escalation_mode`),
+    INDEX `idx_user_preferences_notify_overdue` (`notify_overdue`)
+);
+
+CREATE TABLE IF NOT EXISTS `attachments` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `todo_id` bigint unsigned NOT NULL,
+    `filename` longtext NOT NULL,
+    `content_type` longtext NOT NULL,
+    `size` bigint NOT NULL,
+    `object_key` varchar(255) NOT NULL,
+    `status` varchar(255) NOT NULL,
+    `scan_status` varchar(255),
+    `scan_signature` longtext,
+    `thumbnail_status` varchar(255),
+    PRIMARY KEY (`id`),
+    INDEX `idx_attachments_deleted_at` (`deleted_at`),
+    INDEX `idx_attachments_todo_id` (`todo_id`),
+    UNIQUE INDEX `idx_attachments_object_key` (`object_key`),
+    INDEX `idx_attachments_status` (`status`),
+    INDEX `idx_attachments_scan_status` (`scan_status`),
+    INDEX `idx_attachments_thumbnail_status` (`thumbnail_status`)
+);
+
+CREATE TABLE IF NOT EXISTS `checklist_items` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `todo_id` bigint unsigned NOT NULL,
+    `position` bigint NOT NULL,
+    `text` longtext NOT NULL,
+    `done` boolean NOT NULL,
+    PRIMARY KEY (`id`),
+    INDEX `idx_checklist_items_deleted_at` (`deleted_at`),
+    INDEX `idx_checklist_items_todo_position` (`todo_id`, `position`)
+);
+
+CREATE TABLE IF NOT EXISTS `reactions` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `todo_id` bigint unsigned NOT NULL,
+    `user_id` bigint unsigned NOT NULL,
+    `emoji` varchar(255) NOT NULL,
+    `alive` boolean AS (IF(deleted_at IS NULL, true, NULL)) STORED,
+    PRIMARY KEY (`id`),
+    INDEX `idx_reactions_deleted_at` (`deleted_at`),
+    UNIQUE INDEX `idx_reactions_todo_user_emoji` (`todo_id`, `user_id`, `emoji`, `alive`)
+);
+
+CREATE TABLE IF NOT EXISTS `activities` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `todo_id` bigint unsigned NOT NULL,
+    `user_id` bigint unsigned,
+    `kind` varchar(255) NOT NULL,
+    `old_value` longtext,
+    `new_value` longtext,
+    `detail` longtext,
+    `list_id` bigint unsigned,
+    `open_estimate` double NOT NULL DEFAULT 0,
+    PRIMARY KEY (`id`),
+    INDEX `idx_activities_deleted_at` (`deleted_at`),
+    INDEX `idx_activities_todo_id` (`todo_id`),
+    INDEX `idx_activities_kind` (`kind`),
+    INDEX `idx_activities_list_id` (`list_id`)
+);
+
+CREATE TABLE IF NOT EXISTS `focus_sessions` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `user_id` bigint unsigned NOT NULL,
+    `todo_id` bigint unsigned,
+    `started_at` datetime(3) NOT NULL,
+    `planned_minutes` bigint NOT NULL DEFAULT 0,
+    `ended_at` datetime(3) NULL,
+    `running` boolean AS (IF(ended_at IS NULL AND deleted_at IS NULL, true, NULL)) STORED,
+    PRIMARY KEY (`id`),
+    INDEX `idx_focus_sessions_deleted_at` (`deleted_at`),
+    INDEX `idx_focus_sessions_user_id` (`user_id`),
+    UNIQUE INDEX `idx_focus_sessions_running` (`user_id`, `running`),
+    INDEX `idx_focus_sessions_todo_id` (`todo_id`),
+    INDEX `idx_focus_sessions_started_at` (`started_at`)
+);
+
+CREATE TABLE IF NOT EXISTS `inbound_hooks` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `user_id` bigint unsigned NOT NULL,
+    `token` varchar(255) NOT NULL,
+    `list_id` bigint unsigned,
+    `priority` longtext,
+    `title_template` longtext NOT NULL,
+    `description_template` longtext,
+    PRIMARY KEY (`id`),
+    INDEX `idx_inbound_hooks_deleted_at` (`deleted_at`),
+    INDEX `idx_inbound_hooks_user_id` (`user_id`),
+    UNIQUE INDEX `idx_inbound_hooks_token` (`token`)
+);
+
+CREATE TABLE IF NOT EXISTS `github_links` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `list_id` bigint unsigned NOT NULL,
+    `user_id` bigint unsigned NOT NULL,
+    `owner` varchar(255) NOT NULL,
+    `repo` varchar(255) NOT NULL,
+    `login` longtext NOT NULL,
+    `last_synced_at` datetime(3) NULL,
+    `alive` boolean AS (IF(deleted_at IS NULL, true, NULL)) STORED,
+    PRIMARY KEY (`id`),
+    INDEX `idx_github_links_deleted_at` (`deleted_at`),
+    UNIQUE INDEX `idx_github_links_list` (`list_id`, `alive`),
+    INDEX `idx_github_links_user_id` (`user_id`),
+    INDEX `idx_github_links_repo` (`owner`, `repo`)
+);
+
+CREATE TABLE IF NOT EXISTS `github_issue_syncs` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `link_id` bigint unsigned NOT NULL,
+    `issue_number` bigint NOT NULL,
+    `todo_id` bigint unsigned NOT NULL,
+    `closed` boolean NOT NULL,
+    PRIMARY KEY (`id`),
+    INDEX `idx_github_issue_syncs_deleted_at` (`deleted_at`),
+    UNIQUE INDEX `idx_github_issue_syncs_issue` (`link_id`, `issue_number`),
+    INDEX `idx_github_issue_syncs_todo_id` (`todo_id`)
+);
+
+CREATE TABLE IF NOT EXISTS `calendar_connections` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `user_id` bigint unsigned NOT NULL,
+    `refresh_token` longtext NOT NULL,
+    `access_token` longtext,
+    `access_token_expiry` datetime(3) NULL,
+    `calendar_id` longtext NOT NULL,
+    `sync_token` longtext,
+    `last_synced_at` datetime(3) NULL,
+    `alive` boolean AS (IF(deleted_at IS NULL, true, NULL)) STORED,
+    PRIMARY KEY (`id`),
+    INDEX `idx_calendar_connections_deleted_at` (`deleted_at`),
+    UNIQUE INDEX `idx_calendar_connections_user` (`user_id`, `alive`)
+);
+
+CREATE TABLE IF NOT EXISTS `calendar_events` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `connection_id` bigint unsigned NOT NULL,
+    `todo_id` bigint unsigned NOT NULL,
+    `event_id` varchar(255) NOT NULL,
+    `synced_due` datetime(3) NOT NULL,
+    `synced_title` longtext NOT NULL,
+    PRIMARY KEY (`id`),
+    INDEX `idx_calendar_events_deleted_at` (`deleted_at`),
+    INDEX `idx_calendar_events_connection_id` (`connection_id`),
+    INDEX `idx_calendar_events_todo_id` (`todo_id`),
+    INDEX `idx_calendar_events_event_id` (`event_id`)
+);
+
+CREATE TABLE IF NOT EXISTS `notion_exports` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `user_id` bigint unsigned NOT NULL,
+    `database_id` longtext NOT NULL,
+    `mapping` longtext,
+    `status` varchar(255) NOT NULL,
+    `error` longtext,
+    `started_at` datetime(3) NULL,
+    `finished_at` datetime(3) NULL,
+    PRIMARY KEY (`id`),
+    INDEX `idx_notion_exports_deleted_at` (`deleted_at`),
+    INDEX `idx_notion_exports_user_id` (`user_id`),
+    INDEX `idx_notion_exports_status` (`status`)
+);
+
+CREATE TABLE IF NOT EXISTS `notion_export_rows` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `export_id` bigint unsigned NOT NULL,
+    `todo_id` bigint unsigned NOT NULL,
+    `status` longtext NOT NULL,
+    `page_id` longtext,
+    `error` longtext,
+    PRIMARY KEY (`id`),
+    INDEX `idx_notion_export_rows_deleted_at` (`deleted_at`),
+    INDEX `idx_notion_export_rows_export_id` (`export_id`)
+);
+
+CREATE TABLE IF NOT EXISTS `imports` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `user_id` bigint unsigned NOT NULL,
+    `list_id` bigint unsigned,
+    `format` longtext NOT NULL,
+    `data` longblob,
+    `status` varchar(255) NOT NULL,
+    `error` longtext,
+    `total` bigint NOT NULL DEFAULT 0,
+    `processed` bigint NOT NULL DEFAULT 0,
+    `imported` bigint NOT NULL DEFAULT 0,
+    `failed` bigint NOT NULL DEFAULT 0,
+    `started_at` datetime(3) NULL,
+    `finished_at` datetime(3) NULL,
+    PRIMARY KEY (`id`),
+    INDEX `idx_imports_deleted_at` (`deleted_at`),
+    INDEX `idx_imports_user_id` (`user_id`),
+    INDEX `idx_imports_status` (`status`)
+);
+
+CREATE TABLE IF NOT EXISTS `import_errors` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `import_id` bigint unsigned NOT NULL,
+    `line` bigint NOT NULL,
+    `message` longtext,
+    PRIMARY KEY (`id`),
+    INDEX `idx_import_errors_deleted_at` (`deleted_at`),
+    INDEX `idx_import_errors_import_id` (`import_id`)
+);
+
+CREATE TABLE IF NOT EXISTS `leases` (
+    `name` varchar(255),
+    `holder` longtext NOT NULL,
+    `expires_at` datetime(3) NULL,
+    PRIMARY KEY (`name`)
+);
+
+CREATE TABLE IF NOT EXISTS `passkeys` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `user_id` bigint unsigned NOT NULL,
+    `name` longtext NOT NULL,
+    `credential_id` varchar(255) NOT NULL,
+    `public_key` longblob NOT NULL,
+    `sign_count` int unsigned NOT NULL DEFAULT 0,
+    `transports` longtext,
+    `backed_up` boolean,
+    `last_used_at` datetime(3) NULL,
+    `alive` boolean AS (IF(deleted_at IS NULL, true, NULL)) STORED,
+    PRIMARY KEY (`id`),
+    INDEX `idx_passkeys_deleted_at` (`deleted_at`),
+    INDEX `idx_passkeys_user_id` (`user_id`),
+    UNIQUE INDEX `idx_passkeys_credential` (`credential_id`, `alive`)
+);
+
+CREATE TABLE IF NOT EXISTS `auth_sessions` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `user_id` bigint unsigned NOT NULL,
+    `token_hash` varchar(255) NOT NULL,
+    `passkey_id` bigint unsigned,
+    `identity_id` bigint unsigned,
+    `expires_at` datetime(3) NOT NULL,
+    PRIMARY KEY (`id`),
+    INDEX `idx_auth_sessions_deleted_at` (`deleted_at`),
+    INDEX `idx_auth_sessions_user_id` (`user_id`),
+    UNIQUE INDEX `idx_auth_sessions_token_hash` (`token_hash`),
+    INDEX `idx_auth_sessions_passkey_id` (`passkey_id`),
+    INDEX `idx_auth_sessions_identity_id` (`identity_id`),
+    INDEX `idx_auth_sessions_expires_at` (`expires_at`)
+);
+
+CREATE TABLE IF NOT EXISTS `external_identities` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `user_id` bigint unsigned NOT NULL,
+    `provider` varchar(255) NOT NULL,
+    `subject` varchar(255) NOT NULL,
+    `email` longtext,
+    `name` longtext,
+    `last_login_at` datetime(3) NULL,
+    `alive` boolean AS (IF(deleted_at IS NULL, true, NULL)) STORED,
+    PRIMARY KEY (`id`),
+    INDEX `idx_external_identities_deleted_at` (`deleted_at`),
+    INDEX `idx_external_identities_user_id` (`user_id`),
+    UNIQUE INDEX `idx_external_identities_subject` (`provider`, `subject`, `alive`)
+);
+
+CREATE TABLE IF NOT EXISTS `todo_watchers` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `todo_id` bigint unsigned NOT NULL,
+    `user_id` bigint unsigned NOT NULL,
+    `alive` boolean AS (IF(deleted_at IS NULL, true, NULL)) STORED,
+    PRIMARY KEY (`id`),
+    INDEX `idx_todo_watchers_deleted_at` (`deleted_at`),
+    UNIQUE INDEX `idx_todo_watchers_todo_user` (`todo_id`, `user_id`, `alive`),
+    INDEX `idx_todo_watchers_user_id` (`user_id`)
+);
+
+CREATE TABLE IF NOT EXISTS `users` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `email` varchar(255) NOT NULL,
+    `name` longtext,
+    `password_hash` longtext NOT NULL,
+    `role` varchar(255) NOT NULL DEFAULT 'member',
+    `alive` boolean AS (IF(deleted_at IS NULL, true, NULL)) STORED,
+    PRIMARY KEY (`id`),
+    INDEX `idx_users_deleted_at` (`deleted_at`),
+    UNIQUE INDEX `idx_users_email` (`email`, `alive`),
+    INDEX `idx_users_role` (`role`)
+);
+
+CREATE TABLE IF NOT EXISTS `api_keys` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `user_id` bigint unsigned NOT NULL,
+    `name` longtext NOT NULL,
+    `prefix` longtext NOT NULL,
+    `key_hash` varchar(255) NOT NULL,
+    `last_used_at` datetime(3) NULL,
+    PRIMARY KEY (`id`),
+    INDEX `idx_api_keys_deleted_at` (`deleted_at`),
+    INDEX `idx_api_keys_user_id` (`user_id`),
+    UNIQUE INDEX `idx_api_keys_key_hash` (`key_hash`)
+);
+
+CREATE TABLE IF NOT EXISTS `subtasks` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `todo_id` bigint unsigned NOT NULL,
+    `title` longtext NOT NULL,
+    `completed` boolean NOT NULL,
+    PRIMARY KEY (`id`),
+    INDEX `idx_subtasks_deleted_at` (`deleted_at`),
+    INDEX `idx_subtasks_todo_id` (`todo_id`),
+    CONSTRAINT `fk_todos_subtasks` FOREIGN KEY (`todo_id`) REFERENCES `todos`(`id`)
+);
+
+CREATE TABLE IF NOT EXISTS `reminders` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `todo_id` bigint unsigned NOT NULL,
+    `remind_at` datetime(3) NOT NULL,
+    `channel` longtext NOT NULL,
+    `target` longtext,
+    `status` varchar(255) NOT NULL DEFAULT 'pending',
+    `attempts` bigint NOT NULL DEFAULT 0,
+    `last_error` longtext,
+    `delivered_at` datetime(3) NULL,
+    PRIMARY KEY (`id`),
+    INDEX `idx_reminders_deleted_at` (`deleted_at`),
+    INDEX `idx_reminders_todo_id` (`todo_id`),
+    INDEX `idx_reminders_due` (`remind_at`, `status`)
+);
+
+CREATE TABLE IF NOT EXISTS `idempotency_keys` (
+    `user_id` bigint unsigned,
+    `key` varchar(255),
+    `fingerprint` longtext NOT NULL,
+    `status_code` bigint,
+    `header` longtext,
+    `body` longblob,
+    `created_at` datetime(3) NULL,
+    `expires_at` datetime(3) NULL,
+    PRIMARY KEY (`user_id`, `key`),
+    INDEX `idx_idempotency_keys_expires_at` (`expires_at`)
+);
+
+CREATE TABLE IF NOT EXISTS `outbox_events` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `event_id` varchar(255) NOT NULL,
+    `request_id` longtext,
+    `type` longtext NOT NULL,
+    `user_id` bigint unsigned NOT NULL,
+    `todo_id` bigint unsigned NOT NULL,
+    `occurred_at` datetime(3) NOT NULL,
+    `data` longtext,
+    `status` varchar(255) NOT NULL DEFAULT 'pending',
+    `next_attempt_at` datetime(3) NOT NULL,
+    `attempts` bigint NOT NULL DEFAULT 0,
+    `last_error` longtext,
+    `claimed_until` datetime(3) NULL,
+    `published_at` datetime(3) NULL,
+    PRIMARY KEY (`id`),
+    INDEX `idx_outbox_events_deleted_at` (`deleted_at`),
+    UNIQUE INDEX `idx_outbox_events_event_id` (`event_id`),
+    INDEX `idx_outbox_events_due` (`status`, `next_attempt_at`)
+);
+
+CREATE TABLE IF NOT EXISTS `webhooks` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `user_id` bigint unsigned NOT NULL,
+    `url` longtext NOT NULL,
+    `secret` longtext NOT NULL,
+    `events` longtext,
+    `active` boolean NOT NULL DEFAULT true,
+    PRIMARY KEY (`id`),
+    INDEX `idx_webhooks_deleted_at` (`deleted_at`),
+    INDEX `idx_webhooks_user_id` (`user_id`)
+);
+
+CREATE TABLE IF NOT EXISTS `webhook_deliveries` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `webhook_id` bigint unsigned NOT NULL,
+    `event_id` varchar(255) NOT NULL,
+    `event_type` longtext NOT NULL,
+    `payload` longtext NOT NULL,
+    `status` varchar(255) NOT NULL DEFAULT 'pending',
+    `attempts` bigint NOT NULL DEFAULT 0,
+    `response_status` bigint,
+    `last_error` longtext,
+    `delivered_at` datetime(3) NULL,
+    PRIMARY KEY (`id`),
+    INDEX `idx_webhook_deliveries_deleted_at` (`deleted_at`),
+    UNIQUE INDEX `idx_webhook_deliveries_event` (`webhook_id`, `event_id`),
+    INDEX `idx_webhook_deliveries_webhook_id` (`webhook_id`)
+);
+
+CREATE TABLE IF NOT EXISTS `jobs` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `kind` longtext NOT NULL,
+    `key` varchar(255),
+    `payload` longtext NOT NULL,
+    `status` varchar(255) NOT NULL DEFAULT 'pending',
+    `run_at` datetime(3) NOT NULL,
+    `attempts` bigint NOT NULL DEFAULT 0,
+    `last_error` longtext,
+    `locked_until` datetime(3) NULL,
+    `finished_at` datetime(3) NULL,
+    PRIMARY KEY (`id`),
+    INDEX `idx_jobs_deleted_at` (`deleted_at`),
+    UNIQUE INDEX `idx_jobs_key` (`key`),
+    INDEX `idx_jobs_due` (`status`, `run_at`)
+);
@@ -0,0 +1,4 @@
+-- Nothing to do: MySQL has no trigram index, fuzzy title search scores
+-- titles in the application instead. The version exists so every driver
+-- goes through the same ones.
+DO 0;
@@ -0,0 +1,4 @@
+ALTER TABLE `github_links`
+    DROP COLUMN `access_token`,
+    DROP COLUMN `refresh_token`,
+    DROP COLUMN `access_token_expiry`;
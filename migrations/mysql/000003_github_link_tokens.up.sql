@@ -0,0 +1,9 @@
+-- GitHub is called with the token of the user who linked the list rather
+-- than a server-wide one. Links made before can't sync without a token, so
+-- they're unlinked; the todos they imported are kept.
+ALTER TABLE `github_links`
+    ADD COLUMN `access_token` longtext,
+    ADD COLUMN `refresh_token` longtext,
+    ADD COLUMN `access_token_expiry` datetime(3) NULL;
+
+UPDATE `github_links` SET `deleted_at` = NOW(3) WHERE `deleted_at` IS NULL;
@@ -0,0 +1,599 @@
+-- The schema as AutoMigrate left it, so databases it created can be taken
+-- over by running this migration: everything is created only if missing.
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS todos (
+    id bigserial,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    title text NOT NULL,
+    description text,
+    completed boolean NOT NULL,
+    priority text NOT NULL DEFAULT 'normal',
+    user_id bigint,
+    list_id bigint,
+    due_date timestamptz,
+    start_date timestamptz,
+    completed_at timestamptz,
+    recurrence text,
+    next_occurrence timestamptz,
+    latitude decimal,
+    longitude decimal,
+    radius_meters decimal,
+    depends_on text,
+    estimate decimal,
+    checklist_total bigint NOT NULL DEFAULT 0,
+    checklist_done bigint NOT NULL DEFAULT 0,
+    reaction_counts text,
+    escalation_level bigint NOT NULL DEFAULT 0,
+    overdue_notified_at timestamptz,
+    version bigint NOT NULL DEFAULT 1,
+    PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_todos_location ON todos (latitude, longitude);
+CREATE INDEX IF NOT EXISTS idx_todos_next_occurrence ON todos (next_occurrence);
+CREATE INDEX IF NOT EXISTS idx_todos_due_date ON todos (due_date);
+CREATE INDEX IF NOT EXISTS idx_todos_list_id ON todos (list_id);
+CREATE INDEX IF NOT EXISTS idx_todos_deleted_at ON todos (deleted_at);
+
+CREATE TABLE IF NOT EXISTS tags (
+    id bigserial,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    user_id bigint NOT NULL,
+    name text NOT NULL,
+    PRIMARY KEY (id)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tags_user_name ON tags (user_id, name) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_tags_deleted_at ON tags (deleted_at);
+
+CREATE TABLE IF NOT EXISTS todo_tags (
+    todo_id bigint,
+    tag_id bigint,
+    PRIMARY KEY (todo_id, tag_id),
+    CONSTRAINT fk_todo_tags_todo FOREIGN KEY (todo_id) REFERENCES todos(id),
+    CONSTRAINT fk_todo_tags_tag FOREIGN KEY (tag_id) REFERENCES tags(id)
+);
+
+CREATE TABLE IF NOT EXISTS lists (
+    id bigserial,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    name text NOT NULL,
+    user_id bigint NOT NULL,
+    PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_lists_user_id ON lists (user_id);
+CREATE INDEX IF NOT EXISTS idx_lists_deleted_at ON lists (deleted_at);
+
+CREATE TABLE IF NOT EXISTS feed_tokens (
+    id bigserial,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    user_id bigint NOT NULL,
+    token text NOT NULL,
+    PRIMARY KEY (id)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_feed_tokens_token ON feed_tokens (token);
+CREATE INDEX IF NOT EXISTS idx_feed_tokens_user_id ON feed_tokens (user_id);
+CREATE INDEX IF NOT EXISTS idx_feed_tokens_deleted_at ON feed_tokens (deleted_at);
+
+CREATE TABLE IF NOT EXISTS report_schedules (
+    id bigserial,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    user_id bigint NOT NULL,
+    channel text NOT NULL,
+    target text NOT NULL,
+    format text NOT NULL,
+    weekday bigint NOT NULL,
+    hour bigint NOT NULL,
+    timezone text NOT NULL,
+    next_run_at timestamptz NOT NULL,
+    last_run_at timestamptz,
+    last_error text,
+    PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_report_schedules_next_run_at ON report_schedules (next_run_at);
+CREATE INDEX IF NOT EXISTS idx_report_schedules_user_id ON report_schedules (user_id);
+CREATE INDEX IF NOT EXISTS idx_report_schedules_deleted_at ON report_schedules (deleted_at);
+
+CREATE TABLE IF NOT EXISTS user_preferences (
+    user_id bigint,
+    auto_apply_suggestions boolean NOT NULL DEFAULT false,
+    timezone text,
+    escalation_mode text NOT NULL DEFAULT '',
+    notify_overdue boolean NOT NULL DEFAULT false,
+    notification_channel text,
+    notification_target text,
+    created_at timestamptz,
+    updated_at timestamptz,
+    PRIMARY KEY (user_id)
+);
+CREATE INDEX IF NOT EXISTS idx_user_preferences_notify_overdue ON user_preferences (notify_overdue);
+CREATE INDEX IF NOT EXISTS idx_user_preferences_escalation_mode ON user_preferences (escalation_mode);
+
+CREATE TABLE IF NOT EXISTS attachments (
+    id bigserial,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    todo_id bigint NOT NULL,
+    filename text NOT NULL,
+    content_type text NOT NULL,
+    size bigint NOT NULL,
+    object_key text NOT NULL,
+    status text NOT NULL,
+    scan_status text,
+    scan_signature text,
+    thumbnail_status text,
+    PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_attachments_thumbnail_status ON attachments (thumbnail_status);
+CREATE INDEX IF NOT EXISTS idx_attachments_scan_status ON attachments (scan_status);
+CREATE INDEX IF NOT EXISTS idx_attachments_status ON attachments (status);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_attachments_object_key ON attachments (object_key);
+CREATE INDEX IF NOT EXISTS idx_attachments_todo_id ON attachments (todo_id);
+CREATE INDEX IF NOT EXISTS idx_attachments_deleted_at ON attachments (deleted_at);
+
+CREATE TABLE IF NOT EXISTS checklist_items (
+    id bigserial,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    todo_id bigint NOT NULL,
+    position bigint NOT NULL,
+    text text NOT NULL,
+    done boolean NOT NULL,
+    PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_checklist_items_todo_position ON checklist_items (todo_id, position);
+CREATE INDEX IF NOT EXISTS idx_checklist_items_deleted_at ON checklist_items (deleted_at);
+
+CREATE TABLE IF NOT EXISTS reactions (
+    id bigserial,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    todo_id bigint NOT NULL,
+    user_id bigint NOT NULL,
+    emoji text NOT NULL,
+    PRIMARY KEY (id)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_reactions_todo_user_emoji ON reactions (todo_id,user_id,emoji) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_reactions_deleted_at ON reactions (deleted_at);
+
+CREATE TABLE IF NOT EXISTS activities (
+    id bigserial,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    todo_id bigint NOT NULL,
+    user_id bigint,
+    kind text NOT NULL,
+    old_value text,
+    new_value text,
+    detail text,
+    list_id bigint,
+    open_estimate decimal NOT NULL DEFAULT 0,
+    PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_activities_list_id ON activities (list_id);
+CREATE INDEX IF NOT EXISTS idx_activities_kind ON activities (kind);
+CREATE INDEX IF NOT EXISTS idx_activities_todo_id ON activities (todo_id);
+CREATE INDEX IF NOT EXISTS idx_activities_deleted_at ON activities (deleted_at);
+
+CREATE TABLE IF NOT EXISTS focus_sessions (
+    id bigserial,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    user_id bigint NOT NULL,
+    todo_id bigint,
+    started_at timestamptz NOT NULL,
+    planned_minutes bigint NOT NULL DEFAULT 0,
+    ended_at timestamptz,
+    PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_focus_sessions_started_at ON focus_sessions (started_at);
+CREATE INDEX IF NOT EXISTS idx_focus_sessions_todo_id ON focus_sessions (todo_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_focus_sessions_running ON focus_sessions (user_id) WHERE ended_at IS NULL AND deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_focus_sessions_user_id ON focus_sessions (user_id);
+CREATE INDEX IF NOT EXISTS idx_focus_sessions_deleted_at ON focus_sessions (deleted_at);
+
+CREATE TABLE IF NOT EXISTS inbound_hooks (
+    id bigserial,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    user_id bigint NOT NULL,
+    token text NOT NULL,
+    list_id bigint,
+    priority text,
+    title_template text NOT NULL,
+    description_template text,
+    PRIMARY KEY (id)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_inbound_hooks_token ON inbound_hooks (token);
+CREATE INDEX IF NOT EXISTS idx_inbound_hooks_user_id ON inbound_hooks (user_id);
+CREATE INDEX IF NOT EXISTS idx_inbound_hooks_deleted_at ON inbound_hooks (deleted_at);
+
+CREATE TABLE IF NOT EXISTS github_links (
+    id bigserial,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    list_id bigint NOT NULL,
+    user_id bigint NOT NULL,
+    owner text NOT NULL,
+    repo text NOT NULL,
+    login text NOT NULL,
+    last_synced_at timestamptz,
+    PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_github_links_repo ON github_links (owner, repo);
+CREATE INDEX IF NOT EXISTS idx_github_links_user_id ON github_links (user_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_github_links_list ON github_links (list_id) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_github_links_deleted_at ON github_links (deleted_at);
+
+CREATE TABLE IF NOT EXISTS github_issue_syncs (
+    id bigserial,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    link_id bigint NOT NULL,
+    issue_number bigint NOT NULL,
+    todo_id bigint NOT NULL,
+    closed boolean NOT NULL,
+    PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_github_issue_syncs_todo_id ON github_issue_syncs (todo_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_github_issue_syncs_issue ON github_issue_syncs (link_id, issue_number);
+CREATE INDEX IF NOT EXISTS idx_github_issue_syncs_deleted_at ON github_issue_syncs (deleted_at);
+
+CREATE TABLE IF NOT EXISTS calendar_connections (
+    id bigserial,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    user_id bigint NOT NULL,
+    refresh_token text NOT NULL,
+    access_token text,
+    access_token_expiry timestamptz,
+    calendar_id text NOT NULL,
+    sync_token text,
+    last_synced_at timestamptz,
+    PRIMARY KEY (id)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_calendar_connections_user ON calendar_connections (user_id) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_calendar_connections_deleted_at ON calendar_connections (deleted_at);
+
+CREATE TABLE IF NOT EXISTS calendar_events (
+    id bigserial,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    connection_id bigint NOT NULL,
+    todo_id bigint NOT NULL,
+    event_id text NOT NULL,
+    synced_due timestamptz NOT NULL,
+    synced_title text NOT NULL,
+    PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_calendar_events_event_id ON calendar_events (event_id);
+CREATE INDEX IF NOT EXISTS idx_calendar_events_todo_id ON calendar_events (todo_id);
+CREATE INDEX IF NOT EXISTS idx_calendar_events_connection_id ON calendar_events (connection_id);
+CREATE INDEX IF NOT EXISTS idx_calendar_events_deleted_at ON calendar_events (deleted_at);
+
+CREATE TABLE IF NOT EXISTS notion_exports (
+    id bigserial,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    user_id bigint NOT NULL,
+    database_id text NOT NULL,
+    mapping text,
+    status text NOT NULL,
+    error text,
+    started_at timestamptz,
+    finished_at timestamptz,
+    PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_notion_exports_status ON notion_exports (status);
+CREATE INDEX IF NOT EXISTS idx_notion_exports_user_id ON notion_exports (user_id);
+CREATE INDEX IF NOT EXISTS idx_notion_exports_deleted_at ON notion_exports (deleted_at);
+
+CREATE TABLE IF NOT EXISTS notion_export_rows (
+    id bigserial,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    export_id bigint NOT NULL,
+    todo_id bigint NOT NULL,
+    status text NOT NULL,
+    page_id text,
+    error text,
+    PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_notion_export_rows_export_id ON notion_export_rows (export_id);
+CREATE INDEX IF NOT EXISTS idx_notion_export_rows_deleted_at ON notion_export_rows (deleted_at);
+
+CREATE TABLE IF NOT EXISTS imports (
+    id bigserial,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    user_id bigint NOT NULL,
+    list_id bigint,
+    format text NOT NULL,
+    data bytea,
+    status text NOT NULL,
+    error text,
+    total bigint NOT NULL DEFAULT 0,
+    processed bigint NOT NULL DEFAULT 0,
+    imported bigint NOT NULL DEFAULT 0,
+    failed bigint NOT NULL DEFAULT 0,
+    started_at timestamptz,
+    finished_at timestamptz,
+    PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_imports_status ON imports (status);
+CREATE INDEX IF NOT EXISTS idx_imports_user_id ON imports (user_id);
+CREATE INDEX IF NOT EXISTS idx_imports_deleted_at ON imports (deleted_at);
+
+CREATE TABLE IF NOT EXISTS import_errors (
+    id bigserial,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    import_id bigint NOT NULL,
+    line bigint NOT NULL,
+    message text,
+    PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_import_errors_import_id ON import_errors (import_id);
+CREATE INDEX IF NOT EXISTS idx_import_errors_deleted_at ON import_errors (deleted_at);
+
+CREATE TABLE IF NOT EXISTS leases (
+    name text,
+    holder text NOT NULL,
+    expires_at timestamptz,
+    PRIMARY KEY (name)
+);
+
+CREATE TABLE IF NOT EXISTS passkeys (
+    id bigserial,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    user_id bigint NOT NULL,
+    name text NOT NULL,
+    credential_id text NOT NULL,
+    public_key bytea NOT NULL,
+    sign_count bigint NOT NULL DEFAULT 0,
+    transports text,
+    backed_up boolean,
+    last_used_at timestamptz,
+    PRIMARY KEY (id)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_passkeys_credential ON passkeys (credential_id) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_passkeys_user_id ON passkeys (user_id);
+CREATE INDEX IF NOT EXISTS idx_passkeys_deleted_at ON passkeys (deleted_at);
+
+CREATE TABLE IF NOT EXISTS auth_sessions (
+    id bigserial,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    user_id bigint NOT NULL,
+    token_hash text NOT NULL,
+    passkey_id bigint,
+    identity_id bigint,
+    expires_at timestamptz NOT NULL,
+    PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_auth_sessions_expires_at ON auth_sessions (expires_at);
+CREATE INDEX IF NOT EXISTS idx_auth_sessions_identity_id ON auth_sessions (identity_id);
+CREATE INDEX IF NOT EXISTS idx_auth_sessions_passkey_id ON auth_sessions (passkey_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_auth_sessions_token_hash ON auth_sessions (token_hash);
+CREATE INDEX IF NOT EXISTS idx_auth_sessions_user_id ON auth_sessions (user_id);
+CREATE INDEX IF NOT EXISTS idx_auth_sessions_deleted_at ON auth_sessions (deleted_at);
+
+CREATE TABLE IF NOT EXISTS external_identities (
+    id bigserial,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    user_id bigint NOT NULL,
+    provider text NOT NULL,
+    subject text NOT NULL,
+    email text,
+    name text,
+    last_login_at timestamptz,
+    PRIMARY KEY (id)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_external_identities_subject ON external_identities (provider, subject) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_external_identities_user_id ON external_identities (user_id);
+CREATE INDEX IF NOT EXISTS idx_external_identities_deleted_at ON external_identities (deleted_at);
+
+CREATE TABLE IF NOT EXISTS todo_watchers (
+    id bigserial,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    todo_id bigint NOT NULL,
+    user_id bigint NOT NULL,
+    PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_todo_watchers_user_id ON todo_watchers (user_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_todo_watchers_todo_user ON todo_watchers (todo_id, user_id) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_todo_watchers_deleted_at ON todo_watchers (deleted_at);
+
+CREATE TABLE IF NOT EXISTS users (
+    id bigserial,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    email text NOT NULL,
+    name text,
+    password_hash text NOT NULL,
+    role text NOT NULL DEFAULT 'member',
+    PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_users_role ON users (role);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email ON users (email) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_users_deleted_at ON users (deleted_at);
+
+CREATE TABLE IF NOT EXISTS api_keys (
+    id bigserial,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    user_id bigint NOT NULL,
+    name text NOT NULL,
+    prefix text NOT NULL,
+    key_hash text NOT NULL,
+    last_used_at timestamptz,
+    PRIMARY KEY (id)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_api_keys_key_hash ON api_keys (key_hash);
+CREATE INDEX IF NOT EXISTS idx_api_keys_user_id ON api_keys (user_id);
+CREATE INDEX IF NOT EXISTS idx_api_keys_deleted_at ON api_keys (deleted_at);
+
+CREATE TABLE IF NOT EXISTS subtasks (
+    id bigserial,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    todo_id bigint NOT NULL,
+    title text NOT NULL,
+    completed boolean NOT NULL,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_todos_subtasks FOREIGN KEY (todo_id) REFERENCES todos(id)
+);
+CREATE INDEX IF NOT EXISTS idx_subtasks_todo_id ON subtasks (todo_id);
+CREATE INDEX IF NOT EXISTS idx_subtasks_deleted_at ON subtasks (deleted_at);
+
+CREATE TABLE IF NOT EXISTS reminders (
+    id bigserial,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    todo_id bigint NOT NULL,
+    remind_at timestamptz NOT NULL,
+    channel text NOT NULL,
+    target text,
+    status text NOT NULL DEFAULT 'pending',
+    attempts bigint NOT NULL DEFAULT 0,
+    last_error text,
+    delivered_at timestamptz,
+    PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_reminders_due ON reminders (remind_at, status);
+CREATE INDEX IF NOT EXISTS idx_reminders_todo_id ON reminders (todo_id);
+CREATE INDEX IF NOT EXISTS idx_reminders_deleted_at ON reminders (deleted_at);
+
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    user_id bigint,
+    key text,
+    fingerprint text NOT NULL,
+    status_code bigint,
+    header text,
+    body bytea,
+    created_at timestamptz,
+    expires_at timestamptz,
+    PRIMARY KEY (user_id, key)
+);
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_expires_at ON idempotency_keys (expires_at);
+
+CREATE TABLE IF NOT EXISTS outbox_events (
+    id bigserial,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    event_id text NOT NULL,
+    request_id text,
+    type text NOT NULL,
+    user_id bigint NOT NULL,
+    todo_id bigint NOT NULL,
+    occurred_at timestamptz NOT NULL,
+    data text,
+    status text NOT NULL DEFAULT 'pending',
+    next_attempt_at timestamptz NOT NULL,
+    attempts bigint NOT NULL DEFAULT 0,
+    last_error text,
+    claimed_until timestamptz,
+    published_at timestamptz,
+    PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_outbox_events_due ON outbox_events (status, next_attempt_at);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_outbox_events_event_id ON outbox_events (event_id);
+CREATE INDEX IF NOT EXISTS idx_outbox_events_deleted_at ON outbox_events (deleted_at);
+
+CREATE TABLE IF NOT EXISTS webhooks (
+    id bigserial,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    user_id bigint NOT NULL,
+    url text NOT NULL,
+    secret text NOT NULL,
+    events text,
+    active boolean NOT NULL DEFAULT true,
+    PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_webhooks_user_id ON webhooks (user_id);
+CREATE INDEX IF NOT EXISTS idx_webhooks_deleted_at ON webhooks (deleted_at);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id bigserial,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    webhook_id bigint NOT NULL,
+    event_id text NOT NULL,
+    event_type text NOT NULL,
+    payload text NOT NULL,
+    status text NOT NULL DEFAULT 'pending',
+    attempts bigint NOT NULL DEFAULT 0,
+    response_status bigint,
+    last_error text,
+    delivered_at timestamptz,
+    PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_webhook_id ON webhook_deliveries (webhook_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_webhook_deliveries_event ON webhook_deliveries (webhook_id, event_id);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_deleted_at ON webhook_deliveries (deleted_at);
+
+CREATE TABLE IF NOT EXISTS jobs (
+    id bigserial,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    kind text NOT NULL,
+    key text,
+    payload text NOT NULL,
+    status text NOT NULL DEFAULT 'pending',
+    run_at timestamptz NOT NULL,
+    attempts bigint NOT NULL DEFAULT 0,
+    last_error text,
+    locked_until timestamptz,
+    finished_at timestamptz,
+    PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_jobs_due ON jobs (status, run_at);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_jobs_key ON jobs (key);
+CREATE INDEX IF NOT EXISTS idx_jobs_deleted_at ON jobs (deleted_at);
+
+-- Full-text search over titles and descriptions
+ALTER TABLE todos ADD COLUMN IF NOT EXISTS search_vector tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('english', coalesce(title, '')), 'A') ||
+    setweight(to_tsvector('english', coalesce(description, '')), 'B')) STORED;
+CREATE INDEX IF NOT EXISTS idx_todos_search ON todos USING gin (search_vector);
+
+COMMIT;
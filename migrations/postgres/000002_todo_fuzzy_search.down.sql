@@ -0,0 +1,2 @@
+-- pg_trgm stays installed, other database objects may use it
+DROP INDEX IF EXISTS idx_todos_title_trgm;
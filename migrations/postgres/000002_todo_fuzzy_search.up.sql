@@ -0,0 +1,5 @@
+-- Trigram index for fuzzy title search. Creating the extension needs a
+-- privileged role; if the application user can't, run this migration once
+-- as one with `api migrate up`.
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+CREATE INDEX IF NOT EXISTS idx_todos_title_trgm ON todos USING gin (title gin_trgm_ops);
@@ -0,0 +1,13 @@
+-- GitHub is called with the token of the user who linked the list rather
+-- than a server-wide one. Links made before can't sync without a token, so
+-- they're unlinked; the todos they imported are kept.
+BEGIN;
+
+ALTER TABLE github_links
+    ADD COLUMN access_token text,
+    ADD COLUMN refresh_token text,
+    ADD COLUMN access_token_expiry timestamptz;
+
+UPDATE github_links SET deleted_at = now() WHERE deleted_at IS NULL;
+
+COMMIT;